@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The all-in-one command runs the vc-manager, a syncer and a stub vn-agent in one process, so that
+// changes can be tried end-to-end against a local kind cluster without building and deploying images.
+// It is meant for development only, see doc/all-in-one.md.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	cliflag "k8s.io/component-base/cli/flag"
+	"k8s.io/klog/v2"
+
+	synceroptions "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/cmd/syncer/app/options"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis"
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/tenantprobe"
+	logrutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/logr"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/bootstrap"
+)
+
+func main() {
+	var (
+		metricsAddr             string
+		healthAddr              string
+		controlPlaneProvisioner string
+		provisionerTimeout      time.Duration
+		maxConcurrentReconciles int
+		extraSyncingResources   string
+		vnAgentPort             int
+		vnAgentCertDir          string
+
+		featureGates map[string]bool
+	)
+	klog.InitFlags(flag.CommandLine)
+	flag.StringVar(&metricsAddr, "metrics-addr", ":0", "The address the metric endpoint of the vc-manager binds to.")
+	flag.StringVar(&healthAddr, "health-addr", ":8081", "The address of the healthz/readyz endpoint binds to.")
+	flag.StringVar(&controlPlaneProvisioner, "provisioner", "native",
+		"The underlying platform that will provision control plane for virtualcluster.")
+	flag.DurationVar(&provisionerTimeout, "provisioner-timeout", 10*time.Minute,
+		"The timeout for every control-plane statefulset of a virtualcluster to be ready, every virtualcluster has its own deadlines")
+	flag.IntVar(&maxConcurrentReconciles, "num-reconciles", 10,
+		"The max number of virtualclusters provisioned concurrently")
+	flag.StringVar(&extraSyncingResources, "extra-syncing-resources", "",
+		"A comma separated list of the resource syncers disabled by default to run, e.g. priorityclass,ingress")
+	flag.IntVar(&vnAgentPort, "vn-agent-port", 10550, "The port the stub vn-agent listens on")
+	flag.StringVar(&vnAgentCertDir, "vn-agent-cert-dir", os.TempDir(),
+		"The directory the self-signed certificate of the stub vn-agent is written to")
+	flag.Var(cliflag.NewMapStringBool(&featureGates), "feature-gates", "A set of key=value pairs that describe featuregate gates for various features.")
+
+	flag.Parse()
+
+	loggr, err := logrutil.NewLogger("", false)
+	if err != nil {
+		panic(fmt.Sprintf("fail to initialize logr: %s", err))
+	}
+	logf.SetLogger(loggr)
+	log := logf.Log.WithName("all-in-one")
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		log.Error(err, "unable to set up client config")
+		os.Exit(1)
+	}
+
+	// the components run in a single process, so there is no leader to elect.
+	mgr, err := ctrl.NewManager(cfg, manager.Options{
+		MetricsBindAddress:     metricsAddr,
+		HealthProbeBindAddress: healthAddr,
+	})
+	if err != nil {
+		log.Error(err, "unable to set up overall controller manager")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+		log.Error(err, "unable to create ready check")
+		os.Exit(1)
+	}
+	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		log.Error(err, "unable to create health check")
+		os.Exit(1)
+	}
+	if err := apis.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Error(err, "unable add APIs to scheme")
+		os.Exit(1)
+	}
+
+	log.Info("setting up the syncer")
+	syncerOpts, err := synceroptions.NewResourceSyncerOptions()
+	if err != nil {
+		log.Error(err, "unable to set up the syncer options")
+		os.Exit(1)
+	}
+	syncerConfig := syncerOpts.ComponentConfig
+	syncerConfig.FeatureGates = featureGates
+	syncerConfig.VNAgentPort = int32(vnAgentPort)
+	if extraSyncingResources != "" {
+		syncerConfig.ExtraSyncingResources = strings.Split(extraSyncingResources, ",")
+	}
+	// the syncer sets the feature gates of the vc-manager too, so it is set up first.
+	ss, err := bootstrap.New(bootstrap.Options{
+		Config:             &syncerConfig,
+		SuperClusterConfig: cfg,
+	})
+	if err != nil {
+		log.Error(err, "unable to set up the syncer")
+		os.Exit(1)
+	}
+	if err := mgr.Add(ss); err != nil {
+		log.Error(err, "unable to register the syncer to the manager")
+		os.Exit(1)
+	}
+
+	log.Info("setting up the vc-manager")
+	if err := (&controller.Controllers{
+		Log:                     log.WithName("Controllers"),
+		Client:                  mgr.GetClient(),
+		ProvisionerName:         controlPlaneProvisioner,
+		ProvisionerTimeout:      provisionerTimeout,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		DefaultSecurityProfile:  tenancyv1alpha1.SecurityProfilePrivileged,
+		TenantProbePeriod:       tenantprobe.DefaultPeriod,
+		InstallPresets:          true,
+	}).SetupWithManager(mgr); err != nil {
+		log.Error(err, "unable to register controllers to the manager")
+		os.Exit(1)
+	}
+
+	log.Info("setting up the stub vn-agent", "port", vnAgentPort)
+	if err := mgr.Add(newStubVnAgent(vnAgentPort, vnAgentCertDir)); err != nil {
+		log.Error(err, "unable to register the stub vn-agent to the manager")
+		os.Exit(1)
+	}
+
+	log.Info("starting the vc-manager, syncer and stub vn-agent")
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		log.Error(err, "unable to run the manager")
+		os.Exit(1)
+	}
+}
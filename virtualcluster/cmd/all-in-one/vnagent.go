@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/certificate"
+)
+
+// newStubVnAgent returns a vn-agent stub serving the kubelet port of the vNodes. The tenant apiservers
+// reach it, but the kubelet requests, e.g. logs and exec, are not proxied to the super cluster nodes.
+func newStubVnAgent(port int, certDir string) manager.Runnable {
+	return manager.RunnableFunc(func(ctx context.Context) error {
+		tlsConfig, err := certificate.InitializeTLS(certDir, "", "", "vn")
+		if err != nil {
+			return fmt.Errorf("failed to initial tls config: %v", err)
+		}
+
+		mux := http.NewServeMux()
+		healthz.InstallHandler(mux)
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			klog.V(4).Infof("stub vn-agent does not serve %s %s", r.Method, r.URL.Path)
+			http.Error(w, "the kubelet API is not proxied by the all-in-one vn-agent stub", http.StatusNotImplemented)
+		})
+		s := &http.Server{
+			Addr:              fmt.Sprintf(":%d", port),
+			Handler:           mux,
+			ReadHeaderTimeout: time.Minute,
+			TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+		}
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- s.ListenAndServeTLS(tlsConfig.CertFile, tlsConfig.KeyFile)
+		}()
+		select {
+		case <-ctx.Done():
+			return s.Close()
+		case err := <-errCh:
+			return err
+		}
+	})
+}
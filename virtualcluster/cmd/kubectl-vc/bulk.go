@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+)
+
+const defaultBulkConcurrency = 5
+
+// TargetOptions selects the VirtualClusters a bulk command operates on, either
+// by name, by label selector or all of them in the namespace.
+type TargetOptions struct {
+	namespace     string
+	allNamespaces bool
+	selector      string
+	all           bool
+	concurrency   int
+}
+
+// AddFlags adds the targeting flags to cmd.
+func (o *TargetOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "If present, select VirtualClusters across all namespaces")
+	cmd.Flags().StringVarP(&o.selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().BoolVar(&o.all, "all", false, "Select all VirtualClusters in the namespace")
+	cmd.Flags().IntVar(&o.concurrency, "concurrency", defaultBulkConcurrency, "The number of VirtualClusters operated on concurrently")
+}
+
+// Validate checks that exactly one way of selecting VirtualClusters is used.
+func (o *TargetOptions) Validate(cmd *cobra.Command, args []string) error {
+	ways := 0
+	if len(args) > 0 {
+		ways++
+	}
+	if len(o.selector) > 0 {
+		ways++
+	}
+	if o.all {
+		ways++
+	}
+	switch {
+	case ways == 0:
+		return UsageErrorf(cmd, "VC_NAME, --selector,-l or --all must be specified")
+	case ways > 1:
+		return UsageErrorf(cmd, "VC_NAME, --selector,-l and --all are mutually exclusive")
+	}
+	if o.allNamespaces && len(args) > 0 {
+		return UsageErrorf(cmd, "--all-namespaces,-A can not be used with VC_NAME")
+	}
+	if _, err := labels.Parse(o.selector); err != nil {
+		return UsageErrorf(cmd, "invalid selector %q: %v", o.selector, err)
+	}
+	if o.concurrency <= 0 {
+		return UsageErrorf(cmd, "--concurrency should be greater than 0")
+	}
+	return nil
+}
+
+// Targets returns the VirtualClusters selected by args or the selector flags.
+func (o *TargetOptions) Targets(vccli vcclient.Interface, args []string) ([]*tenancyv1alpha1.VirtualCluster, error) {
+	if len(args) > 0 {
+		var vcs []*tenancyv1alpha1.VirtualCluster
+		for _, arg := range args {
+			ns, name := o.namespace, arg
+			if strings.Contains(arg, "/") {
+				namespacedName := strings.SplitN(arg, "/", 2)
+				ns, name = namespacedName[0], namespacedName[1]
+			}
+			vc, err := vccli.TenancyV1alpha1().VirtualClusters(ns).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			vcs = append(vcs, vc)
+		}
+		return vcs, nil
+	}
+
+	ns := o.namespace
+	if o.allNamespaces {
+		ns = metav1.NamespaceAll
+	}
+	vcList, err := vccli.TenancyV1alpha1().VirtualClusters(ns).List(metav1.ListOptions{LabelSelector: o.selector})
+	if err != nil {
+		return nil, err
+	}
+	vcs := make([]*tenancyv1alpha1.VirtualCluster, 0, len(vcList.Items))
+	for i := range vcList.Items {
+		vcs = append(vcs, &vcList.Items[i])
+	}
+	return vcs, nil
+}
+
+// bulkResult is the outcome of a bulk operation on a single VirtualCluster.
+type bulkResult struct {
+	namespace string
+	name      string
+	message   string
+	err       error
+}
+
+// runBulk calls fn for every vc with at most concurrency calls in flight, the
+// results are returned in the same order as vcs.
+func runBulk(vcs []*tenancyv1alpha1.VirtualCluster, concurrency int, fn func(vc *tenancyv1alpha1.VirtualCluster) (string, error)) []bulkResult {
+	results := make([]bulkResult, len(vcs))
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	workqueue := make(chan int)
+	for w := 0; w < concurrency && w < len(vcs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range workqueue {
+				msg, err := fn(vcs[i])
+				results[i] = bulkResult{
+					namespace: vcs[i].GetNamespace(),
+					name:      vcs[i].GetName(),
+					message:   msg,
+					err:       err,
+				}
+			}
+		}()
+	}
+	for i := range vcs {
+		workqueue <- i
+	}
+	close(workqueue)
+	wg.Wait()
+	return results
+}
+
+// printSummary writes a table of the per-VirtualCluster outcomes to w and
+// returns an error if any of the operations failed.
+func printSummary(w io.Writer, results []bulkResult) error {
+	sorted := make([]bulkResult, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].namespace != sorted[j].namespace {
+			return sorted[i].namespace < sorted[j].namespace
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	failed := 0
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tNAME\tRESULT\tMESSAGE")
+	for _, r := range sorted {
+		result, msg := "Succeeded", r.message
+		if r.err != nil {
+			failed++
+			result, msg = "Failed", r.err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.namespace, r.name, result, msg)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d VirtualCluster(s) failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+)
+
+func newBulkTestVC(namespace, name string, labels map[string]string) *tenancyv1alpha1.VirtualCluster {
+	return &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+	}
+}
+
+func newBulkTestClient() *vcfake.Clientset {
+	return vcfake.NewSimpleClientset([]runtime.Object{
+		newBulkTestVC("foo", "a", map[string]string{"env": "test"}),
+		newBulkTestVC("foo", "b", map[string]string{"env": "prod"}),
+		newBulkTestVC("bar", "c", map[string]string{"env": "test"}),
+	}...)
+}
+
+func vcKeys(vcs []*tenancyv1alpha1.VirtualCluster) []string {
+	keys := []string{}
+	for _, vc := range vcs {
+		keys = append(keys, vc.Namespace+"/"+vc.Name)
+	}
+	return keys
+}
+
+func TestTargets(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		options  TargetOptions
+		args     []string
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "by name in the namespace",
+			options:  TargetOptions{namespace: "foo"},
+			args:     []string{"b", "a"},
+			expected: []string{"foo/b", "foo/a"},
+		},
+		{
+			name:     "by namespaced name",
+			options:  TargetOptions{namespace: "foo"},
+			args:     []string{"a", "bar/c"},
+			expected: []string{"foo/a", "bar/c"},
+		},
+		{
+			name:    "by unknown name",
+			options: TargetOptions{namespace: "foo"},
+			args:    []string{"c"},
+			err:     true,
+		},
+		{
+			name:     "by selector in the namespace",
+			options:  TargetOptions{namespace: "foo", selector: "env=test"},
+			expected: []string{"foo/a"},
+		},
+		{
+			name:     "by selector in all namespaces",
+			options:  TargetOptions{namespace: "foo", selector: "env=test", allNamespaces: true},
+			expected: []string{"bar/c", "foo/a"},
+		},
+		{
+			name:     "all in the namespace",
+			options:  TargetOptions{namespace: "foo", all: true},
+			expected: []string{"foo/a", "foo/b"},
+		},
+		{
+			name:     "all in all namespaces",
+			options:  TargetOptions{all: true, allNamespaces: true},
+			expected: []string{"bar/c", "foo/a", "foo/b"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vcs, err := tc.options.Targets(newBulkTestClient(), tc.args)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", vcKeys(vcs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if keys := vcKeys(vcs); !reflect.DeepEqual(keys, tc.expected) {
+				t.Errorf("expected targets %v, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
+
+func TestRunBulk(t *testing.T) {
+	var vcs []*tenancyv1alpha1.VirtualCluster
+	for i := 0; i < 20; i++ {
+		vcs = append(vcs, newBulkTestVC("foo", fmt.Sprintf("vc-%02d", i), nil))
+	}
+
+	for _, concurrency := range []int{1, 3, 50} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			var (
+				mu              sync.Mutex
+				inFlight, maxIn int
+			)
+			results := runBulk(vcs, concurrency, func(vc *tenancyv1alpha1.VirtualCluster) (string, error) {
+				mu.Lock()
+				inFlight++
+				if inFlight > maxIn {
+					maxIn = inFlight
+				}
+				mu.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				if vc.Name == "vc-07" {
+					return "", fmt.Errorf("failed %s", vc.Name)
+				}
+				return "done " + vc.Name, nil
+			})
+
+			expectedMax := concurrency
+			if expectedMax > len(vcs) {
+				expectedMax = len(vcs)
+			}
+			if maxIn > expectedMax {
+				t.Errorf("expected at most %d calls in flight, got %d", expectedMax, maxIn)
+			}
+			if len(results) != len(vcs) {
+				t.Fatalf("expected %d results, got %d", len(vcs), len(results))
+			}
+			for i, r := range results {
+				if r.namespace != "foo" || r.name != vcs[i].Name {
+					t.Errorf("expected result %d to be foo/%s, got %s/%s", i, vcs[i].Name, r.namespace, r.name)
+				}
+				if vcs[i].Name == "vc-07" {
+					if r.err == nil {
+						t.Errorf("expected the error of %s", r.name)
+					}
+				} else if r.err != nil || r.message != "done "+vcs[i].Name {
+					t.Errorf("expected the message of %s, got %q, %v", vcs[i].Name, r.message, r.err)
+				}
+			}
+		})
+	}
+}
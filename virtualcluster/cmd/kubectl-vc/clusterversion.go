@@ -0,0 +1,558 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/presets"
+)
+
+const (
+	clusterVersionExample = `
+	# List the clusterversions and the number of virtualclusters using them
+	kubectl vc cv list
+
+	# Show the control plane manifests of virtualcluster foo/bar rendered from clusterversion cv-1-22
+	kubectl vc cv render cv-1-22 --vc foo/bar
+
+	# Show the changes upgrading virtualcluster foo/bar to clusterversion cv-1-22 would make
+	kubectl vc cv diff -n foo bar --cluster-version cv-1-22
+
+	# List the built-in presets and export one as clusterversion cv-dev to customize it
+	kubectl vc cv presets
+	kubectl vc cv export small-dev --name cv-dev > cv-dev.yaml
+
+	# Generate clusterversion cv-live from the kubeadm control plane of the current cluster
+	kubectl vc cv generate --name cv-live > cv-live.yaml`
+
+	// diffFieldManager is the field manager of the server-side dry-run applies of the diff.
+	diffFieldManager = "virtualcluster/provisioner/native"
+)
+
+func NewCmdClusterVersion(f Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "clusterversion",
+		Aliases: []string{"cv"},
+		Short:   "Inspect ClusterVersions and the control planes rendered from them",
+		Example: clusterVersionExample,
+		RunE:    runHelp,
+	}
+
+	cmd.AddCommand(newCmdClusterVersionList(f))
+	cmd.AddCommand(newCmdClusterVersionRender(f))
+	cmd.AddCommand(newCmdClusterVersionDiff(f))
+	cmd.AddCommand(newCmdClusterVersionPresets())
+	cmd.AddCommand(newCmdClusterVersionExport())
+	cmd.AddCommand(newCmdClusterVersionGenerate(f))
+
+	return cmd
+}
+
+type ClusterVersionListOptions struct {
+	vcclient vcclient.Interface
+}
+
+func newCmdClusterVersionList(f Factory) *cobra.Command {
+	o := &ClusterVersionListOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the ClusterVersions, their component images and the number of VirtualClusters using them",
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	return cmd
+}
+
+func (o *ClusterVersionListOptions) Complete(f Factory) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	return err
+}
+
+func (o *ClusterVersionListOptions) Run(w io.Writer) error {
+	cvs, err := o.vcclient.TenancyV1alpha1().ClusterVersions().List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	vcs, err := o.vcclient.TenancyV1alpha1().VirtualClusters(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]int)
+	for _, vc := range vcs.Items {
+		used[vc.Spec.ClusterVersionName]++
+	}
+
+	items := cvs.Items
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tETCD\tAPISERVER\tCONTROLLER-MANAGER\tVIRTUALCLUSTERS\tAGE")
+	for i := range items {
+		cv := &items[i]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", cv.Name,
+			componentImage(cv.Spec.ETCD), componentImage(cv.Spec.APIServer), componentImage(cv.Spec.ControllerManager),
+			used[cv.Name], duration.HumanDuration(time.Since(cv.CreationTimestamp.Time)))
+	}
+	return tw.Flush()
+}
+
+// componentImage returns the image of the first container of a control plane component.
+func componentImage(ssBdl *tenancyv1alpha1.StatefulSetSvcBundle) string {
+	if ssBdl == nil || ssBdl.StatefulSet == nil || len(ssBdl.StatefulSet.Spec.Template.Spec.Containers) == 0 {
+		return "<none>"
+	}
+	return ssBdl.StatefulSet.Spec.Template.Spec.Containers[0].Image
+}
+
+func newCmdClusterVersionPresets() *cobra.Command {
+	return &cobra.Command{
+		Use:   "presets",
+		Short: "List the built-in ClusterVersion presets",
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(listPresets(os.Stdout))
+		},
+	}
+}
+
+func listPresets(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tETCD\tAPISERVER\tCONTROLLER-MANAGER\tDESCRIPTION")
+	for _, name := range presets.Names() {
+		cv, err := presets.Get(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name,
+			componentImage(cv.Spec.ETCD), componentImage(cv.Spec.APIServer), componentImage(cv.Spec.ControllerManager),
+			cv.Annotations[presets.AnnotationDescription])
+	}
+	return tw.Flush()
+}
+
+type ClusterVersionExportOptions struct {
+	preset string
+	name   string
+}
+
+func newCmdClusterVersionExport() *cobra.Command {
+	o := &ClusterVersionExportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "export PRESET",
+		Short: "Print the ClusterVersion of a built-in preset, to customize it before applying it",
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVar(&o.name, "name", "", "If present, the name of the exported ClusterVersion, defaults to the preset name")
+
+	return cmd
+}
+
+func (o *ClusterVersionExportOptions) Complete(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "PRESET should not be empty, the presets are %s", strings.Join(presets.Names(), ", "))
+	}
+	o.preset = args[0]
+	return nil
+}
+
+func (o *ClusterVersionExportOptions) Run(w io.Writer) error {
+	manifest, err := presets.Export(o.preset, o.name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(manifest)
+	return err
+}
+
+type ClusterVersionGenerateOptions struct {
+	client    kubernetes.Interface
+	name      string
+	base      string
+	namespace string
+	selector  string
+}
+
+func newCmdClusterVersionGenerate(f Factory) *cobra.Command {
+	o := &ClusterVersionGenerateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "generate --name CV_NAME",
+		Short: "Print a ClusterVersion approximating the kubeadm control plane of the current cluster",
+		Long: `Print a ClusterVersion approximating the kubeadm control plane of the current cluster.
+
+The ClusterVersion is a built-in preset whose etcd, apiserver and controller-manager use the images
+and the flags of the control plane static pods. The flags bound to the control plane nodes, e.g.
+the addresses and the certificate files, are not portable: they are kept as in the preset and
+listed as comments at the top of the output, to be reviewed before applying the ClusterVersion.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVar(&o.name, "name", "", "The name of the generated ClusterVersion")
+	cmd.Flags().StringVar(&o.base, "base", "small-dev", "The preset the generated ClusterVersion is based on")
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceSystem, "The namespace of the control plane pods")
+	cmd.Flags().StringVarP(&o.selector, "selector", "l", "tier=control-plane", "The label selector of the control plane pods")
+
+	return cmd
+}
+
+func (o *ClusterVersionGenerateOptions) Complete(f Factory, cmd *cobra.Command) error {
+	if o.name == "" {
+		return UsageErrorf(cmd, "--name should not be empty")
+	}
+	if !presets.Has(o.base) {
+		return UsageErrorf(cmd, "--base should be one of %s", strings.Join(presets.Names(), ", "))
+	}
+	var err error
+	o.client, err = f.KubernetesClientSet()
+	return err
+}
+
+func (o *ClusterVersionGenerateOptions) Run(w io.Writer) error {
+	base, err := presets.Get(o.base)
+	if err != nil {
+		return err
+	}
+	pods, err := o.client.CoreV1().Pods(o.namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: o.selector})
+	if err != nil {
+		return err
+	}
+	version, err := o.client.Discovery().ServerVersion()
+	if err != nil {
+		return err
+	}
+
+	cv, skipped, err := presets.Generate(base, o.name, pods.Items)
+	if err != nil {
+		return fmt.Errorf("failed to generate clusterversion from the pods %s in %s: %v", o.selector, o.namespace, err)
+	}
+	cv.APIVersion = tenancyv1alpha1.SchemeGroupVersion.String()
+	cv.Kind = "ClusterVersion"
+	if cv.Annotations == nil {
+		cv.Annotations = map[string]string{}
+	}
+	cv.Annotations[presets.AnnotationDescription] = fmt.Sprintf("Generated from a %s control plane, based on the %s preset", version.GitVersion, o.base)
+
+	content, err := yaml.Marshal(cv)
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintln(w, "# Review the settings not taken from the control plane before applying:")
+		for _, s := range skipped {
+			fmt.Fprintf(w, "# - %s\n", s)
+		}
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// renderFlags are the flags shared by the render and diff subcommands.
+type renderFlags struct {
+	vcclient               vcclient.Interface
+	client                 kubernetes.Interface
+	namespace              string
+	defaultSecurityProfile string
+}
+
+func (o *renderFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace of the VirtualCluster")
+	cmd.Flags().StringVar(&o.defaultSecurityProfile, "default-security-profile", string(tenancyv1alpha1.SecurityProfilePrivileged),
+		"The default security profile of the vc-manager, applies to the ClusterVersions not setting one")
+}
+
+func (o *renderFlags) Complete(f Factory) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	o.client, err = f.KubernetesClientSet()
+	return err
+}
+
+// splitName returns the namespace and name of a VirtualCluster given as NAME or NAMESPACE/NAME.
+func (o *renderFlags) splitName(name string) (string, string) {
+	if strings.Contains(name, "/") {
+		namespacedName := strings.SplitN(name, "/", 2)
+		return namespacedName[0], namespacedName[1]
+	}
+	return o.namespace, name
+}
+
+// render returns the control plane manifests of the VirtualCluster rendered from the ClusterVersion.
+func (o *renderFlags) render(vc *tenancyv1alpha1.VirtualCluster, cvName string) ([]client.Object, error) {
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(cvName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := provisioner.RenderOptions{DefaultSecurityProfile: tenancyv1alpha1.SecurityProfile(o.defaultSecurityProfile)}
+	if cv.Spec.Images != nil {
+		nodes, err := o.client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		opts.Nodes = nodes.Items
+	}
+	return provisioner.RenderControlPlane(vc, cv, opts)
+}
+
+type ClusterVersionRenderOptions struct {
+	renderFlags
+	name   string
+	vcName string
+}
+
+func newCmdClusterVersionRender(f Factory) *cobra.Command {
+	o := &ClusterVersionRenderOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "render CV_NAME --vc VC_NAME",
+		Short: "Show the control plane manifests the vc-manager deploys for a VirtualCluster from a ClusterVersion",
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	o.renderFlags.AddFlags(cmd)
+	cmd.Flags().StringVar(&o.vcName, "vc", "", "The VirtualCluster the manifests are rendered for, as NAME or NAMESPACE/NAME")
+
+	return cmd
+}
+
+func (o *ClusterVersionRenderOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "CV_NAME should not be empty")
+	}
+	if o.vcName == "" {
+		return UsageErrorf(cmd, "--vc should not be empty")
+	}
+	o.name = args[0]
+	return o.renderFlags.Complete(f)
+}
+
+func (o *ClusterVersionRenderOptions) Run(w io.Writer) error {
+	namespace, name := o.splitName(o.vcName)
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	objs, err := o.render(vc, o.name)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		content, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "---\n%s", content)
+	}
+	return nil
+}
+
+type ClusterVersionDiffOptions struct {
+	renderFlags
+	genericClient      client.Client
+	name               string
+	clusterVersionName string
+}
+
+func newCmdClusterVersionDiff(f Factory) *cobra.Command {
+	o := &ClusterVersionDiffOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "diff VC_NAME",
+		Short: "Diff the control plane of a running VirtualCluster against the one rendered from a ClusterVersion",
+		Long: `Diff the control plane of a running VirtualCluster against the one rendered from a ClusterVersion.
+
+The rendered manifests are applied to the super cluster with a server-side dry run, so that the
+defaulted objects are compared. The certificate hash annotations are ignored since the PKI is
+generated on every apply. KUBECTL_EXTERNAL_DIFF is used as diff program if set.
+
+Exit status: 0 no differences were found, 1 differences were found, >1 kubectl-vc or diff failed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	o.renderFlags.AddFlags(cmd)
+	cmd.Flags().StringVar(&o.clusterVersionName, "cluster-version", "", "If present, the ClusterVersion to diff against, defaults to the one of the VirtualCluster")
+
+	return cmd
+}
+
+func (o *ClusterVersionDiffOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	o.name = args[0]
+
+	var err error
+	o.genericClient, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+	return o.renderFlags.Complete(f)
+}
+
+func (o *ClusterVersionDiffOptions) Run() error {
+	namespace, name := o.splitName(o.name)
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return fmt.Errorf("virtualcluster is %q, only running virtualclusters can be diffed", vc.Status.Phase)
+	}
+
+	cvName := o.clusterVersionName
+	if cvName == "" {
+		cvName = vc.Spec.ClusterVersionName
+	}
+	objs, err := o.render(vc, cvName)
+	if err != nil {
+		return err
+	}
+
+	dir, err := ioutil.TempDir("", "kubectl-vc-diff")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	liveDir, mergedDir := filepath.Join(dir, "LIVE"), filepath.Join(dir, "MERGED")
+	for _, d := range []string{liveDir, mergedDir} {
+		if err := os.Mkdir(d, 0700); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.TODO()
+	for _, obj := range objs {
+		fileName := fmt.Sprintf("%s.%s.%s.yaml", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName())
+
+		live := obj.DeepCopyObject().(client.Object)
+		err := o.genericClient.Get(ctx, client.ObjectKeyFromObject(obj), live)
+		switch {
+		case err == nil:
+			if err := writeDiffObject(filepath.Join(liveDir, fileName), live); err != nil {
+				return err
+			}
+		case !apierrors.IsNotFound(err):
+			return err
+		}
+
+		if err := o.genericClient.Patch(ctx, obj, client.Apply, client.DryRunAll, client.FieldOwner(diffFieldManager), client.ForceOwnership); err != nil {
+			return fmt.Errorf("failed to dry-run apply %s %s/%s: %v", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+		if err := writeDiffObject(filepath.Join(mergedDir, fileName), obj); err != nil {
+			return err
+		}
+	}
+
+	return runDiff(liveDir, mergedDir)
+}
+
+// writeDiffObject writes obj as yaml to path without the fields that change on every apply.
+func writeDiffObject(path string, obj runtime.Object) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetManagedFields(nil)
+	u.SetResourceVersion("")
+	u.SetGeneration(0)
+	u.SetUID("")
+	u.SetCreationTimestamp(metav1.Time{})
+	unstructured.RemoveNestedField(u.Object, "status")
+
+	annotations, _, _ := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "annotations")
+	for k := range annotations {
+		if strings.HasSuffix(k, "-hash") {
+			unstructured.RemoveNestedField(u.Object, "spec", "template", "metadata", "annotations", k)
+		}
+	}
+
+	data, err := yaml.Marshal(u.Object)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// runDiff diffs the two directories the way kubectl diff does, it exits with status 1 if
+// differences were found.
+func runDiff(from, to string) error {
+	args := []string{"-u", "-N"}
+	program := "diff"
+	if external := os.Getenv("KUBECTL_EXTERNAL_DIFF"); external != "" {
+		fields := strings.Fields(external)
+		program, args = fields[0], fields[1:]
+	}
+
+	cmd := exec.Command(program, append(args, from, to)...) // #nosec G204 we are trusting an operator input
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		os.Exit(1)
+	}
+	return err
+}
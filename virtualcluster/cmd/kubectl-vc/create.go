@@ -17,10 +17,16 @@ limitations under the License.
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -28,15 +34,18 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/scheme"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/presets"
 	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
 	netutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/net"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
@@ -49,19 +58,41 @@ const (
 	pollStsTimeoutSec = 120
 )
 
+const (
+	createExample = `
+	# Create a virtualcluster and write its kubeconfig to vc.kubeconfig
+	kubectl vc create -f vc.yaml -o vc.kubeconfig
+
+	# Create the virtualclusters labeled with env=test defined in vcs.yaml,
+	# kubeconfigs are placed in directory kubeconfigs/
+	kubectl vc create -f vcs.yaml -l env=test -o kubeconfigs/
+
+	# Create all virtualclusters defined in vcs.yaml
+	kubectl vc create -f vcs.yaml --all -o kubeconfigs/
+
+	# Create a virtualcluster from the built-in small-dev preset, the clusterversion
+	# of the preset is installed if it does not exist
+	kubectl vc create -f vc.yaml --preset small-dev -o vc.kubeconfig`
+)
+
 type CreateOptions struct {
-	client     client.Client
-	vcclient   vcclient.Interface
-	fileName   string
-	outputPath string
+	client      client.Client
+	vcclient    vcclient.Interface
+	fileName    string
+	outputPath  string
+	selector    string
+	all         bool
+	concurrency int
+	preset      string
 }
 
 func NewCmdCreate(f Factory) *cobra.Command {
 	o := &CreateOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "create",
-		Short: "Create a new VirtualCluster",
+		Use:     "create",
+		Short:   "Create new VirtualClusters",
+		Example: createExample,
 		Run: func(cmd *cobra.Command, args []string) {
 			CheckErr(o.Complete(f))
 			CheckErr(o.Validate(cmd))
@@ -70,7 +101,11 @@ func NewCmdCreate(f Factory) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&o.fileName, "filename", "f", "", "the configuration to apply. in json, yaml or url")
-	cmd.Flags().StringVarP(&o.outputPath, "output", "o", "", "path to the kubeconfig that is used to access virtual cluster")
+	cmd.Flags().StringVarP(&o.outputPath, "output", "o", "", "path to the kubeconfig that is used to access virtual cluster, or the directory to place the kubeconfigs when creating multiple virtual clusters")
+	cmd.Flags().StringVarP(&o.selector, "selector", "l", "", "Selector (label query) to filter the virtual clusters defined in the configuration on")
+	cmd.Flags().BoolVar(&o.all, "all", false, "Create all virtual clusters defined in the configuration")
+	cmd.Flags().IntVar(&o.concurrency, "concurrency", defaultBulkConcurrency, "The number of virtual clusters created concurrently")
+	cmd.Flags().StringVar(&o.preset, "preset", "", fmt.Sprintf("If present, the built-in ClusterVersion preset the virtual clusters use, one of %s", strings.Join(presets.Names(), ", ")))
 
 	return cmd
 }
@@ -97,6 +132,18 @@ func (o *CreateOptions) Validate(cmd *cobra.Command) error {
 	if len(o.outputPath) == 0 {
 		return UsageErrorf(cmd, "--output,-o should not be empty")
 	}
+	if len(o.selector) > 0 && o.all {
+		return UsageErrorf(cmd, "--selector,-l and --all are mutually exclusive")
+	}
+	if _, err := labels.Parse(o.selector); err != nil {
+		return UsageErrorf(cmd, "invalid selector %q: %v", o.selector, err)
+	}
+	if o.concurrency <= 0 {
+		return UsageErrorf(cmd, "--concurrency should be greater than 0")
+	}
+	if len(o.preset) > 0 && !presets.Has(o.preset) {
+		return UsageErrorf(cmd, "--preset should be one of %s", strings.Join(presets.Names(), ", "))
+	}
 	return nil
 }
 
@@ -106,24 +153,107 @@ func (o *CreateOptions) Run() error {
 		return errors.Wrapf(err, "read \"%s\"", o.fileName)
 	}
 
-	vc := &tenancyv1alpha1.VirtualCluster{}
-	codecs := serializer.NewCodecFactory(scheme.Scheme)
-	if err = runtime.DecodeInto(codecs.UniversalDecoder(), fileBytes, vc); err != nil {
+	vcs, err := decodeVirtualClusters(fileBytes, o.selector)
+	if err != nil {
 		return err
 	}
 
-	kubecfgBytes, err := createVirtualCluster(o.client, o.vcclient, vc)
-	if err != nil {
-		return err
+	if len(o.preset) > 0 && len(vcs) > 0 {
+		if err := installPreset(o.vcclient, o.preset); err != nil {
+			return err
+		}
+		for _, vc := range vcs {
+			vc.Spec.ClusterVersionName = o.preset
+		}
+	}
+
+	switch {
+	case len(vcs) == 0:
+		return fmt.Errorf("no VirtualCluster found in \"%s\"", o.fileName)
+	case len(vcs) == 1 && len(o.selector) == 0 && !o.all:
+		kubecfgBytes, err := createVirtualCluster(o.client, o.vcclient, vcs[0])
+		if err != nil {
+			return err
+		}
+
+		// write tenant kubeconfig to outputPath.
+		if err := ioutil.WriteFile(o.outputPath, kubecfgBytes, 0600); err != nil {
+			return err
+		}
+
+		log.Printf("VirtualCluster %s/%s setup successfully\n", vcs[0].Namespace, vcs[0].Name)
+		return nil
+	case len(o.selector) == 0 && !o.all:
+		return fmt.Errorf("\"%s\" defines %d VirtualClusters, use --selector,-l or --all to create them", o.fileName, len(vcs))
 	}
 
-	// write tenant kubeconfig to outputPath.
-	if err := ioutil.WriteFile(o.outputPath, kubecfgBytes, 0600); err != nil {
+	// outputPath is a directory when creating with --selector,-l or --all.
+	if err := os.MkdirAll(o.outputPath, 0755); err != nil {
 		return err
 	}
+	results := runBulk(vcs, o.concurrency, func(vc *tenancyv1alpha1.VirtualCluster) (string, error) {
+		kubecfgBytes, err := createVirtualCluster(o.client, o.vcclient, vc)
+		if err != nil {
+			return "", err
+		}
+		kbFilePath := filepath.Join(o.outputPath, fmt.Sprintf("%s-%s.kubeconfig", vc.Namespace, vc.Name))
+		if err := ioutil.WriteFile(kbFilePath, kubecfgBytes, 0600); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("kubeconfig is placed at %s", kbFilePath), nil
+	})
+	return printSummary(os.Stdout, results)
+}
 
-	log.Printf("VirtualCluster %s/%s setup successfully\n", vc.Namespace, vc.Name)
+// decodeVirtualClusters decodes the VirtualClusters of a multi-document yaml or json
+// configuration, only the ones matching selector are returned if it is not empty.
+func decodeVirtualClusters(data []byte, selector string) ([]*tenancyv1alpha1.VirtualCluster, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
 
+	var vcs []*tenancyv1alpha1.VirtualCluster
+	codecs := serializer.NewCodecFactory(scheme.Scheme)
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		vc := &tenancyv1alpha1.VirtualCluster{}
+		if err = runtime.DecodeInto(codecs.UniversalDecoder(), doc, vc); err != nil {
+			return nil, err
+		}
+		if !sel.Matches(labels.Set(vc.GetLabels())) {
+			continue
+		}
+		vcs = append(vcs, vc)
+	}
+	return vcs, nil
+}
+
+// installPreset creates the ClusterVersion of the named preset if it does not exist, an existing
+// one is used as is since it may have been customized.
+func installPreset(vccli vcclient.Interface, name string) error {
+	_, err := vccli.TenancyV1alpha1().ClusterVersions().Get(name, metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	cv, err := presets.Get(name)
+	if err != nil {
+		return err
+	}
+	if _, err := vccli.TenancyV1alpha1().ClusterVersions().Create(cv); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "install preset %s", name)
+	}
+	log.Printf("ClusterVersion preset %s installed\n", name)
 	return nil
 }
 
@@ -145,7 +275,7 @@ func createVirtualCluster(cli client.Client, vccli vcclient.Interface, vc *tenan
 		return nil, errors.Wrapf(err, "create virtual cluster")
 	}
 
-	ns := conversion.ToClusterKey(vc)
+	ns := conversion.ToRootNamespace(vc)
 
 	if err := retryIfNotFound(5, 2, func() error {
 		return kubeutil.WaitStatefulSetReady(cli, ns, "etcd", pollStsTimeoutSec, pollStsPeriodSec)
@@ -223,7 +353,7 @@ func getVcKubeConfig(cli client.Client, clusterNamespace, srtName string) ([]byt
 
 // genKubeConfig generates the kubeconfig file for accessing the virtual cluster
 func genKubeConfig(cli client.Client, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion) ([]byte, error) {
-	clusterNamespace := conversion.ToClusterKey(vc)
+	clusterNamespace := conversion.ToRootNamespace(vc)
 	kbCfgBytes, err := getVcKubeConfig(cli, clusterNamespace, "admin-kubeconfig")
 	if err != nil {
 		return nil, err
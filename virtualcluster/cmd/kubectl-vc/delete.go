@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+)
+
+const (
+	deleteExample = `
+	# Delete a virtualcluster
+	kubectl vc delete -n foo bar
+
+	# Delete all virtualclusters labeled with env=test, after confirming the list of virtualclusters
+	kubectl vc delete -n foo -l env=test
+
+	# Delete all virtualclusters in namespace foo without confirmation
+	kubectl vc delete -n foo --all --yes`
+)
+
+type DeleteOptions struct {
+	TargetOptions
+	yes      bool
+	vcclient vcclient.Interface
+	args     []string
+	in       io.Reader
+	out      io.Writer
+}
+
+func NewCmdDelete(f Factory) *cobra.Command {
+	o := &DeleteOptions{in: os.Stdin, out: os.Stdout}
+
+	cmd := &cobra.Command{
+		Use:     "delete [VC_NAME...]",
+		Short:   "Delete VirtualClusters by name, label selector or all of them",
+		Example: deleteExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, args))
+			CheckErr(o.Validate(cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	o.TargetOptions.AddFlags(cmd)
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "Delete the VirtualClusters selected by --selector,-l or --all without confirmation")
+
+	return cmd
+}
+
+func (o *DeleteOptions) Complete(f Factory, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	o.args = args
+	return nil
+}
+
+func (o *DeleteOptions) Run() error {
+	vcs, err := o.Targets(o.vcclient, o.args)
+	if err != nil {
+		return err
+	}
+	if len(o.args) == 0 && !o.yes {
+		confirmed, err := o.confirm(vcs)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("deletion aborted")
+		}
+	}
+
+	results := runBulk(vcs, o.concurrency, func(vc *tenancyv1alpha1.VirtualCluster) (string, error) {
+		if err := o.vcclient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Delete(vc.Name, &metav1.DeleteOptions{}); err != nil {
+			return "", err
+		}
+		return "deleted", nil
+	})
+	return printSummary(o.out, results)
+}
+
+// confirm lists the VirtualClusters selected by a label selector or --all and
+// asks for the deletion to be confirmed, only "y" or "yes" confirm it.
+func (o *DeleteOptions) confirm(vcs []*tenancyv1alpha1.VirtualCluster) (bool, error) {
+	if len(vcs) == 0 {
+		return true, nil
+	}
+	for _, vc := range vcs {
+		fmt.Fprintf(o.out, "%s/%s\n", vc.Namespace, vc.Name)
+	}
+	fmt.Fprintf(o.out, "Delete the %d VirtualCluster(s) above? [y/N]: ", len(vcs))
+	answer, err := bufio.NewReader(o.in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
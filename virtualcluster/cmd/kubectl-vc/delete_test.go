@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeleteConfirmation(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		options   TargetOptions
+		yes       bool
+		args      []string
+		input     string
+		err       bool
+		prompted  bool
+		remaining []string
+	}{
+		{
+			name:      "all without confirmation",
+			options:   TargetOptions{namespace: "foo", all: true},
+			input:     "n\n",
+			err:       true,
+			prompted:  true,
+			remaining: []string{"bar/c", "foo/a", "foo/b"},
+		},
+		{
+			name:      "all without an answer",
+			options:   TargetOptions{namespace: "foo", all: true},
+			err:       true,
+			prompted:  true,
+			remaining: []string{"bar/c", "foo/a", "foo/b"},
+		},
+		{
+			name:      "all confirmed",
+			options:   TargetOptions{namespace: "foo", all: true},
+			input:     "yes\n",
+			prompted:  true,
+			remaining: []string{"bar/c"},
+		},
+		{
+			name:      "selector in all namespaces without confirmation",
+			options:   TargetOptions{selector: "env=test", allNamespaces: true},
+			input:     "\n",
+			err:       true,
+			prompted:  true,
+			remaining: []string{"bar/c", "foo/a", "foo/b"},
+		},
+		{
+			name:      "selector in all namespaces with --yes",
+			options:   TargetOptions{selector: "env=test", allNamespaces: true},
+			yes:       true,
+			remaining: []string{"foo/b"},
+		},
+		{
+			name:      "by name",
+			options:   TargetOptions{namespace: "foo"},
+			args:      []string{"a"},
+			remaining: []string{"bar/c", "foo/b"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vcclient := newBulkTestClient()
+			out := &bytes.Buffer{}
+			tc.options.concurrency = defaultBulkConcurrency
+			o := &DeleteOptions{
+				TargetOptions: tc.options,
+				yes:           tc.yes,
+				vcclient:      vcclient,
+				args:          tc.args,
+				in:            strings.NewReader(tc.input),
+				out:           out,
+			}
+
+			err := o.Run()
+			if tc.err != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.err, err)
+			}
+			if prompted := strings.Contains(out.String(), "[y/N]"); prompted != tc.prompted {
+				t.Errorf("expected prompt %v, got output %q", tc.prompted, out.String())
+			}
+
+			vcList, err := vcclient.TenancyV1alpha1().VirtualClusters(metav1.NamespaceAll).List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var remaining []string
+			for _, vc := range vcList.Items {
+				remaining = append(remaining, vc.Namespace+"/"+vc.Name)
+			}
+			if !reflect.DeepEqual(remaining, tc.remaining) {
+				t.Errorf("expected remaining VirtualClusters %v, got %v", tc.remaining, remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki/inspect"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	diagnoseExample = `
+	# Collect a support bundle of a virtualcluster into ./foo-bar-diagnose-<time>.tar.gz
+	kubectl vc diagnose -n foo bar
+
+	# Collect the last 2 hours of logs into a specific file
+	kubectl vc diagnose foo/bar --since 2h -o /tmp/bar.tar.gz`
+
+	// schedulerAnnotationPrefix is the prefix of the placement annotations set by the scheduler.
+	schedulerAnnotationPrefix = "scheduler.virtualcluster.io/"
+	// patrolMetricsPrefix is the prefix of the patrol metrics exposed by the syncer.
+	patrolMetricsPrefix = "syncer_checker_"
+)
+
+type DiagnoseOptions struct {
+	vcclient        vcclient.Interface
+	client          kubernetes.Interface
+	namespace       string
+	name            string
+	output          string
+	since           time.Duration
+	tailLines       int64
+	syncerNamespace string
+	syncerSelector  string
+	syncerPort      string
+}
+
+func NewCmdDiagnose(f Factory) *cobra.Command {
+	o := &DiagnoseOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "diagnose VC_NAME",
+		Short:   "Collect a support bundle of a VirtualCluster",
+		Example: diagnoseExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "The path of the bundle, defaults to <namespace>-<name>-diagnose-<time>.tar.gz")
+	cmd.Flags().DurationVar(&o.since, "since", time.Hour, "Only collect the logs newer than this duration")
+	cmd.Flags().Int64Var(&o.tailLines, "tail", 5000, "The maximum number of log lines collected per container")
+	cmd.Flags().StringVar(&o.syncerNamespace, "syncer-namespace", "vc-manager", "The namespace of the syncer pods")
+	cmd.Flags().StringVar(&o.syncerSelector, "syncer-selector", "app=vc-syncer", "The label selector of the syncer pods")
+	cmd.Flags().StringVar(&o.syncerPort, "syncer-metrics-port", "80", "The port the syncer serves its metrics on")
+
+	return cmd
+}
+
+func (o *DiagnoseOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+
+	o.name = args[0]
+	if strings.Contains(o.name, "/") {
+		namespacedName := strings.SplitN(o.name, "/", 2)
+		o.namespace = namespacedName[0]
+		o.name = namespacedName[1]
+	}
+
+	if o.output == "" {
+		o.output = fmt.Sprintf("%s-%s-diagnose-%s.tar.gz", o.namespace, o.name, time.Now().Format("20060102150405"))
+	}
+	return nil
+}
+
+func (o *DiagnoseOptions) Run() error {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Clean(o.output), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	b := newBundle(file, fmt.Sprintf("%s-%s", o.namespace, o.name))
+	o.collect(context.TODO(), b, vc)
+	if err := b.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("support bundle of virtualcluster %s/%s is written to %s\n", o.namespace, o.name, o.output)
+	if len(b.errs) > 0 {
+		fmt.Printf("%d items could not be collected, see errors.txt in the bundle\n", len(b.errs))
+	}
+	return nil
+}
+
+// collect adds every part of the bundle, a part that can't be collected is recorded in errors.txt.
+func (o *DiagnoseOptions) collect(ctx context.Context, b *bundle, vc *tenancyv1alpha1.VirtualCluster) {
+	clusterKey := conversion.ToClusterKey(vc)
+
+	b.addObject("virtualcluster.yaml", vc.DeepCopy())
+
+	if vc.Spec.ClusterVersionName != "" {
+		cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+		if err != nil {
+			b.fail("clusterversion.yaml", err)
+		} else {
+			b.addObject("clusterversion.yaml", cv)
+		}
+	}
+
+	rootNS := conversion.ToRootNamespace(vc)
+	o.collectControlPlane(ctx, b, rootNS)
+	o.collectSecrets(ctx, b, rootNS)
+	o.collectPlacements(ctx, b, vc, clusterKey)
+	o.collectSyncer(ctx, b, clusterKey)
+}
+
+// collectControlPlane adds the control plane pods and their logs.
+func (o *DiagnoseOptions) collectControlPlane(ctx context.Context, b *bundle, rootNS string) {
+	pods, err := o.client.CoreV1().Pods(rootNS).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.fail("controlplane/pods.yaml", err)
+		return
+	}
+	b.addObject("controlplane/pods.yaml", pods)
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		for _, c := range pod.Spec.Containers {
+			name := path.Join("controlplane/logs", pod.Name, c.Name+".log")
+			logs, err := o.logs(ctx, pod, c.Name)
+			if err != nil {
+				b.fail(name, err)
+				continue
+			}
+			b.add(name, logs)
+		}
+	}
+}
+
+// collectSecrets adds the metadata of the PKI secrets and their certificates verified against the
+// root CA, the secret data is never collected.
+func (o *DiagnoseOptions) collectSecrets(ctx context.Context, b *bundle, rootNS string) {
+	secrets, err := o.client.CoreV1().Secrets(rootNS).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.fail("controlplane/secrets.yaml", err)
+		return
+	}
+	b.addYAML("controlplane/secrets.yaml", secretsMetadata(secrets.Items, time.Now(), b.fail))
+}
+
+// collectPlacements adds the control plane placement and the placement annotations the scheduler
+// set on the VirtualCluster and its super cluster namespaces.
+func (o *DiagnoseOptions) collectPlacements(ctx context.Context, b *bundle, vc *tenancyv1alpha1.VirtualCluster, clusterKey string) {
+	placements := map[string]interface{}{
+		"controlPlane":   vc.Spec.Placement,
+		"virtualCluster": schedulerAnnotations(vc.Annotations),
+	}
+
+	namespaces, err := o.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.fail("placements.yaml", err)
+	} else {
+		byNamespace := make(map[string]map[string]string)
+		for _, ns := range namespaces.Items {
+			if ns.Annotations[constants.LabelCluster] != clusterKey {
+				continue
+			}
+			byNamespace[ns.Name] = schedulerAnnotations(ns.Annotations)
+		}
+		placements["namespaces"] = byNamespace
+	}
+	b.addYAML("placements.yaml", placements)
+}
+
+// collectSyncer adds the syncer log lines mentioning the VirtualCluster and the patrol metrics.
+func (o *DiagnoseOptions) collectSyncer(ctx context.Context, b *bundle, clusterKey string) {
+	pods, err := o.client.CoreV1().Pods(o.syncerNamespace).List(ctx, metav1.ListOptions{LabelSelector: o.syncerSelector})
+	if err != nil {
+		b.fail("syncer", err)
+		return
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		for _, c := range pod.Spec.Containers {
+			name := path.Join("syncer/logs", pod.Name, c.Name+".log")
+			logs, err := o.logs(ctx, pod, c.Name)
+			if err != nil {
+				b.fail(name, err)
+				continue
+			}
+			b.add(name, filterLines(logs, func(line string) bool { return strings.Contains(line, clusterKey) }))
+		}
+
+		name := path.Join("syncer/metrics", pod.Name+".txt")
+		metrics, err := o.client.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, o.syncerPort, "metrics", nil).DoRaw(ctx)
+		if err != nil {
+			b.fail(name, err)
+			continue
+		}
+		b.add(name, filterLines(metrics, func(line string) bool {
+			return strings.HasPrefix(strings.TrimPrefix(strings.TrimPrefix(line, "# HELP "), "# TYPE "), patrolMetricsPrefix)
+		}))
+	}
+}
+
+func (o *DiagnoseOptions) logs(ctx context.Context, pod *corev1.Pod, container string) ([]byte, error) {
+	sinceSeconds := int64(o.since.Seconds())
+	opts := &corev1.PodLogOptions{Container: container, Timestamps: true}
+	if sinceSeconds > 0 {
+		opts.SinceSeconds = &sinceSeconds
+	}
+	if o.tailLines > 0 {
+		opts.TailLines = &o.tailLines
+	}
+	return o.client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+}
+
+// secretMetadata describes a secret without its data.
+type secretMetadata struct {
+	Name         string                `json:"name"`
+	Type         corev1.SecretType     `json:"type"`
+	Created      metav1.Time           `json:"created"`
+	Keys         map[string]int        `json:"keys"`
+	Certificates []inspect.Certificate `json:"certificates,omitempty"`
+}
+
+// secretsMetadata describes the secrets and the certificates they hold, the certificates are
+// inspected at now and left out if they can't be, e.g. if the root CA secret is missing.
+func secretsMetadata(secrets []corev1.Secret, now time.Time, fail func(string, error)) []secretMetadata {
+	certs, err := inspect.Inspect(secrets, now)
+	if err != nil {
+		fail("controlplane/secrets.yaml", fmt.Errorf("fail to inspect the certificates: %v", err))
+	}
+	metas := make([]secretMetadata, 0, len(secrets))
+	for i := range secrets {
+		metas = append(metas, newSecretMetadata(&secrets[i], certs))
+	}
+	return metas
+}
+
+func newSecretMetadata(secret *corev1.Secret, certs []inspect.Certificate) secretMetadata {
+	m := secretMetadata{
+		Name:    secret.Name,
+		Type:    secret.Type,
+		Created: secret.CreationTimestamp,
+		Keys:    make(map[string]int, len(secret.Data)),
+	}
+	for key, data := range secret.Data {
+		m.Keys[key] = len(data)
+	}
+	for _, c := range certs {
+		if c.Secret == secret.Name {
+			m.Certificates = append(m.Certificates, c)
+		}
+	}
+	return m
+}
+
+func schedulerAnnotations(annotations map[string]string) map[string]string {
+	ret := make(map[string]string)
+	for k, v := range annotations {
+		if strings.HasPrefix(k, schedulerAnnotationPrefix) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+func filterLines(data []byte, keep func(string) bool) []byte {
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); keep(line) {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// bundle writes the collected files into a gzipped tarball under a root directory.
+type bundle struct {
+	root string
+	now  time.Time
+	gz   *gzip.Writer
+	tw   *tar.Writer
+	errs []string
+}
+
+func newBundle(file *os.File, root string) *bundle {
+	gz := gzip.NewWriter(file)
+	return &bundle{root: root, now: time.Now(), gz: gz, tw: tar.NewWriter(gz)}
+}
+
+func (b *bundle) add(name string, data []byte) {
+	hdr := &tar.Header{
+		Name:    path.Join(b.root, name),
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: b.now,
+	}
+	if err := b.tw.WriteHeader(hdr); err != nil {
+		b.fail(name, err)
+		return
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		b.fail(name, err)
+	}
+}
+
+func (b *bundle) addYAML(name string, obj interface{}) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		b.fail(name, err)
+		return
+	}
+	b.add(name, data)
+}
+
+// addObject adds a Kubernetes object without its managed fields.
+func (b *bundle) addObject(name string, obj runtime.Object) {
+	if list, ok := obj.(*corev1.PodList); ok {
+		for i := range list.Items {
+			list.Items[i].ManagedFields = nil
+		}
+	} else if accessor, ok := obj.(metav1.Object); ok {
+		accessor.SetManagedFields(nil)
+	}
+	b.addYAML(name, obj)
+}
+
+func (b *bundle) fail(name string, err error) {
+	b.errs = append(b.errs, fmt.Sprintf("%s: %v", name, err))
+}
+
+// Close writes errors.txt and flushes the tarball.
+func (b *bundle) Close() error {
+	if len(b.errs) > 0 {
+		errs := b.errs
+		b.add("errors.txt", []byte(strings.Join(errs, "\n")+"\n"))
+		b.errs = errs
+	}
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return b.gz.Close()
+}
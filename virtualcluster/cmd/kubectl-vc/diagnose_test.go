@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/cert"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+func TestSecretsMetadata(t *testing.T) {
+	crt, key, err := pkiutil.NewCertificateAuthority(&pkiutil.CertConfig{Config: cert.Config{CommonName: "kubernetes"}})
+	if err != nil {
+		t.Fatalf("fail to create the CA: %v", err)
+	}
+	rootCA := &vcpki.CrtKeyPair{Crt: crt, Key: key.(*rsa.PrivateKey)}
+	vc := &tenancyv1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "default"}}
+	apiserver, err := vcpki.NewAPIServerCrtAndKey(rootCA, vc, []string{"apiserver-svc"}, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("fail to create the apiserver certificate: %v", err)
+	}
+	secrets := []corev1.Secret{
+		*secret.CrtKeyPairToSecret(secret.RootCASecretName, "ns", rootCA),
+		*secret.CrtKeyPairToSecret(secret.APIServerCASecretName, "ns", apiserver),
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "ns"},
+			Data:       map[string][]byte{"token": []byte("secret")},
+		},
+	}
+
+	var errs []string
+	fail := func(name string, err error) {
+		errs = append(errs, name+": "+err.Error())
+	}
+	metas := secretsMetadata(secrets, time.Now(), fail)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(metas) != 3 {
+		t.Fatalf("expected the metadata of 3 secrets, got %+v", metas)
+	}
+	for _, m := range metas {
+		switch m.Name {
+		case secret.RootCASecretName, secret.APIServerCASecretName:
+			if len(m.Certificates) != 1 || !m.Certificates[0].Valid() || m.Certificates[0].Key != corev1.TLSCertKey {
+				t.Errorf("expected the valid certificate of secret %s, got %+v", m.Name, m.Certificates)
+			}
+			if m.Keys[corev1.TLSPrivateKeyKey] == 0 {
+				t.Errorf("expected the size of the private key of secret %s, got %v", m.Name, m.Keys)
+			}
+		case "token":
+			if len(m.Certificates) != 0 || m.Keys["token"] != len("secret") {
+				t.Errorf("unexpected metadata of secret token %+v", m)
+			}
+		default:
+			t.Errorf("unexpected secret %s", m.Name)
+		}
+	}
+	if api := metas[1].Certificates[0]; len(api.IPAddresses) != 1 || api.IPAddresses[0].String() != "10.0.0.1" {
+		t.Errorf("expected the IP address of the apiserver certificate, got %+v", api)
+	}
+
+	data, err := yaml.Marshal(metas)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "BEGIN") {
+		t.Errorf("expected the secret data to be left out, got %s", data)
+	}
+
+	// without the root CA the certificates can't be verified, the secrets are still described
+	errs = nil
+	metas = secretsMetadata(secrets[1:], time.Now(), fail)
+	if len(errs) != 1 || !strings.Contains(errs[0], secret.RootCASecretName) {
+		t.Errorf("expected the root CA secret to be reported missing, got %v", errs)
+	}
+	if len(metas) != 2 || len(metas[0].Certificates) != 0 || metas[0].Keys[corev1.TLSCertKey] == 0 {
+		t.Errorf("expected the metadata of the secrets without certificates, got %+v", metas)
+	}
+}
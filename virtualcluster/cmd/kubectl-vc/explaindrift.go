@@ -0,0 +1,243 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	explainDriftExample = `
+	# Explain why the patroller reports the namespace default of virtualcluster foo/bar
+	kubectl vc explain-drift foo/bar namespace/default
+
+	# Explain the drift of a pod in tenant namespace web
+	kubectl vc explain-drift -n foo bar pod/frontend-0 --tenant-namespace web`
+)
+
+// driftKind describes a kind the drift can be explained for.
+type driftKind struct {
+	newObject  func() client.Object
+	namespaced bool
+}
+
+var driftKinds = map[string]driftKind{
+	"pod":                   {newObject: func() client.Object { return &corev1.Pod{} }, namespaced: true},
+	"configmap":             {newObject: func() client.Object { return &corev1.ConfigMap{} }, namespaced: true},
+	"secret":                {newObject: func() client.Object { return &corev1.Secret{} }, namespaced: true},
+	"service":               {newObject: func() client.Object { return &corev1.Service{} }, namespaced: true},
+	"endpoints":             {newObject: func() client.Object { return &corev1.Endpoints{} }, namespaced: true},
+	"persistentvolumeclaim": {newObject: func() client.Object { return &corev1.PersistentVolumeClaim{} }, namespaced: true},
+	"namespace":             {newObject: func() client.Object { return &corev1.Namespace{} }},
+	"persistentvolume":      {newObject: func() client.Object { return &corev1.PersistentVolume{} }},
+	"storageclass":          {newObject: func() client.Object { return &storagev1.StorageClass{} }},
+	"priorityclass":         {newObject: func() client.Object { return &schedulingv1.PriorityClass{} }},
+}
+
+var driftKindAliases = map[string]string{
+	"po":  "pod",
+	"cm":  "configmap",
+	"svc": "service",
+	"ep":  "endpoints",
+	"pvc": "persistentvolumeclaim",
+	"ns":  "namespace",
+	"pv":  "persistentvolume",
+	"sc":  "storageclass",
+	"pc":  "priorityclass",
+}
+
+type ExplainDriftOptions struct {
+	client                   client.Client
+	vcclient                 vcclient.Interface
+	namespace                string
+	name                     string
+	kind                     string
+	objectName               string
+	tenantNamespace          string
+	defaultOpaqueMetaDomains []string
+}
+
+func NewCmdExplainDrift(f Factory) *cobra.Command {
+	o := &ExplainDriftOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "explain-drift VC_NAME KIND/NAME",
+		Short:   "Explain the differences the syncer detects between a tenant object and its super cluster object",
+		Example: explainDriftExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(cmd.OutOrStdout()))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVar(&o.tenantNamespace, "tenant-namespace", metav1.NamespaceDefault, "The namespace of the object in the tenant control plane")
+	cmd.Flags().StringSliceVar(&o.defaultOpaqueMetaDomains, "default-opaque-meta-domains", []string{"kubernetes.io", "k8s.io"},
+		"The --default-opaque-meta-domains of the syncer")
+
+	return cmd
+}
+
+func (o *ExplainDriftOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+
+	if len(args) != 2 {
+		return UsageErrorf(cmd, "VC_NAME and KIND/NAME are required")
+	}
+
+	o.name = args[0]
+	if strings.Contains(o.name, "/") {
+		namespacedName := strings.SplitN(o.name, "/", 2)
+		o.namespace = namespacedName[0]
+		o.name = namespacedName[1]
+	}
+
+	kindName := strings.SplitN(args[1], "/", 2)
+	if len(kindName) != 2 || kindName[1] == "" {
+		return UsageErrorf(cmd, "object %q should be of the form KIND/NAME", args[1])
+	}
+	o.kind, o.objectName = normalizeDriftKind(kindName[0]), kindName[1]
+	if _, ok := driftKinds[o.kind]; !ok {
+		kinds := make([]string, 0, len(driftKinds))
+		for kind := range driftKinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		return UsageErrorf(cmd, "kind %q is not supported, supported kinds: %s", kindName[0], strings.Join(kinds, ", "))
+	}
+	return nil
+}
+
+// normalizeDriftKind maps the plural and the short names of a kind to its singular name.
+func normalizeDriftKind(kind string) string {
+	kind = strings.ToLower(kind)
+	if alias, ok := driftKindAliases[kind]; ok {
+		return alias
+	}
+	if _, ok := driftKinds[kind]; ok {
+		return kind
+	}
+	for _, suffix := range []string{"es", "s"} {
+		if _, ok := driftKinds[strings.TrimSuffix(kind, suffix)]; ok {
+			return strings.TrimSuffix(kind, suffix)
+		}
+	}
+	return kind
+}
+
+func (o *ExplainDriftOptions) Run(w io.Writer) error {
+	ctx := context.TODO()
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cluster version not found")
+	}
+	kbBytes, err := genKubeConfig(o.client, vc, cv)
+	if err != nil {
+		return err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kbBytes)
+	if err != nil {
+		return err
+	}
+	tenantClient, err := client.New(restConfig, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return err
+	}
+
+	kind := driftKinds[o.kind]
+	clusterKey := conversion.ToClusterKey(vc)
+	vKey, pKey := types.NamespacedName{Name: o.objectName}, types.NamespacedName{Name: o.objectName}
+	switch {
+	case kind.namespaced:
+		vKey.Namespace = o.tenantNamespace
+		pKey.Namespace = conversion.ToSuperClusterNamespace(clusterKey, o.tenantNamespace)
+		if o.kind == "configmap" {
+			_, pKey.Name = conversion.GetConfigMapName(o.objectName)
+		}
+	case o.kind == "namespace":
+		pKey.Name = conversion.ToSuperClusterNamespace(clusterKey, o.objectName)
+	}
+
+	vObj := kind.newObject()
+	if err := tenantClient.Get(ctx, vKey, vObj); err != nil {
+		return errors.Wrapf(err, "failed to get %s %s in the tenant control plane", o.kind, vKey)
+	}
+	pObj := kind.newObject()
+	if err := o.client.Get(ctx, pKey, pObj); err != nil {
+		return errors.Wrapf(err, "failed to get %s %s in the super cluster", o.kind, pKey)
+	}
+
+	syncerConfig := &config.SyncerConfiguration{DefaultOpaqueMetaDomains: o.defaultOpaqueMetaDomains}
+	drifts, err := conversion.Equality(syncerConfig, vc).ExplainDrift(pObj, vObj)
+	if err != nil {
+		return err
+	}
+	return printDrifts(w, drifts, pKey.String(), vKey.String())
+}
+
+func printDrifts(w io.Writer, drifts []conversion.Drift, pKey, vKey string) error {
+	if len(drifts) == 0 {
+		_, err := fmt.Fprintf(w, "no drift detected between %s in the super cluster and %s in the tenant control plane\n", pKey, vKey)
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tFIELD\tSUPER\tTENANT\tWINNER")
+	for _, d := range drifts {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", d.Check, d.Field, driftValue(d.Super), driftValue(d.Tenant), d.Winner)
+	}
+	return tw.Flush()
+}
+
+func driftValue(v string) string {
+	if v == "" {
+		return "<none>"
+	}
+	return v
+}
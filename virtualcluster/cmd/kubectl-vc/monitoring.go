@@ -0,0 +1,300 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+const (
+	monitoringExample = `
+	# Generate the PrometheusRule alerting on the syncer, vc-manager and vn-agent metrics
+	kubectl vc monitoring rules -n monitoring --rule-labels release=prometheus | kubectl apply -f -
+
+	# Generate the Grafana dashboard of installations whose scrape jobs are named vc-*
+	kubectl vc monitoring dashboard --syncer-job vc-syncer --manager-job vc-manager --vn-agent-job vc-vn-agent > dashboard.json`
+)
+
+type MonitoringOptions struct {
+	name       string
+	namespace  string
+	syncerJob  string
+	managerJob string
+	vnAgentJob string
+	ruleLabels map[string]string
+}
+
+func NewCmdMonitoring() *cobra.Command {
+	o := &MonitoringOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "monitoring",
+		Short:   "Generate the Prometheus alerts and the Grafana dashboard of the VirtualCluster components",
+		Example: monitoringExample,
+		RunE:    runHelp,
+	}
+
+	cmd.PersistentFlags().StringVar(&o.name, "name", "virtualcluster", "The name of the PrometheusRule and the title of the dashboard")
+	cmd.PersistentFlags().StringVar(&o.syncerJob, "syncer-job", "vc-syncer", "The Prometheus job scraping the syncer")
+	cmd.PersistentFlags().StringVar(&o.managerJob, "manager-job", "vc-manager", "The Prometheus job scraping vc-manager")
+	cmd.PersistentFlags().StringVar(&o.vnAgentJob, "vn-agent-job", "vn-agent", "The Prometheus job scraping vn-agent")
+
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Print the PrometheusRule alerting on the VirtualCluster components",
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.RunRules(cmd.OutOrStdout()))
+		},
+	}
+	rulesCmd.Flags().StringVarP(&o.namespace, "namespace", "n", "vc-manager", "The namespace of the PrometheusRule")
+	rulesCmd.Flags().StringToStringVar(&o.ruleLabels, "rule-labels", nil, "The labels of the PrometheusRule, e.g. the ones the ruleSelector of the Prometheus matches")
+
+	dashboardCmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Print the Grafana dashboard of the VirtualCluster components",
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.RunDashboard(cmd.OutOrStdout()))
+		},
+	}
+
+	cmd.AddCommand(rulesCmd, dashboardCmd)
+	return cmd
+}
+
+func syncerMetric(key string) string {
+	return metrics.ResourceSyncerSubsystem + "_" + key
+}
+
+type alertRule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+func newAlertRule(alert, expr, forDuration, severity, summary, description string) alertRule {
+	return alertRule{
+		Alert:       alert,
+		Expr:        expr,
+		For:         forDuration,
+		Labels:      map[string]string{"severity": severity},
+		Annotations: map[string]string{"summary": summary, "description": description},
+	}
+}
+
+// syncerRules are the alerts on the syncer, the DWS and UWS error ratios and latencies are
+// per resource, e.g. Pod.
+func (o *MonitoringOptions) syncerRules() []alertRule {
+	job := fmt.Sprintf(`job=%q`, o.syncerJob)
+	errorRatio := func(key string) string {
+		return fmt.Sprintf(`sum by (resource) (rate(%[1]s{%[2]s,code!=%[3]q}[5m])) / sum by (resource) (rate(%[1]s{%[2]s}[5m])) > 0.05`,
+			syncerMetric(key), job, utilconstants.StatusCodeOK)
+	}
+	latency := func(key string) string {
+		return fmt.Sprintf(`histogram_quantile(0.99, sum by (resource, le) (rate(%s_bucket{%s}[5m]))) > 5`, syncerMetric(key), job)
+	}
+	return []alertRule{
+		newAlertRule("VirtualClusterSyncerDown", fmt.Sprintf(`absent(up{%s} == 1)`, job), "5m", "critical",
+			"The syncer is down",
+			"No syncer instance of job "+o.syncerJob+" has been scraped for 5 minutes, tenant objects are not synced to the super cluster."),
+		newAlertRule("VirtualClusterUnhealthy", fmt.Sprintf(`%s{%s,status="unhealth"} > 0`, syncerMetric(metrics.ClusterHealthKey), job), "10m", "warning",
+			"Tenant control planes are unhealthy",
+			"{{ $value }} tenant control planes failed the health check of the syncer for 10 minutes."),
+		newAlertRule("VirtualClusterSyncerDWSErrors", errorRatio(metrics.DWSOperationCounterKey), "15m", "warning",
+			"The syncer fails to sync {{ $labels.resource }} downward",
+			"{{ $value | humanizePercentage }} of the downward syncs of {{ $labels.resource }} fail."),
+		newAlertRule("VirtualClusterSyncerUWSErrors", errorRatio(metrics.UWSOperationCounterKey), "15m", "warning",
+			"The syncer fails to sync {{ $labels.resource }} upward",
+			"{{ $value | humanizePercentage }} of the upward syncs of {{ $labels.resource }} fail."),
+		newAlertRule("VirtualClusterSyncerDWSLatencyHigh", latency(metrics.DWSOperationDurationKey), "15m", "warning",
+			"Downward syncs of {{ $labels.resource }} are slow",
+			"The 99th percentile of the downward sync duration of {{ $labels.resource }} is {{ $value }}s."),
+		newAlertRule("VirtualClusterSyncerUWSLatencyHigh", latency(metrics.UWSOperationDurationKey), "15m", "warning",
+			"Upward syncs of {{ $labels.resource }} are slow",
+			"The 99th percentile of the upward sync duration of {{ $labels.resource }} is {{ $value }}s."),
+		newAlertRule("VirtualClusterSyncerDrift", fmt.Sprintf(`%s{%s} > 0`, syncerMetric(metrics.CheckerMissMatchKey), job), "30m", "warning",
+			"The patrollers keep finding {{ $labels.counter_name }}",
+			"The patrollers found {{ $value }} {{ $labels.counter_name }} in every scan for 30 minutes, use kubectl vc explain-drift to find out why."),
+		newAlertRule("VirtualClusterSyncerRemediationBudgetExceeded", fmt.Sprintf(`%s{%s} > 0`, syncerMetric(metrics.CheckerBudgetExceededKey), job), "15m", "warning",
+			"The patroller of {{ $labels.resource }} exceeds its remediation budget for cluster {{ $labels.cluster }}",
+			"{{ $value }} drifted {{ $labels.resource }} of cluster {{ $labels.cluster }} are not remediated."),
+		newAlertRule("VirtualClusterSyncerStuckDeletions", fmt.Sprintf(`increase(%s{%s}[30m]) > 0`, syncerMetric(metrics.FinalizerStuckKey), job), "", "warning",
+			"Deletions of {{ $labels.resource }} are stuck",
+			"{{ $value }} deletions of {{ $labels.resource }} were blocked by finalizers longer than the stuck threshold."),
+		newAlertRule("VirtualClusterSyncerStuckNamespaceTerminations", fmt.Sprintf(`%s{%s} > 0`, syncerMetric(metrics.NamespaceStuckKey), job), "15m", "warning",
+			"Super cluster namespaces of cluster {{ $labels.cluster }} are stuck terminating",
+			"{{ $value }} super cluster namespaces of cluster {{ $labels.cluster }} are terminating for longer than the namespace teardown timeout."),
+		newAlertRule("VirtualClusterSyncerConfigReloadFailed", fmt.Sprintf(`increase(%s{%s,result!="success"}[15m]) > 0`, syncerMetric(metrics.ConfigReloadKey), job), "", "warning",
+			"The syncer fails to reload its configuration",
+			"The syncer failed to reload its configuration file, it keeps running with the previous settings."),
+		newAlertRule("VirtualClusterSyncerOwnershipRejected", fmt.Sprintf(`increase(%s{%s}[15m]) > 0`, syncerMetric(metrics.OwnershipRejectedKey), job), "", "warning",
+			"The syncer rejects super cluster objects of unverified ownership",
+			"{{ $value }} super cluster objects failed the ownership verification ({{ $labels.reason }})."),
+		newAlertRule("VirtualClusterSyncerWatchRestarts", fmt.Sprintf(`sum by (resource, cluster) (increase(%s{%s}[15m])) > 10`, syncerMetric(metrics.WatchRestartKey), job), "15m", "warning",
+			"The syncer keeps restarting the watch of {{ $labels.resource }} in cluster {{ $labels.cluster }}",
+			"The informer of {{ $labels.resource }} in cluster {{ $labels.cluster }} restarted {{ $value }} times after an error in 15 minutes, the tenant apiserver may be overloaded."),
+	}
+}
+
+func (o *MonitoringOptions) managerRules() []alertRule {
+	job := fmt.Sprintf(`job=%q`, o.managerJob)
+	return []alertRule{
+		newAlertRule("VirtualClusterManagerDown", fmt.Sprintf(`absent(up{%s} == 1)`, job), "5m", "critical",
+			"vc-manager is down",
+			"No vc-manager instance of job "+o.managerJob+" has been scraped for 5 minutes, VirtualClusters are not provisioned."),
+		newAlertRule("VirtualClusterUpgradeFailed", fmt.Sprintf(`increase(clusters_upgrade_failed{%s}[1h]) > 0`, job), "", "warning",
+			"VirtualClusters fail to upgrade to ClusterVersion {{ $labels.cluster_version }}",
+			"{{ $value }} VirtualClusters failed to upgrade to ClusterVersion {{ $labels.cluster_version }} in the last hour."),
+		newAlertRule("VirtualClusterETCDStoragePressure",
+			fmt.Sprintf(`tenant_etcd_db_total_size_bytes{%s} / tenant_etcd_quota_backend_bytes{%s} > 0.8`, job, job), "15m", "warning",
+			"The etcd of VirtualCluster {{ $labels.cluster }} approaches its quota",
+			"The etcd database of VirtualCluster {{ $labels.cluster }} uses {{ $value | humanizePercentage }} of its quota, the tenant apiserver rejects writes once the quota is exceeded."),
+	}
+}
+
+func (o *MonitoringOptions) vnAgentRules() []alertRule {
+	job := fmt.Sprintf(`job=%q`, o.vnAgentJob)
+	return []alertRule{
+		newAlertRule("VirtualClusterVNAgentErrors", fmt.Sprintf(`sum by (instance, reason) (rate(vn_agent_counter_for_tenant_failure{%s}[5m])) > 0`, job), "10m", "warning",
+			"vn-agent fails to proxy tenant requests on {{ $labels.instance }}",
+			"vn-agent on {{ $labels.instance }} fails to proxy kubectl exec and logs requests of tenants ({{ $labels.reason }})."),
+	}
+}
+
+func (o *MonitoringOptions) RunRules(w io.Writer) error {
+	rule := map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata": map[string]interface{}{
+			"name":      o.name,
+			"namespace": o.namespace,
+			"labels":    o.ruleLabels,
+		},
+		"spec": map[string]interface{}{
+			"groups": []map[string]interface{}{
+				{"name": o.name + ".syncer", "rules": o.syncerRules()},
+				{"name": o.name + ".vc-manager", "rules": o.managerRules()},
+				{"name": o.name + ".vn-agent", "rules": o.vnAgentRules()},
+			},
+		},
+	}
+	data, err := yaml.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+type dashboardPanel struct {
+	ID          int               `json:"id"`
+	Type        string            `json:"type"`
+	Title       string            `json:"title"`
+	Datasource  string            `json:"datasource"`
+	GridPos     map[string]int    `json:"gridPos"`
+	FieldConfig interface{}       `json:"fieldConfig"`
+	Targets     []dashboardTarget `json:"targets"`
+}
+
+type dashboardTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+// dashboardPanels returns the panels of the dashboard, two per row.
+func (o *MonitoringOptions) dashboardPanels() []dashboardPanel {
+	syncer := fmt.Sprintf(`job=%q`, o.syncerJob)
+	manager := fmt.Sprintf(`job=%q`, o.managerJob)
+	vnAgent := fmt.Sprintf(`job=%q`, o.vnAgentJob)
+	rate := func(key, by string) string {
+		return fmt.Sprintf(`sum by (%s) (rate(%s{%s}[5m]))`, by, syncerMetric(key), syncer)
+	}
+	p99 := func(metric, selector, by string) string {
+		return fmt.Sprintf(`histogram_quantile(0.99, sum by (%s, le) (rate(%s_bucket{%s}[5m])))`, by, metric, selector)
+	}
+
+	panels := []struct {
+		title, unit, expr, legend string
+	}{
+		{"Tenant control plane health", "short", fmt.Sprintf(`%s{%s}`, syncerMetric(metrics.ClusterHealthKey), syncer), "{{status}}"},
+		{"Syncer work queue depth", "short", fmt.Sprintf(`sum by (name) (workqueue_depth{%s})`, syncer), "{{name}}"},
+		{"Downward syncs", "ops", rate(metrics.DWSOperationCounterKey, "resource, code"), "{{resource}} {{code}}"},
+		{"Downward sync duration (p99)", "s", p99(syncerMetric(metrics.DWSOperationDurationKey), syncer, "resource"), "{{resource}}"},
+		{"Upward syncs", "ops", rate(metrics.UWSOperationCounterKey, "resource, code"), "{{resource}} {{code}}"},
+		{"Upward sync duration (p99)", "s", p99(syncerMetric(metrics.UWSOperationDurationKey), syncer, "resource"), "{{resource}}"},
+		{"Pod operations", "ops", rate(metrics.PodOperationsKey, "operation_type, code"), "{{operation_type}} {{code}}"},
+		{"Pod operation duration (p99)", "s", p99(syncerMetric(metrics.PodOperationsDurationKey), syncer, "operation_type"), "{{operation_type}}"},
+		{"Patroller drifts", "short", fmt.Sprintf(`sum by (counter_name) (%s{%s})`, syncerMetric(metrics.CheckerMissMatchKey), syncer), "{{counter_name}}"},
+		{"Patroller remediations", "ops", rate(metrics.CheckerRemedyKey, "counter_name"), "{{counter_name}}"},
+		{"Patroller scan duration (p99)", "s", p99(syncerMetric(metrics.CheckerScanDurationKey), syncer, "resource"), "{{resource}}"},
+		{"Sync conflicts", "ops", rate(metrics.SyncConflictKey, "resource, policy"), "{{resource}} {{policy}}"},
+		{"Cached tenant objects", "short", fmt.Sprintf(`sum by (resource) (%s{%s})`, syncerMetric(metrics.CachedObjectsKey), syncer), "{{resource}}"},
+		{"Tenant watch restarts", "ops", rate(metrics.WatchRestartKey, "resource, cluster"), "{{resource}} {{cluster}}"},
+		{"VirtualCluster upgrades", "short", fmt.Sprintf(`sum by (cluster_version) (increase(clusters_upgraded{%s}[1h]))`, manager), "{{cluster_version}}"},
+		{"VirtualCluster upgrade failures", "short", fmt.Sprintf(`sum by (cluster_version) (increase(clusters_upgrade_failed{%s}[1h]))`, manager), "{{cluster_version}}"},
+		{"vn-agent requests", "reqps", fmt.Sprintf(`sum by (code) (rate(vn_agent_total_requests{%s}[5m]))`, vnAgent), "{{code}}"},
+		{"vn-agent request latency (p99)", "s", p99("vn_agent_request_latencies", vnAgent, "instance"), "{{instance}}"},
+	}
+
+	dashboardPanels := make([]dashboardPanel, 0, len(panels))
+	for i, p := range panels {
+		dashboardPanels = append(dashboardPanels, dashboardPanel{
+			ID:          i + 1,
+			Type:        "timeseries",
+			Title:       p.title,
+			Datasource:  "${datasource}",
+			GridPos:     map[string]int{"h": 8, "w": 12, "x": 12 * (i % 2), "y": 8 * (i / 2)},
+			FieldConfig: map[string]interface{}{"defaults": map[string]string{"unit": p.unit}},
+			Targets:     []dashboardTarget{{Expr: p.expr, LegendFormat: p.legend, RefID: "A"}},
+		})
+	}
+	return dashboardPanels
+}
+
+func (o *MonitoringOptions) RunDashboard(w io.Writer) error {
+	dashboard := map[string]interface{}{
+		"title":         o.name,
+		"uid":           o.name,
+		"tags":          []string{"virtualcluster"},
+		"timezone":      "browser",
+		"schemaVersion": 30,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{"name": "datasource", "label": "Data source", "type": "datasource", "query": "prometheus"},
+			},
+		},
+		"panels": o.dashboardPanels(),
+	}
+	data, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
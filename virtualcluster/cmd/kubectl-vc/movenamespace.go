@@ -0,0 +1,713 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+const (
+	moveNamespaceExample = `
+	# Move the tenant namespace web of virtualcluster foo/bar from the super cluster of context r1 to the one of context r2
+	kubectl vc move-namespace foo/bar web --from-context r1 --to-context r2
+
+	# Show what the move would do without changing anything
+	kubectl vc move-namespace -n foo bar web --from-context r1 --to-context r2 --dry-run`
+
+	// annotationMoveReplicas records the replicas of a tenant workload scaled down during a move.
+	annotationMoveReplicas = "tenancy.x-k8s.io/move-namespace.replicas"
+	// annotationMoveSnapshotHandle, annotationMoveSnapshotDriver and annotationMoveSnapshotContent
+	// record the snapshot of the data of a tenant PVC taken in the source super cluster.
+	annotationMoveSnapshotHandle  = "tenancy.x-k8s.io/move-namespace.snapshot-handle"
+	annotationMoveSnapshotDriver  = "tenancy.x-k8s.io/move-namespace.snapshot-driver"
+	annotationMoveSnapshotContent = "tenancy.x-k8s.io/move-namespace.snapshot-content"
+	// annotationMoveTarget marks the tenant PVCs recreated for the target super cluster.
+	annotationMoveTarget = "tenancy.x-k8s.io/move-namespace.target"
+
+	// annotationDefaultSnapshotClass marks the default VolumeSnapshotClass of a driver.
+	annotationDefaultSnapshotClass = "snapshot.storage.kubernetes.io/is-default-class"
+
+	moveSnapshotPrefix = "vc-move-"
+	movePollInterval   = 2 * time.Second
+)
+
+// claimBindAnnotations are set by the persistent volume controller on bound claims, they are not
+// kept on the claims recreated in the target super cluster.
+var claimBindAnnotations = []string{
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/selected-node",
+}
+
+type MoveNamespaceOptions struct {
+	client          client.Client
+	vcclient        vcclient.Interface
+	namespace       string
+	name            string
+	tenantNamespace string
+	fromContext     string
+	toContext       string
+	timeout         time.Duration
+	dryRun          bool
+	skipVolumeData  bool
+}
+
+// volumeMove is the plan of the data of a tenant PVC.
+type volumeMove struct {
+	claim *corev1.PersistentVolumeClaim
+	// driver and class are the CSI driver of the volume and the source VolumeSnapshotClass used
+	// to snapshot it.
+	driver string
+	class  string
+	// skip is why the data of the claim is not moved, the claim is recreated empty.
+	skip string
+}
+
+func NewCmdMoveNamespace(f Factory) *cobra.Command {
+	o := &MoveNamespaceOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "move-namespace VC_NAME NAMESPACE",
+		Short:   "Move a tenant namespace and its volume data to another super cluster of the pool",
+		Example: moveNamespaceExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(cmd.OutOrStdout()))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVar(&o.fromContext, "from-context", "", "The kubeconfig context of the super cluster the namespace is placed in")
+	cmd.Flags().StringVar(&o.toContext, "to-context", "", "The kubeconfig context of the super cluster to move the namespace to")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", 10*time.Minute, "How long to wait for each step of the move")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Only print the plan of the move")
+	cmd.Flags().BoolVar(&o.skipVolumeData, "skip-volume-data", false, "Recreate the claims whose data can't be snapshotted empty instead of failing")
+
+	return cmd
+}
+
+func (o *MoveNamespaceOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+
+	if len(args) != 2 {
+		return UsageErrorf(cmd, "VC_NAME and NAMESPACE are required")
+	}
+
+	o.name, o.tenantNamespace = args[0], args[1]
+	if strings.Contains(o.name, "/") {
+		namespacedName := strings.SplitN(o.name, "/", 2)
+		o.namespace = namespacedName[0]
+		o.name = namespacedName[1]
+	}
+
+	if o.fromContext == "" || o.toContext == "" {
+		return UsageErrorf(cmd, "--from-context and --to-context are required")
+	}
+	if o.fromContext == o.toContext {
+		return UsageErrorf(cmd, "--from-context and --to-context should be different")
+	}
+	return nil
+}
+
+// Run moves the tenant namespace. Every step is skipped if it is already done, so a failed move
+// is continued by running the command again.
+func (o *MoveNamespaceOptions) Run(w io.Writer) error {
+	ctx := context.TODO()
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cluster version not found")
+	}
+	kbBytes, err := genKubeConfig(o.client, vc, cv)
+	if err != nil {
+		return err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kbBytes)
+	if err != nil {
+		return err
+	}
+	tenantClient, err := client.New(restConfig, client.Options{Scheme: clientgoscheme.Scheme})
+	if err != nil {
+		return err
+	}
+
+	source, sourceID, err := superClusterClient(ctx, o.fromContext)
+	if err != nil {
+		return err
+	}
+	target, targetID, err := superClusterClient(ctx, o.toContext)
+	if err != nil {
+		return err
+	}
+	if sourceID == targetID {
+		return errors.Errorf("contexts %s and %s are the same super cluster %s", o.fromContext, o.toContext, sourceID)
+	}
+
+	ns := &corev1.Namespace{}
+	if err := tenantClient.Get(ctx, types.NamespacedName{Name: o.tenantNamespace}, ns); err != nil {
+		return err
+	}
+	placements := map[string]int{}
+	if p, ok := ns.Annotations[utilconst.LabelScheduledPlacements]; ok {
+		if err := json.Unmarshal([]byte(p), &placements); err != nil {
+			return errors.Wrapf(err, "failed to parse the placements of namespace %s", o.tenantNamespace)
+		}
+	}
+	// the placements are already flipped when a previous move failed afterwards.
+	flipped := placements[sourceID] == 0 && placements[targetID] > 0
+	if placements[sourceID] == 0 && !flipped {
+		return errors.Errorf("namespace %s is not placed in super cluster %s", o.tenantNamespace, sourceID)
+	}
+
+	superNamespace := conversion.ToSuperClusterNamespace(conversion.ToClusterKey(vc), o.tenantNamespace)
+	if err := checkMovablePods(ctx, tenantClient, o.tenantNamespace); err != nil {
+		return err
+	}
+	volumes, err := o.planVolumes(ctx, tenantClient, source, target, superNamespace, targetID, flipped)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "moving namespace %s of virtualcluster %s/%s from super cluster %s to %s\n", o.tenantNamespace, o.namespace, o.name, sourceID, targetID)
+	for _, v := range volumes {
+		switch {
+		case v.skip != "":
+			fmt.Fprintf(w, "  pvc %s: recreated empty, %s\n", v.claim.Name, v.skip)
+		case v.driver != "":
+			fmt.Fprintf(w, "  pvc %s: data moved with a snapshot of driver %s\n", v.claim.Name, v.driver)
+		}
+	}
+	if o.dryRun {
+		return nil
+	}
+
+	fmt.Fprintf(w, "scaling down the workloads of namespace %s\n", o.tenantNamespace)
+	if err := o.quiesce(ctx, tenantClient); err != nil {
+		return err
+	}
+
+	if !flipped {
+		for i := range volumes {
+			if volumes[i].class == "" {
+				continue
+			}
+			fmt.Fprintf(w, "snapshotting pvc %s in super cluster %s\n", volumes[i].claim.Name, sourceID)
+			if err := o.snapshotVolume(ctx, tenantClient, source, superNamespace, &volumes[i]); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(w, "placing namespace %s in super cluster %s\n", o.tenantNamespace, targetID)
+		placements[targetID] += placements[sourceID]
+		delete(placements, sourceID)
+		if err := setPlacements(ctx, tenantClient, ns, placements); err != nil {
+			return err
+		}
+	}
+
+	if err := wait.PollImmediate(movePollInterval, o.timeout, func() (bool, error) {
+		err := target.Get(ctx, types.NamespacedName{Name: superNamespace}, &corev1.Namespace{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}); err != nil {
+		return errors.Wrapf(err, "namespace %s is not synced to super cluster %s", superNamespace, targetID)
+	}
+
+	var retained []string
+	for _, v := range volumes {
+		fmt.Fprintf(w, "recreating pvc %s\n", v.claim.Name)
+		content, err := o.restoreVolume(ctx, tenantClient, target, superNamespace, targetID, v)
+		if err != nil {
+			return err
+		}
+		if content != "" {
+			retained = append(retained, content)
+		}
+	}
+
+	fmt.Fprintf(w, "scaling up the workloads of namespace %s\n", o.tenantNamespace)
+	if err := resumeWorkloads(ctx, tenantClient, o.tenantNamespace); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "namespace %s is moved to super cluster %s\n", o.tenantNamespace, targetID)
+	if len(retained) > 0 {
+		fmt.Fprintf(w, "the snapshots of the moved data are retained, delete their volumesnapshotcontents once the data is verified:\n")
+		for _, content := range retained {
+			fmt.Fprintf(w, "  %s\n", content)
+		}
+	}
+	return nil
+}
+
+// superClusterScheme returns the scheme of the super cluster clients, including the snapshot API.
+func superClusterScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(s))
+	utilruntime.Must(snapshotv1.AddToScheme(s))
+	return s
+}
+
+// superClusterClient returns a client of the super cluster of the kubeconfig context, and the id
+// of the super cluster.
+func superClusterClient(ctx context.Context, kubeconfigContext string) (client.Client, string, error) {
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: kubeconfigContext}).ClientConfig()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to load context %s", kubeconfigContext)
+	}
+	cli, err := client.New(restConfig, client.Options{Scheme: superClusterScheme()})
+	if err != nil {
+		return nil, "", err
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: metav1.NamespaceSystem, Name: utilconst.SuperClusterInfoCfgMap}, cm); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get the id of the super cluster of context %s", kubeconfigContext)
+	}
+	id := cm.Data[utilconst.SuperClusterIDKey]
+	if id == "" {
+		return nil, "", errors.Errorf("configmap %s of context %s has no %s", utilconst.SuperClusterInfoCfgMap, kubeconfigContext, utilconst.SuperClusterIDKey)
+	}
+	return cli, id, nil
+}
+
+// setPlacements updates the placements of the tenant namespace, the syncers of the super clusters
+// sync the namespace accordingly.
+func setPlacements(ctx context.Context, cli client.Client, ns *corev1.Namespace, placements map[string]int) error {
+	p, err := json.Marshal(placements)
+	if err != nil {
+		return err
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[utilconst.LabelScheduledPlacements] = string(p)
+	return cli.Update(ctx, ns)
+}
+
+// checkMovablePods fails if the namespace has pods that are not recreated by a workload scaled
+// during the move.
+func checkMovablePods(ctx context.Context, cli client.Client, namespace string) error {
+	pods := &corev1.PodList{}
+	if err := cli.List(ctx, pods, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	var unmanaged []string
+	for _, pod := range pods.Items {
+		owner := metav1.GetControllerOf(&pod)
+		if owner == nil || owner.Kind != "ReplicaSet" && owner.Kind != "StatefulSet" {
+			unmanaged = append(unmanaged, pod.Name)
+		}
+	}
+	if len(unmanaged) > 0 {
+		return errors.Errorf("pods %s are not managed by a deployment, replicaset or statefulset, delete them or their controllers before moving namespace %s",
+			strings.Join(unmanaged, ", "), namespace)
+	}
+	return nil
+}
+
+// planVolumes returns the claims to recreate in the target super cluster. The data of a bound
+// claim is moved if its CSI driver can snapshot it in the source super cluster and is installed in
+// the target one.
+func (o *MoveNamespaceOptions) planVolumes(ctx context.Context, tenant, source, target client.Client, superNamespace, targetID string, flipped bool) ([]volumeMove, error) {
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := tenant.List(ctx, claims, client.InNamespace(o.tenantNamespace)); err != nil {
+		return nil, err
+	}
+
+	var volumes, unsupported []volumeMove
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		v := volumeMove{claim: claim}
+		switch {
+		case claim.Annotations[annotationMoveTarget] == targetID:
+			// recreated by a previous move.
+			continue
+		case claim.Annotations[annotationMoveSnapshotHandle] != "":
+			v.driver = claim.Annotations[annotationMoveSnapshotDriver]
+		case claim.Spec.VolumeName == "":
+			// not bound, the target super cluster provisions it.
+			continue
+		case flipped:
+			v.skip = "the namespace is no longer placed in the source super cluster"
+		default:
+			v.driver, v.class, v.skip = snapshotClass(ctx, source, target, superNamespace, claim.Name)
+		}
+		if v.skip != "" && !o.skipVolumeData {
+			unsupported = append(unsupported, v)
+		}
+		volumes = append(volumes, v)
+	}
+
+	if len(unsupported) > 0 {
+		var reasons []string
+		for _, v := range unsupported {
+			reasons = append(reasons, fmt.Sprintf("pvc %s: %s", v.claim.Name, v.skip))
+		}
+		return nil, errors.Errorf("the data of some claims can't be moved, use --skip-volume-data to recreate them empty:\n  %s", strings.Join(reasons, "\n  "))
+	}
+	return volumes, nil
+}
+
+// snapshotClass returns the CSI driver of the super claim and the source VolumeSnapshotClass to
+// snapshot it with, or why it can't be snapshotted.
+func snapshotClass(ctx context.Context, source, target client.Client, superNamespace, name string) (string, string, string) {
+	pClaim := &corev1.PersistentVolumeClaim{}
+	if err := source.Get(ctx, types.NamespacedName{Namespace: superNamespace, Name: name}, pClaim); err != nil {
+		return "", "", fmt.Sprintf("failed to get the super claim: %v", err)
+	}
+	if pClaim.Spec.VolumeName == "" {
+		return "", "", "the super claim is not bound"
+	}
+	pv := &corev1.PersistentVolume{}
+	if err := source.Get(ctx, types.NamespacedName{Name: pClaim.Spec.VolumeName}, pv); err != nil {
+		return "", "", fmt.Sprintf("failed to get the super volume: %v", err)
+	}
+	if pv.Spec.CSI == nil {
+		return "", "", fmt.Sprintf("volume %s is not provisioned by a CSI driver", pv.Name)
+	}
+	driver := pv.Spec.CSI.Driver
+
+	classes := &snapshotv1.VolumeSnapshotClassList{}
+	if err := source.List(ctx, classes); err != nil {
+		return driver, "", fmt.Sprintf("failed to list the volumesnapshotclasses: %v", err)
+	}
+	class := ""
+	for _, c := range classes.Items {
+		if c.Driver != driver {
+			continue
+		}
+		if class == "" || c.Annotations[annotationDefaultSnapshotClass] == "true" {
+			class = c.Name
+		}
+	}
+	if class == "" {
+		return driver, "", fmt.Sprintf("no volumesnapshotclass of driver %s in the source super cluster", driver)
+	}
+
+	if err := target.Get(ctx, types.NamespacedName{Name: driver}, &storagev1.CSIDriver{}); err != nil {
+		return driver, "", fmt.Sprintf("csidriver %s is not installed in the target super cluster: %v", driver, err)
+	}
+	return driver, class, ""
+}
+
+// workloads returns the workloads of the namespace the move scales down, along with their replicas.
+func workloads(ctx context.Context, cli client.Client, namespace string) ([]client.Object, []**int32, error) {
+	var objs []client.Object
+	var replicas []**int32
+
+	deployments := &appsv1.DeploymentList{}
+	if err := cli.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return nil, nil, err
+	}
+	for i := range deployments.Items {
+		objs = append(objs, &deployments.Items[i])
+		replicas = append(replicas, &deployments.Items[i].Spec.Replicas)
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := cli.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return nil, nil, err
+	}
+	for i := range statefulSets.Items {
+		objs = append(objs, &statefulSets.Items[i])
+		replicas = append(replicas, &statefulSets.Items[i].Spec.Replicas)
+	}
+
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := cli.List(ctx, replicaSets, client.InNamespace(namespace)); err != nil {
+		return nil, nil, err
+	}
+	for i := range replicaSets.Items {
+		// the replicasets of deployments are scaled by their deployment.
+		if owner := metav1.GetControllerOf(&replicaSets.Items[i]); owner != nil && owner.Kind == "Deployment" {
+			continue
+		}
+		objs = append(objs, &replicaSets.Items[i])
+		replicas = append(replicas, &replicaSets.Items[i].Spec.Replicas)
+	}
+	return objs, replicas, nil
+}
+
+// quiesce scales the workloads of the tenant namespace to zero, recording their replicas, and
+// waits for their pods to be deleted.
+func (o *MoveNamespaceOptions) quiesce(ctx context.Context, cli client.Client) error {
+	objs, replicas, err := workloads(ctx, cli, o.tenantNamespace)
+	if err != nil {
+		return err
+	}
+	for i, obj := range objs {
+		annotations := obj.GetAnnotations()
+		if _, ok := annotations[annotationMoveReplicas]; ok {
+			continue
+		}
+		current := int32(1)
+		if *replicas[i] != nil {
+			current = **replicas[i]
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[annotationMoveReplicas] = strconv.Itoa(int(current))
+		obj.SetAnnotations(annotations)
+		zero := int32(0)
+		*replicas[i] = &zero
+		if err := cli.Update(ctx, obj); err != nil {
+			return errors.Wrapf(err, "failed to scale down %s", obj.GetName())
+		}
+	}
+
+	return wait.PollImmediate(movePollInterval, o.timeout, func() (bool, error) {
+		pods := &corev1.PodList{}
+		if err := cli.List(ctx, pods, client.InNamespace(o.tenantNamespace)); err != nil {
+			return false, err
+		}
+		return len(pods.Items) == 0, nil
+	})
+}
+
+// resumeWorkloads scales the workloads of the namespace back to the replicas recorded by quiesce.
+func resumeWorkloads(ctx context.Context, cli client.Client, namespace string) error {
+	objs, replicas, err := workloads(ctx, cli, namespace)
+	if err != nil {
+		return err
+	}
+	for i, obj := range objs {
+		annotations := obj.GetAnnotations()
+		recorded, ok := annotations[annotationMoveReplicas]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(recorded)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s of %s", annotationMoveReplicas, obj.GetName())
+		}
+		restored := int32(n)
+		*replicas[i] = &restored
+		delete(annotations, annotationMoveReplicas)
+		obj.SetAnnotations(annotations)
+		if err := cli.Update(ctx, obj); err != nil {
+			return errors.Wrapf(err, "failed to scale up %s", obj.GetName())
+		}
+	}
+	return nil
+}
+
+// snapshotVolume snapshots the super claim of the volume in the source super cluster and records
+// the snapshot on the tenant claim. The snapshot content is retained, so the snapshot outlives the
+// super namespace deleted once the namespace is no longer placed in the source super cluster.
+func (o *MoveNamespaceOptions) snapshotVolume(ctx context.Context, tenant, source client.Client, superNamespace string, v *volumeMove) error {
+	snapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: moveSnapshotName(v.claim.Name), Namespace: superNamespace},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source:                  snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: &v.claim.Name},
+			VolumeSnapshotClassName: &v.class,
+		},
+	}
+	if err := source.Create(ctx, snapshot); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to snapshot pvc %s", v.claim.Name)
+	}
+
+	key := client.ObjectKeyFromObject(snapshot)
+	if err := wait.PollImmediate(movePollInterval, o.timeout, func() (bool, error) {
+		if err := source.Get(ctx, key, snapshot); err != nil {
+			return false, err
+		}
+		status := snapshot.Status
+		if status != nil && status.Error != nil && status.Error.Message != nil {
+			return false, errors.Errorf("snapshot %s failed: %s", key, *status.Error.Message)
+		}
+		return status != nil && status.ReadyToUse != nil && *status.ReadyToUse && status.BoundVolumeSnapshotContentName != nil, nil
+	}); err != nil {
+		return errors.Wrapf(err, "snapshot %s is not ready", key)
+	}
+
+	content := &snapshotv1.VolumeSnapshotContent{}
+	if err := source.Get(ctx, types.NamespacedName{Name: *snapshot.Status.BoundVolumeSnapshotContentName}, content); err != nil {
+		return err
+	}
+	if content.Status == nil || content.Status.SnapshotHandle == nil {
+		return errors.Errorf("volumesnapshotcontent %s has no snapshot handle", content.Name)
+	}
+	if content.Spec.DeletionPolicy != snapshotv1.VolumeSnapshotContentRetain {
+		content.Spec.DeletionPolicy = snapshotv1.VolumeSnapshotContentRetain
+		if err := source.Update(ctx, content); err != nil {
+			return errors.Wrapf(err, "failed to retain volumesnapshotcontent %s", content.Name)
+		}
+	}
+
+	if v.claim.Annotations == nil {
+		v.claim.Annotations = map[string]string{}
+	}
+	v.claim.Annotations[annotationMoveSnapshotHandle] = *content.Status.SnapshotHandle
+	v.claim.Annotations[annotationMoveSnapshotDriver] = v.driver
+	v.claim.Annotations[annotationMoveSnapshotContent] = content.Name
+	return tenant.Update(ctx, v.claim)
+}
+
+// restoreVolume recreates the tenant claim so that the target super cluster provisions it, from a
+// pre-provisioned snapshot of the recorded snapshot handle if any. It returns the contents retained
+// for the claim.
+func (o *MoveNamespaceOptions) restoreVolume(ctx context.Context, tenant, target client.Client, superNamespace, targetID string, v volumeMove) (string, error) {
+	claim := v.claim
+	var dataSource *corev1.TypedLocalObjectReference
+	handle := claim.Annotations[annotationMoveSnapshotHandle]
+	if handle != "" {
+		snapshotName := moveSnapshotName(claim.Name)
+		contentName := moveSnapshotPrefix + string(claim.UID)
+		content := &snapshotv1.VolumeSnapshotContent{
+			ObjectMeta: metav1.ObjectMeta{Name: contentName},
+			Spec: snapshotv1.VolumeSnapshotContentSpec{
+				VolumeSnapshotRef: corev1.ObjectReference{Namespace: superNamespace, Name: snapshotName},
+				DeletionPolicy:    snapshotv1.VolumeSnapshotContentRetain,
+				Driver:            v.driver,
+				Source:            snapshotv1.VolumeSnapshotContentSource{SnapshotHandle: &handle},
+			},
+		}
+		if err := target.Create(ctx, content); err != nil && !apierrors.IsAlreadyExists(err) {
+			return "", errors.Wrapf(err, "failed to create volumesnapshotcontent %s", contentName)
+		}
+		snapshot := &snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: snapshotName, Namespace: superNamespace},
+			Spec: snapshotv1.VolumeSnapshotSpec{
+				Source: snapshotv1.VolumeSnapshotSource{VolumeSnapshotContentName: &contentName},
+			},
+		}
+		if err := target.Create(ctx, snapshot); err != nil && !apierrors.IsAlreadyExists(err) {
+			return "", errors.Wrapf(err, "failed to create volumesnapshot %s/%s", superNamespace, snapshotName)
+		}
+		apiGroup := snapshotv1.GroupName
+		dataSource = &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: snapshotName}
+	}
+
+	if err := tenant.Delete(ctx, claim); err != nil && !apierrors.IsNotFound(err) {
+		return "", errors.Wrapf(err, "failed to delete pvc %s", claim.Name)
+	}
+	key := client.ObjectKeyFromObject(claim)
+	if err := wait.PollImmediate(movePollInterval, o.timeout, func() (bool, error) {
+		err := tenant.Get(ctx, key, &corev1.PersistentVolumeClaim{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}); err != nil {
+		return "", errors.Wrapf(err, "pvc %s is not deleted", claim.Name)
+	}
+
+	if err := tenant.Create(ctx, recreatedClaim(claim, dataSource, targetID)); err != nil {
+		return "", errors.Wrapf(err, "failed to recreate pvc %s", claim.Name)
+	}
+	if handle == "" {
+		return "", nil
+	}
+	return fmt.Sprintf("pvc %s: %s in super cluster %s, %s in super cluster %s", claim.Name,
+		claim.Annotations[annotationMoveSnapshotContent], o.fromContext, moveSnapshotPrefix+string(claim.UID), o.toContext), nil
+}
+
+// recreatedClaim returns an unbound copy of claim restored from dataSource. The requested storage
+// is at least the capacity of the claim, which the restored volume needs.
+func recreatedClaim(claim *corev1.PersistentVolumeClaim, dataSource *corev1.TypedLocalObjectReference, targetID string) *corev1.PersistentVolumeClaim {
+	annotations := map[string]string{}
+	for k, v := range claim.Annotations {
+		annotations[k] = v
+	}
+	for _, k := range append(claimBindAnnotations, annotationMoveSnapshotHandle, annotationMoveSnapshotDriver, annotationMoveSnapshotContent) {
+		delete(annotations, k)
+	}
+	annotations[annotationMoveTarget] = targetID
+
+	recreated := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        claim.Name,
+			Namespace:   claim.Namespace,
+			Labels:      claim.Labels,
+			Annotations: annotations,
+		},
+		Spec: *claim.Spec.DeepCopy(),
+	}
+	recreated.Spec.VolumeName = ""
+	recreated.Spec.DataSource = dataSource
+	if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok {
+		if request := recreated.Spec.Resources.Requests[corev1.ResourceStorage]; capacity.Cmp(request) > 0 {
+			if recreated.Spec.Resources.Requests == nil {
+				recreated.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			recreated.Spec.Resources.Requests[corev1.ResourceStorage] = capacity
+		}
+	}
+	return recreated
+}
+
+// moveSnapshotName returns the name of the snapshots of the claim taken by the move. The name of a
+// long claim is truncated and suffixed with a hash of the claim, so that claims sharing a long
+// prefix don't share the snapshot.
+func moveSnapshotName(claim string) string {
+	name := moveSnapshotPrefix + claim
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(claim))
+	// the truncated name must still end with an alphanumeric character
+	name = strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-9], ".-")
+	return fmt.Sprintf("%s-%08x", name, h.Sum32())
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testTenantNamespace = "web"
+	testSuperNamespace  = "cluster-web"
+	testTargetID        = "r2"
+)
+
+func tenantClaim(name, volumeName string, annotations map[string]string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testTenantNamespace, Annotations: annotations},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: volumeName},
+	}
+}
+
+func superClaim(name, volumeName string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testSuperNamespace},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: volumeName},
+	}
+}
+
+func csiVolume(name, driver string) *corev1.PersistentVolume {
+	pv := &corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if driver != "" {
+		pv.Spec.CSI = &corev1.CSIPersistentVolumeSource{Driver: driver, VolumeHandle: name}
+	}
+	return pv
+}
+
+func snapshotClassOf(name, driver string, isDefault bool) *snapshotv1.VolumeSnapshotClass {
+	class := &snapshotv1.VolumeSnapshotClass{
+		ObjectMeta:     metav1.ObjectMeta{Name: name},
+		Driver:         driver,
+		DeletionPolicy: snapshotv1.VolumeSnapshotContentDelete,
+	}
+	if isDefault {
+		class.Annotations = map[string]string{annotationDefaultSnapshotClass: "true"}
+	}
+	return class
+}
+
+func TestPlanVolumes(t *testing.T) {
+	csiDriver := &storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "csi.example.com"}}
+
+	for _, tc := range []struct {
+		name           string
+		claims         []client.Object
+		source         []client.Object
+		target         []client.Object
+		flipped        bool
+		skipVolumeData bool
+		expected       map[string]volumeMove
+		expectedErr    string
+	}{
+		{
+			name: "unbound and previously moved claims",
+			claims: []client.Object{
+				tenantClaim("unbound", "", nil),
+				tenantClaim("moved", "pv-moved", map[string]string{annotationMoveTarget: testTargetID}),
+			},
+			expected: map[string]volumeMove{},
+		},
+		{
+			name: "snapshotted claim of an interrupted move",
+			claims: []client.Object{
+				tenantClaim("data", "pv-data", map[string]string{
+					annotationMoveSnapshotHandle: "snap-1",
+					annotationMoveSnapshotDriver: "csi.example.com",
+				}),
+			},
+			expected: map[string]volumeMove{"data": {driver: "csi.example.com"}},
+		},
+		{
+			name:   "csi claim with the default snapshot class",
+			claims: []client.Object{tenantClaim("data", "pv-data", nil)},
+			source: []client.Object{
+				superClaim("data", "pv-data"),
+				csiVolume("pv-data", "csi.example.com"),
+				snapshotClassOf("other", "other.example.com", true),
+				snapshotClassOf("slow", "csi.example.com", false),
+				snapshotClassOf("fast", "csi.example.com", true),
+			},
+			target:   []client.Object{csiDriver},
+			expected: map[string]volumeMove{"data": {driver: "csi.example.com", class: "fast"}},
+		},
+		{
+			name:   "csi driver missing in the target",
+			claims: []client.Object{tenantClaim("data", "pv-data", nil)},
+			source: []client.Object{
+				superClaim("data", "pv-data"),
+				csiVolume("pv-data", "csi.example.com"),
+				snapshotClassOf("fast", "csi.example.com", true),
+			},
+			expectedErr: "pvc data: csidriver csi.example.com is not installed in the target super cluster",
+		},
+		{
+			name:           "volume without csi driver recreated empty",
+			claims:         []client.Object{tenantClaim("data", "pv-data", nil)},
+			source:         []client.Object{superClaim("data", "pv-data"), csiVolume("pv-data", "")},
+			skipVolumeData: true,
+			expected:       map[string]volumeMove{"data": {skip: "volume pv-data is not provisioned by a CSI driver"}},
+		},
+		{
+			name:        "placement already flipped",
+			claims:      []client.Object{tenantClaim("data", "pv-data", nil)},
+			flipped:     true,
+			expectedErr: "pvc data: the namespace is no longer placed in the source super cluster",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := superClusterScheme()
+			tenant := fake.NewClientBuilder().WithScheme(s).WithObjects(tc.claims...).Build()
+			source := fake.NewClientBuilder().WithScheme(s).WithObjects(tc.source...).Build()
+			target := fake.NewClientBuilder().WithScheme(s).WithObjects(tc.target...).Build()
+			o := &MoveNamespaceOptions{tenantNamespace: testTenantNamespace, skipVolumeData: tc.skipVolumeData}
+
+			volumes, err := o.planVolumes(context.TODO(), tenant, source, target, testSuperNamespace, testTargetID, tc.flipped)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(volumes) != len(tc.expected) {
+				t.Fatalf("expected %d volumes, got %d", len(tc.expected), len(volumes))
+			}
+			for _, v := range volumes {
+				expected, ok := tc.expected[v.claim.Name]
+				if !ok {
+					t.Errorf("unexpected volume of pvc %s", v.claim.Name)
+					continue
+				}
+				if v.driver != expected.driver || v.class != expected.class || !strings.HasPrefix(v.skip, expected.skip) {
+					t.Errorf("pvc %s: expected %+v, got %+v", v.claim.Name, expected, v)
+				}
+			}
+		})
+	}
+}
+
+func TestRecreatedClaim(t *testing.T) {
+	apiGroup := snapshotv1.GroupName
+	dataSource := &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: "vc-move-data"}
+
+	for _, tc := range []struct {
+		name            string
+		request         string
+		capacity        string
+		dataSource      *corev1.TypedLocalObjectReference
+		expectedRequest string
+	}{
+		{name: "restored from snapshot", request: "1Gi", capacity: "1Gi", dataSource: dataSource, expectedRequest: "1Gi"},
+		{name: "capacity larger than request", request: "1Gi", capacity: "2Gi", dataSource: dataSource, expectedRequest: "2Gi"},
+		{name: "capacity smaller than request", request: "2Gi", capacity: "1Gi", expectedRequest: "2Gi"},
+		{name: "no request", capacity: "1Gi", expectedRequest: "1Gi"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			claim := tenantClaim("data", "pv-data", map[string]string{
+				"pv.kubernetes.io/bind-completed": "yes",
+				annotationMoveSnapshotHandle:      "snap-1",
+				annotationMoveSnapshotDriver:      "csi.example.com",
+				annotationMoveSnapshotContent:     "snapcontent-1",
+				"team":                            "web",
+			})
+			claim.Labels = map[string]string{"app": "web"}
+			if tc.request != "" {
+				claim.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(tc.request)}
+			}
+			claim.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(tc.capacity)}
+
+			recreated := recreatedClaim(claim, tc.dataSource, testTargetID)
+			if recreated.Spec.VolumeName != "" {
+				t.Errorf("expected the recreated claim to be unbound, got volume %s", recreated.Spec.VolumeName)
+			}
+			if recreated.Spec.DataSource != tc.dataSource {
+				t.Errorf("expected data source %v, got %v", tc.dataSource, recreated.Spec.DataSource)
+			}
+			if got := recreated.Spec.Resources.Requests[corev1.ResourceStorage]; got.Cmp(resource.MustParse(tc.expectedRequest)) != 0 {
+				t.Errorf("expected storage request %s, got %s", tc.expectedRequest, got.String())
+			}
+			expectedAnnotations := map[string]string{"team": "web", annotationMoveTarget: testTargetID}
+			if len(recreated.Annotations) != len(expectedAnnotations) {
+				t.Errorf("expected annotations %v, got %v", expectedAnnotations, recreated.Annotations)
+			}
+			for k, v := range expectedAnnotations {
+				if recreated.Annotations[k] != v {
+					t.Errorf("expected annotation %s=%s, got %q", k, v, recreated.Annotations[k])
+				}
+			}
+			if recreated.Labels["app"] != "web" {
+				t.Errorf("expected the labels to be kept, got %v", recreated.Labels)
+			}
+			if claim.Spec.VolumeName != "pv-data" || claim.Annotations[annotationMoveSnapshotHandle] == "" {
+				t.Errorf("expected the original claim not to be changed")
+			}
+		})
+	}
+}
+
+func TestMoveSnapshotName(t *testing.T) {
+	long := strings.Repeat("a", 250)
+	for _, tc := range []struct {
+		name     string
+		claim    string
+		expected string
+	}{
+		{name: "short claim", claim: "data", expected: "vc-move-data"},
+		{name: "longest claim kept", claim: strings.Repeat("a", 245), expected: "vc-move-" + strings.Repeat("a", 245)},
+		{name: "long claim", claim: long},
+		{name: "long claim sharing the prefix", claim: long + "b"},
+		{name: "long claim truncated at a dot", claim: strings.Repeat("a", 235) + "." + strings.Repeat("b", 17)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := moveSnapshotName(tc.claim)
+			if tc.expected != "" && got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+			if errs := validation.IsDNS1123Subdomain(got); len(errs) > 0 {
+				t.Errorf("invalid snapshot name %s: %v", got, errs)
+			}
+		})
+	}
+
+	if moveSnapshotName(long) == moveSnapshotName(long+"b") {
+		t.Errorf("expected long claims sharing a prefix to get different snapshot names")
+	}
+	if moveSnapshotName(long) != moveSnapshotName(long) {
+		t.Errorf("expected the snapshot name to be stable")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+const (
+	pauseExample = `
+	# Pause the reconciliation of a virtualcluster
+	kubectl vc pause -n foo bar
+
+	# Pause all virtualclusters labeled with env=test in every namespace
+	kubectl vc pause -A -l env=test`
+
+	resumeExample = `
+	# Resume the reconciliation of a virtualcluster
+	kubectl vc resume -n foo bar
+
+	# Resume all virtualclusters in namespace foo
+	kubectl vc resume -n foo --all`
+)
+
+type PauseOptions struct {
+	TargetOptions
+	vcclient vcclient.Interface
+	args     []string
+	paused   bool
+}
+
+func NewCmdPause(f Factory) *cobra.Command {
+	return newCmdPause(f, true, "pause [VC_NAME...]", "Stop vc-manager from reconciling VirtualClusters", pauseExample)
+}
+
+func NewCmdResume(f Factory) *cobra.Command {
+	return newCmdPause(f, false, "resume [VC_NAME...]", "Resume the reconciliation of paused VirtualClusters", resumeExample)
+}
+
+func newCmdPause(f Factory, paused bool, use, short, example string) *cobra.Command {
+	o := &PauseOptions{paused: paused}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   short,
+		Example: example,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, args))
+			CheckErr(o.Validate(cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	o.TargetOptions.AddFlags(cmd)
+
+	return cmd
+}
+
+func (o *PauseOptions) Complete(f Factory, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	o.args = args
+	return nil
+}
+
+func (o *PauseOptions) Run() error {
+	vcs, err := o.Targets(o.vcclient, o.args)
+	if err != nil {
+		return err
+	}
+
+	// a null value removes the label in a merge patch
+	var value interface{}
+	msg := "resumed"
+	if o.paused {
+		value, msg = "true", "paused"
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				constants.LabelVCPaused: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	results := runBulk(vcs, o.concurrency, func(vc *tenancyv1alpha1.VirtualCluster) (string, error) {
+		if _, err := o.vcclient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Patch(vc.Name, types.MergePatchType, patch); err != nil {
+			return "", err
+		}
+		return msg, nil
+	})
+	return printSummary(os.Stdout, results)
+}
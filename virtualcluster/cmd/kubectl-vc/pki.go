@@ -0,0 +1,340 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/kubeconfig"
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki/inspect"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+const (
+	pkiExample = `
+	# List the certificates of virtualcluster foo/bar with their SANs and expiry
+	kubectl vc pki status -n foo bar
+
+	# Fail if a certificate of virtualcluster foo/bar expires within 30 days
+	kubectl vc pki status foo/bar --expiry-warning 720h
+
+	# Mint a kubeconfig of user alice in the read-only group viewers of virtualcluster foo/bar, valid for 8 hours
+	kubectl vc pki kubeconfig foo/bar --user alice --group viewers --ttl 8h > alice.kubeconfig`
+)
+
+func NewCmdPKI(f Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "pki",
+		Short:   "Inspect the PKI generated for VirtualClusters and mint kubeconfigs signed by it",
+		Example: pkiExample,
+		RunE:    runHelp,
+	}
+
+	cmd.AddCommand(newCmdPKIStatus(f))
+	cmd.AddCommand(newCmdPKIKubeconfig(f))
+
+	return cmd
+}
+
+type PKIStatusOptions struct {
+	vcclient      vcclient.Interface
+	client        kubernetes.Interface
+	namespace     string
+	name          string
+	output        string
+	expiryWarning time.Duration
+}
+
+func newCmdPKIStatus(f Factory) *cobra.Command {
+	o := &PKIStatusOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "status VC_NAME",
+		Short: "List the certificates of a VirtualCluster and validate them against its root CA",
+		Long: `List the certificates of a VirtualCluster and validate them against its root CA.
+
+The certificates are read from the PKI secrets in the root namespace of the VirtualCluster,
+including the ones embedded in the kubeconfig secrets. The private keys are never printed.
+
+Exit status: 0 all certificates are valid, 1 a certificate can't be verified against the root CA
+or expires within --expiry-warning.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Output format, one of: (empty), wide, yaml")
+	cmd.Flags().DurationVar(&o.expiryWarning, "expiry-warning", 0, "If positive, the certificates expiring within this duration are reported as failures")
+
+	return cmd
+}
+
+func (o *PKIStatusOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	switch o.output {
+	case "", "wide", "yaml":
+	default:
+		return UsageErrorf(cmd, "unsupported output format %q", o.output)
+	}
+
+	o.name = args[0]
+	if strings.Contains(o.name, "/") {
+		namespacedName := strings.SplitN(o.name, "/", 2)
+		o.namespace = namespacedName[0]
+		o.name = namespacedName[1]
+	}
+
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	o.client, err = f.KubernetesClientSet()
+	return err
+}
+
+func (o *PKIStatusOptions) Run(w io.Writer) error {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	certs, err := inspect.InspectNamespace(context.TODO(), o.client, conversion.ToRootNamespace(vc), now)
+	if err != nil {
+		return err
+	}
+
+	if err := o.print(w, certs, now); err != nil {
+		return err
+	}
+
+	var failed int
+	for i := range certs {
+		if !certs[i].Valid() || (o.expiryWarning > 0 && certs[i].ExpiresWithin(now, o.expiryWarning)) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d certificates of virtualcluster %s/%s are invalid or expiring", failed, len(certs), o.namespace, o.name)
+	}
+	return nil
+}
+
+func (o *PKIStatusOptions) print(w io.Writer, certs []inspect.Certificate, now time.Time) error {
+	if o.output == "yaml" {
+		content, err := yaml.Marshal(certs)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if o.output == "wide" {
+		fmt.Fprintln(tw, "SECRET\tKEY\tSUBJECT\tKEY TYPE\tEXPIRES\tSTATUS\tSANS\tISSUER")
+	} else {
+		fmt.Fprintln(tw, "SECRET\tKEY\tSUBJECT\tKEY TYPE\tEXPIRES\tSTATUS")
+	}
+	for i := range certs {
+		c := &certs[i]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s", c.Secret, c.Key, c.Subject, c.KeyType, expiry(c, now), o.status(c, now))
+		if o.output == "wide" {
+			fmt.Fprintf(tw, "\t%s\t%s", sans(c), c.Issuer)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func (o *PKIStatusOptions) status(c *inspect.Certificate, now time.Time) string {
+	switch {
+	case !c.Valid():
+		return "Invalid: " + c.Error
+	case o.expiryWarning > 0 && c.ExpiresWithin(now, o.expiryWarning):
+		return "Expiring"
+	default:
+		return "Valid"
+	}
+}
+
+func expiry(c *inspect.Certificate, now time.Time) string {
+	if !now.Before(c.NotAfter) {
+		return fmt.Sprintf("expired %s ago", duration.HumanDuration(now.Sub(c.NotAfter)))
+	}
+	return "in " + duration.HumanDuration(c.NotAfter.Sub(now))
+}
+
+func sans(c *inspect.Certificate) string {
+	names := append([]string{}, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) == 0 {
+		return "<none>"
+	}
+	return strings.Join(names, ",")
+}
+
+type PKIKubeconfigOptions struct {
+	vcclient  vcclient.Interface
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	user      string
+	groups    []string
+	ttl       time.Duration
+	server    string
+}
+
+func newCmdPKIKubeconfig(f Factory) *cobra.Command {
+	o := &PKIKubeconfigOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "kubeconfig VC_NAME",
+		Short: "Print a kubeconfig of a user of a VirtualCluster signed by its root CA",
+		Long: `Print a kubeconfig of a user of a VirtualCluster signed by its root CA.
+
+The client certificate authenticates --user, member of the --group groups, until --ttl expires. The
+permissions of the user are the ones granted by the RBAC of the tenant cluster, e.g. bind the group
+to the view cluster role for a read-only access. The certificate can't be revoked before it expires,
+keep the ttl short.
+
+The server is the one of the admin kubeconfig of the VirtualCluster unless --server is set.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVar(&o.user, "user", "", "The user name, i.e. the common name of the client certificate")
+	cmd.Flags().StringSliceVar(&o.groups, "group", nil, "The groups of the user, i.e. the organizations of the client certificate")
+	cmd.Flags().DurationVar(&o.ttl, "ttl", 24*time.Hour, "The validity of the client certificate")
+	cmd.Flags().StringVar(&o.server, "server", "", "The url of the tenant apiserver, the one of the admin kubeconfig if empty")
+
+	return cmd
+}
+
+func (o *PKIKubeconfigOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	if o.user == "" {
+		return UsageErrorf(cmd, "--user should not be empty")
+	}
+	if o.ttl <= 0 {
+		return UsageErrorf(cmd, "--ttl should be positive")
+	}
+
+	o.name = args[0]
+	if strings.Contains(o.name, "/") {
+		namespacedName := strings.SplitN(o.name, "/", 2)
+		o.namespace = namespacedName[0]
+		o.name = namespacedName[1]
+	}
+
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	o.client, err = f.KubernetesClientSet()
+	return err
+}
+
+func (o *PKIKubeconfigOptions) Run(w io.Writer) error {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	rootNS := conversion.ToRootNamespace(vc)
+	rootCASecret, err := o.client.CoreV1().Secrets(rootNS).Get(context.TODO(), secret.RootCASecretName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the root ca of virtualcluster %s/%s: %v", o.namespace, o.name, err)
+	}
+	rootCACrt, err := pkiutil.DecodeCertPEM(rootCASecret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return err
+	}
+	rootCAKey, err := vcpki.DecodePrivateKeyPEM(rootCASecret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return err
+	}
+
+	server := o.server
+	if server == "" {
+		server, err = o.adminServer(vc)
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := kubeconfig.GenerateClientKubeconfig(o.name, server, &vcpki.ClientCertConfig{
+		CommonName: o.user,
+		Groups:     o.groups,
+		Validity:   o.ttl,
+	}, &vcpki.CrtKeyPair{Crt: rootCACrt, Key: rootCAKey})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, content)
+	return err
+}
+
+// adminServer returns the server of the current context of the admin kubeconfig of vc.
+func (o *PKIKubeconfigOptions) adminServer(vc *v1alpha1.VirtualCluster) (string, error) {
+	adminKubeconfig, err := conversion.GetKubeConfigOfVC(o.client.CoreV1(), vc)
+	if err != nil {
+		return "", err
+	}
+	config, err := clientcmd.Load(adminKubeconfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the admin kubeconfig of virtualcluster %s/%s: %v", o.namespace, o.name, err)
+	}
+	if current, ok := config.Contexts[config.CurrentContext]; ok {
+		if cluster, ok := config.Clusters[current.Cluster]; ok && cluster.Server != "" {
+			return cluster.Server, nil
+		}
+	}
+	return "", fmt.Errorf("no server found in the admin kubeconfig of virtualcluster %s/%s, set --server", o.namespace, o.name)
+}
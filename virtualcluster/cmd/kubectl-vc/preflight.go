@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/preflight"
+)
+
+const (
+	preflightExample = `
+	# Validate the current cluster as both the meta and the super cluster
+	kubectl vc preflight
+
+	# Validate the meta cluster of the current context and two super clusters
+	kubectl vc preflight --super-context super-1 --super-context super-2
+
+	# Skip the reachability of the tenant apiservers
+	kubectl vc preflight --skip tenant-network`
+)
+
+type PreflightOptions struct {
+	vcclient      vcclient.Interface
+	client        kubernetes.Interface
+	superContexts []string
+	skip          []string
+	output        string
+	managerSA     string
+	syncerSA      string
+	syncerPlugins []string
+	opts          preflight.Options
+}
+
+func NewCmdPreflight(f Factory) *cobra.Command {
+	o := &PreflightOptions{}
+	defaults := preflight.DefaultOptions()
+
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Validate the meta and super clusters before installing or upgrading VirtualCluster",
+		Long: `Validate the meta and super clusters before installing or upgrading VirtualCluster.
+
+The meta cluster is the cluster of the current context, its APIs, the permissions of the vc-manager,
+the storage classes of the etcd volumes of the ClusterVersions and the reachability of the tenant
+apiservers from this machine are checked. The APIs and the permissions of the syncer are checked in
+every --super-context, or in the meta cluster if there is none.
+
+Exit status: 0 no check failed, 1 a check failed. Warnings don't fail the preflight.`,
+		Example: preflightExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&o.superContexts, "super-context", nil, "The kubeconfig context of a super cluster, can be repeated")
+	cmd.Flags().StringSliceVar(&o.skip, "skip", nil, fmt.Sprintf("The checks to skip, of %s, %s, %s, %s",
+		preflight.CheckAPIVersions, preflight.CheckRBAC, preflight.CheckStorageClasses, preflight.CheckTenantNetwork))
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Output format, one of: (empty), yaml")
+	cmd.Flags().StringVar(&o.managerSA, "manager-service-account", defaults.ManagerServiceAccount.String(), "The namespace/name of the service account of the vc-manager")
+	cmd.Flags().StringVar(&o.syncerSA, "syncer-service-account", defaults.SyncerServiceAccount.String(), "The namespace/name of the service account of the syncer")
+	cmd.Flags().StringSliceVar(&o.syncerPlugins, "syncer-plugins", defaults.SyncerPlugins, "The resource plugins enabled in the syncer, their permissions are checked")
+	cmd.Flags().DurationVar(&o.opts.DialTimeout, "dial-timeout", defaults.DialTimeout, "The timeout of a connection to a tenant apiserver")
+
+	return cmd
+}
+
+func (o *PreflightOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return UsageErrorf(cmd, "preflight takes no arguments")
+	}
+	switch o.output {
+	case "", "yaml":
+	default:
+		return UsageErrorf(cmd, "unsupported output format %q", o.output)
+	}
+	for _, s := range o.skip {
+		switch s {
+		case preflight.CheckAPIVersions, preflight.CheckRBAC, preflight.CheckStorageClasses, preflight.CheckTenantNetwork:
+		default:
+			return UsageErrorf(cmd, "unknown check %q", s)
+		}
+	}
+
+	var err error
+	o.opts.Skip = o.skip
+	o.opts.SyncerPlugins = o.syncerPlugins
+	if o.opts.ManagerServiceAccount, err = parseServiceAccount(o.managerSA); err != nil {
+		return UsageErrorf(cmd, "invalid --manager-service-account: %v", err)
+	}
+	if o.opts.SyncerServiceAccount, err = parseServiceAccount(o.syncerSA); err != nil {
+		return UsageErrorf(cmd, "invalid --syncer-service-account: %v", err)
+	}
+
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	o.client, err = f.KubernetesClientSet()
+	return err
+}
+
+func (o *PreflightOptions) Run(w io.Writer) error {
+	ctx := context.TODO()
+	report := preflight.CheckMetaCluster(ctx, "meta", o.client, o.vcclient, o.opts)
+	if len(o.superContexts) == 0 {
+		report = append(report, preflight.CheckSuperCluster(ctx, "meta", o.client, o.opts)...)
+	}
+	for _, kubeconfigContext := range o.superContexts {
+		client, err := contextClientSet(kubeconfigContext)
+		if err != nil {
+			report = append(report, preflight.Result{
+				Cluster: kubeconfigContext,
+				Check:   preflight.CheckAPIVersions,
+				Status:  preflight.StatusFail,
+				Message: err.Error(),
+			})
+			continue
+		}
+		report = append(report, preflight.CheckSuperCluster(ctx, kubeconfigContext, client, o.opts)...)
+	}
+
+	if o.output == "yaml" {
+		content, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(content); err != nil {
+			return err
+		}
+	} else if err := report.Print(w); err != nil {
+		return err
+	}
+
+	if report.Failed() {
+		return fmt.Errorf("preflight failed")
+	}
+	return nil
+}
+
+func parseServiceAccount(s string) (types.NamespacedName, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, errors.Errorf("%q is not namespace/name", s)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// contextClientSet returns a clientset of the cluster of the kubeconfig context.
+func contextClientSet(kubeconfigContext string) (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: kubeconfigContext}).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load context %s", kubeconfigContext)
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/proxy"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/transport"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+const (
+	proxyExample = `
+	# Proxy the tenant apiserver of virtualcluster foo/bar on 127.0.0.1:8001 with the admin credentials
+	kubectl vc proxy foo/bar
+	kubectl --server http://127.0.0.1:8001 get namespaces
+
+	# Proxy it as user alice of group dev with a certificate valid for 30 minutes
+	kubectl vc proxy -n foo bar --user alice --group dev --cert-validity 30m`
+
+	proxyKeepAlive = 30 * time.Second
+)
+
+type ProxyOptions struct {
+	client       client.Client
+	vcclient     vcclient.Interface
+	namespace    string
+	name         string
+	address      string
+	port         int
+	user         string
+	groups       []string
+	certValidity time.Duration
+}
+
+func NewCmdProxy(f Factory) *cobra.Command {
+	o := &ProxyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "proxy VC_NAME",
+		Short: "Run a local proxy to the tenant apiserver of a VirtualCluster",
+		Long: `Run a local proxy to the tenant apiserver of a VirtualCluster.
+
+The proxy authenticates to the tenant apiserver with the admin credentials of the VirtualCluster,
+or with a client certificate of --user and --group signed by its root CA if --user is set. The
+credentials are kept in memory, no kubeconfig is written to disk. The Authorization header of the
+proxied requests is dropped, so the credentials of the current kubeconfig are not forwarded.
+
+Anyone able to connect to the proxy gets the access of its credentials, it only accepts requests
+addressed to a loopback host unless --address is not a loopback address.`,
+		Example: proxyExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVar(&o.address, "address", "127.0.0.1", "The IP address the proxy listens on")
+	cmd.Flags().IntVarP(&o.port, "port", "p", 8001, "The port the proxy listens on, 0 picks a random port")
+	cmd.Flags().StringVar(&o.user, "user", "", "If present, the user of the client certificate minted for the proxy instead of the admin credentials")
+	cmd.Flags().StringSliceVar(&o.groups, "group", nil, "The groups of the client certificate minted for --user")
+	cmd.Flags().DurationVar(&o.certValidity, "cert-validity", time.Hour, "The validity of the client certificate minted for --user")
+
+	return cmd
+}
+
+func (o *ProxyOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	if len(o.groups) > 0 && len(o.user) == 0 {
+		return UsageErrorf(cmd, "--group requires --user")
+	}
+	if o.certValidity <= 0 {
+		return UsageErrorf(cmd, "--cert-validity should be positive")
+	}
+	if net.ParseIP(o.address) == nil {
+		return UsageErrorf(cmd, "--address %q is not an IP address", o.address)
+	}
+
+	o.name = args[0]
+	if strings.Contains(o.name, "/") {
+		namespacedName := strings.SplitN(o.name, "/", 2)
+		o.namespace = namespacedName[0]
+		o.name = namespacedName[1]
+	}
+
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	o.client, err = f.GenericClient()
+	return err
+}
+
+func (o *ProxyOptions) Run() error {
+	config, err := o.tenantConfig()
+	if err != nil {
+		return err
+	}
+	handler, err := newTenantProxyHandler(config, net.ParseIP(o.address).IsLoopback())
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(o.address, strconv.Itoa(o.port)))
+	if err != nil {
+		return err
+	}
+	if !net.ParseIP(o.address).IsLoopback() {
+		log.Printf("WARNING: the proxy listens on %s, anyone able to connect to it gets its access to the tenant apiserver\n", o.address)
+	}
+	identity := "the admin credentials"
+	if o.user != "" {
+		identity = fmt.Sprintf("user %s valid for %s", o.user, o.certValidity)
+	}
+	log.Printf("Proxying VirtualCluster %s/%s as %s on %s, run kubectl --server http://%s\n",
+		o.namespace, o.name, identity, listener.Addr(), listener.Addr())
+	return (&http.Server{Handler: handler}).Serve(listener)
+}
+
+// tenantConfig returns the config of the tenant apiserver authenticating with the admin kubeconfig,
+// or with a client certificate minted for the user.
+func (o *ProxyOptions) tenantConfig() (*rest.Config, error) {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cluster version not found")
+	}
+	if svcType := cv.Spec.APIServer.Service.Spec.Type; svcType != corev1.ServiceTypeNodePort && svcType != corev1.ServiceTypeLoadBalancer {
+		return nil, fmt.Errorf("the tenant apiserver of a %s service can't be reached from outside the super cluster", svcType)
+	}
+
+	kbBytes, err := genKubeConfig(o.client, vc, cv)
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kbBytes)
+	if err != nil {
+		return nil, err
+	}
+	if o.user == "" {
+		return config, nil
+	}
+
+	rootCA := &corev1.Secret{}
+	if err := o.client.Get(context.TODO(), client.ObjectKey{Namespace: conversion.ToRootNamespace(vc), Name: secret.RootCASecretName}, rootCA); err != nil {
+		return nil, err
+	}
+	caCrt, err := pkiutil.DecodeCertPEM(rootCA.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, err
+	}
+	caKey, err := vcpki.DecodePrivateKeyPEM(rootCA.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, err
+	}
+	pair, err := vcpki.NewClientCrtAndKeyWithConfig(&vcpki.ClientCertConfig{
+		CommonName: o.user,
+		Groups:     o.groups,
+		Validity:   o.certValidity,
+	}, &vcpki.CrtKeyPair{Crt: caCrt, Key: caKey})
+	if err != nil {
+		return nil, err
+	}
+	config = rest.AnonymousClientConfig(config)
+	config.CertData = pkiutil.EncodeCertPEM(pair.Crt)
+	config.KeyData = vcpki.EncodePrivateKeyPEM(pair.Key)
+	return config, nil
+}
+
+// newTenantProxyHandler proxies the requests, including the upgraded exec, attach and port-forward
+// streams, to the tenant apiserver of config. Only the requests addressed to a loopback host are
+// accepted if loopbackOnly, to prevent DNS rebinding attacks from browsers.
+func newTenantProxyHandler(config *rest.Config, loopbackOnly bool) (http.Handler, error) {
+	// the handler redirects all the requests if the path of the target is empty
+	host := config.Host
+	if !strings.HasSuffix(host, "/") {
+		host += "/"
+	}
+	target, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	upgradeRT, err := upgradeTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	proxyHandler := proxy.NewUpgradeAwareHandler(target, rt, false, false, proxyErrorResponder{})
+	proxyHandler.UpgradeTransport = upgradeRT
+	proxyHandler.UseRequestLocation = true
+	proxyHandler.UseLocationHost = true
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if loopbackOnly && !isLoopbackHost(req.Host) {
+			http.Error(w, "only requests to a loopback host are accepted", http.StatusForbidden)
+			return
+		}
+		req.Header.Del("Authorization")
+		proxyHandler.ServeHTTP(w, req)
+	}), nil
+}
+
+// upgradeTransport returns the transport of the upgraded requests, the credentials of config are
+// added to the upgrade request while the connection is hijacked.
+func upgradeTransport(config *rest.Config) (proxy.UpgradeRequestRoundTripper, error) {
+	transportConfig, err := config.TransportConfig()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := transport.TLSConfigFor(transportConfig)
+	if err != nil {
+		return nil, err
+	}
+	rt := utilnet.SetOldTransportDefaults(&http.Transport{
+		TLSClientConfig: tlsConfig,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: proxyKeepAlive,
+		}).DialContext,
+	})
+	upgrader, err := transport.HTTPWrappersForConfig(transportConfig, proxy.MirrorRequest)
+	if err != nil {
+		return nil, err
+	}
+	return proxy.NewUpgradeRequestRoundTripper(rt, upgrader), nil
+}
+
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+type proxyErrorResponder struct{}
+
+func (proxyErrorResponder) Error(w http.ResponseWriter, req *http.Request, err error) {
+	log.Printf("failed to proxy %s %s: %v\n", req.Method, req.URL.Path, err)
+	http.Error(w, err.Error(), http.StatusBadGateway)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestTenantProxyHandler(t *testing.T) {
+	var gotAuthorization []string
+	tenant := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotAuthorization = append(gotAuthorization, req.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer tenant.Close()
+
+	for _, tc := range []struct {
+		name           string
+		host           string
+		loopbackOnly   bool
+		expectedStatus int
+	}{
+		{
+			name:           "loopback ip",
+			host:           "127.0.0.1:8001",
+			loopbackOnly:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "localhost",
+			host:           "localhost:8001",
+			loopbackOnly:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "loopback ipv6",
+			host:           "[::1]:8001",
+			loopbackOnly:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "rebound host",
+			host:           "attacker.example.com:8001",
+			loopbackOnly:   true,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "rebound host on a non loopback address",
+			host:           "attacker.example.com:8001",
+			expectedStatus: http.StatusOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gotAuthorization = nil
+			handler, err := newTenantProxyHandler(&rest.Config{Host: tenant.URL, BearerToken: "tenant-token"}, tc.loopbackOnly)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces", nil)
+			req.Host = tc.host
+			req.Header.Set("Authorization", "Bearer kubeconfig-token")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, w.Code, w.Body.String())
+			}
+			if tc.expectedStatus != http.StatusOK {
+				if len(gotAuthorization) != 0 {
+					t.Errorf("expected the request not to be proxied, got %v", gotAuthorization)
+				}
+				return
+			}
+			if len(gotAuthorization) != 1 || gotAuthorization[0] != "Bearer tenant-token" {
+				t.Errorf("expected the tenant apiserver to only get the proxy credentials, got %v", gotAuthorization)
+			}
+		})
+	}
+}
@@ -42,6 +42,18 @@ func main() {
 
 	rootCmd.AddCommand(NewCmdCreate(f))
 	rootCmd.AddCommand(NewCmdExec(f))
+	rootCmd.AddCommand(NewCmdProxy(f))
+	rootCmd.AddCommand(NewCmdDelete(f))
+	rootCmd.AddCommand(NewCmdUpgrade(f))
+	rootCmd.AddCommand(NewCmdPause(f))
+	rootCmd.AddCommand(NewCmdResume(f))
+	rootCmd.AddCommand(NewCmdDiagnose(f))
+	rootCmd.AddCommand(NewCmdExplainDrift(f))
+	rootCmd.AddCommand(NewCmdClusterVersion(f))
+	rootCmd.AddCommand(NewCmdPKI(f))
+	rootCmd.AddCommand(NewCmdMoveNamespace(f))
+	rootCmd.AddCommand(NewCmdPreflight(f))
+	rootCmd.AddCommand(NewCmdMonitoring())
 
 	CheckErr(rootCmd.Execute())
 }
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+const (
+	upgradeExample = `
+	# Upgrade a virtualcluster to the latest revision of its clusterversion
+	kubectl vc upgrade -n foo bar
+
+	# Switch all virtualclusters labeled with tier=gold to clusterversion cv-1-22
+	kubectl vc upgrade -A -l tier=gold --cluster-version cv-1-22`
+)
+
+type UpgradeOptions struct {
+	TargetOptions
+	vcclient           vcclient.Interface
+	args               []string
+	clusterVersionName string
+}
+
+func NewCmdUpgrade(f Factory) *cobra.Command {
+	o := &UpgradeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "upgrade [VC_NAME...]",
+		Short:   "Mark VirtualClusters ready for upgrade, requires the ClusterVersionPartialUpgrade feature of vc-manager",
+		Example: upgradeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, args))
+			CheckErr(o.Validate(cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	o.TargetOptions.AddFlags(cmd)
+	cmd.Flags().StringVar(&o.clusterVersionName, "cluster-version", "", "If present, the ClusterVersion the VirtualClusters are upgraded to")
+
+	return cmd
+}
+
+func (o *UpgradeOptions) Complete(f Factory, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	o.args = args
+	return nil
+}
+
+func (o *UpgradeOptions) Run() error {
+	vcs, err := o.Targets(o.vcclient, o.args)
+	if err != nil {
+		return err
+	}
+
+	patch, err := upgradePatch(o.clusterVersionName)
+	if err != nil {
+		return err
+	}
+
+	results := runBulk(vcs, o.concurrency, func(vc *tenancyv1alpha1.VirtualCluster) (string, error) {
+		if vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+			return "", fmt.Errorf("virtualcluster is %q, only running virtualclusters can be upgraded", vc.Status.Phase)
+		}
+		if _, err := o.vcclient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Patch(vc.Name, types.MergePatchType, patch); err != nil {
+			return "", err
+		}
+		if len(o.clusterVersionName) > 0 {
+			return fmt.Sprintf("upgrade to %s requested", o.clusterVersionName), nil
+		}
+		return fmt.Sprintf("upgrade to latest %s requested", vc.Spec.ClusterVersionName), nil
+	})
+	return printSummary(os.Stdout, results)
+}
+
+// upgradePatch builds the merge patch marking a VirtualCluster ready for upgrade,
+// optionally switching it to another ClusterVersion.
+func upgradePatch(clusterVersionName string) ([]byte, error) {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				constants.LabelVCReadyForUpgrade: "true",
+			},
+		},
+	}
+	if len(clusterVersionName) > 0 {
+		patch["spec"] = map[string]interface{}{
+			"clusterVersionName": clusterVersionName,
+		}
+	}
+	return json.Marshal(patch)
+}
@@ -17,12 +17,17 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
@@ -31,14 +36,22 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis"
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/webhook"
 
 	cliflag "k8s.io/component-base/cli/flag"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/etcdstorage"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/tenantcanary"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/tenantprobe"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/preflight"
 	logrutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/logr"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/scope"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/profiling"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/version"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/version/verflag"
 )
@@ -59,9 +72,25 @@ func main() {
 		disableStacktrace                 bool
 		enableWebhook                     bool
 		provisionerTimeout                time.Duration
+		gitOpsSecretFormat                string
+		gitOpsSecretNamespace             string
+		gitOpsClusterRole                 string
+		defaultSecurityProfile            string
+		extraArgsAllowList                string
+		tenantProbePeriod                 time.Duration
+		tenantCanary                      bool
+		tenantCanaryImage                 string
+		provisioningOutputs               bool
+		etcdStoragePeriod                 time.Duration
+		etcdDefragInterval                time.Duration
+		installPresets                    bool
+		watchNamespaces                   string
+		runPreflight                      bool
 
 		featureGates map[string]bool
 	)
+	profilingOpts := profiling.NewOptions("")
+	profilingOpts.AddGoFlags(flag.CommandLine)
 	flag.StringVar(&metricsAddr, "metrics-addr", ":0", "The address the metric endpoint binds to.")
 	flag.StringVar(&healthAddr, "health-addr", ":8080", "The address of the healthz/readyz endpoint binds to.")
 	flag.StringVar(&controlPlaneProvisionerDeprecated, "master-prov", "",
@@ -76,13 +105,41 @@ func main() {
 	flag.StringVar(&leaderElectionResourceLock, "leader-elect-resource-lock", "configmapsleases",
 		"The type of the resource that will be used as the resourcelock for leader election [configmaps, leases, configmapsleases]")
 	flag.IntVar(&maxConcurrentReconciles, "num-reconciles", 10,
-		"The max number reconcilers of virtualcluster controller")
+		"The max number of virtualclusters provisioned concurrently by the virtualcluster controller")
 	flag.StringVar(&logFile, "log-file", "", "The path of the logfile, if not set, only log to the stderr")
 	flag.BoolVar(&versionOpt, "version", false, "Print the version information")
 	flag.BoolVar(&disableStacktrace, "disable-stacktrace", false, "If set, the automatic stacktrace is disabled")
 	flag.BoolVar(&enableWebhook, "enable-webhook", false, "If set, the virtualcluster webhook is enabled")
-	flag.DurationVar(&provisionerTimeout, "provisioner-timeout", 10*time.Minute, "The timeout for provision control-plane statefulsets")
+	flag.DurationVar(&provisionerTimeout, "provisioner-timeout", 10*time.Minute,
+		"The timeout for every control-plane statefulset of a virtualcluster to be ready, every virtualcluster has its own deadlines")
+	flag.StringVar(&gitOpsSecretFormat, "gitops-secret-format", "",
+		"If set, a cluster secret of the given format (argocd or flux) is emitted for every running virtualcluster.")
+	flag.StringVar(&gitOpsSecretNamespace, "gitops-secret-namespace", "argocd", "The namespace the gitops cluster secrets are emitted in")
+	flag.StringVar(&gitOpsClusterRole, "gitops-cluster-role", "",
+		"The tenant ClusterRole bound to the service account whose token is put in the gitops cluster secrets, "+
+			"if empty a managed ClusterRole without the escalate, bind and impersonate verbs is bound")
 
+	flag.StringVar(&defaultSecurityProfile, "default-security-profile", string(tenancyv1alpha1.SecurityProfilePrivileged),
+		"The security profile of the control plane components of the ClusterVersions not setting one, Privileged or Restricted")
+	flag.StringVar(&extraArgsAllowList, "extra-args-allow-list", "",
+		"A comma separated list of component/flag patterns, e.g. apiserver/feature-gates,etcd/auto-compaction-*, replacing the default allow-list of the control plane extra args")
+	flag.DurationVar(&tenantProbePeriod, "tenant-probe-period", tenantprobe.DefaultPeriod,
+		"The interval between two probes of the version and readiness of the tenant apiservers, 0 disables the probes")
+	flag.BoolVar(&tenantCanary, "tenant-canary", false,
+		"If set, a canary pod is run through the tenant apiserver of every virtualcluster once it is running and the result is recorded in the TenantCanarySucceeded condition")
+	flag.StringVar(&tenantCanaryImage, "tenant-canary-image", tenantcanary.DefaultImage, "The image of the tenant canary pod")
+	flag.DurationVar(&etcdStoragePeriod, "etcd-storage-period", 0,
+		"The interval between two checks of the etcd database size of the native virtualclusters, which set the ETCDStoragePressure condition and run defrag jobs, 0 disables the checks")
+	flag.DurationVar(&etcdDefragInterval, "etcd-defrag-interval", etcdstorage.DefaultDefragInterval,
+		"The minimum interval between two defrag jobs of the etcd of a virtualcluster")
+	flag.BoolVar(&installPresets, "install-presets", false,
+		"If set, the built-in clusterversion presets (small-dev, ha-production, k3s-lite) that don't exist are created on startup")
+	flag.BoolVar(&provisioningOutputs, "provisioning-outputs", false,
+		"If set, the apiserver endpoint, CA and placement of every running virtualcluster are written to a <name>-outputs configmap next to it")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"A comma separated list of namespaces the VirtualClusters are watched in, all the namespaces are watched if not set")
+	flag.BoolVar(&runPreflight, "preflight", false,
+		"If set, the meta cluster is validated at startup and the manager exits if a check fails, see kubectl vc preflight")
 	flag.Var(cliflag.NewMapStringBool(&featureGates), "feature-gates", "A set of key=value pairs that describe featuregate gates for various features.")
 
 	flag.Parse()
@@ -100,6 +157,23 @@ func main() {
 	logf.SetLogger(loggr)
 	log := logf.Log.WithName("entrypoint")
 
+	profilingOpts.Apply()
+
+	switch tenancyv1alpha1.SecurityProfile(defaultSecurityProfile) {
+	case tenancyv1alpha1.SecurityProfilePrivileged, tenancyv1alpha1.SecurityProfileRestricted:
+	default:
+		log.Error(fmt.Errorf("unknown security profile %q", defaultSecurityProfile), "invalid --default-security-profile")
+		os.Exit(1)
+	}
+
+	if extraArgsAllowList != "" {
+		tenancyv1alpha1.ExtraArgsAllowList, err = tenancyv1alpha1.ParseExtraArgsAllowList(extraArgsAllowList)
+		if err != nil {
+			log.Error(err, "invalid --extra-args-allow-list")
+			os.Exit(1)
+		}
+	}
+
 	featuregate.DefaultFeatureGate, err = featuregate.NewFeatureGate(featureGates)
 	if err != nil {
 		log.Error(err, "unable to set up feature gates")
@@ -114,6 +188,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	if runPreflight {
+		log.Info("validating the meta cluster")
+		if err := checkMetaCluster(cfg, log); err != nil {
+			log.Error(err, "preflight failed, see kubectl vc preflight")
+			os.Exit(1)
+		}
+	}
+
 	// Create a new Cmd to provide shared dependencies and start components
 	log.Info("setting up manager")
 
@@ -130,6 +212,16 @@ func main() {
 		Port:                       constants.VirtualClusterWebhookPort,
 		HealthProbeBindAddress:     healthAddr,
 	}
+	if namespaces := scope.ParseNamespaces(watchNamespaces); len(namespaces) > 0 {
+		if featuregate.DefaultFeatureGate.Enabled(featuregate.ControlPlaneSecretChecksum) {
+			log.Error(fmt.Errorf("the control plane objects are not watched out of %v", namespaces),
+				"feature gate ControlPlaneSecretChecksum is not supported with --watch-namespaces")
+			os.Exit(1)
+		}
+		log.Info("watching the virtualclusters of namespaces", "namespaces", namespaces)
+		mgrOpt.NewCache = scope.NewCache(namespaces)
+		mgrOpt.NewClient = scope.NewClient(namespaces)
+	}
 	mgr, err := ctrl.NewManager(cfg, mgrOpt)
 	if err != nil {
 		log.Error(err, "unable to set up overall controller manager")
@@ -172,6 +264,17 @@ func main() {
 		ProvisionerName:         controlPlaneProvisioner,
 		ProvisionerTimeout:      provisionerTimeout,
 		MaxConcurrentReconciles: maxConcurrentReconciles,
+		GitOpsSecretFormat:      gitOpsSecretFormat,
+		GitOpsSecretNamespace:   gitOpsSecretNamespace,
+		GitOpsClusterRole:       gitOpsClusterRole,
+		DefaultSecurityProfile:  tenancyv1alpha1.SecurityProfile(defaultSecurityProfile),
+		TenantProbePeriod:       tenantProbePeriod,
+		ProvisioningOutputs:     provisioningOutputs,
+		TenantCanary:            tenantCanary,
+		TenantCanaryImage:       tenantCanaryImage,
+		ETCDStoragePeriod:       etcdStoragePeriod,
+		ETCDDefragInterval:      etcdDefragInterval,
+		InstallPresets:          installPresets,
 	}).SetupWithManager(mgr); err != nil {
 		log.Error(err, "unable to register controllers to the manager")
 		os.Exit(1)
@@ -192,3 +295,26 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// checkMetaCluster runs the preflight checks of the meta cluster with the identity of the manager
+// and logs their results, it returns an error if a check failed.
+func checkMetaCluster(cfg *rest.Config, log logr.Logger) error {
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	vcClient, err := vcclient.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	opts := preflight.DefaultOptions()
+	opts.ManagerServiceAccount = types.NamespacedName{}
+	report := preflight.CheckMetaCluster(context.TODO(), "meta", client, vcClient, opts)
+	for _, result := range report {
+		log.Info("preflight check", "check", result.Check, "status", result.Status, "message", result.Message)
+	}
+	if report.Failed() {
+		return fmt.Errorf("a preflight check of the meta cluster failed")
+	}
+	return nil
+}
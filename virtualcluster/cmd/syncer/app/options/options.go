@@ -43,12 +43,30 @@ import (
 	"k8s.io/klog/v2"
 
 	syncerappconfig "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/cmd/syncer/app/config"
-	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis"
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
 	syncerconfig "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/bootstrap"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/capability"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conflict"
+	syncerconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/finalizer"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/journal"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metalimit"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/reload"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/reporting"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resync"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/split"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/summary"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/cachefilter"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/profiling"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 )
 
 // ResourceSyncerOptions is the main context object for the resource syncer.
@@ -69,11 +87,20 @@ type ResourceSyncerOptions struct {
 	CertFile            string
 	KeyFile             string
 	DNSOptions          map[string]string
+	CacheTransforms     []string
+	CacheFieldSelectors []string
+	MetadataLimits      []string
+	TenantResourceSplit []string
+	Profiling           *profiling.Options
+	// PrintRBAC prints the super cluster RBAC needed by the enabled resource
+	// syncers and feature gates instead of running the syncer.
+	PrintRBAC bool
 }
 
 // NewResourceSyncerOptions creates a new resource syncer with a default config.
 func NewResourceSyncerOptions() (*ResourceSyncerOptions, error) {
 	return &ResourceSyncerOptions{
+		Profiling: profiling.NewOptions(":6060"),
 		ComponentConfig: syncerconfig.SyncerConfiguration{
 			LeaderElection: syncerconfig.SyncerLeaderElectionConfiguration{
 				LeaderElectionConfiguration: componentbaseconfig.LeaderElectionConfiguration{
@@ -85,16 +112,44 @@ func NewResourceSyncerOptions() (*ResourceSyncerOptions, error) {
 				},
 				LockObjectName: "syncer-leaderelection-lock",
 			},
-			ClientConnection:           componentbaseconfig.ClientConnectionConfiguration{},
-			Timeout:                    "",
-			DisableServiceAccountToken: true,
-			DefaultOpaqueMetaDomains:   []string{"kubernetes.io", "k8s.io"},
-			ExtraSyncingResources:      []string{},
-			ExtraNodeLabels:            []string{},
-			OpaqueTaintKeys:            []string{},
-			VNAgentPort:                int32(10550),
-			VNAgentNamespacedName:      "vc-manager/vn-agent",
-			VNAgentLabelSelector:       "app=vn-agent",
+			ClientConnection:              componentbaseconfig.ClientConnectionConfiguration{},
+			Timeout:                       "",
+			DisableServiceAccountToken:    true,
+			DefaultOpaqueMetaDomains:      []string{"kubernetes.io", "k8s.io"},
+			ExtraSyncingResources:         []string{},
+			ExtraNodeLabels:               []string{},
+			OpaqueTaintKeys:               []string{},
+			VNAgentPort:                   int32(10550),
+			VNAgentNamespacedName:         "vc-manager/vn-agent",
+			VNAgentLabelSelector:          "app=vn-agent",
+			MaxCachedAnnotationSize:       cachefilter.DefaultMaxAnnotationSize,
+			NodeLeaseDurationSeconds:      40,
+			ChangeJournalSize:             journal.DefaultSize,
+			FinalizerBlockTimeout:         metav1.Duration{Duration: finalizer.DefaultBlockTimeout},
+			ConflictSystemManagers:        conflict.DefaultSystemManagers,
+			TenantNodeUpdateQPS:           20,
+			TenantNodeUpdateBurst:         50,
+			TenantSuperRequestBurst:       100,
+			TenantServiceAccountNamespace: rbac.DefaultTenantServiceAccountNamespace,
+			TenantClusterRoleName:         rbac.DefaultTenantClusterRoleName,
+			TenantIdentity:                rbac.TenantIdentityServiceAccount,
+			UsageReportingInterval:        metav1.Duration{Duration: reporting.DefaultInterval},
+			UsageReportingSinks:           []string{reporting.PrometheusSinkName},
+			VirtualClusterSummaryInterval: metav1.Duration{Duration: summary.DefaultInterval},
+			CapabilityProbeInterval:       metav1.Duration{Duration: capability.DefaultInterval},
+			PatrolPeriod:                  metav1.Duration{Duration: patrol.DefaultPeriod},
+			ConfigReloadInterval:          metav1.Duration{Duration: reload.DefaultInterval},
+			DrainTimeout:                  metav1.Duration{Duration: shutdown.DefaultDrainTimeout},
+			BulkSyncingResources:          resync.DefaultBulkResources,
+			BulkSyncMaxDelay:              metav1.Duration{Duration: resync.DefaultMaxDelay},
+			NamespaceRetentionPeriod:      metav1.Duration{Duration: recyclebin.DefaultRetentionPeriod},
+			NamespaceTeardownTimeout:      metav1.Duration{Duration: syncerconstants.DefaultNamespaceTeardownTimeout},
+			TenantConnection: syncerconfig.TenantConnectionConfiguration{
+				DialTimeout:         metav1.Duration{Duration: cluster.DefaultDialTimeout},
+				DialKeepAlive:       metav1.Duration{Duration: cluster.DefaultDialKeepAlive},
+				MaxIdleConnsPerHost: cluster.DefaultMaxIdleConnsPerHost,
+				IdleConnTimeout:     metav1.Duration{Duration: cluster.DefaultIdleConnTimeout},
+			},
 			FeatureGates: map[string]bool{
 				featuregate.SuperClusterPooling:        false,
 				featuregate.SuperClusterServiceNetwork: false,
@@ -133,8 +188,70 @@ func (o *ResourceSyncerOptions) Flags() cliflag.NamedFlagSets {
 	fs.StringSliceVar(&o.ComponentConfig.OpaqueTaintKeys, "opaque-taint-keys", o.ComponentConfig.OpaqueTaintKeys, "OpaqueTaintKeys defines taint keys that need to be synced for each Virtual Cluster")
 	fs.Int32Var(&o.ComponentConfig.VNAgentPort, "vn-agent-port", 10550, "Port the vn-agent listens on")
 	fs.StringVar(&o.ComponentConfig.VNAgentNamespacedName, "vn-agent-namespace-name", "vc-manager/vn-agent", "Namespace/Name of the vn-agent running in cluster, used for VNodeProviderService")
+	fs.Int32Var(&o.ComponentConfig.NodeLeaseDurationSeconds, "node-lease-duration-seconds", o.ComponentConfig.NodeLeaseDurationSeconds, "Duration of the vNode leases renewed in tenant clusters, used for TenantNodeLease")
+	fs.Float32Var(&o.ComponentConfig.TenantNodeUpdateQPS, "tenant-node-update-qps", o.ComponentConfig.TenantNodeUpdateQPS, "Maximum vNode status and lease writes per second to each tenant cluster, 0 disables the limit")
+	fs.IntVar(&o.ComponentConfig.TenantNodeUpdateBurst, "tenant-node-update-burst", o.ComponentConfig.TenantNodeUpdateBurst, "Burst of vNode status and lease writes to each tenant cluster")
+	fs.Float32Var(&o.ComponentConfig.TenantSuperRequestQPS, "tenant-super-request-qps", o.ComponentConfig.TenantSuperRequestQPS, "Maximum super cluster requests per second into the namespaces of each tenant cluster, 0 disables the limit, used for TenantAPIAccounting")
+	fs.IntVar(&o.ComponentConfig.TenantSuperRequestBurst, "tenant-super-request-burst", o.ComponentConfig.TenantSuperRequestBurst, "Burst of the super cluster requests into the namespaces of each tenant cluster, used for TenantAPIAccounting")
+	fs.StringVar(&o.ComponentConfig.VirtualNodePoolLabel, "virtual-node-pool-label", o.ComponentConfig.VirtualNodePoolLabel, "Super cluster node label whose value groups nodes into pools, used for VirtualNodeAggregation")
 	fs.Var(cliflag.NewMapStringString(&o.DNSOptions), "dns-options", "DNSOptions is the default DNS options attached to each pod")
+	fs.StringSliceVar(&o.ComponentConfig.PodReadinessGates, "pod-readiness-gates", o.ComponentConfig.PodReadinessGates, "The pod condition types that can be injected as readiness gates into the super pods, a VirtualCluster opts into them with the tenancy.x-k8s.io/pod-readiness-gates annotation")
 	fs.StringVar(&o.ComponentConfig.VNAgentLabelSelector, "vn-agent-label-selector", "app=vn-agent", "Label key=value of the vn-agent running in cluster, used for VNodeProviderPodIP")
+	fs.StringVar(&o.ComponentConfig.AdmissionWebhookURL, "admission-webhook-url", o.ComponentConfig.AdmissionWebhookURL, "The base URL tenant apiservers use to reach the syncer server for admission, used for TenantPodAdmission")
+	fs.StringVar(&o.ComponentConfig.AdmissionWebhookCAFile, "admission-webhook-ca-file", o.ComponentConfig.AdmissionWebhookCAFile, "The CA bundle file tenant apiservers use to verify the syncer server, used for TenantPodAdmission")
+	fs.StringSliceVar(&o.CacheTransforms, "cache-transforms", o.CacheTransforms, "A list of resource=transform pairs applied to super cluster objects before caching, e.g. pods=strip-managed-fields. "+
+		"Transforms are: "+strings.Join(cachefilter.KnownTransforms(), ", "))
+	fs.StringArrayVar(&o.CacheFieldSelectors, "cache-field-selector", o.CacheFieldSelectors, "A resource=selector pair restricting the super cluster objects cached by the syncer, e.g. events=involvedObject.kind=Pod. "+
+		"Only select out objects the syncer never syncs, can be repeated for several resources")
+	fs.Var(cliflag.NewMapStringString(&o.ComponentConfig.ConflictPolicies), "conflict-policies", "A set of resource[/field.path]=policy pairs that decide which side wins when a field is changed in both tenant and super cluster, e.g. pods/metadata.labels=SuperWins. "+
+		"Policies are TenantWins (default) and SuperWins")
+	fs.StringSliceVar(&o.ComponentConfig.ConflictSystemManagers, "conflict-system-managers", o.ComponentConfig.ConflictSystemManagers, "The super cluster field managers whose changes are never sync conflicts, e.g. the control plane components populating the synced objects")
+	fs.Var(cliflag.NewMapStringString(&o.ComponentConfig.FinalizerPolicies), "finalizer-policies", "A set of resource=policy pairs that decide how the finalizers of tenant objects are translated to the super cluster objects, e.g. configmaps=Mirror. "+
+		"Policies are Strip (default), Mirror and BlockWithTimeout")
+	fs.DurationVar(&o.ComponentConfig.FinalizerBlockTimeout.Duration, "finalizer-block-timeout", o.ComponentConfig.FinalizerBlockTimeout.Duration, "The time the deletion of a super cluster object is blocked by mirrored tenant finalizers under the BlockWithTimeout finalizer policy")
+	fs.IntVar(&o.ComponentConfig.ChangeJournalSize, "change-journal-size", o.ComponentConfig.ChangeJournalSize, "The number of super cluster changes kept in the change journal, used for SyncChangeJournal")
+	fs.BoolVar(&o.ComponentConfig.ChangeJournalLogSink, "change-journal-log-sink", o.ComponentConfig.ChangeJournalLogSink, "If set, the change journal entries are also written to the log, used for SyncChangeJournal")
+	fs.StringSliceVar(&o.ComponentConfig.TenantNamespaceMetaAllowList, "tenant-namespace-meta-allow-list", o.ComponentConfig.TenantNamespaceMetaAllowList, "Key prefixes of the tenant namespace labels and annotations propagated to the super cluster namespaces, all keys are propagated if empty")
+	fs.StringSliceVar(&o.ComponentConfig.SuperNamespaceMetaAllowList, "super-namespace-meta-allow-list", o.ComponentConfig.SuperNamespaceMetaAllowList, "Key prefixes of the super cluster namespace labels and annotations propagated back to the tenant namespaces")
+	fs.StringSliceVar(&o.ComponentConfig.LoadBalancerAnnotationPrefixes, "load-balancer-annotation-prefixes", o.ComponentConfig.LoadBalancerAnnotationPrefixes, "Key prefixes of the annotations set by the super cluster load balancer controllers, propagated back to the tenant LoadBalancer services")
+	fs.Var(cliflag.NewMapStringString(&o.ComponentConfig.AnnotationMappings), "annotation-mappings", "A set of tenant-prefix=super-prefix pairs translating the annotations of the tenant services and ingresses synced to the super cluster, "+
+		"e.g. dns.example.com/=external-dns.alpha.kubernetes.io/, the annotations are dropped if super-prefix is empty")
+	fs.StringSliceVar(&o.ComponentConfig.GuardedAnnotationPrefixes, "guarded-annotation-prefixes", o.ComponentConfig.GuardedAnnotationPrefixes, "Annotation key prefixes of the tenant services and ingresses only synced to the super cluster through the annotation mappings")
+	fs.StringSliceVar(&o.ComponentConfig.AllowedExecCredentialCommands, "allowed-exec-credential-commands", o.ComponentConfig.AllowedExecCredentialCommands, "The exec credential plugin commands the tenant kubeconfigs are allowed to run, any command is allowed if empty")
+	fs.DurationVar(&o.ComponentConfig.TenantConnection.DialTimeout.Duration, "tenant-dial-timeout", o.ComponentConfig.TenantConnection.DialTimeout.Duration, "The timeout of dialing a tenant apiserver")
+	fs.DurationVar(&o.ComponentConfig.TenantConnection.DialKeepAlive.Duration, "tenant-dial-keep-alive", o.ComponentConfig.TenantConnection.DialKeepAlive.Duration, "The keep-alive period of the tenant apiserver connections")
+	fs.IntVar(&o.ComponentConfig.TenantConnection.MaxIdleConnsPerHost, "tenant-max-idle-conns-per-host", o.ComponentConfig.TenantConnection.MaxIdleConnsPerHost, "The number of idle connections kept per tenant apiserver")
+	fs.DurationVar(&o.ComponentConfig.TenantConnection.IdleConnTimeout.Duration, "tenant-idle-conn-timeout", o.ComponentConfig.TenantConnection.IdleConnTimeout.Duration, "The time an idle tenant apiserver connection is kept before being closed")
+	fs.StringVar(&o.ComponentConfig.TenantServiceAccountNamespace, "tenant-service-account-namespace", o.ComponentConfig.TenantServiceAccountNamespace, "The super cluster namespace of the per-tenant service accounts the syncer impersonates, used for TenantImpersonation")
+	fs.StringVar(&o.ComponentConfig.TenantClusterRoleName, "tenant-cluster-role", o.ComponentConfig.TenantClusterRoleName, "The super cluster ClusterRole bound to the per-tenant service accounts in their namespaces, used for TenantImpersonation")
+	fs.StringSliceVar(&o.ComponentConfig.TenantIdentityClusters, "tenant-identity-clusters", o.ComponentConfig.TenantIdentityClusters, "The tenant clusters, i.e. the ClusterNamespace of their VirtualClusters, whose users the RBAC printed by --print-rbac lets the syncer impersonate with --tenant-identity=User")
+	fs.StringVar(&o.ComponentConfig.TenantIdentity, "tenant-identity", o.ComponentConfig.TenantIdentity, "The super cluster identity impersonated for a tenant, ServiceAccount for a service account per tenant or User for the system:vc:<cluster> user, used for TenantImpersonation")
+	fs.DurationVar(&o.ComponentConfig.UsageReportingInterval.Duration, "usage-reporting-interval", o.ComponentConfig.UsageReportingInterval.Duration, "The interval between two usage reports of the virtual clusters, used for UsageReporting")
+	fs.StringSliceVar(&o.ComponentConfig.UsageReportingSinks, "usage-reporting-sinks", o.ComponentConfig.UsageReportingSinks, "The sinks the usage reports are written to, one or more of prometheus, csv=<file path> and webhook=<url>, used for UsageReporting")
+	fs.DurationVar(&o.ComponentConfig.VirtualClusterSummaryInterval.Duration, "virtual-cluster-summary-interval", o.ComponentConfig.VirtualClusterSummaryInterval.Duration, "The interval between two updates of the virtual cluster summaries, used for VirtualClusterSummary")
+	fs.DurationVar(&o.ComponentConfig.CapabilityProbeInterval.Duration, "capability-probe-interval", o.ComponentConfig.CapabilityProbeInterval.Duration, "The interval between two probes of the super cluster capabilities, used for SuperClusterCapabilities")
+	fs.DurationVar(&o.ComponentConfig.PatrolPeriod.Duration, "patrol-period", o.ComponentConfig.PatrolPeriod.Duration, "The period of the patrollers comparing the tenant and super cluster objects")
+	fs.IntVar(&o.ComponentConfig.PatrolRemediationBudget, "patrol-remediation-budget", o.ComponentConfig.PatrolRemediationBudget, "The number of remediation actions a patrol pass may take per tenant cluster and resource before it stops remediating the tenant cluster, unlimited if 0")
+	fs.StringVar(&o.ComponentConfig.ConfigReloadFile, "config-reload-file", o.ComponentConfig.ConfigReloadFile, "A YAML file, usually mounted from a ConfigMap, overriding the reloadable settings while the syncer is running: "+
+		"featureGates ("+strings.Join(reloadableFeatureNames(), ", ")+"), tenantNodeUpdateQPS, tenantNodeUpdateBurst and patrolPeriod")
+	fs.DurationVar(&o.ComponentConfig.ConfigReloadInterval.Duration, "config-reload-interval", o.ComponentConfig.ConfigReloadInterval.Duration, "The interval between two reads of the config reload file")
+	fs.StringSliceVar(&o.ComponentConfig.BulkSyncingResources, "bulk-syncing-resources", o.ComponentConfig.BulkSyncingResources, "The resources synced after the other resources during the initial sync, used for ResyncPriority")
+	fs.DurationVar(&o.ComponentConfig.BulkSyncMaxDelay.Duration, "bulk-sync-max-delay", o.ComponentConfig.BulkSyncMaxDelay.Duration, "The maximum time the bulk resources are held back during the initial sync, used for ResyncPriority")
+	fs.DurationVar(&o.ComponentConfig.DrainTimeout.Duration, "drain-timeout", o.ComponentConfig.DrainTimeout.Duration, "The time the resource syncers are given to drain their queues on shutdown before the leader lease is released")
+	fs.StringVar(&o.ComponentConfig.OwnershipKeyFile, "ownership-key-file", o.ComponentConfig.OwnershipKeyFile, "The file holding the HMAC keys, one per line, signing the ownership annotations of the super cluster objects, the first key signs and all the keys verify, used for OwnershipSignature")
+	fs.BoolVar(&o.ComponentConfig.RequireOwnershipSignature, "require-ownership-signature", o.ComponentConfig.RequireOwnershipSignature, "Reject the deletion of super cluster objects without an ownership signature before the existing objects are signed, used for OwnershipSignature")
+	fs.BoolVar(&o.ComponentConfig.BackfillOwnershipSignatures, "backfill-ownership-signatures", o.ComponentConfig.BackfillOwnershipSignatures, "Sign the super cluster objects synced before OwnershipSignature was enabled, once, ignored with --require-ownership-signature")
+	fs.StringSliceVar(&o.TenantResourceSplit, "tenant-resource-split", o.TenantResourceSplit, "A list of vc-namespace/vc-name=resource pairs assigning the resources of large virtual clusters to this syncer instance, e.g. default/huge=pod, "+
+		"or excluding them from this instance when the resource is prefixed with -, e.g. default/huge=-pod. The resources of the other virtual clusters are all synced")
+	fs.BoolVar(&o.ComponentConfig.SyncSplitTenantsOnly, "sync-split-tenants-only", o.ComponentConfig.SyncSplitTenantsOnly, "Only sync the virtual clusters of the tenant resource split")
+	fs.DurationVar(&o.ComponentConfig.NamespaceRetentionPeriod.Duration, "namespace-retention-period", o.ComponentConfig.NamespaceRetentionPeriod.Duration, "The time the super cluster namespace of a deleted tenant namespace is retained before being deleted, used for NamespaceRecycleBin")
+	fs.DurationVar(&o.ComponentConfig.NamespaceTeardownTimeout.Duration, "namespace-teardown-timeout", o.ComponentConfig.NamespaceTeardownTimeout.Duration, "The time the deletion of the super cluster namespace of a deleted tenant namespace waits for its pods, services and claims to be gone, used for NamespaceDeletionBarrier")
+	fs.DurationVar(&o.ComponentConfig.MaxSyncFreezeDuration.Duration, "max-sync-freeze-duration", o.ComponentConfig.MaxSyncFreezeDuration.Duration, "The longest time a tenant can freeze the downward sync of a namespace for, tenant freezes are not honored if it is 0")
+	fs.BoolVar(&o.PrintRBAC, "print-rbac", o.PrintRBAC, "Print the super cluster RBAC manifests needed by the enabled resources and feature gates, then exit")
+	fs.StringSliceVar(&o.MetadataLimits, "metadata-limits", o.MetadataLimits, "A list of resource:limit=value entries limiting the metadata of the tenant objects synced to the super cluster, e.g. *:annotations-size=65536,configmaps:labels=32. "+
+		"Limits are annotations-size, annotations and labels, the limits of * apply to all the resources")
+	fs.StringSliceVar(&o.ComponentConfig.BannedAnnotationPrefixes, "banned-annotation-prefixes", o.ComponentConfig.BannedAnnotationPrefixes, "Annotation key prefixes the tenant objects synced to the super cluster must not use")
+	fs.IntVar(&o.ComponentConfig.MaxCachedAnnotationSize, "max-cached-annotation-size", o.ComponentConfig.MaxCachedAnnotationSize, "Annotation values larger than this are dropped from the cache by the drop-large-annotations transform")
 
 	serverFlags := fss.FlagSet("metricsServer")
 	serverFlags.StringVar(&o.Address, "address", o.Address, "The server address.")
@@ -142,11 +259,21 @@ func (o *ResourceSyncerOptions) Flags() cliflag.NamedFlagSets {
 	serverFlags.StringVar(&o.CertFile, "cert-file", o.CertFile, "CertFile is the file containing x509 Certificate for HTTPS.")
 	serverFlags.StringVar(&o.KeyFile, "key-file", o.KeyFile, "KeyFile is the file containing x509 private key matching certFile.")
 
+	o.Profiling.AddFlags(fss.FlagSet("profiling"))
+
 	BindFlags(&o.ComponentConfig.LeaderElection, fss.FlagSet("leader election"))
 
 	return fss
 }
 
+func reloadableFeatureNames() []string {
+	var names []string
+	for _, f := range featuregate.ReloadableFeatures() {
+		names = append(names, string(f))
+	}
+	return names
+}
+
 // BindFlags binds the LeaderElectionConfiguration struct fields to a flagset
 func BindFlags(l *syncerconfig.SyncerLeaderElectionConfiguration, fs *pflag.FlagSet) {
 	fs.BoolVar(&l.LeaderElect, "leader-elect", l.LeaderElect, ""+
@@ -169,6 +296,10 @@ func BindFlags(l *syncerconfig.SyncerLeaderElectionConfiguration, fs *pflag.Flag
 	fs.StringVar(&l.ResourceLock, "leader-elect-resource-lock", l.ResourceLock, ""+
 		"The type of resource object that is used for locking during "+
 		"leader election. Supported options are `endpoints` and `configmaps` (default).")
+	fs.BoolVar(&l.WarmStandby, "leader-elect-warm-standby", l.WarmStandby, ""+
+		"Start the tenant cluster caches in the replicas waiting for the leadership, "+
+		"without syncing, so that a new leader does not list the tenant clusters again. "+
+		"This is only applicable if leader election is enabled.")
 	fs.StringVar(&l.LockObjectNamespace, "lock-object-namespace", l.LockObjectNamespace, "DEPRECATED: define the namespace of the lock object.")
 	fs.StringVar(&l.LockObjectName, "lock-object-name", l.LockObjectName, "DEPRECATED: define the name of the lock object.")
 }
@@ -239,13 +370,8 @@ func (o *ResourceSyncerOptions) Config() (*syncerappconfig.Config, error) {
 		}
 	}
 
-	featuregate.DefaultFeatureGate, err = featuregate.NewFeatureGate(c.ComponentConfig.FeatureGates)
-	if err != nil {
-		return nil, err
-	}
-
-	// Setup Scheme for all resources
-	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+	// Validate the configuration, set the feature gates and setup the scheme for all resources.
+	if err := bootstrap.Configure(&c.ComponentConfig); err != nil {
 		return nil, err
 	}
 	c.ComponentConfig.RestConfig = superRestConfig
@@ -255,6 +381,26 @@ func (o *ResourceSyncerOptions) Config() (*syncerappconfig.Config, error) {
 	c.MetaClusterClient = metaClusterClient
 	c.SuperClusterClient = superClusterClient
 	c.SuperClusterInformerFactory = informers.NewSharedInformerFactory(superClusterClient, 0)
+	c.ComponentConfig.CacheTransforms, err = cachefilter.ParseTransforms(o.CacheTransforms)
+	if err != nil {
+		return nil, err
+	}
+	c.ComponentConfig.CacheFieldSelectors, err = cachefilter.ParseFieldSelectors(o.CacheFieldSelectors)
+	if err != nil {
+		return nil, err
+	}
+	if err := cachefilter.RegisterInformers(c.SuperClusterInformerFactory, c.ComponentConfig.CacheTransforms,
+		c.ComponentConfig.CacheFieldSelectors, c.ComponentConfig.MaxCachedAnnotationSize); err != nil {
+		return nil, err
+	}
+	c.ComponentConfig.MetadataLimits, err = metalimit.Parse(o.MetadataLimits)
+	if err != nil {
+		return nil, err
+	}
+	c.ComponentConfig.TenantResourceSplit, err = split.Parse(o.TenantResourceSplit)
+	if err != nil {
+		return nil, err
+	}
 	c.Broadcaster = eventBroadcaster
 	c.Recorder = recorder
 	c.LeaderElectionClient = leaderElectionClient
@@ -306,6 +452,8 @@ func makeLeaderElectionConfig(config syncerconfig.SyncerLeaderElectionConfigurat
 		RetryPeriod:   config.RetryPeriod.Duration,
 		WatchDog:      leaderelection.NewLeaderHealthzAdaptor(time.Second * 20),
 		Name:          constants.ResourceSyncerUserAgent,
+		// the lease is released on graceful shutdown so that the next leader starts right away.
+		ReleaseOnCancel: true,
 	}, nil
 }
 
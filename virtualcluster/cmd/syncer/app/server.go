@@ -19,9 +19,9 @@ package app
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
-	_ "net/http/pprof" // enable pprof in the server
 	"os"
 
 	"github.com/spf13/cobra"
@@ -32,11 +32,16 @@ import (
 	"k8s.io/component-base/cli/globalflag"
 	"k8s.io/component-base/term"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
 
 	syncerconfig "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/cmd/syncer/app/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/cmd/syncer/app/options"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/flowcontrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	utilflag "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/flag"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/version/verflag"
 )
 
@@ -55,6 +60,13 @@ custom resources on behalf of the tenant users in super cluster.`,
 			var err error
 			var c *syncerconfig.Config
 			verflag.PrintAndExitIfRequested()
+			if s.PrintRBAC {
+				if err := printRBAC(cmd.OutOrStdout(), s); err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					os.Exit(1)
+				}
+				os.Exit(0)
+			}
 			utilflag.PrintFlags(cmd.Flags())
 
 			c, err = s.Config()
@@ -62,6 +74,7 @@ custom resources on behalf of the tenant users in super cluster.`,
 				fmt.Fprintf(os.Stderr, "%v\n", err)
 				os.Exit(1)
 			}
+			s.Profiling.Apply()
 
 			if err := Run(c.Complete(), stopChan); err != nil {
 				fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -118,25 +131,17 @@ func Run(cc *syncerconfig.CompletedConfig, stopCh <-chan struct{}) error {
 	// Wait for all caches to sync before resource sync.
 	cc.SuperClusterInformerFactory.WaitForCacheSync(stopCh)
 
-	ctx, cancel := context.WithCancel(context.TODO())
+	// The context is canceled after the resource syncers drained their queues, so that the
+	// leader lease is only released once the in-flight updates are done.
+	ctx, cancel := shutdown.LeaderContext(stopCh, func() {
+		klog.Infof("shutting down, draining the resource syncers")
+		ss.WaitForShutdown(shutdown.DrainTimeout)
+	})
 	defer cancel()
 
 	// Prepare a reusable runCommand function.
 	run := startSyncer(ss, stopCh)
 
-	go func() {
-		select {
-		case <-stopCh:
-			cancel()
-		case <-ctx.Done():
-		}
-	}()
-
-	go func() {
-		// start a pprof http server
-		klog.Fatal(http.ListenAndServe(":6060", nil))
-	}()
-
 	go func() {
 		// start a health http server.
 		mux := http.NewServeMux()
@@ -152,6 +157,10 @@ func Run(cc *syncerconfig.CompletedConfig, stopCh <-chan struct{}) error {
 		cc.LeaderElection.Callbacks = leaderelection.LeaderCallbacks{
 			OnStartedLeading: run,
 			OnStoppedLeading: func() {
+				if shutdown.Stopping(stopCh) {
+					klog.Infof("leader lease released")
+					return
+				}
 				klog.Fatalf("leaderelection lost")
 			},
 		}
@@ -159,17 +168,60 @@ func Run(cc *syncerconfig.CompletedConfig, stopCh <-chan struct{}) error {
 		if err != nil {
 			return fmt.Errorf("couldn't create leader elector: %v", err)
 		}
+		if cc.ComponentConfig.LeaderElection.WarmStandby {
+			ss.WarmUp(stopCh)
+		}
 
 		leaderElector.Run(ctx)
 
+		if shutdown.Stopping(stopCh) {
+			return nil
+		}
 		return fmt.Errorf("lost lease")
 	}
 
 	// Leader election is disabled, so runCommand inline until done.
 	run(ctx)
+	if shutdown.Stopping(stopCh) {
+		return nil
+	}
 	return fmt.Errorf("finished without leader elect")
 }
 
+// printRBAC writes the super cluster RBAC manifests for the enabled resource
+// syncers and feature gates, without connecting to any cluster. The API Priority
+// and Fairness manifests are appended when TenantFlowControl is enabled.
+func printRBAC(w io.Writer, o *options.ResourceSyncerOptions) error {
+	gate, err := featuregate.NewFeatureGate(o.ComponentConfig.FeatureGates)
+	if err != nil {
+		return err
+	}
+	var plugins []string
+	for _, p := range syncer.LoadPlugins(&o.ComponentConfig) {
+		if !rbac.KnownPlugin(p.ID) {
+			return fmt.Errorf("no rbac rules known for resource %q", p.ID)
+		}
+		plugins = append(plugins, p.ID)
+	}
+	objs := rbac.Manifests(rbac.DefaultClusterRoleName, &o.ComponentConfig, plugins, gate)
+	if gate.Enabled(featuregate.TenantFlowControl) {
+		objs = append(objs, flowcontrol.Manifests(&o.ComponentConfig)...)
+	}
+	for i, obj := range objs {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func startSyncer(s syncer.Bootstrap, stopCh <-chan struct{}) func(context.Context) {
 	return func(ctx context.Context) {
 		s.Run(stopCh)
@@ -20,11 +20,13 @@ import (
 	"crypto/tls"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 	cliflag "k8s.io/component-base/cli/flag"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/profiling"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/config"
 )
 
@@ -34,6 +36,8 @@ type Options struct {
 	ServerOption
 	// KubeletOption
 	KubeletOption KubeletClientConfig
+	// Profiling options
+	Profiling *profiling.Options
 }
 
 type ServerOption struct {
@@ -62,6 +66,13 @@ type ServerOption struct {
 
 	// FeatureGates enabled by the user.
 	FeatureGates map[string]bool
+
+	// StreamIdleTimeout is the maximum time a streaming connection can be idle before it is closed.
+	StreamIdleTimeout time.Duration
+
+	// TenantRequestQPS and TenantRequestBurst rate-limit the requests of every tenant.
+	TenantRequestQPS   float32
+	TenantRequestBurst int
 }
 
 // KubeletClientConfig is a subset of the full options exposed in k8s.io/kubernetes/pkg/kubelet/client.KubeletClientConfig
@@ -78,6 +89,7 @@ type KubeletClientConfig struct {
 func NewVnAgentOptions() (*Options, error) {
 	return &Options{
 		KubeletOption: KubeletClientConfig{},
+		Profiling:     profiling.NewOptions(""),
 		ServerOption: ServerOption{
 			FeatureGates: map[string]bool{},
 		},
@@ -97,6 +109,9 @@ func (o *Options) Flags() cliflag.NamedFlagSets {
 	serverFS.UintVar(&o.Port, "port", 10550, "Port is the server listening on")
 	serverFS.StringVar(&o.MetricsAddr, "metrics-addr", ":9100", "Bind address for the metrics server.")
 	serverFS.BoolVar(&o.EnableMetrics, "enable-metrics", true, "Enable metrics server.")
+	serverFS.DurationVar(&o.StreamIdleTimeout, "streaming-connection-idle-timeout", 4*time.Hour, "Maximum time an exec, attach or port-forward connection can be idle before it is closed, 0 means no timeout.")
+	serverFS.Float32Var(&o.TenantRequestQPS, "tenant-request-qps", 0, "Maximum requests per second of each tenant, the requests above the limit are rejected with 429, 0 disables the limit.")
+	serverFS.IntVar(&o.TenantRequestBurst, "tenant-request-burst", 10, "Burst of the requests of each tenant.")
 	serverFS.Var(cliflag.NewMapStringBool(&o.ServerOption.FeatureGates), "feature-gates", "A set of key=value pairs that describe featuregate gates for various features.")
 
 	kubeletFS := fss.FlagSet("kubelet")
@@ -104,6 +119,8 @@ func (o *Options) Flags() cliflag.NamedFlagSets {
 	kubeletFS.StringVar(&o.KubeletOption.KeyFile, "kubelet-client-key", o.KubeletOption.KeyFile, "Path to a client key file for TLS")
 	kubeletFS.UintVar(&o.KubeletOption.Port, "kubelet-port", 10250, "Kubelet security port")
 
+	o.Profiling.AddFlags(fss.FlagSet("profiling"))
+
 	return fss
 }
 
@@ -119,7 +136,12 @@ func fileNotExistOrEmpty(fn string) bool {
 func (o *Options) Config() (*config.Config, *ServerOption, error) {
 	// vc-kubelet-client may be a place holder that contains empty certificate and key data
 	if fileNotExistOrEmpty(o.KubeletOption.CertFile) || fileNotExistOrEmpty(o.KubeletOption.KeyFile) {
-		return &config.Config{KubeletClientCert: nil}, &o.ServerOption, nil
+		return &config.Config{
+			KubeletClientCert:  nil,
+			StreamIdleTimeout:  o.StreamIdleTimeout,
+			TenantRequestQPS:   o.TenantRequestQPS,
+			TenantRequestBurst: o.TenantRequestBurst,
+		}, &o.ServerOption, nil
 	}
 	kubeletClientCertPair, err := tls.LoadX509KeyPair(o.KubeletOption.CertFile, o.KubeletOption.KeyFile)
 	if err != nil {
@@ -132,7 +154,10 @@ func (o *Options) Config() (*config.Config, *ServerOption, error) {
 	}
 
 	return &config.Config{
-		KubeletClientCert: &kubeletClientCertPair,
-		KubeletServerHost: fmt.Sprintf("https://127.0.0.1:%v", o.KubeletOption.Port),
+		KubeletClientCert:  &kubeletClientCertPair,
+		KubeletServerHost:  fmt.Sprintf("https://127.0.0.1:%v", o.KubeletOption.Port),
+		StreamIdleTimeout:  o.StreamIdleTimeout,
+		TenantRequestQPS:   o.TenantRequestQPS,
+		TenantRequestBurst: o.TenantRequestBurst,
 	}, &o.ServerOption, nil
 }
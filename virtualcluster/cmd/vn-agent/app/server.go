@@ -60,6 +60,7 @@ func NewVnAgentCommand(stopChan <-chan struct{}) *cobra.Command {
 				fmt.Fprintf(os.Stderr, "%v\n", err)
 				os.Exit(1)
 			}
+			s.Profiling.Apply()
 
 			if err := Run(c, serverOptions, stopChan); err != nil {
 				fmt.Fprintf(os.Stderr, "%v\n", err)
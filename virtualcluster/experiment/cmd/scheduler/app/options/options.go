@@ -47,6 +47,8 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis"
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/profiling"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 )
 
 type SchedulerOptions struct {
@@ -55,11 +57,13 @@ type SchedulerOptions struct {
 
 	MetaCluster           string
 	MetaClusterKubeconfig string
+	Profiling             *profiling.Options
 }
 
 // NewSchedulerOptions creates new scheduler options with a default config.
 func NewSchedulerOptions() (*SchedulerOptions, error) {
 	return &SchedulerOptions{
+		Profiling: profiling.NewOptions(""),
 		ComponentConfig: schedulerconfig.SchedulerConfiguration{
 			LeaderElection: schedulerconfig.SchedulerLeaderElectionConfiguration{
 				LeaderElectionConfiguration: componentbaseconfig.LeaderElectionConfiguration{
@@ -71,7 +75,9 @@ func NewSchedulerOptions() (*SchedulerOptions, error) {
 				},
 				LockObjectName: "vc-scheduler-leaderelection-lock",
 			},
-			ClientConnection: componentbaseconfig.ClientConnectionConfiguration{},
+			ClientConnection:      componentbaseconfig.ClientConnectionConfiguration{},
+			DrainTimeout:          metav1.Duration{Duration: shutdown.DefaultDrainTimeout},
+			DescheduleChurnBudget: 10,
 		},
 	}, nil
 }
@@ -83,6 +89,14 @@ func (o *SchedulerOptions) Flags() cliflag.NamedFlagSets {
 	fs.StringVar(&o.MetaCluster, "meta-cluster", o.MetaCluster, "The address of the meta cluster Kubernetes APIServer (overrides any value in meta-cluster-kubeconfig).")
 	fs.StringVar(&o.ComponentConfig.ClientConnection.Kubeconfig, "meta-master-kubeconfig", o.ComponentConfig.ClientConnection.Kubeconfig, "Path to kubeconfig file with authorization and meta cluster location information.")
 
+	fs.DurationVar(&o.ComponentConfig.DrainTimeout.Duration, "drain-timeout", o.ComponentConfig.DrainTimeout.Duration, "The time the scheduler is given to drain its queues on shutdown before the leader lease is released")
+	fs.DurationVar(&o.ComponentConfig.DeschedulePeriod.Duration, "deschedule-period", o.ComponentConfig.DeschedulePeriod.Duration, "The interval between two passes of the descheduler that consolidates fragmented namespaces, 0 disables it")
+	fs.IntVar(&o.ComponentConfig.DescheduleChurnBudget, "deschedule-churn-budget", o.ComponentConfig.DescheduleChurnBudget, "The maximum number of namespace slices the descheduler moves in one pass")
+	fs.BoolVar(&o.ComponentConfig.EnablePreemption, "enable-preemption", o.ComponentConfig.EnablePreemption, "If set, a namespace that does not fit in the super clusters deschedules the namespaces of lower scheduling priority")
+	fs.BoolVar(&o.ComponentConfig.EnablePlacementNotification, "enable-placement-notification", o.ComponentConfig.EnablePlacementNotification, "If set, the scheduler records a PlacementChanged event in the super clusters whose placements of a namespace change, for their syncers to act on it without waiting to observe the tenant namespace")
+
+	o.Profiling.AddFlags(fss.FlagSet("profiling"))
+
 	BindFlags(&o.ComponentConfig.LeaderElection, fss.FlagSet("leader election"))
 
 	return fss
@@ -118,6 +132,7 @@ func BindFlags(l *schedulerconfig.SchedulerLeaderElectionConfiguration, fs *pfla
 func (o *SchedulerOptions) Config() (*schedulerappconfig.Config, error) {
 	c := &schedulerappconfig.Config{}
 	c.ComponentConfig = o.ComponentConfig
+	shutdown.DrainTimeout = c.ComponentConfig.DrainTimeout.Duration
 
 	// Prepare kube clients
 	leaderElectionClient, metaClusterClient, virtualClusterClient, superClusterClient, restConfig, err := createClients(c.ComponentConfig.ClientConnection, o.MetaCluster, c.ComponentConfig.LeaderElection.RenewDeadline.Duration)
@@ -198,6 +213,8 @@ func makeLeaderElectionConfig(config schedulerconfig.SchedulerLeaderElectionConf
 		RetryPeriod:   config.RetryPeriod.Duration,
 		WatchDog:      leaderelection.NewLeaderHealthzAdaptor(time.Second * 20),
 		Name:          constants.SchedulerUserAgent,
+		// the lease is released on graceful shutdown so that the next leader starts right away.
+		ReleaseOnCancel: true,
 	}, nil
 }
 
@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/cmd/scheduler/app/options"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/version/verflag"
 )
 
@@ -55,6 +56,7 @@ func NewSchedulerCommand(stopChan <-chan struct{}) *cobra.Command {
 				fmt.Fprintf(os.Stderr, "%v\n", err)
 				os.Exit(1)
 			}
+			s.Profiling.Apply()
 
 			if err := Run(c.Complete(), stopChan); err != nil {
 				fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -109,23 +111,25 @@ func Run(cc *schedulerappconfig.CompletedConfig, stopCh <-chan struct{}) error {
 	// Wait for all caches to sync before resource sync.
 	cc.MetaClusterInformerFactory.WaitForCacheSync(stopCh)
 
-	ctx, cancel := context.WithCancel(context.TODO())
+	// The context is canceled after the scheduler drained its queues, so that the
+	// leader lease is only released once the in-flight scheduling is done.
+	ctx, cancel := shutdown.LeaderContext(stopCh, func() {
+		klog.Infof("shutting down, draining the scheduler")
+		scheduler.WaitForShutdown(shutdown.DrainTimeout)
+	})
 	defer cancel()
 
 	// Prepare a reusable runCommand function.
 	run := startScheduler(scheduler, stopCh)
 
-	go func() {
-		select {
-		case <-stopCh:
-			cancel()
-		case <-ctx.Done():
-		}
-	}()
 	if cc.LeaderElection != nil {
 		cc.LeaderElection.Callbacks = leaderelection.LeaderCallbacks{
 			OnStartedLeading: run,
 			OnStoppedLeading: func() {
+				if shutdown.Stopping(stopCh) {
+					klog.Infof("leader lease released")
+					return
+				}
 				klog.Fatalf("leaderelection lost")
 			},
 		}
@@ -135,10 +139,16 @@ func Run(cc *schedulerappconfig.CompletedConfig, stopCh <-chan struct{}) error {
 		}
 
 		leaderElector.Run(ctx)
+		if shutdown.Stopping(stopCh) {
+			return nil
+		}
 		return fmt.Errorf("lost lease")
 	}
 
 	run(ctx)
+	if shutdown.Stopping(stopCh) {
+		return nil
+	}
 	return fmt.Errorf("finished without leader elect")
 }
 
@@ -17,13 +17,17 @@ limitations under the License.
 package algorithm
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 
 	internalcache "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/cache"
 )
 
+var errNoClusterAvailable = errors.New("no super cluster is available")
+
 // ScheduleNamespaceSlices applies ScheduleOneSlice for each slice
 func ScheduleNamespaceSlices(slices SliceInfoArray, snapshot *internalcache.NamespaceSchedSnapshot) SliceInfoArray {
 	for i, each := range slices {
@@ -32,13 +36,14 @@ func ScheduleNamespaceSlices(slices SliceInfoArray, snapshot *internalcache.Name
 			slices[i].Err = err
 		} else {
 			slices[i].Result = ret
-			_ = snapshot.AddSlices([]*internalcache.Slice{internalcache.NewSlice(each.Namespace, each.Request, ret)})
+			_ = snapshot.AddSlices([]*internalcache.Slice{internalcache.NewZonalSlice(each.Namespace, each.Request, ret, each.Zone)})
 		}
 	}
 	return slices
 }
 
-// ScheduleOneSlice checks snapshot and returns cluster than that fits the slice
+// ScheduleOneSlice checks snapshot and returns cluster than that fits the slice.
+// The unschedulable clusters are only used by the mandatory placements.
 func ScheduleOneSlice(slice *SliceInfo, snapshot *internalcache.NamespaceSchedSnapshot) (string, error) {
 	var err error
 	if slice.Mandatory != "" {
@@ -47,7 +52,7 @@ func ScheduleOneSlice(slice *SliceInfo, snapshot *internalcache.NamespaceSchedSn
 			return "", fmt.Errorf("mandatory cluster %s cannot be found", slice.Mandatory)
 		}
 
-		if err = fitSlice(slice.Request, cluster); err != nil {
+		if err = fitZonalSlice(slice, cluster); err != nil {
 			return "", fmt.Errorf("mandatory request cannot be satisfied %v ", err)
 		}
 		return slice.Mandatory, nil
@@ -55,23 +60,49 @@ func ScheduleOneSlice(slice *SliceInfo, snapshot *internalcache.NamespaceSchedSn
 
 	if slice.Hint != "" {
 		cluster, exists := snapshot.GetClusterUsageMap()[slice.Hint]
-		if !exists {
-			if err = fitSlice(slice.Request, cluster); err == nil {
+		if exists && !cluster.IsUnschedulable() {
+			if err = fitZonalSlice(slice, cluster); err == nil {
 				return slice.Hint, nil
 			}
 		}
 	}
 
 	// First fit
-	for n, cluster := range snapshot.GetClusterUsageMap() {
-		if err = fitSlice(slice.Request, cluster); err == nil {
+	usageMap := snapshot.GetClusterUsageMap()
+	for _, n := range sortedClusterNames(usageMap) {
+		if usageMap[n].IsUnschedulable() {
+			continue
+		}
+		if err = fitZonalSlice(slice, usageMap[n]); err == nil {
 			return n, nil
 		}
 	}
+	if err == nil {
+		err = errNoClusterAvailable
+	}
 	// return the last error
 	return "", err
 }
 
+// fitZonalSlice checks that the slice fits in the cluster and, if the slice requests a zone, in
+// the zone of the cluster too.
+func fitZonalSlice(slice *SliceInfo, cluster *internalcache.ClusterUsage) error {
+	if err := fitSlice(slice.Request, cluster); err != nil {
+		return err
+	}
+	if slice.Zone == "" {
+		return nil
+	}
+	zone := cluster.GetZone(slice.Zone)
+	if zone == nil {
+		return fmt.Errorf("zone %s cannot be found", slice.Zone)
+	}
+	if err := fitSlice(slice.Request, zone); err != nil {
+		return fmt.Errorf("zone %s: %v", slice.Zone, err)
+	}
+	return nil
+}
+
 func fitSlice(request corev1.ResourceList, cluster *internalcache.ClusterUsage) error {
 	used := cluster.GetMaxAlloc()
 
@@ -85,15 +116,30 @@ func fitSlice(request corev1.ResourceList, cluster *internalcache.ClusterUsage)
 	return nil
 }
 
+// sortedClusterNames returns the cluster names of usageMap in order so that
+// the first fit is deterministic
+func sortedClusterNames(usageMap map[string]*internalcache.ClusterUsage) []string {
+	names := make([]string, 0, len(usageMap))
+	for n := range usageMap {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // SchedulePod checks snapshot and returns cluster name that fits the pod
 func SchedulePod(pod *internalcache.Pod, snapshot *internalcache.PodSchedSnapshot) (string, error) {
 	var err error
 	// First fit
-	for name, cluster := range snapshot.GetClusterUsageMap() {
-		if err := fitSlice(pod.GetRequest(), cluster); err == nil {
+	usageMap := snapshot.GetClusterUsageMap()
+	for _, name := range sortedClusterNames(usageMap) {
+		if err = fitSlice(pod.GetRequest(), usageMap[name]); err == nil {
 			return name, nil
 		}
 	}
+	if err == nil {
+		err = errNoClusterAvailable
+	}
 	// return the last error
 	return "", err
 }
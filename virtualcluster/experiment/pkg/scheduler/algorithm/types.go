@@ -26,6 +26,7 @@ type SliceInfo struct {
 	Request   corev1.ResourceList
 	Mandatory string // if not empty, it is the cluster that the slice should go if all checks are passed
 	Hint      string // if not empty, it is the preferred cluster
+	Zone      string // if not empty, it is the zone of the cluster that the slice should go
 
 	Result string // scheduled cluster name
 	Err    error
@@ -35,13 +36,14 @@ type SliceInfo struct {
 type SliceInfoArray []*SliceInfo
 
 // Repeat adds the request to SliceInfoArray one more time.
-func (s *SliceInfoArray) Repeat(n int, namespace string, request corev1.ResourceList, mandatory, hint string) {
+func (s *SliceInfoArray) Repeat(n int, namespace string, request corev1.ResourceList, zone, mandatory, hint string) {
 	for i := 0; i < n; i++ {
 		*s = append(*s, &SliceInfo{
 			Namespace: namespace,
 			Request:   request.DeepCopy(),
 			Mandatory: mandatory,
 			Hint:      hint,
+			Zone:      zone,
 		})
 	}
 }
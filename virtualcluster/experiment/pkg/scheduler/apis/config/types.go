@@ -35,6 +35,26 @@ type SchedulerConfiguration struct {
 
 	// Super control plane rest config
 	RestConfig *rest.Config
+
+	// DrainTimeout is the time the scheduler is given to drain its queues on shutdown,
+	// before the leader lease is released.
+	DrainTimeout metav1.Duration
+
+	// DeschedulePeriod is the interval between two passes of the descheduler, which moves the
+	// slices of fragmented namespaces to a single super cluster. The descheduler is disabled if 0.
+	DeschedulePeriod metav1.Duration
+
+	// DescheduleChurnBudget is the maximum number of slices the descheduler moves in one pass.
+	DescheduleChurnBudget int
+
+	// EnablePreemption allows a namespace that does not fit in the super clusters to deschedule the
+	// namespaces of lower scheduling priority.
+	EnablePreemption bool
+
+	// EnablePlacementNotification makes the scheduler record an event in a super cluster when the
+	// placements of a namespace in it change, so that its syncer does not wait to observe the
+	// placements of the tenant namespace.
+	EnablePlacementNotification bool
 }
 
 // SchedulerLeaderElectionConfiguration expands LeaderElectionConfiguration
@@ -187,7 +187,17 @@ func (c *schedulerCache) GetNamespace(key string) *Namespace {
 	return c.namespaces[key]
 }
 
-func (c *schedulerCache) addNamespaceToCluster(cluster, key string, num int, slice corev1.ResourceList) error {
+func (c *schedulerCache) ListNamespaces() []*Namespace {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	namespaces := make([]*Namespace, 0, len(c.namespaces))
+	for _, v := range c.namespaces {
+		namespaces = append(namespaces, v)
+	}
+	return namespaces
+}
+
+func (c *schedulerCache) addNamespaceToCluster(cluster, key string, num int, slice corev1.ResourceList, zone string) error {
 	if num == 0 {
 		return nil
 	}
@@ -199,7 +209,7 @@ func (c *schedulerCache) addNamespaceToCluster(cluster, key string, num int, sli
 	}
 	var slices []*Slice
 	for i := 0; i < num; i++ {
-		slices = append(slices, NewSlice(key, slice, cluster))
+		slices = append(slices, NewZonalSlice(key, slice, cluster, zone))
 	}
 	if err := clusterState.AddNamespace(key, slices); err != nil {
 		return err
@@ -248,7 +258,7 @@ func (c *schedulerCache) addNamespaceWithoutLock(namespace *Namespace) error {
 	i := -1
 
 	for _, each := range clone.schedule {
-		err = c.addNamespaceToCluster(each.cluster, key, each.num, clone.quotaSlice)
+		err = c.addNamespaceToCluster(each.cluster, key, each.num, clone.quotaSlice, clone.zone)
 		if err != nil {
 			break
 		}
@@ -298,7 +308,7 @@ func (c *schedulerCache) removeNamespaceWithoutLock(namespace *Namespace) error
 	// Rollback if any error happens.
 	if err != nil {
 		for ; i > -1; i-- {
-			_ = c.addNamespaceToCluster(namespace.schedule[i].cluster, key, namespace.schedule[i].num, namespace.quotaSlice)
+			_ = c.addNamespaceToCluster(namespace.schedule[i].cluster, key, namespace.schedule[i].num, namespace.quotaSlice, namespace.zone)
 		}
 	} else {
 		delete(c.namespaces, key)
@@ -344,7 +354,9 @@ func (c *schedulerCache) updateClusterNonAllocationStates(curCluster, newCluster
 		}
 	}
 	curCluster.capacity = newCluster.capacity.DeepCopy()
+	curCluster.zoneCapacity = copyZoneCapacity(newCluster.zoneCapacity)
 	curCluster.shadow = false
+	curCluster.unschedulable = newCluster.unschedulable
 
 	provisionItemsCopy := make(map[string][]*Slice)
 	for k, v := range newCluster.provisionItems {
@@ -409,7 +421,7 @@ func (c *schedulerCache) RemoveProvision(clustername, key string) error {
 	return clusterState.RemoveProvision(key)
 }
 
-func (c *schedulerCache) UpdateClusterCapacity(clustername string, newCapacity corev1.ResourceList) error {
+func (c *schedulerCache) UpdateClusterCapacity(clustername string, newCapacity corev1.ResourceList, newZoneCapacity map[string]corev1.ResourceList) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -418,10 +430,26 @@ func (c *schedulerCache) UpdateClusterCapacity(clustername string, newCapacity c
 		return fmt.Errorf("cluster %s is not in cache, cannot update the cluster capacity", clustername)
 	}
 	clusterState.capacity = newCapacity.DeepCopy()
+	clusterState.zoneCapacity = copyZoneCapacity(newZoneCapacity)
 	clusterState.lastUpdateTime = metav1.Now()
 	return nil
 }
 
+func (c *schedulerCache) SetClusterUnschedulable(clustername string, unschedulable bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clusterState, ok := c.clusters[clustername]
+	if !ok {
+		return fmt.Errorf("cluster %s is not in cache, cannot update the cluster schedulability", clustername)
+	}
+	if clusterState.unschedulable != unschedulable {
+		klog.Infof("cluster %s is marked unschedulable: %v", clustername, unschedulable)
+	}
+	clusterState.unschedulable = unschedulable
+	return nil
+}
+
 func (c *schedulerCache) Dump() string {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -30,6 +30,10 @@ type Cluster struct {
 	labels   map[string]string
 	capacity corev1.ResourceList
 	shadow   bool // a shadow cluster has a fake capacity, hence is not involved in scheduling
+	// an unschedulable (cordoned) cluster keeps its namespaces but does not accept new placements
+	unschedulable bool
+	// zoneCapacity is the capacity of each zone of the cluster, nil if the zones are not tracked
+	zoneCapacity map[string]corev1.ResourceList
 
 	alloc      corev1.ResourceList
 	allocItems map[string][]*Slice            // ns key -> slice array
@@ -99,6 +103,8 @@ func (c *Cluster) DeepCopy() *Cluster {
 			podsCopy[k][name] = struct{}{}
 		}
 	}
+	out.unschedulable = c.unschedulable
+	out.zoneCapacity = copyZoneCapacity(c.zoneCapacity)
 	out.allocItems = allocItemsCopy
 	out.alloc = c.alloc.DeepCopy()
 	out.pods = podsCopy
@@ -138,6 +144,55 @@ func (c *Cluster) addItem(key string, items map[string][]*Slice, alloc corev1.Re
 	return allocCopy, nil
 }
 
+// SetZoneCapacity sets the capacity of each zone of the cluster, the slices of the namespaces
+// requesting a zone are only placed in the clusters whose zone has the capacity for them.
+func (c *Cluster) SetZoneCapacity(zoneCapacity map[string]corev1.ResourceList) {
+	c.zoneCapacity = zoneCapacity
+}
+
+// zoneAlloc returns the allocation of each zone of the cluster by the zonal slices of items.
+func (c *Cluster) zoneAlloc(items map[string][]*Slice) map[string]corev1.ResourceList {
+	ret := make(map[string]corev1.ResourceList, len(c.zoneCapacity))
+	for zone, capacity := range c.zoneCapacity {
+		alloc := capacity.DeepCopy()
+		for k, v := range alloc {
+			v.Set(0)
+			alloc[k] = v
+		}
+		ret[zone] = alloc
+	}
+	for _, slices := range items {
+		for _, s := range slices {
+			alloc, ok := ret[s.zone]
+			if !ok {
+				continue
+			}
+			for k, v := range s.unit {
+				each := alloc[k]
+				each.Add(v)
+				alloc[k] = each
+			}
+		}
+	}
+	return ret
+}
+
+func copyZoneCapacity(zoneCapacity map[string]corev1.ResourceList) map[string]corev1.ResourceList {
+	if zoneCapacity == nil {
+		return nil
+	}
+	ret := make(map[string]corev1.ResourceList, len(zoneCapacity))
+	for zone, capacity := range zoneCapacity {
+		ret[zone] = capacity.DeepCopy()
+	}
+	return ret
+}
+
+// SetUnschedulable cordons or uncordons the cluster
+func (c *Cluster) SetUnschedulable(unschedulable bool) {
+	c.unschedulable = unschedulable
+}
+
 func (c *Cluster) AddNamespace(key string, slices []*Slice) error {
 	ret, err := c.addItem(key, c.allocItems, c.alloc, slices)
 	if err == nil {
@@ -218,6 +273,8 @@ func (c *Cluster) Dump() string {
 		"Labels":         c.labels,
 		"Capacity":       c.capacity,
 		"Shadow":         c.shadow,
+		"Unschedulable":  c.unschedulable,
+		"ZoneCapacity":   c.zoneCapacity,
 		"Alloc":          c.alloc,
 		"AllocItems":     c.allocItems,
 		"Pods":           c.pods,
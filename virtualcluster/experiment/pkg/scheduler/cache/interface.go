@@ -24,6 +24,7 @@ type Cache interface {
 	AddTenant(string)
 	RemoveTenant(string) error
 	GetNamespace(string) *Namespace
+	ListNamespaces() []*Namespace
 	AddNamespace(*Namespace) error
 	RemoveNamespace(*Namespace) error
 	UpdateNamespace(*Namespace, *Namespace) error
@@ -34,7 +35,8 @@ type Cache interface {
 	RemovePod(*Pod) error
 	AddProvision(string, string, []*Slice) error
 	RemoveProvision(string, string) error
-	UpdateClusterCapacity(string, corev1.ResourceList) error
+	UpdateClusterCapacity(string, corev1.ResourceList, map[string]corev1.ResourceList) error
+	SetClusterUnschedulable(string, bool) error
 	SnapshotForNamespaceSched(...*Namespace) (*NamespaceSchedSnapshot, error)
 	SnapshotForPodSched(pod *Pod) (*PodSchedSnapshot, error)
 	Dump() string
@@ -83,24 +83,38 @@ type Namespace struct {
 	quotaSlice corev1.ResourceList
 
 	schedule []*Placement
+
+	// priority orders the namespaces competing for scarce capacity, a namespace can preempt the
+	// placements of the namespaces of lower priority.
+	priority int32
+
+	// zone, if not empty, is the zone of the super clusters the slices of the namespace are placed in.
+	zone string
 }
 
 type Slice struct {
 	owner   string // namespace key
 	unit    corev1.ResourceList
 	cluster string
+	zone    string // empty if the slice is not bound to a zone of the cluster
 }
 
 func NewSlice(owner string, sliceSize corev1.ResourceList, cluster string) *Slice {
+	return NewZonalSlice(owner, sliceSize, cluster, "")
+}
+
+// NewZonalSlice returns a slice placed in the zone of the cluster.
+func NewZonalSlice(owner string, sliceSize corev1.ResourceList, cluster, zone string) *Slice {
 	return &Slice{
 		owner:   owner,
 		unit:    sliceSize.DeepCopy(),
 		cluster: cluster,
+		zone:    zone,
 	}
 }
 
 func (s Slice) DeepCopy() *Slice {
-	return NewSlice(s.owner, s.unit.DeepCopy(), s.cluster)
+	return NewZonalSlice(s.owner, s.unit.DeepCopy(), s.cluster, s.zone)
 }
 
 func (s Slice) String() string {
@@ -109,11 +123,15 @@ func (s Slice) String() string {
 }
 
 func (s Slice) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	o := map[string]interface{}{
 		"Owner":   s.owner,
 		"Unit":    s.unit,
 		"Cluster": s.cluster,
-	})
+	}
+	if s.zone != "" {
+		o["Zone"] = s.zone
+	}
+	return json.Marshal(o)
 }
 
 func NewNamespace(owner, name string, labels map[string]string, quota, quotaSlice corev1.ResourceList, schedule []*Placement) *Namespace {
@@ -136,13 +154,40 @@ func (n *Namespace) DeepCopy() *Namespace {
 	for k, v := range n.labels {
 		labelCopy[k] = v
 	}
-	return NewNamespace(n.owner, n.name, labelCopy, n.quota.DeepCopy(), n.quotaSlice.DeepCopy(), schedCopy)
+	ret := NewNamespace(n.owner, n.name, labelCopy, n.quota.DeepCopy(), n.quotaSlice.DeepCopy(), schedCopy)
+	ret.priority = n.priority
+	ret.zone = n.zone
+	return ret
 }
 
 func (n *Namespace) GetKey() string {
 	return fmt.Sprintf("%s/%s", n.owner, n.name)
 }
 
+func (n *Namespace) GetOwner() string {
+	return n.owner
+}
+
+func (n *Namespace) GetName() string {
+	return n.name
+}
+
+func (n *Namespace) GetPriority() int32 {
+	return n.priority
+}
+
+func (n *Namespace) SetPriority(priority int32) {
+	n.priority = priority
+}
+
+func (n *Namespace) GetZone() string {
+	return n.zone
+}
+
+func (n *Namespace) SetZone(zone string) {
+	n.zone = zone
+}
+
 func (n *Namespace) GetPlacementMap() map[string]int {
 	m := make(map[string]int)
 	for _, each := range n.schedule {
@@ -210,6 +255,7 @@ func (n *Namespace) Dump() string {
 		"Quota":      n.quota,
 		"QuotaSlice": n.quotaSlice,
 		"Schedule":   n.schedule,
+		"Priority":   n.priority,
 	}
 
 	b, err := json.MarshalIndent(o, "", "\t")
@@ -34,15 +34,28 @@ func MaxAlloc(a corev1.ResourceList, b corev1.ResourceList) corev1.ResourceList
 }
 
 type ClusterUsage struct {
-	capacity  corev1.ResourceList
-	alloc     corev1.ResourceList
-	provision corev1.ResourceList
+	capacity      corev1.ResourceList
+	alloc         corev1.ResourceList
+	provision     corev1.ResourceList
+	unschedulable bool
+	// zones is the usage of each zone of the cluster, nil if the zones are not tracked
+	zones map[string]*ClusterUsage
 }
 
 func (u *ClusterUsage) GetCapacity() corev1.ResourceList {
 	return u.capacity
 }
 
+// IsUnschedulable returns true if the cluster is cordoned, i.e. it only honors the existing placements
+func (u *ClusterUsage) IsUnschedulable() bool {
+	return u.unschedulable
+}
+
+// GetZone returns the usage of the zone of the cluster, nil if the zone is unknown
+func (u *ClusterUsage) GetZone(zone string) *ClusterUsage {
+	return u.zones[zone]
+}
+
 func (u *ClusterUsage) GetMaxAlloc() corev1.ResourceList {
 	return MaxAlloc(u.alloc, u.provision)
 }
@@ -66,6 +79,13 @@ func (s *NamespaceSchedSnapshot) AddSlices(slices []*Slice) error {
 			val.Add(v)
 			cur.alloc[k] = val
 		}
+		if zone, ok := cur.zones[each.zone]; ok {
+			for k, v := range each.unit {
+				val := zone.alloc[k].DeepCopy()
+				val.Add(v)
+				zone.alloc[k] = val
+			}
+		}
 	}
 	return nil
 }
@@ -84,6 +104,16 @@ func (s *NamespaceSchedSnapshot) RemoveSlices(slices []*Slice) error {
 			val.Sub(v)
 			cur.alloc[k] = val
 		}
+		if zone, ok := cur.zones[each.zone]; ok {
+			for k, v := range each.unit {
+				val := zone.alloc[k].DeepCopy()
+				if val.Cmp(v) == -1 {
+					return fmt.Errorf("slices removal causes negative allocation in zone %s", each.zone)
+				}
+				val.Sub(v)
+				zone.alloc[k] = val
+			}
+		}
 	}
 	return nil
 }
@@ -103,11 +133,25 @@ func (c *schedulerCache) SnapshotForNamespaceSched(nsToRemove ...*Namespace) (*N
 		if cluster.shadow {
 			continue
 		}
-		s.clusterUsageMap[n] = &ClusterUsage{
-			capacity:  cluster.capacity.DeepCopy(),
-			alloc:     cluster.alloc.DeepCopy(),
-			provision: cluster.provision.DeepCopy(),
+		usage := &ClusterUsage{
+			capacity:      cluster.capacity.DeepCopy(),
+			alloc:         cluster.alloc.DeepCopy(),
+			provision:     cluster.provision.DeepCopy(),
+			unschedulable: cluster.unschedulable,
+		}
+		if cluster.zoneCapacity != nil {
+			zoneAlloc := cluster.zoneAlloc(cluster.allocItems)
+			zoneProvision := cluster.zoneAlloc(cluster.provisionItems)
+			usage.zones = make(map[string]*ClusterUsage, len(cluster.zoneCapacity))
+			for zone, capacity := range cluster.zoneCapacity {
+				usage.zones[zone] = &ClusterUsage{
+					capacity:  capacity.DeepCopy(),
+					alloc:     zoneAlloc[zone],
+					provision: zoneProvision[zone],
+				}
+			}
 		}
+		s.clusterUsageMap[n] = usage
 	}
 
 	// in case of rescheduling, the old namespace needs to be removed from the snapshot
@@ -192,3 +192,60 @@ func TestSnapshotForNamespaceSched(t *testing.T) {
 		})
 	}
 }
+
+func TestSnapshotForNamespaceSchedZones(t *testing.T) {
+	capacity := corev1.ResourceList{
+		"cpu": resource.MustParse("4"),
+	}
+	slice := corev1.ResourceList{
+		"cpu": resource.MustParse("1"),
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	cache := NewSchedulerCache(stop).(*schedulerCache)
+	cache.AddTenant(defaultTenant)
+
+	cluster := NewCluster(defaultCluster1, nil, capacity)
+	cluster.SetZoneCapacity(map[string]corev1.ResourceList{
+		"zone-1": {"cpu": resource.MustParse("2")},
+		"zone-2": {"cpu": resource.MustParse("2")},
+	})
+	if err := cluster.AddProvision("tenant/provisioned", []*Slice{NewZonalSlice("tenant/provisioned", slice, defaultCluster1, "zone-2")}); err != nil {
+		t.Fatalf("failed to add provision: %v", err)
+	}
+	if err := cache.AddCluster(cluster); err != nil {
+		t.Fatalf("failed to add cluster %s", cluster.name)
+	}
+
+	namespace := NewNamespace(defaultTenant, defaultNamespace, nil, corev1.ResourceList{"cpu": resource.MustParse("2")}, slice,
+		[]*Placement{NewPlacement(defaultCluster1, 2)})
+	namespace.SetZone("zone-1")
+	if err := cache.AddNamespace(namespace); err != nil {
+		t.Fatalf("failed to add namespace: %v", err)
+	}
+
+	snapshot, err := cache.SnapshotForNamespaceSched()
+	if err != nil {
+		t.Fatalf("failed to get snapshot: %v", err)
+	}
+	usage := snapshot.GetClusterUsageMap()[defaultCluster1]
+	for zone, expect := range map[string]string{"zone-1": "2", "zone-2": "1"} {
+		alloc := usage.GetZone(zone).GetMaxAlloc()["cpu"]
+		if alloc.Cmp(resource.MustParse(expect)) != 0 {
+			t.Errorf("zone %s allocation is %v, expected %s", zone, alloc.String(), expect)
+		}
+	}
+	if usage.GetZone("zone-3") != nil {
+		t.Errorf("unknown zone should not have usage")
+	}
+
+	snapshot, err = cache.SnapshotForNamespaceSched(namespace)
+	if err != nil {
+		t.Fatalf("failed to get snapshot: %v", err)
+	}
+	alloc := snapshot.GetClusterUsageMap()[defaultCluster1].GetZone("zone-1").GetMaxAlloc()["cpu"]
+	if !alloc.IsZero() {
+		t.Errorf("zone-1 allocation is %v after removing the namespace, expected 0", alloc.String())
+	}
+}
@@ -57,6 +57,23 @@ const (
 	InternalSchedulerEngine SchedulerContextKey = "tenancy.x-k8s.io/schedulerengine"
 	// InternalSchedulerManager name of the context key with manager
 	InternalSchedulerManager SchedulerContextKey = "tenancy.x-k8s.io/schedulermanager"
+	// InternalPlacementNotifier name of the context key with the placement notifier
+	InternalPlacementNotifier SchedulerContextKey = "tenancy.x-k8s.io/placementnotifier"
+)
+
+// The reasons of the Scheduled condition of the tenant namespaces
+const (
+	// ReasonScheduled means all the slices of the namespace are placed in super clusters
+	ReasonScheduled = "Scheduled"
+	// ReasonUnschedulable means the super clusters do not have the capacity for the slices of the namespace
+	ReasonUnschedulable = "Unschedulable"
+	// ReasonInvalidPlacements means the placements of the namespace cannot be honored by the super clusters
+	ReasonInvalidPlacements = "InvalidPlacements"
+	// ReasonNoQuota means the namespace has no resource quota, hence no slice to schedule
+	ReasonNoQuota = "NoQuota"
+	// ReasonPreempted means the placements of the namespace are removed to make room for a namespace of
+	// higher scheduling priority, the namespace is rescheduled
+	ReasonPreempted = "Preempted"
 )
 
 // SchedulerUserAgent is a useragent for scheduler
@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"sort"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/algorithm"
+	internalcache "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/cache"
+)
+
+// Consolidation is a namespace whose slices are moved to a single super cluster by the descheduler
+type Consolidation struct {
+	// Namespace is the namespace with the new placement, it is already updated in the cache
+	Namespace *internalcache.Namespace
+	// Moved is the number of slices that change super cluster
+	Moved int
+}
+
+// ConsolidateNamespaces looks for namespaces whose slices are spread over several super clusters
+// but could fit entirely in one of them, and reschedules them to that cluster. At most budget
+// slices are moved, so that the overhead of switching super clusters stays bounded. The namespaces
+// that span the most clusters are consolidated first, and the cluster already holding most of the
+// slices of a namespace is preferred to minimize the churn. The namespaces of higher scheduling
+// priority are consolidated before the others.
+func (e *schedulerEngine) ConsolidateNamespaces(budget int) ([]Consolidation, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var candidates []*internalcache.Namespace
+	for _, ns := range e.cache.ListNamespaces() {
+		if numClusters(ns) > 1 {
+			candidates = append(candidates, ns)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].GetPriority() != candidates[j].GetPriority() {
+			return candidates[i].GetPriority() > candidates[j].GetPriority()
+		}
+		ni, nj := numClusters(candidates[i]), numClusters(candidates[j])
+		if ni != nj {
+			return ni > nj
+		}
+		return candidates[i].GetKey() < candidates[j].GetKey()
+	})
+
+	var ret []Consolidation
+	for _, ns := range candidates {
+		if budget <= 0 {
+			break
+		}
+		target, moved, err := e.consolidationTarget(ns, budget)
+		if err != nil {
+			return ret, err
+		}
+		if target == "" {
+			continue
+		}
+		total := 0
+		for _, num := range ns.GetPlacementMap() {
+			total += num
+		}
+		consolidated := ns.DeepCopy()
+		consolidated.SetNewPlacements(map[string]int{target: total})
+		if err := e.cache.UpdateNamespace(ns, consolidated); err != nil {
+			return ret, err
+		}
+		klog.V(4).Infof("consolidate namespace %s to cluster %s, %d slices moved", ns.GetKey(), target, moved)
+		ret = append(ret, Consolidation{Namespace: consolidated, Moved: moved})
+		budget -= moved
+	}
+	return ret, nil
+}
+
+// consolidationTarget returns the cluster that can hold all the slices of the namespace by
+// moving at most budget slices, and the number of moved slices. An empty cluster name is
+// returned if there is no such cluster.
+func (e *schedulerEngine) consolidationTarget(ns *internalcache.Namespace, budget int) (string, int, error) {
+	placements := ns.GetPlacementMap()
+	total := 0
+	clusters := make([]string, 0, len(placements))
+	for cluster, num := range placements {
+		total += num
+		clusters = append(clusters, cluster)
+	}
+	if total != ns.GetTotalSlices() {
+		// the namespace is waiting to be rescheduled after a quota change
+		return "", 0, nil
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if placements[clusters[i]] != placements[clusters[j]] {
+			return placements[clusters[i]] > placements[clusters[j]]
+		}
+		return clusters[i] < clusters[j]
+	})
+
+	for _, cluster := range clusters {
+		moved := total - placements[cluster]
+		if moved > budget {
+			// the clusters are sorted by decreasing number of slices
+			break
+		}
+		snapshot, err := e.cache.SnapshotForNamespaceSched(ns)
+		if err != nil {
+			return "", 0, err
+		}
+		if usage, ok := snapshot.GetClusterUsageMap()[cluster]; !ok || usage.IsUnschedulable() {
+			continue
+		}
+		slices := make(algorithm.SliceInfoArray, 0, total)
+		slices.Repeat(total, ns.GetKey(), ns.GetQuotaSlice(), ns.GetZone(), cluster, "")
+		if _, err := GetNewPlacement(algorithm.ScheduleNamespaceSlices(slices, snapshot)); err == nil {
+			return cluster, moved, nil
+		}
+	}
+	return "", 0, nil
+}
+
+func numClusters(ns *internalcache.Namespace) int {
+	n := 0
+	for _, num := range ns.GetPlacementMap() {
+		if num > 0 {
+			n++
+		}
+	}
+	return n
+}
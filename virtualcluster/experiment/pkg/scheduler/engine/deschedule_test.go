@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	internalcache "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/cache"
+)
+
+func TestConsolidateNamespaces(t *testing.T) {
+	capacity := corev1.ResourceList{
+		"cpu":    resource.MustParse("10"),
+		"memory": resource.MustParse("10Gi"),
+	}
+	quotaSlice := corev1.ResourceList{
+		"cpu":    resource.MustParse("1"),
+		"memory": resource.MustParse("1Gi"),
+	}
+	quota := func(n string) corev1.ResourceList {
+		return corev1.ResourceList{
+			"cpu":    resource.MustParse(n),
+			"memory": resource.MustParse(n + "Gi"),
+		}
+	}
+
+	testcases := map[string]struct {
+		namespaces map[string]map[string]int
+		cordoned   []string
+		budget     int
+		expected   map[string]map[string]int
+		moved      int
+	}{
+		"nothing to consolidate": {
+			namespaces: map[string]map[string]int{
+				"ns1": {"a": 4},
+				"ns2": {"b": 4},
+			},
+			budget:   10,
+			expected: map[string]map[string]int{},
+		},
+		"move the minority of the slices": {
+			namespaces: map[string]map[string]int{
+				"ns1": {"a": 1, "b": 3},
+			},
+			budget:   10,
+			expected: map[string]map[string]int{"ns1": {"b": 4}},
+			moved:    1,
+		},
+		"cluster with most slices is full": {
+			namespaces: map[string]map[string]int{
+				"ns1": {"a": 2, "b": 3},
+				"ns2": {"b": 7},
+			},
+			budget:   10,
+			expected: map[string]map[string]int{"ns1": {"a": 5}},
+			moved:    3,
+		},
+		"cordoned clusters are not consolidation targets": {
+			namespaces: map[string]map[string]int{
+				"ns1": {"a": 1, "b": 3},
+			},
+			cordoned: []string{"b"},
+			budget:   10,
+			expected: map[string]map[string]int{"ns1": {"a": 4}},
+			moved:    3,
+		},
+		"no cluster can hold the namespace": {
+			namespaces: map[string]map[string]int{
+				"ns1": {"a": 6, "b": 6},
+			},
+			budget:   10,
+			expected: map[string]map[string]int{},
+		},
+		"churn budget is exhausted": {
+			namespaces: map[string]map[string]int{
+				"ns1": {"a": 1, "b": 1, "c": 1},
+				"ns2": {"a": 2, "b": 2},
+			},
+			budget:   3,
+			expected: map[string]map[string]int{"ns1": {"a": 3}},
+			moved:    2,
+		},
+		"over budget namespaces are skipped": {
+			namespaces: map[string]map[string]int{
+				"ns1": {"a": 3, "b": 3, "c": 1},
+				"ns2": {"b": 2, "c": 1},
+			},
+			budget:   2,
+			expected: map[string]map[string]int{"ns2": {"b": 3}},
+			moved:    1,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			stop := make(chan struct{})
+			defer close(stop)
+			schedulerCache := internalcache.NewSchedulerCache(stop)
+			schedulerCache.AddTenant("tenant")
+			for _, name := range []string{"a", "b", "c"} {
+				if err := schedulerCache.AddCluster(internalcache.NewCluster(name, nil, capacity.DeepCopy())); err != nil {
+					t.Fatalf("fail to add cluster %s: %v", name, err)
+				}
+			}
+			for _, name := range tc.cordoned {
+				if err := schedulerCache.SetClusterUnschedulable(name, true); err != nil {
+					t.Fatalf("fail to cordon cluster %s: %v", name, err)
+				}
+			}
+			for name, placements := range tc.namespaces {
+				total := 0
+				var schedule []*internalcache.Placement
+				for cluster, num := range placements {
+					total += num
+					schedule = append(schedule, internalcache.NewPlacement(cluster, num))
+				}
+				ns := internalcache.NewNamespace("tenant", name, nil, quota(resource.NewQuantity(int64(total), resource.DecimalSI).String()), quotaSlice, schedule)
+				if err := schedulerCache.AddNamespace(ns); err != nil {
+					t.Fatalf("fail to add namespace %s: %v", name, err)
+				}
+			}
+
+			e := NewSchedulerEngine(schedulerCache)
+			consolidations, err := e.ConsolidateNamespaces(tc.budget)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := make(map[string]map[string]int)
+			moved := 0
+			for _, each := range consolidations {
+				got[each.Namespace.GetName()] = each.Namespace.GetPlacementMap()
+				moved += each.Moved
+				if cached := schedulerCache.GetNamespace(each.Namespace.GetKey()).GetPlacementMap(); !reflect.DeepEqual(cached, got[each.Namespace.GetName()]) {
+					t.Errorf("cache of namespace %s is not updated: %v", each.Namespace.GetKey(), cached)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected consolidations %v, got %v", tc.expected, got)
+			}
+			if moved != tc.moved {
+				t.Errorf("expected %d slices to move, got %d", tc.moved, moved)
+			}
+		})
+	}
+}
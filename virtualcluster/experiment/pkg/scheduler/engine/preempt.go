@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/klog/v2"
+
+	internalcache "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/cache"
+)
+
+// PreemptNamespace schedules a namespace that does not fit in the super clusters by descheduling
+// namespaces of lower priority. The victims are the lowest priority namespaces first, and the ones
+// whose slices turn out not to be needed are spared, so that as few namespaces as possible lose their
+// placements. The victims are removed from the cache and the namespace is placed, the tenant
+// namespaces of the victims have to be rescheduled by the caller.
+func (e *schedulerEngine) PreemptNamespace(namespace *internalcache.Namespace) (*internalcache.Namespace, []*internalcache.Namespace, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := namespace.GetKey()
+	curState := e.cache.GetNamespace(key)
+	if curState != nil && !namespace.Comparable(curState) {
+		return nil, nil, fmt.Errorf("updating namespace with quotaslcie change is not supported")
+	}
+
+	var candidates []*internalcache.Namespace
+	for _, ns := range e.cache.ListNamespaces() {
+		if ns.GetKey() != key && ns.GetPriority() < namespace.GetPriority() && numClusters(ns) > 0 {
+			candidates = append(candidates, ns)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("no namespace of lower priority than %d to preempt", namespace.GetPriority())
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].GetPriority() != candidates[j].GetPriority() {
+			return candidates[i].GetPriority() < candidates[j].GetPriority()
+		}
+		return candidates[i].GetKey() < candidates[j].GetKey()
+	})
+	if _, err := placeNamespace(e.cache, namespace, curState, candidates...); err != nil {
+		return nil, nil, fmt.Errorf("the namespace does not fit even if all the namespaces of lower priority are preempted: %v", err)
+	}
+
+	// spare the candidates of the highest priority first, as long as the namespace still fits
+	victims := candidates
+	for i := len(candidates) - 1; i >= 0; i-- {
+		spared := make([]*internalcache.Namespace, 0, len(victims))
+		for _, each := range victims {
+			if each != candidates[i] {
+				spared = append(spared, each)
+			}
+		}
+		if _, err := placeNamespace(e.cache, namespace, curState, spared...); err == nil {
+			victims = spared
+		}
+	}
+
+	for _, victim := range victims {
+		if err := e.cache.RemoveNamespace(victim); err != nil {
+			return nil, nil, fmt.Errorf("failed to preempt namespace %s: %v", victim.GetKey(), err)
+		}
+		klog.V(4).Infof("namespace %s of priority %d is preempted by namespace %s of priority %d", victim.GetKey(), victim.GetPriority(), key, namespace.GetPriority())
+	}
+
+	newPlacement, err := placeNamespace(e.cache, namespace, curState)
+	if err != nil {
+		return nil, victims, err
+	}
+	ret := namespace.DeepCopy()
+	ret.SetNewPlacements(newPlacement)
+	if curState != nil {
+		err = e.cache.UpdateNamespace(curState, ret)
+	} else {
+		err = e.cache.AddNamespace(ret)
+	}
+	return ret, victims, err
+}
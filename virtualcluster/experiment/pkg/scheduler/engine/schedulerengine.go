@@ -34,6 +34,8 @@ type Engine interface {
 	DeScheduleNamespace(key string) error
 	SchedulePod(pod *internalcache.Pod) (*internalcache.Pod, error)
 	DeSchedulePod(key string) error
+	ConsolidateNamespaces(budget int) ([]Consolidation, error)
+	PreemptNamespace(*internalcache.Namespace) (*internalcache.Namespace, []*internalcache.Namespace, error)
 }
 
 var _ Engine = &schedulerEngine{}
@@ -54,6 +56,7 @@ func GetSlicesToSchedule(namespace *internalcache.Namespace, oldPlacements map[s
 	key := namespace.GetKey()
 	slicesToSchedule := make(algorithm.SliceInfoArray, 0)
 	size := namespace.GetQuotaSlice()
+	zone := namespace.GetZone()
 
 	remainingToSchedule := namespace.GetTotalSlices()
 	// handle slices that have mandatory placements
@@ -68,7 +71,7 @@ func GetSlicesToSchedule(namespace *internalcache.Namespace, oldPlacements map[s
 			used := util.Min(val, mandatory)
 			oldPlacements[cluster] = val - used
 		}
-		slicesToSchedule.Repeat(mandatory, key, size, cluster, "")
+		slicesToSchedule.Repeat(mandatory, key, size, zone, cluster, "")
 		remainingToSchedule -= mandatory
 	}
 
@@ -79,10 +82,10 @@ func GetSlicesToSchedule(namespace *internalcache.Namespace, oldPlacements map[s
 			break
 		}
 		hinted := util.Min(num, remainingToSchedule)
-		slicesToSchedule.Repeat(hinted, key, size, "", cluster)
+		slicesToSchedule.Repeat(hinted, key, size, zone, "", cluster)
 		remainingToSchedule -= hinted
 	}
-	slicesToSchedule.Repeat(remainingToSchedule, key, size, "", "")
+	slicesToSchedule.Repeat(remainingToSchedule, key, size, zone, "", "")
 	return slicesToSchedule
 }
 
@@ -108,26 +111,13 @@ func (e *schedulerEngine) ScheduleNamespace(namespace *internalcache.Namespace)
 	// All slices have to be re-examined against the cache since some placed clusters may become invalid. However,
 	// we can use old placement as hint for new placement. The idea is that we should maximally avoid
 	// changing the placement clusters since the overhead of switching super clusters is nontrivial.
-	var oldPlacements map[string]int
 	key := namespace.GetKey()
 	curState := e.cache.GetNamespace(key)
-	if curState != nil {
-		if !namespace.Comparable(curState) {
-			return nil, fmt.Errorf("updating namespace with quotaslcie change is not supported")
-		}
-		oldPlacements = curState.GetPlacementMap()
+	if curState != nil && !namespace.Comparable(curState) {
+		return nil, fmt.Errorf("updating namespace with quotaslcie change is not supported")
 	}
 
-	var newPlacement map[string]int
-	var snapshot *internalcache.NamespaceSchedSnapshot
-	var err error
-	slicesToSchedule := GetSlicesToSchedule(namespace, oldPlacements)
-	snapshot, err = e.cache.SnapshotForNamespaceSched(curState)
-	if err != nil {
-		return nil, err
-	}
-	slicesToSchedule = algorithm.ScheduleNamespaceSlices(slicesToSchedule, snapshot)
-	newPlacement, err = GetNewPlacement(slicesToSchedule)
+	newPlacement, err := placeNamespace(e.cache, namespace, curState)
 	if err != nil {
 		return nil, err
 	}
@@ -143,6 +133,22 @@ func (e *schedulerEngine) ScheduleNamespace(namespace *internalcache.Namespace)
 	return ret, err
 }
 
+// placeNamespace finds the placement of all the slices of the namespace, without updating the cache.
+// curState is the cached state of the namespace, if any, its placements are used as hints. The
+// slices of the excluded namespaces are not accounted in the super clusters.
+func placeNamespace(schedulerCache internalcache.Cache, namespace, curState *internalcache.Namespace, excluded ...*internalcache.Namespace) (map[string]int, error) {
+	var oldPlacements map[string]int
+	if curState != nil {
+		oldPlacements = curState.GetPlacementMap()
+	}
+	slicesToSchedule := GetSlicesToSchedule(namespace, oldPlacements)
+	snapshot, err := schedulerCache.SnapshotForNamespaceSched(append([]*internalcache.Namespace{curState}, excluded...)...)
+	if err != nil {
+		return nil, err
+	}
+	return GetNewPlacement(algorithm.ScheduleNamespaceSlices(slicesToSchedule, snapshot))
+}
+
 func (e *schedulerEngine) DeScheduleNamespace(key string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -72,8 +72,7 @@ func (s *Scheduler) checkSuperClusterHealth(cluster mc.ClusterInterface) {
 		return
 	}
 
-	var capacity corev1.ResourceList
-	capacity, err = util.GetSuperClusterCapacity(cs)
+	capacity, zoneCapacity, err := util.GetSuperClusterCapacity(cs)
 	if err != nil {
 		klog.Warningf("[checkSuperClusterHealth] fails to get cluster %v capacity: %v", cluster.GetClusterName(), err)
 		atomic.AddUint64(&numUnHealthSuperCluster, 1)
@@ -94,7 +93,12 @@ func (s *Scheduler) checkSuperClusterHealth(cluster mc.ClusterInterface) {
 	}
 	atomic.AddUint64(&numHealthSuperCluster, 1)
 	// update scheduler cache
-	_ = s.schedulerCache.UpdateClusterCapacity(cluster.GetClusterName(), capacity)
+	_ = s.schedulerCache.UpdateClusterCapacity(cluster.GetClusterName(), capacity, zoneCapacity)
+	if _, unschedulable, err := util.GetSuperClusterInfo(cs); err != nil {
+		klog.Warningf("[checkSuperClusterHealth] fails to get cluster %v info: %v", cluster.GetClusterName(), err)
+	} else {
+		_ = s.schedulerCache.SetClusterUnschedulable(cluster.GetClusterName(), unschedulable)
+	}
 }
 
 func (s *Scheduler) virtualClusterHealthPatrol() {
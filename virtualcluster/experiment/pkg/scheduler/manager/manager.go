@@ -19,10 +19,13 @@ package manager
 import (
 	"sync"
 
+	"k8s.io/klog/v2"
+
 	schedulerconfig "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 )
 
 // WatchManager manages number of resource watchers.
@@ -69,7 +72,8 @@ func (m *WatchManager) GetResourceWatcherByMCControllerName(name string) Resourc
 }
 
 func (m *WatchManager) Start(stop <-chan struct{}) error {
-	errCh := make(chan error)
+	// errCh is buffered so that the watchers failing while others drain don't block.
+	errCh := make(chan error, len(m.resourceWatchers))
 
 	wg := &sync.WaitGroup{}
 	wg.Add(len(m.resourceWatchers))
@@ -92,9 +96,14 @@ func (m *WatchManager) Start(stop <-chan struct{}) error {
 	select {
 	case <-doneCh:
 		return nil
-	case <-stop:
-		return nil
 	case err := <-errCh:
 		return err
+	case <-stop:
 	}
+
+	// let the controllers drain their queues before returning.
+	if !shutdown.WaitTimeout(wg, shutdown.DrainTimeout) {
+		klog.Warningf("timed out waiting for the controllers to drain their queues")
+	}
+	return nil
 }
@@ -27,6 +27,8 @@ const (
 	SchedulerSubsystem      = "scheduler"
 	SuperClusterHealthKey   = "super_cluster_health"
 	VirtualClusterHealthKey = "virtual_cluster_health"
+	DescheduledSlicesKey    = "descheduled_slices"
+	PreemptedNamespacesKey  = "preempted_namespaces"
 )
 
 var (
@@ -46,6 +48,20 @@ var (
 		},
 		[]string{"status"},
 	)
+	DescheduledSlices = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: SchedulerSubsystem,
+			Name:      DescheduledSlicesKey,
+			Help:      "Number of namespace slices moved to another super cluster to reduce fragmentation.",
+		},
+	)
+	PreemptedNamespaces = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: SchedulerSubsystem,
+			Name:      PreemptedNamespacesKey,
+			Help:      "Number of namespaces descheduled to make room for namespaces of higher scheduling priority.",
+		},
+	)
 )
 
 var registerMetrics sync.Once
@@ -55,5 +71,7 @@ func Register() {
 	registerMetrics.Do(func() {
 		prometheus.MustRegister(SuperClusterHealthStats)
 		prometheus.MustRegister(VirtualClusterHealthStats)
+		prometheus.MustRegister(DescheduledSlices)
+		prometheus.MustRegister(PreemptedNamespaces)
 	})
 }
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/util"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+// PlacementNotifier tells the syncers of the super clusters that the placements of a tenant namespace
+// changed, so that they create or remove the namespace without waiting to observe its placements.
+type PlacementNotifier interface {
+	NotifyPlacementChange(clusterName, namespace string, oldPlacements, newPlacements map[string]int, scheduleTime string)
+}
+
+// placementNotification is the change of the placements of a tenant namespace in a super cluster
+type placementNotification struct {
+	superCluster string
+	clusterName  string
+	namespace    string
+	slices       int
+	scheduleTime string
+}
+
+// placementNotifier records the notifications as events in the super clusters from a work queue, so
+// that an unreachable super cluster doesn't hold the scheduling of the namespaces.
+type placementNotifier struct {
+	queue     workqueue.RateLimitingInterface
+	getClient func(superCluster string) (clientset.Interface, error)
+}
+
+func newPlacementNotifier(getClient func(superCluster string) (clientset.Interface, error)) *placementNotifier {
+	return &placementNotifier{
+		queue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "placement-notification"),
+		getClient: getClient,
+	}
+}
+
+func (n *placementNotifier) NotifyPlacementChange(clusterName, namespace string, oldPlacements, newPlacements map[string]int, scheduleTime string) {
+	for _, id := range util.ChangedSuperClusters(oldPlacements, newPlacements) {
+		n.queue.Add(placementNotification{
+			superCluster: id,
+			clusterName:  clusterName,
+			namespace:    namespace,
+			slices:       newPlacements[id],
+			scheduleTime: scheduleTime,
+		})
+	}
+}
+
+func (n *placementNotifier) worker() {
+	for n.processNextNotification() {
+	}
+}
+
+func (n *placementNotifier) processNextNotification() bool {
+	obj, shutdown := n.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer n.queue.Done(obj)
+
+	notification := obj.(placementNotification)
+	if err := n.notify(notification); err != nil {
+		if n.queue.NumRequeues(obj) < utilconst.MaxReconcileRetryAttempts {
+			klog.Warningf("failed to notify the placements of namespace %s/%s, retry: %v", notification.clusterName, notification.namespace, err)
+			n.queue.AddRateLimited(obj)
+			return true
+		}
+		// the syncer still observes the placements of the tenant namespace
+		klog.Errorf("drop the notification of the placements of namespace %s/%s: %v", notification.clusterName, notification.namespace, err)
+	}
+	n.queue.Forget(obj)
+	return true
+}
+
+func (n *placementNotifier) notify(notification placementNotification) error {
+	client, err := n.getClient(notification.superCluster)
+	if err != nil {
+		return err
+	}
+	event := util.NewPlacementChangedEvent(notification.clusterName, notification.namespace, notification.slices, notification.scheduleTime)
+	_, err = client.CoreV1().Events(event.Namespace).Create(context.TODO(), event, metav1.CreateOptions{})
+	return err
+}
+
+// getSuperClusterClient returns the client of the super cluster whose id is superCluster.
+func (s *Scheduler) getSuperClusterClient(superCluster string) (clientset.Interface, error) {
+	s.superClusterLock.Lock()
+	defer s.superClusterLock.Unlock()
+	for _, each := range s.superClusterSet {
+		if each.GetClusterName() == superCluster {
+			return each.GetClientSet()
+		}
+	}
+	return nil, fmt.Errorf("super cluster %s is not found", superCluster)
+}
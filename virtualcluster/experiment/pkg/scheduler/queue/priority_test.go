@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func priorities(p map[string]int32) PriorityFunc {
+	return func(item interface{}) int32 {
+		return p[item.(string)]
+	}
+}
+
+func drain(t *testing.T, q workqueue.Interface, n int) []string {
+	var got []string
+	for i := 0; i < n; i++ {
+		item, shutdown := q.Get()
+		if shutdown {
+			t.Fatalf("unexpected shutdown")
+		}
+		got = append(got, item.(string))
+		q.Done(item)
+	}
+	return got
+}
+
+func TestPriorityQueueOrder(t *testing.T) {
+	q := newPriorityQueue(priorities(map[string]int32{"high-1": 100, "high-2": 100, "low-1": -1, "low-2": -1}))
+	for _, item := range []string{"default-1", "low-1", "high-1", "default-2", "low-2", "high-2", "default-1"} {
+		q.Add(item)
+	}
+	if q.Len() != 6 {
+		t.Fatalf("expected the duplicated item to be queued once, got %d items", q.Len())
+	}
+
+	expected := []string{"high-1", "high-2", "default-1", "default-2", "low-1", "low-2"}
+	if got := drain(t, q, 6); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected order %v, got %v", expected, got)
+	}
+}
+
+func TestPriorityQueueReaddWhileProcessing(t *testing.T) {
+	q := newPriorityQueue(priorities(map[string]int32{"high": 100}))
+	q.Add("default")
+	item, _ := q.Get()
+
+	// the item is not processed concurrently, it is queued again once done
+	q.Add("default")
+	q.Add("high")
+	if q.Len() != 1 {
+		t.Fatalf("expected only the new item to be queued, got %d items", q.Len())
+	}
+	q.Done(item)
+	expected := []string{"high", "default"}
+	if got := drain(t, q, 2); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected order %v, got %v", expected, got)
+	}
+}
+
+func TestPriorityQueueShutDown(t *testing.T) {
+	q := newPriorityQueue(priorities(nil))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, shutdown := q.Get(); !shutdown {
+			t.Errorf("expected Get to return on shutdown")
+		}
+	}()
+	q.ShutDown()
+	select {
+	case <-done:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timeout waiting for the shutdown")
+	}
+	q.Add("default")
+	if q.Len() != 0 {
+		t.Errorf("expected no item to be added after shutdown")
+	}
+}
+
+func TestRateLimitingPriorityQueue(t *testing.T) {
+	q := NewRateLimitingPriorityQueue(priorities(map[string]int32{"high": 100}),
+		workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond))
+	defer q.ShutDown()
+
+	q.AddRateLimited("high")
+	q.Add("default")
+	if q.NumRequeues("high") != 1 {
+		t.Errorf("expected 1 requeue, got %d", q.NumRequeues("high"))
+	}
+	if err := wait.PollImmediate(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return q.Len() == 2, nil
+	}); err != nil {
+		t.Fatalf("expected the rate limited item to be queued: %v", err)
+	}
+	expected := []string{"high", "default"}
+	if got := drain(t, q, 2); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected order %v, got %v", expected, got)
+	}
+	q.Forget("high")
+	if q.NumRequeues("high") != 0 {
+		t.Errorf("expected the requeues to be forgotten, got %d", q.NumRequeues("high"))
+	}
+}
@@ -26,7 +26,9 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -36,6 +38,8 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/engine"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/queue"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/util"
 	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
@@ -52,7 +56,14 @@ func init() {
 			if v == nil {
 				return nil, fmt.Errorf("cannot found schedulercache in context")
 			}
-			return NewNamespaceController(v.(engine.Engine), ctx.Config.(*schedulerconfig.SchedulerConfiguration))
+			c, err := NewNamespaceController(v.(engine.Engine), ctx.Config.(*schedulerconfig.SchedulerConfiguration))
+			if err != nil {
+				return nil, err
+			}
+			if n, ok := ctx.Context.Value(constants.InternalPlacementNotifier).(scheduler.PlacementNotifier); ok {
+				c.(*controller).PlacementNotifier = n
+			}
+			return c, nil
 		},
 	})
 }
@@ -61,6 +72,8 @@ type controller struct {
 	SchedulerEngine        engine.Engine
 	Config                 *schedulerconfig.SchedulerConfiguration
 	MultiClusterController *mc.MultiClusterController
+	// PlacementNotifier is nil unless the placement notifications are enabled
+	PlacementNotifier scheduler.PlacementNotifier
 }
 
 // NewNamespaceController creates new NamespaceController watcher
@@ -71,14 +84,41 @@ func NewNamespaceController(schedulerEngine engine.Engine, config *schedulerconf
 	}
 
 	var err error
-	c.MultiClusterController, err = mc.NewMCController(&corev1.Namespace{}, &corev1.NamespaceList{}, c)
+	// the namespaces of higher priority are scheduled first when the slices are scarce
+	q := queue.NewRateLimitingPriorityQueue(c.requestPriority, workqueue.DefaultControllerRateLimiter())
+	c.MultiClusterController, err = mc.NewMCController(&corev1.Namespace{}, &corev1.NamespaceList{}, c, mc.WithWorkQueue(q))
 	if err != nil {
 		return nil, err
 	}
 	return c, nil
 }
 
+// requestPriority returns the scheduling priority of the namespace of a request, the default
+// priority if the namespace or its virtual cluster are not found.
+func (c *controller) requestPriority(item interface{}) int32 {
+	req, ok := item.(reconciler.Request)
+	if !ok {
+		return 0
+	}
+	namespace := &corev1.Namespace{}
+	if err := c.MultiClusterController.Get(req.ClusterName, "", req.Name, namespace); err != nil {
+		return 0
+	}
+	vc, err := c.MultiClusterController.GetClusterObject(req.ClusterName)
+	if err != nil {
+		return 0
+	}
+	priority, err := util.GetSchedulingPriority(namespace, vc)
+	if err != nil {
+		return 0
+	}
+	return priority
+}
+
 func (c *controller) Start(stopCh <-chan struct{}) error {
+	if c.Config.DeschedulePeriod.Duration > 0 {
+		go wait.Until(c.deschedule, c.Config.DeschedulePeriod.Duration, stopCh)
+	}
 	return c.MultiClusterController.Start(stopCh)
 }
 
@@ -144,7 +184,8 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 		if err := c.SchedulerEngine.DeScheduleNamespace(fmt.Sprintf("%s/%s", request.ClusterName, request.Name)); err != nil {
 			return reconciler.Result{}, fmt.Errorf("failed to unreserve namespace %s in %s: %v", request.Name, request.ClusterName, err)
 		}
-		return reconciler.Result{}, nil
+		err := c.updateSchedulingCondition(request.ClusterName, namespace, corev1.ConditionFalse, constants.ReasonNoQuota, "The namespace has no resource quota to schedule")
+		return reconciler.Result{}, err
 	}
 	numSched := 0
 	schedule := make([]*internalcache.Placement, 0, len(placements))
@@ -153,17 +194,39 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 		schedule = append(schedule, internalcache.NewPlacement(k, v))
 	}
 
+	vc, err := c.MultiClusterController.GetClusterObject(request.ClusterName)
+	if err != nil {
+		return reconciler.Result{}, err
+	}
+	priority, err := util.GetSchedulingPriority(namespace, vc)
+	if err != nil {
+		return reconciler.Result{}, fmt.Errorf("failed to get scheduling priority in %s: %v", request.Name, err)
+	}
+
 	candidate := internalcache.NewNamespace(request.ClusterName, request.Name, namespace.GetLabels(), quota, quotaSlice, schedule)
+	candidate.SetPriority(priority)
+	candidate.SetZone(util.GetSchedulingZone(namespace))
 	// ensure the cache is consistent with the scheduled placements
 	if numSched == expect {
 		if err := c.SchedulerEngine.EnsureNamespacePlacements(candidate); err != nil {
+			_ = c.updateSchedulingCondition(request.ClusterName, namespace, corev1.ConditionFalse, constants.ReasonInvalidPlacements, err.Error())
 			return reconciler.Result{}, fmt.Errorf("failed to ensure namespace %s's placements in %s: %v", request.Name, request.ClusterName, err)
 		}
-		return reconciler.Result{}, nil
+		err := c.updateSchedulingCondition(request.ClusterName, namespace, corev1.ConditionTrue, constants.ReasonScheduled, scheduledMessage(placements))
+		return reconciler.Result{}, err
 	}
 
 	// some (or all) slices need to be scheduled/rescheduled
 	ret, err := c.SchedulerEngine.ScheduleNamespace(candidate)
+	if err != nil && c.Config.EnablePreemption {
+		preempted, victims, preemptErr := c.SchedulerEngine.PreemptNamespace(candidate)
+		c.evictPreempted(request.ClusterName, namespace, victims)
+		if preemptErr == nil {
+			ret, err = preempted, nil
+		} else {
+			klog.V(4).Infof("namespace %s/%s cannot preempt namespaces of lower priority: %v", request.ClusterName, request.Name, preemptErr)
+		}
+	}
 	if err != nil {
 		c.MultiClusterController.Eventf(request.ClusterName, &corev1.ObjectReference{
 			Kind:      "Namespace",
@@ -171,11 +234,15 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 			Namespace: namespace.Name,
 			UID:       namespace.UID,
 		}, corev1.EventTypeNormal, "Failed", "Failed to schedule namespace %s: %v", request.Name, err)
+		_ = c.updateSchedulingCondition(request.ClusterName, namespace, corev1.ConditionFalse, constants.ReasonUnschedulable, err.Error())
 		return reconciler.Result{}, fmt.Errorf("failed to schedule namespace %s in %s: %v", request.Name, request.ClusterName, err)
 	}
 	// update virtualcluster namespace with the scheduling result.
 	placementMap := ret.GetPlacementMap()
 	err = c.updateSchedulingResult(request.ClusterName, namespace, placementMap)
+	if err == nil {
+		err = c.updateSchedulingCondition(request.ClusterName, namespace, corev1.ConditionTrue, constants.ReasonScheduled, scheduledMessage(placementMap))
+	}
 	if err == nil {
 		updatedPlacement, _ := json.Marshal(placementMap)
 		klog.Infof("Successfully schedule namespace %s/%s with placement %s", request.ClusterName, request.Name, string(updatedPlacement))
@@ -189,21 +256,103 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 	return reconciler.Result{}, err
 }
 
+// deschedule consolidates the fragmented namespaces within the churn budget and writes
+// their new placements to the tenant namespaces.
+func (c *controller) deschedule() {
+	consolidations, err := c.SchedulerEngine.ConsolidateNamespaces(c.Config.DescheduleChurnBudget)
+	if err != nil {
+		klog.Errorf("failed to consolidate namespaces: %v", err)
+	}
+	for _, each := range consolidations {
+		clusterName, name := each.Namespace.GetOwner(), each.Namespace.GetName()
+		namespace := &corev1.Namespace{}
+		if err := c.MultiClusterController.Get(clusterName, "", name, namespace); err != nil {
+			// the cache is corrected when the namespace is reconciled
+			klog.Errorf("failed to get namespace %s in %s: %v", name, clusterName, err)
+			continue
+		}
+		placementMap := each.Namespace.GetPlacementMap()
+		if err := c.updateSchedulingResult(clusterName, namespace, placementMap); err != nil {
+			klog.Errorf("failed to update the scheduling placements of namespace %s in %s: %v", name, clusterName, err)
+			continue
+		}
+		metrics.DescheduledSlices.Add(float64(each.Moved))
+		if err := c.updateSchedulingCondition(clusterName, namespace, corev1.ConditionTrue, constants.ReasonScheduled, scheduledMessage(placementMap)); err != nil {
+			klog.Errorf("failed to update the scheduling condition of namespace %s in %s: %v", name, clusterName, err)
+		}
+		updatedPlacement, _ := json.Marshal(placementMap)
+		klog.Infof("Successfully reschedule namespace %s/%s with placement %s", clusterName, name, string(updatedPlacement))
+		_ = c.MultiClusterController.Eventf(clusterName, &corev1.ObjectReference{
+			Kind:      "Namespace",
+			Name:      namespace.Name,
+			Namespace: namespace.Name,
+			UID:       namespace.UID,
+		}, corev1.EventTypeNormal, "Rescheduled", "Namespace %s is consolidated with placement %s", name, string(updatedPlacement))
+	}
+}
+
+// evictPreempted removes the placements of the tenant namespaces preempted by a namespace, so that they
+// are rescheduled, and records the preemption on both sides. The preempting namespace is only named to
+// the victims of the same tenant.
+func (c *controller) evictPreempted(clusterName string, preemptor *corev1.Namespace, victims []*internalcache.Namespace) {
+	if len(victims) == 0 {
+		return
+	}
+	for _, victim := range victims {
+		owner, name := victim.GetOwner(), victim.GetName()
+		namespace := &corev1.Namespace{}
+		if err := c.MultiClusterController.Get(owner, "", name, namespace); err != nil {
+			klog.Errorf("failed to get preempted namespace %s in %s: %v", name, owner, err)
+			continue
+		}
+		if err := c.updateSchedulingResult(owner, namespace, nil); err != nil {
+			klog.Errorf("failed to remove the scheduling placements of preempted namespace %s in %s: %v", name, owner, err)
+			continue
+		}
+		metrics.PreemptedNamespaces.Inc()
+		message := "The placements of the namespace are removed to make room for a namespace of higher priority"
+		if owner == clusterName {
+			message = fmt.Sprintf("The placements of the namespace are removed to make room for namespace %s of higher priority", preemptor.Name)
+		}
+		if err := c.updateSchedulingCondition(owner, namespace, corev1.ConditionFalse, constants.ReasonPreempted, message); err != nil {
+			klog.Errorf("failed to update the scheduling condition of preempted namespace %s in %s: %v", name, owner, err)
+		}
+		klog.Infof("namespace %s/%s is preempted by namespace %s/%s", owner, name, clusterName, preemptor.Name)
+		_ = c.MultiClusterController.Eventf(owner, &corev1.ObjectReference{
+			Kind:      "Namespace",
+			Name:      namespace.Name,
+			Namespace: namespace.Name,
+			UID:       namespace.UID,
+		}, corev1.EventTypeWarning, constants.ReasonPreempted, "%s", message)
+	}
+	_ = c.MultiClusterController.Eventf(clusterName, &corev1.ObjectReference{
+		Kind:      "Namespace",
+		Name:      preemptor.Name,
+		Namespace: preemptor.Name,
+		UID:       preemptor.UID,
+	}, corev1.EventTypeNormal, "Preempting", "Preempted %d namespaces of lower priority to schedule namespace %s", len(victims), preemptor.Name)
+}
+
 func (c *controller) updateSchedulingResult(clusterName string, namespace *corev1.Namespace, placementMap map[string]int) error {
 	vcClient, err := c.MultiClusterController.GetClusterClient(clusterName)
 	if err != nil {
 		return fmt.Errorf("failed to get vc %s's client: %v", clusterName, err)
 	}
 	clone := namespace.DeepCopy()
+	scheduleTime := metav1.Now().UTC().Format(time.RFC3339)
+	var oldPlacements map[string]int
 	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		oldPlacements, _, _ = util.GetSchedulingInfo(clone)
 		if clone.Annotations == nil {
 			clone.Annotations = make(map[string]string)
 		}
 		if placementMap == nil {
 			delete(clone.Annotations, utilconst.LabelScheduledPlacements)
+			delete(clone.Annotations, utilconst.LabelLastScheduleTime)
 		} else {
 			updatedPlacement, _ := json.Marshal(placementMap)
 			clone.Annotations[utilconst.LabelScheduledPlacements] = string(updatedPlacement)
+			clone.Annotations[utilconst.LabelLastScheduleTime] = scheduleTime
 		}
 		_, updateErr := vcClient.CoreV1().Namespaces().Update(context.TODO(), clone, metav1.UpdateOptions{})
 		if updateErr == nil {
@@ -214,5 +363,43 @@ func (c *controller) updateSchedulingResult(clusterName string, namespace *corev
 		}
 		return updateErr
 	})
+	if err == nil && c.PlacementNotifier != nil {
+		c.PlacementNotifier.NotifyPlacementChange(clusterName, namespace.Name, oldPlacements, placementMap, scheduleTime)
+	}
 	return err
 }
+
+// updateSchedulingCondition sets the Scheduled condition of the tenant namespace so that tenant users can
+// see the scheduling outcome. The namespace status is only updated when the condition changes.
+func (c *controller) updateSchedulingCondition(clusterName string, namespace *corev1.Namespace, status corev1.ConditionStatus, reason, message string) error {
+	vcClient, err := c.MultiClusterController.GetClusterClient(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to get vc %s's client: %v", clusterName, err)
+	}
+	condition := corev1.NamespaceCondition{
+		Type:               utilconst.NamespaceScheduled,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	clone := namespace.DeepCopy()
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if !util.SetNamespaceCondition(&clone.Status, condition) {
+			return nil
+		}
+		_, updateErr := vcClient.CoreV1().Namespaces().UpdateStatus(context.TODO(), clone, metav1.UpdateOptions{})
+		if updateErr == nil {
+			return nil
+		}
+		if got, err := vcClient.CoreV1().Namespaces().Get(context.TODO(), clone.Name, metav1.GetOptions{}); err == nil {
+			clone = got
+		}
+		return updateErr
+	})
+}
+
+func scheduledMessage(placementMap map[string]int) string {
+	placement, _ := json.Marshal(placementMap)
+	return fmt.Sprintf("The namespace is scheduled with placement %s", string(placement))
+}
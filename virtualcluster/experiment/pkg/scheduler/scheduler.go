@@ -48,6 +48,7 @@ import (
 	virtualClusterLister "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/listers/tenancy/v1alpha1"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 )
 
 // ResourceRegisters for easier handle of clusters
@@ -82,6 +83,12 @@ type Scheduler struct {
 
 	schedulerCache  internalcache.Cache
 	schedulerEngine engine.Engine
+
+	// placementNotifier is nil unless EnablePlacementNotification is set.
+	placementNotifier *placementNotifier
+
+	// drained tracks the watchers and the work queue workers, until they are drained on shutdown.
+	drained sync.WaitGroup
 }
 
 // New creates new Scheduler
@@ -157,6 +164,10 @@ func New(
 	ctx := context.WithValue(context.Background(), constants.InternalSchedulerCache, scheduler.schedulerCache)
 	ctx = context.WithValue(ctx, constants.InternalSchedulerEngine, scheduler.schedulerEngine)
 	ctx = context.WithValue(ctx, constants.InternalSchedulerManager, scheduler.virtualClusterWatcher)
+	if config.EnablePlacementNotification {
+		scheduler.placementNotifier = newPlacementNotifier(scheduler.getSuperClusterClient)
+		ctx = context.WithValue(ctx, constants.InternalPlacementNotifier, PlacementNotifier(scheduler.placementNotifier))
+	}
 
 	initContext := &plugin.InitContext{Context: ctx, Config: config}
 	if err := loadResourcePlugins(&SuperClusterResourceRegister, scheduler.superClusterWatcher, initContext); err != nil {
@@ -253,13 +264,16 @@ func (s *Scheduler) Run(stopChan <-chan struct{}) {
 		panic("the scheduler cannot start without an initialized cache")
 	}
 
+	s.drained.Add(4)
 	go func() {
+		defer s.drained.Done()
 		if err := s.virtualClusterWatcher.Start(stopChan); err != nil {
 			klog.Infof("virtualcluster watch manager exits: %v", err)
 		}
 	}()
 
 	go func() {
+		defer s.drained.Done()
 		if err := s.superClusterWatcher.Start(stopChan); err != nil {
 			klog.Infof("supercluster watch manager exits: %v", err)
 		}
@@ -267,35 +281,52 @@ func (s *Scheduler) Run(stopChan <-chan struct{}) {
 
 	go func() {
 		defer utilruntime.HandleCrash()
-		defer s.virtualClusterQueue.ShutDown()
+		defer s.drained.Done()
 
 		klog.Infof("starting scheduler virtualcluster workerqueue")
 		defer klog.Infof("shutting down scheduler virtualcluster workerqueue")
 
-		for i := 0; i < s.virtualClusterWorkers; i++ {
-			go wait.Until(s.virtualClusterWorkerRun, 1*time.Second, stopChan)
-		}
-		<-stopChan
+		shutdown.RunWorkers("scheduler virtualcluster workerqueue", s.virtualClusterQueue, s.virtualClusterWorkers, s.virtualClusterWorkerRun, 1*time.Second, stopChan)
 	}()
 
 	go func() {
 		defer utilruntime.HandleCrash()
-		defer s.superClusterQueue.ShutDown()
+		defer s.drained.Done()
 
 		klog.Infof("starting scheduler supercluster workerqueue")
 		defer klog.Infof("shutting down scheduler supercluster workerqueue")
 
-		for i := 0; i < s.superClusterWorkers; i++ {
-			go wait.Until(s.superClusterWorkerRun, 1*time.Second, stopChan)
-		}
-		<-stopChan
+		shutdown.RunWorkers("scheduler supercluster workerqueue", s.superClusterQueue, s.superClusterWorkers, s.superClusterWorkerRun, 1*time.Second, stopChan)
 	}()
 
+	if s.placementNotifier != nil {
+		s.drained.Add(1)
+		go func() {
+			defer utilruntime.HandleCrash()
+			defer s.drained.Done()
+
+			klog.Infof("starting scheduler placement notification workerqueue")
+			defer klog.Infof("shutting down scheduler placement notification workerqueue")
+
+			shutdown.RunWorkers("scheduler placement notification workerqueue", s.placementNotifier.queue, 1, s.placementNotifier.worker, 1*time.Second, stopChan)
+		}()
+	}
+
 	go wait.Until(s.Dump, 1*time.Minute, stopChan)
 	go wait.Until(s.superClusterHealthPatrol, 1*time.Minute, stopChan)
 	go wait.Until(s.virtualClusterHealthPatrol, 1*time.Minute, stopChan)
 }
 
+// WaitForShutdown waits up to timeout for the watchers and the work queues to drain once the
+// stop channel passed to Run is closed. It returns false if they did not drain in time.
+func (s *Scheduler) WaitForShutdown(timeout time.Duration) bool {
+	if !shutdown.WaitTimeout(&s.drained, timeout) {
+		klog.Warningf("timed out waiting for the scheduler to drain")
+		return false
+	}
+	return true
+}
+
 // Dump scheduler cache.
 func (s *Scheduler) Dump() {
 	klog.Infof("Start dumping scheduler cache\n%s", s.schedulerCache.Dump())
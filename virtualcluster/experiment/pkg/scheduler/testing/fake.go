@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testing provides in-memory fake super clusters, scheduler caches and
+// informers, and a scenario runner, to test the scheduler engine algorithms
+// deterministically without any running super cluster.
+package testing
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/apis/cluster/v1alpha4"
+	superfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/client/clientset/versioned/fake"
+	superinformerfactory "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/client/informers/externalversions"
+	superinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/client/informers/externalversions/cluster/v1alpha4"
+	internalcache "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/cache"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	vcinformerfactory "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+)
+
+// FakeSuperCluster is an in-memory super cluster with a fixed capacity.
+type FakeSuperCluster struct {
+	// Name is the id of the super cluster in the scheduler cache.
+	Name     string              `json:"name"`
+	Labels   map[string]string   `json:"labels,omitempty"`
+	Capacity corev1.ResourceList `json:"capacity"`
+	// ZoneCapacity is the capacity of each zone of the super cluster, the zones are not tracked if empty.
+	ZoneCapacity map[string]corev1.ResourceList `json:"zoneCapacity,omitempty"`
+}
+
+func (c *FakeSuperCluster) zoneCapacity() map[string]corev1.ResourceList {
+	if c.ZoneCapacity == nil {
+		return nil
+	}
+	zones := make(map[string]corev1.ResourceList, len(c.ZoneCapacity))
+	for zone, capacity := range c.ZoneCapacity {
+		zones[zone] = capacity.DeepCopy()
+	}
+	return zones
+}
+
+// NewFakeCache returns a scheduler cache populated with the fake super clusters.
+func NewFakeCache(stop <-chan struct{}, clusters ...FakeSuperCluster) (internalcache.Cache, error) {
+	schedulerCache := internalcache.NewSchedulerCache(stop)
+	for _, each := range clusters {
+		if err := AddFakeSuperCluster(schedulerCache, each); err != nil {
+			return nil, err
+		}
+	}
+	return schedulerCache, nil
+}
+
+// AddFakeSuperCluster adds the fake super cluster to the scheduler cache.
+func AddFakeSuperCluster(schedulerCache internalcache.Cache, cluster FakeSuperCluster) error {
+	if cluster.Name == "" {
+		return fmt.Errorf("fake super cluster without name")
+	}
+	clusterInstance := internalcache.NewCluster(cluster.Name, cluster.Labels, cluster.Capacity.DeepCopy())
+	clusterInstance.SetZoneCapacity(cluster.zoneCapacity())
+	return schedulerCache.AddCluster(clusterInstance)
+}
+
+// NewSuperClusterObject returns the provisioned super cluster object of the fake super cluster.
+func NewSuperClusterObject(namespace string, cluster FakeSuperCluster) *v1alpha4.Cluster {
+	super := &v1alpha4.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name,
+			Namespace: namespace,
+			Labels:    cluster.Labels,
+		},
+	}
+	super.Status.SetTypedPhase(v1alpha4.ClusterPhaseProvisioned)
+	return super
+}
+
+// NewSuperClusterInformer returns a super cluster informer whose store is
+// populated with supers, it does not need to be started.
+func NewSuperClusterInformer(supers ...*v1alpha4.Cluster) (superinformers.ClusterInformer, error) {
+	client := superfake.NewSimpleClientset()
+	informer := superinformerfactory.NewSharedInformerFactory(client, 0).Cluster().V1alpha4().Clusters()
+	if err := addToStore(informer.Informer().GetStore(), supers); err != nil {
+		return nil, err
+	}
+	return informer, nil
+}
+
+// NewVirtualClusterInformer returns a VirtualCluster informer whose store is
+// populated with vcs, it does not need to be started.
+func NewVirtualClusterInformer(vcs ...*v1alpha1.VirtualCluster) (vcinformers.VirtualClusterInformer, error) {
+	client := vcfake.NewSimpleClientset()
+	informer := vcinformerfactory.NewSharedInformerFactory(client, 0).Tenancy().V1alpha1().VirtualClusters()
+	if err := addToStore(informer.Informer().GetStore(), vcs); err != nil {
+		return nil, err
+	}
+	return informer, nil
+}
+
+func addToStore(store cache.Store, objs interface{}) error {
+	switch list := objs.(type) {
+	case []*v1alpha4.Cluster:
+		for _, obj := range list {
+			if err := store.Add(obj); err != nil {
+				return err
+			}
+		}
+	case []*v1alpha1.VirtualCluster:
+		for _, obj := range list {
+			if err := store.Add(obj); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported object list %T", objs)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing_test
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/apis/cluster/v1alpha4"
+	schedulertesting "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/testing"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestFakeInformers(t *testing.T) {
+	super := schedulertesting.NewSuperClusterObject("default", schedulertesting.FakeSuperCluster{
+		Name:     "cluster-a",
+		Capacity: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+	})
+	superInformer, err := schedulertesting.NewSuperClusterInformer(super)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := superInformer.Lister().Clusters("default").Get("cluster-a")
+	if err != nil {
+		t.Fatalf("expected super cluster in lister: %v", err)
+	}
+	if v1alpha4.ClusterPhase(got.Status.Phase) != v1alpha4.ClusterPhaseProvisioned {
+		t.Errorf("expected provisioned super cluster, got %s", got.Status.Phase)
+	}
+
+	vcInformer, err := schedulertesting.NewVirtualClusterInformer(&v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "default"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := vcInformer.Lister().VirtualClusters("default").Get("vc"); err != nil {
+		t.Errorf("expected virtualcluster in lister: %v", err)
+	}
+}
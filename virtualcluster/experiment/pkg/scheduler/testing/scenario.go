@@ -0,0 +1,280 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	internalcache "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/cache"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/engine"
+)
+
+// Action is an operation performed by a scenario step.
+type Action string
+
+const (
+	ActionAddCluster                Action = "AddCluster"
+	ActionRemoveCluster             Action = "RemoveCluster"
+	ActionUpdateClusterCapacity     Action = "UpdateClusterCapacity"
+	ActionCordonCluster             Action = "CordonCluster"
+	ActionUncordonCluster           Action = "UncordonCluster"
+	ActionScheduleNamespace         Action = "ScheduleNamespace"
+	ActionEnsureNamespacePlacements Action = "EnsureNamespacePlacements"
+	ActionDeScheduleNamespace       Action = "DeScheduleNamespace"
+	ActionPreemptNamespace          Action = "PreemptNamespace"
+	ActionSchedulePod               Action = "SchedulePod"
+	ActionDeSchedulePod             Action = "DeSchedulePod"
+)
+
+// Scenario is a sequence of scheduling operations against fake super clusters,
+// along with the expected outcome of each operation.
+type Scenario struct {
+	Name     string             `json:"name"`
+	Clusters []FakeSuperCluster `json:"clusters,omitempty"`
+	Tenants  []string           `json:"tenants,omitempty"`
+	Steps    []Step             `json:"steps"`
+}
+
+// Step is a single operation of a scenario.
+type Step struct {
+	Action    Action            `json:"action"`
+	Cluster   *FakeSuperCluster `json:"cluster,omitempty"`
+	Namespace *FakeNamespace    `json:"namespace,omitempty"`
+	Pod       *FakePod          `json:"pod,omitempty"`
+	Expect    Expectation       `json:"expect,omitempty"`
+}
+
+// FakeNamespace is a tenant namespace to be scheduled.
+type FakeNamespace struct {
+	Tenant     string              `json:"tenant"`
+	Name       string              `json:"name"`
+	Labels     map[string]string   `json:"labels,omitempty"`
+	Quota      corev1.ResourceList `json:"quota,omitempty"`
+	QuotaSlice corev1.ResourceList `json:"quotaSlice,omitempty"`
+	// Placements are the mandatory placements of the namespace.
+	Placements map[string]int `json:"placements,omitempty"`
+	// Priority is the scheduling priority of the namespace.
+	Priority int32 `json:"priority,omitempty"`
+	// Zone is the zone of the super clusters the namespace requests.
+	Zone string `json:"zone,omitempty"`
+}
+
+// FakePod is a tenant pod to be scheduled.
+type FakePod struct {
+	Tenant    string              `json:"tenant"`
+	Namespace string              `json:"namespace"`
+	Name      string              `json:"name"`
+	Request   corev1.ResourceList `json:"request,omitempty"`
+}
+
+// Expectation is the expected outcome of a step.
+type Expectation struct {
+	// Placements are the expected placements of a scheduled namespace.
+	Placements map[string]int `json:"placements,omitempty"`
+	// Cluster is the expected cluster of a scheduled pod.
+	Cluster string `json:"cluster,omitempty"`
+	// Preempted are the expected tenant/name keys of the namespaces preempted by a namespace.
+	Preempted []string `json:"preempted,omitempty"`
+	// Error is a substring of the expected error, the step must succeed if empty.
+	Error string `json:"error,omitempty"`
+}
+
+// ToNamespace converts the fake namespace to a scheduler cache namespace.
+func (n *FakeNamespace) ToNamespace() *internalcache.Namespace {
+	clusters := make([]string, 0, len(n.Placements))
+	for cluster := range n.Placements {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	var placements []*internalcache.Placement
+	for _, cluster := range clusters {
+		placements = append(placements, internalcache.NewPlacement(cluster, n.Placements[cluster]))
+	}
+	ns := internalcache.NewNamespace(n.Tenant, n.Name, n.Labels, n.Quota.DeepCopy(), n.QuotaSlice.DeepCopy(), placements)
+	ns.SetPriority(n.Priority)
+	ns.SetZone(n.Zone)
+	return ns
+}
+
+// ToPod converts the fake pod to a scheduler cache pod.
+func (p *FakePod) ToPod() *internalcache.Pod {
+	return internalcache.NewPod(p.Tenant, p.Namespace, p.Name, "", p.Request.DeepCopy())
+}
+
+// LoadScenarios loads the scenarios from the yaml files matching pattern, one scenario per file.
+func LoadScenarios(pattern string) ([]*Scenario, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var scenarios []*Scenario
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, err
+		}
+		scenario := &Scenario{}
+		if err := yaml.UnmarshalStrict(data, scenario); err != nil {
+			return nil, fmt.Errorf("failed to load scenario %s: %v", file, err)
+		}
+		if scenario.Name == "" {
+			scenario.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	return scenarios, nil
+}
+
+// Run executes the steps of the scenario against a new scheduler engine and
+// returns an error at the first step whose outcome is not the expected one.
+func (s *Scenario) Run() error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	schedulerCache, err := NewFakeCache(stop, s.Clusters...)
+	if err != nil {
+		return err
+	}
+	for _, tenant := range s.Tenants {
+		schedulerCache.AddTenant(tenant)
+	}
+	e := engine.NewSchedulerEngine(schedulerCache)
+
+	for i, step := range s.Steps {
+		if err := runStep(e, schedulerCache, step); err != nil {
+			return fmt.Errorf("step %d (%s): %v", i, step.Action, err)
+		}
+	}
+	return nil
+}
+
+func runStep(e engine.Engine, schedulerCache internalcache.Cache, step Step) error {
+	var (
+		placements map[string]int
+		cluster    string
+		preempted  []string
+		err        error
+	)
+
+	switch step.Action {
+	case ActionAddCluster, ActionUpdateClusterCapacity, ActionCordonCluster, ActionUncordonCluster, ActionRemoveCluster:
+		if step.Cluster == nil {
+			return fmt.Errorf("cluster is not specified")
+		}
+		switch step.Action {
+		case ActionAddCluster:
+			err = AddFakeSuperCluster(schedulerCache, *step.Cluster)
+		case ActionUpdateClusterCapacity:
+			err = schedulerCache.UpdateClusterCapacity(step.Cluster.Name, step.Cluster.Capacity.DeepCopy(), step.Cluster.zoneCapacity())
+		case ActionCordonCluster, ActionUncordonCluster:
+			err = schedulerCache.SetClusterUnschedulable(step.Cluster.Name, step.Action == ActionCordonCluster)
+		default:
+			err = schedulerCache.RemoveCluster(step.Cluster.Name)
+		}
+	case ActionScheduleNamespace, ActionEnsureNamespacePlacements, ActionDeScheduleNamespace, ActionPreemptNamespace:
+		if step.Namespace == nil {
+			return fmt.Errorf("namespace is not specified")
+		}
+		namespace := step.Namespace.ToNamespace()
+		switch step.Action {
+		case ActionScheduleNamespace:
+			var ret *internalcache.Namespace
+			if ret, err = e.ScheduleNamespace(namespace); err == nil {
+				placements = ret.GetPlacementMap()
+			}
+		case ActionEnsureNamespacePlacements:
+			if err = e.EnsureNamespacePlacements(namespace); err == nil {
+				placements = namespace.GetPlacementMap()
+			}
+		case ActionPreemptNamespace:
+			var (
+				ret     *internalcache.Namespace
+				victims []*internalcache.Namespace
+			)
+			if ret, victims, err = e.PreemptNamespace(namespace); err == nil {
+				placements = ret.GetPlacementMap()
+				for _, each := range victims {
+					preempted = append(preempted, each.GetKey())
+				}
+			}
+		default:
+			err = e.DeScheduleNamespace(namespace.GetKey())
+		}
+	case ActionSchedulePod, ActionDeSchedulePod:
+		if step.Pod == nil {
+			return fmt.Errorf("pod is not specified")
+		}
+		pod := step.Pod.ToPod()
+		if step.Action == ActionSchedulePod {
+			var ret *internalcache.Pod
+			if ret, err = e.SchedulePod(pod); err == nil {
+				cluster = ret.GetCluster()
+			}
+		} else {
+			err = e.DeSchedulePod(pod.GetKey())
+		}
+	default:
+		return fmt.Errorf("unknown action %q", step.Action)
+	}
+
+	return step.Expect.verify(placements, cluster, preempted, err)
+}
+
+func (e *Expectation) verify(placements map[string]int, cluster string, preempted []string, err error) error {
+	if e.Error != "" {
+		if err == nil {
+			return fmt.Errorf("expected error %q, got none", e.Error)
+		}
+		if !strings.Contains(err.Error(), e.Error) {
+			return fmt.Errorf("expected error %q, got %v", e.Error, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unexpected error: %v", err)
+	}
+	if e.Placements != nil && !reflect.DeepEqual(withoutEmptyPlacements(placements), withoutEmptyPlacements(e.Placements)) {
+		return fmt.Errorf("expected placements %v, got %v", e.Placements, placements)
+	}
+	if e.Cluster != "" && e.Cluster != cluster {
+		return fmt.Errorf("expected cluster %q, got %q", e.Cluster, cluster)
+	}
+	if e.Preempted != nil && !reflect.DeepEqual(e.Preempted, preempted) {
+		return fmt.Errorf("expected preempted namespaces %v, got %v", e.Preempted, preempted)
+	}
+	return nil
+}
+
+func withoutEmptyPlacements(placements map[string]int) map[string]int {
+	ret := make(map[string]int, len(placements))
+	for cluster, num := range placements {
+		if num > 0 {
+			ret[cluster] = num
+		}
+	}
+	return ret
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing_test
+
+import (
+	"testing"
+
+	schedulertesting "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/testing"
+)
+
+func TestScenarios(t *testing.T) {
+	scenarios, err := schedulertesting.LoadScenarios("testdata/*.yaml")
+	if err != nil {
+		t.Fatalf("failed to load scenarios: %v", err)
+	}
+	if len(scenarios) == 0 {
+		t.Fatalf("no scenario found")
+	}
+	for _, s := range scenarios {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			if err := s.Run(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -67,15 +69,23 @@ func GetClientFromSecret(metaClient clientset.Interface, name, namespace string)
 }
 
 func GetSuperClusterID(client clientset.Interface) (string, error) {
+	id, _, err := GetSuperClusterInfo(client)
+	return id, err
+}
+
+// GetSuperClusterInfo returns the id of the super cluster and whether it is cordoned, from the
+// supercluster-info configmap in kube-system
+func GetSuperClusterInfo(client clientset.Interface) (string, bool, error) {
 	cfg, err := client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), utilconst.SuperClusterInfoCfgMap, metav1.GetOptions{})
 	if err != nil {
-		return "", fmt.Errorf("failed to get super cluster info configmap in kube-system: %v", err)
+		return "", false, fmt.Errorf("failed to get super cluster info configmap in kube-system: %v", err)
 	}
 	id, ok := cfg.Data[utilconst.SuperClusterIDKey]
 	if !ok {
-		return "", fmt.Errorf("failed to get super cluster id from the supercluster-info configmap in kube-system")
+		return "", false, fmt.Errorf("failed to get super cluster id from the supercluster-info configmap in kube-system")
 	}
-	return id, nil
+	unschedulable, _ := strconv.ParseBool(cfg.Data[utilconst.SuperClusterUnschedulableKey])
+	return id, unschedulable, nil
 }
 
 func GetNodeCondition(status *corev1.NodeStatus, conditionType corev1.NodeConditionType) (int, *corev1.NodeCondition) {
@@ -90,6 +100,27 @@ func GetNodeCondition(status *corev1.NodeStatus, conditionType corev1.NodeCondit
 	return -1, nil
 }
 
+// SetNamespaceCondition adds or updates the condition of the namespace status. The last transition
+// time is only changed when the condition status changes. It returns false if the condition is unchanged.
+func SetNamespaceCondition(status *corev1.NamespaceStatus, condition corev1.NamespaceCondition) bool {
+	for i := range status.Conditions {
+		cur := &status.Conditions[i]
+		if cur.Type != condition.Type {
+			continue
+		}
+		if cur.Status == condition.Status && cur.Reason == condition.Reason && cur.Message == condition.Message {
+			return false
+		}
+		if cur.Status == condition.Status {
+			condition.LastTransitionTime = cur.LastTransitionTime
+		}
+		*cur = condition
+		return true
+	}
+	status.Conditions = append(status.Conditions, condition)
+	return true
+}
+
 func getTotalNodeCapacity(nodelist *corev1.NodeList) corev1.ResourceList {
 	total := corev1.ResourceList{
 		corev1.ResourceCPU:    resource.MustParse("0"),
@@ -111,13 +142,42 @@ func getTotalNodeCapacity(nodelist *corev1.NodeList) corev1.ResourceList {
 	return total
 }
 
-func GetSuperClusterCapacity(client clientset.Interface) (corev1.ResourceList, error) {
+// getZoneNodeCapacity returns the capacity of the ready nodes of each zone, nil if no node is
+// labeled with its zone.
+func getZoneNodeCapacity(nodelist *corev1.NodeList) map[string]corev1.ResourceList {
+	var nodes map[string]*corev1.NodeList
+	for _, each := range nodelist.Items {
+		zone := each.GetLabels()[corev1.LabelTopologyZone]
+		if zone == "" {
+			continue
+		}
+		if nodes == nil {
+			nodes = make(map[string]*corev1.NodeList)
+		}
+		if _, ok := nodes[zone]; !ok {
+			nodes[zone] = &corev1.NodeList{}
+		}
+		nodes[zone].Items = append(nodes[zone].Items, each)
+	}
+	if nodes == nil {
+		return nil
+	}
+	zones := make(map[string]corev1.ResourceList, len(nodes))
+	for zone, list := range nodes {
+		zones[zone] = getTotalNodeCapacity(list)
+	}
+	return zones
+}
+
+// GetSuperClusterCapacity returns the capacity of the super cluster and of each of its zones, the
+// zone capacity is nil if the nodes are not labeled with their zone.
+func GetSuperClusterCapacity(client clientset.Interface) (corev1.ResourceList, map[string]corev1.ResourceList, error) {
 	nodelist, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node from super cluster %v", err)
+		return nil, nil, fmt.Errorf("failed to get node from super cluster %v", err)
 	}
 	// TODO: we need leave some headroom before reporting the capacity to tolerate node failures.
-	return getTotalNodeCapacity(nodelist), nil
+	return getTotalNodeCapacity(nodelist), getZoneNodeCapacity(nodelist), nil
 }
 
 func GetProvisionedSlices(namespace *corev1.Namespace, clusterID, key string) ([]*internalcache.Slice, error) {
@@ -136,7 +196,7 @@ func GetProvisionedSlices(namespace *corev1.Namespace, clusterID, key string) ([
 
 	var slices []*internalcache.Slice
 	for i := 0; i < num; i++ {
-		slices = append(slices, internalcache.NewSlice(key, quotaSlice, clusterID))
+		slices = append(slices, internalcache.NewZonalSlice(key, quotaSlice, clusterID, GetSchedulingZone(namespace)))
 	}
 	return slices, nil
 }
@@ -146,11 +206,11 @@ func SyncSuperClusterState(metaClient clientset.Interface, super *v1alpha4.Clust
 	if err != nil {
 		return fmt.Errorf("failed to get client for super cluster %s/%s: %v", super.Namespace, super.Name, err)
 	}
-	id, err := GetSuperClusterID(client)
+	id, unschedulable, err := GetSuperClusterInfo(client)
 	if err != nil {
 		return fmt.Errorf("failed to get cluster id from super cluster %s/%s: %v", super.Namespace, super.Name, err)
 	}
-	capacity, err := GetSuperClusterCapacity(client)
+	capacity, zoneCapacity, err := GetSuperClusterCapacity(client)
 	if err != nil {
 		return fmt.Errorf("failed to get cluster capacity from super cluster %s/%s: %v", super.Namespace, super.Name, err)
 	}
@@ -162,6 +222,8 @@ func SyncSuperClusterState(metaClient clientset.Interface, super *v1alpha4.Clust
 		}
 	}
 	clusterInstance := internalcache.NewCluster(id, labels, capacity)
+	clusterInstance.SetUnschedulable(unschedulable)
+	clusterInstance.SetZoneCapacity(zoneCapacity)
 	nslist, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to get namespaces from super cluster %s/%s: %v", super.Namespace, super.Name, err)
@@ -291,12 +353,105 @@ func GetSchedulingInfo(namespace *corev1.Namespace) (map[string]int, corev1.Reso
 	return placements, quotaSlice, nil
 }
 
+// GetSchedulingPriority returns the scheduling priority of a tenant namespace. It is the priority of
+// its virtual cluster, which is granted by the operator, unless the namespace sets a lower one, so that
+// the tenants cannot raise their priority above the namespaces of the other tenants.
+func GetSchedulingPriority(namespace *corev1.Namespace, vc metav1.Object) (int32, error) {
+	priority, err := parsePriority(vc.GetAnnotations())
+	if err != nil {
+		return 0, fmt.Errorf("virtual cluster %s/%s: %v", vc.GetNamespace(), vc.GetName(), err)
+	}
+	if _, ok := namespace.GetAnnotations()[utilconst.LabelSchedulingPriority]; !ok {
+		return priority, nil
+	}
+	nsPriority, err := parsePriority(namespace.GetAnnotations())
+	if err != nil {
+		return 0, fmt.Errorf("namespace %s: %v", namespace.Name, err)
+	}
+	if nsPriority < priority {
+		return nsPriority, nil
+	}
+	return priority, nil
+}
+
+// GetSchedulingZone returns the zone of the super clusters the slices of a namespace are placed in,
+// empty if the namespace does not request a zone.
+func GetSchedulingZone(namespace *corev1.Namespace) string {
+	return namespace.GetAnnotations()[utilconst.LabelSchedulingZone]
+}
+
+func parsePriority(annotations map[string]string) (int32, error) {
+	val, ok := annotations[utilconst.LabelSchedulingPriority]
+	if !ok {
+		return 0, nil
+	}
+	priority, err := strconv.ParseInt(val, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("unknown format %s of key %s: %v", val, utilconst.LabelSchedulingPriority, err)
+	}
+	return int32(priority), nil
+}
+
+// ChangedSuperClusters returns the sorted ids of the super clusters whose number of slices differs
+// between the old and the new placements of a namespace.
+func ChangedSuperClusters(oldPlacements, newPlacements map[string]int) []string {
+	var changed []string
+	for id, num := range oldPlacements {
+		if newPlacements[id] != num {
+			changed = append(changed, id)
+		}
+	}
+	for id, num := range newPlacements {
+		if _, ok := oldPlacements[id]; !ok && num != 0 {
+			changed = append(changed, id)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// NewPlacementChangedEvent returns the event telling the syncer of a super cluster that the placements
+// of a tenant namespace in the super cluster changed to slices at scheduleTime. The event refers to the
+// super cluster namespace of the tenant namespace and lives in the default namespace, like the events of
+// the other cluster scoped objects.
+func NewPlacementChangedEvent(clusterName, namespace string, slices int, scheduleTime string) *corev1.Event {
+	superNamespace := conversion.ToSuperClusterNamespace(clusterName, namespace)
+	now := metav1.Now()
+	message := fmt.Sprintf("Namespace %s of cluster %s is placed in this super cluster with %d slices", namespace, clusterName, slices)
+	if slices == 0 {
+		message = fmt.Sprintf("Namespace %s of cluster %s is no longer placed in this super cluster", namespace, clusterName)
+	}
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", superNamespace, now.UnixNano()),
+			Namespace: metav1.NamespaceDefault,
+			Annotations: map[string]string{
+				syncerconst.LabelCluster:        clusterName,
+				syncerconst.LabelNamespace:      namespace,
+				utilconst.LabelLastScheduleTime: scheduleTime,
+			},
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+			Name:       superNamespace,
+		},
+		Reason:         utilconst.ReasonPlacementChanged,
+		Message:        message,
+		Source:         corev1.EventSource{Component: constants.SchedulerUserAgent},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           corev1.EventTypeNormal,
+	}
+}
+
 func GetPodSchedulingInfo(pod *corev1.Pod) string {
 	return pod.GetAnnotations()[utilconst.LabelScheduledCluster]
 }
 
 func SyncVirtualClusterState(metaClient clientset.Interface, vc *v1alpha1.VirtualCluster, cache internalcache.Cache) error {
-	clustername := conversion.ToClusterKey(vc)
+	clustername := conversion.ToRootNamespace(vc)
 	cache.AddTenant(clustername)
 
 	client, err := GetClientFromSecret(metaClient, syncerconst.KubeconfigAdminSecretName, clustername)
@@ -353,7 +508,13 @@ func SyncVirtualClusterState(metaClient clientset.Interface, vc *v1alpha1.Virtua
 				labels[k] = v
 			}
 		}
+		priority, err := GetSchedulingPriority(&nslist.Items[nsIndex], vc)
+		if err != nil {
+			return fmt.Errorf("failed to get scheduling priority in %s/%s: %v", vc.Namespace, vc.Name, err)
+		}
 		cNamespace := internalcache.NewNamespace(clustername, each.Name, labels, quota, quotaSlice, schedule)
+		cNamespace.SetPriority(priority)
+		cNamespace.SetZone(GetSchedulingZone(&nslist.Items[nsIndex]))
 		// If the namespace already exists, AddNamespace will update the cache with latest labels and schedule.
 		if err := cache.AddNamespace(cNamespace); err != nil {
 			return fmt.Errorf("failed to add namespace to cache: %s/%s with error %v", clustername, each.Name, err)
@@ -17,10 +17,15 @@ limitations under the License.
 package util
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 )
 
 func Equals(a corev1.ResourceList, b corev1.ResourceList) bool {
@@ -118,6 +123,77 @@ func TestGetTotalNodeCapacity(t *testing.T) {
 	}
 }
 
+func TestGetZoneNodeCapacity(t *testing.T) {
+	node := func(zone, cpu string, ready corev1.ConditionStatus) corev1.Node {
+		n := corev1.Node{
+			Status: corev1.NodeStatus{
+				Capacity: corev1.ResourceList{
+					"cpu":    resource.MustParse(cpu),
+					"memory": resource.MustParse("1Gi"),
+				},
+				Conditions: []corev1.NodeCondition{
+					{
+						Status: ready,
+						Type:   corev1.NodeReady,
+					},
+				},
+			},
+		}
+		if zone != "" {
+			n.Labels = map[string]string{corev1.LabelTopologyZone: zone}
+		}
+		return n
+	}
+	testcases := map[string]struct {
+		nodelist *corev1.NodeList
+		expect   map[string]corev1.ResourceList
+	}{
+		"no zone": {
+			nodelist: &corev1.NodeList{Items: []corev1.Node{node("", "1", corev1.ConditionTrue)}},
+		},
+		"two zones": {
+			nodelist: &corev1.NodeList{
+				Items: []corev1.Node{
+					node("zone-1", "1", corev1.ConditionTrue),
+					node("zone-1", "2", corev1.ConditionTrue),
+					node("zone-2", "4", corev1.ConditionTrue),
+					node("", "8", corev1.ConditionTrue),
+				},
+			},
+			expect: map[string]corev1.ResourceList{
+				"zone-1": {"cpu": resource.MustParse("3"), "memory": resource.MustParse("2Gi")},
+				"zone-2": {"cpu": resource.MustParse("4"), "memory": resource.MustParse("1Gi")},
+			},
+		},
+		"zone without ready node": {
+			nodelist: &corev1.NodeList{
+				Items: []corev1.Node{
+					node("zone-1", "1", corev1.ConditionTrue),
+					node("zone-2", "4", corev1.ConditionFalse),
+				},
+			},
+			expect: map[string]corev1.ResourceList{
+				"zone-1": {"cpu": resource.MustParse("1"), "memory": resource.MustParse("1Gi")},
+				"zone-2": {"cpu": resource.MustParse("0"), "memory": resource.MustParse("0")},
+			},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			zones := getZoneNodeCapacity(tc.nodelist)
+			if len(zones) != len(tc.expect) || (zones == nil) != (tc.expect == nil) {
+				t.Fatalf("the zone capacity is not expected. Exp: %v, Got %v", tc.expect, zones)
+			}
+			for zone, expect := range tc.expect {
+				if !Equals(expect, zones[zone]) {
+					t.Errorf("the capacity of zone %s is not expected. Exp: %v, Got %v", zone, expect, zones[zone])
+				}
+			}
+		})
+	}
+}
+
 func TestGetMaxQuota(t *testing.T) {
 	testcases := map[string]struct {
 		quotalist *corev1.ResourceQuotaList
@@ -331,3 +407,151 @@ func TestGetPodRequirements(t *testing.T) {
 		})
 	}
 }
+
+func TestSetNamespaceCondition(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+	now := metav1.Now()
+	scheduled := func(status corev1.ConditionStatus, reason string, transition metav1.Time) corev1.NamespaceCondition {
+		return corev1.NamespaceCondition{
+			Type:               utilconst.NamespaceScheduled,
+			Status:             status,
+			LastTransitionTime: transition,
+			Reason:             reason,
+			Message:            reason,
+		}
+	}
+	terminating := corev1.NamespaceCondition{Type: corev1.NamespaceDeletionContentFailure, Status: corev1.ConditionFalse}
+
+	testcases := map[string]struct {
+		conditions []corev1.NamespaceCondition
+		condition  corev1.NamespaceCondition
+		changed    bool
+		expect     []corev1.NamespaceCondition
+	}{
+		"add": {
+			conditions: []corev1.NamespaceCondition{terminating},
+			condition:  scheduled(corev1.ConditionTrue, "Scheduled", now),
+			changed:    true,
+			expect:     []corev1.NamespaceCondition{terminating, scheduled(corev1.ConditionTrue, "Scheduled", now)},
+		},
+		"unchanged": {
+			conditions: []corev1.NamespaceCondition{scheduled(corev1.ConditionTrue, "Scheduled", before)},
+			condition:  scheduled(corev1.ConditionTrue, "Scheduled", now),
+			changed:    false,
+			expect:     []corev1.NamespaceCondition{scheduled(corev1.ConditionTrue, "Scheduled", before)},
+		},
+		"status changed": {
+			conditions: []corev1.NamespaceCondition{scheduled(corev1.ConditionTrue, "Scheduled", before)},
+			condition:  scheduled(corev1.ConditionFalse, "Unschedulable", now),
+			changed:    true,
+			expect:     []corev1.NamespaceCondition{scheduled(corev1.ConditionFalse, "Unschedulable", now)},
+		},
+		"reason changed": {
+			conditions: []corev1.NamespaceCondition{scheduled(corev1.ConditionFalse, "Unschedulable", before)},
+			condition:  scheduled(corev1.ConditionFalse, "NoQuota", now),
+			changed:    true,
+			expect:     []corev1.NamespaceCondition{scheduled(corev1.ConditionFalse, "NoQuota", before)},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			status := &corev1.NamespaceStatus{Conditions: tc.conditions}
+			if changed := SetNamespaceCondition(status, tc.condition); changed != tc.changed {
+				t.Errorf("expected changed to be %v, got %v", tc.changed, changed)
+			}
+			if !reflect.DeepEqual(status.Conditions, tc.expect) {
+				t.Errorf("expected conditions %v, got %v", tc.expect, status.Conditions)
+			}
+		})
+	}
+}
+
+func TestGetSchedulingPriority(t *testing.T) {
+	withPriority := func(priority string) map[string]string {
+		if priority == "" {
+			return nil
+		}
+		return map[string]string{utilconst.LabelSchedulingPriority: priority}
+	}
+	for name, tc := range map[string]struct {
+		vcPriority  string
+		nsPriority  string
+		expected    int32
+		expectedErr bool
+	}{
+		"no priority":                         {expected: 0},
+		"priority of the virtual cluster":     {vcPriority: "100", expected: 100},
+		"namespace lowers its priority":       {vcPriority: "100", nsPriority: "10", expected: 10},
+		"namespace cannot raise its priority": {vcPriority: "100", nsPriority: "1000", expected: 100},
+		"negative namespace priority":         {nsPriority: "-5", expected: -5},
+		"invalid priority":                    {vcPriority: "high", expectedErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			vc := &metav1.ObjectMeta{Namespace: "default", Name: "vc", Annotations: withPriority(tc.vcPriority)}
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Annotations: withPriority(tc.nsPriority)}}
+			got, err := GetSchedulingPriority(namespace, vc)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected priority %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestChangedSuperClusters(t *testing.T) {
+	for name, tc := range map[string]struct {
+		old, new map[string]int
+		expected []string
+	}{
+		"unchanged": {
+			old: map[string]int{"a": 1, "b": 2},
+			new: map[string]int{"a": 1, "b": 2},
+		},
+		"first schedule": {
+			new:      map[string]int{"b": 1, "a": 2},
+			expected: []string{"a", "b"},
+		},
+		"descheduled": {
+			old:      map[string]int{"a": 1},
+			expected: []string{"a"},
+		},
+		"moved and resized": {
+			old:      map[string]int{"a": 1, "b": 1, "c": 1},
+			new:      map[string]int{"a": 1, "b": 2, "d": 1},
+			expected: []string{"b", "c", "d"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := ChangedSuperClusters(tc.old, tc.new); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewPlacementChangedEvent(t *testing.T) {
+	event := NewPlacementChangedEvent("tenant-abcdef-vc", "ns", 0, "2022-01-01T00:00:00Z")
+	if event.Namespace != metav1.NamespaceDefault || event.Reason != utilconst.ReasonPlacementChanged {
+		t.Errorf("unexpected event %s/%s with reason %s", event.Namespace, event.Name, event.Reason)
+	}
+	if event.InvolvedObject.Kind != "Namespace" || event.InvolvedObject.Name != "tenant-abcdef-vc-ns" {
+		t.Errorf("unexpected involved object %+v", event.InvolvedObject)
+	}
+	expected := map[string]string{
+		"tenancy.x-k8s.io/cluster":      "tenant-abcdef-vc",
+		"tenancy.x-k8s.io/namespace":    "ns",
+		utilconst.LabelLastScheduleTime: "2022-01-01T00:00:00Z",
+	}
+	if !reflect.DeepEqual(event.Annotations, expected) {
+		t.Errorf("expected annotations %v, got %v", expected, event.Annotations)
+	}
+}
@@ -8,10 +8,13 @@ require (
 	github.com/go-logr/logr v0.4.0
 	github.com/go-logr/zapr v0.4.0
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/kubernetes-csi/external-snapshotter/client/v4 v4.2.0
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.13.0
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
+	github.com/prometheus/common v0.26.0
 	github.com/spf13/cobra v1.1.3
 	github.com/spf13/pflag v1.0.5
 	go.uber.org/zap v1.17.0
@@ -25,9 +28,12 @@ require (
 	k8s.io/code-generator v0.21.9
 	k8s.io/component-base v0.21.9
 	k8s.io/klog/v2 v2.9.0
+	k8s.io/kube-openapi v0.0.0-20211110012726-3cc51fd1e909
 	k8s.io/utils v0.0.0-20210527160623-6fdb442a123b
 	sigs.k8s.io/cluster-api v0.4.0-beta.0
 	sigs.k8s.io/controller-runtime v0.9.0
+	sigs.k8s.io/mcs-api v0.1.0
+	sigs.k8s.io/yaml v1.2.0
 )
 
 replace (
@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// conversion-gen generates the typed per-resource converters of the syncer conversion package.
+//
+// Every resource is listed once in resources. The generated downward converters wrap
+// Conversion.BuildSuperClusterObject, which handles the metadata and the ownership annotations of
+// all the resources alike. The generated upward converters copy the super object, reset its
+// metadata, record the super UID if asked to and then call the hand-written mutateVirtual<Name>
+// hook of the resource, if any.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"sort"
+	"text/template"
+)
+
+var imports = map[string]string{
+	"apiextensionsv1": "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1",
+	"client":          "sigs.k8s.io/controller-runtime/pkg/client",
+	"networkingv1":    "k8s.io/api/networking/v1",
+	"snapshotv1":      "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1",
+	"storagev1":       "k8s.io/api/storage/v1",
+	"v1":              "k8s.io/api/core/v1",
+	"v1scheduling":    "k8s.io/api/scheduling/v1",
+}
+
+// resource describes the converters of a resource.
+type resource struct {
+	// Name is used in the names of the converters, Type by default.
+	Name string
+	// Package is the import alias of the package of Type.
+	Package string
+	Type    string
+	// Downward generates BuildSuperCluster<Name>.
+	Downward bool
+	// Upward generates BuildVirtual<Name>.
+	Upward *upward
+}
+
+type upward struct {
+	// Cluster adds the name of the tenant cluster to the parameters.
+	Cluster bool
+	// Owner is the tenant object the tenant counterpart is bound to, if any.
+	Owner *param
+	// RecordUID records the UID of the super object in the tenant counterpart.
+	RecordUID bool
+	// Mutate calls mutateVirtual<Name> with the tenant counterpart, the cluster and the owner.
+	Mutate bool
+}
+
+type param struct {
+	Name    string
+	Package string
+	Type    string
+}
+
+var resources = []resource{
+	{Package: "v1", Type: "ConfigMap", Downward: true},
+	{Package: "v1", Type: "Endpoints", Downward: true},
+	{Package: "v1", Type: "Event", Upward: &upward{
+		Cluster: true,
+		Owner:   &param{Name: "vInvolvedObject", Package: "client", Type: "Object"},
+		Mutate:  true,
+	}},
+	{Package: "v1", Type: "PersistentVolume", Upward: &upward{
+		Owner:     &param{Name: "vPVC", Package: "v1", Type: "*PersistentVolumeClaim"},
+		RecordUID: true,
+		Mutate:    true,
+	}},
+	{Package: "v1", Type: "PersistentVolumeClaim", Downward: true},
+	{Package: "v1", Type: "Pod", Downward: true},
+	{Package: "v1", Type: "Secret", Downward: true},
+	{Package: "v1", Type: "Service", Downward: true},
+	{Package: "v1", Type: "ServiceAccount", Downward: true},
+	{Name: "CRD", Package: "apiextensionsv1", Type: "CustomResourceDefinition", Upward: &upward{Cluster: true, Mutate: true}},
+	{Package: "networkingv1", Type: "Ingress", Downward: true},
+	{Package: "v1scheduling", Type: "PriorityClass", Upward: &upward{Cluster: true}},
+	{Package: "storagev1", Type: "StorageClass", Upward: &upward{Cluster: true}},
+	{Package: "snapshotv1", Type: "VolumeSnapshot", Downward: true},
+	{Package: "snapshotv1", Type: "VolumeSnapshotClass", Upward: &upward{Cluster: true}},
+	{Package: "snapshotv1", Type: "VolumeSnapshotContent", Upward: &upward{
+		Owner:     &param{Name: "vSnapshot", Package: "snapshotv1", Type: "*VolumeSnapshot"},
+		RecordUID: true,
+		Mutate:    true,
+	}},
+}
+
+var convertersTemplate = template.Must(template.New("converters").Parse(`
+// Code generated by conversion-gen. DO NOT EDIT.
+
+package conversion
+
+import (
+{{- range .Imports }}
+	{{ .Alias }} "{{ .Path }}"
+{{- end }}
+)
+{{ range $r := .Resources }}{{ $type := printf "*%s.%s" .Package .Type }}{{ if .Downward }}
+// BuildSuperCluster{{ .Name }} builds the super control plane counterpart of a tenant control plane {{ .Type }}.
+func BuildSuperCluster{{ .Name }}(c Conversion, cluster string, vObj {{ $type }}) ({{ $type }}, error) {
+	pObj, err := c.BuildSuperClusterObject(cluster, vObj)
+	if err != nil {
+		return nil, err
+	}
+	return pObj.({{ $type }}), nil
+}
+{{ end }}{{ with .Upward }}
+// BuildVirtual{{ $r.Name }} builds the tenant control plane counterpart of a super control plane {{ $r.Type }}.
+func BuildVirtual{{ $r.Name }}({{ if .Cluster }}cluster string, {{ end }}pObj {{ $type }}{{ with .Owner }}, {{ .Name }} {{ .Ref }}{{ end }}) {{ $type }} {
+	vObj := pObj.DeepCopy()
+	ResetMetadata(vObj)
+{{- if .RecordUID }}
+	RecordSuperUID(vObj, pObj)
+{{- end }}
+{{- if .Mutate }}
+	mutateVirtual{{ $r.Name }}(vObj{{ if .Cluster }}, cluster{{ end }}{{ with .Owner }}, {{ .Name }}{{ end }})
+{{- end }}
+	return vObj
+}
+{{ end }}{{ end }}`))
+
+type importSpec struct {
+	Alias string
+	Path  string
+}
+
+// templateResource is a resource with its defaults applied.
+type templateResource struct {
+	resource
+	Upward *templateUpward
+}
+
+type templateUpward struct {
+	upward
+	Owner *templateParam
+}
+
+type templateParam struct {
+	param
+	Ref string
+}
+
+func (p param) ref() string {
+	if len(p.Type) > 0 && p.Type[0] == '*' {
+		return "*" + p.Package + "." + p.Type[1:]
+	}
+	return p.Package + "." + p.Type
+}
+
+// Generate renders the converters of resources, prefixed with header.
+func Generate(header []byte, resources []resource) ([]byte, error) {
+	aliases := map[string]bool{}
+	var rendered []templateResource
+	for _, r := range resources {
+		if _, ok := imports[r.Package]; !ok {
+			return nil, fmt.Errorf("unknown package %q of %s", r.Package, r.Type)
+		}
+		aliases[r.Package] = true
+		t := templateResource{resource: r}
+		if t.Name == "" {
+			t.Name = t.Type
+		}
+		if r.Upward != nil {
+			t.Upward = &templateUpward{upward: *r.Upward}
+			if o := r.Upward.Owner; o != nil {
+				if _, ok := imports[o.Package]; !ok {
+					return nil, fmt.Errorf("unknown package %q of the owner of %s", o.Package, r.Type)
+				}
+				aliases[o.Package] = true
+				t.Upward.Owner = &templateParam{param: *o, Ref: o.ref()}
+			}
+		}
+		rendered = append(rendered, t)
+	}
+	var specs []importSpec
+	for alias := range aliases {
+		specs = append(specs, importSpec{Alias: alias, Path: imports[alias]})
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Path < specs[j].Path })
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSpace(header))
+	buf.WriteString("\n")
+	if err := convertersTemplate.Execute(&buf, struct {
+		Imports   []importSpec
+		Resources []templateResource
+	}{specs, rendered}); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+func main() {
+	headerFile := flag.String("go-header-file", "../../../hack/boilerplate.go.txt", "The file of the header of the generated file")
+	output := flag.String("output", "zz_generated.conversion.go", "The generated file")
+	flag.Parse()
+
+	header, err := ioutil.ReadFile(*headerFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	content, err := Generate(header, resources)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(*output, content, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
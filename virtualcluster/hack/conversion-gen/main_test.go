@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGeneratedConvertersUpToDate(t *testing.T) {
+	header, err := ioutil.ReadFile("../boilerplate.go.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Generate(header, resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ioutil.ReadFile("../../pkg/syncer/conversion/zz_generated.conversion.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("zz_generated.conversion.go is out of date, run go generate ./pkg/syncer/conversion/...")
+	}
+}
+
+func TestGenerateUnknownPackage(t *testing.T) {
+	if _, err := Generate(nil, []resource{{Package: "appsv1", Type: "Deployment", Downward: true}}); err == nil {
+		t.Errorf("expected an unknown package to fail")
+	}
+}
@@ -21,6 +21,7 @@ readonly VC_ALL_TARGETS=(
   cmd/syncer
   cmd/vn-agent
   cmd/kubectl-vc
+  cmd/all-in-one
 )
 readonly VC_ALL_BINARIES=("${VC_ALL_TARGETS[@]##*/}")
 
@@ -0,0 +1,43 @@
+#!/usr/bin/env bash
+
+# Copyright 2022 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# dev-up.sh creates a kind cluster, installs the VirtualCluster CRDs and runs the vc-manager, syncer
+# and a stub vn-agent in one process against it. The arguments are passed to the all-in-one command.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+VC_ROOT="$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd -P)"
+KIND_CLUSTER_NAME=${KIND_CLUSTER_NAME:-"vc-dev"}
+KUBECONFIG_PATH=${KUBECONFIG_PATH:-"${VC_ROOT}/_output/kind-${KIND_CLUSTER_NAME}.kubeconfig"}
+
+command -v kind >/dev/null || { echo "kind is required, see https://kind.sigs.k8s.io/" >&2; exit 1; }
+command -v kubectl >/dev/null || { echo "kubectl is required" >&2; exit 1; }
+
+mkdir -p "$(dirname "${KUBECONFIG_PATH}")"
+if ! kind get clusters | grep -qx "${KIND_CLUSTER_NAME}"; then
+  kind create cluster --name "${KIND_CLUSTER_NAME}" --kubeconfig "${KUBECONFIG_PATH}"
+else
+  kind get kubeconfig --name "${KIND_CLUSTER_NAME}" > "${KUBECONFIG_PATH}"
+fi
+
+export KUBECONFIG="${KUBECONFIG_PATH}"
+kubectl apply -f "${VC_ROOT}/config/crd"
+
+echo "running the all-in-one command against kind cluster ${KIND_CLUSTER_NAME}, KUBECONFIG=${KUBECONFIG_PATH}"
+cd "${VC_ROOT}"
+exec go run ./cmd/all-in-one --kubeconfig "${KUBECONFIG_PATH}" "$@"
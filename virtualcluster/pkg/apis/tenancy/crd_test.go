@@ -0,0 +1,309 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenancy
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"sigs.k8s.io/yaml"
+)
+
+var crdDir = filepath.Join("..", "..", "..", "config", "crd")
+
+// loadSchema returns the validator of the CRD in file after checking its schema is structural.
+// The CEL rules are not evaluated, they are tested against the apiserver of the v1alpha1 envtest suite.
+func loadSchema(t *testing.T, file string) *validate.SchemaValidator {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join(crdDir, file))
+	if err != nil {
+		t.Fatalf("fail to read %s: %v", file, err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(data, crd); err != nil {
+		t.Fatalf("fail to decode %s: %v", file, err)
+	}
+
+	props := &apiextensions.JSONSchemaProps{}
+	if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(crd.Spec.Versions[0].Schema.OpenAPIV3Schema, props, nil); err != nil {
+		t.Fatalf("fail to convert the schema of %s: %v", file, err)
+	}
+	structural, err := schema.NewStructural(props)
+	if err != nil {
+		t.Fatalf("schema of %s is not structural: %v", file, err)
+	}
+	if errs := schema.ValidateStructural(nil, structural); len(errs) > 0 {
+		t.Fatalf("schema of %s is not structural: %v", file, errs.ToAggregate())
+	}
+
+	validator, _, err := validation.NewSchemaValidator(&apiextensions.CustomResourceValidation{OpenAPIV3Schema: props})
+	if err != nil {
+		t.Fatalf("fail to build the validator of %s: %v", file, err)
+	}
+	return validator
+}
+
+func readSample(t *testing.T, file string) map[string]interface{} {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "config", "sampleswithspec", file))
+	if err != nil {
+		t.Fatalf("fail to read %s: %v", file, err)
+	}
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		t.Fatalf("fail to decode %s: %v", file, err)
+	}
+	return obj
+}
+
+func decode(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(s), &obj); err != nil {
+		t.Fatalf("fail to decode %q: %v", s, err)
+	}
+	return obj
+}
+
+func TestClusterVersionSchema(t *testing.T) {
+	validator := loadSchema(t, "tenancy.x-k8s.io_clusterversions.yaml")
+
+	for _, sample := range []string{"clusterversion_v1_nodeport.yaml", "clusterversion_v1_loadbalancer.yaml"} {
+		if errs := validation.ValidateCustomResource(nil, readSample(t, sample), validator); len(errs) > 0 {
+			t.Errorf("sample %s is invalid: %v", sample, errs.ToAggregate())
+		}
+	}
+
+	apiServer := `
+  apiServer:
+    metadata:
+      name: apiserver
+    statefulset:
+      apiVersion: apps/v1
+      kind: StatefulSet
+      metadata:
+        name: apiserver
+      spec:
+        replicas: 1`
+	for _, tc := range []struct {
+		name  string
+		spec  string
+		valid bool
+	}{
+		{
+			name:  "minimal",
+			spec:  apiServer,
+			valid: true,
+		},
+		{
+			name: "all the fields",
+			spec: apiServer + `
+  addons:
+  - name: coredns
+    manifests: ""
+  hooks:
+  - name: register
+    point: PostCreate
+    failurePolicy: Fail
+    webhook:
+      url: https://example.com/register
+      timeoutSeconds: 5
+  highAvailability:
+    nodeAntiAffinity: Required
+    zoneSpread: None
+  security:
+    profile: Restricted
+    runAsUser: 1000
+  images:
+    overrides:
+    - component: etcd
+      digest: sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+    - architectures:
+        arm64: apiserver:1.22-arm64`,
+			valid: true,
+		},
+		{
+			name:  "missing apiServer",
+			spec:  "\n  addons: []",
+			valid: false,
+		},
+		{
+			name: "no replicas",
+			spec: `
+  apiServer:
+    statefulset:
+      apiVersion: apps/v1
+      kind: StatefulSet
+      spec:
+        replicas: 0`,
+			valid: false,
+		},
+		{
+			name:  "unknown failure policy",
+			spec:  apiServer + "\n  hooks:\n  - name: register\n    point: PostCreate\n    failurePolicy: Retry",
+			valid: false,
+		},
+		{
+			name:  "webhook timeout out of range",
+			spec:  apiServer + "\n  hooks:\n  - name: register\n    point: PreDelete\n    webhook:\n      url: https://example.com\n      timeoutSeconds: 60",
+			valid: false,
+		},
+		{
+			name:  "invalid digest",
+			spec:  apiServer + "\n  images:\n    overrides:\n    - digest: latest",
+			valid: false,
+		},
+		{
+			name:  "unknown security profile",
+			spec:  apiServer + "\n  security:\n    profile: Baseline",
+			valid: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := decode(t, "apiVersion: tenancy.x-k8s.io/v1alpha1\nkind: ClusterVersion\nmetadata:\n  name: cv\nspec:"+tc.spec)
+			errs := validation.ValidateCustomResource(nil, obj, validator)
+			if tc.valid && len(errs) > 0 {
+				t.Errorf("unexpected errors: %v", errs.ToAggregate())
+			}
+			if !tc.valid && len(errs) == 0 {
+				t.Errorf("expected the ClusterVersion to be invalid")
+			}
+		})
+	}
+}
+
+func TestVirtualClusterSchema(t *testing.T) {
+	validator := loadSchema(t, "tenancy.x-k8s.io_virtualclusters.yaml")
+
+	for _, sample := range []string{"virtualcluster_1_nodeport.yaml", "virtualcluster_1_loadbalancer.yaml"} {
+		if errs := validation.ValidateCustomResource(nil, readSample(t, sample), validator); len(errs) > 0 {
+			t.Errorf("sample %s is invalid: %v", sample, errs.ToAggregate())
+		}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		spec  string
+		valid bool
+	}{
+		{
+			name:  "minimal",
+			spec:  "clusterVersionName: cv",
+			valid: true,
+		},
+		{
+			name:  "empty cluster version",
+			spec:  `clusterVersionName: ""`,
+			valid: false,
+		},
+		{
+			name:  "negative pki expire days",
+			spec:  "clusterVersionName: cv\n  pkiExpireDays: -1",
+			valid: false,
+		},
+		{
+			name:  "invalid cluster domain",
+			spec:  "clusterVersionName: cv\n  clusterDomain: Cluster_Local",
+			valid: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := decode(t, "apiVersion: tenancy.x-k8s.io/v1alpha1\nkind: VirtualCluster\nmetadata:\n  name: vc\nspec:\n  "+tc.spec)
+			errs := validation.ValidateCustomResource(nil, obj, validator)
+			if tc.valid && len(errs) > 0 {
+				t.Errorf("unexpected errors: %v", errs.ToAggregate())
+			}
+			if !tc.valid && len(errs) == 0 {
+				t.Errorf("expected the VirtualCluster to be invalid")
+			}
+		})
+	}
+
+	obj := decode(t, "apiVersion: tenancy.x-k8s.io/v1alpha1\nkind: VirtualCluster\nmetadata:\n  name: vc\nspec:\n  clusterVersionName: cv\nstatus:\n  phase: Unknown")
+	if errs := validation.ValidateCustomResource(nil, obj, validator); len(errs) == 0 {
+		t.Errorf("expected an unknown phase to be invalid")
+	}
+}
+
+func TestTenantPodPolicySchema(t *testing.T) {
+	validator := loadSchema(t, "tenancy.x-k8s.io_tenantpodpolicies.yaml")
+
+	obj := decode(t, `
+apiVersion: tenancy.x-k8s.io/v1alpha1
+kind: TenantPodPolicy
+metadata:
+  name: gpu
+spec:
+  virtualClusterSelector:
+    matchLabels:
+      tier: gpu
+  nodeSelector:
+    pool: gpu
+  tolerations:
+  - key: nvidia.com/gpu
+    operator: Exists
+    effect: NoSchedule
+  runtimeClassName: nvidia`)
+	if errs := validation.ValidateCustomResource(nil, obj, validator); len(errs) > 0 {
+		t.Errorf("unexpected errors: %v", errs.ToAggregate())
+	}
+
+	obj = decode(t, "apiVersion: tenancy.x-k8s.io/v1alpha1\nkind: TenantPodPolicy\nmetadata:\n  name: gpu\nspec:\n  tolerations: gpu")
+	if errs := validation.ValidateCustomResource(nil, obj, validator); len(errs) == 0 {
+		t.Errorf("expected tolerations of the wrong type to be invalid")
+	}
+}
+
+func TestVirtualClusterSummarySchema(t *testing.T) {
+	validator := loadSchema(t, "tenancy.x-k8s.io_virtualclustersummaries.yaml")
+
+	obj := decode(t, `
+apiVersion: tenancy.x-k8s.io/v1alpha1
+kind: VirtualClusterSummary
+metadata:
+  name: vc-sample-1
+status:
+  phase: Running
+  controlPlaneHealthy: true
+  namespaces: 3
+  pods: 12
+  superClusters:
+  - id: r1
+    reachable: true
+    namespaces: 3
+    objects:
+      pods: 12
+      services: 4
+    requests:
+      cpu: 1500m
+      memory: 2Gi
+    lastUpdateTime: "2022-03-01T10:00:00Z"`)
+	if errs := validation.ValidateCustomResource(nil, obj, validator); len(errs) > 0 {
+		t.Errorf("unexpected errors: %v", errs.ToAggregate())
+	}
+
+	obj = decode(t, "apiVersion: tenancy.x-k8s.io/v1alpha1\nkind: VirtualClusterSummary\nmetadata:\n  name: vc-sample-1\nstatus:\n  superClusters:\n  - id: r1")
+	if errs := validation.ValidateCustomResource(nil, obj, validator); len(errs) == 0 {
+		t.Errorf("expected a super cluster summary without update time to be invalid")
+	}
+}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package v1alpha1
 
-import "fmt"
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // GetEtcdDomain returns the dns of etcd service, note that, though the
 // complete etcd svc dns is {etcdSvcName}.{namespace}.svc.{clusterdomain},
@@ -44,3 +48,44 @@ func (cv *ClusterVersion) GetEtcdServers() (etcdServers []string) {
 func (cv *ClusterVersion) GetAPIServerDomain(namespace string) string {
 	return cv.Spec.APIServer.Service.Name + "." + namespace
 }
+
+// Snapshot returns the replicas, images and args of the control plane
+// components deployed from the ClusterVersion, i.e. the components rendered
+// for a VirtualCluster rather than the ones defined in the spec
+func (cv *ClusterVersion) Snapshot(components ...*StatefulSetSvcBundle) *ClusterVersionSnapshot {
+	snapshot := &ClusterVersionSnapshot{
+		Name:            cv.Name,
+		ResourceVersion: cv.ResourceVersion,
+		AppliedTime:     metav1.Now(),
+	}
+	for _, bdl := range components {
+		if bdl == nil || bdl.StatefulSet == nil {
+			continue
+		}
+		component := ComponentSnapshot{Name: bdl.Name}
+		if bdl.StatefulSet.Spec.Replicas != nil {
+			replicas := *bdl.StatefulSet.Spec.Replicas
+			component.Replicas = &replicas
+		}
+		for _, c := range bdl.StatefulSet.Spec.Template.Spec.Containers {
+			component.Containers = append(component.Containers, ContainerSnapshot{
+				Name:    c.Name,
+				Image:   c.Image,
+				Command: append([]string(nil), c.Command...),
+				Args:    append([]string(nil), c.Args...),
+			})
+		}
+		snapshot.Components = append(snapshot.Components, component)
+	}
+	return snapshot
+}
+
+// GetComponent returns the snapshot of the component with the given name
+func (s *ClusterVersionSnapshot) GetComponent(name string) *ComponentSnapshot {
+	for i := range s.Components {
+		if s.Components[i].Name == name {
+			return &s.Components[i]
+		}
+	}
+	return nil
+}
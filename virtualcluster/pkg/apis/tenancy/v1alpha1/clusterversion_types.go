@@ -18,36 +18,371 @@ package v1alpha1
 
 import (
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // ClusterVersionSpec defines the desired state of ClusterVersion
 type ClusterVersionSpec struct {
-	// APIserver configuration of the virtual cluster
+	// APIserver configuration of the virtual cluster, it is always deployed
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="!has(self.metadata) || !has(self.metadata.name) || self.metadata.name == 'apiserver'",message="the apiServer bundle must be named apiserver"
 	APIServer *StatefulSetSvcBundle `json:"apiServer,omitempty"`
 
 	// Controller-manager configuration of the virtual cluster
+	// +kubebuilder:validation:XValidation:rule="!has(self.metadata) || !has(self.metadata.name) || self.metadata.name == 'controller-manager'",message="the controllerManager bundle must be named controller-manager"
+	// +optional
 	ControllerManager *StatefulSetSvcBundle `json:"controllerManager,omitempty"`
 
-	// ETCD configuration of the virtual cluster
+	// ETCD configuration of the virtual cluster, the number of replicas must
+	// be odd to keep a quorum
+	// +kubebuilder:validation:XValidation:rule="!has(self.metadata) || !has(self.metadata.name) || self.metadata.name == 'etcd'",message="the etcd bundle must be named etcd"
+	// +kubebuilder:validation:XValidation:rule="!has(self.statefulset) || !has(self.statefulset.spec) || !has(self.statefulset.spec.replicas) || self.statefulset.spec.replicas % 2 == 1",message="the number of etcd replicas must be odd"
+	// +optional
 	ETCD *StatefulSetSvcBundle `json:"etcd,omitempty"`
+
+	// Addons are applied into the tenant cluster once the apiserver is ready,
+	// e.g. the CoreDNS and kube-proxy ConfigMaps and Deployments
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Addons []AddonSpec `json:"addons,omitempty"`
+
+	// Hooks are executed by the controller at the lifecycle points of the
+	// virtual cluster, e.g. to register the tenant in external systems
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Hooks []LifecycleHook `json:"hooks,omitempty"`
+
+	// HighAvailability configures how the etcd and apiserver replicas are
+	// spread over the super control plane nodes and zones when there is more
+	// than one replica, both are spread in a best-effort manner if not set
+	// +optional
+	HighAvailability *HighAvailabilityPolicy `json:"highAvailability,omitempty"`
+
+	// APIServerScaling runs the apiserver with several replicas behind its
+	// Service, and optionally scales it with a HorizontalPodAutoscaler
+	// +optional
+	APIServerScaling *APIServerScalingPolicy `json:"apiServerScaling,omitempty"`
+
+	// Security configures the security contexts of the control plane
+	// components
+	// +optional
+	Security *SecurityPolicy `json:"security,omitempty"`
+
+	// Images overrides the images of the control plane containers, e.g. to
+	// pin them to digests or to use per-architecture images on super
+	// clusters with arm64 nodes
+	// +optional
+	Images *ImagePolicy `json:"images,omitempty"`
+
+	// Logging ships the logs of the control plane components to a sink with
+	// a sidecar container, labelled with the virtual cluster they belong to
+	// +optional
+	Logging *LoggingPolicy `json:"logging,omitempty"`
+
+	// SuperClusterRequirements are the prerequisites of the super cluster
+	// the virtual clusters are synced to, checked by the syncer before it
+	// syncs a virtual cluster
+	// +optional
+	SuperClusterRequirements *SuperClusterRequirements `json:"superClusterRequirements,omitempty"`
+}
+
+// ImagePolicy defines the image overrides of the control plane containers
+type ImagePolicy struct {
+	// Overrides are applied in order to the matching containers, a later
+	// override takes precedence over an earlier one
+	// +optional
+	Overrides []ImageOverride `json:"overrides,omitempty"`
+}
+
+// ImageOverride replaces the image of the matching control plane containers.
+// When Architectures is set, the architecture of the control plane is chosen
+// among the ones of the super cluster nodes and the pods are pinned to the
+// nodes of that architecture
+// +kubebuilder:validation:XValidation:rule="has(self.image) || has(self.architectures) || has(self.digest)",message="one of image, architectures and digest must be set"
+type ImageOverride struct {
+	// Component the override applies to, e.g. etcd, apiserver or
+	// controller-manager, all the components if empty
+	// +kubebuilder:validation:Enum="";etcd;apiserver;controller-manager
+	// +optional
+	Component string `json:"component,omitempty"`
+
+	// Container the override applies to, all the containers of the
+	// component if empty
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// Image replaces the image of the container, it is also used on the
+	// architectures missing from Architectures
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Architectures maps a node architecture, e.g. amd64 or arm64, to the
+	// image used on the nodes of that architecture
+	// +optional
+	Architectures map[string]string `json:"architectures,omitempty"`
+
+	// Digest pins Image, or the image of the ClusterVersion if Image is not
+	// set, to a digest, e.g. sha256:..., replacing its tag. The images of
+	// Architectures are expected to be pinned already
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]+([+._-][a-z0-9]+)*:[a-zA-Z0-9=_-]{32,}$`
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
+// SecurityProfile defines how the control plane pods are hardened
+// +kubebuilder:validation:Enum=Privileged;Restricted
+type SecurityProfile string
+
+const (
+	// SecurityProfilePrivileged leaves the StatefulSet templates untouched
+	SecurityProfilePrivileged SecurityProfile = "Privileged"
+	// SecurityProfileRestricted runs the components as non-root users with a
+	// read-only root filesystem, the RuntimeDefault seccomp profile, no
+	// capabilities and no privilege escalation, and refuses hostPath volumes,
+	// so the control plane can run in PSS-restricted or OpenShift namespaces
+	SecurityProfileRestricted SecurityProfile = "Restricted"
+)
+
+// SecurityPolicy defines the security contexts applied to the etcd,
+// apiserver and controller-manager StatefulSets, the fields already set in
+// the StatefulSet templates are left untouched
+type SecurityPolicy struct {
+	// Profile of the control plane pods, the default profile of the
+	// controller is used if not set
+	// +optional
+	Profile SecurityProfile `json:"profile,omitempty"`
+
+	// RunAsUser is the uid the components run as if the templates don't set
+	// one. Leave it unset on OpenShift, where the uid is assigned from the
+	// range of the namespace, and set it on other clusters when the images
+	// run as root by default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+
+	// FSGroup owns the mounted volumes if the templates don't set one,
+	// defaults to RunAsUser
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+}
+
+// SpreadPolicy defines how strictly the replicas of a component are spread
+// +kubebuilder:validation:Enum=Required;Preferred;None
+type SpreadPolicy string
+
+const (
+	// SpreadRequired refuses to schedule a replica that breaks the spreading
+	SpreadRequired SpreadPolicy = "Required"
+	// SpreadPreferred spreads the replicas on a best-effort basis
+	SpreadPreferred SpreadPolicy = "Preferred"
+	// SpreadNone does not inject any spreading constraint
+	SpreadNone SpreadPolicy = "None"
+)
+
+// HighAvailabilityPolicy defines the spreading constraints injected into the
+// etcd and apiserver StatefulSets, the constraints already defined in the
+// StatefulSet templates are left untouched
+type HighAvailabilityPolicy struct {
+	// NodeAntiAffinity keeps the replicas of a component off the same node,
+	// defaults to Preferred
+	// +optional
+	NodeAntiAffinity SpreadPolicy `json:"nodeAntiAffinity,omitempty"`
+
+	// ZoneSpread spreads the replicas of a component across zones, defaults
+	// to Preferred
+	// +optional
+	ZoneSpread SpreadPolicy `json:"zoneSpread,omitempty"`
+}
+
+// APIServerScalingPolicy defines the number of replicas of the apiserver.
+// The apiserver certificate is valid for every replica and each replica
+// balances its etcd connections over all the etcd members
+// +kubebuilder:validation:XValidation:rule="!has(self.maxReplicas) || self.maxReplicas >= self.minReplicas",message="maxReplicas must not be lower than minReplicas"
+type APIServerScalingPolicy struct {
+	// MinReplicas is the number of apiserver replicas, or the lower bound of
+	// the autoscaler if MaxReplicas is greater
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas int32 `json:"minReplicas"`
+
+	// MaxReplicas enables a HorizontalPodAutoscaler of the apiserver
+	// StatefulSet if greater than MinReplicas
+	// +optional
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+
+	// TargetCPUUtilizationPercentage is the average CPU utilization of the
+	// apiserver pods, relative to their requests, the autoscaler aims at.
+	// Defaults to 80 unless TargetInflightRequests is set
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// TargetInflightRequests is the average number of inflight requests per
+	// apiserver pod the autoscaler aims at, it requires a custom metrics
+	// adapter serving the apiserver_current_inflight_requests pod metric
+	// +optional
+	TargetInflightRequests *resource.Quantity `json:"targetInflightRequests,omitempty"`
+}
+
+// Autoscaled returns whether the apiserver is scaled by a HorizontalPodAutoscaler
+func (p *APIServerScalingPolicy) Autoscaled() bool {
+	return p != nil && p.MaxReplicas > p.MinReplicas
+}
+
+// HookPoint is a point in the lifecycle of a virtual cluster at which hooks
+// are executed
+// +kubebuilder:validation:Enum=PostCreate;PreDelete
+type HookPoint string
+
+const (
+	// HookPostCreate hooks are executed once the tenant apiserver is ready,
+	// the creation waits for the Job hooks to complete up to the provisioner
+	// timeout
+	HookPostCreate HookPoint = "PostCreate"
+	// HookPreDelete hooks are executed before the control plane is deleted,
+	// the deletion waits for the Job hooks to complete
+	HookPreDelete HookPoint = "PreDelete"
+)
+
+// HookFailurePolicy defines how the failure of a hook is handled
+// +kubebuilder:validation:Enum=Ignore;Fail
+type HookFailurePolicy string
+
+const (
+	// HookFailurePolicyIgnore records the failure and carries on
+	HookFailurePolicyIgnore HookFailurePolicy = "Ignore"
+	// HookFailurePolicyFail fails the creation, or blocks the deletion, of
+	// the virtual cluster
+	HookFailurePolicyFail HookFailurePolicy = "Fail"
+)
+
+// LifecycleHook defines a Job or a webhook executed at a lifecycle point,
+// exactly one of Job and Webhook must be set
+// +kubebuilder:validation:XValidation:rule="has(self.job) != has(self.webhook)",message="exactly one of job and webhook must be set"
+type LifecycleHook struct {
+	// Name of the hook, the status of the hook is recorded in the
+	// VirtualCluster condition of type HookConditionType(Name)
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Point at which the hook is executed
+	Point HookPoint `json:"point"`
+
+	// FailurePolicy defaults to Ignore
+	// +optional
+	FailurePolicy HookFailurePolicy `json:"failurePolicy,omitempty"`
+
+	// Job is run in the root namespace of the virtual cluster, the admin
+	// kubeconfig of the tenant cluster is pointed to by the KUBECONFIG env
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Job *batchv1.JobTemplateSpec `json:"job,omitempty"`
+
+	// Webhook is sent a POST request describing the virtual cluster
+	// +optional
+	Webhook *WebhookHook `json:"webhook,omitempty"`
+}
+
+// WebhookHook defines the endpoint of a webhook hook
+type WebhookHook struct {
+	// URL of the webhook, a non-2xx response fails the hook
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// TimeoutSeconds of the request, defaults to 10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// AddonSpec defines the manifests of an addon of the tenant cluster
+type AddonSpec struct {
+	// Name of the addon, the status of the addon is recorded in the
+	// VirtualCluster condition of type AddonConditionType(Name)
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Manifests is a multi-document YAML of the objects to apply into the
+	// tenant cluster, namespaced objects must specify the namespace
+	Manifests string `json:"manifests"`
 }
 
 // StatefulSetSvcBundle contains a StatefulSet and the Service that exposed
 // the StatefulSet
 type StatefulSetSvcBundle struct {
+	// The name of the bundle is the name of the component
+	// +kubebuilder:pruning:PreserveUnknownFields
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	// StatefulSet that manages the specified component
+	// StatefulSet that manages the specified component, only its replicas
+	// are validated, they must be positive
 	// +kubebuilder:validation:XEmbeddedResource
+	// +kubebuilder:pruning:PreserveUnknownFields
 	StatefulSet *appsv1.StatefulSet `json:"statefulset,omitempty"`
 
 	// Service that exposes the StatefulSet
 	// +kubebuilder:validation:XEmbeddedResource
+	// +kubebuilder:pruning:PreserveUnknownFields
 	Service *corev1.Service `json:"service,omitempty"`
 }
 
+// ClusterVersionSnapshot is the resolved content of a ClusterVersion at the
+// time it was applied to a VirtualCluster
+type ClusterVersionSnapshot struct {
+	// Name of the applied ClusterVersion
+	Name string `json:"name"`
+
+	// ResourceVersion of the ClusterVersion when it was applied
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// Time at which the ClusterVersion was applied
+	// +optional
+	AppliedTime metav1.Time `json:"appliedTime,omitempty"`
+
+	// Control plane components that were applied
+	// +optional
+	Components []ComponentSnapshot `json:"components,omitempty"`
+}
+
+// ComponentSnapshot records the replicas and containers of a control plane
+// component
+type ComponentSnapshot struct {
+	// Name of the component, e.g. etcd, apiserver or controller-manager
+	Name string `json:"name"`
+
+	// Replicas of the component StatefulSet
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Containers of the component
+	// +optional
+	Containers []ContainerSnapshot `json:"containers,omitempty"`
+}
+
+// ContainerSnapshot records the image and arguments of a component container
+type ContainerSnapshot struct {
+	// Name of the container
+	Name string `json:"name"`
+
+	// Image of the container
+	Image string `json:"image"`
+
+	// Command of the container
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args of the container
+	// +optional
+	Args []string `json:"args,omitempty"`
+}
+
 // ClusterVersionStatus defines the observed state of ClusterVersion
 type ClusterVersionStatus struct {
 }
@@ -82,3 +417,103 @@ type ClusterVersionList struct {
 func init() {
 	SchemeBuilder.Register(&ClusterVersion{}, &ClusterVersionList{})
 }
+
+// LogSinkType is the kind of sink the control plane logs are shipped to
+// +kubebuilder:validation:Enum=Stdout;Loki
+type LogSinkType string
+
+const (
+	// LogSinkStdout writes the logs as JSON lines with the virtual cluster
+	// labels to the stdout of the sidecar, for the node log collectors
+	LogSinkStdout LogSinkType = "Stdout"
+	// LogSinkLoki pushes the logs to a Loki server
+	LogSinkLoki LogSinkType = "Loki"
+)
+
+// LoggingPolicy defines how the logs of the control plane components are
+// shipped. The components write their logs to a volume shared with a
+// fluent-bit sidecar, in addition to their stderr
+type LoggingPolicy struct {
+	// Image of the fluent-bit sidecar, a default fluent-bit image is used
+	// if not set
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Components whose logs are shipped, all the components if empty
+	// +kubebuilder:validation:items:Enum=etcd;apiserver;controller-manager
+	// +optional
+	Components []string `json:"components,omitempty"`
+
+	// Sink the logs are shipped to
+	// +kubebuilder:validation:Required
+	Sink LogSink `json:"sink"`
+
+	// Resources of the sidecar container
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// LogSink defines where the control plane logs are shipped
+// +kubebuilder:validation:XValidation:rule="self.type != 'Loki' || has(self.url)",message="url must be set for the Loki sink"
+type LogSink struct {
+	// Type of the sink
+	// +kubebuilder:validation:Required
+	Type LogSinkType `json:"type"`
+
+	// URL of the Loki push API, e.g.
+	// http://loki.logging:3100/loki/api/v1/push
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// TenantID is the Loki tenant the logs are pushed as, if the Loki server
+	// is multi-tenant
+	// +optional
+	TenantID string `json:"tenantID,omitempty"`
+
+	// Labels are added to the virtual cluster and component labels of every
+	// log record
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ShipsLogs returns true if the logs of the component are shipped
+func (p *LoggingPolicy) ShipsLogs(component string) bool {
+	if p == nil {
+		return false
+	}
+	if len(p.Components) == 0 {
+		return true
+	}
+	for _, c := range p.Components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// SuperClusterRequirements defines the prerequisites a super cluster must
+// meet to sync a virtual cluster, e.g. so that a tenant relying on a CNI or
+// on storage classes is not half-working on a super cluster without them
+type SuperClusterRequirements struct {
+	// MinVersion is the oldest Kubernetes version of the super cluster, e.g.
+	// v1.21.0
+	// +kubebuilder:validation:Pattern=`^v?[0-9]+\.[0-9]+(\.[0-9]+)?$`
+	// +optional
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// NodeSelector requires a schedulable node of the super cluster with
+	// these labels, e.g. the label advertising the CNI of the nodes
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are the taints the tenant pods tolerate, a node selected by
+	// NodeSelector must have no other NoSchedule or NoExecute taint
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// StorageClasses are the names of the storage classes the super cluster
+	// must provide
+	// +optional
+	StorageClasses []string `json:"storageClasses,omitempty"`
+}
@@ -32,6 +32,9 @@ func TestStorageClusterVersion(t *testing.T) {
 	created := &ClusterVersion{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "foo",
+		},
+		Spec: ClusterVersionSpec{
+			APIServer: &StatefulSetSvcBundle{ObjectMeta: metav1.ObjectMeta{Name: "apiserver"}},
 		}}
 	g := gomega.NewGomegaWithT(t)
 
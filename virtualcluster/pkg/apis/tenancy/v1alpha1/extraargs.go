@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// The names of the control plane components, they are also the names of the
+// StatefulSetSvcBundles and of the component containers in a ClusterVersion.
+const (
+	ComponentAPIServer         = "apiserver"
+	ComponentControllerManager = "controller-manager"
+	ComponentETCD              = "etcd"
+)
+
+// ExtraArgsAllowList holds the flags that can be set through the extra args
+// of each control plane component. A pattern ending with "*" allows all the
+// flags with that prefix. Flags that affect the security of the control plane,
+// e.g. the certificates, authentication and authorization flags, are left out.
+var ExtraArgsAllowList = map[string][]string{
+	ComponentAPIServer: {
+		"feature-gates",
+		"runtime-config",
+		"audit-*",
+		"enable-admission-plugins",
+		"disable-admission-plugins",
+		"admission-control-config-file",
+		"max-requests-inflight",
+		"max-mutating-requests-inflight",
+		"request-timeout",
+		"min-request-timeout",
+		"event-ttl",
+		"default-not-ready-toleration-seconds",
+		"default-unreachable-toleration-seconds",
+		"service-node-port-range",
+		"oidc-*",
+		"v",
+	},
+	ComponentControllerManager: {
+		"feature-gates",
+		"controllers",
+		"concurrent-*",
+		"kube-api-qps",
+		"kube-api-burst",
+		"terminated-pod-gc-threshold",
+		"horizontal-pod-autoscaler-*",
+		"node-monitor-*",
+		"v",
+	},
+	ComponentETCD: {
+		"quota-backend-bytes",
+		"auto-compaction-*",
+		"snapshot-count",
+		"heartbeat-interval",
+		"election-timeout",
+		"max-request-bytes",
+		"log-level",
+	},
+}
+
+// ParseExtraArgsAllowList parses a comma separated list of component/flag
+// patterns, e.g. "apiserver/feature-gates,etcd/auto-compaction-*".
+func ParseExtraArgsAllowList(s string) (map[string][]string, error) {
+	allowList := make(map[string][]string)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "/", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("invalid extra args allow-list item %q, expected <component>/<flag>", item)
+		}
+		switch kv[0] {
+		case ComponentAPIServer, ComponentControllerManager, ComponentETCD:
+		default:
+			return nil, fmt.Errorf("unknown control plane component %q in extra args allow-list", kv[0])
+		}
+		allowList[kv[0]] = append(allowList[kv[0]], kv[1])
+	}
+	return allowList, nil
+}
+
+// ExtraArgAllowed returns true if flag can be set through the extra args of component.
+func ExtraArgAllowed(component, flag string) bool {
+	for _, pattern := range ExtraArgsAllowList[component] {
+		if pattern == flag || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(flag, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtraArgs returns the extra args of component, or nil if there are none.
+func (s *ControlPlaneSpec) ExtraArgs(component string) map[string]string {
+	if s == nil {
+		return nil
+	}
+	var c *ControlPlaneComponentSpec
+	switch component {
+	case ComponentAPIServer:
+		c = s.APIServer
+	case ComponentControllerManager:
+		c = s.ControllerManager
+	case ComponentETCD:
+		c = s.ETCD
+	}
+	if c == nil {
+		return nil
+	}
+	return c.ExtraArgs
+}
+
+// validateControlPlane checks the extra args of the components against the allow-list.
+func validateControlPlane(spec *ControlPlaneSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, c := range []struct{ component, name string }{
+		{ComponentAPIServer, "apiServer"},
+		{ComponentControllerManager, "controllerManager"},
+		{ComponentETCD, "etcd"},
+	} {
+		component := c.component
+		args := spec.ExtraArgs(component)
+		flags := make([]string, 0, len(args))
+		for flag := range args {
+			flags = append(flags, flag)
+		}
+		sort.Strings(flags)
+		for _, flag := range flags {
+			argPath := fldPath.Child(c.name, "extraArgs").Key(flag)
+			if strings.HasPrefix(flag, "-") {
+				allErrs = append(allErrs, field.Invalid(argPath, flag, "flag names must not start with dashes"))
+				continue
+			}
+			if !ExtraArgAllowed(component, flag) {
+				allErrs = append(allErrs, field.Forbidden(argPath, fmt.Sprintf("flag %q is not in the %s extra args allow-list", flag, component)))
+			}
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TenantPodPolicySpec defines the defaults injected into the super cluster pods of the
+// selected VirtualClusters.
+type TenantPodPolicySpec struct {
+	// VirtualClusterSelector selects the VirtualClusters in the namespace of the policy
+	// the policy applies to, an empty selector selects all of them.
+	// +optional
+	VirtualClusterSelector *metav1.LabelSelector `json:"virtualClusterSelector,omitempty"`
+
+	// NodeSelector is merged into the node selector of the pods, the keys defined
+	// here take precedence over the tenant ones.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are appended to the tolerations of the pods. They are also added to
+	// the existing pods when the policy changes.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// TopologySpreadConstraints are appended to the pods that don't have a constraint
+	// with the same topology key.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// RuntimeClassName is set on the pods that don't specify a runtime class.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+
+	// ExtendedResourceQuota caps the sum of the extended resources, e.g. nvidia.com/gpu,
+	// requested by the running pods of a VirtualCluster. The syncer doesn't create the pods
+	// exceeding it in the super cluster until enough resources are released. Other
+	// resources are ignored.
+	// +optional
+	ExtendedResourceQuota corev1.ResourceList `json:"extendedResourceQuota,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/client.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=tpp
+
+// TenantPodPolicy is the Schema for the tenantpodpolicies API, it injects default
+// scheduling constraints into every synced pod of the selected VirtualClusters.
+type TenantPodPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TenantPodPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/client.Object
+
+// TenantPodPolicyList contains a list of TenantPodPolicy
+type TenantPodPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TenantPodPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TenantPodPolicy{}, &TenantPodPolicyList{})
+}
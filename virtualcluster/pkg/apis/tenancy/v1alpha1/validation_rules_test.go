@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/yaml"
+)
+
+// requireValidationRules skips the test if the apiserver of the test environment doesn't enforce
+// the x-kubernetes-validations rules of the CRDs, they are enabled by default since 1.25.
+func requireValidationRules(t *testing.T) {
+	t.Helper()
+	info, err := discovery.NewDiscoveryClientForConfigOrDie(cfg).ServerVersion()
+	if err != nil {
+		t.Fatalf("fail to get the apiserver version: %v", err)
+	}
+	v, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		t.Fatalf("fail to parse the apiserver version %q: %v", info.GitVersion, err)
+	}
+	if !v.AtLeast(version.MustParseGeneric("1.25")) {
+		t.Skipf("kube-apiserver %s doesn't enforce the CRD validation rules, they require envtest 1.25 or later", info.GitVersion)
+	}
+}
+
+func decodeUnstructured(t *testing.T, s string) *unstructured.Unstructured {
+	t.Helper()
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(s), &obj.Object); err != nil {
+		t.Fatalf("fail to decode %q: %v", s, err)
+	}
+	return obj
+}
+
+// expectRule checks err is the rejection of the rule of message, or no error if message is empty.
+func expectRule(t *testing.T, err error, message string) {
+	t.Helper()
+	if message == "" {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	}
+	if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), message) {
+		t.Errorf("expected the rule %q to reject the object, got %v", message, err)
+	}
+}
+
+func TestClusterVersionValidationRules(t *testing.T) {
+	requireValidationRules(t)
+
+	apiServer := `
+  apiServer:
+    metadata:
+      name: apiserver`
+	for i, tc := range []struct {
+		name    string
+		spec    string
+		message string
+	}{
+		{
+			name: "minimal",
+			spec: apiServer,
+		},
+		{
+			name:    "misnamed apiServer",
+			spec:    "\n  apiServer:\n    metadata:\n      name: etcd",
+			message: "the apiServer bundle must be named apiserver",
+		},
+		{
+			name:    "misnamed controllerManager",
+			spec:    apiServer + "\n  controllerManager:\n    metadata:\n      name: apiserver",
+			message: "the controllerManager bundle must be named controller-manager",
+		},
+		{
+			name:    "misnamed etcd",
+			spec:    apiServer + "\n  etcd:\n    metadata:\n      name: apiserver",
+			message: "the etcd bundle must be named etcd",
+		},
+		{
+			name: "odd etcd replicas",
+			spec: apiServer + "\n  etcd:\n    statefulset:\n      apiVersion: apps/v1\n      kind: StatefulSet\n      spec:\n        replicas: 3",
+		},
+		{
+			name:    "even etcd replicas",
+			spec:    apiServer + "\n  etcd:\n    statefulset:\n      apiVersion: apps/v1\n      kind: StatefulSet\n      spec:\n        replicas: 2",
+			message: "the number of etcd replicas must be odd",
+		},
+		{
+			name: "scaling range",
+			spec: apiServer + "\n  apiServerScaling:\n    minReplicas: 2\n    maxReplicas: 2",
+		},
+		{
+			name:    "inverted scaling range",
+			spec:    apiServer + "\n  apiServerScaling:\n    minReplicas: 3\n    maxReplicas: 2",
+			message: "maxReplicas must not be lower than minReplicas",
+		},
+		{
+			name:    "hook without action",
+			spec:    apiServer + "\n  hooks:\n  - name: register\n    point: PostCreate",
+			message: "exactly one of job and webhook must be set",
+		},
+		{
+			name:    "hook with two actions",
+			spec:    apiServer + "\n  hooks:\n  - name: register\n    point: PostCreate\n    job: {}\n    webhook:\n      url: https://example.com/register",
+			message: "exactly one of job and webhook must be set",
+		},
+		{
+			name:    "empty image override",
+			spec:    apiServer + "\n  images:\n    overrides:\n    - component: etcd",
+			message: "one of image, architectures and digest must be set",
+		},
+		{
+			name: "loki sink",
+			spec: apiServer + "\n  logging:\n    sink:\n      type: Loki\n      url: http://loki:3100",
+		},
+		{
+			name:    "loki sink without url",
+			spec:    apiServer + "\n  logging:\n    sink:\n      type: Loki",
+			message: "url must be set for the Loki sink",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := decodeUnstructured(t, fmt.Sprintf("apiVersion: tenancy.x-k8s.io/v1alpha1\nkind: ClusterVersion\nmetadata:\n  name: rules-%d\nspec:%s", i, tc.spec))
+			err := c.Create(context.TODO(), obj)
+			expectRule(t, err, tc.message)
+			if err == nil {
+				_ = c.Delete(context.TODO(), obj)
+			}
+		})
+	}
+}
+
+func TestVirtualClusterValidationRules(t *testing.T) {
+	requireValidationRules(t)
+
+	for i, tc := range []struct {
+		name    string
+		spec    string
+		message string
+	}{
+		{
+			name: "flag names",
+			spec: "\n  controlPlane:\n    apiServer:\n      extraArgs:\n        v: \"4\"",
+		},
+		{
+			name:    "dashed apiServer flag",
+			spec:    "\n  controlPlane:\n    apiServer:\n      extraArgs:\n        --v: \"4\"",
+			message: "flag names must not start with dashes",
+		},
+		{
+			name:    "dashed controllerManager flag",
+			spec:    "\n  controlPlane:\n    controllerManager:\n      extraArgs:\n        -v: \"4\"",
+			message: "flag names must not start with dashes",
+		},
+		{
+			name:    "dashed etcd flag",
+			spec:    "\n  controlPlane:\n    etcd:\n      extraArgs:\n        --snapshot-count: \"1000\"",
+			message: "flag names must not start with dashes",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := decodeUnstructured(t, fmt.Sprintf("apiVersion: tenancy.x-k8s.io/v1alpha1\nkind: VirtualCluster\nmetadata:\n  name: rules-%d\n  namespace: default\nspec:\n  clusterVersionName: cv%s", i, tc.spec))
+			err := c.Create(context.TODO(), obj)
+			expectRule(t, err, tc.message)
+			if err == nil {
+				_ = c.Delete(context.TODO(), obj)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name    string
+		field   []string
+		message string
+	}{
+		{
+			name:    "root namespace name",
+			field:   []string{"spec", "rootNamespace", "name"},
+			message: "name is immutable",
+		},
+		{
+			name:    "cluster namespace",
+			field:   []string{"status", "clusterNamespace"},
+			message: "clusterNamespace is immutable",
+		},
+	} {
+		t.Run(tc.name+" is immutable", func(t *testing.T) {
+			obj := decodeUnstructured(t, "apiVersion: tenancy.x-k8s.io/v1alpha1\nkind: VirtualCluster\nmetadata:\n  name: rules-immutable\n  namespace: default\nspec:\n  clusterVersionName: cv")
+			if err := unstructured.SetNestedField(obj.Object, "tenant-a", tc.field...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := c.Create(context.TODO(), obj); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer func() { _ = c.Delete(context.TODO(), obj) }()
+
+			// updating another field keeps the value.
+			if err := unstructured.SetNestedField(obj.Object, "cv2", "spec", "clusterVersionName"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectRule(t, c.Update(context.TODO(), obj), "")
+
+			if err := unstructured.SetNestedField(obj.Object, "tenant-b", tc.field...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectRule(t, c.Update(context.TODO(), obj), tc.message)
+		})
+	}
+}
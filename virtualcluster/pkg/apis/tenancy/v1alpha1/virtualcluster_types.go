@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -26,14 +27,18 @@ type VirtualClusterSpec struct {
 	// ClusterDomain is the domain name of the virtual cluster
 	// e.g. a pod dns will be
 	// {some-pod}.{some-namespace}.svc.{ClusterDomain}
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +kubebuilder:validation:MaxLength=253
 	// +optional
 	ClusterDomain string `json:"clusterDomain,omitempty"`
 
 	// The name of the desired cluster version
+	// +kubebuilder:validation:MinLength=1
 	ClusterVersionName string `json:"clusterVersionName"`
 
 	// The valid period of the tenant cluster PKI, if not set
 	// the PKI will never expire (i.e. 10 years)
+	// +kubebuilder:validation:Minimum=0
 	// +optional
 	PKIExpireDays int64 `json:"pkiExpireDays,omitempty"`
 
@@ -51,6 +56,162 @@ type VirtualClusterSpec struct {
 	// Service CIDRs used by VirtualCluster
 	// +optional
 	ServiceCidr string `json:"serviceCidr,omitempty"`
+
+	// Placement constrains the super control plane nodes the tenant control
+	// plane pods are scheduled to, it is injected into the StatefulSets of
+	// the ClusterVersion.
+	// +optional
+	Placement *ControlPlanePlacement `json:"placement,omitempty"`
+
+	// ETCDStorage overrides the storage requested by the etcd volume claim
+	// templates of the ClusterVersion. It can be increased, but not decreased,
+	// on a running cluster if the storage class allows volume expansion.
+	// +optional
+	ETCDStorage *resource.Quantity `json:"etcdStorage,omitempty"`
+
+	// ControlPlane customizes the control plane components of the
+	// ClusterVersion for this cluster.
+	// +optional
+	ControlPlane *ControlPlaneSpec `json:"controlPlane,omitempty"`
+
+	// StorageQuota limits the persistent volume claims of the tenant across
+	// all its namespaces. It is enforced by the syncer when the claims are
+	// synced to the super control plane.
+	// +optional
+	StorageQuota *StorageQuota `json:"storageQuota,omitempty"`
+
+	// ObjectLimits caps the number of tenant objects synced to the super
+	// control plane, to protect it from runaway tenant controllers. It is
+	// enforced by the syncer when the objects are created.
+	// +optional
+	ObjectLimits *ObjectLimits `json:"objectLimits,omitempty"`
+
+	// RootNamespace customizes the root namespace of the cluster on the meta
+	// control plane, where the tenant control plane is deployed into.
+	// +optional
+	RootNamespace *RootNamespaceSpec `json:"rootNamespace,omitempty"`
+
+	// SuperClusterRequirements are added to the super cluster requirements of
+	// the ClusterVersion: the minimum version overrides it, the node selector
+	// labels take precedence and the tolerations and storage classes are
+	// appended
+	// +optional
+	SuperClusterRequirements *SuperClusterRequirements `json:"superClusterRequirements,omitempty"`
+}
+
+// RootNamespaceSpec defines the root namespace of a virtual cluster
+type RootNamespaceSpec struct {
+	// Name is a pre-created namespace adopted as the root namespace instead of
+	// generating one. It must exist when the cluster is created, can't belong
+	// to another virtual cluster and is not deleted with the cluster.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="name is immutable"
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Labels are added to the root namespace.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the root namespace, they can't override the
+	// annotations identifying the virtual cluster.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ResourceQuota is the spec of a ResourceQuota created in the root namespace.
+	// +optional
+	ResourceQuota *corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+}
+
+// StorageQuota defines the storage the persistent volume claims of a tenant can request
+type StorageQuota struct {
+	// Requests is the total storage requested by the claims of the tenant.
+	// +optional
+	Requests *resource.Quantity `json:"requests,omitempty"`
+
+	// StorageClasses limits the claims of each storage class, keyed by the
+	// storage class name.
+	// +optional
+	StorageClasses map[string]StorageClassQuota `json:"storageClasses,omitempty"`
+}
+
+// StorageClassQuota defines the storage the persistent volume claims of a tenant can request from
+// a storage class
+type StorageClassQuota struct {
+	// Requests is the total storage requested by the claims of the storage class.
+	// +optional
+	Requests *resource.Quantity `json:"requests,omitempty"`
+
+	// Claims is the number of claims of the storage class.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Claims *int32 `json:"claims,omitempty"`
+}
+
+// ObjectLimits defines the number of objects a tenant can sync to the super control plane
+type ObjectLimits struct {
+	// Pods is the number of pods of the tenant.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Pods *int32 `json:"pods,omitempty"`
+
+	// Services is the number of services of the tenant.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Services *int32 `json:"services,omitempty"`
+
+	// Total is the number of namespaced objects of the tenant across all
+	// the synced resources.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Total *int32 `json:"total,omitempty"`
+}
+
+// ControlPlaneSpec customizes the tenant control plane components
+type ControlPlaneSpec struct {
+	// +optional
+	APIServer *ControlPlaneComponentSpec `json:"apiServer,omitempty"`
+
+	// +optional
+	ControllerManager *ControlPlaneComponentSpec `json:"controllerManager,omitempty"`
+
+	// +optional
+	ETCD *ControlPlaneComponentSpec `json:"etcd,omitempty"`
+}
+
+// ControlPlaneComponentSpec customizes one tenant control plane component
+type ControlPlaneComponentSpec struct {
+	// ExtraArgs are the flags passed to the component, keyed by the flag name
+	// without the leading dashes, e.g. {"feature-gates": "Foo=true"}. They
+	// override the flags of the ClusterVersion, and only the flags in the
+	// extra args allow-list of the vc-manager are accepted.
+	// +kubebuilder:validation:MaxProperties=64
+	// +kubebuilder:validation:XValidation:rule="self.all(flag, !flag.startsWith('-'))",message="flag names must not start with dashes"
+	// +optional
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+}
+
+// ControlPlanePlacement defines the scheduling constraints of the tenant control plane pods
+type ControlPlanePlacement struct {
+	// NodeSelector is merged into the node selector of the control plane pods,
+	// the keys defined here take precedence over the ClusterVersion ones.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are appended to the tolerations of the control plane pods.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// TopologySpreadConstraints are appended to the topology spread constraints
+	// of the control plane pods. A constraint without labelSelector selects the
+	// pods of the same control plane component.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName overrides the priority class of the control plane pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
 // VirtualClusterStatus defines the observed state of VirtualCluster
@@ -59,7 +220,8 @@ type VirtualClusterStatus struct {
 	Phase ClusterPhase `json:"phase"`
 
 	// ClusterNamespace defines the namespace where the control plane components
-	// are deployed into.
+	// are deployed into, it can't be changed once set.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="clusterNamespace is immutable"
 	// +optional
 	ClusterNamespace string `json:"clusterNamespace,omitempty"`
 
@@ -76,8 +238,25 @@ type VirtualClusterStatus struct {
 
 	// Cluster Conditions
 	Conditions []ClusterCondition `json:"conditions,omitempty"`
+
+	// AppliedClusterVersion records the resolved ClusterVersion that was last
+	// applied to the tenant control plane, so that later edits to the shared
+	// ClusterVersion do not change what this cluster is supposed to run.
+	// +optional
+	AppliedClusterVersion *ClusterVersionSnapshot `json:"appliedClusterVersion,omitempty"`
+
+	// KubernetesVersion is the git version reported by the /version endpoint
+	// of the tenant apiserver, i.e. the version that is actually running.
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
+	// ControlPlaneHealthy reports whether the /readyz endpoint of the tenant
+	// apiserver succeeded the last time it was probed.
+	// +optional
+	ControlPlaneHealthy *bool `json:"controlPlaneHealthy,omitempty"`
 }
 
+// +kubebuilder:validation:Enum="";Pending;Running;Updating;Error
 type ClusterPhase string
 
 const (
@@ -95,7 +274,52 @@ const (
 	ClusterError ClusterPhase = "Error"
 )
 
+// ETCDStorageExpandedCondition records the progress of the expansion of the etcd
+// persistent volume claims to VirtualClusterSpec.ETCDStorage
+const ETCDStorageExpandedCondition = "ETCDStorageExpanded"
+
+// ETCDStoragePressureCondition is true while the etcd database approaches its backend quota,
+// the tenant apiserver rejects writes once the quota is exceeded
+const ETCDStoragePressureCondition = "ETCDStoragePressure"
+
+// PatrolBudgetExceededCondition is true while the syncer patrollers stopped remediating the
+// tenant cluster because the remediation actions exceeded the PatrolRemediationBudget
+const PatrolBudgetExceededCondition = "PatrolRemediationBudgetExceeded"
+
+// ObjectLimitExceededCondition is true while the syncer refuses to create tenant objects in the
+// super control plane because they exceed the ObjectLimits of the VirtualCluster
+const ObjectLimitExceededCondition = "ObjectLimitExceeded"
+
+// TenantCanaryCondition records whether a canary pod could be created, synced, scheduled, run
+// and streamed the logs of through the tenant apiserver once the cluster is running
+const TenantCanaryCondition = "TenantCanarySucceeded"
+
+// SuperClusterNotReadyCondition is true while the syncer doesn't sync the cluster because the super
+// cluster doesn't meet the SuperClusterRequirements, its reason is the first requirement unmet
+const SuperClusterNotReadyCondition = "SuperClusterNotReady"
+
+// ReconcileFailedCondition is true while the last create, upgrade or delete of the control plane
+// failed, its reason is the category of the error: UserError, TransientInfra or Bug
+const ReconcileFailedCondition = "ReconcileFailed"
+
+// AddonConditionType returns the type of the condition that records the status
+// of the named ClusterVersion addon
+func AddonConditionType(name string) string {
+	return "AddonReady/" + name
+}
+
+// HookConditionType returns the type of the condition that records the status
+// of the named ClusterVersion lifecycle hook
+func HookConditionType(name string) string {
+	return "HookSucceeded/" + name
+}
+
 type ClusterCondition struct {
+	// Type of the condition, conditions without type record the phase
+	// transitions of the cluster
+	// +optional
+	Type string `json:"type,omitempty"`
+
 	// Cluster Condition Status
 	// Can be True, False, Unknown.
 	Status corev1.ConditionStatus `json:"status"`
@@ -125,6 +349,8 @@ type ClusterCondition struct {
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
 // +kubebuilder:printcolumn:name="ClusterVersion",type="string",JSONPath=".spec.clusterVersionName"
 // +kubebuilder:printcolumn:name="ClusterNamespace",type="string",JSONPath=".status.clusterNamespace",priority=1
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.kubernetesVersion",priority=1
+// +kubebuilder:printcolumn:name="Healthy",type="boolean",JSONPath=".status.controlPlaneHealthy",priority=1
 type VirtualCluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -34,6 +34,9 @@ func TestStorageVirtualCluster(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "foo",
 			Namespace: "default",
+		},
+		Spec: VirtualClusterSpec{
+			ClusterVersionName: "cv",
 		}}
 	g := gomega.NewGomegaWithT(t)
 
@@ -18,11 +18,17 @@ package v1alpha1
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -42,7 +48,16 @@ var _ webhook.Validator = &VirtualCluster{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (vc *VirtualCluster) ValidateCreate() error {
 	vclog.Info("validate create", "vc-name", vc.Name)
-	// do nothing for delete request
+	allErrs := validateControlPlane(vc.Spec.ControlPlane, field.NewPath("spec").Child("controlPlane"))
+	allErrs = append(allErrs, validateStorageQuota(vc.Spec.StorageQuota, field.NewPath("spec").Child("storageQuota"))...)
+	allErrs = append(allErrs, validateObjectLimits(vc.Spec.ObjectLimits, field.NewPath("spec").Child("objectLimits"))...)
+	allErrs = append(allErrs, validateRootNamespace(vc.Spec.RootNamespace, field.NewPath("spec").Child("rootNamespace"))...)
+	allErrs = append(allErrs, validateSuperClusterRequirements(vc.Spec.SuperClusterRequirements, field.NewPath("spec").Child("superClusterRequirements"))...)
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "VirtualCluster"},
+			vc.Name, allErrs)
+	}
 	return nil
 }
 
@@ -74,5 +89,117 @@ func (vc *VirtualCluster) validateVirtualClusterUpdate(old runtime.Object) error
 			schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "VirtualCluster"},
 			vc.Name, allErrs)
 	}
+	// the etcd storage can only be expanded
+	if oldVC.Spec.ETCDStorage != nil {
+		fldPath := field.NewPath("spec").Child("etcdStorage")
+		switch {
+		case vc.Spec.ETCDStorage == nil:
+			allErrs = append(allErrs,
+				field.Invalid(fldPath, nil, "cannot unset virtualcluster.Spec.ETCDStorage"))
+		case vc.Spec.ETCDStorage.Cmp(*oldVC.Spec.ETCDStorage) < 0:
+			allErrs = append(allErrs,
+				field.Invalid(fldPath, vc.Spec.ETCDStorage.String(),
+					fmt.Sprintf("cannot shrink virtualcluster.Spec.ETCDStorage from %s", oldVC.Spec.ETCDStorage.String())))
+		}
+	}
+	allErrs = append(allErrs, validateControlPlane(vc.Spec.ControlPlane, field.NewPath("spec").Child("controlPlane"))...)
+	allErrs = append(allErrs, validateStorageQuota(vc.Spec.StorageQuota, field.NewPath("spec").Child("storageQuota"))...)
+	allErrs = append(allErrs, validateObjectLimits(vc.Spec.ObjectLimits, field.NewPath("spec").Child("objectLimits"))...)
+	// the root namespace can't be changed once adopted
+	if rootNamespaceName(vc) != rootNamespaceName(oldVC) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec").Child("rootNamespace").Child("name"),
+				rootNamespaceName(vc), "cannot change virtualcluster.Spec.RootNamespace.Name"))
+	}
+	allErrs = append(allErrs, validateRootNamespace(vc.Spec.RootNamespace, field.NewPath("spec").Child("rootNamespace"))...)
+	allErrs = append(allErrs, validateSuperClusterRequirements(vc.Spec.SuperClusterRequirements, field.NewPath("spec").Child("superClusterRequirements"))...)
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "VirtualCluster"},
+			vc.Name, allErrs)
+	}
 	return nil
 }
+
+func rootNamespaceName(vc *VirtualCluster) string {
+	if vc.Spec.RootNamespace == nil {
+		return ""
+	}
+	return vc.Spec.RootNamespace.Name
+}
+
+// validateRootNamespace checks the name, labels and annotations of the root namespace.
+func validateRootNamespace(rootNS *RootNamespaceSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if rootNS == nil {
+		return allErrs
+	}
+	if rootNS.Name != "" {
+		for _, msg := range validation.IsDNS1123Label(rootNS.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), rootNS.Name, msg))
+		}
+	}
+	allErrs = append(allErrs, metav1validation.ValidateLabels(rootNS.Labels, fldPath.Child("labels"))...)
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(rootNS.Annotations, fldPath.Child("annotations"))...)
+	return allErrs
+}
+
+// validateObjectLimits checks that the object limits are not negative.
+func validateObjectLimits(limits *ObjectLimits, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if limits == nil {
+		return allErrs
+	}
+	for _, limit := range []struct {
+		name  string
+		value *int32
+	}{{"pods", limits.Pods}, {"services", limits.Services}, {"total", limits.Total}} {
+		if limit.value != nil && *limit.value < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(limit.name), *limit.value, "must be greater than or equal to 0"))
+		}
+	}
+	return allErrs
+}
+
+// validateSuperClusterRequirements checks that the minimum version is a version and the node
+// selector labels are valid.
+func validateSuperClusterRequirements(req *SuperClusterRequirements, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if req == nil {
+		return allErrs
+	}
+	if req.MinVersion != "" {
+		if _, err := version.ParseGeneric(req.MinVersion); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("minVersion"), req.MinVersion, err.Error()))
+		}
+	}
+	allErrs = append(allErrs, metav1validation.ValidateLabels(req.NodeSelector, fldPath.Child("nodeSelector"))...)
+	return allErrs
+}
+
+// validateStorageQuota checks that the storage quota has no negative limits.
+func validateStorageQuota(quota *StorageQuota, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if quota == nil {
+		return allErrs
+	}
+	if quota.Requests != nil && quota.Requests.Sign() < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("requests"), quota.Requests.String(), "must be greater than or equal to 0"))
+	}
+	classes := make([]string, 0, len(quota.StorageClasses))
+	for class := range quota.StorageClasses {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		classQuota := quota.StorageClasses[class]
+		classPath := fldPath.Child("storageClasses").Key(class)
+		if classQuota.Requests != nil && classQuota.Requests.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(classPath.Child("requests"), classQuota.Requests.String(), "must be greater than or equal to 0"))
+		}
+		if classQuota.Claims != nil && *classQuota.Claims < 0 {
+			allErrs = append(allErrs, field.Invalid(classPath.Child("claims"), *classQuota.Claims, "must be greater than or equal to 0"))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VirtualClusterSummaryStatus is the state of a VirtualCluster across the super clusters
+// syncing it.
+type VirtualClusterSummaryStatus struct {
+	// Phase is the phase of the VirtualCluster.
+	// +optional
+	Phase ClusterPhase `json:"phase,omitempty"`
+
+	// ControlPlaneHealthy is the health of the tenant apiserver reported by the
+	// VirtualCluster.
+	// +optional
+	ControlPlaneHealthy *bool `json:"controlPlaneHealthy,omitempty"`
+
+	// Namespaces is the number of tenant namespaces synced to the super clusters.
+	// +optional
+	Namespaces int32 `json:"namespaces,omitempty"`
+
+	// Pods is the number of tenant pods synced to the super clusters.
+	// +optional
+	Pods int32 `json:"pods,omitempty"`
+
+	// SuperClusters are the summaries written by the syncers of the VirtualCluster,
+	// sorted by super cluster id.
+	// +optional
+	SuperClusters []SuperClusterSummary `json:"superClusters,omitempty"`
+}
+
+// SuperClusterSummary is the state of a VirtualCluster in one super cluster.
+type SuperClusterSummary struct {
+	// ID is the id of the super cluster, it is empty if super cluster pooling
+	// is disabled.
+	// +optional
+	ID string `json:"id,omitempty"`
+
+	// Reachable reports whether the syncer reached the tenant apiserver at its last
+	// health check.
+	Reachable bool `json:"reachable"`
+
+	// Namespaces is the number of tenant namespaces placed in the super cluster.
+	// +optional
+	Namespaces int32 `json:"namespaces,omitempty"`
+
+	// Objects is the number of synced objects in the super cluster by resource.
+	// +optional
+	Objects map[string]int32 `json:"objects,omitempty"`
+
+	// Requests and Limits are the sum of the resource requests and limits of the
+	// pending and running pods.
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	// +optional
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+
+	// Usage is the resource usage of the pods, it is empty if the super cluster
+	// metrics API is unavailable.
+	// +optional
+	Usage corev1.ResourceList `json:"usage,omitempty"`
+
+	// LastUpdateTime is the last time the syncer updated the summary.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/client.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vcs
+
+// VirtualClusterSummary is the Schema for the virtualclustersummaries API, a read-only
+// summary of the VirtualCluster of the same name written by the syncers.
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Healthy",type="boolean",JSONPath=".status.controlPlaneHealthy"
+// +kubebuilder:printcolumn:name="Namespaces",type="integer",JSONPath=".status.namespaces"
+// +kubebuilder:printcolumn:name="Pods",type="integer",JSONPath=".status.pods"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type VirtualClusterSummary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status VirtualClusterSummaryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/client.Object
+
+// VirtualClusterSummaryList contains a list of VirtualClusterSummary
+type VirtualClusterSummaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VirtualClusterSummary `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VirtualClusterSummary{}, &VirtualClusterSummaryList{})
+}
@@ -31,10 +31,18 @@ func (c *FakeTenancyV1alpha1) ClusterVersions() v1alpha1.ClusterVersionInterface
 	return &FakeClusterVersions{c}
 }
 
+func (c *FakeTenancyV1alpha1) TenantPodPolicies(namespace string) v1alpha1.TenantPodPolicyInterface {
+	return &FakeTenantPodPolicies{c, namespace}
+}
+
 func (c *FakeTenancyV1alpha1) VirtualClusters(namespace string) v1alpha1.VirtualClusterInterface {
 	return &FakeVirtualClusters{c, namespace}
 }
 
+func (c *FakeTenancyV1alpha1) VirtualClusterSummaries(namespace string) v1alpha1.VirtualClusterSummaryInterface {
+	return &FakeVirtualClusterSummaries{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeTenancyV1alpha1) RESTClient() rest.Interface {
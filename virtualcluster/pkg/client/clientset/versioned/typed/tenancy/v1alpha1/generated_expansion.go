@@ -19,4 +19,8 @@ package v1alpha1
 
 type ClusterVersionExpansion interface{}
 
+type TenantPodPolicyExpansion interface{}
+
 type VirtualClusterExpansion interface{}
+
+type VirtualClusterSummaryExpansion interface{}
@@ -26,7 +26,9 @@ import (
 type TenancyV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	ClusterVersionsGetter
+	TenantPodPoliciesGetter
 	VirtualClustersGetter
+	VirtualClusterSummariesGetter
 }
 
 // TenancyV1alpha1Client is used to interact with features provided by the tenancy.x-k8s.io group.
@@ -38,10 +40,18 @@ func (c *TenancyV1alpha1Client) ClusterVersions() ClusterVersionInterface {
 	return newClusterVersions(c)
 }
 
+func (c *TenancyV1alpha1Client) TenantPodPolicies(namespace string) TenantPodPolicyInterface {
+	return newTenantPodPolicies(c, namespace)
+}
+
 func (c *TenancyV1alpha1Client) VirtualClusters(namespace string) VirtualClusterInterface {
 	return newVirtualClusters(c, namespace)
 }
 
+func (c *TenancyV1alpha1Client) VirtualClusterSummaries(namespace string) VirtualClusterSummaryInterface {
+	return newVirtualClusterSummaries(c, namespace)
+}
+
 // NewForConfig creates a new TenancyV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*TenancyV1alpha1Client, error) {
 	config := *c
@@ -54,8 +54,12 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=tenancy.x-k8s.io, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("clusterversions"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ClusterVersions().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("tenantpodpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().TenantPodPolicies().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("virtualclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().VirtualClusters().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("virtualclustersummaries"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().VirtualClusterSummaries().Informer()}, nil
 
 	}
 
@@ -25,8 +25,12 @@ import (
 type Interface interface {
 	// ClusterVersions returns a ClusterVersionInformer.
 	ClusterVersions() ClusterVersionInformer
+	// TenantPodPolicies returns a TenantPodPolicyInformer.
+	TenantPodPolicies() TenantPodPolicyInformer
 	// VirtualClusters returns a VirtualClusterInformer.
 	VirtualClusters() VirtualClusterInformer
+	// VirtualClusterSummaries returns a VirtualClusterSummaryInformer.
+	VirtualClusterSummaries() VirtualClusterSummaryInformer
 }
 
 type version struct {
@@ -45,7 +49,17 @@ func (v *version) ClusterVersions() ClusterVersionInformer {
 	return &clusterVersionInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// TenantPodPolicies returns a TenantPodPolicyInformer.
+func (v *version) TenantPodPolicies() TenantPodPolicyInformer {
+	return &tenantPodPolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // VirtualClusters returns a VirtualClusterInformer.
 func (v *version) VirtualClusters() VirtualClusterInformer {
 	return &virtualClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// VirtualClusterSummaries returns a VirtualClusterSummaryInformer.
+func (v *version) VirtualClusterSummaries() VirtualClusterSummaryInformer {
+	return &virtualClusterSummaryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -21,6 +21,14 @@ package v1alpha1
 // ClusterVersionLister.
 type ClusterVersionListerExpansion interface{}
 
+// TenantPodPolicyListerExpansion allows custom methods to be added to
+// TenantPodPolicyLister.
+type TenantPodPolicyListerExpansion interface{}
+
+// TenantPodPolicyNamespaceListerExpansion allows custom methods to be added to
+// TenantPodPolicyNamespaceLister.
+type TenantPodPolicyNamespaceListerExpansion interface{}
+
 // VirtualClusterListerExpansion allows custom methods to be added to
 // VirtualClusterLister.
 type VirtualClusterListerExpansion interface{}
@@ -28,3 +36,11 @@ type VirtualClusterListerExpansion interface{}
 // VirtualClusterNamespaceListerExpansion allows custom methods to be added to
 // VirtualClusterNamespaceLister.
 type VirtualClusterNamespaceListerExpansion interface{}
+
+// VirtualClusterSummaryListerExpansion allows custom methods to be added to
+// VirtualClusterSummaryLister.
+type VirtualClusterSummaryListerExpansion interface{}
+
+// VirtualClusterSummaryNamespaceListerExpansion allows custom methods to be added to
+// VirtualClusterSummaryNamespaceLister.
+type VirtualClusterSummaryNamespaceListerExpansion interface{}
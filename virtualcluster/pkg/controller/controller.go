@@ -17,14 +17,25 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"time"
 
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/etcdstorage"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/gitops"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/outputs"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/secretchecksum"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/tenantcanary"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/tenantprobe"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/presets"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 )
 
 // Controllers defines all the shared information between all
@@ -34,6 +45,35 @@ type Controllers struct {
 	MaxConcurrentReconciles int
 	ProvisionerName         string
 	ProvisionerTimeout      time.Duration
+	// GitOpsSecretFormat enables the registration of VirtualClusters in GitOps
+	// tooling if set, see gitops.FormatArgoCD and gitops.FormatFlux
+	GitOpsSecretFormat    string
+	GitOpsSecretNamespace string
+	GitOpsClusterRole     string
+	// DefaultSecurityProfile applies to the ClusterVersions not setting a
+	// security profile
+	DefaultSecurityProfile tenancyv1alpha1.SecurityProfile
+	// TenantProbePeriod is the interval between two probes of the version and
+	// readiness of the tenant apiservers, the probes are disabled if not positive
+	TenantProbePeriod time.Duration
+	// ProvisioningOutputs enables writing the provisioning outputs of running
+	// VirtualClusters to ConfigMaps, see outputs.ReconcileOutputs
+	ProvisioningOutputs bool
+	// TenantCanary enables running a canary pod in every VirtualCluster once it
+	// is running, see tenantcanary.ReconcileTenantCanary
+	TenantCanary bool
+	// TenantCanaryImage is the image of the canary pod
+	TenantCanaryImage string
+	// ETCDStoragePeriod is the interval between two checks of the etcd storage of
+	// the tenant control planes, the checks are disabled if not positive, see
+	// etcdstorage.ReconcileETCDStorage
+	ETCDStoragePeriod time.Duration
+	// ETCDDefragInterval is the minimum interval between two defrag jobs of a
+	// tenant etcd
+	ETCDDefragInterval time.Duration
+	// InstallPresets creates the ClusterVersions of the built-in presets that
+	// don't exist on startup, see presets.Install
+	InstallPresets bool
 }
 
 // SetupWithManager adds all Controllers to the Manager
@@ -62,15 +102,90 @@ func (c *Controllers) SetupWithManager(mgr ctrl.Manager) error {
 		}).SetupWithManager(mgr, opts); err != nil {
 			return err
 		}
+
+		if c.InstallPresets {
+			if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+				// a failed installation must not stop the manager
+				if err := presets.Install(ctx, mgr.GetClient(), c.Log.WithName("presets")); err != nil {
+					c.Log.Error(err, "failed to install the clusterversion presets")
+				}
+				return nil
+			})); err != nil {
+				return err
+			}
+		}
+
+		if c.ETCDStoragePeriod > 0 {
+			if err := (&etcdstorage.ReconcileETCDStorage{
+				Client:         mgr.GetClient(),
+				Log:            c.Log.WithName("etcdstorage"),
+				Period:         c.ETCDStoragePeriod,
+				DefragInterval: c.ETCDDefragInterval,
+			}).SetupWithManager(mgr, opts); err != nil {
+				return err
+			}
+		}
+
+		if featuregate.DefaultFeatureGate.Enabled(featuregate.ControlPlaneSecretChecksum) {
+			if err := (&secretchecksum.ReconcileSecretChecksum{
+				Client:    mgr.GetClient(),
+				APIReader: mgr.GetAPIReader(),
+				Log:       c.Log.WithName("secretchecksum"),
+			}).SetupWithManager(mgr, opts); err != nil {
+				return err
+			}
+		}
 	}
 
 	if err := (&controllers.ReconcileVirtualCluster{
-		Client:             mgr.GetClient(),
-		Log:                c.Log.WithName("virtualcluster"),
-		ProvisionerName:    c.ProvisionerName,
-		ProvisionerTimeout: c.ProvisionerTimeout,
+		Client:                 mgr.GetClient(),
+		Log:                    c.Log.WithName("virtualcluster"),
+		ProvisionerName:        c.ProvisionerName,
+		ProvisionerTimeout:     c.ProvisionerTimeout,
+		DefaultSecurityProfile: c.DefaultSecurityProfile,
 	}).SetupWithManager(mgr, opts); err != nil {
 		return err
 	}
+
+	if c.TenantProbePeriod > 0 {
+		if err := (&tenantprobe.ReconcileTenantProbe{
+			Client: mgr.GetClient(),
+			Log:    c.Log.WithName("tenantprobe"),
+			Period: c.TenantProbePeriod,
+		}).SetupWithManager(mgr, opts); err != nil {
+			return err
+		}
+	}
+
+	if c.TenantCanary {
+		if err := (&tenantcanary.ReconcileTenantCanary{
+			Client: mgr.GetClient(),
+			Log:    c.Log.WithName("tenantcanary"),
+			Image:  c.TenantCanaryImage,
+		}).SetupWithManager(mgr, opts); err != nil {
+			return err
+		}
+	}
+
+	if c.GitOpsSecretFormat != "" {
+		if err := (&gitops.ReconcileRegistration{
+			Client:      mgr.GetClient(),
+			Log:         c.Log.WithName("gitops"),
+			Format:      c.GitOpsSecretFormat,
+			Namespace:   c.GitOpsSecretNamespace,
+			ClusterRole: c.GitOpsClusterRole,
+		}).SetupWithManager(mgr, opts); err != nil {
+			return err
+		}
+	}
+
+	if c.ProvisioningOutputs {
+		if err := (&outputs.ReconcileOutputs{
+			Client: mgr.GetClient(),
+			Log:    c.Log.WithName("outputs"),
+		}).SetupWithManager(mgr, opts); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcdstorage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// DefaultPeriod is the default interval between two checks of the etcd storage of a tenant
+	DefaultPeriod = 5 * time.Minute
+	// DefaultPressureThreshold is the default fraction of the quota the database size has to
+	// reach for the ETCDStoragePressureCondition to be true
+	DefaultPressureThreshold = 0.8
+	// DefaultDefragThreshold is the default fraction of the quota the fragmented bytes have to
+	// reach for a defrag job to be run
+	DefaultDefragThreshold = 0.2
+	// DefaultDefragInterval is the default minimum interval between two defrag jobs of a tenant
+	DefaultDefragInterval = 24 * time.Hour
+
+	// etcdName is the name of the etcd statefulset and service in the root namespace
+	etcdName = "etcd"
+
+	scrapeTimeout = 10 * time.Second
+)
+
+var (
+	dbSizeGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tenant_etcd_db_total_size_bytes",
+			Help: "Physically allocated size of the etcd database of a tenant control plane",
+		},
+		[]string{"cluster"},
+	)
+	dbSizeInUseGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tenant_etcd_db_in_use_size_bytes",
+			Help: "Logically used size of the etcd database of a tenant control plane",
+		},
+		[]string{"cluster"},
+	)
+	quotaGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tenant_etcd_quota_backend_bytes",
+			Help: "Backend quota of the etcd of a tenant control plane",
+		},
+		[]string{"cluster"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(dbSizeGauge, dbSizeInUseGauge, quotaGauge)
+}
+
+var _ reconcile.Reconciler = &ReconcileETCDStorage{}
+
+// ReconcileETCDStorage periodically reads the database size of the etcd of every running
+// VirtualCluster, exports it as metrics, sets the ETCDStoragePressureCondition when the size
+// approaches the etcd quota and runs a defrag job when enough space can be released
+type ReconcileETCDStorage struct {
+	client.Client
+	Log logr.Logger
+	// Period between two checks of the etcd storage of a tenant, DefaultPeriod if not set
+	Period time.Duration
+	// PressureThreshold is the fraction of the quota above which the storage is under
+	// pressure, DefaultPressureThreshold if not set
+	PressureThreshold float64
+	// DefragThreshold is the fraction of the quota the fragmented bytes have to reach for a
+	// defrag job to be run, DefaultDefragThreshold if not set
+	DefragThreshold float64
+	// DefragInterval is the minimum interval between two defrag jobs of a tenant,
+	// DefaultDefragInterval if not set
+	DefragInterval time.Duration
+
+	// scrape reads the etcd storage usage of a tenant, overridden in tests
+	scrape func(context.Context, *tenancyv1alpha1.VirtualCluster) (Usage, error)
+}
+
+// SetupWithManager will configure the etcd storage reconciler
+func (r *ReconcileETCDStorage) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	r.setDefaults()
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("etcd-storage").
+		WithOptions(opts).
+		For(&tenancyv1alpha1.VirtualCluster{}).
+		Complete(r)
+}
+
+func (r *ReconcileETCDStorage) setDefaults() {
+	if r.Period <= 0 {
+		r.Period = DefaultPeriod
+	}
+	if r.PressureThreshold <= 0 {
+		r.PressureThreshold = DefaultPressureThreshold
+	}
+	if r.DefragThreshold <= 0 {
+		r.DefragThreshold = DefaultDefragThreshold
+	}
+	if r.DefragInterval <= 0 {
+		r.DefragInterval = DefaultDefragInterval
+	}
+	if r.scrape == nil {
+		r.scrape = r.scrapeUsage
+	}
+}
+
+// Reconcile checks the etcd storage of a running VirtualCluster, an etcd that can't be scraped
+// is checked again after the Period
+func (r *ReconcileETCDStorage) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	cluster := conversion.ToClusterKey(vc)
+	if !vc.DeletionTimestamp.IsZero() {
+		deleteMetrics(cluster)
+		return reconcile.Result{}, nil
+	}
+	if vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return reconcile.Result{}, nil
+	}
+
+	usage, err := r.scrape(ctx, vc)
+	if err != nil {
+		r.Log.Info("failed to read the etcd storage usage", "vc", vc.Name, "err", err.Error())
+		return reconcile.Result{RequeueAfter: r.Period}, nil
+	}
+	dbSizeGauge.WithLabelValues(cluster).Set(usage.Size)
+	dbSizeInUseGauge.WithLabelValues(cluster).Set(usage.InUse)
+	quotaGauge.WithLabelValues(cluster).Set(usage.Quota)
+
+	if err := r.setPressureCondition(ctx, vc, usage); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.reconcileDefrag(ctx, vc, usage); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: r.Period}, nil
+}
+
+// setPressureCondition patches the ETCDStoragePressureCondition of vc if it changed, the
+// messages don't include the current size so that the condition isn't patched every Period
+func (r *ReconcileETCDStorage) setPressureCondition(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, usage Usage) error {
+	quota := resource.NewQuantity(int64(usage.Quota), resource.BinarySI).String()
+	status, reason := corev1.ConditionFalse, "WithinQuota"
+	message := fmt.Sprintf("the etcd database is below %.0f%% of its quota of %s", r.PressureThreshold*100, quota)
+	if usage.Size >= r.PressureThreshold*usage.Quota {
+		status, reason = corev1.ConditionTrue, "ApproachingQuota"
+		message = fmt.Sprintf("the etcd database reached %.0f%% of its quota of %s, the tenant apiserver rejects writes once the quota is exceeded", r.PressureThreshold*100, quota)
+	}
+
+	orig := vc.DeepCopy()
+	kubeutil.SetVCCondition(vc, tenancyv1alpha1.ETCDStoragePressureCondition, status, reason, message)
+	if apiequality.Semantic.DeepEqual(orig.Status.Conditions, vc.Status.Conditions) {
+		return nil
+	}
+	r.Log.Info("etcd storage pressure changed", "vc", vc.Name, "pressure", status, "size", usage.Size, "quota", usage.Quota)
+	// a merge patch replaces the conditions list, the optimistic lock fails the patch rather
+	// than dropping the conditions written meanwhile
+	return r.Patch(ctx, vc, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+}
+
+func deleteMetrics(cluster string) {
+	dbSizeGauge.DeleteLabelValues(cluster)
+	dbSizeInUseGauge.DeleteLabelValues(cluster)
+	quotaGauge.DeleteLabelValues(cluster)
+}
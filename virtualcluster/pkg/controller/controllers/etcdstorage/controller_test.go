@@ -0,0 +1,263 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcdstorage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func TestParseUsage(t *testing.T) {
+	testcases := map[string]struct {
+		metrics       string
+		expectedUsage Usage
+		expectedError string
+	}{
+		"all metrics": {
+			metrics: `# TYPE etcd_mvcc_db_total_size_in_bytes gauge
+etcd_mvcc_db_total_size_in_bytes 1000
+# TYPE etcd_mvcc_db_total_size_in_use_in_bytes gauge
+etcd_mvcc_db_total_size_in_use_in_bytes 400
+# TYPE etcd_server_quota_backend_bytes gauge
+etcd_server_quota_backend_bytes 4000
+`,
+			expectedUsage: Usage{Size: 1000, InUse: 400, Quota: 4000},
+		},
+		"default quota": {
+			metrics: `# TYPE etcd_mvcc_db_total_size_in_bytes gauge
+etcd_mvcc_db_total_size_in_bytes 1000
+`,
+			expectedUsage: Usage{Size: 1000, InUse: 1000, Quota: defaultQuota},
+		},
+		"size not reported": {
+			metrics: `# TYPE etcd_server_quota_backend_bytes gauge
+etcd_server_quota_backend_bytes 4000
+`,
+			expectedError: "is not reported",
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			usage, err := parseUsage(strings.NewReader(tc.metrics))
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("expected error %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if usage != tc.expectedUsage {
+				t.Errorf("expected usage %+v, got %+v", tc.expectedUsage, usage)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"},
+		Status:     tenancyv1alpha1.VirtualClusterStatus{Phase: tenancyv1alpha1.ClusterRunning},
+	}
+	rootNS := conversion.ToClusterKey(vc)
+	etcd := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: etcdName, Namespace: rootNS},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "etcd", Image: "virtualcluster/etcd-v3.4.0"}}},
+			},
+		},
+	}
+	finishedJob := func(finishedAt time.Time) *batchv1.Job {
+		job := newDefragJob(rootNS, "virtualcluster/etcd-v3.4.0")
+		job.Status.Conditions = []batchv1.JobCondition{{
+			Type:               batchv1.JobComplete,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(finishedAt),
+		}}
+		return job
+	}
+
+	testcases := map[string]struct {
+		usage       Usage
+		existingJob *batchv1.Job
+
+		expectedPressure corev1.ConditionStatus
+		expectedJob      bool
+	}{
+		"within quota": {
+			usage:            Usage{Size: 100, InUse: 100, Quota: 1000},
+			expectedPressure: corev1.ConditionFalse,
+		},
+		"approaching quota": {
+			usage:            Usage{Size: 850, InUse: 800, Quota: 1000},
+			expectedPressure: corev1.ConditionTrue,
+		},
+		"fragmented": {
+			usage:            Usage{Size: 850, InUse: 300, Quota: 1000},
+			expectedPressure: corev1.ConditionTrue,
+			expectedJob:      true,
+		},
+		"defragmented recently": {
+			usage:            Usage{Size: 850, InUse: 300, Quota: 1000},
+			existingJob:      finishedJob(time.Now().Add(-time.Hour)),
+			expectedPressure: corev1.ConditionTrue,
+			expectedJob:      true,
+		},
+		"defragmented before the interval": {
+			usage:            Usage{Size: 850, InUse: 300, Quota: 1000},
+			existingJob:      finishedJob(time.Now().Add(-2 * DefaultDefragInterval)),
+			expectedPressure: corev1.ConditionTrue,
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			objs := []client.Object{vc.DeepCopy(), etcd.DeepCopy()}
+			if tc.existingJob != nil {
+				objs = append(objs, tc.existingJob)
+			}
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+			r := &ReconcileETCDStorage{
+				Client: cli,
+				Log:    ctrl.Log.WithName("test"),
+				scrape: func(context.Context, *tenancyv1alpha1.VirtualCluster) (Usage, error) {
+					return tc.usage, nil
+				},
+			}
+			r.setDefaults()
+
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: vc.Namespace, Name: vc.Name}}
+			result, err := r.Reconcile(context.TODO(), request)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.RequeueAfter != DefaultPeriod {
+				t.Errorf("expected to requeue after %v, got %v", DefaultPeriod, result.RequeueAfter)
+			}
+
+			got := &tenancyv1alpha1.VirtualCluster{}
+			if err := cli.Get(context.TODO(), request.NamespacedName, got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var pressure corev1.ConditionStatus
+			for _, cond := range got.Status.Conditions {
+				if cond.Type == tenancyv1alpha1.ETCDStoragePressureCondition {
+					pressure = cond.Status
+				}
+			}
+			if pressure != tc.expectedPressure {
+				t.Errorf("expected pressure %q, got %q", tc.expectedPressure, pressure)
+			}
+
+			err = cli.Get(context.TODO(), client.ObjectKey{Namespace: rootNS, Name: DefragJobName}, &batchv1.Job{})
+			if tc.expectedJob && err != nil {
+				t.Errorf("expected the defrag job, got %v", err)
+			}
+			if !tc.expectedJob && !apierrors.IsNotFound(err) {
+				t.Errorf("expected no defrag job, got %v", err)
+			}
+		})
+	}
+}
+
+// concurrentWriter sets a condition of the VirtualCluster once it is read, as another
+// controller updating its status meanwhile would
+type concurrentWriter struct {
+	client.Client
+	written bool
+}
+
+func (c *concurrentWriter) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := c.Client.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	vc, ok := obj.(*tenancyv1alpha1.VirtualCluster)
+	if !ok || c.written {
+		return nil
+	}
+	c.written = true
+	concurrent := vc.DeepCopy()
+	kubeutil.SetVCCondition(concurrent, tenancyv1alpha1.ETCDStorageExpandedCondition, corev1.ConditionFalse, "ETCDStorageExpanding", "")
+	return c.Client.Update(ctx, concurrent)
+}
+
+func TestReconcileConflict(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"},
+		Status:     tenancyv1alpha1.VirtualClusterStatus{Phase: tenancyv1alpha1.ClusterRunning},
+	}
+	cli := &concurrentWriter{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(vc).Build()}
+	r := &ReconcileETCDStorage{
+		Client: cli,
+		Log:    ctrl.Log.WithName("test"),
+		scrape: func(context.Context, *tenancyv1alpha1.VirtualCluster) (Usage, error) {
+			return Usage{Size: 850, InUse: 800, Quota: 1000}, nil
+		},
+	}
+	r.setDefaults()
+
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: vc.Namespace, Name: vc.Name}}
+	if _, err := r.Reconcile(context.TODO(), request); !apierrors.IsConflict(err) {
+		t.Fatalf("expected the stale patch to conflict, got %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := &tenancyv1alpha1.VirtualCluster{}
+	if err := cli.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conditions := map[string]corev1.ConditionStatus{}
+	for _, cond := range got.Status.Conditions {
+		conditions[cond.Type] = cond.Status
+	}
+	if conditions[tenancyv1alpha1.ETCDStoragePressureCondition] != corev1.ConditionTrue {
+		t.Errorf("expected the pressure condition, got %v", got.Status.Conditions)
+	}
+	if _, ok := conditions[tenancyv1alpha1.ETCDStorageExpandedCondition]; !ok {
+		t.Errorf("expected the concurrent condition to be kept, got %v", got.Status.Conditions)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcdstorage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// DefragJobName is the name of the defrag job in the root namespace
+	DefragJobName = "etcd-defrag"
+
+	defragDeadlineSeconds = 600
+	rootCAMountPath       = "/etc/kubernetes/pki/root"
+	etcdCAMountPath       = "/etc/kubernetes/pki/etcd"
+)
+
+// reconcileDefrag runs the defrag job of vc when the fragmented bytes reach the DefragThreshold,
+// a finished job is kept for the DefragInterval so that etcd is not defragmented more often
+func (r *ReconcileETCDStorage) reconcileDefrag(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, usage Usage) error {
+	needed := usage.Fragmented() >= r.DefragThreshold*usage.Quota
+	rootNS := conversion.ToRootNamespace(vc)
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: rootNS, Name: DefragJobName}, job)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		finishedAt, finished := jobFinishedAt(job)
+		if !finished || !job.DeletionTimestamp.IsZero() || time.Since(finishedAt) < r.DefragInterval || !needed {
+			return nil
+		}
+		// the next job is created once the finished one is gone
+		r.Log.Info("deleting the finished etcd defrag job", "vc", vc.Name, "finishedAt", finishedAt)
+		return client.IgnoreNotFound(r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+	}
+	if !needed {
+		return nil
+	}
+
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: rootNS, Name: etcdName}, sts); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if len(sts.Spec.Template.Spec.Containers) == 0 {
+		return nil
+	}
+	r.Log.Info("running an etcd defrag job", "vc", vc.Name, "fragmented", usage.Fragmented(), "quota", usage.Quota)
+	if err := r.Create(ctx, newDefragJob(rootNS, sts.Spec.Template.Spec.Containers[0].Image)); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// jobFinishedAt returns when job completed or failed
+func jobFinishedAt(job *batchv1.Job) (time.Time, bool) {
+	for _, cond := range job.Status.Conditions {
+		if (cond.Type == batchv1.JobComplete || cond.Type == batchv1.JobFailed) && cond.Status == corev1.ConditionTrue {
+			return cond.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// newDefragJob builds a job defragmenting every etcd member with the etcdctl of image, the etcd
+// client certificate is the one of the etcd statefulset
+func newDefragJob(namespace, image string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DefragJobName,
+			Namespace: namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          pointer.Int32Ptr(2),
+			ActiveDeadlineSeconds: pointer.Int64Ptr(defragDeadlineSeconds),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:  "defrag",
+						Image: image,
+						Command: []string{
+							"/usr/local/bin/etcdctl",
+							fmt.Sprintf("--endpoints=https://%s:2379", etcdName),
+							"--cacert=" + rootCAMountPath + "/" + corev1.TLSCertKey,
+							"--cert=" + etcdCAMountPath + "/" + corev1.TLSCertKey,
+							"--key=" + etcdCAMountPath + "/" + corev1.TLSPrivateKeyKey,
+							"defrag",
+							"--cluster",
+						},
+						Env: []corev1.EnvVar{{Name: "ETCDCTL_API", Value: "3"}},
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: secret.ETCDCASecretName, MountPath: etcdCAMountPath, ReadOnly: true},
+							{Name: secret.RootCASecretName, MountPath: rootCAMountPath, ReadOnly: true},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{
+							Name: secret.ETCDCASecretName,
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: secret.ETCDCASecretName},
+							},
+						},
+						{
+							Name: secret.RootCASecretName,
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: secret.RootCASecretName},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcdstorage
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	metricDBSize      = "etcd_mvcc_db_total_size_in_bytes"
+	metricDBSizeInUse = "etcd_mvcc_db_total_size_in_use_in_bytes"
+	metricQuota       = "etcd_server_quota_backend_bytes"
+
+	// defaultQuota is the backend quota of etcd when --quota-backend-bytes is not set
+	defaultQuota = 2 * 1024 * 1024 * 1024
+)
+
+// Usage is the storage used by the etcd of a tenant control plane, in bytes
+type Usage struct {
+	// Size is the physically allocated size of the database, it is checked against the quota
+	Size float64
+	// InUse is the logically used size of the database, Size - InUse is released by a defrag
+	InUse float64
+	// Quota is the --quota-backend-bytes of etcd
+	Quota float64
+}
+
+// Fragmented returns the bytes a defrag would release
+func (u Usage) Fragmented() float64 {
+	if u.InUse >= u.Size {
+		return 0
+	}
+	return u.Size - u.InUse
+}
+
+// scrapeUsage reads the usage from the /metrics endpoint of the etcd in the root namespace of vc,
+// the etcd client certificate and root CA are read from the PKI secrets of the root namespace
+func (r *ReconcileETCDStorage) scrapeUsage(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (Usage, error) {
+	rootNS := conversion.ToRootNamespace(vc)
+	rootCASrt := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: rootNS, Name: secret.RootCASecretName}, rootCASrt); err != nil {
+		return Usage{}, err
+	}
+	etcdSrt := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: rootNS, Name: secret.ETCDCASecretName}, etcdSrt); err != nil {
+		return Usage{}, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(rootCASrt.Data[corev1.TLSCertKey]) {
+		return Usage{}, fmt.Errorf("no certificate found in secret %s/%s", rootNS, secret.RootCASecretName)
+	}
+	cert, err := tls.X509KeyPair(etcdSrt.Data[corev1.TLSCertKey], etcdSrt.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return Usage{}, err
+	}
+	httpClient := &http.Client{
+		Timeout: scrapeTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      pool,
+				Certificates: []tls.Certificate{cert},
+				MinVersion:   tls.VersionTLS12,
+			},
+		},
+	}
+	defer httpClient.CloseIdleConnections()
+	return ScrapeUsage(ctx, httpClient, fmt.Sprintf("https://%s.%s:2379/metrics", etcdName, rootNS))
+}
+
+// ScrapeUsage reads the usage from the etcd metrics served at url
+func ScrapeUsage(ctx context.Context, httpClient *http.Client, url string) (Usage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Usage{}, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Usage{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Usage{}, fmt.Errorf("etcd metrics responded with status %s", resp.Status)
+	}
+	return parseUsage(resp.Body)
+}
+
+// parseUsage reads the usage from metrics in the Prometheus text format, the etcd default
+// quota is assumed if the quota is not reported
+func parseUsage(r io.Reader) (Usage, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return Usage{}, err
+	}
+	size, ok := gaugeValue(families[metricDBSize])
+	if !ok {
+		return Usage{}, fmt.Errorf("metric %s is not reported", metricDBSize)
+	}
+	usage := Usage{Size: size, InUse: size, Quota: defaultQuota}
+	if inUse, ok := gaugeValue(families[metricDBSizeInUse]); ok {
+		usage.InUse = inUse
+	}
+	if quota, ok := gaugeValue(families[metricQuota]); ok && quota > 0 {
+		usage.Quota = quota
+	}
+	return usage, nil
+}
+
+func gaugeValue(family *dto.MetricFamily) (float64, bool) {
+	if family == nil || len(family.Metric) == 0 || family.Metric[0].Gauge == nil {
+		return 0, false
+	}
+	return family.Metric[0].Gauge.GetValue(), true
+}
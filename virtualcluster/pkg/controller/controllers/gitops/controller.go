@@ -0,0 +1,293 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// ServiceAccountName is the tenant service account whose token is handed to the GitOps tooling
+	ServiceAccountName = "vc-gitops"
+	// DefaultClusterRoleName is the least privilege tenant ClusterRole managed by the controller,
+	// it is bound to the service account unless another ClusterRole is configured
+	DefaultClusterRoleName = "vc-gitops"
+
+	// tokenExpiration is the lifetime of the tokens requested for the service account
+	tokenExpiration = 24 * time.Hour
+	// tokenRefreshBefore is how long before its expiry the token of a secret is replaced
+	tokenRefreshBefore = 8 * time.Hour
+	// minTokenRefreshPeriod bounds the refresh rate of the tokens of a tenant
+	minTokenRefreshPeriod = time.Minute
+	// tokenExpiryAnnotation records the expiry of the token of a registration secret
+	tokenExpiryAnnotation = "tenancy.x-k8s.io/gitops-token-expiry"
+	// legacyTokenSecretName is the service account token secret created by earlier versions
+	legacyTokenSecretName = ServiceAccountName + "-token"
+)
+
+var _ reconcile.Reconciler = &ReconcileRegistration{}
+
+// ReconcileRegistration registers running VirtualClusters in GitOps tooling by emitting
+// a cluster secret per VirtualCluster in Namespace
+type ReconcileRegistration struct {
+	client.Client
+	Log logr.Logger
+	// Format of the emitted secrets, FormatArgoCD or FormatFlux
+	Format string
+	// Namespace the secrets are emitted in, e.g. the namespace of ArgoCD
+	Namespace string
+	// ClusterRole bound to the tenant service account, DefaultClusterRoleName if empty
+	ClusterRole string
+}
+
+// SetupWithManager will configure the registration reconciler
+func (r *ReconcileRegistration) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	if err := ValidateFormat(r.Format); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("gitops-registration").
+		WithOptions(opts).
+		For(&tenancyv1alpha1.VirtualCluster{}).
+		Complete(r)
+}
+
+// Reconcile creates the registration secret of a running VirtualCluster and removes it once
+// the VirtualCluster is deleted
+func (r *ReconcileRegistration) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, r.deregister(ctx, request.Namespace, request.Name)
+		}
+		return reconcile.Result{}, err
+	}
+	if !vc.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, r.deregister(ctx, vc.Namespace, vc.Name)
+	}
+	if vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return reconcile.Result{}, nil
+	}
+
+	existing := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: secretName(vc)}, existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+	exists := err == nil
+	if exists && existing.Annotations[constants.LabelVCUID] == string(vc.UID) {
+		// the token of the secret is replaced once it is about to expire
+		if refresh := tokenRefreshTime(existing); time.Now().Before(refresh) {
+			return reconcile.Result{RequeueAfter: time.Until(refresh)}, nil
+		}
+	}
+
+	ep, err := r.tenantEndpoint(ctx, vc)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	desired, err := newRegistrationSecret(r.Format, r.Namespace, vc, ep)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	requeue := reconcile.Result{RequeueAfter: time.Until(tokenRefreshTime(desired))}
+	if requeue.RequeueAfter < minTokenRefreshPeriod {
+		// the tenant apiserver issued a shorter lived token than requested
+		requeue.RequeueAfter = minTokenRefreshPeriod
+	}
+	if !exists {
+		r.Log.Info("registering virtualcluster in gitops", "vc", vc.Name, "secret", desired.Name)
+		return requeue, r.Create(ctx, desired)
+	}
+	existing.Labels = desired.Labels
+	existing.Annotations = desired.Annotations
+	existing.Data = desired.Data
+	return requeue, r.Update(ctx, existing)
+}
+
+// tokenRefreshTime returns the time the token of the registration secret is to be replaced, a
+// secret without a recorded expiry is replaced right away
+func tokenRefreshTime(srt *corev1.Secret) time.Time {
+	expiry, err := time.Parse(time.RFC3339, srt.Annotations[tokenExpiryAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+	return expiry.Add(-tokenRefreshBefore)
+}
+
+// deregister removes the registration secrets of the named VirtualCluster
+func (r *ReconcileRegistration) deregister(ctx context.Context, namespace, name string) error {
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(r.Namespace), client.MatchingLabels{
+		constants.LabelVCName:      name,
+		constants.LabelVCNamespace: namespace,
+	}); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		r.Log.Info("deregistering virtualcluster from gitops", "vc", name, "secret", secrets.Items[i].Name)
+		if err := r.Delete(ctx, &secrets.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenantEndpoint returns the tenant apiserver of vc and a new token of the GitOps service account
+func (r *ReconcileRegistration) tenantEndpoint(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (endpoint, error) {
+	adminSrt := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: conversion.ToRootNamespace(vc), Name: secret.AdminSecretName}, adminSrt); err != nil {
+		return endpoint{}, err
+	}
+	adminConfig, err := clientcmd.Load(adminSrt.Data[secret.AdminSecretName])
+	if err != nil {
+		return endpoint{}, err
+	}
+	kubeContext, ok := adminConfig.Contexts[adminConfig.CurrentContext]
+	if !ok {
+		return endpoint{}, fmt.Errorf("admin kubeconfig of %s has no current context", vc.Name)
+	}
+	cluster, ok := adminConfig.Clusters[kubeContext.Cluster]
+	if !ok {
+		return endpoint{}, fmt.Errorf("admin kubeconfig of %s has no cluster %s", vc.Name, kubeContext.Cluster)
+	}
+	restConfig, err := clientcmd.NewDefaultClientConfig(*adminConfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return endpoint{}, err
+	}
+	tenantClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return endpoint{}, err
+	}
+	token, err := r.ensureServiceAccountToken(ctx, tenantClient)
+	if err != nil {
+		return endpoint{}, fmt.Errorf("fail to set up gitops service account of %s: %v", vc.Name, err)
+	}
+	return endpoint{
+		Server:      cluster.Server,
+		CAData:      cluster.CertificateAuthorityData,
+		Token:       token.Status.Token,
+		TokenExpiry: token.Status.ExpirationTimestamp.Time,
+	}, nil
+}
+
+// ensureServiceAccountToken creates the GitOps service account bound to ClusterRole in the tenant
+// cluster, and requests a bound token of the service account
+func (r *ReconcileRegistration) ensureServiceAccountToken(ctx context.Context, tenantClient kubernetes.Interface) (*authenticationv1.TokenRequest, error) {
+	clusterRole := r.ClusterRole
+	if clusterRole == "" {
+		clusterRole = DefaultClusterRoleName
+		if err := ensureDefaultClusterRole(ctx, tenantClient); err != nil {
+			return nil, err
+		}
+	}
+	_, err := tenantClient.CoreV1().ServiceAccounts(metav1.NamespaceSystem).Create(ctx, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: ServiceAccountName, Namespace: metav1.NamespaceSystem},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	if err := ensureClusterRoleBinding(ctx, tenantClient, clusterRole); err != nil {
+		return nil, err
+	}
+	// the token secret of earlier versions never expires
+	err = tenantClient.CoreV1().Secrets(metav1.NamespaceSystem).Delete(ctx, legacyTokenSecretName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	expirationSeconds := int64(tokenExpiration / time.Second)
+	return tenantClient.CoreV1().ServiceAccounts(metav1.NamespaceSystem).CreateToken(ctx, ServiceAccountName, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expirationSeconds},
+	}, metav1.CreateOptions{})
+}
+
+// ensureClusterRoleBinding binds clusterRole to the GitOps service account, the binding of a
+// previously configured ClusterRole is replaced since its role can't be changed
+func ensureClusterRoleBinding(ctx context.Context, tenantClient kubernetes.Interface, clusterRole string) error {
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: ServiceAccountName},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRole,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      ServiceAccountName,
+			Namespace: metav1.NamespaceSystem,
+		}},
+	}
+	existing, err := tenantClient.RbacV1().ClusterRoleBindings().Get(ctx, binding.Name, metav1.GetOptions{})
+	if err == nil && existing.RoleRef == binding.RoleRef {
+		return nil
+	}
+	if err == nil {
+		err = tenantClient.RbacV1().ClusterRoleBindings().Delete(ctx, binding.Name, metav1.DeleteOptions{})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	_, err = tenantClient.RbacV1().ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{})
+	return err
+}
+
+// ensureDefaultClusterRole creates or updates the DefaultClusterRoleName ClusterRole. It lets the
+// GitOps tooling manage the tenant objects, but not escalate its privileges, e.g. by binding or
+// impersonating, nor reach the non-resource urls.
+func ensureDefaultClusterRole(ctx context.Context, tenantClient kubernetes.Interface) error {
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultClusterRoleName},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"*"},
+			Resources: []string{"*"},
+			Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+		}},
+	}
+	existing, err := tenantClient.RbacV1().ClusterRoles().Get(ctx, role.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = tenantClient.RbacV1().ClusterRoles().Create(ctx, role, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil || equality.Semantic.DeepEqual(existing.Rules, role.Rules) {
+		return err
+	}
+	existing.Rules = role.Rules
+	_, err = tenantClient.RbacV1().ClusterRoles().Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func newTokenClient(expiry time.Time, objs ...runtime.Object) *fake.Clientset {
+	tenantClient := fake.NewSimpleClientset(objs...)
+	tenantClient.PrependReactor("create", "serviceaccounts", func(action core.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		req := action.(core.CreateAction).GetObject().(*authenticationv1.TokenRequest).DeepCopy()
+		req.Status = authenticationv1.TokenRequestStatus{Token: "token", ExpirationTimestamp: metav1.NewTime(expiry)}
+		return true, req, nil
+	})
+	return tenantClient
+}
+
+func TestEnsureServiceAccountToken(t *testing.T) {
+	expiry := time.Now().Add(tokenExpiration)
+	legacy := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: legacyTokenSecretName, Namespace: metav1.NamespaceSystem}}
+	staleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: ServiceAccountName},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+
+	for _, tc := range []struct {
+		name         string
+		clusterRole  string
+		expectedRole string
+	}{
+		{name: "default role", expectedRole: DefaultClusterRoleName},
+		{name: "configured role", clusterRole: "edit", expectedRole: "edit"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tenantClient := newTokenClient(expiry, legacy.DeepCopy(), staleBinding.DeepCopy())
+			r := &ReconcileRegistration{ClusterRole: tc.clusterRole}
+			token, err := r.ensureServiceAccountToken(context.TODO(), tenantClient)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token.Status.Token != "token" || !token.Status.ExpirationTimestamp.Time.Equal(expiry) {
+				t.Errorf("unexpected token status %+v", token.Status)
+			}
+			if exp := token.Spec.ExpirationSeconds; exp == nil || *exp != int64(tokenExpiration/time.Second) {
+				t.Errorf("expected the token to expire in %s, got %v", tokenExpiration, exp)
+			}
+
+			binding, err := tenantClient.RbacV1().ClusterRoleBindings().Get(context.TODO(), ServiceAccountName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if binding.RoleRef.Name != tc.expectedRole {
+				t.Errorf("expected the service account to be bound to %s, got %s", tc.expectedRole, binding.RoleRef.Name)
+			}
+			_, err = tenantClient.RbacV1().ClusterRoles().Get(context.TODO(), DefaultClusterRoleName, metav1.GetOptions{})
+			if managed := err == nil; managed != (tc.clusterRole == "") {
+				t.Errorf("expected the default role to be managed %v, got %v", tc.clusterRole == "", managed)
+			}
+			if _, err := tenantClient.CoreV1().Secrets(metav1.NamespaceSystem).Get(context.TODO(), legacyTokenSecretName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expected the legacy token secret to be deleted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDefaultClusterRoleCannotEscalate(t *testing.T) {
+	tenantClient := fake.NewSimpleClientset()
+	if err := ensureDefaultClusterRole(context.TODO(), tenantClient); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	role, err := tenantClient.RbacV1().ClusterRoles().Get(context.TODO(), DefaultClusterRoleName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, rule := range role.Rules {
+		if len(rule.NonResourceURLs) != 0 {
+			t.Errorf("unexpected non-resource urls %v", rule.NonResourceURLs)
+		}
+		for _, verb := range rule.Verbs {
+			switch verb {
+			case "*", "escalate", "bind", "impersonate":
+				t.Errorf("unexpected verb %q in the default role", verb)
+			}
+		}
+	}
+}
+
+func TestTokenRefreshTime(t *testing.T) {
+	expiry := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		expected    time.Time
+	}{
+		{name: "no expiry"},
+		{name: "invalid expiry", annotations: map[string]string{tokenExpiryAnnotation: "tomorrow"}},
+		{
+			name:        "expiry",
+			annotations: map[string]string{tokenExpiryAnnotation: expiry.Format(time.RFC3339)},
+			expected:    expiry.Add(-tokenRefreshBefore),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srt := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			if got := tokenRefreshTime(srt); !got.Equal(tc.expected) {
+				t.Errorf("expected refresh time %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// FormatArgoCD emits ArgoCD declarative cluster secrets
+	FormatArgoCD = "argocd"
+	// FormatFlux emits Flux kubeconfig secrets, referenced by spec.kubeConfig.secretRef
+	FormatFlux = "flux"
+
+	argoCDSecretTypeLabel = "argocd.argoproj.io/secret-type"
+	fluxKubeconfigKey     = "value"
+)
+
+// endpoint is the tenant apiserver a registration secret points at
+type endpoint struct {
+	Server      string
+	CAData      []byte
+	Token       string
+	TokenExpiry time.Time
+}
+
+// argoCDClusterConfig is the config of an ArgoCD cluster secret
+type argoCDClusterConfig struct {
+	BearerToken     string                `json:"bearerToken"`
+	TLSClientConfig argoCDTLSClientConfig `json:"tlsClientConfig"`
+}
+
+type argoCDTLSClientConfig struct {
+	Insecure bool   `json:"insecure"`
+	CAData   []byte `json:"caData,omitempty"`
+}
+
+// ValidateFormat returns an error if format is not a supported registration secret format
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatArgoCD, FormatFlux:
+		return nil
+	}
+	return fmt.Errorf("unsupported gitops secret format %q, must be one of %s, %s", format, FormatArgoCD, FormatFlux)
+}
+
+// secretName returns the name of the registration secret of vc
+func secretName(vc *tenancyv1alpha1.VirtualCluster) string {
+	return conversion.ToClusterKey(vc)
+}
+
+// newRegistrationSecret builds the registration secret of vc in format
+func newRegistrationSecret(format, namespace string, vc *tenancyv1alpha1.VirtualCluster, ep endpoint) (*corev1.Secret, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName(vc),
+			Namespace: namespace,
+			Labels: map[string]string{
+				constants.LabelVCName:      vc.Name,
+				constants.LabelVCNamespace: vc.Namespace,
+			},
+			Annotations: map[string]string{
+				constants.LabelVCUID: string(vc.UID),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	if !ep.TokenExpiry.IsZero() {
+		secret.Annotations[tokenExpiryAnnotation] = ep.TokenExpiry.UTC().Format(time.RFC3339)
+	}
+
+	switch format {
+	case FormatArgoCD:
+		config, err := json.Marshal(argoCDClusterConfig{
+			BearerToken:     ep.Token,
+			TLSClientConfig: argoCDTLSClientConfig{CAData: ep.CAData},
+		})
+		if err != nil {
+			return nil, err
+		}
+		secret.Labels[argoCDSecretTypeLabel] = "cluster"
+		secret.Data = map[string][]byte{
+			"name":   []byte(vc.Namespace + "/" + vc.Name),
+			"server": []byte(ep.Server),
+			"config": config,
+		}
+	case FormatFlux:
+		kubeconfig, err := yaml.Marshal(clientcmdv1.Config{
+			APIVersion: "v1",
+			Kind:       "Config",
+			Clusters: []clientcmdv1.NamedCluster{{
+				Name:    vc.Name,
+				Cluster: clientcmdv1.Cluster{Server: ep.Server, CertificateAuthorityData: ep.CAData},
+			}},
+			AuthInfos: []clientcmdv1.NamedAuthInfo{{
+				Name:     vc.Name,
+				AuthInfo: clientcmdv1.AuthInfo{Token: ep.Token},
+			}},
+			Contexts: []clientcmdv1.NamedContext{{
+				Name:    vc.Name,
+				Context: clientcmdv1.Context{Cluster: vc.Name, AuthInfo: vc.Name},
+			}},
+			CurrentContext: vc.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		secret.Data = map[string][]byte{fluxKubeconfigKey: kubeconfig}
+	default:
+		return nil, ValidateFormat(format)
+	}
+	return secret, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestNewRegistrationSecret(t *testing.T) {
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vc",
+			Namespace: "tenant-1",
+			UID:       "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f",
+		},
+	}
+	ep := endpoint{
+		Server:      "https://apiserver-svc.tenant-1:6443",
+		CAData:      []byte("ca"),
+		Token:       "token",
+		TokenExpiry: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("argocd", func(t *testing.T) {
+		secret, err := newRegistrationSecret(FormatArgoCD, "argocd", vc, ep)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if secret.Annotations[tokenExpiryAnnotation] != "2022-01-02T00:00:00Z" {
+			t.Errorf("unexpected token expiry %q", secret.Annotations[tokenExpiryAnnotation])
+		}
+		if secret.Namespace != "argocd" || secret.Labels[argoCDSecretTypeLabel] != "cluster" {
+			t.Errorf("unexpected secret meta %v", secret.ObjectMeta)
+		}
+		if string(secret.Data["server"]) != ep.Server || string(secret.Data["name"]) != "tenant-1/vc" {
+			t.Errorf("unexpected secret data %v", secret.Data)
+		}
+		config := argoCDClusterConfig{}
+		if err := json.Unmarshal(secret.Data["config"], &config); err != nil {
+			t.Fatalf("invalid config: %v", err)
+		}
+		if config.BearerToken != "token" || string(config.TLSClientConfig.CAData) != "ca" {
+			t.Errorf("unexpected config %+v", config)
+		}
+	})
+
+	t.Run("flux", func(t *testing.T) {
+		secret, err := newRegistrationSecret(FormatFlux, "flux-system", vc, ep)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		config, err := clientcmd.Load(secret.Data[fluxKubeconfigKey])
+		if err != nil {
+			t.Fatalf("invalid kubeconfig: %v", err)
+		}
+		if config.Clusters["vc"].Server != ep.Server || config.AuthInfos["vc"].Token != "token" {
+			t.Errorf("unexpected kubeconfig %+v", config)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := newRegistrationSecret("rancher", "cattle", vc, ep); err == nil {
+			t.Errorf("expected error for unknown format")
+		}
+	})
+}
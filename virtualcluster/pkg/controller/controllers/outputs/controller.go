@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outputs
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+var _ reconcile.Reconciler = &ReconcileOutputs{}
+
+// ReconcileOutputs writes the provisioning outputs of every running VirtualCluster to a
+// ConfigMap next to it, the ConfigMap is owned by the VirtualCluster and deleted with it
+type ReconcileOutputs struct {
+	client.Client
+	Log logr.Logger
+}
+
+// SetupWithManager will configure the outputs reconciler
+func (r *ReconcileOutputs) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("provisioning-outputs").
+		WithOptions(opts).
+		For(&tenancyv1alpha1.VirtualCluster{}).
+		Owns(&corev1.ConfigMap{}).
+		Complete(r)
+}
+
+// Reconcile writes the outputs ConfigMap of a running VirtualCluster
+func (r *ReconcileOutputs) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if !vc.DeletionTimestamp.IsZero() || vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return reconcile.Result{}, nil
+	}
+
+	adminSrt := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: conversion.ToRootNamespace(vc), Name: secret.AdminSecretName}, adminSrt); err != nil {
+		return reconcile.Result{}, err
+	}
+	superClusterID, err := r.superClusterID(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	o, err := NewOutputs(vc, adminSrt.Data[secret.AdminSecretName], superClusterID)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	desired, err := o.ConfigMap(vc)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := controllerutil.SetControllerReference(vc, desired, r.Scheme()); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	existing := &corev1.ConfigMap{}
+	err = r.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if apierrors.IsNotFound(err) {
+		r.Log.Info("writing provisioning outputs", "vc", vc.Name, "configmap", desired.Name)
+		return reconcile.Result{}, r.Create(ctx, desired)
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !metav1.IsControlledBy(existing, vc) {
+		r.Log.Info("configmap is not owned by the virtualcluster, skip writing provisioning outputs", "vc", vc.Name, "configmap", desired.Name)
+		return reconcile.Result{}, nil
+	}
+	if reflect.DeepEqual(existing.Data, desired.Data) && reflect.DeepEqual(existing.Labels, desired.Labels) {
+		return reconcile.Result{}, nil
+	}
+	existing.Labels = desired.Labels
+	existing.Data = desired.Data
+	r.Log.Info("updating provisioning outputs", "vc", vc.Name, "configmap", desired.Name)
+	return reconcile.Result{}, r.Update(ctx, existing)
+}
+
+// superClusterID returns the id of the super cluster registered in the supercluster-info
+// ConfigMap, or an empty string if the super cluster is not registered
+func (r *ReconcileOutputs) superClusterID(ctx context.Context) (string, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: utilconstants.SuperClusterInfoCfgMap}, cm); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	return cm.Data[utilconstants.SuperClusterIDKey], nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outputs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+func newAdminKubeconfig(server string) []byte {
+	return []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: vc
+  cluster:
+    server: ` + server + `
+    certificate-authority-data: Y2E=
+users:
+- name: admin
+  user:
+    token: secret-token
+contexts:
+- name: admin@vc
+  context:
+    cluster: vc
+    user: admin
+current-context: admin@vc
+`)
+}
+
+func TestReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"},
+		Spec: tenancyv1alpha1.VirtualClusterSpec{
+			ClusterVersionName: "cv-sample",
+			Placement:          &tenancyv1alpha1.ControlPlanePlacement{NodeSelector: map[string]string{"pool": "control-plane"}},
+		},
+		Status: tenancyv1alpha1.VirtualClusterStatus{Phase: tenancyv1alpha1.ClusterPending},
+	}
+	rootNS := conversion.ToClusterKey(vc)
+	adminSrt := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: rootNS, Name: secret.AdminSecretName},
+		Data:       map[string][]byte{secret.AdminSecretName: newAdminKubeconfig("https://apiserver-svc." + rootNS + ":6443")},
+	}
+	superClusterInfo := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: utilconstants.SuperClusterInfoCfgMap},
+		Data:       map[string]string{utilconstants.SuperClusterIDKey: "super-1"},
+	}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(vc, adminSrt, superClusterInfo).Build()
+	r := &ReconcileOutputs{Client: cli, Log: ctrl.Log.WithName("test")}
+	key := client.ObjectKey{Namespace: vc.Namespace, Name: ConfigMapName(vc)}
+
+	reconcileVC := func() {
+		t.Helper()
+		if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vc)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	reconcileVC()
+	if err := cli.Get(context.TODO(), key, &corev1.ConfigMap{}); err == nil {
+		t.Errorf("expected no outputs before the virtualcluster is running")
+	}
+
+	vc.Status.Phase = tenancyv1alpha1.ClusterRunning
+	if err := cli.Update(context.TODO(), vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reconcileVC()
+	cm := &corev1.ConfigMap{}
+	if err := cli.Get(context.TODO(), key, cm); err != nil {
+		t.Fatalf("expected the outputs configmap: %v", err)
+	}
+	if !metav1.IsControlledBy(cm, vc) {
+		t.Errorf("expected the outputs configmap to be owned by the virtualcluster")
+	}
+
+	var o Outputs
+	if err := json.Unmarshal([]byte(cm.Data[OutputsKey]), &o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Outputs{
+		SchemaVersion:     SchemaVersion,
+		ClusterID:         string(vc.UID),
+		ClusterName:       "tenant-1/vc",
+		APIServerEndpoint: "https://apiserver-svc." + rootNS + ":6443",
+		CACertificate:     "ca",
+		ClusterVersion:    "cv-sample",
+		AdminKubeconfigSecret: SecretReference{
+			Namespace: rootNS,
+			Name:      secret.AdminSecretName,
+			Key:       secret.AdminSecretName,
+		},
+		Placement: Placement{
+			RootNamespace:  rootNS,
+			SuperClusterID: "super-1",
+			NodeSelector:   map[string]string{"pool": "control-plane"},
+		},
+	}
+	if got, _ := json.Marshal(o); string(got) != mustMarshal(t, expected) {
+		t.Errorf("expected outputs %s, got %s", mustMarshal(t, expected), got)
+	}
+	if cm.Data[APIServerEndpointKey] != expected.APIServerEndpoint || cm.Data[ClusterIDKey] != expected.ClusterID {
+		t.Errorf("unexpected flat outputs %v", cm.Data)
+	}
+	for k, v := range cm.Data {
+		if strings.Contains(v, "secret-token") {
+			t.Errorf("expected no credentials in the outputs, got them in %s", k)
+		}
+	}
+
+	vc.Status.KubernetesVersion = "v1.21.9"
+	if err := cli.Update(context.TODO(), vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reconcileVC()
+	if err := cli.Get(context.TODO(), key, cm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cm.Data[KubernetesVersionKey] != "v1.21.9" {
+		t.Errorf("expected the outputs to be updated with the kubernetes version, got %v", cm.Data)
+	}
+}
+
+func mustMarshal(t *testing.T, o Outputs) string {
+	t.Helper()
+	data, err := json.Marshal(o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outputs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// SchemaVersion is the version of the outputs schema, it is bumped on incompatible changes
+	SchemaVersion = "v1"
+
+	// ConfigMapSuffix is appended to the name of the VirtualCluster to name its outputs ConfigMap
+	ConfigMapSuffix = "-outputs"
+
+	// OutputsKey is the ConfigMap key of the outputs document in JSON
+	OutputsKey = "outputs.json"
+
+	// The flat ConfigMap keys, for consumers that can't parse JSON
+	SchemaVersionKey         = "schema_version"
+	ClusterIDKey             = "cluster_id"
+	APIServerEndpointKey     = "api_server_endpoint"
+	CACertificateKey         = "ca_certificate"
+	ClusterVersionKey        = "cluster_version"
+	KubernetesVersionKey     = "kubernetes_version"
+	RootNamespaceKey         = "root_namespace"
+	SuperClusterIDKey        = "super_cluster_id"
+	AdminKubeconfigSecretKey = "admin_kubeconfig_secret" // #nosec G101 -- This is a key name
+)
+
+// Outputs describes a provisioned VirtualCluster for external automation, e.g. Terraform
+// providers and service catalogs
+type Outputs struct {
+	// SchemaVersion is the version of this schema
+	SchemaVersion string `json:"schemaVersion"`
+	// ClusterID is the UID of the VirtualCluster, it is stable for the lifetime of the tenant
+	ClusterID string `json:"clusterID"`
+	// ClusterName is the namespace/name of the VirtualCluster
+	ClusterName string `json:"clusterName"`
+	// APIServerEndpoint is the URL of the tenant apiserver
+	APIServerEndpoint string `json:"apiServerEndpoint"`
+	// CACertificate is the PEM encoded CA certificate of the tenant apiserver
+	CACertificate string `json:"caCertificate"`
+	// ClusterVersion is the name of the ClusterVersion of the control plane
+	ClusterVersion string `json:"clusterVersion"`
+	// KubernetesVersion is the version reported by the tenant apiserver, if it was probed
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// AdminKubeconfigSecret is the secret holding the admin kubeconfig of the tenant, the
+	// credentials are not part of the outputs
+	AdminKubeconfigSecret SecretReference `json:"adminKubeconfigSecret"`
+	// Placement describes where the control plane runs
+	Placement Placement `json:"placement"`
+}
+
+// SecretReference references a secret key
+type SecretReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+}
+
+// Placement describes where the control plane of a VirtualCluster runs
+type Placement struct {
+	// RootNamespace is the namespace of the control plane in the super cluster
+	RootNamespace string `json:"rootNamespace"`
+	// SuperClusterID is the id of the super cluster, if it is registered in the supercluster-info ConfigMap
+	SuperClusterID string `json:"superClusterID,omitempty"`
+	// NodeSelector of the control plane pods set by the VirtualCluster
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations of the control plane pods set by the VirtualCluster
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// ConfigMapName returns the name of the outputs ConfigMap of vc
+func ConfigMapName(vc *tenancyv1alpha1.VirtualCluster) string {
+	return vc.Name + ConfigMapSuffix
+}
+
+// NewOutputs builds the outputs of vc from its admin kubeconfig
+func NewOutputs(vc *tenancyv1alpha1.VirtualCluster, adminKubeconfig []byte, superClusterID string) (*Outputs, error) {
+	config, err := clientcmd.Load(adminKubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	kubeContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("admin kubeconfig of %s has no current context", vc.Name)
+	}
+	cluster, ok := config.Clusters[kubeContext.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("admin kubeconfig of %s has no cluster %s", vc.Name, kubeContext.Cluster)
+	}
+
+	rootNS := conversion.ToRootNamespace(vc)
+	o := &Outputs{
+		SchemaVersion:     SchemaVersion,
+		ClusterID:         string(vc.UID),
+		ClusterName:       vc.Namespace + "/" + vc.Name,
+		APIServerEndpoint: cluster.Server,
+		CACertificate:     string(cluster.CertificateAuthorityData),
+		ClusterVersion:    vc.Spec.ClusterVersionName,
+		KubernetesVersion: vc.Status.KubernetesVersion,
+		AdminKubeconfigSecret: SecretReference{
+			Namespace: rootNS,
+			Name:      secret.AdminSecretName,
+			Key:       secret.AdminSecretName,
+		},
+		Placement: Placement{
+			RootNamespace:  rootNS,
+			SuperClusterID: superClusterID,
+		},
+	}
+	if vc.Spec.Placement != nil {
+		o.Placement.NodeSelector = vc.Spec.Placement.NodeSelector
+		o.Placement.Tolerations = vc.Spec.Placement.Tolerations
+	}
+	return o, nil
+}
+
+// ConfigMap returns the outputs ConfigMap of vc, holding the outputs document and its flat keys
+func (o *Outputs) ConfigMap(vc *tenancyv1alpha1.VirtualCluster) (*corev1.ConfigMap, error) {
+	doc, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName(vc),
+			Namespace: vc.Namespace,
+			Labels: map[string]string{
+				constants.LabelVCName:      vc.Name,
+				constants.LabelVCNamespace: vc.Namespace,
+			},
+		},
+		Data: map[string]string{
+			OutputsKey:               string(doc),
+			SchemaVersionKey:         o.SchemaVersion,
+			ClusterIDKey:             o.ClusterID,
+			APIServerEndpointKey:     o.APIServerEndpoint,
+			CACertificateKey:         o.CACertificate,
+			ClusterVersionKey:        o.ClusterVersion,
+			KubernetesVersionKey:     o.KubernetesVersion,
+			RootNamespaceKey:         o.Placement.RootNamespace,
+			SuperClusterIDKey:        o.Placement.SuperClusterID,
+			AdminKubeconfigSecretKey: o.AdminKubeconfigSecret.Namespace + "/" + o.AdminKubeconfigSecret.Name,
+		},
+	}, nil
+}
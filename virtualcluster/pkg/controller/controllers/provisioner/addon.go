@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const addonFieldManager = "virtualcluster/provisioner/addon"
+
+// decodeManifests decodes the multi-document YAML manifests into objects
+func decodeManifests(manifests string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifests), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				return objs, nil
+			}
+			return nil, err
+		}
+		// skip empty documents
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("object without kind or name in manifests")
+		}
+		objs = append(objs, obj)
+	}
+}
+
+// newTenantClient creates a client of the tenant cluster using the admin kubeconfig
+// stored in the root namespace of vc
+func (mpn *Native) newTenantClient(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (client.Client, error) {
+	adminSrt := &corev1.Secret{}
+	if err := mpn.Get(ctx, client.ObjectKey{Namespace: conversion.ToRootNamespace(vc), Name: secret.AdminSecretName}, adminSrt); err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(adminSrt.Data[secret.AdminSecretName])
+	if err != nil {
+		return nil, err
+	}
+	return client.New(restConfig, client.Options{})
+}
+
+// applyAddons applies the addons of the ClusterVersion into the tenant cluster and
+// records the result of each addon as a condition of vc. A failed addon does not
+// fail the provisioning of the control plane.
+func (mpn *Native) applyAddons(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion) {
+	if len(cv.Spec.Addons) == 0 {
+		return
+	}
+
+	tenantClient, err := mpn.newTenantClient(ctx, vc)
+	if err != nil {
+		mpn.Log.Error(err, "fail to create tenant client for addons", "vc", vc.GetName())
+		for _, addon := range cv.Spec.Addons {
+			kubeutil.SetVCCondition(vc, tenancyv1alpha1.AddonConditionType(addon.Name), corev1.ConditionFalse,
+				"TenantClientError", err.Error())
+		}
+		return
+	}
+
+	for _, addon := range cv.Spec.Addons {
+		condType := tenancyv1alpha1.AddonConditionType(addon.Name)
+		if err := applyAddon(ctx, tenantClient, addon); err != nil {
+			mpn.Log.Error(err, "fail to apply addon", "vc", vc.GetName(), "addon", addon.Name)
+			kubeutil.SetVCCondition(vc, condType, corev1.ConditionFalse, "AddonApplyFailed", err.Error())
+			continue
+		}
+		mpn.Log.Info("addon applied", "vc", vc.GetName(), "addon", addon.Name)
+		kubeutil.SetVCCondition(vc, condType, corev1.ConditionTrue, "AddonApplied", "")
+	}
+}
+
+func applyAddon(ctx context.Context, tenantClient client.Client, addon tenancyv1alpha1.AddonSpec) error {
+	objs, err := decodeManifests(addon.Manifests)
+	if err != nil {
+		return fmt.Errorf("fail to decode manifests: %v", err)
+	}
+	for _, obj := range objs {
+		if err := tenantClient.Patch(ctx, obj, client.Apply, client.ForceOwnership, client.FieldOwner(addonFieldManager)); err != nil {
+			return fmt.Errorf("fail to apply %s %s: %v", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+)
+
+func TestDecodeManifests(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		manifests string
+		kinds     []string
+		expectErr bool
+	}{
+		{
+			name: "multiple documents",
+			manifests: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: coredns
+  namespace: kube-system
+data:
+  Corefile: |
+    .:53 {}
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kube-proxy
+  namespace: kube-system
+`,
+			kinds: []string{"ConfigMap", "ConfigMap"},
+		},
+		{
+			name:      "empty manifests",
+			manifests: "",
+		},
+		{
+			name: "object without name",
+			manifests: `
+apiVersion: v1
+kind: ConfigMap
+`,
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			objs, err := decodeManifests(tc.manifests)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if len(objs) != len(tc.kinds) {
+				t.Fatalf("expected %d objects, got %d", len(tc.kinds), len(objs))
+			}
+			for i := range objs {
+				if objs[i].GetKind() != tc.kinds[i] {
+					t.Errorf("expected kind %s, got %s", tc.kinds[i], objs[i].GetKind())
+				}
+			}
+		})
+	}
+}
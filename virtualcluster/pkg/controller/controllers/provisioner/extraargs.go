@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// applyExtraArgs sets the allowed extra args of the VirtualCluster on the container
+// of a control plane component, overriding the flags set by the ClusterVersion.
+// It returns the flags that were skipped because they are not in the allow-list.
+func applyExtraArgs(template *corev1.PodTemplateSpec, component string, spec *tenancyv1alpha1.ControlPlaneSpec) []string {
+	args := spec.ExtraArgs(component)
+	if len(args) == 0 || len(template.Spec.Containers) == 0 {
+		return nil
+	}
+	container := &template.Spec.Containers[0]
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == component {
+			container = &template.Spec.Containers[i]
+			break
+		}
+	}
+
+	flags := make([]string, 0, len(args))
+	for flag := range args {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+
+	var skipped []string
+	for _, flag := range flags {
+		if !tenancyv1alpha1.ExtraArgAllowed(component, flag) {
+			skipped = append(skipped, flag)
+			continue
+		}
+		arg := "--" + flag + "=" + args[flag]
+		if !replaceFlag(container.Command, flag, arg) && !replaceFlag(container.Args, flag, arg) {
+			container.Args = append(container.Args, arg)
+		}
+	}
+	return skipped
+}
+
+// replaceFlag replaces the first --flag or --flag=value item of args with arg.
+func replaceFlag(args []string, flag, arg string) bool {
+	for i, a := range args {
+		if a == "--"+flag || strings.HasPrefix(a, "--"+flag+"=") {
+			args[i] = arg
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestApplyExtraArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		containers   []corev1.Container
+		controlPlane *tenancyv1alpha1.ControlPlaneSpec
+		expected     []corev1.Container
+		skipped      []string
+	}{
+		{
+			name:       "no control plane",
+			containers: []corev1.Container{{Name: "apiserver", Args: []string{"--v=2"}}},
+			expected:   []corev1.Container{{Name: "apiserver", Args: []string{"--v=2"}}},
+		},
+		{
+			name: "override and append args",
+			containers: []corev1.Container{
+				{Name: "sidecar"},
+				{Name: "apiserver", Command: []string{"kube-apiserver", "--v=2"}, Args: []string{"--feature-gates=A=true"}},
+			},
+			controlPlane: &tenancyv1alpha1.ControlPlaneSpec{
+				APIServer: &tenancyv1alpha1.ControlPlaneComponentSpec{ExtraArgs: map[string]string{
+					"v":                "4",
+					"feature-gates":    "B=true",
+					"audit-log-maxage": "7",
+				}},
+			},
+			expected: []corev1.Container{
+				{Name: "sidecar"},
+				{Name: "apiserver", Command: []string{"kube-apiserver", "--v=4"}, Args: []string{"--feature-gates=B=true", "--audit-log-maxage=7"}},
+			},
+		},
+		{
+			name:       "skip flags not in the allow-list",
+			containers: []corev1.Container{{Name: "apiserver"}},
+			controlPlane: &tenancyv1alpha1.ControlPlaneSpec{
+				APIServer: &tenancyv1alpha1.ControlPlaneComponentSpec{ExtraArgs: map[string]string{
+					"authorization-mode": "AlwaysAllow",
+					"request-timeout":    "2m",
+				}},
+			},
+			expected: []corev1.Container{{Name: "apiserver", Args: []string{"--request-timeout=2m"}}},
+			skipped:  []string{"authorization-mode"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: tc.containers}}
+			skipped := applyExtraArgs(template, tenancyv1alpha1.ComponentAPIServer, tc.controlPlane)
+			if !equality.Semantic.DeepEqual(template.Spec.Containers, tc.expected) {
+				t.Errorf("expected containers %+v, got %+v", tc.expected, template.Spec.Containers)
+			}
+			if !equality.Semantic.DeepEqual(skipped, tc.skipped) {
+				t.Errorf("expected skipped flags %v, got %v", tc.skipped, skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// antiAffinityWeight is the weight of the preferred pod anti-affinity term
+const antiAffinityWeight = 100
+
+// applyHighAvailability spreads the replicas of a control plane component
+// over nodes and zones according to the policy, it is a no-op for single
+// replica StatefulSets
+func applyHighAvailability(sts *appsv1.StatefulSet, policy *tenancyv1alpha1.HighAvailabilityPolicy) {
+	if sts.Spec.Replicas == nil || *sts.Spec.Replicas <= 1 {
+		return
+	}
+	nodeAntiAffinity, zoneSpread := tenancyv1alpha1.SpreadPreferred, tenancyv1alpha1.SpreadPreferred
+	if policy != nil {
+		if policy.NodeAntiAffinity != "" {
+			nodeAntiAffinity = policy.NodeAntiAffinity
+		}
+		if policy.ZoneSpread != "" {
+			zoneSpread = policy.ZoneSpread
+		}
+	}
+
+	template := &sts.Spec.Template
+	if len(template.Labels) == 0 {
+		// the replicas can not be told apart from other pods
+		return
+	}
+	selector := componentSelector(template)
+
+	if affinity := template.Spec.Affinity; affinity == nil || affinity.PodAntiAffinity == nil {
+		term := corev1.PodAffinityTerm{
+			LabelSelector: selector,
+			TopologyKey:   corev1.LabelHostname,
+		}
+		switch nodeAntiAffinity {
+		case tenancyv1alpha1.SpreadRequired:
+			setPodAntiAffinity(template, &corev1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+			})
+		case tenancyv1alpha1.SpreadNone:
+		default:
+			setPodAntiAffinity(template, &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{Weight: antiAffinityWeight, PodAffinityTerm: term},
+				},
+			})
+		}
+	}
+
+	if !hasTopologySpreadConstraint(template.Spec.TopologySpreadConstraints, corev1.LabelTopologyZone) {
+		constraint := corev1.TopologySpreadConstraint{
+			MaxSkew:       1,
+			TopologyKey:   corev1.LabelTopologyZone,
+			LabelSelector: selector,
+		}
+		switch zoneSpread {
+		case tenancyv1alpha1.SpreadRequired:
+			constraint.WhenUnsatisfiable = corev1.DoNotSchedule
+		case tenancyv1alpha1.SpreadNone:
+			return
+		default:
+			constraint.WhenUnsatisfiable = corev1.ScheduleAnyway
+		}
+		template.Spec.TopologySpreadConstraints = append(template.Spec.TopologySpreadConstraints, constraint)
+	}
+}
+
+// componentSelector selects the pods created from template
+func componentSelector(template *corev1.PodTemplateSpec) *metav1.LabelSelector {
+	matchLabels := make(map[string]string, len(template.Labels))
+	for k, v := range template.Labels {
+		matchLabels[k] = v
+	}
+	return &metav1.LabelSelector{MatchLabels: matchLabels}
+}
+
+func setPodAntiAffinity(template *corev1.PodTemplateSpec, podAntiAffinity *corev1.PodAntiAffinity) {
+	if template.Spec.Affinity == nil {
+		template.Spec.Affinity = &corev1.Affinity{}
+	}
+	template.Spec.Affinity.PodAntiAffinity = podAntiAffinity
+}
+
+func hasTopologySpreadConstraint(constraints []corev1.TopologySpreadConstraint, topologyKey string) bool {
+	for _, c := range constraints {
+		if c.TopologyKey == topologyKey {
+			return true
+		}
+	}
+	return false
+}
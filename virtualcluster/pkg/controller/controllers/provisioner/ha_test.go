@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func newHAStatefulSet(replicas int32, podSpec corev1.PodSpec) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"component-name": "etcd"}},
+				Spec:       podSpec,
+			},
+		},
+	}
+}
+
+func TestApplyHighAvailability(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"component-name": "etcd"}}
+	hostTerm := corev1.PodAffinityTerm{LabelSelector: selector, TopologyKey: corev1.LabelHostname}
+	existingAffinity := &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{TopologyKey: "rack"}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		sts      *appsv1.StatefulSet
+		policy   *tenancyv1alpha1.HighAvailabilityPolicy
+		expected corev1.PodSpec
+	}{
+		{
+			name:     "single replica",
+			sts:      newHAStatefulSet(1, corev1.PodSpec{}),
+			expected: corev1.PodSpec{},
+		},
+		{
+			name: "preferred by default",
+			sts:  newHAStatefulSet(3, corev1.PodSpec{}),
+			expected: corev1.PodSpec{
+				Affinity: &corev1.Affinity{
+					PodAntiAffinity: &corev1.PodAntiAffinity{
+						PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+							{Weight: antiAffinityWeight, PodAffinityTerm: hostTerm},
+						},
+					},
+				},
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+					{MaxSkew: 1, TopologyKey: corev1.LabelTopologyZone, WhenUnsatisfiable: corev1.ScheduleAnyway, LabelSelector: selector},
+				},
+			},
+		},
+		{
+			name: "required",
+			sts:  newHAStatefulSet(3, corev1.PodSpec{}),
+			policy: &tenancyv1alpha1.HighAvailabilityPolicy{
+				NodeAntiAffinity: tenancyv1alpha1.SpreadRequired,
+				ZoneSpread:       tenancyv1alpha1.SpreadRequired,
+			},
+			expected: corev1.PodSpec{
+				Affinity: &corev1.Affinity{
+					PodAntiAffinity: &corev1.PodAntiAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{hostTerm},
+					},
+				},
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+					{MaxSkew: 1, TopologyKey: corev1.LabelTopologyZone, WhenUnsatisfiable: corev1.DoNotSchedule, LabelSelector: selector},
+				},
+			},
+		},
+		{
+			name: "disabled",
+			sts:  newHAStatefulSet(3, corev1.PodSpec{}),
+			policy: &tenancyv1alpha1.HighAvailabilityPolicy{
+				NodeAntiAffinity: tenancyv1alpha1.SpreadNone,
+				ZoneSpread:       tenancyv1alpha1.SpreadNone,
+			},
+			expected: corev1.PodSpec{},
+		},
+		{
+			name: "keep constraints defined in the template",
+			sts: newHAStatefulSet(3, corev1.PodSpec{
+				Affinity: existingAffinity,
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+					{MaxSkew: 2, TopologyKey: corev1.LabelTopologyZone, WhenUnsatisfiable: corev1.DoNotSchedule},
+				},
+			}),
+			expected: corev1.PodSpec{
+				Affinity: existingAffinity,
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+					{MaxSkew: 2, TopologyKey: corev1.LabelTopologyZone, WhenUnsatisfiable: corev1.DoNotSchedule},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			applyHighAvailability(tc.sts, tc.policy)
+			if !equality.Semantic.DeepEqual(tc.sts.Spec.Template.Spec, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, tc.sts.Spec.Template.Spec)
+			}
+		})
+	}
+}
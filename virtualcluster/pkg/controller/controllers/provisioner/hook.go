@@ -0,0 +1,235 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// hookKubeconfigDir is where the admin kubeconfig of the tenant cluster is mounted in hook Jobs
+	hookKubeconfigDir = "/etc/virtualcluster"
+
+	defaultHookWebhookTimeoutSeconds = 10
+	maxHookWebhookTimeoutSeconds     = 30
+)
+
+var (
+	// hookJobPollPeriod is the period at which a PostCreate Job hook is checked for completion
+	hookJobPollPeriod = ComponentPollPeriodSec * time.Second
+
+	// hookWebhookClient sends the requests of the webhook hooks, its timeout bounds every
+	// request even if the context of the caller has no deadline
+	hookWebhookClient = &http.Client{Timeout: maxHookWebhookTimeoutSeconds * time.Second}
+)
+
+// hookPayload is the body of the requests sent to webhook hooks
+type hookPayload struct {
+	Point          tenancyv1alpha1.HookPoint `json:"point"`
+	Name           string                    `json:"name"`
+	Namespace      string                    `json:"namespace"`
+	UID            string                    `json:"uid"`
+	ClusterVersion string                    `json:"clusterVersion"`
+	RootNamespace  string                    `json:"rootNamespace"`
+}
+
+// runHooks executes the hooks of cv at point and records the result of each hook as a
+// condition of vc. It returns an error if a hook with the Fail policy failed, or if a
+// PreDelete Job hook has not completed yet, so that the caller retries. PostCreate Job
+// hooks are waited for, a Job not completed before the provisioner timeout fails.
+func (mpn *Native) runHooks(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, point tenancyv1alpha1.HookPoint) error {
+	var pending []string
+	for _, hook := range cv.Spec.Hooks {
+		if hook.Point != point {
+			continue
+		}
+		condType := tenancyv1alpha1.HookConditionType(hook.Name)
+		done, err := mpn.runHook(ctx, vc, hook)
+		switch {
+		case err != nil:
+			mpn.Log.Error(err, "lifecycle hook failed", "vc", vc.GetName(), "hook", hook.Name, "point", point)
+			kubeutil.SetVCCondition(vc, condType, corev1.ConditionFalse, "HookFailed", err.Error())
+			if hook.FailurePolicy == tenancyv1alpha1.HookFailurePolicyFail {
+				return fmt.Errorf("%s hook %s failed: %v", point, hook.Name, err)
+			}
+		case !done:
+			kubeutil.SetVCCondition(vc, condType, corev1.ConditionUnknown, "HookRunning", "")
+			pending = append(pending, hook.Name)
+		default:
+			mpn.Log.Info("lifecycle hook succeeded", "vc", vc.GetName(), "hook", hook.Name, "point", point)
+			kubeutil.SetVCCondition(vc, condType, corev1.ConditionTrue, "HookSucceeded", "")
+		}
+	}
+	if len(pending) > 0 && point == tenancyv1alpha1.HookPreDelete {
+		return fmt.Errorf("waiting for %s hooks %v to complete", point, pending)
+	}
+	return nil
+}
+
+// runHook executes hook and returns whether it has completed.
+func (mpn *Native) runHook(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, hook tenancyv1alpha1.LifecycleHook) (bool, error) {
+	switch {
+	case hook.Job != nil && hook.Webhook != nil:
+		return false, fmt.Errorf("hook must specify only one of job and webhook")
+	case hook.Job != nil && hook.Point == tenancyv1alpha1.HookPostCreate:
+		return mpn.waitJobHook(ctx, vc, hook)
+	case hook.Job != nil:
+		return mpn.runJobHook(ctx, vc, hook)
+	case hook.Webhook != nil:
+		return true, callWebhookHook(ctx, vc, hook)
+	default:
+		return false, fmt.Errorf("hook must specify one of job and webhook")
+	}
+}
+
+// runJobHook creates the Job of hook in the root namespace of vc if it does not exist and
+// returns whether the Job has succeeded.
+func (mpn *Native) runJobHook(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, hook tenancyv1alpha1.LifecycleHook) (bool, error) {
+	job := &batchv1.Job{}
+	err := mpn.Get(ctx, client.ObjectKey{Namespace: conversion.ToRootNamespace(vc), Name: hookJobName(hook)}, job)
+	if apierrors.IsNotFound(err) {
+		if err := mpn.Create(ctx, newHookJob(vc, hook)); err != nil && !apierrors.IsAlreadyExists(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return false, fmt.Errorf("job %s failed: %s", job.Name, cond.Message)
+		}
+	}
+	return job.Status.Succeeded > 0, nil
+}
+
+// waitJobHook runs the Job of hook and waits for it to complete, up to the provisioner timeout.
+func (mpn *Native) waitJobHook(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, hook tenancyv1alpha1.LifecycleHook) (bool, error) {
+	if mpn.ProvisionerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mpn.ProvisionerTimeout)
+		defer cancel()
+	}
+	err := wait.PollImmediateUntil(hookJobPollPeriod, func() (bool, error) {
+		return mpn.runJobHook(ctx, vc, hook)
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		return false, fmt.Errorf("job %s did not complete in time", hookJobName(hook))
+	}
+	return err == nil, err
+}
+
+func hookJobName(hook tenancyv1alpha1.LifecycleHook) string {
+	return fmt.Sprintf("hook-%s-%s", strings.ToLower(string(hook.Point)), hook.Name)
+}
+
+// newHookJob builds the Job of hook, every container is given the admin kubeconfig of the
+// tenant cluster and the identity of vc through env.
+func newHookJob(vc *tenancyv1alpha1.VirtualCluster, hook tenancyv1alpha1.LifecycleHook) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: *hook.Job.ObjectMeta.DeepCopy(),
+		Spec:       *hook.Job.Spec.DeepCopy(),
+	}
+	job.Name = hookJobName(hook)
+	job.Namespace = conversion.ToRootNamespace(vc)
+
+	podSpec := &job.Spec.Template.Spec
+	if podSpec.RestartPolicy == "" {
+		podSpec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: secret.AdminSecretName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: secret.AdminSecretName},
+		},
+	})
+	env := []corev1.EnvVar{
+		{Name: "KUBECONFIG", Value: filepath.Join(hookKubeconfigDir, secret.AdminSecretName)},
+		{Name: "VC_NAME", Value: vc.Name},
+		{Name: "VC_NAMESPACE", Value: vc.Namespace},
+		{Name: "VC_HOOK_POINT", Value: string(hook.Point)},
+	}
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		c.Env = append(c.Env, env...)
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      secret.AdminSecretName,
+			MountPath: hookKubeconfigDir,
+			ReadOnly:  true,
+		})
+	}
+	return job
+}
+
+// callWebhookHook sends a POST request describing vc to the webhook of hook.
+func callWebhookHook(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, hook tenancyv1alpha1.LifecycleHook) error {
+	body, err := json.Marshal(hookPayload{
+		Point:          hook.Point,
+		Name:           vc.Name,
+		Namespace:      vc.Namespace,
+		UID:            string(vc.UID),
+		ClusterVersion: vc.Spec.ClusterVersionName,
+		RootNamespace:  conversion.ToRootNamespace(vc),
+	})
+	if err != nil {
+		return err
+	}
+
+	timeout := int32(defaultHookWebhookTimeoutSeconds)
+	if hook.Webhook.TimeoutSeconds != nil {
+		timeout = *hook.Webhook.TimeoutSeconds
+	}
+	if timeout > maxHookWebhookTimeoutSeconds {
+		timeout = maxHookWebhookTimeoutSeconds
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := hookWebhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
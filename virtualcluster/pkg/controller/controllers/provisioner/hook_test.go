@@ -0,0 +1,267 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"time"
+)
+
+func newHookTestVC() *tenancyv1alpha1.VirtualCluster {
+	return &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vc",
+			Namespace: "tenant-1",
+			UID:       "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f",
+		},
+		Spec: tenancyv1alpha1.VirtualClusterSpec{ClusterVersionName: "cv"},
+	}
+}
+
+func newJobHook(name string, point tenancyv1alpha1.HookPoint) tenancyv1alpha1.LifecycleHook {
+	return tenancyv1alpha1.LifecycleHook{
+		Name:  name,
+		Point: point,
+		Job: &batchv1.JobTemplateSpec{
+			Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "register", Image: "register:latest"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestNewHookJob(t *testing.T) {
+	vc := newHookTestVC()
+	job := newHookJob(vc, newJobHook("billing", tenancyv1alpha1.HookPostCreate))
+	if job.Name != "hook-postcreate-billing" || job.Namespace != conversion.ToClusterKey(vc) {
+		t.Errorf("unexpected job %s/%s", job.Namespace, job.Name)
+	}
+	if job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("expected default restart policy Never, got %s", job.Spec.Template.Spec.RestartPolicy)
+	}
+	container := job.Spec.Template.Spec.Containers[0]
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != hookKubeconfigDir {
+		t.Errorf("expected admin kubeconfig to be mounted, got %v", container.VolumeMounts)
+	}
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if env["VC_NAME"] != "vc" || env["VC_NAMESPACE"] != "tenant-1" || env["KUBECONFIG"] == "" {
+		t.Errorf("unexpected env %v", env)
+	}
+}
+
+func TestCallWebhookHook(t *testing.T) {
+	var got hookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if got.Name == "rejected" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	hook := tenancyv1alpha1.LifecycleHook{
+		Name:    "gitops",
+		Point:   tenancyv1alpha1.HookPostCreate,
+		Webhook: &tenancyv1alpha1.WebhookHook{URL: server.URL},
+	}
+	vc := newHookTestVC()
+	if err := callWebhookHook(context.TODO(), vc, hook); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "vc" || got.Point != tenancyv1alpha1.HookPostCreate || got.RootNamespace != conversion.ToClusterKey(vc) {
+		t.Errorf("unexpected payload %+v", got)
+	}
+
+	vc.Name = "rejected"
+	if err := callWebhookHook(context.TODO(), vc, hook); err == nil {
+		t.Errorf("expected error on non-2xx response")
+	}
+}
+
+func TestRunPreDeleteHooks(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	vc := newHookTestVC()
+	cv := &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "cv"},
+		Spec: tenancyv1alpha1.ClusterVersionSpec{
+			Hooks: []tenancyv1alpha1.LifecycleHook{
+				newJobHook("deregister", tenancyv1alpha1.HookPreDelete),
+				newJobHook("billing", tenancyv1alpha1.HookPostCreate),
+			},
+		},
+	}
+	mpn := &Native{
+		Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		scheme: scheme,
+		Log:    ctrl.Log.WithName("test"),
+	}
+
+	if err := mpn.runHooks(context.TODO(), vc, cv, tenancyv1alpha1.HookPreDelete); err == nil {
+		t.Fatalf("expected deletion to wait for the job hook")
+	}
+	job := &batchv1.Job{}
+	key := client.ObjectKey{Namespace: conversion.ToClusterKey(vc), Name: "hook-predelete-deregister"}
+	if err := mpn.Get(context.TODO(), key, job); err != nil {
+		t.Fatalf("expected hook job to be created: %v", err)
+	}
+	if err := mpn.Get(context.TODO(), client.ObjectKey{Namespace: key.Namespace, Name: "hook-postcreate-billing"}, &batchv1.Job{}); err == nil {
+		t.Errorf("expected PostCreate hook not to run")
+	}
+
+	job.Status.Succeeded = 1
+	if err := mpn.Status().Update(context.TODO(), job); err != nil {
+		t.Fatalf("failed to update job status: %v", err)
+	}
+	if err := mpn.runHooks(context.TODO(), vc, cv, tenancyv1alpha1.HookPreDelete); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cond := vc.Status.Conditions[len(vc.Status.Conditions)-1]
+	if cond.Type != tenancyv1alpha1.HookConditionType("deregister") || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected hook succeeded condition, got %+v", cond)
+	}
+}
+
+func TestRunPostCreateHooks(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	defer func(period time.Duration) { hookJobPollPeriod = period }(hookJobPollPeriod)
+	hookJobPollPeriod = 10 * time.Millisecond
+
+	vc := newHookTestVC()
+	jobWithStatus := func(hook tenancyv1alpha1.LifecycleHook, status batchv1.JobStatus) *batchv1.Job {
+		job := newHookJob(vc, hook)
+		job.Status = status
+		return job
+	}
+	failed := batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}}
+
+	testcases := map[string]struct {
+		failurePolicy     tenancyv1alpha1.HookFailurePolicy
+		existingStatus    *batchv1.JobStatus
+		expectedErr       bool
+		expectedCondition corev1.ConditionStatus
+	}{
+		"job succeeded": {
+			failurePolicy:     tenancyv1alpha1.HookFailurePolicyFail,
+			existingStatus:    &batchv1.JobStatus{Succeeded: 1},
+			expectedCondition: corev1.ConditionTrue,
+		},
+		"job failed with policy Fail": {
+			failurePolicy:     tenancyv1alpha1.HookFailurePolicyFail,
+			existingStatus:    &failed,
+			expectedErr:       true,
+			expectedCondition: corev1.ConditionFalse,
+		},
+		"job failed with policy Ignore": {
+			failurePolicy:     tenancyv1alpha1.HookFailurePolicyIgnore,
+			existingStatus:    &failed,
+			expectedCondition: corev1.ConditionFalse,
+		},
+		"job never completes with policy Fail": {
+			failurePolicy:     tenancyv1alpha1.HookFailurePolicyFail,
+			expectedErr:       true,
+			expectedCondition: corev1.ConditionFalse,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			hook := newJobHook("billing", tenancyv1alpha1.HookPostCreate)
+			hook.FailurePolicy = tc.failurePolicy
+			cv := &tenancyv1alpha1.ClusterVersion{
+				ObjectMeta: metav1.ObjectMeta{Name: "cv"},
+				Spec:       tenancyv1alpha1.ClusterVersionSpec{Hooks: []tenancyv1alpha1.LifecycleHook{hook}},
+			}
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.existingStatus != nil {
+				builder = builder.WithObjects(jobWithStatus(hook, *tc.existingStatus))
+			}
+			mpn := &Native{
+				Client:             builder.Build(),
+				scheme:             scheme,
+				Log:                ctrl.Log.WithName("test"),
+				ProvisionerTimeout: 100 * time.Millisecond,
+			}
+			vc := vc.DeepCopy()
+
+			err := mpn.runHooks(context.TODO(), vc, cv, tenancyv1alpha1.HookPostCreate)
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			cond := vc.Status.Conditions[len(vc.Status.Conditions)-1]
+			if cond.Type != tenancyv1alpha1.HookConditionType("billing") || cond.Status != tc.expectedCondition {
+				t.Errorf("expected hook condition %s, got %+v", tc.expectedCondition, cond)
+			}
+		})
+	}
+}
+
+func TestCallWebhookHookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	defer func(client *http.Client) { hookWebhookClient = client }(hookWebhookClient)
+	hookWebhookClient = &http.Client{Timeout: 100 * time.Millisecond}
+
+	hook := tenancyv1alpha1.LifecycleHook{
+		Name:    "gitops",
+		Point:   tenancyv1alpha1.HookPostCreate,
+		Webhook: &tenancyv1alpha1.WebhookHook{URL: server.URL},
+	}
+	start := time.Now()
+	if err := callWebhookHook(context.TODO(), newHookTestVC(), hook); err == nil {
+		t.Errorf("expected error on a webhook not responding")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the request to time out with the client, took %s", elapsed)
+	}
+}
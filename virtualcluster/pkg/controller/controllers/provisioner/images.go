@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// needsArchitectures returns true if an override of the policy pins the
+// control plane to an architecture, i.e. it has per-architecture images
+// and no fallback image
+func needsArchitectures(policy *tenancyv1alpha1.ImagePolicy) bool {
+	if policy == nil {
+		return false
+	}
+	for _, o := range policy.Overrides {
+		if len(o.Architectures) > 0 && o.Image == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeArchitectures returns the sorted architectures of the schedulable nodes
+// matching the node selector
+func nodeArchitectures(nodes []corev1.Node, nodeSelector map[string]string) []string {
+	selector := labels.SelectorFromSet(nodeSelector)
+	seen := make(map[string]struct{})
+	var archs []string
+	for _, node := range nodes {
+		arch := node.Labels[corev1.LabelArchStable]
+		if arch == "" || node.Spec.Unschedulable || !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if _, ok := seen[arch]; !ok {
+			seen[arch] = struct{}{}
+			archs = append(archs, arch)
+		}
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+// applyImages overrides the images of the containers of a control plane
+// component. If an override has to pin the component to an architecture, the
+// first of archs that all the matching overrides provide an image for is
+// chosen, or the architecture the template already selects, and the pods are
+// scheduled to the nodes of that architecture
+func applyImages(template *corev1.PodTemplateSpec, component string, policy *tenancyv1alpha1.ImagePolicy, archs []string) error {
+	if policy == nil || len(policy.Overrides) == 0 {
+		return nil
+	}
+
+	var pinned []tenancyv1alpha1.ImageOverride
+	for _, c := range template.Spec.Containers {
+		for _, o := range policy.Overrides {
+			if matchOverride(o, component, c.Name) && len(o.Architectures) > 0 && o.Image == "" {
+				pinned = append(pinned, o)
+			}
+		}
+	}
+
+	arch := ""
+	if len(pinned) > 0 {
+		candidates := archs
+		if selected, ok := template.Spec.NodeSelector[corev1.LabelArchStable]; ok {
+			candidates = []string{selected}
+		}
+		for _, candidate := range candidates {
+			if supportsArchitecture(pinned, candidate) {
+				arch = candidate
+				break
+			}
+		}
+		if arch == "" {
+			return fmt.Errorf("no image of component %s matches the super cluster node architectures %v", component, candidates)
+		}
+		if template.Spec.NodeSelector == nil {
+			template.Spec.NodeSelector = map[string]string{}
+		}
+		template.Spec.NodeSelector[corev1.LabelArchStable] = arch
+	}
+
+	for i := range template.Spec.Containers {
+		c := &template.Spec.Containers[i]
+		for _, o := range policy.Overrides {
+			if !matchOverride(o, component, c.Name) {
+				continue
+			}
+			if image, ok := o.Architectures[arch]; ok && arch != "" {
+				c.Image = image
+				continue
+			}
+			image := o.Image
+			if image == "" {
+				image = c.Image
+			}
+			if o.Digest != "" {
+				image = withDigest(image, o.Digest)
+			}
+			c.Image = image
+		}
+	}
+	return nil
+}
+
+func matchOverride(o tenancyv1alpha1.ImageOverride, component, container string) bool {
+	return (o.Component == "" || o.Component == component) && (o.Container == "" || o.Container == container)
+}
+
+func supportsArchitecture(overrides []tenancyv1alpha1.ImageOverride, arch string) bool {
+	for _, o := range overrides {
+		if _, ok := o.Architectures[arch]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// withDigest replaces the tag or digest of image with digest
+func withDigest(image, digest string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image + "@" + digest
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestApplyImages(t *testing.T) {
+	etcdArchs := tenancyv1alpha1.ImageOverride{
+		Component:     "etcd",
+		Architectures: map[string]string{"arm64": "etcd:3.4-arm64"},
+	}
+	for _, tc := range []struct {
+		name         string
+		nodeSelector map[string]string
+		policy       *tenancyv1alpha1.ImagePolicy
+		archs        []string
+		expected     string
+		expectedArch string
+		expectErr    bool
+	}{
+		{
+			name:     "no policy",
+			expected: "etcd:3.4",
+		},
+		{
+			name: "pin the digest of the cluster version image",
+			policy: &tenancyv1alpha1.ImagePolicy{Overrides: []tenancyv1alpha1.ImageOverride{
+				{Digest: "sha256:abc"},
+			}},
+			expected: "etcd@sha256:abc",
+		},
+		{
+			name: "later override wins",
+			policy: &tenancyv1alpha1.ImagePolicy{Overrides: []tenancyv1alpha1.ImageOverride{
+				{Image: "registry:5000/etcd:3.5"},
+				{Component: "etcd", Container: "etcd", Image: "registry:5000/etcd:3.5.1", Digest: "sha256:def"},
+				{Component: "apiserver", Image: "apiserver:1.22"},
+			}},
+			expected: "registry:5000/etcd@sha256:def",
+		},
+		{
+			name:         "pin to the architecture of the image",
+			policy:       &tenancyv1alpha1.ImagePolicy{Overrides: []tenancyv1alpha1.ImageOverride{etcdArchs}},
+			archs:        []string{"amd64", "arm64"},
+			expected:     "etcd:3.4-arm64",
+			expectedArch: "arm64",
+		},
+		{
+			name:         "architecture selected by the placement",
+			nodeSelector: map[string]string{corev1.LabelArchStable: "amd64"},
+			policy:       &tenancyv1alpha1.ImagePolicy{Overrides: []tenancyv1alpha1.ImageOverride{etcdArchs}},
+			archs:        []string{"amd64", "arm64"},
+			expectErr:    true,
+		},
+		{
+			name: "fallback image is not pinned",
+			policy: &tenancyv1alpha1.ImagePolicy{Overrides: []tenancyv1alpha1.ImageOverride{
+				{Image: "etcd:3.4-multiarch", Architectures: map[string]string{"arm64": "etcd:3.4-arm64"}},
+			}},
+			archs:    []string{"amd64"},
+			expected: "etcd:3.4-multiarch",
+		},
+		{
+			name:      "no matching architecture",
+			policy:    &tenancyv1alpha1.ImagePolicy{Overrides: []tenancyv1alpha1.ImageOverride{etcdArchs}},
+			archs:     []string{"amd64"},
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				NodeSelector: tc.nodeSelector,
+				Containers:   []corev1.Container{{Name: "etcd", Image: "etcd:3.4"}},
+			}}
+			err := applyImages(template, "etcd", tc.policy, tc.archs)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if image := template.Spec.Containers[0].Image; image != tc.expected {
+				t.Errorf("expected image %q, got %q", tc.expected, image)
+			}
+			if arch := template.Spec.NodeSelector[corev1.LabelArchStable]; arch != tc.expectedArch {
+				t.Errorf("expected architecture %q, got %q", tc.expectedArch, arch)
+			}
+		})
+	}
+}
+
+func TestNodeArchitectures(t *testing.T) {
+	node := func(name, arch, pool string, unschedulable bool) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelArchStable: arch, "pool": pool}},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		}
+	}
+	nodes := []corev1.Node{
+		node("a", "arm64", "control-plane", false),
+		node("b", "amd64", "control-plane", false),
+		node("c", "arm64", "control-plane", false),
+		node("d", "s390x", "control-plane", true),
+		node("e", "ppc64le", "workers", false),
+	}
+
+	if archs := nodeArchitectures(nodes, nil); !equality.Semantic.DeepEqual(archs, []string{"amd64", "arm64", "ppc64le"}) {
+		t.Errorf("unexpected architectures %v", archs)
+	}
+	if archs := nodeArchitectures(nodes, map[string]string{"pool": "control-plane"}); !equality.Semantic.DeepEqual(archs, []string{"amd64", "arm64"}) {
+		t.Errorf("unexpected architectures %v", archs)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// DefaultLogShipperImage is the fluent-bit image of the log shipper sidecar
+	// if the logging policy doesn't set one
+	DefaultLogShipperImage = "cr.fluentbit.io/fluent/fluent-bit:1.9.10"
+
+	logShipperContainerName = "log-shipper"
+	logVolumeName           = "control-plane-logs"
+	logDir                  = "/var/log/virtualcluster"
+	// logFileMaxSizeMB is the size at which the apiserver and the
+	// controller-manager start over their log file
+	logFileMaxSizeMB = "100"
+	defaultLokiURI   = "/loki/api/v1/push"
+)
+
+// logVolumeSizeLimit bounds the log volume, etcd doesn't rotate its log file
+// so the kubelet evicts the pod, and gets it a fresh volume, once it is full
+var logVolumeSizeLimit = resource.MustParse("1Gi")
+
+var lokiLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// applyLogShipping makes a control plane component write its logs to a volume
+// shared with a fluent-bit sidecar, which ships them to the sink of the policy
+// with the labels of the virtual cluster
+func applyLogShipping(template *corev1.PodTemplateSpec, component string, vc *tenancyv1alpha1.VirtualCluster, policy *tenancyv1alpha1.LoggingPolicy) error {
+	if !policy.ShipsLogs(component) || len(template.Spec.Containers) == 0 {
+		return nil
+	}
+	args, err := logShipperArgs(component, logShipperLabels(component, vc, policy.Sink.Labels), policy.Sink)
+	if err != nil {
+		return err
+	}
+
+	container := &template.Spec.Containers[0]
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == component {
+			container = &template.Spec.Containers[i]
+			break
+		}
+	}
+	logFile := path.Join(logDir, component+".log")
+	if component == "etcd" {
+		setFlag(container, "logger", "zap")
+		setFlag(container, "log-outputs", "stderr,"+logFile)
+	} else {
+		setFlag(container, "logtostderr", "false")
+		setFlag(container, "alsologtostderr", "true")
+		setFlag(container, "log-file", logFile)
+		setFlag(container, "log-file-max-size", logFileMaxSizeMB)
+	}
+
+	addEmptyDir(&template.Spec, logVolumeName)
+	for i := range template.Spec.Volumes {
+		if v := &template.Spec.Volumes[i]; v.Name == logVolumeName && v.EmptyDir != nil {
+			limit := logVolumeSizeLimit.DeepCopy()
+			v.EmptyDir.SizeLimit = &limit
+		}
+	}
+	if !isMounted(container, logDir) {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: logVolumeName, MountPath: logDir})
+	}
+
+	image := policy.Image
+	if image == "" {
+		image = DefaultLogShipperImage
+	}
+	sidecar := corev1.Container{
+		Name:         logShipperContainerName,
+		Image:        image,
+		Command:      []string{"/fluent-bit/bin/fluent-bit"},
+		Args:         args,
+		Resources:    policy.Resources,
+		VolumeMounts: []corev1.VolumeMount{{Name: logVolumeName, MountPath: logDir, ReadOnly: true}},
+	}
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == logShipperContainerName {
+			template.Spec.Containers[i] = sidecar
+			return nil
+		}
+	}
+	template.Spec.Containers = append(template.Spec.Containers, sidecar)
+	return nil
+}
+
+// logShipperLabels returns the labels of the log records of a component, the
+// virtual cluster labels take precedence over the extra labels of the sink
+func logShipperLabels(component string, vc *tenancyv1alpha1.VirtualCluster, extra map[string]string) map[string]string {
+	labels := make(map[string]string, len(extra)+4)
+	for k, v := range extra {
+		labels[k] = v
+	}
+	labels["virtualcluster_namespace"] = vc.Namespace
+	labels["virtualcluster_name"] = vc.Name
+	labels["virtualcluster_root_namespace"] = conversion.ToRootNamespace(vc)
+	labels["component"] = component
+	return labels
+}
+
+// logShipperArgs returns the fluent-bit command line tailing the log file of
+// the component, adding the labels to the records and shipping them to sink
+func logShipperArgs(component string, labels map[string]string, sink tenancyv1alpha1.LogSink) ([]string, error) {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := []string{
+		"--quiet",
+		"-i", "tail",
+		"-p", "path=" + path.Join(logDir, component+".log"),
+		"-p", "tag=" + component,
+		"-p", "refresh_interval=5",
+		"-p", "skip_long_lines=on",
+		"-F", "record_modifier", "-m", "*",
+	}
+	for _, k := range keys {
+		args = append(args, "-p", fmt.Sprintf("record=%s %s", k, labels[k]))
+	}
+
+	switch sink.Type {
+	case tenancyv1alpha1.LogSinkStdout:
+		args = append(args, "-o", "stdout", "-m", "*", "-p", "format=json_lines")
+	case tenancyv1alpha1.LogSinkLoki:
+		loki, err := lokiArgs(sink, keys, labels)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, loki...)
+	default:
+		return nil, fmt.Errorf("unknown log sink type %q", sink.Type)
+	}
+	return args, nil
+}
+
+func lokiArgs(sink tenancyv1alpha1.LogSink, keys []string, labels map[string]string) ([]string, error) {
+	u, err := url.Parse(sink.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid Loki URL %q", sink.URL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	uri := u.Path
+	if uri == "" {
+		uri = defaultLokiURI
+	}
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if !lokiLabelName.MatchString(k) || strings.ContainsAny(labels[k], ",=") {
+			return nil, fmt.Errorf("invalid Loki label %s=%s", k, labels[k])
+		}
+		pairs = append(pairs, k+"="+labels[k])
+	}
+
+	tls := "off"
+	if u.Scheme == "https" {
+		tls = "on"
+	}
+	args := []string{
+		"-o", "loki", "-m", "*",
+		"-p", "host=" + u.Hostname(),
+		"-p", "port=" + port,
+		"-p", "uri=" + uri,
+		"-p", "tls=" + tls,
+		"-p", "labels=" + strings.Join(pairs, ","),
+		"-p", "line_format=json",
+	}
+	if sink.TenantID != "" {
+		args = append(args, "-p", "tenant_id="+sink.TenantID)
+	}
+	if net.ParseIP(u.Hostname()) == nil && tls == "on" {
+		args = append(args, "-p", "tls.vhost="+u.Hostname())
+	}
+	return args, nil
+}
+
+// setFlag sets --flag=value in the command or args of the container
+func setFlag(c *corev1.Container, flag, value string) {
+	arg := "--" + flag + "=" + value
+	if !replaceFlag(c.Command, flag, arg) && !replaceFlag(c.Args, flag, arg) {
+		c.Args = append(c.Args, arg)
+	}
+}
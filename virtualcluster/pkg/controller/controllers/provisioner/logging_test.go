@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func TestApplyLogShipping(t *testing.T) {
+	vc := &tenancyv1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "vc", UID: "d5a4f5a6-6f4e-4d6c-9f4e-2c4b1c8d9e0f"}}
+
+	for _, tc := range []struct {
+		name         string
+		component    string
+		args         []string
+		policy       *tenancyv1alpha1.LoggingPolicy
+		expectedArgs []string
+		expectedLogs []string
+		expectedErr  string
+	}{
+		{
+			name:         "no policy",
+			component:    "apiserver",
+			args:         []string{"--v=2"},
+			expectedArgs: []string{"--v=2"},
+		},
+		{
+			name:         "component not shipped",
+			component:    "etcd",
+			policy:       &tenancyv1alpha1.LoggingPolicy{Components: []string{"apiserver"}, Sink: tenancyv1alpha1.LogSink{Type: tenancyv1alpha1.LogSinkStdout}},
+			expectedArgs: nil,
+		},
+		{
+			name:         "etcd to stdout",
+			component:    "etcd",
+			args:         []string{"--log-outputs=stderr"},
+			policy:       &tenancyv1alpha1.LoggingPolicy{Sink: tenancyv1alpha1.LogSink{Type: tenancyv1alpha1.LogSinkStdout, Labels: map[string]string{"component": "spoofed", "env": "prod"}}},
+			expectedArgs: []string{"--log-outputs=stderr," + logDir + "/etcd.log", "--logger=zap"},
+			expectedLogs: []string{
+				"path=" + logDir + "/etcd.log",
+				"record=component etcd",
+				"record=env prod",
+				"record=virtualcluster_name vc",
+				"format=json_lines",
+			},
+		},
+		{
+			name:      "apiserver to loki",
+			component: "apiserver",
+			policy: &tenancyv1alpha1.LoggingPolicy{Sink: tenancyv1alpha1.LogSink{
+				Type: tenancyv1alpha1.LogSinkLoki, URL: "https://loki.logging/custom/push", TenantID: "ops",
+			}},
+			expectedArgs: []string{"--logtostderr=false", "--alsologtostderr=true", "--log-file=" + logDir + "/apiserver.log", "--log-file-max-size=" + logFileMaxSizeMB},
+			expectedLogs: []string{
+				"host=loki.logging",
+				"port=443",
+				"uri=/custom/push",
+				"tls=on",
+				"tenant_id=ops",
+				"labels=component=apiserver,virtualcluster_name=vc,virtualcluster_namespace=tenant,virtualcluster_root_namespace=" + conversion.ToClusterKey(vc),
+			},
+		},
+		{
+			name:        "invalid loki url",
+			component:   "apiserver",
+			policy:      &tenancyv1alpha1.LoggingPolicy{Sink: tenancyv1alpha1.LogSink{Type: tenancyv1alpha1.LogSinkLoki, URL: "loki:3100"}},
+			expectedErr: "invalid Loki URL",
+		},
+		{
+			name:      "invalid loki label",
+			component: "apiserver",
+			policy: &tenancyv1alpha1.LoggingPolicy{Sink: tenancyv1alpha1.LogSink{
+				Type: tenancyv1alpha1.LogSinkLoki, URL: "http://loki:3100", Labels: map[string]string{"team.name": "a"},
+			}},
+			expectedErr: "invalid Loki label",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: tc.component, Args: tc.args}}}}
+			err := applyLogShipping(template, tc.component, vc, tc.policy)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := template.Spec.Containers[0].Args; strings.Join(got, " ") != strings.Join(tc.expectedArgs, " ") {
+				t.Errorf("expected args %v, got %v", tc.expectedArgs, got)
+			}
+			if len(tc.expectedLogs) == 0 {
+				if len(template.Spec.Containers) != 1 || len(template.Spec.Volumes) != 0 {
+					t.Errorf("expected the template to be untouched, got %+v", template.Spec)
+				}
+				return
+			}
+
+			// applying the policy again must not add another sidecar.
+			if err := applyLogShipping(template, tc.component, vc, tc.policy); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(template.Spec.Containers) != 2 || len(template.Spec.Volumes) != 1 || len(template.Spec.Containers[0].VolumeMounts) != 1 {
+				t.Fatalf("expected one log shipper sidecar and volume, got %+v", template.Spec)
+			}
+			if limit := template.Spec.Volumes[0].EmptyDir.SizeLimit; limit == nil || limit.Cmp(logVolumeSizeLimit) != 0 {
+				t.Errorf("expected the log volume to be limited to %s, got %v", logVolumeSizeLimit.String(), limit)
+			}
+			sidecar := template.Spec.Containers[1]
+			if sidecar.Name != logShipperContainerName || sidecar.Image != DefaultLogShipperImage {
+				t.Errorf("unexpected sidecar %+v", sidecar)
+			}
+			args := strings.Join(sidecar.Args, "\n")
+			for _, expected := range tc.expectedLogs {
+				if !strings.Contains(args, expected) {
+					t.Errorf("expected sidecar arg %q, got %v", expected, sidecar.Args)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// pkiOwnershipError is returned when a PKI secret of the root namespace belongs to
+// another virtual cluster, it is never retried
+type pkiOwnershipError struct {
+	namespace, name, owner, uid string
+}
+
+func (e *pkiOwnershipError) Error() string {
+	return fmt.Sprintf("PKI secret %s/%s belongs to virtualcluster %s, not %s", e.namespace, e.name, e.owner, e.uid)
+}
+
+func isPKIOwnershipError(err error) bool {
+	var ownershipErr *pkiOwnershipError
+	return errors.As(err, &ownershipErr)
+}
+
+// checkPKISecretOwner returns an error if the secret is owned by another virtual cluster,
+// the secrets created before the owner was recorded are adopted
+func checkPKISecretOwner(s *corev1.Secret, vc *tenancyv1alpha1.VirtualCluster) error {
+	if owner := s.Annotations[constants.LabelVCUID]; owner != "" && owner != string(vc.UID) {
+		return &pkiOwnershipError{namespace: s.Namespace, name: s.Name, owner: owner, uid: string(vc.UID)}
+	}
+	return nil
+}
+
+// isRetriablePKIError returns true for the conflicts and the transient apiserver errors
+func isRetriablePKIError(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
+
+// applyPKISecret creates the PKI secret of vc, or updates the existing one if it is stale.
+// The update is conditioned on the resource version of the secret that was compared, so a
+// concurrent change is never overwritten but retried with backoff, like the transient errors
+func (mpn *Native) applyPKISecret(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, desired *corev1.Secret) error {
+	desired = desired.DeepCopy()
+	if desired.Annotations == nil {
+		desired.Annotations = map[string]string{}
+	}
+	desired.Annotations[constants.LabelVCUID] = string(vc.UID)
+
+	reader := mpn.apiReader
+	if reader == nil {
+		reader = mpn.Client
+	}
+	err := retry.OnError(retry.DefaultBackoff, isRetriablePKIError, func() error {
+		existing := &corev1.Secret{}
+		err := reader.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+		if apierrors.IsNotFound(err) {
+			mpn.Log.Info("creating secret", "name", desired.Name, "namespace", desired.Namespace)
+			return mpn.Create(ctx, desired.DeepCopy())
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkPKISecretOwner(existing, vc); err != nil {
+			return err
+		}
+		if existing.Type != desired.Type {
+			return fmt.Errorf("PKI secret %s/%s is of type %s instead of %s, delete it to regenerate it", existing.Namespace, existing.Name, existing.Type, desired.Type)
+		}
+		if equality.Semantic.DeepEqual(existing.Data, desired.Data) && existing.Annotations[constants.LabelVCUID] == string(vc.UID) {
+			return nil
+		}
+
+		mpn.Log.Info("updating secret", "name", desired.Name, "namespace", desired.Namespace)
+		updated := existing.DeepCopy()
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[constants.LabelVCUID] = string(vc.UID)
+		updated.Data = desired.Data
+		return mpn.Update(ctx, updated)
+	})
+	if err != nil && !isPKIOwnershipError(err) {
+		return fmt.Errorf("failed to apply PKI secret %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+	return err
+}
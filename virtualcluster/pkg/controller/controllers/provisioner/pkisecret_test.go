@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func pkiSecret(name, owner, data string) *corev1.Secret {
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vc-root", Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte(data)},
+	}
+	if owner != "" {
+		s.Annotations = map[string]string{constants.LabelVCUID: owner}
+	}
+	return s
+}
+
+func TestApplyPKISecret(t *testing.T) {
+	vc := &tenancyv1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vc", UID: "uid-1"}}
+
+	for _, tc := range []struct {
+		name            string
+		existing        *corev1.Secret
+		desired         *corev1.Secret
+		expectedData    string
+		expectedUpdated bool
+		expectedErr     string
+	}{
+		{
+			name:            "created",
+			desired:         pkiSecret("apiserver-ca", "", "new"),
+			expectedData:    "new",
+			expectedUpdated: true,
+		},
+		{
+			name:            "stale secret is updated",
+			existing:        pkiSecret("apiserver-ca", "uid-1", "old"),
+			desired:         pkiSecret("apiserver-ca", "", "new"),
+			expectedData:    "new",
+			expectedUpdated: true,
+		},
+		{
+			name:            "secret without owner is adopted",
+			existing:        pkiSecret("apiserver-ca", "", "same"),
+			desired:         pkiSecret("apiserver-ca", "", "same"),
+			expectedData:    "same",
+			expectedUpdated: true,
+		},
+		{
+			name:         "up to date secret is not updated",
+			existing:     pkiSecret("apiserver-ca", "uid-1", "same"),
+			desired:      pkiSecret("apiserver-ca", "", "same"),
+			expectedData: "same",
+		},
+		{
+			name:         "secret of another virtual cluster",
+			existing:     pkiSecret("apiserver-ca", "uid-2", "old"),
+			desired:      pkiSecret("apiserver-ca", "", "new"),
+			expectedData: "old",
+			expectedErr:  "belongs to virtualcluster uid-2",
+		},
+		{
+			name: "secret of another type",
+			existing: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "vc-root", Name: "apiserver-ca"},
+				Type:       corev1.SecretTypeOpaque,
+				Data:       map[string][]byte{corev1.TLSCertKey: []byte("old")},
+			},
+			desired:      pkiSecret("apiserver-ca", "", "new"),
+			expectedData: "old",
+			expectedErr:  "delete it to regenerate it",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			resourceVersion := ""
+			if tc.existing != nil {
+				builder = builder.WithObjects(tc.existing)
+			}
+			mpn := &Native{Client: builder.Build(), Log: ctrl.Log.WithName("test")}
+			if tc.existing != nil {
+				current := &corev1.Secret{}
+				if err := mpn.Get(context.TODO(), client.ObjectKeyFromObject(tc.existing), current); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				resourceVersion = current.ResourceVersion
+			}
+
+			err := mpn.applyPKISecret(context.TODO(), vc, tc.desired)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("expected error %q, got %v", tc.expectedErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := &corev1.Secret{}
+			if err := mpn.Get(context.TODO(), client.ObjectKeyFromObject(tc.desired), got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got.Data[corev1.TLSCertKey]) != tc.expectedData {
+				t.Errorf("expected data %q, got %q", tc.expectedData, got.Data[corev1.TLSCertKey])
+			}
+			if updated := got.ResourceVersion != resourceVersion; updated != tc.expectedUpdated {
+				t.Errorf("expected updated %v, got %v", tc.expectedUpdated, updated)
+			}
+			if tc.expectedErr == "" && got.Annotations[constants.LabelVCUID] != string(vc.UID) {
+				t.Errorf("expected the secret to be owned by %s, got %v", vc.UID, got.Annotations)
+			}
+		})
+	}
+}
+
+func TestCreateAndApplyPKIForeignRootCA(t *testing.T) {
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vc", UID: "uid-1"},
+		Status:     tenancyv1alpha1.VirtualClusterStatus{ClusterNamespace: "vc-root"},
+	}
+	mpn := &Native{
+		Client: fake.NewClientBuilder().WithObjects(pkiSecret(secret.RootCASecretName, "uid-2", "foreign")).Build(),
+		Log:    ctrl.Log.WithName("test"),
+	}
+
+	// the root CA of another virtual cluster must neither be reused nor overwritten.
+	_, err := mpn.createAndApplyPKI(context.TODO(), vc, &tenancyv1alpha1.ClusterVersion{}, false)
+	if !isPKIOwnershipError(err) {
+		t.Fatalf("expected an ownership error, got %v", err)
+	}
+	secrets := &corev1.SecretList{}
+	if err := mpn.List(context.TODO(), secrets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secrets.Items) != 1 || string(secrets.Items[0].Data[corev1.TLSCertKey]) != "foreign" {
+		t.Errorf("expected only the untouched foreign root CA secret, got %+v", secrets.Items)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// applyPlacement injects the scheduling constraints of the VirtualCluster into
+// the pod template of a control plane component
+func applyPlacement(template *corev1.PodTemplateSpec, placement *tenancyv1alpha1.ControlPlanePlacement) {
+	if placement == nil {
+		return
+	}
+	podSpec := &template.Spec
+
+	if len(placement.NodeSelector) > 0 {
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = map[string]string{}
+		}
+		for k, v := range placement.NodeSelector {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+
+	for _, toleration := range placement.Tolerations {
+		if !hasToleration(podSpec.Tolerations, toleration) {
+			podSpec.Tolerations = append(podSpec.Tolerations, toleration)
+		}
+	}
+
+	for _, constraint := range placement.TopologySpreadConstraints {
+		c := *constraint.DeepCopy()
+		// spread the pods of the same component by default
+		if c.LabelSelector == nil {
+			c.LabelSelector = componentSelector(template)
+		}
+		podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, c)
+	}
+
+	if placement.PriorityClassName != "" {
+		podSpec.PriorityClassName = placement.PriorityClassName
+		// the priority is resolved from the class by the admission plugin
+		podSpec.Priority = nil
+	}
+}
+
+func hasToleration(tolerations []corev1.Toleration, toleration corev1.Toleration) bool {
+	for i := range tolerations {
+		if tolerations[i].MatchToleration(&toleration) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestApplyPlacement(t *testing.T) {
+	priority := int32(100)
+	for _, tc := range []struct {
+		name      string
+		template  corev1.PodTemplateSpec
+		placement *tenancyv1alpha1.ControlPlanePlacement
+		expected  corev1.PodTemplateSpec
+	}{
+		{
+			name: "no placement",
+			template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{NodeSelector: map[string]string{"pool": "default"}},
+			},
+			expected: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{NodeSelector: map[string]string{"pool": "default"}},
+			},
+		},
+		{
+			name: "merge node selector and override priority class",
+			template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeSelector:      map[string]string{"pool": "default", "arch": "amd64"},
+					PriorityClassName: "low",
+					Priority:          &priority,
+				},
+			},
+			placement: &tenancyv1alpha1.ControlPlanePlacement{
+				NodeSelector:      map[string]string{"pool": "control-plane"},
+				PriorityClassName: "system-cluster-critical",
+			},
+			expected: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeSelector:      map[string]string{"pool": "control-plane", "arch": "amd64"},
+					PriorityClassName: "system-cluster-critical",
+				},
+			},
+		},
+		{
+			name: "append tolerations once",
+			template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "cp", Effect: corev1.TaintEffectNoSchedule}},
+				},
+			},
+			placement: &tenancyv1alpha1.ControlPlanePlacement{
+				Tolerations: []corev1.Toleration{
+					{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "cp", Effect: corev1.TaintEffectNoSchedule},
+					{Key: "tier", Operator: corev1.TolerationOpExists},
+				},
+			},
+			expected: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Tolerations: []corev1.Toleration{
+						{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "cp", Effect: corev1.TaintEffectNoSchedule},
+						{Key: "tier", Operator: corev1.TolerationOpExists},
+					},
+				},
+			},
+		},
+		{
+			name: "default topology spread label selector to the component pods",
+			template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"component-name": "apiserver"}},
+			},
+			placement: &tenancyv1alpha1.ControlPlanePlacement{
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+					{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+					{
+						MaxSkew:           1,
+						TopologyKey:       "kubernetes.io/hostname",
+						WhenUnsatisfiable: corev1.DoNotSchedule,
+						LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "control-plane"}},
+					},
+				},
+			},
+			expected: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"component-name": "apiserver"}},
+				Spec: corev1.PodSpec{
+					TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+						{
+							MaxSkew:           1,
+							TopologyKey:       "topology.kubernetes.io/zone",
+							WhenUnsatisfiable: corev1.ScheduleAnyway,
+							LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"component-name": "apiserver"}},
+						},
+						{
+							MaxSkew:           1,
+							TopologyKey:       "kubernetes.io/hostname",
+							WhenUnsatisfiable: corev1.DoNotSchedule,
+							LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "control-plane"}},
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			template := tc.template.DeepCopy()
+			applyPlacement(template, tc.placement)
+			if !equality.Semantic.DeepEqual(*template, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, *template)
+			}
+		})
+	}
+}
@@ -29,3 +29,11 @@ type Provisioner interface {
 	// UpgradeVirtualCluster is used to apply current clusterversion if featuregate.VirtualClusterApplyUpdate enabled
 	UpgradeVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error
 }
+
+// StorageExpander is implemented by the provisioners that can expand the
+// storage of a running tenant control plane
+type StorageExpander interface {
+	// ExpandETCDStorage expands the etcd volumes to vc.Spec.ETCDStorage, it
+	// returns false while the volumes are being resized
+	ExpandETCDStorage(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (bool, error)
+}
@@ -24,9 +24,11 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/util/cert"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -39,6 +41,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	vcerrors "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
 	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
 )
 
@@ -57,11 +60,17 @@ type Native struct {
 	scheme             *runtime.Scheme
 	Log                logr.Logger
 	ProvisionerTimeout time.Duration
+	// DefaultSecurityProfile applies to the ClusterVersions not setting a security profile
+	DefaultSecurityProfile tenancyv1alpha1.SecurityProfile
+	// apiReader reads the secrets the checksum annotations are computed from, bypassing the
+	// cache that may not have observed the secrets applied just before
+	apiReader client.Reader
 }
 
 func NewProvisionerNative(mgr manager.Manager, log logr.Logger, provisionerTimeout time.Duration) (*Native, error) {
 	return &Native{
 		Client:             mgr.GetClient(),
+		apiReader:          mgr.GetAPIReader(),
 		scheme:             mgr.GetScheme(),
 		Log:                log.WithName("Native"),
 		ProvisionerTimeout: provisionerTimeout,
@@ -91,16 +100,27 @@ func (mpn *Native) CreateVirtualCluster(ctx context.Context, vc *tenancyv1alpha1
 	if err != nil {
 		return err
 	}
-	return mpn.applyVirtualCluster(ctx, cv, vc, true)
+	deployed, err := mpn.applyVirtualCluster(ctx, cv, vc, true)
+	if err != nil {
+		return err
+	}
+	vc.Status.AppliedClusterVersion = cv.Snapshot(deployed...)
+
+	// 6. apply addons once the apiserver is ready
+	mpn.applyAddons(ctx, vc, cv)
+
+	// 7. run the PostCreate hooks
+	return mpn.runHooks(ctx, vc, cv, tenancyv1alpha1.HookPostCreate)
 }
 
 func (mpn *Native) fetchClusterVersion(vc *tenancyv1alpha1.VirtualCluster) (*tenancyv1alpha1.ClusterVersion, error) {
 	cvObjectKey := client.ObjectKey{Name: vc.Spec.ClusterVersionName}
 	cv := &tenancyv1alpha1.ClusterVersion{}
 	if err := mpn.Get(context.Background(), cvObjectKey, cv); err != nil {
-		err = fmt.Errorf("desired ClusterVersion %s not found",
-			vc.Spec.ClusterVersionName)
-		return nil, err
+		if apierrors.IsNotFound(err) {
+			return nil, vcerrors.NewUserError("desired ClusterVersion %s not found", vc.Spec.ClusterVersionName)
+		}
+		return nil, fmt.Errorf("failed to get ClusterVersion %s: %w", vc.Spec.ClusterVersionName, err)
 	}
 	return cv, nil
 }
@@ -118,51 +138,87 @@ func (mpn *Native) UpgradeVirtualCluster(ctx context.Context, vc *tenancyv1alpha
 
 	// We currently do not support ETCD upgrades because of amount of manual actions required
 	// The easiest way to achieve it - pass empty ETCD definition to the ClusterVersion
-	return mpn.applyVirtualCluster(ctx, cv, vc, false)
+	deployed, err := mpn.applyVirtualCluster(ctx, cv, vc, false)
+	if err != nil {
+		return err
+	}
+	vc.Status.AppliedClusterVersion = upgradedSnapshot(vc.Status.AppliedClusterVersion, cv, deployed)
+	mpn.applyAddons(ctx, vc, cv)
+	return nil
+}
+
+// upgradedSnapshot returns the snapshot of the components deployed by the upgrade,
+// the etcd component is carried over from the previous snapshot as it is never
+// upgraded, whether the upgraded ClusterVersion defines it or not
+func upgradedSnapshot(prev *tenancyv1alpha1.ClusterVersionSnapshot, cv *tenancyv1alpha1.ClusterVersion, deployed []*tenancyv1alpha1.StatefulSetSvcBundle) *tenancyv1alpha1.ClusterVersionSnapshot {
+	snapshot := cv.Snapshot(deployed...)
+	if prev == nil {
+		return snapshot
+	}
+	if prevETCD := prev.GetComponent("etcd"); prevETCD != nil {
+		snapshot.Components = append([]tenancyv1alpha1.ComponentSnapshot{*prevETCD.DeepCopy()}, snapshot.Components...)
+	}
+	return snapshot
 }
 
-func (mpn *Native) applyVirtualCluster(ctx context.Context, cv *tenancyv1alpha1.ClusterVersion, vc *tenancyv1alpha1.VirtualCluster, applyETCD bool) error {
+// applyVirtualCluster deploys the control plane of vc from cv, it returns the components
+// deployed, rendered in place for vc. The whole control plane has to be ready before the
+// provisioner timeout, so that a vc whose statefulsets never get ready releases its worker
+// after one timeout
+func (mpn *Native) applyVirtualCluster(ctx context.Context, cv *tenancyv1alpha1.ClusterVersion, vc *tenancyv1alpha1.VirtualCluster, applyETCD bool) ([]*tenancyv1alpha1.StatefulSetSvcBundle, error) {
+	if mpn.ProvisionerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mpn.ProvisionerTimeout)
+		defer cancel()
+	}
 	var err error
 	isClusterIP := cv.Spec.APIServer.Service != nil && cv.Spec.APIServer.Service.Spec.Type == corev1.ServiceTypeClusterIP
 	// if ClusterIP, have to update API Server ahead of time to lay it down in the PKI
 	if isClusterIP {
 		mpn.Log.Info("applying ClusterIP Service for API component", "component", cv.Spec.APIServer.Name)
-		complementAPIServerTemplate(conversion.ToClusterKey(vc), cv.Spec.APIServer, nil)
+		complementAPIServerTemplate(conversion.ToRootNamespace(vc), cv.Spec.APIServer, nil)
 		err := mpn.Patch(ctx, cv.Spec.APIServer.Service, client.Apply, patchOptions)
 		if err != nil {
 			mpn.Log.Error(err, "failed to update service", "service", cv.Spec.APIServer.Service.GetName())
-			return err
+			return nil, err
 		}
 	}
 
 	// 2. apply PKI
 	clusterCAGroup, err := mpn.createAndApplyPKI(ctx, vc, cv, isClusterIP)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var deployed []*tenancyv1alpha1.StatefulSetSvcBundle
 	// 3. deploy etcd if defined
 	if applyETCD {
-		err = mpn.deployComponent(ctx, vc, cv.Spec.ETCD, clusterCAGroup)
+		err = mpn.deployComponent(ctx, vc, cv, cv.Spec.ETCD, clusterCAGroup)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		deployed = append(deployed, cv.Spec.ETCD)
 	}
 
 	// 4. deploy apiserver (must be defined always)
-	err = mpn.deployComponent(ctx, vc, cv.Spec.APIServer, clusterCAGroup)
+	err = mpn.deployComponent(ctx, vc, cv, cv.Spec.APIServer, clusterCAGroup)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	deployed = append(deployed, cv.Spec.APIServer)
+	if err = mpn.applyAPIServerAutoscaler(ctx, vc, cv); err != nil {
+		return nil, err
 	}
 
 	// 5. deploy controller-manager if defined
 	if cv.Spec.ControllerManager != nil {
-		err = mpn.deployComponent(ctx, vc, cv.Spec.ControllerManager, clusterCAGroup)
+		err = mpn.deployComponent(ctx, vc, cv, cv.Spec.ControllerManager, clusterCAGroup)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		deployed = append(deployed, cv.Spec.ControllerManager)
 	}
-	return nil
+	return deployed, nil
 }
 
 // genInitialClusterArgs generates the values for `--initial-cluster` option of etcd based on the number of
@@ -207,6 +263,13 @@ func complementAPIServerTemplate(vcns string, apiserverBdl *tenancyv1alpha1.Stat
 	apiserverBdl.StatefulSet.ObjectMeta.Namespace = vcns
 	apiserverBdl.Service.ObjectMeta.Namespace = vcns
 
+	labels := apiserverBdl.StatefulSet.Spec.Template.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[constants.LabelCluster] = vcns
+	apiserverBdl.StatefulSet.Spec.Template.SetLabels(labels)
+
 	// we use complementAPIServerTemplate for service creation before creating certs if the service isClusterIP
 	if clusterCAGroup == nil {
 		return
@@ -221,19 +284,24 @@ func complementAPIServerTemplate(vcns string, apiserverBdl *tenancyv1alpha1.Stat
 	annotations[secret.FrontProxyCASecretName+"-hash"] = secret.GetHash(clusterCAGroup.FrontProxy)
 	annotations[secret.ServiceAccountSecretName+"-hash"] = secret.GetHash(clusterCAGroup.ServiceAccountPrivateKey)
 	apiserverBdl.StatefulSet.Spec.Template.SetAnnotations(annotations)
-
-	labels := apiserverBdl.StatefulSet.Spec.Template.GetLabels()
-	if labels == nil {
-		labels = map[string]string{}
-	}
-	labels[constants.LabelCluster] = vcns
-	apiserverBdl.StatefulSet.Spec.Template.SetLabels(labels)
 }
 
 // complementCtrlMgrTemplate complements the controller manager template of the specified clusterversion
 // based on the virtual cluster setting
 func complementCtrlMgrTemplate(vcns string, ctrlMgrBdl *tenancyv1alpha1.StatefulSetSvcBundle, clusterCAGroup *vcpki.ClusterCAGroup) {
 	ctrlMgrBdl.StatefulSet.ObjectMeta.Namespace = vcns
+
+	labels := ctrlMgrBdl.StatefulSet.Spec.Template.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[constants.LabelCluster] = vcns
+	ctrlMgrBdl.StatefulSet.Spec.Template.SetLabels(labels)
+
+	if clusterCAGroup == nil {
+		return
+	}
+
 	annotations := ctrlMgrBdl.StatefulSet.Spec.Template.GetAnnotations()
 	if annotations == nil {
 		annotations = map[string]string{}
@@ -242,35 +310,54 @@ func complementCtrlMgrTemplate(vcns string, ctrlMgrBdl *tenancyv1alpha1.Stateful
 	annotations[secret.ServiceAccountSecretName+"-hash"] = secret.GetHash(clusterCAGroup.ServiceAccountPrivateKey)
 	annotations[secret.ControllerManagerSecretName+"-hash"] = secret.GetHash(clusterCAGroup.CtrlMgrKbCfg)
 	ctrlMgrBdl.StatefulSet.Spec.Template.SetAnnotations(annotations)
-
-	labels := ctrlMgrBdl.StatefulSet.Spec.Template.GetLabels()
-	if labels == nil {
-		labels = map[string]string{}
-	}
-	labels[constants.LabelCluster] = vcns
-	ctrlMgrBdl.StatefulSet.Spec.Template.SetLabels(labels)
 }
 
 // deployComponent deploys control plane component in namespace vcName based on the given StatefulSet
 // and Service Bundle ssBdl
 // the method also adds annotations with certificates hashes to trigger pod recreation if certificates were changed
-func (mpn *Native) deployComponent(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, ssBdl *tenancyv1alpha1.StatefulSetSvcBundle, clusterCAGroup *vcpki.ClusterCAGroup) error {
-	mpn.Log.Info("deploying StatefulSet for control plane component", "component", ssBdl.Name)
+func (mpn *Native) deployComponent(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, ssBdl *tenancyv1alpha1.StatefulSetSvcBundle, clusterCAGroup *vcpki.ClusterCAGroup) error {
+	if err := mpn.applyComponent(ctx, vc, cv, ssBdl, clusterCAGroup); err != nil {
+		return err
+	}
+
+	// wait for the statefuleset to be ready until the deadline of the vc, every vc waits with
+	// its own deadline so that a vc whose statefulsets never get ready does not delay the others
+	return kubeutil.WaitStatefulSetReadyContext(ctx, mpn, conversion.ToRootNamespace(vc), ssBdl.Name, ComponentPollPeriodSec*time.Second)
+}
 
-	ns := conversion.ToClusterKey(vc)
+// applyComponent renders the StatefulSet and the Service of a control plane component and
+// applies them without waiting for the StatefulSet to be ready
+func (mpn *Native) applyComponent(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, ssBdl *tenancyv1alpha1.StatefulSetSvcBundle, clusterCAGroup *vcpki.ClusterCAGroup) error {
+	mpn.Log.Info("deploying StatefulSet for control plane component", "component", ssBdl.Name)
 
-	switch ssBdl.Name {
-	case "etcd":
-		complementETCDTemplate(ns, ssBdl)
-	case "apiserver":
-		complementAPIServerTemplate(ns, ssBdl, clusterCAGroup)
-	case "controller-manager":
-		complementCtrlMgrTemplate(ns, ssBdl, clusterCAGroup)
-	default:
-		return fmt.Errorf("try to deploy unknown component: %s", ssBdl.Name)
+	// the super cluster nodes are only listed if the component has to be pinned to an architecture
+	var nodes []corev1.Node
+	if needsArchitectures(cv.Spec.Images) {
+		nodeList := &corev1.NodeList{}
+		if err := mpn.List(ctx, nodeList); err != nil {
+			return err
+		}
+		nodes = nodeList.Items
+	}
+	skipped, err := complementComponent(vc, cv, ssBdl, clusterCAGroup, nodes, securityPolicy(cv, mpn.DefaultSecurityProfile))
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		mpn.Log.Info("skipping extra args that are not in the allow-list", "component", ssBdl.Name, "flags", skipped)
+	}
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.ControlPlaneSecretChecksum) {
+		if err := mpn.setSecretChecksum(ctx, ssBdl.StatefulSet); err != nil {
+			return err
+		}
+	}
+	if ssBdl.Name == "apiserver" {
+		if err := mpn.keepAutoscaledReplicas(ctx, ssBdl.StatefulSet, cv.Spec.APIServerScaling); err != nil {
+			return err
+		}
 	}
 
-	err := mpn.Patch(ctx, ssBdl.StatefulSet, client.Apply, patchOptions)
+	err = mpn.Patch(ctx, ssBdl.StatefulSet, client.Apply, patchOptions)
 	if err != nil {
 		return err
 	}
@@ -283,18 +370,34 @@ func (mpn *Native) deployComponent(ctx context.Context, vc *tenancyv1alpha1.Virt
 			return err
 		}
 	}
+	return nil
+}
 
-	// wait for the statefuleset to be ready
-	err = kubeutil.WaitStatefulSetReady(mpn, ns, ssBdl.Name, int64(mpn.ProvisionerTimeout/time.Second), ComponentPollPeriodSec)
+// setSecretChecksum annotates the pod template of sts with the checksum of the secrets it references,
+// the annotation is then kept up to date by the secret checksum controller
+func (mpn *Native) setSecretChecksum(ctx context.Context, sts *appsv1.StatefulSet) error {
+	reader := mpn.apiReader
+	if reader == nil {
+		reader = mpn.Client
+	}
+	checksum, err := secret.PodSpecChecksum(ctx, reader, sts.Namespace, &sts.Spec.Template.Spec)
 	if err != nil {
 		return err
 	}
+	annotations := sts.Spec.Template.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[constants.LabelSecretChecksum] = checksum
+	sts.Spec.Template.SetAnnotations(annotations)
 	return nil
 }
 
 // createOrUpdatePKISecrets creates secrets to store crt/key pairs and kubeconfigs
-// for control plane components of the virtual cluster
-func (mpn *Native) createOrUpdatePKISecrets(ctx context.Context, caGroup *vcpki.ClusterCAGroup, namespace string) error {
+// for control plane components of the virtual cluster, or updates the existing ones.
+// The root CA secret is applied first so that the other secrets are never signed by
+// a CA that isn't stored, the failures of the other secrets are aggregated
+func (mpn *Native) createOrUpdatePKISecrets(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, caGroup *vcpki.ClusterCAGroup, namespace string) error {
 	// create secret for root crt/key pair
 	rootSrt := secret.CrtKeyPairToSecret(secret.RootCASecretName, namespace, caGroup.RootCA)
 	// create secret for apiserver crt/key pair
@@ -318,28 +421,28 @@ func (mpn *Native) createOrUpdatePKISecrets(ctx context.Context, caGroup *vcpki.
 	if err != nil {
 		return err
 	}
-	secrets := []*corev1.Secret{rootSrt, apiserverSrt, etcdSrt, frontProxySrt,
-		ctrlMgrSrt, adminSrt, svcActSrt}
 
-	// create all secrets on metacluster
-	for _, srt := range secrets {
-		mpn.Log.Info("applying secret", "name",
-			srt.Name, "namespace", srt.Namespace)
+	if err := mpn.applyPKISecret(ctx, vc, rootSrt); err != nil {
+		return err
+	}
 
-		err := mpn.Patch(ctx, srt, client.Apply, patchOptions)
-		if err != nil {
-			return err
+	var errs []error
+	for _, srt := range []*corev1.Secret{apiserverSrt, etcdSrt, frontProxySrt, ctrlMgrSrt, adminSrt, svcActSrt} {
+		if err := mpn.applyPKISecret(ctx, vc, srt); err != nil {
+			if isPKIOwnershipError(err) {
+				return err
+			}
+			errs = append(errs, err)
 		}
 	}
-
-	return nil
+	return utilerrors.NewAggregate(errs)
 }
 
 // createAndApplyPKI constructs the PKI (all crt/key pair and kubeconfig) for the
 // virtual clusters, and store them as secrets in the meta cluster
 // The method returns the current ClusterCAGroup to use it as annotations for control-plane pods for restart
 func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, isClusterIP bool) (*vcpki.ClusterCAGroup, error) {
-	ns := conversion.ToClusterKey(vc)
+	ns := conversion.ToRootNamespace(vc)
 	caGroup := &vcpki.ClusterCAGroup{}
 
 	var rootCAPair *vcpki.CrtKeyPair
@@ -349,6 +452,9 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 	err := mpn.Get(ctx, client.ObjectKey{Name: secret.RootCASecretName, Namespace: vc.Status.ClusterNamespace}, rootCaSecret)
 	switch {
 	case err == nil:
+		if err := checkPKISecretOwner(rootCaSecret, vc); err != nil {
+			return nil, err
+		}
 		rootCACrt, rootCAErr := pkiutil.DecodeCertPEM(rootCaSecret.Data[corev1.TLSCertKey])
 		if rootCAErr != nil {
 			return nil, rootCAErr
@@ -412,14 +518,14 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 	clusterIP := ""
 	if isClusterIP {
 		var err error
-		clusterIP, err = kubeutil.GetSvcClusterIP(mpn, conversion.ToClusterKey(vc), cv.Spec.APIServer.Service.GetName())
+		clusterIP, err = kubeutil.GetSvcClusterIP(mpn, conversion.ToRootNamespace(vc), cv.Spec.APIServer.Service.GetName())
 		if err != nil {
 			mpn.Log.Info("Warning: failed to get API Service", "service", cv.Spec.APIServer.Service.GetName(), "err", err)
 		}
 	}
 
 	apiserverDomain := cv.GetAPIServerDomain(ns)
-	apiserverCAPair, err := vcpki.NewAPIServerCrtAndKey(rootCAPair, vc, apiserverDomain, clusterIP)
+	apiserverCAPair, err := vcpki.NewAPIServerCrtAndKey(rootCAPair, vc, apiserverDomains(cv, ns), clusterIP)
 	if err != nil {
 		return nil, err
 	}
@@ -456,7 +562,7 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 	caGroup.ServiceAccountPrivateKey = svcAcctCAPair
 
 	// store ca and kubeconfig into secrets
-	genSrtsErr := mpn.createOrUpdatePKISecrets(ctx, caGroup, ns)
+	genSrtsErr := mpn.createOrUpdatePKISecrets(ctx, vc, caGroup, ns)
 	if genSrtsErr != nil {
 		return nil, genSrtsErr
 	}
@@ -464,8 +570,14 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 	return caGroup, nil
 }
 
-func (mpn *Native) DeleteVirtualCluster(_ context.Context, _ *tenancyv1alpha1.VirtualCluster) error {
-	return nil
+// DeleteVirtualCluster runs the PreDelete hooks of vc
+func (mpn *Native) DeleteVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	cv, err := mpn.fetchClusterVersion(vc)
+	if err != nil {
+		mpn.Log.Info("skip PreDelete hooks", "vc", vc.GetName(), "reason", err.Error())
+		return nil
+	}
+	return mpn.runHooks(ctx, vc, cv, tenancyv1alpha1.HookPreDelete)
 }
 
 func (mpn *Native) GetProvisioner() string {
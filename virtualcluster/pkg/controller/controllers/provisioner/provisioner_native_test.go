@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// renderedBundles complements the components of cv for vc like deployComponent does.
+func renderedBundles(t *testing.T, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, bdls ...*tenancyv1alpha1.StatefulSetSvcBundle) []*tenancyv1alpha1.StatefulSetSvcBundle {
+	t.Helper()
+	for _, bdl := range bdls {
+		if _, err := complementComponent(vc, cv, bdl, nil, nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return bdls
+}
+
+func newSnapshotTestCluster() (*tenancyv1alpha1.VirtualCluster, *tenancyv1alpha1.ClusterVersion) {
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vc", UID: "uid"},
+		Spec: tenancyv1alpha1.VirtualClusterSpec{
+			ControlPlane: &tenancyv1alpha1.ControlPlaneSpec{
+				APIServer: &tenancyv1alpha1.ControlPlaneComponentSpec{ExtraArgs: map[string]string{"v": "4"}},
+			},
+		},
+	}
+	cv := &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "cv", ResourceVersion: "2"},
+		Spec: tenancyv1alpha1.ClusterVersionSpec{
+			ETCD:              newRenderBundle("etcd", true),
+			APIServer:         newRenderBundle("apiserver", true),
+			ControllerManager: newRenderBundle("controller-manager", false),
+			Images: &tenancyv1alpha1.ImagePolicy{Overrides: []tenancyv1alpha1.ImageOverride{
+				{Component: "apiserver", Image: "apiserver:v2"},
+			}},
+		},
+	}
+	return vc, cv
+}
+
+func TestSnapshot(t *testing.T) {
+	vc, cv := newSnapshotTestCluster()
+	deployed := renderedBundles(t, vc, cv, cv.Spec.ETCD, cv.Spec.APIServer)
+
+	snapshot := cv.Snapshot(deployed...)
+	if snapshot.Name != "cv" || snapshot.ResourceVersion != "2" {
+		t.Errorf("expected the snapshot of cv at version 2, got %s at %s", snapshot.Name, snapshot.ResourceVersion)
+	}
+	if len(snapshot.Components) != 2 {
+		t.Fatalf("expected the snapshot of the 2 deployed components, got %+v", snapshot.Components)
+	}
+	etcd := snapshot.GetComponent("etcd")
+	if etcd == nil || !strings.Contains(strings.Join(etcd.Containers[0].Args, " "), "--initial-cluster") {
+		t.Errorf("expected the complemented etcd args, got %+v", etcd)
+	}
+	apiserver := snapshot.GetComponent("apiserver")
+	if apiserver == nil || apiserver.Containers[0].Image != "apiserver:v2" || !reflect.DeepEqual(apiserver.Containers[0].Args, []string{"--v=4"}) {
+		t.Errorf("expected the overridden apiserver image and the extra args, got %+v", apiserver)
+	}
+	if snapshot.GetComponent("controller-manager") != nil {
+		t.Errorf("expected the components not deployed not to be recorded")
+	}
+
+	// the snapshot doesn't share the slices of the deployed StatefulSets.
+	deployed[1].StatefulSet.Spec.Template.Spec.Containers[0].Args[0] = "--v=2"
+	if apiserver.Containers[0].Args[0] != "--v=4" {
+		t.Errorf("expected the snapshot to copy the args")
+	}
+}
+
+func TestUpgradedSnapshot(t *testing.T) {
+	vc, cv := newSnapshotTestCluster()
+	prev := cv.Snapshot(renderedBundles(t, vc, cv.DeepCopy(), cv.DeepCopy().Spec.ETCD)...)
+	prevETCD := prev.GetComponent("etcd").DeepCopy()
+
+	for name, etcd := range map[string]*tenancyv1alpha1.StatefulSetSvcBundle{
+		"etcd upgraded in the ClusterVersion":  newRenderBundle("etcd", true),
+		"etcd removed from the ClusterVersion": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			upgraded := cv.DeepCopy()
+			upgraded.Spec.ETCD = etcd
+			if etcd != nil {
+				etcd.StatefulSet.Spec.Template.Spec.Containers[0].Image = "etcd:v2"
+			}
+			deployed := renderedBundles(t, vc, upgraded, upgraded.Spec.APIServer, upgraded.Spec.ControllerManager)
+
+			snapshot := upgradedSnapshot(prev, upgraded, deployed)
+			names := []string{}
+			for _, c := range snapshot.Components {
+				names = append(names, c.Name)
+			}
+			if !reflect.DeepEqual(names, []string{"etcd", "apiserver", "controller-manager"}) {
+				t.Fatalf("expected the etcd, apiserver and controller-manager components, got %v", names)
+			}
+			if got := snapshot.GetComponent("etcd"); !reflect.DeepEqual(got, prevETCD) {
+				t.Errorf("expected the etcd component to be carried over, got %+v", got)
+			}
+			if got := snapshot.GetComponent("apiserver"); got.Containers[0].Image != "apiserver:v2" {
+				t.Errorf("expected the rendered apiserver, got %+v", got)
+			}
+		})
+	}
+
+	if snapshot := upgradedSnapshot(nil, cv, renderedBundles(t, vc, cv, cv.Spec.APIServer)); snapshot.GetComponent("etcd") != nil {
+		t.Errorf("expected no etcd component without a previous snapshot, got %+v", snapshot.Components)
+	}
+}
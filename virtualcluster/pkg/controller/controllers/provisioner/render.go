@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	vcerrors "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+)
+
+// RenderOptions are the vc-manager settings the control plane manifests depend on.
+type RenderOptions struct {
+	// Nodes are the super cluster nodes, they are only used if the ClusterVersion pins the images
+	// to the node architectures.
+	Nodes []corev1.Node
+	// DefaultSecurityProfile applies to the ClusterVersions not setting a security profile.
+	DefaultSecurityProfile tenancyv1alpha1.SecurityProfile
+}
+
+// RenderControlPlane returns the StatefulSets and Services the native provisioner deploys for the
+// control plane of vc from cv. The PKI is generated on every apply, so the certificate hash
+// annotations of the pod templates are not rendered.
+func RenderControlPlane(vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, opts RenderOptions) ([]client.Object, error) {
+	cv = cv.DeepCopy()
+	policy := securityPolicy(cv, opts.DefaultSecurityProfile)
+
+	var objs []client.Object
+	for _, ssBdl := range []*tenancyv1alpha1.StatefulSetSvcBundle{cv.Spec.ETCD, cv.Spec.APIServer, cv.Spec.ControllerManager} {
+		if ssBdl == nil || ssBdl.StatefulSet == nil {
+			continue
+		}
+		if _, err := complementComponent(vc, cv, ssBdl, nil, opts.Nodes, policy); err != nil {
+			return nil, err
+		}
+		ssBdl.StatefulSet.TypeMeta = metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "StatefulSet"}
+		objs = append(objs, ssBdl.StatefulSet)
+		if ssBdl.Service != nil {
+			ssBdl.Service.Namespace = ssBdl.StatefulSet.Namespace
+			ssBdl.Service.TypeMeta = metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Service"}
+			objs = append(objs, ssBdl.Service)
+		}
+	}
+	if hpa := apiserverAutoscaler(conversion.ToRootNamespace(vc), cv); hpa != nil {
+		objs = append(objs, hpa)
+	}
+	return objs, nil
+}
+
+// complementComponent complements the StatefulSet and Service of a control plane component of the
+// ClusterVersion based on the virtual cluster setting, it returns the extra args that are skipped.
+func complementComponent(vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, ssBdl *tenancyv1alpha1.StatefulSetSvcBundle, clusterCAGroup *vcpki.ClusterCAGroup, nodes []corev1.Node, policy *tenancyv1alpha1.SecurityPolicy) ([]string, error) {
+	ns := conversion.ToRootNamespace(vc)
+
+	switch ssBdl.Name {
+	case "etcd":
+		complementETCDTemplate(ns, ssBdl)
+		if vc.Spec.ETCDStorage != nil {
+			setVolumeClaimStorage(ssBdl.StatefulSet, *vc.Spec.ETCDStorage)
+		}
+	case "apiserver":
+		complementAPIServerTemplate(ns, ssBdl, clusterCAGroup)
+		applyAPIServerScaling(ssBdl.StatefulSet, cv)
+	case "controller-manager":
+		complementCtrlMgrTemplate(ns, ssBdl, clusterCAGroup)
+	default:
+		return nil, vcerrors.NewUserError("try to deploy unknown component: %s", ssBdl.Name)
+	}
+
+	template := &ssBdl.StatefulSet.Spec.Template
+	applyPlacement(template, vc.Spec.Placement)
+	skipped := applyExtraArgs(template, ssBdl.Name, vc.Spec.ControlPlane)
+	var archs []string
+	if needsArchitectures(cv.Spec.Images) {
+		archs = nodeArchitectures(nodes, template.Spec.NodeSelector)
+	}
+	// the following errors come from the spec of the ClusterVersion or the VirtualCluster
+	if err := applyImages(template, ssBdl.Name, cv.Spec.Images, archs); err != nil {
+		return nil, vcerrors.WithCategory(err, vcerrors.CategoryUserError)
+	}
+	// the sidecar is added after the image overrides, which may match all the containers
+	if err := applyLogShipping(template, ssBdl.Name, vc, cv.Spec.Logging); err != nil {
+		return nil, vcerrors.WithCategory(err, vcerrors.CategoryUserError)
+	}
+	if err := applySecurityProfile(template, policy); err != nil {
+		return nil, vcerrors.WithCategory(err, vcerrors.CategoryUserError)
+	}
+	if ssBdl.Name == "etcd" || ssBdl.Name == "apiserver" {
+		applyHighAvailability(ssBdl.StatefulSet, cv.Spec.HighAvailability)
+	}
+	return skipped, nil
+}
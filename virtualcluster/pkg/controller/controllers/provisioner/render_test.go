@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	vcerrors "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+)
+
+func newRenderBundle(name string, withService bool) *tenancyv1alpha1.StatefulSetSvcBundle {
+	replicas := int32(1)
+	bdl := &tenancyv1alpha1.StatefulSetSvcBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		StatefulSet: &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: name, Image: name + ":v1"}}},
+				},
+			},
+		},
+	}
+	if withService {
+		bdl.Service = &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	return bdl
+}
+
+func TestRenderControlPlane(t *testing.T) {
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vc", UID: "uid"},
+		Spec: tenancyv1alpha1.VirtualClusterSpec{
+			Placement: &tenancyv1alpha1.ControlPlanePlacement{NodeSelector: map[string]string{"pool": "control-plane"}},
+		},
+	}
+	cv := &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "cv"},
+		Spec: tenancyv1alpha1.ClusterVersionSpec{
+			ETCD:              newRenderBundle("etcd", true),
+			APIServer:         newRenderBundle("apiserver", true),
+			ControllerManager: newRenderBundle("controller-manager", false),
+		},
+	}
+	original := cv.DeepCopy()
+
+	objs, err := RenderControlPlane(vc, cv, RenderOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs) != 5 {
+		t.Fatalf("expected 5 objects, got %d", len(objs))
+	}
+
+	ns := conversion.ToClusterKey(vc)
+	for _, obj := range objs {
+		if obj.GetNamespace() != ns {
+			t.Errorf("expected %s to be in namespace %s, got %s", obj.GetName(), ns, obj.GetNamespace())
+		}
+		if obj.GetObjectKind().GroupVersionKind().Kind == "" {
+			t.Errorf("expected the kind of %s to be set", obj.GetName())
+		}
+		sts, ok := obj.(*appsv1.StatefulSet)
+		if !ok {
+			continue
+		}
+		if sts.Spec.Template.Labels[constants.LabelCluster] != ns {
+			t.Errorf("expected the pods of %s to be labeled with the cluster", sts.Name)
+		}
+		if sts.Spec.Template.Spec.NodeSelector["pool"] != "control-plane" {
+			t.Errorf("expected the placement to be applied to %s", sts.Name)
+		}
+	}
+
+	if cv.Spec.APIServer.StatefulSet.Namespace != original.Spec.APIServer.StatefulSet.Namespace {
+		t.Errorf("expected the ClusterVersion not to be modified")
+	}
+	cv.Spec.APIServer = newRenderBundle("kube-apiserver", true)
+	if _, err := RenderControlPlane(vc, cv, RenderOptions{}); vcerrors.CategoryOf(err) != vcerrors.CategoryUserError {
+		t.Errorf("expected an unknown component to be a user error, got %v", err)
+	}
+}
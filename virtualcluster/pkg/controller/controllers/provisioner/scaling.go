@@ -0,0 +1,239 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// defaultTargetCPUUtilization is the default average CPU utilization of the apiserver pods
+	// the autoscaler aims at
+	defaultTargetCPUUtilization = int32(80)
+	// inflightRequestsMetric is the apiserver metric of the number of requests being served
+	inflightRequestsMetric = "apiserver_current_inflight_requests"
+	// etcdServersFlag lists the etcd members the apiserver connects to
+	etcdServersFlag = "etcd-servers"
+)
+
+// applyAPIServerScaling sets the number of replicas of the apiserver StatefulSet and lists all the
+// etcd members in its --etcd-servers flag, so that the etcd client of every replica balances its
+// connections over the members instead of all of them connecting to the first one
+func applyAPIServerScaling(sts *appsv1.StatefulSet, cv *tenancyv1alpha1.ClusterVersion) {
+	policy := cv.Spec.APIServerScaling
+	if policy == nil {
+		return
+	}
+	replicas := policy.MinReplicas
+	sts.Spec.Replicas = &replicas
+
+	if cv.Spec.ETCD == nil || cv.Spec.ETCD.StatefulSet == nil || cv.Spec.ETCD.Service == nil {
+		return
+	}
+	for i := range sts.Spec.Template.Spec.Containers {
+		container := &sts.Spec.Template.Spec.Containers[i]
+		for _, args := range [][]string{container.Command, container.Args} {
+			for j, arg := range args {
+				if !strings.HasPrefix(arg, "--"+etcdServersFlag+"=") {
+					continue
+				}
+				servers := strings.TrimPrefix(arg, "--"+etcdServersFlag+"=")
+				if balanced, ok := balancedETCDServers(servers, cv); ok {
+					args[j] = "--" + etcdServersFlag + "=" + balanced
+				}
+			}
+		}
+	}
+}
+
+// balancedETCDServers returns the urls of all the etcd members of the ClusterVersion, with the scheme
+// and port of the first url of servers. It returns false if servers are not members of the etcd of
+// the ClusterVersion, e.g. an external etcd.
+func balancedETCDServers(servers string, cv *tenancyv1alpha1.ClusterVersion) (string, bool) {
+	domain := cv.GetEtcdDomain()
+	var first *url.URL
+	for _, server := range strings.Split(servers, ",") {
+		u, err := url.Parse(server)
+		if err != nil || u.Host == "" {
+			return "", false
+		}
+		if host := u.Hostname(); host != domain && !strings.HasSuffix(host, "."+domain) {
+			return "", false
+		}
+		if first == nil {
+			first = u
+		}
+	}
+
+	members := cv.GetEtcdServers()
+	urls := make([]string, 0, len(members))
+	for _, member := range members {
+		host := member
+		if port := first.Port(); port != "" {
+			host = member + ":" + port
+		}
+		urls = append(urls, (&url.URL{Scheme: first.Scheme, Host: host}).String())
+	}
+	return strings.Join(urls, ","), true
+}
+
+// maxAPIServerReplicas returns the maximum number of apiserver replicas of the ClusterVersion
+func maxAPIServerReplicas(cv *tenancyv1alpha1.ClusterVersion) int32 {
+	replicas := int32(1)
+	if sts := cv.Spec.APIServer.StatefulSet; sts != nil && sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	if policy := cv.Spec.APIServerScaling; policy != nil {
+		replicas = policy.MinReplicas
+		if policy.MaxReplicas > replicas {
+			replicas = policy.MaxReplicas
+		}
+	}
+	return replicas
+}
+
+// apiserverDomains returns the domains the apiserver certificate is valid for, i.e. the domain of
+// the apiserver service and, if the apiserver has several replicas, the fully qualified domain of
+// the service and the domains of the replicas, which are only resolvable in namespace
+func apiserverDomains(cv *tenancyv1alpha1.ClusterVersion, namespace string) []string {
+	domains := []string{cv.GetAPIServerDomain(namespace)}
+	replicas := maxAPIServerReplicas(cv)
+	if replicas <= 1 || cv.Spec.APIServer.StatefulSet == nil || cv.Spec.APIServer.Service == nil {
+		return domains
+	}
+
+	svcName := cv.Spec.APIServer.Service.Name
+	domains = append(domains, svcName+"."+namespace+".svc")
+	for i := int32(0); i < replicas; i++ {
+		replica := fmt.Sprintf("%s-%d.%s", cv.Spec.APIServer.StatefulSet.Name, i, svcName)
+		domains = append(domains, replica, replica+"."+namespace)
+	}
+	return domains
+}
+
+// apiserverAutoscaler returns the HorizontalPodAutoscaler of the apiserver StatefulSet, or nil if
+// the apiserver is not autoscaled
+func apiserverAutoscaler(namespace string, cv *tenancyv1alpha1.ClusterVersion) *autoscalingv2beta2.HorizontalPodAutoscaler {
+	policy := cv.Spec.APIServerScaling
+	if !policy.Autoscaled() {
+		return nil
+	}
+
+	var metrics []autoscalingv2beta2.MetricSpec
+	if policy.TargetCPUUtilizationPercentage != nil || policy.TargetInflightRequests == nil {
+		cpu := defaultTargetCPUUtilization
+		if policy.TargetCPUUtilizationPercentage != nil {
+			cpu = *policy.TargetCPUUtilizationPercentage
+		}
+		metrics = append(metrics, autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.ResourceMetricSourceType,
+			Resource: &autoscalingv2beta2.ResourceMetricSource{
+				Name:   corev1.ResourceCPU,
+				Target: autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.UtilizationMetricType, AverageUtilization: &cpu},
+			},
+		})
+	}
+	if policy.TargetInflightRequests != nil {
+		target := policy.TargetInflightRequests.DeepCopy()
+		metrics = append(metrics, autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.PodsMetricSourceType,
+			Pods: &autoscalingv2beta2.PodsMetricSource{
+				Metric: autoscalingv2beta2.MetricIdentifier{Name: inflightRequestsMetric},
+				Target: autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.AverageValueMetricType, AverageValue: &target},
+			},
+		})
+	}
+
+	minReplicas := policy.MinReplicas
+	return &autoscalingv2beta2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: autoscalingv2beta2.SchemeGroupVersion.String(),
+			Kind:       "HorizontalPodAutoscaler",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cv.Spec.APIServer.StatefulSet.Name,
+			Namespace: namespace,
+		},
+		Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "StatefulSet",
+				Name:       cv.Spec.APIServer.StatefulSet.Name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: policy.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+}
+
+// keepAutoscaledReplicas sets the replicas of the apiserver StatefulSet to the ones of the running
+// StatefulSet if it is autoscaled, so that applying the ClusterVersion does not undo the scaling
+func (mpn *Native) keepAutoscaledReplicas(ctx context.Context, sts *appsv1.StatefulSet, policy *tenancyv1alpha1.APIServerScalingPolicy) error {
+	if !policy.Autoscaled() {
+		return nil
+	}
+	running := &appsv1.StatefulSet{}
+	if err := mpn.Get(ctx, client.ObjectKeyFromObject(sts), running); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if running.Spec.Replicas == nil {
+		return nil
+	}
+	replicas := *running.Spec.Replicas
+	if replicas < policy.MinReplicas {
+		replicas = policy.MinReplicas
+	}
+	if replicas > policy.MaxReplicas {
+		replicas = policy.MaxReplicas
+	}
+	sts.Spec.Replicas = &replicas
+	return nil
+}
+
+// applyAPIServerAutoscaler applies the HorizontalPodAutoscaler of the apiserver if it is autoscaled,
+// and deletes it otherwise
+func (mpn *Native) applyAPIServerAutoscaler(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion) error {
+	ns := conversion.ToRootNamespace(vc)
+	hpa := apiserverAutoscaler(ns, cv)
+	if hpa == nil {
+		hpa = &autoscalingv2beta2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: cv.Spec.APIServer.StatefulSet.Name, Namespace: ns},
+		}
+		// the autoscaling/v2beta2 API may not be served by super clusters not autoscaling any apiserver
+		if err := mpn.Delete(ctx, hpa); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return err
+		}
+		return nil
+	}
+	mpn.Log.Info("applying HorizontalPodAutoscaler for control plane component", "component", cv.Spec.APIServer.Name)
+	return mpn.Patch(ctx, hpa, client.Apply, patchOptions)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func newScalingClusterVersion(policy *tenancyv1alpha1.APIServerScalingPolicy, etcdReplicas int32, etcdServers string) *tenancyv1alpha1.ClusterVersion {
+	cv := &tenancyv1alpha1.ClusterVersion{
+		Spec: tenancyv1alpha1.ClusterVersionSpec{
+			APIServer: newRenderBundle("apiserver", true),
+			ETCD:      newRenderBundle("etcd", true),
+		},
+	}
+	cv.Spec.APIServer.Service.Name = "apiserver-svc"
+	cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers[0].Args = []string{"--etcd-servers=" + etcdServers, "--v=2"}
+	cv.Spec.ETCD.StatefulSet.Spec.Replicas = &etcdReplicas
+	cv.Spec.APIServerScaling = policy
+	return cv
+}
+
+func TestApplyAPIServerScaling(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		policy           *tenancyv1alpha1.APIServerScalingPolicy
+		etcdServers      string
+		expectedReplicas int32
+		expectedServers  string
+	}{
+		{
+			name:             "no policy",
+			etcdServers:      "https://etcd-0.etcd:2379",
+			expectedReplicas: 1,
+			expectedServers:  "https://etcd-0.etcd:2379",
+		},
+		{
+			name:             "members of the etcd of the clusterversion",
+			policy:           &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 3},
+			etcdServers:      "https://etcd-0.etcd:2379",
+			expectedReplicas: 3,
+			expectedServers:  "https://etcd-0.etcd:2379,https://etcd-1.etcd:2379,https://etcd-2.etcd:2379",
+		},
+		{
+			name:             "external etcd",
+			policy:           &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 2},
+			etcdServers:      "https://etcd.example.com:2379",
+			expectedReplicas: 2,
+			expectedServers:  "https://etcd.example.com:2379",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cv := newScalingClusterVersion(tc.policy, 3, tc.etcdServers)
+			sts := cv.Spec.APIServer.StatefulSet
+			applyAPIServerScaling(sts, cv)
+
+			if *sts.Spec.Replicas != tc.expectedReplicas {
+				t.Errorf("expected %d replicas, got %d", tc.expectedReplicas, *sts.Spec.Replicas)
+			}
+			expectedArgs := []string{"--etcd-servers=" + tc.expectedServers, "--v=2"}
+			if args := sts.Spec.Template.Spec.Containers[0].Args; !reflect.DeepEqual(args, expectedArgs) {
+				t.Errorf("expected args %v, got %v", expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestAPIServerDomains(t *testing.T) {
+	cv := newScalingClusterVersion(nil, 1, "https://etcd-0.etcd:2379")
+	if domains := apiserverDomains(cv, "vc-root"); !reflect.DeepEqual(domains, []string{"apiserver-svc.vc-root"}) {
+		t.Errorf("expected the service domain only, got %v", domains)
+	}
+
+	cv.Spec.APIServerScaling = &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 1, MaxReplicas: 2}
+	expected := []string{
+		"apiserver-svc.vc-root",
+		"apiserver-svc.vc-root.svc",
+		"apiserver-0.apiserver-svc", "apiserver-0.apiserver-svc.vc-root",
+		"apiserver-1.apiserver-svc", "apiserver-1.apiserver-svc.vc-root",
+	}
+	if domains := apiserverDomains(cv, "vc-root"); !reflect.DeepEqual(domains, expected) {
+		t.Errorf("expected %v, got %v", expected, domains)
+	}
+}
+
+func TestAPIServerAutoscaler(t *testing.T) {
+	cpu := int32(60)
+	inflight := resource.MustParse("200")
+
+	for _, tc := range []struct {
+		name            string
+		policy          *tenancyv1alpha1.APIServerScalingPolicy
+		expectedMetrics []autoscalingv2beta2.MetricSourceType
+	}{
+		{
+			name:   "fixed replicas",
+			policy: &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 3, MaxReplicas: 3},
+		},
+		{
+			name:            "cpu by default",
+			policy:          &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 2, MaxReplicas: 5},
+			expectedMetrics: []autoscalingv2beta2.MetricSourceType{autoscalingv2beta2.ResourceMetricSourceType},
+		},
+		{
+			name:            "inflight requests only",
+			policy:          &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 2, MaxReplicas: 5, TargetInflightRequests: &inflight},
+			expectedMetrics: []autoscalingv2beta2.MetricSourceType{autoscalingv2beta2.PodsMetricSourceType},
+		},
+		{
+			name:   "cpu and inflight requests",
+			policy: &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 2, MaxReplicas: 5, TargetCPUUtilizationPercentage: &cpu, TargetInflightRequests: &inflight},
+			expectedMetrics: []autoscalingv2beta2.MetricSourceType{
+				autoscalingv2beta2.ResourceMetricSourceType, autoscalingv2beta2.PodsMetricSourceType,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cv := newScalingClusterVersion(tc.policy, 1, "https://etcd-0.etcd:2379")
+			hpa := apiserverAutoscaler("vc-root", cv)
+			if tc.expectedMetrics == nil {
+				if hpa != nil {
+					t.Errorf("expected no autoscaler, got %v", hpa)
+				}
+				return
+			}
+
+			if hpa.Namespace != "vc-root" || hpa.Spec.ScaleTargetRef.Name != "apiserver" || hpa.Spec.ScaleTargetRef.Kind != "StatefulSet" {
+				t.Errorf("unexpected autoscaler target %s/%s %v", hpa.Namespace, hpa.Name, hpa.Spec.ScaleTargetRef)
+			}
+			if *hpa.Spec.MinReplicas != tc.policy.MinReplicas || hpa.Spec.MaxReplicas != tc.policy.MaxReplicas {
+				t.Errorf("expected replicas %d-%d, got %d-%d", tc.policy.MinReplicas, tc.policy.MaxReplicas, *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+			}
+			var metrics []autoscalingv2beta2.MetricSourceType
+			for _, m := range hpa.Spec.Metrics {
+				metrics = append(metrics, m.Type)
+			}
+			if !reflect.DeepEqual(metrics, tc.expectedMetrics) {
+				t.Errorf("expected metrics %v, got %v", tc.expectedMetrics, metrics)
+			}
+		})
+	}
+}
+
+func TestKeepAutoscaledReplicas(t *testing.T) {
+	running := int32(7)
+	existing := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vc-root", Name: "apiserver"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &running},
+	}
+	mpn := &Native{Client: fake.NewClientBuilder().WithObjects(existing).Build()}
+
+	for _, tc := range []struct {
+		name     string
+		policy   *tenancyv1alpha1.APIServerScalingPolicy
+		expected int32
+	}{
+		{name: "not autoscaled", policy: &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 3}, expected: 3},
+		{name: "running replicas", policy: &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 3, MaxReplicas: 10}, expected: 7},
+		{name: "capped by max replicas", policy: &tenancyv1alpha1.APIServerScalingPolicy{MinReplicas: 3, MaxReplicas: 5}, expected: 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := tc.policy.MinReplicas
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "vc-root", Name: "apiserver"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas, Template: corev1.PodTemplateSpec{}},
+			}
+			if err := mpn.keepAutoscaledReplicas(context.TODO(), sts, tc.policy); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *sts.Spec.Replicas != tc.expected {
+				t.Errorf("expected %d replicas, got %d", tc.expected, *sts.Spec.Replicas)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// tmpVolumeName is the emptyDir mounted at /tmp of every container with a
+// read-only root filesystem
+const tmpVolumeName = "tmp"
+
+// securityPolicy returns the security policy of the ClusterVersion, or the
+// default profile if the ClusterVersion doesn't set one
+func securityPolicy(cv *tenancyv1alpha1.ClusterVersion, defaultProfile tenancyv1alpha1.SecurityProfile) *tenancyv1alpha1.SecurityPolicy {
+	if cv.Spec.Security != nil && cv.Spec.Security.Profile != "" {
+		return cv.Spec.Security
+	}
+	policy := &tenancyv1alpha1.SecurityPolicy{Profile: defaultProfile}
+	if cv.Spec.Security != nil {
+		policy.RunAsUser = cv.Spec.Security.RunAsUser
+		policy.FSGroup = cv.Spec.Security.FSGroup
+	}
+	return policy
+}
+
+// applySecurityProfile hardens the pod template of a control plane component
+// according to the policy, the security context fields already set in the
+// template are left untouched
+func applySecurityProfile(template *corev1.PodTemplateSpec, policy *tenancyv1alpha1.SecurityPolicy) error {
+	if policy == nil || policy.Profile != tenancyv1alpha1.SecurityProfileRestricted {
+		return nil
+	}
+
+	for _, v := range template.Spec.Volumes {
+		if v.HostPath != nil {
+			return fmt.Errorf("hostPath volume %q is not allowed by the %s security profile", v.Name, policy.Profile)
+		}
+	}
+
+	if template.Spec.SecurityContext == nil {
+		template.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	psc := template.Spec.SecurityContext
+	if psc.RunAsNonRoot == nil {
+		psc.RunAsNonRoot = pointer.BoolPtr(true)
+	}
+	if psc.RunAsUser == nil && policy.RunAsUser != nil {
+		psc.RunAsUser = pointer.Int64Ptr(*policy.RunAsUser)
+	}
+	if psc.FSGroup == nil {
+		if policy.FSGroup != nil {
+			psc.FSGroup = pointer.Int64Ptr(*policy.FSGroup)
+		} else if policy.RunAsUser != nil {
+			psc.FSGroup = pointer.Int64Ptr(*policy.RunAsUser)
+		}
+	}
+	if psc.SeccompProfile == nil {
+		psc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+
+	for i := range template.Spec.InitContainers {
+		restrictContainer(&template.Spec, &template.Spec.InitContainers[i])
+	}
+	for i := range template.Spec.Containers {
+		restrictContainer(&template.Spec, &template.Spec.Containers[i])
+	}
+	return nil
+}
+
+// restrictContainer drops the privileges of the container and mounts an
+// emptyDir on the directories it writes to, as its root filesystem becomes
+// read-only
+func restrictContainer(spec *corev1.PodSpec, c *corev1.Container) {
+	if c.SecurityContext == nil {
+		c.SecurityContext = &corev1.SecurityContext{}
+	}
+	sc := c.SecurityContext
+	if sc.AllowPrivilegeEscalation == nil {
+		sc.AllowPrivilegeEscalation = pointer.BoolPtr(false)
+	}
+	if sc.Privileged == nil {
+		sc.Privileged = pointer.BoolPtr(false)
+	}
+	if sc.Capabilities == nil {
+		sc.Capabilities = &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+	}
+	if sc.ReadOnlyRootFilesystem == nil {
+		sc.ReadOnlyRootFilesystem = pointer.BoolPtr(true)
+	}
+	if !*sc.ReadOnlyRootFilesystem {
+		return
+	}
+
+	writable := []string{"/tmp"}
+	if dataDir := argValue(c, "--data-dir"); dataDir != "" {
+		writable = append(writable, dataDir)
+	}
+	for _, dir := range writable {
+		if isMounted(c, dir) {
+			continue
+		}
+		name := tmpVolumeName
+		if dir != "/tmp" {
+			name = c.Name + "-" + strings.ReplaceAll(strings.Trim(path.Base(dir), "."), "_", "-")
+		}
+		addEmptyDir(spec, name)
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: name, MountPath: dir})
+	}
+}
+
+// argValue returns the value of the flag in the container command or args
+func argValue(c *corev1.Container, flag string) string {
+	for _, arg := range append(append([]string{}, c.Command...), c.Args...) {
+		if strings.HasPrefix(arg, flag+"=") {
+			return strings.TrimPrefix(arg, flag+"=")
+		}
+	}
+	return ""
+}
+
+// isMounted returns true if dir is, or is inside, a volume mount of the container
+func isMounted(c *corev1.Container, dir string) bool {
+	dir = path.Clean(dir)
+	for _, m := range c.VolumeMounts {
+		mountPath := path.Clean(m.MountPath)
+		if dir == mountPath || strings.HasPrefix(dir, mountPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func addEmptyDir(spec *corev1.PodSpec, name string) {
+	for _, v := range spec.Volumes {
+		if v.Name == name {
+			return
+		}
+	}
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name:         name,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/utils/pointer"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func restrictedContainerContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: pointer.BoolPtr(false),
+		Privileged:               pointer.BoolPtr(false),
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		ReadOnlyRootFilesystem:   pointer.BoolPtr(true),
+	}
+}
+
+func emptyDir(name string) corev1.Volume {
+	return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+}
+
+func TestApplySecurityProfile(t *testing.T) {
+	restricted := &tenancyv1alpha1.SecurityPolicy{Profile: tenancyv1alpha1.SecurityProfileRestricted}
+	runtimeDefault := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+
+	for _, tc := range []struct {
+		name        string
+		spec        corev1.PodSpec
+		policy      *tenancyv1alpha1.SecurityPolicy
+		expected    corev1.PodSpec
+		expectedErr string
+	}{
+		{
+			name:     "no policy",
+			spec:     corev1.PodSpec{Containers: []corev1.Container{{Name: "apiserver"}}},
+			expected: corev1.PodSpec{Containers: []corev1.Container{{Name: "apiserver"}}},
+		},
+		{
+			name:     "privileged",
+			spec:     corev1.PodSpec{Containers: []corev1.Container{{Name: "apiserver"}}},
+			policy:   &tenancyv1alpha1.SecurityPolicy{Profile: tenancyv1alpha1.SecurityProfilePrivileged},
+			expected: corev1.PodSpec{Containers: []corev1.Container{{Name: "apiserver"}}},
+		},
+		{
+			name:   "restricted",
+			spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "apiserver"}}},
+			policy: restricted,
+			expected: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: pointer.BoolPtr(true), SeccompProfile: runtimeDefault},
+				Containers: []corev1.Container{{
+					Name:            "apiserver",
+					SecurityContext: restrictedContainerContext(),
+					VolumeMounts:    []corev1.VolumeMount{{Name: tmpVolumeName, MountPath: "/tmp"}},
+				}},
+				Volumes: []corev1.Volume{emptyDir(tmpVolumeName)},
+			},
+		},
+		{
+			name: "restricted with run as user and etcd data dir",
+			spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:    "etcd",
+				Command: []string{"etcd"},
+				Args:    []string{"--data-dir=/var/lib/etcd/data"},
+			}}},
+			policy: &tenancyv1alpha1.SecurityPolicy{Profile: tenancyv1alpha1.SecurityProfileRestricted, RunAsUser: pointer.Int64Ptr(1000)},
+			expected: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsNonRoot:   pointer.BoolPtr(true),
+					RunAsUser:      pointer.Int64Ptr(1000),
+					FSGroup:        pointer.Int64Ptr(1000),
+					SeccompProfile: runtimeDefault,
+				},
+				Containers: []corev1.Container{{
+					Name:            "etcd",
+					Command:         []string{"etcd"},
+					Args:            []string{"--data-dir=/var/lib/etcd/data"},
+					SecurityContext: restrictedContainerContext(),
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: tmpVolumeName, MountPath: "/tmp"},
+						{Name: "etcd-data", MountPath: "/var/lib/etcd/data"},
+					},
+				}},
+				Volumes: []corev1.Volume{emptyDir(tmpVolumeName), emptyDir("etcd-data")},
+			},
+		},
+		{
+			name: "mounted data dir and template settings are kept",
+			spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: pointer.Int64Ptr(2000)},
+				Containers: []corev1.Container{{
+					Name:            "etcd",
+					Args:            []string{"--data-dir=/var/lib/etcd/data"},
+					SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: pointer.BoolPtr(false)},
+					VolumeMounts:    []corev1.VolumeMount{{Name: "data", MountPath: "/var/lib/etcd"}},
+				}},
+			},
+			policy: &tenancyv1alpha1.SecurityPolicy{Profile: tenancyv1alpha1.SecurityProfileRestricted, RunAsUser: pointer.Int64Ptr(1000)},
+			expected: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsNonRoot:   pointer.BoolPtr(true),
+					RunAsUser:      pointer.Int64Ptr(2000),
+					FSGroup:        pointer.Int64Ptr(1000),
+					SeccompProfile: runtimeDefault,
+				},
+				Containers: []corev1.Container{{
+					Name: "etcd",
+					Args: []string{"--data-dir=/var/lib/etcd/data"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: pointer.BoolPtr(false),
+						Privileged:               pointer.BoolPtr(false),
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+						ReadOnlyRootFilesystem:   pointer.BoolPtr(false),
+					},
+					VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/var/lib/etcd"}},
+				}},
+			},
+		},
+		{
+			name: "hostPath is refused",
+			spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         "certs",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/etc/kubernetes"}},
+			}}},
+			policy:      restricted,
+			expectedErr: `hostPath volume "certs" is not allowed`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			template := &corev1.PodTemplateSpec{Spec: tc.spec}
+			err := applySecurityProfile(template, tc.policy)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equality.Semantic.DeepEqual(template.Spec, tc.expected) {
+				t.Errorf("unexpected pod spec\ngot:      %+v\nexpected: %+v", template.Spec, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSecurityPolicy(t *testing.T) {
+	cv := &tenancyv1alpha1.ClusterVersion{}
+	if p := securityPolicy(cv, tenancyv1alpha1.SecurityProfileRestricted); p.Profile != tenancyv1alpha1.SecurityProfileRestricted {
+		t.Errorf("expected the default profile, got %q", p.Profile)
+	}
+
+	cv.Spec.Security = &tenancyv1alpha1.SecurityPolicy{RunAsUser: pointer.Int64Ptr(1000)}
+	p := securityPolicy(cv, tenancyv1alpha1.SecurityProfileRestricted)
+	if p.Profile != tenancyv1alpha1.SecurityProfileRestricted || p.RunAsUser == nil || *p.RunAsUser != 1000 {
+		t.Errorf("expected the default profile with the run as user of the ClusterVersion, got %+v", p)
+	}
+
+	cv.Spec.Security.Profile = tenancyv1alpha1.SecurityProfilePrivileged
+	if p := securityPolicy(cv, tenancyv1alpha1.SecurityProfileRestricted); p.Profile != tenancyv1alpha1.SecurityProfilePrivileged {
+		t.Errorf("expected the profile of the ClusterVersion, got %q", p.Profile)
+	}
+}
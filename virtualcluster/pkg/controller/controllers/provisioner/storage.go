@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+var _ StorageExpander = &Native{}
+
+// ExpandETCDStorage expands the persistent volume claims of the etcd StatefulSet
+// to vc.Spec.ETCDStorage. As the volume claim templates of a StatefulSet are
+// immutable, the StatefulSet is deleted with the orphan propagation policy, so
+// that the etcd pods keep running, and deployed again with the expanded templates
+// by a later call once the garbage collector released it.
+func (mpn *Native) ExpandETCDStorage(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (bool, error) {
+	if vc.Spec.ETCDStorage == nil {
+		return true, nil
+	}
+	desired := *vc.Spec.ETCDStorage
+	ns := conversion.ToRootNamespace(vc)
+
+	sts := &appsv1.StatefulSet{}
+	if err := mpn.Get(ctx, client.ObjectKey{Namespace: ns, Name: "etcd"}, sts); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		// the StatefulSet got lost in the middle of a previous expansion, deploy it
+		// again from the ClusterVersion and check its claims once it is requeued
+		cv, err := mpn.fetchClusterVersion(vc)
+		if err != nil {
+			return false, err
+		}
+		if cv.Spec.ETCD == nil {
+			return true, nil
+		}
+		mpn.Log.Info("etcd StatefulSet not found, redeploying", "vc", vc.GetName())
+		return false, mpn.applyComponent(ctx, vc, cv, cv.Spec.ETCD, nil)
+	}
+	if len(sts.Spec.VolumeClaimTemplates) == 0 {
+		// etcd does not use persistent volumes
+		return true, nil
+	}
+	if sts.DeletionTimestamp != nil {
+		// the garbage collector is orphaning the etcd pods
+		return false, nil
+	}
+
+	expanded, err := mpn.expandVolumeClaims(ctx, sts, desired)
+	if err != nil {
+		return false, err
+	}
+
+	if volumeClaimTemplatesNeedExpansion(sts, desired) {
+		mpn.Log.Info("deleting etcd StatefulSet to expand its volume claim templates", "namespace", sts.Namespace, "storage", desired.String())
+		orphan := metav1.DeletePropagationOrphan
+		if err := mpn.Delete(ctx, sts, &client.DeleteOptions{PropagationPolicy: &orphan}); err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	return expanded, nil
+}
+
+// expandVolumeClaims resizes the claims created from the volume claim templates
+// of sts, it returns true once the capacity of every claim reached desired
+func (mpn *Native) expandVolumeClaims(ctx context.Context, sts *appsv1.StatefulSet, desired resource.Quantity) (bool, error) {
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	expanded := true
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		for i := int32(0); i < replicas; i++ {
+			pvc := &corev1.PersistentVolumeClaim{}
+			key := client.ObjectKey{Namespace: sts.Namespace, Name: fmt.Sprintf("%s-%s-%d", template.Name, sts.Name, i)}
+			if err := mpn.Get(ctx, key, pvc); err != nil {
+				if apierrors.IsNotFound(err) {
+					// not created yet, it will be created from the expanded template
+					continue
+				}
+				return false, err
+			}
+
+			if request := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; request.Cmp(desired) < 0 {
+				mpn.Log.Info("expanding etcd persistent volume claim", "pvc", key.String(), "from", request.String(), "to", desired.String())
+				if pvc.Spec.Resources.Requests == nil {
+					pvc.Spec.Resources.Requests = corev1.ResourceList{}
+				}
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+				if err := mpn.Update(ctx, pvc); err != nil {
+					return false, fmt.Errorf("fail to expand pvc %s: %v", key.String(), err)
+				}
+			}
+
+			if capacity := pvc.Status.Capacity[corev1.ResourceStorage]; capacity.Cmp(desired) < 0 {
+				expanded = false
+			}
+		}
+	}
+	return expanded, nil
+}
+
+// setVolumeClaimStorage sets the storage requested by all volume claim templates of sts
+func setVolumeClaimStorage(sts *appsv1.StatefulSet, storage resource.Quantity) {
+	for i := range sts.Spec.VolumeClaimTemplates {
+		resources := &sts.Spec.VolumeClaimTemplates[i].Spec.Resources
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		resources.Requests[corev1.ResourceStorage] = storage
+	}
+}
+
+// volumeClaimTemplatesNeedExpansion returns true if any volume claim template of sts
+// requests less storage than desired
+func volumeClaimTemplatesNeedExpansion(sts *appsv1.StatefulSet, desired resource.Quantity) bool {
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		if request := template.Spec.Resources.Requests[corev1.ResourceStorage]; request.Cmp(desired) < 0 {
+			return true
+		}
+	}
+	return false
+}
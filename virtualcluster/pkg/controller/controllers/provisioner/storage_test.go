@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// applyClient creates or updates the objects of server side apply patches, which
+// the fake client does not support.
+type applyClient struct {
+	client.Client
+}
+
+func (c applyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	current := obj.DeepCopyObject().(client.Object)
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return c.Create(ctx, obj)
+	}
+	obj.SetResourceVersion(current.GetResourceVersion())
+	return c.Update(ctx, obj)
+}
+
+func TestExpandETCDStorage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	small, large := resource.MustParse("1Gi"), resource.MustParse("2Gi")
+	vc := newHookTestVC()
+	vc.Spec.ETCDStorage = &large
+	ns := conversion.ToClusterKey(vc)
+
+	replicas := int32(2)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: ns},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: "data"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: small}},
+				},
+			}},
+		},
+	}
+	cv := &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "cv"},
+		Spec:       tenancyv1alpha1.ClusterVersionSpec{ETCD: newRenderBundle("etcd", true)},
+	}
+	cv.Spec.ETCD.StatefulSet.Spec.Replicas = &replicas
+	cv.Spec.ETCD.StatefulSet.Spec.VolumeClaimTemplates = sts.DeepCopy().Spec.VolumeClaimTemplates
+	objs := []client.Object{sts, cv}
+	for i := 0; i < int(replicas); i++ {
+		objs = append(objs, &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("data-etcd-%d", i), Namespace: ns},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: small}},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Capacity: corev1.ResourceList{corev1.ResourceStorage: small}},
+		})
+	}
+	mpn := &Native{
+		Client:             applyClient{fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()},
+		scheme:             scheme,
+		Log:                ctrl.Log.WithName("test"),
+		ProvisionerTimeout: time.Second,
+	}
+
+	done, err := mpn.ExpandETCDStorage(context.TODO(), vc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if done {
+		t.Errorf("expected expansion to be in progress")
+	}
+
+	// the StatefulSet is orphan deleted without waiting for the garbage collector
+	if err := mpn.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: "etcd"}, &appsv1.StatefulSet{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected etcd StatefulSet to be deleted, got %v", err)
+	}
+
+	// the StatefulSet is kept while the garbage collector orphans the pods
+	deleting := sts.DeepCopy()
+	deleting.ResourceVersion = ""
+	now := metav1.Now()
+	deleting.DeletionTimestamp, deleting.Finalizers = &now, []string{metav1.FinalizerOrphanDependents}
+	if err := mpn.Create(context.TODO(), deleting); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done, err = mpn.ExpandETCDStorage(context.TODO(), vc)
+	if err != nil || done {
+		t.Fatalf("expected expansion to wait for the deletion, got done %v, error %v", done, err)
+	}
+	if err := mpn.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: "etcd"}, deleting); err != nil || deleting.DeletionTimestamp == nil {
+		t.Fatalf("expected etcd StatefulSet to be left to the garbage collector, got %v", err)
+	}
+
+	// the StatefulSet is deployed again with the expanded templates
+	deleting.Finalizers = nil
+	if err := mpn.Update(context.TODO(), deleting); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mpn.Delete(context.TODO(), deleting); err != nil && !apierrors.IsNotFound(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Now()
+	done, err = mpn.ExpandETCDStorage(context.TODO(), vc)
+	if err != nil || done {
+		t.Fatalf("expected expansion to be requeued once the StatefulSet is deployed, got done %v, error %v", done, err)
+	}
+	// the StatefulSet never gets ready in the fake client, it is not waited for
+	if elapsed := time.Since(start); elapsed >= mpn.ProvisionerTimeout {
+		t.Errorf("expected the StatefulSet to be deployed without waiting for it, took %v", elapsed)
+	}
+	redeployed := &appsv1.StatefulSet{}
+	if err := mpn.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: "etcd"}, redeployed); err != nil {
+		t.Fatalf("expected etcd StatefulSet to be deployed again, got %v", err)
+	}
+	if volumeClaimTemplatesNeedExpansion(redeployed, large) {
+		t.Errorf("expected etcd StatefulSet to be deployed with the expanded templates, got %+v", redeployed.Spec.VolumeClaimTemplates)
+	}
+
+	for i := 0; i < int(replicas); i++ {
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := mpn.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: fmt.Sprintf("data-etcd-%d", i)}, pvc); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if request := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; request.Cmp(large) != 0 {
+			t.Errorf("expected pvc %s to request %s, got %s", pvc.Name, large.String(), request.String())
+		}
+		// the volume is resized
+		pvc.Status.Capacity[corev1.ResourceStorage] = large
+		if err := mpn.Update(context.TODO(), pvc); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	done, err = mpn.ExpandETCDStorage(context.TODO(), vc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !done {
+		t.Errorf("expected expansion to complete")
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretchecksum
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// busyRequeuePeriod is the interval between two checks of a control plane being provisioned or upgraded
+const busyRequeuePeriod = 30 * time.Second
+
+var _ reconcile.Reconciler = &ReconcileSecretChecksum{}
+
+// ReconcileSecretChecksum keeps the secret checksum annotation of the control plane StatefulSet pod
+// templates up to date, so that the pods are rolled out again when the secrets they mount change.
+// Only the StatefulSets annotated by the provisioner are reconciled.
+type ReconcileSecretChecksum struct {
+	client.Client
+	// APIReader reads the secrets bypassing the cache, so that the checksum matches the one
+	// computed by the provisioner
+	APIReader client.Reader
+	Log       logr.Logger
+}
+
+// SetupWithManager will configure the secret checksum reconciler
+func (r *ReconcileSecretChecksum) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("secret-checksum").
+		WithOptions(opts).
+		For(&appsv1.StatefulSet{}, builder.WithPredicates(predicate.GenerationChangedPredicate{}, predicate.NewPredicateFuncs(hasChecksum))).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.statefulSetsOfSecret)).
+		Complete(r)
+}
+
+// Reconcile updates the secret checksum of the pod template of a control plane StatefulSet, unless
+// its VirtualCluster is being provisioned or upgraded, as the provisioner sets the checksum then
+func (r *ReconcileSecretChecksum) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, request.NamespacedName, sts); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if !sts.DeletionTimestamp.IsZero() || !hasChecksum(sts) {
+		return reconcile.Result{}, nil
+	}
+
+	busy, err := r.provisioning(ctx, sts.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if busy {
+		return reconcile.Result{RequeueAfter: busyRequeuePeriod}, nil
+	}
+
+	checksum, err := secret.PodSpecChecksum(ctx, r.APIReader, sts.Namespace, &sts.Spec.Template.Spec)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if sts.Spec.Template.Annotations[constants.LabelSecretChecksum] == checksum {
+		return reconcile.Result{}, nil
+	}
+
+	r.Log.Info("secrets of control plane component changed, rolling it out", "namespace", sts.Namespace, "statefulset", sts.Name)
+	orig := sts.DeepCopy()
+	sts.Spec.Template.Annotations[constants.LabelSecretChecksum] = checksum
+	return reconcile.Result{}, r.Patch(ctx, sts, client.MergeFrom(orig))
+}
+
+// provisioning returns whether the VirtualCluster of the root namespace is being provisioned or
+// upgraded, the namespaces not created by the vc-manager are never considered busy
+func (r *ReconcileSecretChecksum) provisioning(ctx context.Context, namespace string) (bool, error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	name, ok := ns.Annotations[constants.LabelVCName]
+	if !ok {
+		return false, nil
+	}
+
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: ns.Annotations[constants.LabelVCNamespace], Name: name}, vc)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !vc.DeletionTimestamp.IsZero() || vc.Status.Phase != tenancyv1alpha1.ClusterRunning ||
+		vc.Labels[constants.LabelVCReadyForUpgrade] == "true", nil
+}
+
+// statefulSetsOfSecret maps a secret to the annotated StatefulSets of its namespace referencing it
+func (r *ReconcileSecretChecksum) statefulSetsOfSecret(obj client.Object) []reconcile.Request {
+	stsList := &appsv1.StatefulSetList{}
+	if err := r.List(context.TODO(), stsList, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list statefulsets", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range stsList.Items {
+		sts := &stsList.Items[i]
+		if !hasChecksum(sts) {
+			continue
+		}
+		for _, name := range secret.ReferencedSecretNames(&sts.Spec.Template.Spec) {
+			if name == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(sts)})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+func hasChecksum(obj client.Object) bool {
+	sts, ok := obj.(*appsv1.StatefulSet)
+	if !ok {
+		return false
+	}
+	_, ok = sts.Spec.Template.Annotations[constants.LabelSecretChecksum]
+	return ok
+}
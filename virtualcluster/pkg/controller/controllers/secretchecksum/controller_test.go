@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretchecksum
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func newStatefulSet(namespace, name, checksum string, annotated bool) *appsv1.StatefulSet {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{
+						Name:         "root-ca",
+						VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secret.RootCASecretName}},
+					}},
+				},
+			},
+		},
+	}
+	if annotated {
+		sts.Spec.Template.Annotations = map[string]string{constants.LabelSecretChecksum: checksum}
+	}
+	return sts
+}
+
+func TestReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"},
+		Status:     tenancyv1alpha1.VirtualClusterStatus{Phase: tenancyv1alpha1.ClusterRunning},
+	}
+	rootNS := conversion.ToClusterKey(vc)
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: rootNS,
+			Annotations: map[string]string{
+				constants.LabelVCName:      vc.Name,
+				constants.LabelVCNamespace: vc.Namespace,
+			},
+		},
+	}
+	rootCA := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: rootNS, Name: secret.RootCASecretName},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("crt")},
+	}
+	initial := secret.Checksum([]corev1.Secret{*rootCA})
+	apiserver := newStatefulSet(rootNS, "apiserver", initial, true)
+	unmanaged := newStatefulSet(rootNS, "unmanaged", "", false)
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(vc, ns, rootCA, apiserver, unmanaged).Build()
+	r := &ReconcileSecretChecksum{Client: cli, APIReader: cli, Log: ctrl.Log.WithName("test")}
+
+	reconcileAndGet := func(sts *appsv1.StatefulSet) (reconcile.Result, *appsv1.StatefulSet) {
+		t.Helper()
+		result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(sts)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := &appsv1.StatefulSet{}
+		if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(sts), got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result, got
+	}
+
+	if _, got := reconcileAndGet(apiserver); got.Spec.Template.Annotations[constants.LabelSecretChecksum] != initial {
+		t.Errorf("expected the checksum to be kept when the secrets didn't change")
+	}
+
+	rootCA.Data[corev1.TLSCertKey] = []byte("rotated")
+	if err := cli.Update(context.TODO(), rootCA); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rotated := secret.Checksum([]corev1.Secret{*rootCA})
+
+	requests := r.statefulSetsOfSecret(rootCA)
+	if len(requests) != 1 || requests[0].Name != apiserver.Name {
+		t.Errorf("expected the secret to be mapped to the annotated statefulset only, got %v", requests)
+	}
+
+	vc.Labels = map[string]string{constants.LabelVCReadyForUpgrade: "true"}
+	if err := cli.Update(context.TODO(), vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, got := reconcileAndGet(apiserver)
+	if result.RequeueAfter != busyRequeuePeriod {
+		t.Errorf("expected to requeue after %v during the upgrade, got %v", busyRequeuePeriod, result.RequeueAfter)
+	}
+	if got.Spec.Template.Annotations[constants.LabelSecretChecksum] != initial {
+		t.Errorf("expected the checksum not to be updated during the upgrade")
+	}
+
+	vc.Labels = nil
+	if err := cli.Update(context.TODO(), vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, got := reconcileAndGet(apiserver); got.Spec.Template.Annotations[constants.LabelSecretChecksum] != rotated {
+		t.Errorf("expected the checksum to be updated to %s, got %s", rotated, got.Spec.Template.Annotations[constants.LabelSecretChecksum])
+	}
+
+	if _, got := reconcileAndGet(unmanaged); len(got.Spec.Template.Annotations) != 0 {
+		t.Errorf("expected the statefulset without checksum not to be annotated, got %v", got.Spec.Template.Annotations)
+	}
+}
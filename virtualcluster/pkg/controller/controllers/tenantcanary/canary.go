@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenantcanary
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// canaryNamespacePrefix is the prefix of the generated name of the tenant namespace the
+	// canary pod is created in, the namespace is deleted once the canary is done
+	canaryNamespacePrefix = "vc-canary-"
+	// canaryLabel marks the tenant namespaces created by the canaries, the other namespaces
+	// are never deleted
+	canaryLabel = "tenancy.x-k8s.io/canary"
+	canaryPod   = "canary"
+
+	cleanupTimeout = 30 * time.Second
+)
+
+// canaryError describes the step a canary failed at
+type canaryError struct {
+	reason  string
+	message string
+}
+
+func (e *canaryError) Error() string {
+	return e.reason + ": " + e.message
+}
+
+// runCanary creates the canary pod in a new namespace of the tenant cluster and waits until it
+// is scheduled and running and its logs can be streamed, the namespace is deleted afterwards
+func (r *ReconcileTenantCanary) runCanary(ctx context.Context, tenantClient kubernetes.Interface) *canaryError {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	// the namespaces of the previous canaries are deleted again in case their cleanup failed
+	r.cleanup(tenantClient)
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		GenerateName: canaryNamespacePrefix,
+		Labels:       map[string]string{canaryLabel: "true"},
+	}}
+	ns, err := tenantClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+	if err != nil {
+		return &canaryError{"NamespaceNotReady", fmt.Sprintf("failed to create the canary namespace: %v", err)}
+	}
+	defer r.cleanup(tenantClient)
+	canaryNamespace := ns.Name
+
+	// pods can't be created before the service account controller of the tenant created the
+	// default service account
+	if err := r.poll(ctx, func() (bool, error) {
+		_, err := tenantClient.CoreV1().ServiceAccounts(canaryNamespace).Get(ctx, "default", metav1.GetOptions{})
+		return err == nil, nil
+	}); err != nil {
+		return &canaryError{"NamespaceNotReady", fmt.Sprintf("the default service account of namespace %s was not created within %v", canaryNamespace, r.Timeout)}
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: canaryPod, Namespace: canaryNamespace},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  canaryPod,
+				Image: r.Image,
+			}},
+			AutomountServiceAccountToken:  pointer.BoolPtr(false),
+			TerminationGracePeriodSeconds: pointer.Int64Ptr(0),
+		},
+	}
+	if _, err := tenantClient.CoreV1().Pods(canaryNamespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return &canaryError{"PodNotCreated", fmt.Sprintf("failed to create the canary pod: %v", err)}
+	}
+
+	// the syncer sets the node of the tenant pod once the super cluster pod is scheduled
+	if err := r.poll(ctx, func() (bool, error) {
+		p, err := tenantClient.CoreV1().Pods(canaryNamespace).Get(ctx, canaryPod, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		pod = p
+		return pod.Spec.NodeName != "", nil
+	}); err != nil {
+		return &canaryError{"PodNotScheduled", fmt.Sprintf("the canary pod was not synced to the super cluster and scheduled within %v", r.Timeout)}
+	}
+
+	if err := r.poll(ctx, func() (bool, error) {
+		p, err := tenantClient.CoreV1().Pods(canaryNamespace).Get(ctx, canaryPod, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		pod = p
+		return pod.Status.Phase == corev1.PodRunning, nil
+	}); err != nil {
+		return &canaryError{"PodNotRunning", fmt.Sprintf("the canary pod was not running on node %s within %v%s", pod.Spec.NodeName, r.Timeout, waitingReason(pod))}
+	}
+
+	if _, err := tenantClient.CoreV1().Pods(canaryNamespace).GetLogs(canaryPod, &corev1.PodLogOptions{}).DoRaw(ctx); err != nil {
+		return &canaryError{"LogsUnavailable", fmt.Sprintf("failed to stream the logs of the canary pod from node %s: %v", pod.Spec.NodeName, err)}
+	}
+	return nil
+}
+
+func (r *ReconcileTenantCanary) poll(ctx context.Context, condition wait.ConditionFunc) error {
+	return wait.PollImmediateUntil(r.pollInterval, condition, ctx.Done())
+}
+
+// cleanup deletes the canary namespaces, the ones carrying the canaryLabel, the deletion is
+// not waited for
+func (r *ReconcileTenantCanary) cleanup(tenantClient kubernetes.Interface) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	namespaces, err := tenantClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: canaryLabel + "=true"})
+	if err != nil {
+		r.Log.Error(err, "failed to list the canary namespaces")
+		return
+	}
+	for _, ns := range namespaces.Items {
+		if ns.DeletionTimestamp != nil {
+			continue
+		}
+		opts := metav1.DeleteOptions{Preconditions: metav1.NewUIDPreconditions(string(ns.UID))}
+		if err := tenantClient.CoreV1().Namespaces().Delete(ctx, ns.Name, opts); err != nil && !apierrors.IsNotFound(err) {
+			r.Log.Error(err, "failed to delete the canary namespace", "namespace", ns.Name)
+		}
+	}
+}
+
+// waitingReason returns why the container of the canary pod is waiting, e.g. ErrImagePull
+func waitingReason(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			return ": " + status.State.Waiting.Reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenantcanary
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// DefaultImage is the image of the canary pod
+	DefaultImage = "k8s.gcr.io/pause:3.5"
+	// DefaultTimeout is the default time a canary is given to succeed
+	DefaultTimeout = 3 * time.Minute
+	// DefaultRetryPeriod is the default interval between two runs of a failed canary
+	DefaultRetryPeriod = 10 * time.Minute
+)
+
+var _ reconcile.Reconciler = &ReconcileTenantCanary{}
+
+// ReconcileTenantCanary runs a canary once a VirtualCluster is running: a pod is created in a
+// temporary namespace through the tenant apiserver, it has to be synced, scheduled and running
+// and its logs have to be streamed. The result is recorded in the TenantCanaryCondition.
+type ReconcileTenantCanary struct {
+	client.Client
+	Log logr.Logger
+	// Image of the canary pod, DefaultImage if not set
+	Image string
+	// Timeout of a canary, DefaultTimeout if not set
+	Timeout time.Duration
+	// RetryPeriod between two runs of a failed canary, DefaultRetryPeriod if not set
+	RetryPeriod time.Duration
+
+	// newTenantClient creates the client of the tenant apiserver, overridden in tests
+	newTenantClient func(*rest.Config) (kubernetes.Interface, error)
+	pollInterval    time.Duration
+}
+
+// SetupWithManager will configure the tenant canary reconciler
+func (r *ReconcileTenantCanary) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	r.setDefaults()
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("tenant-canary").
+		WithOptions(opts).
+		For(&tenancyv1alpha1.VirtualCluster{}, builder.WithPredicates(predicate.Funcs{UpdateFunc: phaseChanged})).
+		Complete(r)
+}
+
+func (r *ReconcileTenantCanary) setDefaults() {
+	if r.Image == "" {
+		r.Image = DefaultImage
+	}
+	if r.Timeout <= 0 {
+		r.Timeout = DefaultTimeout
+	}
+	if r.RetryPeriod <= 0 {
+		r.RetryPeriod = DefaultRetryPeriod
+	}
+	if r.newTenantClient == nil {
+		r.newTenantClient = func(config *rest.Config) (kubernetes.Interface, error) {
+			return kubernetes.NewForConfig(config)
+		}
+	}
+	if r.pollInterval <= 0 {
+		r.pollInterval = 2 * time.Second
+	}
+}
+
+// Reconcile runs the canary of a running VirtualCluster unless it already succeeded, a failed
+// canary is run again after the RetryPeriod
+func (r *ReconcileTenantCanary) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if !vc.DeletionTimestamp.IsZero() || vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return reconcile.Result{}, nil
+	}
+	if canarySucceeded(vc) {
+		return reconcile.Result{}, nil
+	}
+
+	restConfig, err := r.tenantRESTConfig(ctx, vc)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{RequeueAfter: r.RetryPeriod}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	tenantClient, err := r.newTenantClient(restConfig)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.Log.Info("running tenant canary", "vc", vc.Name)
+	canaryErr := r.runCanary(ctx, tenantClient)
+	status, reason, message := corev1.ConditionTrue, "Succeeded", "the canary pod was synced, scheduled and running, and its logs were streamed"
+	if canaryErr != nil {
+		r.Log.Info("tenant canary failed", "vc", vc.Name, "reason", canaryErr.reason, "message", canaryErr.message)
+		status, reason, message = corev1.ConditionFalse, canaryErr.reason, canaryErr.message
+	}
+
+	// the canary may take a while, get the latest vc before patching its status
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	orig := vc.DeepCopy()
+	kubeutil.SetVCCondition(vc, tenancyv1alpha1.TenantCanaryCondition, status, reason, message)
+	if err := r.Patch(ctx, vc, client.MergeFrom(orig)); err != nil {
+		return reconcile.Result{}, err
+	}
+	if canaryErr != nil {
+		return reconcile.Result{RequeueAfter: r.RetryPeriod}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// tenantRESTConfig returns the config of the tenant apiserver using the admin kubeconfig
+// stored in the root namespace of vc
+func (r *ReconcileTenantCanary) tenantRESTConfig(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (*rest.Config, error) {
+	adminSrt := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: conversion.ToRootNamespace(vc), Name: secret.AdminSecretName}, adminSrt); err != nil {
+		return nil, err
+	}
+	return clientcmd.RESTConfigFromKubeConfig(adminSrt.Data[secret.AdminSecretName])
+}
+
+func canarySucceeded(vc *tenancyv1alpha1.VirtualCluster) bool {
+	for _, cond := range vc.Status.Conditions {
+		if cond.Type == tenancyv1alpha1.TenantCanaryCondition {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// phaseChanged only lets the phase transitions of the VirtualClusters trigger a canary, the
+// failed canaries are retried by requeueing
+func phaseChanged(e event.UpdateEvent) bool {
+	oldVC, ok := e.ObjectOld.(*tenancyv1alpha1.VirtualCluster)
+	if !ok {
+		return false
+	}
+	newVC, ok := e.ObjectNew.(*tenancyv1alpha1.VirtualCluster)
+	if !ok {
+		return false
+	}
+	return oldVC.Status.Phase != newVC.Status.Phase
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenantcanary
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: tenant
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: admin
+  context:
+    cluster: tenant
+    user: admin
+current-context: admin
+users:
+- name: admin
+  user:
+    token: token
+`
+
+// newTenantClientset returns a tenant clientset that creates the default service account of
+// the new namespaces and, if schedule is set, schedules and runs the new pods
+func newTenantClientset(schedule bool) *k8sfake.Clientset {
+	cs := k8sfake.NewSimpleClientset()
+	cs.PrependReactor("create", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		ns := action.(k8stesting.CreateAction).GetObject().(*corev1.Namespace)
+		if ns.Name == "" {
+			ns.Name = ns.GenerateName + "x7k2q"
+		}
+		sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: ns.Name}}
+		return false, nil, cs.Tracker().Add(sa)
+	})
+	cs.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if schedule {
+			pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+			pod.Spec.NodeName = "node-1"
+			pod.Status.Phase = corev1.PodRunning
+		}
+		return false, nil, nil
+	})
+	return cs
+}
+
+func TestReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	succeeded := tenancyv1alpha1.ClusterCondition{Type: tenancyv1alpha1.TenantCanaryCondition, Status: corev1.ConditionTrue, Reason: "Succeeded"}
+	testcases := map[string]struct {
+		phase          tenancyv1alpha1.ClusterPhase
+		conditions     []tenancyv1alpha1.ClusterCondition
+		schedule       bool
+		expectedStatus corev1.ConditionStatus
+		expectedReason string
+		expectedRun    bool
+	}{
+		"canary succeeds": {
+			phase:          tenancyv1alpha1.ClusterRunning,
+			schedule:       true,
+			expectedStatus: corev1.ConditionTrue,
+			expectedReason: "Succeeded",
+			expectedRun:    true,
+		},
+		"pod not scheduled": {
+			phase:          tenancyv1alpha1.ClusterRunning,
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: "PodNotScheduled",
+			expectedRun:    true,
+		},
+		"canary already succeeded": {
+			phase:          tenancyv1alpha1.ClusterRunning,
+			conditions:     []tenancyv1alpha1.ClusterCondition{succeeded},
+			expectedStatus: corev1.ConditionTrue,
+			expectedReason: "Succeeded",
+		},
+		"cluster not running": {
+			phase: tenancyv1alpha1.ClusterPending,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			vc := &tenancyv1alpha1.VirtualCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"},
+				Status:     tenancyv1alpha1.VirtualClusterStatus{Phase: tc.phase, Conditions: tc.conditions},
+			}
+			adminSrt := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secret.AdminSecretName, Namespace: conversion.ToClusterKey(vc)},
+				Data:       map[string][]byte{secret.AdminSecretName: []byte(kubeconfig)},
+			}
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(vc, adminSrt).Build()
+			tenantClient := newTenantClientset(tc.schedule)
+			// a namespace of the tenant, and the namespace of a previous canary whose cleanup failed
+			_ = tenantClient.Tracker().Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "vc-canary"}})
+			_ = tenantClient.Tracker().Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   canaryNamespacePrefix + "m4p9z",
+				Labels: map[string]string{canaryLabel: "true"},
+			}})
+			r := &ReconcileTenantCanary{
+				Client:  cli,
+				Log:     ctrl.Log.WithName("test"),
+				Timeout: 100 * time.Millisecond,
+				newTenantClient: func(*rest.Config) (kubernetes.Interface, error) {
+					return tenantClient, nil
+				},
+				pollInterval: 10 * time.Millisecond,
+			}
+			r.setDefaults()
+
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: vc.Namespace, Name: vc.Name}}
+			result, err := r.Reconcile(context.TODO(), request)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectedStatus == corev1.ConditionFalse && result.RequeueAfter != DefaultRetryPeriod {
+				t.Errorf("expected a failed canary to be retried after %v, got %v", DefaultRetryPeriod, result.RequeueAfter)
+			}
+
+			created := false
+			for _, action := range tenantClient.Actions() {
+				if action.Matches("create", "pods") {
+					created = true
+				}
+			}
+			if created != tc.expectedRun {
+				t.Errorf("expected canary run %v, got %v", tc.expectedRun, created)
+			}
+			namespaces, err := tenantClient.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectedRun && (len(namespaces.Items) != 1 || namespaces.Items[0].Name != "vc-canary") {
+				t.Errorf("expected only the canary namespaces to be deleted, got %v", namespaces.Items)
+			}
+
+			got := &tenancyv1alpha1.VirtualCluster{}
+			if err := cli.Get(context.TODO(), request.NamespacedName, got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var cond *tenancyv1alpha1.ClusterCondition
+			for i := range got.Status.Conditions {
+				if got.Status.Conditions[i].Type == tenancyv1alpha1.TenantCanaryCondition {
+					cond = &got.Status.Conditions[i]
+				}
+			}
+			if tc.expectedStatus == "" {
+				if cond != nil {
+					t.Errorf("expected no canary condition, got %+v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tc.expectedStatus || cond.Reason != tc.expectedReason {
+				t.Errorf("expected canary condition %s/%s, got %+v", tc.expectedStatus, tc.expectedReason, cond)
+			}
+		})
+	}
+}
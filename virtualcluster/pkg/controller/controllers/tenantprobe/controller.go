@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenantprobe
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// DefaultPeriod is the default interval between two probes of a tenant apiserver
+	DefaultPeriod = time.Minute
+
+	probeTimeout = 10 * time.Second
+)
+
+var _ reconcile.Reconciler = &ReconcileTenantProbe{}
+
+// ReconcileTenantProbe periodically queries the /version and /readyz endpoints of the
+// apiserver of every running VirtualCluster and records the results in its status
+type ReconcileTenantProbe struct {
+	client.Client
+	Log logr.Logger
+	// Period between two probes of a tenant apiserver, DefaultPeriod if not set
+	Period time.Duration
+}
+
+// SetupWithManager will configure the tenant probe reconciler
+func (r *ReconcileTenantProbe) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	if r.Period <= 0 {
+		r.Period = DefaultPeriod
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("tenant-probe").
+		WithOptions(opts).
+		For(&tenancyv1alpha1.VirtualCluster{}).
+		Complete(r)
+}
+
+// Reconcile probes the tenant apiserver of a running VirtualCluster and updates the
+// kubernetesVersion and controlPlaneHealthy status fields when they change
+func (r *ReconcileTenantProbe) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if !vc.DeletionTimestamp.IsZero() || vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return reconcile.Result{}, nil
+	}
+
+	restConfig, err := r.tenantRESTConfig(ctx, vc)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{RequeueAfter: r.Period}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	version, healthy := Probe(ctx, restConfig)
+	if version == "" {
+		// keep the last known version while the apiserver can't be reached
+		version = vc.Status.KubernetesVersion
+	}
+
+	if vc.Status.KubernetesVersion == version && vc.Status.ControlPlaneHealthy != nil && *vc.Status.ControlPlaneHealthy == healthy {
+		return reconcile.Result{RequeueAfter: r.Period}, nil
+	}
+	r.Log.Info("tenant apiserver status changed", "vc", vc.Name, "version", version, "healthy", healthy)
+	orig := vc.DeepCopy()
+	vc.Status.KubernetesVersion = version
+	vc.Status.ControlPlaneHealthy = &healthy
+	// a merge patch replaces the conditions list, the optimistic lock fails the patch rather
+	// than dropping the conditions written meanwhile, vc is probed again on conflict
+	if err := r.Patch(ctx, vc, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: r.Period}, nil
+}
+
+// tenantRESTConfig returns the config of the tenant apiserver using the admin kubeconfig
+// stored in the root namespace of vc
+func (r *ReconcileTenantProbe) tenantRESTConfig(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (*rest.Config, error) {
+	adminSrt := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: conversion.ToRootNamespace(vc), Name: secret.AdminSecretName}, adminSrt); err != nil {
+		return nil, err
+	}
+	return clientcmd.RESTConfigFromKubeConfig(adminSrt.Data[secret.AdminSecretName])
+}
+
+// Probe returns the git version reported by the apiserver, or an empty string if it can't be
+// queried, and whether its /readyz endpoint succeeds
+func Probe(ctx context.Context, restConfig *rest.Config) (string, bool) {
+	config := rest.CopyConfig(restConfig)
+	config.Timeout = probeTimeout
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return "", false
+	}
+
+	version := ""
+	if info, err := dc.ServerVersion(); err == nil {
+		version = info.GitVersion
+	}
+	_, err = dc.RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+	return version, err == nil
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenantprobe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func TestReconcile(t *testing.T) {
+	var ready int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/version":
+			fmt.Fprint(w, `{"major":"1","minor":"22","gitVersion":"v1.22.4"}`)
+		case "/readyz":
+			if atomic.LoadInt32(&ready) == 1 {
+				fmt.Fprint(w, "ok")
+				return
+			}
+			http.Error(w, "etcd not ready", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"},
+		Status:     tenancyv1alpha1.VirtualClusterStatus{Phase: tenancyv1alpha1.ClusterRunning},
+	}
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: tenant
+  cluster:
+    server: %s
+contexts:
+- name: admin
+  context:
+    cluster: tenant
+    user: admin
+current-context: admin
+users:
+- name: admin
+  user:
+    token: token
+`, server.URL)
+	adminSrt := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secret.AdminSecretName, Namespace: conversion.ToClusterKey(vc)},
+		Data:       map[string][]byte{secret.AdminSecretName: []byte(kubeconfig)},
+	}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(vc, adminSrt).Build()
+	r := &ReconcileTenantProbe{Client: cli, Log: ctrl.Log.WithName("test"), Period: DefaultPeriod}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: vc.Namespace, Name: vc.Name}}
+
+	check := func(version string, healthy bool) {
+		t.Helper()
+		result, err := r.Reconcile(context.TODO(), request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.RequeueAfter != DefaultPeriod {
+			t.Errorf("expected to requeue after %v, got %v", DefaultPeriod, result.RequeueAfter)
+		}
+		got := &tenancyv1alpha1.VirtualCluster{}
+		if err := cli.Get(context.TODO(), request.NamespacedName, got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Status.KubernetesVersion != version {
+			t.Errorf("expected version %q, got %q", version, got.Status.KubernetesVersion)
+		}
+		if got.Status.ControlPlaneHealthy == nil || *got.Status.ControlPlaneHealthy != healthy {
+			t.Errorf("expected healthy %v, got %v", healthy, got.Status.ControlPlaneHealthy)
+		}
+		if got.Status.Phase != tenancyv1alpha1.ClusterRunning {
+			t.Errorf("expected phase to be kept, got %q", got.Status.Phase)
+		}
+	}
+
+	check("v1.22.4", true)
+	atomic.StoreInt32(&ready, 0)
+	check("v1.22.4", false)
+	server.Close()
+	check("v1.22.4", false)
+}
+
+// concurrentWriter adds a condition to the VirtualCluster once it is read, as another
+// controller updating its status meanwhile would
+type concurrentWriter struct {
+	client.Client
+	written bool
+}
+
+func (c *concurrentWriter) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := c.Client.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	vc, ok := obj.(*tenancyv1alpha1.VirtualCluster)
+	if !ok || c.written {
+		return nil
+	}
+	c.written = true
+	concurrent := vc.DeepCopy()
+	kubeutil.SetVCCondition(concurrent, tenancyv1alpha1.ETCDStoragePressureCondition, corev1.ConditionTrue, "ApproachingQuota", "")
+	return c.Client.Update(ctx, concurrent)
+}
+
+func TestReconcileConflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/version" {
+			fmt.Fprint(w, `{"major":"1","minor":"22","gitVersion":"v1.22.4"}`)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"},
+		Status:     tenancyv1alpha1.VirtualClusterStatus{Phase: tenancyv1alpha1.ClusterRunning},
+	}
+	adminSrt := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secret.AdminSecretName, Namespace: conversion.ToClusterKey(vc)},
+		Data: map[string][]byte{secret.AdminSecretName: []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: tenant
+  cluster:
+    server: %s
+contexts:
+- name: admin
+  context:
+    cluster: tenant
+current-context: admin
+`, server.URL))},
+	}
+
+	cli := &concurrentWriter{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(vc, adminSrt).Build()}
+	r := &ReconcileTenantProbe{Client: cli, Log: ctrl.Log.WithName("test"), Period: DefaultPeriod}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: vc.Namespace, Name: vc.Name}}
+
+	if _, err := r.Reconcile(context.TODO(), request); !apierrors.IsConflict(err) {
+		t.Fatalf("expected the stale patch to conflict, got %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := &tenancyv1alpha1.VirtualCluster{}
+	if err := cli.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status.KubernetesVersion != "v1.22.4" {
+		t.Errorf("expected version v1.22.4, got %q", got.Status.KubernetesVersion)
+	}
+	if len(got.Status.Conditions) != 1 || got.Status.Conditions[0].Type != tenancyv1alpha1.ETCDStoragePressureCondition {
+		t.Errorf("expected the concurrent condition to be kept, got %v", got.Status.Conditions)
+	}
+}
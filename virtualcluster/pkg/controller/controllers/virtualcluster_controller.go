@@ -24,8 +24,11 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -39,15 +42,24 @@ import (
 	strutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/strings"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	vcerrors "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
 )
 
+// etcdStorageExpansionPollPeriod is the period the progress of an etcd storage expansion is checked
+const etcdStorageExpansionPollPeriod = 10 * time.Second
+
 // GetProvisioner returns a new provisioner.Provisioner by ProvisionerName
 func (r *ReconcileVirtualCluster) GetProvisioner(mgr ctrl.Manager, log logr.Logger, provisionerTimeout time.Duration) (provisioner.Provisioner, error) {
 	switch r.ProvisionerName {
 	case "aliyun":
 		return provisioner.NewProvisionerAliyun(mgr, log, provisionerTimeout)
 	case "native":
-		return provisioner.NewProvisionerNative(mgr, log, provisionerTimeout)
+		native, err := provisioner.NewProvisionerNative(mgr, log, provisionerTimeout)
+		if err != nil {
+			return nil, err
+		}
+		native.DefaultSecurityProfile = r.DefaultSecurityProfile
+		return native, nil
 	}
 	return nil, fmt.Errorf("virtualcluster provisioner missing")
 }
@@ -61,15 +73,26 @@ type ReconcileVirtualCluster struct {
 	ProvisionerName    string
 	ProvisionerTimeout time.Duration
 	Provisioner        provisioner.Provisioner
+	// DefaultSecurityProfile applies to the ClusterVersions not setting a
+	// security profile, only used by the native provisioner
+	DefaultSecurityProfile tenancyv1alpha1.SecurityProfile
+	// Recorder records the errors of the VirtualClusters as events whose reason is
+	// their errors.Category
+	Recorder record.EventRecorder
 }
 
 // SetupWithManager will configure the VirtualCluster reconciler
 func (r *ReconcileVirtualCluster) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
-	provisioner, err := r.GetProvisioner(mgr, r.Log, r.ProvisionerTimeout)
-	if err != nil {
-		return err
+	if r.Provisioner == nil {
+		provisioner, err := r.GetProvisioner(mgr, r.Log, r.ProvisionerTimeout)
+		if err != nil {
+			return err
+		}
+		r.Provisioner = provisioner
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("virtualcluster-controller")
 	}
-	r.Provisioner = provisioner
 
 	// Expose featuregate.ClusterVersionPartialUpgrade metrics only if it enabled
 	if featuregate.DefaultFeatureGate.Enabled(featuregate.ClusterVersionPartialUpgrade) {
@@ -88,12 +111,18 @@ func (r *ReconcileVirtualCluster) SetupWithManager(mgr ctrl.Manager, opts contro
 
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=virtualclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=virtualclusters/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=clusterversions,verbs=get;list;watch
@@ -131,6 +160,7 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 			// block if fail to delete VC
 			if err = r.Provisioner.DeleteVirtualCluster(ctx, vc); err != nil {
 				r.Log.Error(err, "fail to delete virtualcluster", "vc-name", vc.Name)
+				r.recordError(vc, "delete", err)
 				return
 			}
 			// remove finalizer from the list and update it.
@@ -140,6 +170,11 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 		return
 	}
 
+	if vc.Labels[constants.LabelVCPaused] == "true" {
+		r.Log.Info("VirtualCluster is paused, skip reconciling", "vc", vc.GetName())
+		return
+	}
+
 	// reconcile VirtualCluster (vc) based on vc status
 	// NOTE: vc status is required by other components (e.g. syncer need to
 	// know the vc status in order to setup connection to the tenant control plane)
@@ -163,9 +198,11 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 				errReason := fmt.Sprintf("fail to create virtualcluster(%s): %s", vc.GetName(), err)
 				errMsg := fmt.Sprintf("retry: %d", retryTimes-1)
 				kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterPending, errMsg, errReason)
+				r.recordError(vc, "create", err)
 			} else {
 				kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning,
 					"tenant control plane is running", "TenantControlPlaneRunning")
+				clearError(vc)
 			}
 		} else {
 			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterError,
@@ -176,6 +213,11 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 		return
 	case tenancyv1alpha1.ClusterRunning:
 		r.Log.Info("VirtualCluster is running", "vc", vc.GetName())
+		if expander, ok := r.Provisioner.(provisioner.StorageExpander); ok && vc.Spec.ETCDStorage != nil {
+			if rncilRslt, err = r.expandETCDStorage(ctx, vc, expander); err != nil || !rncilRslt.IsZero() {
+				return
+			}
+		}
 		if !featuregate.DefaultFeatureGate.Enabled(featuregate.ClusterVersionPartialUpgrade) {
 			return
 		}
@@ -189,10 +231,12 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 		if err != nil {
 			r.Log.Error(err, "fail to upgrade virtualcluster", "vc", vc.GetName())
 			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning, fmt.Sprintf("fail to upgrade: %s", err), "TenantControlPlaneUpgradeFailed")
+			r.recordError(vc, "upgrade", err)
 			clustersUpgradeFailedCounter.WithLabelValues(vc.Spec.ClusterVersionName, vc.Labels[constants.LabelClusterVersionApplied]).Inc()
 		} else {
 			r.Log.Info("upgrade finished", "vc", vc.GetName())
 			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning, "tenant control plane is upgraded", "TenantControlPlaneUpgradeCompleted")
+			clearError(vc)
 			clustersUpgradedCounter.WithLabelValues(vc.Spec.ClusterVersionName, vc.Labels[constants.LabelClusterVersionApplied]).Inc()
 		}
 
@@ -218,7 +262,63 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 		r.Log.Info("fail to create virtualcluster", "vc", vc.GetName())
 		return
 	default:
-		err = fmt.Errorf("unknown vc phase: %s", vc.Status.Phase)
+		err = vcerrors.NewBug("unknown vc phase: %s", vc.Status.Phase)
+		r.recordError(vc, "reconcile", err)
 		return
 	}
 }
+
+// recordError records err as a warning event of vc and in its ReconcileFailedCondition, both
+// with the category of err as reason, so that the transient failures can be told apart from
+// the errors the owner of vc has to fix
+func (r *ReconcileVirtualCluster) recordError(vc *tenancyv1alpha1.VirtualCluster, action string, err error) {
+	category := vcerrors.CategoryOf(err)
+	message := fmt.Sprintf("fail to %s virtualcluster: %v", action, err)
+	if r.Recorder != nil {
+		r.Recorder.Event(vc, corev1.EventTypeWarning, string(category), message)
+	}
+	kubeutil.SetVCCondition(vc, tenancyv1alpha1.ReconcileFailedCondition, corev1.ConditionTrue, string(category), message)
+}
+
+// clearError sets the ReconcileFailedCondition of vc to false once the control plane is
+// reconciled, the condition is only added on failures
+func clearError(vc *tenancyv1alpha1.VirtualCluster) {
+	for _, cond := range vc.Status.Conditions {
+		if cond.Type == tenancyv1alpha1.ReconcileFailedCondition {
+			kubeutil.SetVCCondition(vc, tenancyv1alpha1.ReconcileFailedCondition, corev1.ConditionFalse, "Reconciled", "")
+			return
+		}
+	}
+}
+
+// expandETCDStorage expands the etcd storage of a running vc and records the
+// progress as a condition, vc is requeued until the expansion completes
+func (r *ReconcileVirtualCluster) expandETCDStorage(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, expander provisioner.StorageExpander) (reconcile.Result, error) {
+	oldStatus := vc.Status.DeepCopy()
+	done, err := expander.ExpandETCDStorage(ctx, vc)
+	switch {
+	case err != nil:
+		r.Log.Error(err, "fail to expand etcd storage", "vc", vc.GetName())
+		kubeutil.SetVCCondition(vc, tenancyv1alpha1.ETCDStorageExpandedCondition, corev1.ConditionFalse,
+			"ETCDStorageExpansionFailed", err.Error())
+	case !done:
+		kubeutil.SetVCCondition(vc, tenancyv1alpha1.ETCDStorageExpandedCondition, corev1.ConditionFalse,
+			"ETCDStorageExpanding", fmt.Sprintf("expanding etcd storage to %s", vc.Spec.ETCDStorage.String()))
+	default:
+		kubeutil.SetVCCondition(vc, tenancyv1alpha1.ETCDStorageExpandedCondition, corev1.ConditionTrue,
+			"ETCDStorageExpanded", fmt.Sprintf("etcd storage is expanded to %s", vc.Spec.ETCDStorage.String()))
+	}
+
+	if !equality.Semantic.DeepEqual(oldStatus, &vc.Status) {
+		if updateErr := kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log); updateErr != nil {
+			return reconcile.Result{}, updateErr
+		}
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !done {
+		return reconcile.Result{RequeueAfter: etcdStorageExpansionPollPeriod}, nil
+	}
+	return reconcile.Result{}, nil
+}
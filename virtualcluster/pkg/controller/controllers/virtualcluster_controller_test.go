@@ -18,20 +18,36 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis"
 	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 )
 
@@ -225,3 +241,129 @@ var _ = Describe("VirtualCluster Controller", func() {
 		})
 	})
 })
+
+// gatedProvisioner reports every VirtualCluster it starts creating and only completes the
+// creations once released.
+type gatedProvisioner struct {
+	started chan string
+	release chan struct{}
+}
+
+func (p *gatedProvisioner) CreateVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	p.started <- vc.Name
+	select {
+	case <-p.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *gatedProvisioner) DeleteVirtualCluster(context.Context, *tenancyv1alpha1.VirtualCluster) error {
+	return nil
+}
+
+func (p *gatedProvisioner) UpgradeVirtualCluster(context.Context, *tenancyv1alpha1.VirtualCluster) error {
+	return nil
+}
+
+func (p *gatedProvisioner) GetProvisioner() string {
+	return "gated"
+}
+
+func TestReconcileVirtualClustersConcurrently(t *testing.T) {
+	const numVCs = 10
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	var vcs []client.Object
+	for i := 0; i < numVCs; i++ {
+		vc := &tenancyv1alpha1.VirtualCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  "default",
+				Name:       fmt.Sprintf("vc-%d", i),
+				Finalizers: []string{"virtualcluster.finalizer.gated"},
+			},
+			Spec: tenancyv1alpha1.VirtualClusterSpec{ClusterVersionName: "cv"},
+		}
+		kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterPending, "retry: 3", "ClusterCreating")
+		vcs = append(vcs, vc)
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(vcs...).Build()
+	informers := &informertest.FakeInformers{Scheme: s}
+	mgr, err := ctrl.NewManager(&rest.Config{Host: "http://127.0.0.1:1"}, ctrl.Options{
+		Scheme:             s,
+		MetricsBindAddress: "0",
+		MapperProvider: func(*rest.Config) (meta.RESTMapper, error) {
+			return meta.NewDefaultRESTMapper(nil), nil
+		},
+		NewCache: func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return informers, nil
+		},
+		NewClient: func(cache.Cache, *rest.Config, client.Options, ...client.Object) (client.Client, error) {
+			return fakeClient, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create the manager: %v", err)
+	}
+
+	p := &gatedProvisioner{started: make(chan string, numVCs), release: make(chan struct{})}
+	r := &ReconcileVirtualCluster{
+		Client:      fakeClient,
+		Log:         ctrl.Log.WithName("controllers").WithName("VirtualCluster"),
+		Provisioner: p,
+		Recorder:    record.NewFakeRecorder(numVCs),
+	}
+	if err := r.SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: numVCs}); err != nil {
+		t.Fatalf("failed to set up the reconciler: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = mgr.Start(ctx)
+	}()
+	vcInformer, err := informers.FakeInformerFor(&tenancyv1alpha1.VirtualCluster{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every VirtualCluster is being created before any creation completes, the event handler
+	// of the controller is registered once the manager is started.
+	started := sets.NewString()
+	timeout := time.After(10 * time.Second)
+	for started.Len() < numVCs {
+		for _, vc := range vcs {
+			vcInformer.Add(vc)
+		}
+		select {
+		case name := <-p.started:
+			started.Insert(name)
+		case <-time.After(100 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("expected the %d VirtualClusters to be created concurrently, only %v are", numVCs, started.List())
+		}
+	}
+	close(p.release)
+
+	err = wait.PollImmediate(50*time.Millisecond, 10*time.Second, func() (bool, error) {
+		for _, obj := range vcs {
+			vc := &tenancyv1alpha1.VirtualCluster{}
+			if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(obj), vc); err != nil {
+				return false, err
+			}
+			if vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Errorf("expected the VirtualClusters to be running: %v", err)
+	}
+}
@@ -24,7 +24,6 @@ import (
 	"encoding/pem"
 	"fmt"
 	"net"
-	"strings"
 	"text/template"
 
 	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
@@ -56,12 +55,28 @@ preferences: {}
 
 // GenerateKubeconfig generates kubeconfig for given user
 func GenerateKubeconfig(user, clusterName, apiserverDomain string, groups []string, rootCA *vcpki.CrtKeyPair) (string, error) {
-	caPair, err := vcpki.NewClientCrtAndKey(user, rootCA, groups)
+	return GenerateClientKubeconfig(clusterName, ServerURL(apiserverDomain),
+		&vcpki.ClientCertConfig{CommonName: user, Groups: groups}, rootCA)
+}
+
+// GenerateClientKubeconfig generates a kubeconfig of the apiserver at server using a client
+// certificate of cfg signed by rootCA, e.g. a short-lived certificate of a read-only group.
+func GenerateClientKubeconfig(clusterName, server string, cfg *vcpki.ClientCertConfig, rootCA *vcpki.CrtKeyPair) (string, error) {
+	caPair, err := vcpki.NewClientCrtAndKeyWithConfig(cfg, rootCA)
 	if err != nil {
 		return "", err
 	}
-	return generateKubeconfigUseCertAndKey(clusterName,
-		[]string{apiserverDomain}, rootCA.Crt, caPair, user)
+	return generateKubeconfigUseCertAndKey(clusterName, server, rootCA.Crt, caPair, cfg.CommonName)
+}
+
+// ServerURL returns the url of the apiserver listening on the default port of address,
+// an ip or a domain
+func ServerURL(address string) string {
+	if ip := net.ParseIP(address); ip != nil && ip.To4() == nil {
+		// is ipv6
+		return fmt.Sprintf("https://[%v]:6443", address)
+	}
+	return fmt.Sprintf("https://%v:6443", address)
 }
 
 // encodeCertPEM encodes x509 certificate to pem
@@ -82,24 +97,13 @@ func encodePrivateKeyPEM(private *rsa.PrivateKey) []byte {
 }
 
 // generateKubeconfigUseCertAndKey generates kubeconfig based on the given crt/key pair
-func generateKubeconfigUseCertAndKey(clusterName string, ips []string, apiserverCA *x509.Certificate, caPair *vcpki.CrtKeyPair, username string) (string, error) {
-	urls := make([]string, 0, len(ips))
-	for _, ip := range ips {
-		addr := net.ParseIP(ip)
-		if addr.To4() != nil {
-			// is ipv4
-			urls = append(urls, fmt.Sprintf("https://%v:6443", ip))
-		} else {
-			// is ipv6
-			urls = append(urls, fmt.Sprintf("https://[%v]:6443", ip))
-		}
-	}
+func generateKubeconfigUseCertAndKey(clusterName, server string, apiserverCA *x509.Certificate, caPair *vcpki.CrtKeyPair, username string) (string, error) {
 	ctx := map[string]string{
 		"ca":           base64.StdEncoding.EncodeToString(encodeCertPEM(apiserverCA)),
 		"key":          base64.StdEncoding.EncodeToString(encodePrivateKeyPEM(caPair.Key)),
 		"cert":         base64.StdEncoding.EncodeToString(encodeCertPEM(caPair.Crt)),
 		"username":     username,
-		"controlPlane": strings.Join(urls, ","),
+		"controlPlane": server,
 		"cluster":      clusterName,
 	}
 
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"crypto/rsa"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/cert"
+
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+func TestServerURL(t *testing.T) {
+	for address, expected := range map[string]string{
+		"10.0.0.1":      "https://10.0.0.1:6443",
+		"fd00::1":       "https://[fd00::1]:6443",
+		"apiserver-svc": "https://apiserver-svc:6443",
+	} {
+		if got := ServerURL(address); got != expected {
+			t.Errorf("ServerURL(%q) = %q, expected %q", address, got, expected)
+		}
+	}
+}
+
+func TestGenerateClientKubeconfig(t *testing.T) {
+	caCrt, caKey, err := pkiutil.NewCertificateAuthority(&pkiutil.CertConfig{Config: cert.Config{CommonName: "kubernetes"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCA := &vcpki.CrtKeyPair{Crt: caCrt, Key: caKey.(*rsa.PrivateKey)}
+
+	content, err := GenerateClientKubeconfig("vc", "https://vc.example.com:6443", &vcpki.ClientCertConfig{
+		CommonName: "alice",
+		Groups:     []string{"viewers"},
+		Validity:   8 * time.Hour,
+	}, rootCA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := clientcmd.Load([]byte(content))
+	if err != nil {
+		t.Fatalf("invalid kubeconfig: %v", err)
+	}
+	if server := config.Clusters["vc"].Server; server != "https://vc.example.com:6443" {
+		t.Errorf("unexpected server %q", server)
+	}
+	certs, err := cert.ParseCertsPEM(config.AuthInfos["alice"].ClientCertificateData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crt := certs[0]
+	if crt.Subject.CommonName != "alice" || !reflect.DeepEqual(crt.Subject.Organization, []string{"viewers"}) {
+		t.Errorf("unexpected subject %v", crt.Subject)
+	}
+	if validity := time.Until(crt.NotAfter); validity > 8*time.Hour || validity < 8*time.Hour-time.Minute {
+		t.Errorf("expected the certificate to expire in 8h, got %v", validity)
+	}
+	if err := crt.CheckSignatureFrom(caCrt); err != nil {
+		t.Errorf("expected the certificate to be signed by the root ca: %v", err)
+	}
+
+	if _, err := GenerateClientKubeconfig("vc", "https://vc.example.com:6443", &vcpki.ClientCertConfig{}, rootCA); err == nil {
+		t.Errorf("expected a client certificate without common name to be refused")
+	}
+}
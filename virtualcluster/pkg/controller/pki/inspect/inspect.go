@@ -0,0 +1,221 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inspect reads the certificates of the PKI generated for a VirtualCluster back from its
+// secrets and validates them against the root CA of the cluster.
+package inspect
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+)
+
+// PKISecrets are the secrets holding the certificates generated for a VirtualCluster. The
+// service account secret only holds a key pair and is not inspected.
+var PKISecrets = []string{
+	secret.RootCASecretName,
+	secret.APIServerCASecretName,
+	secret.ETCDCASecretName,
+	secret.FrontProxyCASecretName,
+	secret.ControllerManagerSecretName,
+	secret.AdminSecretName,
+}
+
+// Certificate describes a certificate of the PKI of a VirtualCluster.
+type Certificate struct {
+	// Secret is the name of the secret holding the certificate.
+	Secret string `json:"secret"`
+	// Key locates the certificate in the secret, i.e. the data key, or the kubeconfig field for
+	// the certificates embedded in a kubeconfig.
+	Key         string    `json:"key"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dnsNames,omitempty"`
+	IPAddresses []net.IP  `json:"ipAddresses,omitempty"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	// KeyType is the algorithm and size of the public key, e.g. RSA-2048.
+	KeyType string `json:"keyType"`
+	IsCA    bool   `json:"isCA,omitempty"`
+	// Error is why the certificate can't be verified against the root CA, empty if it can.
+	Error string `json:"error,omitempty"`
+}
+
+// Valid tells whether the certificate is verified against the root CA.
+func (c *Certificate) Valid() bool {
+	return c.Error == ""
+}
+
+// ExpiresWithin tells whether the certificate is expired at now+d.
+func (c *Certificate) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !now.Add(d).Before(c.NotAfter)
+}
+
+// InspectNamespace reads the certificates of the PKI secrets in the root namespace of a
+// VirtualCluster. The secrets that are not found are skipped, except the root CA one.
+func InspectNamespace(ctx context.Context, client kubernetes.Interface, namespace string, now time.Time) ([]Certificate, error) {
+	var secrets []corev1.Secret
+	for _, name := range PKISecrets {
+		s, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		secrets = append(secrets, *s)
+	}
+	return Inspect(secrets, now)
+}
+
+// Inspect lists the certificates of the secrets and verifies them against the certificate of
+// the root CA secret at the given time. The certificates are sorted by secret and key.
+func Inspect(secrets []corev1.Secret, now time.Time) ([]Certificate, error) {
+	var rootCA *x509.Certificate
+	for i := range secrets {
+		if secrets[i].Name != secret.RootCASecretName {
+			continue
+		}
+		certs, err := parseCertificates(secrets[i].Data[corev1.TLSCertKey])
+		if err != nil || len(certs) == 0 {
+			return nil, fmt.Errorf("fail to parse the certificate of secret %s: %v", secret.RootCASecretName, err)
+		}
+		rootCA = certs[0]
+	}
+	if rootCA == nil {
+		return nil, fmt.Errorf("root CA secret %s not found", secret.RootCASecretName)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCA)
+
+	var ret []Certificate
+	for i := range secrets {
+		s := &secrets[i]
+		for key, certs := range secretCertificates(s) {
+			for _, cert := range certs {
+				c := newCertificate(s.Name, key, cert)
+				if _, err := cert.Verify(x509.VerifyOptions{
+					Roots:       roots,
+					CurrentTime: now,
+					KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+				}); err != nil {
+					c.Error = err.Error()
+				}
+				ret = append(ret, c)
+			}
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Secret != ret[j].Secret {
+			return ret[i].Secret < ret[j].Secret
+		}
+		return ret[i].Key < ret[j].Key
+	})
+	return ret, nil
+}
+
+// secretCertificates returns the certificates of the secret keyed by their location, the
+// kubeconfigs are parsed for the certificates they embed.
+func secretCertificates(s *corev1.Secret) map[string][]*x509.Certificate {
+	ret := make(map[string][]*x509.Certificate)
+	for key, data := range s.Data {
+		if certs, err := parseCertificates(data); err == nil && len(certs) > 0 {
+			ret[key] = certs
+			continue
+		}
+		cfg, err := clientcmd.Load(data)
+		if err != nil {
+			continue
+		}
+		for name, authInfo := range cfg.AuthInfos {
+			if certs, err := parseCertificates(authInfo.ClientCertificateData); err == nil && len(certs) > 0 {
+				ret[fmt.Sprintf("%s:users[%s].client-certificate-data", key, name)] = certs
+			}
+		}
+		for name, cluster := range cfg.Clusters {
+			if certs, err := parseCertificates(cluster.CertificateAuthorityData); err == nil && len(certs) > 0 {
+				ret[fmt.Sprintf("%s:clusters[%s].certificate-authority-data", key, name)] = certs
+			}
+		}
+	}
+	return ret
+}
+
+// parseCertificates parses the PEM encoded certificates of data, the other PEM blocks (e.g.
+// private keys) are skipped.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func newCertificate(secretName, key string, cert *x509.Certificate) Certificate {
+	return Certificate{
+		Secret:      secretName,
+		Key:         key,
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		DNSNames:    cert.DNSNames,
+		IPAddresses: cert.IPAddresses,
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+		KeyType:     KeyType(cert.PublicKey),
+		IsCA:        cert.IsCA,
+	}
+}
+
+// KeyType describes the algorithm and size of a public key, e.g. RSA-2048 or ECDSA-P-256.
+func KeyType(pub interface{}) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return "Unknown"
+	}
+}
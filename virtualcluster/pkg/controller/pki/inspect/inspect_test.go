@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspect
+
+import (
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/cert"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/kubeconfig"
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+func newTestCA(t *testing.T) *vcpki.CrtKeyPair {
+	crt, key, err := pkiutil.NewCertificateAuthority(&pkiutil.CertConfig{Config: cert.Config{CommonName: "kubernetes"}})
+	if err != nil {
+		t.Fatalf("fail to create the CA: %v", err)
+	}
+	return &vcpki.CrtKeyPair{Crt: crt, Key: key.(*rsa.PrivateKey)}
+}
+
+func TestInspect(t *testing.T) {
+	rootCA := newTestCA(t)
+	vc := &tenancyv1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "default"}}
+	apiserver, err := vcpki.NewAPIServerCrtAndKey(rootCA, vc, []string{"apiserver-svc"}, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("fail to create the apiserver certificate: %v", err)
+	}
+	adminKbCfg, err := kubeconfig.GenerateKubeconfig("admin", "vc", "apiserver-svc", []string{"system:masters"}, rootCA)
+	if err != nil {
+		t.Fatalf("fail to create the admin kubeconfig: %v", err)
+	}
+	foreign, err := vcpki.NewFrontProxyClientCertAndKey(newTestCA(t))
+	if err != nil {
+		t.Fatalf("fail to create the front proxy certificate: %v", err)
+	}
+
+	secrets := []corev1.Secret{
+		*secret.CrtKeyPairToSecret(secret.RootCASecretName, "ns", rootCA),
+		*secret.CrtKeyPairToSecret(secret.APIServerCASecretName, "ns", apiserver),
+		*secret.CrtKeyPairToSecret(secret.FrontProxyCASecretName, "ns", foreign),
+		*secret.KubeconfigToSecret(secret.AdminSecretName, "ns", adminKbCfg),
+	}
+
+	certs, err := Inspect(secrets, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[string]Certificate)
+	for _, c := range certs {
+		got[c.Secret+"/"+c.Key] = c
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected 5 certificates, got %v", certs)
+	}
+
+	api := got[secret.APIServerCASecretName+"/"+corev1.TLSCertKey]
+	if !api.Valid() || api.KeyType != "RSA-2048" || len(api.IPAddresses) != 1 || api.IPAddresses[0].String() != "10.0.0.1" {
+		t.Errorf("unexpected apiserver certificate %+v", api)
+	}
+	if root := got[secret.RootCASecretName+"/"+corev1.TLSCertKey]; !root.Valid() || !root.IsCA {
+		t.Errorf("unexpected root certificate %+v", root)
+	}
+	for _, key := range []string{
+		secret.AdminSecretName + ":users[admin].client-certificate-data",
+		secret.AdminSecretName + ":clusters[vc].certificate-authority-data",
+	} {
+		if c, ok := got[secret.AdminSecretName+"/"+key]; !ok || !c.Valid() {
+			t.Errorf("expected valid kubeconfig certificate %s, got %+v", key, c)
+		}
+	}
+	if proxy := got[secret.FrontProxyCASecretName+"/"+corev1.TLSCertKey]; proxy.Valid() {
+		t.Errorf("expected the certificate of another CA to be invalid")
+	}
+
+	expired, err := Inspect(secrets[:2], time.Now().Add(100*365*24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, c := range expired {
+		if c.Valid() {
+			t.Errorf("expected certificate %s/%s to be expired", c.Secret, c.Key)
+		}
+		if !c.ExpiresWithin(time.Now(), 100*365*24*time.Hour) {
+			t.Errorf("expected certificate %s/%s to expire within 100 years", c.Secret, c.Key)
+		}
+	}
+
+	if _, err := Inspect(secrets[1:], time.Now()); err == nil {
+		t.Errorf("expected an error without the root CA secret")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"k8s.io/client-go/util/cert"
 
@@ -54,8 +55,9 @@ type ClusterCAGroup struct {
 	ServiceAccountPrivateKey *rsa.PrivateKey
 }
 
-// NewAPIServerCrtAndKey creates crt and key for apiserver using ca.
-func NewAPIServerCrtAndKey(ca *CrtKeyPair, vc *tenancyv1alpha1.VirtualCluster, apiserverDomain string, apiserverIPs ...string) (*CrtKeyPair, error) {
+// NewAPIServerCrtAndKey creates crt and key for apiserver using ca, the first of the apiserverDomains
+// is the domain of the apiserver service, the others are e.g. the domains of the apiserver replicas.
+func NewAPIServerCrtAndKey(ca *CrtKeyPair, vc *tenancyv1alpha1.VirtualCluster, apiserverDomains []string, apiserverIPs ...string) (*CrtKeyPair, error) {
 	clusterDomain := defaultClusterDomain
 	if vc.Spec.ClusterDomain != "" {
 		clusterDomain = vc.Spec.ClusterDomain
@@ -68,11 +70,11 @@ func NewAPIServerCrtAndKey(ca *CrtKeyPair, vc *tenancyv1alpha1.VirtualCluster, a
 			"kubernetes.default",
 			"kubernetes.default.svc",
 			fmt.Sprintf("kubernetes.default.svc.%s", clusterDomain),
-			apiserverDomain,
-			// add virtual cluster name (i.e. namespace) for vn-agent
-			vc.Name,
 		},
 	}
+	altNames.DNSNames = append(altNames.DNSNames, apiserverDomains...)
+	// add virtual cluster name (i.e. namespace) for vn-agent
+	altNames.DNSNames = append(altNames.DNSNames, vc.Name)
 
 	if externalApiserverDomain, ok := vc.Labels[constants.LabelExternalApiserverDomain]; ok {
 		altNames.DNSNames = append(altNames.DNSNames, externalApiserverDomain)
@@ -210,14 +212,29 @@ func NewFrontProxyClientCertAndKey(ca *CrtKeyPair) (*CrtKeyPair, error) {
 	return &CrtKeyPair{frontProxyClientCert, rsaKey}, nil
 }
 
+// ClientCertConfig configures a client certificate, the apiserver authenticates it as the
+// user CommonName member of the Groups.
+type ClientCertConfig struct {
+	CommonName string
+	Groups     []string
+	// Validity of the certificate, pkiutil.CertificateValidity if not set
+	Validity time.Duration
+}
+
 // NewClientCrtAndKey creates crt-key pair for client
 func NewClientCrtAndKey(user string, ca *CrtKeyPair, groups []string) (*CrtKeyPair, error) {
+	return NewClientCrtAndKeyWithConfig(&ClientCertConfig{CommonName: user, Groups: groups}, ca)
+}
+
+// NewClientCrtAndKeyWithConfig creates crt-key pair for the client of cfg signed by ca
+func NewClientCrtAndKeyWithConfig(cfg *ClientCertConfig, ca *CrtKeyPair) (*CrtKeyPair, error) {
 	config := &pkiutil.CertConfig{
 		Config: cert.Config{
-			CommonName:   user,
-			Organization: groups,
+			CommonName:   cfg.CommonName,
+			Organization: cfg.Groups,
 			Usages:       []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 		},
+		Validity: cfg.Validity,
 	}
 
 	crt, key, err := pkiutil.NewCertAndKey(ca.Crt, ca.Key, config)
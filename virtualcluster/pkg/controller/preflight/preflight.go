@@ -0,0 +1,464 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preflight validates that the meta and super clusters meet the requirements of
+// the virtualcluster components, so that a missing API, permission or storage class is
+// reported before the components fail at runtime with obscure errors.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPass Status = "PASS"
+	// StatusWarn is a failed check the components can run without, e.g. an optional API.
+	StatusWarn Status = "WARN"
+	StatusFail Status = "FAIL"
+)
+
+// The names of the checks, used to skip them.
+const (
+	CheckAPIVersions    = "api-versions"
+	CheckRBAC           = "rbac"
+	CheckStorageClasses = "storage-classes"
+	CheckTenantNetwork  = "tenant-network"
+)
+
+const (
+	// DefaultDialTimeout is the default timeout of a connection to a tenant apiserver.
+	DefaultDialTimeout = 5 * time.Second
+
+	// defaultStorageClassAnnotation marks the storage class used by the PVCs without class.
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+)
+
+// DefaultSyncerPlugins are the resource plugins enabled by default in the syncer.
+var DefaultSyncerPlugins = []string{
+	"configmap", "endpoints", "event", "namespace", "node", "persistentvolume", "persistentvolumeclaim",
+	"pod", "secret", "service", "serviceaccount", "storageclass",
+}
+
+// requiredAPI is a resource a component can't run without, unless optional.
+type requiredAPI struct {
+	schema.GroupVersionResource
+	optional bool
+	// reason is reported when an optional API is missing.
+	reason string
+}
+
+var metaAPIs = []requiredAPI{
+	{GroupVersionResource: tenancyv1alpha1.SchemeGroupVersion.WithResource("virtualclusters")},
+	{GroupVersionResource: tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterversions")},
+	{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}},
+	{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}},
+	{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "services"}},
+	{GroupVersionResource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}},
+	{GroupVersionResource: schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}},
+	{GroupVersionResource: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}},
+}
+
+var superAPIs = []requiredAPI{
+	{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}},
+	{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "pods"}},
+	{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "services"}},
+	{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}},
+	{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}},
+	{GroupVersionResource: schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}},
+	{
+		GroupVersionResource: schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"},
+		optional:             true,
+		reason:               "the usage reporting and the usage of the summaries are unavailable",
+	},
+}
+
+// managerRules are the meta cluster permissions the manager can't provision a VirtualCluster without.
+var managerRules = []rbacv1.PolicyRule{
+	{APIGroups: []string{"tenancy.x-k8s.io"}, Resources: []string{"virtualclusters", "clusterversions"}, Verbs: []string{"get", "list", "watch", "update"}},
+	{APIGroups: []string{"tenancy.x-k8s.io"}, Resources: []string{"virtualclusters/status"}, Verbs: []string{"update", "patch"}},
+	{APIGroups: []string{"apps"}, Resources: []string{"statefulsets"}, Verbs: []string{"get", "list", "watch", "create", "update", "delete"}},
+	{APIGroups: []string{""}, Resources: []string{"namespaces", "secrets", "services", "configmaps"}, Verbs: []string{"get", "list", "watch", "create", "update", "delete"}},
+}
+
+// Result is the outcome of a check against a cluster.
+type Result struct {
+	Cluster string `json:"cluster"`
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report are the results of the checks of one or more clusters.
+type Report []Result
+
+// Failed returns true if a check of the report failed.
+func (r Report) Failed() bool {
+	for _, result := range r {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the report as a table.
+func (r Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLUSTER\tCHECK\tSTATUS\tMESSAGE")
+	for _, result := range r {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Cluster, result.Check, result.Status, result.Message)
+	}
+	return tw.Flush()
+}
+
+// Options configure the checks.
+type Options struct {
+	// Skip are the names of the checks not to run.
+	Skip []string
+	// ManagerServiceAccount is the service account of the vc-manager in the meta cluster, the
+	// access of the caller is checked if empty, e.g. by the vc-manager itself.
+	ManagerServiceAccount types.NamespacedName
+	// SyncerServiceAccount is the service account of the syncer in the super clusters.
+	SyncerServiceAccount types.NamespacedName
+	// SyncerPlugins are the resource plugins enabled in the syncer, their permissions are checked.
+	SyncerPlugins []string
+	// DialTimeout is the timeout of a connection to a tenant apiserver.
+	DialTimeout time.Duration
+}
+
+// DefaultOptions returns the options matching the default installation, config/setup/all_in_one.yaml.
+func DefaultOptions() Options {
+	return Options{
+		ManagerServiceAccount: types.NamespacedName{Namespace: "vc-manager", Name: "vc-manager"},
+		SyncerServiceAccount:  types.NamespacedName{Namespace: "vc-manager", Name: "vc-syncer"},
+		SyncerPlugins:         DefaultSyncerPlugins,
+		DialTimeout:           DefaultDialTimeout,
+	}
+}
+
+func (o Options) skipped(check string) bool {
+	for _, s := range o.Skip {
+		if s == check {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckMetaCluster validates the meta cluster the vc-manager runs in: the APIs, the permissions of
+// the manager, the storage classes of the etcd PVCs and the reachability of the tenant apiservers.
+func CheckMetaCluster(ctx context.Context, name string, client kubernetes.Interface, vcClient vcclient.Interface, opts Options) Report {
+	var report Report
+	if !opts.skipped(CheckAPIVersions) {
+		report = append(report, checkAPIs(name, client, metaAPIs)...)
+	}
+	if !opts.skipped(CheckRBAC) {
+		report = append(report, checkRBAC(ctx, name, client, opts.ManagerServiceAccount, managerRules))
+	}
+	if !opts.skipped(CheckStorageClasses) {
+		report = append(report, checkStorageClasses(ctx, name, client, vcClient))
+	}
+	if !opts.skipped(CheckTenantNetwork) {
+		report = append(report, checkTenantNetwork(ctx, name, client, vcClient, opts.DialTimeout))
+	}
+	return report
+}
+
+// CheckSuperCluster validates a super cluster the syncer runs in: the APIs and the permissions
+// of the syncer for the enabled plugins.
+func CheckSuperCluster(ctx context.Context, name string, client kubernetes.Interface, opts Options) Report {
+	var report Report
+	if !opts.skipped(CheckAPIVersions) {
+		report = append(report, checkAPIs(name, client, superAPIs)...)
+	}
+	if !opts.skipped(CheckRBAC) {
+		role := rbac.ClusterRole(rbac.DefaultClusterRoleName, &config.SyncerConfiguration{}, opts.SyncerPlugins, featuregate.DefaultFeatureGate)
+		report = append(report, checkRBAC(ctx, name, client, opts.SyncerServiceAccount, role.Rules))
+	}
+	return report
+}
+
+// checkAPIs returns a result per group version, the missing resources fail it.
+func checkAPIs(cluster string, client kubernetes.Interface, apis []requiredAPI) Report {
+	var versions []schema.GroupVersion
+	byVersion := make(map[schema.GroupVersion][]requiredAPI)
+	for _, api := range apis {
+		gv := api.GroupVersion()
+		if _, ok := byVersion[gv]; !ok {
+			versions = append(versions, gv)
+		}
+		byVersion[gv] = append(byVersion[gv], api)
+	}
+
+	var report Report
+	for _, gv := range versions {
+		result := Result{Cluster: cluster, Check: CheckAPIVersions, Status: StatusPass, Message: gv.String()}
+		served := sets.NewString()
+		list, err := client.Discovery().ServerResourcesForGroupVersion(gv.String())
+		if err != nil && !apierrors.IsNotFound(err) {
+			result.Status = StatusFail
+			result.Message = fmt.Sprintf("failed to discover %s: %v", gv, err)
+			report = append(report, result)
+			continue
+		}
+		if list != nil {
+			for _, r := range list.APIResources {
+				served.Insert(r.Name)
+			}
+		}
+
+		var missing, reasons []string
+		optional := true
+		for _, api := range byVersion[gv] {
+			if served.Has(api.Resource) {
+				continue
+			}
+			missing = append(missing, api.Resource)
+			optional = optional && api.optional
+			if api.reason != "" {
+				reasons = append(reasons, api.reason)
+			}
+		}
+		if len(missing) > 0 {
+			result.Status = StatusFail
+			if optional {
+				result.Status = StatusWarn
+			}
+			result.Message = fmt.Sprintf("%s does not serve %s", gv, strings.Join(missing, ", "))
+			if len(reasons) > 0 {
+				result.Message += ": " + strings.Join(reasons, ", ")
+			}
+		}
+		report = append(report, result)
+	}
+	return report
+}
+
+// checkRBAC reviews every verb of the rules for the service account, the denied ones fail it.
+// The access of the caller is reviewed if the service account is empty.
+func checkRBAC(ctx context.Context, cluster string, client kubernetes.Interface, sa types.NamespacedName, rules []rbacv1.PolicyRule) Result {
+	subject := fmt.Sprintf("service account %s", sa)
+	if sa.Name == "" {
+		subject = "the current identity"
+	}
+	result := Result{Cluster: cluster, Check: CheckRBAC, Status: StatusPass, Message: subject}
+	denied := sets.NewString()
+	for _, rule := range rules {
+		name := ""
+		if len(rule.ResourceNames) > 0 {
+			name = rule.ResourceNames[0]
+		}
+		for _, group := range rule.APIGroups {
+			for _, res := range rule.Resources {
+				attrs := authorizationv1.ResourceAttributes{Group: group, Resource: res, Name: name}
+				if parts := strings.SplitN(res, "/", 2); len(parts) == 2 {
+					attrs.Resource, attrs.Subresource = parts[0], parts[1]
+				}
+				for _, verb := range rule.Verbs {
+					attrs.Verb = verb
+					allowed, err := reviewAccess(ctx, client, sa, attrs)
+					if err != nil {
+						result.Status = StatusWarn
+						result.Message = fmt.Sprintf("failed to review the access of %s: %v", subject, err)
+						return result
+					}
+					if !allowed {
+						denied.Insert(fmt.Sprintf("%s %s", verb, schema.GroupResource{Group: group, Resource: res}))
+					}
+				}
+			}
+		}
+	}
+	if denied.Len() > 0 {
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("%s can't %s", subject, strings.Join(denied.List(), ", "))
+	}
+	return result
+}
+
+func reviewAccess(ctx context.Context, client kubernetes.Interface, sa types.NamespacedName, attrs authorizationv1.ResourceAttributes) (bool, error) {
+	if sa.Name == "" {
+		review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return false, err
+		}
+		return review.Status.Allowed, nil
+	}
+	review, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               serviceaccount.MakeUsername(sa.Namespace, sa.Name),
+			Groups:             serviceaccount.MakeGroupNames(sa.Namespace),
+			ResourceAttributes: &attrs,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
+// checkStorageClasses verifies the storage classes of the etcd volume claim templates of the
+// ClusterVersions exist, or that a default storage class exists for the templates without class.
+func checkStorageClasses(ctx context.Context, cluster string, client kubernetes.Interface, vcClient vcclient.Interface) Result {
+	result := Result{Cluster: cluster, Check: CheckStorageClasses, Status: StatusPass}
+	cvs, err := vcClient.TenancyV1alpha1().ClusterVersions().List(metav1.ListOptions{})
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("failed to list the clusterversions: %v", err)
+		return result
+	}
+	classes, err := client.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("failed to list the storage classes: %v", err)
+		return result
+	}
+	existing, defaultClass := sets.NewString(), ""
+	for _, sc := range classes.Items {
+		existing.Insert(sc.Name)
+		if sc.Annotations[defaultStorageClassAnnotation] == "true" {
+			defaultClass = sc.Name
+		}
+	}
+
+	var problems []string
+	checked := 0
+	for _, cv := range cvs.Items {
+		if cv.Spec.ETCD == nil || cv.Spec.ETCD.StatefulSet == nil {
+			continue
+		}
+		for _, pvc := range cv.Spec.ETCD.StatefulSet.Spec.VolumeClaimTemplates {
+			checked++
+			switch class := pvc.Spec.StorageClassName; {
+			case class == nil && defaultClass == "":
+				problems = append(problems, fmt.Sprintf("clusterversion %s uses the default storage class, there is none", cv.Name))
+			case class != nil && *class != "" && !existing.Has(*class):
+				problems = append(problems, fmt.Sprintf("clusterversion %s uses the missing storage class %s", cv.Name, *class))
+			}
+		}
+	}
+	switch {
+	case len(problems) > 0:
+		result.Status = StatusFail
+		result.Message = strings.Join(problems, "; ")
+	case checked == 0:
+		result.Message = "no clusterversion stores etcd in a volume"
+	default:
+		result.Message = fmt.Sprintf("%d etcd volume claim templates", checked)
+	}
+	return result
+}
+
+// checkTenantNetwork dials the apiserver of every running VirtualCluster. The unreachable ones
+// only warn, they shouldn't keep the components of the other VirtualClusters from starting.
+func checkTenantNetwork(ctx context.Context, cluster string, client kubernetes.Interface, vcClient vcclient.Interface, timeout time.Duration) Result {
+	result := Result{Cluster: cluster, Check: CheckTenantNetwork, Status: StatusPass}
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+	vcs, err := vcClient.TenancyV1alpha1().VirtualClusters(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("failed to list the virtualclusters: %v", err)
+		return result
+	}
+
+	var unreachable []string
+	checked := 0
+	for i := range vcs.Items {
+		vc := &vcs.Items[i]
+		if vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+			continue
+		}
+		checked++
+		address, err := tenantAddress(client, vc)
+		if err == nil {
+			var conn net.Conn
+			dialer := &net.Dialer{Timeout: timeout}
+			if conn, err = dialer.DialContext(ctx, "tcp", address); err == nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("%s/%s: %v", vc.Namespace, vc.Name, err))
+		}
+	}
+	sort.Strings(unreachable)
+	switch {
+	case len(unreachable) > 0:
+		result.Status = StatusWarn
+		result.Message = fmt.Sprintf("%d of %d tenant apiservers are unreachable: %s", len(unreachable), checked, strings.Join(unreachable, "; "))
+	case checked == 0:
+		result.Message = "no running virtualcluster"
+	default:
+		result.Message = fmt.Sprintf("%d tenant apiservers are reachable", checked)
+	}
+	return result
+}
+
+// tenantAddress returns the host:port of the tenant apiserver in the admin kubeconfig of vc.
+func tenantAddress(client kubernetes.Interface, vc *tenancyv1alpha1.VirtualCluster) (string, error) {
+	kubeconfig, err := conversion.GetKubeConfigOfVC(client.CoreV1(), vc)
+	if err != nil {
+		return "", err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(restConfig.Host)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "http" {
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	}
+	return net.JoinHostPort(u.Hostname(), "443"), nil
+}
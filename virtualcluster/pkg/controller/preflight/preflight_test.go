@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func TestCheckAPIs(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "namespaces"}, {Name: "pods"}, {Name: "services"}, {Name: "configmaps"}}},
+		{GroupVersion: "coordination.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "leases"}}},
+		{GroupVersion: "metrics.k8s.io/v1beta1", APIResources: []metav1.APIResource{{Name: "nodes"}}},
+	}
+
+	report := CheckSuperCluster(context.TODO(), "super", client, Options{Skip: []string{CheckRBAC}})
+	expected := map[string]Status{
+		"v1":                     StatusFail,
+		"coordination.k8s.io/v1": StatusPass,
+		"metrics.k8s.io/v1beta1": StatusWarn,
+	}
+	if len(report) != len(expected) {
+		t.Fatalf("expected a result per group version, got %+v", report)
+	}
+	for _, r := range report {
+		gv := strings.SplitN(r.Message, " ", 2)[0]
+		if r.Status != expected[gv] {
+			t.Errorf("expected %s to be %s, got %+v", gv, expected[gv], r)
+		}
+	}
+	if !strings.Contains(report[0].Message, "secrets") {
+		t.Errorf("expected the missing resources to be reported, got %q", report[0].Message)
+	}
+	if !report.Failed() {
+		t.Errorf("expected the report to fail")
+	}
+}
+
+func TestCheckRBAC(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var users []string
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		users = append(users, sar.Spec.User)
+		attrs := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = !(attrs.Resource == "pods" && attrs.Verb == "delete")
+		return true, sar, nil
+	})
+
+	report := CheckSuperCluster(context.TODO(), "super", client, Options{
+		Skip:                 []string{CheckAPIVersions},
+		SyncerServiceAccount: DefaultOptions().SyncerServiceAccount,
+		SyncerPlugins:        []string{"pod"},
+	})
+	if len(report) != 1 || report[0].Status != StatusFail || report[0].Message != "service account vc-manager/vc-syncer can't delete pods" {
+		t.Errorf("expected the denied verbs to fail, got %+v", report)
+	}
+	if len(users) == 0 || users[0] != "system:serviceaccount:vc-manager:vc-syncer" {
+		t.Errorf("expected the access of the service account to be reviewed, got %v", users)
+	}
+
+	self := fake.NewSimpleClientset()
+	self.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+	report = CheckMetaCluster(context.TODO(), "meta", self, vcfake.NewSimpleClientset(),
+		Options{Skip: []string{CheckAPIVersions, CheckStorageClasses, CheckTenantNetwork}})
+	if len(report) != 1 || report[0].Status != StatusPass || report[0].Message != "the current identity" {
+		t.Errorf("expected the access of the caller to be reviewed without service account, got %+v", report)
+	}
+
+	denied := fake.NewSimpleClientset()
+	denied.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("forbidden")
+	})
+	report = CheckSuperCluster(context.TODO(), "super", denied, Options{Skip: []string{CheckAPIVersions}, SyncerPlugins: []string{"pod"}})
+	if len(report) != 1 || report[0].Status != StatusWarn {
+		t.Errorf("expected a review failure to warn, got %+v", report)
+	}
+}
+
+func etcdClusterVersion(name string, class *string) *tenancyv1alpha1.ClusterVersion {
+	return &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: tenancyv1alpha1.ClusterVersionSpec{
+			ETCD: &tenancyv1alpha1.StatefulSetSvcBundle{
+				StatefulSet: &appsv1.StatefulSet{
+					Spec: appsv1.StatefulSetSpec{
+						VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: class}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCheckStorageClasses(t *testing.T) {
+	fast, missing := "fast", "missing"
+	for _, tc := range []struct {
+		name    string
+		objects []runtime.Object
+		cvs     []runtime.Object
+		status  Status
+	}{
+		{
+			name:   "no clusterversion",
+			status: StatusPass,
+		},
+		{
+			name:    "existing class",
+			objects: []runtime.Object{&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fast"}}},
+			cvs:     []runtime.Object{etcdClusterVersion("cv", &fast)},
+			status:  StatusPass,
+		},
+		{
+			name:    "missing class",
+			objects: []runtime.Object{&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fast"}}},
+			cvs:     []runtime.Object{etcdClusterVersion("cv", &missing)},
+			status:  StatusFail,
+		},
+		{
+			name: "default class",
+			objects: []runtime.Object{&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+				Name:        "standard",
+				Annotations: map[string]string{defaultStorageClassAnnotation: "true"},
+			}}},
+			cvs:    []runtime.Object{etcdClusterVersion("cv", nil)},
+			status: StatusPass,
+		},
+		{
+			name:   "no default class",
+			cvs:    []runtime.Object{etcdClusterVersion("cv", nil)},
+			status: StatusFail,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			report := CheckMetaCluster(context.TODO(), "meta", fake.NewSimpleClientset(tc.objects...), vcfake.NewSimpleClientset(tc.cvs...),
+				Options{Skip: []string{CheckAPIVersions, CheckRBAC, CheckTenantNetwork}})
+			if len(report) != 1 || report[0].Status != tc.status {
+				t.Errorf("expected %s, got %+v", tc.status, report)
+			}
+		})
+	}
+}
+
+func TestCheckTenantNetwork(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	running := func(name, address string) (*tenancyv1alpha1.VirtualCluster, *corev1.Secret) {
+		vc := &tenancyv1alpha1.VirtualCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tenant-1", UID: types.UID("uid-" + name)},
+			Status:     tenancyv1alpha1.VirtualClusterStatus{Phase: tenancyv1alpha1.ClusterRunning},
+		}
+		kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: tenant
+  cluster:
+    server: https://%s
+contexts:
+- name: admin
+  context:
+    cluster: tenant
+    user: admin
+current-context: admin
+users:
+- name: admin
+`, address)
+		return vc, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: constants.KubeconfigAdminSecretName, Namespace: conversion.ToClusterKey(vc)},
+			Data:       map[string][]byte{constants.KubeconfigAdminSecretName: []byte(kubeconfig)},
+		}
+	}
+	up, upSecret := running("up", listener.Addr().String())
+	down, downSecret := running("down", closed.Addr().String())
+	pending := &tenancyv1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "tenant-1"}}
+
+	opts := Options{Skip: []string{CheckAPIVersions, CheckRBAC, CheckStorageClasses}}
+	report := CheckMetaCluster(context.TODO(), "meta", fake.NewSimpleClientset(upSecret), vcfake.NewSimpleClientset(up, pending), opts)
+	if len(report) != 1 || report[0].Status != StatusPass || report[0].Message != "1 tenant apiservers are reachable" {
+		t.Errorf("expected the running tenant apiserver to be reachable, got %+v", report)
+	}
+
+	report = CheckMetaCluster(context.TODO(), "meta", fake.NewSimpleClientset(upSecret, downSecret), vcfake.NewSimpleClientset(up, down), opts)
+	if len(report) != 1 || report[0].Status != StatusWarn || !strings.Contains(report[0].Message, "tenant-1/down") {
+		t.Errorf("expected the unreachable tenant apiserver to warn, got %+v", report)
+	}
+	if report.Failed() {
+		t.Errorf("expected unreachable tenant apiservers not to fail the report")
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package presets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// LabelControlPlaneComponent is the label of the kubeadm static pods naming their component.
+const LabelControlPlaneComponent = "component"
+
+// nonPortableFlags are the flags bound to the node or the layout of a control plane, they are
+// set by the ClusterVersion the generated one is based on. The flags ending with - are prefixes.
+var nonPortableFlags = []string{
+	"advertise-address", "bind-address", "secure-port", "etcd-servers", "apiserver-count",
+	"name", "data-dir", "listen-", "initial-", "advertise-client-urls",
+	"kubeconfig", "authentication-kubeconfig", "authorization-kubeconfig", "leader-elect",
+}
+
+// Generate returns base, named name, approximating the running control plane whose kubeadm static
+// pods are given: the images of the etcd, apiserver and controller-manager containers are the ones
+// of the pods, and the flags of the pods are set on the containers. The flags bound to the nodes
+// or to files of the pods are not portable, they are returned along with the components without
+// a pod, for review.
+func Generate(base *tenancyv1alpha1.ClusterVersion, name string, pods []corev1.Pod) (*tenancyv1alpha1.ClusterVersion, []string, error) {
+	cv := base.DeepCopy()
+	cv.Name = name
+	cv.ResourceVersion = ""
+	cv.UID = ""
+	delete(cv.Labels, LabelPreset)
+	delete(cv.Annotations, AnnotationDescription)
+
+	byComponent := map[string]*corev1.Pod{}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	for i := range pods {
+		component := pods[i].Labels[LabelControlPlaneComponent]
+		if _, ok := byComponent[component]; !ok && len(pods[i].Spec.Containers) > 0 {
+			byComponent[component] = &pods[i]
+		}
+	}
+
+	var skipped []string
+	found := 0
+	for _, c := range []struct {
+		component string
+		bundle    *tenancyv1alpha1.StatefulSetSvcBundle
+	}{
+		{"etcd", cv.Spec.ETCD},
+		{"kube-apiserver", cv.Spec.APIServer},
+		{"kube-controller-manager", cv.Spec.ControllerManager},
+	} {
+		if c.bundle == nil || c.bundle.StatefulSet == nil || len(c.bundle.StatefulSet.Spec.Template.Spec.Containers) == 0 {
+			return nil, nil, fmt.Errorf("clusterversion %s has no %s container", base.Name, c.component)
+		}
+		pod, ok := byComponent[c.component]
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("%s: no pod found, kept as in %s", c.component, base.Name))
+			continue
+		}
+		found++
+		skipped = append(skipped, mergeContainer(c.component, &c.bundle.StatefulSet.Spec.Template.Spec.Containers[0], &pod.Spec.Containers[0])...)
+	}
+	if found == 0 {
+		return nil, nil, fmt.Errorf("no etcd, kube-apiserver or kube-controller-manager pod found")
+	}
+	return cv, skipped, nil
+}
+
+// mergeContainer sets the image and the portable flags of live on container, it returns the flags
+// that were not set.
+func mergeContainer(component string, container, live *corev1.Container) []string {
+	container.Image = live.Image
+
+	var skipped []string
+	for _, arg := range append(append([]string{}, live.Command...), live.Args...) {
+		if !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		key := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)[0]
+		value := strings.TrimPrefix(strings.TrimPrefix(arg, "--"+key), "=")
+		switch {
+		case !portableFlag(key):
+			skipped = append(skipped, fmt.Sprintf("%s: %s is bound to the node", component, arg))
+		case strings.HasPrefix(value, "/"):
+			skipped = append(skipped, fmt.Sprintf("%s: %s is a file of the node", component, arg))
+		default:
+			setFlag(container, key, arg)
+		}
+	}
+	return skipped
+}
+
+func portableFlag(key string) bool {
+	for _, flag := range nonPortableFlags {
+		if key == flag || strings.HasSuffix(flag, "-") && strings.HasPrefix(key, flag) {
+			return false
+		}
+	}
+	return true
+}
+
+// setFlag replaces the flag key of the container arguments with arg, or appends arg.
+func setFlag(container *corev1.Container, key, arg string) {
+	for i, existing := range container.Args {
+		if existing == "--"+key || strings.HasPrefix(existing, "--"+key+"=") {
+			container.Args[i] = arg
+			return
+		}
+	}
+	container.Args = append(container.Args, arg)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package presets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func staticPod(component, image string, command ...string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      component + "-node-1",
+			Namespace: metav1.NamespaceSystem,
+			Labels:    map[string]string{LabelControlPlaneComponent: component, "tier": "control-plane"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: component, Image: image, Command: command}},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	base, err := Get("small-dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pods := []corev1.Pod{
+		staticPod("etcd", "k8s.gcr.io/etcd:3.5.0-0", "etcd",
+			"--advertise-client-urls=https://192.168.1.10:2379",
+			"--cert-file=/etc/kubernetes/pki/etcd/server.crt",
+			"--client-cert-auth=true",
+			"--snapshot-count=10000"),
+		staticPod("kube-apiserver", "k8s.gcr.io/kube-apiserver:v1.23.4", "kube-apiserver",
+			"--advertise-address=192.168.1.10",
+			"--etcd-servers=https://127.0.0.1:2379",
+			"--service-cluster-ip-range=10.96.0.0/12",
+			"--enable-admission-plugins=NodeRestriction",
+			"--tls-cert-file=/etc/kubernetes/pki/apiserver.crt",
+			"--audit-log-maxage=30"),
+	}
+
+	cv, skipped, err := Generate(base, "cv-live", pods)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cv.Name != "cv-live" || cv.Labels[LabelPreset] != "" || cv.Annotations[AnnotationDescription] != "" {
+		t.Errorf("expected clusterversion cv-live not labeled as preset, got %s %v %v", cv.Name, cv.Labels, cv.Annotations)
+	}
+	if base.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers[0].Image != "k8s.gcr.io/kube-apiserver:v1.22.13" {
+		t.Errorf("expected the base not to be changed")
+	}
+
+	etcd := cv.Spec.ETCD.StatefulSet.Spec.Template.Spec.Containers[0]
+	if etcd.Image != "k8s.gcr.io/etcd:3.5.0-0" {
+		t.Errorf("expected the etcd image of the pod, got %s", etcd.Image)
+	}
+	if !contains(etcd.Args, "--client-cert-auth=true") || contains(etcd.Args, "--client-cert-auth") || !contains(etcd.Args, "--snapshot-count=10000") {
+		t.Errorf("expected the etcd flags to be merged, got %v", etcd.Args)
+	}
+	if !contains(etcd.Args, "--cert-file=/etc/kubernetes/pki/etcd/tls.crt") || !contains(etcd.Args, "--advertise-client-urls=https://$(HOSTNAME).etcd:2379") {
+		t.Errorf("expected the etcd flags bound to the node to be kept, got %v", etcd.Args)
+	}
+
+	apiserver := cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers[0]
+	if apiserver.Image != "k8s.gcr.io/kube-apiserver:v1.23.4" {
+		t.Errorf("expected the apiserver image of the pod, got %s", apiserver.Image)
+	}
+	for _, arg := range []string{"--service-cluster-ip-range=10.96.0.0/12", "--enable-admission-plugins=NodeRestriction", "--audit-log-maxage=30", "--etcd-servers=https://etcd-0.etcd:2379"} {
+		if !contains(apiserver.Args, arg) {
+			t.Errorf("expected apiserver flag %s, got %v", arg, apiserver.Args)
+		}
+	}
+	if !reflect.DeepEqual(apiserver.Command, []string{"kube-apiserver"}) {
+		t.Errorf("expected the apiserver command to be kept, got %v", apiserver.Command)
+	}
+
+	controllerManager := cv.Spec.ControllerManager.StatefulSet.Spec.Template.Spec.Containers[0]
+	if controllerManager.Image != "k8s.gcr.io/kube-controller-manager:v1.22.13" {
+		t.Errorf("expected the controller-manager of the base, got %s", controllerManager.Image)
+	}
+
+	expectedSkipped := []string{
+		"etcd: --advertise-client-urls=https://192.168.1.10:2379 is bound to the node",
+		"etcd: --cert-file=/etc/kubernetes/pki/etcd/server.crt is a file of the node",
+		"kube-apiserver: --advertise-address=192.168.1.10 is bound to the node",
+		"kube-apiserver: --etcd-servers=https://127.0.0.1:2379 is bound to the node",
+		"kube-apiserver: --tls-cert-file=/etc/kubernetes/pki/apiserver.crt is a file of the node",
+		"kube-controller-manager: no pod found, kept as in small-dev",
+	}
+	if !reflect.DeepEqual(skipped, expectedSkipped) {
+		t.Errorf("expected skipped %q, got %q", expectedSkipped, skipped)
+	}
+
+	if _, _, err := Generate(base, "cv-live", nil); err == nil || !strings.Contains(err.Error(), "no etcd") {
+		t.Errorf("expected an error without pods, got %v", err)
+	}
+}
+
+func contains(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
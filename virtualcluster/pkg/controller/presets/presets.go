@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package presets embeds the built-in ClusterVersions, so that VirtualClusters
+// can reference them without authoring a ClusterVersion first.
+package presets
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/scheme"
+)
+
+const (
+	// LabelPreset is set on the ClusterVersions of the presets to the preset name
+	LabelPreset = "tenancy.x-k8s.io/preset"
+	// AnnotationDescription describes what a preset is meant for
+	AnnotationDescription = "tenancy.x-k8s.io/preset-description"
+
+	manifestsDir = "manifests"
+)
+
+//go:embed manifests/*.yaml
+var manifests embed.FS
+
+// Names returns the sorted names of the presets
+func Names() []string {
+	entries, err := manifests.ReadDir(manifestsDir)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has returns whether name is a preset
+func Has(name string) bool {
+	_, err := Manifest(name)
+	return err == nil
+}
+
+// Manifest returns the ClusterVersion yaml of the named preset, to be exported and customized
+func Manifest(name string) ([]byte, error) {
+	if name == "" || strings.ContainsAny(name, "/.") {
+		return nil, fmt.Errorf("preset %q is not found", name)
+	}
+	data, err := manifests.ReadFile(path.Join(manifestsDir, name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("preset %q is not found, the presets are %s", name, strings.Join(Names(), ", "))
+	}
+	return data, nil
+}
+
+// Export returns the yaml of the named preset as a ClusterVersion named name, keeping the comments
+// of the manifest. A renamed preset is no longer labeled as a preset since it is meant to be
+// customized. The name of the preset is kept if name is empty.
+func Export(preset, name string) ([]byte, error) {
+	data, err := Manifest(preset)
+	if err != nil || name == "" || name == preset {
+		return data, err
+	}
+	header := fmt.Sprintf("metadata:\n  name: %s\n  labels:\n    %s: %s\n", preset, LabelPreset, preset)
+	if !bytes.Contains(data, []byte(header)) {
+		return nil, fmt.Errorf("preset %q can't be renamed", preset)
+	}
+	return bytes.Replace(data, []byte(header), []byte(fmt.Sprintf("metadata:\n  name: %s\n", name)), 1), nil
+}
+
+// Get returns the ClusterVersion of the named preset
+func Get(name string) (*tenancyv1alpha1.ClusterVersion, error) {
+	data, err := Manifest(name)
+	if err != nil {
+		return nil, err
+	}
+	cv := &tenancyv1alpha1.ClusterVersion{}
+	codecs := serializer.NewCodecFactory(scheme.Scheme)
+	if err := runtime.DecodeInto(codecs.UniversalDecoder(), data, cv); err != nil {
+		return nil, fmt.Errorf("failed to decode preset %q: %v", name, err)
+	}
+	return cv, nil
+}
+
+// Install creates the ClusterVersions of the presets that don't exist, the existing ones are
+// left untouched so that they can be customized
+func Install(ctx context.Context, cli client.Client, log logr.Logger) error {
+	for _, name := range Names() {
+		cv, err := Get(name)
+		if err != nil {
+			return err
+		}
+		err = cli.Create(ctx, cv)
+		switch {
+		case err == nil:
+			log.Info("installed the clusterversion preset", "name", name)
+		case apierrors.IsAlreadyExists(err):
+		default:
+			return fmt.Errorf("failed to install the clusterversion preset %q: %v", name, err)
+		}
+	}
+	return nil
+}
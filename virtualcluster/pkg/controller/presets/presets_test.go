@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package presets
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/scheme"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner"
+)
+
+func TestPresets(t *testing.T) {
+	if names, expected := Names(), []string{"ha-production", "k3s-lite", "small-dev"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected presets %v, got %v", expected, names)
+	}
+
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"},
+	}
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			cv, err := Get(name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cv.Name != name || cv.Labels[LabelPreset] != name {
+				t.Errorf("expected clusterversion %s labeled as preset, got %s %v", name, cv.Name, cv.Labels)
+			}
+			if cv.Annotations[AnnotationDescription] == "" {
+				t.Errorf("expected a description")
+			}
+			if replicas := cv.Spec.ETCD.StatefulSet.Spec.Replicas; replicas == nil || *replicas%2 != 1 {
+				t.Errorf("expected an odd number of etcd replicas, got %v", replicas)
+			}
+
+			objs, err := provisioner.RenderControlPlane(vc, cv, provisioner.RenderOptions{})
+			if err != nil {
+				t.Fatalf("failed to render the control plane: %v", err)
+			}
+			var statefulsets int
+			for _, obj := range objs {
+				if _, ok := obj.(*appsv1.StatefulSet); ok {
+					statefulsets++
+				}
+			}
+			if statefulsets != 3 {
+				t.Errorf("expected 3 statefulsets, got %d", statefulsets)
+			}
+		})
+	}
+
+	if _, err := Get("unknown"); err == nil || !strings.Contains(err.Error(), "small-dev") {
+		t.Errorf("expected an error listing the presets, got %v", err)
+	}
+	if Has("../presets") {
+		t.Errorf("expected paths not to be presets")
+	}
+}
+
+func TestExport(t *testing.T) {
+	manifest, _ := Manifest("small-dev")
+	exported, err := Export("small-dev", "")
+	if err != nil || string(exported) != string(manifest) {
+		t.Errorf("expected the manifest to be exported as is, got %v", err)
+	}
+
+	exported, err = Export("small-dev", "cv-dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(exported), "# a statefulset and service bundle for etcd") {
+		t.Errorf("expected the comments to be kept")
+	}
+	cv := &tenancyv1alpha1.ClusterVersion{}
+	if err := runtime.DecodeInto(serializer.NewCodecFactory(scheme.Scheme).UniversalDecoder(), exported, cv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cv.Name != "cv-dev" || cv.Labels[LabelPreset] != "" {
+		t.Errorf("expected clusterversion cv-dev not labeled as preset, got %s %v", cv.Name, cv.Labels)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	customized, _ := Get("small-dev")
+	customized.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers[0].Image = "registry.local/kube-apiserver:v1.22.13"
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(customized).Build()
+
+	if err := Install(context.TODO(), cli, ctrl.Log.WithName("test")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range Names() {
+		cv := &tenancyv1alpha1.ClusterVersion{}
+		if err := cli.Get(context.TODO(), client.ObjectKey{Name: name}, cv); err != nil {
+			t.Errorf("expected preset %s to be installed, got %v", name, err)
+		}
+		if image := cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers[0].Image; name == "small-dev" && image != "registry.local/kube-apiserver:v1.22.13" {
+			t.Errorf("expected the customized preset to be kept, got image %s", image)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReferencedSecretNames returns the sorted names of the secrets mounted as volumes or
+// referenced in the environment of the containers of the pod spec
+func ReferencedSecretNames(spec *corev1.PodSpec) []string {
+	names := sets.NewString()
+	for _, v := range spec.Volumes {
+		if v.Secret != nil {
+			names.Insert(v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.Secret != nil {
+					names.Insert(src.Secret.Name)
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, env := range c.EnvFrom {
+			if env.SecretRef != nil {
+				names.Insert(env.SecretRef.Name)
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				names.Insert(env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	names.Delete("")
+	return names.List()
+}
+
+// Checksum returns the sha256 checksum of the names and data of the secrets
+func Checksum(secrets []corev1.Secret) string {
+	sorted := make([]corev1.Secret, len(secrets))
+	copy(sorted, secrets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	h := sha256.New()
+	for _, srt := range sorted {
+		writeField(h, []byte(srt.Name))
+		keys := make([]string, 0, len(srt.Data))
+		for k := range srt.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		binary.Write(h, binary.BigEndian, uint64(len(keys))) // #nosec G104 hash writes never fail
+		for _, k := range keys {
+			writeField(h, []byte(k))
+			writeField(h, srt.Data[k])
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// writeField writes the length prefixed field to h, so that the boundaries of the fields are
+// part of the checksum
+func writeField(h hash.Hash, field []byte) {
+	binary.Write(h, binary.BigEndian, uint64(len(field))) // #nosec G104 hash writes never fail
+	h.Write(field)
+}
+
+// PodSpecChecksum reads the secrets referenced by the pod spec in namespace and returns their
+// checksum, the secrets that don't exist are left out
+func PodSpecChecksum(ctx context.Context, reader client.Reader, namespace string, spec *corev1.PodSpec) (string, error) {
+	var secrets []corev1.Secret
+	for _, name := range ReferencedSecretNames(spec) {
+		srt := corev1.Secret{}
+		err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &srt)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		secrets = append(secrets, srt)
+	}
+	return Checksum(secrets), nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newSecret(name string, data map[string]string) corev1.Secret {
+	srt := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vc-root", Name: name},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		srt.Data[k] = []byte(v)
+	}
+	return srt
+}
+
+func TestReferencedSecretNames(t *testing.T) {
+	spec := &corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "root-ca", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: RootCASecretName}}},
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+			{Name: "projected", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "encryption-config"}}}},
+			}}},
+		},
+		InitContainers: []corev1.Container{{
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "init-env"}}}},
+		}},
+		Containers: []corev1.Container{{
+			Env: []corev1.EnvVar{
+				{Name: "PLAIN", Value: "value"},
+				{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: RootCASecretName}}}},
+			},
+		}},
+	}
+
+	got := ReferencedSecretNames(spec)
+	want := []string{"encryption-config", "init-env", RootCASecretName}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	a := newSecret("a", map[string]string{"tls.crt": "crt", "tls.key": "key"})
+	b := newSecret("b", map[string]string{"config": "aescbc"})
+
+	sum := Checksum([]corev1.Secret{a, b})
+	if sum != Checksum([]corev1.Secret{b, a}) {
+		t.Errorf("expected the checksum not to depend on the order of the secrets")
+	}
+
+	rotated := newSecret("a", map[string]string{"tls.crt": "crt2", "tls.key": "key"})
+	if sum == Checksum([]corev1.Secret{rotated, b}) {
+		t.Errorf("expected the checksum to change when a secret changes")
+	}
+
+	shifted := newSecret("a", map[string]string{"tls.crt": "crtt", "ls.key": "key"})
+	if Checksum([]corev1.Secret{a}) == Checksum([]corev1.Secret{shifted}) {
+		t.Errorf("expected the checksum to depend on the key boundaries")
+	}
+}
+
+func TestPodSpecChecksum(t *testing.T) {
+	a := newSecret("a", map[string]string{"tls.crt": "crt"})
+	cli := fake.NewClientBuilder().WithObjects(&a).Build()
+	spec := &corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "a", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "a"}}},
+			{Name: "missing", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "missing"}}},
+		},
+	}
+
+	got, err := PodSpecChecksum(context.TODO(), cli, "vc-root", spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := Checksum([]corev1.Secret{a}); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
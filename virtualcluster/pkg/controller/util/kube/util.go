@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -72,51 +73,186 @@ func GetSvcClusterIP(cli client.Client, namespace, name string) (string, error)
 // WaitStatefulSetReady checks if the statefulset 'namespace/name' can be ready within
 // the 'timeout'
 func WaitStatefulSetReady(cli client.Client, namespace, name string, timeOutSec, periodSec int64) error {
-	timeOut := time.After(time.Duration(timeOutSec) * time.Second)
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Duration(timeOutSec)*time.Second)
+	defer cancel()
+	return WaitStatefulSetReadyContext(ctx, cli, namespace, name, time.Duration(periodSec)*time.Second)
+}
+
+// WaitStatefulSetReadyContext checks every period if the statefulset 'namespace/name' is
+// ready until ctx is done. Every caller owns its timer, so that the statefulsets of
+// concurrently provisioned virtualclusters are waited for independently.
+func WaitStatefulSetReadyContext(ctx context.Context, cli client.Client, namespace, name string, period time.Duration) error {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
 	for {
-		period := time.After(time.Duration(periodSec) * time.Second)
 		select {
-		case <-timeOut:
-			return fmt.Errorf("%s/%s is not ready in %d seconds", namespace, name, timeOutSec)
-		case <-period:
+		case <-ctx.Done():
+			if deadline, ok := ctx.Deadline(); ok {
+				return fmt.Errorf("%s/%s is not ready before %s: %v", namespace, name, deadline.Format(time.RFC3339), ctx.Err())
+			}
+			return fmt.Errorf("%s/%s is not ready: %v", namespace, name, ctx.Err())
+		case <-ticker.C:
 			sts := &appsv1.StatefulSet{}
-			if err := cli.Get(context.TODO(), types.NamespacedName{
+			if err := cli.Get(ctx, types.NamespacedName{
 				Namespace: namespace,
 				Name:      name,
 			}, sts); err != nil {
 				return err
 			}
 
-			if sts.Status.ReadyReplicas == *sts.Spec.Replicas {
+			replicas := int32(1)
+			if sts.Spec.Replicas != nil {
+				replicas = *sts.Spec.Replicas
+			}
+			if sts.Status.ReadyReplicas == replicas {
 				return nil
 			}
 		}
 	}
 }
 
-// CreateRootNS creates the root namespace for the vc
+// RootNSQuotaName is the name of the ResourceQuota created in the root namespace
+// from the spec of the vc
+const RootNSQuotaName = "virtualcluster-root"
+
+// vcAnnotationPrefix prefixes the annotations identifying the vc of a root namespace.
+const vcAnnotationPrefix = "tenancy.x-k8s.io/vc"
+
+// CreateRootNS creates the root namespace for the vc, or adopts the pre-created
+// one referenced by the vc, and applies the labels, annotations and resource
+// quota of the vc spec to it
 func CreateRootNS(cli client.Client, vc *tenancyv1alpha1.VirtualCluster) (string, error) {
-	nsName := conversion.ToClusterKey(vc)
-	namespace := &corev1.Namespace{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: nsName,
-			Annotations: map[string]string{
-				constants.LabelVCName:      vc.Name,
-				constants.LabelVCNamespace: vc.Namespace,
-				constants.LabelVCUID:       string(vc.UID),
-				constants.LabelVCRootNS:    "true",
+	nsName := conversion.ToRootNamespace(vc)
+	rootNS := vc.Spec.RootNamespace
+	if rootNS == nil {
+		rootNS = &tenancyv1alpha1.RootNamespaceSpec{}
+	}
+
+	var err error
+	if rootNS.Name != "" {
+		err = adoptRootNS(cli, vc, rootNS)
+	} else {
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        nsName,
+				Annotations: rootNSAnnotations(vc, rootNS),
 			},
-		},
+		}
+		for k, v := range rootNS.Labels {
+			if namespace.Labels == nil {
+				namespace.Labels = make(map[string]string, len(rootNS.Labels))
+			}
+			namespace.Labels[k] = v
+		}
+		namespace.Annotations[constants.LabelVCRootNS] = "true"
+
+		if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterLabelling) {
+			namespace.SetLabels(conversion.WithSuperClusterLabels(namespace.GetLabels()))
+		}
+		err = cli.Create(context.TODO(), namespace)
+		if apierrors.IsAlreadyExists(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nsName, err
 	}
+	return nsName, applyRootNSQuota(cli, nsName, rootNS.ResourceQuota)
+}
 
-	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterLabelling) {
-		namespace.SetLabels(conversion.WithSuperClusterLabels(namespace.GetLabels()))
+// rootNSAnnotations returns the annotations of the root namespace. The annotations of the vc spec
+// identifying a vc or the owner of a namespace are dropped, so that a tenant can't forge the
+// ownership of its root namespace.
+func rootNSAnnotations(vc *tenancyv1alpha1.VirtualCluster, rootNS *tenancyv1alpha1.RootNamespaceSpec) map[string]string {
+	annotations := make(map[string]string, len(rootNS.Annotations)+3)
+	for k, v := range rootNS.Annotations {
+		if ownershipAnnotation(k) {
+			continue
+		}
+		annotations[k] = v
 	}
-	err := cli.Create(context.TODO(), namespace)
-	if apierrors.IsAlreadyExists(err) {
-		return nsName, nil
+	annotations[constants.LabelVCName] = vc.Name
+	annotations[constants.LabelVCNamespace] = vc.Namespace
+	annotations[constants.LabelVCUID] = string(vc.UID)
+	return annotations
+}
+
+// ownershipAnnotation returns true if the annotation identifies a vc, e.g. tenancy.x-k8s.io/vcuid,
+// or the tenant cluster owning a namespace.
+func ownershipAnnotation(key string) bool {
+	return strings.HasPrefix(key, vcAnnotationPrefix) || key == constants.LabelCluster
+}
+
+// adoptRootNS merges the labels and annotations of the vc spec into the
+// pre-created root namespace. The adopted namespace is not marked as a root
+// namespace created by the vc-manager, so it is not deleted with the vc.
+func adoptRootNS(cli client.Client, vc *tenancyv1alpha1.VirtualCluster, rootNS *tenancyv1alpha1.RootNamespaceSpec) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		namespace := &corev1.Namespace{}
+		if err := cli.Get(context.TODO(), types.NamespacedName{Name: rootNS.Name}, namespace); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("root namespace %s does not exist, it must be created before the virtualcluster", rootNS.Name)
+			}
+			return err
+		}
+		if !namespace.DeletionTimestamp.IsZero() {
+			return fmt.Errorf("root namespace %s is being deleted", rootNS.Name)
+		}
+		if uid, ok := namespace.Annotations[constants.LabelVCUID]; ok && uid != string(vc.UID) {
+			return fmt.Errorf("root namespace %s belongs to virtualcluster %s/%s", rootNS.Name,
+				namespace.Annotations[constants.LabelVCNamespace], namespace.Annotations[constants.LabelVCName])
+		}
+		if namespace.Annotations[constants.LabelVCRootNS] == "true" {
+			return fmt.Errorf("root namespace %s is managed by the vc-manager", rootNS.Name)
+		}
+
+		labels := namespace.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string, len(rootNS.Labels))
+		}
+		for k, v := range rootNS.Labels {
+			labels[k] = v
+		}
+		if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterLabelling) {
+			labels = conversion.WithSuperClusterLabels(labels)
+		}
+		annotations := namespace.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		for k, v := range rootNSAnnotations(vc, rootNS) {
+			annotations[k] = v
+		}
+		namespace.SetLabels(labels)
+		namespace.SetAnnotations(annotations)
+		return cli.Update(context.TODO(), namespace)
+	})
+}
+
+// applyRootNSQuota creates or updates the ResourceQuota of the root namespace 'nsName'
+func applyRootNSQuota(cli client.Client, nsName string, spec *corev1.ResourceQuotaSpec) error {
+	if spec == nil {
+		return nil
+	}
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: nsName,
+			Name:      RootNSQuotaName,
+		},
+		Spec: *spec.DeepCopy(),
 	}
-	return nsName, err
+	err := cli.Create(context.TODO(), quota)
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing := &corev1.ResourceQuota{}
+		if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: nsName, Name: RootNSQuotaName}, existing); err != nil {
+			return err
+		}
+		existing.Spec = *spec.DeepCopy()
+		return cli.Update(context.TODO(), existing)
+	})
 }
 
 // AnnotateVC add the annotation('key'='val') to the VirtualCluster 'vc'
@@ -166,7 +302,7 @@ func RetryUpdateVCStatusOnConflict(ctx context.Context, cli client.Client, vc *t
 
 // SetVCStatus set the virtualcluster 'vc' status, and append the new status to conditions list
 func SetVCStatus(vc *tenancyv1alpha1.VirtualCluster, phase tenancyv1alpha1.ClusterPhase, message, reason string) {
-	nsName := conversion.ToClusterKey(vc)
+	nsName := conversion.ToRootNamespace(vc)
 	vc.Status.ClusterNamespace = nsName
 	vc.Status.Phase = phase
 	vc.Status.Message = message
@@ -178,6 +314,29 @@ func SetVCStatus(vc *tenancyv1alpha1.VirtualCluster, phase tenancyv1alpha1.Clust
 	})
 }
 
+// SetVCCondition sets the condition of the given type in the virtualcluster 'vc' status,
+// the transition time is only updated if the condition status changes
+func SetVCCondition(vc *tenancyv1alpha1.VirtualCluster, condType string, status corev1.ConditionStatus, reason, message string) {
+	cond := tenancyv1alpha1.ClusterCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             reason,
+		Message:            message,
+	}
+	for i := range vc.Status.Conditions {
+		if vc.Status.Conditions[i].Type != condType {
+			continue
+		}
+		if vc.Status.Conditions[i].Status == status {
+			cond.LastTransitionTime = vc.Status.Conditions[i].LastTransitionTime
+		}
+		vc.Status.Conditions[i] = cond
+		return
+	}
+	vc.Status.Conditions = append(vc.Status.Conditions, cond)
+}
+
 // IsObjExist check if object with 'key' exist
 func IsObjExist(cli client.Client, key client.ObjectKey, obj client.Object, log logr.Logger) bool {
 	if err := cli.Get(context.TODO(), key, obj); err != nil {
@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func newRootNSTestVC(rootNS *tenancyv1alpha1.RootNamespaceSpec) *tenancyv1alpha1.VirtualCluster {
+	return &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "default", UID: "vc-uid"},
+		Spec:       tenancyv1alpha1.VirtualClusterSpec{RootNamespace: rootNS},
+	}
+}
+
+func TestCreateRootNS(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	quota := &corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}}
+	for name, tc := range map[string]struct {
+		rootNS        *tenancyv1alpha1.RootNamespaceSpec
+		existing      []client.Object
+		expectedErr   bool
+		expectedNS    string
+		expectedRoot  bool
+		expectedLabel string
+	}{
+		"generated root namespace": {
+			rootNS: &tenancyv1alpha1.RootNamespaceSpec{
+				Labels:        map[string]string{"team": "a"},
+				Annotations:   map[string]string{"team": "a", constants.LabelCluster: "other-cluster", "tenancy.x-k8s.io/vcowner": "other"},
+				ResourceQuota: quota,
+			},
+			expectedNS:    conversion.ToClusterKey(newRootNSTestVC(nil)),
+			expectedRoot:  true,
+			expectedLabel: "a",
+		},
+		"adopted root namespace": {
+			rootNS: &tenancyv1alpha1.RootNamespaceSpec{
+				Name:          "team-a",
+				Labels:        map[string]string{"team": "a"},
+				Annotations:   map[string]string{"team": "a", constants.LabelVCRootNS: "true", constants.LabelVCUID: "other-uid"},
+				ResourceQuota: quota,
+			},
+			existing: []client.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name: "team-a", Labels: map[string]string{"policy": "strict"},
+			}}},
+			expectedNS:    "team-a",
+			expectedLabel: "a",
+		},
+		"adopted root namespace does not exist": {
+			rootNS:      &tenancyv1alpha1.RootNamespaceSpec{Name: "team-a"},
+			expectedErr: true,
+		},
+		"adopted root namespace belongs to another virtualcluster": {
+			rootNS: &tenancyv1alpha1.RootNamespaceSpec{Name: "team-a"},
+			existing: []client.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name: "team-a", Annotations: map[string]string{constants.LabelVCUID: "other-uid"},
+			}}},
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.existing...).Build()
+			vc := newRootNSTestVC(tc.rootNS)
+			nsName, err := CreateRootNS(cli, vc)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if nsName != tc.expectedNS {
+				t.Errorf("expected root namespace %s, got %s", tc.expectedNS, nsName)
+			}
+
+			ns := &corev1.Namespace{}
+			if err := cli.Get(context.TODO(), types.NamespacedName{Name: nsName}, ns); err != nil {
+				t.Fatalf("failed to get the root namespace: %v", err)
+			}
+			if ns.Labels["team"] != tc.expectedLabel {
+				t.Errorf("expected label team=%s, got %v", tc.expectedLabel, ns.Labels)
+			}
+			if ns.Annotations[constants.LabelVCUID] != string(vc.UID) {
+				t.Errorf("expected the root namespace to be annotated with the vc uid, got %v", ns.Annotations)
+			}
+			if isRoot := ns.Annotations[constants.LabelVCRootNS] == "true"; isRoot != tc.expectedRoot {
+				t.Errorf("expected root namespace annotation %v, got %v", tc.expectedRoot, isRoot)
+			}
+			if ns.Annotations["team"] != "a" {
+				t.Errorf("expected the annotations of the spec to be added, got %v", ns.Annotations)
+			}
+			if _, ok := ns.Annotations[constants.LabelCluster]; ok {
+				t.Errorf("expected the cluster annotation of the spec to be dropped, got %v", ns.Annotations)
+			}
+			if _, ok := ns.Annotations["tenancy.x-k8s.io/vcowner"]; ok {
+				t.Errorf("expected the vc annotations of the spec to be dropped, got %v", ns.Annotations)
+			}
+			if tc.rootNS.Name != "" && ns.Labels["policy"] != "strict" {
+				t.Errorf("expected the labels of the adopted namespace to be kept, got %v", ns.Labels)
+			}
+
+			rq := &corev1.ResourceQuota{}
+			if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: nsName, Name: RootNSQuotaName}, rq); err != nil {
+				t.Fatalf("failed to get the resource quota: %v", err)
+			}
+			if pods := rq.Spec.Hard[corev1.ResourcePods]; pods.Cmp(resource.MustParse("10")) != 0 {
+				t.Errorf("expected pods quota 10, got %s", pods.String())
+			}
+		})
+	}
+}
+
+func TestWaitStatefulSetReadyContext(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	// the statefulsets of 100 virtualclusters created at once, the last one never gets ready
+	const clusters = 100
+	replicas := int32(3)
+	var objs []client.Object
+	for i := 0; i < clusters; i++ {
+		objs = append(objs, &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: fmt.Sprintf("vc-%d", i)},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		})
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	go func() {
+		for i := clusters - 2; i >= 0; i-- {
+			sts := &appsv1.StatefulSet{}
+			if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: fmt.Sprintf("vc-%d", i), Name: "etcd"}, sts); err != nil {
+				t.Errorf("failed to get statefulset: %v", err)
+				return
+			}
+			sts.Status.ReadyReplicas = replicas
+			if err := cli.Status().Update(context.TODO(), sts); err != nil {
+				t.Errorf("failed to update statefulset: %v", err)
+				return
+			}
+		}
+	}()
+
+	timeout := 2 * time.Second
+	start := time.Now()
+	errs := make([]error, clusters)
+	elapsed := make([]time.Duration, clusters)
+	var wg sync.WaitGroup
+	for i := 0; i < clusters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+			defer cancel()
+			errs[i] = WaitStatefulSetReadyContext(ctx, cli, fmt.Sprintf("vc-%d", i), "etcd", 10*time.Millisecond)
+			elapsed[i] = time.Since(start)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < clusters-1; i++ {
+		if errs[i] != nil {
+			t.Errorf("expected statefulset of vc-%d to be ready, got %v", i, errs[i])
+		}
+		if elapsed[i] >= timeout {
+			t.Errorf("expected statefulset of vc-%d to be ready before the deadline, took %s", i, elapsed[i])
+		}
+	}
+	if errs[clusters-1] == nil {
+		t.Errorf("expected the statefulset never ready to time out")
+	}
+	if elapsed[clusters-1] > 2*timeout {
+		t.Errorf("expected the statefulset never ready to time out after %s, took %s", timeout, elapsed[clusters-1])
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+// ParseNamespaces parses a comma separated list of namespaces, an empty list
+// means all the namespaces
+func ParseNamespaces(s string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(s, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// NewCache returns a cache watching the namespaced objects of the given namespaces
+// only, the cluster scoped objects are still watched cluster-wide
+func NewCache(namespaces []string) cache.NewCacheFunc {
+	return cache.MultiNamespacedCacheBuilder(namespaces)
+}
+
+// NewClient returns a client reading the namespaced objects of the given namespaces
+// and the cluster scoped objects from the cache, and the other objects from the
+// apiserver. The root namespaces of the VirtualClusters are created on the fly, so
+// the objects of the control planes are not in the cache of a scoped manager.
+func NewClient(namespaces []string) cluster.NewClientFunc {
+	return func(c cache.Cache, config *rest.Config, options client.Options, uncachedObjects ...client.Object) (client.Client, error) {
+		apiClient, err := client.New(config, options)
+		if err != nil {
+			return nil, err
+		}
+		return client.NewDelegatingClient(client.NewDelegatingClientInput{
+			CacheReader: &scopedReader{
+				cacheReader: c,
+				apiReader:   apiClient,
+				namespaces:  sets.NewString(namespaces...),
+			},
+			Client:          apiClient,
+			UncachedObjects: uncachedObjects,
+		})
+	}
+}
+
+// scopedReader reads the objects out of the watched namespaces from the apiserver
+type scopedReader struct {
+	cacheReader client.Reader
+	apiReader   client.Reader
+	namespaces  sets.String
+}
+
+var _ client.Reader = &scopedReader{}
+
+func (r *scopedReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if r.cached(key.Namespace) {
+		return r.cacheReader.Get(ctx, key, obj)
+	}
+	return r.apiReader.Get(ctx, key, obj)
+}
+
+func (r *scopedReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if r.cached(listOpts.Namespace) {
+		return r.cacheReader.List(ctx, list, opts...)
+	}
+	return r.apiReader.List(ctx, list, opts...)
+}
+
+// cached returns whether the objects of the namespace are in the cache, an empty
+// namespace stands for the cluster scoped objects or all the watched namespaces
+func (r *scopedReader) cached(namespace string) bool {
+	return namespace == "" || r.namespaces.Has(namespace)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseNamespaces(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		expected []string
+	}{
+		{s: "", expected: nil},
+		{s: " , ", expected: nil},
+		{s: "tenant-a", expected: []string{"tenant-a"}},
+		{s: "tenant-a, tenant-b,,", expected: []string{"tenant-a", "tenant-b"}},
+	} {
+		if got := ParseNamespaces(tc.s); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("ParseNamespaces(%q): expected %v, got %v", tc.s, tc.expected, got)
+		}
+	}
+}
+
+// configMap returns a configmap recording the reader it is read from
+func configMap(namespace, name, source string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       map[string]string{"source": source},
+	}
+}
+
+func TestScopedReader(t *testing.T) {
+	cacheReader := fake.NewClientBuilder().WithObjects(
+		configMap("tenant-a", "vc", "cache"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"source": "cache"}}},
+	).Build()
+	apiReader := fake.NewClientBuilder().WithObjects(
+		configMap("tenant-a", "vc", "api"),
+		configMap("default-vc-root", "kubeconfig", "api"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"source": "api"}}},
+	).Build()
+	r := &scopedReader{cacheReader: cacheReader, apiReader: apiReader, namespaces: sets.NewString("tenant-a")}
+
+	for _, tc := range []struct {
+		name     string
+		key      client.ObjectKey
+		expected string
+	}{
+		{name: "watched namespace", key: client.ObjectKey{Namespace: "tenant-a", Name: "vc"}, expected: "cache"},
+		{name: "root namespace", key: client.ObjectKey{Namespace: "default-vc-root", Name: "kubeconfig"}, expected: "api"},
+	} {
+		t.Run("get from "+tc.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{}
+			if err := r.Get(context.TODO(), tc.key, cm); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cm.Data["source"] != tc.expected {
+				t.Errorf("expected %s to be read from the %s, got %s", tc.key, tc.expected, cm.Data["source"])
+			}
+		})
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Name: "tenant-a"}, ns); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ns.Labels["source"] != "cache" {
+		t.Errorf("expected the cluster scoped objects to be read from the cache, got %s", ns.Labels["source"])
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opts     []client.ListOption
+		expected []string
+	}{
+		{name: "watched namespace", opts: []client.ListOption{client.InNamespace("tenant-a")}, expected: []string{"cache"}},
+		{name: "root namespace", opts: []client.ListOption{client.InNamespace("default-vc-root")}, expected: []string{"api"}},
+		{name: "all namespaces", expected: []string{"cache"}},
+	} {
+		t.Run("list from "+tc.name, func(t *testing.T) {
+			list := &corev1.ConfigMapList{}
+			if err := r.List(context.TODO(), list, tc.opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, cm := range list.Items {
+				got = append(got, cm.Data["source"])
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected the configmaps to be read from %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package accounting counts the API requests and bytes of the syncer clients per tenant, used by
+// featuregate.TenantAPIAccounting.
+package accounting
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/transport"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/accounting"
+)
+
+const (
+	planeTenant = "tenant"
+	planeSuper  = "super"
+)
+
+// ErrThrottled is returned for a super cluster request of a tenant above its limit. The request is
+// not sent, and the caller retries it later, e.g. the reconcile request failing on it is requeued
+// with backoff instead of blocking a worker shared by all the tenants.
+var ErrThrottled = errors.New("super cluster request of the tenant is throttled")
+
+// TenantTransport returns a transport wrapper accounting all the requests of the clients of a
+// tenant cluster to the cluster. The tenant apiserver is rate-limited by the QPS of the clients.
+func TenantTransport(cluster string) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{
+			plane:    planeTenant,
+			tenantOf: func(*http.Request) string { return cluster },
+			delegate: rt,
+		}
+	}
+}
+
+// SuperTransport returns a transport wrapper accounting the requests made into a tenant namespace
+// of the super cluster to the tenant, whose requests above limiters fail with ErrThrottled. Neither the requests
+// shared by all the tenants nor the watches of the super cluster informers are accounted.
+func SuperTransport(nsLister listersv1.NamespaceLister, limiters *accounting.Limiters) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{
+			plane:    planeSuper,
+			tenantOf: func(req *http.Request) string { return rbac.TenantOf(nsLister, req.URL.Path) },
+			limiters: limiters,
+			delegate: rt,
+		}
+	}
+}
+
+type roundTripper struct {
+	plane    string
+	tenantOf func(*http.Request) string
+	limiters *accounting.Limiters
+	delegate http.RoundTripper
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	cluster := rt.tenantOf(req)
+	if cluster == "" {
+		return rt.delegate.RoundTrip(req)
+	}
+
+	if rt.limiters != nil && !rt.limiters.TryAccept(cluster) {
+		metrics.TenantAPIThrottled.WithLabelValues(cluster).Inc()
+		return nil, fmt.Errorf("%s %s of cluster %s: %w", req.Method, req.URL.Path, cluster, ErrThrottled)
+	}
+
+	if req.ContentLength > 0 {
+		metrics.TenantAPIBytes.WithLabelValues(cluster, rt.plane, "sent").Add(float64(req.ContentLength))
+	}
+	resp, err := rt.delegate.RoundTrip(req)
+	if err != nil {
+		metrics.TenantAPIRequests.WithLabelValues(cluster, rt.plane, "error").Inc()
+		return resp, err
+	}
+	metrics.TenantAPIRequests.WithLabelValues(cluster, rt.plane, fmt.Sprintf("%dxx", resp.StatusCode/100)).Inc()
+	if resp.Body != nil {
+		received := metrics.TenantAPIBytes.WithLabelValues(cluster, rt.plane, "received")
+		resp.Body = accounting.CountReader(resp.Body, func(n int) { received.Add(float64(n)) })
+	}
+	return resp, nil
+}
+
+func (rt *roundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
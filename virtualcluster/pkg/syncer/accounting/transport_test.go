@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/accounting"
+)
+
+type fakeRoundTripper struct {
+	requests int
+	status   int
+	body     string
+	err      error
+}
+
+func (rt *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests++
+	if rt.err != nil {
+		return nil, rt.err
+	}
+	return &http.Response{StatusCode: rt.status, Body: ioutil.NopCloser(strings.NewReader(rt.body))}, nil
+}
+
+func do(t *testing.T, rt http.RoundTripper, req *http.Request) {
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return
+	}
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestTenantTransport(t *testing.T) {
+	metrics.DeleteTenantAPIMetrics("tenant-a")
+	delegate := &fakeRoundTripper{status: http.StatusCreated, body: `{"kind":"Pod"}`}
+	rt := TenantTransport("tenant-a")(delegate)
+
+	body := []byte(`{"kind":"Pod","metadata":{"name":"foo"}}`)
+	req, _ := http.NewRequest(http.MethodPost, "https://tenant/api/v1/namespaces/default/pods", bytes.NewReader(body))
+	do(t, rt, req)
+	delegate.status = http.StatusNotFound
+	req, _ = http.NewRequest(http.MethodGet, "https://tenant/api/v1/namespaces/default/pods/bar", nil)
+	do(t, rt, req)
+	delegate.err = fmt.Errorf("connection refused")
+	do(t, rt, req)
+
+	for class, expected := range map[string]float64{"2xx": 1, "4xx": 1, "error": 1} {
+		if got := testutil.ToFloat64(metrics.TenantAPIRequests.WithLabelValues("tenant-a", "tenant", class)); got != expected {
+			t.Errorf("expected %v %s requests, got %v", expected, class, got)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.TenantAPIBytes.WithLabelValues("tenant-a", "tenant", "sent")); got != float64(len(body)) {
+		t.Errorf("expected %d bytes sent, got %v", len(body), got)
+	}
+	if got := testutil.ToFloat64(metrics.TenantAPIBytes.WithLabelValues("tenant-a", "tenant", "received")); got != float64(2*len(delegate.body)) {
+		t.Errorf("expected %d bytes received, got %v", 2*len(delegate.body), got)
+	}
+}
+
+func TestSuperTransport(t *testing.T) {
+	metrics.DeleteTenantAPIMetrics("tenant-b")
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "tenant-b-default",
+		Annotations: map[string]string{constants.LabelCluster: "tenant-b"},
+	}})
+	lister := listersv1.NewNamespaceLister(indexer)
+
+	delegate := &fakeRoundTripper{status: http.StatusOK}
+	rt := SuperTransport(lister, accounting.NewLimiters(0.001, 1))(delegate)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://super/api/v1/nodes/node-1", nil)
+	do(t, rt, req)
+	do(t, rt, req)
+	if delegate.requests != 2 {
+		t.Errorf("expected the requests outside the tenant namespaces not to be limited, got %d requests", delegate.requests)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://super/api/v1/namespaces/tenant-b-default/pods/foo", nil)
+	do(t, rt, req)
+	if _, err := rt.RoundTrip(req); !errors.Is(err, ErrThrottled) {
+		t.Errorf("expected the request above the limit to fail with ErrThrottled, got %v", err)
+	}
+	if delegate.requests != 3 {
+		t.Errorf("expected the throttled request not to be sent, got %d requests", delegate.requests)
+	}
+	if got := testutil.ToFloat64(metrics.TenantAPIRequests.WithLabelValues("tenant-b", "super", "2xx")); got != 1 {
+		t.Errorf("expected 1 request of the tenant, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.TenantAPIThrottled.WithLabelValues("tenant-b")); got != 1 {
+		t.Errorf("expected 1 throttled request of the tenant, got %v", got)
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/scheme"
+)
+
+// PathPrefix is the http path prefix of the admission webhook served by the syncer.
+// The tenant cluster name follows the prefix, e.g. /validate/{cluster}.
+const PathPrefix = "/validate/"
+
+// Validator is implemented by the resource syncers that can tell whether a tenant
+// object is able to be synced to the super control plane before it is persisted
+// in the tenant control plane.
+type Validator interface {
+	ValidateAdmission(clusterName string, obj client.Object) error
+}
+
+// RawValidator is implemented by the Validators that also check the fields of the reviewed
+// object which the typed objects of this client version don't have, e.g. the fields of newer
+// Kubernetes versions that would be dropped silently by the syncer.
+type RawValidator interface {
+	ValidateRawAdmission(clusterName string, raw []byte) error
+}
+
+// Server dispatches the admission reviews sent by tenant apiservers to the
+// Validator registered for the kind of the reviewed object.
+type Server struct {
+	sync.RWMutex
+	validators map[string]Validator
+}
+
+var _ http.Handler = &Server{}
+
+// NewServer returns an empty admission Server.
+func NewServer() *Server {
+	return &Server{validators: make(map[string]Validator)}
+}
+
+// Register registers the Validator of the given kind, e.g. Pod.
+func (s *Server) Register(kind string, v Validator) {
+	s.Lock()
+	defer s.Unlock()
+	s.validators[kind] = v
+}
+
+// Kinds returns the kinds that have a registered Validator.
+func (s *Server) Kinds() []string {
+	s.RLock()
+	defer s.RUnlock()
+	kinds := make([]string, 0, len(s.validators))
+	for k := range s.validators {
+		kinds = append(kinds, k)
+	}
+	return kinds
+}
+
+func (s *Server) getValidator(kind string) Validator {
+	s.RLock()
+	defer s.RUnlock()
+	return s.validators[kind]
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	clusterName := strings.Trim(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	if clusterName == "" {
+		http.Error(w, "missing cluster name in request path", http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "failed to decode admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = s.review(clusterName, review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	resp, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode admission review: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(resp); err != nil {
+		klog.Errorf("failed to write admission response for cluster %s: %v", clusterName, err)
+	}
+}
+
+func (s *Server) review(clusterName string, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	v := s.getValidator(req.Kind.Kind)
+	if v == nil || req.Operation != admissionv1.Create {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	if rv, ok := v.(RawValidator); ok {
+		if err := rv.ValidateRawAdmission(clusterName, req.Object.Raw); err != nil {
+			klog.V(4).Infof("reject %s %s/%s of cluster %s: %v", req.Kind.Kind, req.Namespace, req.Name, clusterName, err)
+			return deny(http.StatusForbidden, err.Error())
+		}
+	}
+
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer()
+	runtimeObj, _, err := decoder.Decode(req.Object.Raw, nil, nil)
+	if err != nil {
+		return deny(http.StatusBadRequest, fmt.Sprintf("failed to decode %s: %v", req.Kind.Kind, err))
+	}
+	obj, ok := runtimeObj.(client.Object)
+	if !ok {
+		return deny(http.StatusBadRequest, fmt.Sprintf("unexpected object type %T", runtimeObj))
+	}
+	// the namespace is not always set in the object for create requests
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(req.Namespace)
+	}
+
+	if err := v.ValidateAdmission(clusterName, obj); err != nil {
+		klog.V(4).Infof("reject %s %s/%s of cluster %s: %v", req.Kind.Kind, req.Namespace, obj.GetName(), clusterName, err)
+		return deny(http.StatusForbidden, err.Error())
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func deny(code int32, message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    code,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: message,
+		},
+	}
+}
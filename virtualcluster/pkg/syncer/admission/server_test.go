@@ -0,0 +1,216 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeValidator struct {
+	clusterName string
+	namespace   string
+}
+
+func (f *fakeValidator) ValidateAdmission(clusterName string, obj client.Object) error {
+	f.clusterName = clusterName
+	f.namespace = obj.GetNamespace()
+	if obj.(*corev1.Pod).Spec.NodeName != "" {
+		return fmt.Errorf("nodeName is not supported")
+	}
+	return nil
+}
+
+// fakeRawValidator rejects the pods of which the raw object sets spec.os
+type fakeRawValidator struct {
+	fakeValidator
+}
+
+func (f *fakeRawValidator) ValidateRawAdmission(clusterName string, raw []byte) error {
+	pod := struct {
+		Spec map[string]interface{} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(raw, &pod); err != nil {
+		return err
+	}
+	if _, ok := pod.Spec["os"]; ok {
+		return fmt.Errorf("spec.os is not supported")
+	}
+	return nil
+}
+
+func TestServeHTTPRawValidator(t *testing.T) {
+	pod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "p1"},
+	}
+	for _, tc := range []struct {
+		name    string
+		os      bool
+		allowed bool
+	}{
+		{name: "allowed pod", allowed: true},
+		{name: "pod with spec.os", os: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewServer()
+			s.Register("Pod", &fakeRawValidator{})
+
+			body := newReview(t, admissionv1.Create, pod)
+			if tc.os {
+				// the typed pod doesn't have the field, it is added to the raw object
+				body = bytes.Replace(body, []byte(`"spec":{`), []byte(`"spec":{"os":{"name":"windows"},`), 1)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/validate/cluster1", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+
+			review := &admissionv1.AdmissionReview{}
+			if err := json.Unmarshal(w.Body.Bytes(), review); err != nil {
+				t.Fatalf("unmarshal response: %v", err)
+			}
+			if review.Response.Allowed != tc.allowed {
+				t.Errorf("expected allowed %v, got %v", tc.allowed, review.Response.Allowed)
+			}
+		})
+	}
+}
+
+func newReview(t *testing.T, op admissionv1.Operation, pod *corev1.Pod) []byte {
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("marshal pod: %v", err)
+	}
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID("123"),
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Namespace: "default",
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("marshal review: %v", err)
+	}
+	return body
+}
+
+func TestServeHTTP(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		path        string
+		op          admissionv1.Operation
+		nodeName    string
+		code        int
+		allowed     bool
+		validatedBy string
+	}{
+		{
+			name:        "allowed pod",
+			path:        "/validate/cluster1",
+			op:          admissionv1.Create,
+			code:        http.StatusOK,
+			allowed:     true,
+			validatedBy: "cluster1",
+		},
+		{
+			name:        "rejected pod",
+			path:        "/validate/cluster1",
+			op:          admissionv1.Create,
+			nodeName:    "n1",
+			code:        http.StatusOK,
+			allowed:     false,
+			validatedBy: "cluster1",
+		},
+		{
+			name:     "update is not validated",
+			path:     "/validate/cluster1",
+			op:       admissionv1.Update,
+			nodeName: "n1",
+			code:     http.StatusOK,
+			allowed:  true,
+		},
+		{
+			name: "missing cluster",
+			path: "/validate/",
+			op:   admissionv1.Create,
+			code: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &fakeValidator{}
+			s := NewServer()
+			s.Register("Pod", v)
+
+			pod := &corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				ObjectMeta: metav1.ObjectMeta{Name: "p1"},
+				Spec:       corev1.PodSpec{NodeName: tc.nodeName},
+			}
+			req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewReader(newReview(t, tc.op, pod)))
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+
+			if w.Code != tc.code {
+				t.Fatalf("expected code %d, got %d", tc.code, w.Code)
+			}
+			if tc.code != http.StatusOK {
+				return
+			}
+			review := &admissionv1.AdmissionReview{}
+			if err := json.Unmarshal(w.Body.Bytes(), review); err != nil {
+				t.Fatalf("unmarshal response: %v", err)
+			}
+			if review.Response.UID != "123" {
+				t.Errorf("expected response uid 123, got %s", review.Response.UID)
+			}
+			if review.Response.Allowed != tc.allowed {
+				t.Errorf("expected allowed %v, got %v", tc.allowed, review.Response.Allowed)
+			}
+			if v.clusterName != tc.validatedBy {
+				t.Errorf("expected validated cluster %q, got %q", tc.validatedBy, v.clusterName)
+			}
+			if tc.validatedBy != "" && v.namespace != "default" {
+				t.Errorf("expected namespace default, got %q", v.namespace)
+			}
+		})
+	}
+}
+
+func TestBuildWebhookConfiguration(t *testing.T) {
+	wh := BuildWebhookConfiguration("cluster1", "https://syncer.vc-manager/", []byte("ca"), []string{"Pod", "Unknown"})
+	if got := *wh.Webhooks[0].ClientConfig.URL; got != "https://syncer.vc-manager/validate/cluster1" {
+		t.Errorf("unexpected webhook url %s", got)
+	}
+	if got := wh.Webhooks[0].Rules[0].Resources; len(got) != 1 || got[0] != "pods" {
+		t.Errorf("unexpected webhook resources %v", got)
+	}
+}
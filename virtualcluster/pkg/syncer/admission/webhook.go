@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+// WebhookConfigurationName is the name of the ValidatingWebhookConfiguration
+// installed in every tenant control plane.
+const WebhookConfigurationName = "vc-syncer-admission"
+
+// kindResources maps the kinds that can be validated to their resource names.
+var kindResources = map[string]string{
+	"Pod": "pods",
+}
+
+// BuildWebhookConfiguration returns the ValidatingWebhookConfiguration that points the
+// tenant apiserver of cluster to the syncer admission server served at baseURL.
+func BuildWebhookConfiguration(clusterName, baseURL string, caBundle []byte, kinds []string) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	url := strings.TrimSuffix(baseURL, "/") + PathPrefix + clusterName
+	failurePolicy := admissionregistrationv1.Ignore
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	scope := admissionregistrationv1.NamespacedScope
+
+	var resources []string
+	for _, k := range kinds {
+		if r, ok := kindResources[k]; ok {
+			resources = append(resources, r)
+		}
+	}
+
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   WebhookConfigurationName,
+			Labels: map[string]string{constants.LabelControlled: "true"},
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{
+				Name: "syncability.tenancy.x-k8s.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					URL:      &url,
+					CABundle: caBundle,
+				},
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{""},
+							APIVersions: []string{"v1"},
+							Resources:   resources,
+							Scope:       &scope,
+						},
+					},
+				},
+				FailurePolicy:           &failurePolicy,
+				SideEffects:             &sideEffects,
+				AdmissionReviewVersions: []string{"v1"},
+			},
+		},
+	}
+}
+
+// webhookListener installs the admission webhook configuration into every
+// tenant control plane that is watched by the syncer.
+type webhookListener struct {
+	server   *Server
+	baseURL  string
+	caBundle []byte
+}
+
+var _ listener.ClusterChangeListener = &webhookListener{}
+
+// NewWebhookListener returns a listener that registers the admission webhook of
+// server in the tenant control planes once they are ready.
+func NewWebhookListener(server *Server, baseURL string, caBundle []byte) listener.ClusterChangeListener {
+	return &webhookListener{server: server, baseURL: baseURL, caBundle: caBundle}
+}
+
+func (l *webhookListener) AddCluster(cluster mc.ClusterInterface) {}
+
+func (l *webhookListener) RemoveCluster(cluster mc.ClusterInterface) {}
+
+func (l *webhookListener) WatchCluster(cluster mc.ClusterInterface) {
+	cs, err := cluster.GetClientSet()
+	if err != nil {
+		klog.Errorf("failed to get clientset of cluster %s: %v", cluster.GetClusterName(), err)
+		return
+	}
+
+	expected := BuildWebhookConfiguration(cluster.GetClusterName(), l.baseURL, l.caBundle, l.server.Kinds())
+	webhooks := cs.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	current, err := webhooks.Get(context.TODO(), WebhookConfigurationName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = webhooks.Create(context.TODO(), expected, metav1.CreateOptions{})
+	} else if err == nil {
+		current.Webhooks = expected.Webhooks
+		_, err = webhooks.Update(context.TODO(), current, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("failed to install admission webhook in cluster %s: %v", cluster.GetClusterName(), err)
+		return
+	}
+	klog.Infof("admission webhook installed in cluster %s", cluster.GetClusterName())
+}
@@ -49,6 +49,10 @@ type SyncerConfiguration struct {
 	// ExtraSyncingResources defines additional resources that need to be synced for each Virtual Cluster
 	ExtraSyncingResources []string
 
+	// SyncingResources restricts the resource syncers to the listed ones, including the ones disabled
+	// by default. The default resource syncers and ExtraSyncingResources are run if it is empty.
+	SyncingResources []string
+
 	// DisableServiceAccountToken indicates whether to disable super cluster service account tokens being auto generated
 	// and mounted in vc pods.
 	DisableServiceAccountToken bool
@@ -68,6 +72,25 @@ type SyncerConfiguration struct {
 	// VNAgentPort defines the port that the VN Agent is running on per host
 	VNAgentPort int32
 
+	// NodeLeaseDurationSeconds is the duration of the vNode leases the syncer renews in tenant
+	// clusters, this is used for feature TenantNodeLease.
+	NodeLeaseDurationSeconds int32
+
+	// TenantNodeUpdateQPS and TenantNodeUpdateBurst rate-limit the vNode status and lease
+	// writes per tenant cluster. A zero QPS disables the limit.
+	TenantNodeUpdateQPS   float32
+	TenantNodeUpdateBurst int
+
+	// TenantSuperRequestQPS and TenantSuperRequestBurst rate-limit the super cluster requests made
+	// into the namespaces of every tenant cluster, this is used for feature TenantAPIAccounting.
+	// A zero QPS disables the limit.
+	TenantSuperRequestQPS   float32
+	TenantSuperRequestBurst int
+
+	// VirtualNodePoolLabel is the super cluster node label whose value groups nodes into pools,
+	// this is used for feature VirtualNodeAggregation.
+	VirtualNodePoolLabel string
+
 	// VNAgentNamespacedName defines the namespace/name of the VN Agent Kubernetes
 	// service, this is used for feature VNodeProviderService.
 	VNAgentNamespacedName string
@@ -79,6 +102,13 @@ type SyncerConfiguration struct {
 	// FeatureGates enabled by the user.
 	FeatureGates map[string]bool
 
+	// AllowedExecCredentialCommands is the list of the exec credential plugin commands the tenant
+	// kubeconfigs are allowed to run, any command is allowed if it is empty.
+	AllowedExecCredentialCommands []string
+
+	// TenantConnection are the transport settings of the connections to the tenant apiservers.
+	TenantConnection TenantConnectionConfiguration
+
 	// Super cluster rest config
 	RestConfig *rest.Config
 
@@ -87,6 +117,212 @@ type SyncerConfiguration struct {
 
 	// The DNSOptions are the DNS options in resolv.conf that is attached to pod
 	DNSOptions []corev1.PodDNSConfigOption
+
+	// PodReadinessGates are the pod condition types that can be injected as readiness gates into the
+	// super pods of the VirtualClusters opting into them. Their conditions are set on the tenant pods
+	// by tenant controllers and synced downward, so the super pods are only ready once the tenant
+	// considers them ready.
+	PodReadinessGates []string
+
+	// AdmissionWebhookURL is the base URL of the syncer server that tenant apiservers
+	// use to call the admission webhook, this is used for feature TenantPodAdmission.
+	AdmissionWebhookURL string
+
+	// AdmissionWebhookCAFile is the CA bundle file that tenant apiservers use to
+	// verify the syncer server, this is used for feature TenantPodAdmission.
+	AdmissionWebhookCAFile string
+
+	// CacheTransforms defines the transforms applied to super cluster objects before they
+	// are stored in the informer caches, keyed by resource, e.g. {"pods": ["strip-managed-fields"]}.
+	CacheTransforms map[string][]string
+
+	// CacheFieldSelectors restricts the super cluster objects listed and watched into the
+	// informer caches, keyed by resource, e.g. {"events": "involvedObject.kind=Pod"}.
+	CacheFieldSelectors map[string]string
+
+	// MaxCachedAnnotationSize is the annotation value size above which the
+	// drop-large-annotations cache transform drops an annotation.
+	MaxCachedAnnotationSize int
+
+	// MetadataLimits limits the labels and annotations of the tenant objects synced to the super
+	// cluster, keyed by resource. The limits of "*" apply to the resources, or the limits of a
+	// resource, that are not set.
+	MetadataLimits map[string]MetadataLimit
+
+	// BannedAnnotationPrefixes is the list of annotation key prefixes the tenant objects synced to
+	// the super cluster must not use.
+	BannedAnnotationPrefixes []string
+
+	// ConflictPolicies defines which side wins when a field is changed in both tenant and super
+	// cluster, keyed by resource or resource/field.path, e.g. {"pods": "TenantWins",
+	// "pods/metadata.labels": "SuperWins"}. Resources default to TenantWins.
+	ConflictPolicies map[string]string
+
+	// ConflictSystemManagers are the super cluster field managers whose changes are never
+	// conflicts, e.g. kube-scheduler. Nil means conflict.DefaultSystemManagers.
+	ConflictSystemManagers []string
+
+	// FinalizerPolicies defines how the finalizers of tenant objects are translated to the super
+	// cluster objects, keyed by resource, e.g. {"configmaps": "Mirror"}. Resources default to Strip.
+	FinalizerPolicies map[string]string
+
+	// FinalizerBlockTimeout is the time the deletion of a super cluster object is blocked by
+	// mirrored tenant finalizers under the BlockWithTimeout finalizer policy.
+	FinalizerBlockTimeout metav1.Duration
+
+	// ChangeJournalSize is the number of super cluster changes kept in the change journal,
+	// this is used for feature SyncChangeJournal.
+	ChangeJournalSize int
+
+	// ChangeJournalLogSink also writes the change journal entries to the log.
+	ChangeJournalLogSink bool
+
+	// TenantNamespaceMetaAllowList is the list of key prefixes of the tenant namespace labels and
+	// annotations propagated to the super cluster namespaces. All the keys are propagated if it is empty.
+	TenantNamespaceMetaAllowList []string
+
+	// SuperNamespaceMetaAllowList is the list of key prefixes of the super cluster namespace labels and
+	// annotations propagated back to the tenant namespaces. Those keys are owned by the super cluster
+	// and are never propagated downward.
+	SuperNamespaceMetaAllowList []string
+
+	// LoadBalancerAnnotationPrefixes is the list of key prefixes of the annotations the super cluster
+	// load balancer controllers set on the LoadBalancer services. Those keys are owned by the super
+	// cluster, they are propagated back to the tenant services and never propagated downward.
+	LoadBalancerAnnotationPrefixes []string
+
+	// AnnotationMappings translates the annotations of the tenant services and ingresses synced to the
+	// super cluster, keyed by tenant annotation key prefix, e.g. {"dns.example.com/": "external-dns.alpha.kubernetes.io/"}.
+	// The longest matching prefix is replaced by its value, the annotation is dropped if the value is empty.
+	AnnotationMappings map[string]string
+
+	// GuardedAnnotationPrefixes is the list of annotation key prefixes of the tenant services and ingresses
+	// that are only synced to the super cluster through AnnotationMappings, the other ones are dropped.
+	GuardedAnnotationPrefixes []string
+
+	// TenantServiceAccountNamespace is the super cluster namespace that holds the per-tenant
+	// service accounts the syncer impersonates, this is used for feature TenantImpersonation.
+	TenantServiceAccountNamespace string
+
+	// TenantClusterRoleName is the super cluster ClusterRole bound to the per-tenant service
+	// accounts in their own namespaces, this is used for feature TenantImpersonation.
+	TenantClusterRoleName string
+
+	// TenantIdentity is the kind of super cluster identity the syncer impersonates for a tenant,
+	// ServiceAccount or User, this is used for feature TenantImpersonation.
+	TenantIdentity string
+
+	// TenantIdentityClusters are the tenant clusters, i.e. the ClusterNamespace of their VirtualClusters,
+	// whose users the syncer may impersonate with the User TenantIdentity. RBAC can't match user names by
+	// prefix, the ClusterRole of the syncer only grants the impersonation of the users of these clusters.
+	TenantIdentityClusters []string
+
+	// UsageReportingInterval is the interval between two usage reports of the VirtualClusters,
+	// this is used for feature UsageReporting.
+	UsageReportingInterval metav1.Duration
+
+	// UsageReportingSinks is the list of sinks the usage reports are written to, each one is of the
+	// form <name>[=<argument>], e.g. ["prometheus", "csv=/data/usage.csv", "webhook=https://host/path"],
+	// this is used for feature UsageReporting.
+	UsageReportingSinks []string
+
+	// VirtualClusterSummaryInterval is the interval between two updates of the VirtualClusterSummary
+	// objects, this is used for feature VirtualClusterSummary.
+	VirtualClusterSummaryInterval metav1.Duration
+
+	// CapabilityProbeInterval is the interval between two probes of the super cluster capabilities,
+	// this is used for feature SuperClusterCapabilities.
+	CapabilityProbeInterval metav1.Duration
+
+	// PatrolPeriod is the period of the patrollers comparing the tenant and super cluster objects.
+	PatrolPeriod metav1.Duration
+
+	// PatrolRemediationBudget is the number of remediation actions, e.g. deletes and requeues, a patrol
+	// pass may take per tenant cluster and resource. Above it the patroller stops remediating the tenant
+	// cluster and raises an alert. The budget is unlimited if it is 0.
+	PatrolRemediationBudget int
+
+	// ConfigReloadFile is a file, usually mounted from a ConfigMap, whose settings override the
+	// reloadable settings of this configuration, e.g. the patrol period, the vNode update limits
+	// and some feature gates. The file is re-read every ConfigReloadInterval.
+	ConfigReloadFile string
+
+	// ConfigReloadInterval is the interval between two reads of ConfigReloadFile.
+	ConfigReloadInterval metav1.Duration
+
+	// BulkSyncingResources is the list of the resources, by plugin name, that are synced after the other
+	// resources during the initial sync, this is used for feature ResyncPriority.
+	BulkSyncingResources []string
+
+	// BulkSyncMaxDelay is the maximum time the bulk resources are held back during the initial sync,
+	// this is used for feature ResyncPriority.
+	BulkSyncMaxDelay metav1.Duration
+
+	// DrainTimeout is the time the resource syncers are given to drain their queues on shutdown,
+	// before the leader lease is released.
+	DrainTimeout metav1.Duration
+
+	// OwnershipKeyFile is a file holding the keys, one per line, of the HMAC signing the ownership
+	// annotations of the super cluster objects. The first key signs, all the keys verify so that the
+	// key can be rotated. This is used for feature OwnershipSignature.
+	OwnershipKeyFile string
+
+	// RequireOwnershipSignature rejects the destructive operations on the super cluster objects without
+	// an ownership signature right away. By default the objects without a signature are only rejected
+	// once the objects synced before the feature was enabled are signed. This is used for feature
+	// OwnershipSignature.
+	RequireOwnershipSignature bool
+
+	// BackfillOwnershipSignatures signs the super cluster objects synced before feature
+	// OwnershipSignature was enabled, once. The completion is recorded in the super cluster and the
+	// objects are never signed again. It is ignored if RequireOwnershipSignature is set.
+	BackfillOwnershipSignatures bool
+
+	// TenantResourceSplit assigns the resources of large virtual clusters to several syncer instances,
+	// keyed by the namespace/name of the virtual cluster, e.g. {"default/huge": ["pod"]}. The resources
+	// are the plugin names this instance syncs, or the plugin names prefixed with "-" it does not sync.
+	// The resources of the other virtual clusters are all synced.
+	TenantResourceSplit map[string][]string
+
+	// SyncSplitTenantsOnly restricts this instance to the virtual clusters of TenantResourceSplit.
+	SyncSplitTenantsOnly bool
+
+	// NamespaceRetentionPeriod is the time the super cluster namespace of a deleted tenant namespace
+	// is retained before being deleted, this is used for feature NamespaceRecycleBin.
+	NamespaceRetentionPeriod metav1.Duration
+
+	// NamespaceTeardownTimeout is the time the deletion of the super cluster namespace of a deleted
+	// tenant namespace waits for its pods, services and claims to be gone, this is used for feature NamespaceDeletionBarrier.
+	// The super cluster namespaces terminating for longer are reported as stuck.
+	NamespaceTeardownTimeout metav1.Duration
+
+	// MaxSyncFreezeDuration is the longest time a tenant can freeze the downward sync of a namespace
+	// for, the freezes requested by the tenants are not honored if it is 0.
+	MaxSyncFreezeDuration metav1.Duration
+}
+
+// TenantConnectionConfiguration defines the transport settings shared by the connections to all the
+// tenant apiservers.
+type TenantConnectionConfiguration struct {
+	// DialTimeout is the timeout of dialing a tenant apiserver.
+	DialTimeout metav1.Duration
+	// DialKeepAlive is the keep-alive period of the tenant apiserver connections.
+	DialKeepAlive metav1.Duration
+	// MaxIdleConnsPerHost is the number of idle connections kept per tenant apiserver.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is the time an idle tenant apiserver connection is kept before being closed.
+	IdleConnTimeout metav1.Duration
+}
+
+// MetadataLimit defines the limits of the metadata of the tenant objects of a resource, a limit that
+// is 0 is the one of "*", it is not enforced if both are 0.
+type MetadataLimit struct {
+	// MaxAnnotationsSize is the total size of the keys and values of the annotations.
+	MaxAnnotationsSize int
+	// MaxAnnotations is the number of annotations.
+	MaxAnnotations int
+	// MaxLabels is the number of labels.
+	MaxLabels int
 }
 
 // SyncerLeaderElectionConfiguration expands LeaderElectionConfiguration
@@ -97,4 +333,7 @@ type SyncerLeaderElectionConfiguration struct {
 	LockObjectNamespace string
 	// LockObjectName defines the lock object name
 	LockObjectName string
+	// WarmStandby makes the replicas that are not leading start the caches of the tenant clusters,
+	// without syncing them, so that they take over quickly once they lead.
+	WarmStandby bool
 }
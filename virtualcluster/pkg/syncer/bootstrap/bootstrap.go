@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bootstrap wires the syncer for projects embedding a subset of the resource syncers in
+// their own controller manager, instead of running the syncer binary.
+//
+// The resource syncers are registered by importing their packages, e.g.
+//
+//	import _ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/pod"
+//
+// and the registered ones can be restricted further with SyncingResources of the configuration.
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
+	vcinformer "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conflict"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/finalizer"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/freeze"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/cachefilter"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
+)
+
+// Options are the settings of an embedded syncer.
+type Options struct {
+	// Config is the syncer configuration. Its RestConfig is set to SuperClusterConfig.
+	Config *config.SyncerConfiguration
+	// SuperClusterConfig is the rest config of the super cluster the tenant objects are synced to.
+	SuperClusterConfig *restclient.Config
+	// MetaClusterConfig is the rest config of the cluster storing the VirtualClusters,
+	// SuperClusterConfig is used if it is nil.
+	MetaClusterConfig *restclient.Config
+}
+
+// Syncer is an embedded syncer. It implements the Runnable of controller-runtime, so it can be
+// added to a controller manager, and only runs on the leader.
+type Syncer struct {
+	syncer                 *syncer.Syncer
+	superClient            clientset.Interface
+	virtualClusterInformer vcinformer.VirtualClusterInformer
+	superInformers         informers.SharedInformerFactory
+	broadcaster            record.EventBroadcaster
+}
+
+// Configure validates the syncer configuration and applies its process wide settings: the feature
+// gates, the drain timeout, the maximum sync freeze duration and the scheme of the VirtualCluster APIs. Only one syncer configuration
+// can be applied per process.
+func Configure(c *config.SyncerConfiguration) error {
+	if err := conflict.ValidatePolicies(c.ConflictPolicies); err != nil {
+		return err
+	}
+	if err := finalizer.ValidatePolicies(c.FinalizerPolicies); err != nil {
+		return err
+	}
+	if err := rbac.ValidateTenantIdentity(c.TenantIdentity); err != nil {
+		return err
+	}
+	if err := ValidateResources(c); err != nil {
+		return err
+	}
+	if err := conversion.ValidatePodReadinessGates(c.PodReadinessGates); err != nil {
+		return err
+	}
+
+	if c.MaxSyncFreezeDuration.Duration < 0 {
+		return fmt.Errorf("the maximum sync freeze duration %s must not be negative", c.MaxSyncFreezeDuration.Duration)
+	}
+
+	shutdown.DrainTimeout = c.DrainTimeout.Duration
+	freeze.MaxDuration = c.MaxSyncFreezeDuration.Duration
+
+	gate, err := featuregate.NewFeatureGate(c.FeatureGates)
+	if err != nil {
+		return err
+	}
+	if gate.Enabled(featuregate.TenantFlowControl) && !gate.Enabled(featuregate.TenantImpersonation) {
+		return fmt.Errorf("feature %s requires feature %s, the flows of the tenants are told apart by their impersonated users", featuregate.TenantFlowControl, featuregate.TenantImpersonation)
+	}
+	featuregate.DefaultFeatureGate = gate
+
+	// Setup Scheme for all resources
+	return apis.AddToScheme(scheme.Scheme)
+}
+
+// ValidateResources checks that the resource syncers of SyncingResources are registered.
+func ValidateResources(c *config.SyncerConfiguration) error {
+	registered := make(map[string]bool)
+	for _, r := range plugin.SyncerResourceRegister.List() {
+		registered[r.ID] = true
+	}
+	for _, id := range c.SyncingResources {
+		if !registered[id] {
+			return fmt.Errorf("resource syncer %q is not registered, its package must be imported", id)
+		}
+	}
+	return nil
+}
+
+// New builds the clients, informers and resource syncers of an embedded syncer. It applies the
+// configuration with Configure.
+func New(opts Options) (*Syncer, error) {
+	if opts.Config == nil || opts.SuperClusterConfig == nil {
+		return nil, fmt.Errorf("the syncer configuration and the super cluster rest config are required")
+	}
+	if err := Configure(opts.Config); err != nil {
+		return nil, err
+	}
+	metaRestConfig := opts.MetaClusterConfig
+	if metaRestConfig == nil {
+		metaRestConfig = opts.SuperClusterConfig
+	}
+
+	superClusterClient, err := clientset.NewForConfig(restclient.AddUserAgent(opts.SuperClusterConfig, constants.ResourceSyncerUserAgent))
+	if err != nil {
+		return nil, err
+	}
+	metaClusterClient, err := clientset.NewForConfig(restclient.AddUserAgent(metaRestConfig, constants.ResourceSyncerUserAgent))
+	if err != nil {
+		return nil, err
+	}
+	virtualClusterClient, err := vcclient.NewForConfig(metaRestConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	superInformers := informers.NewSharedInformerFactory(superClusterClient, 0)
+	if err := cachefilter.RegisterInformers(superInformers, opts.Config.CacheTransforms,
+		opts.Config.CacheFieldSelectors, opts.Config.MaxCachedAnnotationSize); err != nil {
+		return nil, err
+	}
+	virtualClusterInformer := vcinformers.NewSharedInformerFactory(virtualClusterClient, 0).Tenancy().V1alpha1().VirtualClusters()
+
+	broadcaster := record.NewBroadcaster()
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: constants.ResourceSyncerUserAgent})
+
+	opts.Config.RestConfig = opts.SuperClusterConfig
+	ss, err := syncer.New(opts.Config, virtualClusterClient, virtualClusterInformer, metaClusterClient, superClusterClient, superInformers, recorder)
+	if err != nil {
+		return nil, fmt.Errorf("new syncer: %v", err)
+	}
+
+	return &Syncer{
+		syncer:                 ss,
+		superClient:            superClusterClient,
+		virtualClusterInformer: virtualClusterInformer,
+		superInformers:         superInformers,
+		broadcaster:            broadcaster,
+	}, nil
+}
+
+// Start runs the syncer until the context is done, then waits for the resource syncers to drain
+// their queues for at most the drain timeout.
+func (s *Syncer) Start(ctx context.Context) error {
+	s.broadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: s.superClient.CoreV1().Events("")})
+	defer s.broadcaster.Shutdown()
+
+	go s.virtualClusterInformer.Informer().Run(ctx.Done())
+	s.superInformers.Start(ctx.Done())
+	s.superInformers.WaitForCacheSync(ctx.Done())
+
+	s.syncer.Run(ctx.Done())
+	<-ctx.Done()
+
+	klog.Infof("shutting down, draining the resource syncers")
+	if !s.syncer.WaitForShutdown(shutdown.DrainTimeout) {
+		return fmt.Errorf("resource syncers not drained within %v", shutdown.DrainTimeout)
+	}
+	return nil
+}
+
+// NeedLeaderElection tells the controller manager to only run the syncer on the leader.
+func (s *Syncer) NeedLeaderElection() bool {
+	return true
+}
+
+// ListenAndServe serves the metrics, tenant pod admission and change journal endpoints of the syncer.
+func (s *Syncer) ListenAndServe(address, certFile, keyFile string) {
+	s.syncer.ListenAndServe(address, certFile, keyFile)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/configmap"
+	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/secret"
+	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/serviceexport"
+)
+
+func TestSyncingResources(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config      config.SyncerConfiguration
+		expectedErr bool
+		expectedIDs []string
+	}{
+		"default resource syncers": {
+			expectedIDs: []string{"configmap", "secret"},
+		},
+		"extra resource syncers": {
+			config:      config.SyncerConfiguration{ExtraSyncingResources: []string{"serviceexport"}},
+			expectedIDs: []string{"configmap", "secret", "serviceexport"},
+		},
+		"subset of resource syncers": {
+			config:      config.SyncerConfiguration{SyncingResources: []string{"secret", "serviceexport"}},
+			expectedIDs: []string{"secret", "serviceexport"},
+		},
+		"resource syncer not registered": {
+			config:      config.SyncerConfiguration{SyncingResources: []string{"secret", "pod"}},
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateResources(&tc.config)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var ids []string
+			for _, p := range syncer.LoadPlugins(&tc.config) {
+				ids = append(ids, p.ID)
+			}
+			if !reflect.DeepEqual(ids, tc.expectedIDs) {
+				t.Errorf("expected resource syncers %v, got %v", tc.expectedIDs, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+)
+
+var _ patrol.BudgetListener = &Syncer{}
+
+// BudgetExceeded records the clusters whose patrol remediation actions exceeded the budget in the
+// PatrolBudgetExceededCondition of their VirtualCluster, the condition is only updated when the set of
+// kinds exceeding the budget changes.
+func (s *Syncer) BudgetExceeded(kind string, exceeded map[string]int) {
+	s.budgetLock.Lock()
+	changed := make(map[string]sets.String)
+	for cluster := range exceeded {
+		if cluster == "" {
+			continue
+		}
+		if s.budgetExceeded[cluster] == nil {
+			s.budgetExceeded[cluster] = sets.NewString()
+		}
+		if !s.budgetExceeded[cluster].Has(kind) {
+			s.budgetExceeded[cluster].Insert(kind)
+			changed[cluster] = sets.NewString(s.budgetExceeded[cluster].List()...)
+		}
+	}
+	for cluster, kinds := range s.budgetExceeded {
+		if _, ok := exceeded[cluster]; ok || !kinds.Has(kind) {
+			continue
+		}
+		kinds.Delete(kind)
+		changed[cluster] = sets.NewString(kinds.List()...)
+		if kinds.Len() == 0 {
+			delete(s.budgetExceeded, cluster)
+		}
+	}
+	s.budgetLock.Unlock()
+
+	for cluster, kinds := range changed {
+		if err := s.setBudgetCondition(cluster, kinds); err != nil {
+			klog.Errorf("failed to set the %s condition of cluster %s: %v", v1alpha1.PatrolBudgetExceededCondition, cluster, err)
+		}
+	}
+}
+
+func (s *Syncer) setBudgetCondition(cluster string, kinds sets.String) error {
+	cond := v1alpha1.ClusterCondition{
+		Type:   v1alpha1.PatrolBudgetExceededCondition,
+		Status: corev1.ConditionFalse,
+		Reason: "WithinBudget",
+	}
+	if kinds.Len() > 0 {
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = "RemediationStopped"
+		cond.Message = fmt.Sprintf("the patrollers of %s stopped remediating the cluster, the remediation actions exceed the budget", strings.Join(kinds.List(), ", "))
+	}
+	return s.updateClusterCondition(cluster, cond)
+}
+
+// updateClusterCondition sets the condition in the status of the VirtualCluster of the cluster.
+func (s *Syncer) updateClusterCondition(cluster string, cond v1alpha1.ClusterCondition) error {
+	var owner *struct{ namespace, name string }
+	s.mu.Lock()
+	for _, c := range s.clusterSet {
+		if c != nil && c.GetClusterName() == cluster {
+			name, namespace, _ := c.GetOwnerInfo()
+			owner = &struct{ namespace, name string }{namespace, name}
+			break
+		}
+	}
+	s.mu.Unlock()
+	if owner == nil {
+		// the cluster is removed
+		return nil
+	}
+	return s.updateVirtualClusterCondition(owner.namespace, owner.name, cond)
+}
+
+// updateVirtualClusterCondition sets the condition in the status of the VirtualCluster namespace/name.
+func (s *Syncer) updateVirtualClusterCondition(namespace, name string, cond v1alpha1.ClusterCondition) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		vc, err := s.vcClient.TenancyV1alpha1().VirtualClusters(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		oldStatus := vc.Status.DeepCopy()
+		kubeutil.SetVCCondition(vc, cond.Type, cond.Status, cond.Reason, cond.Message)
+		if equality.Semantic.DeepEqual(oldStatus, &vc.Status) {
+			return nil
+		}
+		if cond.Status == corev1.ConditionFalse && len(vc.Status.Conditions) > len(oldStatus.Conditions) {
+			// the condition is only added once the budget or the requirements are not met
+			return nil
+		}
+		_, err = s.vcClient.TenancyV1alpha1().VirtualClusters(namespace).UpdateStatus(vc)
+		return err
+	})
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package capability probes the super cluster for the APIs and features the tenant pods can use,
+// and advertises them in a ConfigMap in every tenant cluster, so that tenant tooling can adapt to
+// the super cluster it runs on, e.g. detect whether EndpointSlices or PodSecurity admission are supported.
+package capability
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/version"
+	clientset "k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+const (
+	// ConfigMapNamespace is the tenant namespace of the capability ConfigMap, it is readable by all users.
+	ConfigMapNamespace = metav1.NamespacePublic
+	// ConfigMapName is the name of the capability ConfigMap.
+	ConfigMapName = "super-cluster-capabilities"
+
+	// KubernetesVersionKey is the git version of the super cluster apiserver.
+	KubernetesVersionKey = "kubernetesVersion"
+	// APIGroupVersionsKey is the newline separated list of the group versions served by the super cluster.
+	APIGroupVersionsKey = "apiGroupVersions"
+	// RuntimeClassesKey is the comma separated list of the super cluster RuntimeClasses.
+	RuntimeClassesKey = "runtimeClasses"
+	// CSIDriversKey is the comma separated list of the super cluster CSIDrivers.
+	CSIDriversKey = "csiDrivers"
+	// EndpointSlicesKey is "true" if the super cluster serves EndpointSlices.
+	EndpointSlicesKey = "endpointSlices"
+	// PodSecurityAdmissionKey is "true" if the super cluster enforces the PodSecurity admission by default.
+	PodSecurityAdmissionKey = "podSecurityAdmission"
+)
+
+// podSecurityAdmissionVersion is the first version in which the PodSecurity admission is enabled by default.
+var podSecurityAdmissionVersion = version.MustParseGeneric("v1.23.0")
+
+// Capabilities are the APIs and features of the super cluster.
+type Capabilities struct {
+	KubernetesVersion string
+	APIGroupVersions  []string
+	RuntimeClasses    []string
+	CSIDrivers        []string
+}
+
+// Probe inspects the super cluster through client.
+func Probe(ctx context.Context, client clientset.Interface) (*Capabilities, error) {
+	info, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := client.Discovery().ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Capabilities{KubernetesVersion: info.GitVersion}
+	for _, g := range groups.Groups {
+		for _, v := range g.Versions {
+			c.APIGroupVersions = append(c.APIGroupVersions, v.GroupVersion)
+		}
+	}
+	sort.Strings(c.APIGroupVersions)
+
+	if c.Serves("node.k8s.io/v1") {
+		runtimeClasses, err := client.NodeV1().RuntimeClasses().List(ctx, metav1.ListOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if runtimeClasses != nil {
+			for _, rc := range runtimeClasses.Items {
+				c.RuntimeClasses = append(c.RuntimeClasses, rc.Name)
+			}
+		}
+	}
+	if c.Serves("storage.k8s.io/v1") {
+		drivers, err := client.StorageV1().CSIDrivers().List(ctx, metav1.ListOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if drivers != nil {
+			for _, d := range drivers.Items {
+				c.CSIDrivers = append(c.CSIDrivers, d.Name)
+			}
+		}
+	}
+	sort.Strings(c.RuntimeClasses)
+	sort.Strings(c.CSIDrivers)
+	return c, nil
+}
+
+// Serves returns whether the super cluster serves groupVersion.
+func (c *Capabilities) Serves(groupVersion string) bool {
+	return sets.NewString(c.APIGroupVersions...).Has(groupVersion)
+}
+
+// PodSecurityAdmission returns whether the super cluster version enables the PodSecurity admission by default.
+func (c *Capabilities) PodSecurityAdmission() bool {
+	v, err := version.ParseGeneric(c.KubernetesVersion)
+	if err != nil {
+		return false
+	}
+	return v.AtLeast(podSecurityAdmissionVersion)
+}
+
+// Data returns the ConfigMap data advertising the capabilities.
+func (c *Capabilities) Data() map[string]string {
+	return map[string]string{
+		KubernetesVersionKey:    c.KubernetesVersion,
+		APIGroupVersionsKey:     strings.Join(c.APIGroupVersions, "\n"),
+		RuntimeClassesKey:       strings.Join(c.RuntimeClasses, ","),
+		CSIDriversKey:           strings.Join(c.CSIDrivers, ","),
+		EndpointSlicesKey:       strconv.FormatBool(c.Serves("discovery.k8s.io/v1") || c.Serves("discovery.k8s.io/v1beta1")),
+		PodSecurityAdmissionKey: strconv.FormatBool(c.PodSecurityAdmission()),
+	}
+}
+
+// BuildConfigMap returns the capability ConfigMap installed in the tenant clusters.
+func BuildConfigMap(c *Capabilities) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: ConfigMapNamespace,
+			Labels:    map[string]string{constants.LabelControlled: "true"},
+		},
+		Data: c.Data(),
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capability
+
+import (
+	"context"
+	"testing"
+
+	nodev1 "k8s.io/api/node/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestProbe(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		gitVersion    string
+		groupVersions []string
+		expect        map[string]string
+	}{
+		{
+			name:          "recent super cluster",
+			gitVersion:    "v1.24.3",
+			groupVersions: []string{"v1", "discovery.k8s.io/v1", "node.k8s.io/v1", "storage.k8s.io/v1"},
+			expect: map[string]string{
+				KubernetesVersionKey:    "v1.24.3",
+				APIGroupVersionsKey:     "discovery.k8s.io/v1\nnode.k8s.io/v1\nstorage.k8s.io/v1\nv1",
+				RuntimeClassesKey:       "gvisor,kata",
+				CSIDriversKey:           "ebs.csi.aws.com",
+				EndpointSlicesKey:       "true",
+				PodSecurityAdmissionKey: "true",
+			},
+		},
+		{
+			name:          "old super cluster",
+			gitVersion:    "v1.18.20",
+			groupVersions: []string{"v1", "node.k8s.io/v1beta1"},
+			expect: map[string]string{
+				KubernetesVersionKey:    "v1.18.20",
+				APIGroupVersionsKey:     "node.k8s.io/v1beta1\nv1",
+				RuntimeClassesKey:       "",
+				CSIDriversKey:           "",
+				EndpointSlicesKey:       "false",
+				PodSecurityAdmissionKey: "false",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(
+				&nodev1.RuntimeClass{ObjectMeta: metav1.ObjectMeta{Name: "kata"}},
+				&nodev1.RuntimeClass{ObjectMeta: metav1.ObjectMeta{Name: "gvisor"}},
+				&storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "ebs.csi.aws.com"}},
+			)
+			discovery := client.Discovery().(*fakediscovery.FakeDiscovery)
+			discovery.FakedServerVersion = &version.Info{GitVersion: tc.gitVersion}
+			for _, gv := range tc.groupVersions {
+				discovery.Resources = append(discovery.Resources, &metav1.APIResourceList{GroupVersion: gv})
+			}
+
+			caps, err := Probe(context.TODO(), client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data := BuildConfigMap(caps).Data
+			for k, v := range tc.expect {
+				if data[k] != v {
+					t.Errorf("expected %s to be %q, got %q", k, v, data[k])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capability
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+// DefaultInterval is the default interval between two probes of the super cluster.
+const DefaultInterval = 10 * time.Minute
+
+// Publisher periodically probes the super cluster and installs the capability ConfigMap into
+// every tenant cluster that is watched by the syncer.
+type Publisher struct {
+	superClient clientset.Interface
+
+	mu       sync.Mutex
+	current  *Capabilities
+	clusters map[string]mc.ClusterInterface
+}
+
+var _ listener.ClusterChangeListener = &Publisher{}
+
+// NewPublisher returns a Publisher probing the super cluster through superClient.
+func NewPublisher(superClient clientset.Interface) *Publisher {
+	return &Publisher{
+		superClient: superClient,
+		clusters:    make(map[string]mc.ClusterInterface),
+	}
+}
+
+func (p *Publisher) AddCluster(cluster mc.ClusterInterface) {}
+
+func (p *Publisher) RemoveCluster(cluster mc.ClusterInterface) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clusters, cluster.GetClusterName())
+}
+
+func (p *Publisher) WatchCluster(cluster mc.ClusterInterface) {
+	p.mu.Lock()
+	p.clusters[cluster.GetClusterName()] = cluster
+	current := p.current
+	p.mu.Unlock()
+
+	if current != nil {
+		p.publish(cluster, current)
+	}
+}
+
+// Run probes the super cluster every interval and publishes the capabilities to the tenant
+// clusters whenever they change, until stopCh is closed.
+func (p *Publisher) Run(interval time.Duration, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	wait.Until(p.probe, interval, stopCh)
+}
+
+func (p *Publisher) probe() {
+	caps, err := Probe(context.TODO(), p.superClient)
+	if err != nil {
+		klog.Errorf("failed to probe super cluster capabilities: %v", err)
+		return
+	}
+
+	p.mu.Lock()
+	p.current = caps
+	clusters := make([]mc.ClusterInterface, 0, len(p.clusters))
+	for _, c := range p.clusters {
+		clusters = append(clusters, c)
+	}
+	p.mu.Unlock()
+
+	for _, c := range clusters {
+		p.publish(c, caps)
+	}
+}
+
+// publish creates or updates the capability ConfigMap in cluster.
+func (p *Publisher) publish(cluster mc.ClusterInterface, caps *Capabilities) {
+	cs, err := cluster.GetClientSet()
+	if err != nil {
+		klog.Errorf("failed to get clientset of cluster %s: %v", cluster.GetClusterName(), err)
+		return
+	}
+
+	expected := BuildConfigMap(caps)
+	configMaps := cs.CoreV1().ConfigMaps(ConfigMapNamespace)
+	current, err := configMaps.Get(context.TODO(), ConfigMapName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = configMaps.Create(context.TODO(), expected, metav1.CreateOptions{})
+	case err == nil:
+		if equality.Semantic.DeepEqual(current.Data, expected.Data) {
+			return
+		}
+		current.Data = expected.Data
+		_, err = configMaps.Update(context.TODO(), current, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("failed to publish super cluster capabilities in cluster %s: %v", cluster.GetClusterName(), err)
+		return
+	}
+	klog.V(4).Infof("super cluster capabilities published in cluster %s", cluster.GetClusterName())
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conflict
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+// Policy decides which side wins when a field is changed in both tenant and super cluster.
+type Policy string
+
+const (
+	// TenantWins overwrites the super cluster field with the tenant value. It is the default.
+	TenantWins Policy = "TenantWins"
+	// SuperWins keeps the super cluster field and drops the tenant change.
+	SuperWins Policy = "SuperWins"
+)
+
+// fieldSeparator separates the resource and the field path in a policy key,
+// e.g. "pods/metadata.labels".
+const fieldSeparator = "/"
+
+// DefaultSystemManagers are the super cluster field managers whose changes are never conflicts,
+// i.e. the control plane components populating the fields of the synced objects, e.g. the
+// scheduler binding the pods.
+var DefaultSystemManagers = []string{
+	"kube-apiserver",
+	"kube-controller-manager",
+	"kube-scheduler",
+	"kubelet",
+	"cloud-controller-manager",
+}
+
+// syncedFields are the field paths synced downward per resource. The other fields, e.g. the
+// finalizers or the owner references of the super cluster object, are never in conflict.
+var syncedFields = map[string][]string{
+	"configmaps": {"metadata.labels", "metadata.annotations", "data", "binaryData"},
+	"secrets":    {"metadata.labels", "metadata.annotations", "data", "type"},
+}
+
+// defaultSyncedFields are the field paths synced downward of the resources not in syncedFields.
+var defaultSyncedFields = []string{"metadata.labels", "metadata.annotations", "spec"}
+
+// EventRecorder records events in tenant clusters.
+type EventRecorder interface {
+	Eventf(clusterName string, ref *corev1.ObjectReference, eventtype string, reason, messageFmt string, args ...interface{}) error
+}
+
+// Conflict is a field changed in both tenant and super cluster since the last sync.
+type Conflict struct {
+	// Field is the dotted path of the field.
+	Field string
+	// Manager is the super cluster field manager that changed the field.
+	Manager string
+	// Policy is the policy applied to the field.
+	Policy Policy
+}
+
+// Resolver resolves the downward sync conflicts of one resource.
+type Resolver struct {
+	resource      string
+	defaultPolicy Policy
+	// fieldPolicies overrides defaultPolicy for fields matching the path prefix.
+	fieldPolicies map[string]Policy
+	// fieldManager is the field manager of the syncer writes.
+	fieldManager string
+	// systemManagers are the field managers whose changes are not conflicts.
+	systemManagers map[string]bool
+	recorder       EventRecorder
+}
+
+// ValidatePolicies checks policies configured as resource[/field.path]=Policy.
+func ValidatePolicies(policies map[string]string) error {
+	for key, p := range policies {
+		if strings.TrimSpace(strings.SplitN(key, fieldSeparator, 2)[0]) == "" {
+			return fmt.Errorf("invalid conflict policy key %q, expected <resource>[/<field path>]", key)
+		}
+		switch Policy(p) {
+		case TenantWins, SuperWins:
+		default:
+			return fmt.Errorf("invalid conflict policy %q for %q, expected %s or %s", p, key, TenantWins, SuperWins)
+		}
+	}
+	return nil
+}
+
+// NewResolver creates a Resolver for resource from the configured policies, the changes made by
+// systemManagers are not conflicts.
+func NewResolver(resource string, policies map[string]string, systemManagers []string, recorder EventRecorder) *Resolver {
+	r := &Resolver{
+		resource:       resource,
+		defaultPolicy:  TenantWins,
+		fieldPolicies:  make(map[string]Policy),
+		fieldManager:   strings.SplitN(utilconstants.ResourceSyncerUserAgent, "/", 2)[0],
+		systemManagers: make(map[string]bool),
+		recorder:       recorder,
+	}
+	for _, m := range systemManagers {
+		r.systemManagers[m] = true
+	}
+	for key, p := range policies {
+		kv := strings.SplitN(key, fieldSeparator, 2)
+		if kv[0] != resource {
+			continue
+		}
+		if len(kv) == 1 {
+			r.defaultPolicy = Policy(p)
+		} else {
+			r.fieldPolicies[kv[1]] = Policy(p)
+		}
+	}
+	return r
+}
+
+// policyFor returns the policy of the longest matching field rule, or the resource default.
+func (r *Resolver) policyFor(field string) Policy {
+	policy, matched := r.defaultPolicy, ""
+	for prefix, p := range r.fieldPolicies {
+		if (field == prefix || strings.HasPrefix(field, prefix+".")) && len(prefix) > len(matched) {
+			policy, matched = p, prefix
+		}
+	}
+	return policy
+}
+
+// synced returns whether the field at path is synced downward.
+func (r *Resolver) synced(path []string) bool {
+	fields, ok := syncedFields[r.resource]
+	if !ok {
+		fields = defaultSyncedFields
+	}
+	field := strings.Join(path, ".")
+	for _, prefix := range fields {
+		if field == prefix || strings.HasPrefix(field, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve checks the fields in which updated, the super cluster object computed from vObj, differs
+// from pObj. A synced field is in conflict if it is also managed in pObj by a field manager other
+// than the syncer and the system managers, which means it was changed in both clusters since the
+// last sync. Conflicting fields
+// under SuperWins are reverted to the pObj value in updated. Conflicts are counted in metrics and
+// recorded as events of vObj in the tenant cluster.
+//
+// It returns whether updated still differs from pObj.
+func (r *Resolver) Resolve(clusterName string, vObj, pObj, updated client.Object) (bool, error) {
+	managed := r.foreignManagedFields(pObj)
+	if len(managed) == 0 {
+		return true, nil
+	}
+
+	pU, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pObj)
+	if err != nil {
+		return false, err
+	}
+	uU, err := runtime.DefaultUnstructuredConverter.ToUnstructured(updated)
+	if err != nil {
+		return false, err
+	}
+
+	var conflicts []Conflict
+	for field, mf := range managed {
+		path := mf.path
+		pv, pFound, _ := unstructured.NestedFieldNoCopy(pU, path...)
+		uv, uFound, _ := unstructured.NestedFieldNoCopy(uU, path...)
+		if pFound == uFound && equality.Semantic.DeepEqual(pv, uv) {
+			continue
+		}
+		c := Conflict{Field: field, Manager: mf.manager, Policy: r.policyFor(field)}
+		if c.Policy == SuperWins {
+			if pFound {
+				err = unstructured.SetNestedField(uU, runtime.DeepCopyJSONValue(pv), path...)
+			} else {
+				unstructured.RemoveNestedField(uU, path...)
+			}
+			if err != nil {
+				return false, err
+			}
+		}
+		conflicts = append(conflicts, c)
+	}
+	if len(conflicts) == 0 {
+		return true, nil
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(uU, updated); err != nil {
+		return false, err
+	}
+	r.record(clusterName, vObj, conflicts)
+	return !equality.Semantic.DeepEqual(pU, uU), nil
+}
+
+func (r *Resolver) record(clusterName string, vObj client.Object, conflicts []Conflict) {
+	ref, err := reference.GetReference(scheme.Scheme, vObj)
+	if err != nil {
+		klog.Errorf("failed to get reference of %s/%s: %v", vObj.GetNamespace(), vObj.GetName(), err)
+	}
+	for _, c := range conflicts {
+		metrics.SyncConflictCounter.WithLabelValues(r.resource, string(c.Policy)).Inc()
+		klog.Warningf("field %s of %s %s/%s in cluster %s was changed by %s in super cluster, %s applied",
+			c.Field, r.resource, vObj.GetNamespace(), vObj.GetName(), clusterName, c.Manager, c.Policy)
+		if r.recorder == nil || ref == nil {
+			continue
+		}
+		if err := r.recorder.Eventf(clusterName, ref, corev1.EventTypeWarning, constants.ReasonSyncConflict,
+			"Field %s was also changed by %s in super cluster, %s applied", c.Field, c.Manager, c.Policy); err != nil {
+			klog.Errorf("failed to record sync conflict event: %v", err)
+		}
+	}
+}
+
+type managedField struct {
+	path    []string
+	manager string
+}
+
+// foreignManagedFields returns the synced pObj fields managed by a manager other than the syncer
+// and the system managers, keyed by their dotted paths. Paths stop at the first list element, since list items are
+// compared as a whole.
+func (r *Resolver) foreignManagedFields(pObj client.Object) map[string]managedField {
+	fields := make(map[string]managedField)
+	for _, entry := range pObj.GetManagedFields() {
+		if entry.Manager == r.fieldManager || r.systemManagers[entry.Manager] || entry.FieldsV1 == nil {
+			continue
+		}
+		var set map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &set); err != nil {
+			klog.Errorf("failed to decode managed fields of %s/%s: %v", pObj.GetNamespace(), pObj.GetName(), err)
+			continue
+		}
+		walkFields(set, nil, func(path []string) {
+			if r.synced(path) {
+				fields[strings.Join(path, ".")] = managedField{path: path, manager: entry.Manager}
+			}
+		})
+	}
+	return fields
+}
+
+// walkFields calls fn for the leaf field paths of a FieldsV1 set.
+func walkFields(set map[string]interface{}, prefix []string, fn func([]string)) {
+	leaf := true
+	for key, child := range set {
+		if !strings.HasPrefix(key, "f:") {
+			continue
+		}
+		leaf = false
+		path := append(append([]string{}, prefix...), strings.TrimPrefix(key, "f:"))
+		if m, ok := child.(map[string]interface{}); ok && len(m) > 0 {
+			walkFields(m, path, fn)
+		} else {
+			fn(path)
+		}
+	}
+	if leaf && len(prefix) > 0 {
+		fn(prefix)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conflict
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeRecorder struct {
+	reasons []string
+}
+
+func (f *fakeRecorder) Eventf(clusterName string, ref *corev1.ObjectReference, eventtype string, reason, messageFmt string, args ...interface{}) error {
+	f.reasons = append(f.reasons, reason)
+	return nil
+}
+
+func newConfigMaps(manager string) (vObj, pObj, updated *corev1.ConfigMap) {
+	pObj = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cm",
+			Namespace: "ns",
+			Labels:    map[string]string{"app.kubernetes.io/name": "super"},
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{
+					Manager:  manager,
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{".":{},"f:app.kubernetes.io/name":{}}}}`)},
+				},
+			},
+		},
+		Data: map[string]string{"a": "b"},
+	}
+	vObj = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cm",
+			Namespace: "default",
+			Labels:    map[string]string{"app.kubernetes.io/name": "tenant"},
+		},
+		Data: map[string]string{"a": "b"},
+	}
+	updated = pObj.DeepCopy()
+	updated.Labels = map[string]string{"app.kubernetes.io/name": "tenant"}
+	return
+}
+
+func TestResolve(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		manager       string
+		policies      map[string]string
+		expectLabel   string
+		expectChanged bool
+		expectEvents  int
+	}{
+		{
+			name:          "changed by syncer only",
+			manager:       "resource-syncer",
+			policies:      map[string]string{"configmaps": "SuperWins"},
+			expectLabel:   "tenant",
+			expectChanged: true,
+		},
+		{
+			name:          "changed by a system manager",
+			manager:       "kube-controller-manager",
+			policies:      map[string]string{"configmaps": "SuperWins"},
+			expectLabel:   "tenant",
+			expectChanged: true,
+		},
+		{
+			name:          "tenant wins by default",
+			manager:       "kubectl",
+			expectLabel:   "tenant",
+			expectChanged: true,
+			expectEvents:  1,
+		},
+		{
+			name:         "super wins",
+			manager:      "kubectl",
+			policies:     map[string]string{"configmaps": "SuperWins"},
+			expectLabel:  "super",
+			expectEvents: 1,
+		},
+		{
+			name:          "field rule overrides resource policy",
+			manager:       "kubectl",
+			policies:      map[string]string{"configmaps": "SuperWins", "configmaps/metadata.labels": "TenantWins"},
+			expectLabel:   "tenant",
+			expectChanged: true,
+			expectEvents:  1,
+		},
+		{
+			name:          "rules of other resources are ignored",
+			manager:       "kubectl",
+			policies:      map[string]string{"pods": "SuperWins"},
+			expectLabel:   "tenant",
+			expectChanged: true,
+			expectEvents:  1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &fakeRecorder{}
+			vObj, pObj, updated := newConfigMaps(tc.manager)
+			changed, err := NewResolver("configmaps", tc.policies, DefaultSystemManagers, recorder).Resolve("cluster", vObj, pObj, updated)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if changed != tc.expectChanged {
+				t.Errorf("expected changed %v, got %v", tc.expectChanged, changed)
+			}
+			if got := updated.Labels["app.kubernetes.io/name"]; got != tc.expectLabel {
+				t.Errorf("expected label %s, got %s", tc.expectLabel, got)
+			}
+			if len(recorder.reasons) != tc.expectEvents {
+				t.Errorf("expected %d events, got %v", tc.expectEvents, recorder.reasons)
+			}
+		})
+	}
+}
+
+func TestResolveSyncedFields(t *testing.T) {
+	recorder := &fakeRecorder{}
+	vObj, pObj, updated := newConfigMaps("kubectl")
+	pObj.Labels = vObj.Labels
+	pObj.Finalizers = []string{"example.com/protect"}
+	pObj.ManagedFields[0].FieldsV1 = &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:finalizers":{".":{},"v:\"example.com/protect\"":{}}}}`)}
+	updated = pObj.DeepCopy()
+	updated.Finalizers = nil
+
+	changed, err := NewResolver("configmaps", map[string]string{"configmaps": "SuperWins"}, DefaultSystemManagers, recorder).
+		Resolve("cluster", vObj, pObj, updated)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed || len(updated.Finalizers) != 0 {
+		t.Errorf("expected the fields not synced downward to be left to the caller, got changed %v and finalizers %v", changed, updated.Finalizers)
+	}
+	if len(recorder.reasons) != 0 {
+		t.Errorf("expected no conflict on the fields not synced downward, got %v", recorder.reasons)
+	}
+}
+
+func TestValidatePolicies(t *testing.T) {
+	if err := ValidatePolicies(map[string]string{"pods": "SuperWins", "pods/spec.activeDeadlineSeconds": "TenantWins"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidatePolicies(map[string]string{"pods": "LastWriterWins"}); err == nil {
+		t.Errorf("expected error for unknown policy")
+	}
+	if err := ValidatePolicies(map[string]string{"/metadata.labels": "SuperWins"}); err == nil {
+		t.Errorf("expected error for missing resource")
+	}
+}
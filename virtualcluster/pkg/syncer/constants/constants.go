@@ -54,6 +54,14 @@ const (
 	// (use featuregate.VirtualClusterApplyUpdate to enable it in the provisioner)
 	LabelVCReadyForUpgrade = "tenancy.x-k8s.io/ready-for-upgrade"
 
+	// LabelVCPaused is set to "true" to stop the vc-manager from reconciling the VirtualCluster,
+	// deletion is still handled while the cluster is paused.
+	LabelVCPaused = "tenancy.x-k8s.io/paused"
+
+	// LabelVCHibernated is set to "true" to stop the syncer from syncing the VirtualCluster, the informers
+	// and connections of the tenant cluster are closed until the label is removed.
+	LabelVCHibernated = "tenancy.x-k8s.io/hibernated"
+
 	// LabelClusterVersionApplied should be set equal to the ClusterVersion.metadata.resourceVersion value
 	// This label is used in featuregate.VirtualClusterApplyUpdate to compare if the update must be applied.
 	LabelClusterVersionApplied = "tenancy.x-k8s.io/cluster-version-applied"
@@ -64,6 +72,43 @@ const (
 	// LabelSecretUID is the service account token secret UID in tenant namespace.
 	LabelSecretUID = "tenancy.x-k8s.io/secret.UID" // #nosec G101 -- This is a label key
 
+	// LabelSemanticHash is the semantic hash of the tenant object the super cluster object was last synced from.
+	LabelSemanticHash = "tenancy.x-k8s.io/semantic-hash"
+
+	// LabelOwnershipSignature is the HMAC signature of the ownership annotations of the super cluster object.
+	LabelOwnershipSignature = "tenancy.x-k8s.io/ownership-signature"
+
+	// LabelRetainedUntil is the RFC3339 time the super cluster namespace of a deleted tenant namespace, or
+	// an object kept in it, is retained until (use featuregate.NamespaceRecycleBin to enable it).
+	LabelRetainedUntil = "tenancy.x-k8s.io/retained-until"
+
+	// LabelTeardownStarted is the RFC3339 time the ordered teardown of a super control plane namespace started.
+	LabelTeardownStarted = "tenancy.x-k8s.io/teardown-started"
+
+	// LabelSyncFreezeUntil is the RFC3339 time the downward sync of a tenant namespace is frozen until, it is
+	// set by the tenant and honored up to the maximum freeze duration of the syncer.
+	LabelSyncFreezeUntil = "tenancy.x-k8s.io/sync-freeze-until"
+
+	// LabelSyncFreezeStarted is the RFC3339 time the sync freeze of a tenant namespace started, it is set
+	// by the syncer on the super control plane namespace.
+	LabelSyncFreezeStarted = "tenancy.x-k8s.io/sync-freeze-started"
+
+	// LabelSecretChecksum is the checksum of the secrets mounted by a control plane pod template.
+	LabelSecretChecksum = "tenancy.x-k8s.io/secret-checksum" // #nosec G101 -- This is an annotation key
+
+	// LabelMirroredFinalizers is the comma separated list of the finalizers the syncer copied from the tenant object.
+	LabelMirroredFinalizers = "tenancy.x-k8s.io/mirrored-finalizers"
+
+	// LabelInjectedReadinessGates is the comma separated list of the readiness gates the syncer injected into a super pod.
+	LabelInjectedReadinessGates = "tenancy.x-k8s.io/injected-readiness-gates"
+
+	// LabelPodReadinessGates is the comma separated list of the readiness gates of the syncer a VirtualCluster opts into,
+	// they are only injected into the super pods of the VirtualClusters whose controllers set their conditions.
+	LabelPodReadinessGates = "tenancy.x-k8s.io/pod-readiness-gates"
+
+	// TaintSuperNodeMaintenance is added to a vNode when its super cluster node is cordoned for maintenance.
+	TaintSuperNodeMaintenance = "tenancy.x-k8s.io/super-node-maintenance"
+
 	// LabelTenantIgnoreSync is used by resources that do not need to be synced.
 	LabelTenantIgnoreSync = "tenancy.x-k8s.io/ignore-sync"
 
@@ -79,6 +124,8 @@ const (
 
 	TenantDNSServerNS          = "kube-system"
 	TenantDNSServerServiceName = "kube-dns"
+	// DefaultClusterDomain is the cluster domain of a virtual cluster that doesn't set one.
+	DefaultClusterDomain = "cluster.local"
 
 	// TenantDisableDNSPolicyMutation is a label that allows pods to stop the syncer from mutating the dnsPolicy
 	TenantDisableDNSPolicyMutation = "tenancy.x-k8s.io/disable.dnsPolicyMutation"
@@ -90,9 +137,19 @@ const (
 	// LabelSuperClusterID is a label key added to the vNode object in tenant when SuperClusterPooling feature is enabled.
 	LabelSuperClusterID = "tenancy.x-k8s.io/superclusterid"
 
+	// LabelSourceCluster is a label key added to the super control plane EndpointSlices imported from
+	// the super cluster whose id is the value, this is used for SuperClusterPooling service discovery.
+	LabelSourceCluster = "multicluster.kubernetes.io/source-cluster"
+	// EndpointSliceManagedBy is the manager of the super control plane EndpointSlices imported from
+	// the other super clusters of a pool.
+	EndpointSliceManagedBy = "vc-syncer.tenancy.x-k8s.io"
+
 	// DefaultvNodeGCGracePeriod is the grace period of time before deleting an orphan vNode in tenant control plane.
 	DefaultvNodeGCGracePeriod = time.Second * 120
 
+	// DefaultNamespaceTeardownTimeout is the default time the deletion of a super control plane namespace waits for its children.
+	DefaultNamespaceTeardownTimeout = 5 * time.Minute
+
 	DefaultOpaqueMetaPrefix      = "tenancy.x-k8s.io"
 	DefaultTransparentMetaPrefix = "transparency.tenancy.x-k8s.io"
 
@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package constants
+
+// The reasons of the events the syncer records on tenant objects when it rejects, mutates or deletes
+// something on behalf of the tenant. Tenants and auditors match on them, so they must not be renamed.
+const (
+	// ReasonFailedCreate is recorded when the super control plane object of a tenant object cannot be created.
+	ReasonFailedCreate = "FailedCreate"
+	// ReasonNotSupported is recorded when a tenant object uses a field the syncer does not support.
+	ReasonNotSupported = "NotSupported"
+	// ReasonUnsupportedSource is recorded when a tenant volume snapshot has a source the syncer does not support.
+	ReasonUnsupportedSource = "UnsupportedSource"
+	// ReasonExceededQuota is recorded when syncing a tenant object exceeds a quota of the tenant.
+	ReasonExceededQuota = "ExceededQuota"
+	// ReasonMetadataLimitExceeded is recorded when the metadata of a tenant object exceeds the metadata limits.
+	ReasonMetadataLimitExceeded = "MetadataLimitExceeded"
+	// ReasonObjectLimitExceeded is recorded when a tenant object exceeds the object limits of its VirtualCluster.
+	ReasonObjectLimitExceeded = "ObjectLimitExceeded"
+	// ReasonRejectedByValidation is recorded when a tenant pod is rejected by the validation plugin.
+	ReasonRejectedByValidation = "RejectedByValidation"
+	// ReasonMutatedByPolicy is recorded when the TenantPodPolicy changes the spec of a tenant pod in the super control plane.
+	ReasonMutatedByPolicy = "MutatedByPolicy"
+	// ReasonSyncConflict is recorded when a synced field is also changed in the super control plane.
+	ReasonSyncConflict = "SyncConflict"
+	// ReasonDeletedBySuperCluster is recorded when a tenant pod is deleted because its super control plane pod is deleted.
+	ReasonDeletedBySuperCluster = "DeletedBySuperCluster"
+	// ReasonPatrolRemediation is recorded when the periodic checker deletes a tenant object to repair an inconsistency.
+	ReasonPatrolRemediation = "PatrolRemediation"
+	// ReasonSyncFreezeRejected is recorded when the sync freeze requested by a tenant namespace is not honored.
+	ReasonSyncFreezeRejected = "SyncFreezeRejected"
+	// ReasonNodeMaintenance is recorded when the super control plane node of a vNode is under maintenance.
+	ReasonNodeMaintenance = "NodeMaintenance"
+	// ReasonNodeMaintenanceCompleted is recorded when the super control plane node of a vNode is back in service.
+	ReasonNodeMaintenanceCompleted = "NodeMaintenanceCompleted"
+	// ReasonForbiddenTLSSecret is recorded when a tenant ingress uses a TLS certificate that isn't a secret of the tenant.
+	ReasonForbiddenTLSSecret = "ForbiddenTLSSecret"
+)
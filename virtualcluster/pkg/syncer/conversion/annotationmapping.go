@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// AnnotationMapper translates the annotations of the tenant services and ingresses
+// to the ones approved in the super cluster, e.g. the external-dns and the cloud
+// load balancer annotations.
+type AnnotationMapper struct {
+	config *config.SyncerConfiguration
+}
+
+// NewAnnotationMapper returns the annotation mapper of the syncer configuration.
+func NewAnnotationMapper(config *config.SyncerConfiguration) AnnotationMapper {
+	return AnnotationMapper{config: config}
+}
+
+// Enabled returns true if any mapping or guarded prefix is configured, otherwise
+// the tenant annotations are synced as they are.
+func (m AnnotationMapper) Enabled() bool {
+	return m.config != nil && (len(m.config.AnnotationMappings) > 0 || len(m.config.GuardedAnnotationPrefixes) > 0)
+}
+
+// MapDownward returns the annotations of a tenant object translated to the super
+// cluster, kv is not modified. The syncer annotations are kept as they are. If
+// several annotations are translated to the same key, the first one in key order wins.
+func (m AnnotationMapper) MapDownward(kv map[string]string) map[string]string {
+	if !m.Enabled() || kv == nil {
+		return kv
+	}
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	mapped := make(map[string]string, len(kv))
+	for _, k := range keys {
+		key, ok := m.mapKey(k)
+		if !ok {
+			continue
+		}
+		if _, exists := mapped[key]; !exists {
+			mapped[key] = kv[k]
+		}
+	}
+	return mapped
+}
+
+// mapKey returns the super cluster key of a tenant annotation key, or false if the
+// annotation is dropped.
+func (m AnnotationMapper) mapKey(key string) (string, bool) {
+	if strings.HasPrefix(key, constants.DefaultOpaqueMetaPrefix) {
+		return key, true
+	}
+
+	var prefix string
+	found := false
+	for p := range m.config.AnnotationMappings {
+		if strings.HasPrefix(key, p) && (!found || len(p) > len(prefix)) {
+			prefix, found = p, true
+		}
+	}
+	if !found {
+		return key, !hasPrefixInArray(key, m.config.GuardedAnnotationPrefixes)
+	}
+
+	target := m.config.AnnotationMappings[prefix]
+	if target == "" {
+		return "", false
+	}
+	mapped := target + strings.TrimPrefix(key, prefix)
+	// tenants must not forge the syncer annotations through a mapping.
+	return mapped, !strings.HasPrefix(mapped, constants.DefaultOpaqueMetaPrefix)
+}
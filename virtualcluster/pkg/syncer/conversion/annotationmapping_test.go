@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func TestAnnotationMapperMapDownward(t *testing.T) {
+	mappings := &config.SyncerConfiguration{
+		AnnotationMappings: map[string]string{
+			"dns.example.com/":                       "external-dns.alpha.kubernetes.io/",
+			"service.beta.kubernetes.io/":            "",
+			"service.beta.kubernetes.io/lb-internal": "service.beta.kubernetes.io/lb-internal",
+			"forge/":                                 constants.DefaultOpaqueMetaPrefix + "/",
+		},
+		GuardedAnnotationPrefixes: []string{"external-dns.alpha.kubernetes.io/"},
+	}
+	for _, tc := range []struct {
+		name     string
+		config   *config.SyncerConfiguration
+		in       map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "disabled",
+			config:   &config.SyncerConfiguration{},
+			in:       map[string]string{"dns.example.com/hostname": "a.example.com"},
+			expected: map[string]string{"dns.example.com/hostname": "a.example.com"},
+		},
+		{
+			name:   "mappings",
+			config: mappings,
+			in: map[string]string{
+				"dns.example.com/hostname":               "a.example.com",
+				"service.beta.kubernetes.io/lb-internal": "true",
+				"service.beta.kubernetes.io/lb-subnets":  "subnet-1",
+				"other":                                  "x",
+				constants.LabelCluster:                   "cluster",
+			},
+			expected: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "a.example.com",
+				"service.beta.kubernetes.io/lb-internal":    "true",
+				"other":                                     "x",
+				constants.LabelCluster:                      "cluster",
+			},
+		},
+		{
+			name:     "guarded prefixes are only synced through a mapping",
+			config:   mappings,
+			in:       map[string]string{"external-dns.alpha.kubernetes.io/hostname": "victim.example.com"},
+			expected: map[string]string{},
+		},
+		{
+			name:     "the first annotation in key order wins",
+			config:   &config.SyncerConfiguration{AnnotationMappings: map[string]string{"dns.example.com/": "external-dns.alpha.kubernetes.io/"}},
+			in:       map[string]string{"dns.example.com/hostname": "a.example.com", "external-dns.alpha.kubernetes.io/hostname": "b.example.com"},
+			expected: map[string]string{"external-dns.alpha.kubernetes.io/hostname": "a.example.com"},
+		},
+		{
+			name:     "syncer annotations can't be forged",
+			config:   mappings,
+			in:       map[string]string{"forge/uid": "123"},
+			expected: map[string]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NewAnnotationMapper(tc.config).MapDownward(tc.in)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckServiceEqualityAnnotationMappings(t *testing.T) {
+	e := Equality(&config.SyncerConfiguration{
+		AnnotationMappings: map[string]string{"dns.example.com/": "external-dns.alpha.kubernetes.io/"},
+	}, &v1alpha1.VirtualCluster{})
+	vService := &v1.Service{}
+	vService.Annotations = map[string]string{"dns.example.com/hostname": "a.example.com"}
+	pService := vService.DeepCopy()
+	pService.Annotations = map[string]string{"external-dns.alpha.kubernetes.io/hostname": "a.example.com"}
+
+	if updated := e.CheckServiceEquality(pService, vService); updated != nil {
+		t.Errorf("expected no update, got annotations %v", updated.Annotations)
+	}
+
+	vService.Annotations["dns.example.com/hostname"] = "b.example.com"
+	updated := e.CheckServiceEquality(pService, vService)
+	expected := map[string]string{"external-dns.alpha.kubernetes.io/hostname": "b.example.com"}
+	if updated == nil || !reflect.DeepEqual(updated.Annotations, expected) {
+		t.Errorf("expected annotations %v, got %v", expected, updated)
+	}
+}
+
+func TestCheckIngressEqualityAnnotationMappings(t *testing.T) {
+	e := Equality(&config.SyncerConfiguration{
+		AnnotationMappings:        map[string]string{"dns.example.com/": "external-dns.alpha.kubernetes.io/"},
+		GuardedAnnotationPrefixes: []string{"external-dns.alpha.kubernetes.io/"},
+	}, &v1alpha1.VirtualCluster{})
+	vIngress := &networkingv1.Ingress{}
+	vIngress.Annotations = map[string]string{"dns.example.com/hostname": "a.example.com"}
+	pIngress := vIngress.DeepCopy()
+	pIngress.Annotations = map[string]string{"external-dns.alpha.kubernetes.io/hostname": "a.example.com"}
+
+	if updated := e.CheckIngressEquality(pIngress, vIngress); updated != nil {
+		t.Errorf("expected no update, got annotations %v", updated.Annotations)
+	}
+
+	// an update adding a guarded annotation does not bypass the mapping
+	vIngress.Annotations["external-dns.alpha.kubernetes.io/target"] = "1.2.3.4"
+	if updated := e.CheckIngressEquality(pIngress, vIngress); updated != nil {
+		t.Errorf("expected the guarded annotation to be dropped, got annotations %v", updated.Annotations)
+	}
+
+	vIngress.Annotations["dns.example.com/hostname"] = "b.example.com"
+	updated := e.CheckIngressEquality(pIngress, vIngress)
+	expected := map[string]string{"external-dns.alpha.kubernetes.io/hostname": "b.example.com"}
+	if updated == nil || !reflect.DeepEqual(updated.Annotations, expected) {
+		t.Errorf("expected annotations %v, got %v", expected, updated)
+	}
+}
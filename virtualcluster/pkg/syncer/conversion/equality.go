@@ -29,10 +29,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metalimit"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 )
 
@@ -77,14 +79,13 @@ func (e vcEquality) CheckPodEquality(pPod, vPod *v1.Pod) *v1.Pod {
 // report readiness state to pod conditions. In other words, the source of truth for user-defined
 // pod conditions should be tenant side controller, only the condition reported by kubelet should
 // keep consistent with super.
+// The readiness gates injected by the syncer are user-defined too. The conditions reported by kubelet
+// are never synced downward, even if the tenant declares them as readiness gates.
 // It is not recommended that webhook in super append other readiness gate to pod. we left them unchanged
 // in super, don't sync them upward to tenant side.
 // ref https://kubernetes.io/docs/concepts/workloads/pods/pod-lifecycle/#pod-readiness-gate
 func CheckDWPodConditionEquality(pPod, vPod *v1.Pod) *v1.PodStatus {
-	readinessGateSet := sets.NewString()
-	for _, r := range vPod.Spec.ReadinessGates {
-		readinessGateSet.Insert(string(r.ConditionType))
-	}
+	readinessGateSet := tenantReadinessGates(pPod, vPod)
 	vConditionMap := make(map[string]v1.PodCondition)
 	for _, c := range vPod.Status.Conditions {
 		if readinessGateSet.Has(string(c.Type)) {
@@ -115,6 +116,26 @@ func CheckDWPodConditionEquality(pPod, vPod *v1.Pod) *v1.PodStatus {
 	return nil
 }
 
+// CheckDWPodResourcesEquality returns the tenant containers whose resources differ from
+// the super pod, only the name and resources of the containers are set. The resources
+// can't be updated, they are resized in place through the pod resize subresource.
+func CheckDWPodResourcesEquality(pPod, vPod *v1.Pod) []v1.Container {
+	pResources := make(map[string]v1.ResourceRequirements)
+	for _, c := range pPod.Spec.Containers {
+		pResources[c.Name] = c.Resources
+	}
+
+	var resized []v1.Container
+	for _, c := range vPod.Spec.Containers {
+		p, exists := pResources[c.Name]
+		if !exists || equality.Semantic.DeepEqual(p, c.Resources) {
+			continue
+		}
+		resized = append(resized, v1.Container{Name: c.Name, Resources: *c.Resources.DeepCopy()})
+	}
+	return resized
+}
+
 // CheckDWObjectMetaEquality check whether super control plane object meta and virtual object meta
 // are logically equal. The source of truth is virtual object.
 // Reference to ObjectMetaUpdateValidation: https://github.com/kubernetes/kubernetes/blob/release-1.15/staging/src/k8s.io/apimachinery/pkg/api/validation/objectmeta.go#L227
@@ -231,6 +252,34 @@ func (e vcEquality) checkUWKVEquality(pKV, vKV map[string]string) (map[string]st
 // are logically equal. If not, return the updated value. The source of truth is virtual object.
 // The exceptional keys that used by super control plane object are specified in
 // VC.Spec.TransparentMetaPrefixes plus an ignorelist (e.g., tenancy.x-k8s.io).
+// CheckMetadataLimits checks the metadata of the super cluster object updated from a tenant object
+// against the metadata limits, like the super cluster objects built on create. The keys owned by the
+// syncer are not counted.
+func (e vcEquality) CheckMetadataLimits(vObj, updated client.Object) error {
+	synced := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+		Labels:      e.tenantKeys(updated.GetLabels()),
+		Annotations: e.tenantKeys(updated.GetAnnotations()),
+	}}
+	return metalimit.Check(e.config, vObj, synced)
+}
+
+// tenantKeys returns the keys of kv synced from the tenant object, the ones checkDWKVEquality syncs.
+func (e vcEquality) tenantKeys(kv map[string]string) map[string]string {
+	exceptionsList := []string{constants.DefaultOpaqueMetaPrefix, constants.DefaultTransparentMetaPrefix}
+	if e.vc != nil {
+		exceptionsList = append(exceptionsList, e.vc.Spec.TransparentMetaPrefixes...)
+		exceptionsList = append(exceptionsList, e.vc.Spec.OpaqueMetaPrefixes...)
+	}
+	synced := make(map[string]string, len(kv))
+	for k, v := range kv {
+		if hasPrefixInArray(k, exceptionsList) || isOpaquedKey(e.config, k) {
+			continue
+		}
+		synced[k] = v
+	}
+	return synced
+}
+
 func (e vcEquality) checkDWKVEquality(pKV, vKV map[string]string) (map[string]string, bool) {
 	var exceptionsList []string
 	if e.vc != nil {
@@ -310,14 +359,12 @@ func isOpaquedKey(config *config.SyncerConfiguration, key string) bool {
 
 // CheckUWPodStatusEquality compute status upward to tenant.
 // User-defined readiness type condition unchanged in tenant, others
-// keep consistent with super.
+// keep consistent with super. The readiness gates injected by the syncer
+// are user-defined.
 func (e vcEquality) CheckUWPodStatusEquality(pObj, vObj *v1.Pod) *v1.PodStatus {
 	newVStatus := pObj.Status.DeepCopy()
 
-	vReadinessGateSet := sets.NewString()
-	for _, r := range vObj.Spec.ReadinessGates {
-		vReadinessGateSet.Insert(string(r.ConditionType))
-	}
+	vReadinessGateSet := tenantReadinessGates(pObj, vObj)
 	pReadinessGateSet := sets.NewString()
 	for _, r := range pObj.Spec.ReadinessGates {
 		pReadinessGateSet.Insert(string(r.ConditionType))
@@ -331,7 +378,7 @@ func (e vcEquality) CheckUWPodStatusEquality(pObj, vObj *v1.Pod) *v1.PodStatus {
 
 	for i := 0; i < len(newVStatus.Conditions); i++ {
 		c := newVStatus.Conditions[i]
-		if !pReadinessGateSet.Has(string(c.Type)) && !vReadinessGateSet.Has(string(c.Type)) {
+		if !pReadinessGateSet.Has(string(c.Type)) && !vReadinessGateSet.Has(string(c.Type)) || kubeletConditions.Has(string(c.Type)) {
 			continue
 		}
 
@@ -646,16 +693,23 @@ func (e vcEquality) CheckCRDEquality(pObj, vObj *apiextensionsv1.CustomResourceD
 }
 
 func (e vcEquality) CheckIngressEquality(pObj, vObj *v1networking.Ingress) *v1networking.Ingress {
-	pObjCopy := pObj.DeepCopy()
-	pObjCopy.ObjectMeta = vObj.ObjectMeta
-	// pObj.TypeMeta is empty
-	pObjCopy.TypeMeta = vObj.TypeMeta
+	var updated *v1networking.Ingress
+	// The tenant annotations are mapped to the super cluster ones, as when the ingress is created.
+	vMeta := vObj.ObjectMeta.DeepCopy()
+	vMeta.Annotations = NewAnnotationMapper(e.config).MapDownward(vMeta.Annotations)
+	updatedMeta := e.CheckDWObjectMetaEquality(&pObj.ObjectMeta, vMeta)
+	if updatedMeta != nil {
+		updated = pObj.DeepCopy()
+		updated.ObjectMeta = *updatedMeta
+	}
 
-	if !equality.Semantic.DeepEqual(vObj, pObjCopy) {
-		return pObjCopy
-	} else {
-		return nil
+	if !equality.Semantic.DeepEqual(pObj.Spec, vObj.Spec) {
+		if updated == nil {
+			updated = pObj.DeepCopy()
+		}
+		updated.Spec = *vObj.Spec.DeepCopy()
 	}
+	return updated
 }
 
 func filterNodePort(svc *v1.Service) *v1.ServiceSpec {
@@ -669,7 +723,12 @@ func filterNodePort(svc *v1.Service) *v1.ServiceSpec {
 
 func (e vcEquality) CheckServiceEquality(pObj, vObj *v1.Service) *v1.Service {
 	var updated *v1.Service
-	updatedMeta := e.CheckDWObjectMetaEquality(&pObj.ObjectMeta, &vObj.ObjectMeta)
+	// The tenant annotations are mapped to the super cluster ones, the load balancer
+	// annotations are owned by the super cluster.
+	vMeta := vObj.ObjectMeta.DeepCopy()
+	vMeta.Annotations = NewAnnotationMapper(e.config).MapDownward(vMeta.Annotations)
+	vMeta.Annotations, _ = e.CheckUWLoadBalancerAnnotations(pObj, vMeta.Annotations)
+	updatedMeta := e.CheckDWObjectMetaEquality(&pObj.ObjectMeta, vMeta)
 	if updatedMeta != nil {
 		if updated == nil {
 			updated = pObj.DeepCopy()
@@ -706,6 +765,36 @@ func (e vcEquality) CheckServiceEquality(pObj, vObj *v1.Service) *v1.Service {
 	return updated
 }
 
+// CheckUWLoadBalancerAnnotations returns vAnnotations with the annotations of the LoadBalancer
+// service pObj matching LoadBalancerAnnotationPrefixes synced, and whether they are already equal.
+func (e vcEquality) CheckUWLoadBalancerAnnotations(pObj *v1.Service, vAnnotations map[string]string) (map[string]string, bool) {
+	if pObj.Spec.Type != v1.ServiceTypeLoadBalancer || e.config == nil || len(e.config.LoadBalancerAnnotationPrefixes) == 0 {
+		return vAnnotations, true
+	}
+	return syncOwnedKeys(vAnnotations, pObj.Annotations, e.isLoadBalancerAnnotation)
+}
+
+// DropLoadBalancerAnnotations returns the annotations of a service built from a tenant service
+// without the ones matching LoadBalancerAnnotationPrefixes, the tenant can't set them.
+func (e vcEquality) DropLoadBalancerAnnotations(annotations map[string]string) map[string]string {
+	if e.config == nil || len(e.config.LoadBalancerAnnotationPrefixes) == 0 || annotations == nil {
+		return annotations
+	}
+	kept := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if !e.isLoadBalancerAnnotation(k) {
+			kept[k] = v
+		}
+	}
+	return kept
+}
+
+// isLoadBalancerAnnotation returns true if the key is set by the super cluster load balancer
+// controllers, the keys of the syncer itself never are.
+func (e vcEquality) isLoadBalancerAnnotation(key string) bool {
+	return !strings.HasPrefix(key, constants.DefaultOpaqueMetaPrefix) && hasPrefixInArray(key, e.config.LoadBalancerAnnotationPrefixes)
+}
+
 func (e vcEquality) CheckPVCEquality(pObj, vObj *v1.PersistentVolumeClaim) *v1.PersistentVolumeClaim {
 	var updated *v1.PersistentVolumeClaim
 	// PVC meta can be changed
@@ -295,6 +295,31 @@ func TestCheckUWKVEquality(t *testing.T) {
 	}
 }
 
+func TestCheckServiceEqualityLoadBalancerAnnotations(t *testing.T) {
+	e := Equality(&config.SyncerConfiguration{LoadBalancerAnnotationPrefixes: []string{"lb.example.com/"}}, &v1alpha1.VirtualCluster{})
+	pService := &v1.Service{}
+	pService.Spec.Type = v1.ServiceTypeLoadBalancer
+	pService.Annotations = map[string]string{"a": "b", "lb.example.com/hostname": "svc.lb.example.com"}
+	vService := pService.DeepCopy()
+	vService.Annotations = map[string]string{"a": "b", "lb.example.com/stale": "true"}
+
+	// the load balancer annotations are owned by the super cluster.
+	if updated := e.CheckServiceEquality(pService, vService); updated != nil {
+		t.Errorf("expected no downward update, got annotations %v", updated.Annotations)
+	}
+
+	annotations, equal := e.CheckUWLoadBalancerAnnotations(pService, vService.Annotations)
+	expected := map[string]string{"a": "b", "lb.example.com/hostname": "svc.lb.example.com"}
+	if equal || !equality.Semantic.DeepEqual(annotations, expected) {
+		t.Errorf("expected annotations %v, got %v", expected, annotations)
+	}
+
+	pService.Spec.Type = v1.ServiceTypeClusterIP
+	if _, equal := e.CheckUWLoadBalancerAnnotations(pService, vService.Annotations); !equal {
+		t.Errorf("expected the annotations of a ClusterIP service to be ignored")
+	}
+}
+
 func TestCheckContainersImageEquality(t *testing.T) {
 	for _, tt := range []struct {
 		name     string
@@ -0,0 +1,249 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	v1scheduling "k8s.io/api/scheduling/v1"
+	v1storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Winner is the side whose value the syncer writes to the other side to fix a drift.
+type Winner string
+
+const (
+	// WinnerTenant means the syncer updates the super cluster object with the tenant value.
+	WinnerTenant Winner = "tenant"
+	// WinnerSuper means the syncer updates the tenant object with the super cluster value.
+	WinnerSuper Winner = "super"
+)
+
+// Drift is a field the equality checks of the syncer find different in the super cluster object
+// and the tenant object.
+type Drift struct {
+	// Check is the equality check detecting the drift, e.g. CheckPodEquality.
+	Check string
+	// Field is the path of the field, e.g. metadata.labels[app].
+	Field string
+	// Super is the JSON value of the field in the super cluster object once the drift is fixed
+	// downward, or its current value if the drift is fixed upward. Empty if the field is not set.
+	Super string
+	// Tenant is the JSON value of the field in the tenant object once the drift is fixed
+	// upward, or its current value if the drift is fixed downward. Empty if the field is not set.
+	Tenant string
+	// Winner is the side the syncer takes the value of.
+	Winner Winner
+}
+
+// ExplainDrift runs the equality checks the patrollers run on pObj and vObj, and returns the
+// drifts they detect field by field. The semantic hash shortcut of the patrollers is not taken,
+// so every drift is reported even if the super cluster object has an up-to-date hash.
+func (e vcEquality) ExplainDrift(pObj, vObj client.Object) ([]Drift, error) {
+	if reflect.TypeOf(pObj) != reflect.TypeOf(vObj) {
+		return nil, fmt.Errorf("super cluster object is a %T, tenant object is a %T", pObj, vObj)
+	}
+	// the checks may modify the objects they are given.
+	pObj, vObj = pObj.DeepCopyObject().(client.Object), vObj.DeepCopyObject().(client.Object)
+
+	var r driftRecorder
+	switch v := vObj.(type) {
+	case *v1.Namespace:
+		p := pObj.(*v1.Namespace)
+		if policy := NewNamespaceMetaPolicy(e.config); policy.Enabled() {
+			if updated := policy.CheckDWNamespaceEquality(p, v); updated != nil {
+				r.record("CheckDWNamespaceEquality", WinnerTenant, p, updated)
+			}
+		} else if updated := e.CheckNamespaceEquality(p, v); updated != nil {
+			r.record("CheckNamespaceEquality", WinnerTenant, p, updated)
+		}
+	case *v1.Pod:
+		p := pObj.(*v1.Pod)
+		if updated := e.CheckPodEquality(p, v); updated != nil {
+			r.record("CheckPodEquality", WinnerTenant, p, updated)
+		}
+		if status := CheckDWPodConditionEquality(p, v); status != nil {
+			updated := p.DeepCopy()
+			updated.Status = *status
+			r.record("CheckDWPodConditionEquality", WinnerTenant, p, updated)
+		}
+		if status := e.CheckUWPodStatusEquality(p, v); status != nil {
+			updated := v.DeepCopy()
+			updated.Status = *status
+			r.record("CheckUWPodStatusEquality", WinnerSuper, v, updated)
+		}
+		if meta := e.CheckUWObjectMetaEquality(&p.ObjectMeta, &v.ObjectMeta); meta != nil {
+			updated := v.DeepCopy()
+			updated.ObjectMeta = *meta
+			r.record("CheckUWObjectMetaEquality", WinnerSuper, v, updated)
+		}
+	case *v1.ConfigMap:
+		if updated := e.CheckConfigMapEquality(pObj.(*v1.ConfigMap), v); updated != nil {
+			r.record("CheckConfigMapEquality", WinnerTenant, pObj, updated)
+		}
+	case *v1.Secret:
+		if updated := e.CheckSecretEquality(pObj.(*v1.Secret), v); updated != nil {
+			r.record("CheckSecretEquality", WinnerTenant, pObj, updated)
+		}
+	case *v1.Service:
+		p := pObj.(*v1.Service)
+		if updated := e.CheckServiceEquality(p, v); updated != nil {
+			r.record("CheckServiceEquality", WinnerTenant, p, updated)
+		}
+		if meta := e.CheckUWObjectMetaEquality(&p.ObjectMeta, &v.ObjectMeta); meta != nil {
+			updated := v.DeepCopy()
+			updated.ObjectMeta = *meta
+			r.record("CheckUWObjectMetaEquality", WinnerSuper, v, updated)
+		}
+	case *v1.Endpoints:
+		if updated := e.CheckEndpointsEquality(pObj.(*v1.Endpoints), v); updated != nil {
+			r.record("CheckEndpointsEquality", WinnerTenant, pObj, updated)
+		}
+	case *v1.PersistentVolumeClaim:
+		p := pObj.(*v1.PersistentVolumeClaim)
+		if updated := e.CheckPVCEquality(p, v); updated != nil {
+			r.record("CheckPVCEquality", WinnerTenant, p, updated)
+		}
+		if updated := e.CheckUWPVCStatusEquality(p, v); updated != nil {
+			r.record("CheckUWPVCStatusEquality", WinnerSuper, v, updated)
+		}
+	case *v1.PersistentVolume:
+		if spec := e.CheckPVSpecEquality(&pObj.(*v1.PersistentVolume).Spec, &v.Spec); spec != nil {
+			updated := v.DeepCopy()
+			updated.Spec = *spec
+			r.record("CheckPVSpecEquality", WinnerSuper, v, updated)
+		}
+	case *v1storage.StorageClass:
+		if updated := e.CheckStorageClassEquality(pObj.(*v1storage.StorageClass), v); updated != nil {
+			r.record("CheckStorageClassEquality", WinnerSuper, v, updated)
+		}
+	case *v1scheduling.PriorityClass:
+		if updated := e.CheckPriorityClassEquality(pObj.(*v1scheduling.PriorityClass), v); updated != nil {
+			r.record("CheckPriorityClassEquality", WinnerSuper, v, updated)
+		}
+	default:
+		return nil, fmt.Errorf("explaining the drift of a %T is not supported", vObj)
+	}
+	return r.drifts, r.err
+}
+
+type driftRecorder struct {
+	drifts []Drift
+	err    error
+}
+
+// record records the fields the syncer changes from current to updated. current is the super
+// cluster object if the tenant wins, and the tenant object if the super cluster wins.
+func (r *driftRecorder) record(check string, winner Winner, current, updated runtime.Object) {
+	if r.err != nil {
+		return
+	}
+	before, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		r.err = err
+		return
+	}
+	after, err := runtime.DefaultUnstructuredConverter.ToUnstructured(updated)
+	if err != nil {
+		r.err = err
+		return
+	}
+	for _, f := range diffFields("", before, after) {
+		d := Drift{Check: check, Field: f.path, Winner: winner}
+		if winner == WinnerTenant {
+			d.Super, d.Tenant = f.before, f.after
+		} else {
+			d.Tenant, d.Super = f.before, f.after
+		}
+		r.drifts = append(r.drifts, d)
+	}
+}
+
+type fieldDiff struct {
+	path          string
+	before, after string
+}
+
+// diffFields returns the leaf fields differing in before and after, the items of lists of
+// different lengths are not compared one by one.
+func diffFields(path string, before, after interface{}) []fieldDiff {
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	bMap, bIsMap := before.(map[string]interface{})
+	aMap, aIsMap := after.(map[string]interface{})
+	if (bIsMap || before == nil) && (aIsMap || after == nil) && (bIsMap || aIsMap) {
+		keys := make(map[string]struct{})
+		for k := range bMap {
+			keys[k] = struct{}{}
+		}
+		for k := range aMap {
+			keys[k] = struct{}{}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var diffs []fieldDiff
+		for _, k := range sorted {
+			diffs = append(diffs, diffFields(joinField(path, k), bMap[k], aMap[k])...)
+		}
+		return diffs
+	}
+	bList, bIsList := before.([]interface{})
+	aList, aIsList := after.([]interface{})
+	if bIsList && aIsList && len(bList) == len(aList) {
+		var diffs []fieldDiff
+		for i := range bList {
+			diffs = append(diffs, diffFields(fmt.Sprintf("%s[%d]", path, i), bList[i], aList[i])...)
+		}
+		return diffs
+	}
+	return []fieldDiff{{path: path, before: jsonValue(before), after: jsonValue(after)}}
+}
+
+// joinField appends a field to a path, the map keys that aren't identifiers, e.g. label keys,
+// are bracketed.
+func joinField(path, field string) string {
+	if strings.ContainsAny(field, "./") {
+		return path + "[" + field + "]"
+	}
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func jsonValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+)
+
+func TestExplainDrift(t *testing.T) {
+	syncerConfig := &config.SyncerConfiguration{
+		DefaultOpaqueMetaDomains: []string{"kubernetes.io"},
+	}
+	for _, tt := range []struct {
+		name        string
+		pObj        client.Object
+		vObj        client.Object
+		expected    []Drift
+		expectedErr bool
+	}{
+		{
+			name: "no drift",
+			pObj: &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "vc-default", Labels: map[string]string{"app": "web"}}},
+			vObj: &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"app": "web"}}},
+		},
+		{
+			name: "namespace labels",
+			pObj: &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "vc-default", Labels: map[string]string{"app": "old"}}},
+			vObj: &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"app": "web", "example.com/team": "a"}}},
+			expected: []Drift{
+				{Check: "CheckNamespaceEquality", Field: "metadata.labels.app", Super: `"old"`, Tenant: `"web"`, Winner: WinnerTenant},
+				{Check: "CheckNamespaceEquality", Field: "metadata.labels[example.com/team]", Super: "", Tenant: `"a"`, Winner: WinnerTenant},
+			},
+		},
+		{
+			name: "persistent volume spec",
+			pObj: &v1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+				Spec:       v1.PersistentVolumeSpec{StorageClassName: "fast"},
+			},
+			vObj: &v1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+				Spec:       v1.PersistentVolumeSpec{StorageClassName: "slow"},
+			},
+			expected: []Drift{
+				{Check: "CheckPVSpecEquality", Field: "spec.storageClassName", Super: `"fast"`, Tenant: `"slow"`, Winner: WinnerSuper},
+			},
+		},
+		{
+			name:        "different kinds",
+			pObj:        &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			vObj:        &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			expectedErr: true,
+		},
+		{
+			name:        "unsupported kind",
+			pObj:        &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1"}},
+			vObj:        &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1"}},
+			expectedErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pObj := tt.pObj.DeepCopyObject()
+			drifts, err := Equality(syncerConfig, &v1alpha1.VirtualCluster{}).ExplainDrift(tt.pObj, tt.vObj)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if !reflect.DeepEqual(drifts, tt.expected) {
+				t.Errorf("expected drifts %+v, got %+v", tt.expected, drifts)
+			}
+			if !reflect.DeepEqual(pObj, tt.pObj) {
+				t.Errorf("expected the super cluster object not to be modified, got %+v", tt.pObj)
+			}
+		})
+	}
+}
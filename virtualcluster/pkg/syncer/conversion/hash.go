@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+const (
+	// patrolVerifiedCacheSize bounds the number of super cluster objects whose verified
+	// resourceVersion is remembered, the evicted ones are fully compared by the next patrol.
+	patrolVerifiedCacheSize = 100000
+	// patrolVerifiedTTL is how long a verified resourceVersion is trusted by the patroller.
+	patrolVerifiedTTL = time.Hour
+)
+
+// patrolVerified holds, by UID, the resourceVersion at which a super cluster object was last
+// found equal to its tenant object by the patroller.
+var patrolVerified = cache.NewLRUExpireCache(patrolVerifiedCacheSize)
+
+// volatileMetaFields are the metadata fields populated by the tenant apiserver,
+// they are never synced downward hence excluded from the semantic hash.
+var volatileMetaFields = []string{"resourceVersion", "generation", "managedFields", "creationTimestamp", "selfLink"}
+
+// SemanticHash returns a hash of the content of the tenant object that is synced
+// downward, i.e., everything but the status and the server populated metadata.
+func SemanticHash(obj client.Object) (string, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", err
+	}
+	delete(u, "status")
+	if m, ok := u["metadata"].(map[string]interface{}); ok {
+		for _, f := range volatileMetaFields {
+			delete(m, f)
+		}
+	}
+	// json encodes map keys in sorted order, so the output is stable.
+	b, err := json.Marshal(u)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New64a()
+	h.Write(b)
+	return fmt.Sprintf("%x", h.Sum64()), nil
+}
+
+// CheckSemanticHash returns the semantic hash of vObj and whether pObj has recorded it.
+// It returns an empty hash if feature PatrolSemanticHash is disabled.
+func CheckSemanticHash(pObj, vObj client.Object) (string, bool) {
+	if !featuregate.DefaultFeatureGate.Enabled(featuregate.PatrolSemanticHash) {
+		return "", false
+	}
+	hash, err := SemanticHash(vObj)
+	if err != nil {
+		klog.Errorf("failed to compute semantic hash of %s/%s: %v", vObj.GetNamespace(), vObj.GetName(), err)
+		return "", false
+	}
+	return hash, pObj.GetAnnotations()[constants.LabelSemanticHash] == hash
+}
+
+// SetSemanticHash records hash in the annotations of the super cluster object.
+func SetSemanticHash(pObj client.Object, hash string) {
+	anno := pObj.GetAnnotations()
+	if anno == nil {
+		anno = make(map[string]string)
+	}
+	anno[constants.LabelSemanticHash] = hash
+	pObj.SetAnnotations(anno)
+}
+
+// PatrolSkipsEquality returns whether the patroller can skip the equality check of pObj and vObj,
+// i.e. pObj has recorded the semantic hash of vObj and pObj is unchanged since the patroller last
+// found it equal to vObj. The hash only covers the tenant object, the resourceVersion of pObj
+// catches the changes made to the super cluster object out of band.
+func PatrolSkipsEquality(pObj, vObj client.Object) bool {
+	if _, equal := CheckSemanticHash(pObj, vObj); !equal {
+		return false
+	}
+	rv, ok := patrolVerified.Get(pObj.GetUID())
+	return ok && rv == pObj.GetResourceVersion()
+}
+
+// RecordPatrolEquality records that the patroller found pObj equal to its tenant object.
+func RecordPatrolEquality(pObj client.Object) {
+	if !featuregate.DefaultFeatureGate.Enabled(featuregate.PatrolSemanticHash) {
+		return
+	}
+	patrolVerified.Add(pObj.GetUID(), pObj.GetResourceVersion(), patrolVerifiedTTL)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+func TestSemanticHash(t *testing.T) {
+	base := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm", ResourceVersion: "1"},
+		Data:       map[string]string{"a": "b"},
+	}
+	baseHash, err := SemanticHash(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		mutate     func(cm *v1.ConfigMap)
+		expectSame bool
+	}{
+		{
+			name:       "resource version changed",
+			mutate:     func(cm *v1.ConfigMap) { cm.ResourceVersion = "2" },
+			expectSame: true,
+		},
+		{
+			name:       "managed fields changed",
+			mutate:     func(cm *v1.ConfigMap) { cm.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl"}} },
+			expectSame: true,
+		},
+		{
+			name:   "data changed",
+			mutate: func(cm *v1.ConfigMap) { cm.Data["a"] = "c" },
+		},
+		{
+			name:   "label added",
+			mutate: func(cm *v1.ConfigMap) { cm.Labels = map[string]string{"a": "b"} },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := base.DeepCopy()
+			tc.mutate(cm)
+			hash, err := SemanticHash(cm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (hash == baseHash) != tc.expectSame {
+				t.Errorf("expected same hash %v, got %s and %s", tc.expectSame, baseHash, hash)
+			}
+		})
+	}
+}
+
+func TestCheckSemanticHash(t *testing.T) {
+	defer func() {
+		featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(nil)
+	}()
+	vObj := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm"},
+		Data:       map[string]string{"a": "b"},
+	}
+	pObj := vObj.DeepCopy()
+
+	featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(nil)
+	if hash, equal := CheckSemanticHash(pObj, vObj); hash != "" || equal {
+		t.Errorf("expected no hash with feature disabled, got %q, %v", hash, equal)
+	}
+
+	featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(map[string]bool{featuregate.PatrolSemanticHash: true})
+	hash, equal := CheckSemanticHash(pObj, vObj)
+	if hash == "" || equal {
+		t.Fatalf("expected unrecorded hash, got %q, %v", hash, equal)
+	}
+	SetSemanticHash(pObj, hash)
+	if _, equal := CheckSemanticHash(pObj, vObj); !equal {
+		t.Errorf("expected recorded hash to match")
+	}
+	vObj.Data["a"] = "c"
+	if _, equal := CheckSemanticHash(pObj, vObj); equal {
+		t.Errorf("expected hash mismatch after tenant change")
+	}
+}
+
+func TestPatrolSkipsEquality(t *testing.T) {
+	defer func() {
+		featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(nil)
+	}()
+	featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(map[string]bool{featuregate.PatrolSemanticHash: true})
+
+	vObj := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm"},
+		Data:       map[string]string{"a": "b"},
+	}
+	pObj := vObj.DeepCopy()
+	pObj.UID = "patrol-skips-equality"
+	pObj.ResourceVersion = "1"
+	hash, _ := CheckSemanticHash(pObj, vObj)
+	SetSemanticHash(pObj, hash)
+
+	if PatrolSkipsEquality(pObj, vObj) {
+		t.Errorf("expected the equality check not to be skipped before the patroller verified the object")
+	}
+	RecordPatrolEquality(pObj)
+	if !PatrolSkipsEquality(pObj, vObj) {
+		t.Errorf("expected the equality check of the verified object to be skipped")
+	}
+
+	changed := pObj.DeepCopy()
+	changed.ResourceVersion = "2"
+	changed.Data["a"] = "c"
+	if PatrolSkipsEquality(changed, vObj) {
+		t.Errorf("expected the equality check not to be skipped after an out of band change of the super object")
+	}
+
+	vObj.Data["a"] = "c"
+	if PatrolSkipsEquality(pObj, vObj) {
+		t.Errorf("expected the equality check not to be skipped after a tenant change")
+	}
+}
@@ -27,8 +27,6 @@ import (
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
-	v1scheduling "k8s.io/api/scheduling/v1"
-	storagev1 "k8s.io/api/storage/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -41,6 +39,8 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metalimit"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
@@ -49,11 +49,37 @@ import (
 // ToClusterKey makes a unique key which is used to create the root namespace in super control plane for a virtual cluster.
 // To avoid name conflict, the key uses the format <namespace>-<hash>-<name>
 func ToClusterKey(vc *v1alpha1.VirtualCluster) string {
+	// An adopted root namespace is named by the user, the key keeps the hash so that the super
+	// cluster namespaces <key>-<namespace> of the tenant can't collide with each other or with the
+	// ones of other tenants, e.g. root namespace kube and tenant namespace system.
+	if adoptedRootNamespace(vc) != "" {
+		return hashedClusterKey(vc)
+	}
 	// If the ClusterNamespace is set then this will automatically return that prefix allowing us to override
 	// any other hooks for the ClusterNamespace.
 	if vc.Status.ClusterNamespace != "" {
 		return vc.Status.ClusterNamespace
 	}
+	return hashedClusterKey(vc)
+}
+
+// ToRootNamespace returns the root namespace of the virtual cluster, holding its control plane. It is
+// the cluster key unless a pre-created root namespace is adopted.
+func ToRootNamespace(vc *v1alpha1.VirtualCluster) string {
+	if name := adoptedRootNamespace(vc); name != "" {
+		return name
+	}
+	return ToClusterKey(vc)
+}
+
+func adoptedRootNamespace(vc *v1alpha1.VirtualCluster) string {
+	if vc.Spec.RootNamespace == nil {
+		return ""
+	}
+	return vc.Spec.RootNamespace.Name
+}
+
+func hashedClusterKey(vc *v1alpha1.VirtualCluster) string {
 	digest := sha256.Sum256([]byte(vc.GetUID()))
 	return vc.GetNamespace() + "-" + hex.EncodeToString(digest[0:])[0:6] + "-" + vc.GetName()
 }
@@ -106,10 +132,10 @@ func GetKubeConfigOfVC(c v1core.CoreV1Interface, vc *v1alpha1.VirtualCluster) ([
 		secretFieldName = "value"
 	}
 
-	clusterName := ToClusterKey(vc)
-	adminKubeConfigSecret, err := c.Secrets(clusterName).Get(context.TODO(), secretName, metav1.GetOptions{})
+	rootNS := ToRootNamespace(vc)
+	adminKubeConfigSecret, err := c.Secrets(rootNS).Get(context.TODO(), secretName, metav1.GetOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get secret (%s) for virtual cluster in root namespace %s: %v", constants.KubeconfigAdminSecretName, clusterName, err)
+		return nil, fmt.Errorf("failed to get secret (%s) for virtual cluster in root namespace %s: %v", constants.KubeconfigAdminSecretName, rootNS, err)
 	}
 	return adminKubeConfigSecret.Data[secretFieldName], nil
 }
@@ -142,6 +168,15 @@ func (c *objectConversion) BuildSuperClusterObject(cluster string, obj client.Ob
 	if err != nil {
 		return nil, err
 	}
+	if err := metalimit.Check(c.config, obj, m); err != nil {
+		return nil, err
+	}
+	// the super cluster object is only built to be created, the unknown clusters fail below
+	if vc, err := util.GetVirtualClusterObject(c.mcc, cluster); err == nil {
+		if err := objectlimit.Check(cluster, vc, obj); err != nil {
+			return nil, err
+		}
+	}
 
 	vcName, vcNS, _, err := c.mcc.GetOwnerInfo(cluster)
 	if err != nil {
@@ -188,7 +223,12 @@ func (c *objectConversion) BuildSuperClusterObject(cluster string, obj client.Ob
 		m.SetLabels(WithSuperClusterLabels(m.GetLabels()))
 	}
 
+	if hash, _ := CheckSemanticHash(m, obj); hash != "" {
+		SetSemanticHash(m, hash)
+	}
+
 	m.SetNamespace(ToSuperClusterNamespace(cluster, obj.GetNamespace()))
+	SignOwnership(m)
 
 	return m, nil
 }
@@ -229,6 +269,13 @@ func (c *objectConversion) BuildSuperClusterNamespace(cluster string, obj client
 		return nil, errors.Wrapf(err, "get cluster owner info")
 	}
 
+	policy := NewNamespaceMetaPolicy(c.config)
+	m.SetLabels(policy.FilterDownward(m.GetLabels()))
+	m.SetAnnotations(policy.FilterDownward(m.GetAnnotations()))
+	if err := metalimit.Check(c.config, obj, m); err != nil {
+		return nil, err
+	}
+
 	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterLabelling) {
 		m.SetLabels(WithSuperClusterLabels(m.GetLabels()))
 	}
@@ -248,6 +295,7 @@ func (c *objectConversion) BuildSuperClusterNamespace(cluster string, obj client
 	m.SetAnnotations(anno)
 
 	m.SetName(ToSuperClusterNamespace(cluster, obj.GetName()))
+	SignOwnership(m)
 
 	return m, nil
 }
@@ -283,37 +331,20 @@ func ResetMetadata(obj metav1.Object) {
 	obj.SetOwnerReferences(nil)
 	obj.SetFinalizers(nil)
 	obj.SetClusterName("")
+	obj.SetManagedFields(nil)
 }
 
-func BuildVirtualEvent(cluster string, pEvent *v1.Event, vObj client.Object) *v1.Event {
-	vEvent := pEvent.DeepCopy()
-	ResetMetadata(vEvent)
-	vEvent.SetNamespace(vObj.GetNamespace())
-	vEvent.InvolvedObject.Namespace = vObj.GetNamespace()
-	vEvent.InvolvedObject.UID = vObj.GetUID()
+func mutateVirtualEvent(vEvent *v1.Event, cluster string, vInvolvedObject client.Object) {
+	vEvent.SetNamespace(vInvolvedObject.GetNamespace())
+	vEvent.InvolvedObject.Namespace = vInvolvedObject.GetNamespace()
+	vEvent.InvolvedObject.UID = vInvolvedObject.GetUID()
 	vEvent.InvolvedObject.ResourceVersion = ""
 
 	vEvent.Message = strings.ReplaceAll(vEvent.Message, cluster+"-", "")
 	vEvent.Message = strings.ReplaceAll(vEvent.Message, cluster, "")
-
-	return vEvent
 }
 
-func BuildVirtualStorageClass(cluster string, pStorageClass *storagev1.StorageClass) *storagev1.StorageClass {
-	vStorageClass := pStorageClass.DeepCopy()
-	ResetMetadata(vStorageClass)
-	return vStorageClass
-}
-
-func BuildVirtualPriorityClass(cluster string, pPriorityClass *v1scheduling.PriorityClass) *v1scheduling.PriorityClass {
-	vPriorityClass := pPriorityClass.DeepCopy()
-	ResetMetadata(vPriorityClass)
-	return vPriorityClass
-}
-
-func BuildVirtualCRD(cluster string, pCRD *apiextensionsv1.CustomResourceDefinition) *apiextensionsv1.CustomResourceDefinition {
-	vCRD := pCRD.DeepCopy()
-	ResetMetadata(vCRD)
+func mutateVirtualCRD(vCRD *apiextensionsv1.CustomResourceDefinition, cluster string) {
 	if featuregate.DefaultFeatureGate.Enabled(featuregate.DisableCRDPreserveUnknownFields) {
 		// In Kubernetes 1.20 the spec.preserveUnknownFields is not allowed to be set to true
 		// given Kubernetes has already deprecated this any cluster >=1.20 will not be able
@@ -321,21 +352,12 @@ func BuildVirtualCRD(cluster string, pCRD *apiextensionsv1.CustomResourceDefinit
 		vCRD.Spec.PreserveUnknownFields = false
 
 	}
-
-	return vCRD
 }
 
-func BuildVirtualPersistentVolume(pPV *v1.PersistentVolume, vPVC *v1.PersistentVolumeClaim) *v1.PersistentVolume {
-	vPV := pPV.DeepCopy()
-	ResetMetadata(vPV)
-	if vPV.Annotations == nil {
-		vPV.Annotations = make(map[string]string)
-	}
-	vPV.Annotations[constants.LabelUID] = string(pPV.UID)
+func mutateVirtualPersistentVolume(vPV *v1.PersistentVolume, vPVC *v1.PersistentVolumeClaim) {
 	// The pv needs to bind with the vPVC
 	vPV.Spec.ClaimRef.Namespace = vPVC.Namespace
 	vPV.Spec.ClaimRef.UID = vPVC.UID
-	return vPV
 }
 
 // IsControlPlaneService will return if the namespacedName matches the proper
@@ -10,21 +10,41 @@ import (
 )
 
 func TestToClusterKey(t *testing.T) {
+	meta := metav1.ObjectMeta{
+		Name:      "name",
+		Namespace: "ns",
+		UID:       "d64ea0c0-91f8-46f5-8643-c0cab32ab0cd",
+	}
 	for _, tt := range []struct {
-		name        string
-		vc          *v1alpha1.VirtualCluster
-		expectedKey string
+		name           string
+		vc             *v1alpha1.VirtualCluster
+		expectedKey    string
+		expectedRootNS string
 	}{
 		{
-			name: "normal vc",
+			name:           "normal vc",
+			vc:             &v1alpha1.VirtualCluster{ObjectMeta: meta},
+			expectedKey:    "ns-fd1b34-name",
+			expectedRootNS: "ns-fd1b34-name",
+		},
+		{
+			name: "cluster namespace override",
 			vc: &v1alpha1.VirtualCluster{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "name",
-					Namespace: "ns",
-					UID:       "d64ea0c0-91f8-46f5-8643-c0cab32ab0cd",
-				},
+				ObjectMeta: meta,
+				Status:     v1alpha1.VirtualClusterStatus{ClusterNamespace: "ns"},
 			},
-			expectedKey: "ns-fd1b34-name",
+			expectedKey:    "ns",
+			expectedRootNS: "ns",
+		},
+		{
+			name: "adopted root namespace",
+			vc: &v1alpha1.VirtualCluster{
+				ObjectMeta: meta,
+				Spec:       v1alpha1.VirtualClusterSpec{RootNamespace: &v1alpha1.RootNamespaceSpec{Name: "kube"}},
+				Status:     v1alpha1.VirtualClusterStatus{ClusterNamespace: "kube"},
+			},
+			expectedKey:    "ns-fd1b34-name",
+			expectedRootNS: "kube",
 		},
 	} {
 		t.Run(tt.name, func(tc *testing.T) {
@@ -32,6 +52,9 @@ func TestToClusterKey(t *testing.T) {
 			if key != tt.expectedKey {
 				tc.Errorf("expected key %s, got %s", tt.expectedKey, key)
 			}
+			if rootNS := ToRootNamespace(tt.vc); rootNS != tt.expectedRootNS {
+				tc.Errorf("expected root namespace %s, got %s", tt.expectedRootNS, rootNS)
+			}
 		})
 	}
 }
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConvertIngress converts a networking.k8s.io/v1beta1 Ingress served by the control planes older
+// than v1.19 to a networking.k8s.io/v1 Ingress, or the other way around.
+func ConvertIngress(in, out client.Object) error {
+	switch in := in.(type) {
+	case *networkingv1beta1.Ingress:
+		o, ok := out.(*networkingv1.Ingress)
+		if !ok {
+			return fmt.Errorf("unable to convert %T to %T", in, out)
+		}
+		*o = networkingv1.Ingress{
+			ObjectMeta: *in.ObjectMeta.DeepCopy(),
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: in.Spec.IngressClassName,
+				DefaultBackend:   ingressBackendToV1(in.Spec.Backend),
+			},
+			Status: networkingv1.IngressStatus{LoadBalancer: *in.Status.LoadBalancer.DeepCopy()},
+		}
+		for _, tls := range in.Spec.TLS {
+			o.Spec.TLS = append(o.Spec.TLS, networkingv1.IngressTLS{Hosts: tls.Hosts, SecretName: tls.SecretName})
+		}
+		for _, rule := range in.Spec.Rules {
+			r := networkingv1.IngressRule{Host: rule.Host}
+			if rule.HTTP != nil {
+				r.HTTP = &networkingv1.HTTPIngressRuleValue{}
+				for _, path := range rule.HTTP.Paths {
+					r.HTTP.Paths = append(r.HTTP.Paths, networkingv1.HTTPIngressPath{
+						Path:     path.Path,
+						PathType: (*networkingv1.PathType)(path.PathType),
+						Backend:  *ingressBackendToV1(&path.Backend),
+					})
+				}
+			}
+			o.Spec.Rules = append(o.Spec.Rules, r)
+		}
+	case *networkingv1.Ingress:
+		o, ok := out.(*networkingv1beta1.Ingress)
+		if !ok {
+			return fmt.Errorf("unable to convert %T to %T", in, out)
+		}
+		*o = networkingv1beta1.Ingress{
+			ObjectMeta: *in.ObjectMeta.DeepCopy(),
+			Spec: networkingv1beta1.IngressSpec{
+				IngressClassName: in.Spec.IngressClassName,
+				Backend:          ingressBackendToV1beta1(in.Spec.DefaultBackend),
+			},
+			Status: networkingv1beta1.IngressStatus{LoadBalancer: *in.Status.LoadBalancer.DeepCopy()},
+		}
+		for _, tls := range in.Spec.TLS {
+			o.Spec.TLS = append(o.Spec.TLS, networkingv1beta1.IngressTLS{Hosts: tls.Hosts, SecretName: tls.SecretName})
+		}
+		for _, rule := range in.Spec.Rules {
+			r := networkingv1beta1.IngressRule{Host: rule.Host}
+			if rule.HTTP != nil {
+				r.HTTP = &networkingv1beta1.HTTPIngressRuleValue{}
+				for _, path := range rule.HTTP.Paths {
+					r.HTTP.Paths = append(r.HTTP.Paths, networkingv1beta1.HTTPIngressPath{
+						Path:     path.Path,
+						PathType: (*networkingv1beta1.PathType)(path.PathType),
+						Backend:  *ingressBackendToV1beta1(&path.Backend),
+					})
+				}
+			}
+			o.Spec.Rules = append(o.Spec.Rules, r)
+		}
+	default:
+		return fmt.Errorf("unable to convert %T to %T", in, out)
+	}
+	return nil
+}
+
+func ingressBackendToV1(in *networkingv1beta1.IngressBackend) *networkingv1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+	out := &networkingv1.IngressBackend{Resource: in.Resource.DeepCopy()}
+	if in.ServiceName != "" {
+		out.Service = &networkingv1.IngressServiceBackend{Name: in.ServiceName}
+		if in.ServicePort.Type == intstr.String {
+			out.Service.Port.Name = in.ServicePort.StrVal
+		} else {
+			out.Service.Port.Number = in.ServicePort.IntVal
+		}
+	}
+	return out
+}
+
+func ingressBackendToV1beta1(in *networkingv1.IngressBackend) *networkingv1beta1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+	out := &networkingv1beta1.IngressBackend{Resource: in.Resource.DeepCopy()}
+	if in.Service != nil {
+		out.ServiceName = in.Service.Name
+		if in.Service.Port.Name != "" {
+			out.ServicePort = intstr.FromString(in.Service.Port.Name)
+		} else {
+			out.ServicePort = intstr.FromInt(int(in.Service.Port.Number))
+		}
+	}
+	return out
+}
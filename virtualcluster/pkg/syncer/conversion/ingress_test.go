@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestConvertIngress(t *testing.T) {
+	pathType := networkingv1beta1.PathTypePrefix
+	v1beta1Ingress := &networkingv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "12345"},
+		Spec: networkingv1beta1.IngressSpec{
+			Backend: &networkingv1beta1.IngressBackend{ServiceName: "default-http", ServicePort: intstr.FromInt(80)},
+			TLS:     []networkingv1beta1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "tls"}},
+			Rules: []networkingv1beta1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networkingv1beta1.IngressRuleValue{HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+					Paths: []networkingv1beta1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend:  networkingv1beta1.IngressBackend{ServiceName: "web", ServicePort: intstr.FromString("http")},
+					}},
+				}},
+			}},
+		},
+		Status: networkingv1beta1.IngressStatus{
+			LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "10.0.0.1"}}},
+		},
+	}
+
+	ingress := &networkingv1.Ingress{}
+	if err := ConvertIngress(v1beta1Ingress, ingress); err != nil {
+		t.Fatalf("unexpected error converting to v1: %v", err)
+	}
+	if ingress.Spec.DefaultBackend.Service.Name != "default-http" || ingress.Spec.DefaultBackend.Service.Port.Number != 80 {
+		t.Errorf("unexpected default backend %+v", ingress.Spec.DefaultBackend)
+	}
+	if port := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port; port.Name != "http" || port.Number != 0 {
+		t.Errorf("unexpected path backend port %+v", port)
+	}
+	if ingress.UID != "12345" || ingress.Status.LoadBalancer.Ingress[0].IP != "10.0.0.1" {
+		t.Errorf("expected the metadata and the status to be kept, got %+v", ingress)
+	}
+
+	roundTrip := &networkingv1beta1.Ingress{}
+	if err := ConvertIngress(ingress, roundTrip); err != nil {
+		t.Fatalf("unexpected error converting to v1beta1: %v", err)
+	}
+	if !reflect.DeepEqual(roundTrip, v1beta1Ingress) {
+		t.Errorf("expected %+v after a round trip, got %+v", v1beta1Ingress, roundTrip)
+	}
+
+	if err := ConvertIngress(&v1.Service{}, ingress); err == nil {
+		t.Errorf("expected an error converting a service")
+	}
+}
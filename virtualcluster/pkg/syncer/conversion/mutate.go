@@ -119,11 +119,26 @@ func PodMutateDefault(vPod *v1.Pod, saSecretMap map[string]string, services []*v
 
 		// setup env var map
 		apiServerClusterIP, serviceEnv := getServiceEnvVarMap(p.PPod.Namespace, p.ClusterName, p.PPod.Spec.EnableServiceLinks, services)
+		// the kubelet does not manage the hosts file of Windows containers, the host aliases
+		// below don't resolve there so point Windows pods to the apiserver ip directly.
+		if IsWindowsPod(vPod) && apiServerClusterIP != "" {
+			serviceEnv["KUBERNETES_SERVICE_HOST"] = apiServerClusterIP
+		}
+
+		vc, err := util.GetVirtualClusterObject(p.Mc, p.ClusterName)
+		if err != nil {
+			return err
+		}
+		// the kubelet searches the default cluster domain when none is set, so do the tenant pods.
+		clusterDomain := vc.Spec.ClusterDomain
+		if clusterDomain == "" {
+			clusterDomain = constants.DefaultClusterDomain
+		}
 
 		// if apiServerClusterIP is empty, just let it fails.
 		p.PPod.Spec.HostAliases = append(p.PPod.Spec.HostAliases, v1.HostAlias{
 			IP:        apiServerClusterIP,
-			Hostnames: []string{"kubernetes", "kubernetes.default", "kubernetes.default.svc"},
+			Hostnames: []string{"kubernetes", "kubernetes.default", "kubernetes.default.svc", "kubernetes.default.svc." + clusterDomain},
 		})
 
 		for i := range p.PPod.Spec.Containers {
@@ -169,11 +184,7 @@ func PodMutateDefault(vPod *v1.Pod, saSecretMap map[string]string, services []*v
 			mutateWeightedPodAffinityTerms(p.PPod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, p.ClusterName)
 		}
 
-		vc, err := util.GetVirtualClusterObject(p.Mc, p.ClusterName)
-		if err != nil {
-			return err
-		}
-		mutateDNSConfig(p, vPod, vc.Spec.ClusterDomain, nameServer, dnsOption)
+		mutateDNSConfig(p, vPod, clusterDomain, nameServer, dnsOption)
 
 		// FIXME(zhuangqh): how to support pod subdomain.
 		if p.PPod.Spec.Subdomain != "" {
@@ -283,6 +294,46 @@ func getServiceEnvVarMap(ns, cluster string, enableServiceLinks *bool, services
 	return apiServerService, m
 }
 
+// IsWindowsPod returns true if the pod can only run on Windows nodes, i.e. it selects
+// Windows nodes by their os label or sets Windows specific security context options.
+func IsWindowsPod(pod *v1.Pod) bool {
+	if pod.Spec.NodeSelector[v1.LabelOSStable] == "windows" {
+		return true
+	}
+	if pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.WindowsOptions != nil {
+		return true
+	}
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, c := range containers {
+			if c.SecurityContext != nil && c.SecurityContext.WindowsOptions != nil {
+				return true
+			}
+		}
+	}
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil ||
+		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return false
+	}
+	// the node selector terms are ORed, every one of them has to select Windows nodes only
+	terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		if !selectsWindowsOnly(term) {
+			return false
+		}
+	}
+	return len(terms) > 0
+}
+
+func selectsWindowsOnly(term v1.NodeSelectorTerm) bool {
+	for _, req := range term.MatchExpressions {
+		if req.Key == v1.LabelOSStable && req.Operator == v1.NodeSelectorOpIn &&
+			len(req.Values) == 1 && req.Values[0] == "windows" {
+			return true
+		}
+	}
+	return false
+}
+
 func mutateDNSConfig(p *PodMutateCtx, vPod *v1.Pod, clusterDomain, nameServer string, dnsOption []v1.PodDNSConfigOption) {
 	// If the TenantAllowDNSPolicy feature gate is added AND if the vPod labels include
 	// tenancy.x-k8s.io/disable.dnsPolicyMutation: "true" then we should return without
@@ -344,11 +395,44 @@ func mutateClusterFirstDNS(p *PodMutateCtx, vPod *v1.Pod, clusterDomain, nameSer
 		dnsConfig.Searches = omitDuplicates(append(dnsConfig.Searches, existingDNSConfig.Searches...))
 		dnsConfig.Options = omitDuplicatePodDNSConfigOption(append(dnsConfig.Options, existingDNSConfig.Options...))
 	}
+	// the tenant apiserver validated the dnsConfig of the tenant, the entries prepended for
+	// the tenant DNS may exceed the limits of the super control plane, drop the last ones.
+	if len(dnsConfig.Nameservers) > maxDNSNameservers {
+		klog.Warningf("pod %s/%s of cluster %s has more than %d nameservers, dropping %v", vPod.Namespace, vPod.Name, p.ClusterName, maxDNSNameservers, dnsConfig.Nameservers[maxDNSNameservers:])
+		dnsConfig.Nameservers = dnsConfig.Nameservers[:maxDNSNameservers]
+	}
+	if searches := limitDNSSearches(dnsConfig.Searches); len(searches) < len(dnsConfig.Searches) {
+		klog.Warningf("pod %s/%s of cluster %s exceeds the DNS search limits, dropping %v", vPod.Namespace, vPod.Name, p.ClusterName, dnsConfig.Searches[len(searches):])
+		dnsConfig.Searches = searches
+	}
 
 	p.PPod.Spec.DNSPolicy = v1.DNSNone
 	p.PPod.Spec.DNSConfig = dnsConfig
 }
 
+// The limits of the pod dnsConfig validated by the apiserver.
+const (
+	maxDNSNameservers     = 3
+	maxDNSSearchPaths     = 6
+	maxDNSSearchListChars = 256
+)
+
+// limitDNSSearches returns the longest prefix of searches within the apiserver limits.
+func limitDNSSearches(searches []string) []string {
+	chars := 0
+	for i, search := range searches {
+		// the searches are joined by spaces in resolv.conf
+		chars += len(search)
+		if i > 0 {
+			chars++
+		}
+		if i >= maxDNSSearchPaths || chars > maxDNSSearchListChars {
+			return searches[:i]
+		}
+	}
+	return searches
+}
+
 func omitDuplicates(strs []string) []string {
 	uniqueStrs := make(map[string]bool)
 
@@ -468,4 +552,6 @@ func (s *saSecretMutator) Mutate(vSecret *v1.Secret, clusterName string) {
 
 	s.pSecret.Name = ""
 	s.pSecret.GenerateName = vSecret.GetAnnotations()[v1.ServiceAccountNameKey] + "-token-"
+	// the name the secret is signed with is generated by the apiserver
+	SignOwnership(s.pSecret)
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package conversion
 
 import (
+	"strings"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
@@ -460,6 +461,25 @@ func Test_mutateDNSConfig(t *testing.T) {
 				Options:     defaultOptions,
 			},
 		},
+		{
+			name: "dns policy set to cluster first with config at the limits",
+			args: args{
+				p: podMutateCtxFunc(v1.DNSClusterFirst, &v1.PodDNSConfig{
+					Nameservers: []string{"127.0.0.1", "127.0.0.2", "127.0.0.3"},
+					Searches:    []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com", "e.example.com", "f.example.com"},
+				}, false),
+				vPod:          newPod(),
+				clusterDomain: "cluster.local",
+				nameServer:    "0.0.0.0",
+				dnsoptions:    defaultOptions,
+			},
+			expectedDNSPolicy: &dnsNone,
+			expectedDNSConfig: &v1.PodDNSConfig{
+				Nameservers: []string{"0.0.0.0", "127.0.0.1", "127.0.0.2"},
+				Searches:    []string{"ns.svc.cluster.local", "svc.cluster.local", "cluster.local", "a.example.com", "b.example.com", "c.example.com"},
+				Options:     defaultOptions,
+			},
+		},
 		{
 			name: "dns policy set to cluster first host network",
 			args: args{
@@ -585,6 +605,87 @@ func Test_mutateDNSConfig(t *testing.T) {
 	}
 }
 
+func Test_limitDNSSearches(t *testing.T) {
+	long := strings.Repeat("a", 120)
+	for _, tt := range []struct {
+		searches []string
+		expected []string
+	}{
+		{searches: nil, expected: nil},
+		{searches: []string{"a", "b", "c", "d", "e", "f"}, expected: []string{"a", "b", "c", "d", "e", "f"}},
+		{searches: []string{"a", "b", "c", "d", "e", "f", "g"}, expected: []string{"a", "b", "c", "d", "e", "f"}},
+		{searches: []string{long, long, "b"}, expected: []string{long, long, "b"}},
+		{searches: []string{long, long, "bcdefghijklmnop"}, expected: []string{long, long}},
+	} {
+		if got := limitDNSSearches(tt.searches); !equality.Semantic.DeepEqual(got, tt.expected) {
+			t.Errorf("expected searches %v, got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestIsWindowsPod(t *testing.T) {
+	osRequirement := func(values ...string) v1.NodeSelectorTerm {
+		return v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+			{Key: v1.LabelOSStable, Operator: v1.NodeSelectorOpIn, Values: values},
+		}}
+	}
+	withTerms := func(terms ...v1.NodeSelectorTerm) func(*v1.Pod) {
+		return func(pod *v1.Pod) {
+			pod.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: terms},
+			}}
+		}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		pod      *v1.Pod
+		expected bool
+	}{
+		{
+			name: "linux pod",
+			pod: newPod(func(pod *v1.Pod) {
+				pod.Spec.NodeSelector = map[string]string{v1.LabelOSStable: "linux"}
+			}),
+		},
+		{
+			name: "windows node selector",
+			pod: newPod(func(pod *v1.Pod) {
+				pod.Spec.NodeSelector = map[string]string{v1.LabelOSStable: "windows"}
+			}),
+			expected: true,
+		},
+		{
+			name: "container windows options",
+			pod: newPod(func(pod *v1.Pod) {
+				pod.Spec.Containers = []v1.Container{{SecurityContext: &v1.SecurityContext{
+					WindowsOptions: &v1.WindowsSecurityContextOptions{RunAsUserName: pointer.String("ContainerUser")},
+				}}}
+			}),
+			expected: true,
+		},
+		{
+			name:     "windows node affinity",
+			pod:      newPod(withTerms(osRequirement("windows"))),
+			expected: true,
+		},
+		{
+			name: "any os node affinity",
+			pod:  newPod(withTerms(osRequirement("windows", "linux"))),
+		},
+		{
+			name: "node affinity term without os",
+			pod:  newPod(withTerms(osRequirement("windows"), v1.NodeSelectorTerm{})),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsWindowsPod(tt.pod); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
 func newPod(fns ...func(*v1.Pod)) *v1.Pod {
 	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
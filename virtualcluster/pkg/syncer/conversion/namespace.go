@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// NamespaceMetaPolicy decides which labels and annotations are propagated
+// between the tenant namespaces and their super cluster namespaces.
+type NamespaceMetaPolicy struct {
+	config *config.SyncerConfiguration
+}
+
+// NewNamespaceMetaPolicy returns the namespace meta policy of the syncer configuration.
+func NewNamespaceMetaPolicy(config *config.SyncerConfiguration) NamespaceMetaPolicy {
+	return NamespaceMetaPolicy{config: config}
+}
+
+// Enabled returns true if any allow list is configured, otherwise every
+// tenant namespace label and annotation is propagated downward as before.
+func (p NamespaceMetaPolicy) Enabled() bool {
+	return p.config != nil && (len(p.config.TenantNamespaceMetaAllowList) > 0 || len(p.config.SuperNamespaceMetaAllowList) > 0)
+}
+
+// UpwardEnabled returns true if super cluster namespace keys are propagated back.
+func (p NamespaceMetaPolicy) UpwardEnabled() bool {
+	return p.config != nil && len(p.config.SuperNamespaceMetaAllowList) > 0
+}
+
+// isDownwardKey returns true if the tenant owns the key and it is propagated
+// to the super cluster namespace.
+func (p NamespaceMetaPolicy) isDownwardKey(key string) bool {
+	if p.isSyncerKey(key) || p.isUpwardKey(key) {
+		return false
+	}
+	if len(p.config.TenantNamespaceMetaAllowList) == 0 {
+		return true
+	}
+	return hasPrefixInArray(key, p.config.TenantNamespaceMetaAllowList)
+}
+
+// isUpwardKey returns true if the super cluster owns the key and it is
+// propagated back to the tenant namespace.
+func (p NamespaceMetaPolicy) isUpwardKey(key string) bool {
+	return !p.isSyncerKey(key) && hasPrefixInArray(key, p.config.SuperNamespaceMetaAllowList)
+}
+
+// isSyncerKey returns true for the keys set by the syncer itself and the
+// opaque keys, which are never propagated.
+func (p NamespaceMetaPolicy) isSyncerKey(key string) bool {
+	return strings.HasPrefix(key, constants.DefaultOpaqueMetaPrefix) || isOpaquedKey(p.config, key)
+}
+
+// FilterDownward drops the tenant namespace keys not propagated to the super
+// cluster from kv.
+func (p NamespaceMetaPolicy) FilterDownward(kv map[string]string) map[string]string {
+	if !p.Enabled() || kv == nil {
+		return kv
+	}
+	for k := range kv {
+		if !p.isDownwardKey(k) {
+			delete(kv, k)
+		}
+	}
+	return kv
+}
+
+// CheckDWNamespaceEquality returns the super cluster namespace with the keys
+// owned by the tenant synced from the tenant namespace, or nil if they are equal.
+func (p NamespaceMetaPolicy) CheckDWNamespaceEquality(pObj, vObj *v1.Namespace) *v1.Namespace {
+	updatedMeta := syncOwnedMeta(&pObj.ObjectMeta, &vObj.ObjectMeta, p.isDownwardKey)
+	if updatedMeta == nil {
+		return nil
+	}
+	updated := pObj.DeepCopy()
+	updated.ObjectMeta = *updatedMeta
+	return updated
+}
+
+// CheckUWNamespaceEquality returns the tenant namespace with the keys owned
+// by the super cluster synced from the super cluster namespace, or nil if
+// they are equal.
+func (p NamespaceMetaPolicy) CheckUWNamespaceEquality(pObj, vObj *v1.Namespace) *v1.Namespace {
+	updatedMeta := syncOwnedMeta(&vObj.ObjectMeta, &pObj.ObjectMeta, p.isUpwardKey)
+	if updatedMeta == nil {
+		return nil
+	}
+	updated := vObj.DeepCopy()
+	updated.ObjectMeta = *updatedMeta
+	return updated
+}
+
+// syncOwnedMeta returns a copy of dst whose labels and annotations matching
+// owned are the same as in src, or nil if they already are.
+func syncOwnedMeta(dst, src *metav1.ObjectMeta, owned func(string) bool) *metav1.ObjectMeta {
+	labels, labelsEqual := syncOwnedKeys(dst.Labels, src.Labels, owned)
+	annotations, annotationsEqual := syncOwnedKeys(dst.Annotations, src.Annotations, owned)
+	if labelsEqual && annotationsEqual {
+		return nil
+	}
+	updated := dst.DeepCopy()
+	updated.Labels = labels
+	updated.Annotations = annotations
+	return updated
+}
+
+func syncOwnedKeys(dst, src map[string]string, owned func(string) bool) (map[string]string, bool) {
+	equal := true
+	for k, v := range src {
+		if owned(k) && dst[k] != v {
+			equal = false
+		}
+	}
+	for k := range dst {
+		if _, ok := src[k]; owned(k) && !ok {
+			equal = false
+		}
+	}
+	if equal {
+		return dst, true
+	}
+
+	synced := make(map[string]string)
+	for k, v := range dst {
+		if !owned(k) {
+			synced[k] = v
+		}
+	}
+	for k, v := range src {
+		if owned(k) {
+			synced[k] = v
+		}
+	}
+	return synced, false
+}
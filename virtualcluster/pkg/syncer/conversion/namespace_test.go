@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func namespaceWithMeta(labels, annotations map[string]string) *v1.Namespace {
+	return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: labels, Annotations: annotations}}
+}
+
+func TestNamespaceMetaPolicyFilterDownward(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   *config.SyncerConfiguration
+		in       map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "disabled",
+			config:   &config.SyncerConfiguration{},
+			in:       map[string]string{"team": "a", "pod-security.kubernetes.io/enforce": "privileged"},
+			expected: map[string]string{"team": "a", "pod-security.kubernetes.io/enforce": "privileged"},
+		},
+		{
+			name: "allow list",
+			config: &config.SyncerConfiguration{
+				DefaultOpaqueMetaDomains:     []string{"kubernetes.io"},
+				TenantNamespaceMetaAllowList: []string{"team", "billing.example.com/", "pod-security.kubernetes.io/"},
+			},
+			in: map[string]string{
+				"team":                               "a",
+				"billing.example.com/cost-center":    "42",
+				"other":                              "x",
+				"pod-security.kubernetes.io/enforce": "privileged",
+			},
+			expected: map[string]string{"team": "a", "billing.example.com/cost-center": "42"},
+		},
+		{
+			name:     "super cluster keys are not propagated downward",
+			config:   &config.SyncerConfiguration{SuperNamespaceMetaAllowList: []string{"chargeback/"}},
+			in:       map[string]string{"team": "a", "chargeback/account": "forged"},
+			expected: map[string]string{"team": "a"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NewNamespaceMetaPolicy(tc.config).FilterDownward(tc.in)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNamespaceMetaPolicyEquality(t *testing.T) {
+	policy := NewNamespaceMetaPolicy(&config.SyncerConfiguration{
+		TenantNamespaceMetaAllowList: []string{"team"},
+		SuperNamespaceMetaAllowList:  []string{"chargeback/"},
+	})
+	syncerAnnotations := map[string]string{constants.LabelCluster: "cluster", constants.LabelUID: "uid"}
+
+	t.Run("downward", func(t *testing.T) {
+		pNS := namespaceWithMeta(map[string]string{"team": "a", "chargeback/account": "1", "super-only": "x"}, syncerAnnotations)
+		vNS := namespaceWithMeta(map[string]string{"team": "b", "chargeback/account": "forged", "other": "y"}, nil)
+		updated := policy.CheckDWNamespaceEquality(pNS, vNS)
+		if updated == nil {
+			t.Fatalf("expected the super cluster namespace to be updated")
+		}
+		expectedLabels := map[string]string{"team": "b", "chargeback/account": "1", "super-only": "x"}
+		if !reflect.DeepEqual(updated.Labels, expectedLabels) {
+			t.Errorf("expected labels %v, got %v", expectedLabels, updated.Labels)
+		}
+		if !reflect.DeepEqual(updated.Annotations, syncerAnnotations) {
+			t.Errorf("expected the syncer annotations to be kept, got %v", updated.Annotations)
+		}
+
+		vNS.Labels = map[string]string{}
+		updated = policy.CheckDWNamespaceEquality(pNS, vNS)
+		if updated == nil || updated.Labels["team"] != "" {
+			t.Errorf("expected the removed tenant label to be removed, got %v", updated)
+		}
+
+		if policy.CheckDWNamespaceEquality(pNS, namespaceWithMeta(map[string]string{"team": "a"}, nil)) != nil {
+			t.Errorf("expected no update")
+		}
+	})
+
+	t.Run("upward", func(t *testing.T) {
+		pNS := namespaceWithMeta(map[string]string{"team": "a", "chargeback/account": "1"}, syncerAnnotations)
+		vNS := namespaceWithMeta(map[string]string{"team": "b", "chargeback/stale": "2"}, nil)
+		updated := policy.CheckUWNamespaceEquality(pNS, vNS)
+		if updated == nil {
+			t.Fatalf("expected the tenant namespace to be updated")
+		}
+		expectedLabels := map[string]string{"team": "b", "chargeback/account": "1"}
+		if !reflect.DeepEqual(updated.Labels, expectedLabels) {
+			t.Errorf("expected labels %v, got %v", expectedLabels, updated.Labels)
+		}
+		if len(updated.Annotations) != 0 {
+			t.Errorf("expected the syncer annotations not to be propagated, got %v", updated.Annotations)
+		}
+		if policy.CheckUWNamespaceEquality(pNS, updated) != nil {
+			t.Errorf("expected no update once synced")
+		}
+	})
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+const (
+	// ownershipSignatureVersion prefixes the signatures so that the signed content can be changed later.
+	ownershipSignatureVersion = "v2."
+	// legacyOwnershipSignatureVersion prefixes the signatures that leave out the kind and the name of
+	// the object, they are only accepted until the backfill re-signs them.
+	legacyOwnershipSignatureVersion = "v1."
+	// minOwnershipKeySize is the minimum size in bytes of an ownership key.
+	minOwnershipKeySize = 32
+)
+
+// ownershipAnnotations are the annotations the syncer relies on to find the tenant object of a super
+// cluster object, they are signed along with the kind, the super cluster namespace and the name of
+// the object.
+var ownershipAnnotations = []string{
+	constants.LabelCluster,
+	constants.LabelNamespace,
+	constants.LabelUID,
+	constants.LabelVCName,
+	constants.LabelVCNamespace,
+}
+
+// OwnershipSigner signs the ownership annotations of the super cluster objects with an HMAC, so that
+// an admin of a super cluster namespace cannot forge them to make the syncer delete other objects.
+type OwnershipSigner struct {
+	// keys verify the signatures, the first one signs.
+	keys             [][]byte
+	requireSignature bool
+	// backfilled is set once the objects signed before are re-signed, the unsigned objects are
+	// rejected from then on.
+	backfilled int32
+}
+
+// NewOwnershipSigner returns an OwnershipSigner, the first key signs and all the keys verify.
+func NewOwnershipSigner(keys [][]byte, requireSignature bool) (*OwnershipSigner, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no ownership key")
+	}
+	for i, key := range keys {
+		if len(key) < minOwnershipKeySize {
+			return nil, fmt.Errorf("ownership key %d is shorter than %d bytes", i, minOwnershipKeySize)
+		}
+	}
+	return &OwnershipSigner{keys: keys, requireSignature: requireSignature}, nil
+}
+
+// LoadOwnershipKeys reads the ownership keys of a file, one per line, blank lines are ignored.
+func LoadOwnershipKeys(file string) ([][]byte, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var keys [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			keys = append(keys, []byte(line))
+		}
+	}
+	return keys, scanner.Err()
+}
+
+// ownershipKind returns the kind signed for obj. The typed objects of the listers have no TypeMeta,
+// so their kind is the name of their type.
+func ownershipKind(obj metav1.Object) string {
+	switch o := obj.(type) {
+	case *metav1.PartialObjectMetadata:
+		return o.Kind
+	case *unstructured.Unstructured:
+		return o.GetKind()
+	}
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// ownershipNames returns the names of obj that may have been signed. An object is signed before it
+// is created, so the signature of an object named by the apiserver covers its generateName, which
+// can't be mistaken for a name.
+func ownershipNames(obj metav1.Object) []string {
+	var names []string
+	if obj.GetName() != "" {
+		names = append(names, obj.GetName())
+	}
+	if obj.GetGenerateName() != "" {
+		names = append(names, "generateName:"+obj.GetGenerateName())
+	}
+	return names
+}
+
+func (s *OwnershipSigner) signature(key []byte, obj metav1.Object, name string) string {
+	// the super cluster namespace of a namespace is its name.
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = obj.GetName()
+	}
+	fields := []string{ownershipKind(obj), namespace, name}
+	return ownershipSignatureVersion + s.mac(key, obj, fields)
+}
+
+// legacySignature is the signature of the objects signed before the kind and the name were signed.
+func (s *OwnershipSigner) legacySignature(key []byte, obj metav1.Object) string {
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = obj.GetName()
+	}
+	return legacyOwnershipSignatureVersion + s.mac(key, obj, []string{namespace})
+}
+
+func (s *OwnershipSigner) mac(key []byte, obj metav1.Object, fields []string) string {
+	anno := obj.GetAnnotations()
+	for _, k := range ownershipAnnotations {
+		fields = append(fields, anno[k])
+	}
+	// json encoding keeps the fields unambiguous whatever they contain.
+	content, _ := json.Marshal(fields)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(content)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Sign records the signature of the ownership annotations in the annotations of obj, it must be
+// called once the super cluster namespace, the name and the ownership annotations of obj are set.
+func (s *OwnershipSigner) Sign(obj metav1.Object) {
+	if s == nil {
+		return
+	}
+	anno := obj.GetAnnotations()
+	if anno == nil {
+		anno = make(map[string]string)
+	}
+	name := obj.GetName()
+	if name == "" {
+		name = "generateName:" + obj.GetGenerateName()
+	}
+	anno[constants.LabelOwnershipSignature] = s.signature(s.keys[0], obj, name)
+	obj.SetAnnotations(anno)
+}
+
+// signed returns true if obj is signed by any of the keys, and false if it is not signed or its
+// signature is invalid. legacy is true if obj is validly signed by a legacy signature.
+func (s *OwnershipSigner) signed(obj metav1.Object) (signed, legacy bool) {
+	signature := obj.GetAnnotations()[constants.LabelOwnershipSignature]
+	for _, key := range s.keys {
+		if strings.HasPrefix(signature, legacyOwnershipSignatureVersion) {
+			if hmac.Equal([]byte(signature), []byte(s.legacySignature(key, obj))) {
+				return false, true
+			}
+			continue
+		}
+		for _, name := range ownershipNames(obj) {
+			if hmac.Equal([]byte(signature), []byte(s.signature(key, obj, name))) {
+				return true, false
+			}
+		}
+	}
+	return false, false
+}
+
+// Verify returns an error if the ownership annotations of obj are not signed by any of the keys. The
+// unsigned objects and the legacy signatures are accepted until the backfill is done, unless the
+// signatures are required.
+func (s *OwnershipSigner) Verify(obj metav1.Object) error {
+	if s == nil {
+		return nil
+	}
+	_, ok := obj.GetAnnotations()[constants.LabelOwnershipSignature]
+	signed, legacy := s.signed(obj)
+	switch {
+	case signed:
+		return nil
+	case !ok || legacy:
+		if !s.requireSignature && !s.Backfilled() {
+			return nil
+		}
+		metrics.OwnershipRejected.WithLabelValues("Unsigned").Inc()
+		return fmt.Errorf("object %s/%s has no ownership signature", obj.GetNamespace(), obj.GetName())
+	}
+	metrics.OwnershipRejected.WithLabelValues("InvalidSignature").Inc()
+	return fmt.Errorf("object %s/%s has an invalid ownership signature", obj.GetNamespace(), obj.GetName())
+}
+
+// NeedsBackfill returns true if obj is unsigned, or signed by a legacy signature, and must be
+// re-signed by the backfill. The objects with an invalid signature are left to be rejected.
+func (s *OwnershipSigner) NeedsBackfill(obj metav1.Object) bool {
+	if s == nil {
+		return false
+	}
+	if _, ok := obj.GetAnnotations()[constants.LabelOwnershipSignature]; !ok {
+		return true
+	}
+	_, legacy := s.signed(obj)
+	return legacy
+}
+
+// SetBackfilled records that the existing objects are signed, the unsigned objects are rejected
+// from then on.
+func (s *OwnershipSigner) SetBackfilled() {
+	atomic.StoreInt32(&s.backfilled, 1)
+}
+
+// Backfilled returns true once the existing objects are signed.
+func (s *OwnershipSigner) Backfilled() bool {
+	return atomic.LoadInt32(&s.backfilled) == 1
+}
+
+var (
+	ownershipSignerLock sync.RWMutex
+	ownershipSigner     *OwnershipSigner
+)
+
+// SetOwnershipSigner sets the signer of SignOwnership and VerifyOwnership, a nil signer disables the
+// signatures.
+func SetOwnershipSigner(s *OwnershipSigner) {
+	ownershipSignerLock.Lock()
+	defer ownershipSignerLock.Unlock()
+	ownershipSigner = s
+}
+
+// GetOwnershipSigner returns the signer of SignOwnership and VerifyOwnership, it is nil unless feature
+// OwnershipSignature is enabled.
+func GetOwnershipSigner() *OwnershipSigner {
+	ownershipSignerLock.RLock()
+	defer ownershipSignerLock.RUnlock()
+	return ownershipSigner
+}
+
+// SignOwnership signs the ownership annotations of the super cluster object, it is a no-op unless
+// feature OwnershipSignature is enabled.
+func SignOwnership(obj metav1.Object) {
+	GetOwnershipSigner().Sign(obj)
+}
+
+// VerifyOwnership returns an error if the ownership annotations of the super cluster object are forged,
+// it must be called before deleting the object. It always succeeds unless feature OwnershipSignature
+// is enabled.
+func VerifyOwnership(obj metav1.Object) error {
+	return GetOwnershipSigner().Verify(obj)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"bytes"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func TestOwnershipSigner(t *testing.T) {
+	oldKey := bytes.Repeat([]byte("a"), minOwnershipKeySize)
+	newKey := bytes.Repeat([]byte("b"), minOwnershipKeySize)
+
+	signed := func(key []byte) *v1.ConfigMap {
+		cm := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "cluster-default",
+				Name:      "cm",
+				Annotations: map[string]string{
+					constants.LabelCluster:   "cluster",
+					constants.LabelNamespace: "default",
+					constants.LabelUID:       "uid",
+				},
+			},
+		}
+		s, err := NewOwnershipSigner([][]byte{key}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Sign(cm)
+		return cm
+	}
+
+	legacySigned := func(key []byte, cm *v1.ConfigMap) string {
+		s, err := NewOwnershipSigner([][]byte{key}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.legacySignature(key, cm)
+	}
+
+	for _, tc := range []struct {
+		name             string
+		obj              *v1.ConfigMap
+		mutate           func(cm *v1.ConfigMap)
+		requireSignature bool
+		backfilled       bool
+		expectErr        bool
+	}{
+		{
+			name: "signed",
+			obj:  signed(newKey),
+		},
+		{
+			name: "signed by the previous key",
+			obj:  signed(oldKey),
+		},
+		{
+			name:      "signed by an unknown key",
+			obj:       signed(bytes.Repeat([]byte("c"), minOwnershipKeySize)),
+			expectErr: true,
+		},
+		{
+			name: "forged uid",
+			obj:  signed(newKey),
+			mutate: func(cm *v1.ConfigMap) {
+				cm.Annotations[constants.LabelUID] = "other"
+			},
+			expectErr: true,
+		},
+		{
+			name: "copied to another namespace",
+			obj:  signed(newKey),
+			mutate: func(cm *v1.ConfigMap) {
+				cm.Namespace = "cluster-other"
+			},
+			expectErr: true,
+		},
+		{
+			name: "copied to another object",
+			obj:  signed(newKey),
+			mutate: func(cm *v1.ConfigMap) {
+				cm.Name = "other"
+			},
+			expectErr: true,
+		},
+		{
+			name: "legacy signature",
+			obj:  signed(newKey),
+			mutate: func(cm *v1.ConfigMap) {
+				cm.Annotations[constants.LabelOwnershipSignature] = legacySigned(newKey, cm)
+			},
+		},
+		{
+			name: "legacy signature after the backfill",
+			obj:  signed(newKey),
+			mutate: func(cm *v1.ConfigMap) {
+				cm.Annotations[constants.LabelOwnershipSignature] = legacySigned(newKey, cm)
+			},
+			backfilled: true,
+			expectErr:  true,
+		},
+		{
+			name: "unsigned",
+			obj:  signed(newKey),
+			mutate: func(cm *v1.ConfigMap) {
+				delete(cm.Annotations, constants.LabelOwnershipSignature)
+			},
+		},
+		{
+			name: "unsigned but signature required",
+			obj:  signed(newKey),
+			mutate: func(cm *v1.ConfigMap) {
+				delete(cm.Annotations, constants.LabelOwnershipSignature)
+			},
+			requireSignature: true,
+			expectErr:        true,
+		},
+		{
+			name: "unsigned after the backfill",
+			obj:  signed(newKey),
+			mutate: func(cm *v1.ConfigMap) {
+				delete(cm.Annotations, constants.LabelOwnershipSignature)
+			},
+			backfilled: true,
+			expectErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewOwnershipSigner([][]byte{newKey, oldKey}, tc.requireSignature)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.backfilled {
+				s.SetBackfilled()
+			}
+			if tc.mutate != nil {
+				tc.mutate(tc.obj)
+			}
+			if err := s.Verify(tc.obj); (err != nil) != tc.expectErr {
+				t.Errorf("expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestNewOwnershipSignerRejectsShortKeys(t *testing.T) {
+	if _, err := NewOwnershipSigner([][]byte{[]byte("short")}, false); err == nil {
+		t.Errorf("expected an error for a short key")
+	}
+	if _, err := NewOwnershipSigner(nil, false); err == nil {
+		t.Errorf("expected an error without keys")
+	}
+}
+
+func TestOwnershipSignerKindAndGeneratedName(t *testing.T) {
+	s, err := NewOwnershipSigner([][]byte{bytes.Repeat([]byte("a"), minOwnershipKeySize)}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := metav1.ObjectMeta{
+		Namespace:    "cluster-default",
+		GenerateName: "default-token-",
+		Annotations: map[string]string{
+			constants.LabelCluster:   "cluster",
+			constants.LabelNamespace: "default",
+			constants.LabelUID:       "uid",
+		},
+	}
+	secret := &v1.Secret{ObjectMeta: meta}
+	s.Sign(secret)
+	// the apiserver names the secret
+	secret.Name = "default-token-x7k2p"
+	if err := s.Verify(secret); err != nil {
+		t.Errorf("expected the secret named by the apiserver to be verified, got %v", err)
+	}
+	if s.NeedsBackfill(secret) {
+		t.Errorf("expected the signed secret not to need a backfill")
+	}
+
+	cm := &v1.ConfigMap{ObjectMeta: *secret.ObjectMeta.DeepCopy()}
+	if err := s.Verify(cm); err == nil {
+		t.Errorf("expected the signature of a secret to be rejected on a configmap")
+	}
+	if s.NeedsBackfill(cm) {
+		t.Errorf("expected an invalid signature not to be backfilled")
+	}
+	delete(cm.Annotations, constants.LabelOwnershipSignature)
+	if !s.NeedsBackfill(cm) {
+		t.Errorf("expected an unsigned configmap to need a backfill")
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// kubeletConditions are the pod conditions reported by the super kubelet, tenants can't set them
+// even through readiness gates.
+var kubeletConditions = sets.NewString(
+	string(v1.PodScheduled),
+	string(v1.PodInitialized),
+	string(v1.ContainersReady),
+	string(v1.PodReady),
+)
+
+// ValidatePodReadinessGates checks that the readiness gates injected into the super pods are not
+// conditions of the kubelet.
+func ValidatePodReadinessGates(gates []string) error {
+	for _, gate := range gates {
+		if gate == "" || kubeletConditions.Has(gate) {
+			return fmt.Errorf("%q can't be injected as a pod readiness gate", gate)
+		}
+	}
+	return nil
+}
+
+// PodMutateReadinessGates injects the readiness gates into the super pod, if the VirtualCluster of
+// the pod opts into them. The injected gates are recorded on the super pod, their conditions are
+// owned by the tenant like the ones of the tenant readiness gates.
+func PodMutateReadinessGates(gates []string) PodMutator {
+	return func(p *PodMutateCtx) error {
+		vc, err := p.Mc.GetClusterObject(p.ClusterName)
+		if err != nil {
+			return err
+		}
+		injectReadinessGates(p.PPod, OptedInReadinessGates(vc, gates))
+		return nil
+	}
+}
+
+// OptedInReadinessGates returns the readiness gates the VirtualCluster opts into among the given
+// ones, i.e. the ones listed in its LabelPodReadinessGates annotation. A tenant opts into the gates
+// whose conditions its controllers set, the pods of the other tenants would never be ready.
+func OptedInReadinessGates(vc metav1.Object, gates []string) []string {
+	v := vc.GetAnnotations()[constants.LabelPodReadinessGates]
+	if v == "" {
+		return nil
+	}
+	optedIn := sets.NewString(strings.Split(v, ",")...)
+	var enabled []string
+	for _, gate := range gates {
+		if optedIn.Has(gate) {
+			enabled = append(enabled, gate)
+		}
+	}
+	return enabled
+}
+
+func injectReadinessGates(pPod *v1.Pod, gates []string) {
+	existing := sets.NewString()
+	for _, r := range pPod.Spec.ReadinessGates {
+		existing.Insert(string(r.ConditionType))
+	}
+	var injected []string
+	for _, gate := range gates {
+		if existing.Has(gate) {
+			continue
+		}
+		existing.Insert(gate)
+		injected = append(injected, gate)
+		pPod.Spec.ReadinessGates = append(pPod.Spec.ReadinessGates, v1.PodReadinessGate{ConditionType: v1.PodConditionType(gate)})
+	}
+	if len(injected) == 0 {
+		return
+	}
+	if pPod.Annotations == nil {
+		pPod.Annotations = map[string]string{}
+	}
+	pPod.Annotations[constants.LabelInjectedReadinessGates] = strings.Join(injected, ",")
+}
+
+// tenantReadinessGates returns the readiness gates whose conditions are set by the tenant: the
+// readiness gates of the tenant pod and the ones injected into the super pod. The conditions of the
+// kubelet are always owned by the super cluster, as are the readiness gates added to the super pod
+// by super cluster webhooks.
+func tenantReadinessGates(pPod, vPod *v1.Pod) sets.String {
+	gates := sets.NewString()
+	for _, r := range vPod.Spec.ReadinessGates {
+		gates.Insert(string(r.ConditionType))
+	}
+	if injected := pPod.Annotations[constants.LabelInjectedReadinessGates]; injected != "" {
+		gates.Insert(strings.Split(injected, ",")...)
+	}
+	return gates.Difference(kubeletConditions)
+}
+
+// TenantConditionsChanged returns true if the conditions of the tenant pod which can be owned by the
+// tenant changed, i.e. the ones which aren't conditions of the kubelet.
+func TenantConditionsChanged(oldPod, newPod *v1.Pod) bool {
+	return !equality.Semantic.DeepEqual(nonKubeletConditions(oldPod), nonKubeletConditions(newPod))
+}
+
+func nonKubeletConditions(pod *v1.Pod) []v1.PodCondition {
+	var conditions []v1.PodCondition
+	for _, c := range pod.Status.Conditions {
+		if !kubeletConditions.Has(string(c.Type)) {
+			conditions = append(conditions, c)
+		}
+	}
+	return conditions
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func TestValidatePodReadinessGates(t *testing.T) {
+	for _, tt := range []struct {
+		gates   []string
+		wantErr bool
+	}{
+		{gates: nil},
+		{gates: []string{"example.com/tenant-ready"}},
+		{gates: []string{"example.com/tenant-ready", "Ready"}, wantErr: true},
+		{gates: []string{""}, wantErr: true},
+	} {
+		if err := ValidatePodReadinessGates(tt.gates); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePodReadinessGates(%v) error = %v, wantErr %v", tt.gates, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInjectReadinessGates(t *testing.T) {
+	pPod := &v1.Pod{
+		Spec: v1.PodSpec{
+			ReadinessGates: []v1.PodReadinessGate{{ConditionType: "example.com/lb"}},
+		},
+	}
+	injectReadinessGates(pPod, []string{"example.com/lb", "example.com/tenant-ready"})
+
+	expected := []v1.PodReadinessGate{{ConditionType: "example.com/lb"}, {ConditionType: "example.com/tenant-ready"}}
+	if !equality.Semantic.DeepEqual(pPod.Spec.ReadinessGates, expected) {
+		t.Errorf("expected readiness gates %v, got %v", expected, pPod.Spec.ReadinessGates)
+	}
+	if got := pPod.Annotations[constants.LabelInjectedReadinessGates]; got != "example.com/tenant-ready" {
+		t.Errorf("expected the injected gates to be recorded, got %q", got)
+	}
+}
+
+func TestOptedInReadinessGates(t *testing.T) {
+	gates := []string{"example.com/tenant-ready", "example.com/mesh-ready"}
+	for _, tt := range []struct {
+		annotation string
+		expected   []string
+	}{
+		{annotation: "", expected: nil},
+		{annotation: "example.com/mesh-ready", expected: []string{"example.com/mesh-ready"}},
+		{annotation: "example.com/mesh-ready,example.com/tenant-ready", expected: gates},
+		{annotation: "example.com/unknown", expected: nil},
+	} {
+		vc := &metav1.ObjectMeta{Annotations: map[string]string{constants.LabelPodReadinessGates: tt.annotation}}
+		if got := OptedInReadinessGates(vc, gates); !equality.Semantic.DeepEqual(got, tt.expected) {
+			t.Errorf("OptedInReadinessGates(%q) = %v, expected %v", tt.annotation, got, tt.expected)
+		}
+	}
+}
+
+func TestInjectedReadinessGateConditions(t *testing.T) {
+	injected := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{constants.LabelInjectedReadinessGates: "example.com/tenant-ready"},
+		},
+		Spec: v1.PodSpec{
+			ReadinessGates: []v1.PodReadinessGate{{ConditionType: "example.com/tenant-ready"}, {ConditionType: "example.com/super-lb"}},
+		},
+		Status: v1.PodStatus{
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionFalse},
+				{Type: "example.com/super-lb", Status: v1.ConditionTrue},
+			},
+		},
+	}
+	tenant := &v1.Pod{
+		Spec: v1.PodSpec{
+			ReadinessGates: []v1.PodReadinessGate{{ConditionType: v1.PodReady}},
+		},
+		Status: v1.PodStatus{
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+				{Type: "example.com/tenant-ready", Status: v1.ConditionTrue},
+			},
+		},
+	}
+
+	t.Run("downward", func(t *testing.T) {
+		expected := &v1.PodStatus{
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionFalse},
+				{Type: "example.com/super-lb", Status: v1.ConditionTrue},
+				{Type: "example.com/tenant-ready", Status: v1.ConditionTrue},
+			},
+		}
+		if got := CheckDWPodConditionEquality(injected, tenant); !equality.Semantic.DeepEqual(got, expected) {
+			t.Errorf("expected status %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("upward", func(t *testing.T) {
+		expected := &v1.PodStatus{
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionFalse},
+				{Type: "example.com/tenant-ready", Status: v1.ConditionTrue},
+			},
+		}
+		if got := Equality(nil, nil).CheckUWPodStatusEquality(injected, tenant); !equality.Semantic.DeepEqual(got, expected) {
+			t.Errorf("expected status %v, got %v", expected, got)
+		}
+	})
+}
+
+func TestTenantConditionsChanged(t *testing.T) {
+	pod := &v1.Pod{Status: v1.PodStatus{Conditions: []v1.PodCondition{
+		{Type: v1.PodReady, Status: v1.ConditionFalse},
+		{Type: "example.com/tenant-ready", Status: v1.ConditionFalse},
+	}}}
+
+	kubelet := pod.DeepCopy()
+	kubelet.Status.Conditions[0].Status = v1.ConditionTrue
+	kubelet.Status.Phase = v1.PodRunning
+	if TenantConditionsChanged(pod, kubelet) {
+		t.Errorf("expected the kubelet conditions to be ignored")
+	}
+
+	tenant := pod.DeepCopy()
+	tenant.Status.Conditions[1].Status = v1.ConditionTrue
+	if !TenantConditionsChanged(pod, tenant) {
+		t.Errorf("expected the change of the readiness gate condition to be reported")
+	}
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// The typed BuildSuperCluster<Kind> and BuildVirtual<Kind> converters of zz_generated.conversion.go
+// are generated from the resources listed in hack/conversion-gen. A new resource is added there,
+// with a mutateVirtual<Kind> hook here when its tenant counterpart needs more than a copy.
+//go:generate go run ../../../hack/conversion-gen
+
+// RecordSuperUID records the UID of pObj in the annotations of its tenant counterpart vObj.
+func RecordSuperUID(vObj, pObj metav1.Object) {
+	anno := vObj.GetAnnotations()
+	if anno == nil {
+		anno = make(map[string]string)
+	}
+	anno[constants.LabelUID] = string(pObj.GetUID())
+	vObj.SetAnnotations(anno)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func TestBuildVirtualObjects(t *testing.T) {
+	superMeta := metav1.ObjectMeta{
+		Name:            "obj",
+		UID:             "super-uid",
+		ResourceVersion: "10",
+		Finalizers:      []string{"kubernetes.io/pv-protection"},
+		ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kube-controller-manager"}},
+	}
+
+	pPV := &v1.PersistentVolume{
+		ObjectMeta: superMeta,
+		Spec:       v1.PersistentVolumeSpec{ClaimRef: &v1.ObjectReference{Namespace: "default-ns", Name: "pvc", UID: "super-pvc-uid"}},
+	}
+	vPVC := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pvc", UID: "tenant-pvc-uid"}}
+	vPV := BuildVirtualPersistentVolume(pPV, vPVC)
+	if vPV.UID != "" || vPV.ResourceVersion != "" || vPV.Finalizers != nil || vPV.ManagedFields != nil {
+		t.Errorf("expected the super metadata to be reset, got %+v", vPV.ObjectMeta)
+	}
+	if vPV.Annotations[constants.LabelUID] != "super-uid" {
+		t.Errorf("expected the super UID to be recorded, got %v", vPV.Annotations)
+	}
+	if vPV.Spec.ClaimRef.Namespace != "default" || vPV.Spec.ClaimRef.UID != "tenant-pvc-uid" {
+		t.Errorf("expected the pv to be bound to the tenant pvc, got %+v", vPV.Spec.ClaimRef)
+	}
+	if pPV.ManagedFields == nil || pPV.Spec.ClaimRef.Namespace != "default-ns" {
+		t.Errorf("expected the super pv to be left untouched")
+	}
+
+	vStorageClass := BuildVirtualStorageClass("cluster", &storagev1.StorageClass{ObjectMeta: superMeta, Provisioner: "csi"})
+	if vStorageClass.UID != "" || vStorageClass.ManagedFields != nil || vStorageClass.Annotations[constants.LabelUID] != "" {
+		t.Errorf("expected the super metadata to be reset without recording the UID, got %+v", vStorageClass.ObjectMeta)
+	}
+	if vStorageClass.Provisioner != "csi" {
+		t.Errorf("expected the storage class to be copied, got %+v", vStorageClass)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// mutateVirtualVolumeSnapshotContent binds the tenant counterpart of a super VolumeSnapshotContent to
+// vSnapshot. The snapshot is taken in the super control plane, hence the tenant content is a
+// pre-provisioned one pointing to the handle of the snapshot.
+func mutateVirtualVolumeSnapshotContent(vContent *snapshotv1.VolumeSnapshotContent, vSnapshot *snapshotv1.VolumeSnapshot) {
+	vContent.Spec.VolumeSnapshotRef.Namespace = vSnapshot.Namespace
+	vContent.Spec.VolumeSnapshotRef.UID = vSnapshot.UID
+	vContent.Spec.VolumeSnapshotRef.ResourceVersion = ""
+	if vContent.Status != nil && vContent.Status.SnapshotHandle != nil {
+		vContent.Spec.Source = snapshotv1.VolumeSnapshotContentSource{SnapshotHandle: vContent.Status.SnapshotHandle}
+	}
+}
+
+func (e vcEquality) CheckVolumeSnapshotEquality(pObj, vObj *snapshotv1.VolumeSnapshot) *snapshotv1.VolumeSnapshot {
+	var updated *snapshotv1.VolumeSnapshot
+	updatedMeta := e.CheckDWObjectMetaEquality(&pObj.ObjectMeta, &vObj.ObjectMeta)
+	if updatedMeta != nil {
+		updated = pObj.DeepCopy()
+		updated.ObjectMeta = *updatedMeta
+	}
+	// The spec of a snapshot is immutable and its status is managed by the super control plane snapshot controller.
+	return updated
+}
+
+func (e vcEquality) CheckUWVolumeSnapshotStatusEquality(pObj, vObj *snapshotv1.VolumeSnapshot) *snapshotv1.VolumeSnapshot {
+	if pObj.Status == nil || equality.Semantic.DeepEqual(pObj.Status, vObj.Status) {
+		return nil
+	}
+	updated := vObj.DeepCopy()
+	updated.Status = pObj.Status.DeepCopy()
+	return updated
+}
+
+func (e vcEquality) CheckUWVolumeSnapshotContentSpecEquality(pObj, vObj *snapshotv1.VolumeSnapshotContentSpec) *snapshotv1.VolumeSnapshotContentSpec {
+	pCopy := pObj.DeepCopy()
+	pCopy.VolumeSnapshotRef = vObj.VolumeSnapshotRef
+	pCopy.Source = vObj.Source
+	if equality.Semantic.DeepEqual(vObj, pCopy) {
+		return nil
+	}
+	return pCopy
+}
+
+func (e vcEquality) CheckUWVolumeSnapshotContentStatusEquality(pObj, vObj *snapshotv1.VolumeSnapshotContent) *snapshotv1.VolumeSnapshotContent {
+	if pObj.Status == nil || equality.Semantic.DeepEqual(pObj.Status, vObj.Status) {
+		return nil
+	}
+	updated := vObj.DeepCopy()
+	updated.Status = pObj.Status.DeepCopy()
+	return updated
+}
+
+func (e vcEquality) CheckVolumeSnapshotClassEquality(pObj, vObj *snapshotv1.VolumeSnapshotClass) *snapshotv1.VolumeSnapshotClass {
+	pObjCopy := pObj.DeepCopy()
+	pObjCopy.ObjectMeta = vObj.ObjectMeta
+	// pObj.TypeMeta is empty
+	pObjCopy.TypeMeta = vObj.TypeMeta
+
+	if !equality.Semantic.DeepEqual(vObj, pObjCopy) {
+		return pObjCopy
+	}
+	return nil
+}
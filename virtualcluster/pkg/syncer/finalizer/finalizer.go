@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package finalizer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+// Policy decides how the finalizers of a tenant object are translated to its super cluster object.
+type Policy string
+
+const (
+	// Strip never copies the tenant finalizers to the super cluster object, so the super cluster
+	// deletion is never blocked by tenant controllers. It is the default.
+	Strip Policy = "Strip"
+	// Mirror copies the tenant finalizers to the super cluster object, so the super cluster deletion
+	// waits until the tenant controllers remove them.
+	Mirror Policy = "Mirror"
+	// BlockWithTimeout mirrors the tenant finalizers like Mirror, but removes them from a super
+	// cluster object that has been terminating for longer than the block timeout.
+	BlockWithTimeout Policy = "BlockWithTimeout"
+)
+
+// DefaultBlockTimeout is the default time a super cluster deletion is blocked under BlockWithTimeout.
+const DefaultBlockTimeout = 10 * time.Minute
+
+// ValidatePolicies checks policies configured as resource=Policy.
+func ValidatePolicies(policies map[string]string) error {
+	for resource, p := range policies {
+		if strings.TrimSpace(resource) == "" {
+			return fmt.Errorf("invalid finalizer policy key %q, expected <resource>", resource)
+		}
+		switch Policy(p) {
+		case Strip, Mirror, BlockWithTimeout:
+		default:
+			return fmt.Errorf("invalid finalizer policy %q for %q, expected %s, %s or %s", p, resource, Strip, Mirror, BlockWithTimeout)
+		}
+	}
+	return nil
+}
+
+// Translator translates the finalizers of the tenant objects of one resource to their super
+// cluster objects. The finalizers it copied are recorded in the LabelMirroredFinalizers annotation
+// of the super cluster object, so that finalizers added by super cluster controllers are kept.
+type Translator struct {
+	resource string
+	policy   Policy
+	timeout  time.Duration
+	now      func() time.Time
+}
+
+// NewTranslator creates a Translator for resource from the configured policies.
+func NewTranslator(resource string, policies map[string]string, timeout time.Duration) *Translator {
+	t := &Translator{
+		resource: resource,
+		policy:   Strip,
+		timeout:  timeout,
+		now:      time.Now,
+	}
+	if p, ok := policies[resource]; ok {
+		t.policy = Policy(p)
+	}
+	if t.timeout <= 0 {
+		t.timeout = DefaultBlockTimeout
+	}
+	return t
+}
+
+// Policy returns the policy applied to the resource.
+func (t *Translator) Policy() Policy {
+	return t.policy
+}
+
+// Apply sets the finalizers of vObj, as translated by the policy, on pObj. The mirrored finalizers
+// are removed from a super cluster object whose block timeout expired. It returns whether pObj
+// was changed.
+func (t *Translator) Apply(vObj, pObj client.Object) bool {
+	var desired []string
+	if t.policy != Strip && vObj != nil {
+		desired = vObj.GetFinalizers()
+	}
+	if t.Expired(pObj) {
+		desired = nil
+		metrics.FinalizerStuckDeletionCounter.WithLabelValues(t.resource, string(t.policy)).Inc()
+		klog.Warningf("%s %s/%s has been terminating for more than %v in super cluster, removing mirrored finalizers %v",
+			t.resource, pObj.GetNamespace(), pObj.GetName(), t.timeout, mirrored(pObj).List())
+	}
+	return t.set(pObj, desired)
+}
+
+// Release removes the mirrored finalizers from pObj, so that the deletion of pObj is not blocked
+// once the tenant object is gone. It returns whether pObj was changed.
+func (t *Translator) Release(pObj client.Object) bool {
+	return t.set(pObj, nil)
+}
+
+// Expired returns whether pObj has been terminating with mirrored finalizers for longer than the
+// block timeout under BlockWithTimeout.
+func (t *Translator) Expired(pObj client.Object) bool {
+	if t.policy != BlockWithTimeout || pObj.GetDeletionTimestamp() == nil || mirrored(pObj).Len() == 0 {
+		return false
+	}
+	return t.now().Sub(pObj.GetDeletionTimestamp().Time) > t.timeout
+}
+
+func (t *Translator) set(pObj client.Object, desired []string) bool {
+	old := mirrored(pObj)
+	var finalizers []string
+	for _, f := range pObj.GetFinalizers() {
+		if !old.Has(f) {
+			finalizers = append(finalizers, f)
+		}
+	}
+	current := sets.NewString(finalizers...)
+	added := sets.NewString()
+	for _, f := range desired {
+		if !current.Has(f) {
+			finalizers = append(finalizers, f)
+			current.Insert(f)
+			added.Insert(f)
+		}
+	}
+
+	changed := !sets.NewString(pObj.GetFinalizers()...).Equal(current) || !old.Equal(added)
+	if !changed {
+		return false
+	}
+	if old.Len() > 0 && added.Len() == 0 && pObj.GetDeletionTimestamp() != nil {
+		metrics.FinalizerBlockedDeletionDuration.WithLabelValues(t.resource).Observe(t.now().Sub(pObj.GetDeletionTimestamp().Time).Seconds())
+	}
+
+	pObj.SetFinalizers(finalizers)
+	anno := pObj.GetAnnotations()
+	if added.Len() == 0 {
+		delete(anno, constants.LabelMirroredFinalizers)
+	} else {
+		if anno == nil {
+			anno = make(map[string]string)
+		}
+		anno[constants.LabelMirroredFinalizers] = strings.Join(added.List(), ",")
+	}
+	pObj.SetAnnotations(anno)
+	return true
+}
+
+// mirrored returns the finalizers of pObj copied from the tenant object.
+func mirrored(pObj client.Object) sets.String {
+	v := pObj.GetAnnotations()[constants.LabelMirroredFinalizers]
+	if v == "" {
+		return sets.NewString()
+	}
+	return sets.NewString(strings.Split(v, ",")...)
+}
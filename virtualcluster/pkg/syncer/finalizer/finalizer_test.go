@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package finalizer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func newConfigMap(finalizers []string, mirrored string, deleted *metav1.Time) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "cm",
+			Namespace:         "ns",
+			Finalizers:        finalizers,
+			DeletionTimestamp: deleted,
+		},
+	}
+	if mirrored != "" {
+		cm.Annotations = map[string]string{constants.LabelMirroredFinalizers: mirrored}
+	}
+	return cm
+}
+
+func TestApply(t *testing.T) {
+	now := time.Now()
+	longAgo := metav1.NewTime(now.Add(-time.Hour))
+	recently := metav1.NewTime(now.Add(-time.Second))
+
+	for _, tc := range []struct {
+		name             string
+		policies         map[string]string
+		vFinalizers      []string
+		pObj             *corev1.ConfigMap
+		expectChanged    bool
+		expectFinalizers []string
+		expectMirrored   string
+	}{
+		{
+			name:             "strip by default",
+			vFinalizers:      []string{"tenant.io/a"},
+			pObj:             newConfigMap([]string{"super.io/a"}, "", nil),
+			expectFinalizers: []string{"super.io/a"},
+		},
+		{
+			name:             "strip removes previously mirrored finalizers",
+			vFinalizers:      []string{"tenant.io/a"},
+			pObj:             newConfigMap([]string{"super.io/a", "tenant.io/a"}, "tenant.io/a", nil),
+			expectChanged:    true,
+			expectFinalizers: []string{"super.io/a"},
+		},
+		{
+			name:             "mirror keeps super finalizers",
+			policies:         map[string]string{"configmaps": "Mirror"},
+			vFinalizers:      []string{"tenant.io/a"},
+			pObj:             newConfigMap([]string{"super.io/a"}, "", nil),
+			expectChanged:    true,
+			expectFinalizers: []string{"super.io/a", "tenant.io/a"},
+			expectMirrored:   "tenant.io/a",
+		},
+		{
+			name:             "mirror removes finalizers removed in tenant",
+			policies:         map[string]string{"configmaps": "Mirror"},
+			vFinalizers:      []string{"tenant.io/b"},
+			pObj:             newConfigMap([]string{"tenant.io/a", "tenant.io/b"}, "tenant.io/a,tenant.io/b", nil),
+			expectChanged:    true,
+			expectFinalizers: []string{"tenant.io/b"},
+			expectMirrored:   "tenant.io/b",
+		},
+		{
+			name:             "mirror up to date",
+			policies:         map[string]string{"configmaps": "Mirror"},
+			vFinalizers:      []string{"tenant.io/a"},
+			pObj:             newConfigMap([]string{"tenant.io/a"}, "tenant.io/a", &longAgo),
+			expectFinalizers: []string{"tenant.io/a"},
+			expectMirrored:   "tenant.io/a",
+		},
+		{
+			name:             "block within timeout",
+			policies:         map[string]string{"configmaps": "BlockWithTimeout"},
+			vFinalizers:      []string{"tenant.io/a"},
+			pObj:             newConfigMap([]string{"tenant.io/a"}, "tenant.io/a", &recently),
+			expectFinalizers: []string{"tenant.io/a"},
+			expectMirrored:   "tenant.io/a",
+		},
+		{
+			name:          "block timeout expired",
+			policies:      map[string]string{"configmaps": "BlockWithTimeout"},
+			vFinalizers:   []string{"tenant.io/a"},
+			pObj:          newConfigMap([]string{"tenant.io/a"}, "tenant.io/a", &longAgo),
+			expectChanged: true,
+		},
+		{
+			name:        "policies of other resources are ignored",
+			policies:    map[string]string{"secrets": "Mirror"},
+			vFinalizers: []string{"tenant.io/a"},
+			pObj:        newConfigMap(nil, "", nil),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			translator := NewTranslator("configmaps", tc.policies, time.Minute)
+			translator.now = func() time.Time { return now }
+			vObj := newConfigMap(tc.vFinalizers, "", nil)
+
+			changed := translator.Apply(vObj, tc.pObj)
+			if changed != tc.expectChanged {
+				t.Errorf("expected changed %v, got %v", tc.expectChanged, changed)
+			}
+			if !reflect.DeepEqual(tc.pObj.Finalizers, tc.expectFinalizers) {
+				t.Errorf("expected finalizers %v, got %v", tc.expectFinalizers, tc.pObj.Finalizers)
+			}
+			if got := tc.pObj.Annotations[constants.LabelMirroredFinalizers]; got != tc.expectMirrored {
+				t.Errorf("expected mirrored finalizers %q, got %q", tc.expectMirrored, got)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	translator := NewTranslator("configmaps", map[string]string{"configmaps": "Mirror"}, 0)
+	pObj := newConfigMap([]string{"super.io/a", "tenant.io/a"}, "tenant.io/a", nil)
+	if !translator.Release(pObj) {
+		t.Fatalf("expected mirrored finalizers to be released")
+	}
+	if !reflect.DeepEqual(pObj.Finalizers, []string{"super.io/a"}) {
+		t.Errorf("expected super finalizers to be kept, got %v", pObj.Finalizers)
+	}
+	if _, ok := pObj.Annotations[constants.LabelMirroredFinalizers]; ok {
+		t.Errorf("expected mirrored finalizers annotation to be removed")
+	}
+	if translator.Release(pObj) {
+		t.Errorf("expected released object to be unchanged")
+	}
+}
+
+func TestValidatePolicies(t *testing.T) {
+	if err := ValidatePolicies(map[string]string{"configmaps": "Mirror", "secrets": "BlockWithTimeout", "pvcs": "Strip"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidatePolicies(map[string]string{"configmaps": "Keep"}); err == nil {
+		t.Errorf("expected error for unknown policy")
+	}
+	if err := ValidatePolicies(map[string]string{" ": "Mirror"}); err == nil {
+		t.Errorf("expected error for empty resource")
+	}
+}
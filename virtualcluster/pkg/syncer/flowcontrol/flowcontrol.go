@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package flowcontrol maps the sync traffic of every tenant to its own flow of
+// the super cluster API Priority and Fairness, used by featuregate.TenantFlowControl.
+package flowcontrol
+
+import (
+	"net/http"
+
+	flowcontrolv1beta1 "k8s.io/api/flowcontrol/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/transport"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+)
+
+const (
+	// DefaultName is the name of the PriorityLevelConfiguration and FlowSchema of the tenant sync traffic.
+	DefaultName = "vc-syncer-tenants"
+
+	// matchingPrecedence puts the FlowSchema ahead of the suggested service-accounts FlowSchema,
+	// which would otherwise match the per-tenant service accounts.
+	matchingPrecedence = 1000
+	// assuredConcurrencyShares of the tenant sync traffic, the same as the suggested workload-low
+	// priority level that the syncer requests fall into by default.
+	assuredConcurrencyShares = 100
+	queues                   = 128
+	handSize                 = 6
+	queueLengthLimit         = 50
+)
+
+// PriorityLevel returns the PriorityLevelConfiguration shared by the sync traffic of all tenants.
+// Its requests are fair queued per tenant, so one busy tenant only fills its own queues.
+func PriorityLevel(name string) *flowcontrolv1beta1.PriorityLevelConfiguration {
+	return &flowcontrolv1beta1.PriorityLevelConfiguration{
+		TypeMeta:   metav1.TypeMeta{APIVersion: flowcontrolv1beta1.SchemeGroupVersion.String(), Kind: "PriorityLevelConfiguration"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: flowcontrolv1beta1.PriorityLevelConfigurationSpec{
+			Type: flowcontrolv1beta1.PriorityLevelEnablementLimited,
+			Limited: &flowcontrolv1beta1.LimitedPriorityLevelConfiguration{
+				AssuredConcurrencyShares: assuredConcurrencyShares,
+				LimitResponse: flowcontrolv1beta1.LimitResponse{
+					Type: flowcontrolv1beta1.LimitResponseTypeQueue,
+					Queuing: &flowcontrolv1beta1.QueuingConfiguration{
+						Queues:           queues,
+						HandSize:         handSize,
+						QueueLengthLimit: queueLengthLimit,
+					},
+				},
+			},
+		},
+	}
+}
+
+// FlowSchema returns the FlowSchema matching the requests the syncer makes as the per-tenant
+// identities of featuregate.TenantImpersonation. Distinguishing the flows by user gives every
+// tenant its own flow in the priority level.
+func FlowSchema(name string, cfg *config.SyncerConfiguration) *flowcontrolv1beta1.FlowSchema {
+	return &flowcontrolv1beta1.FlowSchema{
+		TypeMeta:   metav1.TypeMeta{APIVersion: flowcontrolv1beta1.SchemeGroupVersion.String(), Kind: "FlowSchema"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: flowcontrolv1beta1.FlowSchemaSpec{
+			PriorityLevelConfiguration: flowcontrolv1beta1.PriorityLevelConfigurationReference{Name: name},
+			MatchingPrecedence:         matchingPrecedence,
+			DistinguisherMethod:        &flowcontrolv1beta1.FlowDistinguisherMethod{Type: flowcontrolv1beta1.FlowDistinguisherMethodByUserType},
+			Rules: []flowcontrolv1beta1.PolicyRulesWithSubjects{{
+				Subjects: []flowcontrolv1beta1.Subject{tenantsSubject(cfg)},
+				ResourceRules: []flowcontrolv1beta1.ResourcePolicyRule{{
+					Verbs:        []string{flowcontrolv1beta1.VerbAll},
+					APIGroups:    []string{flowcontrolv1beta1.APIGroupAll},
+					Resources:    []string{flowcontrolv1beta1.ResourceAll},
+					Namespaces:   []string{flowcontrolv1beta1.NamespaceEvery},
+					ClusterScope: true,
+				}},
+			}},
+		},
+	}
+}
+
+// tenantsSubject matches the identities impersonated for all the tenants.
+func tenantsSubject(cfg *config.SyncerConfiguration) flowcontrolv1beta1.Subject {
+	if rbac.UsesServiceAccounts(cfg) {
+		return flowcontrolv1beta1.Subject{
+			Kind: flowcontrolv1beta1.SubjectKindServiceAccount,
+			ServiceAccount: &flowcontrolv1beta1.ServiceAccountSubject{
+				Namespace: cfg.TenantServiceAccountNamespace,
+				Name:      flowcontrolv1beta1.NameAll,
+			},
+		}
+	}
+	return flowcontrolv1beta1.Subject{
+		Kind:  flowcontrolv1beta1.SubjectKindGroup,
+		Group: &flowcontrolv1beta1.GroupSubject{Name: rbac.TenantsGroup},
+	}
+}
+
+// Manifests returns the API Priority and Fairness objects isolating the sync traffic of the tenants.
+func Manifests(cfg *config.SyncerConfiguration) []runtime.Object {
+	return []runtime.Object{PriorityLevel(DefaultName), FlowSchema(DefaultName, cfg)}
+}
+
+// LabelTenants returns a transport wrapper that appends the tenant cluster to the user agent of
+// every request made into a tenant namespace of the super cluster, so that the tenants can be
+// told apart in the audit log of the super apiserver.
+func LabelTenants(nsLister listersv1.NamespaceLister) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &labelingRoundTripper{nsLister: nsLister, delegate: rt}
+	}
+}
+
+type labelingRoundTripper struct {
+	nsLister listersv1.NamespaceLister
+	delegate http.RoundTripper
+}
+
+func (rt *labelingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	cluster := rbac.TenantOf(rt.nsLister, req.URL.Path)
+	if cluster == "" {
+		return rt.delegate.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", TenantUserAgent(req.Header.Get("User-Agent"), cluster))
+	return rt.delegate.RoundTrip(req)
+}
+
+// TenantUserAgent returns the user agent of the requests made on behalf of the tenant cluster.
+func TenantUserAgent(userAgent, cluster string) string {
+	if userAgent == "" {
+		return "tenant/" + cluster
+	}
+	return userAgent + " tenant/" + cluster
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	flowcontrolv1beta1 "k8s.io/api/flowcontrol/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+)
+
+type recordingRoundTripper struct {
+	req *http.Request
+}
+
+func (rt *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.req = req
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestLabelTenants(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "tenant-a-default",
+		Annotations: map[string]string{constants.LabelCluster: "tenant-a"},
+	}})
+	lister := listersv1.NewNamespaceLister(indexer)
+
+	tests := map[string]struct {
+		path string
+		want string
+	}{
+		"tenant namespace":       {path: "/api/v1/namespaces/tenant-a-default/pods/foo", want: "resource-syncer/v0 tenant/tenant-a"},
+		"cluster scoped":         {path: "/api/v1/nodes/node-1", want: "resource-syncer/v0"},
+		"not a tenant namespace": {path: "/api/v1/namespaces/kube-system/configmaps/foo", want: "resource-syncer/v0"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			delegate := &recordingRoundTripper{}
+			rt := LabelTenants(lister)(delegate)
+			req, err := http.NewRequest(http.MethodGet, "https://super"+tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("User-Agent", "resource-syncer/v0")
+			if _, err := rt.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := delegate.req.Header.Get("User-Agent"); got != tc.want {
+				t.Errorf("expected user agent %q, got %q", tc.want, got)
+			}
+			if req.Header.Get("User-Agent") != "resource-syncer/v0" {
+				t.Errorf("the original request must not be modified")
+			}
+		})
+	}
+}
+
+func TestManifests(t *testing.T) {
+	objs := Manifests(&config.SyncerConfiguration{TenantServiceAccountNamespace: "tenants"})
+	if len(objs) != 2 {
+		t.Fatalf("expected a priority level and a flow schema, got %d objects", len(objs))
+	}
+	pl, ok := objs[0].(*flowcontrolv1beta1.PriorityLevelConfiguration)
+	if !ok {
+		t.Fatalf("expected a priority level, got %T", objs[0])
+	}
+	fs, ok := objs[1].(*flowcontrolv1beta1.FlowSchema)
+	if !ok {
+		t.Fatalf("expected a flow schema, got %T", objs[1])
+	}
+	if fs.Spec.PriorityLevelConfiguration.Name != pl.Name {
+		t.Errorf("expected the flow schema to reference priority level %s, got %s", pl.Name, fs.Spec.PriorityLevelConfiguration.Name)
+	}
+	if fs.Spec.DistinguisherMethod == nil || fs.Spec.DistinguisherMethod.Type != flowcontrolv1beta1.FlowDistinguisherMethodByUserType {
+		t.Errorf("expected the flows to be distinguished by user, got %v", fs.Spec.DistinguisherMethod)
+	}
+	if sa := fs.Spec.Rules[0].Subjects[0].ServiceAccount; sa == nil || sa.Namespace != "tenants" || sa.Name != flowcontrolv1beta1.NameAll {
+		t.Errorf("expected the flow schema to match all the tenant service accounts, got %v", sa)
+	}
+}
+
+func TestManifestsTenantUsers(t *testing.T) {
+	objs := Manifests(&config.SyncerConfiguration{TenantServiceAccountNamespace: "tenants", TenantIdentity: rbac.TenantIdentityUser})
+	fs, ok := objs[1].(*flowcontrolv1beta1.FlowSchema)
+	if !ok {
+		t.Fatalf("expected a flow schema, got %T", objs[1])
+	}
+	subject := fs.Spec.Rules[0].Subjects[0]
+	if subject.Kind != flowcontrolv1beta1.SubjectKindGroup || subject.Group == nil || subject.Group.Name != rbac.TenantsGroup {
+		t.Errorf("expected the flow schema to match the group of all the tenant users, got %v", subject)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package freeze lets tenants temporarily freeze the downward sync of a namespace, e.g. during a
+// migration or an incident, by annotating the tenant namespace with the time the freeze ends. While
+// a namespace is frozen no tenant change is written to the super cluster and the periodic checker
+// does not remediate it, but the status of the super cluster objects is still synced up. The
+// freeze is bounded by MaxDuration, set by the super cluster operators, from the time it started
+// so that renewing it does not extend it.
+package freeze
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// MaxDuration is the longest time a tenant namespace can be frozen for, freezes are not honored
+// if it is 0. It is set once at startup.
+var MaxDuration time.Duration
+
+// Enabled returns true if the tenants can freeze the sync of their namespaces.
+func Enabled() bool {
+	return MaxDuration > 0
+}
+
+// StartRecorder records the time the sync freezes of the tenant namespaces started, where the
+// tenants cannot change it.
+type StartRecorder interface {
+	// Start returns the time the sync freeze of the tenant namespace started, now if it just started.
+	Start(clusterName, namespace string, now time.Time) (time.Time, error)
+}
+
+// Starts records the time the sync freezes started, it is set once at startup. The freezes are only
+// bounded by the time they end if it is nil.
+var Starts StartRecorder
+
+// FrozenFor returns the remaining time the downward sync of the tenant namespace is frozen for, 0
+// if the namespace is not frozen. A freeze that cannot be read, that ends later than MaxDuration
+// from now, or that started MaxDuration ago is rejected with an error and not honored. A renewed
+// freeze still ends MaxDuration after it started.
+func FrozenFor(clusterName string, ns *corev1.Namespace, now time.Time) (time.Duration, error) {
+	if !Enabled() {
+		return 0, nil
+	}
+	v, ok := ns.GetAnnotations()[constants.LabelSyncFreezeUntil]
+	if !ok {
+		return 0, nil
+	}
+	until, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sync freeze %q: %v", v, err)
+	}
+	remaining := until.Sub(now)
+	if remaining > MaxDuration {
+		return 0, fmt.Errorf("sync freeze until %s exceeds the maximum freeze duration %s", v, MaxDuration)
+	}
+	if remaining <= 0 {
+		return 0, nil
+	}
+	if Starts == nil {
+		return remaining, nil
+	}
+	started, err := Starts.Start(clusterName, ns.Name, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record the start of the sync freeze: %v", err)
+	}
+	end := started.Add(MaxDuration)
+	if !now.Before(end) {
+		return 0, fmt.Errorf("sync freeze started at %s exceeds the maximum freeze duration %s", started.UTC().Format(time.RFC3339), MaxDuration)
+	}
+	if until.After(end) {
+		remaining = end.Sub(now)
+	}
+	return remaining, nil
+}
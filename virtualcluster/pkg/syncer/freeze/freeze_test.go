@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package freeze
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+type fixedStart time.Time
+
+func (f fixedStart) Start(clusterName, namespace string, now time.Time) (time.Time, error) {
+	return time.Time(f), nil
+}
+
+func TestFrozenFor(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	testcases := map[string]struct {
+		maxDuration time.Duration
+		freeze      string
+		started     string
+
+		expected      time.Duration
+		expectedError string
+	}{
+		"not frozen": {
+			maxDuration: time.Hour,
+		},
+		"frozen": {
+			maxDuration: time.Hour,
+			freeze:      "2022-03-01T12:30:00Z",
+			expected:    30 * time.Minute,
+		},
+		"freeze expired": {
+			maxDuration: time.Hour,
+			freeze:      "2022-03-01T11:30:00Z",
+		},
+		"freeze exceeds the maximum duration": {
+			maxDuration:   time.Hour,
+			freeze:        "2022-03-01T14:00:00Z",
+			expectedError: "exceeds the maximum freeze duration",
+		},
+		"invalid freeze": {
+			maxDuration:   time.Hour,
+			freeze:        "tomorrow",
+			expectedError: "invalid sync freeze",
+		},
+		"renewed freeze ends the maximum duration after it started": {
+			maxDuration: time.Hour,
+			freeze:      "2022-03-01T12:50:00Z",
+			started:     "2022-03-01T11:20:00Z",
+			expected:    20 * time.Minute,
+		},
+		"renewed freeze started the maximum duration ago": {
+			maxDuration:   time.Hour,
+			freeze:        "2022-03-01T12:30:00Z",
+			started:       "2022-03-01T11:00:00Z",
+			expectedError: "started at 2022-03-01T11:00:00Z exceeds the maximum freeze duration",
+		},
+		"freezes disabled": {
+			freeze: "2022-03-01T12:30:00Z",
+		},
+	}
+	defer func(d time.Duration, s StartRecorder) { MaxDuration, Starts = d, s }(MaxDuration, Starts)
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			MaxDuration = tc.maxDuration
+			Starts = nil
+			if tc.started != "" {
+				started, err := time.Parse(time.RFC3339, tc.started)
+				if err != nil {
+					t.Fatal(err)
+				}
+				Starts = fixedStart(started)
+			}
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+			if tc.freeze != "" {
+				ns.Annotations = map[string]string{constants.LabelSyncFreezeUntil: tc.freeze}
+			}
+			got, err := FrozenFor("tenant", ns, now)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("expected error %q, got %v", tc.expectedError, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected frozen for %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package journal records the changes the syncer makes to the super control plane, so
+// that operators can tell which controller changed a super cluster object and how.
+package journal
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// PathPrefix is the http path the journal is served at by the syncer.
+const PathPrefix = "/debug/journal"
+
+// DefaultSize is the default number of entries kept by a Journal.
+const DefaultSize = 1000
+
+// Entry records a write request the syncer sent to the super control plane.
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Controller  string    `json:"controller"`
+	Verb        string    `json:"verb"`
+	Resource    string    `json:"resource"`
+	Subresource string    `json:"subresource,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	// Changes summarizes the changed fields of patch requests, e.g. metadata.labels.
+	Changes []string `json:"changes,omitempty"`
+	// Code is the http status code of the response, 0 if no response was received.
+	Code int `json:"code"`
+}
+
+// Filter selects the entries returned by Journal.List, empty fields match any entry.
+type Filter struct {
+	Controller string
+	Resource   string
+	Namespace  string
+	Name       string
+	// Limit is the maximum number of entries to return, 0 means no limit.
+	Limit int
+}
+
+func (f Filter) match(e *Entry) bool {
+	return (f.Controller == "" || f.Controller == e.Controller) &&
+		(f.Resource == "" || f.Resource == e.Resource) &&
+		(f.Namespace == "" || f.Namespace == e.Namespace) &&
+		(f.Name == "" || f.Name == e.Name)
+}
+
+// Journal is a fixed size ring buffer of entries, the oldest entries are overwritten.
+type Journal struct {
+	sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	// logSink also writes every entry to the log.
+	logSink bool
+}
+
+var _ http.Handler = &Journal{}
+
+// New returns a Journal that keeps the last size entries.
+func New(size int, logSink bool) *Journal {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Journal{entries: make([]Entry, size), logSink: logSink}
+}
+
+// Record adds e to the journal.
+func (j *Journal) Record(e Entry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	j.Lock()
+	j.entries[j.next] = e
+	j.next = (j.next + 1) % len(j.entries)
+	if j.next == 0 {
+		j.full = true
+	}
+	j.Unlock()
+
+	if j.logSink {
+		klog.InfoS("super cluster object changed", "controller", e.Controller, "verb", e.Verb,
+			"resource", e.Resource, "subresource", e.Subresource, "namespace", e.Namespace, "name", e.Name,
+			"changes", e.Changes, "code", e.Code)
+	}
+}
+
+// List returns the entries matching filter, newest first.
+func (j *Journal) List(filter Filter) []Entry {
+	j.Lock()
+	defer j.Unlock()
+
+	count := j.next
+	if j.full {
+		count = len(j.entries)
+	}
+	var result []Entry
+	for i := 1; i <= count; i++ {
+		e := &j.entries[(j.next-i+len(j.entries))%len(j.entries)]
+		if !filter.match(e) {
+			continue
+		}
+		result = append(result, *e)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// ServeHTTP lists the entries as json, filtered by the controller, resource, namespace,
+// name and limit query parameters.
+func (j *Journal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := Filter{
+		Controller: query.Get("controller"),
+		Resource:   query.Get("resource"),
+		Namespace:  query.Get("namespace"),
+		Name:       query.Get("name"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := json.Marshal(j.List(filter))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(resp); err != nil {
+		klog.Errorf("failed to write journal response: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestJournalList(t *testing.T) {
+	j := New(3, false)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		j.Record(Entry{Controller: "pod", Resource: "pods", Namespace: "ns", Name: name})
+	}
+	j.Record(Entry{Controller: "service", Resource: "services", Namespace: "ns", Name: "e"})
+
+	names := func(entries []Entry) []string {
+		var result []string
+		for _, e := range entries {
+			result = append(result, e.Name)
+		}
+		return result
+	}
+	if got := names(j.List(Filter{})); !reflect.DeepEqual(got, []string{"e", "d", "c"}) {
+		t.Errorf("expected the newest entries first, got %v", got)
+	}
+	if got := names(j.List(Filter{Resource: "pods"})); !reflect.DeepEqual(got, []string{"d", "c"}) {
+		t.Errorf("expected pod entries, got %v", got)
+	}
+	if got := names(j.List(Filter{Limit: 1})); !reflect.DeepEqual(got, []string{"e"}) {
+		t.Errorf("expected one entry, got %v", got)
+	}
+}
+
+func TestEntryFromPath(t *testing.T) {
+	for path, expected := range map[string]Entry{
+		"/api/v1/namespaces/ns/pods":                {Namespace: "ns", Resource: "pods"},
+		"/api/v1/namespaces/ns/pods/foo/status":     {Namespace: "ns", Resource: "pods", Name: "foo", Subresource: "status"},
+		"/api/v1/namespaces/ns":                     {Resource: "namespaces", Name: "ns"},
+		"/apis/apps/v1/namespaces/ns/deployments/x": {Namespace: "ns", Resource: "deployments", Name: "x"},
+		"/apis/storage.k8s.io/v1/storageclasses/sc": {Resource: "storageclasses", Name: "sc"},
+	} {
+		if got := entryFromPath(path); !reflect.DeepEqual(got, expected) {
+			t.Errorf("path %s: expected %+v, got %+v", path, expected, got)
+		}
+	}
+}
+
+func TestPatchChanges(t *testing.T) {
+	got := patchChanges(types.StrategicMergePatchType, []byte(`{"metadata":{"labels":{"app":"x"},"annotations":null},"spec":{"replicas":2}}`))
+	expected := []string{"metadata.annotations", "metadata.labels.app", "spec.replicas"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	got = patchChanges(types.JSONPatchType, []byte(`[{"op":"replace","path":"/spec/replicas","value":2}]`))
+	if !reflect.DeepEqual(got, []string{"replace /spec/replicas"}) {
+		t.Errorf("unexpected json patch changes %v", got)
+	}
+}
+
+func TestWrapTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	j := New(10, false)
+	client := &http.Client{Transport: WrapTransport(j, "configmap")(http.DefaultTransport)}
+	for _, req := range []struct {
+		method string
+		body   string
+	}{
+		{http.MethodGet, ""},
+		{http.MethodPost, `{"metadata":{"name":"cm"}}`},
+	} {
+		r, _ := http.NewRequest(req.method, server.URL+"/api/v1/namespaces/ns/configmaps", strings.NewReader(req.body))
+		resp, err := client.Do(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	entries := j.List(Filter{})
+	if len(entries) != 1 {
+		t.Fatalf("expected only the write to be recorded, got %v", entries)
+	}
+	e := entries[0]
+	if e.Controller != "configmap" || e.Verb != "create" || e.Name != "cm" || e.Code != http.StatusCreated {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	rec := httptest.NewRecorder()
+	j.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, PathPrefix+"?resource=configmaps", nil))
+	var served []Entry
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil || len(served) != 1 {
+		t.Errorf("unexpected response %s: %v", rec.Body.String(), err)
+	}
+}
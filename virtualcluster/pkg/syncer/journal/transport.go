@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/transport"
+)
+
+// maxChanges bounds the changed fields recorded per entry.
+const maxChanges = 20
+
+// WrapTransport returns a transport wrapper that records the write requests sent
+// through it in j on behalf of controller.
+func WrapTransport(j *Journal, controller string) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{journal: j, controller: controller, delegate: rt}
+	}
+}
+
+type roundTripper struct {
+	journal    *Journal
+	controller string
+	delegate   http.RoundTripper
+}
+
+var verbs = map[string]string{
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "patch",
+	http.MethodDelete: "delete",
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb, ok := verbs[req.Method]
+	if !ok {
+		return rt.delegate.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil && req.GetBody != nil {
+		if reader, err := req.GetBody(); err == nil {
+			body, _ = ioutil.ReadAll(reader)
+			reader.Close()
+		}
+	}
+
+	entry := entryFromPath(req.URL.Path)
+	entry.Controller = rt.controller
+	entry.Verb = verb
+	switch {
+	case verb == "create" && entry.Name == "":
+		entry.Name = nameFromBody(body)
+	case verb == "patch":
+		entry.Changes = patchChanges(types.PatchType(req.Header.Get("Content-Type")), body)
+	}
+
+	resp, err := rt.delegate.RoundTrip(req)
+	if resp != nil {
+		entry.Code = resp.StatusCode
+	}
+	rt.journal.Record(entry)
+	return resp, err
+}
+
+func (rt *roundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
+
+// entryFromPath parses the resource, namespace, name and subresource of a request path,
+// e.g. /api/v1/namespaces/default/pods/foo/status.
+func entryFromPath(path string) Entry {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		segments = segments[3:]
+	default:
+		return Entry{Resource: path}
+	}
+
+	entry := Entry{}
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		entry.Namespace = segments[1]
+		segments = segments[2:]
+	}
+	if len(segments) > 0 {
+		entry.Resource = segments[0]
+	}
+	if len(segments) > 1 {
+		entry.Name = segments[1]
+	}
+	if len(segments) > 2 {
+		entry.Subresource = strings.Join(segments[2:], "/")
+	}
+	return entry
+}
+
+func nameFromBody(body []byte) string {
+	obj := struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return ""
+	}
+	return obj.Metadata.Name
+}
+
+// patchChanges summarizes the fields changed by a patch, merge patches are summarized
+// down to the third level, e.g. metadata.labels.app.
+func patchChanges(patchType types.PatchType, body []byte) []string {
+	var changes []string
+	switch patchType {
+	case types.JSONPatchType:
+		var ops []struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(body, &ops); err != nil {
+			return nil
+		}
+		for _, op := range ops {
+			changes = append(changes, op.Op+" "+op.Path)
+		}
+	case types.MergePatchType, types.StrategicMergePatchType, types.ApplyPatchType:
+		var patch map[string]interface{}
+		if err := json.Unmarshal(bytes.TrimSpace(body), &patch); err != nil {
+			return nil
+		}
+		changes = fieldPaths("", patch, 3)
+		sort.Strings(changes)
+	}
+	if len(changes) > maxChanges {
+		changes = append(changes[:maxChanges], "...")
+	}
+	return changes
+}
+
+func fieldPaths(prefix string, obj map[string]interface{}, depth int) []string {
+	var paths []string
+	for k, v := range obj {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if child, ok := v.(map[string]interface{}); ok && depth > 1 && len(child) > 0 {
+			paths = append(paths, fieldPaths(path, child, depth-1)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -18,25 +18,42 @@ package manager
 
 import (
 	"sync"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
 
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conflict"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/finalizer"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resync"
 	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 )
 
 // ControllerManager manages number of resource syncers. It starts their caches, waits for those to sync,
 // then starts the controllers.
 type ControllerManager struct {
 	resourceSyncers map[ResourceSyncer]struct{}
+	// bulkSyncers are the resource syncers of the resync.Bulk class, they are started once resyncGate
+	// is open.
+	bulkSyncers map[ResourceSyncer]struct{}
+	resyncGate  *resync.Gate
+	// clusterFilters restrict the tenant clusters of the resource syncers, the resource syncers without
+	// a filter sync all the tenant clusters.
+	clusterFilters map[ResourceSyncer]func(cluster mc.ClusterInterface) bool
 }
 
 type ResourceSyncerOptions struct {
@@ -47,7 +64,11 @@ type ResourceSyncerOptions struct {
 }
 
 func New() *ControllerManager {
-	return &ControllerManager{resourceSyncers: make(map[ResourceSyncer]struct{})}
+	return &ControllerManager{
+		resourceSyncers: make(map[ResourceSyncer]struct{}),
+		bulkSyncers:     make(map[ResourceSyncer]struct{}),
+		clusterFilters:  make(map[ResourceSyncer]func(cluster mc.ClusterInterface) bool),
+	}
 }
 
 // ResourceSyncer is the interface used by ControllerManager to manage multiple resource syncers.
@@ -63,6 +84,12 @@ type ResourceSyncer interface {
 	StartPatrol(stopCh <-chan struct{}) error
 }
 
+// ConfigReloader is implemented by the resource syncers that apply the reloadable settings of
+// the syncer configuration, e.g. the patrol period, while they are running.
+type ConfigReloader interface {
+	ReloadConfig(cfg *config.SyncerConfiguration)
+}
+
 // AddResourceSyncer adds a resource syncer to the ControllerManager.
 func (m *ControllerManager) AddResourceSyncer(s ResourceSyncer) {
 	m.resourceSyncers[s] = struct{}{}
@@ -72,7 +99,50 @@ func (m *ControllerManager) AddResourceSyncer(s ResourceSyncer) {
 		panic("resource Syncer should provide listener")
 	}
 
-	listener.AddListener(l)
+	listener.AddListener(&filteringListener{ClusterChangeListener: l, filter: func(cluster mc.ClusterInterface) bool {
+		filter, ok := m.clusterFilters[s]
+		return !ok || filter(cluster)
+	}})
+}
+
+// SetClusterFilter restricts the tenant clusters a resource syncer added to the ControllerManager syncs
+// to the ones filter accepts, it must be set before the tenant clusters are added.
+func (m *ControllerManager) SetClusterFilter(s ResourceSyncer, filter func(cluster mc.ClusterInterface) bool) {
+	m.clusterFilters[s] = filter
+}
+
+// filteringListener adds the tenant clusters accepted by filter to the resource syncer.
+type filteringListener struct {
+	listener.ClusterChangeListener
+	filter func(cluster mc.ClusterInterface) bool
+}
+
+func (l *filteringListener) AddCluster(cluster mc.ClusterInterface) {
+	if l.filter(cluster) {
+		l.ClusterChangeListener.AddCluster(cluster)
+	}
+}
+
+func (l *filteringListener) WatchCluster(cluster mc.ClusterInterface) {
+	if l.filter(cluster) {
+		l.ClusterChangeListener.WatchCluster(cluster)
+	}
+}
+
+// SetResyncClass sets the resync priority class of a resource syncer added to the ControllerManager,
+// the resource syncers are of the resync.Interactive class by default.
+func (m *ControllerManager) SetResyncClass(s ResourceSyncer, class resync.Class) {
+	if class == resync.Bulk {
+		m.bulkSyncers[s] = struct{}{}
+	} else {
+		delete(m.bulkSyncers, s)
+	}
+}
+
+// SetResyncGate sets the gate holding back the resource syncers of the resync.Bulk class during the
+// initial sync, they are started right away if it is not set.
+func (m *ControllerManager) SetResyncGate(g *resync.Gate) {
+	m.resyncGate = g
 }
 
 type ResourceSyncerNew func(*config.SyncerConfiguration,
@@ -87,6 +157,8 @@ type BaseResourceSyncer struct {
 	UpwardController       *uw.UpwardController
 	Patroller              *pa.Patroller
 	convertor              conversion.Conversion
+	conflictResolver       *conflict.Resolver
+	finalizerTranslator    *finalizer.Translator
 }
 
 var _ ResourceSyncer = &BaseResourceSyncer{}
@@ -134,18 +206,109 @@ func (b *BaseResourceSyncer) Conversion() conversion.Conversion {
 	return b.convertor
 }
 
+// ConflictResolver is a shortcut to construct the conflict resolver of the synced resource
+func (b *BaseResourceSyncer) ConflictResolver() *conflict.Resolver {
+	if b.conflictResolver == nil {
+		resource, _ := meta.UnsafeGuessKindToResource(schema.GroupVersionKind{Kind: b.MultiClusterController.GetObjectKind()})
+		var policies map[string]string
+		systemManagers := conflict.DefaultSystemManagers
+		if b.Config != nil {
+			policies = b.Config.ConflictPolicies
+			if b.Config.ConflictSystemManagers != nil {
+				systemManagers = b.Config.ConflictSystemManagers
+			}
+		}
+		b.conflictResolver = conflict.NewResolver(resource.Resource, policies, systemManagers, b.MultiClusterController)
+	}
+
+	return b.conflictResolver
+}
+
+// FinalizerTranslator is a shortcut to construct the finalizer translator of the synced resource
+func (b *BaseResourceSyncer) FinalizerTranslator() *finalizer.Translator {
+	if b.finalizerTranslator == nil {
+		resource, _ := meta.UnsafeGuessKindToResource(schema.GroupVersionKind{Kind: b.MultiClusterController.GetObjectKind()})
+		var (
+			policies map[string]string
+			timeout  time.Duration
+		)
+		if b.Config != nil {
+			policies, timeout = b.Config.FinalizerPolicies, b.Config.FinalizerBlockTimeout.Duration
+		}
+		b.finalizerTranslator = finalizer.NewTranslator(resource.Resource, policies, timeout)
+	}
+
+	return b.finalizerTranslator
+}
+
+// ReloadConfig applies the reloadable settings of cfg to the patroller of the resource syncer.
+func (b *BaseResourceSyncer) ReloadConfig(cfg *config.SyncerConfiguration) {
+	if b.Patroller != nil {
+		b.Patroller.SetPeriod(cfg.PatrolPeriod.Duration)
+		b.Patroller.SetRemediationBudget(cfg.PatrolRemediationBudget)
+	}
+}
+
+// RemediationBudget returns the remediation budget of the running patrol pass, it is unlimited if the
+// resource syncer has no patroller.
+func (b *BaseResourceSyncer) RemediationBudget() *pa.Budget {
+	if b.Patroller == nil {
+		return nil
+	}
+	return b.Patroller.Budget()
+}
+
+// IsFrozen returns true if the downward sync of the tenant namespace of the tenant or super cluster
+// object is frozen, it is used by the checkers to skip the remediation of the frozen namespaces.
+func (b *BaseResourceSyncer) IsFrozen(obj differ.ClusterObject) bool {
+	if b.MultiClusterController == nil {
+		return false
+	}
+	if obj.OwnerCluster == "" {
+		clusterName, namespace := conversion.GetVirtualOwner(obj)
+		return b.MultiClusterController.SyncFrozenFor(clusterName, namespace) > 0
+	}
+	namespace := obj.GetNamespace()
+	if _, ok := obj.Object.(*corev1.Namespace); ok {
+		namespace = obj.GetName()
+	}
+	return b.MultiClusterController.SyncFrozenFor(obj.OwnerCluster, namespace) > 0
+}
+
+// ReloadConfig applies the reloadable settings of cfg to the resource syncers that support it.
+func (m *ControllerManager) ReloadConfig(cfg *config.SyncerConfiguration) {
+	for s := range m.resourceSyncers {
+		if r, ok := s.(ConfigReloader); ok {
+			r.ReloadConfig(cfg)
+		}
+	}
+}
+
 // Start gets all the unique caches of the controllers it manages, starts them,
 // then starts the controllers as soon as their respective caches are synced.
-// Start blocks until an error or stop is received.
+// Start blocks until an error or stop is received, in the latter case it waits for the controllers to drain.
 func (m *ControllerManager) Start(stop <-chan struct{}) error {
-	errCh := make(chan error)
+	// errCh is buffered so that the controllers failing while others drain don't block.
+	errCh := make(chan error, len(m.resourceSyncers)*3)
 
 	wg := &sync.WaitGroup{}
 	wg.Add(len(m.resourceSyncers) * 3)
 
+	var interactiveQueues []resync.Queue
 	for s := range m.resourceSyncers {
+		// the bulk resource syncers are held back until the gate is open
+		wait := func() bool { return true }
+		if _, ok := m.bulkSyncers[s]; ok && m.resyncGate != nil {
+			wait = func() bool { return m.resyncGate.Wait(stop) }
+		} else {
+			interactiveQueues = append(interactiveQueues, queuesOf(s)...)
+		}
+
 		go func(s ResourceSyncer) {
 			defer wg.Done()
+			if !wait() {
+				return
+			}
 			if err := s.StartDWS(stop); err != nil {
 				errCh <- err
 			}
@@ -153,6 +316,9 @@ func (m *ControllerManager) Start(stop <-chan struct{}) error {
 		// start UWS syncer
 		go func(s ResourceSyncer) {
 			defer wg.Done()
+			if !wait() {
+				return
+			}
 			if err := s.StartUWS(stop); err != nil {
 				errCh <- err
 			}
@@ -160,11 +326,17 @@ func (m *ControllerManager) Start(stop <-chan struct{}) error {
 		// start periodic checker
 		go func(s ResourceSyncer) {
 			defer wg.Done()
+			if !wait() {
+				return
+			}
 			if err := s.StartPatrol(stop); err != nil {
 				errCh <- err
 			}
 		}(s)
 	}
+	if m.resyncGate != nil {
+		go m.resyncGate.Run(interactiveQueues, stop)
+	}
 
 	doneCh := make(chan struct{})
 	go func() {
@@ -175,9 +347,26 @@ func (m *ControllerManager) Start(stop <-chan struct{}) error {
 	select {
 	case <-doneCh:
 		return nil
-	case <-stop:
-		return nil
 	case err := <-errCh:
 		return err
+	case <-stop:
+	}
+
+	// let the controllers drain their queues before returning.
+	if !shutdown.WaitTimeout(wg, shutdown.DrainTimeout) {
+		klog.Warningf("timed out waiting for the controllers to drain their queues")
+	}
+	return nil
+}
+
+// queuesOf returns the work queues of the downward and upward controllers of a resource syncer.
+func queuesOf(s ResourceSyncer) []resync.Queue {
+	var queues []resync.Queue
+	if dws := s.GetMCController(); dws != nil && dws.Queue != nil {
+		queues = append(queues, dws.Queue)
+	}
+	if uws := s.GetUpwardController(); uws != nil && uws.Queue != nil {
+		queues = append(queues, uws.Queue)
 	}
+	return queues
 }
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -31,7 +32,9 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resync"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
@@ -461,3 +464,86 @@ func TestBuildSuperClusterNamespace(t *testing.T) {
 		})
 	}
 }
+
+type startRecorder struct {
+	BaseResourceSyncer
+	started chan struct{}
+}
+
+func (r *startRecorder) StartDWS(stopCh <-chan struct{}) error {
+	close(r.started)
+	<-stopCh
+	return nil
+}
+
+func TestStartBulkResourceSyncers(t *testing.T) {
+	pods, err := mc.NewMCController(&corev1.Pod{}, &corev1.PodList{}, &fakeReconciler{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events, err := mc.NewMCController(&corev1.Event{}, &corev1.EventList{}, &fakeReconciler{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	interactive := &startRecorder{BaseResourceSyncer: BaseResourceSyncer{MultiClusterController: pods}, started: make(chan struct{})}
+	bulk := &startRecorder{BaseResourceSyncer: BaseResourceSyncer{MultiClusterController: events}, started: make(chan struct{})}
+
+	m := New()
+	m.AddResourceSyncer(interactive)
+	m.AddResourceSyncer(bulk)
+	m.SetResyncClass(bulk, resync.Bulk)
+	gate := resync.NewGate(func() bool { return false }, time.Hour)
+	m.SetResyncGate(gate)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go m.Start(stop)
+
+	select {
+	case <-interactive.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the interactive resource syncer to be started")
+	}
+	select {
+	case <-bulk.started:
+		t.Fatalf("expected the bulk resource syncer to be held back")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	gate.Open()
+	select {
+	case <-bulk.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the bulk resource syncer to be started once the gate is open")
+	}
+}
+
+func TestClusterFilter(t *testing.T) {
+	pods, err := mc.NewMCController(&corev1.Pod{}, &corev1.PodList{}, &fakeReconciler{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := &startRecorder{BaseResourceSyncer: BaseResourceSyncer{MultiClusterController: pods}}
+
+	m := New()
+	m.AddResourceSyncer(s)
+	m.SetClusterFilter(s, func(cluster mc.ClusterInterface) bool {
+		name, _, _ := cluster.GetOwnerInfo()
+		return name != "huge"
+	})
+	l := listener.Listeners[len(listener.Listeners)-1]
+
+	huge := cluster.NewFakeTenantCluster(&v1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "huge", UID: "1"}}, nil, nil)
+	small := cluster.NewFakeTenantCluster(&v1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "small", UID: "2"}}, nil, nil)
+	for _, c := range []mc.ClusterInterface{huge, small} {
+		l.AddCluster(c)
+		l.WatchCluster(c)
+	}
+
+	if pods.GetCluster(huge.GetClusterName()) != nil {
+		t.Errorf("expected the filtered cluster not to be added")
+	}
+	if pods.GetCluster(small.GetClusterName()) == nil {
+		t.Errorf("expected the cluster to be added")
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metalimit
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+)
+
+const (
+	// AllResources is the resource whose limits apply to the resources without their own limits.
+	AllResources = "*"
+
+	// The names of the limits of a resource.
+	LimitAnnotationsSize = "annotations-size"
+	LimitAnnotations     = "annotations"
+	LimitLabels          = "labels"
+)
+
+// Parse parses limits configured as <resource>:<limit>=<value>, e.g. "*:annotations-size=65536"
+// or "configmaps:labels=32".
+func Parse(entries []string) (map[string]config.MetadataLimit, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	limits := make(map[string]config.MetadataLimit)
+	for _, entry := range entries {
+		resourceLimit := strings.SplitN(entry, "=", 2)
+		keys := strings.SplitN(resourceLimit[0], ":", 2)
+		if len(resourceLimit) != 2 || len(keys) != 2 || keys[0] == "" {
+			return nil, fmt.Errorf("invalid metadata limit %q, expected <resource>:<limit>=<value>", entry)
+		}
+		value, err := strconv.Atoi(resourceLimit[1])
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid metadata limit %q, the value must be a non-negative integer", entry)
+		}
+		limit := limits[keys[0]]
+		switch keys[1] {
+		case LimitAnnotationsSize:
+			limit.MaxAnnotationsSize = value
+		case LimitAnnotations:
+			limit.MaxAnnotations = value
+		case LimitLabels:
+			limit.MaxLabels = value
+		default:
+			return nil, fmt.Errorf("invalid metadata limit %q, expected %s, %s or %s", entry, LimitAnnotationsSize, LimitAnnotations, LimitLabels)
+		}
+		limits[keys[0]] = limit
+	}
+	return limits, nil
+}
+
+// ViolationError is returned when the metadata of a tenant object exceeds the limits.
+type ViolationError struct {
+	// Object refers to the tenant object.
+	Object  corev1.ObjectReference
+	Message string
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("%s %s/%s exceeds the metadata limits: %s", e.Object.Kind, e.Object.Namespace, e.Object.Name, e.Message)
+}
+
+// Check returns a *ViolationError if the labels and annotations of pObj, the super cluster object built
+// from the tenant object vObj before the syncer adds its own metadata, exceed the limits of the
+// resource of vObj or use a banned annotation prefix.
+func Check(cfg *config.SyncerConfiguration, vObj, pObj client.Object) error {
+	if cfg == nil || (len(cfg.MetadataLimits) == 0 && len(cfg.BannedAnnotationPrefixes) == 0) {
+		return nil
+	}
+	ref := corev1.ObjectReference{
+		Namespace: vObj.GetNamespace(),
+		Name:      vObj.GetName(),
+		UID:       vObj.GetUID(),
+	}
+	resource := ""
+	if gvk, err := apiutil.GVKForObject(vObj, scheme.Scheme); err == nil {
+		ref.APIVersion, ref.Kind = gvk.ToAPIVersionAndKind()
+		plural, _ := meta.UnsafeGuessKindToResource(gvk)
+		resource = plural.Resource
+	}
+
+	annotations := pObj.GetAnnotations()
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, prefix := range cfg.BannedAnnotationPrefixes {
+			if strings.HasPrefix(k, prefix) {
+				return &ViolationError{Object: ref, Message: fmt.Sprintf("annotation %s uses the banned prefix %s", k, prefix)}
+			}
+		}
+	}
+
+	limit := limitOf(cfg.MetadataLimits, resource)
+	if limit.MaxAnnotations > 0 && len(annotations) > limit.MaxAnnotations {
+		return &ViolationError{Object: ref, Message: fmt.Sprintf("%d annotations, limited to %d", len(annotations), limit.MaxAnnotations)}
+	}
+	if limit.MaxAnnotationsSize > 0 {
+		size := 0
+		for k, v := range annotations {
+			size += len(k) + len(v)
+		}
+		if size > limit.MaxAnnotationsSize {
+			return &ViolationError{Object: ref, Message: fmt.Sprintf("annotations of %d bytes, limited to %d bytes", size, limit.MaxAnnotationsSize)}
+		}
+	}
+	if labels := pObj.GetLabels(); limit.MaxLabels > 0 && len(labels) > limit.MaxLabels {
+		return &ViolationError{Object: ref, Message: fmt.Sprintf("%d labels, limited to %d", len(labels), limit.MaxLabels)}
+	}
+	return nil
+}
+
+// limitOf returns the limits of resource, the limits it doesn't set are the ones of AllResources.
+func limitOf(limits map[string]config.MetadataLimit, resource string) config.MetadataLimit {
+	limit := limits[resource]
+	all := limits[AllResources]
+	if limit.MaxAnnotationsSize == 0 {
+		limit.MaxAnnotationsSize = all.MaxAnnotationsSize
+	}
+	if limit.MaxAnnotations == 0 {
+		limit.MaxAnnotations = all.MaxAnnotations
+	}
+	if limit.MaxLabels == 0 {
+		limit.MaxLabels = all.MaxLabels
+	}
+	return limit
+}
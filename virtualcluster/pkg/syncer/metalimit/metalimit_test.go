@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metalimit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+)
+
+func TestParse(t *testing.T) {
+	for name, tc := range map[string]struct {
+		entries  []string
+		expected map[string]config.MetadataLimit
+		err      bool
+	}{
+		"empty": {},
+		"limits": {
+			entries: []string{"*:annotations-size=65536", "configmaps:labels=32", "configmaps:annotations=16"},
+			expected: map[string]config.MetadataLimit{
+				"*":          {MaxAnnotationsSize: 65536},
+				"configmaps": {MaxLabels: 32, MaxAnnotations: 16},
+			},
+		},
+		"missing resource": {entries: []string{"labels=32"}, err: true},
+		"missing value":    {entries: []string{"pods:labels"}, err: true},
+		"unknown limit":    {entries: []string{"pods:finalizers=1"}, err: true},
+		"negative value":   {entries: []string{"pods:labels=-1"}, err: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			limits, err := Parse(tc.entries)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", limits)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(limits, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, limits)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cfg := &config.SyncerConfiguration{
+		MetadataLimits: map[string]config.MetadataLimit{
+			"*":          {MaxAnnotationsSize: 20, MaxLabels: 2},
+			"configmaps": {MaxLabels: 1},
+		},
+		BannedAnnotationPrefixes: []string{"banned.io/"},
+	}
+
+	for name, tc := range map[string]struct {
+		obj         client.Object
+		labels      map[string]string
+		annotations map[string]string
+		expected    string
+	}{
+		"within limits": {
+			obj:         &corev1.Pod{},
+			labels:      map[string]string{"a": "b", "c": "d"},
+			annotations: map[string]string{"a": "b"},
+		},
+		"banned annotation prefix": {
+			obj:         &corev1.Pod{},
+			annotations: map[string]string{"banned.io/a": "b"},
+			expected:    "annotation banned.io/a uses the banned prefix banned.io/",
+		},
+		"annotations too large": {
+			obj:         &corev1.Pod{},
+			annotations: map[string]string{"key": strings.Repeat("v", 20)},
+			expected:    "annotations of 23 bytes, limited to 20 bytes",
+		},
+		"too many labels": {
+			obj:      &corev1.Pod{},
+			labels:   map[string]string{"a": "b", "c": "d", "e": "f"},
+			expected: "3 labels, limited to 2",
+		},
+		"resource limit": {
+			obj:      &corev1.ConfigMap{},
+			labels:   map[string]string{"a": "b", "c": "d"},
+			expected: "2 labels, limited to 1",
+		},
+		"resource inherits limits": {
+			obj:         &corev1.ConfigMap{},
+			annotations: map[string]string{"key": strings.Repeat("v", 20)},
+			expected:    "annotations of 23 bytes, limited to 20 bytes",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.obj.SetNamespace("default")
+			tc.obj.SetName("obj")
+			tc.obj.SetLabels(tc.labels)
+			tc.obj.SetAnnotations(tc.annotations)
+			err := Check(cfg, tc.obj, tc.obj)
+			if tc.expected == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			violation, ok := err.(*ViolationError)
+			if !ok {
+				t.Fatalf("expected a violation, got %v", err)
+			}
+			if violation.Message != tc.expected {
+				t.Errorf("expected message %q, got %q", tc.expected, violation.Message)
+			}
+			if violation.Object.Name != "obj" || violation.Object.Namespace != "default" || violation.Object.Kind == "" {
+				t.Errorf("expected a reference to the tenant object, got %+v", violation.Object)
+			}
+		})
+	}
+}
@@ -36,6 +36,21 @@ const (
 	UWSOperationCounterKey   = "uws_operations_total"
 	UWSOperationDurationKey  = "uws_operations_duration_seconds"
 	ClusterHealthKey         = "virtual_cluster_health"
+	SyncConflictKey          = "sync_conflicts_total"
+	VirtualClusterUsageKey   = "virtual_cluster_resource_usage"
+	FinalizerStuckKey        = "finalizer_stuck_deletions_total"
+	FinalizerBlockedKey      = "finalizer_blocked_deletion_duration_seconds"
+	ConfigReloadKey          = "config_reloads_total"
+	CheckerBudgetExceededKey = "checker_remediation_budget_exceeded"
+	OwnershipRejectedKey     = "ownership_verification_failures_total"
+	CachedObjectsKey         = "tenant_cached_objects"
+	InformerResyncKey        = "tenant_informer_resyncs_total"
+	WatchRestartKey          = "tenant_informer_watch_restarts_total"
+	NamespaceTeardownKey     = "namespace_teardown_duration_seconds"
+	NamespaceStuckKey        = "namespace_stuck_terminations"
+	TenantAPIRequestsKey     = "tenant_api_requests_total"
+	TenantAPIBytesKey        = "tenant_api_bytes_total"
+	TenantAPIThrottledKey    = "tenant_api_throttled_requests_total"
 )
 
 var (
@@ -120,6 +135,113 @@ var (
 		},
 		[]string{"status"},
 	)
+	SyncConflictCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      SyncConflictKey,
+			Help:      "Cumulative number of fields changed in both tenant and super cluster, by resolving policy.",
+		},
+		[]string{"resource", "policy"})
+	VirtualClusterUsage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      VirtualClusterUsageKey,
+			Help:      "Last reported resource requests, limits and usage of the super cluster pods per virtual cluster.",
+		},
+		[]string{"vc_namespace", "vc_name", "resource", "type"})
+	FinalizerStuckDeletionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      FinalizerStuckKey,
+			Help:      "Cumulative number of super cluster objects whose mirrored finalizers were removed after the block timeout.",
+		},
+		[]string{"resource", "policy"})
+	FinalizerBlockedDeletionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      FinalizerBlockedKey,
+			Help:      "Duration in seconds the deletion of super cluster objects was blocked by mirrored tenant finalizers.",
+			Buckets:   []float64{1, 10, 30, 60, 300, 600, 1800, 3600},
+		},
+		[]string{"resource"})
+	ConfigReloadCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      ConfigReloadKey,
+			Help:      "Cumulative number of syncer configuration reloads, by result.",
+		},
+		[]string{"result"})
+	CheckerBudgetExceeded = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      CheckerBudgetExceededKey,
+			Help:      "Number of remediation actions the last checker scan required per virtual cluster above the remediation budget.",
+		},
+		[]string{"resource", "cluster"})
+	OwnershipRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      OwnershipRejectedKey,
+			Help:      "Cumulative number of super cluster objects whose ownership signature failed the verification, by reason.",
+		},
+		[]string{"reason"})
+	CachedObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      CachedObjectsKey,
+			Help:      "Number of objects in the informer caches of the tenant control planes, by resource and cluster.",
+		},
+		[]string{"resource", "cluster"})
+	InformerResyncCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      InformerResyncKey,
+			Help:      "Cumulative number of objects resynced by the informers of the tenant control planes, by resource and cluster.",
+		},
+		[]string{"resource", "cluster"})
+	WatchRestartCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      WatchRestartKey,
+			Help:      "Cumulative number of list and watch restarts after an error of the informers of the tenant control planes, by resource and cluster.",
+		},
+		[]string{"resource", "cluster"})
+	NamespaceTeardownDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      NamespaceTeardownKey,
+			Help:      "Duration in seconds the deletion of super cluster namespaces waited for their children, by result.",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+		},
+		[]string{"result"})
+	NamespaceStuckTerminations = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      NamespaceStuckKey,
+			Help:      "Number of super cluster namespaces terminating for longer than the namespace teardown timeout, by virtual cluster.",
+		},
+		[]string{"cluster"})
+	TenantAPIRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      TenantAPIRequestsKey,
+			Help:      "Cumulative number of API requests made on behalf of each virtual cluster, by control plane and status class.",
+		},
+		[]string{"cluster", "plane", "class"})
+	TenantAPIBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      TenantAPIBytesKey,
+			Help:      "Cumulative number of API request and response body bytes of each virtual cluster, by control plane and direction.",
+		},
+		[]string{"cluster", "plane", "direction"})
+	TenantAPIThrottled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      TenantAPIThrottledKey,
+			Help:      "Cumulative number of super cluster requests of each virtual cluster delayed by the tenant rate limit.",
+		},
+		[]string{"cluster"})
 )
 
 var registerMetrics sync.Once
@@ -132,11 +254,26 @@ func Register() {
 		prometheus.MustRegister(CheckerMissMatchStats)
 		prometheus.MustRegister(CheckerRemedyStats)
 		prometheus.MustRegister(CheckerScanDuration)
+		prometheus.MustRegister(CheckerBudgetExceeded)
+		prometheus.MustRegister(OwnershipRejected)
 		prometheus.MustRegister(DWSOperationCounter)
 		prometheus.MustRegister(DWSOperationDuration)
 		prometheus.MustRegister(UWSOperationDuration)
 		prometheus.MustRegister(UWSOperationCounter)
 		prometheus.MustRegister(ClusterHealthStats)
+		prometheus.MustRegister(SyncConflictCounter)
+		prometheus.MustRegister(VirtualClusterUsage)
+		prometheus.MustRegister(FinalizerStuckDeletionCounter)
+		prometheus.MustRegister(FinalizerBlockedDeletionDuration)
+		prometheus.MustRegister(ConfigReloadCounter)
+		prometheus.MustRegister(CachedObjects)
+		prometheus.MustRegister(InformerResyncCounter)
+		prometheus.MustRegister(WatchRestartCounter)
+		prometheus.MustRegister(NamespaceTeardownDuration)
+		prometheus.MustRegister(NamespaceStuckTerminations)
+		prometheus.MustRegister(TenantAPIRequests)
+		prometheus.MustRegister(TenantAPIBytes)
+		prometheus.MustRegister(TenantAPIThrottled)
 	})
 }
 
@@ -169,3 +306,30 @@ func RecordDWSOperationDuration(resource, cluster string, start time.Time) {
 func RecordDWSOperationStatus(resource, cluster, code string) {
 	DWSOperationCounter.With(prometheus.Labels{"resource": resource, "vc_name": cluster, "code": code}).Inc()
 }
+
+// DeleteInformerMetrics deletes the informer metrics of the resource of a removed cluster.
+func DeleteInformerMetrics(resource, cluster string) {
+	CachedObjects.DeleteLabelValues(resource, cluster)
+	InformerResyncCounter.DeleteLabelValues(resource, cluster)
+	WatchRestartCounter.DeleteLabelValues(resource, cluster)
+}
+
+// Planes, directions and status classes of the API accounting metrics.
+var (
+	TenantAPIPlanes        = []string{"tenant", "super"}
+	TenantAPIDirections    = []string{"sent", "received"}
+	TenantAPIStatusClasses = []string{"1xx", "2xx", "3xx", "4xx", "5xx", "error"}
+)
+
+// DeleteTenantAPIMetrics deletes the API accounting metrics of a removed cluster.
+func DeleteTenantAPIMetrics(cluster string) {
+	for _, plane := range TenantAPIPlanes {
+		for _, class := range TenantAPIStatusClasses {
+			TenantAPIRequests.DeleteLabelValues(cluster, plane, class)
+		}
+		for _, direction := range TenantAPIDirections {
+			TenantAPIBytes.DeleteLabelValues(cluster, plane, direction)
+		}
+	}
+	TenantAPIThrottled.DeleteLabelValues(cluster)
+}
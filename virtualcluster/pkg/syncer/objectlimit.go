@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
+)
+
+var _ objectlimit.Listener = &Syncer{}
+
+// ObjectLimitExceeded records the resources whose object limits refuse the tenant objects of the
+// cluster in the ObjectLimitExceededCondition of its VirtualCluster.
+func (s *Syncer) ObjectLimitExceeded(cluster string, resources []string) {
+	cond := v1alpha1.ClusterCondition{
+		Type:               v1alpha1.ObjectLimitExceededCondition,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             "WithinLimits",
+	}
+	if len(resources) > 0 {
+		limits := make([]string, 0, len(resources))
+		for _, r := range resources {
+			if r == objectlimit.AllResources {
+				r = "total"
+			}
+			limits = append(limits, r)
+		}
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = "ObjectsRefused"
+		cond.Message = fmt.Sprintf("the objects exceeding the limits of %s are not synced to the super control plane", strings.Join(limits, ", "))
+	}
+	if err := s.updateClusterCondition(cluster, cond); err != nil {
+		klog.Errorf("failed to set the %s condition of cluster %s: %v", v1alpha1.ObjectLimitExceededCondition, cluster, err)
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package objectlimit caps the number of objects a VirtualCluster syncs to the super cluster, see
+// v1alpha1.ObjectLimits. The objects of a tenant are counted from the informer caches of the super
+// cluster objects registered by the resource syncers, with the objects admitted the caches have not
+// seen yet. The tenant objects exceeding a cap are not created until the tenant deletes other
+// objects or the cap is raised.
+package objectlimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
+)
+
+const (
+	// AllResources is the resource of the limit of the objects of all the resources.
+	AllResources = "*"
+
+	// RetryPeriod is the time the tenant objects exceeding a limit are retried after.
+	RetryPeriod = time.Minute
+
+	// byVirtualCluster indexes the super cluster objects by the namespace/name of their VirtualCluster.
+	byVirtualCluster = "objectlimit-virtualcluster"
+	// byTenantUID indexes the super cluster objects by the UID of their tenant object.
+	byTenantUID = "objectlimit-tenant-uid"
+)
+
+// ExceededError is returned when creating a tenant object in the super cluster exceeds the object
+// limits of its VirtualCluster.
+type ExceededError struct {
+	// Object refers to the tenant object.
+	Object corev1.ObjectReference
+	// Resource is the resource whose limit is exceeded, AllResources for the limit of all the resources.
+	Resource string
+	Message  string
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s %s/%s exceeds the object limits: %s", e.Object.Kind, e.Object.Namespace, e.Object.Name, e.Message)
+}
+
+// Listener is notified when the resources exceeding the object limits of a cluster change.
+type Listener interface {
+	// ObjectLimitExceeded is called with the resources whose limits refused the last tenant objects of the
+	// cluster, AllResources for the limit of all the resources. The list is empty once the objects are
+	// created again.
+	ObjectLimitExceeded(cluster string, resources []string)
+}
+
+var (
+	lock      sync.Mutex
+	indexers  = make(map[string]cache.Indexer)
+	exceeded  = make(map[string]sets.String)
+	listeners []Listener
+	// admitted holds the objects of each cluster admitted by Check that the caches have not seen yet.
+	admitted = make(map[string]*assume.Tenant)
+)
+
+// RegisterIndexer registers the informer cache of the super cluster objects of the resource, e.g.
+// "pods", the objects of the registered resources count towards the limit of all the resources.
+// The cache is indexed by VirtualCluster, it must be registered before the informer is started.
+func RegisterIndexer(resource string, indexer cache.Indexer) {
+	if err := addIndexers(indexer); err != nil {
+		// the objects are counted by their labels, and the admitted objects expire instead of being seen.
+		klog.Warningf("failed to index the %s of the super cluster by VirtualCluster: %v", resource, err)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	indexers[resource] = indexer
+}
+
+func addIndexers(indexer cache.Indexer) error {
+	if _, ok := indexer.GetIndexers()[byVirtualCluster]; ok {
+		return nil
+	}
+	return indexer.AddIndexers(cache.Indexers{
+		byVirtualCluster: func(obj interface{}) ([]string, error) {
+			o, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, err
+			}
+			name, namespace := o.GetLabels()[constants.LabelVCName], o.GetLabels()[constants.LabelVCNamespace]
+			if name == "" {
+				return nil, nil
+			}
+			return []string{namespace + "/" + name}, nil
+		},
+		byTenantUID: func(obj interface{}) ([]string, error) {
+			o, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, err
+			}
+			if uid := o.GetAnnotations()[constants.LabelUID]; uid != "" {
+				return []string{uid}, nil
+			}
+			return nil, nil
+		},
+	})
+}
+
+// AddListener registers a listener notified when the resources exceeding the limits change.
+func AddListener(l Listener) {
+	lock.Lock()
+	defer lock.Unlock()
+	listeners = append(listeners, l)
+}
+
+// Check returns an *ExceededError if creating the super cluster object of the tenant object vObj of
+// the cluster exceeds the object limits of vc. The checks of a cluster are serialized, an object
+// admitted counts towards the limits until its super cluster object is in the cache, or for
+// assume.TTL if it is not created.
+func Check(cluster string, vc *v1alpha1.VirtualCluster, vObj client.Object) error {
+	if vc == nil || vc.Spec.ObjectLimits == nil {
+		return nil
+	}
+	ref := corev1.ObjectReference{
+		Namespace: vObj.GetNamespace(),
+		Name:      vObj.GetName(),
+		UID:       vObj.GetUID(),
+	}
+	resource := ""
+	if gvk, err := apiutil.GVKForObject(vObj, scheme.Scheme); err == nil {
+		ref.APIVersion, ref.Kind = gvk.ToAPIVersionAndKind()
+		resource = resourceOf(gvk)
+	}
+
+	tenant := admittedOf(cluster)
+	tenant.Lock()
+	err := check(vc, tenant, resource, vObj.GetUID())
+	if err == nil {
+		tenant.Assume(vObj)
+	}
+	tenant.Unlock()
+
+	if err != nil {
+		err.Object = ref
+		updateExceeded(cluster, []string{err.Resource}, nil)
+		return err
+	}
+	updateExceeded(cluster, nil, []string{resource, AllResources})
+	return nil
+}
+
+func resourceOf(gvk schema.GroupVersionKind) string {
+	plural, _ := meta.UnsafeGuessKindToResource(gvk)
+	return plural.Resource
+}
+
+func admittedOf(cluster string) *assume.Tenant {
+	lock.Lock()
+	defer lock.Unlock()
+	tenant, ok := admitted[cluster]
+	if !ok {
+		tenant = &assume.Tenant{}
+		admitted[cluster] = tenant
+	}
+	return tenant
+}
+
+// check returns an *ExceededError if creating an object of the resource exceeds the limits of vc,
+// the objects admitted for the cluster of vc are counted but the one of the tenant object uid.
+// The admitted objects must be locked.
+func check(vc *v1alpha1.VirtualCluster, tenant *assume.Tenant, resource string, uid types.UID) *ExceededError {
+	limits := vc.Spec.ObjectLimits
+	var limit *int32
+	switch resource {
+	case "pods":
+		limit = limits.Pods
+	case "services":
+		limit = limits.Services
+	}
+
+	lock.Lock()
+	registered := make(map[string]cache.Indexer, len(indexers))
+	for r, indexer := range indexers {
+		registered[r] = indexer
+	}
+	lock.Unlock()
+
+	pending := make(map[string]int)
+	for _, obj := range tenant.Assumed(func(obj metav1.Object) bool {
+		return seen(registered, obj)
+	}) {
+		if obj.GetUID() == uid {
+			continue
+		}
+		if gvk, err := apiutil.GVKForObject(obj.(runtime.Object), scheme.Scheme); err == nil {
+			pending[resourceOf(gvk)]++
+		}
+	}
+
+	total := 0
+	for r, indexer := range registered {
+		n := count(indexer, vc) + pending[r]
+		if r == resource && limit != nil && n >= int(*limit) {
+			return &ExceededError{Resource: resource, Message: fmt.Sprintf("%d %s, limited to %d", n, resource, *limit)}
+		}
+		total += n
+	}
+	if limits.Total != nil && total >= int(*limits.Total) {
+		return &ExceededError{Resource: AllResources, Message: fmt.Sprintf("%d objects, limited to %d", total, *limits.Total)}
+	}
+	return nil
+}
+
+// count returns the number of super cluster objects of vc in the cache.
+func count(indexer cache.Indexer, vc *v1alpha1.VirtualCluster) int {
+	if objs, err := indexer.ByIndex(byVirtualCluster, vc.Namespace+"/"+vc.Name); err == nil {
+		return len(objs)
+	}
+	n := 0
+	_ = cache.ListAll(indexer, labels.SelectorFromSet(labels.Set{
+		constants.LabelVCName:      vc.Name,
+		constants.LabelVCNamespace: vc.Namespace,
+	}), func(interface{}) {
+		n++
+	})
+	return n
+}
+
+// seen returns true if the super cluster object of the tenant object is in a cache.
+func seen(registered map[string]cache.Indexer, vObj metav1.Object) bool {
+	for _, indexer := range registered {
+		if objs, err := indexer.ByIndex(byTenantUID, string(vObj.GetUID())); err == nil && len(objs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// updateExceeded records the limits of the cluster that are exceeded and the ones that are not, and
+// notifies the listeners if the limits exceeded by the cluster change.
+func updateExceeded(cluster string, exceed, clear []string) {
+	lock.Lock()
+	resources := sets.NewString(exceeded[cluster].UnsortedList()...)
+	resources.Insert(exceed...)
+	resources.Delete(clear...)
+	if resources.Equal(exceeded[cluster]) {
+		lock.Unlock()
+		return
+	}
+	if resources.Len() == 0 {
+		delete(exceeded, cluster)
+	} else {
+		exceeded[cluster] = resources
+	}
+	notified := listeners
+	lock.Unlock()
+
+	for _, l := range notified {
+		l.ObjectLimitExceeded(cluster, resources.List())
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectlimit
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
+)
+
+type recordingListener struct {
+	notified map[string][]string
+}
+
+func (l *recordingListener) ObjectLimitExceeded(cluster string, resources []string) {
+	l.notified[cluster] = resources
+}
+
+func superObject(obj client.Object, vcName string) client.Object {
+	obj.SetNamespace("tenant-1-" + vcName + "-default")
+	obj.SetLabels(map[string]string{
+		constants.LabelVCName:      vcName,
+		constants.LabelVCNamespace: "tenant-1",
+	})
+	return obj
+}
+
+func newIndexer(objs ...client.Object) cache.Indexer {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = addIndexers(indexer)
+	for _, obj := range objs {
+		_ = indexer.Add(obj)
+	}
+	return indexer
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestCheck(t *testing.T) {
+	defer func(i map[string]cache.Indexer, e map[string]sets.String, l []Listener, a map[string]*assume.Tenant) {
+		indexers, exceeded, listeners, admitted = i, e, l, a
+	}(indexers, exceeded, listeners, admitted)
+	exceeded = make(map[string]sets.String)
+	indexers = map[string]cache.Indexer{
+		"pods": newIndexer(
+			superObject(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0"}}, "vc"),
+			superObject(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1"}}, "vc"),
+			superObject(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0"}}, "other")),
+		"services": newIndexer(superObject(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web"}}, "vc")),
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-2"}}
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db"}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"}}
+
+	for name, tc := range map[string]struct {
+		limits *v1alpha1.ObjectLimits
+		obj    client.Object
+
+		expectedError    string
+		expectedExceeded []string
+	}{
+		"no limits": {
+			obj: pod,
+		},
+		"within the limits": {
+			limits: &v1alpha1.ObjectLimits{Pods: int32Ptr(3), Services: int32Ptr(1), Total: int32Ptr(4)},
+			obj:    pod,
+		},
+		"pods exceeded": {
+			limits:           &v1alpha1.ObjectLimits{Pods: int32Ptr(2)},
+			obj:              pod,
+			expectedError:    "2 pods, limited to 2",
+			expectedExceeded: []string{"pods"},
+		},
+		"services exceeded": {
+			limits:           &v1alpha1.ObjectLimits{Pods: int32Ptr(2), Services: int32Ptr(1)},
+			obj:              service,
+			expectedError:    "1 services, limited to 1",
+			expectedExceeded: []string{"services"},
+		},
+		"total exceeded": {
+			limits:           &v1alpha1.ObjectLimits{Total: int32Ptr(3)},
+			obj:              configMap,
+			expectedError:    "3 objects, limited to 3",
+			expectedExceeded: []string{AllResources},
+		},
+		"limit of another resource exceeded": {
+			limits: &v1alpha1.ObjectLimits{Services: int32Ptr(1)},
+			obj:    pod,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			listener := &recordingListener{notified: map[string][]string{}}
+			listeners = []Listener{listener}
+			vc := &v1alpha1.VirtualCluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-1", Name: "vc"},
+				Spec:       v1alpha1.VirtualClusterSpec{ObjectLimits: tc.limits},
+			}
+
+			err := Check("cluster", vc, tc.obj)
+			if tc.expectedError != "" {
+				exceeded, ok := err.(*ExceededError)
+				if !ok || !strings.Contains(exceeded.Message, tc.expectedError) {
+					t.Fatalf("expected error %q, got %v", tc.expectedError, err)
+				}
+				if exceeded.Object.Name != tc.obj.GetName() || exceeded.Object.Kind == "" {
+					t.Errorf("expected the error to refer to the tenant object, got %+v", exceeded.Object)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := listener.notified["cluster"]; !reflect.DeepEqual(got, tc.expectedExceeded) {
+				t.Errorf("expected the exceeded resources %v, got %v", tc.expectedExceeded, got)
+			}
+
+			exceeded = make(map[string]sets.String)
+			admitted = make(map[string]*assume.Tenant)
+		})
+	}
+}
+
+func TestCheckAdmitted(t *testing.T) {
+	defer func(i map[string]cache.Indexer, e map[string]sets.String, l []Listener, a map[string]*assume.Tenant) {
+		indexers, exceeded, listeners, admitted = i, e, l, a
+	}(indexers, exceeded, listeners, admitted)
+	exceeded, listeners, admitted = make(map[string]sets.String), nil, make(map[string]*assume.Tenant)
+	podIndexer := newIndexer(superObject(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0"}}, "vc"))
+	indexers = map[string]cache.Indexer{"pods": podIndexer}
+
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-1", Name: "vc"},
+		Spec:       v1alpha1.VirtualClusterSpec{ObjectLimits: &v1alpha1.ObjectLimits{Pods: int32Ptr(2)}},
+	}
+	pod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name)}}
+	}
+	expectExceeded := func(err error, expected string) {
+		t.Helper()
+		if exceeded, ok := err.(*ExceededError); !ok || !strings.Contains(exceeded.Message, expected) {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	}
+
+	if err := Check("cluster", vc, pod("web-1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// web-1 is admitted but not in the cache yet.
+	expectExceeded(Check("cluster", vc, pod("web-2")), "2 pods, limited to 2")
+	if err := Check("cluster", vc, pod("web-1")); err != nil {
+		t.Errorf("expected the admitted object to be checked again, got %v", err)
+	}
+
+	// once in the cache, web-1 is counted once.
+	superPod := superObject(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1"}}, "vc")
+	superPod.SetAnnotations(map[string]string{constants.LabelUID: "web-1"})
+	_ = podIndexer.Add(superPod)
+	expectExceeded(Check("cluster", vc, pod("web-2")), "2 pods, limited to 2")
+	if len(admitted["cluster"].Assumed(func(metav1.Object) bool { return false })) != 0 {
+		t.Errorf("expected the admitted object in the cache to be forgotten")
+	}
+}
+
+func TestCheckConcurrently(t *testing.T) {
+	defer func(i map[string]cache.Indexer, e map[string]sets.String, l []Listener, a map[string]*assume.Tenant) {
+		indexers, exceeded, listeners, admitted = i, e, l, a
+	}(indexers, exceeded, listeners, admitted)
+	exceeded, listeners, admitted = make(map[string]sets.String), nil, make(map[string]*assume.Tenant)
+	indexers = map[string]cache.Indexer{"pods": newIndexer(), "services": newIndexer()}
+
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-1", Name: "vc"},
+		Spec:       v1alpha1.VirtualClusterSpec{ObjectLimits: &v1alpha1.ObjectLimits{Pods: int32Ptr(3), Total: int32Ptr(5)}},
+	}
+
+	var wg sync.WaitGroup
+	var pods, services int32
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("pod-%d", i), UID: types.UID(fmt.Sprintf("pod-%d", i))}}
+			if Check("cluster", vc, pod) == nil {
+				atomic.AddInt32(&pods, 1)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("svc-%d", i), UID: types.UID(fmt.Sprintf("svc-%d", i))}}
+			if Check("cluster", vc, service) == nil {
+				atomic.AddInt32(&services, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if pods > 3 || pods+services != 5 {
+		t.Errorf("expected at most 3 pods and 5 objects admitted, got %d pods and %d services", pods, services)
+	}
+}
+
+func TestUpdateExceeded(t *testing.T) {
+	defer func(e map[string]sets.String, l []Listener) { exceeded, listeners = e, l }(exceeded, listeners)
+	exceeded = make(map[string]sets.String)
+	listener := &recordingListener{notified: map[string][]string{}}
+	listeners = []Listener{listener}
+
+	for _, step := range []struct {
+		exceed, clear []string
+
+		expectedNotified bool
+		expected         []string
+	}{
+		{exceed: []string{"pods"}, expectedNotified: true, expected: []string{"pods"}},
+		{exceed: []string{"pods"}},
+		{exceed: []string{AllResources}, expectedNotified: true, expected: []string{AllResources, "pods"}},
+		{clear: []string{"services", AllResources}, expectedNotified: true, expected: []string{"pods"}},
+		{clear: []string{"services"}},
+		{clear: []string{"pods", AllResources}, expectedNotified: true, expected: []string{}},
+	} {
+		delete(listener.notified, "cluster")
+		updateExceeded("cluster", step.exceed, step.clear)
+		got, notified := listener.notified["cluster"]
+		if notified != step.expectedNotified || (notified && !reflect.DeepEqual(got, step.expected)) {
+			t.Errorf("exceed %v and clear %v: expected notified %v with %v, got %v with %v", step.exceed, step.clear, step.expectedNotified, step.expected, notified, got)
+		}
+	}
+	if len(exceeded) != 0 {
+		t.Errorf("expected no exceeded cluster, got %v", exceeded)
+	}
+}
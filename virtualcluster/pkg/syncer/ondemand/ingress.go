@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ondemand
+
+import (
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	networkinginformers "k8s.io/client-go/informers/networking/v1"
+	listersnetworkingv1 "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+// IngressTLSSecrets returns the names of the TLS secrets the ingress references in its namespace.
+// The secrets of other namespaces, referred to as <namespace>/<name> by some ingress controllers,
+// are not included.
+func IngressTLSSecrets(ing *networkingv1.Ingress) sets.String {
+	names := sets.NewString()
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName != "" && !strings.Contains(tls.SecretName, "/") {
+			names.Insert(tls.SecretName)
+		}
+	}
+	return names
+}
+
+// IngressReferences counts the TLS secrets of the tenant ingresses as references too. The super
+// control plane copies of the ingresses are watched, rather than the tenant ingresses, so that
+// only the ingresses actually synced count, whatever version of the ingresses the tenant serves.
+// A nil IngressReferences references nothing.
+type IngressReferences struct {
+	lister    listersnetworkingv1.IngressLister
+	HasSynced cache.InformerSynced
+}
+
+// NewIngressReferences returns the references of the super control plane ingresses of informer,
+// the secrets referenced by a changed ingress are requeued to c so that they are synced or deleted.
+func NewIngressReferences(c *mc.MultiClusterController, informer networkinginformers.IngressInformer) *IngressReferences {
+	informer.Informer().AddEventHandler(&ingressHandler{c: c})
+	return &IngressReferences{
+		lister:    informer.Lister(),
+		HasSynced: informer.Informer().HasSynced,
+	}
+}
+
+// ReferencedObjects returns the namespace/name keys of the secrets referenced by the ingresses of
+// the cluster.
+func (r *IngressReferences) ReferencedObjects(clusterName string) (sets.String, error) {
+	keys := sets.NewString()
+	if r == nil {
+		return keys, nil
+	}
+	ingresses, err := r.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ing := range ingresses {
+		cluster, namespace := conversion.GetVirtualOwner(ing)
+		if cluster != clusterName || namespace == "" {
+			continue
+		}
+		for name := range IngressTLSSecrets(ing) {
+			keys.Insert(namespace + "/" + name)
+		}
+	}
+	return keys, nil
+}
+
+// Referenced returns true if an ingress of the namespace of the cluster references the secret.
+func (r *IngressReferences) Referenced(clusterName, namespace, name string) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+	ingresses, err := r.lister.Ingresses(conversion.ToSuperClusterNamespace(clusterName, namespace)).List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, ing := range ingresses {
+		if cluster, _ := conversion.GetVirtualOwner(ing); cluster == clusterName && IngressTLSSecrets(ing).Has(name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ingressHandler requeues the tenant secrets referenced by a changed super control plane ingress.
+type ingressHandler struct {
+	c *mc.MultiClusterController
+}
+
+func (h *ingressHandler) requeue(ing *networkingv1.Ingress, names sets.String) {
+	cluster, namespace := conversion.GetVirtualOwner(ing)
+	if cluster == "" || namespace == "" {
+		return
+	}
+	(&podHandler{clusterName: cluster, c: h.c, kind: Secrets}).requeue(namespace, names)
+}
+
+func (h *ingressHandler) OnAdd(obj interface{}) {
+	if ing, ok := obj.(*networkingv1.Ingress); ok {
+		h.requeue(ing, IngressTLSSecrets(ing))
+	}
+}
+
+func (h *ingressHandler) OnUpdate(oldObj, newObj interface{}) {
+	oldIngress, ok1 := oldObj.(*networkingv1.Ingress)
+	newIngress, ok2 := newObj.(*networkingv1.Ingress)
+	if !ok1 || !ok2 {
+		return
+	}
+	oldRefs, newRefs := IngressTLSSecrets(oldIngress), IngressTLSSecrets(newIngress)
+	if oldRefs.Equal(newRefs) {
+		return
+	}
+	h.requeue(newIngress, oldRefs.Union(newRefs))
+}
+
+func (h *ingressHandler) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if ing, ok := obj.(*networkingv1.Ingress); ok {
+		h.requeue(ing, IngressTLSSecrets(ing))
+	}
+}
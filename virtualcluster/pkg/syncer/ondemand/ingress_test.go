@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ondemand
+
+import (
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listersnetworkingv1 "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func superIngress(cluster, namespace, name string, secretNames ...string) *networkingv1.Ingress {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: conversion.ToSuperClusterNamespace(cluster, namespace),
+			Annotations: map[string]string{
+				constants.LabelCluster:   cluster,
+				constants.LabelNamespace: namespace,
+			},
+		},
+	}
+	for _, secretName := range secretNames {
+		ing.Spec.TLS = append(ing.Spec.TLS, networkingv1.IngressTLS{Hosts: []string{"foo.example.com"}, SecretName: secretName})
+	}
+	return ing
+}
+
+func TestIngressTLSSecrets(t *testing.T) {
+	ing := superIngress("tenant-a", "default", "ing", "tls-1", "", "other/tls-2", "tls-1", "tls-3")
+	if got, expected := IngressTLSSecrets(ing).List(), []string{"tls-1", "tls-3"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected TLS secrets %v, got %v", expected, got)
+	}
+}
+
+func TestIngressReferences(t *testing.T) {
+	var none *IngressReferences
+	if keys, err := none.ReferencedObjects("tenant-a"); err != nil || keys.Len() != 0 {
+		t.Errorf("expected nil references to reference nothing, got %v, %v", keys, err)
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, ing := range []*networkingv1.Ingress{
+		superIngress("tenant-a", "default", "ing-1", "tls-1"),
+		superIngress("tenant-a", "web", "ing-2", "tls-2", "tls-3"),
+		superIngress("tenant-b", "default", "ing-1", "tls-4"),
+	} {
+		if err := indexer.Add(ing); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &IngressReferences{lister: listersnetworkingv1.NewIngressLister(indexer)}
+
+	keys, err := r.ReferencedObjects("tenant-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"default/tls-1", "web/tls-2", "web/tls-3"}; !reflect.DeepEqual(keys.List(), expected) {
+		t.Errorf("expected referenced secrets %v, got %v", expected, keys.List())
+	}
+
+	for _, tc := range []struct {
+		cluster, namespace, name string
+		expected                 bool
+	}{
+		{"tenant-a", "web", "tls-3", true},
+		{"tenant-a", "default", "tls-2", false},
+		{"tenant-a", "default", "tls-4", false},
+		{"tenant-b", "default", "tls-4", true},
+	} {
+		referenced, err := r.Referenced(tc.cluster, tc.namespace, tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if referenced != tc.expected {
+			t.Errorf("expected secret %s/%s of %s referenced to be %v", tc.namespace, tc.name, tc.cluster, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ondemand restricts the tenant configmaps and secrets synced to the super control plane
+// to the ones referenced by the tenant pods, and the TLS secrets of the synced tenant ingresses,
+// used by featuregate.OnDemandConfigSync. The copies of the objects nothing references any longer
+// are deleted, so that the namespaces full of configuration unrelated to the workloads are not
+// mirrored.
+package ondemand
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+// Kind is the kind of the objects referenced by the pods that are synced on demand.
+type Kind string
+
+const (
+	ConfigMaps Kind = "configmaps"
+	Secrets    Kind = "secrets"
+)
+
+func (k Kind) newObject() client.Object {
+	if k == Secrets {
+		return &corev1.Secret{}
+	}
+	return &corev1.ConfigMap{}
+}
+
+// Enabled returns true if only the configmaps and secrets referenced by the tenant pods are synced.
+func Enabled() bool {
+	return featuregate.DefaultFeatureGate.Enabled(featuregate.OnDemandConfigSync)
+}
+
+// References returns the names of the objects of kind the pod references in its namespace.
+func References(pod *corev1.Pod, kind Kind) sets.String {
+	names := sets.NewString()
+	add := func(name string) {
+		if name != "" {
+			names.Insert(name)
+		}
+	}
+	addLocalRef := func(ref *corev1.LocalObjectReference) {
+		if ref != nil {
+			add(ref.Name)
+		}
+	}
+
+	visitEnv := func(envFrom []corev1.EnvFromSource, env []corev1.EnvVar) {
+		for _, from := range envFrom {
+			switch {
+			case kind == ConfigMaps && from.ConfigMapRef != nil:
+				add(from.ConfigMapRef.Name)
+			case kind == Secrets && from.SecretRef != nil:
+				add(from.SecretRef.Name)
+			}
+		}
+		for _, e := range env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			switch {
+			case kind == ConfigMaps && e.ValueFrom.ConfigMapKeyRef != nil:
+				add(e.ValueFrom.ConfigMapKeyRef.Name)
+			case kind == Secrets && e.ValueFrom.SecretKeyRef != nil:
+				add(e.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	for i := range pod.Spec.InitContainers {
+		visitEnv(pod.Spec.InitContainers[i].EnvFrom, pod.Spec.InitContainers[i].Env)
+	}
+	for i := range pod.Spec.Containers {
+		visitEnv(pod.Spec.Containers[i].EnvFrom, pod.Spec.Containers[i].Env)
+	}
+	for i := range pod.Spec.EphemeralContainers {
+		visitEnv(pod.Spec.EphemeralContainers[i].EnvFrom, pod.Spec.EphemeralContainers[i].Env)
+	}
+
+	for _, v := range pod.Spec.Volumes {
+		if kind == ConfigMaps {
+			if v.ConfigMap != nil {
+				add(v.ConfigMap.Name)
+			}
+			if v.Projected != nil {
+				for _, source := range v.Projected.Sources {
+					if source.ConfigMap != nil {
+						add(source.ConfigMap.Name)
+					}
+				}
+			}
+			continue
+		}
+
+		switch {
+		case v.Secret != nil:
+			add(v.Secret.SecretName)
+		case v.Projected != nil:
+			for _, source := range v.Projected.Sources {
+				if source.Secret != nil {
+					add(source.Secret.Name)
+				}
+			}
+		case v.AzureFile != nil:
+			add(v.AzureFile.SecretName)
+		case v.CephFS != nil:
+			addLocalRef(v.CephFS.SecretRef)
+		case v.Cinder != nil:
+			addLocalRef(v.Cinder.SecretRef)
+		case v.CSI != nil:
+			addLocalRef(v.CSI.NodePublishSecretRef)
+		case v.FlexVolume != nil:
+			addLocalRef(v.FlexVolume.SecretRef)
+		case v.ISCSI != nil:
+			addLocalRef(v.ISCSI.SecretRef)
+		case v.RBD != nil:
+			addLocalRef(v.RBD.SecretRef)
+		case v.ScaleIO != nil:
+			addLocalRef(v.ScaleIO.SecretRef)
+		case v.StorageOS != nil:
+			addLocalRef(v.StorageOS.SecretRef)
+		}
+	}
+	if kind == Secrets {
+		for _, s := range pod.Spec.ImagePullSecrets {
+			add(s.Name)
+		}
+	}
+	return names
+}
+
+// ReferencedObjects returns the namespace/name keys of the objects of kind referenced by the pods
+// of the cluster.
+func ReferencedObjects(c *mc.MultiClusterController, clusterName string, kind Kind, opts ...client.ListOption) (sets.String, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(clusterName, pods, opts...); err != nil {
+		return nil, err
+	}
+	keys := sets.NewString()
+	for i := range pods.Items {
+		for name := range References(&pods.Items[i], kind) {
+			keys.Insert(pods.Items[i].Namespace + "/" + name)
+		}
+	}
+	return keys, nil
+}
+
+// Referenced returns true if a pod of the namespace of the cluster references the object of kind.
+func Referenced(c *mc.MultiClusterController, clusterName, namespace, name string, kind Kind) (bool, error) {
+	keys, err := ReferencedObjects(c, clusterName, kind, client.InNamespace(namespace))
+	if err != nil {
+		return false, err
+	}
+	return keys.Has(namespace + "/" + name), nil
+}
+
+// NewListener returns l watching the pods of the tenant clusters too, the objects of kind
+// referenced by a changed pod are requeued to c so that they are synced or deleted.
+func NewListener(l listener.ClusterChangeListener, c *mc.MultiClusterController, kind Kind) listener.ClusterChangeListener {
+	return &podListener{ClusterChangeListener: l, c: c, kind: kind}
+}
+
+type podListener struct {
+	listener.ClusterChangeListener
+	c    *mc.MultiClusterController
+	kind Kind
+}
+
+func (l *podListener) AddCluster(cluster mc.ClusterInterface) {
+	l.ClusterChangeListener.AddCluster(cluster)
+	if _, err := cluster.GetInformer(&corev1.Pod{}); err != nil {
+		klog.Errorf("failed to add cluster %s pod informer for on demand %s: %v", cluster.GetClusterName(), l.kind, err)
+	}
+}
+
+func (l *podListener) WatchCluster(cluster mc.ClusterInterface) {
+	l.ClusterChangeListener.WatchCluster(cluster)
+	h := &podHandler{clusterName: cluster.GetClusterName(), c: l.c, kind: l.kind}
+	if err := cluster.AddEventHandler(&corev1.Pod{}, h); err != nil {
+		klog.Errorf("failed to watch cluster %s pods for on demand %s: %v", cluster.GetClusterName(), l.kind, err)
+	}
+}
+
+// podHandler requeues the objects referenced by a changed tenant pod.
+type podHandler struct {
+	clusterName string
+	c           *mc.MultiClusterController
+	kind        Kind
+}
+
+func (h *podHandler) requeue(namespace string, names sets.String) {
+	for name := range names {
+		obj := h.kind.newObject()
+		if err := h.c.Get(h.clusterName, namespace, name, obj); err != nil {
+			if !apierrors.IsNotFound(err) {
+				klog.Errorf("failed to get %s %s/%s of cluster %s: %v", h.kind, namespace, name, h.clusterName, err)
+			}
+			continue
+		}
+		if err := h.c.RequeueObject(h.clusterName, obj); err != nil {
+			klog.Errorf("failed to requeue %s %s/%s of cluster %s: %v", h.kind, namespace, name, h.clusterName, err)
+		}
+	}
+}
+
+func (h *podHandler) OnAdd(obj interface{}) {
+	if pod, ok := obj.(*corev1.Pod); ok {
+		h.requeue(pod.Namespace, References(pod, h.kind))
+	}
+}
+
+func (h *podHandler) OnUpdate(oldObj, newObj interface{}) {
+	oldPod, ok1 := oldObj.(*corev1.Pod)
+	newPod, ok2 := newObj.(*corev1.Pod)
+	if !ok1 || !ok2 {
+		return
+	}
+	oldRefs, newRefs := References(oldPod, h.kind), References(newPod, h.kind)
+	if oldRefs.Equal(newRefs) {
+		return
+	}
+	h.requeue(newPod.Namespace, oldRefs.Union(newRefs))
+}
+
+func (h *podHandler) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*corev1.Pod); ok {
+		h.requeue(pod.Namespace, References(pod, h.kind))
+	}
+}
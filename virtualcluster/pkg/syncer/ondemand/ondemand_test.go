@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ondemand
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReferences(t *testing.T) {
+	ref := func(name string) corev1.LocalObjectReference {
+		return corev1.LocalObjectReference{Name: name}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{
+				EnvFrom: []corev1.EnvFromSource{
+					{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: ref("init-cm")}},
+					{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: ref("init-secret")}},
+				},
+			}},
+			Containers: []corev1.Container{{
+				Env: []corev1.EnvVar{
+					{Name: "plain", Value: "value"},
+					{Name: "cm", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: ref("env-cm")}}},
+					{Name: "secret", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: ref("env-secret")}}},
+				},
+			}},
+			Volumes: []corev1.Volume{
+				{Name: "cm", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: ref("volume-cm")}}},
+				{Name: "secret", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "volume-secret"}}},
+				{Name: "projected", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+					{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: ref("projected-cm")}},
+					{Secret: &corev1.SecretProjection{LocalObjectReference: ref("projected-secret")}},
+					{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"}},
+				}}}},
+				{Name: "csi", VolumeSource: corev1.VolumeSource{CSI: &corev1.CSIVolumeSource{Driver: "csi", NodePublishSecretRef: &corev1.LocalObjectReference{Name: "csi-secret"}}}},
+				{Name: "empty", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			},
+			ImagePullSecrets: []corev1.LocalObjectReference{ref("pull-secret")},
+		},
+	}
+
+	for kind, expected := range map[Kind][]string{
+		ConfigMaps: {"env-cm", "init-cm", "projected-cm", "volume-cm"},
+		Secrets:    {"csi-secret", "env-secret", "init-secret", "projected-secret", "pull-secret", "volume-secret"},
+	} {
+		if got := References(pod, kind).List(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %s references %v, got %v", kind, expected, got)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+// ownershipBackfillRetryPeriod is the period at which a failed ownership backfill is retried.
+const ownershipBackfillRetryPeriod = 5 * time.Minute
+
+// ownershipBackfillPageSize is the number of objects listed at once by the ownership backfill.
+const ownershipBackfillPageSize = 500
+
+// ownershipBackfillResources are the kinds of the super cluster objects the syncer signs.
+var ownershipBackfillResources = []struct {
+	resource schema.GroupVersionResource
+	kind     string
+}{
+	{schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, "Namespace"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "Pod"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ConfigMap"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, "Secret"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "services"}, "Service"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}, "Endpoints"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, "ServiceAccount"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, "PersistentVolumeClaim"},
+	{schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, "Ingress"},
+	{schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}, "VolumeSnapshot"},
+}
+
+// runOwnershipBackfill rejects the unsigned super cluster objects if the ownership signatures were
+// backfilled before. Otherwise, if --backfill-ownership-signatures is set, it signs the objects created
+// before feature OwnershipSignature was enabled, or signed by a legacy signature, and records the
+// completion in the super cluster so that the backfill never runs again. A restart must not sign the
+// ownership annotations forged since. Nothing is signed if the signatures are required.
+func (s *Syncer) runOwnershipBackfill(stopChan <-chan struct{}) {
+	signer := conversion.GetOwnershipSigner()
+	if signer == nil || s.config.RequireOwnershipSignature {
+		return
+	}
+	client, err := metadata.NewForConfig(s.config.RestConfig)
+	if err != nil {
+		klog.Errorf("failed to create the client of the ownership backfill: %v", err)
+		return
+	}
+	_ = wait.PollImmediateUntil(ownershipBackfillRetryPeriod, func() (bool, error) {
+		done, err := backfillOwnershipOnce(context.TODO(), s.superClient, client, signer, s.config.BackfillOwnershipSignatures)
+		if err != nil {
+			klog.Errorf("failed to backfill the ownership signatures, retrying in %s: %v", ownershipBackfillRetryPeriod, err)
+			return false, nil
+		}
+		if done {
+			signer.SetBackfilled()
+			klog.Infof("ownership signatures are backfilled, unsigned super cluster objects are rejected")
+		} else {
+			klog.Infof("ownership signatures are not backfilled, unsigned super cluster objects are accepted until the syncer runs with --backfill-ownership-signatures")
+		}
+		return true, nil
+	}, stopChan)
+}
+
+// backfillOwnershipOnce returns true if the backfill marker exists. Otherwise, if backfill is set, it
+// signs the objects and creates the marker.
+func backfillOwnershipOnce(ctx context.Context, superClient clientset.Interface, client metadata.Interface,
+	signer *conversion.OwnershipSigner, backfill bool) (bool, error) {
+	configMaps := superClient.CoreV1().ConfigMaps(metav1.NamespaceSystem)
+	_, err := configMaps.Get(ctx, utilconst.OwnershipBackfillCfgMap, metav1.GetOptions{})
+	if err == nil {
+		return true, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	if !backfill {
+		return false, nil
+	}
+	if err := backfillOwnershipSignatures(ctx, client, signer); err != nil {
+		return false, err
+	}
+	marker := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: utilconst.OwnershipBackfillCfgMap},
+		Data:       map[string]string{"completedAt": time.Now().UTC().Format(time.RFC3339)},
+	}
+	if _, err := configMaps.Create(ctx, marker, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// backfillOwnershipSignatures signs the super cluster objects that need it, and returns the first
+// error met. The kinds that are not served by the super cluster are skipped.
+func backfillOwnershipSignatures(ctx context.Context, client metadata.Interface, signer *conversion.OwnershipSigner) error {
+	var firstErr error
+	for _, r := range ownershipBackfillResources {
+		opts := metav1.ListOptions{Limit: ownershipBackfillPageSize}
+		for {
+			list, err := client.Resource(r.resource).List(ctx, opts)
+			if apierrors.IsNotFound(err) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			for i := range list.Items {
+				obj := &list.Items[i]
+				obj.Kind = r.kind
+				if obj.Annotations[constants.LabelCluster] == "" || !signer.NeedsBackfill(obj) {
+					continue
+				}
+				if err := backfillOwnershipSignature(ctx, client, r.resource, signer, obj); err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+			if list.Continue == "" {
+				break
+			}
+			opts.Continue = list.Continue
+		}
+	}
+	return firstErr
+}
+
+func backfillOwnershipSignature(ctx context.Context, client metadata.Interface, resource schema.GroupVersionResource,
+	signer *conversion.OwnershipSigner, obj *metav1.PartialObjectMetadata) error {
+	signer.Sign(obj)
+	// the resourceVersion makes the patch fail if the ownership annotations changed since the list
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": obj.ResourceVersion,
+			"annotations": map[string]string{
+				constants.LabelOwnershipSignature: obj.Annotations[constants.LabelOwnershipSignature],
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Resource(resource).Namespace(obj.Namespace).Patch(ctx, obj.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/metadata/fake"
+	core "k8s.io/client-go/testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+// newBackfillClient returns a metadata client serving objs as configmaps, and the patches of the
+// configmaps by name.
+func newBackfillClient(t *testing.T, objs ...metav1.PartialObjectMetadata) (*fake.FakeMetadataClient, map[string]*corev1.ConfigMap) {
+	client := fake.NewSimpleMetadataClient(runtime.NewScheme())
+	client.PrependReactor("list", "*", func(action core.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Resource != "configmaps" {
+			return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+		}
+		list := &metav1.List{}
+		for _, obj := range objs {
+			obj := obj
+			list.Items = append(list.Items, runtime.RawExtension{Object: &obj})
+		}
+		return true, list, nil
+	})
+	patched := map[string]*corev1.ConfigMap{}
+	client.PrependReactor("patch", "configmaps", func(action core.Action) (bool, runtime.Object, error) {
+		patch := action.(core.PatchAction)
+		cm := &corev1.ConfigMap{}
+		if err := json.Unmarshal(patch.GetPatch(), cm); err != nil {
+			t.Fatalf("unexpected patch: %v", err)
+		}
+		patched[patch.GetName()] = cm
+		return true, &metav1.PartialObjectMetadata{}, nil
+	})
+
+	return client, patched
+}
+
+func TestBackfillOwnershipSignatures(t *testing.T) {
+	signer, err := conversion.NewOwnershipSigner([][]byte{bytes.Repeat([]byte("a"), 32)}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owned := func(name string) metav1.PartialObjectMetadata {
+		return metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "cluster-default",
+			Name:            name,
+			ResourceVersion: "1",
+			Annotations: map[string]string{
+				constants.LabelCluster:   "cluster",
+				constants.LabelNamespace: "default",
+				constants.LabelUID:       "uid-" + name,
+			},
+		}}
+	}
+	signed := owned("signed")
+	signed.Annotations[constants.LabelOwnershipSignature] = func() string {
+		cm := &corev1.ConfigMap{ObjectMeta: *signed.ObjectMeta.DeepCopy()}
+		signer.Sign(cm)
+		return cm.Annotations[constants.LabelOwnershipSignature]
+	}()
+	forged := owned("forged")
+	forged.Annotations[constants.LabelOwnershipSignature] = "v2.forged"
+	notOwned := metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "not-owned"}}
+
+	client, patched := newBackfillClient(t, owned("unsigned"), signed, forged, notOwned)
+	if err := backfillOwnershipSignatures(context.TODO(), client, signer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(patched) != 1 || patched["unsigned"] == nil {
+		t.Fatalf("expected only the unsigned configmap to be signed, got %v", patched)
+	}
+	if patched["unsigned"].ResourceVersion != "1" {
+		t.Errorf("expected the patch to be guarded by the resourceVersion, got %q", patched["unsigned"].ResourceVersion)
+	}
+	cm := owned("unsigned")
+	backfilled := &corev1.ConfigMap{ObjectMeta: cm.ObjectMeta}
+	backfilled.Annotations[constants.LabelOwnershipSignature] = patched["unsigned"].Annotations[constants.LabelOwnershipSignature]
+	signer.SetBackfilled()
+	if err := signer.Verify(backfilled); err != nil {
+		t.Errorf("expected the backfilled configmap to be verified, got %v", err)
+	}
+}
+
+func TestBackfillOwnershipOnce(t *testing.T) {
+	signer, err := conversion.NewOwnershipSigner([][]byte{bytes.Repeat([]byte("a"), 32)}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := func(name string) metav1.PartialObjectMetadata {
+		return metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "cluster-default",
+			Name:        name,
+			Annotations: map[string]string{constants.LabelCluster: "cluster", constants.LabelNamespace: "default"},
+		}}
+	}
+	superClient := k8sfake.NewSimpleClientset()
+
+	client, patched := newBackfillClient(t, unsigned("legacy"))
+	if done, err := backfillOwnershipOnce(context.TODO(), superClient, client, signer, false); err != nil || done {
+		t.Fatalf("expected nothing to be backfilled without the flag, got %v, %v", done, err)
+	}
+	if len(patched) != 0 {
+		t.Fatalf("expected nothing to be signed without the flag, got %v", patched)
+	}
+
+	if done, err := backfillOwnershipOnce(context.TODO(), superClient, client, signer, true); err != nil || !done {
+		t.Fatalf("expected the backfill to complete, got %v, %v", done, err)
+	}
+	if len(patched) != 1 || patched["legacy"] == nil {
+		t.Fatalf("expected the legacy configmap to be signed, got %v", patched)
+	}
+	if _, err := superClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(context.TODO(), utilconst.OwnershipBackfillCfgMap, metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the completion to be recorded, got %v", err)
+	}
+
+	// an ownership annotation forged after the backfill is never signed by a restart
+	client, patched = newBackfillClient(t, unsigned("forged"))
+	if done, err := backfillOwnershipOnce(context.TODO(), superClient, client, signer, true); err != nil || !done {
+		t.Fatalf("expected the backfill to be done, got %v, %v", done, err)
+	}
+	if len(patched) != 0 || len(client.Actions()) != 0 {
+		t.Errorf("expected the super cluster objects not to be listed again, got %v", client.Actions())
+	}
+}
+
+func TestRunOwnershipBackfillRequired(t *testing.T) {
+	signer, err := conversion.NewOwnershipSigner([][]byte{bytes.Repeat([]byte("a"), 32)}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conversion.SetOwnershipSigner(signer)
+	defer conversion.SetOwnershipSigner(nil)
+
+	superClient := k8sfake.NewSimpleClientset()
+	s := &Syncer{
+		config:      &config.SyncerConfiguration{RequireOwnershipSignature: true, BackfillOwnershipSignatures: true},
+		superClient: superClient,
+	}
+	stop := make(chan struct{})
+	close(stop)
+	s.runOwnershipBackfill(stop)
+	if len(superClient.Actions()) != 0 {
+		t.Errorf("expected no backfill when the signatures are required, got %v", superClient.Actions())
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patrol
+
+import (
+	"sync"
+)
+
+// Budget limits the remediation actions, e.g. deletes and requeues, a patrol pass takes per tenant
+// cluster. Once the limit of a cluster is reached, the pass stops remediating it, which protects the
+// tenant from a bug or a bad cache making all its objects look orphaned.
+// A nil Budget is unlimited.
+type Budget struct {
+	limit int
+
+	mu      sync.Mutex
+	actions map[string]int
+}
+
+// NewBudget returns a Budget of limit actions per cluster, it is unlimited if limit is not positive.
+func NewBudget(limit int) *Budget {
+	return &Budget{limit: limit, actions: make(map[string]int)}
+}
+
+// Take reserves a remediation action on the cluster, it returns false if the action must be skipped.
+func (b *Budget) Take(cluster string) bool {
+	if b == nil || b.limit <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.actions[cluster]++
+	return b.actions[cluster] <= b.limit
+}
+
+// Exceeded returns the clusters whose remediation actions exceeded the limit, mapped to the number of
+// actions the pass required.
+func (b *Budget) Exceeded() map[string]int {
+	exceeded := make(map[string]int)
+	if b == nil || b.limit <= 0 {
+		return exceeded
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for cluster, n := range b.actions {
+		if n > b.limit {
+			exceeded[cluster] = n
+		}
+	}
+	return exceeded
+}
+
+// BudgetListener is notified of the clusters exceeding the remediation budget after each patrol pass.
+type BudgetListener interface {
+	// BudgetExceeded is called with the clusters whose remediation actions of the kind exceeded the
+	// budget in the last pass, mapped to the number of actions the pass required. The other clusters
+	// are within the budget.
+	BudgetExceeded(kind string, exceeded map[string]int)
+}
+
+var (
+	budgetListenersLock sync.Mutex
+	budgetListeners     []BudgetListener
+)
+
+// AddBudgetListener registers a listener notified after each patrol pass of all the patrollers.
+func AddBudgetListener(l BudgetListener) {
+	budgetListenersLock.Lock()
+	defer budgetListenersLock.Unlock()
+	budgetListeners = append(budgetListeners, l)
+}
+
+func notifyBudgetListeners(kind string, exceeded map[string]int) {
+	budgetListenersLock.Lock()
+	listeners := budgetListeners
+	budgetListenersLock.Unlock()
+	for _, l := range listeners {
+		l.BudgetExceeded(kind, exceeded)
+	}
+}
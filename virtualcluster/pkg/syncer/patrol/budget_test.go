@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patrol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		budget   *Budget
+		take     map[string]int
+		allowed  map[string]int
+		exceeded map[string]int
+	}{
+		{
+			name:     "nil budget",
+			budget:   nil,
+			take:     map[string]int{"a": 3},
+			allowed:  map[string]int{"a": 3},
+			exceeded: map[string]int{},
+		},
+		{
+			name:     "unlimited budget",
+			budget:   NewBudget(0),
+			take:     map[string]int{"a": 3},
+			allowed:  map[string]int{"a": 3},
+			exceeded: map[string]int{},
+		},
+		{
+			name:     "limited per cluster",
+			budget:   NewBudget(2),
+			take:     map[string]int{"a": 5, "b": 2},
+			allowed:  map[string]int{"a": 2, "b": 2},
+			exceeded: map[string]int{"a": 5},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed := make(map[string]int)
+			for cluster, n := range tt.take {
+				for i := 0; i < n; i++ {
+					if tt.budget.Take(cluster) {
+						allowed[cluster]++
+					}
+				}
+			}
+			if !reflect.DeepEqual(allowed, tt.allowed) {
+				t.Errorf("expected allowed actions %v, got %v", tt.allowed, allowed)
+			}
+			if exceeded := tt.budget.Exceeded(); !reflect.DeepEqual(exceeded, tt.exceeded) {
+				t.Errorf("expected exceeded clusters %v, got %v", tt.exceeded, exceeded)
+			}
+		})
+	}
+}
@@ -86,6 +86,78 @@ func (h FilteringHandler) OnDelete(obj ClusterObject) {
 	h.Handler.OnDelete(obj)
 }
 
+// Budget limits the remediation actions taken per cluster, it is implemented by patrol.Budget.
+type Budget interface {
+	Take(cluster string) bool
+}
+
+// BudgetHandler takes the missing and orphan objects, which are the ones a bad cache produces in
+// bulk, from the remediation budget of their cluster, and skips them once it is exhausted.
+type BudgetHandler struct {
+	Budget  Budget
+	Handler Handler
+}
+
+// OnAdd calls the nested handler only if the budget of the object cluster is not exhausted
+func (h BudgetHandler) OnAdd(obj ClusterObject) {
+	if h.Budget != nil && !h.Budget.Take(ownerCluster(obj)) {
+		return
+	}
+	h.Handler.OnAdd(obj)
+}
+
+// OnUpdate calls the nested handler.
+func (h BudgetHandler) OnUpdate(obj1, obj2 ClusterObject) {
+	h.Handler.OnUpdate(obj1, obj2)
+}
+
+// OnDelete calls the nested handler only if the budget of the object cluster is not exhausted
+func (h BudgetHandler) OnDelete(obj ClusterObject) {
+	if h.Budget != nil && !h.Budget.Take(ownerCluster(obj)) {
+		return
+	}
+	h.Handler.OnDelete(obj)
+}
+
+// FreezeHandler skips the objects of the tenant namespaces whose downward sync is frozen, so that
+// the inconsistencies of a frozen namespace are not remediated until the freeze ends.
+type FreezeHandler struct {
+	Frozen  func(obj ClusterObject) bool
+	Handler Handler
+}
+
+// OnAdd calls the nested handler only if the object is not frozen
+func (h FreezeHandler) OnAdd(obj ClusterObject) {
+	if h.Frozen != nil && h.Frozen(obj) {
+		return
+	}
+	h.Handler.OnAdd(obj)
+}
+
+// OnUpdate calls the nested handler only if the objects are not frozen
+func (h FreezeHandler) OnUpdate(obj1, obj2 ClusterObject) {
+	if h.Frozen != nil && (h.Frozen(obj1) || h.Frozen(obj2)) {
+		return
+	}
+	h.Handler.OnUpdate(obj1, obj2)
+}
+
+// OnDelete calls the nested handler only if the object is not frozen
+func (h FreezeHandler) OnDelete(obj ClusterObject) {
+	if h.Frozen != nil && h.Frozen(obj) {
+		return
+	}
+	h.Handler.OnDelete(obj)
+}
+
+func ownerCluster(obj ClusterObject) string {
+	if obj.OwnerCluster != "" {
+		return obj.OwnerCluster
+	}
+	clusterName, _ := conversion.GetVirtualOwner(obj)
+	return clusterName
+}
+
 func DefaultDifferFilter(knownClusterSet sets.String) func(obj ClusterObject) bool {
 	return func(obj ClusterObject) bool {
 		// vObj
@@ -19,9 +19,10 @@ package patrol
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 
@@ -30,9 +31,23 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// DefaultPeriod is the default period of the patrollers.
+const DefaultPeriod = 60 * time.Second
+
 type Patroller struct {
 	// objectKind is the kind of target object this controller watched.
 	objectKind string
+	// period is the current patrol period in nanoseconds, it can be changed by SetPeriod.
+	period int64
+	// remediationBudget is the current remediation budget per cluster of a pass, it can be changed by
+	// SetRemediationBudget.
+	remediationBudget int64
+
+	// budget is the remediation budget of the running pass.
+	budgetLock sync.Mutex
+	budget     *Budget
+	// exceeded are the clusters that exceeded the budget in the last pass.
+	exceeded map[string]int
 
 	Options
 }
@@ -55,7 +70,7 @@ func NewPatroller(objectType client.Object, rc reconciler.PatrolReconciler, opts
 		Options: Options{
 			name:       fmt.Sprintf("%s-patroller", strings.ToLower(kinds[0].Kind)),
 			Reconciler: rc,
-			Period:     60 * time.Second,
+			Period:     DefaultPeriod,
 		},
 	}
 
@@ -66,15 +81,82 @@ func NewPatroller(objectType client.Object, rc reconciler.PatrolReconciler, opts
 	if p.Reconciler == nil {
 		return nil, fmt.Errorf("patroller %q: must specify patrol reconciler", p.objectKind)
 	}
+	p.period = int64(p.Period)
 	return p, nil
 }
 
 func (p *Patroller) Start(stop <-chan struct{}) {
 	klog.Infof("start periodic checker %s", p.name)
-	wait.Until(p.run, p.Period, stop)
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		p.run()
+		select {
+		case <-stop:
+			return
+		case <-time.After(p.GetPeriod()):
+		}
+	}
+}
+
+// Budget returns the remediation budget of the running pass, every remediation action of the
+// reconciler must be taken from it.
+func (p *Patroller) Budget() *Budget {
+	p.budgetLock.Lock()
+	defer p.budgetLock.Unlock()
+	return p.budget
+}
+
+// SetRemediationBudget changes the remediation budget per cluster, it takes effect on the next pass.
+// The budget is unlimited if it is not positive.
+func (p *Patroller) SetRemediationBudget(n int) {
+	if int64(n) == atomic.LoadInt64(&p.remediationBudget) {
+		return
+	}
+	klog.Infof("periodic checker %s remediation budget changed to %d", p.name, n)
+	atomic.StoreInt64(&p.remediationBudget, int64(n))
+}
+
+// GetPeriod returns the current patrol period.
+func (p *Patroller) GetPeriod() time.Duration {
+	return time.Duration(atomic.LoadInt64(&p.period))
+}
+
+// SetPeriod changes the patrol period, it takes effect after the current wait.
+func (p *Patroller) SetPeriod(t time.Duration) {
+	if t <= 0 || t == p.GetPeriod() {
+		return
+	}
+	klog.Infof("periodic checker %s period changed to %v", p.name, t)
+	atomic.StoreInt64(&p.period, int64(t))
 }
 
 func (p *Patroller) run() {
 	defer metrics.RecordCheckerScanDuration(p.objectKind, time.Now())
+	budget := NewBudget(int(atomic.LoadInt64(&p.remediationBudget)))
+	p.budgetLock.Lock()
+	p.budget = budget
+	p.budgetLock.Unlock()
+
 	p.Reconciler.PatrollerDo()
+	p.reportBudget(budget.Exceeded())
+}
+
+// reportBudget records the clusters that exceeded the remediation budget in the last pass.
+func (p *Patroller) reportBudget(exceeded map[string]int) {
+	for cluster, n := range exceeded {
+		klog.Errorf("periodic checker %s stopped remediating cluster %s: %d actions exceed the remediation budget of %d",
+			p.name, cluster, n, atomic.LoadInt64(&p.remediationBudget))
+		metrics.CheckerBudgetExceeded.WithLabelValues(p.objectKind, cluster).Set(float64(n))
+	}
+	for cluster := range p.exceeded {
+		if _, ok := exceeded[cluster]; !ok {
+			metrics.CheckerBudgetExceeded.DeleteLabelValues(p.objectKind, cluster)
+		}
+	}
+	p.exceeded = exceeded
+	notifyBudgetListeners(p.objectKind, exceeded)
 }
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+)
+
+const (
+	// TenantIdentityServiceAccount impersonates a service account per tenant, created by the
+	// syncer in config.TenantServiceAccountNamespace. The syncer can only impersonate the
+	// service accounts of that namespace.
+	TenantIdentityServiceAccount = "ServiceAccount"
+	// TenantIdentityUser impersonates the user system:vc:<cluster> in the system:vcs group,
+	// which super cluster RBAC, admission policies and audit policies can match without a
+	// service account per tenant. The syncer can only impersonate the users of the clusters
+	// listed in config.TenantIdentityClusters, and no other group.
+	TenantIdentityUser = "User"
+
+	// TenantUserPrefix prefixes the cluster in the user names of TenantIdentityUser.
+	TenantUserPrefix = "system:vc:"
+	// TenantsGroup is the group of every user of TenantIdentityUser.
+	TenantsGroup = "system:vcs"
+)
+
+// Identity is the super cluster identity the syncer impersonates for a tenant.
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// ValidateTenantIdentity returns an error if identity is not a known kind of tenant identity.
+func ValidateTenantIdentity(identity string) error {
+	switch identity {
+	case TenantIdentityServiceAccount, TenantIdentityUser:
+		return nil
+	default:
+		return fmt.Errorf("unknown tenant identity %q, expected %s or %s", identity, TenantIdentityServiceAccount, TenantIdentityUser)
+	}
+}
+
+// UsesServiceAccounts returns true if the tenants are impersonated as service accounts created
+// by the syncer, the default for configurations predating TenantIdentity.
+func UsesServiceAccounts(cfg *config.SyncerConfiguration) bool {
+	return cfg.TenantIdentity != TenantIdentityUser
+}
+
+// TenantIdentityOf returns the identity impersonated for the tenant cluster.
+func TenantIdentityOf(cfg *config.SyncerConfiguration, cluster string) Identity {
+	if UsesServiceAccounts(cfg) {
+		return Identity{User: TenantUserName(cfg.TenantServiceAccountNamespace, cluster)}
+	}
+	return Identity{User: TenantUserPrefix + cluster, Groups: []string{TenantsGroup}}
+}
+
+// TenantUserNames returns the sorted user names impersonated for the tenant clusters.
+func TenantUserNames(cfg *config.SyncerConfiguration, clusters []string) []string {
+	names := sets.NewString()
+	for _, cluster := range clusters {
+		if cluster != "" {
+			names.Insert(TenantIdentityOf(cfg, cluster).User)
+		}
+	}
+	return names.List()
+}
+
+// TenantSubject returns the RBAC subject of the identity impersonated for the tenant cluster.
+func TenantSubject(cfg *config.SyncerConfiguration, cluster string) rbacv1.Subject {
+	if UsesServiceAccounts(cfg) {
+		return rbacv1.Subject{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      TenantServiceAccountName(cluster),
+			Namespace: cfg.TenantServiceAccountNamespace,
+		}
+	}
+	return rbacv1.Subject{
+		APIGroup: rbacv1.GroupName,
+		Kind:     rbacv1.UserKind,
+		Name:     TenantUserPrefix + cluster,
+	}
+}
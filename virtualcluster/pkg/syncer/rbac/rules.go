@@ -0,0 +1,355 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbac generates the minimal super cluster RBAC the syncer needs for
+// a given set of enabled resource plugins and feature gates, and implements
+// the per-tenant impersonation used by featuregate.TenantImpersonation.
+package rbac
+
+import (
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+const (
+	// DefaultClusterRoleName is the ClusterRole bound to the syncer service account.
+	DefaultClusterRoleName = "vc-syncer-role"
+	// DefaultTenantClusterRoleName is the ClusterRole bound to the per-tenant service accounts.
+	DefaultTenantClusterRoleName = "vc-syncer-tenant"
+	// DefaultTenantServiceAccountNamespace holds the per-tenant service accounts.
+	DefaultTenantServiceAccountNamespace = "vc-syncer-tenants"
+	// TenantRoleBindingName is the RoleBinding created in each tenant namespace of the super cluster.
+	TenantRoleBindingName = "vc-syncer-tenant"
+)
+
+var (
+	readVerbs  = []string{"get", "list", "watch"}
+	writeVerbs = []string{"create", "update", "patch", "delete"}
+)
+
+// pluginRules are the super cluster permissions of one resource plugin.
+type pluginRules struct {
+	// read rules are always granted to the syncer, its informers watch the whole cluster.
+	read []rbacv1.PolicyRule
+	// namespaced rules are writes into tenant namespaces, they move to the tenant
+	// ClusterRole when featuregate.TenantImpersonation is enabled.
+	namespaced []rbacv1.PolicyRule
+	// cluster rules are writes to cluster scoped objects, always made by the syncer.
+	cluster []rbacv1.PolicyRule
+}
+
+func rule(group string, verbs []string, resources ...string) rbacv1.PolicyRule {
+	return rbacv1.PolicyRule{APIGroups: []string{group}, Resources: resources, Verbs: verbs}
+}
+
+// syncedResource is a resource the plugin reads from the super cluster and
+// writes into tenant namespaces.
+func syncedResource(group string, resources ...string) pluginRules {
+	return pluginRules{
+		read:       []rbacv1.PolicyRule{rule(group, readVerbs, resources...)},
+		namespaced: []rbacv1.PolicyRule{rule(group, append([]string{"get"}, writeVerbs...), resources...)},
+	}
+}
+
+// watchedResource is a resource the plugin only reads from the super cluster.
+func watchedResource(group string, resources ...string) pluginRules {
+	return pluginRules{read: []rbacv1.PolicyRule{rule(group, readVerbs, resources...)}}
+}
+
+// rulesByPlugin is keyed by plugin.Registration.ID.
+var rulesByPlugin = map[string]pluginRules{
+	"configmap":             syncedResource("", "configmaps"),
+	"crd":                   watchedResource("apiextensions.k8s.io", "customresourcedefinitions"),
+	"endpoints":             syncedResource("", "endpoints"),
+	"event":                 watchedResource("", "events"),
+	"ingress":               syncedResource("networking.k8s.io", "ingresses"),
+	"node":                  watchedResource("", "nodes"),
+	"persistentvolume":      watchedResource("", "persistentvolumes", "persistentvolumeclaims"),
+	"persistentvolumeclaim": syncedResource("", "persistentvolumeclaims"),
+	"priorityclass":         watchedResource("scheduling.k8s.io", "priorityclasses"),
+	"secret":                syncedResource("", "secrets"),
+	"service":               syncedResource("", "services"),
+	"serviceexport":         syncedResource("discovery.k8s.io", "endpointslices"),
+	"serviceaccount":        syncedResource("", "serviceaccounts"),
+	"storageclass":          watchedResource("storage.k8s.io", "storageclasses"),
+	"volumesnapshot":        syncedResource("snapshot.storage.k8s.io", "volumesnapshots"),
+	"volumesnapshotclass":   watchedResource("snapshot.storage.k8s.io", "volumesnapshotclasses"),
+	"volumesnapshotcontent": watchedResource("snapshot.storage.k8s.io", "volumesnapshots", "volumesnapshotcontents"),
+	"namespace": {
+		read:    []rbacv1.PolicyRule{rule("", readVerbs, "namespaces")},
+		cluster: []rbacv1.PolicyRule{rule("", writeVerbs, "namespaces")},
+	},
+	"pod": {
+		read: []rbacv1.PolicyRule{rule("", readVerbs, "pods", "nodes")},
+		namespaced: []rbacv1.PolicyRule{
+			rule("", append([]string{"get"}, writeVerbs...), "pods"),
+			rule("", []string{"update"}, "pods/status"),
+		},
+	},
+}
+
+// commonRules are needed by the syncer regardless of the enabled plugins.
+var commonRules = []rbacv1.PolicyRule{
+	rule("", readVerbs, "namespaces"),
+	rule("", []string{"create", "patch"}, "events"),
+	rule("tenancy.x-k8s.io", readVerbs, "virtualclusters"),
+}
+
+// KnownPlugin returns true if the permissions of the plugin are known.
+func KnownPlugin(id string) bool {
+	_, ok := rulesByPlugin[id]
+	return ok
+}
+
+// TenantServiceAccountName returns the service account impersonated for the tenant cluster.
+func TenantServiceAccountName(cluster string) string {
+	return cluster
+}
+
+// TenantUserName returns the user name impersonated for the tenant cluster.
+func TenantUserName(saNamespace, cluster string) string {
+	return serviceaccount.MakeUsername(saNamespace, TenantServiceAccountName(cluster))
+}
+
+// ClusterRole returns the ClusterRole of the syncer for the enabled plugins and
+// feature gates. With featuregate.TenantImpersonation enabled, writes into tenant
+// namespaces are left to TenantClusterRole instead.
+func ClusterRole(name string, cfg *config.SyncerConfiguration, plugins []string, gate featuregate.FeatureGate) *rbacv1.ClusterRole {
+	impersonate := gate.Enabled(featuregate.TenantImpersonation)
+
+	rules := append([]rbacv1.PolicyRule{}, commonRules...)
+	for _, id := range plugins {
+		r := rulesByPlugin[id]
+		rules = append(rules, r.read...)
+		rules = append(rules, r.cluster...)
+		if !impersonate {
+			rules = append(rules, r.namespaced...)
+		}
+	}
+
+	if gate.Enabled(featuregate.SuperClusterPooling) {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{utilconst.SuperClusterInfoCfgMap},
+			Verbs:         []string{"get"},
+		}, rule("", []string{"list", "watch"}, "events"))
+	}
+	if gate.Enabled(featuregate.OwnershipSignature) {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{utilconst.OwnershipBackfillCfgMap},
+			Verbs:         []string{"get"},
+		})
+		if cfg.BackfillOwnershipSignatures && !cfg.RequireOwnershipSignature {
+			// creations can't be restricted by name, only the one-time backfill records its completion
+			rules = append(rules, rule("", []string{"create"}, "configmaps"))
+		}
+	}
+	if gate.Enabled(featuregate.VNodeProviderService) {
+		if parts := strings.SplitN(cfg.VNAgentNamespacedName, "/", 2); len(parts) == 2 {
+			rules = append(rules, rbacv1.PolicyRule{
+				APIGroups:     []string{""},
+				Resources:     []string{"services"},
+				ResourceNames: []string{parts[1]},
+				Verbs:         []string{"get"},
+			})
+		}
+	}
+	if gate.Enabled(featuregate.VNodeProviderPodIP) {
+		rules = append(rules, rule("", readVerbs, "pods"))
+	}
+	if gate.Enabled(featuregate.UsageReporting) {
+		rules = append(rules, rule("", readVerbs, "pods"), rule("metrics.k8s.io", []string{"list"}, "pods"))
+	}
+	if gate.Enabled(featuregate.VirtualClusterSummary) {
+		rules = append(rules,
+			rule("", readVerbs, "pods"),
+			rule("metrics.k8s.io", []string{"list"}, "pods"),
+			rule("tenancy.x-k8s.io", []string{"get", "create", "update"}, "virtualclustersummaries"))
+	}
+	if gate.Enabled(featuregate.TenantPodPolicy) {
+		rules = append(rules, rule("tenancy.x-k8s.io", readVerbs, "tenantpodpolicies"))
+	}
+	if gate.Enabled(featuregate.SuperClusterCapabilities) {
+		rules = append(rules, rule("node.k8s.io", []string{"list"}, "runtimeclasses"), rule("storage.k8s.io", []string{"list"}, "csidrivers"))
+	}
+	if gate.Enabled(featuregate.SuperClusterRequirements) {
+		rules = append(rules,
+			rule("tenancy.x-k8s.io", []string{"get"}, "clusterversions"),
+			rule("tenancy.x-k8s.io", []string{"update"}, "virtualclusters/status"),
+			rule("storage.k8s.io", []string{"get"}, "storageclasses"),
+			rule("", []string{"list"}, "nodes"),
+		)
+	}
+	if impersonate {
+		rules = append(rules,
+			rule("rbac.authorization.k8s.io", []string{"get", "list", "watch", "create", "update"}, "rolebindings"),
+			rbacv1.PolicyRule{
+				APIGroups:     []string{"rbac.authorization.k8s.io"},
+				Resources:     []string{"clusterroles"},
+				ResourceNames: []string{cfg.TenantClusterRoleName},
+				Verbs:         []string{"bind"},
+			})
+		if !UsesServiceAccounts(cfg) {
+			rules = append(rules, rbacv1.PolicyRule{
+				APIGroups:     []string{""},
+				Resources:     []string{"groups"},
+				ResourceNames: []string{TenantsGroup},
+				Verbs:         []string{"impersonate"},
+			})
+			// user names can't be restricted to a prefix, only the users of the listed clusters are
+			// granted, a rule without resource names would grant every user, cluster admins included.
+			if users := TenantUserNames(cfg, cfg.TenantIdentityClusters); len(users) > 0 {
+				rules = append(rules, rbacv1.PolicyRule{
+					APIGroups:     []string{""},
+					Resources:     []string{"users"},
+					ResourceNames: users,
+					Verbs:         []string{"impersonate"},
+				})
+			}
+		}
+	}
+
+	return &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Rules:      compact(rules),
+	}
+}
+
+// TenantClusterRole returns the ClusterRole bound to the identity of each tenant
+// in the namespaces of that tenant.
+func TenantClusterRole(cfg *config.SyncerConfiguration, plugins []string) *rbacv1.ClusterRole {
+	var rules []rbacv1.PolicyRule
+	for _, id := range plugins {
+		rules = append(rules, rulesByPlugin[id].namespaced...)
+	}
+	return &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: cfg.TenantClusterRoleName},
+		Rules:      compact(rules),
+	}
+}
+
+// TenantServiceAccountRole returns the Role that lets the syncer manage and
+// impersonate the per-tenant service accounts. Being namespaced, it can't be
+// used to impersonate any other service account.
+func TenantServiceAccountRole(name string, cfg *config.SyncerConfiguration) *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cfg.TenantServiceAccountNamespace},
+		Rules: []rbacv1.PolicyRule{
+			rule("", []string{"get", "create", "delete", "impersonate"}, "serviceaccounts"),
+		},
+	}
+}
+
+// LeaderElectionRole returns the Role for the leader election lock of the syncer.
+func LeaderElectionRole(name string, cfg *config.SyncerConfiguration) *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cfg.LeaderElection.LockObjectNamespace},
+		Rules: []rbacv1.PolicyRule{
+			rule("", []string{"get", "create", "update"}, "configmaps", "endpoints"),
+			rule("coordination.k8s.io", []string{"get", "create", "update"}, "leases"),
+		},
+	}
+}
+
+// TenantRoleBinding returns the RoleBinding granting the identity impersonated for
+// the tenant access to one of its namespaces in the super cluster.
+func TenantRoleBinding(cfg *config.SyncerConfiguration, cluster, namespace string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: TenantRoleBindingName, Namespace: namespace},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     cfg.TenantClusterRoleName,
+		},
+		Subjects: []rbacv1.Subject{TenantSubject(cfg, cluster)},
+	}
+}
+
+// Manifests returns every RBAC object the syncer needs for the enabled plugins
+// and feature gates.
+func Manifests(name string, cfg *config.SyncerConfiguration, plugins []string, gate featuregate.FeatureGate) []runtime.Object {
+	objs := []runtime.Object{ClusterRole(name, cfg, plugins, gate)}
+	if cfg.LeaderElection.LeaderElect && cfg.LeaderElection.LockObjectNamespace != "" {
+		objs = append(objs, LeaderElectionRole(name+"-leader-election", cfg))
+	}
+	if gate.Enabled(featuregate.TenantImpersonation) {
+		objs = append(objs, TenantClusterRole(cfg, plugins))
+		if UsesServiceAccounts(cfg) {
+			objs = append(objs, TenantServiceAccountRole(name+"-impersonation", cfg))
+		}
+	}
+	return objs
+}
+
+// compact merges rules of the same api group, resource names and verbs, so the
+// generated roles stay readable.
+func compact(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	type key struct{ group, names, verbs string }
+	var keys []key
+	resources := map[key][]string{}
+	for _, r := range rules {
+		for _, group := range r.APIGroups {
+			verbs := append([]string{}, r.Verbs...)
+			sort.Strings(verbs)
+			k := key{group: group, names: strings.Join(r.ResourceNames, ","), verbs: strings.Join(verbs, ",")}
+			if _, ok := resources[k]; !ok {
+				keys = append(keys, k)
+			}
+			for _, res := range r.Resources {
+				if !contains(resources[k], res) {
+					resources[k] = append(resources[k], res)
+				}
+			}
+		}
+	}
+
+	merged := make([]rbacv1.PolicyRule, 0, len(keys))
+	for _, k := range keys {
+		res := resources[k]
+		sort.Strings(res)
+		r := rbacv1.PolicyRule{APIGroups: []string{k.group}, Resources: res, Verbs: strings.Split(k.verbs, ",")}
+		if k.names != "" {
+			r.ResourceNames = strings.Split(k.names, ",")
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
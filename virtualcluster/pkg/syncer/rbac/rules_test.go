@@ -0,0 +1,294 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+// allows returns true if any of the rules grants verb on the resource.
+func allows(rules []rbacv1.PolicyRule, group, resource, verb string) bool {
+	for _, r := range rules {
+		if contains(r.APIGroups, group) && contains(r.Resources, resource) && contains(r.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClusterRole(t *testing.T) {
+	cfg := &config.SyncerConfiguration{
+		TenantClusterRoleName: DefaultTenantClusterRoleName,
+		VNAgentNamespacedName: "vc-manager/vn-agent",
+	}
+
+	tests := map[string]struct {
+		plugins    []string
+		gates      map[string]bool
+		allowed    [][3]string
+		disallowed [][3]string
+	}{
+		"only enabled plugins": {
+			plugins: []string{"configmap", "namespace"},
+			allowed: [][3]string{
+				{"", "configmaps", "create"},
+				{"", "namespaces", "delete"},
+				{"", "events", "create"},
+			},
+			disallowed: [][3]string{
+				{"", "secrets", "get"},
+				{"", "pods", "create"},
+				{"networking.k8s.io", "ingresses", "list"},
+			},
+		},
+		"read only plugins": {
+			plugins: []string{"storageclass", "priorityclass"},
+			allowed: [][3]string{
+				{"storage.k8s.io", "storageclasses", "watch"},
+				{"scheduling.k8s.io", "priorityclasses", "list"},
+			},
+			disallowed: [][3]string{
+				{"storage.k8s.io", "storageclasses", "create"},
+				{"scheduling.k8s.io", "priorityclasses", "delete"},
+			},
+		},
+		"impersonation moves namespaced writes out": {
+			plugins: []string{"pod", "secret", "namespace"},
+			gates:   map[string]bool{featuregate.TenantImpersonation: true},
+			allowed: [][3]string{
+				{"", "pods", "watch"},
+				{"", "secrets", "list"},
+				{"", "namespaces", "create"},
+				{"rbac.authorization.k8s.io", "rolebindings", "create"},
+				{"rbac.authorization.k8s.io", "clusterroles", "bind"},
+			},
+			disallowed: [][3]string{
+				{"", "pods", "create"},
+				{"", "pods/status", "update"},
+				{"", "secrets", "update"},
+				{"", "users", "impersonate"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gate, err := featuregate.NewFeatureGate(tc.gates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			role := ClusterRole(DefaultClusterRoleName, cfg, tc.plugins, gate)
+			for _, a := range tc.allowed {
+				if !allows(role.Rules, a[0], a[1], a[2]) {
+					t.Errorf("expected %s on %q/%s to be allowed", a[2], a[0], a[1])
+				}
+			}
+			for _, a := range tc.disallowed {
+				if allows(role.Rules, a[0], a[1], a[2]) {
+					t.Errorf("expected %s on %q/%s to be disallowed", a[2], a[0], a[1])
+				}
+			}
+		})
+	}
+}
+
+func TestClusterRoleFeatureGates(t *testing.T) {
+	cfg := &config.SyncerConfiguration{VNAgentNamespacedName: "vc-manager/vn-agent"}
+	gate, err := featuregate.NewFeatureGate(map[string]bool{
+		featuregate.SuperClusterPooling:      true,
+		featuregate.VNodeProviderService:     true,
+		featuregate.UsageReporting:           true,
+		featuregate.TenantPodPolicy:          true,
+		featuregate.SuperClusterCapabilities: true,
+		featuregate.VirtualClusterSummary:    true,
+		featuregate.SuperClusterRequirements: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	role := ClusterRole(DefaultClusterRoleName, cfg, nil, gate)
+	names := map[string]bool{}
+	for _, r := range role.Rules {
+		if len(r.ResourceNames) == 0 && (contains(r.Resources, "configmaps") || contains(r.Resources, "services")) {
+			t.Errorf("expected feature gate rules to be restricted by name, got %+v", r)
+		}
+		for _, n := range r.ResourceNames {
+			names[n] = true
+		}
+	}
+	if !names["vn-agent"] || !names[utilconst.SuperClusterInfoCfgMap] {
+		t.Errorf("expected the vn-agent service and super cluster info configmap to be granted, got %v", names)
+	}
+	metrics, policies, capabilities, summaries, requirements := false, false, false, false, false
+	for _, r := range role.Rules {
+		if contains(r.APIGroups, "metrics.k8s.io") && contains(r.Resources, "pods") && contains(r.Verbs, "list") {
+			metrics = true
+		}
+		if contains(r.APIGroups, "tenancy.x-k8s.io") && contains(r.Resources, "tenantpodpolicies") && contains(r.Verbs, "watch") {
+			policies = true
+		}
+		if contains(r.APIGroups, "node.k8s.io") && contains(r.Resources, "runtimeclasses") && contains(r.Verbs, "list") {
+			capabilities = true
+		}
+		if contains(r.APIGroups, "tenancy.x-k8s.io") && contains(r.Resources, "virtualclustersummaries") && contains(r.Verbs, "update") {
+			summaries = true
+		}
+		if contains(r.APIGroups, "tenancy.x-k8s.io") && contains(r.Resources, "clusterversions") && contains(r.Verbs, "get") {
+			requirements = true
+		}
+	}
+	if !metrics {
+		t.Errorf("expected the pod metrics to be granted for usage reporting, got %+v", role.Rules)
+	}
+	if !policies {
+		t.Errorf("expected the tenant pod policies to be readable, got %+v", role.Rules)
+	}
+	if !capabilities {
+		t.Errorf("expected the runtime classes to be listable for capability probes, got %+v", role.Rules)
+	}
+	if !summaries {
+		t.Errorf("expected the virtual cluster summaries to be writable, got %+v", role.Rules)
+	}
+	if !requirements {
+		t.Errorf("expected the cluster versions to be readable for super cluster requirements, got %+v", role.Rules)
+	}
+}
+
+func TestClusterRoleOwnershipBackfill(t *testing.T) {
+	gate, err := featuregate.NewFeatureGate(map[string]bool{featuregate.OwnershipSignature: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name           string
+		cfg            *config.SyncerConfiguration
+		expectedCreate bool
+	}{
+		{name: "signatures", cfg: &config.SyncerConfiguration{}},
+		{name: "backfill", cfg: &config.SyncerConfiguration{BackfillOwnershipSignatures: true}, expectedCreate: true},
+		{name: "required signatures", cfg: &config.SyncerConfiguration{BackfillOwnershipSignatures: true, RequireOwnershipSignature: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			role := ClusterRole(DefaultClusterRoleName, tc.cfg, nil, gate)
+			marker := false
+			for _, r := range role.Rules {
+				marker = marker || (contains(r.ResourceNames, utilconst.OwnershipBackfillCfgMap) && contains(r.Verbs, "get"))
+			}
+			if !marker {
+				t.Errorf("expected the backfill marker to be readable, got %+v", role.Rules)
+			}
+			if create := allows(role.Rules, "", "configmaps", "create"); create != tc.expectedCreate {
+				t.Errorf("expected configmap creation %v, got %v", tc.expectedCreate, create)
+			}
+		})
+	}
+}
+
+func TestTenantClusterRole(t *testing.T) {
+	cfg := &config.SyncerConfiguration{TenantClusterRoleName: "tenant"}
+	role := TenantClusterRole(cfg, []string{"pod", "namespace", "node", "service"})
+	if role.Name != "tenant" {
+		t.Errorf("unexpected name %q", role.Name)
+	}
+	for _, a := range [][3]string{{"", "pods", "create"}, {"", "pods/status", "update"}, {"", "services", "delete"}} {
+		if !allows(role.Rules, a[0], a[1], a[2]) {
+			t.Errorf("expected %s on %s to be allowed", a[2], a[1])
+		}
+	}
+	for _, a := range [][3]string{{"", "namespaces", "create"}, {"", "nodes", "get"}, {"", "pods", "list"}} {
+		if allows(role.Rules, a[0], a[1], a[2]) {
+			t.Errorf("expected %s on %s to be disallowed", a[2], a[1])
+		}
+	}
+}
+
+func TestManifests(t *testing.T) {
+	cfg := &config.SyncerConfiguration{
+		TenantClusterRoleName:         DefaultTenantClusterRoleName,
+		TenantServiceAccountNamespace: DefaultTenantServiceAccountNamespace,
+	}
+	cfg.LeaderElection.LeaderElect = true
+	cfg.LeaderElection.LockObjectNamespace = "vc-manager"
+
+	gate, _ := featuregate.NewFeatureGate(nil)
+	if n := len(Manifests(DefaultClusterRoleName, cfg, []string{"pod"}, gate)); n != 2 {
+		t.Errorf("expected the cluster role and leader election role, got %d objects", n)
+	}
+
+	gate, _ = featuregate.NewFeatureGate(map[string]bool{featuregate.TenantImpersonation: true})
+	objs := Manifests(DefaultClusterRoleName, cfg, []string{"pod"}, gate)
+	if len(objs) != 4 {
+		t.Fatalf("expected 4 objects, got %d", len(objs))
+	}
+	saRole := objs[3].(*rbacv1.Role)
+	if saRole.Namespace != DefaultTenantServiceAccountNamespace || !allows(saRole.Rules, "", "serviceaccounts", "impersonate") {
+		t.Errorf("unexpected impersonation role %+v", saRole)
+	}
+
+	cfg.TenantIdentity = TenantIdentityUser
+	objs = Manifests(DefaultClusterRoleName, cfg, []string{"pod"}, gate)
+	if len(objs) != 3 {
+		t.Fatalf("expected no service account role for user identities, got %d objects", len(objs))
+	}
+	role := objs[0].(*rbacv1.ClusterRole)
+	if allows(role.Rules, "", "users", "impersonate") {
+		t.Errorf("expected no user to be impersonated without tenant identity clusters, got %+v", role.Rules)
+	}
+
+	cfg.TenantIdentityClusters = []string{"tenant-b", "tenant-a"}
+	role = Manifests(DefaultClusterRoleName, cfg, []string{"pod"}, gate)[0].(*rbacv1.ClusterRole)
+	if !allows(role.Rules, "", "users", "impersonate") || !allows(role.Rules, "", "groups", "impersonate") {
+		t.Errorf("expected the syncer to impersonate tenant users, got %+v", role.Rules)
+	}
+	for _, r := range role.Rules {
+		for _, res := range r.Resources {
+			if res == "groups" && !reflect.DeepEqual(r.ResourceNames, []string{TenantsGroup}) {
+				t.Errorf("expected the syncer to impersonate the %s group only, got %+v", TenantsGroup, r)
+			}
+			if res == "users" && !reflect.DeepEqual(r.ResourceNames, []string{"system:vc:tenant-a", "system:vc:tenant-b"}) {
+				t.Errorf("expected the syncer to impersonate the users of the listed tenants only, got %+v", r)
+			}
+		}
+	}
+}
+
+func TestTenantRoleBinding(t *testing.T) {
+	cfg := &config.SyncerConfiguration{
+		TenantClusterRoleName:         DefaultTenantClusterRoleName,
+		TenantServiceAccountNamespace: DefaultTenantServiceAccountNamespace,
+	}
+	rb := TenantRoleBinding(cfg, "tenant-a", "tenant-a-default")
+	expected := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "tenant-a", Namespace: DefaultTenantServiceAccountNamespace}
+	if len(rb.Subjects) != 1 || rb.Subjects[0] != expected {
+		t.Errorf("expected subject %+v, got %+v", expected, rb.Subjects)
+	}
+
+	cfg.TenantIdentity = TenantIdentityUser
+	rb = TenantRoleBinding(cfg, "tenant-a", "tenant-a-default")
+	expected = rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: "system:vc:tenant-a"}
+	if len(rb.Subjects) != 1 || rb.Subjects[0] != expected {
+		t.Errorf("expected subject %+v, got %+v", expected, rb.Subjects)
+	}
+}
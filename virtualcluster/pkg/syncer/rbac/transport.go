@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/transport"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a context for the requests made on behalf of the tenant cluster, e.g. by the
+// downward reconcile of one of its objects.
+func WithTenant(ctx context.Context, cluster string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, cluster)
+}
+
+// TenantFromContext returns the tenant cluster the requests of ctx are made on behalf of, or "".
+func TenantFromContext(ctx context.Context) string {
+	cluster, _ := ctx.Value(tenantKey{}).(string)
+	return cluster
+}
+
+// ImpersonateTenants returns a transport wrapper that sends every request made
+// into a tenant namespace of the super cluster as the identity of that tenant.
+// The tenant is the one of the request context, see WithTenant, and a request
+// into a namespace owned by another tenant is refused. A request without a tenant
+// in its context is sent as the owner of its namespace. Cluster scoped requests,
+// requests into namespaces not owned by a tenant and RBAC requests are left to the
+// syncer identity.
+func ImpersonateTenants(nsLister listersv1.NamespaceLister, cfg *config.SyncerConfiguration) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &impersonatingRoundTripper{nsLister: nsLister, cfg: cfg, delegate: rt}
+	}
+}
+
+type impersonatingRoundTripper struct {
+	nsLister listersv1.NamespaceLister
+	cfg      *config.SyncerConfiguration
+	delegate http.RoundTripper
+}
+
+func (rt *impersonatingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	namespace := namespaceFromPath(req.URL.Path)
+	if namespace == "" {
+		return rt.delegate.RoundTrip(req)
+	}
+	owner := namespaceOwner(rt.nsLister, namespace)
+	cluster := TenantFromContext(req.Context())
+	switch {
+	case cluster == "":
+		cluster = owner
+	case owner != "" && owner != cluster:
+		return nil, fmt.Errorf("refused the request of cluster %s into namespace %s of cluster %s", cluster, namespace, owner)
+	}
+	if cluster == "" {
+		return rt.delegate.RoundTrip(req)
+	}
+	identity := TenantIdentityOf(rt.cfg, cluster)
+	req = req.Clone(req.Context())
+	req.Header.Set(transport.ImpersonateUserHeader, identity.User)
+	for _, group := range identity.Groups {
+		req.Header.Add(transport.ImpersonateGroupHeader, group)
+	}
+	return rt.delegate.RoundTrip(req)
+}
+
+// TenantOf returns the tenant cluster owning the namespace the request path is
+// made into, or "" if the request is not made on behalf of a tenant.
+func TenantOf(nsLister listersv1.NamespaceLister, path string) string {
+	namespace := namespaceFromPath(path)
+	if namespace == "" {
+		return ""
+	}
+	return namespaceOwner(nsLister, namespace)
+}
+
+// namespaceOwner returns the tenant cluster owning the super cluster namespace, or "".
+func namespaceOwner(nsLister listersv1.NamespaceLister, namespace string) string {
+	ns, err := nsLister.Get(namespace)
+	if err != nil {
+		return ""
+	}
+	return ns.GetAnnotations()[constants.LabelCluster]
+}
+
+// namespaceFromPath returns the namespace of a namespaced resource request path,
+// e.g. /api/v1/namespaces/{ns}/pods or /apis/{group}/{version}/namespaces/{ns}/ingresses.
+// Requests on the namespace object itself, including its subresources, and RBAC
+// requests return "".
+func namespaceFromPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) >= 5 && parts[0] == "api" && parts[2] == "namespaces":
+		if len(parts) == 5 && (parts[4] == "status" || parts[4] == "finalize") {
+			return ""
+		}
+		return parts[3]
+	case len(parts) >= 6 && parts[0] == "apis" && parts[3] == "namespaces":
+		if parts[1] == rbacv1.GroupName {
+			return ""
+		}
+		return parts[4]
+	default:
+		return ""
+	}
+}
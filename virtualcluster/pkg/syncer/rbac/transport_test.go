@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/transport"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+type recordingRoundTripper struct {
+	req *http.Request
+}
+
+func (rt *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.req = req
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestImpersonateTenants(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "tenant-a-default",
+		Annotations: map[string]string{constants.LabelCluster: "tenant-a"},
+	}})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}})
+	lister := listersv1.NewNamespaceLister(indexer)
+
+	tests := map[string]struct {
+		path string
+		want string
+	}{
+		"core namespaced":        {path: "/api/v1/namespaces/tenant-a-default/pods/foo", want: "system:serviceaccount:tenants:tenant-a"},
+		"group namespaced":       {path: "/apis/networking.k8s.io/v1/namespaces/tenant-a-default/ingresses", want: "system:serviceaccount:tenants:tenant-a"},
+		"subresource":            {path: "/api/v1/namespaces/tenant-a-default/pods/foo/status", want: "system:serviceaccount:tenants:tenant-a"},
+		"namespace object":       {path: "/api/v1/namespaces/tenant-a-default"},
+		"namespace status":       {path: "/api/v1/namespaces/tenant-a-default/status"},
+		"cluster scoped":         {path: "/api/v1/nodes/node-1"},
+		"rbac":                   {path: "/apis/rbac.authorization.k8s.io/v1/namespaces/tenant-a-default/rolebindings"},
+		"not a tenant namespace": {path: "/api/v1/namespaces/kube-system/configmaps/superclusterinfo"},
+		"unknown namespace":      {path: "/api/v1/namespaces/missing/pods"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			delegate := &recordingRoundTripper{}
+			rt := ImpersonateTenants(lister, &config.SyncerConfiguration{TenantServiceAccountNamespace: "tenants"})(delegate)
+			req, err := http.NewRequest(http.MethodPost, "https://super"+tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := rt.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := delegate.req.Header.Get(transport.ImpersonateUserHeader); got != tc.want {
+				t.Errorf("expected impersonated user %q, got %q", tc.want, got)
+			}
+			if groups := delegate.req.Header.Values(transport.ImpersonateGroupHeader); len(groups) != 0 {
+				t.Errorf("expected no impersonated group, got %v", groups)
+			}
+			if req.Header.Get(transport.ImpersonateUserHeader) != "" {
+				t.Errorf("the original request must not be modified")
+			}
+		})
+	}
+}
+
+func TestImpersonateTenantUsers(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "tenant-a-default",
+		Annotations: map[string]string{constants.LabelCluster: "tenant-a"},
+	}})
+	cfg := &config.SyncerConfiguration{TenantIdentity: TenantIdentityUser}
+
+	delegate := &recordingRoundTripper{}
+	rt := ImpersonateTenants(listersv1.NewNamespaceLister(indexer), cfg)(delegate)
+	req, err := http.NewRequest(http.MethodPost, "https://super/api/v1/namespaces/tenant-a-default/pods", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := delegate.req.Header.Get(transport.ImpersonateUserHeader); got != "system:vc:tenant-a" {
+		t.Errorf("expected impersonated user system:vc:tenant-a, got %q", got)
+	}
+	if groups := delegate.req.Header.Values(transport.ImpersonateGroupHeader); len(groups) != 1 || groups[0] != TenantsGroup {
+		t.Errorf("expected impersonated group %s, got %v", TenantsGroup, groups)
+	}
+}
+
+func TestImpersonateRequestTenant(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "tenant-a-default",
+		Annotations: map[string]string{constants.LabelCluster: "tenant-a"},
+	}})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "tenant-b-default",
+		Annotations: map[string]string{constants.LabelCluster: "tenant-b"},
+	}})
+	lister := listersv1.NewNamespaceLister(indexer)
+
+	tests := map[string]struct {
+		path    string
+		want    string
+		refused bool
+	}{
+		"own namespace":     {path: "/api/v1/namespaces/tenant-a-default/pods", want: "system:serviceaccount:tenants:tenant-a"},
+		"other tenant":      {path: "/api/v1/namespaces/tenant-b-default/pods", refused: true},
+		"unknown namespace": {path: "/api/v1/namespaces/tenant-a-new/pods", want: "system:serviceaccount:tenants:tenant-a"},
+		"cluster scoped":    {path: "/api/v1/nodes/node-1"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			delegate := &recordingRoundTripper{}
+			rt := ImpersonateTenants(lister, &config.SyncerConfiguration{TenantServiceAccountNamespace: "tenants"})(delegate)
+			req, err := http.NewRequestWithContext(WithTenant(context.TODO(), "tenant-a"), http.MethodPost, "https://super"+tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = rt.RoundTrip(req)
+			if tc.refused {
+				if err == nil || delegate.req != nil {
+					t.Errorf("expected the request to be refused, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := delegate.req.Header.Get(transport.ImpersonateUserHeader); got != tc.want {
+				t.Errorf("expected impersonated user %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
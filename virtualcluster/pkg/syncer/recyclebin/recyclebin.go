@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package recyclebin retains the super cluster namespaces of deleted tenant namespaces, used by
+// featuregate.NamespaceRecycleBin. While a namespace is retained its pods are deleted as usual,
+// but its configmaps, secrets and persistentvolumeclaims are kept, so that the data of an
+// accidentally deleted tenant namespace can be recovered until the retention period expires.
+// Recreating the tenant namespace restores the retained super cluster namespace, and recreating a
+// tenant object adopts its retained super cluster object.
+package recyclebin
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+// DefaultRetentionPeriod is the default time the super cluster namespace of a deleted tenant
+// namespace is retained.
+const DefaultRetentionPeriod = 24 * time.Hour
+
+// Enabled returns true if the super cluster namespaces of deleted tenant namespaces are retained.
+func Enabled() bool {
+	return featuregate.DefaultFeatureGate.Enabled(featuregate.NamespaceRecycleBin)
+}
+
+// RetainedUntil returns the time the super cluster object is retained until, and false if the
+// object is not retained.
+func RetainedUntil(obj metav1.Object) (time.Time, bool) {
+	v, ok := obj.GetAnnotations()[constants.LabelRetainedUntil]
+	if !ok {
+		return time.Time{}, false
+	}
+	until, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		// an unreadable retention is treated as expired rather than retaining the object forever
+		return time.Time{}, true
+	}
+	return until, true
+}
+
+// Retain returns a copy of the super cluster namespace retained until the given time.
+func Retain(ns *corev1.Namespace, until time.Time) *corev1.Namespace {
+	retained := ns.DeepCopy()
+	markRetained(retained, until)
+	return retained
+}
+
+// Restore returns a copy of the retained super cluster namespace owned by the recreated tenant
+// namespace of the given uid.
+func Restore(ns *corev1.Namespace, uid string) *corev1.Namespace {
+	restored := ns.DeepCopy()
+	delegate(restored, uid)
+	return restored
+}
+
+// RetainObject returns a copy of the super cluster object of a deleted tenant object retained for
+// the given period, or nil if the object is already retained. The mark keeps the object once the
+// tenant namespace is recreated and the retained namespace restored, until the tenant object is
+// recreated, see Adopt, or the retention expires.
+func RetainObject(pObj client.Object, period time.Duration) client.Object {
+	if _, retained := RetainedUntil(pObj); retained {
+		return nil
+	}
+	retained := pObj.DeepCopyObject().(client.Object)
+	markRetained(retained, time.Now().Add(period))
+	return retained
+}
+
+// Adopt returns a copy of the retained super cluster object owned by the recreated tenant object of
+// the given uid, or nil if the object is not retained.
+func Adopt(pObj client.Object, uid string) client.Object {
+	if _, retained := RetainedUntil(pObj); !retained || pObj.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	adopted := pObj.DeepCopyObject().(client.Object)
+	delegate(adopted, uid)
+	return adopted
+}
+
+func markRetained(obj metav1.Object, until time.Time) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[constants.LabelRetainedUntil] = until.UTC().Format(time.RFC3339)
+	obj.SetAnnotations(annotations)
+}
+
+func delegate(obj metav1.Object, uid string) {
+	annotations := obj.GetAnnotations()
+	delete(annotations, constants.LabelRetainedUntil)
+	annotations[constants.LabelUID] = uid
+	obj.SetAnnotations(annotations)
+	conversion.SignOwnership(obj)
+}
+
+// Retains returns true if the super cluster object of a deleted tenant object must be kept: the
+// object is retained and the retention has not expired, or its tenant namespace is being deleted,
+// or is gone, and the namespace is retained. The tenant namespace is read from the cache of the
+// tenant cluster.
+func Retains(mccontroller mc.MultiClusterInterface, pObj client.Object) (bool, error) {
+	if !Enabled() {
+		return false, nil
+	}
+	if until, retained := RetainedUntil(pObj); retained {
+		return time.Now().Before(until), nil
+	}
+	clusterName, namespace := conversion.GetVirtualOwner(pObj)
+	if clusterName == "" || namespace == "" {
+		return false, nil
+	}
+	cluster := mccontroller.GetCluster(clusterName)
+	if cluster == nil {
+		return false, errors.NewClusterNotFound(clusterName)
+	}
+	tenantClient, err := cluster.GetDelegatingClient()
+	if err != nil {
+		return false, err
+	}
+	vNamespace := &corev1.Namespace{}
+	err = tenantClient.Get(context.TODO(), client.ObjectKey{Name: namespace}, vNamespace)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return vNamespace.DeletionTimestamp != nil, nil
+}
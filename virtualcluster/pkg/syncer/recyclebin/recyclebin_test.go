@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recyclebin
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+)
+
+func TestRetainAndRestore(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "tenant-a-default",
+			Annotations: map[string]string{constants.LabelUID: "12345"},
+		},
+	}
+	if _, retained := RetainedUntil(ns); retained {
+		t.Fatalf("expected the namespace not to be retained")
+	}
+
+	until := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	retained := Retain(ns, until)
+	if _, ok := ns.Annotations[constants.LabelRetainedUntil]; ok {
+		t.Errorf("the original namespace must not be modified")
+	}
+	got, ok := RetainedUntil(retained)
+	if !ok || !got.Equal(until) {
+		t.Errorf("expected the namespace to be retained until %v, got %v", until, got)
+	}
+
+	restored := Restore(retained, "67890")
+	if _, ok := RetainedUntil(restored); ok {
+		t.Errorf("expected the restored namespace not to be retained")
+	}
+	if restored.Annotations[constants.LabelUID] != "67890" {
+		t.Errorf("expected the restored namespace to be delegated to the new tenant namespace, got %s", restored.Annotations[constants.LabelUID])
+	}
+
+	retained.Annotations[constants.LabelRetainedUntil] = "tomorrow"
+	if got, ok := RetainedUntil(retained); !ok || !got.IsZero() {
+		t.Errorf("expected an unreadable retention to be expired, got %v", got)
+	}
+}
+
+func TestRetainAndAdoptObject(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cm-1",
+			Namespace:   "tenant-a-default",
+			Annotations: map[string]string{constants.LabelUID: "12345"},
+		},
+	}
+	if adopted := Adopt(cm, "67890"); adopted != nil {
+		t.Errorf("expected a configmap that is not retained not to be adopted, got %v", adopted)
+	}
+
+	retained, ok := RetainObject(cm, time.Hour).(*corev1.ConfigMap)
+	if !ok {
+		t.Fatalf("expected a retained copy of the configmap")
+	}
+	if _, ok := cm.Annotations[constants.LabelRetainedUntil]; ok {
+		t.Errorf("the original configmap must not be modified")
+	}
+	if until, ok := RetainedUntil(retained); !ok || time.Until(until) <= 0 {
+		t.Errorf("expected the configmap to be retained, got %v", until)
+	}
+	if again := RetainObject(retained, time.Hour); again != nil {
+		t.Errorf("expected a retained configmap to keep its retention, got %v", again)
+	}
+
+	adopted, ok := Adopt(retained, "67890").(*corev1.ConfigMap)
+	if !ok {
+		t.Fatalf("expected the retained configmap to be adopted")
+	}
+	if _, ok := RetainedUntil(adopted); ok {
+		t.Errorf("expected the adopted configmap not to be retained")
+	}
+	if adopted.Annotations[constants.LabelUID] != "67890" {
+		t.Errorf("expected the adopted configmap to be delegated to the new tenant object, got %s", adopted.Annotations[constants.LabelUID])
+	}
+}
+
+func TestRetainsRetainedObject(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.NamespaceRecycleBin, true)()
+
+	for _, tc := range []struct {
+		until    time.Time
+		expected bool
+	}{
+		{until: time.Now().Add(time.Hour), expected: true},
+		{until: time.Now().Add(-time.Hour), expected: false},
+	} {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:        "cm-1",
+			Namespace:   "tenant-a-default",
+			Annotations: map[string]string{constants.LabelRetainedUntil: tc.until.UTC().Format(time.RFC3339)},
+		}}
+		// the retention of the object is decided without the tenant cluster
+		retained, err := Retains(nil, cm)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if retained != tc.expected {
+			t.Errorf("expected the configmap retained until %v to be retained %v, got %v", tc.until, tc.expected, retained)
+		}
+	}
+}
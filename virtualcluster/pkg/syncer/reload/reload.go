@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reload re-reads the reloadable settings of the syncer configuration from a file, usually
+// mounted from a ConfigMap, and applies them to the running syncer, so that tuning the syncer does
+// not require a restart and a full resync of all the tenant clusters.
+package reload
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+// DefaultInterval is the default interval between two reads of the settings file.
+const DefaultInterval = 30 * time.Second
+
+// Settings are the reloadable settings of the syncer configuration. Settings that are not set
+// keep the value the syncer was started with.
+type Settings struct {
+	// FeatureGates toggles the reloadable feature gates.
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+	// TenantNodeUpdateQPS overrides SyncerConfiguration.TenantNodeUpdateQPS.
+	TenantNodeUpdateQPS *float32 `json:"tenantNodeUpdateQPS,omitempty"`
+	// TenantNodeUpdateBurst overrides SyncerConfiguration.TenantNodeUpdateBurst.
+	TenantNodeUpdateBurst *int `json:"tenantNodeUpdateBurst,omitempty"`
+	// PatrolPeriod overrides SyncerConfiguration.PatrolPeriod.
+	PatrolPeriod *metav1.Duration `json:"patrolPeriod,omitempty"`
+	// PatrolRemediationBudget overrides SyncerConfiguration.PatrolRemediationBudget.
+	PatrolRemediationBudget *int `json:"patrolRemediationBudget,omitempty"`
+}
+
+// Parse decodes the YAML or JSON settings in data and validates them.
+func Parse(data []byte) (*Settings, error) {
+	s := &Settings{}
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, err
+	}
+	for name := range s.FeatureGates {
+		if !featuregate.Reloadable(featuregate.Feature(name)) {
+			return nil, fmt.Errorf("feature gate %q is unknown or can't be changed without restarting the syncer", name)
+		}
+	}
+	if s.TenantNodeUpdateQPS != nil && *s.TenantNodeUpdateQPS < 0 {
+		return nil, fmt.Errorf("tenantNodeUpdateQPS must not be negative")
+	}
+	if s.TenantNodeUpdateBurst != nil && *s.TenantNodeUpdateBurst < 0 {
+		return nil, fmt.Errorf("tenantNodeUpdateBurst must not be negative")
+	}
+	if s.PatrolPeriod != nil && s.PatrolPeriod.Duration <= 0 {
+		return nil, fmt.Errorf("patrolPeriod must be positive")
+	}
+	if s.PatrolRemediationBudget != nil && *s.PatrolRemediationBudget < 0 {
+		return nil, fmt.Errorf("patrolRemediationBudget must not be negative")
+	}
+	return s, nil
+}
+
+// Apply returns a copy of base overridden by the settings.
+func (s *Settings) Apply(base *config.SyncerConfiguration) *config.SyncerConfiguration {
+	cfg := *base
+	if len(s.FeatureGates) > 0 {
+		cfg.FeatureGates = make(map[string]bool, len(base.FeatureGates)+len(s.FeatureGates))
+		for k, v := range base.FeatureGates {
+			cfg.FeatureGates[k] = v
+		}
+		for k, v := range s.FeatureGates {
+			cfg.FeatureGates[k] = v
+		}
+	}
+	if s.TenantNodeUpdateQPS != nil {
+		cfg.TenantNodeUpdateQPS = *s.TenantNodeUpdateQPS
+	}
+	if s.TenantNodeUpdateBurst != nil {
+		cfg.TenantNodeUpdateBurst = *s.TenantNodeUpdateBurst
+	}
+	if s.PatrolPeriod != nil {
+		cfg.PatrolPeriod = *s.PatrolPeriod
+	}
+	if s.PatrolRemediationBudget != nil {
+		cfg.PatrolRemediationBudget = *s.PatrolRemediationBudget
+	}
+	return &cfg
+}
+
+// Reloader watches the settings file and applies the reloaded configuration whenever it changes.
+type Reloader struct {
+	path  string
+	base  *config.SyncerConfiguration
+	apply func(*config.SyncerConfiguration)
+
+	// features are the values of the reloadable feature gates the syncer was started with.
+	features map[featuregate.Feature]bool
+	// last is the content of the settings file that was applied last.
+	last []byte
+}
+
+// NewReloader returns a Reloader overriding base with the settings in path and passing the
+// result to apply.
+func NewReloader(path string, base *config.SyncerConfiguration, apply func(*config.SyncerConfiguration)) *Reloader {
+	features := make(map[featuregate.Feature]bool)
+	for _, name := range featuregate.ReloadableFeatures() {
+		features[name] = featuregate.DefaultFeatureGate.Enabled(name)
+	}
+	return &Reloader{path: path, base: base, apply: apply, features: features}
+}
+
+// Run reads the settings file every interval until stopCh is closed. Mounted ConfigMaps are
+// updated by swapping symlinks, so the file content is compared instead of watching inotify events.
+func (r *Reloader) Run(interval time.Duration, stopCh <-chan struct{}) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	wait.Until(r.reload, interval, stopCh)
+}
+
+func (r *Reloader) reload() {
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		klog.Errorf("failed to read syncer settings file %s: %v", r.path, err)
+		metrics.ConfigReloadCounter.WithLabelValues("failure").Inc()
+		return
+	}
+	if r.last != nil && bytes.Equal(data, r.last) {
+		return
+	}
+	if err := r.Reload(data); err != nil {
+		klog.Errorf("failed to reload syncer settings file %s, keeping the current configuration: %v", r.path, err)
+		metrics.ConfigReloadCounter.WithLabelValues("failure").Inc()
+		// don't retry until the file changes again.
+		r.last = data
+		return
+	}
+	r.last = data
+	klog.Infof("syncer settings reloaded from %s", r.path)
+	metrics.ConfigReloadCounter.WithLabelValues("success").Inc()
+}
+
+// Reload parses data and applies the resulting configuration. An invalid content leaves the running
+// configuration untouched.
+func (r *Reloader) Reload(data []byte) error {
+	s, err := Parse(data)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range featuregate.ReloadableFeatures() {
+		enabled, ok := s.FeatureGates[string(name)]
+		if !ok {
+			enabled = r.features[name]
+		}
+		if featuregate.DefaultFeatureGate.Enabled(name) == enabled {
+			continue
+		}
+		if err := featuregate.DefaultFeatureGate.Set(name, enabled); err != nil {
+			return err
+		}
+		klog.Infof("feature gate %s set to %t", name, enabled)
+	}
+
+	r.apply(s.Apply(r.base))
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reload
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "valid settings",
+			data: "featureGates:\n  PatrolSemanticHash: true\ntenantNodeUpdateQPS: 5\ntenantNodeUpdateBurst: 10\npatrolPeriod: 2m\n",
+		},
+		{
+			name:    "gate that requires a restart",
+			data:    "featureGates:\n  SuperClusterPooling: true\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			data:    "vnAgentPort: 10551\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative qps",
+			data:    "tenantNodeUpdateQPS: -1\n",
+			wantErr: true,
+		},
+		{
+			name:    "zero patrol period",
+			data:    "patrolPeriod: 0s\n",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.data))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	defer func(gate featuregate.FeatureGate) { featuregate.DefaultFeatureGate = gate }(featuregate.DefaultFeatureGate)
+	featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(nil)
+
+	base := &config.SyncerConfiguration{
+		TenantNodeUpdateQPS:   20,
+		TenantNodeUpdateBurst: 50,
+		PatrolPeriod:          metav1.Duration{Duration: time.Minute},
+	}
+	var applied *config.SyncerConfiguration
+	r := NewReloader("", base, func(cfg *config.SyncerConfiguration) { applied = cfg })
+
+	if err := r.Reload([]byte("featureGates:\n  PatrolSemanticHash: true\ntenantNodeUpdateQPS: 5\npatrolPeriod: 2m\npatrolRemediationBudget: 100\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !featuregate.DefaultFeatureGate.Enabled(featuregate.PatrolSemanticHash) {
+		t.Errorf("expected PatrolSemanticHash to be enabled")
+	}
+	if applied.TenantNodeUpdateQPS != 5 || applied.TenantNodeUpdateBurst != 50 || applied.PatrolPeriod.Duration != 2*time.Minute || applied.PatrolRemediationBudget != 100 {
+		t.Errorf("unexpected applied configuration %+v", applied)
+	}
+	if base.TenantNodeUpdateQPS != 20 || base.PatrolPeriod.Duration != time.Minute {
+		t.Errorf("base configuration must not be modified, got %+v", base)
+	}
+
+	if err := r.Reload([]byte("featureGates:\n  SuperClusterPooling: true\n")); err == nil {
+		t.Errorf("expected an error for a gate that requires a restart")
+	}
+	if applied.TenantNodeUpdateQPS != 5 {
+		t.Errorf("an invalid content must not be applied")
+	}
+
+	// removed settings are reverted to the values the syncer was started with.
+	if err := r.Reload([]byte("{}")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.PatrolSemanticHash) {
+		t.Errorf("expected PatrolSemanticHash to be reverted")
+	}
+	if applied.TenantNodeUpdateQPS != 20 || applied.PatrolPeriod.Duration != time.Minute {
+		t.Errorf("unexpected applied configuration %+v", applied)
+	}
+}
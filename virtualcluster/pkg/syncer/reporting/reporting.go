@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reporting aggregates the resource requests, limits and usage of the super cluster
+// pods per VirtualCluster and writes them to pluggable sinks, so that platform teams get
+// chargeback data without joining against the super cluster namespace naming conventions.
+package reporting
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// DefaultInterval is the default interval between two reports.
+const DefaultInterval = 5 * time.Minute
+
+// Record is the resource consumption of a VirtualCluster at the time of a report.
+type Record struct {
+	Time        time.Time `json:"time"`
+	Cluster     string    `json:"cluster"`
+	VCNamespace string    `json:"vcNamespace"`
+	VCName      string    `json:"vcName"`
+	// Pods is the number of pending and running pods of the VirtualCluster.
+	Pods     int                 `json:"pods"`
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	Limits   corev1.ResourceList `json:"limits,omitempty"`
+	// Usage is nil if the super cluster metrics API is unavailable.
+	Usage corev1.ResourceList `json:"usage,omitempty"`
+}
+
+// Sink stores or exports the records of a report.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, records []Record) error
+}
+
+// UsageGetter returns the current resource usage of the super cluster pods.
+type UsageGetter interface {
+	// PodUsage returns the pod usage keyed by namespace/name.
+	PodUsage(ctx context.Context) (map[string]corev1.ResourceList, error)
+}
+
+// Reporter periodically aggregates the super cluster pods per VirtualCluster.
+type Reporter struct {
+	podLister listersv1.PodLister
+	usage     UsageGetter
+	sinks     []Sink
+	now       func() time.Time
+}
+
+// NewReporter returns a Reporter writing to sinks, usage can be nil to report only
+// the requests and limits.
+func NewReporter(podLister listersv1.PodLister, usage UsageGetter, sinks []Sink) *Reporter {
+	return &Reporter{
+		podLister: podLister,
+		usage:     usage,
+		sinks:     sinks,
+		now:       time.Now,
+	}
+}
+
+// Run reports every interval until stopCh is closed.
+func (r *Reporter) Run(interval time.Duration, stopCh <-chan struct{}) {
+	klog.Infof("starting usage reporter with interval %v", interval)
+	defer klog.Infof("shutting down usage reporter")
+
+	wait.Until(func() {
+		r.Report(context.TODO())
+	}, interval, stopCh)
+}
+
+// Report aggregates the super cluster pods and writes the records to all the sinks.
+func (r *Reporter) Report(ctx context.Context) {
+	records, err := r.Aggregate(ctx)
+	if err != nil {
+		klog.Errorf("failed to aggregate virtual cluster usage: %v", err)
+		return
+	}
+	for _, sink := range r.sinks {
+		if err := sink.Write(ctx, records); err != nil {
+			klog.Errorf("failed to write virtual cluster usage to sink %s: %v", sink.Name(), err)
+		}
+	}
+}
+
+// Aggregate returns one record per VirtualCluster owning pending or running pods in the
+// super cluster, sorted by cluster.
+func (r *Reporter) Aggregate(ctx context.Context) ([]Record, error) {
+	pods, err := r.podLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var usage map[string]corev1.ResourceList
+	if r.usage != nil {
+		usage, err = r.usage.PodUsage(ctx)
+		if err != nil {
+			// usage is optional, requests and limits are still worth reporting.
+			klog.Warningf("failed to get pod usage from super cluster metrics api: %v", err)
+			usage = nil
+		}
+	}
+
+	now := r.now()
+	byCluster := make(map[string]*Record)
+	for _, pod := range pods {
+		cluster := pod.GetAnnotations()[constants.LabelCluster]
+		if cluster == "" {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		record, ok := byCluster[cluster]
+		if !ok {
+			record = &Record{
+				Time:        now,
+				Cluster:     cluster,
+				VCNamespace: pod.GetAnnotations()[constants.LabelVCNamespace],
+				VCName:      pod.GetAnnotations()[constants.LabelVCName],
+				Requests:    corev1.ResourceList{},
+				Limits:      corev1.ResourceList{},
+			}
+			if usage != nil {
+				record.Usage = corev1.ResourceList{}
+			}
+			byCluster[cluster] = record
+		}
+		record.Pods++
+		requests, limits := podRequestsAndLimits(pod)
+		addResourceList(record.Requests, requests)
+		addResourceList(record.Limits, limits)
+		if usage != nil {
+			addResourceList(record.Usage, usage[pod.Namespace+"/"+pod.Name])
+		}
+	}
+
+	records := make([]Record, 0, len(byCluster))
+	for _, record := range byCluster {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Cluster < records[j].Cluster
+	})
+	return records, nil
+}
+
+// podRequestsAndLimits returns the effective requests and limits of a pod, which is the
+// larger one of the sum of the containers and any init container, plus the pod overhead.
+func podRequestsAndLimits(pod *corev1.Pod) (corev1.ResourceList, corev1.ResourceList) {
+	requests, limits := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		addResourceList(requests, c.Resources.Requests)
+		addResourceList(limits, c.Resources.Limits)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		maxResourceList(requests, c.Resources.Requests)
+		maxResourceList(limits, c.Resources.Limits)
+	}
+	addResourceList(requests, pod.Spec.Overhead)
+	addResourceList(limits, pod.Spec.Overhead)
+	return requests, limits
+}
+
+func addResourceList(list, add corev1.ResourceList) {
+	for name, quantity := range add {
+		if value, ok := list[name]; ok {
+			value.Add(quantity)
+			list[name] = value
+		} else {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+func maxResourceList(list, other corev1.ResourceList) {
+	for name, quantity := range other {
+		if value, ok := list[name]; !ok || quantity.Cmp(value) > 0 {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+}
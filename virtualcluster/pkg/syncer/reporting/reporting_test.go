@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+type fakeUsage struct {
+	usage map[string]corev1.ResourceList
+	err   error
+}
+
+func (f *fakeUsage) PodUsage(context.Context) (map[string]corev1.ResourceList, error) {
+	return f.usage, f.err
+}
+
+type fakeSink struct {
+	records []Record
+}
+
+func (f *fakeSink) Name() string {
+	return "fake"
+}
+
+func (f *fakeSink) Write(_ context.Context, records []Record) error {
+	f.records = records
+	return nil
+}
+
+func resources(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+func tenantPod(namespace, name, cluster string, phase corev1.PodPhase, requests, limits corev1.ResourceList) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:      "c",
+				Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+	if cluster != "" {
+		pod.Annotations = map[string]string{
+			constants.LabelCluster:     cluster,
+			constants.LabelVCNamespace: "tenant-" + cluster,
+			constants.LabelVCName:      cluster,
+		}
+	}
+	return pod
+}
+
+func newTestReporter(t *testing.T, usage UsageGetter, sinks []Sink, pods ...*corev1.Pod) *Reporter {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, pod := range pods {
+		if err := indexer.Add(pod); err != nil {
+			t.Fatalf("failed to add pod: %v", err)
+		}
+	}
+	r := NewReporter(listersv1.NewPodLister(indexer), usage, sinks)
+	r.now = func() time.Time { return time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return r
+}
+
+func TestAggregate(t *testing.T) {
+	initPod := tenantPod("a-ns", "init", "a", corev1.PodPending, resources("100m", "64Mi"), nil)
+	initPod.Spec.InitContainers = []corev1.Container{{
+		Name:      "init",
+		Resources: corev1.ResourceRequirements{Requests: resources("1", "32Mi")},
+	}}
+	pods := []*corev1.Pod{
+		tenantPod("a-ns", "p1", "a", corev1.PodRunning, resources("500m", "128Mi"), resources("1", "256Mi")),
+		tenantPod("a-ns", "p2", "a", corev1.PodRunning, resources("250m", "128Mi"), nil),
+		tenantPod("a-ns", "done", "a", corev1.PodSucceeded, resources("4", "4Gi"), nil),
+		initPod,
+		tenantPod("b-ns", "p1", "b", corev1.PodRunning, resources("1", "1Gi"), nil),
+		tenantPod("kube-system", "super", "", corev1.PodRunning, resources("1", "1Gi"), nil),
+	}
+	usage := &fakeUsage{usage: map[string]corev1.ResourceList{
+		"a-ns/p1": resources("300m", "100Mi"),
+		"a-ns/p2": resources("100m", "50Mi"),
+		"b-ns/p1": resources("10m", "10Mi"),
+	}}
+
+	records, err := newTestReporter(t, usage, nil, pods...).Aggregate(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d: %+v", len(records), records)
+	}
+
+	a := records[0]
+	if a.Cluster != "a" || a.VCNamespace != "tenant-a" || a.VCName != "a" || a.Pods != 3 {
+		t.Errorf("unexpected record of cluster a: %+v", a)
+	}
+	// the init container requests 1 cpu which is more than the 100m of its pod containers.
+	expectQuantity(t, a.Requests, corev1.ResourceCPU, "1750m")
+	expectQuantity(t, a.Requests, corev1.ResourceMemory, "320Mi")
+	expectQuantity(t, a.Limits, corev1.ResourceCPU, "1")
+	expectQuantity(t, a.Usage, corev1.ResourceCPU, "400m")
+	expectQuantity(t, a.Usage, corev1.ResourceMemory, "150Mi")
+
+	b := records[1]
+	if b.Cluster != "b" || b.Pods != 1 {
+		t.Errorf("unexpected record of cluster b: %+v", b)
+	}
+	expectQuantity(t, b.Usage, corev1.ResourceCPU, "10m")
+}
+
+func TestReportWithoutMetricsAPI(t *testing.T) {
+	sink := &fakeSink{}
+	r := newTestReporter(t, &fakeUsage{err: errors.New("the server could not find the requested resource")}, []Sink{sink},
+		tenantPod("a-ns", "p1", "a", corev1.PodRunning, resources("500m", "128Mi"), nil))
+
+	r.Report(context.TODO())
+	if len(sink.records) != 1 {
+		t.Fatalf("expected 1 record, got %+v", sink.records)
+	}
+	if sink.records[0].Usage != nil {
+		t.Errorf("expected no usage, got %v", sink.records[0].Usage)
+	}
+	expectQuantity(t, sink.records[0].Requests, corev1.ResourceCPU, "500m")
+}
+
+func TestDecodePodUsage(t *testing.T) {
+	data := []byte(`{"kind":"PodMetricsList","apiVersion":"metrics.k8s.io/v1beta1","items":[
+		{"metadata":{"name":"p1","namespace":"a-ns"},"containers":[
+			{"name":"c1","usage":{"cpu":"100m","memory":"10Mi"}},
+			{"name":"c2","usage":{"cpu":"50m","memory":"20Mi"}}]}]}`)
+	usage, err := decodePodUsage(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectQuantity(t, usage["a-ns/p1"], corev1.ResourceCPU, "150m")
+	expectQuantity(t, usage["a-ns/p1"], corev1.ResourceMemory, "30Mi")
+}
+
+func expectQuantity(t *testing.T, list corev1.ResourceList, name corev1.ResourceName, expected string) {
+	t.Helper()
+	quantity, ok := list[name]
+	if !ok {
+		t.Errorf("expected %s %s, got none", name, expected)
+		return
+	}
+	if quantity.Cmp(resource.MustParse(expected)) != 0 {
+		t.Errorf("expected %s %s, got %s", name, expected, quantity.String())
+	}
+}
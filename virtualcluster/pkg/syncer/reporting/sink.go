@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+const (
+	// PrometheusSinkName exports the last report as the syncer_virtual_cluster_resource_usage gauge.
+	PrometheusSinkName = "prometheus"
+	// CSVSinkName appends the reports to a csv file, e.g. csv=/var/lib/vc-usage/usage.csv on a PVC.
+	CSVSinkName = "csv"
+	// WebhookSinkName posts the reports as json, e.g. webhook=https://billing.example.com/usage.
+	WebhookSinkName = "webhook"
+)
+
+// ParseSinks creates the sinks from specs of the form <name>[=<argument>].
+func ParseSinks(specs []string) ([]Sink, error) {
+	var sinks []Sink
+	for _, spec := range specs {
+		name, arg := spec, ""
+		if i := strings.Index(spec, "="); i >= 0 {
+			name, arg = spec[:i], spec[i+1:]
+		}
+		switch name {
+		case PrometheusSinkName:
+			sinks = append(sinks, NewPrometheusSink())
+		case CSVSinkName:
+			if arg == "" {
+				return nil, fmt.Errorf("reporting sink %q requires a file path", spec)
+			}
+			sinks = append(sinks, NewCSVSink(arg))
+		case WebhookSinkName:
+			if arg == "" {
+				return nil, fmt.Errorf("reporting sink %q requires a url", spec)
+			}
+			sinks = append(sinks, NewWebhookSink(arg))
+		default:
+			return nil, fmt.Errorf("unknown reporting sink %q", spec)
+		}
+	}
+	return sinks, nil
+}
+
+type prometheusSink struct{}
+
+// NewPrometheusSink returns a Sink setting the syncer virtual cluster usage gauge.
+func NewPrometheusSink() Sink {
+	return &prometheusSink{}
+}
+
+func (p *prometheusSink) Name() string {
+	return PrometheusSinkName
+}
+
+func (p *prometheusSink) Write(_ context.Context, records []Record) error {
+	// Reset so that the deleted virtual clusters are not reported forever.
+	metrics.VirtualClusterUsage.Reset()
+	for _, r := range records {
+		metrics.VirtualClusterUsage.WithLabelValues(r.VCNamespace, r.VCName, string(corev1.ResourcePods), "count").Set(float64(r.Pods))
+		for typ, list := range map[string]corev1.ResourceList{"requests": r.Requests, "limits": r.Limits, "usage": r.Usage} {
+			for name, quantity := range list {
+				metrics.VirtualClusterUsage.WithLabelValues(r.VCNamespace, r.VCName, string(name), typ).Set(quantity.AsApproximateFloat64())
+			}
+		}
+	}
+	return nil
+}
+
+// csvHeader is the first line of a new csv file.
+var csvHeader = []string{"time", "cluster", "vc_namespace", "vc_name", "pods",
+	"cpu_requests", "memory_requests", "cpu_limits", "memory_limits", "cpu_usage", "memory_usage"}
+
+type csvSink struct {
+	path string
+}
+
+// NewCSVSink returns a Sink appending one row per record to the file at path.
+func NewCSVSink(path string) Sink {
+	return &csvSink{path: path}
+}
+
+func (c *csvSink) Name() string {
+	return CSVSinkName
+}
+
+func (c *csvSink) Write(_ context.Context, records []Record) error {
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if info.Size() == 0 {
+		if err := w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	for _, r := range records {
+		row := []string{r.Time.UTC().Format(time.RFC3339), r.Cluster, r.VCNamespace, r.VCName, strconv.Itoa(r.Pods),
+			quantityString(r.Requests, corev1.ResourceCPU), quantityString(r.Requests, corev1.ResourceMemory),
+			quantityString(r.Limits, corev1.ResourceCPU), quantityString(r.Limits, corev1.ResourceMemory),
+			quantityString(r.Usage, corev1.ResourceCPU), quantityString(r.Usage, corev1.ResourceMemory)}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func quantityString(list corev1.ResourceList, name corev1.ResourceName) string {
+	if quantity, ok := list[name]; ok {
+		return quantity.String()
+	}
+	return ""
+}
+
+// webhookPayload is the body posted by the webhook sink.
+type webhookPayload struct {
+	Records []Record `json:"records"`
+}
+
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a Sink posting the records as json to url.
+func NewWebhookSink(url string) Sink {
+	return &webhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (w *webhookSink) Name() string {
+	return WebhookSinkName
+}
+
+func (w *webhookSink) Write(ctx context.Context, records []Record) error {
+	body, err := json.Marshal(webhookPayload{Records: records})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", w.url, resp.StatusCode)
+	}
+	return nil
+}
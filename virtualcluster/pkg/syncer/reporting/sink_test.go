@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+var testRecords = []Record{{
+	Time:        time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	Cluster:     "a",
+	VCNamespace: "tenant-a",
+	VCName:      "a",
+	Pods:        2,
+	Requests:    resources("750m", "256Mi"),
+	Limits:      resources("1", "256Mi"),
+}}
+
+func TestParseSinks(t *testing.T) {
+	for _, tc := range []struct {
+		specs   []string
+		names   []string
+		wantErr bool
+	}{
+		{specs: nil},
+		{specs: []string{"prometheus", "csv=/data/usage.csv", "webhook=https://billing/usage"}, names: []string{"prometheus", "csv", "webhook"}},
+		{specs: []string{"csv"}, wantErr: true},
+		{specs: []string{"webhook="}, wantErr: true},
+		{specs: []string{"s3=bucket"}, wantErr: true},
+	} {
+		sinks, err := ParseSinks(tc.specs)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%v: expected error %v, got %v", tc.specs, tc.wantErr, err)
+			continue
+		}
+		var names []string
+		for _, s := range sinks {
+			names = append(names, s.Name())
+		}
+		if strings.Join(names, ",") != strings.Join(tc.names, ",") {
+			t.Errorf("%v: expected sinks %v, got %v", tc.specs, tc.names, names)
+		}
+	}
+}
+
+func TestPrometheusSink(t *testing.T) {
+	if err := NewPrometheusSink().Write(context.TODO(), testRecords); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := testutil.ToFloat64(metrics.VirtualClusterUsage.WithLabelValues("tenant-a", "a", "cpu", "requests")); v != 0.75 {
+		t.Errorf("expected cpu requests 0.75, got %v", v)
+	}
+	if v := testutil.ToFloat64(metrics.VirtualClusterUsage.WithLabelValues("tenant-a", "a", "pods", "count")); v != 2 {
+		t.Errorf("expected 2 pods, got %v", v)
+	}
+
+	// deleted virtual clusters are no longer reported.
+	if err := NewPrometheusSink().Write(context.TODO(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := testutil.CollectAndCount(metrics.VirtualClusterUsage); n != 0 {
+		t.Errorf("expected no series, got %d", n)
+	}
+}
+
+func TestCSVSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink := NewCSVSink(filepath.Join(dir, "usage.csv"))
+	for i := 0; i < 2; i++ {
+		if err := sink.Write(context.TODO(), testRecords); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "usage.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "time,cluster,") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if expected := "2022-01-01T00:00:00Z,a,tenant-a,a,2,750m,256Mi,1,256Mi,,"; lines[1] != expected {
+		t.Errorf("expected row %q, got %q", expected, lines[1])
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewWebhookSink(server.URL).Write(context.TODO(), testRecords); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Records) != 1 || got.Records[0].Cluster != "a" || got.Records[0].Pods != 2 {
+		t.Errorf("unexpected payload %+v", got)
+	}
+	expectQuantity(t, got.Records[0].Requests, "cpu", "750m")
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := NewWebhookSink(failing.URL).Write(context.TODO(), testRecords); err == nil {
+		t.Errorf("expected error from failing webhook")
+	}
+}
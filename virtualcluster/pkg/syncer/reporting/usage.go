@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// metricsAPIPodsPath is the path of the pod metrics served by metrics-server.
+const metricsAPIPodsPath = "/apis/metrics.k8s.io/v1beta1/pods"
+
+// podMetricsList is the subset of metrics.k8s.io/v1beta1 PodMetricsList the reporter needs.
+type podMetricsList struct {
+	Items []podMetrics `json:"items"`
+}
+
+type podMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Containers        []containerMetrics `json:"containers"`
+}
+
+type containerMetrics struct {
+	Name  string              `json:"name"`
+	Usage corev1.ResourceList `json:"usage"`
+}
+
+type metricsAPIUsage struct {
+	client rest.Interface
+}
+
+var _ UsageGetter = &metricsAPIUsage{}
+
+// NewMetricsAPIUsage returns a UsageGetter reading the pod metrics of the super cluster
+// metrics API through client, e.g. the discovery rest client of the super cluster.
+func NewMetricsAPIUsage(client rest.Interface) UsageGetter {
+	return &metricsAPIUsage{client: client}
+}
+
+func (m *metricsAPIUsage) PodUsage(ctx context.Context) (map[string]corev1.ResourceList, error) {
+	data, err := m.client.Get().AbsPath(metricsAPIPodsPath).DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decodePodUsage(data)
+}
+
+func decodePodUsage(data []byte) (map[string]corev1.ResourceList, error) {
+	list := &podMetricsList{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, err
+	}
+	usage := make(map[string]corev1.ResourceList, len(list.Items))
+	for _, item := range list.Items {
+		podUsage := corev1.ResourceList{}
+		for _, c := range item.Containers {
+			addResourceList(podUsage, c.Usage)
+		}
+		usage[item.Namespace+"/"+item.Name] = podUsage
+	}
+	return usage, nil
+}
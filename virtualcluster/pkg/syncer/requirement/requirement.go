@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package requirement checks the prerequisites a super cluster must meet to sync a virtual cluster,
+// e.g. its version, its storage classes or the labels advertising the CNI of its nodes, so that a
+// virtual cluster is not synced to a super cluster on which its workloads can't run.
+package requirement
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/version"
+	clientset "k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// ReasonUnsupportedVersion is the reason of a super cluster older than the minimum version.
+	ReasonUnsupportedVersion = "UnsupportedVersion"
+	// ReasonMissingStorageClasses is the reason of a super cluster without the required storage classes.
+	ReasonMissingStorageClasses = "MissingStorageClasses"
+	// ReasonNoMatchingNodes is the reason of a super cluster without a schedulable node with the
+	// required labels and tolerated taints.
+	ReasonNoMatchingNodes = "NoMatchingNodes"
+)
+
+// Failure is a requirement the super cluster doesn't meet.
+type Failure struct {
+	Reason  string
+	Message string
+}
+
+// Resolve merges the super cluster requirements of the VirtualCluster into the ones of its
+// ClusterVersion: the minimum version of the VirtualCluster overrides the one of the ClusterVersion,
+// its node selector labels take precedence, and its tolerations and storage classes are appended.
+// cv may be nil, e.g. when the ClusterVersion was deleted. It returns nil without requirements.
+func Resolve(cv *v1alpha1.ClusterVersion, vc *v1alpha1.VirtualCluster) *v1alpha1.SuperClusterRequirements {
+	var base, override *v1alpha1.SuperClusterRequirements
+	if cv != nil {
+		base = cv.Spec.SuperClusterRequirements
+	}
+	if vc != nil {
+		override = vc.Spec.SuperClusterRequirements
+	}
+	switch {
+	case base == nil && override == nil:
+		return nil
+	case base == nil:
+		return override.DeepCopy()
+	case override == nil:
+		return base.DeepCopy()
+	}
+
+	req := base.DeepCopy()
+	if override.MinVersion != "" {
+		req.MinVersion = override.MinVersion
+	}
+	if len(override.NodeSelector) > 0 && req.NodeSelector == nil {
+		req.NodeSelector = make(map[string]string, len(override.NodeSelector))
+	}
+	for k, v := range override.NodeSelector {
+		req.NodeSelector[k] = v
+	}
+	for _, t := range override.Tolerations {
+		req.Tolerations = append(req.Tolerations, *t.DeepCopy())
+	}
+	req.StorageClasses = append(req.StorageClasses, override.StorageClasses...)
+	return req
+}
+
+// Check returns the requirements the super cluster doesn't meet, in the order of the version, the
+// storage classes and the nodes. An error is returned if the super cluster can't be inspected.
+func Check(ctx context.Context, client clientset.Interface, req *v1alpha1.SuperClusterRequirements) ([]Failure, error) {
+	if req == nil {
+		return nil, nil
+	}
+	var failures []Failure
+
+	if req.MinVersion != "" {
+		f, err := checkVersion(client, req.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			failures = append(failures, *f)
+		}
+	}
+
+	if len(req.StorageClasses) > 0 {
+		f, err := checkStorageClasses(ctx, client, req.StorageClasses)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			failures = append(failures, *f)
+		}
+	}
+
+	if len(req.NodeSelector) > 0 || len(req.Tolerations) > 0 {
+		f, err := checkNodes(ctx, client, req.NodeSelector, req.Tolerations)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			failures = append(failures, *f)
+		}
+	}
+	return failures, nil
+}
+
+// Message joins the messages of the failures.
+func Message(failures []Failure) string {
+	messages := make([]string, 0, len(failures))
+	for _, f := range failures {
+		messages = append(messages, f.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func checkVersion(client clientset.Interface, minVersion string) (*Failure, error) {
+	min, err := version.ParseGeneric(minVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minimum version %q: %v", minVersion, err)
+	}
+	info, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	current, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid super cluster version %q: %v", info.GitVersion, err)
+	}
+	if current.AtLeast(min) {
+		return nil, nil
+	}
+	return &Failure{
+		Reason:  ReasonUnsupportedVersion,
+		Message: fmt.Sprintf("the super cluster version %s is older than %s", info.GitVersion, minVersion),
+	}, nil
+}
+
+func checkStorageClasses(ctx context.Context, client clientset.Interface, names []string) (*Failure, error) {
+	var missing []string
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		_, err := client.StorageV1().StorageClasses().Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			missing = append(missing, name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	sort.Strings(missing)
+	return &Failure{
+		Reason:  ReasonMissingStorageClasses,
+		Message: fmt.Sprintf("the super cluster has no storage classes %s", strings.Join(missing, ", ")),
+	}, nil
+}
+
+func checkNodes(ctx context.Context, client clientset.Interface, selector map[string]string, tolerations []corev1.Toleration) (*Failure, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()})
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes.Items {
+		if schedulable(&nodes.Items[i], tolerations) {
+			return nil, nil
+		}
+	}
+	message := "the super cluster has no schedulable node"
+	if len(selector) > 0 {
+		message = fmt.Sprintf("%s with the labels %s", message, labels.SelectorFromSet(selector).String())
+	}
+	if len(tolerations) > 0 {
+		message += " whose taints are tolerated"
+	}
+	return &Failure{Reason: ReasonNoMatchingNodes, Message: message}, nil
+}
+
+// schedulable returns true if the node is not cordoned and tolerations tolerate all its
+// NoSchedule and NoExecute taints.
+func schedulable(node *corev1.Node, tolerations []corev1.Toleration) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requirement
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestResolve(t *testing.T) {
+	cv := &v1alpha1.ClusterVersion{Spec: v1alpha1.ClusterVersionSpec{SuperClusterRequirements: &v1alpha1.SuperClusterRequirements{
+		MinVersion:     "v1.20.0",
+		NodeSelector:   map[string]string{"cni": "calico", "zone": "a"},
+		Tolerations:    []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+		StorageClasses: []string{"standard"},
+	}}}
+	vc := &v1alpha1.VirtualCluster{Spec: v1alpha1.VirtualClusterSpec{SuperClusterRequirements: &v1alpha1.SuperClusterRequirements{
+		MinVersion:     "v1.22",
+		NodeSelector:   map[string]string{"cni": "cilium"},
+		Tolerations:    []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}},
+		StorageClasses: []string{"fast"},
+	}}}
+
+	if req := Resolve(nil, &v1alpha1.VirtualCluster{}); req != nil {
+		t.Errorf("expected no requirements, got %+v", req)
+	}
+	if req := Resolve(nil, vc); !reflect.DeepEqual(req, vc.Spec.SuperClusterRequirements) {
+		t.Errorf("expected the requirements of the VirtualCluster, got %+v", req)
+	}
+	if req := Resolve(cv, &v1alpha1.VirtualCluster{}); !reflect.DeepEqual(req, cv.Spec.SuperClusterRequirements) {
+		t.Errorf("expected the requirements of the ClusterVersion, got %+v", req)
+	}
+
+	expect := &v1alpha1.SuperClusterRequirements{
+		MinVersion:   "v1.22",
+		NodeSelector: map[string]string{"cni": "cilium", "zone": "a"},
+		Tolerations: []corev1.Toleration{
+			{Key: "dedicated", Operator: corev1.TolerationOpExists},
+			{Key: "gpu", Operator: corev1.TolerationOpExists},
+		},
+		StorageClasses: []string{"standard", "fast"},
+	}
+	if req := Resolve(cv, vc); !reflect.DeepEqual(req, expect) {
+		t.Errorf("expected %+v, got %+v", expect, req)
+	}
+	if cv.Spec.SuperClusterRequirements.NodeSelector["cni"] != "calico" || len(cv.Spec.SuperClusterRequirements.StorageClasses) != 1 {
+		t.Errorf("the requirements of the ClusterVersion are modified: %+v", cv.Spec.SuperClusterRequirements)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cordoned", Labels: map[string]string{"cni": "cilium"}},
+			Spec:       corev1.NodeSpec{Unschedulable: true},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu", Labels: map[string]string{"cni": "calico"}},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+				{Key: "gpu", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+			}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		req     *v1alpha1.SuperClusterRequirements
+		reasons []string
+	}{
+		{
+			name: "no requirements",
+		},
+		{
+			name: "all met",
+			req: &v1alpha1.SuperClusterRequirements{
+				MinVersion:     "v1.21",
+				NodeSelector:   map[string]string{"cni": "calico"},
+				Tolerations:    []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}},
+				StorageClasses: []string{"standard"},
+			},
+		},
+		{
+			name:    "old version",
+			req:     &v1alpha1.SuperClusterRequirements{MinVersion: "v1.22.0"},
+			reasons: []string{ReasonUnsupportedVersion},
+		},
+		{
+			name:    "missing storage class",
+			req:     &v1alpha1.SuperClusterRequirements{StorageClasses: []string{"standard", "fast"}},
+			reasons: []string{ReasonMissingStorageClasses},
+		},
+		{
+			name:    "cordoned node",
+			req:     &v1alpha1.SuperClusterRequirements{NodeSelector: map[string]string{"cni": "cilium"}},
+			reasons: []string{ReasonNoMatchingNodes},
+		},
+		{
+			name:    "untolerated taint",
+			req:     &v1alpha1.SuperClusterRequirements{NodeSelector: map[string]string{"cni": "calico"}},
+			reasons: []string{ReasonNoMatchingNodes},
+		},
+		{
+			name: "all unmet",
+			req: &v1alpha1.SuperClusterRequirements{
+				MinVersion:     "v1.23",
+				NodeSelector:   map[string]string{"cni": "flannel"},
+				StorageClasses: []string{"fast"},
+			},
+			reasons: []string{ReasonUnsupportedVersion, ReasonMissingStorageClasses, ReasonNoMatchingNodes},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}},
+				&nodes[0], &nodes[1],
+			)
+			client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.21.9"}
+
+			failures, err := Check(context.TODO(), client, tc.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var reasons []string
+			for _, f := range failures {
+				reasons = append(reasons, f.Reason)
+			}
+			if !reflect.DeepEqual(reasons, tc.reasons) {
+				t.Errorf("expected the reasons %v, got %v: %s", tc.reasons, reasons, Message(failures))
+			}
+		})
+	}
+}
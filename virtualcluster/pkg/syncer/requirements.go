@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/requirement"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+)
+
+// superClusterRequirementsRetryPeriod is the period at which the requirements of a cluster that is
+// not synced are checked again, e.g. until the missing storage class is created.
+const superClusterRequirementsRetryPeriod = time.Minute
+
+// checkSuperClusterRequirements checks the super cluster requirements of the VirtualCluster and of
+// its ClusterVersion, and records them in the SuperClusterNotReadyCondition of the VirtualCluster.
+// It returns false if the super cluster doesn't meet them.
+func (s *Syncer) checkSuperClusterRequirements(vc *v1alpha1.VirtualCluster) (bool, error) {
+	var cv *v1alpha1.ClusterVersion
+	if vc.Spec.ClusterVersionName != "" {
+		var err error
+		cv, err = s.vcClient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			// only the requirements of the VirtualCluster apply
+			cv = nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+
+	req := requirement.Resolve(cv, vc)
+	if req == nil {
+		return true, nil
+	}
+	failures, err := requirement.Check(context.TODO(), s.superClient, req)
+	if err != nil {
+		return false, err
+	}
+
+	cond := v1alpha1.ClusterCondition{
+		Type:   v1alpha1.SuperClusterNotReadyCondition,
+		Status: corev1.ConditionFalse,
+		Reason: "RequirementsMet",
+	}
+	if len(failures) > 0 {
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = failures[0].Reason
+		cond.Message = requirement.Message(failures)
+		s.recordClusterError(vcReference(vc.Namespace, vc.Name, vc.UID), errors.NewUserError("%s", cond.Message),
+			"VirtualCluster %s/%s is not synced: %s", vc.Namespace, vc.Name, cond.Message)
+	}
+	if s.isLeading() {
+		if err := s.updateVirtualClusterCondition(vc.Namespace, vc.Name, cond); err != nil {
+			klog.Errorf("failed to set the %s condition of VirtualCluster %s/%s: %v", v1alpha1.SuperClusterNotReadyCondition, vc.Namespace, vc.Name, err)
+		}
+	}
+	return len(failures) == 0, nil
+}
@@ -31,7 +31,9 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/ondemand"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 )
@@ -87,8 +89,21 @@ func (c *controller) PatrollerDo() {
 			knownClusterSet.Delete(cluster)
 			continue
 		}
+		var referenced sets.String
+		if ondemand.Enabled() {
+			referenced, err = ondemand.ReferencedObjects(c.MultiClusterController, cluster, ondemand.ConfigMaps)
+			if err != nil {
+				klog.Errorf("error listing pods from cluster %s informer cache: %v", cluster, err)
+				knownClusterSet.Delete(cluster)
+				continue
+			}
+		}
 
 		for i := range cmList.Items {
+			// the configmaps no pod references are left out, so that their copies are deleted
+			if referenced != nil && !referenced.Has(cmList.Items[i].Namespace+"/"+cmList.Items[i].Name) {
+				continue
+			}
 			vSet.Insert(differ.ClusterObject{
 				Object:       &cmList.Items[i],
 				OwnerCluster: cluster,
@@ -114,18 +129,38 @@ func (c *controller) PatrollerDo() {
 			configMapDiffer.OnDelete(pObj)
 			return
 		}
+		if c.FinalizerTranslator().Expired(pCM) {
+			if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
+				klog.Errorf("error requeue vConfigMap %v/%v in cluster %s: %v", vObj.GetNamespace(), vObj.GetName(), vObj.GetOwnerCluster(), err)
+			} else {
+				metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantConfigMaps").Inc()
+			}
+			return
+		}
 		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, vObj.GetOwnerCluster())
 		if err != nil {
 			klog.Errorf("fail to get cluster spec : %s", vObj.GetOwnerCluster())
 			return
 		}
+		if conversion.PatrolSkipsEquality(pCM, vCM) {
+			return
+		}
 		updated := conversion.Equality(c.Config, vc).CheckConfigMapEquality(pCM, vCM)
 		if updated != nil {
 			atomic.AddUint64(&numMissMatchedConfigMaps, 1)
 			klog.Warningf("ConfigMap %s diff in super&tenant control plane", pObj.Key)
+		} else {
+			conversion.RecordPatrolEquality(pCM)
 		}
 	}
 	configMapDiffer.DeleteFunc = func(pObj differ.ClusterObject) {
+		if err := conversion.VerifyOwnership(pObj); err != nil {
+			klog.Errorf("refusing to delete pConfigMap %s in super control plane: %v", pObj.Key, err)
+			return
+		}
+		if retained, err := recyclebin.Retains(c.MultiClusterController, pObj); err != nil || retained {
+			return
+		}
 		_, pName := conversion.GetConfigMapName(pObj.GetName())
 		deleteOptions := &metav1.DeleteOptions{}
 		deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pObj.GetUID()))
@@ -137,7 +172,7 @@ func (c *controller) PatrollerDo() {
 	}
 
 	vSet.Difference(pSet, differ.FilteringHandler{
-		Handler:    configMapDiffer,
+		Handler:    differ.FreezeHandler{Frozen: c.IsFrozen, Handler: differ.BudgetHandler{Budget: c.RemediationBudget(), Handler: configMapDiffer}},
 		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
 	})
 
@@ -28,7 +28,10 @@ import (
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/ondemand"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
 )
@@ -71,6 +74,7 @@ func NewConfigMapController(config *config.SyncerConfiguration,
 	}
 
 	c.configMapLister = informer.Core().V1().ConfigMaps().Lister()
+	objectlimit.RegisterIndexer("configmaps", informer.Core().V1().ConfigMaps().Informer().GetIndexer())
 	if options.IsFake {
 		c.configMapSynced = func() bool { return true }
 	} else {
@@ -84,3 +88,13 @@ func NewConfigMapController(config *config.SyncerConfiguration,
 
 	return c, nil
 }
+
+// GetListener watches the tenant pods along with the configmaps when only the configmaps referenced by the
+// pods are synced, the configmaps are requeued when the pods referencing them change.
+func (c *controller) GetListener() listener.ClusterChangeListener {
+	l := c.BaseResourceSyncer.GetListener()
+	if !ondemand.Enabled() {
+		return l
+	}
+	return ondemand.NewListener(l, c.MultiClusterController, ondemand.ConfigMaps)
+}
@@ -28,6 +28,9 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/ondemand"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
@@ -62,6 +65,14 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 		}
 		vExists = false
 	}
+	if vExists && ondemand.Enabled() {
+		// the super control plane copy of a configmap no pod references is deleted
+		referenced, err := ondemand.Referenced(c.MultiClusterController, request.ClusterName, request.Namespace, vName, ondemand.ConfigMaps)
+		if err != nil {
+			return reconciler.Result{Requeue: true}, err
+		}
+		vExists = referenced
+	}
 
 	switch {
 	case vExists && !pExists:
@@ -92,12 +103,13 @@ func (c *controller) reconcileConfigMapCreate(clusterName, targetName, targetNam
 	// This supports setting a different name between tenant and super
 	configMap.SetName(targetName)
 
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, configMap)
+	pConfigMap, err := conversion.BuildSuperClusterConfigMap(c.Conversion(), clusterName, configMap)
 	if err != nil {
 		return err
 	}
+	c.FinalizerTranslator().Apply(configMap, pConfigMap)
 
-	pConfigMap, err := c.configMapClient.ConfigMaps(targetNamespace).Create(context.TODO(), newObj.(*corev1.ConfigMap), metav1.CreateOptions{})
+	pConfigMap, err = c.configMapClient.ConfigMaps(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pConfigMap, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		if pConfigMap.Annotations[constants.LabelUID] == requestUID {
 			klog.Infof("configmap %s/%s of cluster %s already exist in super control plane", targetNamespace, configMap.Name, clusterName)
@@ -110,7 +122,20 @@ func (c *controller) reconcileConfigMapCreate(clusterName, targetName, targetNam
 
 func (c *controller) reconcileConfigMapUpdate(clusterName, targetNamespace, requestUID string, pConfigMap, vConfigMap *corev1.ConfigMap) error {
 	if pConfigMap.Annotations[constants.LabelUID] != requestUID {
-		return fmt.Errorf("pConfigMap %s/%s delegated UID is different from updated object", targetNamespace, pConfigMap.Name)
+		// the tenant object is recreated in the restored namespace, it adopts the retained object
+		adopted, _ := recyclebin.Adopt(pConfigMap, requestUID).(*corev1.ConfigMap)
+		if adopted == nil {
+			return fmt.Errorf("pConfigMap %s/%s delegated UID is different from updated object", targetNamespace, pConfigMap.Name)
+		}
+		if err := conversion.VerifyOwnership(pConfigMap); err != nil {
+			return err
+		}
+		adopted, err := c.configMapClient.ConfigMaps(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), adopted, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		klog.Infof("configmap %s/%s of cluster %s adopted the retained object in super control plane", targetNamespace, pConfigMap.Name, clusterName)
+		pConfigMap = adopted
 	}
 	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
 	if err != nil {
@@ -118,7 +143,30 @@ func (c *controller) reconcileConfigMapUpdate(clusterName, targetNamespace, requ
 	}
 	updatedConfigMap := conversion.Equality(c.Config, vc).CheckConfigMapEquality(pConfigMap, vConfigMap)
 	if updatedConfigMap != nil {
-		_, err = c.configMapClient.ConfigMaps(targetNamespace).Update(context.TODO(), updatedConfigMap, metav1.UpdateOptions{})
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vConfigMap, updatedConfigMap); err != nil {
+			return err
+		}
+		changed, err := c.ConflictResolver().Resolve(clusterName, vConfigMap, pConfigMap, updatedConfigMap)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			updatedConfigMap = nil
+		}
+	}
+	if hash, equal := conversion.CheckSemanticHash(pConfigMap, vConfigMap); hash != "" && !equal {
+		if updatedConfigMap == nil {
+			updatedConfigMap = pConfigMap.DeepCopy()
+		}
+		conversion.SetSemanticHash(updatedConfigMap, hash)
+	}
+	if updatedConfigMap != nil {
+		c.FinalizerTranslator().Apply(vConfigMap, updatedConfigMap)
+	} else if finalized := pConfigMap.DeepCopy(); c.FinalizerTranslator().Apply(vConfigMap, finalized) {
+		updatedConfigMap = finalized
+	}
+	if updatedConfigMap != nil {
+		_, err = c.configMapClient.ConfigMaps(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updatedConfigMap, metav1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
@@ -130,10 +178,29 @@ func (c *controller) reconcileConfigMapRemove(clusterName, targetNamespace, requ
 	if pConfigMap.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pConfigMap %s/%s delegated UID is different from deleted object", targetNamespace, name)
 	}
+	if err := conversion.VerifyOwnership(pConfigMap); err != nil {
+		return err
+	}
+	if retained, err := recyclebin.Retains(c.MultiClusterController, pConfigMap); err != nil {
+		return err
+	} else if retained {
+		if marked := recyclebin.RetainObject(pConfigMap, c.Config.NamespaceRetentionPeriod.Duration); marked != nil {
+			if _, err := c.configMapClient.ConfigMaps(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), marked.(*corev1.ConfigMap), metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		klog.V(4).Infof("keeping configmap %s/%s of the retained namespace in super control plane", targetNamespace, name)
+		return nil
+	}
+	if released := pConfigMap.DeepCopy(); c.FinalizerTranslator().Release(released) {
+		if _, err := c.configMapClient.ConfigMaps(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), released, metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 	}
-	err := c.configMapClient.ConfigMaps(targetNamespace).Delete(context.TODO(), name, *opts)
+	err := c.configMapClient.ConfigMaps(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
 	if apierrors.IsNotFound(err) {
 		klog.Warningf("configmap %s/%s of cluster %s not found in super control plane", targetNamespace, name, clusterName)
 		return nil
@@ -19,17 +19,24 @@ package configmap
 import (
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
 	core "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
 )
@@ -372,3 +379,273 @@ func TestDWConfigMapUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDWConfigMapOnDemand(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.OnDemandConfigSync, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	referencingPod := func(cm string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pod-1",
+				Namespace: "default",
+			},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					Name: "config",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: cm}},
+					},
+				}},
+			},
+		}
+	}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant []runtime.Object
+		ExpectedAction         string
+		ExpectedPObject        string
+	}{
+		"referenced cm": {
+			ExistingObjectInTenant: []runtime.Object{
+				tenantConfigMap("cm-1", "default", "12345"),
+				referencingPod("cm-1"),
+			},
+			ExpectedAction:  "create",
+			ExpectedPObject: superDefaultNSName + "/cm-1",
+		},
+		"unreferenced cm": {
+			ExistingObjectInTenant: []runtime.Object{
+				tenantConfigMap("cm-2", "default", "12345"),
+				referencingPod("cm-1"),
+			},
+		},
+		"no longer referenced cm": {
+			ExistingObjectInSuper: []runtime.Object{
+				superConfigMap("cm-3", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantConfigMap("cm-3", "default", "12345"),
+			},
+			ExpectedAction:  "delete",
+			ExpectedPObject: superDefaultNSName + "/cm-3",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewConfigMapController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.ExistingObjectInTenant[0], nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+			if reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				return
+			}
+
+			if tc.ExpectedAction == "" {
+				if len(actions) != 0 {
+					t.Errorf("%s: Expect no operation, got %v", k, actions)
+				}
+				return
+			}
+			if len(actions) != 1 || !actions[0].Matches(tc.ExpectedAction, "configmaps") {
+				t.Errorf("%s: Expected to %s cm %s. Actual actions were: %#v", k, tc.ExpectedAction, tc.ExpectedPObject, actions)
+				return
+			}
+			var fullName string
+			switch action := actions[0].(type) {
+			case core.CreateAction:
+				cm := action.GetObject().(*corev1.ConfigMap)
+				fullName = action.GetNamespace() + "/" + cm.Name
+			case core.DeleteAction:
+				fullName = action.GetNamespace() + "/" + action.GetName()
+			}
+			if fullName != tc.ExpectedPObject {
+				t.Errorf("%s: Expected %s to be %sd, got %s", k, tc.ExpectedPObject, tc.ExpectedAction, fullName)
+			}
+		})
+	}
+}
+
+func TestDWConfigMapRecycleBin(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.NamespaceRecycleBin, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+	tenantNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	retainedConfigMap := func(name, uid string, until time.Time) *corev1.ConfigMap {
+		cm := superConfigMap(name, superDefaultNSName, uid, defaultClusterKey)
+		cm.Annotations[constants.LabelRetainedUntil] = until.UTC().Format(time.RFC3339)
+		return cm
+	}
+
+	newController := func(cfg *config.SyncerConfiguration, client clientset.Interface, informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface, vcInformer vcinformers.VirtualClusterInformer, options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		cfg.NamespaceRetentionPeriod = metav1.Duration{Duration: time.Hour}
+		return NewConfigMapController(cfg, client, informer, vcClient, vcInformer, options)
+	}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant []runtime.Object
+		EnqueueObject          *corev1.ConfigMap
+
+		ExpectedAction      string
+		ExpectedRetained    bool
+		ExpectedDelegateUID string
+	}{
+		"retain cm of deleted namespace": {
+			ExistingObjectInSuper: []runtime.Object{superConfigMap("cm-1", superDefaultNSName, "12345", defaultClusterKey)},
+			EnqueueObject:         tenantConfigMap("cm-1", "default", "12345"),
+			ExpectedAction:        "update",
+			ExpectedRetained:      true,
+			ExpectedDelegateUID:   "12345",
+		},
+		"keep retained cm of restored namespace": {
+			ExistingObjectInSuper:  []runtime.Object{retainedConfigMap("cm-2", "12345", time.Now().Add(time.Hour))},
+			ExistingObjectInTenant: []runtime.Object{tenantNamespace},
+			EnqueueObject:          tenantConfigMap("cm-2", "default", "12345"),
+		},
+		"delete cm whose retention expired": {
+			ExistingObjectInSuper:  []runtime.Object{retainedConfigMap("cm-3", "12345", time.Now().Add(-time.Hour))},
+			ExistingObjectInTenant: []runtime.Object{tenantNamespace},
+			EnqueueObject:          tenantConfigMap("cm-3", "default", "12345"),
+			ExpectedAction:         "delete",
+		},
+		"delete cm of live namespace": {
+			ExistingObjectInSuper:  []runtime.Object{superConfigMap("cm-4", superDefaultNSName, "12345", defaultClusterKey)},
+			ExistingObjectInTenant: []runtime.Object{tenantNamespace},
+			EnqueueObject:          tenantConfigMap("cm-4", "default", "12345"),
+			ExpectedAction:         "delete",
+		},
+		"adopt retained cm by recreated cm": {
+			ExistingObjectInSuper:  []runtime.Object{retainedConfigMap("cm-5", "12345", time.Now().Add(time.Hour))},
+			ExistingObjectInTenant: []runtime.Object{tenantNamespace, tenantConfigMap("cm-5", "default", "67890")},
+			EnqueueObject:          tenantConfigMap("cm-5", "default", "67890"),
+			ExpectedAction:         "update",
+			ExpectedDelegateUID:    "67890",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(newController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.EnqueueObject, nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+			if reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				return
+			}
+
+			if tc.ExpectedAction == "" {
+				if len(actions) != 0 {
+					t.Errorf("%s: Expect no operation, got %v", k, actions)
+				}
+				return
+			}
+			if len(actions) == 0 || !actions[0].Matches(tc.ExpectedAction, "configmaps") {
+				t.Errorf("%s: Expected to %s the cm. Actual actions were: %#v", k, tc.ExpectedAction, actions)
+				return
+			}
+			if tc.ExpectedAction != "update" {
+				return
+			}
+			cm := actions[0].(core.UpdateAction).GetObject().(*corev1.ConfigMap)
+			if _, retained := cm.Annotations[constants.LabelRetainedUntil]; retained != tc.ExpectedRetained {
+				t.Errorf("%s: Expected the cm to be retained %v, got annotations %v", k, tc.ExpectedRetained, cm.Annotations)
+			}
+			if cm.Annotations[constants.LabelUID] != tc.ExpectedDelegateUID {
+				t.Errorf("%s: Expected the cm to be delegated to %s, got %s", k, tc.ExpectedDelegateUID, cm.Annotations[constants.LabelUID])
+			}
+		})
+	}
+}
+
+func TestDWConfigMapUpdateMetadataLimits(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	newController := func(cfg *config.SyncerConfiguration, client clientset.Interface, informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface, vcInformer vcinformers.VirtualClusterInformer, options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		cfg.MetadataLimits = map[string]config.MetadataLimit{"configmaps": {MaxLabels: 1}}
+		return NewConfigMapController(cfg, client, informer, vcClient, vcInformer, options)
+	}
+
+	testcases := map[string]struct {
+		labels         map[string]string
+		expectedAction bool
+		expectedError  string
+	}{
+		"update within the limits": {
+			labels:         map[string]string{"a": "1"},
+			expectedAction: true,
+		},
+		"update exceeding the limits": {
+			labels:        map[string]string{"a": "1", "b": "2"},
+			expectedError: "exceeds the metadata limits: 2 labels, limited to 1",
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			vConfigMap := tenantConfigMap("cm-1", "default", "12345")
+			vConfigMap.Labels = tc.labels
+			actions, reconcileErr, err := util.RunDownwardSync(newController, testTenant,
+				[]runtime.Object{superConfigMap("cm-1", superDefaultNSName, "12345", defaultClusterKey)},
+				[]runtime.Object{vConfigMap}, vConfigMap, nil)
+			if err != nil {
+				t.Fatalf("%s: error running downward sync: %v", k, err)
+			}
+			if tc.expectedError != "" {
+				if reconcileErr == nil || !strings.Contains(reconcileErr.Error(), tc.expectedError) {
+					t.Errorf("%s: expected error %q, got %v", k, tc.expectedError, reconcileErr)
+				}
+			} else if reconcileErr != nil {
+				t.Errorf("%s: unexpected reconcile error: %v", k, reconcileErr)
+			}
+			if tc.expectedAction != (len(actions) == 1 && actions[0].Matches("update", "configmaps")) {
+				t.Errorf("%s: unexpected actions %v", k, actions)
+			}
+		})
+	}
+}
@@ -126,6 +126,9 @@ func (c *controller) checkCRDOfTenantCluster(clusterName string) {
 			Name: vCRD.Name,
 		}, pCRD)
 		if apierrors.IsNotFound(err) {
+			if !c.RemediationBudget().Take(clusterName) {
+				continue
+			}
 			opts := &metav1.DeleteOptions{
 				PropagationPolicy: &constants.DefaultDeletionPolicy,
 			}
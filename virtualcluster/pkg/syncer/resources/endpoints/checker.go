@@ -111,7 +111,7 @@ func (c *controller) PatrollerDo() {
 	}
 
 	vSet.Difference(pSet, differ.FilteringHandler{
-		Handler:    d,
+		Handler:    differ.FreezeHandler{Frozen: c.IsFrozen, Handler: differ.BudgetHandler{Budget: c.RemediationBudget(), Handler: d}},
 		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
 	})
 
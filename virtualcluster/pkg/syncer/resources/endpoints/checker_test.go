@@ -19,6 +19,7 @@ package endpoints
 import (
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
@@ -43,16 +44,7 @@ func TestEndpointsPatrol(t *testing.T) {
 	defaultClusterKey := conversion.ToClusterKey(testTenant)
 	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
 
-	testcases := map[string]struct {
-		ExistingObjectInSuper  []runtime.Object
-		ExistingObjectInTenant []runtime.Object
-		ExpectedDeletedPObject []string
-		ExpectedCreatedPObject []string
-		ExpectedUpdatedPObject []runtime.Object
-		ExpectedNoOperation    bool
-		WaitDWS                bool
-		WaitUWS                bool
-	}{
+	util.RunPatrolScenarios(t, NewEndpointsController, testTenant, "endpoints", map[string]util.PatrolScenario{
 		"pEndpoints exists, vEndpoints exists": {
 			ExistingObjectInSuper: []runtime.Object{
 				superEndpoints("ep", superDefaultNSName, "12345", defaultClusterKey),
@@ -68,27 +60,37 @@ func TestEndpointsPatrol(t *testing.T) {
 			},
 			ExpectedNoOperation: true,
 		},
-	}
-
-	for k, tc := range testcases {
-		t.Run(k, func(t *testing.T) {
-			tenantActions, superActions, err := util.RunPatrol(NewEndpointsController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, nil, tc.WaitDWS, tc.WaitUWS, nil)
-			if err != nil {
-				t.Errorf("%s: error running patrol: %v", k, err)
-				return
-			}
+		"vEndpoints of a service with selector exists, pEndpoints does not exists": {
+			ExistingObjectInTenant: []runtime.Object{
+				tenantEndpoints("ep", "default", "12345"),
+				applySelectorToService(tenantService("ep", "default", "12345"), "app", "ep"),
+			},
+			ExpectedNoOperation: true,
+			WaitDWS:             true,
+		},
+		"pEndpoints exists, vEndpoints does not exists": {
+			ExistingObjectInSuper: []runtime.Object{
+				superEndpoints("ep", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			// the checker does not garbage collect the endpoints.
+			ExpectedNoOperation: true,
+		},
+		"pEndpoints exists, vEndpoints exists with different subsets": {
+			ExistingObjectInSuper: []runtime.Object{
+				superEndpoints("ep", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				withSubsets(tenantEndpoints("ep", "default", "12345"), "10.0.0.1"),
+			},
+			ExpectedUpdatedPObject: []string{
+				superDefaultNSName + "/ep",
+			},
+			WaitDWS: true,
+		},
+	})
+}
 
-			if tc.ExpectedNoOperation {
-				if len(superActions) != 0 {
-					t.Errorf("%s: Expect no operation, got %v in super cluster", k, superActions)
-					return
-				}
-				if len(tenantActions) != 0 {
-					t.Errorf("%s: Expect no operation, got %v tenant cluster", k, tenantActions)
-					return
-				}
-				return
-			}
-		})
-	}
+func withSubsets(ep *corev1.Endpoints, ip string) *corev1.Endpoints {
+	ep.Subsets = []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: ip}}}}
+	return ep
 }
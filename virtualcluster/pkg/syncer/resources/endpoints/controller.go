@@ -28,6 +28,7 @@ import (
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
@@ -71,6 +72,7 @@ func NewEndpointsController(config *config.SyncerConfiguration,
 	}
 
 	c.endpointsLister = informer.Core().V1().Endpoints().Lister()
+	objectlimit.RegisterIndexer("endpoints", informer.Core().V1().Endpoints().Informer().GetIndexer())
 	if options.IsFake {
 		c.endpointsSynced = func() bool { return true }
 	} else {
@@ -28,6 +28,7 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
@@ -97,14 +98,12 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 }
 
 func (c *controller) reconcileEndpointsCreate(clusterName, targetNamespace, requestUID string, ep *corev1.Endpoints) error {
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, ep)
+	pEndpoints, err := conversion.BuildSuperClusterEndpoints(c.Conversion(), clusterName, ep)
 	if err != nil {
 		return err
 	}
 
-	pEndpoints := newObj.(*corev1.Endpoints)
-
-	pEndpoints, err = c.endpointClient.Endpoints(targetNamespace).Create(context.TODO(), pEndpoints, metav1.CreateOptions{})
+	pEndpoints, err = c.endpointClient.Endpoints(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pEndpoints, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		if pEndpoints.Annotations[constants.LabelUID] == requestUID {
 			klog.Infof("endpoints %s/%s of cluster %s already exist in super control plane", targetNamespace, pEndpoints.Name, clusterName)
@@ -125,7 +124,10 @@ func (c *controller) reconcileEndpointsUpdate(clusterName, targetNamespace, requ
 	}
 	updatedEndpoints := conversion.Equality(c.Config, vc).CheckEndpointsEquality(pEP, vEP)
 	if updatedEndpoints != nil {
-		_, err = c.endpointClient.Endpoints(targetNamespace).Update(context.TODO(), updatedEndpoints, metav1.UpdateOptions{})
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vEP, updatedEndpoints); err != nil {
+			return err
+		}
+		_, err = c.endpointClient.Endpoints(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updatedEndpoints, metav1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
@@ -137,10 +139,13 @@ func (c *controller) reconcileEndpointsRemove(clusterName, targetNamespace, requ
 	if pEP.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pEndpoints %s/%s delegated UID is different from deleted object", targetNamespace, pEP.Name)
 	}
+	if err := conversion.VerifyOwnership(pEP); err != nil {
+		return err
+	}
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 	}
-	err := c.endpointClient.Endpoints(targetNamespace).Delete(context.TODO(), name, *opts)
+	err := c.endpointClient.Endpoints(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
 	if apierrors.IsNotFound(err) {
 		klog.Warningf("endpoints %s/%s of %s cluster not found in super control plane", targetNamespace, name, clusterName)
 		return nil
@@ -81,6 +81,9 @@ func (c *controller) PatrollerDo() {
 		if len(clusterName) == 0 || len(vNamespace) == 0 {
 			continue
 		}
+		if c.MultiClusterController.SyncFrozenFor(clusterName, vNamespace) > 0 {
+			continue
+		}
 		shouldDelete := false
 		vIngress := &networkingv1.Ingress{}
 		err := c.MultiClusterController.Get(clusterName, vNamespace, pIngress.Name, vIngress)
@@ -94,6 +97,12 @@ func (c *controller) PatrollerDo() {
 			}
 		}
 		if shouldDelete {
+			if err := conversion.VerifyOwnership(pIngress); err != nil {
+				klog.Errorf("refusing to delete pIngress %s/%s in super control plane: %v", pIngress.Namespace, pIngress.Name, err)
+				shouldDelete = false
+			}
+		}
+		if shouldDelete && c.RemediationBudget().Take(clusterName) {
 			deleteOptions := metav1.NewPreconditionDeleteOptions(string(pIngress.UID))
 			if err = c.ingressClient.Ingresses(pIngress.Namespace).Delete(context.TODO(), pIngress.Name, *deleteOptions); err != nil {
 				klog.Errorf("error deleting pIngress %s/%s in super control plane: %v", pIngress.Namespace, pIngress.Name, err)
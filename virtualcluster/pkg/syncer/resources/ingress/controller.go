@@ -20,10 +20,12 @@ import (
 	"fmt"
 
 	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	v1networking "k8s.io/client-go/kubernetes/typed/networking/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
 	listersnetworkingv1 "k8s.io/client-go/listers/networking/v1"
 	"k8s.io/client-go/tools/cache"
 
@@ -32,7 +34,10 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
@@ -55,6 +60,9 @@ type controller struct {
 	// super control plane informer/listers/synced functions
 	ingressLister listersnetworkingv1.IngressLister
 	ingressSynced cache.InformerSynced
+	// super control plane secret lister/synced function, when the ingresses are isolated from its certificates
+	secretLister listersv1.SecretLister
+	secretSynced cache.InformerSynced
 }
 
 func NewIngressController(config *config.SyncerConfiguration,
@@ -71,17 +79,33 @@ func NewIngressController(config *config.SyncerConfiguration,
 	}
 
 	var err error
-	c.MultiClusterController, err = mc.NewMCController(&networkingv1.Ingress{}, &networkingv1.IngressList{}, c, mc.WithOptions(options.MCOptions))
+	// the tenant control planes older than v1.19 only serve networking.k8s.io/v1beta1 Ingresses.
+	c.MultiClusterController, err = mc.NewMCController(&networkingv1.Ingress{}, &networkingv1.IngressList{}, c, mc.WithOptions(options.MCOptions),
+		mc.WithUpdatePredicate(util.DWSUpdatePredicate(nil)),
+		mc.WithVersionedTypes(mc.VersionedType{
+			ObjectType:     &networkingv1beta1.Ingress{},
+			ObjectListType: &networkingv1beta1.IngressList{},
+			Convert:        conversion.ConvertIngress,
+		}))
 	if err != nil {
 		return nil, err
 	}
 
 	c.ingressLister = informer.Networking().V1().Ingresses().Lister()
+	objectlimit.RegisterIndexer("ingresses", informer.Networking().V1().Ingresses().Informer().GetIndexer())
 	if options.IsFake {
 		c.ingressSynced = func() bool { return true }
 	} else {
 		c.ingressSynced = informer.Networking().V1().Ingresses().Informer().HasSynced
 	}
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.IngressTLSIsolation) {
+		c.secretLister = informer.Core().V1().Secrets().Lister()
+		if options.IsFake {
+			c.secretSynced = func() bool { return true }
+		} else {
+			c.secretSynced = informer.Core().V1().Secrets().Informer().HasSynced
+		}
+	}
 
 	c.UpwardController, err = uw.NewUWController(&networkingv1.Ingress{}, c, uw.WithOptions(options.UWOptions))
 	if err != nil {
@@ -28,12 +28,17 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
 
 func (c *controller) StartDWS(stopCh <-chan struct{}) error {
-	if !cache.WaitForCacheSync(stopCh, c.ingressSynced) {
+	synced := []cache.InformerSynced{c.ingressSynced}
+	if c.secretSynced != nil {
+		synced = append(synced, c.secretSynced)
+	}
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		return fmt.Errorf("failed to wait for caches to sync before starting Ingress dws")
 	}
 	return c.MultiClusterController.Start(stopCh)
@@ -67,7 +72,7 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 			return reconciler.Result{Requeue: true}, err
 		}
 	case !vExists && pExists:
-		err := c.reconcileIngressRemove(targetNamespace, request.UID, request.Name, pIngress)
+		err := c.reconcileIngressRemove(request.ClusterName, targetNamespace, request.UID, request.Name, pIngress)
 		if err != nil {
 			klog.Errorf("failed reconcile ingress %s/%s DELETE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
 			return reconciler.Result{Requeue: true}, err
@@ -85,14 +90,19 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 }
 
 func (c *controller) reconcileIngressCreate(clusterName, targetNamespace, requestUID string, ingress *networkingv1.Ingress) error {
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, ingress)
-	if err != nil {
+	if message, err := c.checkTLSSecrets(clusterName, targetNamespace, ingress); err != nil {
 		return err
+	} else if message != "" {
+		return c.rejectTLSSecrets(clusterName, ingress, message)
 	}
 
-	pIngress := newObj.(*networkingv1.Ingress)
+	pIngress, err := conversion.BuildSuperClusterIngress(c.Conversion(), clusterName, ingress)
+	if err != nil {
+		return err
+	}
+	pIngress.Annotations = conversion.NewAnnotationMapper(c.Config).MapDownward(pIngress.Annotations)
 
-	pIngress, err = c.ingressClient.Ingresses(targetNamespace).Create(context.TODO(), pIngress, metav1.CreateOptions{})
+	pIngress, err = c.ingressClient.Ingresses(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pIngress, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		if pIngress.Annotations[constants.LabelUID] == requestUID {
 			klog.Infof("ingress %s/%s of cluster %s already exist in super control plane", targetNamespace, pIngress.Name, clusterName)
@@ -114,7 +124,17 @@ func (c *controller) reconcileIngressUpdate(clusterName, targetNamespace, reques
 	}
 	updated := conversion.Equality(c.Config, vc).CheckIngressEquality(pIngress, vIngress)
 	if updated != nil {
-		_, err = c.ingressClient.Ingresses(targetNamespace).Update(context.TODO(), updated, metav1.UpdateOptions{})
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vIngress, updated); err != nil {
+			return err
+		}
+		// the super control plane ingress keeps the TLS secrets it was synced with
+		if message, err := c.checkTLSSecrets(clusterName, targetNamespace, vIngress); err != nil {
+			return err
+		} else if message != "" {
+			return c.rejectTLSSecrets(clusterName, vIngress, message)
+		}
+
+		_, err = c.ingressClient.Ingresses(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updated, metav1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
@@ -122,16 +142,19 @@ func (c *controller) reconcileIngressUpdate(clusterName, targetNamespace, reques
 	return nil
 }
 
-func (c *controller) reconcileIngressRemove(targetNamespace, requestUID, name string, pIngress *networkingv1.Ingress) error {
+func (c *controller) reconcileIngressRemove(clusterName, targetNamespace, requestUID, name string, pIngress *networkingv1.Ingress) error {
 	if pIngress.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pIngress %s/%s delegated UID is different from deleted object", targetNamespace, name)
 	}
+	if err := conversion.VerifyOwnership(pIngress); err != nil {
+		return err
+	}
 
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 		Preconditions:     metav1.NewUIDPreconditions(string(pIngress.UID)),
 	}
-	err := c.ingressClient.Ingresses(targetNamespace).Delete(context.TODO(), name, *opts)
+	err := c.ingressClient.Ingresses(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
 	if apierrors.IsNotFound(err) {
 		klog.Warningf("To be deleted ingress %s/%s not found in super control plane", targetNamespace, name)
 		return nil
@@ -20,6 +20,7 @@ import (
 	"strings"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 )
 
 func tenantIngress(name, namespace, uid string) *networkingv1.Ingress {
@@ -344,3 +346,107 @@ func TestDWIngressUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDWIngressTLSIsolation(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.IngressTLSIsolation, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	withTLS := func(ing *networkingv1.Ingress, secretName string) *networkingv1.Ingress {
+		ing.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"foo.example.com"}, SecretName: secretName}}
+		return ing
+	}
+	superSecret := func(name string, annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: superDefaultNSName, Annotations: annotations},
+			Type:       corev1.SecretTypeTLS,
+		}
+	}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant *networkingv1.Ingress
+
+		ExpectedCreatedIngresses []string
+	}{
+		"tenant secret": {
+			ExistingObjectInSuper: []runtime.Object{
+				superSecret("tls", map[string]string{constants.LabelCluster: defaultClusterKey, constants.LabelNamespace: "default"}),
+			},
+			ExistingObjectInTenant:   withTLS(tenantIngress("ing-1", "default", "12345"), "tls"),
+			ExpectedCreatedIngresses: []string{superDefaultNSName + "/ing-1"},
+		},
+		"tenant secret not synced yet": {
+			ExistingObjectInTenant:   withTLS(tenantIngress("ing-1", "default", "12345"), "tls"),
+			ExpectedCreatedIngresses: []string{superDefaultNSName + "/ing-1"},
+		},
+		"default certificate": {
+			ExistingObjectInTenant:   withTLS(tenantIngress("ing-1", "default", "12345"), ""),
+			ExpectedCreatedIngresses: []string{},
+		},
+		"secret of another namespace": {
+			ExistingObjectInTenant:   withTLS(tenantIngress("ing-1", "default", "12345"), "kube-system/wildcard"),
+			ExpectedCreatedIngresses: []string{},
+		},
+		"super control plane secret": {
+			ExistingObjectInSuper: []runtime.Object{
+				superSecret("wildcard", nil),
+			},
+			ExistingObjectInTenant:   withTLS(tenantIngress("ing-1", "default", "12345"), "wildcard"),
+			ExpectedCreatedIngresses: []string{},
+		},
+		"secret of another tenant": {
+			ExistingObjectInSuper: []runtime.Object{
+				superSecret("tls", map[string]string{constants.LabelCluster: "other", constants.LabelNamespace: "default"}),
+			},
+			ExistingObjectInTenant:   withTLS(tenantIngress("ing-1", "default", "12345"), "tls"),
+			ExpectedCreatedIngresses: []string{},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewIngressController,
+				testTenant,
+				tc.ExistingObjectInSuper,
+				[]runtime.Object{tc.ExistingObjectInTenant},
+				tc.ExistingObjectInTenant,
+				nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+			if reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+			}
+
+			if len(tc.ExpectedCreatedIngresses) != len(actions) {
+				t.Errorf("%s: Expected to create ingress %#v. Actual actions were: %#v", k, tc.ExpectedCreatedIngresses, actions)
+				return
+			}
+			for i, expectedName := range tc.ExpectedCreatedIngresses {
+				action := actions[i]
+				if !action.Matches("create", "ingresses") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+				}
+				created := action.(core.CreateAction).GetObject().(*networkingv1.Ingress)
+				if fullName := created.Namespace + "/" + created.Name; fullName != expectedName {
+					t.Errorf("%s: Expected %s to be created, got %s", k, expectedName, fullName)
+				}
+			}
+		})
+	}
+}
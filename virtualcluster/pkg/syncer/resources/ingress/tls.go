@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// checkTLSSecrets returns why the tenant ingress must not be synced, or "" if it may be synced, when
+// the ingresses are isolated from the certificates of the super control plane: every TLS entry of
+// the ingress names a secret of its namespace, and the super control plane secret of that name, if
+// any, is the copy of a tenant secret rather than e.g. a wildcard certificate replicated into all
+// the namespaces.
+func (c *controller) checkTLSSecrets(clusterName, targetNamespace string, ing *networkingv1.Ingress) (string, error) {
+	if c.secretLister == nil {
+		return "", nil
+	}
+	for _, tls := range ing.Spec.TLS {
+		switch {
+		case tls.SecretName == "":
+			return fmt.Sprintf("The TLS of hosts %s has no secretName, the default certificate of the super control plane is not served to tenants", strings.Join(tls.Hosts, ",")), nil
+		case strings.Contains(tls.SecretName, "/"):
+			return fmt.Sprintf("The TLS secret %s is not in the namespace of the Ingress", tls.SecretName), nil
+		}
+		pSecret, err := c.secretLister.Secrets(targetNamespace).Get(tls.SecretName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if cluster, _ := conversion.GetVirtualOwner(pSecret); cluster != clusterName {
+			return fmt.Sprintf("The TLS secret %s is a secret of the super control plane", tls.SecretName), nil
+		}
+	}
+	return "", nil
+}
+
+// rejectTLSSecrets records an event on the tenant ingress not synced because of its TLS secrets.
+func (c *controller) rejectTLSSecrets(clusterName string, ing *networkingv1.Ingress, message string) error {
+	klog.V(4).Infof("skip ingress %s/%s of cluster %s: %s", ing.Namespace, ing.Name, clusterName, message)
+	return c.MultiClusterController.Eventf(clusterName, &corev1.ObjectReference{
+		Kind:       "Ingress",
+		APIVersion: networkingv1.SchemeGroupVersion.String(),
+		Name:       ing.Name,
+		Namespace:  ing.Namespace,
+		UID:        ing.UID,
+	}, corev1.EventTypeWarning, constants.ReasonForbiddenTLSSecret, message)
+}
@@ -22,10 +22,12 @@ import (
 
 	pkgerr "github.com/pkg/errors"
 	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -90,7 +92,7 @@ func (c *controller) BackPopulate(key string) error {
 	if updatedMeta != nil {
 		newIngress = vIngress.DeepCopy()
 		newIngress.ObjectMeta = *updatedMeta
-		if _, err = tenantClient.NetworkingV1().Ingresses(vIngress.Namespace).Update(context.TODO(), newIngress, metav1.UpdateOptions{}); err != nil {
+		if err = c.updateVIngress(tenantClient, clusterName, newIngress, false); err != nil {
 			return fmt.Errorf("failed to back populate ingress %s/%s meta update for cluster %s: %v", vIngress.Namespace, vIngress.Name, clusterName, err)
 		}
 	}
@@ -100,14 +102,54 @@ func (c *controller) BackPopulate(key string) error {
 			newIngress = vIngress.DeepCopy()
 		} else {
 			// vIngress has been updated, let us fetch the lastest version.
-			if newIngress, err = tenantClient.NetworkingV1().Ingresses(vIngress.Namespace).Get(context.TODO(), vIngress.Name, metav1.GetOptions{}); err != nil {
+			if newIngress, err = c.getVIngress(tenantClient, clusterName, vIngress.Namespace, vIngress.Name); err != nil {
 				return fmt.Errorf("failed to retrieve vIngress %s/%s from cluster %s: %v", vIngress.Namespace, vIngress.Name, clusterName, err)
 			}
 		}
 		newIngress.Status = pIngress.Status
-		if _, err = tenantClient.NetworkingV1().Ingresses(vIngress.Namespace).UpdateStatus(context.TODO(), newIngress, metav1.UpdateOptions{}); err != nil {
+		if err = c.updateVIngress(tenantClient, clusterName, newIngress, true); err != nil {
 			return fmt.Errorf("failed to back populate ingress %s/%s status update for cluster %s: %v", vIngress.Namespace, vIngress.Name, clusterName, err)
 		}
 	}
 	return nil
 }
+
+// getVIngress gets the vIngress from the tenant control plane in the version it serves.
+func (c *controller) getVIngress(tenantClient clientset.Interface, clusterName, namespace, name string) (*networkingv1.Ingress, error) {
+	if c.MultiClusterController.GetVersionedType(clusterName) == nil {
+		return tenantClient.NetworkingV1().Ingresses(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
+	versioned, err := tenantClient.NetworkingV1beta1().Ingresses(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	vIngress := &networkingv1.Ingress{}
+	if err := conversion.ConvertIngress(versioned, vIngress); err != nil {
+		return nil, err
+	}
+	return vIngress, nil
+}
+
+// updateVIngress updates the vIngress, or its status, in the version the tenant control plane serves.
+func (c *controller) updateVIngress(tenantClient clientset.Interface, clusterName string, vIngress *networkingv1.Ingress, status bool) error {
+	if c.MultiClusterController.GetVersionedType(clusterName) == nil {
+		var err error
+		if status {
+			_, err = tenantClient.NetworkingV1().Ingresses(vIngress.Namespace).UpdateStatus(context.TODO(), vIngress, metav1.UpdateOptions{})
+		} else {
+			_, err = tenantClient.NetworkingV1().Ingresses(vIngress.Namespace).Update(context.TODO(), vIngress, metav1.UpdateOptions{})
+		}
+		return err
+	}
+	versioned := &networkingv1beta1.Ingress{}
+	if err := conversion.ConvertIngress(vIngress, versioned); err != nil {
+		return err
+	}
+	var err error
+	if status {
+		_, err = tenantClient.NetworkingV1beta1().Ingresses(versioned.Namespace).UpdateStatus(context.TODO(), versioned, metav1.UpdateOptions{})
+	} else {
+		_, err = tenantClient.NetworkingV1beta1().Ingresses(versioned.Namespace).Update(context.TODO(), versioned, metav1.UpdateOptions{})
+	}
+	return err
+}
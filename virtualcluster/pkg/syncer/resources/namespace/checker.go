@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
@@ -92,6 +93,7 @@ func (c *controller) PatrollerDo() {
 		klog.Errorf("error listing namespaces from super control plane informer cache: %v", err)
 		return
 	}
+	c.recordStuckTerminations(pList)
 	pSet := differ.NewDiffSet()
 	for _, p := range pList {
 		pSet.Insert(differ.ClusterObject{Object: p, Key: p.GetName()})
@@ -124,6 +126,9 @@ func (c *controller) PatrollerDo() {
 
 	d := differ.HandlerFuncs{}
 	d.AddFunc = func(vObj differ.ClusterObject) {
+		if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) && util.IsSuperClusterUnschedulable() {
+			return
+		}
 		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
 			klog.Errorf("error requeue vNamespace %v in cluster %s: %v", vObj.GetName(), vObj.GetOwnerCluster(), err)
 		} else {
@@ -135,17 +140,31 @@ func (c *controller) PatrollerDo() {
 		p := pObj.Object.(*corev1.Namespace)
 
 		// if vc object is deleted, we should reach here
-		if c.shouldBeGarbageCollected(p) || p.Annotations[constants.LabelUID] != string(v.UID) {
+		if c.shouldBeGarbageCollected(p) {
 			c.deleteNamespace(p)
 			return
 		}
-
-		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, vObj.GetOwnerCluster())
-		if err != nil {
-			klog.Errorf("fail to get cluster spec : %s", vObj.GetOwnerCluster())
+		if p.Annotations[constants.LabelUID] != string(v.UID) {
+			if _, retained := recyclebin.RetainedUntil(p); retained && p.DeletionTimestamp == nil {
+				// the tenant namespace is recreated, the namespace syncer restores the retained namespace
+				d.OnAdd(vObj)
+				return
+			}
+			c.deleteNamespace(p)
 			return
 		}
-		updatedNamespace := conversion.Equality(c.Config, vc).CheckNamespaceEquality(p, v)
+
+		var updatedNamespace *corev1.Namespace
+		if policy := conversion.NewNamespaceMetaPolicy(c.Config); policy.Enabled() {
+			updatedNamespace = policy.CheckDWNamespaceEquality(p, v)
+		} else {
+			vc, err := util.GetVirtualClusterObject(c.MultiClusterController, vObj.GetOwnerCluster())
+			if err != nil {
+				klog.Errorf("fail to get cluster spec : %s", vObj.GetOwnerCluster())
+				return
+			}
+			updatedNamespace = conversion.Equality(c.Config, vc).CheckNamespaceEquality(p, v)
+		}
 		if updatedNamespace != nil {
 			klog.Warningf("metadata of namespace %s diff in super&tenant cluster", pObj.Key)
 			d.OnAdd(vObj)
@@ -164,7 +183,9 @@ func (c *controller) PatrollerDo() {
 		clusterName, _ := conversion.GetVirtualOwner(p)
 		// most possible case. vc is loaded and tenant ns is missing
 		if knownClusterSet.Has(clusterName) {
-			c.deleteNamespace(p)
+			if err := c.retainOrDeleteNamespace(clusterName, p); err != nil {
+				klog.Errorf("error retaining or tearing down pNamespace %s in super control plane: %v", p.Name, err)
+			}
 			return
 		}
 
@@ -176,7 +197,7 @@ func (c *controller) PatrollerDo() {
 	}
 
 	vSet.Difference(pSet, differ.FilteringHandler{
-		Handler: d,
+		Handler: differ.FreezeHandler{Frozen: c.IsFrozen, Handler: differ.BudgetHandler{Budget: c.RemediationBudget(), Handler: d}},
 		FilterFunc: func(obj differ.ClusterObject) bool {
 			// vObj
 			if obj.OwnerCluster != "" {
@@ -197,7 +218,33 @@ func (c *controller) PatrollerDo() {
 	})
 }
 
+// retainOrDeleteNamespace deletes the super cluster namespace whose tenant namespace is gone or placed
+// in other super clusters, unless the recycle bin retains it or its pods are being torn down.
+func (c *controller) retainOrDeleteNamespace(clusterName string, p *corev1.Namespace) error {
+	if recyclebin.Enabled() {
+		retainFor, err := c.retainNamespace(clusterName, p.Name, p.Annotations[constants.LabelUID], p)
+		if err != nil {
+			return err
+		}
+		if retainFor > 0 {
+			return nil
+		}
+	}
+	if teardownEnabled() {
+		wait, err := c.teardownNamespace(clusterName, p.Name, p.Annotations[constants.LabelUID], p)
+		if err != nil || wait > 0 {
+			return err
+		}
+	}
+	c.deleteNamespace(p)
+	return nil
+}
+
 func (c *controller) deleteNamespace(ns *corev1.Namespace) {
+	if err := conversion.VerifyOwnership(ns); err != nil {
+		klog.Errorf("refusing to delete pNamespace %s in super control plane: %v", ns.GetName(), err)
+		return
+	}
 	deleteOptions := &metav1.DeleteOptions{}
 	deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(ns.GetUID()))
 	if err := c.namespaceClient.Namespaces().Delete(context.TODO(), ns.GetName(), *deleteOptions); err != nil {
@@ -21,15 +21,23 @@ import (
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	v1rbac "k8s.io/client-go/kubernetes/typed/rbac/v1"
 	listersv1 "k8s.io/client-go/listers/core/v1"
+	listersrbacv1 "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	vclisters "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/listers/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/freeze"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
 )
@@ -50,10 +58,29 @@ type controller struct {
 	// super control plane namespace lister
 	nsLister listersv1.NamespaceLister
 	nsSynced cache.InformerSynced
+	// super control plane rbac client and rolebinding lister, used for TenantImpersonation
+	saClient   v1core.ServiceAccountsGetter
+	rbacClient v1rbac.RoleBindingsGetter
+	rbLister   listersrbacv1.RoleBindingLister
+	rbSynced   cache.InformerSynced
+	// super control plane pod, service and pvc clients and listers, used for NamespaceDeletionBarrier
+	podClient v1core.PodsGetter
+	podLister listersv1.PodLister
+	podSynced cache.InformerSynced
+	svcClient v1core.ServicesGetter
+	svcLister listersv1.ServiceLister
+	svcSynced cache.InformerSynced
+	pvcClient v1core.PersistentVolumeClaimsGetter
+	pvcLister listersv1.PersistentVolumeClaimLister
+	pvcSynced cache.InformerSynced
 	// super control plane virtual cluster lister
 	vcClient vcclient.Interface
 	vcLister vclisters.VirtualClusterLister
 	vcSynced cache.InformerSynced
+	// super control plane informer of the PlacementChanged events of the scheduler, and the queue
+	// of the placement changes, used for SuperClusterPooling
+	placementInformer cache.SharedIndexInformer
+	placementQueue    workqueue.RateLimitingInterface
 }
 
 func NewNamespaceController(config *config.SyncerConfiguration,
@@ -86,10 +113,80 @@ func NewNamespaceController(config *config.SyncerConfiguration,
 		c.vcSynced = vcInformer.Informer().HasSynced
 	}
 
+	if freeze.Enabled() {
+		freeze.Starts = &freezeStarts{namespaceClient: c.namespaceClient, nsLister: c.nsLister}
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantImpersonation) {
+		c.saClient = client.CoreV1()
+		c.rbacClient = client.RbacV1()
+		c.rbLister = informer.Rbac().V1().RoleBindings().Lister()
+		if options.IsFake {
+			c.rbSynced = func() bool { return true }
+		} else {
+			c.rbSynced = informer.Rbac().V1().RoleBindings().Informer().HasSynced
+		}
+	}
+
+	if teardownEnabled() {
+		c.podClient = client.CoreV1()
+		c.podLister = informer.Core().V1().Pods().Lister()
+		c.svcClient = client.CoreV1()
+		c.svcLister = informer.Core().V1().Services().Lister()
+		c.pvcClient = client.CoreV1()
+		c.pvcLister = informer.Core().V1().PersistentVolumeClaims().Lister()
+		if options.IsFake {
+			c.podSynced = func() bool { return true }
+			c.svcSynced = func() bool { return true }
+			c.pvcSynced = func() bool { return true }
+		} else {
+			c.podSynced = informer.Core().V1().Pods().Informer().HasSynced
+			c.svcSynced = informer.Core().V1().Services().Informer().HasSynced
+			c.pvcSynced = informer.Core().V1().PersistentVolumeClaims().Informer().HasSynced
+		}
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) && !options.IsFake {
+		c.placementQueue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "namespace-placement")
+		c.placementInformer = newPlacementInformer(client)
+		c.placementInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueuePlacementChange,
+		})
+	}
+
 	c.Patroller, err = pa.NewPatroller(&corev1.Namespace{}, c, pa.WithOptions(options.PatrolOptions))
 	if err != nil {
 		return nil, err
 	}
 
+	if conversion.NewNamespaceMetaPolicy(config).UpwardEnabled() {
+		c.UpwardController, err = uw.NewUWController(&corev1.Namespace{}, c, uw.WithOptions(options.UWOptions))
+		if err != nil {
+			return nil, err
+		}
+		informer.Core().V1().Namespaces().Informer().AddEventHandler(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc: c.enqueueNamespace,
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					newNamespace := newObj.(*corev1.Namespace)
+					oldNamespace := oldObj.(*corev1.Namespace)
+					if newNamespace.ResourceVersion != oldNamespace.ResourceVersion {
+						c.enqueueNamespace(newObj)
+					}
+				},
+			})
+	}
+
 	return c, nil
 }
+
+func (c *controller) enqueueNamespace(obj interface{}) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+	if ns.GetAnnotations()[constants.LabelCluster] == "" {
+		return
+	}
+	c.UpwardController.AddToQueue(ns.Name)
+}
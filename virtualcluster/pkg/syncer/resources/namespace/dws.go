@@ -19,15 +19,20 @@ package namespace
 import (
 	"context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
@@ -37,6 +42,15 @@ func (c *controller) StartDWS(stopCh <-chan struct{}) error {
 	if !cache.WaitForCacheSync(stopCh, c.nsSynced) {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
+	if c.rbSynced != nil && !cache.WaitForCacheSync(stopCh, c.rbSynced) {
+		return fmt.Errorf("failed to wait for rolebinding caches to sync")
+	}
+	if c.podSynced != nil && !cache.WaitForCacheSync(stopCh, c.podSynced, c.svcSynced, c.pvcSynced) {
+		return fmt.Errorf("failed to wait for teardown caches to sync")
+	}
+	if c.placementInformer != nil {
+		go c.runPlacementWorker(stopCh)
+	}
 	return c.MultiClusterController.Start(stopCh)
 }
 
@@ -62,12 +76,37 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 	}
 	switch {
 	case vExists && !pExists:
+		if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) && util.IsSuperClusterUnschedulable() {
+			// the namespace is created by the checker once the super cluster is uncordoned
+			klog.Infof("super cluster is unschedulable, skip creating namespace %s of cluster %s", targetNamespace, request.ClusterName)
+			return reconciler.Result{}, nil
+		}
 		err := c.reconcileNamespaceCreate(request.ClusterName, targetNamespace, vNamespace)
 		if err != nil {
 			klog.Errorf("failed reconcile namespace %s CREATE of cluster %s %v", request.Name, request.ClusterName, err)
 			return reconciler.Result{Requeue: true}, err
 		}
 	case !vExists && pExists:
+		if recyclebin.Enabled() {
+			retainFor, err := c.retainNamespace(request.ClusterName, targetNamespace, request.UID, pNamespace)
+			if err != nil {
+				klog.Errorf("failed to retain namespace %s of cluster %s %v", request.Name, request.ClusterName, err)
+				return reconciler.Result{Requeue: true}, err
+			}
+			if retainFor > 0 {
+				return reconciler.Result{RequeueAfter: retainFor}, nil
+			}
+		}
+		if teardownEnabled() {
+			wait, err := c.teardownNamespace(request.ClusterName, targetNamespace, request.UID, pNamespace)
+			if err != nil {
+				klog.Errorf("failed to tear down namespace %s of cluster %s %v", request.Name, request.ClusterName, err)
+				return reconciler.Result{Requeue: true}, err
+			}
+			if wait > 0 {
+				return reconciler.Result{RequeueAfter: wait}, nil
+			}
+		}
 		err := c.reconcileNamespaceRemove(request.ClusterName, targetNamespace, request.UID, pNamespace)
 		if err != nil {
 			klog.Errorf("failed reconcile namespace %s DELETE of cluster %s %v", request.Name, request.ClusterName, err)
@@ -94,6 +133,51 @@ func (c *controller) reconcileNamespaceCreate(clusterName, targetNamespace strin
 	_, err = c.namespaceClient.Namespaces().Create(context.TODO(), newObj.(*corev1.Namespace), metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		klog.Infof("namespace %s of cluster %s already exist in super control plane", targetNamespace, clusterName)
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.ensureTenantRoleBinding(clusterName, targetNamespace)
+}
+
+// ensureTenantRoleBinding grants the identity impersonated for the tenant access
+// to one of its super cluster namespaces, used for TenantImpersonation.
+func (c *controller) ensureTenantRoleBinding(clusterName, targetNamespace string) error {
+	if c.rbacClient == nil {
+		return nil
+	}
+	rb := rbac.TenantRoleBinding(c.Config, clusterName, targetNamespace)
+	existing, err := c.rbLister.RoleBindings(targetNamespace).Get(rbac.TenantRoleBindingName)
+	if err == nil {
+		if equality.Semantic.DeepEqual(existing.Subjects, rb.Subjects) {
+			return nil
+		}
+		// the tenant identity changed since the binding was created
+		updated := existing.DeepCopy()
+		updated.Subjects = rb.Subjects
+		_, err = c.rbacClient.RoleBindings(targetNamespace).Update(context.TODO(), updated, metav1.UpdateOptions{})
+		return err
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if rbac.UsesServiceAccounts(c.Config) {
+		sa := &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        rbac.TenantServiceAccountName(clusterName),
+				Namespace:   c.Config.TenantServiceAccountNamespace,
+				Annotations: map[string]string{constants.LabelCluster: clusterName},
+			},
+		}
+		_, err := c.saClient.ServiceAccounts(sa.Namespace).Create(context.TODO(), sa, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create tenant service account %s/%s: %v", sa.Namespace, sa.Name, err)
+		}
+	}
+
+	_, err = c.rbacClient.RoleBindings(targetNamespace).Create(context.TODO(), rb, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
 		return nil
 	}
 	return err
@@ -101,21 +185,53 @@ func (c *controller) reconcileNamespaceCreate(clusterName, targetNamespace strin
 
 func (c *controller) reconcileNamespaceUpdate(clusterName, targetNamespace, requestUID string, pNamespace, vNamespace *corev1.Namespace) error {
 	if pNamespace.Annotations[constants.LabelUID] != requestUID {
-		return fmt.Errorf("pNamespace %s exists but its delegated UID is different", targetNamespace)
+		if _, retained := recyclebin.RetainedUntil(pNamespace); !retained || pNamespace.DeletionTimestamp != nil {
+			return fmt.Errorf("pNamespace %s exists but its delegated UID is different", targetNamespace)
+		}
+		restored, err := c.restoreNamespace(clusterName, targetNamespace, requestUID, pNamespace)
+		if err != nil {
+			return err
+		}
+		pNamespace = restored
 	}
 
-	// update namespace meta is a generic operation, guarded by SuperClusterPooling for now
-	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
-		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+	// the namespace is synced again, its next freeze starts anew
+	if _, ok := pNamespace.Annotations[constants.LabelSyncFreezeStarted]; ok {
+		unfrozen := pNamespace.DeepCopy()
+		delete(unfrozen.Annotations, constants.LabelSyncFreezeStarted)
+		updated, err := c.namespaceClient.Namespaces().Update(context.TODO(), unfrozen, metav1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
-		updatedNamespace := conversion.Equality(c.Config, vc).CheckNamespaceEquality(pNamespace, vNamespace)
-		if updatedNamespace != nil {
-			_, err = c.namespaceClient.Namespaces().Update(context.TODO(), updatedNamespace, metav1.UpdateOptions{})
-			if err != nil {
-				return err
-			}
+		pNamespace = updated
+	}
+
+	// namespaces created before TenantImpersonation was enabled get their binding here
+	if err := c.ensureTenantRoleBinding(clusterName, targetNamespace); err != nil {
+		return err
+	}
+
+	// update namespace meta is a generic operation, guarded by SuperClusterPooling for now,
+	// unless the keys to propagate are configured
+	var updatedNamespace *corev1.Namespace
+	var vc *v1alpha1.VirtualCluster
+	if policy := conversion.NewNamespaceMetaPolicy(c.Config); policy.Enabled() {
+		updatedNamespace = policy.CheckDWNamespaceEquality(pNamespace, vNamespace)
+	} else if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
+		var err error
+		vc, err = util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+		if err != nil {
+			return err
+		}
+		updatedNamespace = conversion.Equality(c.Config, vc).CheckNamespaceEquality(pNamespace, vNamespace)
+	}
+	if updatedNamespace != nil {
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vNamespace, updatedNamespace); err != nil {
+			return err
+		}
+		_, err := c.namespaceClient.Namespaces().Update(context.TODO(), updatedNamespace, metav1.UpdateOptions{})
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -125,6 +241,9 @@ func (c *controller) reconcileNamespaceRemove(clusterName, targetNamespace, requ
 	if pNamespace.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pNamespace %s delegated UID is different from deleted object", targetNamespace)
 	}
+	if err := conversion.VerifyOwnership(pNamespace); err != nil {
+		return err
+	}
 
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
@@ -137,3 +256,39 @@ func (c *controller) reconcileNamespaceRemove(clusterName, targetNamespace, requ
 	}
 	return err
 }
+
+// retainNamespace marks the super cluster namespace of a deleted tenant namespace as retained for the
+// configured period, and returns the time left before it is deleted. The retention is over once it
+// returns 0.
+func (c *controller) retainNamespace(clusterName, targetNamespace, requestUID string, pNamespace *corev1.Namespace) (time.Duration, error) {
+	if pNamespace.Annotations[constants.LabelUID] != requestUID {
+		// not the super cluster namespace of the deleted tenant namespace, reconcileNamespaceRemove refuses it
+		return 0, nil
+	}
+	until, retained := recyclebin.RetainedUntil(pNamespace)
+	if !retained {
+		until = time.Now().Add(c.Config.NamespaceRetentionPeriod.Duration)
+		if err := conversion.VerifyOwnership(pNamespace); err != nil {
+			return 0, err
+		}
+		_, err := c.namespaceClient.Namespaces().Update(context.TODO(), recyclebin.Retain(pNamespace, until), metav1.UpdateOptions{})
+		if err != nil {
+			return 0, err
+		}
+		klog.Infof("retaining namespace %s of cluster %s until %s", targetNamespace, clusterName, until.Format(time.RFC3339))
+	}
+	return time.Until(until), nil
+}
+
+// restoreNamespace gives the retained super cluster namespace to the recreated tenant namespace.
+func (c *controller) restoreNamespace(clusterName, targetNamespace, requestUID string, pNamespace *corev1.Namespace) (*corev1.Namespace, error) {
+	if err := conversion.VerifyOwnership(pNamespace); err != nil {
+		return nil, err
+	}
+	restored, err := c.namespaceClient.Namespaces().Update(context.TODO(), recyclebin.Restore(pNamespace, requestUID), metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	klog.Infof("restored retained namespace %s of cluster %s", targetNamespace, clusterName)
+	return restored, nil
+}
@@ -20,16 +20,27 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
 	core "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	syncerutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
 	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
@@ -112,6 +123,7 @@ func TestDWNamespaceCreation(t *testing.T) {
 		ExistingObjectInSuper  []runtime.Object
 		ExistingObjectInTenant *corev1.Namespace
 		IsLabellingEnabled     bool
+		IsCordoned             bool
 
 		ExpectedCreatedNamespace []string
 		ExpectedError            string
@@ -127,6 +139,12 @@ func TestDWNamespaceCreation(t *testing.T) {
 			ExpectedCreatedNamespace: []string{defaultSuperNSName},
 			IsLabellingEnabled:       true,
 		},
+		"new namespace in a cordoned super cluster": {
+			ExistingObjectInSuper:    []runtime.Object{},
+			ExistingObjectInTenant:   applyAnnotationToNS(tenantNamespace(defaultNSName, "12345"), utilconst.LabelScheduledPlacements, fmt.Sprintf("{\"%s\":1}", utilconst.SuperClusterID)),
+			IsCordoned:               true,
+			ExpectedCreatedNamespace: []string{},
+		},
 		"new namespace but already exists": {
 			ExistingObjectInSuper: []runtime.Object{
 				superNamespace(defaultSuperNSName, "12345", defaultClusterKey),
@@ -150,6 +168,11 @@ func TestDWNamespaceCreation(t *testing.T) {
 			if tc.IsLabellingEnabled {
 				defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.SuperClusterLabelling, true)()
 			}
+			if tc.IsCordoned {
+				defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.SuperClusterPooling, true)()
+				syncerutil.SetSuperClusterUnschedulable(true)
+				defer syncerutil.SetSuperClusterUnschedulable(false)
+			}
 
 			actions, reconcileErr, err := util.RunDownwardSync(NewNamespaceController,
 				testTenant,
@@ -198,6 +221,162 @@ func TestDWNamespaceCreation(t *testing.T) {
 	}
 }
 
+func TestDWNamespaceTenantRoleBinding(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.TenantImpersonation, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	clusterKey := conversion.ToClusterKey(testTenant)
+	superNSName := conversion.ToSuperClusterNamespace(clusterKey, "default")
+	existingBinding := rbac.TenantRoleBinding(&config.SyncerConfiguration{}, clusterKey, superNSName)
+	userBinding := rbac.TenantRoleBinding(&config.SyncerConfiguration{TenantIdentity: rbac.TenantIdentityUser}, clusterKey, superNSName)
+
+	testcases := map[string]struct {
+		TenantIdentity        string
+		ExistingObjectInSuper []runtime.Object
+		ExpectedActions       [][2]string
+		ExpectedSubject       rbacv1.Subject
+	}{
+		"new namespace": {
+			ExistingObjectInSuper: []runtime.Object{},
+			ExpectedActions:       [][2]string{{"create", "namespaces"}, {"create", "serviceaccounts"}, {"create", "rolebindings"}},
+		},
+		"existing namespace without binding": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(superNSName, "12345", clusterKey)},
+			ExpectedActions:       [][2]string{{"create", "serviceaccounts"}, {"create", "rolebindings"}},
+		},
+		"existing namespace with binding": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(superNSName, "12345", clusterKey), existingBinding},
+			ExpectedActions:       [][2]string{},
+		},
+		"existing binding of another identity": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(superNSName, "12345", clusterKey), userBinding},
+			ExpectedActions:       [][2]string{{"update", "rolebindings"}},
+		},
+		"new namespace with user identity": {
+			TenantIdentity:        rbac.TenantIdentityUser,
+			ExistingObjectInSuper: []runtime.Object{},
+			ExpectedActions:       [][2]string{{"create", "namespaces"}, {"create", "rolebindings"}},
+			ExpectedSubject:       userBinding.Subjects[0],
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			vNamespace := tenantNamespace("default", "12345")
+			newController := func(config *config.SyncerConfiguration,
+				client clientset.Interface,
+				informer informers.SharedInformerFactory,
+				vcClient vcclient.Interface,
+				vcInformer vcinformers.VirtualClusterInformer,
+				options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+				config.TenantIdentity = tc.TenantIdentity
+				return NewNamespaceController(config, client, informer, vcClient, vcInformer, options)
+			}
+			actions, reconcileErr, err := util.RunDownwardSync(newController, testTenant, tc.ExistingObjectInSuper, []runtime.Object{vNamespace}, vNamespace, nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+			if reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+			}
+
+			if len(tc.ExpectedActions) != len(actions) {
+				t.Errorf("%s: Expected actions %v. Actual actions were: %#v", k, tc.ExpectedActions, actions)
+				return
+			}
+			for i, expected := range tc.ExpectedActions {
+				if !actions[i].Matches(expected[0], expected[1]) {
+					t.Errorf("%s: Unexpected action %s, expected %v", k, actions[i], expected)
+				}
+			}
+			if len(actions) == 0 {
+				return
+			}
+			expectedSubject := tc.ExpectedSubject
+			if expectedSubject.Name == "" {
+				expectedSubject = existingBinding.Subjects[0]
+			}
+			rb := actions[len(actions)-1].(core.CreateAction).GetObject().(*rbacv1.RoleBinding)
+			if rb.Namespace != superNSName || len(rb.Subjects) != 1 || rb.Subjects[0] != expectedSubject {
+				t.Errorf("%s: unexpected rolebinding %+v", k, rb)
+			}
+		})
+	}
+}
+
+func TestDWNamespaceMetaAllowList(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	clusterKey := conversion.ToClusterKey(testTenant)
+	superNSName := conversion.ToSuperClusterNamespace(clusterKey, "default")
+	existingNamespace := superNamespace(superNSName, "12345", clusterKey)
+	existingNamespace.Labels = map[string]string{"chargeback": "42"}
+
+	newController := func(cfg *config.SyncerConfiguration, client clientset.Interface, informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface, vcInformer vcinformers.VirtualClusterInformer, options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		cfg.TenantNamespaceMetaAllowList = []string{"team"}
+		return NewNamespaceController(cfg, client, informer, vcClient, vcInformer, options)
+	}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper []runtime.Object
+		ExpectedAction        string
+		ExpectedLabels        map[string]string
+	}{
+		"new namespace": {
+			ExistingObjectInSuper: []runtime.Object{},
+			ExpectedAction:        "create",
+			ExpectedLabels:        map[string]string{"team": "a"},
+		},
+		"existing namespace": {
+			ExistingObjectInSuper: []runtime.Object{existingNamespace},
+			ExpectedAction:        "update",
+			ExpectedLabels:        map[string]string{"team": "a", "chargeback": "42"},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			vNamespace := tenantNamespace("default", "12345")
+			vNamespace.Labels = map[string]string{"team": "a", "unsafe": "b"}
+			actions, reconcileErr, err := util.RunDownwardSync(newController, testTenant, tc.ExistingObjectInSuper, []runtime.Object{vNamespace}, vNamespace, nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+			if reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+			}
+			if len(actions) != 1 || !actions[0].Matches(tc.ExpectedAction, "namespaces") {
+				t.Fatalf("%s: Expected to %s namespace. Actual actions were: %#v", k, tc.ExpectedAction, actions)
+			}
+			ns := actions[0].(core.CreateAction).GetObject().(*corev1.Namespace)
+			if !equality.Semantic.DeepEqual(ns.Labels, tc.ExpectedLabels) {
+				t.Errorf("%s: expected labels %v, got %v", k, tc.ExpectedLabels, ns.Labels)
+			}
+		})
+	}
+}
+
 func TestDWNamespaceDeletion(t *testing.T) {
 	testTenant := &v1alpha1.VirtualCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -282,3 +461,205 @@ func TestDWNamespaceDeletion(t *testing.T) {
 		})
 	}
 }
+
+func TestDWNamespaceRecycleBin(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.NamespaceRecycleBin, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	clusterKey := conversion.ToClusterKey(testTenant)
+	superNSName := conversion.ToSuperClusterNamespace(clusterKey, "default")
+	retainedNamespace := func(until time.Time) *corev1.Namespace {
+		return applyAnnotationToNS(superNamespace(superNSName, "12345", clusterKey), constants.LabelRetainedUntil, until.UTC().Format(time.RFC3339))
+	}
+
+	newController := func(cfg *config.SyncerConfiguration, client clientset.Interface, informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface, vcInformer vcinformers.VirtualClusterInformer, options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		cfg.NamespaceRetentionPeriod = metav1.Duration{Duration: time.Hour}
+		return NewNamespaceController(cfg, client, informer, vcClient, vcInformer, options)
+	}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant *corev1.Namespace
+		EnqueueObject          *corev1.Namespace
+
+		ExpectedAction      string
+		ExpectedRetained    bool
+		ExpectedDelegateUID string
+	}{
+		"retain deleted namespace": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(superNSName, "12345", clusterKey)},
+			EnqueueObject:         tenantNamespace("default", "12345"),
+			ExpectedAction:        "update",
+			ExpectedRetained:      true,
+			ExpectedDelegateUID:   "12345",
+		},
+		"keep retained namespace": {
+			ExistingObjectInSuper: []runtime.Object{retainedNamespace(time.Now().Add(time.Hour))},
+			EnqueueObject:         tenantNamespace("default", "12345"),
+		},
+		"delete expired namespace": {
+			ExistingObjectInSuper: []runtime.Object{retainedNamespace(time.Now().Add(-time.Minute))},
+			EnqueueObject:         tenantNamespace("default", "12345"),
+			ExpectedAction:        "delete",
+		},
+		"restore recreated namespace": {
+			ExistingObjectInSuper:  []runtime.Object{retainedNamespace(time.Now().Add(time.Hour))},
+			ExistingObjectInTenant: tenantNamespace("default", "67890"),
+			EnqueueObject:          tenantNamespace("default", "67890"),
+			ExpectedAction:         "update",
+			ExpectedDelegateUID:    "67890",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			var existingInTenant []runtime.Object
+			if tc.ExistingObjectInTenant != nil {
+				existingInTenant = append(existingInTenant, tc.ExistingObjectInTenant)
+			}
+			actions, reconcileErr, err := util.RunDownwardSync(newController, testTenant, tc.ExistingObjectInSuper, existingInTenant, tc.EnqueueObject, nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+			if reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+			}
+			if tc.ExpectedAction == "" {
+				if len(actions) != 0 {
+					t.Errorf("%s: Expected no action. Actual actions were: %#v", k, actions)
+				}
+				return
+			}
+			if len(actions) != 1 || !actions[0].Matches(tc.ExpectedAction, "namespaces") {
+				t.Fatalf("%s: Expected to %s namespace. Actual actions were: %#v", k, tc.ExpectedAction, actions)
+			}
+			if tc.ExpectedAction != "update" {
+				return
+			}
+			ns := actions[0].(core.UpdateAction).GetObject().(*corev1.Namespace)
+			if _, retained := ns.Annotations[constants.LabelRetainedUntil]; retained != tc.ExpectedRetained {
+				t.Errorf("%s: expected retained %v, got annotations %v", k, tc.ExpectedRetained, ns.Annotations)
+			}
+			if ns.Annotations[constants.LabelUID] != tc.ExpectedDelegateUID {
+				t.Errorf("%s: expected delegated uid %s, got %s", k, tc.ExpectedDelegateUID, ns.Annotations[constants.LabelUID])
+			}
+		})
+	}
+}
+
+func TestDWNamespaceDeletionBarrier(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.NamespaceDeletionBarrier, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	clusterKey := conversion.ToClusterKey(testTenant)
+	superNSName := conversion.ToSuperClusterNamespace(clusterKey, "default")
+	tearingDown := func(started time.Time) *corev1.Namespace {
+		return applyAnnotationToNS(superNamespace(superNSName, "12345", clusterKey), constants.LabelTeardownStarted, started.UTC().Format(time.RFC3339))
+	}
+	superPod := func(name string, terminating bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: superNSName,
+				UID:       types.UID(name + "-uid"),
+			},
+		}
+		if terminating {
+			now := metav1.Now()
+			pod.DeletionTimestamp = &now
+		}
+		return pod
+	}
+	superService := func(name string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: superNSName, UID: types.UID(name + "-uid")}}
+	}
+	superClaim := func(name string, terminating bool) *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: superNSName, UID: types.UID(name + "-uid")}}
+		if terminating {
+			now := metav1.Now()
+			pvc.DeletionTimestamp = &now
+		}
+		return pvc
+	}
+
+	newController := func(cfg *config.SyncerConfiguration, client clientset.Interface, informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface, vcInformer vcinformers.VirtualClusterInformer, options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		cfg.NamespaceTeardownTimeout = metav1.Duration{Duration: time.Minute}
+		return NewNamespaceController(cfg, client, informer, vcClient, vcInformer, options)
+	}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper []runtime.Object
+		ExpectedActions       []string
+	}{
+		"start teardown": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(superNSName, "12345", clusterKey), superPod("web", false), superService("web"), superClaim("data", false)},
+			ExpectedActions:       []string{"update namespaces/" + superNSName, "delete pods/web"},
+		},
+		"wait for terminating pods": {
+			ExistingObjectInSuper: []runtime.Object{tearingDown(time.Now()), superPod("web", true), superService("web")},
+		},
+		"delete services and claims once pods are gone": {
+			ExistingObjectInSuper: []runtime.Object{tearingDown(time.Now()), superService("web"), superClaim("data", false)},
+			ExpectedActions:       []string{"delete services/web", "delete persistentvolumeclaims/data"},
+		},
+		"wait for terminating claims": {
+			ExistingObjectInSuper: []runtime.Object{tearingDown(time.Now()), superClaim("data", true)},
+		},
+		"delete namespace without children": {
+			ExistingObjectInSuper: []runtime.Object{tearingDown(time.Now())},
+			ExpectedActions:       []string{"delete namespaces/" + superNSName},
+		},
+		"delete namespace after timeout": {
+			ExistingObjectInSuper: []runtime.Object{tearingDown(time.Now().Add(-time.Hour)), superPod("web", true)},
+			ExpectedActions:       []string{"delete namespaces/" + superNSName},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(newController, testTenant, tc.ExistingObjectInSuper, nil, tenantNamespace("default", "12345"), nil)
+			if err != nil {
+				t.Fatalf("%s: error running downward sync: %v", k, err)
+			}
+			if reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+			}
+
+			var got []string
+			for _, action := range actions {
+				name := ""
+				switch a := action.(type) {
+				case core.DeleteAction:
+					name = a.GetName()
+				case core.UpdateAction:
+					name = a.GetObject().(metav1.Object).GetName()
+				}
+				got = append(got, action.GetVerb()+" "+action.GetResource().Resource+"/"+name)
+			}
+			if !equality.Semantic.DeepEqual(got, tc.ExpectedActions) {
+				t.Errorf("%s: expected actions %v, got %v", k, tc.ExpectedActions, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/freeze"
+)
+
+// freezeStarts records the time the sync freezes started on the super cluster namespaces, which the
+// tenants cannot change. The record is removed once the namespace is synced again.
+type freezeStarts struct {
+	namespaceClient v1core.NamespacesGetter
+	nsLister        listersv1.NamespaceLister
+}
+
+var _ freeze.StartRecorder = &freezeStarts{}
+
+func (f *freezeStarts) Start(clusterName, namespace string, now time.Time) (time.Time, error) {
+	pNamespace, err := f.nsLister.Get(conversion.ToSuperClusterNamespace(clusterName, namespace))
+	if apierrors.IsNotFound(err) {
+		// nothing of the namespace is synced yet
+		return now, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if started, err := time.Parse(time.RFC3339, pNamespace.Annotations[constants.LabelSyncFreezeStarted]); err == nil {
+		return started, nil
+	}
+	updated := pNamespace.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	updated.Annotations[constants.LabelSyncFreezeStarted] = now.UTC().Format(time.RFC3339)
+	_, err = f.namespaceClient.Namespaces().Update(context.TODO(), updated, metav1.UpdateOptions{})
+	if err != nil && !apierrors.IsConflict(err) {
+		return time.Time{}, err
+	}
+	// a conflict means the lister is stale, e.g. the start was just recorded
+	return now, nil
+}
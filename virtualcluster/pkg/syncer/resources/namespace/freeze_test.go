@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func TestFreezeStarts(t *testing.T) {
+	superName := conversion.ToSuperClusterNamespace("tenant", "default")
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: superName}})
+	informer := informers.NewSharedInformerFactory(client, 0).Core().V1().Namespaces()
+	starts := &freezeStarts{namespaceClient: client.CoreV1(), nsLister: informer.Lister()}
+
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	if started, err := starts.Start("tenant", "missing", now); err != nil || !started.Equal(now) {
+		t.Errorf("expected the freeze of a namespace that is not synced to start now, got %v, %v", started, err)
+	}
+
+	pNamespace, _ := client.CoreV1().Namespaces().Get(context.TODO(), superName, metav1.GetOptions{})
+	_ = informer.Informer().GetStore().Add(pNamespace)
+	if started, err := starts.Start("tenant", "default", now); err != nil || !started.Equal(now) {
+		t.Fatalf("expected the freeze to start now, got %v, %v", started, err)
+	}
+	pNamespace, _ = client.CoreV1().Namespaces().Get(context.TODO(), superName, metav1.GetOptions{})
+	if pNamespace.Annotations[constants.LabelSyncFreezeStarted] != "2022-03-01T12:00:00Z" {
+		t.Fatalf("expected the start of the freeze to be recorded, got %v", pNamespace.Annotations)
+	}
+
+	// the tenant renews the freeze later on
+	_ = informer.Informer().GetStore().Update(pNamespace)
+	if started, err := starts.Start("tenant", "default", now.Add(time.Hour)); err != nil || !started.Equal(now) {
+		t.Errorf("expected the renewed freeze to keep its start, got %v, %v", started, err)
+	}
+}
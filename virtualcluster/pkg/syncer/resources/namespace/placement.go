@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
+)
+
+// placementChange is a tenant namespace whose placements in this super cluster were changed by the
+// scheduler at scheduleTime.
+type placementChange struct {
+	clusterName  string
+	namespace    string
+	scheduleTime string
+}
+
+// newPlacementInformer watches the PlacementChanged events recorded by the scheduler in the super cluster.
+func newPlacementInformer(client clientset.Interface) cache.SharedIndexInformer {
+	return coreinformers.NewFilteredEventInformer(client, metav1.NamespaceDefault, 0, cache.Indexers{}, func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("reason", utilconstants.ReasonPlacementChanged).String()
+	})
+}
+
+func (c *controller) enqueuePlacementChange(obj interface{}) {
+	event, ok := obj.(*corev1.Event)
+	if !ok {
+		return
+	}
+	change := placementChange{
+		clusterName:  event.Annotations[constants.LabelCluster],
+		namespace:    event.Annotations[constants.LabelNamespace],
+		scheduleTime: event.Annotations[utilconstants.LabelLastScheduleTime],
+	}
+	if change.clusterName == "" || change.namespace == "" {
+		return
+	}
+	c.placementQueue.Add(change)
+}
+
+// runPlacementWorker handles the placement changes until stopCh is closed. The events recorded while the
+// syncer was down are replayed when the informer lists them, which is harmless as the changes are
+// reconciled against the current placements of the tenant namespaces.
+func (c *controller) runPlacementWorker(stopCh <-chan struct{}) {
+	go c.placementInformer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.placementInformer.HasSynced) {
+		klog.Errorf("failed to wait for the placement events cache to sync")
+		return
+	}
+	shutdown.RunWorkers("namespace placement", c.placementQueue, 1, c.placementWorker, time.Second, stopCh)
+}
+
+func (c *controller) placementWorker() {
+	for c.processNextPlacementChange() {
+	}
+}
+
+func (c *controller) processNextPlacementChange() bool {
+	obj, shutdown := c.placementQueue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.placementQueue.Done(obj)
+
+	change := obj.(placementChange)
+	if err := c.reconcilePlacementChange(change); err != nil {
+		if c.placementQueue.NumRequeues(obj) < utilconstants.MaxReconcileRetryAttempts {
+			klog.V(4).Infof("failed to reconcile the placements of namespace %s of cluster %s, retry: %v", change.namespace, change.clusterName, err)
+			c.placementQueue.AddRateLimited(obj)
+			return true
+		}
+		// the patroller still reconciles the namespace
+		klog.Warningf("drop the placement change of namespace %s of cluster %s: %v", change.namespace, change.clusterName, err)
+	}
+	c.placementQueue.Forget(obj)
+	return true
+}
+
+// reconcilePlacementChange requeues a tenant namespace placed in this super cluster, or removes the super
+// cluster namespace of a tenant namespace that is gone or placed in other super clusters, which the
+// downward syncer ignores.
+func (c *controller) reconcilePlacementChange(change placementChange) error {
+	if c.MultiClusterController.GetCluster(change.clusterName) == nil {
+		// the tenant cluster is not synced by this syncer
+		return nil
+	}
+	vNamespace := &corev1.Namespace{}
+	err := c.MultiClusterController.Get(change.clusterName, "", change.namespace, vNamespace)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if isStalePlacement(vNamespace, change.scheduleTime) {
+			return fmt.Errorf("the placements of the namespace are older than %s", change.scheduleTime)
+		}
+		if mc.IsNamespaceScheduledToCluster(vNamespace, utilconstants.SuperClusterID) == nil {
+			return c.MultiClusterController.RequeueObject(change.clusterName, vNamespace)
+		}
+	}
+
+	pNamespace, err := c.nsLister.Get(conversion.ToSuperClusterNamespace(change.clusterName, change.namespace))
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if clusterName, namespace := conversion.GetVirtualOwner(pNamespace); clusterName != change.clusterName || namespace != change.namespace {
+		return nil
+	}
+	if pNamespace.Annotations[constants.LabelVCRootNS] == "true" || pNamespace.DeletionTimestamp != nil {
+		return nil
+	}
+	klog.Infof("namespace %s of cluster %s is no longer placed in this super cluster", change.namespace, change.clusterName)
+	return c.retainOrDeleteNamespace(change.clusterName, pNamespace)
+}
+
+// isStalePlacement returns true if the informer cache of the tenant namespace has not observed the
+// placements scheduled at scheduleTime yet.
+func isStalePlacement(vNamespace *corev1.Namespace, scheduleTime string) bool {
+	notified, err := time.Parse(time.RFC3339, scheduleTime)
+	if err != nil {
+		return false
+	}
+	scheduled, err := time.Parse(time.RFC3339, vNamespace.Annotations[utilconstants.LabelLastScheduleTime])
+	if err != nil {
+		return false
+	}
+	return scheduled.Before(notified)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	fakevcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	vcinformerFactory "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+type nopReconciler struct{}
+
+func (nopReconciler) Reconcile(reconciler.Request) (reconciler.Result, error) {
+	return reconciler.Result{}, nil
+}
+
+func TestReconcilePlacementChange(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.SuperClusterPooling, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	defaultSuperNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+	scheduleTime := "2022-01-01T00:00:00Z"
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant *corev1.Namespace
+		ClusterName            string
+
+		ExpectedRequeued      bool
+		ExpectedDeletedObject string
+		ExpectedError         string
+	}{
+		"namespace placed in this super cluster": {
+			ExistingObjectInTenant: applyAnnotationToNS(tenantNamespace("default", "12345"), utilconst.LabelLastScheduleTime, scheduleTime),
+			ExpectedRequeued:       true,
+		},
+		"namespace placed in other super clusters": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(defaultSuperNSName, "12345", defaultClusterKey)},
+			ExistingObjectInTenant: applyAnnotationToNS(
+				applyAnnotationToNS(tenantNamespace("default", "12345"), utilconst.LabelScheduledPlacements, "{\"other\":1}"),
+				utilconst.LabelLastScheduleTime, scheduleTime),
+			ExpectedDeletedObject: defaultSuperNSName,
+		},
+		"namespace gone": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(defaultSuperNSName, "12345", defaultClusterKey)},
+			ExpectedDeletedObject: defaultSuperNSName,
+		},
+		"placements not observed yet": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(defaultSuperNSName, "12345", defaultClusterKey)},
+			ExistingObjectInTenant: applyAnnotationToNS(
+				applyAnnotationToNS(tenantNamespace("default", "12345"), utilconst.LabelScheduledPlacements, "{\"other\":1}"),
+				utilconst.LabelLastScheduleTime, "2021-12-31T23:59:00Z"),
+			ExpectedError: "older than",
+		},
+		"super cluster namespace of another tenant namespace": {
+			ExistingObjectInSuper: []runtime.Object{applyAnnotationToNS(superNamespace(defaultSuperNSName, "12345", defaultClusterKey), "tenancy.x-k8s.io/namespace", "other")},
+		},
+		"tenant cluster not synced": {
+			ExistingObjectInSuper: []runtime.Object{superNamespace(defaultSuperNSName, "12345", defaultClusterKey)},
+			ClusterName:           "unknown",
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			tenantClientset := fake.NewSimpleClientset()
+			tenantClientBuilder := fakeClient.NewClientBuilder()
+			if tc.ExistingObjectInTenant != nil {
+				tenantClientset = fake.NewSimpleClientset(tc.ExistingObjectInTenant)
+				tenantClientBuilder = tenantClientBuilder.WithRuntimeObjects(tc.ExistingObjectInTenant)
+			}
+			tenantCluster := cluster.NewFakeTenantCluster(testTenant, tenantClientset, tenantClientBuilder.Build())
+
+			superClient := fake.NewSimpleClientset(tc.ExistingObjectInSuper...)
+			superInformer := informers.NewSharedInformerFactory(superClient, 0)
+			for _, each := range tc.ExistingObjectInSuper {
+				_ = superInformer.Core().V1().Namespaces().Informer().GetStore().Add(each)
+			}
+			vcClient := fakevcclient.NewSimpleClientset()
+			vcInformer := vcinformerFactory.NewSharedInformerFactory(vcClient, 0).Tenancy().V1alpha1().VirtualClusters()
+
+			resourceSyncer, err := NewNamespaceController(&config.SyncerConfiguration{}, superClient, superInformer, vcClient, vcInformer,
+				manager.ResourceSyncerOptions{MCOptions: &mc.Options{Reconciler: nopReconciler{}}, IsFake: true})
+			if err != nil {
+				t.Fatalf("error creating controller: %v", err)
+			}
+			resourceSyncer.GetListener().AddCluster(tenantCluster)
+			resourceSyncer.GetListener().WatchCluster(tenantCluster)
+			defer resourceSyncer.GetListener().RemoveCluster(tenantCluster)
+			c := resourceSyncer.(*controller)
+
+			clusterName := tc.ClusterName
+			if clusterName == "" {
+				clusterName = defaultClusterKey
+			}
+			err = c.reconcilePlacementChange(placementChange{clusterName: clusterName, namespace: "default", scheduleTime: scheduleTime})
+			if tc.ExpectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.ExpectedError) {
+					t.Errorf("expected error %q, got %v", tc.ExpectedError, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if requeued := c.MultiClusterController.Queue.Len() == 1; requeued != tc.ExpectedRequeued {
+				t.Errorf("expected requeued %v, got %v", tc.ExpectedRequeued, requeued)
+			}
+			var deleted []string
+			for _, action := range superClient.Actions() {
+				if action.GetVerb() == "delete" {
+					deleted = append(deleted, action.(core.DeleteAction).GetName())
+				}
+			}
+			if tc.ExpectedDeletedObject == "" && len(deleted) != 0 {
+				t.Errorf("expected no deletion, got %v", deleted)
+			}
+			if tc.ExpectedDeletedObject != "" && (len(deleted) != 1 || deleted[0] != tc.ExpectedDeletedObject) {
+				t.Errorf("expected %s to be deleted, got %v", tc.ExpectedDeletedObject, deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+// teardownPollPeriod is the period the namespace syncer checks the progress of a teardown at.
+const teardownPollPeriod = 5 * time.Second
+
+func teardownEnabled() bool {
+	return featuregate.DefaultFeatureGate.Enabled(featuregate.NamespaceDeletionBarrier)
+}
+
+func (c *controller) teardownTimeout() time.Duration {
+	if c.Config.NamespaceTeardownTimeout.Duration > 0 {
+		return c.Config.NamespaceTeardownTimeout.Duration
+	}
+	return constants.DefaultNamespaceTeardownTimeout
+}
+
+// teardownNamespace deletes the children of the super cluster namespace of a deleted tenant
+// namespace, so that they terminate gracefully before the namespace is deleted. The start of the
+// teardown is recorded on the namespace. It returns the time to wait before checking the progress
+// again, the namespace can be deleted once it returns 0: all the children are gone or the teardown
+// timed out.
+func (c *controller) teardownNamespace(clusterName, targetNamespace, requestUID string, pNamespace *corev1.Namespace) (time.Duration, error) {
+	if pNamespace.Annotations[constants.LabelUID] != requestUID || pNamespace.DeletionTimestamp != nil {
+		// reconcileNamespaceRemove refuses it, or the namespace is already being deleted
+		return 0, nil
+	}
+	if err := conversion.VerifyOwnership(pNamespace); err != nil {
+		return 0, err
+	}
+
+	started, err := time.Parse(time.RFC3339, pNamespace.Annotations[constants.LabelTeardownStarted])
+	if err != nil {
+		started = time.Now()
+		updated := pNamespace.DeepCopy()
+		updated.Annotations[constants.LabelTeardownStarted] = started.UTC().Format(time.RFC3339)
+		if _, err := c.namespaceClient.Namespaces().Update(context.TODO(), updated, metav1.UpdateOptions{}); err != nil {
+			return 0, err
+		}
+		klog.Infof("tearing down namespace %s of cluster %s", targetNamespace, clusterName)
+	}
+
+	remaining, resource, err := c.deleteNamespaceChildren(targetNamespace)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case remaining == 0:
+		metrics.NamespaceTeardownDuration.WithLabelValues("completed").Observe(time.Since(started).Seconds())
+		return 0, nil
+	case time.Since(started) >= c.teardownTimeout():
+		klog.Warningf("teardown of namespace %s of cluster %s timed out with %d %s left, deleting the namespace", targetNamespace, clusterName, remaining, resource)
+		metrics.NamespaceTeardownDuration.WithLabelValues("timeout").Observe(time.Since(started).Seconds())
+		return 0, nil
+	}
+	klog.V(4).Infof("waiting for %d %s of namespace %s of cluster %s to be deleted", remaining, resource, targetNamespace, clusterName)
+	return teardownPollPeriod, nil
+}
+
+// deleteNamespaceChildren deletes the children of a super cluster namespace in order: the pods
+// first, then the services, so that their load balancers are released, and the claims, which the
+// pvc-protection finalizer keeps until their pods are gone anyway. It returns the number of
+// children of the current step left and their resource, 0 once all of them are gone.
+func (c *controller) deleteNamespaceChildren(namespace string) (int, string, error) {
+	pods, err := c.podLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return 0, "", err
+	}
+	podObjs := make([]metav1.Object, 0, len(pods))
+	for _, pod := range pods {
+		podObjs = append(podObjs, pod)
+	}
+	remaining, err := deleteChildren(podObjs, func(name string, opts metav1.DeleteOptions) error {
+		return c.podClient.Pods(namespace).Delete(context.TODO(), name, opts)
+	})
+	if err != nil || remaining > 0 {
+		return remaining, "pods", err
+	}
+
+	services, err := c.svcLister.Services(namespace).List(labels.Everything())
+	if err != nil {
+		return 0, "", err
+	}
+	claims, err := c.pvcLister.PersistentVolumeClaims(namespace).List(labels.Everything())
+	if err != nil {
+		return 0, "", err
+	}
+	svcObjs := make([]metav1.Object, 0, len(services))
+	for _, svc := range services {
+		svcObjs = append(svcObjs, svc)
+	}
+	pvcObjs := make([]metav1.Object, 0, len(claims))
+	for _, pvc := range claims {
+		pvcObjs = append(pvcObjs, pvc)
+	}
+	svcRemaining, err := deleteChildren(svcObjs, func(name string, opts metav1.DeleteOptions) error {
+		return c.svcClient.Services(namespace).Delete(context.TODO(), name, opts)
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	pvcRemaining, err := deleteChildren(pvcObjs, func(name string, opts metav1.DeleteOptions) error {
+		return c.pvcClient.PersistentVolumeClaims(namespace).Delete(context.TODO(), name, opts)
+	})
+	return svcRemaining + pvcRemaining, "services and claims", err
+}
+
+// deleteChildren deletes the objects owned by the syncer which are not terminating yet, it returns
+// the number of them left.
+func deleteChildren(objs []metav1.Object, deleteFn func(name string, opts metav1.DeleteOptions) error) (int, error) {
+	remaining := 0
+	for _, obj := range objs {
+		if err := conversion.VerifyOwnership(obj); err != nil {
+			continue
+		}
+		remaining++
+		if obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		opts := metav1.DeleteOptions{Preconditions: metav1.NewUIDPreconditions(string(obj.GetUID()))}
+		if err := deleteFn(obj.GetName(), opts); err != nil && !apierrors.IsNotFound(err) {
+			return 0, err
+		}
+	}
+	return remaining, nil
+}
+
+// recordStuckTerminations reports the super cluster namespaces terminating for longer than the
+// teardown timeout, by virtual cluster.
+func (c *controller) recordStuckTerminations(pList []*corev1.Namespace) {
+	stuck := map[string]int{}
+	for _, p := range pList {
+		if p.DeletionTimestamp == nil || time.Since(p.DeletionTimestamp.Time) < c.teardownTimeout() {
+			continue
+		}
+		clusterName, _ := conversion.GetVirtualOwner(p)
+		if clusterName == "" {
+			continue
+		}
+		klog.Warningf("namespace %s of cluster %s is terminating since %s", p.Name, clusterName, p.DeletionTimestamp.Format(time.RFC3339))
+		stuck[clusterName]++
+	}
+	metrics.NamespaceStuckTerminations.Reset()
+	for clusterName, n := range stuck {
+		metrics.NamespaceStuckTerminations.WithLabelValues(clusterName).Set(float64(n))
+	}
+}
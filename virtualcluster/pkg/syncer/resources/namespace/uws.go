@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"context"
+	"fmt"
+
+	pkgerr "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// StartUWS starts the upward syncer, which propagates the super cluster
+// namespace keys in SuperNamespaceMetaAllowList back to the tenant namespaces
+func (c *controller) StartUWS(stopCh <-chan struct{}) error {
+	if c.UpwardController == nil {
+		return nil
+	}
+	if !cache.WaitForCacheSync(stopCh, c.nsSynced) {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	return c.UpwardController.Start(stopCh)
+}
+
+func (c *controller) BackPopulate(key string) error {
+	pNamespace, err := c.nsLister.Get(key)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	clusterName, vName := pNamespace.Annotations[constants.LabelCluster], pNamespace.Annotations[constants.LabelNamespace]
+	if clusterName == "" || vName == "" {
+		return nil
+	}
+
+	vNamespace := &corev1.Namespace{}
+	if err := c.MultiClusterController.Get(clusterName, "", vName, vNamespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return pkgerr.Wrapf(err, "could not find pNamespace %s's vNamespace in controller cache", key)
+	}
+	if pNamespace.Annotations[constants.LabelUID] != string(vNamespace.UID) {
+		return fmt.Errorf("backPopulated pNamespace %s delegated UID is different from updated object", key)
+	}
+
+	updated := conversion.NewNamespaceMetaPolicy(c.Config).CheckUWNamespaceEquality(pNamespace, vNamespace)
+	if updated == nil {
+		return nil
+	}
+
+	tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
+	if err != nil {
+		return pkgerr.Wrapf(err, "failed to create client from cluster %s config", clusterName)
+	}
+	if _, err := tenantClient.CoreV1().Namespaces().Update(context.TODO(), updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to back populate namespace %s meta update for cluster %s: %v", vName, clusterName, err)
+	}
+	return nil
+}
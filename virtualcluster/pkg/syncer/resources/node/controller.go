@@ -26,6 +26,7 @@ import (
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	listersv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
 
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
@@ -50,10 +51,14 @@ func init() {
 
 type controller struct {
 	manager.BaseResourceSyncer
-	// lock to protect nodeNameToCluster
+	// lock to protect nodeNameToCluster and limiters
 	sync.Mutex
 	// phyical node to tenant cluster map. A physical node can be presented as virtual node in multiple tenant clusters.
 	nodeNameToCluster map[string]map[string]struct{}
+	// per tenant cluster rate limiters of vNode status and lease writes
+	limiters map[string]flowcontrol.RateLimiter
+	// reloaded vNode update limits, they take precedence over the ones of Config
+	updateLimits *updateLimits
 	// super control plane node client
 	nodeClient v1core.NodesGetter
 	// super control plane node lister/synced function
@@ -73,6 +78,7 @@ func NewNodeController(config *config.SyncerConfiguration,
 			Config: config,
 		},
 		nodeNameToCluster: make(map[string]map[string]struct{}),
+		limiters:          make(map[string]flowcontrol.RateLimiter),
 		nodeClient:        client.CoreV1(),
 		vnodeProvider:     vnode.GetNodeProvider(config, client),
 	}
@@ -107,8 +113,14 @@ func NewNodeController(config *config.SyncerConfiguration,
 				}
 
 				if equality.Semantic.DeepEqual(newNode.Status.Conditions, oldNode.Status.Conditions) &&
-					equality.Semantic.DeepEqual(newNode.Status.Addresses, oldNode.Status.Addresses) {
-					// We only update tenant virtual nodes if there are condition or addresses changes, e.g., updating LastHeartBeatTime.
+					equality.Semantic.DeepEqual(newNode.Status.Addresses, oldNode.Status.Addresses) &&
+					newNode.Spec.Unschedulable == oldNode.Spec.Unschedulable &&
+					equality.Semantic.DeepEqual(newNode.Spec.Taints, oldNode.Spec.Taints) &&
+					equality.Semantic.DeepEqual(newNode.Status.Capacity, oldNode.Status.Capacity) &&
+					equality.Semantic.DeepEqual(newNode.Status.Allocatable, oldNode.Status.Allocatable) {
+					// We only update tenant virtual nodes if there are condition, addresses, cordon, taint or
+					// resource changes, e.g., not for updating LastHeartBeatTime. Device plugins advertise
+					// their extended resources after the node registered.
 					return
 				}
 
@@ -120,6 +132,19 @@ func NewNodeController(config *config.SyncerConfiguration,
 	return c, nil
 }
 
+// ReloadConfig applies the reloaded vNode update limits, the rate limiters are recreated on the next update.
+func (c *controller) ReloadConfig(cfg *config.SyncerConfiguration) {
+	c.BaseResourceSyncer.ReloadConfig(cfg)
+	c.Lock()
+	defer c.Unlock()
+	limits := &updateLimits{qps: cfg.TenantNodeUpdateQPS, burst: cfg.TenantNodeUpdateBurst}
+	if c.updateLimits != nil && *c.updateLimits == *limits {
+		return
+	}
+	c.updateLimits = limits
+	c.limiters = make(map[string]flowcontrol.RateLimiter)
+}
+
 func (c *controller) SetVNodeProvider(provider provider.VirtualNodeProvider) {
 	c.Lock()
 	c.vnodeProvider = provider
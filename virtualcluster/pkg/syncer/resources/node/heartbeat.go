@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode"
+)
+
+const (
+	// maxConcurrentClusterUpdates bounds the tenant clusters updated in parallel for one node.
+	maxConcurrentClusterUpdates = 16
+
+	// defaultNodeLeaseDurationSeconds is used when the configuration does not set a lease duration.
+	defaultNodeLeaseDurationSeconds int32 = 40
+)
+
+// updateLimits rate-limit the vNode status and lease writes to a tenant cluster.
+type updateLimits struct {
+	qps   float32
+	burst int
+}
+
+// getClusterLimiter returns the rate limiter of vNode writes to the given tenant cluster.
+func (c *controller) getClusterLimiter(clusterName string) flowcontrol.RateLimiter {
+	c.Lock()
+	defer c.Unlock()
+	limiter, ok := c.limiters[clusterName]
+	if !ok {
+		limits := c.updateLimits
+		if limits == nil && c.Config != nil {
+			limits = &updateLimits{qps: c.Config.TenantNodeUpdateQPS, burst: c.Config.TenantNodeUpdateBurst}
+		}
+		if limits == nil || limits.qps <= 0 {
+			limiter = flowcontrol.NewFakeAlwaysRateLimiter()
+		} else {
+			burst := limits.burst
+			if burst < 1 {
+				burst = 1
+			}
+			limiter = flowcontrol.NewTokenBucketRateLimiter(limits.qps, burst)
+		}
+		c.limiters[clusterName] = limiter
+	}
+	return limiter
+}
+
+func (c *controller) leaseDurationSeconds() int32 {
+	if c.Config == nil || c.Config.NodeLeaseDurationSeconds <= 0 {
+		return defaultNodeLeaseDurationSeconds
+	}
+	return c.Config.NodeLeaseDurationSeconds
+}
+
+// leaseRenewInterval follows the kubelet, which renews its lease every quarter of the lease duration.
+func (c *controller) leaseRenewInterval() time.Duration {
+	return time.Duration(c.leaseDurationSeconds()) * time.Second / 4
+}
+
+// renewLeases renews the lease of every vNode whose super cluster node is ready.
+// Unlike node status, which is only written on changes, leases are the vNode heartbeats.
+func (c *controller) renewLeases() {
+	c.Lock()
+	nodeToClusters := make(map[string][]string, len(c.nodeNameToCluster))
+	for nodeName, clusters := range c.nodeNameToCluster {
+		for clusterName := range clusters {
+			nodeToClusters[nodeName] = append(nodeToClusters[nodeName], clusterName)
+		}
+	}
+	c.Unlock()
+
+	for nodeName, clusterList := range nodeToClusters {
+		node, err := c.getSuperNode(nodeName)
+		if err != nil {
+			klog.Errorf("failed to get node %s: %v", nodeName, err)
+			continue
+		}
+		if node == nil || !vnode.IsNodeReady(node) {
+			// let the tenant node lifecycle controller notice the vNode is not ready.
+			continue
+		}
+		for _, clusterName := range clusterList {
+			if !c.getClusterLimiter(clusterName).TryAccept() {
+				klog.V(4).Infof("lease renewal of node %s/%s is throttled", clusterName, nodeName)
+				continue
+			}
+			tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
+			if err != nil {
+				klog.Errorf("failed to create client from cluster %s config: %v", clusterName, err)
+				continue
+			}
+			vNode := &corev1.Node{}
+			if err := c.MultiClusterController.Get(clusterName, "", nodeName, vNode); err != nil {
+				if !apierrors.IsNotFound(err) {
+					klog.Errorf("failed to get node %s/%s: %v", clusterName, nodeName, err)
+				}
+				continue
+			}
+			if err := c.renewNodeLease(tenantClient, vNode); err != nil {
+				klog.Errorf("failed to renew node %s/%s's lease: %v", clusterName, nodeName, err)
+			}
+		}
+	}
+}
+
+// renewNodeLease creates or renews the lease of vNode in the tenant node lease namespace.
+func (c *controller) renewNodeLease(client clientset.Interface, vNode *corev1.Node) error {
+	leaseClient := client.CoordinationV1().Leases(corev1.NamespaceNodeLease)
+	now := metav1.NewMicroTime(time.Now())
+	lease, err := leaseClient.Get(context.TODO(), vNode.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leaseClient.Create(context.TODO(), newNodeLease(vNode, c.leaseDurationSeconds(), now), metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	lease = lease.DeepCopy()
+	lease.Spec.HolderIdentity = pointer.StringPtr(vNode.Name)
+	lease.Spec.LeaseDurationSeconds = pointer.Int32Ptr(c.leaseDurationSeconds())
+	lease.Spec.RenewTime = &now
+	if len(lease.OwnerReferences) == 0 {
+		lease.OwnerReferences = nodeLeaseOwnerReferences(vNode)
+	}
+	_, err = leaseClient.Update(context.TODO(), lease, metav1.UpdateOptions{})
+	return err
+}
+
+func newNodeLease(vNode *corev1.Node, durationSeconds int32, now metav1.MicroTime) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            vNode.Name,
+			Namespace:       corev1.NamespaceNodeLease,
+			OwnerReferences: nodeLeaseOwnerReferences(vNode),
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       pointer.StringPtr(vNode.Name),
+			LeaseDurationSeconds: pointer.Int32Ptr(durationSeconds),
+			RenewTime:            &now,
+		},
+	}
+}
+
+// nodeLeaseOwnerReferences makes the lease garbage collected together with the vNode.
+func nodeLeaseOwnerReferences(vNode *corev1.Node) []metav1.OwnerReference {
+	if vNode.UID == "" {
+		return nil
+	}
+	return []metav1.OwnerReference{
+		{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Node",
+			Name:       vNode.Name,
+			UID:        vNode.UID,
+		},
+	}
+}
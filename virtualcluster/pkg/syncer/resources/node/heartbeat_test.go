@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+)
+
+func TestRenewNodeLease(t *testing.T) {
+	vNode := makeNode("n1")
+	vNode.UID = "d5f7d1d4-8a66-4b2e-9b5e-1a2b3c4d5e6f"
+	staleTime := metav1.NewMicroTime(time.Now().Add(-10 * time.Minute))
+
+	for name, tc := range map[string]struct {
+		existing *coordinationv1.Lease
+	}{
+		"create lease": {},
+		"renew lease": {
+			existing: &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "n1",
+					Namespace: corev1.NamespaceNodeLease,
+				},
+				Spec: coordinationv1.LeaseSpec{
+					HolderIdentity: pointer.StringPtr("n1"),
+					RenewTime:      &staleTime,
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			if tc.existing != nil {
+				client = fake.NewSimpleClientset(tc.existing)
+			}
+			c := &controller{}
+			c.Config = &config.SyncerConfiguration{NodeLeaseDurationSeconds: 20}
+
+			if err := c.renewNodeLease(client, vNode); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			lease, err := client.CoordinationV1().Leases(corev1.NamespaceNodeLease).Get(context.TODO(), "n1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get lease: %v", err)
+			}
+			if lease.Spec.RenewTime == nil || !lease.Spec.RenewTime.After(staleTime.Time) {
+				t.Errorf("expected lease to be renewed, got %v", lease.Spec.RenewTime)
+			}
+			if lease.Spec.LeaseDurationSeconds == nil || *lease.Spec.LeaseDurationSeconds != 20 {
+				t.Errorf("expected lease duration 20, got %v", lease.Spec.LeaseDurationSeconds)
+			}
+			if len(lease.OwnerReferences) != 1 || lease.OwnerReferences[0].UID != vNode.UID {
+				t.Errorf("expected lease to be owned by the vNode, got %v", lease.OwnerReferences)
+			}
+		})
+	}
+}
+
+func TestGetClusterLimiter(t *testing.T) {
+	c := &controller{limiters: make(map[string]flowcontrol.RateLimiter)}
+	c.Config = &config.SyncerConfiguration{TenantNodeUpdateQPS: 1, TenantNodeUpdateBurst: 2}
+
+	limiter := c.getClusterLimiter("tenant-1")
+	if limiter != c.getClusterLimiter("tenant-1") {
+		t.Errorf("expected the limiter to be reused for the same cluster")
+	}
+	accepted := 0
+	for i := 0; i < 5; i++ {
+		if limiter.TryAccept() {
+			accepted++
+		}
+	}
+	if accepted != 2 {
+		t.Errorf("expected burst of 2 writes to be accepted, got %d", accepted)
+	}
+	if !c.getClusterLimiter("tenant-2").TryAccept() {
+		t.Errorf("expected clusters to be limited independently")
+	}
+
+	c = &controller{limiters: make(map[string]flowcontrol.RateLimiter)}
+	c.Config = &config.SyncerConfiguration{}
+	for i := 0; i < 100; i++ {
+		if !c.getClusterLimiter("tenant-1").TryAccept() {
+			t.Fatalf("expected no limit when qps is zero")
+		}
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// coordinateMaintenance evicts the tenant pods of a vNode whose super cluster node is cordoned.
+// The evictions go through the tenant eviction API so that tenant PodDisruptionBudgets are
+// respected, an error is returned to retry later if any eviction is blocked by a budget.
+func (c *controller) coordinateMaintenance(clusterName string, tenantClient clientset.Interface, vNode, newVNode *corev1.Node) error {
+	wasUnderMaintenance := hasMaintenanceTaint(vNode)
+	if !hasMaintenanceTaint(newVNode) {
+		if wasUnderMaintenance {
+			c.recordNodeEvent(clusterName, vNode, corev1.EventTypeNormal, constants.ReasonNodeMaintenanceCompleted,
+				"Super cluster node of %s is no longer under maintenance", vNode.Name)
+		}
+		return nil
+	}
+	if !wasUnderMaintenance {
+		c.recordNodeEvent(clusterName, vNode, corev1.EventTypeWarning, constants.ReasonNodeMaintenance,
+			"Super cluster node of %s is cordoned for maintenance, pods on it will be evicted", vNode.Name)
+	}
+
+	podList, err := tenantClient.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", vNode.Name).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods on node %s/%s: %v", clusterName, vNode.Name, err)
+	}
+
+	var blocked []string
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if !needsMaintenanceEviction(pod) {
+			continue
+		}
+		err := tenantClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(context.TODO(), &policyv1beta1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+			DeleteOptions: &metav1.DeleteOptions{
+				Preconditions: metav1.NewUIDPreconditions(string(pod.UID)),
+			},
+		})
+		switch {
+		case err == nil:
+			c.recordPodEvent(clusterName, pod, corev1.EventTypeNormal, "NodeMaintenanceEviction",
+				"Evicted because super cluster node of %s is under maintenance", vNode.Name)
+		case apierrors.IsNotFound(err) || apierrors.IsConflict(err):
+			// the pod is gone or has been recreated.
+		case apierrors.IsTooManyRequests(err):
+			blocked = append(blocked, pod.Namespace+"/"+pod.Name)
+			c.recordPodEvent(clusterName, pod, corev1.EventTypeWarning, "NodeMaintenanceEvictionBlocked",
+				"Eviction for the maintenance of node %s is blocked by a disruption budget: %v", vNode.Name, err)
+		default:
+			return fmt.Errorf("failed to evict pod %s/%s in cluster %s: %v", pod.Namespace, pod.Name, clusterName, err)
+		}
+	}
+
+	if len(blocked) > 0 {
+		return fmt.Errorf("evictions of pods %v on node %s/%s are blocked by disruption budgets", blocked, clusterName, vNode.Name)
+	}
+	return nil
+}
+
+func hasMaintenanceTaint(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == constants.TaintSuperNodeMaintenance {
+			return true
+		}
+	}
+	return false
+}
+
+// needsMaintenanceEviction follows kubectl drain, which leaves terminating, finished and DaemonSet pods alone.
+func needsMaintenanceEviction(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
+
+func (c *controller) recordNodeEvent(clusterName string, vNode *corev1.Node, eventType, reason, messageFmt string, args ...interface{}) {
+	ref := &corev1.ObjectReference{
+		Kind:       "Node",
+		APIVersion: corev1.SchemeGroupVersion.String(),
+		Name:       vNode.Name,
+		UID:        vNode.UID,
+	}
+	if err := c.MultiClusterController.Eventf(clusterName, ref, eventType, reason, messageFmt, args...); err != nil {
+		klog.Errorf("failed to record event for node %s/%s: %v", clusterName, vNode.Name, err)
+	}
+}
+
+func (c *controller) recordPodEvent(clusterName string, pod *corev1.Pod, eventType, reason, messageFmt string, args ...interface{}) {
+	ref := &corev1.ObjectReference{
+		Kind:       "Pod",
+		APIVersion: corev1.SchemeGroupVersion.String(),
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		UID:        pod.UID,
+	}
+	if err := c.MultiClusterController.Eventf(clusterName, ref, eventType, reason, messageFmt, args...); err != nil {
+		klog.Errorf("failed to record event for pod %s/%s/%s: %v", clusterName, pod.Namespace, pod.Name, err)
+	}
+}
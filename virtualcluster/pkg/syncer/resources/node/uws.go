@@ -21,11 +21,16 @@ import (
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
 )
@@ -36,23 +41,43 @@ func (c *controller) StartUWS(stopCh <-chan struct{}) error {
 	if !cache.WaitForCacheSync(stopCh, c.nodeSynced) {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantNodeLease) {
+		go wait.Until(c.renewLeases, c.leaseRenewInterval(), stopCh)
+	}
 	return c.UpwardController.Start(stopCh)
 }
 
 func (c *controller) enqueueNode(obj interface{}) {
 	node := obj.(*corev1.Node)
-	c.UpwardController.AddToQueue(node.Name)
+	c.UpwardController.AddToQueue(vnode.TenantNodeName(c.Config, node))
 }
 
-func (c *controller) BackPopulate(nodeName string) error {
+// getSuperNode returns the super cluster node presented by the vNode, or nil if it does not exist.
+func (c *controller) getSuperNode(nodeName string) (*corev1.Node, error) {
+	if vnode.IsAggregatedNodeName(nodeName) {
+		nodes, err := c.nodeLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		// The pool may be empty, the vNode will be garbage collected once it has no pods.
+		return vnode.AggregateNodes(nodeName, vnode.FilterPoolNodes(c.Config, nodeName, nodes)), nil
+	}
 	node, err := c.nodeLister.Get(nodeName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return node, err
+}
+
+func (c *controller) BackPopulate(nodeName string) error {
+	node, err := c.getSuperNode(nodeName)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// TODO: notify every tenant.
-			return nil
-		}
 		return err
 	}
+	if node == nil {
+		// TODO: notify every tenant.
+		return nil
+	}
 	klog.V(4).Infof("back populate node %s/%s", node.Namespace, node.Name)
 	c.Lock()
 	clusterList := make([]string, 0, len(c.nodeNameToCluster[node.Name]))
@@ -65,19 +90,54 @@ func (c *controller) BackPopulate(nodeName string) error {
 		return nil
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(clusterList))
+	// Fan out to the tenants with bounded concurrency, a node shared by many tenants
+	// should not spawn a goroutine per tenant on every change.
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		throttled []string
+		errs      []error
+	)
+	clusterCh := make(chan string, len(clusterList))
 	for _, clusterName := range clusterList {
-		go c.updateClusterNode(clusterName, node, &wg)
+		clusterCh <- clusterName
+	}
+	close(clusterCh)
+	workers := maxConcurrentClusterUpdates
+	if len(clusterList) < workers {
+		workers = len(clusterList)
+	}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for clusterName := range clusterCh {
+				if !c.getClusterLimiter(clusterName).TryAccept() {
+					mu.Lock()
+					throttled = append(throttled, clusterName)
+					mu.Unlock()
+					continue
+				}
+				if err := c.updateClusterNode(clusterName, node); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
 	}
 	wg.Wait()
 
-	return nil
+	if len(throttled) > 0 {
+		// requeue the node, the next attempt skips the tenants that are up to date.
+		errs = append(errs, fmt.Errorf("node %s updates are throttled for clusters %v", node.Name, throttled))
+	}
+	return utilerrors.NewAggregate(errs)
 }
 
-func (c *controller) updateClusterNode(clusterName string, node *corev1.Node, wg *sync.WaitGroup) {
-	defer wg.Done()
-
+// updateClusterNode updates the vNode of the given tenant cluster. Only errors that require
+// the node to be requeued, e.g., pending maintenance evictions, are returned.
+func (c *controller) updateClusterNode(clusterName string, node *corev1.Node) error {
 	tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
 	if err != nil {
 		klog.Errorf("failed to create client from cluster %s config: %v", clusterName, err)
@@ -85,7 +145,7 @@ func (c *controller) updateClusterNode(clusterName string, node *corev1.Node, wg
 		c.Lock()
 		delete(c.nodeNameToCluster[node.Name], clusterName)
 		c.Unlock()
-		return
+		return nil
 	}
 
 	vNode := &corev1.Node{}
@@ -98,7 +158,7 @@ func (c *controller) updateClusterNode(clusterName string, node *corev1.Node, wg
 			}
 			c.Unlock()
 		}
-		return
+		return nil
 	}
 
 	newVNode := vNode.DeepCopy()
@@ -106,20 +166,55 @@ func (c *controller) updateClusterNode(clusterName string, node *corev1.Node, wg
 	vNodeAddress, err := c.vnodeProvider.GetNodeAddress(node)
 	if err != nil {
 		klog.Errorf("unable get node address from provider: %v", err)
-		return
+		return nil
 	}
 	newVNode.Status.Addresses = vNodeAddress
 	nodeDaemonEndpoints, err := c.vnodeProvider.GetNodeDaemonEndpoints(node)
 	if err != nil {
 		klog.Errorf("unable get node daemon endpoints from provider: %v", err)
-		return
+		return nil
 	}
 	newVNode.Status.DaemonEndpoints = nodeDaemonEndpoints
 
+	// The capacity of a node changes as device plugins register their extended resources, and
+	// the one of a node pool as nodes join or leave.
+	newVNode.Status.Capacity = node.Status.Capacity
+	newVNode.Status.Allocatable = node.Status.Allocatable
+
 	newVNode.Spec.Taints = provider.GetNodeTaints(c.vnodeProvider, node, metav1.Now())
 	newVNode.ObjectMeta.SetLabels(provider.GetNodeLabels(c.vnodeProvider, node))
 
-	if err := vnode.UpdateNode(tenantClient.CoreV1().Nodes(), vNode, newVNode); err != nil {
-		klog.Errorf("failed to update node %s/%s's heartbeats: %v", clusterName, node.Name, err)
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantNodeLease) && vnode.IsNodeReady(node) {
+		if err := c.renewNodeLease(tenantClient, vNode); err != nil {
+			klog.Errorf("failed to renew node %s/%s's lease: %v", clusterName, vNode.Name, err)
+		}
+	}
+
+	if !vNodeUpToDate(vNode, newVNode) {
+		if err := vnode.UpdateNode(tenantClient.CoreV1().Nodes(), vNode, newVNode); err != nil {
+			klog.Errorf("failed to update node %s/%s's heartbeats: %v", clusterName, node.Name, err)
+			return nil
+		}
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.NodeMaintenanceEviction) {
+		return c.coordinateMaintenance(clusterName, tenantClient, vNode, newVNode)
+	}
+	return nil
+}
+
+// vNodeUpToDate returns true if newVNode carries no change that UpdateNode would write.
+func vNodeUpToDate(vNode, newVNode *corev1.Node) bool {
+	return equality.Semantic.DeepEqual(vNode.Status, newVNode.Status) &&
+		equality.Semantic.DeepEqual(vNode.Labels, newVNode.Labels) &&
+		equality.Semantic.DeepEqual(withoutTaintTime(vNode.Spec), withoutTaintTime(newVNode.Spec))
+}
+
+// withoutTaintTime drops the taint TimeAdded, which GetNodeTaints always sets to now.
+func withoutTaintTime(spec corev1.NodeSpec) corev1.NodeSpec {
+	spec = *spec.DeepCopy()
+	for i := range spec.Taints {
+		spec.Taints[i].TimeAdded = nil
 	}
+	return spec
 }
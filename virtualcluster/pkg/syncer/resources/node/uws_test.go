@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	core "k8s.io/client-go/testing"
@@ -76,6 +77,7 @@ func TestUWNode(t *testing.T) {
 		ExistingObjectInTenant []runtime.Object
 		EnqueuedKey            string
 		ExpectedUpdatedObject  []string
+		ExpectedPatchContains  string
 		ExpectedNoOperation    bool
 		ExpectedError          string
 		StateModifyFunc        func(manager.ResourceSyncer)
@@ -112,6 +114,25 @@ func TestUWNode(t *testing.T) {
 				"n1",
 			},
 		},
+		"pNode advertises extended resources": {
+			ExistingObjectInSuper: []runtime.Object{
+				func() *corev1.Node {
+					node := makeNode("n1")
+					node.Status.Capacity = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")}
+					node.Status.Allocatable = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")}
+					return node
+				}(),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				makeNode("n1"),
+			},
+			EnqueuedKey:     "n1",
+			StateModifyFunc: mFunc1,
+			ExpectedUpdatedObject: []string{
+				"n1",
+			},
+			ExpectedPatchContains: `"nvidia.com/gpu":"4"`,
+		},
 	}
 
 	for k, tc := range testcases {
@@ -152,6 +173,9 @@ func TestUWNode(t *testing.T) {
 					if name != expectedName {
 						t.Errorf("%s: Expected %s to be patched, got %s", k, expectedName, name)
 					}
+					if patch := string(action.(core.PatchAction).GetPatch()); !strings.Contains(patch, tc.ExpectedPatchContains) {
+						t.Errorf("%s: Expected patch to contain %s, got %s", k, tc.ExpectedPatchContains, patch)
+					}
 					matched = true
 					break
 				}
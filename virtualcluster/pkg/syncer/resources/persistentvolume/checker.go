@@ -148,7 +148,7 @@ func (c *controller) PatrollerDo() {
 	}
 
 	pSet.Difference(vSet, differ.FilteringHandler{
-		Handler: d,
+		Handler: differ.FreezeHandler{Frozen: c.IsFrozen, Handler: differ.BudgetHandler{Budget: c.RemediationBudget(), Handler: d}},
 		FilterFunc: func(obj differ.ClusterObject) bool {
 			// if both vObj pObj exists, pObj may not pass the filter.
 			// differ will skip this onUpdate.
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -101,15 +102,26 @@ func (c *controller) PatrollerDo() {
 			d.OnDelete(pObj)
 			return
 		}
+		if c.FinalizerTranslator().Expired(p) {
+			if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
+				klog.Errorf("error requeue vPVC %v/%v in cluster %s: %v", vObj.GetNamespace(), vObj.GetName(), vObj.GetOwnerCluster(), err)
+			} else {
+				metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantPVCs").Inc()
+			}
+			return
+		}
 		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, vObj.GetOwnerCluster())
 		if err != nil {
 			klog.Errorf("fail to get cluster spec : %s", vObj.GetOwnerCluster())
 			return
 		}
-		updatedPVC := conversion.Equality(c.Config, vc).CheckPVCEquality(p, v)
-		if updatedPVC != nil {
-			atomic.AddUint64(&numMissMatchedPVCs, 1)
-			klog.Warningf("spec of pvc %s diff in super&tenant control plane", pObj.Key)
+		if !conversion.PatrolSkipsEquality(p, v) {
+			if conversion.Equality(c.Config, vc).CheckPVCEquality(p, v) != nil {
+				atomic.AddUint64(&numMissMatchedPVCs, 1)
+				klog.Warningf("spec of pvc %s diff in super&tenant control plane", pObj.Key)
+			} else {
+				conversion.RecordPatrolEquality(p)
+			}
 		}
 
 		if conversion.Equality(c.Config, vc).CheckUWPVCStatusEquality(p, v) != nil {
@@ -118,6 +130,13 @@ func (c *controller) PatrollerDo() {
 		}
 	}
 	d.DeleteFunc = func(pObj differ.ClusterObject) {
+		if err := conversion.VerifyOwnership(pObj); err != nil {
+			klog.Errorf("refusing to delete pPVC %s in super control plane: %v", pObj.Key, err)
+			return
+		}
+		if retained, err := recyclebin.Retains(c.MultiClusterController, pObj); err != nil || retained {
+			return
+		}
 		deleteOptions := &metav1.DeleteOptions{}
 		deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pObj.GetUID()))
 		if err = c.pvcClient.PersistentVolumeClaims(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), *deleteOptions); err != nil {
@@ -128,7 +147,7 @@ func (c *controller) PatrollerDo() {
 	}
 
 	vSet.Difference(pSet, differ.FilteringHandler{
-		Handler:    d,
+		Handler:    differ.FreezeHandler{Frozen: c.IsFrozen, Handler: differ.BudgetHandler{Budget: c.RemediationBudget(), Handler: d}},
 		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
 	})
 
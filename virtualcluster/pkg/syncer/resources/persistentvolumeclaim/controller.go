@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
@@ -29,6 +30,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
 	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
 
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
@@ -36,6 +38,9 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
 )
@@ -57,6 +62,8 @@ type controller struct {
 	pvcLister listersv1.PersistentVolumeClaimLister
 	pvcSynced cache.InformerSynced
 	informer  coreinformers.Interface
+	// quotas serializes the writes of the claims limited by a storage quota per tenant
+	quotas assume.Tenants
 }
 
 func NewPVCController(config *config.SyncerConfiguration,
@@ -74,12 +81,14 @@ func NewPVCController(config *config.SyncerConfiguration,
 	}
 
 	var err error
-	c.MultiClusterController, err = mc.NewMCController(&corev1.PersistentVolumeClaim{}, &corev1.PersistentVolumeClaimList{}, c, mc.WithOptions(options.MCOptions))
+	c.MultiClusterController, err = mc.NewMCController(&corev1.PersistentVolumeClaim{}, &corev1.PersistentVolumeClaimList{}, c, mc.WithOptions(options.MCOptions),
+		mc.WithUpdatePredicate(util.DWSUpdatePredicate(nil)))
 	if err != nil {
 		return nil, err
 	}
 
 	c.pvcLister = informer.Core().V1().PersistentVolumeClaims().Lister()
+	objectlimit.RegisterIndexer("persistentvolumeclaims", informer.Core().V1().PersistentVolumeClaims().Informer().GetIndexer())
 	if options.IsFake {
 		c.pvcSynced = func() bool { return true }
 	} else {
@@ -100,6 +109,11 @@ func NewPVCController(config *config.SyncerConfiguration,
 		cache.ResourceEventHandlerFuncs{
 			UpdateFunc: func(oldObj, newObj interface{}) {
 				pvc := newObj.(*corev1.PersistentVolumeClaim)
+				if featuregate.DefaultFeatureGate.Enabled(featuregate.StatusOnlyUpdateFilter) &&
+					equality.Semantic.DeepEqual(oldObj.(*corev1.PersistentVolumeClaim).Status, pvc.Status) {
+					// the upward syncer only back populates the status.
+					return
+				}
 				c.enqueuePersistentVolumeClaim(pvc)
 			},
 		},
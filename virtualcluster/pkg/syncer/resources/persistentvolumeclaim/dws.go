@@ -28,7 +28,10 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
 
@@ -87,14 +90,32 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 }
 
 func (c *controller) reconcilePVCCreate(clusterName, targetNamespace, requestUID string, pvc *corev1.PersistentVolumeClaim) error {
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, pvc)
+	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
 	if err != nil {
 		return err
 	}
+	var tenant *assume.Tenant
+	if vc.Spec.StorageQuota != nil {
+		// concurrent workers could overrun the quota of the tenant if the check and the creation were not serialized.
+		tenant = c.quotas.Get(clusterName)
+		tenant.Lock()
+		defer tenant.Unlock()
+		if err := c.checkStorageQuota(vc, tenant, pvc, nil); err != nil {
+			c.recordQuotaExceeded(clusterName, pvc, err)
+			return err
+		}
+	}
 
-	pPVC := newObj.(*corev1.PersistentVolumeClaim)
+	pPVC, err := conversion.BuildSuperClusterPersistentVolumeClaim(c.Conversion(), clusterName, pvc)
+	if err != nil {
+		return err
+	}
+	c.FinalizerTranslator().Apply(pvc, pPVC)
 
-	pPVC, err = c.pvcClient.PersistentVolumeClaims(targetNamespace).Create(context.TODO(), pPVC, metav1.CreateOptions{})
+	pPVC, err = c.pvcClient.PersistentVolumeClaims(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pPVC, metav1.CreateOptions{})
+	if err == nil && tenant != nil {
+		tenant.Assume(pPVC)
+	}
 	if apierrors.IsAlreadyExists(err) {
 		if pPVC.Annotations[constants.LabelUID] == requestUID {
 			klog.Infof("pvc %s/%s of cluster %s already exist in super control plane", targetNamespace, pPVC.Name, clusterName)
@@ -107,18 +128,68 @@ func (c *controller) reconcilePVCCreate(clusterName, targetNamespace, requestUID
 
 func (c *controller) reconcilePVCUpdate(clusterName, targetNamespace, requestUID string, pPVC, vPVC *corev1.PersistentVolumeClaim) error {
 	if pPVC.Annotations[constants.LabelUID] != requestUID {
-		return fmt.Errorf("pPVC %s/%s delegated UID is different from updated object", targetNamespace, pPVC.Name)
+		// the tenant object is recreated in the restored namespace, it adopts the retained object
+		adopted, _ := recyclebin.Adopt(pPVC, requestUID).(*corev1.PersistentVolumeClaim)
+		if adopted == nil {
+			return fmt.Errorf("pPVC %s/%s delegated UID is different from updated object", targetNamespace, pPVC.Name)
+		}
+		if err := conversion.VerifyOwnership(pPVC); err != nil {
+			return err
+		}
+		adopted, err := c.pvcClient.PersistentVolumeClaims(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), adopted, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		klog.Infof("pvc %s/%s of cluster %s adopted the retained object in super control plane", targetNamespace, pPVC.Name, clusterName)
+		pPVC = adopted
 	}
 	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
 	if err != nil {
 		return err
 	}
+	var tenant *assume.Tenant
 	updatedPVC := conversion.Equality(c.Config, vc).CheckPVCEquality(pPVC, vPVC)
 	if updatedPVC != nil {
-		_, err = c.pvcClient.PersistentVolumeClaims(targetNamespace).Update(context.TODO(), updatedPVC, metav1.UpdateOptions{})
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vPVC, updatedPVC); err != nil {
+			return err
+		}
+		if vc.Spec.StorageQuota != nil {
+			// the check and the update of a claim requesting more storage are serialized like the creations.
+			tenant = c.quotas.Get(clusterName)
+			tenant.Lock()
+			defer tenant.Unlock()
+			if err := c.checkStorageQuota(vc, tenant, vPVC, pPVC); err != nil {
+				c.recordQuotaExceeded(clusterName, vPVC, err)
+				return err
+			}
+		}
+		changed, err := c.ConflictResolver().Resolve(clusterName, vPVC, pPVC, updatedPVC)
 		if err != nil {
 			return err
 		}
+		if !changed {
+			updatedPVC = nil
+		}
+	}
+	if hash, equal := conversion.CheckSemanticHash(pPVC, vPVC); hash != "" && !equal {
+		if updatedPVC == nil {
+			updatedPVC = pPVC.DeepCopy()
+		}
+		conversion.SetSemanticHash(updatedPVC, hash)
+	}
+	if updatedPVC != nil {
+		c.FinalizerTranslator().Apply(vPVC, updatedPVC)
+	} else if finalized := pPVC.DeepCopy(); c.FinalizerTranslator().Apply(vPVC, finalized) {
+		updatedPVC = finalized
+	}
+	if updatedPVC != nil {
+		updatedPVC, err = c.pvcClient.PersistentVolumeClaims(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updatedPVC, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		if tenant != nil {
+			tenant.Assume(updatedPVC)
+		}
 	}
 	return nil
 }
@@ -127,13 +198,47 @@ func (c *controller) reconcilePVCRemove(clusterName, targetNamespace, requestUID
 	if pPVC.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pPVC %s/%s delegated UID is different from deleted object", targetNamespace, pPVC.Name)
 	}
+	if err := conversion.VerifyOwnership(pPVC); err != nil {
+		return err
+	}
+	if retained, err := recyclebin.Retains(c.MultiClusterController, pPVC); err != nil {
+		return err
+	} else if retained {
+		if marked := recyclebin.RetainObject(pPVC, c.Config.NamespaceRetentionPeriod.Duration); marked != nil {
+			if _, err := c.pvcClient.PersistentVolumeClaims(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), marked.(*corev1.PersistentVolumeClaim), metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		klog.V(4).Infof("keeping pvc %s/%s of the retained namespace in super control plane", targetNamespace, name)
+		return nil
+	}
+	if released := pPVC.DeepCopy(); c.FinalizerTranslator().Release(released) {
+		if _, err := c.pvcClient.PersistentVolumeClaims(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), released, metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 	}
-	err := c.pvcClient.PersistentVolumeClaims(targetNamespace).Delete(context.TODO(), name, *opts)
+	err := c.pvcClient.PersistentVolumeClaims(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
 	if apierrors.IsNotFound(err) {
 		klog.Warningf("pvc %s/%s of cluster %s not found in super control plane", targetNamespace, name, clusterName)
 		return nil
 	}
 	return err
 }
+
+// recordQuotaExceeded records a warning event on the tenant claim exceeding the storage quota.
+func (c *controller) recordQuotaExceeded(clusterName string, vPVC *corev1.PersistentVolumeClaim, err error) {
+	if _, ok := err.(*quotaExceededError); !ok {
+		return
+	}
+	if eventErr := c.MultiClusterController.Eventf(clusterName, &corev1.ObjectReference{
+		Kind:      "PersistentVolumeClaim",
+		Name:      vPVC.Name,
+		Namespace: vPVC.Namespace,
+		UID:       vPVC.UID,
+	}, corev1.EventTypeWarning, constants.ReasonExceededQuota, "Error syncing: %v", err); eventErr != nil {
+		klog.Errorf("failed to record the quota event of pvc %s/%s in cluster %s: %v", vPVC.Namespace, vPVC.Name, clusterName, eventErr)
+	}
+}
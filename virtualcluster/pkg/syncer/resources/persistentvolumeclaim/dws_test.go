@@ -17,7 +17,9 @@ limitations under the License.
 package persistentvolumeclaim
 
 import (
+	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -26,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 	"k8s.io/utils/pointer"
 
@@ -363,3 +366,177 @@ func TestDWPVCUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDWPVCStorageQuota(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{
+			StorageQuota: &v1alpha1.StorageQuota{
+				Requests: resource.NewQuantity(10<<30, resource.BinarySI),
+				StorageClasses: map[string]v1alpha1.StorageClassQuota{
+					"fast": {Claims: pointer.Int32Ptr(1)},
+				},
+			},
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+	superOtherNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "other")
+
+	withRequest := func(pvc *corev1.PersistentVolumeClaim, storage, class string) *corev1.PersistentVolumeClaim {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(storage)}
+		if class != "" {
+			pvc.Spec.StorageClassName = pointer.StringPtr(class)
+		}
+		return pvc
+	}
+	ofTenant := func(pvc *corev1.PersistentVolumeClaim) *corev1.PersistentVolumeClaim {
+		pvc.Labels = map[string]string{
+			constants.LabelVCName:      testTenant.Name,
+			constants.LabelVCNamespace: testTenant.Namespace,
+		}
+		return pvc
+	}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant []runtime.Object
+		ExpectedCreatedPVC     []string
+		ExpectedError          string
+	}{
+		"within quota": {
+			ExistingObjectInSuper: []runtime.Object{
+				ofTenant(withRequest(superPVC("pvc-2", superOtherNSName, "23456", defaultClusterKey), "5Gi", "")),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				withRequest(tenantPVC("pvc-1", "default", "12345"), "4Gi", ""),
+			},
+			ExpectedCreatedPVC: []string{superDefaultNSName + "/pvc-1"},
+		},
+		"exceeds the total requests": {
+			ExistingObjectInSuper: []runtime.Object{
+				ofTenant(withRequest(superPVC("pvc-2", superOtherNSName, "23456", defaultClusterKey), "5Gi", "")),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				withRequest(tenantPVC("pvc-1", "default", "12345"), "6Gi", ""),
+			},
+			ExpectedCreatedPVC: []string{},
+			ExpectedError:      "exceeded storage quota: requested 6Gi, used 5Gi, limited 10Gi",
+		},
+		"claims of other tenants are not counted": {
+			ExistingObjectInSuper: []runtime.Object{
+				withRequest(unknownPVC("pvc-2", "other-tenant-default"), "8Gi", ""),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				withRequest(tenantPVC("pvc-1", "default", "12345"), "6Gi", ""),
+			},
+			ExpectedCreatedPVC: []string{superDefaultNSName + "/pvc-1"},
+		},
+		"exceeds the claims of the storage class": {
+			ExistingObjectInSuper: []runtime.Object{
+				ofTenant(withRequest(superPVC("pvc-2", superOtherNSName, "23456", defaultClusterKey), "1Gi", "fast")),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				withRequest(tenantPVC("pvc-1", "default", "12345"), "1Gi", "fast"),
+			},
+			ExpectedCreatedPVC: []string{},
+			ExpectedError:      "exceeded claim quota of storage class fast",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewPVCController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.ExistingObjectInTenant[0], nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+			} else if tc.ExpectedError != "" {
+				t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+			}
+
+			if len(tc.ExpectedCreatedPVC) != len(actions) {
+				t.Errorf("%s: Expected to create PVC %#v. Actual actions were: %#v", k, tc.ExpectedCreatedPVC, actions)
+				return
+			}
+			for i, expectedName := range tc.ExpectedCreatedPVC {
+				created := actions[i].(core.CreateAction).GetObject().(*corev1.PersistentVolumeClaim)
+				if fullName := created.Namespace + "/" + created.Name; fullName != expectedName {
+					t.Errorf("%s: Expected %s to be created, got %s", k, expectedName, fullName)
+				}
+			}
+		})
+	}
+}
+
+func TestDWPVCStorageQuotaConcurrentCreates(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{
+			StorageQuota: &v1alpha1.StorageQuota{
+				Requests: resource.NewQuantity(10<<30, resource.BinarySI),
+			},
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	var tenantPVCs []runtime.Object
+	for i := 0; i < 5; i++ {
+		pvc := tenantPVC(fmt.Sprintf("pvc-%d", i), "default", fmt.Sprintf("1234%d", i))
+		pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("4Gi")}
+		tenantPVCs = append(tenantPVCs, pvc)
+	}
+	// the apiserver sets the UID of the created claims, the informer cache never sees them.
+	var created int32
+	assignUID := func(action core.Action) (bool, runtime.Object, error) {
+		pvc := action.(core.CreateAction).GetObject().(*corev1.PersistentVolumeClaim)
+		pvc.UID = types.UID(fmt.Sprintf("super-%d", atomic.AddInt32(&created, 1)))
+		return false, nil, nil
+	}
+
+	actions, reconcileErrs, err := util.RunConcurrentDownwardSync(NewPVCController, testTenant, nil, tenantPVCs, tenantPVCs,
+		func(tenantClientset, superClientset *fake.Clientset) {
+			superClientset.PrependReactor("create", "persistentvolumeclaims", assignUID)
+		})
+	if err != nil {
+		t.Fatalf("error running downward sync: %v", err)
+	}
+
+	if len(actions) != 2 {
+		t.Errorf("expected the quota to allow 2 claims to be created, actual actions were: %#v", actions)
+	}
+	exceeded := 0
+	for _, reconcileErr := range reconcileErrs {
+		if reconcileErr == nil {
+			continue
+		}
+		if !strings.Contains(reconcileErr.Error(), "exceeded storage quota") {
+			t.Errorf("expected a storage quota error, got %v", reconcileErr)
+		}
+		exceeded++
+	}
+	if exceeded != 3 {
+		t.Errorf("expected 3 claims to exceed the quota, got errors %v", reconcileErrs)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistentvolumeclaim
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
+)
+
+// quotaExceededError is returned when syncing a claim exceeds the storage quota of its tenant.
+type quotaExceededError struct {
+	message string
+}
+
+func (e *quotaExceededError) Error() string {
+	return e.message
+}
+
+// checkStorageQuota returns a quotaExceededError if syncing vPVC exceeds the storage quota of the
+// tenant. pPVC is the super control plane claim of vPVC, nil if it is not created yet. The usage
+// is computed from the super control plane claims of the tenant in all its namespaces, with the
+// claims written by the syncer the informer cache has not seen yet. The tenant must be locked.
+func (c *controller) checkStorageQuota(vc *v1alpha1.VirtualCluster, tenant *assume.Tenant, vPVC, pPVC *corev1.PersistentVolumeClaim) error {
+	quota := vc.Spec.StorageQuota
+	if quota == nil {
+		return nil
+	}
+	requested := vPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+	if pPVC != nil && requested.Cmp(pPVC.Spec.Resources.Requests[corev1.ResourceStorage]) <= 0 {
+		// only the claims requesting more storage are checked.
+		return nil
+	}
+
+	pList, err := c.pvcLister.List(labels.SelectorFromSet(labels.Set{
+		constants.LabelVCName:      vc.Name,
+		constants.LabelVCNamespace: vc.Namespace,
+	}))
+	if err != nil {
+		return err
+	}
+
+	className := storageClassName(vPVC)
+	total, classTotal := requested.DeepCopy(), requested.DeepCopy()
+	classClaims := int32(1)
+	for _, p := range claimsOf(tenant, pList) {
+		if pPVC != nil && p.UID == pPVC.UID {
+			continue
+		}
+		total.Add(p.Spec.Resources.Requests[corev1.ResourceStorage])
+		if className != "" && storageClassName(p) == className {
+			classTotal.Add(p.Spec.Resources.Requests[corev1.ResourceStorage])
+			classClaims++
+		}
+	}
+
+	if quota.Requests != nil && total.Cmp(*quota.Requests) > 0 {
+		return &quotaExceededError{fmt.Sprintf("exceeded storage quota: requested %s, used %s, limited %s",
+			requested.String(), used(total, requested), quota.Requests.String())}
+	}
+	classQuota, ok := quota.StorageClasses[className]
+	if className == "" || !ok {
+		return nil
+	}
+	if classQuota.Requests != nil && classTotal.Cmp(*classQuota.Requests) > 0 {
+		return &quotaExceededError{fmt.Sprintf("exceeded storage quota of storage class %s: requested %s, used %s, limited %s",
+			className, requested.String(), used(classTotal, requested), classQuota.Requests.String())}
+	}
+	if pPVC == nil && classQuota.Claims != nil && classClaims > *classQuota.Claims {
+		return &quotaExceededError{fmt.Sprintf("exceeded claim quota of storage class %s: used %d, limited %d",
+			className, classClaims-1, *classQuota.Claims)}
+	}
+	return nil
+}
+
+// claimsOf returns the cached claims and the claims written by the tenant the cache has not seen
+// yet, an assumed claim replaces its cached version until the cache sees it request as much
+// storage. The tenant must be locked.
+func claimsOf(tenant *assume.Tenant, cached []*corev1.PersistentVolumeClaim) []*corev1.PersistentVolumeClaim {
+	cachedByUID := make(map[types.UID]*corev1.PersistentVolumeClaim, len(cached))
+	for _, pvc := range cached {
+		cachedByUID[pvc.UID] = pvc
+	}
+	assumed := make(map[types.UID]*corev1.PersistentVolumeClaim)
+	for _, obj := range tenant.Assumed(func(obj metav1.Object) bool {
+		pvc, ok := cachedByUID[obj.GetUID()]
+		if !ok {
+			return false
+		}
+		requested := obj.(*corev1.PersistentVolumeClaim).Spec.Resources.Requests[corev1.ResourceStorage]
+		return requested.Cmp(pvc.Spec.Resources.Requests[corev1.ResourceStorage]) <= 0
+	}) {
+		assumed[obj.GetUID()] = obj.(*corev1.PersistentVolumeClaim)
+	}
+	if len(assumed) == 0 {
+		return cached
+	}
+	claims := make([]*corev1.PersistentVolumeClaim, 0, len(cached)+len(assumed))
+	for _, pvc := range cached {
+		if a, ok := assumed[pvc.UID]; ok {
+			pvc = a
+			delete(assumed, pvc.UID)
+		}
+		claims = append(claims, pvc)
+	}
+	for _, pvc := range assumed {
+		claims = append(claims, pvc)
+	}
+	return claims
+}
+
+func storageClassName(pvc *corev1.PersistentVolumeClaim) string {
+	if pvc.Spec.StorageClassName != nil {
+		return *pvc.Spec.StorageClassName
+	}
+	return pvc.Annotations[corev1.BetaStorageClassAnnotation]
+}
+
+// used returns the storage used before the request.
+func used(total, requested resource.Quantity) string {
+	u := total.DeepCopy()
+	u.Sub(requested)
+	return u.String()
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/admission"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+var _ admission.Validator = &controller{}
+var _ admission.RawValidator = &controller{}
+
+// ValidateAdmission dry-runs the downward syncing of a tenant pod that is being created
+// and returns the reason if the pod cannot be synced to the super control plane.
+func (c *controller) ValidateAdmission(clusterName string, obj client.Object) error {
+	vPod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return fmt.Errorf("unexpected object type %T", obj)
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantAllowResourceNoSync) {
+		if vPod.GetLabels()[constants.LabelTenantIgnoreSync] == "true" {
+			return nil
+		}
+	}
+
+	if vPod.Spec.NodeName != "" {
+		return fmt.Errorf("the Pod has nodeName set in the spec which is not supported for now")
+	}
+
+	pPod, err := conversion.BuildSuperClusterPod(c.Conversion(), clusterName, vPod)
+	if err != nil {
+		return err
+	}
+
+	// services may not be ready when the first pods of a tenant are created, the
+	// service environment variables are not relevant to the dry-run anyway.
+	services, _ := c.getPodRelatedServices(clusterName, pPod)
+	nameServer, err := c.getClusterNameServer(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to find nameserver: %v", err)
+	}
+
+	ms := append([]conversion.PodMutator{}, c.podMutators...)
+	ms = append(ms, conversion.PodMutateDefault(vPod, map[string]string{}, services, nameServer, c.Config.DNSOptions))
+	if err := conversion.VC(c.MultiClusterController, clusterName).Pod(pPod, vPod).Mutate(ms...); err != nil {
+		return fmt.Errorf("failed to mutate pod: %v", err)
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantPodPolicy) {
+		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+		if err != nil {
+			return err
+		}
+		policy, err := c.getTenantPodPolicy(vc)
+		if err != nil {
+			return fmt.Errorf("failed to get TenantPodPolicy of cluster %s: %v", clusterName, err)
+		}
+		if policy != nil && len(quotaRequests(policy.ExtendedResourceQuota, pPod)) > 0 {
+			tenant := c.quotas.Get(clusterName)
+			tenant.Lock()
+			err := c.enforceExtendedResourceQuota(clusterName, tenant, policy.ExtendedResourceQuota, pPod)
+			tenant.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.dryRunCreate(clusterName, conversion.ToSuperClusterNamespace(clusterName, vPod.Namespace), pPod); err != nil {
+		return err
+	}
+
+	if c.plugin != nil && c.plugin.Enabled() {
+		t := c.plugin.GetTenantLocker(clusterName)
+		if t == nil {
+			return fmt.Errorf("cannot get tenant")
+		}
+		t.Cond.Lock()
+		defer t.Cond.Unlock()
+		if !c.plugin.Validation(pPod, clusterName) {
+			return fmt.Errorf("the Pod is rejected by the validation plugin of the super control plane")
+		}
+	}
+	return nil
+}
+
+// dryRunCreate evaluates the admission of the super control plane on pPod, e.g. its ResourceQuotas
+// and LimitRanges, by a server side dry-run of the creation. Only a rejection of pPod fails the
+// validation, the namespace of the first pods of a tenant namespace e.g. may not be synced yet.
+func (c *controller) dryRunCreate(clusterName, targetNamespace string, pPod *corev1.Pod) error {
+	_, err := c.client.Pods(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pPod, metav1.CreateOptions{
+		DryRun: []string{metav1.DryRunAll},
+	})
+	if apierrors.IsForbidden(err) || apierrors.IsInvalid(err) {
+		return fmt.Errorf("the Pod is rejected by the super control plane: %v", err)
+	}
+	if err != nil {
+		klog.V(4).Infof("skip the dry-run of pod %s/%s of cluster %s: %v", targetNamespace, pPod.Name, clusterName, err)
+	}
+	return nil
+}
+
+// ValidateRawAdmission rejects the tenant pods that set fields of later Kubernetes versions which
+// the syncer cannot sync, rather than dropping them silently in the super control plane.
+func (c *controller) ValidateRawAdmission(clusterName string, raw []byte) error {
+	pod := &unstructured.Unstructured{}
+	if err := pod.UnmarshalJSON(raw); err != nil {
+		return err
+	}
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantAllowResourceNoSync) {
+		if pod.GetLabels()[constants.LabelTenantIgnoreSync] == "true" {
+			return nil
+		}
+	}
+	if fields := unsupportedPodFields(pod.Object); len(fields) > 0 {
+		return fmt.Errorf("the Pod sets %s which is not supported for now", strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// unsupportedPodFields returns the paths of the fields set in the raw pod obj which are dropped
+// by the typed pods of this client version, i.e. spec.os and the HostProcess Windows options.
+func unsupportedPodFields(obj map[string]interface{}) []string {
+	var fields []string
+	if _, ok, _ := unstructured.NestedFieldNoCopy(obj, "spec", "os"); ok {
+		fields = append(fields, "spec.os")
+	}
+	if _, ok, _ := unstructured.NestedFieldNoCopy(obj, "spec", "securityContext", "windowsOptions", "hostProcess"); ok {
+		fields = append(fields, "spec.securityContext.windowsOptions.hostProcess")
+	}
+	for _, kind := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _, _ := unstructured.NestedSlice(obj, "spec", kind)
+		for i, container := range containers {
+			m, ok := container.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, ok, _ := unstructured.NestedFieldNoCopy(m, "securityContext", "windowsOptions", "hostProcess"); ok {
+				fields = append(fields, fmt.Sprintf("spec.%s[%d].securityContext.windowsOptions.hostProcess", kind, i))
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestDryRunCreate(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	for _, tc := range []struct {
+		name      string
+		createErr error
+		rejected  bool
+	}{
+		{name: "admitted"},
+		{
+			name:      "quota exceeded",
+			createErr: apierrors.NewForbidden(pods, "pod-1", errors.New("exceeded quota: compute, requested: cpu=2, used: cpu=3, limited: cpu=4")),
+			rejected:  true,
+		},
+		{
+			name:      "limit range violated",
+			createErr: apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "pod-1", nil),
+			rejected:  true,
+		},
+		{
+			name:      "namespace not synced yet",
+			createErr: apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "cluster1-default"),
+		},
+		{
+			name:      "webhook without dry-run support",
+			createErr: apierrors.NewBadRequest("admission webhook does not support dry run"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			client.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				return true, nil, tc.createErr
+			})
+			c := &controller{client: client.CoreV1()}
+			pPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "cluster1-default"}}
+			err := c.dryRunCreate("cluster1", "cluster1-default", pPod)
+			if rejected := err != nil; rejected != tc.rejected {
+				t.Errorf("expected rejected %v, got %v", tc.rejected, err)
+			}
+		})
+	}
+}
+
+func TestValidateRawAdmission(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		raw         string
+		expectedErr string
+	}{
+		{
+			name: "linux pod",
+			raw:  `{"kind":"Pod","apiVersion":"v1","spec":{"containers":[{"name":"c"}]}}`,
+		},
+		{
+			name: "windows pod selecting the os by node selector",
+			raw: `{"kind":"Pod","apiVersion":"v1","spec":{"nodeSelector":{"kubernetes.io/os":"windows"},
+				"securityContext":{"windowsOptions":{"runAsUserName":"ContainerUser"}},"containers":[{"name":"c"}]}}`,
+		},
+		{
+			name:        "pod os",
+			raw:         `{"kind":"Pod","apiVersion":"v1","spec":{"os":{"name":"windows"},"containers":[{"name":"c"}]}}`,
+			expectedErr: "the Pod sets spec.os which is not supported for now",
+		},
+		{
+			name: "host process pod",
+			raw: `{"kind":"Pod","apiVersion":"v1","spec":{"securityContext":{"windowsOptions":{"hostProcess":true}},
+				"containers":[{"name":"c"}]}}`,
+			expectedErr: "the Pod sets spec.securityContext.windowsOptions.hostProcess which is not supported for now",
+		},
+		{
+			name: "host process containers",
+			raw: `{"kind":"Pod","apiVersion":"v1","spec":{
+				"initContainers":[{"name":"i","securityContext":{"windowsOptions":{"hostProcess":false}}}],
+				"containers":[{"name":"a"},{"name":"b","securityContext":{"windowsOptions":{"hostProcess":true}}}]}}`,
+			expectedErr: "the Pod sets spec.initContainers[0].securityContext.windowsOptions.hostProcess, " +
+				"spec.containers[1].securityContext.windowsOptions.hostProcess which is not supported for now",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := (&controller{}).ValidateRawAdmission("cluster1", []byte(tc.raw))
+			if tc.expectedErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tc.expectedErr != "" && (err == nil || err.Error() != tc.expectedErr) {
+				t.Errorf("expected error %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
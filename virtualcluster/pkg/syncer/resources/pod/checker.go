@@ -39,6 +39,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode"
 	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
@@ -196,7 +197,7 @@ func (c *controller) PatrollerDo() {
 	d.DeleteFunc = c.differDeleteFunc
 
 	vSet.Difference(pSet, differ.FilteringHandler{
-		Handler: d,
+		Handler: differ.FreezeHandler{Frozen: c.IsFrozen, Handler: differ.BudgetHandler{Budget: c.RemediationBudget(), Handler: d}},
 		FilterFunc: func(obj differ.ClusterObject) bool {
 			// vObj
 			if obj.GetOwnerCluster() != "" {
@@ -255,12 +256,15 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 		return
 	}
 
-	if pPod.Spec.NodeName != "" && vPod.Spec.NodeName != "" && pPod.Spec.NodeName != vPod.Spec.NodeName {
+	// Pods on an aggregated vNode run on any node of the pool.
+	if pPod.Spec.NodeName != "" && vPod.Spec.NodeName != "" && pPod.Spec.NodeName != vPod.Spec.NodeName && !vnode.IsAggregatedNodeName(vPod.Spec.NodeName) {
 		// If pPod can be deleted arbitrarily, e.g., evicted by node controller, this inconsistency may happen.
 		// For example, if pPod is deleted just before uws tries to bind the vPod and dws gets a request from checker or
 		// user update at the same time, a new pPod is going to be created potentially in a different node.
 		// However, uws bound vPod to a wrong node already. There is no easy remediation besides deleting tenant pod.
 		c.forceDeleteVPod(vObj.GetOwnerCluster(), vPod, true)
+		c.recordPodEvent(vObj.GetOwnerCluster(), vPod, corev1.EventTypeWarning, constants.ReasonPatrolRemediation,
+			"Pod is deleted because it runs on node %s in the super control plane rather than on node %s", pPod.Spec.NodeName, vPod.Spec.NodeName)
 		klog.Errorf("Found pPod %s nodename is different from tenant pod nodename, delete the vPod", pObj.Key)
 		metrics.CheckerRemedyStats.WithLabelValues("DeletedTenantPodsDueToNodeMissMatch").Inc()
 		return
@@ -273,9 +277,22 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 		return
 	}
 
-	if conversion.Equality(c.Config, vc).CheckPodEquality(pPod, vPod) != nil {
-		atomic.AddUint64(&numSpecMissMatchedPods, 1)
-		klog.Warningf("spec of pod %s diff in super&tenant control plane", pObj.Key)
+	if !conversion.PatrolSkipsEquality(pPod, vPod) {
+		if conversion.Equality(c.Config, vc).CheckPodEquality(pPod, vPod) != nil {
+			atomic.AddUint64(&numSpecMissMatchedPods, 1)
+			klog.Warningf("spec of pod %s diff in super&tenant control plane", pObj.Key)
+			if err := c.MultiClusterController.RequeueObject(clusterName, vPod); err != nil {
+				klog.Errorf("error requeue vPod %s: %v", vObj.Key, err)
+			} else {
+				metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantPods").Inc()
+			}
+		} else {
+			conversion.RecordPatrolEquality(pPod)
+		}
+	}
+
+	if policy, err := c.getTenantPodPolicy(vc); err == nil && len(policyMissingTolerations(policy, pPod)) > 0 {
+		klog.Warningf("pod %s misses tolerations of its TenantPodPolicy", pObj.Key)
 		if err := c.MultiClusterController.RequeueObject(clusterName, vPod); err != nil {
 			klog.Errorf("error requeue vPod %s: %v", vObj.Key, err)
 		} else {
@@ -330,6 +347,8 @@ func (c *controller) differAddFunc(vObj differ.ClusterObject) {
 			return
 		}
 		c.forceDeleteVPod(vObj.GetOwnerCluster(), vPod, false)
+		c.recordPodEvent(vObj.GetOwnerCluster(), vPod, corev1.EventTypeWarning, constants.ReasonPatrolRemediation,
+			"Pod is deleted because it no longer exists on node %s in the super control plane", vPod.Spec.NodeName)
 		metrics.CheckerRemedyStats.WithLabelValues("DeletedTenantPodsDueToSuperEviction").Inc()
 		return
 	}
@@ -361,6 +380,10 @@ func (c *controller) forceDeleteVPod(clusterName string, vPod *corev1.Pod, grace
 }
 
 func (c *controller) graceDeletePPod(pPod *corev1.Pod) {
+	if err := conversion.VerifyOwnership(pPod); err != nil {
+		klog.Errorf("refusing to delete pPod %v/%v in super control plane: %v", pPod.Namespace, pPod.Name, err)
+		return
+	}
 	gracePeriod := int64(minimumGracePeriodInSeconds)
 	deleteOptions := metav1.NewDeleteOptions(gracePeriod)
 	deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pPod.UID))
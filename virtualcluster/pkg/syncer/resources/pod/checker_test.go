@@ -28,6 +28,7 @@ import (
 	core "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
@@ -98,6 +99,7 @@ func TestPodPatrol(t *testing.T) {
 		ExpectedUpdatedPPods   []runtime.Object
 		ExpectedUpdatedVPods   []runtime.Object
 		ExpectedNoOperation    bool
+		ExpectedEventReasons   []string
 		WaitDWS                bool // Make sure to set this flag if the test involves DWS.
 		WaitUWS                bool // Make sure to set this flag if the test involves UWS.
 	}{
@@ -187,6 +189,7 @@ func TestPodPatrol(t *testing.T) {
 			ExpectedDeletedVPods: []string{
 				"default/pod-7",
 			},
+			ExpectedEventReasons: []string{constants.ReasonPatrolRemediation},
 		},
 		"vPod nodename is not equal to pPod nodename": {
 			ExistingObjectInSuper: []runtime.Object{
@@ -198,6 +201,7 @@ func TestPodPatrol(t *testing.T) {
 			ExpectedDeletedVPods: []string{
 				"default/pod-8",
 			},
+			ExpectedEventReasons: []string{constants.ReasonPatrolRemediation},
 		},
 		"pPod vPod in normal case": {
 			ExistingObjectInSuper: []runtime.Object{
@@ -221,12 +225,25 @@ func TestPodPatrol(t *testing.T) {
 
 	for k, tc := range testcases {
 		t.Run(k, func(t *testing.T) {
-			tenantActions, superActions, err := util.RunPatrol(NewPodController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, nil, tc.WaitDWS, tc.WaitUWS, nil)
+			actions, superActions, err := util.RunPatrol(NewPodController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, nil, tc.WaitDWS, tc.WaitUWS, nil)
 			if err != nil {
 				t.Errorf("%s: error running patrol: %v", k, err)
 				return
 			}
 
+			var tenantActions []core.Action
+			var eventReasons []string
+			for _, action := range actions {
+				if action.Matches("create", "events") {
+					eventReasons = append(eventReasons, action.(core.CreateAction).GetObject().(*corev1.Event).Reason)
+					continue
+				}
+				tenantActions = append(tenantActions, action)
+			}
+			if !equality.Semantic.DeepEqual(eventReasons, tc.ExpectedEventReasons) {
+				t.Errorf("%s: expected event reasons %v, got %v", k, tc.ExpectedEventReasons, eventReasons)
+			}
+
 			if tc.ExpectedNoOperation {
 				if len(superActions) != 0 {
 					t.Errorf("%s: Expect no operation, got %v in super cluster", k, superActions)
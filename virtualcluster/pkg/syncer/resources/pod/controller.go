@@ -35,13 +35,18 @@ import (
 
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	vclisters "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/listers/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/pod/mutatorplugin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/pod/validationplugin"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
@@ -71,6 +76,10 @@ type controller struct {
 	serviceSynced cache.InformerSynced
 	secretLister  listersv1.SecretLister
 	secretSynced  cache.InformerSynced
+	// TenantPodPolicy informer/lister/synced functions, only set with featuregate.TenantPodPolicy
+	policyInformer cache.SharedIndexInformer
+	policyLister   vclisters.TenantPodPolicyLister
+	policySynced   cache.InformerSynced
 	// Cluster vNode PodMap and GCMap, needed for vNode garbage collection
 	sync.Mutex
 	clusterVNodePodMap map[string]map[string]map[string]struct{}
@@ -80,6 +89,11 @@ type controller struct {
 	vnodeProvider provider.VirtualNodeProvider
 	plugin        validationplugin.Interface
 	podMutators   []conversion.PodMutator
+	// quotas serializes the creation of the pods limited by an extended resource quota per tenant
+	quotas assume.Tenants
+	// resizing holds the UIDs of the super pods resized in place whose resize is not completed,
+	// only their raw statuses are read from the apiservers, used for InPlacePodResize
+	resizing sync.Map
 }
 
 type VirtulNodeDeletionPhase string
@@ -114,7 +128,8 @@ func NewPodController(config *config.SyncerConfiguration,
 
 	var err error
 	c.MultiClusterController, err = mc.NewMCController(&corev1.Pod{}, &corev1.PodList{}, c,
-		mc.WithMaxConcurrentReconciles(constants.DwsControllerWorkerHigh), mc.WithOptions(options.MCOptions))
+		mc.WithMaxConcurrentReconciles(constants.DwsControllerWorkerHigh), mc.WithOptions(options.MCOptions),
+		mc.WithUpdatePredicate(util.DWSUpdatePredicate(tenantConditionsChanged)))
 	if err != nil {
 		return nil, err
 	}
@@ -153,10 +168,14 @@ func NewPodController(config *config.SyncerConfiguration,
 		mp := mutator.(mutatorplugin.Interface)
 		c.podMutators = append(c.podMutators, mp.Mutator())
 	}
+	if len(config.PodReadinessGates) > 0 {
+		c.podMutators = append(c.podMutators, conversion.PodMutateReadinessGates(config.PodReadinessGates))
+	}
 
 	c.serviceLister = c.informer.Services().Lister()
 	c.secretLister = c.informer.Secrets().Lister()
 	c.podLister = c.informer.Pods().Lister()
+	objectlimit.RegisterIndexer("pods", c.informer.Pods().Informer().GetIndexer())
 	if options.IsFake {
 		c.serviceSynced = func() bool { return true }
 		c.secretSynced = func() bool { return true }
@@ -178,6 +197,20 @@ func NewPodController(config *config.SyncerConfiguration,
 		return nil, err
 	}
 
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantPodPolicy) {
+		c.policyInformer = vcinformers.NewTenantPodPolicyInformer(vcClient, metav1.NamespaceAll, 0,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		c.policyLister = vclisters.NewTenantPodPolicyLister(c.policyInformer.GetIndexer())
+		c.policySynced = c.policyInformer.HasSynced
+		c.policyInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueueTenantPodPolicy,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				c.enqueueTenantPodPolicy(newObj)
+			},
+			DeleteFunc: c.enqueueTenantPodPolicy,
+		})
+	}
+
 	c.informer.Pods().Informer().AddEventHandler(
 		cache.FilteringResourceEventHandler{
 			FilterFunc: func(obj interface{}) bool {
@@ -208,13 +241,41 @@ func NewPodController(config *config.SyncerConfiguration,
 
 					c.enqueuePod(newObj)
 				},
-				DeleteFunc: c.enqueuePod,
+				DeleteFunc: c.enqueueDeletedPod,
 			},
 		},
 	)
 	return c, nil
 }
 
+// enqueueDeletedPod also requeues the vPod of a deleted pPod, so that a terminating vPod is
+// removed as soon as its pPod is gone instead of waiting for the periodic checker.
+func (c *controller) enqueueDeletedPod(obj interface{}) {
+	c.enqueuePod(obj)
+
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pPod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	clusterName, vNamespace := conversion.GetVirtualOwner(pPod)
+	if clusterName == "" || vNamespace == "" {
+		return
+	}
+	vPod := &corev1.Pod{}
+	if err := c.MultiClusterController.Get(clusterName, vNamespace, pPod.Name, vPod); err != nil {
+		return
+	}
+	if vPod.DeletionTimestamp == nil || string(vPod.UID) != pPod.Annotations[constants.LabelUID] {
+		return
+	}
+	if err := c.MultiClusterController.RequeueObject(clusterName, vPod); err != nil {
+		klog.V(4).Infof("failed to requeue vPod %s/%s of cluster %s: %v", vNamespace, pPod.Name, clusterName, err)
+	}
+}
+
 func (c *controller) enqueuePod(obj interface{}) {
 	pod, ok := obj.(*corev1.Pod)
 	if !ok {
@@ -317,3 +378,17 @@ func (c *controller) SetVNodeProvider(provider provider.VirtualNodeProvider) {
 func assignedPod(pod *corev1.Pod) bool {
 	return len(pod.Spec.NodeName) != 0
 }
+
+// tenantConditionsChanged returns true if the conditions of the tenant pod synced downward may
+// have changed, i.e. the conditions of its readiness gates.
+func tenantConditionsChanged(oldObj, newObj interface{}) bool {
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return true
+	}
+	newPod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return true
+	}
+	return conversion.TenantConditionsChanged(oldPod, newPod)
+}
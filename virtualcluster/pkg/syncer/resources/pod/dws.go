@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	pkgerr "github.com/pkg/errors"
@@ -34,10 +35,13 @@ import (
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
@@ -47,6 +51,12 @@ func (c *controller) StartDWS(stopCh <-chan struct{}) error {
 	if !cache.WaitForCacheSync(stopCh, c.podSynced, c.serviceSynced, c.secretSynced) {
 		return fmt.Errorf("failed to wait for caches to sync before starting Pod dws")
 	}
+	if c.policyInformer != nil {
+		go c.policyInformer.Run(stopCh)
+		if !cache.WaitForCacheSync(stopCh, c.policySynced) {
+			return fmt.Errorf("failed to wait for TenantPodPolicy cache to sync before starting Pod dws")
+		}
+	}
 	return c.MultiClusterController.Start(stopCh)
 }
 
@@ -85,15 +95,19 @@ func (c *controller) Reconcile(request reconciler.Request) (res reconciler.Resul
 		if err != nil {
 			klog.Errorf("failed reconcile Pod %s/%s CREATE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
 
+			reason := constants.ReasonFailedCreate
+			if _, ok := err.(*quotaExceededError); ok {
+				reason = constants.ReasonExceededQuota
+			}
 			if parentRef := getParentRefFromPod(vPod); parentRef != nil {
-				c.MultiClusterController.Eventf(request.ClusterName, parentRef, corev1.EventTypeWarning, "FailedCreate", "Error creating: %v", err)
+				c.MultiClusterController.Eventf(request.ClusterName, parentRef, corev1.EventTypeWarning, reason, "Error creating: %v", err)
 			}
 			c.MultiClusterController.Eventf(request.ClusterName, &corev1.ObjectReference{
 				Kind:      "Pod",
 				Name:      vPod.Name,
 				Namespace: vPod.Namespace,
 				UID:       vPod.UID,
-			}, corev1.EventTypeWarning, "FailedCreate", "Error creating: %v", err)
+			}, corev1.EventTypeWarning, reason, "Error creating: %v", err)
 
 			return reconciler.Result{Requeue: true}, err
 		}
@@ -168,7 +182,7 @@ func getParentRefFromPod(vPod *corev1.Pod) *corev1.ObjectReference {
 func (c *controller) reconcilePodCreate(clusterName, targetNamespace, requestUID string, vPod *corev1.Pod) error {
 	// load deleting pod, don't create any pod on super control plane.
 	if vPod.DeletionTimestamp != nil {
-		return nil
+		return c.finishPodDeletion(clusterName, vPod)
 	}
 
 	if vPod.Spec.NodeName != "" {
@@ -178,17 +192,15 @@ func (c *controller) reconcilePodCreate(clusterName, targetNamespace, requestUID
 			Name:      vPod.Name,
 			Namespace: vPod.Namespace,
 			UID:       vPod.UID,
-		}, corev1.EventTypeWarning, "NotSupported", "The Pod has nodeName set in the spec which is not supported for now")
+		}, corev1.EventTypeWarning, constants.ReasonNotSupported, "The Pod has nodeName set in the spec which is not supported for now")
 		return err
 	}
 
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, vPod)
+	pPod, err := conversion.BuildSuperClusterPod(c.Conversion(), clusterName, vPod)
 	if err != nil {
 		return err
 	}
 
-	pPod := newObj.(*corev1.Pod)
-
 	pSecretMap, err := c.findPodServiceAccountSecret(clusterName, pPod, vPod)
 	if err != nil {
 		return fmt.Errorf("failed to get service account secret from cluster %s cache: %v", clusterName, err)
@@ -208,6 +220,22 @@ func (c *controller) reconcilePodCreate(clusterName, targetNamespace, requestUID
 	// It is not an easy task as it uses a lot of controller methods now, but could be nice to be generalised.
 	var ms = append(c.podMutators, conversion.PodMutateDefault(vPod, pSecretMap, services, nameServer, c.Config.DNSOptions))
 
+	var policy *v1alpha1.TenantPodPolicySpec
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantPodPolicy) {
+		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+		if err != nil {
+			return err
+		}
+		policy, err = c.getTenantPodPolicy(vc)
+		if err != nil {
+			return fmt.Errorf("failed to get TenantPodPolicy of cluster %s: %v", clusterName, err)
+		}
+		if policy != nil {
+			// copy the mutators, the shared ones must not be extended by the policy of one tenant.
+			ms = append(append([]conversion.PodMutator{}, ms...), tenantPodPolicyMutator(policy))
+		}
+	}
+
 	err = conversion.VC(c.MultiClusterController, clusterName).Pod(pPod, vPod).Mutate(ms...)
 	if err != nil {
 		return fmt.Errorf("failed to mutate pod: %v", err)
@@ -224,10 +252,12 @@ func (c *controller) reconcilePodCreate(clusterName, targetNamespace, requestUID
 			}
 			t.Cond.Lock()
 			defer t.Cond.Unlock()
-			if !c.plugin.Validation(newObj, clusterName) {
+			if !c.plugin.Validation(pPod, clusterName) {
 				// put pod aside, not to try to create it again.
 				klog.Errorf("validation failed for virtual cluster namespace %v, no pod sync", targetNamespace)
 				recordOperationDuration("validation_plugin", pluginstart)
+				c.recordPodEvent(clusterName, vPod, corev1.EventTypeWarning, constants.ReasonRejectedByValidation,
+					"The Pod is rejected by the validation plugin of the super control plane")
 				return nil
 				// do not requeue return apierrors.NewBadRequest("validation failed for virtual cluster")
 			}
@@ -235,7 +265,21 @@ func (c *controller) reconcilePodCreate(clusterName, targetNamespace, requestUID
 		recordOperationDuration("validation_plugin", pluginstart)
 	}
 
-	pPod, err = c.client.Pods(targetNamespace).Create(context.TODO(), pPod, metav1.CreateOptions{})
+	var tenant *assume.Tenant
+	if policy != nil && len(quotaRequests(policy.ExtendedResourceQuota, pPod)) > 0 {
+		// concurrent workers could overrun the quota of the tenant if the check and the creation were not serialized.
+		tenant = c.quotas.Get(clusterName)
+		tenant.Lock()
+		defer tenant.Unlock()
+		if err := c.enforceExtendedResourceQuota(clusterName, tenant, policy.ExtendedResourceQuota, pPod); err != nil {
+			return err
+		}
+	}
+
+	pPod, err = c.client.Pods(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pPod, metav1.CreateOptions{})
+	if err == nil && tenant != nil {
+		tenant.Assume(pPod)
+	}
 	if apierrors.IsAlreadyExists(err) {
 		if pPod.Annotations[constants.LabelUID] == requestUID {
 			klog.Infof("pod %s/%s of cluster %s already exist in super control plane", targetNamespace, pPod.Name, clusterName)
@@ -243,10 +287,30 @@ func (c *controller) reconcilePodCreate(clusterName, targetNamespace, requestUID
 		}
 		return fmt.Errorf("pPod %s/%s exists but the UID is different from tenant control plane", targetNamespace, pPod.Name)
 	}
+	if err == nil && policy != nil {
+		if fields := policyChangedFields(policy, vPod); len(fields) > 0 {
+			c.recordPodEvent(clusterName, vPod, corev1.EventTypeNormal, constants.ReasonMutatedByPolicy,
+				"The TenantPodPolicy set the %s of the Pod in the super control plane", strings.Join(fields, ", "))
+		}
+	}
 
 	return err
 }
 
+// recordPodEvent records an event on the tenant pod, failing to record it does not fail the sync.
+func (c *controller) recordPodEvent(clusterName string, vPod *corev1.Pod, eventType, reason, messageFmt string, args ...interface{}) {
+	ref := &corev1.ObjectReference{
+		Kind:       "Pod",
+		APIVersion: corev1.SchemeGroupVersion.String(),
+		Name:       vPod.Name,
+		Namespace:  vPod.Namespace,
+		UID:        vPod.UID,
+	}
+	if err := c.MultiClusterController.Eventf(clusterName, ref, eventType, reason, messageFmt, args...); err != nil {
+		klog.Errorf("failed to record event for pod %s/%s/%s: %v", clusterName, vPod.Namespace, vPod.Name, err)
+	}
+}
+
 func (c *controller) findPodServiceAccountSecret(clusterName string, pPod, vPod *corev1.Pod) (map[string]string, error) {
 	mountSecretSet := sets.NewString()
 	for _, volume := range vPod.Spec.Volumes {
@@ -343,13 +407,18 @@ func (c *controller) reconcilePodUpdate(clusterName, targetNamespace, requestUID
 	}
 
 	if vPod.DeletionTimestamp != nil {
-		if pPod.DeletionTimestamp != nil {
+		gracePeriod, shorter := shortenedGracePeriod(vPod, pPod)
+		if !shorter {
 			// pPod is under deletion, waiting for UWS bock populate the pod status.
 			return nil
 		}
-		deleteOptions := metav1.NewDeleteOptions(*vPod.DeletionGracePeriodSeconds)
+		if err := conversion.VerifyOwnership(pPod); err != nil {
+			return err
+		}
+		// the tenant deleted the pod, or deleted it again with a shorter grace period, e.g., kubectl delete --grace-period=1.
+		deleteOptions := metav1.NewDeleteOptions(gracePeriod)
 		deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pPod.UID))
-		err := c.client.Pods(targetNamespace).Delete(context.TODO(), pPod.Name, *deleteOptions)
+		err := c.client.Pods(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), pPod.Name, *deleteOptions)
 		if apierrors.IsNotFound(err) {
 			return nil
 		}
@@ -361,16 +430,49 @@ func (c *controller) reconcilePodUpdate(clusterName, targetNamespace, requestUID
 	}
 	updatedPod := conversion.Equality(c.Config, vc).CheckPodEquality(pPod, vPod)
 	if updatedPod != nil {
-		pPod, err = c.client.Pods(targetNamespace).Update(context.TODO(), updatedPod, metav1.UpdateOptions{})
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vPod, updatedPod); err != nil {
+			return err
+		}
+		changed, err := c.ConflictResolver().Resolve(clusterName, vPod, pPod, updatedPod)
 		if err != nil {
 			return err
 		}
+		if !changed {
+			updatedPod = nil
+		}
+	}
+	policy, err := c.getTenantPodPolicy(vc)
+	if err != nil {
+		return err
+	}
+	if missing := policyMissingTolerations(policy, pPod); len(missing) > 0 {
+		if updatedPod == nil {
+			updatedPod = pPod.DeepCopy()
+		}
+		updatedPod.Spec.Tolerations = append(updatedPod.Spec.Tolerations, missing...)
+	}
+	if hash, equal := conversion.CheckSemanticHash(pPod, vPod); hash != "" && !equal {
+		if updatedPod == nil {
+			updatedPod = pPod.DeepCopy()
+		}
+		conversion.SetSemanticHash(updatedPod, hash)
+	}
+	if updatedPod != nil {
+		pPod, err = c.client.Pods(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updatedPod, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.InPlacePodResize) {
+		if err := c.reconcilePodResize(targetNamespace, pPod, vPod); err != nil {
+			return err
+		}
 	}
 	updatedPodStatus := conversion.CheckDWPodConditionEquality(pPod, vPod)
 	if updatedPodStatus != nil {
 		updatedPod = pPod.DeepCopy()
 		updatedPod.Status = *updatedPodStatus
-		_, err = c.client.Pods(targetNamespace).UpdateStatus(context.TODO(), updatedPod, metav1.UpdateOptions{})
+		_, err = c.client.Pods(targetNamespace).UpdateStatus(rbac.WithTenant(context.TODO(), clusterName), updatedPod, metav1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
@@ -382,12 +484,15 @@ func (c *controller) reconcilePodRemove(clusterName, targetNamespace, requestUID
 	if pPod.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pPod %s/%s delegated UID is different from deleted object", targetNamespace, name)
 	}
+	if err := conversion.VerifyOwnership(pPod); err != nil {
+		return err
+	}
 
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 		Preconditions:     metav1.NewUIDPreconditions(string(pPod.UID)),
 	}
-	err := c.client.Pods(targetNamespace).Delete(context.TODO(), name, *opts)
+	err := c.client.Pods(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
 	if apierrors.IsNotFound(err) {
 		klog.Warningf("To be deleted pod %s/%s of cluster (%s) is not found in super control plane", targetNamespace, name, clusterName)
 		return nil
@@ -395,6 +500,41 @@ func (c *controller) reconcilePodRemove(clusterName, targetNamespace, requestUID
 	return err
 }
 
+// finishPodDeletion removes the terminating vPod whose pPod is gone. There is no kubelet in the
+// tenant control plane, so the syncer finishes the deletion once nothing runs in the super cluster.
+func (c *controller) finishPodDeletion(clusterName string, vPod *corev1.Pod) error {
+	tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
+	if err != nil {
+		return pkgerr.Wrapf(err, "failed to create client from cluster %s config", clusterName)
+	}
+	deleteOptions := metav1.NewDeleteOptions(0)
+	deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(vPod.UID))
+	err = tenantClient.CoreV1().Pods(vPod.Namespace).Delete(context.TODO(), vPod.Name, *deleteOptions)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if vPod.Spec.NodeName != "" {
+		c.updateClusterVNodePodMap(clusterName, vPod.Spec.NodeName, string(vPod.UID), reconciler.DeleteEvent)
+	}
+	return nil
+}
+
+// shortenedGracePeriod returns the deletion grace period of the terminating pod from, if the
+// pod to is not terminating or terminates with a longer grace period. The apiserver only ever
+// shortens the grace period of a terminating pod, so a longer one is not propagated.
+func shortenedGracePeriod(from, to *corev1.Pod) (int64, bool) {
+	if from.DeletionGracePeriodSeconds == nil {
+		return 0, false
+	}
+	if to.DeletionTimestamp != nil && to.DeletionGracePeriodSeconds != nil && *to.DeletionGracePeriodSeconds <= *from.DeletionGracePeriodSeconds {
+		return 0, false
+	}
+	return *from.DeletionGracePeriodSeconds, true
+}
+
 func recordOperationDuration(operation string, start time.Time) {
 	metrics.PodOperationsDuration.WithLabelValues(operation).Observe(metrics.SinceInSeconds(start))
 }
@@ -26,11 +26,13 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 	"k8s.io/utils/pointer"
 
@@ -41,7 +43,9 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 )
 
 const testTenantServiceAccountTokenSecretName = "default-token-jbrn5"
@@ -118,6 +122,15 @@ func applyNodeNameToPod(vPod *corev1.Pod, nodeName string) *corev1.Pod {
 	return vPod
 }
 
+func applyWindowsToPod(pod *corev1.Pod) *corev1.Pod {
+	pod.Spec.NodeSelector = map[string]string{corev1.LabelOSStable: "windows"}
+	pod.Spec.Tolerations = []corev1.Toleration{{Key: "os", Value: "windows", Effect: corev1.TaintEffectNoSchedule}}
+	pod.Spec.SecurityContext = &corev1.PodSecurityContext{
+		WindowsOptions: &corev1.WindowsSecurityContextOptions{RunAsUserName: pointer.String("ContainerUser")},
+	}
+	return pod
+}
+
 func applyDeletionTimestampToPod(vPod *corev1.Pod, t time.Time, gracePeriodSeconds int64) *corev1.Pod {
 	metaTime := metav1.NewTime(t)
 	vPod.DeletionTimestamp = &metaTime
@@ -201,7 +214,7 @@ func superPod(clusterKey, vcName, vcNamespace, name, namespace, uid string) *cor
 			},
 			HostAliases: []corev1.HostAlias{
 				{
-					Hostnames: []string{"kubernetes", "kubernetes.default", "kubernetes.default.svc"},
+					Hostnames: []string{"kubernetes", "kubernetes.default", "kubernetes.default.svc", "kubernetes.default.svc.cluster.local"},
 				},
 			},
 		},
@@ -307,6 +320,32 @@ func TestDWPodCreation(t *testing.T) {
 			}()},
 			DisablePodServiceLinks: true,
 		},
+		"new Windows Pod": {
+			ExistingObjectInSuper: []runtime.Object{
+				superSecret("default-token-12345", superDefaultNSName, "s12345"),
+				superService("kubernetes", superDefaultNSName, "12345", "10.96.0.1"),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyWindowsToPod(tenantPod("pod-1", "default", "12345")),
+				tenantSecret(testTenantServiceAccountTokenSecretName, "default", "s12345"),
+				tenantServiceAccount("default", "default", "12345"),
+			},
+			ExpectedCreatedPods: []*corev1.Pod{func() *corev1.Pod {
+				pod := applyWindowsToPod(superPod(defaultClusterKey, defaultVCName, defaultVCNamespace, "pod-1", "default", "12345"))
+				pod.Spec.HostAliases[0].IP = "10.96.0.1"
+				pod.Spec.Containers[0].Env = []corev1.EnvVar{
+					{Name: "KUBERNETES_PORT", Value: "tcp://10.96.0.1:80"},
+					{Name: "KUBERNETES_PORT_80_TCP", Value: "tcp://10.96.0.1:80"},
+					{Name: "KUBERNETES_PORT_80_TCP_ADDR", Value: "10.96.0.1"},
+					{Name: "KUBERNETES_PORT_80_TCP_PORT", Value: "80"},
+					{Name: "KUBERNETES_PORT_80_TCP_PROTO", Value: "tcp"},
+					{Name: "KUBERNETES_SERVICE_HOST", Value: "10.96.0.1"},
+					{Name: "KUBERNETES_SERVICE_PORT", Value: "80"},
+					{Name: "KUBERNETES_SERVICE_PORT_TEST", Value: "80"},
+				}
+				return pod
+			}()},
+		},
 		"load pod which under deletion": {
 			ExistingObjectInSuper: []runtime.Object{},
 			ExistingObjectInTenant: []runtime.Object{
@@ -550,6 +589,7 @@ func TestDWPodDeletion(t *testing.T) {
 		ExistingObjectInTenant []runtime.Object
 		EnqueueObject          *corev1.Pod
 		ExpectedDeletedPods    []string
+		ExpectedDeletedVPods   []string
 		ExpectedError          string
 	}{
 		"delete Pod": {
@@ -602,11 +642,43 @@ func TestDWPodDeletion(t *testing.T) {
 			ExpectedDeletedPods: []string{},
 			ExpectedError:       "",
 		},
+		"terminating vPod with a shorter grace period than terminating pPod": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyDeletionTimestampToPod(superPod(defaultClusterKey, defaultVCName, defaultVCNamespace, "pod-1", "default", "12345"), time.Now(), 30),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyDeletionTimestampToPod(tenantPod("pod-1", "default", "12345"), time.Now(), 5),
+			},
+			EnqueueObject:       applyDeletionTimestampToPod(tenantPod("pod-1", "default", "12345"), time.Now(), 5),
+			ExpectedDeletedPods: []string{superDefaultNSName + "/pod-1"},
+		},
+		"terminating vPod with a longer grace period than terminating pPod": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyDeletionTimestampToPod(superPod(defaultClusterKey, defaultVCName, defaultVCNamespace, "pod-1", "default", "12345"), time.Now(), 5),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyDeletionTimestampToPod(tenantPod("pod-1", "default", "12345"), time.Now(), 30),
+			},
+			EnqueueObject:       applyDeletionTimestampToPod(tenantPod("pod-1", "default", "12345"), time.Now(), 30),
+			ExpectedDeletedPods: []string{},
+		},
+		"terminating vPod and pPod is gone": {
+			ExistingObjectInTenant: []runtime.Object{
+				applyDeletionTimestampToPod(tenantPod("pod-1", "default", "12345"), time.Now(), 30),
+			},
+			EnqueueObject:        applyDeletionTimestampToPod(tenantPod("pod-1", "default", "12345"), time.Now(), 30),
+			ExpectedDeletedPods:  []string{},
+			ExpectedDeletedVPods: []string{"default/pod-1"},
+		},
 	}
 
 	for k, tc := range testcases {
 		t.Run(k, func(t *testing.T) {
-			actions, reconcileErr, err := util.RunDownwardSync(NewPodController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.EnqueueObject, nil)
+			var tenantClient *fake.Clientset
+			actions, reconcileErr, err := util.RunDownwardSync(NewPodController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.EnqueueObject,
+				func(tenantClientset, superClientset *fake.Clientset) {
+					tenantClient = tenantClientset
+				})
 			if err != nil {
 				t.Errorf("%s: error running downward sync: %v", k, err)
 				return
@@ -638,6 +710,16 @@ func TestDWPodDeletion(t *testing.T) {
 					t.Errorf("%s: Expected %s to be created, got %s", k, expectedName, fullName)
 				}
 			}
+
+			var deletedVPods []string
+			for _, action := range tenantClient.Actions() {
+				if action.Matches("delete", "pods") {
+					deletedVPods = append(deletedVPods, action.(core.DeleteAction).GetNamespace()+"/"+action.(core.DeleteAction).GetName())
+				}
+			}
+			if strings.Join(deletedVPods, ",") != strings.Join(tc.ExpectedDeletedVPods, ",") {
+				t.Errorf("%s: Expected to delete vPods %v, got %v", k, tc.ExpectedDeletedVPods, deletedVPods)
+			}
 		})
 	}
 }
@@ -788,3 +870,368 @@ func TestDWPodUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDWPodResize(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.InPlacePodResize, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+
+	specWithCPU := func(cpu string) *corev1.PodSpec {
+		return &corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Image: "ngnix",
+					Name:  "c-1",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+					},
+				},
+			},
+			NodeName: "i-xxx",
+		}
+	}
+
+	testcases := map[string]struct {
+		superCPU       string
+		tenantCPU      string
+		expectedResize bool
+	}{
+		"no diff":             {superCPU: "100m", tenantCPU: "100m"},
+		"resources increased": {superCPU: "100m", tenantCPU: "500m", expectedResize: true},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			pPod := applySpecToPod(superPod(defaultClusterKey, testTenant.Name, testTenant.Namespace, "pod-1", "default", "12345"), specWithCPU(tc.superCPU))
+			vPod := applySpecToPod(tenantPod("pod-1", "default", "12345"), specWithCPU(tc.tenantCPU))
+			actions, reconcileErr, err := util.RunDownwardSync(NewPodController, testTenant, []runtime.Object{pPod}, []runtime.Object{vPod}, vPod, nil)
+			if err != nil {
+				t.Fatalf("error running downward sync: %v", err)
+			}
+			if reconcileErr != nil {
+				t.Fatalf("unexpected reconcile error: %v", reconcileErr)
+			}
+
+			var resizes []core.PatchAction
+			for _, action := range actions {
+				if action.Matches("update", "pods") {
+					t.Errorf("resources must not be updated, got %v", action)
+				}
+				if action.Matches("patch", "pods") && action.GetSubresource() == resizeSubresource {
+					resizes = append(resizes, action.(core.PatchAction))
+				}
+			}
+			if !tc.expectedResize {
+				if len(resizes) != 0 {
+					t.Errorf("expected no resize, got %v", resizes)
+				}
+				return
+			}
+			if len(resizes) != 1 {
+				t.Fatalf("expected a resize, got actions %v", actions)
+			}
+			expectedPatch := `{"spec":{"containers":[{"name":"c-1","resources":{"requests":{"cpu":"500m"}}}]}}`
+			if string(resizes[0].GetPatch()) != expectedPatch {
+				t.Errorf("expected patch %s, got %s", expectedPatch, resizes[0].GetPatch())
+			}
+		})
+	}
+}
+
+func TestDWPodTenantPodPolicy(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.TenantPodPolicy, true)()
+
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	policy := &v1alpha1.TenantPodPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "dedicated", Namespace: testTenant.Namespace},
+		Spec: v1alpha1.TenantPodPolicySpec{
+			NodeSelector: map[string]string{"pool": "tenant-1"},
+			Tolerations: []corev1.Toleration{
+				{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "tenant-1", Effect: corev1.TaintEffectNoSchedule},
+			},
+			RuntimeClassName:      pointer.StringPtr("gvisor"),
+			ExtendedResourceQuota: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")},
+		},
+	}
+	newPodController := func(config *config.SyncerConfiguration,
+		client clientset.Interface,
+		informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface,
+		vcInformer vcinformers.VirtualClusterInformer,
+		options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		if _, err := vcClient.TenancyV1alpha1().TenantPodPolicies(policy.Namespace).Create(policy); err != nil {
+			return nil, err
+		}
+		return NewPodController(config, client, informer, vcClient, vcInformer, options)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		vPod := tenantPod("pod-1", "default", "12345")
+		actions, reconcileErr, err := util.RunDownwardSync(newPodController, testTenant,
+			[]runtime.Object{
+				superSecret("default-token-12345", superDefaultNSName, "s12345"),
+				superService("kubernetes", superDefaultNSName, "12345", ""),
+			},
+			[]runtime.Object{
+				vPod,
+				tenantSecret(testTenantServiceAccountTokenSecretName, "default", "s12345"),
+				tenantServiceAccount("default", "default", "12345"),
+			}, vPod, nil)
+		if err != nil {
+			t.Fatalf("error running downward sync: %v", err)
+		}
+		if reconcileErr != nil {
+			t.Fatalf("unexpected reconcile error: %v", reconcileErr)
+		}
+		if len(actions) != 1 || !actions[0].Matches("create", "pods") {
+			t.Fatalf("expected a pod to be created, got %v", actions)
+		}
+		pPod := actions[0].(core.CreateAction).GetObject().(*corev1.Pod)
+		if pPod.Spec.NodeSelector["pool"] != "tenant-1" {
+			t.Errorf("expected node selector of the policy, got %v", pPod.Spec.NodeSelector)
+		}
+		if !equality.Semantic.DeepEqual(pPod.Spec.Tolerations, policy.Spec.Tolerations) {
+			t.Errorf("expected tolerations %v, got %v", policy.Spec.Tolerations, pPod.Spec.Tolerations)
+		}
+		if pPod.Spec.RuntimeClassName == nil || *pPod.Spec.RuntimeClassName != "gvisor" {
+			t.Errorf("expected runtime class gvisor, got %v", pPod.Spec.RuntimeClassName)
+		}
+	})
+
+	t.Run("update existing pod", func(t *testing.T) {
+		spec := &corev1.PodSpec{
+			Containers: []corev1.Container{{Image: "ngnix", Name: "c-1"}},
+			NodeName:   "i-xxx",
+		}
+		pPod := applySpecToPod(superPod(defaultClusterKey, testTenant.Name, testTenant.Namespace, "pod-1", "default", "12345"), spec)
+		vPod := applySpecToPod(tenantPod("pod-1", "default", "12345"), spec)
+		actions, reconcileErr, err := util.RunDownwardSync(newPodController, testTenant, []runtime.Object{pPod}, []runtime.Object{vPod}, vPod, nil)
+		if err != nil {
+			t.Fatalf("error running downward sync: %v", err)
+		}
+		if reconcileErr != nil {
+			t.Fatalf("unexpected reconcile error: %v", reconcileErr)
+		}
+		if len(actions) != 1 || !actions[0].Matches("update", "pods") {
+			t.Fatalf("expected the pod to be updated, got %v", actions)
+		}
+		updated := actions[0].(core.UpdateAction).GetObject().(*corev1.Pod)
+		if !equality.Semantic.DeepEqual(updated.Spec.Tolerations, policy.Spec.Tolerations) {
+			t.Errorf("expected tolerations %v, got %v", policy.Spec.Tolerations, updated.Spec.Tolerations)
+		}
+		if updated.Spec.NodeSelector != nil || updated.Spec.RuntimeClassName != nil {
+			t.Errorf("immutable fields must not be updated, got %+v", updated.Spec)
+		}
+	})
+
+	for _, tc := range []struct {
+		name          string
+		usedPhase     corev1.PodPhase
+		expectedError string
+	}{
+		{name: "extended resource quota exceeded", usedPhase: corev1.PodRunning, expectedError: "exceeded extended resource quota"},
+		{name: "extended resources released", usedPhase: corev1.PodSucceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gpus := func(pod *corev1.Pod, n string) *corev1.Pod {
+				pod.Spec.Containers[0].Resources.Limits = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse(n)}
+				return pod
+			}
+			used := gpus(superPod(defaultClusterKey, testTenant.Name, testTenant.Namespace, "pod-0", "default", "12344"), "2")
+			used.Status.Phase = tc.usedPhase
+			vPod := gpus(tenantPod("pod-1", "default", "12345"), "1")
+			actions, reconcileErr, err := util.RunDownwardSync(newPodController, testTenant,
+				[]runtime.Object{
+					used,
+					superSecret("default-token-12345", superDefaultNSName, "s12345"),
+					superService("kubernetes", superDefaultNSName, "12345", ""),
+				},
+				[]runtime.Object{
+					vPod,
+					tenantSecret(testTenantServiceAccountTokenSecretName, "default", "s12345"),
+					tenantServiceAccount("default", "default", "12345"),
+				}, vPod, nil)
+			if err != nil {
+				t.Fatalf("error running downward sync: %v", err)
+			}
+			created := false
+			for _, action := range actions {
+				created = created || action.Matches("create", "pods")
+			}
+			if tc.expectedError != "" {
+				if reconcileErr == nil || !strings.Contains(reconcileErr.Error(), tc.expectedError) {
+					t.Errorf("expected error %q, got %v", tc.expectedError, reconcileErr)
+				}
+				if created {
+					t.Errorf("expected no pod to be created, got %v", actions)
+				}
+				return
+			}
+			if reconcileErr != nil {
+				t.Fatalf("unexpected reconcile error: %v", reconcileErr)
+			}
+			if !created {
+				t.Errorf("expected a pod to be created, got %v", actions)
+			}
+		})
+	}
+}
+
+func TestDWPodReadinessGates(t *testing.T) {
+	newPodController := func(config *config.SyncerConfiguration,
+		client clientset.Interface,
+		informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface,
+		vcInformer vcinformers.VirtualClusterInformer,
+		options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		config.PodReadinessGates = []string{"example.com/tenant-ready", "example.com/mesh-ready"}
+		return NewPodController(config, client, informer, vcClient, vcInformer, options)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		annotation string
+		expected   []corev1.PodReadinessGate
+	}{
+		{
+			name: "tenant without gate controller",
+		},
+		{
+			name:       "tenant opted in",
+			annotation: "example.com/tenant-ready",
+			expected:   []corev1.PodReadinessGate{{ConditionType: "example.com/tenant-ready"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testTenant := &v1alpha1.VirtualCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "tenant-1",
+					UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+				},
+				Status: v1alpha1.VirtualClusterStatus{
+					Phase: v1alpha1.ClusterRunning,
+				},
+			}
+			if tt.annotation != "" {
+				testTenant.Annotations = map[string]string{constants.LabelPodReadinessGates: tt.annotation}
+			}
+			superDefaultNSName := conversion.ToSuperClusterNamespace(conversion.ToClusterKey(testTenant), "default")
+
+			vPod := tenantPod("pod-1", "default", "12345")
+			actions, reconcileErr, err := util.RunDownwardSync(newPodController, testTenant,
+				[]runtime.Object{
+					superSecret("default-token-12345", superDefaultNSName, "s12345"),
+					superService("kubernetes", superDefaultNSName, "12345", ""),
+				},
+				[]runtime.Object{
+					vPod,
+					tenantSecret(testTenantServiceAccountTokenSecretName, "default", "s12345"),
+					tenantServiceAccount("default", "default", "12345"),
+				}, vPod, nil)
+			if err != nil {
+				t.Fatalf("error running downward sync: %v", err)
+			}
+			if reconcileErr != nil {
+				t.Fatalf("unexpected reconcile error: %v", reconcileErr)
+			}
+			if len(actions) != 1 || !actions[0].Matches("create", "pods") {
+				t.Fatalf("expected a pod to be created, got %v", actions)
+			}
+			pPod := actions[0].(core.CreateAction).GetObject().(*corev1.Pod)
+			if !equality.Semantic.DeepEqual(pPod.Spec.ReadinessGates, tt.expected) {
+				t.Errorf("expected readiness gates %v, got %v", tt.expected, pPod.Spec.ReadinessGates)
+			}
+		})
+	}
+}
+
+func TestDWPodSchedulingZone(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	superDefaultNSName := conversion.ToSuperClusterNamespace(conversion.ToClusterKey(testTenant), "default")
+
+	for _, tt := range []struct {
+		name         string
+		zone         string
+		nodeSelector map[string]string
+		expected     map[string]string
+	}{
+		{
+			name: "namespace without zone",
+		},
+		{
+			name:     "namespace placed in a zone",
+			zone:     "zone-a",
+			expected: map[string]string{corev1.LabelTopologyZone: "zone-a"},
+		},
+		{
+			name:         "pod selecting another zone",
+			zone:         "zone-a",
+			nodeSelector: map[string]string{corev1.LabelTopologyZone: "zone-b", "disk": "ssd"},
+			expected:     map[string]string{corev1.LabelTopologyZone: "zone-a", "disk": "ssd"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			vNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+			if tt.zone != "" {
+				vNamespace.Annotations = map[string]string{utilconst.LabelSchedulingZone: tt.zone}
+			}
+			vPod := tenantPod("pod-1", "default", "12345")
+			vPod.Spec.NodeSelector = tt.nodeSelector
+			actions, reconcileErr, err := util.RunDownwardSync(NewPodController, testTenant,
+				[]runtime.Object{
+					superSecret("default-token-12345", superDefaultNSName, "s12345"),
+					superService("kubernetes", superDefaultNSName, "12345", ""),
+				},
+				[]runtime.Object{
+					vNamespace,
+					vPod,
+					tenantSecret(testTenantServiceAccountTokenSecretName, "default", "s12345"),
+					tenantServiceAccount("default", "default", "12345"),
+				}, vPod, nil)
+			if err != nil {
+				t.Fatalf("error running downward sync: %v", err)
+			}
+			if reconcileErr != nil {
+				t.Fatalf("unexpected reconcile error: %v", reconcileErr)
+			}
+			if len(actions) != 1 || !actions[0].Matches("create", "pods") {
+				t.Fatalf("expected a pod to be created, got %v", actions)
+			}
+			pPod := actions[0].(core.CreateAction).GetObject().(*corev1.Pod)
+			if !equality.Semantic.DeepEqual(pPod.Spec.NodeSelector, tt.expected) {
+				t.Errorf("expected node selector %v, got %v", tt.expected, pPod.Spec.NodeSelector)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutatorplugin
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+	uplugin "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+func init() {
+	MutatorRegister.Register(&uplugin.Registration{
+		ID: "00_PodSchedulingZoneMutator",
+		InitFn: func(ctx *uplugin.InitContext) (interface{}, error) {
+			return &PodSchedulingZoneMutatorPlugin{}, nil
+		},
+	})
+}
+
+type PodSchedulingZoneMutatorPlugin struct{}
+
+// Mutator pins the pods of a tenant namespace placed in a zone by the scheduler to the nodes of
+// this zone, the capacity of the zone is reserved for the namespace.
+func (pl *PodSchedulingZoneMutatorPlugin) Mutator() conversion.PodMutator {
+	return func(p *conversion.PodMutateCtx) error {
+		zone, err := schedulingZone(p)
+		if err != nil || zone == "" {
+			return err
+		}
+		if p.PPod.Spec.NodeSelector == nil {
+			p.PPod.Spec.NodeSelector = make(map[string]string)
+		}
+		p.PPod.Spec.NodeSelector[corev1.LabelTopologyZone] = zone
+		return nil
+	}
+}
+
+// schedulingZone returns the zone the tenant namespace of the pod is placed in, empty if it does
+// not request a zone.
+func schedulingZone(p *conversion.PodMutateCtx) (string, error) {
+	cluster := p.Mc.GetCluster(p.ClusterName)
+	if cluster == nil {
+		return "", errors.NewClusterNotFound(p.ClusterName)
+	}
+	tenantClient, err := cluster.GetDelegatingClient()
+	if err != nil {
+		return "", err
+	}
+	vNamespace := &corev1.Namespace{}
+	err = tenantClient.Get(context.TODO(), client.ObjectKey{Name: p.VPod.Namespace}, vNamespace)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return vNamespace.Annotations[utilconst.LabelSchedulingZone], nil
+}
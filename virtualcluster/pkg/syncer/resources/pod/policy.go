@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+// getTenantPodPolicy returns the TenantPodPolicy spec merged from the policies selecting vc, or
+// nil if the feature is disabled or no policy selects it.
+func (c *controller) getTenantPodPolicy(vc *v1alpha1.VirtualCluster) (*v1alpha1.TenantPodPolicySpec, error) {
+	if !featuregate.DefaultFeatureGate.Enabled(featuregate.TenantPodPolicy) || c.policyLister == nil {
+		return nil, nil
+	}
+	policies, err := c.policyLister.TenantPodPolicies(vc.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return mergeTenantPodPolicies(policies, vc), nil
+}
+
+// selectsVirtualCluster returns true if the policy applies to vc. A policy without selector
+// applies to all the VirtualClusters of its namespace.
+func selectsVirtualCluster(policy *v1alpha1.TenantPodPolicy, vc *v1alpha1.VirtualCluster) bool {
+	if policy.Namespace != vc.Namespace {
+		return false
+	}
+	if policy.Spec.VirtualClusterSelector == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.VirtualClusterSelector)
+	if err != nil {
+		klog.Warningf("ignore TenantPodPolicy %s/%s with invalid selector: %v", policy.Namespace, policy.Name, err)
+		return false
+	}
+	return selector.Matches(labels.Set(vc.Labels))
+}
+
+// mergeTenantPodPolicies merges the policies selecting vc in the order of their names, the
+// first policy setting a node selector key, a topology key or a resource quota wins.
+func mergeTenantPodPolicies(policies []*v1alpha1.TenantPodPolicy, vc *v1alpha1.VirtualCluster) *v1alpha1.TenantPodPolicySpec {
+	sorted := make([]*v1alpha1.TenantPodPolicy, 0, len(policies))
+	for _, p := range policies {
+		if selectsVirtualCluster(p, vc) {
+			sorted = append(sorted, p)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	merged := &v1alpha1.TenantPodPolicySpec{}
+	for _, p := range sorted {
+		for k, v := range p.Spec.NodeSelector {
+			if merged.NodeSelector == nil {
+				merged.NodeSelector = make(map[string]string)
+			}
+			if _, exists := merged.NodeSelector[k]; !exists {
+				merged.NodeSelector[k] = v
+			}
+		}
+		merged.Tolerations = append(merged.Tolerations, missingTolerations(merged.Tolerations, p.Spec.Tolerations)...)
+		merged.TopologySpreadConstraints = mergeTopologySpreadConstraints(merged.TopologySpreadConstraints, p.Spec.TopologySpreadConstraints)
+		if merged.RuntimeClassName == nil && p.Spec.RuntimeClassName != nil {
+			merged.RuntimeClassName = p.Spec.RuntimeClassName
+		}
+		for k, v := range p.Spec.ExtendedResourceQuota {
+			if merged.ExtendedResourceQuota == nil {
+				merged.ExtendedResourceQuota = make(corev1.ResourceList)
+			}
+			if _, exists := merged.ExtendedResourceQuota[k]; !exists {
+				merged.ExtendedResourceQuota[k] = v
+			}
+		}
+	}
+	return merged
+}
+
+// missingTolerations returns the tolerations of expected not in existing.
+func missingTolerations(existing, expected []corev1.Toleration) []corev1.Toleration {
+	var missing []corev1.Toleration
+	for i := range expected {
+		found := false
+		for j := range existing {
+			if existing[j].MatchToleration(&expected[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, expected[i])
+		}
+	}
+	return missing
+}
+
+// mergeTopologySpreadConstraints appends the constraints of expected whose topology key
+// isn't constrained by existing yet.
+func mergeTopologySpreadConstraints(existing, expected []corev1.TopologySpreadConstraint) []corev1.TopologySpreadConstraint {
+	for _, e := range expected {
+		found := false
+		for _, c := range existing {
+			if c.TopologyKey == e.TopologyKey {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing = append(existing, e)
+		}
+	}
+	return existing
+}
+
+// applyTenantPodPolicy injects the policy into the spec of pPod. The node selector of the
+// policy overrides the tenant one, the other fields only fill what the tenant left unset.
+func applyTenantPodPolicy(policy *v1alpha1.TenantPodPolicySpec, pPod *corev1.Pod) {
+	for k, v := range policy.NodeSelector {
+		if pPod.Spec.NodeSelector == nil {
+			pPod.Spec.NodeSelector = make(map[string]string)
+		}
+		pPod.Spec.NodeSelector[k] = v
+	}
+	pPod.Spec.Tolerations = append(pPod.Spec.Tolerations, missingTolerations(pPod.Spec.Tolerations, policy.Tolerations)...)
+	pPod.Spec.TopologySpreadConstraints = mergeTopologySpreadConstraints(pPod.Spec.TopologySpreadConstraints, policy.TopologySpreadConstraints)
+	if pPod.Spec.RuntimeClassName == nil && policy.RuntimeClassName != nil {
+		runtimeClassName := *policy.RuntimeClassName
+		pPod.Spec.RuntimeClassName = &runtimeClassName
+	}
+}
+
+// policyChangedFields returns the pod spec fields that applying the policy changes from the spec of vPod.
+func policyChangedFields(policy *v1alpha1.TenantPodPolicySpec, vPod *corev1.Pod) []string {
+	var fields []string
+	for k, v := range policy.NodeSelector {
+		if vPod.Spec.NodeSelector[k] != v {
+			fields = append(fields, "nodeSelector")
+			break
+		}
+	}
+	if len(missingTolerations(vPod.Spec.Tolerations, policy.Tolerations)) > 0 {
+		fields = append(fields, "tolerations")
+	}
+	existing := append([]corev1.TopologySpreadConstraint{}, vPod.Spec.TopologySpreadConstraints...)
+	if len(mergeTopologySpreadConstraints(existing, policy.TopologySpreadConstraints)) != len(vPod.Spec.TopologySpreadConstraints) {
+		fields = append(fields, "topologySpreadConstraints")
+	}
+	if vPod.Spec.RuntimeClassName == nil && policy.RuntimeClassName != nil {
+		fields = append(fields, "runtimeClassName")
+	}
+	return fields
+}
+
+// tenantPodPolicyMutator injects the policy into the pPod to be created.
+func tenantPodPolicyMutator(policy *v1alpha1.TenantPodPolicySpec) conversion.PodMutator {
+	return func(p *conversion.PodMutateCtx) error {
+		applyTenantPodPolicy(policy, p.PPod)
+		return nil
+	}
+}
+
+// policyMissingTolerations returns the tolerations of the policy missing in pPod. Tolerations
+// are the only field of the policy that can be added to a running pod.
+func policyMissingTolerations(policy *v1alpha1.TenantPodPolicySpec, pPod *corev1.Pod) []corev1.Toleration {
+	if policy == nil {
+		return nil
+	}
+	return missingTolerations(pPod.Spec.Tolerations, policy.Tolerations)
+}
+
+// enqueueTenantPodPolicy requeues the tenant pods of the VirtualClusters selected by the
+// policy, so a policy change reaches the existing pods without waiting for the patroller.
+func (c *controller) enqueueTenantPodPolicy(obj interface{}) {
+	policy, ok := obj.(*v1alpha1.TenantPodPolicy)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return
+		}
+		if policy, ok = tombstone.Obj.(*v1alpha1.TenantPodPolicy); !ok {
+			return
+		}
+	}
+
+	for _, clusterName := range c.MultiClusterController.GetClusterNames() {
+		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+		if err != nil || !selectsVirtualCluster(policy, vc) {
+			continue
+		}
+		vPodList := &corev1.PodList{}
+		if err := c.MultiClusterController.List(clusterName, vPodList); err != nil {
+			utilruntime.HandleError(err)
+			continue
+		}
+		for i := range vPodList.Items {
+			if err := c.MultiClusterController.RequeueObject(clusterName, &vPodList.Items[i]); err != nil {
+				klog.Errorf("error requeue vPod %s/%s in cluster %s: %v", vPodList.Items[i].Namespace, vPodList.Items[i].Name, clusterName, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestMergeTenantPodPolicies(t *testing.T) {
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "tenant-1", Labels: map[string]string{"tier": "gold"}},
+	}
+	gpu := corev1.Toleration{Key: "gpu", Operator: corev1.TolerationOpExists}
+	dedicated := corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "a"}
+	zone := corev1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway}
+
+	policies := []*v1alpha1.TenantPodPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "tenant-1"},
+			Spec: v1alpha1.TenantPodPolicySpec{
+				NodeSelector:     map[string]string{"pool": "shared", "arch": "amd64"},
+				Tolerations:      []corev1.Toleration{gpu, dedicated},
+				RuntimeClassName: pointer.StringPtr("runc"),
+				ExtendedResourceQuota: corev1.ResourceList{
+					"nvidia.com/gpu":            resource.MustParse("8"),
+					"intel.com/sriov_netdevice": resource.MustParse("4"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "tenant-1"},
+			Spec: v1alpha1.TenantPodPolicySpec{
+				VirtualClusterSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+				NodeSelector:              map[string]string{"pool": "gold"},
+				Tolerations:               []corev1.Toleration{gpu},
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{zone},
+				RuntimeClassName:          pointer.StringPtr("gvisor"),
+				ExtendedResourceQuota:     corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-tier", Namespace: "tenant-1"},
+			Spec: v1alpha1.TenantPodPolicySpec{
+				VirtualClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "silver"}},
+				NodeSelector:           map[string]string{"pool": "silver"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-namespace", Namespace: "tenant-2"},
+			Spec:       v1alpha1.TenantPodPolicySpec{NodeSelector: map[string]string{"pool": "other"}},
+		},
+	}
+
+	expected := &v1alpha1.TenantPodPolicySpec{
+		NodeSelector:              map[string]string{"pool": "gold", "arch": "amd64"},
+		Tolerations:               []corev1.Toleration{gpu, dedicated},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{zone},
+		RuntimeClassName:          pointer.StringPtr("gvisor"),
+		ExtendedResourceQuota: corev1.ResourceList{
+			"nvidia.com/gpu":            resource.MustParse("2"),
+			"intel.com/sriov_netdevice": resource.MustParse("4"),
+		},
+	}
+	if merged := mergeTenantPodPolicies(policies, vc); !equality.Semantic.DeepEqual(merged, expected) {
+		t.Errorf("expected merged policy %+v, got %+v", expected, merged)
+	}
+	if merged := mergeTenantPodPolicies(policies[2:], vc); merged != nil {
+		t.Errorf("expected no policy, got %+v", merged)
+	}
+}
+
+func TestApplyTenantPodPolicy(t *testing.T) {
+	gpu := corev1.Toleration{Key: "gpu", Operator: corev1.TolerationOpExists}
+	tenantZone := corev1.TopologySpreadConstraint{MaxSkew: 2, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule}
+	policyZone := corev1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway}
+	policyHost := corev1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.ScheduleAnyway}
+	policy := &v1alpha1.TenantPodPolicySpec{
+		NodeSelector:              map[string]string{"pool": "gold"},
+		Tolerations:               []corev1.Toleration{gpu},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{policyZone, policyHost},
+		RuntimeClassName:          pointer.StringPtr("gvisor"),
+	}
+
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			NodeSelector:              map[string]string{"pool": "any", "disk": "ssd"},
+			Tolerations:               []corev1.Toleration{gpu},
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{tenantZone},
+			RuntimeClassName:          pointer.StringPtr("kata"),
+		},
+	}
+	if fields := policyChangedFields(policy, pod); !equality.Semantic.DeepEqual(fields, []string{"nodeSelector", "topologySpreadConstraints"}) {
+		t.Errorf("expected the policy to change nodeSelector and topologySpreadConstraints, got %v", fields)
+	}
+	applyTenantPodPolicy(policy, pod)
+
+	expected := corev1.PodSpec{
+		NodeSelector:              map[string]string{"pool": "gold", "disk": "ssd"},
+		Tolerations:               []corev1.Toleration{gpu},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{tenantZone, policyHost},
+		RuntimeClassName:          pointer.StringPtr("kata"),
+	}
+	if !equality.Semantic.DeepEqual(pod.Spec, expected) {
+		t.Errorf("expected spec %+v, got %+v", expected, pod.Spec)
+	}
+	if missing := policyMissingTolerations(policy, &corev1.Pod{}); !equality.Semantic.DeepEqual(missing, []corev1.Toleration{gpu}) {
+		t.Errorf("expected missing tolerations %v, got %v", []corev1.Toleration{gpu}, missing)
+	}
+}
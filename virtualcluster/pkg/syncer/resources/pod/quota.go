@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
+)
+
+// isExtendedResourceName returns true for the resources advertised by device plugins or
+// node admins, i.e. the fully qualified names outside of the kubernetes.io domain.
+func isExtendedResourceName(name corev1.ResourceName) bool {
+	if !strings.Contains(string(name), "/") || strings.Contains(string(name), corev1.ResourceDefaultNamespacePrefix) {
+		return false
+	}
+	return !strings.HasPrefix(string(name), corev1.DefaultResourceRequestsPrefix)
+}
+
+// podExtendedResourceRequests returns the extended resources requested by the pod the way the
+// scheduler accounts them, the larger of the sum of the containers and of any init container.
+func podExtendedResourceRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	add := func(list corev1.ResourceList, c corev1.Container, max bool) {
+		for name, q := range containerRequests(c) {
+			if !isExtendedResourceName(name) {
+				continue
+			}
+			existing, ok := list[name]
+			switch {
+			case !ok:
+				list[name] = q.DeepCopy()
+			case max:
+				if q.Cmp(existing) > 0 {
+					list[name] = q.DeepCopy()
+				}
+			default:
+				existing.Add(q)
+				list[name] = existing
+			}
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		add(requests, c, false)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		add(requests, c, true)
+	}
+	return requests
+}
+
+// containerRequests returns the requests of the container, extended resources only set in
+// the limits are requested as much as their limit.
+func containerRequests(c corev1.Container) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for name, q := range c.Resources.Limits {
+		requests[name] = q
+	}
+	for name, q := range c.Resources.Requests {
+		requests[name] = q
+	}
+	return requests
+}
+
+// quotaRequests returns the requests of the pod limited by the quota.
+func quotaRequests(quota corev1.ResourceList, pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for name, q := range podExtendedResourceRequests(pod) {
+		if _, limited := quota[name]; limited && !q.IsZero() {
+			requests[name] = q
+		}
+	}
+	return requests
+}
+
+// quotaExceededError is returned when creating a pod exceeds the extended resource quota of its tenant.
+type quotaExceededError struct {
+	message string
+}
+
+func (e *quotaExceededError) Error() string {
+	return e.message
+}
+
+// checkExtendedResourceQuota returns a quotaExceededError if the requests of the pod added to the
+// requests of the other pods exceed the quota. The pods that terminated release their
+// resources, the ones being deleted still hold them.
+func checkExtendedResourceQuota(quota, requests corev1.ResourceList, pod *corev1.Pod, pods []*corev1.Pod) error {
+	used := corev1.ResourceList{}
+	for _, p := range pods {
+		if p.Namespace == pod.Namespace && p.Name == pod.Name {
+			continue
+		}
+		if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for name, q := range quotaRequests(quota, p) {
+			existing := used[name]
+			existing.Add(q)
+			used[name] = existing
+		}
+	}
+
+	names := make([]string, 0, len(requests))
+	for name := range requests {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		requested, limit, inUse := requests[corev1.ResourceName(name)], quota[corev1.ResourceName(name)], used[corev1.ResourceName(name)]
+		total := inUse.DeepCopy()
+		total.Add(requested)
+		if total.Cmp(limit) > 0 {
+			return &quotaExceededError{fmt.Sprintf("exceeded extended resource quota: requested %s=%s, used %s, limited %s",
+				name, requested.String(), inUse.String(), limit.String())}
+		}
+	}
+	return nil
+}
+
+// podsOf returns the cached pods and the pods assumed by the tenant the cache has not seen
+// yet, the tenant must be locked.
+func podsOf(tenant *assume.Tenant, cached []*corev1.Pod) []*corev1.Pod {
+	seen := make(map[types.UID]bool, len(cached))
+	for _, pod := range cached {
+		seen[pod.UID] = true
+	}
+	pods := cached
+	for _, obj := range tenant.Assumed(func(obj metav1.Object) bool { return seen[obj.GetUID()] }) {
+		pods = append(pods, obj.(*corev1.Pod))
+	}
+	return pods
+}
+
+// enforceExtendedResourceQuota returns an error if creating pPod in the super cluster exceeds
+// the extended resource quota of the tenant. The usage is read from the informer cache, with
+// the pods created by the syncer the cache has not seen yet.
+func (c *controller) enforceExtendedResourceQuota(clusterName string, tenant *assume.Tenant, quota corev1.ResourceList, pPod *corev1.Pod) error {
+	requests := quotaRequests(quota, pPod)
+	if len(requests) == 0 {
+		return nil
+	}
+	pods, err := c.podLister.List(labels.SelectorFromSet(labels.Set{constants.LabelCluster: clusterName}))
+	if err != nil {
+		return fmt.Errorf("failed to list the pods of cluster %s: %v", clusterName, err)
+	}
+	return checkExtendedResourceQuota(quota, requests, pPod, podsOf(tenant, pods))
+}
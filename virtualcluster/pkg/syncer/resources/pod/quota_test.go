@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/assume"
+)
+
+func TestPodExtendedResourceRequests(t *testing.T) {
+	container := func(requests, limits corev1.ResourceList) corev1.Container {
+		return corev1.Container{Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits}}
+	}
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				container(nil, corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("3")}),
+				container(corev1.ResourceList{"intel.com/sriov_netdevice": resource.MustParse("1")}, nil),
+			},
+			Containers: []corev1.Container{
+				container(
+					corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), "nvidia.com/gpu": resource.MustParse("1")},
+					corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				),
+				container(nil, corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1"), "example.kubernetes.io/foo": resource.MustParse("1")}),
+				container(corev1.ResourceList{"intel.com/sriov_netdevice": resource.MustParse("2")}, nil),
+			},
+		},
+	}
+
+	expected := corev1.ResourceList{
+		"nvidia.com/gpu":            resource.MustParse("3"),
+		"intel.com/sriov_netdevice": resource.MustParse("2"),
+	}
+	if requests := podExtendedResourceRequests(pod); !equality.Semantic.DeepEqual(requests, expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
+
+func TestCheckExtendedResourceQuota(t *testing.T) {
+	gpuPod := func(name string, gpus string, phase corev1.PodPhase) *corev1.Pod {
+		pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse(gpus)}},
+		}}}}
+		pod.Name, pod.Namespace, pod.Status.Phase = name, "ns", phase
+		return pod
+	}
+	quota := corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")}
+
+	for _, tc := range []struct {
+		name        string
+		pods        []*corev1.Pod
+		expectError bool
+	}{
+		{name: "no pods"},
+		{name: "within quota", pods: []*corev1.Pod{gpuPod("a", "1", corev1.PodRunning), gpuPod("b", "1", corev1.PodPending)}},
+		{name: "exceeding quota", pods: []*corev1.Pod{gpuPod("a", "2", corev1.PodRunning), gpuPod("b", "2", corev1.PodPending)}, expectError: true},
+		{name: "terminated pods", pods: []*corev1.Pod{gpuPod("a", "2", corev1.PodSucceeded), gpuPod("b", "2", corev1.PodFailed)}},
+		{name: "the pod itself", pods: []*corev1.Pod{gpuPod("new", "2", corev1.PodPending), gpuPod("b", "2", corev1.PodRunning)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := gpuPod("new", "2", "")
+			err := checkExtendedResourceQuota(quota, quotaRequests(quota, pod), pod, tc.pods)
+			if tc.expectError != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestPodsOf(t *testing.T) {
+	pod := func(uid string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: uid, UID: types.UID(uid)}}
+	}
+	names := func(pods []*corev1.Pod) []string {
+		var names []string
+		for _, p := range pods {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	tenant := &assume.Tenant{}
+	tenant.Assume(pod("a"))
+	tenant.Assume(pod("b"))
+
+	if got := names(podsOf(tenant, []*corev1.Pod{pod("a"), pod("c")})); !reflect.DeepEqual(got, []string{"a", "c", "b"}) {
+		t.Errorf("expected the cached pods and the assumed pod b, got %v", got)
+	}
+	if got := names(podsOf(tenant, nil)); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("expected the assumed pod b, got %v", got)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// resizeSubresource is the pod subresource updating the container resources in place.
+const resizeSubresource = "resize"
+
+// resizeContainerStatusFields are the container status fields reported by the kubelet
+// during an in-place resize.
+var resizeContainerStatusFields = []string{"allocatedResources", "resources"}
+
+type containerResources struct {
+	Name      string                      `json:"name"`
+	Resources corev1.ResourceRequirements `json:"resources"`
+}
+
+// reconcilePodResize resizes pPod in place if the tenant changed the container resources.
+func (c *controller) reconcilePodResize(targetNamespace string, pPod, vPod *corev1.Pod) error {
+	resized := conversion.CheckDWPodResourcesEquality(pPod, vPod)
+	if len(resized) == 0 {
+		return nil
+	}
+	containers := make([]containerResources, 0, len(resized))
+	for _, r := range resized {
+		containers = append(containers, containerResources{Name: r.Name, Resources: r.Resources})
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": containers,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Pods(targetNamespace).Patch(context.TODO(), pPod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, resizeSubresource)
+	if err != nil {
+		return err
+	}
+	c.resizing.Store(pPod.UID, struct{}{})
+	return nil
+}
+
+// reconcileResizeStatus back populates the resize status of pPod to vPod while the resize
+// started by the syncer is not completed. The typed pods of the informer caches don't have
+// the resize fields, so the pods of the other resizes are not read at all. A resize in
+// progress while the syncer restarts is back populated by the kubelet status only.
+func (c *controller) reconcileResizeStatus(tenantClient rest.Interface, pPod, vPod *corev1.Pod) error {
+	if pPod.DeletionTimestamp != nil {
+		c.resizing.Delete(pPod.UID)
+		return nil
+	}
+	if _, ok := c.resizing.Load(pPod.UID); !ok {
+		return nil
+	}
+	completed, err := syncResizeStatus(context.TODO(), c.client.RESTClient(), tenantClient, pPod, vPod)
+	if err != nil {
+		return err
+	}
+	if completed {
+		c.resizing.Delete(pPod.UID)
+	}
+	return nil
+}
+
+// syncResizeStatus back populates the resize status and the allocated resources of pPod to
+// vPod, and returns whether the resize of pPod is completed. The typed pod of this client
+// version doesn't have those fields, so both pods are read and the tenant pod is patched as
+// raw json.
+func syncResizeStatus(ctx context.Context, superClient, tenantClient rest.Interface, pPod, vPod *corev1.Pod) (bool, error) {
+	pObj, err := getRawPod(ctx, superClient, pPod.Namespace, pPod.Name)
+	if err != nil {
+		return false, err
+	}
+	vObj, err := getRawPod(ctx, tenantClient, vPod.Namespace, vPod.Name)
+	if err != nil {
+		return false, err
+	}
+	patch, err := resizeStatusPatch(pObj, vObj)
+	if err != nil {
+		return false, err
+	}
+	if patch != nil {
+		err = tenantClient.Patch(types.MergePatchType).
+			Namespace(vPod.Namespace).
+			Resource("pods").
+			Name(vPod.Name).
+			SubResource("status").
+			Body(patch).
+			Do(ctx).
+			Error()
+		if err != nil {
+			return false, err
+		}
+	}
+	return resizeCompleted(pObj), nil
+}
+
+// resizeCompleted returns whether the kubelet has allocated the requested resources of all the
+// containers of pObj, with no resize pending or in progress.
+func resizeCompleted(pObj map[string]interface{}) bool {
+	if resize, _, _ := unstructured.NestedFieldNoCopy(pObj, "status", "resize"); resize != nil {
+		return false
+	}
+	allocated := make(map[string]interface{})
+	statuses, _, _ := unstructured.NestedSlice(pObj, "status", "containerStatuses")
+	for _, s := range statuses {
+		if m, ok := s.(map[string]interface{}); ok {
+			name, _ := m["name"].(string)
+			allocated[name] = m["allocatedResources"]
+		}
+	}
+	containers, _, _ := unstructured.NestedSlice(pObj, "spec", "containers")
+	for _, c := range containers {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		requests, _, _ := unstructured.NestedFieldNoCopy(m, "resources", "requests")
+		if !equality.Semantic.DeepEqual(requests, allocated[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+func getRawPod(ctx context.Context, client rest.Interface, namespace, name string) (map[string]interface{}, error) {
+	data, err := client.Get().Namespace(namespace).Resource("pods").Name(name).DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// resizeStatusPatch returns the merge patch setting the resize status of pObj to vObj, or nil
+// if they are equal. The container statuses are replaced as a whole by a merge patch, so the
+// patch carries all of them and the resource version guards against concurrent updates.
+func resizeStatusPatch(pObj, vObj map[string]interface{}) ([]byte, error) {
+	changed := false
+	status := make(map[string]interface{})
+
+	pResize, _, _ := unstructured.NestedFieldNoCopy(pObj, "status", "resize")
+	vResize, _, _ := unstructured.NestedFieldNoCopy(vObj, "status", "resize")
+	if !equality.Semantic.DeepEqual(pResize, vResize) {
+		// a nil resize removes the field from the tenant pod.
+		status["resize"] = pResize
+		changed = true
+	}
+
+	pStatuses := make(map[string]map[string]interface{})
+	pList, _, _ := unstructured.NestedSlice(pObj, "status", "containerStatuses")
+	for _, s := range pList {
+		if m, ok := s.(map[string]interface{}); ok {
+			name, _ := m["name"].(string)
+			pStatuses[name] = m
+		}
+	}
+	vList, _, _ := unstructured.NestedSlice(vObj, "status", "containerStatuses")
+	containersChanged := false
+	for _, s := range vList {
+		v, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := v["name"].(string)
+		p, exists := pStatuses[name]
+		if !exists {
+			continue
+		}
+		for _, field := range resizeContainerStatusFields {
+			if equality.Semantic.DeepEqual(p[field], v[field]) {
+				continue
+			}
+			if p[field] == nil {
+				delete(v, field)
+			} else {
+				v[field] = p[field]
+			}
+			containersChanged = true
+		}
+	}
+	if containersChanged {
+		status["containerStatuses"] = vList
+		changed = true
+	}
+
+	if !changed {
+		return nil, nil
+	}
+	resourceVersion, _, _ := unstructured.NestedString(vObj, "metadata", "resourceVersion")
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": resourceVersion,
+		},
+		"status": status,
+	})
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResizeStatusPatch(t *testing.T) {
+	pod := func(data string) map[string]interface{} {
+		obj := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(data), &obj); err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+
+	for name, tc := range map[string]struct {
+		pPod     string
+		vPod     string
+		expected string
+	}{
+		"no resize": {
+			pPod: `{"status":{"containerStatuses":[{"name":"c-1","ready":true}]}}`,
+			vPod: `{"metadata":{"resourceVersion":"10"},"status":{"containerStatuses":[{"name":"c-1","ready":true}]}}`,
+		},
+		"resize in progress": {
+			pPod:     `{"status":{"resize":"InProgress","containerStatuses":[{"name":"c-1","ready":true,"allocatedResources":{"cpu":"500m"}}]}}`,
+			vPod:     `{"metadata":{"resourceVersion":"10"},"status":{"containerStatuses":[{"name":"c-1","ready":false,"allocatedResources":{"cpu":"100m"}}]}}`,
+			expected: `{"metadata":{"resourceVersion":"10"},"status":{"containerStatuses":[{"allocatedResources":{"cpu":"500m"},"name":"c-1","ready":false}],"resize":"InProgress"}}`,
+		},
+		"resize done": {
+			pPod:     `{"status":{"containerStatuses":[{"name":"c-1","allocatedResources":{"cpu":"500m"}}]}}`,
+			vPod:     `{"metadata":{"resourceVersion":"11"},"status":{"resize":"InProgress","containerStatuses":[{"name":"c-1","allocatedResources":{"cpu":"500m"}}]}}`,
+			expected: `{"metadata":{"resourceVersion":"11"},"status":{"resize":null}}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			patch, err := resizeStatusPatch(pod(tc.pPod), pod(tc.vPod))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(patch) != tc.expected {
+				t.Errorf("expected patch %s, got %s", tc.expected, patch)
+			}
+		})
+	}
+}
+
+func TestResizeCompleted(t *testing.T) {
+	for name, tc := range map[string]struct {
+		pPod     string
+		expected bool
+	}{
+		"allocated": {
+			pPod:     `{"spec":{"containers":[{"name":"c-1","resources":{"requests":{"cpu":"500m"}}}]},"status":{"containerStatuses":[{"name":"c-1","allocatedResources":{"cpu":"500m"}}]}}`,
+			expected: true,
+		},
+		"resize in progress": {
+			pPod: `{"spec":{"containers":[{"name":"c-1","resources":{"requests":{"cpu":"500m"}}}]},"status":{"resize":"InProgress","containerStatuses":[{"name":"c-1","allocatedResources":{"cpu":"500m"}}]}}`,
+		},
+		"resize not picked up by the kubelet yet": {
+			pPod: `{"spec":{"containers":[{"name":"c-1","resources":{"requests":{"cpu":"500m"}}}]},"status":{"containerStatuses":[{"name":"c-1","allocatedResources":{"cpu":"100m"}}]}}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			obj := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(tc.pPod), &obj); err != nil {
+				t.Fatal(err)
+			}
+			if got := resizeCompleted(obj); got != tc.expected {
+				t.Errorf("expected completed %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestReconcileResizeStatusOfUntrackedPods(t *testing.T) {
+	// the controller has no client, reading the raw pods would panic
+	c := &controller{}
+	pPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", UID: "12345"}}
+	if err := c.reconcileResizeStatus(nil, pPod, pPod.DeepCopy()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c.resizing.Store(pPod.UID, struct{}{})
+	deleted := pPod.DeepCopy()
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	if err := c.reconcileResizeStatus(nil, deleted, pPod.DeepCopy()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, ok := c.resizing.Load(pPod.UID); ok {
+		t.Errorf("expected the resize of a deleted pod to be forgotten")
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
@@ -132,10 +133,21 @@ func (c *controller) BackPopulate(key string) error {
 		}
 	}
 
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.InPlacePodResize) {
+		if err := c.reconcileResizeStatus(tenantClient.CoreV1().RESTClient(), pPod, vPod); err != nil {
+			return fmt.Errorf("failed to back populate pod %s/%s resize status for cluster %s: %v", vPod.Namespace, vPod.Name, clusterName, err)
+		}
+	}
+
 	// pPod is under deletion.
 	if pPod.DeletionTimestamp != nil {
 		if vPod.DeletionTimestamp == nil {
 			klog.V(4).Infof("pPod %s/%s is under deletion accidentally", pPod.Namespace, pPod.Name)
+			if featuregate.DefaultFeatureGate.Enabled(featuregate.NodeMaintenanceEviction) {
+				// Explain the disruption to tenant operators, e.g., the super cluster node is drained.
+				c.recordPodEvent(clusterName, vPod, corev1.EventTypeWarning, constants.ReasonDeletedBySuperCluster,
+					"Pod is deleted in the super cluster from node %s", vPod.Spec.NodeName)
+			}
 			gracePeriod := int64(minimumGracePeriodInSeconds)
 			if vPod.Spec.TerminationGracePeriodSeconds != nil {
 				gracePeriod = *vPod.Spec.TerminationGracePeriodSeconds
@@ -144,9 +156,9 @@ func (c *controller) BackPopulate(key string) error {
 			if err = tenantClient.CoreV1().Pods(vPod.Namespace).Delete(context.TODO(), vPod.Name, *deleteOptions); err != nil {
 				return err
 			}
-		} else if *vPod.DeletionGracePeriodSeconds != *pPod.DeletionGracePeriodSeconds {
-			klog.V(4).Infof("delete virtual pPod %s/%s with grace period seconds %v", vPod.Namespace, vPod.Name, *pPod.DeletionGracePeriodSeconds)
-			deleteOptions := metav1.NewDeleteOptions(*pPod.DeletionGracePeriodSeconds)
+		} else if gracePeriod, shorter := shortenedGracePeriod(pPod, vPod); shorter {
+			klog.V(4).Infof("delete virtual pPod %s/%s with grace period seconds %v", vPod.Namespace, vPod.Name, gracePeriod)
+			deleteOptions := metav1.NewDeleteOptions(gracePeriod)
 			deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(vPod.UID))
 			if err = tenantClient.CoreV1().Pods(vPod.Namespace).Delete(context.TODO(), vPod.Name, *deleteOptions); err != nil {
 				return err
@@ -165,31 +177,46 @@ func (c *controller) bindPodToNode(pPod *corev1.Pod, clusterName string, tenantC
 	if err != nil {
 		return fmt.Errorf("failed to get node %s from super control plane: %v", pPod.Spec.NodeName, err)
 	}
+	vNodeName := vnode.TenantNodeName(c.Config, n)
 	// We need to handle the race with vNodeGC thread here.
 	if err = func() error {
 		c.Lock()
 		defer c.Unlock()
-		if !c.removeQuiescingNodeFromClusterVNodeGCMap(clusterName, pPod.Spec.NodeName) {
-			return fmt.Errorf("the bind target vNode %s is being GCed in cluster %s, retry", pPod.Spec.NodeName, clusterName)
+		if !c.removeQuiescingNodeFromClusterVNodeGCMap(clusterName, vNodeName) {
+			return fmt.Errorf("the bind target vNode %s is being GCed in cluster %s, retry", vNodeName, clusterName)
 		}
 		return nil
 	}(); err != nil {
 		return err
 	}
 
-	if err := c.MultiClusterController.Get(clusterName, "", n.GetName(), &corev1.Node{}); err != nil {
+	if err := c.MultiClusterController.Get(clusterName, "", vNodeName, &corev1.Node{}); err != nil {
 		// check if target node has already registered on the vc
 		// before creating
 		if !apierrors.IsNotFound(err) {
 			return err
 		}
+		if vnode.IsAggregatedNodeName(vNodeName) {
+			nodeList, err := c.client.Nodes().List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list nodes of pool %s from super control plane: %v", vNodeName, err)
+			}
+			// the bind target node is always part of the pool, even if the list is stale.
+			nodes := []*corev1.Node{n}
+			for i := range nodeList.Items {
+				if nodeList.Items[i].Name != n.Name {
+					nodes = append(nodes, &nodeList.Items[i])
+				}
+			}
+			n = vnode.AggregateNodes(vNodeName, vnode.FilterPoolNodes(c.Config, vNodeName, nodes))
+		}
 		vn, err := vnode.NewVirtualNode(c.vnodeProvider, n)
 		if err != nil {
-			return fmt.Errorf("failed to create virtual node %s in cluster %s from provider: %v", pPod.Spec.NodeName, clusterName, err)
+			return fmt.Errorf("failed to create virtual node %s in cluster %s from provider: %v", vNodeName, clusterName, err)
 		}
 		_, err = tenantClient.CoreV1().Nodes().Create(context.TODO(), vn, metav1.CreateOptions{})
 		if err != nil && !apierrors.IsAlreadyExists(err) {
-			return fmt.Errorf("failed to create virtual node %s in cluster %s with err: %v", pPod.Spec.NodeName, clusterName, err)
+			return fmt.Errorf("failed to create virtual node %s in cluster %s with err: %v", vNodeName, clusterName, err)
 		}
 	}
 
@@ -200,12 +227,12 @@ func (c *controller) bindPodToNode(pPod *corev1.Pod, clusterName string, tenantC
 		},
 		Target: corev1.ObjectReference{
 			Kind:       "Node",
-			Name:       pPod.Spec.NodeName,
+			Name:       vNodeName,
 			APIVersion: "v1",
 		},
 	}, metav1.CreateOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to bind vPod %s/%s to node %s %v", vPod.Namespace, vPod.Name, pPod.Spec.NodeName, err)
+		return fmt.Errorf("failed to bind vPod %s/%s to node %s %v", vPod.Namespace, vPod.Name, vNodeName, err)
 	}
 	return nil
 }
@@ -331,6 +331,18 @@ func TestUWPodDeletion(t *testing.T) {
 			ExpectedDeletePods: []string{"default/pod-1"},
 			ExpectedError:      "",
 		},
+		"pPod deleting with a longer grace period than vPod": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyDeletionTimestampToPod(applyStatusToPod(superAssignedPod("pod-1", superDefaultNSName, "12345", "n1", defaultClusterKey), statusRunning), time.Now(), 30),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyDeletionTimestampToPod(applyStatusToPod(tenantAssignedPod("pod-1", "default", "12345", "n1"), statusRunning), time.Now(), 5),
+				fakeNode("n1"),
+			},
+			EnquedKey:          superDefaultNSName + "/pod-1",
+			ExpectedDeletePods: []string{},
+			ExpectedError:      "",
+		},
 	}
 
 	for k, tc := range testcases {
@@ -370,6 +382,13 @@ func TestUWPodDeletion(t *testing.T) {
 					t.Errorf("%s: Expect deleted pod %s but not found", k, expectedName)
 				}
 			}
+			if len(tc.ExpectedDeletePods) == 0 {
+				for _, action := range actions {
+					if action.Matches("delete", "pods") {
+						t.Errorf("%s: Unexpected action %s", k, action)
+					}
+				}
+			}
 		})
 	}
 }
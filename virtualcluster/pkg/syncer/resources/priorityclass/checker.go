@@ -102,6 +102,9 @@ func (c *controller) checkPriorityClassOfTenantCluster(clusterName string) {
 		pPriorityClass, err := c.priorityclassLister.Get(vPriorityClass.Name)
 		if apierrors.IsNotFound(err) {
 			// super control plane is the source of the truth for priorityclass object, delete tenant control plane obj
+			if !c.RemediationBudget().Take(clusterName) {
+				continue
+			}
 			tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
 			if err != nil {
 				klog.Errorf("error getting cluster %s clientset: %v", clusterName, err)
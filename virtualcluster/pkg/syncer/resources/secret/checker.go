@@ -26,12 +26,15 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/ondemand"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -74,6 +77,7 @@ func (c *controller) PatrollerDo() {
 	}
 
 	klog.V(4).Infof("check secrets consistency in super")
+	referencedSecrets := map[string]sets.String{}
 	for _, pSecret := range secretList {
 		// service account token type secret are managed by super individually.
 		if pSecret.Type == corev1.SecretTypeServiceAccountToken {
@@ -84,6 +88,9 @@ func (c *controller) PatrollerDo() {
 		if len(clusterName) == 0 || len(vNamespace) == 0 {
 			continue
 		}
+		if c.MultiClusterController.SyncFrozenFor(clusterName, vNamespace) > 0 {
+			continue
+		}
 
 		shouldDelete := false
 
@@ -103,10 +110,32 @@ func (c *controller) PatrollerDo() {
 			if pSecret.Annotations[constants.LabelUID] != string(vSecret.UID) {
 				shouldDelete = true
 				klog.Warningf("Found pSecret %s/%s delegated UID is different from tenant object.", pSecret.Namespace, pSecret.Name)
+			} else if vSecret.Type != corev1.SecretTypeServiceAccountToken && ondemand.Enabled() {
+				referenced, ok := referencedSecrets[clusterName]
+				if !ok {
+					referenced, err = c.referencedObjects(clusterName)
+					if err != nil {
+						klog.Errorf("error listing the references of the secrets of cluster %s: %v", clusterName, err)
+						continue
+					}
+					referencedSecrets[clusterName] = referenced
+				}
+				shouldDelete = !referenced.Has(vNamespace + "/" + vSecretName)
 			}
 		}
 
 		if shouldDelete {
+			if err := conversion.VerifyOwnership(pSecret); err != nil {
+				klog.Errorf("refusing to delete pSecret %s/%s in super control plane: %v", pSecret.Namespace, pSecret.Name, err)
+				shouldDelete = false
+			}
+		}
+		if shouldDelete {
+			if retained, err := recyclebin.Retains(c.MultiClusterController, pSecret); err != nil || retained {
+				shouldDelete = false
+			}
+		}
+		if shouldDelete && c.RemediationBudget().Take(clusterName) {
 			deleteOptions := metav1.NewPreconditionDeleteOptions(string(pSecret.UID))
 			if err := c.secretClient.Secrets(pSecret.Namespace).Delete(context.TODO(), pSecret.Name, *deleteOptions); err != nil {
 				klog.Errorf("error deleting pSecret %s/%s in super control plane: %v", pSecret.Namespace, pSecret.Name, err)
@@ -128,6 +157,14 @@ func (c *controller) checkSecretOfTenantCluster(clusterName string) {
 		return
 	}
 	klog.V(4).Infof("check secrets consistency in cluster %s", clusterName)
+	var referenced sets.String
+	if ondemand.Enabled() {
+		referenced, err = c.referencedObjects(clusterName)
+		if err != nil {
+			klog.Errorf("error listing the references of the secrets of cluster %s: %v", clusterName, err)
+			return
+		}
+	}
 
 	for i, vSecret := range secretList.Items {
 		targetNamespace := conversion.ToSuperClusterNamespace(clusterName, vSecret.Namespace)
@@ -136,6 +173,10 @@ func (c *controller) checkSecretOfTenantCluster(clusterName string) {
 			c.checkServiceAccountTokenTypeSecretOfTenantCluster(clusterName, targetNamespace, &secretList.Items[i])
 			continue
 		}
+		// the secrets no pod or ingress references are not synced, their copies are deleted by the super loop
+		if referenced != nil && !referenced.Has(vSecret.Namespace+"/"+vSecret.Name) {
+			continue
+		}
 
 		pSecret, err := c.secretLister.Secrets(targetNamespace).Get(vSecret.Name)
 		if apierrors.IsNotFound(err) {
@@ -156,16 +197,29 @@ func (c *controller) checkSecretOfTenantCluster(clusterName string) {
 			klog.Errorf("Found pSecret %s/%s delegated UID is different from tenant object.", targetNamespace, pSecret.Name)
 			continue
 		}
+		if c.FinalizerTranslator().Expired(pSecret) {
+			if err := c.MultiClusterController.RequeueObject(clusterName, &secretList.Items[i]); err != nil {
+				klog.Errorf("error requeue vSecret %v/%v in cluster %s: %v", vSecret.Namespace, vSecret.Name, clusterName, err)
+			} else {
+				metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantOpaqueSecrets").Inc()
+			}
+			continue
+		}
 		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
 		if err != nil {
 			klog.Errorf("fail to get cluster spec : %s", clusterName)
 			continue
 		}
 
+		if conversion.PatrolSkipsEquality(pSecret, &secretList.Items[i]) {
+			continue
+		}
 		updatedSecret := conversion.Equality(c.Config, vc).CheckSecretEquality(pSecret, &secretList.Items[i])
 		if updatedSecret != nil {
 			atomic.AddUint64(&numMissMatchedOpaqueSecrets, 1)
 			klog.Warningf("spec of secret %v/%v diff in super&tenant control plane", vSecret.Namespace, vSecret.Name)
+		} else {
+			conversion.RecordPatrolEquality(pSecret)
 		}
 	}
 }
@@ -18,6 +18,7 @@ package secret
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -28,7 +29,10 @@ import (
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/ondemand"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
 )
@@ -49,6 +53,8 @@ type controller struct {
 	// super control plane secret lister/synced function
 	secretLister listersv1.SecretLister
 	secretSynced cache.InformerSynced
+	// super control plane ingresses referencing the TLS secrets, when only the referenced secrets are synced
+	ingressReferences *ondemand.IngressReferences
 }
 
 func NewSecretController(config *config.SyncerConfiguration,
@@ -68,11 +74,18 @@ func NewSecretController(config *config.SyncerConfiguration,
 	}
 
 	c.secretLister = informer.Core().V1().Secrets().Lister()
+	objectlimit.RegisterIndexer("secrets", informer.Core().V1().Secrets().Informer().GetIndexer())
 	if options.IsFake {
 		c.secretSynced = func() bool { return true }
 	} else {
 		c.secretSynced = informer.Core().V1().Secrets().Informer().HasSynced
 	}
+	if ondemand.Enabled() && config != nil && plugin.SyncerResourceRegister.Enabled("ingress", config.SyncingResources, config.ExtraSyncingResources) {
+		c.ingressReferences = ondemand.NewIngressReferences(c.MultiClusterController, informer.Networking().V1().Ingresses())
+		if options.IsFake {
+			c.ingressReferences.HasSynced = func() bool { return true }
+		}
+	}
 
 	c.Patroller, err = pa.NewPatroller(&corev1.Secret{}, c, pa.WithOptions(options.PatrolOptions))
 	if err != nil {
@@ -81,3 +94,37 @@ func NewSecretController(config *config.SyncerConfiguration,
 
 	return c, nil
 }
+
+// GetListener watches the tenant pods along with the secrets when only the secrets referenced by the
+// pods are synced, the secrets are requeued when the pods referencing them change. The secrets
+// referenced by the ingresses are requeued by the super control plane ingress informer.
+func (c *controller) GetListener() listener.ClusterChangeListener {
+	l := c.BaseResourceSyncer.GetListener()
+	if !ondemand.Enabled() {
+		return l
+	}
+	return ondemand.NewListener(l, c.MultiClusterController, ondemand.Secrets)
+}
+
+// referenced returns true if a tenant pod or a synced ingress references the secret.
+func (c *controller) referenced(clusterName, namespace, name string) (bool, error) {
+	referenced, err := ondemand.Referenced(c.MultiClusterController, clusterName, namespace, name, ondemand.Secrets)
+	if err != nil || referenced {
+		return referenced, err
+	}
+	return c.ingressReferences.Referenced(clusterName, namespace, name)
+}
+
+// referencedObjects returns the namespace/name keys of the secrets referenced by the tenant pods and
+// the synced ingresses of the cluster.
+func (c *controller) referencedObjects(clusterName string) (sets.String, error) {
+	referenced, err := ondemand.ReferencedObjects(c.MultiClusterController, clusterName, ondemand.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	byIngresses, err := c.ingressReferences.ReferencedObjects(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return referenced.Union(byIngresses), nil
+}
@@ -30,12 +30,19 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/ondemand"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
 
 func (c *controller) StartDWS(stopCh <-chan struct{}) error {
-	if !cache.WaitForCacheSync(stopCh, c.secretSynced) {
+	synced := []cache.InformerSynced{c.secretSynced}
+	if c.ingressReferences != nil {
+		synced = append(synced, c.ingressReferences.HasSynced)
+	}
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 	return c.MultiClusterController.Start(stopCh)
@@ -48,7 +55,16 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 	vSecret := &corev1.Secret{}
 	err := c.MultiClusterController.Get(request.ClusterName, request.Namespace, request.Name, vSecret)
 	if err == nil {
-
+		if vSecret.Type != corev1.SecretTypeServiceAccountToken && ondemand.Enabled() {
+			// the super control plane copy of a secret no pod or ingress references is deleted
+			referenced, err := c.referenced(request.ClusterName, request.Namespace, request.Name)
+			if err != nil {
+				return reconciler.Result{Requeue: true}, err
+			}
+			if !referenced {
+				vSecret = &corev1.Secret{}
+			}
+		}
 	} else if !apierrors.IsNotFound(err) {
 		return reconciler.Result{Requeue: true}, err
 	}
@@ -91,7 +107,7 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 			return reconciler.Result{Requeue: true}, err
 		}
 	case reflect.DeepEqual(vSecret, &corev1.Secret{}) && pSecret != nil:
-		err := c.reconcileSecretRemove(targetNamespace, request.UID, request.Name, pSecret)
+		err := c.reconcileSecretRemove(request.ClusterName, targetNamespace, request.UID, request.Name, pSecret)
 		if err != nil {
 			klog.Errorf("failed reconcile secret %s/%s DELETE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
 			return reconciler.Result{Requeue: true}, err
@@ -118,15 +134,13 @@ func (c *controller) reconcileSecretCreate(clusterName, targetNamespace, request
 }
 
 func (c *controller) reconcileServiceAccountSecretCreate(clusterName, targetNamespace string, vSecret *corev1.Secret) error {
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, vSecret)
+	pSecret, err := conversion.BuildSuperClusterSecret(c.Conversion(), clusterName, vSecret)
 	if err != nil {
 		return err
 	}
-
-	pSecret := newObj.(*corev1.Secret)
 	conversion.VC(c.MultiClusterController, "").ServiceAccountTokenSecret(pSecret).Mutate(vSecret, clusterName)
 
-	_, err = c.secretClient.Secrets(targetNamespace).Create(context.TODO(), pSecret, metav1.CreateOptions{})
+	_, err = c.secretClient.Secrets(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pSecret, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		klog.Infof("secret %s/%s of cluster %s already exist in super control plane", targetNamespace, pSecret.Name, clusterName)
 		return nil
@@ -135,7 +149,7 @@ func (c *controller) reconcileServiceAccountSecretCreate(clusterName, targetName
 	return err
 }
 
-func (c *controller) reconcileServiceAccountSecretUpdate(targetNamespace string, pSecret, vSecret *corev1.Secret) error {
+func (c *controller) reconcileServiceAccountSecretUpdate(clusterName, targetNamespace string, pSecret, vSecret *corev1.Secret) error {
 	updatedBinaryData, equal := conversion.Equality(c.Config, nil).CheckBinaryDataEquality(pSecret.Data, vSecret.Data)
 	if equal {
 		return nil
@@ -143,7 +157,7 @@ func (c *controller) reconcileServiceAccountSecretUpdate(targetNamespace string,
 
 	updatedSecret := pSecret.DeepCopy()
 	updatedSecret.Data = updatedBinaryData
-	_, err := c.secretClient.Secrets(targetNamespace).Update(context.TODO(), updatedSecret, metav1.UpdateOptions{})
+	_, err := c.secretClient.Secrets(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updatedSecret, metav1.UpdateOptions{})
 	if err != nil {
 		return err
 	}
@@ -152,12 +166,13 @@ func (c *controller) reconcileServiceAccountSecretUpdate(targetNamespace string,
 }
 
 func (c *controller) reconcileNormalSecretCreate(clusterName, targetNamespace, requestUID string, secret *corev1.Secret) error {
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, secret)
+	pSecret, err := conversion.BuildSuperClusterSecret(c.Conversion(), clusterName, secret)
 	if err != nil {
 		return err
 	}
+	c.FinalizerTranslator().Apply(secret, pSecret)
 
-	pSecret, err := c.secretClient.Secrets(targetNamespace).Create(context.TODO(), newObj.(*corev1.Secret), metav1.CreateOptions{})
+	pSecret, err = c.secretClient.Secrets(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pSecret, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		if pSecret.Annotations[constants.LabelUID] == requestUID {
 			klog.Infof("secret %s/%s of cluster %s already exist in super control plane", targetNamespace, secret.Name, clusterName)
@@ -172,7 +187,7 @@ func (c *controller) reconcileNormalSecretCreate(clusterName, targetNamespace, r
 func (c *controller) reconcileSecretUpdate(clusterName, targetNamespace, requestUID string, pSecret, vSecret *corev1.Secret) error {
 	switch vSecret.Type {
 	case corev1.SecretTypeServiceAccountToken:
-		return c.reconcileServiceAccountSecretUpdate(targetNamespace, pSecret, vSecret)
+		return c.reconcileServiceAccountSecretUpdate(clusterName, targetNamespace, pSecret, vSecret)
 	default:
 		return c.reconcileNormalSecretUpdate(clusterName, targetNamespace, requestUID, pSecret, vSecret)
 	}
@@ -180,7 +195,20 @@ func (c *controller) reconcileSecretUpdate(clusterName, targetNamespace, request
 
 func (c *controller) reconcileNormalSecretUpdate(clusterName, targetNamespace, requestUID string, pSecret, vSecret *corev1.Secret) error {
 	if pSecret.Annotations[constants.LabelUID] != requestUID {
-		return fmt.Errorf("pEndpoints %s/%s delegated UID is different from updated object", targetNamespace, pSecret.Name)
+		// the tenant object is recreated in the restored namespace, it adopts the retained object
+		adopted, _ := recyclebin.Adopt(pSecret, requestUID).(*corev1.Secret)
+		if adopted == nil {
+			return fmt.Errorf("pEndpoints %s/%s delegated UID is different from updated object", targetNamespace, pSecret.Name)
+		}
+		if err := conversion.VerifyOwnership(pSecret); err != nil {
+			return err
+		}
+		adopted, err := c.secretClient.Secrets(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), adopted, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		klog.Infof("secret %s/%s of cluster %s adopted the retained object in super control plane", targetNamespace, pSecret.Name, clusterName)
+		pSecret = adopted
 	}
 	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
 	if err != nil {
@@ -188,7 +216,30 @@ func (c *controller) reconcileNormalSecretUpdate(clusterName, targetNamespace, r
 	}
 	updatedSecret := conversion.Equality(c.Config, vc).CheckSecretEquality(pSecret, vSecret)
 	if updatedSecret != nil {
-		_, err = c.secretClient.Secrets(targetNamespace).Update(context.TODO(), updatedSecret, metav1.UpdateOptions{})
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vSecret, updatedSecret); err != nil {
+			return err
+		}
+		changed, err := c.ConflictResolver().Resolve(clusterName, vSecret, pSecret, updatedSecret)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			updatedSecret = nil
+		}
+	}
+	if hash, equal := conversion.CheckSemanticHash(pSecret, vSecret); hash != "" && !equal {
+		if updatedSecret == nil {
+			updatedSecret = pSecret.DeepCopy()
+		}
+		conversion.SetSemanticHash(updatedSecret, hash)
+	}
+	if updatedSecret != nil {
+		c.FinalizerTranslator().Apply(vSecret, updatedSecret)
+	} else if finalized := pSecret.DeepCopy(); c.FinalizerTranslator().Apply(vSecret, finalized) {
+		updatedSecret = finalized
+	}
+	if updatedSecret != nil {
+		_, err = c.secretClient.Secrets(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updatedSecret, metav1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
@@ -197,21 +248,40 @@ func (c *controller) reconcileNormalSecretUpdate(clusterName, targetNamespace, r
 	return nil
 }
 
-func (c *controller) reconcileSecretRemove(targetNamespace, requestUID, name string, secret *corev1.Secret) error {
+func (c *controller) reconcileSecretRemove(clusterName, targetNamespace, requestUID, name string, secret *corev1.Secret) error {
+	if err := conversion.VerifyOwnership(secret); err != nil {
+		return err
+	}
 	if _, isSaSecret := secret.Labels[constants.LabelSecretUID]; isSaSecret {
-		return c.reconcileServiceAccountTokenSecretRemove(targetNamespace, requestUID, name)
+		return c.reconcileServiceAccountTokenSecretRemove(clusterName, targetNamespace, requestUID, name)
 	}
-	return c.reconcileNormalSecretRemove(targetNamespace, requestUID, name, secret)
+	return c.reconcileNormalSecretRemove(clusterName, targetNamespace, requestUID, name, secret)
 }
 
-func (c *controller) reconcileNormalSecretRemove(targetNamespace, requestUID, name string, pSecret *corev1.Secret) error {
+func (c *controller) reconcileNormalSecretRemove(clusterName, targetNamespace, requestUID, name string, pSecret *corev1.Secret) error {
 	if pSecret.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pSecret %s/%s delegated UID is different from deleted object", targetNamespace, pSecret.Name)
 	}
+	if retained, err := recyclebin.Retains(c.MultiClusterController, pSecret); err != nil {
+		return err
+	} else if retained {
+		if marked := recyclebin.RetainObject(pSecret, c.Config.NamespaceRetentionPeriod.Duration); marked != nil {
+			if _, err := c.secretClient.Secrets(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), marked.(*corev1.Secret), metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		klog.V(4).Infof("keeping secret %s/%s of the retained namespace in super control plane", targetNamespace, name)
+		return nil
+	}
+	if released := pSecret.DeepCopy(); c.FinalizerTranslator().Release(released) {
+		if _, err := c.secretClient.Secrets(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), released, metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 	}
-	err := c.secretClient.Secrets(targetNamespace).Delete(context.TODO(), name, *opts)
+	err := c.secretClient.Secrets(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
 	if apierrors.IsNotFound(err) {
 		klog.Warningf("secret %s/%s of cluster is not found in super control plane", targetNamespace, name)
 		return nil
@@ -219,11 +289,11 @@ func (c *controller) reconcileNormalSecretRemove(targetNamespace, requestUID, na
 	return err
 }
 
-func (c *controller) reconcileServiceAccountTokenSecretRemove(targetNamespace, requestUID, name string) error {
+func (c *controller) reconcileServiceAccountTokenSecretRemove(clusterName, targetNamespace, requestUID, name string) error {
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 	}
-	err := c.secretClient.Secrets(targetNamespace).DeleteCollection(context.TODO(), *opts, metav1.ListOptions{
+	err := c.secretClient.Secrets(targetNamespace).DeleteCollection(rbac.WithTenant(context.TODO(), clusterName), *opts, metav1.ListOptions{
 		LabelSelector: labels.Set(map[string]string{
 			constants.LabelSecretUID: requestUID,
 		}).String(),
@@ -106,18 +106,28 @@ func (c *controller) PatrollerDo() {
 			d.OnDelete(pObj)
 			return
 		}
+		if c.FinalizerTranslator().Expired(p) {
+			if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
+				klog.Errorf("error requeue vService %v/%v in cluster %s: %v", vObj.GetNamespace(), vObj.GetName(), vObj.GetOwnerCluster(), err)
+			} else {
+				metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantServices").Inc()
+			}
+			return
+		}
 
 		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, vObj.GetOwnerCluster())
 		if err != nil {
 			klog.Errorf("fail to get cluster spec : %s: %v", vObj.GetOwnerCluster(), err)
 			return
 		}
-		updatedService := conversion.Equality(c.Config, vc).CheckServiceEquality(p, v)
-		if updatedService != nil {
-			atomic.AddUint64(&numSpecMissMatchedServices, 1)
-			klog.Warningf("spec of service %s diff in super&tenant control plane", pObj.Key)
-			d.OnAdd(vObj)
-			return
+		if !conversion.PatrolSkipsEquality(p, v) {
+			if conversion.Equality(c.Config, vc).CheckServiceEquality(p, v) != nil {
+				atomic.AddUint64(&numSpecMissMatchedServices, 1)
+				klog.Warningf("spec of service %s diff in super&tenant control plane", pObj.Key)
+				d.OnAdd(vObj)
+				return
+			}
+			conversion.RecordPatrolEquality(p)
 		}
 
 		if isBackPopulateService(p) {
@@ -139,6 +149,10 @@ func (c *controller) PatrollerDo() {
 		}
 	}
 	d.DeleteFunc = func(pObj differ.ClusterObject) {
+		if err := conversion.VerifyOwnership(pObj); err != nil {
+			klog.Errorf("refusing to delete pService %s in super control plane: %v", pObj.Key, err)
+			return
+		}
 		deleteOptions := metav1.NewPreconditionDeleteOptions(string(pObj.GetUID()))
 		if err = c.serviceClient.Services(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), *deleteOptions); err != nil {
 			klog.Errorf("error deleting pService %s in super control plane: %v", pObj.Key, err)
@@ -148,7 +162,7 @@ func (c *controller) PatrollerDo() {
 	}
 
 	vSet.Difference(pSet, differ.FilteringHandler{
-		Handler:    d,
+		Handler:    differ.FreezeHandler{Frozen: c.IsFrozen, Handler: differ.BudgetHandler{Budget: c.RemediationBudget(), Handler: d}},
 		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
 	})
 
@@ -32,7 +32,9 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
@@ -70,12 +72,14 @@ func NewServiceController(config *config.SyncerConfiguration,
 	}
 
 	var err error
-	c.MultiClusterController, err = mc.NewMCController(&corev1.Service{}, &corev1.ServiceList{}, c, mc.WithOptions(options.MCOptions))
+	c.MultiClusterController, err = mc.NewMCController(&corev1.Service{}, &corev1.ServiceList{}, c, mc.WithOptions(options.MCOptions),
+		mc.WithUpdatePredicate(util.DWSUpdatePredicate(nil)))
 	if err != nil {
 		return nil, err
 	}
 
 	c.serviceLister = informer.Core().V1().Services().Lister()
+	objectlimit.RegisterIndexer("services", informer.Core().V1().Services().Informer().GetIndexer())
 	if options.IsFake {
 		c.serviceSynced = func() bool { return true }
 	} else {
@@ -28,6 +28,7 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
@@ -66,7 +67,7 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 			return reconciler.Result{Requeue: true}, err
 		}
 	case !vExists && pExists:
-		err := c.reconcileServiceRemove(targetNamespace, request.UID, request.Name, pService)
+		err := c.reconcileServiceRemove(request.ClusterName, targetNamespace, request.UID, request.Name, pService)
 		if err != nil {
 			klog.Errorf("failed reconcile service %s/%s DELETE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
 			return reconciler.Result{Requeue: true}, err
@@ -84,15 +85,17 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 }
 
 func (c *controller) reconcileServiceCreate(clusterName, targetNamespace, requestUID string, service *corev1.Service) error {
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, service)
+	pService, err := conversion.BuildSuperClusterService(c.Conversion(), clusterName, service)
 	if err != nil {
 		return err
 	}
-
-	pService := newObj.(*corev1.Service)
+	c.FinalizerTranslator().Apply(service, pService)
+	pService.Annotations = conversion.NewAnnotationMapper(c.Config).MapDownward(pService.Annotations)
+	// the load balancer annotations are owned by the super cluster, as when the service is updated
+	pService.Annotations = conversion.Equality(c.Config, nil).DropLoadBalancerAnnotations(pService.Annotations)
 	conversion.VC(nil, "").Service(pService).Mutate(service)
 
-	pService, err = c.serviceClient.Services(targetNamespace).Create(context.TODO(), pService, metav1.CreateOptions{})
+	pService, err = c.serviceClient.Services(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pService, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		if pService.Annotations[constants.LabelUID] == requestUID {
 			klog.Infof("service %s/%s of cluster %s already exist in super control plane", targetNamespace, pService.Name, clusterName)
@@ -114,7 +117,30 @@ func (c *controller) reconcileServiceUpdate(clusterName, targetNamespace, reques
 	}
 	updated := conversion.Equality(c.Config, vc).CheckServiceEquality(pService, vService)
 	if updated != nil {
-		_, err = c.serviceClient.Services(targetNamespace).Update(context.TODO(), updated, metav1.UpdateOptions{})
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vService, updated); err != nil {
+			return err
+		}
+		changed, err := c.ConflictResolver().Resolve(clusterName, vService, pService, updated)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			updated = nil
+		}
+	}
+	if hash, equal := conversion.CheckSemanticHash(pService, vService); hash != "" && !equal {
+		if updated == nil {
+			updated = pService.DeepCopy()
+		}
+		conversion.SetSemanticHash(updated, hash)
+	}
+	if updated != nil {
+		c.FinalizerTranslator().Apply(vService, updated)
+	} else if finalized := pService.DeepCopy(); c.FinalizerTranslator().Apply(vService, finalized) {
+		updated = finalized
+	}
+	if updated != nil {
+		_, err = c.serviceClient.Services(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updated, metav1.UpdateOptions{})
 		if err != nil {
 			return err
 		}
@@ -122,16 +148,24 @@ func (c *controller) reconcileServiceUpdate(clusterName, targetNamespace, reques
 	return nil
 }
 
-func (c *controller) reconcileServiceRemove(targetNamespace, requestUID, name string, pService *corev1.Service) error {
+func (c *controller) reconcileServiceRemove(clusterName, targetNamespace, requestUID, name string, pService *corev1.Service) error {
 	if pService.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pService %s/%s delegated UID is different from deleted object", targetNamespace, name)
 	}
+	if err := conversion.VerifyOwnership(pService); err != nil {
+		return err
+	}
 
+	if released := pService.DeepCopy(); c.FinalizerTranslator().Release(released) {
+		if _, err := c.serviceClient.Services(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), released, metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 		Preconditions:     metav1.NewUIDPreconditions(string(pService.UID)),
 	}
-	err := c.serviceClient.Services(targetNamespace).Delete(context.TODO(), name, *opts)
+	err := c.serviceClient.Services(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
 	if apierrors.IsNotFound(err) {
 		klog.Warningf("To be deleted service %s/%s not found in super control plane", targetNamespace, name)
 		return nil
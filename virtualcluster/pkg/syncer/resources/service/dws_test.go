@@ -27,9 +27,15 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	core "k8s.io/client-go/testing"
 
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
 )
 
@@ -376,3 +382,45 @@ func TestDWServiceUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDWServiceCreationLoadBalancerAnnotations(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	newController := func(cfg *config.SyncerConfiguration, client clientset.Interface, informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface, vcInformer vcinformers.VirtualClusterInformer, options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		cfg.LoadBalancerAnnotationPrefixes = []string{"service.beta.kubernetes.io/"}
+		return NewServiceController(cfg, client, informer, vcClient, vcInformer, options)
+	}
+
+	vService := tenantService("svc-1", "default", "12345")
+	vService.Spec.Type = corev1.ServiceTypeLoadBalancer
+	vService.Annotations = map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+		"example.com/team": "a",
+	}
+	actions, reconcileErr, err := util.RunDownwardSync(newController, testTenant, nil, []runtime.Object{vService}, vService, nil)
+	if err != nil {
+		t.Fatalf("error running downward sync: %v", err)
+	}
+	if reconcileErr != nil {
+		t.Fatalf("unexpected reconcile error: %v", reconcileErr)
+	}
+	if len(actions) != 1 || !actions[0].Matches("create", "services") {
+		t.Fatalf("expected a service to be created, got %v", actions)
+	}
+	pService := actions[0].(core.CreateAction).GetObject().(*corev1.Service)
+	if _, exists := pService.Annotations["service.beta.kubernetes.io/aws-load-balancer-internal"]; exists {
+		t.Errorf("expected the load balancer annotation of the tenant to be dropped, got %v", pService.Annotations)
+	}
+	if pService.Annotations["example.com/team"] != "a" {
+		t.Errorf("expected the other annotations of the tenant to be kept, got %v", pService.Annotations)
+	}
+}
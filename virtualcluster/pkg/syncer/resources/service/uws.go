@@ -102,7 +102,19 @@ func (c *controller) BackPopulate(key string) error {
 	}
 
 	var newService *corev1.Service
-	updatedMeta := conversion.Equality(c.Config, vc).CheckUWObjectMetaEquality(&pService.ObjectMeta, &vService.ObjectMeta)
+	eq := conversion.Equality(c.Config, vc)
+	updatedMeta := eq.CheckUWObjectMetaEquality(&pService.ObjectMeta, &vService.ObjectMeta)
+	vAnnotations := vService.Annotations
+	if updatedMeta != nil {
+		vAnnotations = updatedMeta.Annotations
+	}
+	// Tenant automation, e.g. external-dns, relies on the annotations of the load balancer controllers.
+	if annotations, equal := eq.CheckUWLoadBalancerAnnotations(pService, vAnnotations); !equal {
+		if updatedMeta == nil {
+			updatedMeta = vService.ObjectMeta.DeepCopy()
+		}
+		updatedMeta.Annotations = annotations
+	}
 	if updatedMeta != nil {
 		newService = vService.DeepCopy()
 		newService.ObjectMeta = *updatedMeta
@@ -30,6 +30,7 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
 )
 
@@ -43,6 +44,16 @@ func applyLoadBalancerToService(svc *corev1.Service, ip string) *corev1.Service
 	return svc
 }
 
+func applyAnnotationsToService(svc *corev1.Service, annotations map[string]string) *corev1.Service {
+	if svc.Annotations == nil {
+		svc.Annotations = make(map[string]string)
+	}
+	for k, v := range annotations {
+		svc.Annotations[k] = v
+	}
+	return svc
+}
+
 func TestUWService(t *testing.T) {
 	testTenant := &v1alpha1.VirtualCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -61,6 +72,7 @@ func TestUWService(t *testing.T) {
 	testcases := map[string]struct {
 		ExistingObjectInSuper  []runtime.Object
 		ExistingObjectInTenant []runtime.Object
+		LBAnnotationPrefixes   []string
 		EnqueuedKey            string
 		ExpectedUpdatedObject  []runtime.Object
 		ExpectedNoOperation    bool
@@ -119,11 +131,47 @@ func TestUWService(t *testing.T) {
 				applyLoadBalancerToService(tenantService("svc", "default", "12345"), "1.1.1.1"),
 			},
 		},
+		"pService exists, vService exists with different load balancer annotations": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyAnnotationsToService(applyLoadBalancerToService(superService("svc", superDefaultNSName, "12345", defaultClusterKey), "1.1.1.1"), map[string]string{
+					"lb.example.com/hostname": "svc.lb.example.com",
+					"other.example.com/a":     "b",
+				}),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyAnnotationsToService(applyLoadBalancerToService(tenantService("svc", "default", "12345"), "1.1.1.1"), map[string]string{
+					"lb.example.com/stale": "true",
+					"tenant/a":             "b",
+				}),
+			},
+			LBAnnotationPrefixes: []string{"lb.example.com/"},
+			EnqueuedKey:          superDefaultNSName + "/svc",
+			ExpectedUpdatedObject: []runtime.Object{
+				applyAnnotationsToService(applyLoadBalancerToService(tenantService("svc", "default", "12345"), "1.1.1.1"), map[string]string{
+					"lb.example.com/hostname": "svc.lb.example.com",
+					"tenant/a":                "b",
+				}),
+			},
+		},
+		"pService exists, vService exists with load balancer annotations not configured": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyAnnotationsToService(applyLoadBalancerToService(superService("svc", superDefaultNSName, "12345", defaultClusterKey), "1.1.1.1"), map[string]string{
+					"lb.example.com/hostname": "svc.lb.example.com",
+				}),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyLoadBalancerToService(tenantService("svc", "default", "12345"), "1.1.1.1"),
+			},
+			EnqueuedKey:         superDefaultNSName + "/svc",
+			ExpectedNoOperation: true,
+		},
 	}
 
 	for k, tc := range testcases {
 		t.Run(k, func(t *testing.T) {
-			actions, reconcileErr, err := util.RunUpwardSync(NewServiceController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.EnqueuedKey, nil)
+			actions, reconcileErr, err := util.RunUpwardSync(NewServiceController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.EnqueuedKey, func(rs manager.ResourceSyncer) {
+				rs.(*controller).Config.LoadBalancerAnnotationPrefixes = tc.LBAnnotationPrefixes
+			})
 			if err != nil {
 				t.Errorf("%s: error running upward sync: %v", k, err)
 				return
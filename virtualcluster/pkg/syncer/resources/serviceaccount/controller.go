@@ -28,6 +28,7 @@ import (
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
 	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
@@ -68,6 +69,7 @@ func NewServiceAccountController(config *config.SyncerConfiguration,
 	}
 
 	c.saLister = informer.Core().V1().ServiceAccounts().Lister()
+	objectlimit.RegisterIndexer("serviceaccounts", informer.Core().V1().ServiceAccounts().Informer().GetIndexer())
 	if options.IsFake {
 		c.saSynced = func() bool { return true }
 	} else {
@@ -28,6 +28,7 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
 
@@ -86,15 +87,14 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 }
 
 func (c *controller) reconcileServiceAccountCreate(clusterName, targetNamespace, requestUID string, vSa *corev1.ServiceAccount) error {
-	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, vSa)
+	pServiceAccount, err := conversion.BuildSuperClusterServiceAccount(c.Conversion(), clusterName, vSa)
 	if err != nil {
 		return err
 	}
-	pServiceAccount := newObj.(*corev1.ServiceAccount)
 	// set to empty and token controller will regenerate one.
 	pServiceAccount.Secrets = nil
 
-	pServiceAccount, err = c.saClient.ServiceAccounts(targetNamespace).Create(context.TODO(), pServiceAccount, metav1.CreateOptions{})
+	pServiceAccount, err = c.saClient.ServiceAccounts(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pServiceAccount, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		if pServiceAccount.Annotations[constants.LabelUID] == requestUID {
 			klog.Infof("service account %s/%s of cluster %s already exist in super control plane", targetNamespace, pServiceAccount.Name, clusterName)
@@ -116,7 +116,8 @@ func (c *controller) reconcileServiceAccountUpdate(clusterName, targetNamespace,
 			pSa.Annotations[constants.LabelCluster] = clusterName
 			pSa.Annotations[constants.LabelUID] = string(vSa.UID)
 			pSa.Annotations[constants.LabelNamespace] = vSa.Namespace
-			_, err = c.saClient.ServiceAccounts(targetNamespace).Update(context.TODO(), pSa, metav1.UpdateOptions{})
+			conversion.SignOwnership(pSa)
+			_, err = c.saClient.ServiceAccounts(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), pSa, metav1.UpdateOptions{})
 		}
 		return err
 	}
@@ -133,10 +134,13 @@ func (c *controller) reconcileServiceAccountRemove(clusterName, targetNamespace,
 	if pSa.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("to be deleted pServiceAccount %s/%s delegated UID is different from deleted object", targetNamespace, pSa.Name)
 	}
+	if err := conversion.VerifyOwnership(pSa); err != nil {
+		return err
+	}
 	opts := &metav1.DeleteOptions{
 		PropagationPolicy: &constants.DefaultDeletionPolicy,
 	}
-	err := c.saClient.ServiceAccounts(targetNamespace).Delete(context.TODO(), name, *opts)
+	err := c.saClient.ServiceAccounts(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
 	if apierrors.IsNotFound(err) {
 		klog.Warningf("service account %s/%s of cluster %s not found in super control plane", targetNamespace, name, clusterName)
 		return nil
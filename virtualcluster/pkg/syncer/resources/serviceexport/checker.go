@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceexport
+
+import (
+	"context"
+	"fmt"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	mcsv1alpha1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+func (c *controller) StartPatrol(stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+
+	if !cache.WaitForCacheSync(stopCh, c.sliceSynced) {
+		return fmt.Errorf("failed to wait for caches to sync before starting ServiceExport checker")
+	}
+	c.Patroller.Start(stopCh)
+	return nil
+}
+
+// PatrollerDo requeues the tenant service exports, so that the imported endpointslices catch up with
+// missed tenant events, and deletes the imported endpointslices whose service export is gone.
+func (c *controller) PatrollerDo() {
+	clusterNames := c.MultiClusterController.GetClusterNames()
+	if len(clusterNames) == 0 {
+		klog.V(5).Infof("super cluster has no tenant control planes, giving up periodic checker: %s", "serviceexport")
+		return
+	}
+
+	knownClusterSet := sets.NewString()
+	exported := sets.NewString()
+	for _, cluster := range clusterNames {
+		vList := &mcsv1alpha1.ServiceExportList{}
+		if err := c.MultiClusterController.List(cluster, vList); err != nil {
+			klog.Errorf("error listing serviceexports from cluster %s informer cache: %v", cluster, err)
+			continue
+		}
+		knownClusterSet.Insert(cluster)
+		for i := range vList.Items {
+			exported.Insert(exportKey(cluster, vList.Items[i].Namespace, vList.Items[i].Name))
+			if err := c.MultiClusterController.RequeueObject(cluster, &vList.Items[i]); err != nil {
+				klog.Errorf("error requeue vServiceExport %s/%s in cluster %s: %v", vList.Items[i].Namespace, vList.Items[i].Name, cluster, err)
+			}
+		}
+	}
+
+	pList, err := c.sliceLister.List(labels.SelectorFromSet(labels.Set{discoveryv1.LabelManagedBy: constants.EndpointSliceManagedBy}))
+	if err != nil {
+		klog.Errorf("error listing endpointslices from super control plane informer cache: %v", err)
+		return
+	}
+	for _, pSlice := range pList {
+		// the endpointslices of the clusters not listed are not garbage collected.
+		cluster := pSlice.Annotations[constants.LabelCluster]
+		if !knownClusterSet.Has(cluster) || exported.Has(exportKey(cluster, pSlice.Annotations[constants.LabelNamespace], pSlice.Labels[discoveryv1.LabelServiceName])) {
+			continue
+		}
+		err := c.sliceClient.EndpointSlices(pSlice.Namespace).Delete(context.TODO(), pSlice.Name, metav1.DeleteOptions{
+			Preconditions: metav1.NewUIDPreconditions(string(pSlice.UID)),
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("error deleting orphan endpointslice %s/%s in super control plane: %v", pSlice.Namespace, pSlice.Name, err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanSuperControlPlaneEndpointSlices").Inc()
+		}
+	}
+}
+
+func exportKey(cluster, namespace, name string) string {
+	return cluster + "/" + namespace + "/" + name
+}
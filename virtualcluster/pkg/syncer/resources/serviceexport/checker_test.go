@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceexport
+
+import (
+	"testing"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+)
+
+// newPatrolController caches the endpointslices with the informer of the patrol simulation.
+func newPatrolController(config *config.SyncerConfiguration,
+	client clientset.Interface,
+	informer informers.SharedInformerFactory,
+	vcClient vcclient.Interface,
+	vcInformer vcinformers.VirtualClusterInformer,
+	options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+	return newController(config, client, informer, options)
+}
+
+func superSlice(name, namespace, service, clusterKey string) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       "4c9e3f0e-1b7d-4f37-8a8e-1e4b0c5d2f6a",
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: service,
+				discoveryv1.LabelManagedBy:   constants.EndpointSliceManagedBy,
+			},
+			Annotations: map[string]string{
+				constants.LabelCluster:   clusterKey,
+				constants.LabelNamespace: "default",
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+	}
+}
+
+func TestServiceExportPatrol(t *testing.T) {
+	clusterKey := conversion.ToClusterKey(testTenant)
+	superNamespace := conversion.ToSuperClusterNamespace(clusterKey, "default")
+
+	util.RunPatrolScenarios(t, newPatrolController, testTenant, "endpointslices", map[string]util.PatrolScenario{
+		"pEndpointSlice exists, vServiceExport does not exists": {
+			ExistingObjectInSuper: []runtime.Object{
+				superSlice("web-a", superNamespace, "web", clusterKey),
+			},
+			ExpectedDeletedPObject: []string{
+				superNamespace + "/web-a",
+			},
+		},
+		"pEndpointSlice of an unknown cluster exists": {
+			ExistingObjectInSuper: []runtime.Object{
+				superSlice("web-a", "tenant-2-ns", "web", "tenant-2"),
+			},
+			ExpectedNoOperation: true,
+		},
+		// the checker deletes the orphan and requeues the service export, whose service is missing.
+		"pEndpointSlices exist, vServiceExport exports another missing service": {
+			ExistingObjectInSuper: []runtime.Object{
+				superSlice("web-a", superNamespace, "web", clusterKey),
+				superSlice("db-a", superNamespace, "db", clusterKey),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantServiceExport("db", "default"),
+			},
+			ExpectedDeletedPObject: []string{
+				superNamespace + "/web-a",
+				superNamespace + "/db-a",
+			},
+			WaitDWS: true,
+		},
+	})
+}
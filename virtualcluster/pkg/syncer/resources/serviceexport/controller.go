@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceexport
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1discovery "k8s.io/client-go/kubernetes/typed/discovery/v1"
+	listersv1 "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	mcsv1alpha1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/handler"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+func init() {
+	// the tenant control plane clients decode the service export objects using the client-go scheme.
+	utilruntime.Must(mcsv1alpha1.AddToScheme(scheme.Scheme))
+
+	plugin.SyncerResourceRegister.Register(&plugin.Registration{
+		ID: "serviceexport",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			return NewServiceExportController(ctx.Config.(*config.SyncerConfiguration), ctx.Client, ctx.Informer, ctx.VCClient, ctx.VCInformer, manager.ResourceSyncerOptions{})
+		},
+		Disable: true,
+	})
+}
+
+type controller struct {
+	manager.BaseResourceSyncer
+	// super control plane endpointslice client
+	sliceClient v1discovery.EndpointSlicesGetter
+	// super control plane informer/lister/synced functions of the endpointslices managed by the syncer
+	informerFactory informers.SharedInformerFactory
+	sliceLister     listersv1.EndpointSliceLister
+	sliceSynced     cache.InformerSynced
+}
+
+func NewServiceExportController(config *config.SyncerConfiguration,
+	client clientset.Interface,
+	informer informers.SharedInformerFactory,
+	vcClient vcclient.Interface,
+	vcInformer vcinformers.VirtualClusterInformer,
+	options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+	// only the endpointslices imported by the syncer are cached, rather than every endpointslice of the super control plane.
+	sliceInformerFactory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.LabelSelector = discoveryv1.LabelManagedBy + "=" + constants.EndpointSliceManagedBy
+	}))
+	return newController(config, client, sliceInformerFactory, options)
+}
+
+func newController(config *config.SyncerConfiguration,
+	client clientset.Interface,
+	informerFactory informers.SharedInformerFactory,
+	options manager.ResourceSyncerOptions) (*controller, error) {
+	c := &controller{
+		BaseResourceSyncer: manager.BaseResourceSyncer{
+			Config: config,
+		},
+		sliceClient:     client.DiscoveryV1(),
+		informerFactory: informerFactory,
+	}
+
+	var err error
+	c.MultiClusterController, err = mc.NewMCController(&mcsv1alpha1.ServiceExport{}, &mcsv1alpha1.ServiceExportList{}, c, mc.WithOptions(options.MCOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	sliceInformer := informerFactory.Discovery().V1().EndpointSlices()
+	c.sliceLister = sliceInformer.Lister()
+	if options.IsFake {
+		c.sliceSynced = func() bool { return true }
+	} else {
+		c.sliceSynced = sliceInformer.Informer().HasSynced
+	}
+
+	c.Patroller, err = pa.NewPatroller(&mcsv1alpha1.ServiceExport{}, c, pa.WithOptions(options.PatrolOptions))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetListener watches the tenant services and pods along with the service exports, the endpoints
+// imported for a service export are built from them.
+func (c *controller) GetListener() listener.ClusterChangeListener {
+	return &exportListener{
+		ClusterChangeListener: listener.NewMCControllerListener(c.MultiClusterController, mc.WatchOptions{}),
+		c:                     c.MultiClusterController,
+	}
+}
+
+type exportListener struct {
+	listener.ClusterChangeListener
+	c *mc.MultiClusterController
+}
+
+func (l *exportListener) AddCluster(cluster mc.ClusterInterface) {
+	l.ClusterChangeListener.AddCluster(cluster)
+	for _, obj := range []client.Object{&corev1.Service{}, &corev1.Pod{}} {
+		if _, err := cluster.GetInformer(obj); err != nil {
+			klog.Errorf("failed to add cluster %s %T informer for service exports: %v", cluster.GetClusterName(), obj, err)
+		}
+	}
+}
+
+func (l *exportListener) WatchCluster(cluster mc.ClusterInterface) {
+	l.ClusterChangeListener.WatchCluster(cluster)
+	// a service export has the name of the service it exports.
+	h := &handler.EnqueueRequestForObject{ClusterName: cluster.GetClusterName(), Queue: l.c.Queue}
+	if err := cluster.AddEventHandler(&corev1.Service{}, h); err != nil {
+		klog.Errorf("failed to watch cluster %s services for service exports: %v", cluster.GetClusterName(), err)
+	}
+	if err := cluster.AddEventHandler(&corev1.Pod{}, &podHandler{clusterName: cluster.GetClusterName(), c: l.c}); err != nil {
+		klog.Errorf("failed to watch cluster %s pods for service exports: %v", cluster.GetClusterName(), err)
+	}
+}
+
+// podHandler requeues the service exports of the namespace of a changed tenant pod.
+type podHandler struct {
+	clusterName string
+	c           *mc.MultiClusterController
+}
+
+func (h *podHandler) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return
+	}
+	exports := &mcsv1alpha1.ServiceExportList{}
+	if err := h.c.List(h.clusterName, exports, client.InNamespace(namespace)); err != nil {
+		klog.Errorf("failed to list service exports of cluster %s namespace %s: %v", h.clusterName, namespace, err)
+		return
+	}
+	for i := range exports.Items {
+		if err := h.c.RequeueObject(h.clusterName, &exports.Items[i]); err != nil {
+			klog.Errorf("failed to requeue service export %s/%s of cluster %s: %v", namespace, exports.Items[i].Name, h.clusterName, err)
+		}
+	}
+}
+
+func (h *podHandler) OnAdd(obj interface{}) {
+	h.enqueue(obj)
+}
+
+func (h *podHandler) OnUpdate(oldObj, newObj interface{}) {
+	oldPod, ok1 := oldObj.(*corev1.Pod)
+	newPod, ok2 := newObj.(*corev1.Pod)
+	if ok1 && ok2 && !endpointChanged(oldPod, newPod) {
+		return
+	}
+	h.enqueue(newObj)
+}
+
+func (h *podHandler) OnDelete(obj interface{}) {
+	h.enqueue(obj)
+}
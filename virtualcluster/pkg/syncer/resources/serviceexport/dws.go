@@ -0,0 +1,436 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceexport
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	mcsv1alpha1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+// maxEndpointsPerSlice is the number of endpoints of an imported endpointslice, the same as the
+// default of the endpointslice controller.
+const maxEndpointsPerSlice = 100
+
+func (c *controller) StartDWS(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.sliceSynced) {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	return c.MultiClusterController.Start(stopCh)
+}
+
+// The reconcile logic for tenant control plane service export informer, the endpoints of the exported
+// service in the other super clusters of the pool are imported to the super control plane as endpointslices.
+func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, error) {
+	klog.V(4).Infof("reconcile serviceexport %s/%s event for cluster %s", request.Namespace, request.Name, request.ClusterName)
+	targetNamespace := conversion.ToSuperClusterNamespace(request.ClusterName, request.Namespace)
+
+	vExport := &mcsv1alpha1.ServiceExport{}
+	if err := c.MultiClusterController.Get(request.ClusterName, request.Namespace, request.Name, vExport); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		vExport = nil
+	}
+	if vExport == nil || vExport.DeletionTimestamp != nil {
+		// the service import is owned by the service export, it is garbage collected by the tenant control plane.
+		if err := c.reconcileSlices(request.ClusterName, targetNamespace, request.Namespace, request.Name, nil); err != nil {
+			klog.Errorf("failed reconcile serviceexport %s/%s DELETE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+		return reconciler.Result{}, nil
+	}
+
+	vService := &corev1.Service{}
+	if err := c.MultiClusterController.Get(request.ClusterName, request.Namespace, request.Name, vService); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		vService = nil
+	}
+	if vService == nil || vService.Spec.Type == corev1.ServiceTypeExternalName {
+		reason, message := "ServiceNotFound", "the exported service does not exist"
+		if vService != nil {
+			reason, message = "ServiceTypeNotSupported", "services of type ExternalName cannot be exported"
+		}
+		if err := c.reconcileSlices(request.ClusterName, targetNamespace, request.Namespace, request.Name, nil); err != nil {
+			return reconciler.Result{Requeue: true}, err
+		}
+		if err := c.updateExportCondition(request.ClusterName, vExport, corev1.ConditionFalse, reason, message); err != nil {
+			return reconciler.Result{Requeue: true}, err
+		}
+		return reconciler.Result{}, nil
+	}
+
+	vPods := &corev1.PodList{}
+	if len(vService.Spec.Selector) > 0 {
+		if err := c.MultiClusterController.List(request.ClusterName, vPods, client.InNamespace(request.Namespace), client.MatchingLabels(vService.Spec.Selector)); err != nil {
+			return reconciler.Result{Requeue: true}, err
+		}
+	}
+	slices, clusters := desiredSlices(request.ClusterName, targetNamespace, vService, vPods.Items)
+	if err := c.reconcileSlices(request.ClusterName, targetNamespace, request.Namespace, request.Name, slices); err != nil {
+		klog.Errorf("failed reconcile serviceexport %s/%s of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+		return reconciler.Result{Requeue: true}, err
+	}
+	if err := c.reconcileServiceImport(request.ClusterName, vExport, vService, clusters); err != nil {
+		klog.Errorf("failed reconcile serviceimport %s/%s of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+		return reconciler.Result{Requeue: true}, err
+	}
+	if err := c.updateExportCondition(request.ClusterName, vExport, corev1.ConditionTrue, "ServiceExported", "the service is exported to the super clusters of the pool"); err != nil {
+		return reconciler.Result{Requeue: true}, err
+	}
+	return reconciler.Result{}, nil
+}
+
+// reconcileSlices makes the endpointslices imported for the tenant service in the super control plane
+// the desired ones.
+func (c *controller) reconcileSlices(clusterName, targetNamespace, namespace, name string, desired []*discoveryv1.EndpointSlice) error {
+	selector := labels.SelectorFromSet(labels.Set{
+		discoveryv1.LabelServiceName: name,
+		discoveryv1.LabelManagedBy:   constants.EndpointSliceManagedBy,
+	})
+	existing, err := c.sliceLister.EndpointSlices(targetNamespace).List(selector)
+	if err != nil {
+		return err
+	}
+	existingByName := make(map[string]*discoveryv1.EndpointSlice, len(existing))
+	for _, slice := range existing {
+		if slice.Annotations[constants.LabelCluster] != clusterName || slice.Annotations[constants.LabelNamespace] != namespace {
+			continue
+		}
+		existingByName[slice.Name] = slice
+	}
+
+	for _, slice := range desired {
+		pSlice, exists := existingByName[slice.Name]
+		delete(existingByName, slice.Name)
+		if !exists {
+			_, err := c.sliceClient.EndpointSlices(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), slice, metav1.CreateOptions{})
+			if err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+			continue
+		}
+		if apiequality.Semantic.DeepEqual(pSlice.Labels, slice.Labels) &&
+			apiequality.Semantic.DeepEqual(pSlice.Endpoints, slice.Endpoints) &&
+			apiequality.Semantic.DeepEqual(pSlice.Ports, slice.Ports) {
+			continue
+		}
+		updated := pSlice.DeepCopy()
+		updated.Labels = slice.Labels
+		updated.Endpoints = slice.Endpoints
+		updated.Ports = slice.Ports
+		if _, err := c.sliceClient.EndpointSlices(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updated, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	for _, pSlice := range existingByName {
+		err := c.sliceClient.EndpointSlices(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), pSlice.Name, metav1.DeleteOptions{
+			Preconditions: metav1.NewUIDPreconditions(string(pSlice.UID)),
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+type sliceKey struct {
+	cluster     string
+	addressType discoveryv1.AddressType
+	ports       string
+}
+
+// desiredSlices returns the endpointslices importing the endpoints of the service pods that are scheduled
+// to the other super clusters, and the super clusters having endpoints of the service.
+func desiredSlices(clusterName, targetNamespace string, vService *corev1.Service, vPods []corev1.Pod) ([]*discoveryv1.EndpointSlice, []string) {
+	clusters := make(map[string]struct{})
+	endpoints := make(map[sliceKey][]discoveryv1.Endpoint)
+	ports := make(map[sliceKey][]discoveryv1.EndpointPort)
+	for i := range vPods {
+		pod := &vPods[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || len(pod.Status.PodIPs) == 0 {
+			continue
+		}
+		cluster := pod.Annotations[utilconstants.LabelScheduledCluster]
+		if cluster == "" {
+			cluster = utilconstants.SuperClusterID
+		}
+		if cluster != "" {
+			clusters[cluster] = struct{}{}
+		}
+		if cluster == utilconstants.SuperClusterID {
+			// the endpoints in this super cluster are managed by the endpointslice controller.
+			continue
+		}
+
+		podPorts := endpointPorts(vService, pod)
+		for _, ip := range pod.Status.PodIPs {
+			addressType := discoveryv1.AddressTypeIPv4
+			if parsed := net.ParseIP(ip.IP); parsed == nil {
+				continue
+			} else if parsed.To4() == nil {
+				addressType = discoveryv1.AddressTypeIPv6
+			}
+			key := sliceKey{cluster: cluster, addressType: addressType, ports: portsKey(podPorts)}
+			endpoint := discoveryv1.Endpoint{
+				Addresses:  []string{ip.IP},
+				Conditions: discoveryv1.EndpointConditions{Ready: pointer.BoolPtr(podReady(pod))},
+			}
+			if pod.Spec.Hostname != "" && pod.Spec.Subdomain == vService.Name {
+				endpoint.Hostname = pointer.StringPtr(pod.Spec.Hostname)
+			}
+			endpoints[key] = append(endpoints[key], endpoint)
+			ports[key] = podPorts
+		}
+	}
+
+	var slices []*discoveryv1.EndpointSlice
+	for key, eps := range endpoints {
+		sort.Slice(eps, func(i, j int) bool { return eps[i].Addresses[0] < eps[j].Addresses[0] })
+		for chunk := 0; chunk*maxEndpointsPerSlice < len(eps); chunk++ {
+			end := (chunk + 1) * maxEndpointsPerSlice
+			if end > len(eps) {
+				end = len(eps)
+			}
+			slices = append(slices, &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      sliceName(vService.Name, key, chunk),
+					Namespace: targetNamespace,
+					Labels: map[string]string{
+						discoveryv1.LabelServiceName: vService.Name,
+						discoveryv1.LabelManagedBy:   constants.EndpointSliceManagedBy,
+						constants.LabelSourceCluster: key.cluster,
+					},
+					Annotations: map[string]string{
+						constants.LabelCluster:   clusterName,
+						constants.LabelNamespace: vService.Namespace,
+					},
+				},
+				AddressType: key.addressType,
+				Endpoints:   eps[chunk*maxEndpointsPerSlice : end],
+				Ports:       ports[key],
+			})
+		}
+	}
+	sort.Slice(slices, func(i, j int) bool { return slices[i].Name < slices[j].Name })
+
+	clusterNames := make([]string, 0, len(clusters))
+	for cluster := range clusters {
+		clusterNames = append(clusterNames, cluster)
+	}
+	sort.Strings(clusterNames)
+	return slices, clusterNames
+}
+
+// sliceName returns a name of the imported endpointslice that is stable across reconciliations.
+func sliceName(service string, key sliceKey, chunk int) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s/%s/%s/%d", key.cluster, key.addressType, key.ports, chunk)
+	if len(service) > 52 {
+		service = service[:52]
+	}
+	return fmt.Sprintf("%s-%08x", service, h.Sum32())
+}
+
+// endpointPorts returns the ports of the service the pod serves, the target ports are resolved
+// against the container ports of the pod.
+func endpointPorts(vService *corev1.Service, pod *corev1.Pod) []discoveryv1.EndpointPort {
+	var ports []discoveryv1.EndpointPort
+	for i := range vService.Spec.Ports {
+		servicePort := &vService.Spec.Ports[i]
+		port, ok := findPort(pod, servicePort)
+		if !ok {
+			continue
+		}
+		protocol := servicePort.Protocol
+		ports = append(ports, discoveryv1.EndpointPort{
+			Name:        pointer.StringPtr(servicePort.Name),
+			Protocol:    &protocol,
+			Port:        pointer.Int32Ptr(port),
+			AppProtocol: servicePort.AppProtocol,
+		})
+	}
+	return ports
+}
+
+func findPort(pod *corev1.Pod, servicePort *corev1.ServicePort) (int32, bool) {
+	if servicePort.TargetPort.StrVal == "" {
+		if servicePort.TargetPort.IntVal == 0 {
+			return servicePort.Port, true
+		}
+		return servicePort.TargetPort.IntVal, true
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == servicePort.TargetPort.StrVal && port.Protocol == servicePort.Protocol {
+				return port.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func portsKey(ports []discoveryv1.EndpointPort) string {
+	s := make([]string, 0, len(ports))
+	for _, port := range ports {
+		s = append(s, fmt.Sprintf("%s:%s:%d", *port.Name, *port.Protocol, *port.Port))
+	}
+	return strings.Join(s, ",")
+}
+
+func podReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// endpointChanged returns true if the pod update changes the endpoints imported for it.
+func endpointChanged(oldPod, newPod *corev1.Pod) bool {
+	return podReady(oldPod) != podReady(newPod) ||
+		oldPod.Status.Phase != newPod.Status.Phase ||
+		!apiequality.Semantic.DeepEqual(oldPod.Status.PodIPs, newPod.Status.PodIPs) ||
+		!apiequality.Semantic.DeepEqual(oldPod.Labels, newPod.Labels) ||
+		oldPod.Annotations[utilconstants.LabelScheduledCluster] != newPod.Annotations[utilconstants.LabelScheduledCluster]
+}
+
+// reconcileServiceImport makes the tenant service import of the service export describe the exported service.
+func (c *controller) reconcileServiceImport(clusterName string, vExport *mcsv1alpha1.ServiceExport, vService *corev1.Service, clusters []string) error {
+	tenantClient, err := c.MultiClusterController.GetCluster(clusterName).GetDelegatingClient()
+	if err != nil {
+		return err
+	}
+
+	spec := mcsv1alpha1.ServiceImportSpec{
+		Type:                  mcsv1alpha1.ClusterSetIP,
+		SessionAffinity:       vService.Spec.SessionAffinity,
+		SessionAffinityConfig: vService.Spec.SessionAffinityConfig,
+	}
+	if vService.Spec.ClusterIP == corev1.ClusterIPNone {
+		spec.Type = mcsv1alpha1.Headless
+	}
+	for _, port := range vService.Spec.Ports {
+		spec.Ports = append(spec.Ports, mcsv1alpha1.ServicePort{
+			Name:        port.Name,
+			Protocol:    port.Protocol,
+			AppProtocol: port.AppProtocol,
+			Port:        port.Port,
+		})
+	}
+	var status mcsv1alpha1.ServiceImportStatus
+	for _, cluster := range clusters {
+		status.Clusters = append(status.Clusters, mcsv1alpha1.ClusterStatus{Cluster: cluster})
+	}
+
+	vImport := &mcsv1alpha1.ServiceImport{}
+	err = tenantClient.Get(context.TODO(), client.ObjectKey{Namespace: vExport.Namespace, Name: vExport.Name}, vImport)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if apierrors.IsNotFound(err) {
+		vImport = &mcsv1alpha1.ServiceImport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      vExport.Name,
+				Namespace: vExport.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(vExport, mcsv1alpha1.SchemeGroupVersion.WithKind("ServiceExport")),
+				},
+			},
+			Spec: spec,
+		}
+		if err := tenantClient.Create(context.TODO(), vImport); err != nil {
+			return err
+		}
+	} else if !apiequality.Semantic.DeepEqual(vImport.Spec, spec) {
+		vImport.Spec = spec
+		if err := tenantClient.Update(context.TODO(), vImport); err != nil {
+			return err
+		}
+	}
+
+	if apiequality.Semantic.DeepEqual(vImport.Status, status) {
+		return nil
+	}
+	vImport.Status = status
+	return tenantClient.Status().Update(context.TODO(), vImport)
+}
+
+// updateExportCondition sets the Valid condition of the tenant service export.
+func (c *controller) updateExportCondition(clusterName string, vExport *mcsv1alpha1.ServiceExport, status corev1.ConditionStatus, reason, message string) error {
+	for _, condition := range vExport.Status.Conditions {
+		if condition.Type == mcsv1alpha1.ServiceExportValid && condition.Status == status &&
+			pointer.StringDeref(condition.Reason, "") == reason && pointer.StringDeref(condition.Message, "") == message {
+			return nil
+		}
+	}
+
+	tenantClient, err := c.MultiClusterController.GetCluster(clusterName).GetDelegatingClient()
+	if err != nil {
+		return err
+	}
+	now := metav1.Now()
+	updated := vExport.DeepCopy()
+	condition := mcsv1alpha1.ServiceExportCondition{
+		Type:               mcsv1alpha1.ServiceExportValid,
+		Status:             status,
+		LastTransitionTime: &now,
+		Reason:             pointer.StringPtr(reason),
+		Message:            pointer.StringPtr(message),
+	}
+	conditions := updated.Status.Conditions[:0]
+	for _, existing := range updated.Status.Conditions {
+		if existing.Type != mcsv1alpha1.ServiceExportValid {
+			conditions = append(conditions, existing)
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+	}
+	updated.Status.Conditions = append(conditions, condition)
+	return tenantClient.Status().Update(context.TODO(), updated)
+}
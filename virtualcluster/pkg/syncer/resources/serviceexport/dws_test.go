@@ -0,0 +1,234 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceexport
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	mcsv1alpha1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+var testTenant = &v1alpha1.VirtualCluster{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "test",
+		Namespace: "tenant-1",
+		UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+	},
+	Status: v1alpha1.VirtualClusterStatus{
+		Phase: v1alpha1.ClusterRunning,
+	},
+}
+
+func newTestController(t *testing.T, existingObjectInSuper []runtime.Object, existingObjectInTenant []client.Object) (*controller, *fake.Clientset, client.Client) {
+	superClient := fake.NewSimpleClientset(existingObjectInSuper...)
+	informerFactory := informers.NewSharedInformerFactory(superClient, 0)
+	c, err := newController(&config.SyncerConfiguration{}, superClient, informerFactory, manager.ResourceSyncerOptions{IsFake: true})
+	if err != nil {
+		t.Fatalf("error creating serviceexport controller: %v", err)
+	}
+	for _, each := range existingObjectInSuper {
+		_ = informerFactory.Discovery().V1().EndpointSlices().Informer().GetStore().Add(each)
+	}
+
+	tenantClient := fakeClient.NewClientBuilder().WithObjects(existingObjectInTenant...).Build()
+	c.GetListener().AddCluster(cluster.NewFakeTenantCluster(testTenant, fake.NewSimpleClientset(), tenantClient))
+	return c, superClient, tenantClient
+}
+
+func tenantServiceExport(name, namespace string) *mcsv1alpha1.ServiceExport {
+	return &mcsv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: "d0a5f2a4-4f5e-4a4b-9d4c-6d9e1b1f1c2a"},
+	}
+}
+
+func tenantService(name, namespace string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": name},
+			Ports:    []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromString("http")}},
+		},
+	}
+}
+
+func tenantPod(name, namespace, app, superCluster, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{"app": app},
+			Annotations: map[string]string{utilconstants.LabelScheduledCluster: superCluster},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "c", Ports: []corev1.ContainerPort{{Name: "http", Protocol: corev1.ProtocolTCP, ContainerPort: 8080}}}},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			PodIPs:     []corev1.PodIP{{IP: ip}},
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	defer func(id string) { utilconstants.SuperClusterID = id }(utilconstants.SuperClusterID)
+	utilconstants.SuperClusterID = "super-a"
+
+	clusterKey := conversion.ToClusterKey(testTenant)
+	superNamespace := conversion.ToSuperClusterNamespace(clusterKey, "default")
+	svc := tenantService("web", "default")
+	localPod := tenantPod("web-0", "default", "web", "super-a", "10.0.0.1")
+	remotePod := tenantPod("web-1", "default", "web", "super-b", "10.1.0.1")
+	otherPod := tenantPod("db-0", "default", "db", "super-b", "10.1.0.2")
+
+	expected, clusters := desiredSlices(clusterKey, superNamespace, svc, []corev1.Pod{*localPod, *remotePod})
+	if len(expected) != 1 {
+		t.Fatalf("expected 1 imported endpointslice, got %d", len(expected))
+	}
+	if !reflect.DeepEqual(clusters, []string{"super-a", "super-b"}) {
+		t.Errorf("expected the clusters super-a and super-b, got %v", clusters)
+	}
+	stale := expected[0].DeepCopy()
+	stale.Endpoints[0].Addresses = []string{"10.1.0.9"}
+
+	for name, tc := range map[string]struct {
+		superObjects      []runtime.Object
+		tenantObjects     []client.Object
+		expectedActions   []string
+		expectedAddresses []string
+		expectedCondition corev1.ConditionStatus
+		expectedReason    string
+	}{
+		"import remote endpoints": {
+			tenantObjects:     []client.Object{tenantServiceExport("web", "default"), svc, localPod, remotePod, otherPod},
+			expectedActions:   []string{"create"},
+			expectedAddresses: []string{"10.1.0.1"},
+			expectedCondition: corev1.ConditionTrue,
+			expectedReason:    "ServiceExported",
+		},
+		"imported endpoints up to date": {
+			superObjects:      []runtime.Object{expected[0].DeepCopy()},
+			tenantObjects:     []client.Object{tenantServiceExport("web", "default"), svc, localPod, remotePod},
+			expectedCondition: corev1.ConditionTrue,
+			expectedReason:    "ServiceExported",
+		},
+		"update imported endpoints": {
+			superObjects:      []runtime.Object{stale},
+			tenantObjects:     []client.Object{tenantServiceExport("web", "default"), svc, localPod, remotePod},
+			expectedActions:   []string{"update"},
+			expectedAddresses: []string{"10.1.0.1"},
+			expectedCondition: corev1.ConditionTrue,
+			expectedReason:    "ServiceExported",
+		},
+		"service not found": {
+			superObjects:      []runtime.Object{expected[0].DeepCopy()},
+			tenantObjects:     []client.Object{tenantServiceExport("web", "default"), localPod, remotePod},
+			expectedActions:   []string{"delete"},
+			expectedCondition: corev1.ConditionFalse,
+			expectedReason:    "ServiceNotFound",
+		},
+		"service export deleted": {
+			superObjects:    []runtime.Object{expected[0].DeepCopy()},
+			tenantObjects:   []client.Object{svc, localPod, remotePod},
+			expectedActions: []string{"delete"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, superClient, tenantClient := newTestController(t, tc.superObjects, tc.tenantObjects)
+			_, err := c.Reconcile(reconciler.Request{ClusterName: clusterKey, NamespacedName: types.NamespacedName{Namespace: "default", Name: "web"}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var verbs []string
+			for _, action := range superClient.Actions() {
+				if action.GetResource().Resource != "endpointslices" || action.GetVerb() == "list" || action.GetVerb() == "watch" {
+					continue
+				}
+				verbs = append(verbs, action.GetVerb())
+				var slice *discoveryv1.EndpointSlice
+				switch a := action.(type) {
+				case core.CreateAction:
+					slice = a.GetObject().(*discoveryv1.EndpointSlice)
+				case core.UpdateAction:
+					slice = a.GetObject().(*discoveryv1.EndpointSlice)
+				default:
+					continue
+				}
+				var addresses []string
+				for _, endpoint := range slice.Endpoints {
+					addresses = append(addresses, endpoint.Addresses...)
+				}
+				if !reflect.DeepEqual(addresses, tc.expectedAddresses) {
+					t.Errorf("expected imported addresses %v, got %v", tc.expectedAddresses, addresses)
+				}
+				if slice.Labels[constants.LabelSourceCluster] != "super-b" || slice.Labels[discoveryv1.LabelServiceName] != "web" {
+					t.Errorf("unexpected labels of the imported endpointslice: %v", slice.Labels)
+				}
+				if len(slice.Ports) != 1 || *slice.Ports[0].Port != 8080 {
+					t.Errorf("expected the target port 8080 to be resolved, got %+v", slice.Ports)
+				}
+			}
+			if !reflect.DeepEqual(verbs, tc.expectedActions) {
+				t.Errorf("expected actions %v, got %v", tc.expectedActions, verbs)
+			}
+
+			if tc.expectedCondition == "" {
+				return
+			}
+			export := &mcsv1alpha1.ServiceExport{}
+			if err := tenantClient.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "web"}, export); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(export.Status.Conditions) != 1 || export.Status.Conditions[0].Status != tc.expectedCondition || *export.Status.Conditions[0].Reason != tc.expectedReason {
+				t.Errorf("expected condition %s/%s, got %+v", tc.expectedCondition, tc.expectedReason, export.Status.Conditions)
+			}
+			if tc.expectedCondition != corev1.ConditionTrue {
+				return
+			}
+			serviceImport := &mcsv1alpha1.ServiceImport{}
+			if err := tenantClient.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "web"}, serviceImport); err != nil {
+				t.Fatalf("expected the service import to be created: %v", err)
+			}
+			if serviceImport.Spec.Type != mcsv1alpha1.ClusterSetIP || len(serviceImport.Spec.Ports) != 1 || len(serviceImport.Status.Clusters) != 2 {
+				t.Errorf("unexpected service import %+v", serviceImport)
+			}
+		})
+	}
+}
@@ -100,6 +100,9 @@ func (c *controller) checkStorageClassOfTenantCluster(clusterName string) {
 		pStorageClass, err := c.storageclassLister.Get(vStorageClass.Name)
 		if apierrors.IsNotFound(err) {
 			// super control plane is the source of the truth for sc object, delete tenant control plane obj
+			if !c.RemediationBudget().Take(clusterName) {
+				continue
+			}
 			tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
 			if err != nil {
 				klog.Errorf("error getting cluster %s clientset: %v", clusterName, err)
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"sort"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func TestVolumeSnapshotPatrol(t *testing.T) {
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	fromContent := tenantVolumeSnapshot("snapshot-1", "default", "12345")
+	fromContent.Spec.Source = snapshotv1.VolumeSnapshotSource{VolumeSnapshotContentName: pointer.StringPtr("content-1")}
+	labeled := tenantVolumeSnapshot("snapshot-1", "default", "12345")
+	labeled.Labels = map[string]string{"app": "db"}
+	readyToUse := superVolumeSnapshot("snapshot-1", superDefaultNSName, "12345", defaultClusterKey)
+	readyToUse.Status = &snapshotv1.VolumeSnapshotStatus{ReadyToUse: pointer.BoolPtr(true)}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant []client.Object
+
+		ExpectedDeletedPObject  []string
+		ExpectedRequeuedVObject int
+		ExpectedRequeuedPObject int
+		ExpectedMissMatched     uint64
+	}{
+		"pSnapshot exists, vSnapshot exists": {
+			ExistingObjectInSuper:  []runtime.Object{superVolumeSnapshot("snapshot-1", superDefaultNSName, "12345", defaultClusterKey)},
+			ExistingObjectInTenant: []client.Object{tenantVolumeSnapshot("snapshot-1", "default", "12345")},
+		},
+		"pSnapshot exists, vSnapshot does not exists": {
+			ExistingObjectInSuper:  []runtime.Object{superVolumeSnapshot("snapshot-1", superDefaultNSName, "12345", defaultClusterKey)},
+			ExpectedDeletedPObject: []string{superDefaultNSName + "/snapshot-1"},
+		},
+		"pSnapshot of an unknown cluster exists": {
+			ExistingObjectInSuper: []runtime.Object{superVolumeSnapshot("snapshot-1", "tenant-2-default", "12345", "tenant-2")},
+		},
+		"pSnapshot exists, vSnapshot exists with different uid": {
+			ExistingObjectInSuper:  []runtime.Object{superVolumeSnapshot("snapshot-1", superDefaultNSName, "123456", defaultClusterKey)},
+			ExistingObjectInTenant: []client.Object{tenantVolumeSnapshot("snapshot-1", "default", "12345")},
+			ExpectedDeletedPObject: []string{superDefaultNSName + "/snapshot-1"},
+		},
+		"vSnapshot exists, pSnapshot does not exists": {
+			ExistingObjectInTenant:  []client.Object{tenantVolumeSnapshot("snapshot-1", "default", "12345")},
+			ExpectedRequeuedVObject: 1,
+		},
+		"vSnapshot of a tenant content exists, pSnapshot does not exists": {
+			ExistingObjectInTenant: []client.Object{fromContent},
+		},
+		"pSnapshot exists, vSnapshot exists with different labels": {
+			ExistingObjectInSuper:  []runtime.Object{superVolumeSnapshot("snapshot-1", superDefaultNSName, "12345", defaultClusterKey)},
+			ExistingObjectInTenant: []client.Object{labeled},
+			ExpectedMissMatched:    1,
+		},
+		"pSnapshot exists, vSnapshot exists with different status": {
+			ExistingObjectInSuper:   []runtime.Object{readyToUse},
+			ExistingObjectInTenant:  []client.Object{tenantVolumeSnapshot("snapshot-1", "default", "12345")},
+			ExpectedRequeuedPObject: 1,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			c, snapshotClient, _ := newTestController(t, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant)
+			c.PatrollerDo()
+
+			var deleted []string
+			for _, action := range snapshotClient.Actions() {
+				if action.Matches("delete", "volumesnapshots") {
+					deleted = append(deleted, action.GetNamespace()+"/"+action.(core.DeleteAction).GetName())
+				}
+			}
+			sort.Strings(deleted)
+			if !equality.Semantic.DeepEqual(deleted, tc.ExpectedDeletedPObject) {
+				t.Errorf("expected deleted pSnapshots %v, got %v", tc.ExpectedDeletedPObject, deleted)
+			}
+			if got := c.MultiClusterController.Queue.Len(); got != tc.ExpectedRequeuedVObject {
+				t.Errorf("expected %d requeued vSnapshots, got %d", tc.ExpectedRequeuedVObject, got)
+			}
+			if got := c.UpwardController.Queue.Len(); got != tc.ExpectedRequeuedPObject {
+				t.Errorf("expected %d requeued pSnapshots, got %d", tc.ExpectedRequeuedPObject, got)
+			}
+			if numMissMatchedVolumeSnapshots != tc.ExpectedMissMatched {
+				t.Errorf("expected %d mismatched snapshots, got %d", tc.ExpectedMissMatched, numMissMatchedVolumeSnapshots)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"fmt"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotclient "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned"
+	v1snapshot "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/typed/volumesnapshot/v1"
+	snapshotinformers "github.com/kubernetes-csi/external-snapshotter/client/v4/informers/externalversions"
+	listersv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/listers/volumesnapshot/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+func init() {
+	// the tenant control plane clients decode the snapshot objects using the client-go scheme.
+	utilruntime.Must(snapshotv1.AddToScheme(scheme.Scheme))
+
+	plugin.SyncerResourceRegister.Register(&plugin.Registration{
+		ID: "volumesnapshot",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			return NewVolumeSnapshotController(ctx.Config.(*config.SyncerConfiguration), ctx.Client, ctx.Informer, ctx.VCClient, ctx.VCInformer, manager.ResourceSyncerOptions{})
+		},
+		Disable: true,
+	})
+}
+
+type controller struct {
+	manager.BaseResourceSyncer
+	// super control plane volumesnapshot client
+	snapshotClient v1snapshot.VolumeSnapshotsGetter
+	// super control plane volumesnapshot informer/lister/synced functions
+	informerFactory snapshotinformers.SharedInformerFactory
+	snapshotLister  listersv1.VolumeSnapshotLister
+	snapshotSynced  cache.InformerSynced
+}
+
+func NewVolumeSnapshotController(config *config.SyncerConfiguration,
+	client clientset.Interface,
+	informer informers.SharedInformerFactory,
+	vcClient vcclient.Interface,
+	vcInformer vcinformers.VirtualClusterInformer,
+	options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+	if config.RestConfig == nil {
+		return nil, fmt.Errorf("cannot get super control plane restful config")
+	}
+	snapshotClient, err := snapshotclient.NewForConfig(config.RestConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newController(config, snapshotClient, snapshotinformers.NewSharedInformerFactory(snapshotClient, 0), options)
+}
+
+func newController(config *config.SyncerConfiguration,
+	snapshotClient snapshotclient.Interface,
+	informerFactory snapshotinformers.SharedInformerFactory,
+	options manager.ResourceSyncerOptions) (*controller, error) {
+	c := &controller{
+		BaseResourceSyncer: manager.BaseResourceSyncer{
+			Config: config,
+		},
+		snapshotClient:  snapshotClient.SnapshotV1(),
+		informerFactory: informerFactory,
+	}
+
+	var err error
+	c.MultiClusterController, err = mc.NewMCController(&snapshotv1.VolumeSnapshot{}, &snapshotv1.VolumeSnapshotList{}, c, mc.WithOptions(options.MCOptions),
+		mc.WithUpdatePredicate(util.DWSUpdatePredicate(nil)))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotInformer := informerFactory.Snapshot().V1().VolumeSnapshots()
+	c.snapshotLister = snapshotInformer.Lister()
+	objectlimit.RegisterIndexer("volumesnapshots", snapshotInformer.Informer().GetIndexer())
+	if options.IsFake {
+		c.snapshotSynced = func() bool { return true }
+	} else {
+		c.snapshotSynced = snapshotInformer.Informer().HasSynced
+	}
+
+	c.UpwardController, err = uw.NewUWController(&snapshotv1.VolumeSnapshot{}, c, uw.WithOptions(options.UWOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Patroller, err = pa.NewPatroller(&snapshotv1.VolumeSnapshot{}, c, pa.WithOptions(options.PatrolOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				c.enqueueVolumeSnapshot(newObj)
+			},
+		},
+	)
+	return c, nil
+}
+
+func (c *controller) enqueueVolumeSnapshot(obj interface{}) {
+	snapshot, ok := obj.(*snapshotv1.VolumeSnapshot)
+	if !ok {
+		return
+	}
+
+	clusterName, _ := conversion.GetVirtualOwner(snapshot)
+	if clusterName == "" {
+		return
+	}
+
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %v: %v", obj, err))
+		return
+	}
+
+	klog.V(4).Infof("enqueue VolumeSnapshot %s", key)
+	c.UpwardController.AddToQueue(key)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/recyclebin"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+func (c *controller) StartDWS(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.snapshotSynced) {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	return c.MultiClusterController.Start(stopCh)
+}
+
+// The reconcile logic for tenant control plane volumesnapshot informer
+func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, error) {
+	klog.V(4).Infof("reconcile volumesnapshot %s/%s event for cluster %s", request.Namespace, request.Name, request.ClusterName)
+
+	targetNamespace := conversion.ToSuperClusterNamespace(request.ClusterName, request.Namespace)
+	pSnapshot, err := c.snapshotLister.VolumeSnapshots(targetNamespace).Get(request.Name)
+	pExists := true
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		pExists = false
+	}
+	vExists := true
+
+	vSnapshot := &snapshotv1.VolumeSnapshot{}
+	if err := c.MultiClusterController.Get(request.ClusterName, request.Namespace, request.Name, vSnapshot); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		vExists = false
+	}
+	switch {
+	case vExists && !pExists:
+		err := c.reconcileVolumeSnapshotCreate(request.ClusterName, targetNamespace, request.UID, vSnapshot)
+		if err != nil {
+			klog.Errorf("failed reconcile volumesnapshot %s/%s CREATE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	case !vExists && pExists:
+		err := c.reconcileVolumeSnapshotRemove(request.ClusterName, targetNamespace, request.UID, request.Name, pSnapshot)
+		if err != nil {
+			klog.Errorf("failed reconcile volumesnapshot %s/%s DELETE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	case vExists && pExists:
+		err := c.reconcileVolumeSnapshotUpdate(request.ClusterName, targetNamespace, request.UID, pSnapshot, vSnapshot)
+		if err != nil {
+			klog.Errorf("failed reconcile volumesnapshot %s/%s UPDATE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	default:
+		// object is gone.
+	}
+	return reconciler.Result{}, nil
+}
+
+func (c *controller) reconcileVolumeSnapshotCreate(clusterName, targetNamespace, requestUID string, snapshot *snapshotv1.VolumeSnapshot) error {
+	if snapshot.Spec.Source.PersistentVolumeClaimName == nil {
+		// A content of the tenant control plane cannot be referred to in the super control plane.
+		klog.V(4).Infof("skip volumesnapshot %s/%s of cluster %s not taken from a pvc", snapshot.Namespace, snapshot.Name, clusterName)
+		return c.MultiClusterController.Eventf(clusterName, &corev1.ObjectReference{
+			Kind:       "VolumeSnapshot",
+			APIVersion: snapshotv1.SchemeGroupVersion.String(),
+			Name:       snapshot.Name,
+			Namespace:  snapshot.Namespace,
+			UID:        snapshot.UID,
+		}, corev1.EventTypeWarning, constants.ReasonUnsupportedSource, "Only the snapshots of a persistentVolumeClaimName source are taken by the super control plane")
+	}
+
+	pSnapshot, err := conversion.BuildSuperClusterVolumeSnapshot(c.Conversion(), clusterName, snapshot)
+	if err != nil {
+		return err
+	}
+	c.FinalizerTranslator().Apply(snapshot, pSnapshot)
+	// the status is managed by the super control plane snapshot controller.
+	pSnapshot.Status = nil
+
+	pSnapshot, err = c.snapshotClient.VolumeSnapshots(targetNamespace).Create(rbac.WithTenant(context.TODO(), clusterName), pSnapshot, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		if pSnapshot.Annotations[constants.LabelUID] == requestUID {
+			klog.Infof("volumesnapshot %s/%s of cluster %s already exist in super control plane", targetNamespace, pSnapshot.Name, clusterName)
+			return nil
+		}
+		return fmt.Errorf("pSnapshot %s/%s exists but its delegated object UID is different", targetNamespace, pSnapshot.Name)
+	}
+	return err
+}
+
+func (c *controller) reconcileVolumeSnapshotUpdate(clusterName, targetNamespace, requestUID string, pSnapshot, vSnapshot *snapshotv1.VolumeSnapshot) error {
+	if pSnapshot.Annotations[constants.LabelUID] != requestUID {
+		// the tenant object is recreated in the restored namespace, it adopts the retained object
+		adopted, _ := recyclebin.Adopt(pSnapshot, requestUID).(*snapshotv1.VolumeSnapshot)
+		if adopted == nil {
+			return fmt.Errorf("pSnapshot %s/%s delegated UID is different from updated object", targetNamespace, pSnapshot.Name)
+		}
+		if err := conversion.VerifyOwnership(pSnapshot); err != nil {
+			return err
+		}
+		adopted, err := c.snapshotClient.VolumeSnapshots(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), adopted, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		klog.Infof("volumesnapshot %s/%s of cluster %s adopted the retained object in super control plane", targetNamespace, pSnapshot.Name, clusterName)
+		pSnapshot = adopted
+	}
+	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+	if err != nil {
+		return err
+	}
+	updatedSnapshot := conversion.Equality(c.Config, vc).CheckVolumeSnapshotEquality(pSnapshot, vSnapshot)
+	if updatedSnapshot != nil {
+		if err := conversion.Equality(c.Config, vc).CheckMetadataLimits(vSnapshot, updatedSnapshot); err != nil {
+			return err
+		}
+		changed, err := c.ConflictResolver().Resolve(clusterName, vSnapshot, pSnapshot, updatedSnapshot)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			updatedSnapshot = nil
+		}
+	}
+	if hash, equal := conversion.CheckSemanticHash(pSnapshot, vSnapshot); hash != "" && !equal {
+		if updatedSnapshot == nil {
+			updatedSnapshot = pSnapshot.DeepCopy()
+		}
+		conversion.SetSemanticHash(updatedSnapshot, hash)
+	}
+	if updatedSnapshot != nil {
+		c.FinalizerTranslator().Apply(vSnapshot, updatedSnapshot)
+	} else if finalized := pSnapshot.DeepCopy(); c.FinalizerTranslator().Apply(vSnapshot, finalized) {
+		updatedSnapshot = finalized
+	}
+	if updatedSnapshot != nil {
+		_, err = c.snapshotClient.VolumeSnapshots(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), updatedSnapshot, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *controller) reconcileVolumeSnapshotRemove(clusterName, targetNamespace, requestUID, name string, pSnapshot *snapshotv1.VolumeSnapshot) error {
+	if pSnapshot.Annotations[constants.LabelUID] != requestUID {
+		return fmt.Errorf("to be deleted pSnapshot %s/%s delegated UID is different from deleted object", targetNamespace, pSnapshot.Name)
+	}
+	if err := conversion.VerifyOwnership(pSnapshot); err != nil {
+		return err
+	}
+	if retained, err := recyclebin.Retains(c.MultiClusterController, pSnapshot); err != nil {
+		return err
+	} else if retained {
+		if marked := recyclebin.RetainObject(pSnapshot, c.Config.NamespaceRetentionPeriod.Duration); marked != nil {
+			if _, err := c.snapshotClient.VolumeSnapshots(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), marked.(*snapshotv1.VolumeSnapshot), metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		klog.V(4).Infof("keeping volumesnapshot %s/%s of the retained namespace in super control plane", targetNamespace, name)
+		return nil
+	}
+	if released := pSnapshot.DeepCopy(); c.FinalizerTranslator().Release(released) {
+		if _, err := c.snapshotClient.VolumeSnapshots(targetNamespace).Update(rbac.WithTenant(context.TODO(), clusterName), released, metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	opts := &metav1.DeleteOptions{
+		PropagationPolicy: &constants.DefaultDeletionPolicy,
+	}
+	err := c.snapshotClient.VolumeSnapshots(targetNamespace).Delete(rbac.WithTenant(context.TODO(), clusterName), name, *opts)
+	if apierrors.IsNotFound(err) {
+		klog.Warningf("volumesnapshot %s/%s of cluster %s not found in super control plane", targetNamespace, name, clusterName)
+		return nil
+	}
+	return err
+}
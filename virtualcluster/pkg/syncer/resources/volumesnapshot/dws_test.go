@@ -0,0 +1,234 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"strings"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotfake "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	snapshotinformers "github.com/kubernetes-csi/external-snapshotter/client/v4/informers/externalversions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+var testTenant = &v1alpha1.VirtualCluster{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "test",
+		Namespace: "tenant-1",
+		UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+	},
+	Status: v1alpha1.VirtualClusterStatus{
+		Phase: v1alpha1.ClusterRunning,
+	},
+}
+
+// newTestController returns a controller watching the snapshots of a fake super control plane and
+// a fake tenant control plane, the snapshot objects are not supported by the syncer test utilities.
+func newTestController(t *testing.T, existingObjectInSuper []runtime.Object, existingObjectInTenant []client.Object) (*controller, *snapshotfake.Clientset, client.Client) {
+	snapshotClient := snapshotfake.NewSimpleClientset(existingObjectInSuper...)
+	informerFactory := snapshotinformers.NewSharedInformerFactory(snapshotClient, 0)
+	c, err := newController(&config.SyncerConfiguration{}, snapshotClient, informerFactory, manager.ResourceSyncerOptions{IsFake: true})
+	if err != nil {
+		t.Fatalf("error creating volumesnapshot controller: %v", err)
+	}
+	for _, each := range existingObjectInSuper {
+		_ = informerFactory.Snapshot().V1().VolumeSnapshots().Informer().GetStore().Add(each)
+	}
+
+	tenantClient := fakeClient.NewClientBuilder().WithObjects(existingObjectInTenant...).Build()
+	c.GetListener().AddCluster(cluster.NewFakeTenantCluster(testTenant, fake.NewSimpleClientset(), tenantClient))
+	return c, snapshotClient, tenantClient
+}
+
+func tenantVolumeSnapshot(name, namespace, uid string) *snapshotv1.VolumeSnapshot {
+	return &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(uid),
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: pointer.StringPtr("pvc-1"),
+			},
+			VolumeSnapshotClassName: pointer.StringPtr("csi-snapclass"),
+		},
+	}
+}
+
+func superVolumeSnapshot(name, namespace, uid, clusterKey string) *snapshotv1.VolumeSnapshot {
+	return &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				constants.LabelUID:       uid,
+				constants.LabelCluster:   clusterKey,
+				constants.LabelNamespace: "default",
+			},
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: pointer.StringPtr("pvc-1"),
+			},
+			VolumeSnapshotClassName: pointer.StringPtr("csi-snapclass"),
+		},
+	}
+}
+
+func TestDWVolumeSnapshotCreation(t *testing.T) {
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	fromContent := tenantVolumeSnapshot("snapshot-1", "default", "12345")
+	fromContent.Spec.Source = snapshotv1.VolumeSnapshotSource{VolumeSnapshotContentName: pointer.StringPtr("content-1")}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper []runtime.Object
+		VolumeSnapshot        *snapshotv1.VolumeSnapshot
+		ExpectedCreated       bool
+		ExpectedError         string
+	}{
+		"new snapshot": {
+			VolumeSnapshot:  tenantVolumeSnapshot("snapshot-1", "default", "12345"),
+			ExpectedCreated: true,
+		},
+		"new snapshot but already exists": {
+			ExistingObjectInSuper: []runtime.Object{
+				superVolumeSnapshot("snapshot-1", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			VolumeSnapshot: tenantVolumeSnapshot("snapshot-1", "default", "12345"),
+		},
+		"new snapshot but existing different uid one": {
+			ExistingObjectInSuper: []runtime.Object{
+				superVolumeSnapshot("snapshot-1", superDefaultNSName, "123456", defaultClusterKey),
+			},
+			VolumeSnapshot: tenantVolumeSnapshot("snapshot-1", "default", "12345"),
+			ExpectedError:  "delegated UID is different",
+		},
+		"new snapshot of a tenant content": {
+			VolumeSnapshot: fromContent,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			c, snapshotClient, _ := newTestController(t, tc.ExistingObjectInSuper, []client.Object{tc.VolumeSnapshot})
+			_, reconcileErr := c.Reconcile(reconciler.Request{
+				ClusterName:    defaultClusterKey,
+				NamespacedName: types.NamespacedName{Namespace: "default", Name: tc.VolumeSnapshot.Name},
+				UID:            string(tc.VolumeSnapshot.UID),
+			})
+			if tc.ExpectedError == "" && reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+			} else if tc.ExpectedError != "" && (reconcileErr == nil || !strings.Contains(reconcileErr.Error(), tc.ExpectedError)) {
+				t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+			}
+
+			var created []*snapshotv1.VolumeSnapshot
+			for _, action := range snapshotClient.Actions() {
+				if action.GetVerb() == "create" {
+					created = append(created, action.(core.CreateAction).GetObject().(*snapshotv1.VolumeSnapshot))
+				}
+			}
+			if !tc.ExpectedCreated {
+				if len(created) != 0 {
+					t.Errorf("expected no snapshot created, got %v", created)
+				}
+				return
+			}
+			if len(created) != 1 {
+				t.Fatalf("expected a snapshot created, got %v", created)
+			}
+			if created[0].Namespace != superDefaultNSName || created[0].Annotations[constants.LabelUID] != "12345" {
+				t.Errorf("unexpected created snapshot %+v", created[0])
+			}
+			if *created[0].Spec.Source.PersistentVolumeClaimName != "pvc-1" || *created[0].Spec.VolumeSnapshotClassName != "csi-snapclass" {
+				t.Errorf("expected the source and the class to be kept, got %+v", created[0].Spec)
+			}
+		})
+	}
+}
+
+func TestDWVolumeSnapshotDeletion(t *testing.T) {
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper []runtime.Object
+		EnqueuedUID           string
+		ExpectedDeleted       bool
+		ExpectedError         string
+	}{
+		"delete snapshot": {
+			ExistingObjectInSuper: []runtime.Object{
+				superVolumeSnapshot("snapshot-1", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			EnqueuedUID:     "12345",
+			ExpectedDeleted: true,
+		},
+		"delete snapshot with different uid": {
+			ExistingObjectInSuper: []runtime.Object{
+				superVolumeSnapshot("snapshot-1", superDefaultNSName, "123456", defaultClusterKey),
+			},
+			EnqueuedUID:   "12345",
+			ExpectedError: "delegated UID is different",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			c, snapshotClient, _ := newTestController(t, tc.ExistingObjectInSuper, nil)
+			_, reconcileErr := c.Reconcile(reconciler.Request{
+				ClusterName:    defaultClusterKey,
+				NamespacedName: types.NamespacedName{Namespace: "default", Name: "snapshot-1"},
+				UID:            tc.EnqueuedUID,
+			})
+			if tc.ExpectedError == "" && reconcileErr != nil {
+				t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+			} else if tc.ExpectedError != "" && (reconcileErr == nil || !strings.Contains(reconcileErr.Error(), tc.ExpectedError)) {
+				t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+			}
+
+			deleted := false
+			for _, action := range snapshotClient.Actions() {
+				if action.GetVerb() == "delete" && action.(core.DeleteAction).GetName() == "snapshot-1" {
+					deleted = true
+				}
+			}
+			if deleted != tc.ExpectedDeleted {
+				t.Errorf("expected deleted %v, got %v", tc.ExpectedDeleted, deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+)
+
+// StartUWS starts the upward syncer
+// and blocks until an empty struct is sent to the stop channel.
+func (c *controller) StartUWS(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.snapshotSynced) {
+		return fmt.Errorf("failed to wait for caches to sync volumesnapshot")
+	}
+	return c.UpwardController.Start(stopCh)
+}
+
+// BackPopulate copies the status of the super control plane snapshot, i.e. its readiness, restore
+// size and bound content, to the tenant control plane snapshot.
+func (c *controller) BackPopulate(key string) error {
+	pNamespace, pName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key %v: %v", key, err))
+		return nil
+	}
+
+	pSnapshot, err := c.snapshotLister.VolumeSnapshots(pNamespace).Get(pName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	clusterName, vNamespace := conversion.GetVirtualOwner(pSnapshot)
+	if clusterName == "" {
+		// Snapshot does not belong to any tenant.
+		return nil
+	}
+
+	cluster := c.MultiClusterController.GetCluster(clusterName)
+	if cluster == nil {
+		return errors.NewClusterNotFound(clusterName)
+	}
+	tenantClient, err := cluster.GetDelegatingClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client from cluster %s config: %w", clusterName, err)
+	}
+
+	vSnapshot := &snapshotv1.VolumeSnapshot{}
+	if err := c.MultiClusterController.Get(clusterName, vNamespace, pName, vSnapshot); err != nil {
+		klog.Errorf("failed to get tenant cluster %s volumesnapshot %s/%s", clusterName, vNamespace, pName)
+		return err
+	}
+
+	updatedSnapshot := conversion.Equality(c.Config, nil).CheckUWVolumeSnapshotStatusEquality(pSnapshot, vSnapshot)
+	if updatedSnapshot != nil {
+		if err := tenantClient.Status().Update(context.TODO(), updatedSnapshot); err != nil {
+			klog.Errorf("failed to update tenant cluster %s volumesnapshot %s/%s, %v", clusterName, vNamespace, pName, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func TestUWVolumeSnapshotStatus(t *testing.T) {
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	restoreSize := resource.MustParse("1Gi")
+	status := &snapshotv1.VolumeSnapshotStatus{
+		BoundVolumeSnapshotContentName: pointer.StringPtr("snapcontent-12345"),
+		ReadyToUse:                     pointer.BoolPtr(true),
+		RestoreSize:                    &restoreSize,
+	}
+
+	readySnapshot := superVolumeSnapshot("snapshot-1", superDefaultNSName, "12345", defaultClusterKey)
+	readySnapshot.Status = status
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper []runtime.Object
+		ExpectedStatus        *snapshotv1.VolumeSnapshotStatus
+	}{
+		"ready snapshot": {
+			ExistingObjectInSuper: []runtime.Object{readySnapshot},
+			ExpectedStatus:        status,
+		},
+		"snapshot without status": {
+			ExistingObjectInSuper: []runtime.Object{superVolumeSnapshot("snapshot-1", superDefaultNSName, "12345", defaultClusterKey)},
+		},
+		"snapshot not found": {},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			c, _, tenantClient := newTestController(t, tc.ExistingObjectInSuper, []client.Object{tenantVolumeSnapshot("snapshot-1", "default", "12345")})
+			if err := c.BackPopulate(superDefaultNSName + "/snapshot-1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			vSnapshot := &snapshotv1.VolumeSnapshot{}
+			if err := tenantClient.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "snapshot-1"}, vSnapshot); err != nil {
+				t.Fatalf("unexpected error getting the tenant snapshot: %v", err)
+			}
+			if tc.ExpectedStatus == nil {
+				if vSnapshot.Status != nil {
+					t.Errorf("expected no status, got %+v", vSnapshot.Status)
+				}
+				return
+			}
+			if vSnapshot.Status == nil || *vSnapshot.Status.BoundVolumeSnapshotContentName != "snapcontent-12345" ||
+				!*vSnapshot.Status.ReadyToUse || vSnapshot.Status.RestoreSize.Cmp(restoreSize) != 0 {
+				t.Errorf("expected status %+v, got %+v", tc.ExpectedStatus, vSnapshot.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotclass
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+var numMissMatchedVolumeSnapshotClasses uint64
+
+func (c *controller) StartPatrol(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.classSynced) {
+		return fmt.Errorf("failed to wait for caches to sync before starting VolumeSnapshotClass checker")
+	}
+	c.Patroller.Start(stopCh)
+	return nil
+}
+
+// PatrollerDo check if VolumeSnapshotClass keeps consistency between super control plane and tenant control planes.
+func (c *controller) PatrollerDo() {
+	clusterNames := c.MultiClusterController.GetClusterNames()
+	if len(clusterNames) == 0 {
+		klog.V(5).Infof("super cluster has no tenant control planes, giving up periodic checker: %s", "volumesnapshotclass")
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	numMissMatchedVolumeSnapshotClasses = 0
+
+	for _, clusterName := range clusterNames {
+		wg.Add(1)
+		go func(clusterName string) {
+			defer wg.Done()
+			c.checkVolumeSnapshotClassOfTenantCluster(clusterName)
+		}(clusterName)
+	}
+	wg.Wait()
+
+	pClassList, err := c.classLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("error listing volumesnapshotclass from super control plane informer cache: %v", err)
+		return
+	}
+
+	for _, pClass := range pClassList {
+		if !publicVolumeSnapshotClass(pClass) {
+			continue
+		}
+		for _, clusterName := range clusterNames {
+			if err := c.MultiClusterController.Get(clusterName, "", pClass.Name, &snapshotv1.VolumeSnapshotClass{}); err != nil {
+				if apierrors.IsNotFound(err) {
+					metrics.CheckerRemedyStats.WithLabelValues("RequeuedSuperControlPlaneVolumeSnapshotClasses").Inc()
+					c.UpwardController.AddToQueue(clusterName + "/" + pClass.Name)
+				}
+				klog.Errorf("fail to get volumesnapshotclass from cluster %s: %v", clusterName, err)
+			}
+		}
+	}
+
+	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedVolumeSnapshotClasses").Set(float64(numMissMatchedVolumeSnapshotClasses))
+}
+
+func (c *controller) checkVolumeSnapshotClassOfTenantCluster(clusterName string) {
+	classList := &snapshotv1.VolumeSnapshotClassList{}
+	if err := c.MultiClusterController.List(clusterName, classList); err != nil {
+		klog.Errorf("error listing volumesnapshotclass from cluster %s informer cache: %v", clusterName, err)
+		return
+	}
+	klog.V(4).Infof("check volumesnapshotclass consistency in cluster %s", clusterName)
+
+	for i, vClass := range classList.Items {
+		pClass, err := c.classLister.Get(vClass.Name)
+		if apierrors.IsNotFound(err) {
+			// super control plane is the source of the truth for volumesnapshotclass object, delete tenant control plane obj
+			if !c.RemediationBudget().Take(clusterName) {
+				continue
+			}
+			cluster := c.MultiClusterController.GetCluster(clusterName)
+			if cluster == nil {
+				return
+			}
+			tenantClient, err := cluster.GetDelegatingClient()
+			if err != nil {
+				klog.Errorf("error getting cluster %s client: %v", clusterName, err)
+				continue
+			}
+			if err := tenantClient.Delete(context.TODO(), &classList.Items[i], client.PropagationPolicy(constants.DefaultDeletionPolicy)); err != nil {
+				klog.Errorf("error deleting volumesnapshotclass %v in cluster %s: %v", vClass.Name, clusterName, err)
+			} else {
+				metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanTenantVolumeSnapshotClasses").Inc()
+			}
+			continue
+		}
+
+		if err != nil {
+			klog.Errorf("failed to get pVolumeSnapshotClass %s from super control plane cache: %v", vClass.Name, err)
+			continue
+		}
+
+		updatedClass := conversion.Equality(nil, nil).CheckVolumeSnapshotClassEquality(pClass, &classList.Items[i])
+		if updatedClass != nil {
+			atomic.AddUint64(&numMissMatchedVolumeSnapshotClasses, 1)
+			klog.Warningf("spec of volumesnapshotclass %v diff in super&tenant control plane", vClass.Name)
+			if publicVolumeSnapshotClass(pClass) {
+				c.UpwardController.AddToQueue(clusterName + "/" + pClass.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotclass
+
+import (
+	"context"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotfake "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	snapshotinformers "github.com/kubernetes-csi/external-snapshotter/client/v4/informers/externalversions"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+)
+
+func TestVolumeSnapshotClassPatrol(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	notPublic := func(class *snapshotv1.VolumeSnapshotClass) {
+		class.Labels = nil
+	}
+	otherDriver := func(class *snapshotv1.VolumeSnapshotClass) {
+		class.Driver = "other.csi.k8s.io"
+	}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  *snapshotv1.VolumeSnapshotClass
+		ExistingObjectInTenant *snapshotv1.VolumeSnapshotClass
+
+		ExpectedDeletedVObject  bool
+		ExpectedRequeuedPObject int
+		ExpectedMissMatched     uint64
+	}{
+		"pClass exists, vClass exists": {
+			ExistingObjectInSuper:  makeVolumeSnapshotClass("csi-snapclass", "12345"),
+			ExistingObjectInTenant: makeVolumeSnapshotClass("csi-snapclass", "123456"),
+		},
+		"pClass exists, vClass does not exists": {
+			ExistingObjectInSuper:   makeVolumeSnapshotClass("csi-snapclass", "12345"),
+			ExpectedRequeuedPObject: 1,
+		},
+		"pClass not public, vClass does not exists": {
+			ExistingObjectInSuper: makeVolumeSnapshotClass("csi-snapclass", "12345", notPublic),
+		},
+		"vClass exists, pClass does not exists": {
+			ExistingObjectInTenant: makeVolumeSnapshotClass("csi-snapclass", "123456"),
+			ExpectedDeletedVObject: true,
+		},
+		"pClass exists, vClass exists with different spec": {
+			ExistingObjectInSuper:   makeVolumeSnapshotClass("csi-snapclass", "12345"),
+			ExistingObjectInTenant:  makeVolumeSnapshotClass("csi-snapclass", "123456", otherDriver),
+			ExpectedRequeuedPObject: 1,
+			ExpectedMissMatched:     1,
+		},
+		"pClass not public, vClass exists with different spec": {
+			ExistingObjectInSuper:  makeVolumeSnapshotClass("csi-snapclass", "12345", notPublic),
+			ExistingObjectInTenant: makeVolumeSnapshotClass("csi-snapclass", "123456", otherDriver),
+			ExpectedMissMatched:    1,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			informerFactory := snapshotinformers.NewSharedInformerFactory(snapshotfake.NewSimpleClientset(), 0)
+			c, err := newController(&config.SyncerConfiguration{}, informerFactory, manager.ResourceSyncerOptions{IsFake: true})
+			if err != nil {
+				t.Fatalf("error creating volumesnapshotclass controller: %v", err)
+			}
+			if tc.ExistingObjectInSuper != nil {
+				_ = informerFactory.Snapshot().V1().VolumeSnapshotClasses().Informer().GetStore().Add(tc.ExistingObjectInSuper)
+			}
+			builder := fakeClient.NewClientBuilder()
+			if tc.ExistingObjectInTenant != nil {
+				builder = builder.WithObjects(tc.ExistingObjectInTenant)
+			}
+			tenantClient := builder.Build()
+			c.GetListener().AddCluster(cluster.NewFakeTenantCluster(testTenant, fake.NewSimpleClientset(), tenantClient))
+
+			c.PatrollerDo()
+
+			if tc.ExistingObjectInTenant != nil {
+				err := tenantClient.Get(context.TODO(), client.ObjectKey{Name: "csi-snapclass"}, &snapshotv1.VolumeSnapshotClass{})
+				if deleted := apierrors.IsNotFound(err); deleted != tc.ExpectedDeletedVObject {
+					t.Errorf("expected vClass deleted %v, got %v", tc.ExpectedDeletedVObject, deleted)
+				}
+			}
+			if got := c.UpwardController.Queue.Len(); got != tc.ExpectedRequeuedPObject {
+				t.Errorf("expected %d requeued pClasses, got %d", tc.ExpectedRequeuedPObject, got)
+			}
+			if numMissMatchedVolumeSnapshotClasses != tc.ExpectedMissMatched {
+				t.Errorf("expected %d mismatched classes, got %d", tc.ExpectedMissMatched, numMissMatchedVolumeSnapshotClasses)
+			}
+		})
+	}
+}
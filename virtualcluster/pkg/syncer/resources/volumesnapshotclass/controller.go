@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotclass
+
+import (
+	"fmt"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotclient "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned"
+	snapshotinformers "github.com/kubernetes-csi/external-snapshotter/client/v4/informers/externalversions"
+	listersv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/listers/volumesnapshot/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+func init() {
+	// the tenant control plane clients decode the snapshot objects using the client-go scheme.
+	utilruntime.Must(snapshotv1.AddToScheme(scheme.Scheme))
+
+	plugin.SyncerResourceRegister.Register(&plugin.Registration{
+		ID: "volumesnapshotclass",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			return NewVolumeSnapshotClassController(ctx.Config.(*config.SyncerConfiguration), ctx.Client, ctx.Informer, ctx.VCClient, ctx.VCInformer, manager.ResourceSyncerOptions{})
+		},
+		Disable: true,
+	})
+}
+
+type controller struct {
+	manager.BaseResourceSyncer
+	// super control plane volumesnapshotclasses informer/lister/synced functions
+	informerFactory snapshotinformers.SharedInformerFactory
+	classLister     listersv1.VolumeSnapshotClassLister
+	classSynced     cache.InformerSynced
+}
+
+func NewVolumeSnapshotClassController(config *config.SyncerConfiguration,
+	client clientset.Interface,
+	informer informers.SharedInformerFactory,
+	vcClient vcclient.Interface,
+	vcInformer vcinformers.VirtualClusterInformer,
+	options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+	if config.RestConfig == nil {
+		return nil, fmt.Errorf("cannot get super control plane restful config")
+	}
+	snapshotClient, err := snapshotclient.NewForConfig(config.RestConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newController(config, snapshotinformers.NewSharedInformerFactory(snapshotClient, 0), options)
+}
+
+func newController(config *config.SyncerConfiguration,
+	informerFactory snapshotinformers.SharedInformerFactory,
+	options manager.ResourceSyncerOptions) (*controller, error) {
+	c := &controller{
+		BaseResourceSyncer: manager.BaseResourceSyncer{
+			Config: config,
+		},
+		informerFactory: informerFactory,
+	}
+
+	var err error
+	c.MultiClusterController, err = mc.NewMCController(&snapshotv1.VolumeSnapshotClass{}, &snapshotv1.VolumeSnapshotClassList{}, c, mc.WithOptions(options.MCOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	classInformer := informerFactory.Snapshot().V1().VolumeSnapshotClasses()
+	c.classLister = classInformer.Lister()
+	if options.IsFake {
+		c.classSynced = func() bool { return true }
+	} else {
+		c.classSynced = classInformer.Informer().HasSynced
+	}
+
+	c.UpwardController, err = uw.NewUWController(&snapshotv1.VolumeSnapshotClass{}, c, uw.WithOptions(options.UWOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Patroller, err = pa.NewPatroller(&snapshotv1.VolumeSnapshotClass{}, c, pa.WithOptions(options.PatrolOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	classInformer.Informer().AddEventHandler(
+		cache.FilteringResourceEventHandler{
+			FilterFunc: func(obj interface{}) bool {
+				switch t := obj.(type) {
+				case *snapshotv1.VolumeSnapshotClass:
+					return publicVolumeSnapshotClass(t)
+				case cache.DeletedFinalStateUnknown:
+					if e, ok := t.Obj.(*snapshotv1.VolumeSnapshotClass); ok {
+						return publicVolumeSnapshotClass(e)
+					}
+					utilruntime.HandleError(fmt.Errorf("unable to convert object %v to *snapshotv1.VolumeSnapshotClass", obj))
+					return false
+				default:
+					utilruntime.HandleError(fmt.Errorf("unable to handle object in super control plane volumesnapshotclass controller: %v", obj))
+					return false
+				}
+			},
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc: c.enqueueVolumeSnapshotClass,
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					newClass := newObj.(*snapshotv1.VolumeSnapshotClass)
+					oldClass := oldObj.(*snapshotv1.VolumeSnapshotClass)
+					if newClass.ResourceVersion != oldClass.ResourceVersion {
+						c.enqueueVolumeSnapshotClass(newObj)
+					}
+				},
+				DeleteFunc: c.enqueueVolumeSnapshotClass,
+			},
+		})
+	return c, nil
+}
+
+func publicVolumeSnapshotClass(e *snapshotv1.VolumeSnapshotClass) bool {
+	// We only backpopulate specific volumesnapshotclass to tenant control planes
+	return e.Labels[constants.PublicObjectKey] == "true"
+}
+
+func (c *controller) enqueueVolumeSnapshotClass(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %v: %v", obj, err))
+		return
+	}
+
+	clusterNames := c.MultiClusterController.GetClusterNames()
+	if len(clusterNames) == 0 {
+		klog.Infof("No tenant control planes, stop backpopulate volumesnapshotclass %v", key)
+		return
+	}
+
+	for _, clusterName := range clusterNames {
+		c.UpwardController.AddToQueue(clusterName + "/" + key)
+	}
+}
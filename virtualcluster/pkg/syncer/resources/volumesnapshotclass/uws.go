@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotclass
+
+import (
+	"context"
+	"fmt"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+// StartUWS starts the upward syncer
+// and blocks until an empty struct is sent to the stop channel.
+func (c *controller) StartUWS(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.classSynced) {
+		return fmt.Errorf("failed to wait for caches to sync volumesnapshotclass")
+	}
+	return c.UpwardController.Start(stopCh)
+}
+
+func (c *controller) BackPopulate(key string) error {
+	// The key format is clustername/className.
+	clusterName, className, _ := cache.SplitMetaNamespaceKey(key)
+
+	op := reconciler.AddEvent
+	pClass, err := c.classLister.Get(className)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		op = reconciler.DeleteEvent
+	}
+
+	cluster := c.MultiClusterController.GetCluster(clusterName)
+	if cluster == nil {
+		return errors.NewClusterNotFound(clusterName)
+	}
+	tenantClient, err := cluster.GetDelegatingClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client from cluster %s config: %v", clusterName, err)
+	}
+
+	vClass := &snapshotv1.VolumeSnapshotClass{}
+	if err := c.MultiClusterController.Get(clusterName, "", className, vClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			if op == reconciler.AddEvent {
+				// Available in super, hence create a new in tenant control plane
+				vClass := conversion.BuildVirtualVolumeSnapshotClass(clusterName, pClass)
+				if err := tenantClient.Create(context.TODO(), vClass); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return err
+	}
+
+	if op == reconciler.DeleteEvent {
+		if err := tenantClient.Delete(context.TODO(), vClass, client.PropagationPolicy(constants.DefaultDeletionPolicy)); err != nil {
+			return err
+		}
+	} else {
+		updatedClass := conversion.Equality(c.Config, nil).CheckVolumeSnapshotClassEquality(pClass, vClass)
+		if updatedClass != nil {
+			if err := tenantClient.Update(context.TODO(), updatedClass); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
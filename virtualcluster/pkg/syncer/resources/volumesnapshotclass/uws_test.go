@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotclass
+
+import (
+	"context"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotfake "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	snapshotinformers "github.com/kubernetes-csi/external-snapshotter/client/v4/informers/externalversions"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+)
+
+func makeVolumeSnapshotClass(name, uid string, mFuncs ...func(*snapshotv1.VolumeSnapshotClass)) *snapshotv1.VolumeSnapshotClass {
+	class := &snapshotv1.VolumeSnapshotClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			UID:    types.UID(uid),
+			Labels: map[string]string{constants.PublicObjectKey: "true"},
+		},
+		Driver:         "hostpath.csi.k8s.io",
+		DeletionPolicy: snapshotv1.VolumeSnapshotContentDelete,
+	}
+	for _, f := range mFuncs {
+		f(class)
+	}
+	return class
+}
+
+func TestUWVolumeSnapshotClass(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  *snapshotv1.VolumeSnapshotClass
+		ExistingObjectInTenant *snapshotv1.VolumeSnapshotClass
+		ExpectedDriver         string
+	}{
+		"pClass exists but vClass not found": {
+			ExistingObjectInSuper: makeVolumeSnapshotClass("csi-snapclass", "12345"),
+			ExpectedDriver:        "hostpath.csi.k8s.io",
+		},
+		"pClass and vClass differ": {
+			ExistingObjectInSuper: makeVolumeSnapshotClass("csi-snapclass", "12345"),
+			ExistingObjectInTenant: makeVolumeSnapshotClass("csi-snapclass", "123456", func(class *snapshotv1.VolumeSnapshotClass) {
+				class.Driver = "other.csi.k8s.io"
+			}),
+			ExpectedDriver: "hostpath.csi.k8s.io",
+		},
+		"pClass not found": {
+			ExistingObjectInTenant: makeVolumeSnapshotClass("csi-snapclass", "123456"),
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			informerFactory := snapshotinformers.NewSharedInformerFactory(snapshotfake.NewSimpleClientset(), 0)
+			c, err := newController(&config.SyncerConfiguration{}, informerFactory, manager.ResourceSyncerOptions{IsFake: true})
+			if err != nil {
+				t.Fatalf("error creating volumesnapshotclass controller: %v", err)
+			}
+			if tc.ExistingObjectInSuper != nil {
+				_ = informerFactory.Snapshot().V1().VolumeSnapshotClasses().Informer().GetStore().Add(tc.ExistingObjectInSuper)
+			}
+			builder := fakeClient.NewClientBuilder()
+			if tc.ExistingObjectInTenant != nil {
+				builder = builder.WithObjects(tc.ExistingObjectInTenant)
+			}
+			tenantClient := builder.Build()
+			c.GetListener().AddCluster(cluster.NewFakeTenantCluster(testTenant, fake.NewSimpleClientset(), tenantClient))
+
+			if err := c.BackPopulate(defaultClusterKey + "/csi-snapclass"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			vClass := &snapshotv1.VolumeSnapshotClass{}
+			err = tenantClient.Get(context.TODO(), client.ObjectKey{Name: "csi-snapclass"}, vClass)
+			if tc.ExpectedDriver == "" {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expected the class to be deleted, got %+v, %v", vClass, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected a class in tenant: %v", err)
+			}
+			if vClass.Driver != tc.ExpectedDriver {
+				t.Errorf("expected driver %s, got %s", tc.ExpectedDriver, vClass.Driver)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotcontent
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+)
+
+var numSpecMissMatchedVolumeSnapshotContents uint64
+
+func (c *controller) StartPatrol(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.contentSynced, c.snapshotSynced) {
+		return fmt.Errorf("failed to wait for caches to sync before starting VolumeSnapshotContent checker")
+	}
+	c.Patroller.Start(stopCh)
+	return nil
+}
+
+// PatrollerDo check if volume snapshot contents keep consistency between super control plane and tenant control planes.
+func (c *controller) PatrollerDo() {
+	clusterNames := c.MultiClusterController.GetClusterNames()
+	if len(clusterNames) == 0 {
+		klog.V(5).Infof("super cluster has no tenant control planes, giving up periodic checker: %s", "volumesnapshotcontent")
+		return
+	}
+
+	numSpecMissMatchedVolumeSnapshotContents = 0
+
+	pList, err := c.contentLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("error listing volumesnapshotcontent from super control plane informer cache: %v", err)
+		return
+	}
+	pSet := differ.NewDiffSet()
+	for _, p := range pList {
+		pSet.Insert(differ.ClusterObject{Object: p, Key: p.GetName()})
+	}
+
+	vSet := differ.NewDiffSet()
+	for _, cluster := range clusterNames {
+		vList := &snapshotv1.VolumeSnapshotContentList{}
+		if err := c.MultiClusterController.List(cluster, vList); err != nil {
+			klog.Errorf("error listing volumesnapshotcontent from cluster %s informer cache: %v", cluster, err)
+			continue
+		}
+
+		for i := range vList.Items {
+			if vList.Items[i].Annotations[constants.LabelUID] == "" {
+				// not created by the syncer.
+				continue
+			}
+			vSet.Insert(differ.ClusterObject{
+				Object:       &vList.Items[i],
+				OwnerCluster: cluster,
+				Key:          vList.Items[i].GetName(),
+			})
+		}
+	}
+
+	d := differ.HandlerFuncs{}
+	d.AddFunc = func(pObj differ.ClusterObject) {
+		c.UpwardController.AddToQueue(pObj.GetName())
+		metrics.CheckerRemedyStats.WithLabelValues("RequeuedSuperControlPlaneVolumeSnapshotContents").Inc()
+	}
+	d.UpdateFunc = func(pObj, vObj differ.ClusterObject) {
+		pContent := pObj.Object.(*snapshotv1.VolumeSnapshotContent)
+		vContent := vObj.Object.(*snapshotv1.VolumeSnapshotContent)
+
+		if vContent.Annotations[constants.LabelUID] != string(pContent.UID) {
+			d.OnDelete(vObj)
+			return
+		}
+
+		equality := conversion.Equality(c.Config, nil)
+		if equality.CheckUWVolumeSnapshotContentSpecEquality(&pContent.Spec, &vContent.Spec) != nil {
+			atomic.AddUint64(&numSpecMissMatchedVolumeSnapshotContents, 1)
+			klog.Warningf("spec of volumesnapshotcontent %v diff in super&tenant control plane %s", vContent.Name, vObj.GetOwnerCluster())
+			c.enqueueVolumeSnapshotContent(pContent)
+		} else if equality.CheckUWVolumeSnapshotContentStatusEquality(pContent, vContent) != nil {
+			klog.Warningf("status of volumesnapshotcontent %v diff in super&tenant control plane %s", vContent.Name, vObj.GetOwnerCluster())
+			c.enqueueVolumeSnapshotContent(pContent)
+		}
+	}
+	d.DeleteFunc = func(vObj differ.ClusterObject) {
+		cluster := c.MultiClusterController.GetCluster(vObj.GetOwnerCluster())
+		if cluster == nil {
+			return
+		}
+		tenantClient, err := cluster.GetDelegatingClient()
+		if err != nil {
+			klog.Errorf("error getting cluster %s client: %v", vObj.GetOwnerCluster(), err)
+			return
+		}
+		vContent := vObj.Object.(*snapshotv1.VolumeSnapshotContent)
+		opts := []client.DeleteOption{
+			client.PropagationPolicy(constants.DefaultDeletionPolicy),
+			client.Preconditions(*metav1.NewUIDPreconditions(string(vContent.UID))),
+		}
+		if err := tenantClient.Delete(context.TODO(), vContent, opts...); err != nil {
+			klog.Errorf("error deleting volumesnapshotcontent %v in cluster %s: %v", vContent.Name, vObj.GetOwnerCluster(), err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanTenantVolumeSnapshotContents").Inc()
+		}
+	}
+
+	pSet.Difference(vSet, differ.FilteringHandler{
+		Handler: differ.FreezeHandler{Frozen: c.IsFrozen, Handler: differ.BudgetHandler{Budget: c.RemediationBudget(), Handler: d}},
+		FilterFunc: func(obj differ.ClusterObject) bool {
+			if obj.OwnerCluster != "" {
+				return true
+			}
+
+			pContent := obj.Object.(*snapshotv1.VolumeSnapshotContent)
+			if !boundVolumeSnapshotContent(pContent) || pContent.Status == nil || pContent.Status.SnapshotHandle == nil {
+				return false
+			}
+			pSnapshot, err := c.snapshotLister.VolumeSnapshots(pContent.Spec.VolumeSnapshotRef.Namespace).Get(pContent.Spec.VolumeSnapshotRef.Name)
+			if err != nil {
+				return false
+			}
+			clusterName, _ := conversion.GetVirtualOwner(pSnapshot)
+			return clusterName != ""
+		},
+	})
+
+	metrics.CheckerMissMatchStats.WithLabelValues("SpecMissMatchedVolumeSnapshotContents").Set(float64(numSpecMissMatchedVolumeSnapshotContents))
+}
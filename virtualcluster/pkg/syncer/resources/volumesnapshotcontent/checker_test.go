@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotcontent
+
+import (
+	"context"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotfake "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	snapshotinformers "github.com/kubernetes-csi/external-snapshotter/client/v4/informers/externalversions"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+)
+
+func TestVolumeSnapshotContentPatrol(t *testing.T) {
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	pSnapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "snapshot-1",
+			Namespace: superDefaultNSName,
+			Annotations: map[string]string{
+				constants.LabelUID:       "12345",
+				constants.LabelCluster:   defaultClusterKey,
+				constants.LabelNamespace: "default",
+			},
+		},
+	}
+	vSnapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "snapshot-1",
+			Namespace: "default",
+			UID:       "12345",
+		},
+	}
+	pContent := superContent("content-1", "content-uid", superDefaultNSName, "snapshot-1", pointer.StringPtr("handle-1"))
+	vContent := conversion.BuildVirtualVolumeSnapshotContent(pContent, vSnapshot)
+	notReady := vContent.DeepCopy()
+	notReady.Status.ReadyToUse = pointer.BoolPtr(false)
+	otherUID := vContent.DeepCopy()
+	otherUID.Annotations[constants.LabelUID] = "other-uid"
+	notSynced := vContent.DeepCopy()
+	notSynced.Annotations = nil
+
+	testcases := map[string]struct {
+		ExistingContentInSuper  *snapshotv1.VolumeSnapshotContent
+		ExistingContentInTenant *snapshotv1.VolumeSnapshotContent
+
+		ExpectedDeletedVObject  bool
+		ExpectedRequeuedPObject int
+		ExpectedMissMatched     uint64
+	}{
+		"pContent exists, vContent exists": {
+			ExistingContentInSuper:  pContent,
+			ExistingContentInTenant: vContent,
+		},
+		"pContent exists, vContent does not exists": {
+			ExistingContentInSuper:  pContent,
+			ExpectedRequeuedPObject: 1,
+		},
+		"pContent not taken yet, vContent does not exists": {
+			ExistingContentInSuper: superContent("content-1", "content-uid", superDefaultNSName, "snapshot-1", nil),
+		},
+		"pContent of another namespace, vContent does not exists": {
+			ExistingContentInSuper: superContent("content-1", "content-uid", "kube-system", "snapshot-1", pointer.StringPtr("handle-1")),
+		},
+		"pContent exists, vContent exists with different status": {
+			ExistingContentInSuper:  pContent,
+			ExistingContentInTenant: notReady,
+			ExpectedRequeuedPObject: 1,
+		},
+		"pContent exists, vContent exists with different spec": {
+			ExistingContentInSuper: pContent,
+			ExistingContentInTenant: func() *snapshotv1.VolumeSnapshotContent {
+				content := vContent.DeepCopy()
+				content.Spec.DeletionPolicy = snapshotv1.VolumeSnapshotContentRetain
+				return content
+			}(),
+			ExpectedRequeuedPObject: 1,
+			ExpectedMissMatched:     1,
+		},
+		"pContent exists, vContent exists with different uid": {
+			ExistingContentInSuper:  pContent,
+			ExistingContentInTenant: otherUID,
+			ExpectedDeletedVObject:  true,
+		},
+		"vContent exists, pContent does not exists": {
+			ExistingContentInTenant: vContent,
+			ExpectedDeletedVObject:  true,
+		},
+		"vContent not created by the syncer exists, pContent does not exists": {
+			ExistingContentInTenant: notSynced,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			informerFactory := snapshotinformers.NewSharedInformerFactory(snapshotfake.NewSimpleClientset(), 0)
+			c, err := newController(&config.SyncerConfiguration{}, informerFactory, manager.ResourceSyncerOptions{IsFake: true})
+			if err != nil {
+				t.Fatalf("error creating volumesnapshotcontent controller: %v", err)
+			}
+			_ = informerFactory.Snapshot().V1().VolumeSnapshots().Informer().GetStore().Add(pSnapshot)
+			if tc.ExistingContentInSuper != nil {
+				_ = informerFactory.Snapshot().V1().VolumeSnapshotContents().Informer().GetStore().Add(tc.ExistingContentInSuper)
+			}
+			tenantObjects := []client.Object{vSnapshot.DeepCopy()}
+			if tc.ExistingContentInTenant != nil {
+				tenantObjects = append(tenantObjects, tc.ExistingContentInTenant.DeepCopy())
+			}
+			tenantClient := fakeClient.NewClientBuilder().WithObjects(tenantObjects...).Build()
+			c.GetListener().AddCluster(cluster.NewFakeTenantCluster(testTenant, fake.NewSimpleClientset(), tenantClient))
+
+			c.PatrollerDo()
+
+			if tc.ExistingContentInTenant != nil {
+				err := tenantClient.Get(context.TODO(), client.ObjectKey{Name: "content-1"}, &snapshotv1.VolumeSnapshotContent{})
+				if deleted := apierrors.IsNotFound(err); deleted != tc.ExpectedDeletedVObject {
+					t.Errorf("expected vContent deleted %v, got %v", tc.ExpectedDeletedVObject, deleted)
+				}
+			}
+			if got := c.UpwardController.Queue.Len(); got != tc.ExpectedRequeuedPObject {
+				t.Errorf("expected %d requeued pContents, got %d", tc.ExpectedRequeuedPObject, got)
+			}
+			if numSpecMissMatchedVolumeSnapshotContents != tc.ExpectedMissMatched {
+				t.Errorf("expected %d mismatched contents, got %d", tc.ExpectedMissMatched, numSpecMissMatchedVolumeSnapshotContents)
+			}
+		})
+	}
+}
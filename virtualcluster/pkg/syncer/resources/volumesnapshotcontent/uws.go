@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotcontent
+
+import (
+	"context"
+	"fmt"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+)
+
+// StartUWS starts the upward syncer
+// and blocks until an empty struct is sent to the stop channel.
+func (c *controller) StartUWS(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.contentSynced, c.snapshotSynced) {
+		return fmt.Errorf("failed to wait for caches to sync volumesnapshotcontent")
+	}
+	return c.UpwardController.Start(stopCh)
+}
+
+// BackPopulate creates or updates the tenant control plane counterpart of a super control plane
+// content bound to a tenant snapshot, once the snapshot is taken.
+func (c *controller) BackPopulate(key string) error {
+	pContent, err := c.contentLister.Get(key)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !boundVolumeSnapshotContent(pContent) {
+		return nil
+	}
+
+	pSnapshot, err := c.snapshotLister.VolumeSnapshots(pContent.Spec.VolumeSnapshotRef.Namespace).Get(pContent.Spec.VolumeSnapshotRef.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// Bound snapshot is gone, we cannot find the tenant who owns the content. Checker will fix any possible race.
+			return nil
+		}
+		return err
+	}
+
+	clusterName, vNamespace := conversion.GetVirtualOwner(pSnapshot)
+	if clusterName == "" {
+		// Bound snapshot does not belong to any tenant.
+		return nil
+	}
+
+	cluster := c.MultiClusterController.GetCluster(clusterName)
+	if cluster == nil {
+		return errors.NewClusterNotFound(clusterName)
+	}
+	tenantClient, err := cluster.GetDelegatingClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client from cluster %s config: %w", clusterName, err)
+	}
+
+	vContent := &snapshotv1.VolumeSnapshotContent{}
+	if err := c.MultiClusterController.Get(clusterName, "", key, vContent); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if pContent.Status == nil || pContent.Status.SnapshotHandle == nil {
+			// The snapshot is not taken yet, the content will be requeued once it is.
+			return nil
+		}
+		// Create a new content with bound snapshot in tenant control plane
+		vSnapshot := &snapshotv1.VolumeSnapshot{}
+		if err := tenantClient.Get(context.TODO(), client.ObjectKey{Namespace: vNamespace, Name: pSnapshot.Name}, vSnapshot); err != nil {
+			// If corresponding snapshot does not exist in tenant, we'll let checker fix any possible race.
+			klog.Errorf("Cannot find the bound volumesnapshot %s/%s in tenant cluster %s for volumesnapshotcontent %s", vNamespace, pSnapshot.Name, clusterName, key)
+			return nil
+		}
+		vContent = conversion.BuildVirtualVolumeSnapshotContent(pContent, vSnapshot)
+		status := vContent.Status
+		if err := tenantClient.Create(context.TODO(), vContent); err != nil {
+			return err
+		}
+		// The status is not persisted on creation.
+		vContent.Status = status
+		return tenantClient.Status().Update(context.TODO(), vContent)
+	}
+
+	if vContent.Annotations[constants.LabelUID] != string(pContent.UID) {
+		return fmt.Errorf("vContent %s in cluster %s delegated UID is different from pContent", vContent.Name, clusterName)
+	}
+
+	updatedSpec := conversion.Equality(c.Config, nil).CheckUWVolumeSnapshotContentSpecEquality(&pContent.Spec, &vContent.Spec)
+	if updatedSpec != nil {
+		vContent = vContent.DeepCopy()
+		vContent.Spec = *updatedSpec
+		if err := tenantClient.Update(context.TODO(), vContent); err != nil {
+			return err
+		}
+	}
+
+	updatedContent := conversion.Equality(c.Config, nil).CheckUWVolumeSnapshotContentStatusEquality(pContent, vContent)
+	if updatedContent != nil {
+		if err := tenantClient.Status().Update(context.TODO(), updatedContent); err != nil {
+			klog.Errorf("failed to update tenant cluster %s volumesnapshotcontent %s status, %v", clusterName, key, err)
+			return err
+		}
+	}
+	return nil
+}
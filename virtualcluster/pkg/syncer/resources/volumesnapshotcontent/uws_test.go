@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshotcontent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotfake "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	snapshotinformers "github.com/kubernetes-csi/external-snapshotter/client/v4/informers/externalversions"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+)
+
+var testTenant = &v1alpha1.VirtualCluster{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "test",
+		Namespace: "tenant-1",
+		UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+	},
+	Status: v1alpha1.VirtualClusterStatus{
+		Phase: v1alpha1.ClusterRunning,
+	},
+}
+
+func superContent(name, uid, snapshotNamespace, snapshotName string, snapshotHandle *string) *snapshotv1.VolumeSnapshotContent {
+	content := &snapshotv1.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			UID:  types.UID(uid),
+		},
+		Spec: snapshotv1.VolumeSnapshotContentSpec{
+			VolumeSnapshotRef: corev1.ObjectReference{
+				Kind:      "VolumeSnapshot",
+				Namespace: snapshotNamespace,
+				Name:      snapshotName,
+				UID:       "super-snapshot-uid",
+			},
+			DeletionPolicy:          snapshotv1.VolumeSnapshotContentDelete,
+			Driver:                  "hostpath.csi.k8s.io",
+			VolumeSnapshotClassName: pointer.StringPtr("csi-snapclass"),
+			Source: snapshotv1.VolumeSnapshotContentSource{
+				VolumeHandle: pointer.StringPtr("volume-1"),
+			},
+		},
+	}
+	if snapshotHandle != nil {
+		content.Status = &snapshotv1.VolumeSnapshotContentStatus{
+			SnapshotHandle: snapshotHandle,
+			ReadyToUse:     pointer.BoolPtr(true),
+			RestoreSize:    pointer.Int64Ptr(1024),
+		}
+	}
+	return content
+}
+
+func TestUWVolumeSnapshotContent(t *testing.T) {
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	pSnapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "snapshot-1",
+			Namespace: superDefaultNSName,
+			Annotations: map[string]string{
+				constants.LabelUID:       "12345",
+				constants.LabelCluster:   defaultClusterKey,
+				constants.LabelNamespace: "default",
+			},
+		},
+	}
+	vSnapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "snapshot-1",
+			Namespace: "default",
+			UID:       "12345",
+		},
+	}
+	existingContent := conversion.BuildVirtualVolumeSnapshotContent(superContent("content-1", "content-uid", superDefaultNSName, "snapshot-1", pointer.StringPtr("handle-1")), vSnapshot)
+	existingContent.Status.ReadyToUse = pointer.BoolPtr(false)
+	otherContent := existingContent.DeepCopy()
+	otherContent.Annotations[constants.LabelUID] = "other-uid"
+
+	testcases := map[string]struct {
+		ExistingContentInSuper  *snapshotv1.VolumeSnapshotContent
+		ExistingContentInTenant *snapshotv1.VolumeSnapshotContent
+		ExpectedContent         bool
+		ExpectedError           string
+	}{
+		"snapshot taken": {
+			ExistingContentInSuper: superContent("content-1", "content-uid", superDefaultNSName, "snapshot-1", pointer.StringPtr("handle-1")),
+			ExpectedContent:        true,
+		},
+		"snapshot not taken yet": {
+			ExistingContentInSuper: superContent("content-1", "content-uid", superDefaultNSName, "snapshot-1", nil),
+		},
+		"snapshot of another namespace": {
+			ExistingContentInSuper: superContent("content-1", "content-uid", "kube-system", "snapshot-1", pointer.StringPtr("handle-1")),
+		},
+		"status updated": {
+			ExistingContentInSuper:  superContent("content-1", "content-uid", superDefaultNSName, "snapshot-1", pointer.StringPtr("handle-1")),
+			ExistingContentInTenant: existingContent,
+			ExpectedContent:         true,
+		},
+		"content with different uid": {
+			ExistingContentInSuper:  superContent("content-1", "content-uid", superDefaultNSName, "snapshot-1", pointer.StringPtr("handle-1")),
+			ExistingContentInTenant: otherContent,
+			ExpectedError:           "delegated UID is different",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			snapshotClient := snapshotfake.NewSimpleClientset()
+			informerFactory := snapshotinformers.NewSharedInformerFactory(snapshotClient, 0)
+			c, err := newController(&config.SyncerConfiguration{}, informerFactory, manager.ResourceSyncerOptions{IsFake: true})
+			if err != nil {
+				t.Fatalf("error creating volumesnapshotcontent controller: %v", err)
+			}
+			_ = informerFactory.Snapshot().V1().VolumeSnapshots().Informer().GetStore().Add(pSnapshot)
+			_ = informerFactory.Snapshot().V1().VolumeSnapshotContents().Informer().GetStore().Add(tc.ExistingContentInSuper)
+
+			tenantObjects := []client.Object{vSnapshot.DeepCopy()}
+			if tc.ExistingContentInTenant != nil {
+				tenantObjects = append(tenantObjects, tc.ExistingContentInTenant.DeepCopy())
+			}
+			tenantClient := fakeClient.NewClientBuilder().WithObjects(tenantObjects...).Build()
+			c.GetListener().AddCluster(cluster.NewFakeTenantCluster(testTenant, fake.NewSimpleClientset(), tenantClient))
+
+			err = c.BackPopulate("content-1")
+			if tc.ExpectedError == "" && err != nil {
+				t.Errorf("expected no error, but got \"%v\"", err)
+			} else if tc.ExpectedError != "" && (err == nil || !strings.Contains(err.Error(), tc.ExpectedError)) {
+				t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, err)
+			}
+
+			vContent := &snapshotv1.VolumeSnapshotContent{}
+			err = tenantClient.Get(context.TODO(), client.ObjectKey{Name: "content-1"}, vContent)
+			if !tc.ExpectedContent {
+				if tc.ExistingContentInTenant == nil && !apierrors.IsNotFound(err) {
+					t.Errorf("expected no content in tenant, got %+v, %v", vContent, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected a content in tenant: %v", err)
+			}
+			if vContent.Annotations[constants.LabelUID] != "content-uid" {
+				t.Errorf("expected the content to be annotated with the uid of the super one, got %v", vContent.Annotations)
+			}
+			ref := vContent.Spec.VolumeSnapshotRef
+			if ref.Namespace != "default" || ref.Name != "snapshot-1" || ref.UID != "12345" {
+				t.Errorf("expected the content to be bound to the tenant snapshot, got %+v", ref)
+			}
+			if vContent.Spec.Source.SnapshotHandle == nil || *vContent.Spec.Source.SnapshotHandle != "handle-1" || vContent.Spec.Source.VolumeHandle != nil {
+				t.Errorf("expected the content to be pre-provisioned from the snapshot handle, got %+v", vContent.Spec.Source)
+			}
+			if vContent.Status == nil || !*vContent.Status.ReadyToUse || *vContent.Status.RestoreSize != 1024 {
+				t.Errorf("expected the status to be copied, got %+v", vContent.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resync
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Class is the resync priority class of a resource syncer.
+type Class string
+
+const (
+	// Interactive resource syncers are started right away, their resources, e.g. pods and services,
+	// are the ones the tenants wait for.
+	Interactive Class = "Interactive"
+	// Bulk resource syncers are started once the interactive ones converged after the initial sync.
+	Bulk Class = "Bulk"
+)
+
+const (
+	// DefaultMaxDelay is the default maximum time the bulk resource syncers are held back.
+	DefaultMaxDelay = 5 * time.Minute
+
+	// pollPeriod is the period of checking whether the interactive resource syncers converged.
+	pollPeriod = time.Second
+)
+
+// DefaultBulkResources are the plugins that are synced after the interactive ones by default.
+var DefaultBulkResources = []string{"configmap", "crd", "event", "ingress", "persistentvolume", "priorityclass", "storageclass"}
+
+// Queue is the work queue of an interactive resource syncer.
+type Queue interface {
+	Len() int
+}
+
+// Gate holds back the bulk resource syncers during the initial sync, i.e. until the caches of the
+// tenant clusters found at startup are synced and the queues of the interactive resource syncers
+// are drained, or until the maximum delay elapsed.
+type Gate struct {
+	initialSynced func() bool
+	maxDelay      time.Duration
+
+	once sync.Once
+	open chan struct{}
+}
+
+// NewGate returns a closed Gate, initialSynced returns true once the caches of the tenant clusters
+// found at startup are synced.
+func NewGate(initialSynced func() bool, maxDelay time.Duration) *Gate {
+	if maxDelay == 0 {
+		maxDelay = DefaultMaxDelay
+	}
+	return &Gate{
+		initialSynced: initialSynced,
+		maxDelay:      maxDelay,
+		open:          make(chan struct{}),
+	}
+}
+
+// Run opens the gate once the initial sync is done and the queues are empty at two consecutive
+// polls, so that the events of a cache synced just before the first poll are queued.
+func (g *Gate) Run(queues []Queue, stop <-chan struct{}) {
+	start := time.Now()
+	deadline := time.NewTimer(g.maxDelay)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollPeriod)
+	defer ticker.Stop()
+
+	drained := false
+	for {
+		select {
+		case <-stop:
+			return
+		case <-deadline.C:
+			klog.Warningf("interactive resource syncers did not converge in %v, starting the bulk resource syncers", g.maxDelay)
+			g.Open()
+			return
+		case <-ticker.C:
+			if !g.initialSynced() || !empty(queues) {
+				drained = false
+				continue
+			}
+			if drained {
+				klog.Infof("interactive resource syncers converged in %v, starting the bulk resource syncers", time.Since(start))
+				g.Open()
+				return
+			}
+			drained = true
+		}
+	}
+}
+
+// Open opens the gate.
+func (g *Gate) Open() {
+	g.once.Do(func() { close(g.open) })
+}
+
+// Wait blocks until the gate is open, it returns false if stop is closed first.
+func (g *Gate) Wait(stop <-chan struct{}) bool {
+	select {
+	case <-g.open:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+func empty(queues []Queue) bool {
+	for _, q := range queues {
+		if q.Len() > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resync
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeQueue struct {
+	len int32
+}
+
+func (q *fakeQueue) Len() int {
+	return int(atomic.LoadInt32(&q.len))
+}
+
+func isOpen(g *Gate) bool {
+	select {
+	case <-g.open:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestGate(t *testing.T) {
+	var synced int32
+	queue := &fakeQueue{len: 10}
+	g := NewGate(func() bool { return atomic.LoadInt32(&synced) == 1 }, time.Minute)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go g.Run([]Queue{queue}, stop)
+
+	time.Sleep(3 * pollPeriod)
+	if isOpen(g) {
+		t.Fatalf("expected the gate to be closed before the initial sync")
+	}
+
+	atomic.StoreInt32(&synced, 1)
+	time.Sleep(3 * pollPeriod)
+	if isOpen(g) {
+		t.Fatalf("expected the gate to be closed while the queue is not empty")
+	}
+
+	atomic.StoreInt32(&queue.len, 0)
+	time.Sleep(3 * pollPeriod)
+	if !isOpen(g) {
+		t.Fatalf("expected the gate to be open once the queue is drained")
+	}
+}
+
+func TestGateMaxDelay(t *testing.T) {
+	g := NewGate(func() bool { return false }, 2*pollPeriod)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go g.Run(nil, stop)
+
+	if !g.Wait(stop) {
+		t.Fatalf("expected the gate to be open")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package split assigns the resources of a tenant cluster to several syncer instances, so that a
+// tenant whose load exceeds the throughput of a single syncer, e.g. its pod churn, is synced by
+// multiple deployments. For example, with
+//
+//	syncer-pods:    --tenant-resource-split=default/huge=pod --sync-split-tenants-only
+//	syncer-default: --tenant-resource-split=default/huge=-pod
+//
+// the pods of virtual cluster default/huge are synced by syncer-pods, and all the other resources of
+// default/huge and all the resources of the other virtual clusters are synced by syncer-default.
+package split
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+)
+
+// excludePrefix marks the resources a syncer instance does not sync for a tenant.
+const excludePrefix = "-"
+
+// Parse parses a list of <vc namespace>/<vc name>=<resource> pairs, where resource is a syncer plugin
+// name, e.g. pod, or a plugin name prefixed with "-" to sync all the other resources of the virtual
+// cluster. The resources of a virtual cluster are either all included or all excluded.
+func Parse(pairs []string) (map[string][]string, error) {
+	split := make(map[string][]string)
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[1] == "" || strings.TrimPrefix(kv[1], excludePrefix) == "" {
+			return nil, fmt.Errorf("invalid tenant resource split %q, expected <vc namespace>/<vc name>=[-]<resource>", pair)
+		}
+		if namespace, name, err := cache.SplitMetaNamespaceKey(kv[0]); err != nil || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid tenant resource split %q, expected <vc namespace>/<vc name>=[-]<resource>", pair)
+		}
+		split[kv[0]] = append(split[kv[0]], kv[1])
+	}
+	for key, resources := range split {
+		excluded := 0
+		for _, r := range resources {
+			if strings.HasPrefix(r, excludePrefix) {
+				excluded++
+			}
+		}
+		if excluded != 0 && excluded != len(resources) {
+			return nil, fmt.Errorf("the resources of virtual cluster %s are both included and excluded", key)
+		}
+	}
+	return split, nil
+}
+
+// SyncsCluster returns whether the syncer instance syncs any resource of the virtual cluster of the
+// given namespace/name key. If splitOnly is set, only the virtual clusters of the split are synced.
+func SyncsCluster(split map[string][]string, splitOnly bool, key string) bool {
+	if _, ok := split[key]; ok {
+		return true
+	}
+	return !splitOnly
+}
+
+// SyncsResource returns whether the syncer instance syncs the resource, by plugin name, of the virtual
+// cluster of the given namespace/name key.
+func SyncsResource(split map[string][]string, splitOnly bool, key, resource string) bool {
+	resources, ok := split[key]
+	if !ok {
+		return !splitOnly
+	}
+	set := sets.NewString(resources...)
+	if strings.HasPrefix(resources[0], excludePrefix) {
+		return !set.Has(excludePrefix + resource)
+	}
+	return set.Has(resource)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package split
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		pairs     []string
+		expectErr bool
+	}{
+		{name: "included", pairs: []string{"default/huge=pod", "default/huge=service"}},
+		{name: "excluded", pairs: []string{"default/huge=-pod"}},
+		{name: "missing namespace", pairs: []string{"huge=pod"}, expectErr: true},
+		{name: "missing resource", pairs: []string{"default/huge="}, expectErr: true},
+		{name: "missing excluded resource", pairs: []string{"default/huge=-"}, expectErr: true},
+		{name: "included and excluded", pairs: []string{"default/huge=pod", "default/huge=-service"}, expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(tc.pairs); (err != nil) != tc.expectErr {
+				t.Errorf("expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestSyncsResource(t *testing.T) {
+	pods, err := Parse([]string{"default/huge=pod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	others, err := Parse([]string{"default/huge=-pod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		split     map[string][]string
+		splitOnly bool
+		key       string
+		resource  string
+		expected  bool
+	}{
+		{name: "no split", split: nil, key: "default/huge", resource: "pod", expected: true},
+		{name: "included resource", split: pods, splitOnly: true, key: "default/huge", resource: "pod", expected: true},
+		{name: "not included resource", split: pods, splitOnly: true, key: "default/huge", resource: "service", expected: false},
+		{name: "other cluster of split only instance", split: pods, splitOnly: true, key: "default/small", resource: "pod", expected: false},
+		{name: "excluded resource", split: others, key: "default/huge", resource: "pod", expected: false},
+		{name: "not excluded resource", split: others, key: "default/huge", resource: "service", expected: true},
+		{name: "other cluster", split: others, key: "default/small", resource: "pod", expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SyncsResource(tc.split, tc.splitOnly, tc.key, tc.resource); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package summary mirrors the state of the VirtualClusters synced by the syncer into the
+// VirtualClusterSummary objects of the meta cluster, so that the object counts, health,
+// placement and usage of a VirtualCluster can be read with kubectl instead of being joined
+// from the metrics and annotations of every super cluster.
+package summary
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vclisters "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/listers/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/reporting"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+// DefaultInterval is the default interval between two updates of the summaries.
+const DefaultInterval = 5 * time.Minute
+
+// staleIntervals is the number of intervals after which the summary of a super cluster is
+// dropped, its syncer is gone or doesn't sync the VirtualCluster anymore.
+const staleIntervals = 3
+
+// countedResources are the super cluster resources whose synced objects are counted, by the id
+// of the plugin syncing them.
+var countedResources = map[string]string{
+	"pod":                   "pods",
+	"service":               "services",
+	"configmap":             "configmaps",
+	"secret":                "secrets",
+	"persistentvolumeclaim": "persistentvolumeclaims",
+	"serviceaccount":        "serviceaccounts",
+}
+
+// ReachableFunc returns whether the tenant apiserver of the cluster answered the last health check.
+type ReachableFunc func(clusterName string) bool
+
+// Writer periodically writes the summary of the super cluster into the VirtualClusterSummary of
+// every VirtualCluster watched by the syncer.
+type Writer struct {
+	vcClient   vcclient.Interface
+	vcLister   vclisters.VirtualClusterLister
+	namespaces cache.GenericLister
+	objects    map[string]cache.GenericLister
+	reporter   *reporting.Reporter
+	reachable  ReachableFunc
+	interval   time.Duration
+	now        func() time.Time
+
+	mu       sync.Mutex
+	clusters map[string]mc.ClusterInterface
+}
+
+var _ listener.ClusterChangeListener = &Writer{}
+
+// NewWriter returns a Writer counting the objects synced by plugins in the super cluster informers,
+// usage can be nil to only summarize the requests and limits of the pods.
+func NewWriter(vcClient vcclient.Interface, vcLister vclisters.VirtualClusterLister, superInformers informers.SharedInformerFactory,
+	plugins []string, usage reporting.UsageGetter, reachable ReachableFunc, interval time.Duration) (*Writer, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	w := &Writer{
+		vcClient:  vcClient,
+		vcLister:  vcLister,
+		objects:   make(map[string]cache.GenericLister),
+		reporter:  reporting.NewReporter(superInformers.Core().V1().Pods().Lister(), usage, nil),
+		reachable: reachable,
+		interval:  interval,
+		now:       time.Now,
+		clusters:  make(map[string]mc.ClusterInterface),
+	}
+	namespaces, err := superInformers.ForResource(corev1.SchemeGroupVersion.WithResource("namespaces"))
+	if err != nil {
+		return nil, err
+	}
+	w.namespaces = namespaces.Lister()
+	for _, id := range plugins {
+		resource, ok := countedResources[id]
+		if !ok {
+			continue
+		}
+		informer, err := superInformers.ForResource(schema.GroupVersionResource{Version: "v1", Resource: resource})
+		if err != nil {
+			return nil, err
+		}
+		w.objects[resource] = informer.Lister()
+	}
+	return w, nil
+}
+
+func (w *Writer) AddCluster(cluster mc.ClusterInterface) {}
+
+func (w *Writer) RemoveCluster(cluster mc.ClusterInterface) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.clusters, cluster.GetClusterName())
+}
+
+func (w *Writer) WatchCluster(cluster mc.ClusterInterface) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.clusters[cluster.GetClusterName()] = cluster
+}
+
+// Run updates the summaries every interval until stopCh is closed.
+func (w *Writer) Run(stopCh <-chan struct{}) {
+	klog.Infof("starting virtual cluster summary writer with interval %v", w.interval)
+	defer klog.Infof("shutting down virtual cluster summary writer")
+
+	wait.Until(func() {
+		w.Write(context.TODO())
+	}, w.interval, stopCh)
+}
+
+// Write updates the summary of every watched VirtualCluster.
+func (w *Writer) Write(ctx context.Context) {
+	w.mu.Lock()
+	clusters := make([]mc.ClusterInterface, 0, len(w.clusters))
+	for _, c := range w.clusters {
+		clusters = append(clusters, c)
+	}
+	w.mu.Unlock()
+	if len(clusters) == 0 {
+		return
+	}
+
+	summaries, err := w.summarize(ctx)
+	if err != nil {
+		klog.Errorf("failed to summarize the virtual clusters: %v", err)
+		return
+	}
+	for _, c := range clusters {
+		entry, ok := summaries[c.GetClusterName()]
+		if !ok {
+			entry = &v1alpha1.SuperClusterSummary{}
+		}
+		entry.ID = utilconstants.SuperClusterID
+		entry.Reachable = w.reachable(c.GetClusterName())
+		entry.LastUpdateTime = metav1.NewTime(w.now())
+		if err := w.write(c, *entry); err != nil {
+			klog.Errorf("failed to write the summary of cluster %s: %v", c.GetClusterName(), err)
+		}
+	}
+}
+
+// summarize returns the summary of the super cluster by cluster name.
+func (w *Writer) summarize(ctx context.Context) (map[string]*v1alpha1.SuperClusterSummary, error) {
+	summaries := make(map[string]*v1alpha1.SuperClusterSummary)
+	get := func(obj interface{}) *v1alpha1.SuperClusterSummary {
+		o, err := meta.Accessor(obj)
+		if err != nil {
+			return nil
+		}
+		cluster := o.GetAnnotations()[constants.LabelCluster]
+		if cluster == "" {
+			return nil
+		}
+		s, ok := summaries[cluster]
+		if !ok {
+			s = &v1alpha1.SuperClusterSummary{}
+			summaries[cluster] = s
+		}
+		return s
+	}
+
+	namespaces, err := w.namespaces.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range namespaces {
+		if s := get(ns); s != nil {
+			s.Namespaces++
+		}
+	}
+	for resource, lister := range w.objects {
+		objs, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			if s := get(obj); s != nil {
+				if s.Objects == nil {
+					s.Objects = make(map[string]int32)
+				}
+				s.Objects[resource]++
+			}
+		}
+	}
+
+	records, err := w.reporter.Aggregate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if s, ok := summaries[record.Cluster]; ok {
+			s.Requests, s.Limits, s.Usage = record.Requests, record.Limits, record.Usage
+		}
+	}
+	return summaries, nil
+}
+
+// write creates or updates the VirtualClusterSummary of cluster with the entry of the super cluster.
+func (w *Writer) write(cluster mc.ClusterInterface, entry v1alpha1.SuperClusterSummary) error {
+	name, namespace, uid := cluster.GetOwnerInfo()
+	vc, err := w.vcLister.VirtualClusters(namespace).Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	summaries := w.vcClient.TenancyV1alpha1().VirtualClusterSummaries(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		summary, err := summaries.Get(name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			summary = &v1alpha1.VirtualClusterSummary{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: v1alpha1.SchemeGroupVersion.String(),
+						Kind:       "VirtualCluster",
+						Name:       name,
+						UID:        vc.UID,
+					}},
+				},
+			}
+			mergeStatus(&summary.Status, vc, entry, w.now().Add(-staleIntervals*w.interval))
+			_, err = summaries.Create(summary)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if len(summary.OwnerReferences) > 0 && string(summary.OwnerReferences[0].UID) != uid {
+			// the summary of a deleted VirtualCluster of the same name, the garbage collector deletes it.
+			return nil
+		}
+		mergeStatus(&summary.Status, vc, entry, w.now().Add(-staleIntervals*w.interval))
+		_, err = summaries.Update(summary)
+		return err
+	})
+}
+
+// mergeStatus replaces the entry of the super cluster in status, drops the entries of the other
+// super clusters not updated since staleBefore and recomputes the totals.
+func mergeStatus(status *v1alpha1.VirtualClusterSummaryStatus, vc *v1alpha1.VirtualCluster, entry v1alpha1.SuperClusterSummary, staleBefore time.Time) {
+	status.Phase = vc.Status.Phase
+	status.ControlPlaneHealthy = nil
+	if vc.Status.ControlPlaneHealthy != nil {
+		healthy := *vc.Status.ControlPlaneHealthy
+		status.ControlPlaneHealthy = &healthy
+	}
+
+	superClusters := []v1alpha1.SuperClusterSummary{entry}
+	for _, s := range status.SuperClusters {
+		if s.ID == entry.ID || s.LastUpdateTime.Time.Before(staleBefore) {
+			continue
+		}
+		superClusters = append(superClusters, s)
+	}
+	sort.Slice(superClusters, func(i, j int) bool {
+		return superClusters[i].ID < superClusters[j].ID
+	})
+	status.SuperClusters = superClusters
+
+	status.Namespaces, status.Pods = 0, 0
+	for _, s := range superClusters {
+		status.Namespaces += s.Namespaces
+		status.Pods += s.Objects["pods"]
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summary
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	vclisters "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/listers/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+)
+
+func superObjectMeta(name, namespace, cluster string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Annotations: map[string]string{constants.LabelCluster: cluster},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	defer func(id string) { utilconstants.SuperClusterID = id }(utilconstants.SuperClusterID)
+	utilconstants.SuperClusterID = "r1"
+
+	healthy := true
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1", UID: "vc-uid"},
+		Status:     v1alpha1.VirtualClusterStatus{Phase: v1alpha1.ClusterRunning, ControlPlaneHealthy: &healthy},
+	}
+	key := conversion.ToClusterKey(vc)
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	vcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := vcIndexer.Add(vc); err != nil {
+		t.Fatal(err)
+	}
+	superInformers := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+
+	for _, tc := range []struct {
+		name          string
+		existing      *v1alpha1.VirtualClusterSummary
+		expectIDs     []string
+		expectNSTotal int32
+	}{
+		{
+			name:          "create",
+			expectIDs:     []string{"r1"},
+			expectNSTotal: 1,
+		},
+		{
+			name: "merge with the other super clusters",
+			existing: &v1alpha1.VirtualClusterSummary{
+				ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "tenant-1"},
+				Status: v1alpha1.VirtualClusterSummaryStatus{
+					SuperClusters: []v1alpha1.SuperClusterSummary{
+						{ID: "r0", Namespaces: 4, LastUpdateTime: metav1.NewTime(now.Add(-time.Hour))},
+						{ID: "r1", Namespaces: 7, LastUpdateTime: metav1.NewTime(now.Add(-time.Minute))},
+						{ID: "r2", Namespaces: 2, Objects: map[string]int32{"pods": 3}, LastUpdateTime: metav1.NewTime(now.Add(-time.Minute))},
+					},
+				},
+			},
+			expectIDs:     []string{"r1", "r2"},
+			expectNSTotal: 3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vcClient := vcfake.NewSimpleClientset()
+			if tc.existing != nil {
+				vcClient = vcfake.NewSimpleClientset(tc.existing)
+			}
+			w, err := NewWriter(vcClient, vclisters.NewVirtualClusterLister(vcIndexer), superInformers, []string{"namespace", "pod", "service"}, nil,
+				func(string) bool { return false }, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.now = func() time.Time { return now }
+
+			for _, obj := range []interface{}{
+				&corev1.Namespace{ObjectMeta: superObjectMeta(key+"-default", "", key)},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+			} {
+				if err := superInformers.Core().V1().Namespaces().Informer().GetIndexer().Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, obj := range []interface{}{
+				&corev1.Pod{
+					ObjectMeta: superObjectMeta("web", key+"-default", key),
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{
+						Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+					}}},
+					Status: corev1.PodStatus{Phase: corev1.PodRunning},
+				},
+				&corev1.Pod{ObjectMeta: superObjectMeta("job", key+"-default", key), Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+				&corev1.Pod{ObjectMeta: superObjectMeta("other", "other-default", "other")},
+			} {
+				if err := superInformers.Core().V1().Pods().Informer().GetIndexer().Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := superInformers.Core().V1().Services().Informer().GetIndexer().Add(&corev1.Service{ObjectMeta: superObjectMeta("web", key+"-default", key)}); err != nil {
+				t.Fatal(err)
+			}
+
+			w.WatchCluster(cluster.NewFakeTenantCluster(vc, nil, nil))
+			w.Write(context.TODO())
+
+			summary, err := vcClient.TenancyV1alpha1().VirtualClusterSummaries("tenant-1").Get("vc", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get the summary: %v", err)
+			}
+			if tc.existing == nil && (len(summary.OwnerReferences) != 1 || summary.OwnerReferences[0].UID != vc.UID) {
+				t.Errorf("expected the summary to be owned by the virtual cluster, got %v", summary.OwnerReferences)
+			}
+			if summary.Status.Phase != v1alpha1.ClusterRunning || summary.Status.ControlPlaneHealthy == nil || !*summary.Status.ControlPlaneHealthy {
+				t.Errorf("expected the phase and health of the virtual cluster, got %+v", summary.Status)
+			}
+			var ids []string
+			for _, s := range summary.Status.SuperClusters {
+				ids = append(ids, s.ID)
+			}
+			if len(ids) != len(tc.expectIDs) {
+				t.Fatalf("expected super clusters %v, got %v", tc.expectIDs, ids)
+			}
+			for i := range ids {
+				if ids[i] != tc.expectIDs[i] {
+					t.Fatalf("expected super clusters %v, got %v", tc.expectIDs, ids)
+				}
+			}
+			if summary.Status.Namespaces != tc.expectNSTotal {
+				t.Errorf("expected %d namespaces, got %d", tc.expectNSTotal, summary.Status.Namespaces)
+			}
+
+			entry := summary.Status.SuperClusters[0]
+			if entry.Reachable || entry.Namespaces != 1 || entry.Objects["pods"] != 2 || entry.Objects["services"] != 1 {
+				t.Errorf("unexpected summary of the super cluster: %+v", entry)
+			}
+			if cpu := entry.Requests[corev1.ResourceCPU]; cpu.MilliValue() != 100 {
+				t.Errorf("expected the requests of the running pods, got %v", entry.Requests)
+			}
+			if !entry.LastUpdateTime.Time.Equal(now) {
+				t.Errorf("expected the summary to be updated at %v, got %v", now, entry.LastUpdateTime)
+			}
+		})
+	}
+}
@@ -19,8 +19,10 @@ package syncer
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,14 +31,17 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/transport"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -45,17 +50,34 @@ import (
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	vclisters "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/listers/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/accounting"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/admission"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/capability"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/flowcontrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/journal"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/rbac"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/reload"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/reporting"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resync"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/split"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/summary"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	utilaccounting "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/accounting"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
 	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 )
 
 var (
@@ -63,8 +85,12 @@ var (
 	numUnHealthCluster uint64
 )
 
+// superClusterInfoRefreshPeriod is the interval to reload the supercluster-info configmap.
+const superClusterInfoRefreshPeriod = 30 * time.Second
+
 type Syncer struct {
 	config            *config.SyncerConfiguration
+	vcClient          vcclient.Interface
 	metaClient        clientset.Interface
 	superClient       clientset.Interface
 	recorder          record.EventRecorder
@@ -79,6 +105,45 @@ type Syncer struct {
 	// clusterSet holds the cluster collection in which cluster is running.
 	mu         sync.Mutex
 	clusterSet map[string]mc.ClusterInterface
+	// connections creates the transports shared by the clients of each tenant cluster.
+	connections *cluster.ConnectionManager
+	// initialClusters are the keys of the running clusters found at startup whose caches are not
+	// synced yet, it is nil until the virtual cluster cache is synced.
+	initialClusters sets.String
+	// budgetExceeded holds the kinds whose patrol remediation budget is exceeded per cluster.
+	budgetLock     sync.Mutex
+	budgetExceeded map[string]sets.String
+	// admission validates tenant objects at creation time, it is nil if
+	// featuregate.TenantPodAdmission is disabled.
+	admission *admission.Server
+	// journal records the changes made to the super control plane, it is nil if
+	// featuregate.SyncChangeJournal is disabled.
+	journal *journal.Journal
+	// reporter reports the resource usage per virtual cluster, it is nil if
+	// featuregate.UsageReporting is disabled.
+	reporter *reporting.Reporter
+	// summary writes the VirtualClusterSummary objects, it is nil if
+	// featuregate.VirtualClusterSummary is disabled.
+	summary *summary.Writer
+	// unreachable holds the clusters whose tenant apiserver failed the last health check.
+	unreachable sets.String
+	// capabilities advertises the super cluster capabilities to the tenant clusters, it is
+	// nil if featuregate.SuperClusterCapabilities is disabled.
+	capabilities *capability.Publisher
+	// superLimiters rate-limit the super cluster requests of every tenant, they are nil if
+	// featuregate.TenantAPIAccounting or the limit is disabled.
+	superLimiters *utilaccounting.Limiters
+	// reloader applies the settings of the config reload file, it is nil if no file is set.
+	reloader *reload.Reloader
+	// running is set once Run is called, stopped is closed once the resource syncers stopped.
+	running int32
+	stopped chan struct{}
+	// leading is closed once Run is called. Until then the tenant cluster caches started by
+	// WarmUp are not watched, so that a standby replica writes nothing.
+	leading     chan struct{}
+	leadingOnce sync.Once
+	// clustersOnce starts the virtual cluster controller once, from WarmUp or Run.
+	clustersOnce sync.Once
 }
 
 type virtualclusterGetter struct {
@@ -99,6 +164,8 @@ func (v *virtualclusterGetter) GetObject(namespace, name string) (client.Object,
 type Bootstrap interface {
 	ListenAndServe(address, certFile, keyFile string)
 	Run(<-chan struct{})
+	WarmUp(<-chan struct{})
+	WaitForShutdown(timeout time.Duration) bool
 }
 
 func New(
@@ -112,13 +179,26 @@ func New(
 ) (*Syncer, error) {
 	syncer := &Syncer{
 		config:      config,
+		vcClient:    virtualClusterClient,
 		metaClient:  metaClusterClient,
 		superClient: superClusterClient,
 		recorder:    recorder,
 		queue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "virtual_cluster"),
 		workers:     constants.UwsControllerWorkerLow,
 		clusterSet:  make(map[string]mc.ClusterInterface),
+		unreachable: sets.NewString(),
+		connections: cluster.NewConnectionManager(cluster.ConnectionOptions{
+			DialTimeout:         config.TenantConnection.DialTimeout.Duration,
+			DialKeepAlive:       config.TenantConnection.DialKeepAlive.Duration,
+			MaxIdleConnsPerHost: config.TenantConnection.MaxIdleConnsPerHost,
+			IdleConnTimeout:     config.TenantConnection.IdleConnTimeout.Duration,
+		}),
+		budgetExceeded: make(map[string]sets.String),
+		stopped:        make(chan struct{}),
+		leading:        make(chan struct{}),
 	}
+	patrol.AddBudgetListener(syncer)
+	objectlimit.AddListener(syncer)
 
 	// Handle VirtualCluster add&delete
 	virtualClusterInformer.Informer().AddEventHandler(
@@ -142,7 +222,62 @@ func New(
 	multiClusterControllerManager := manager.New()
 	syncer.controllerManager = multiClusterControllerManager
 
+	var bulkResources sets.String
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.ResyncPriority) {
+		bulkResources = sets.NewString(config.BulkSyncingResources...)
+		multiClusterControllerManager.SetResyncGate(resync.NewGate(syncer.initialClustersSynced, config.BulkSyncMaxDelay.Duration))
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantPodAdmission) {
+		syncer.admission = admission.NewServer()
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.SyncChangeJournal) {
+		syncer.journal = journal.New(config.ChangeJournalSize, config.ChangeJournalLogSink)
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.UsageReporting) {
+		sinks, err := reporting.ParseSinks(config.UsageReportingSinks)
+		if err != nil {
+			return nil, err
+		}
+		syncer.reporter = reporting.NewReporter(superClusterInformers.Core().V1().Pods().Lister(),
+			reporting.NewMetricsAPIUsage(superClusterClient.Discovery().RESTClient()), sinks)
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterCapabilities) {
+		syncer.capabilities = capability.NewPublisher(superClusterClient)
+		listener.AddListener(syncer.capabilities)
+	}
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.OwnershipSignature) {
+		keys, err := conversion.LoadOwnershipKeys(config.OwnershipKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the ownership keys: %v", err)
+		}
+		signer, err := conversion.NewOwnershipSigner(keys, config.RequireOwnershipSignature)
+		if err != nil {
+			return nil, err
+		}
+		conversion.SetOwnershipSigner(signer)
+	}
+
 	plugins := LoadPlugins(config)
+
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.VirtualClusterSummary) {
+		ids := make([]string, 0, len(plugins))
+		for _, p := range plugins {
+			ids = append(ids, p.ID)
+		}
+		writer, err := summary.NewWriter(virtualClusterClient, syncer.lister, superClusterInformers, ids,
+			reporting.NewMetricsAPIUsage(superClusterClient.Discovery().RESTClient()), syncer.reachable, config.VirtualClusterSummaryInterval.Duration)
+		if err != nil {
+			return nil, err
+		}
+		syncer.summary = writer
+		listener.AddListener(syncer.summary)
+	}
+
 	initContext := &plugin.InitContext{
 		Context:    context.Background(),
 		Config:     config,
@@ -152,10 +287,54 @@ func New(
 		VCInformer: virtualClusterInformer,
 	}
 
+	var impersonation transport.WrapperFunc
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantImpersonation) {
+		impersonation = rbac.ImpersonateTenants(superClusterInformers.Core().V1().Namespaces().Lister(), config)
+	}
+	var tenantLabeling transport.WrapperFunc
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantFlowControl) {
+		tenantLabeling = flowcontrol.LabelTenants(superClusterInformers.Core().V1().Namespaces().Lister())
+	}
+
+	var superAccounting transport.WrapperFunc
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantAPIAccounting) {
+		syncer.superLimiters = utilaccounting.NewLimiters(config.TenantSuperRequestQPS, config.TenantSuperRequestBurst)
+		superAccounting = accounting.SuperTransport(superClusterInformers.Core().V1().Namespaces().Lister(), syncer.superLimiters)
+	}
+
 	for _, p := range plugins {
 		klog.Infof("loading plugin %q...", p.ID)
 
-		result := p.Init(initContext)
+		pluginContext := initContext
+		if (syncer.journal != nil || impersonation != nil || tenantLabeling != nil || superAccounting != nil) && config.RestConfig != nil {
+			// Each plugin gets its own super cluster client so that the journal knows
+			// which controller made a change, and writes into tenant namespaces are
+			// made as the tenant when impersonation is enabled, labeled with the
+			// tenant when tenant flow control is enabled, and accounted to the tenant
+			// when tenant API accounting is enabled.
+			restConfig := restclient.CopyConfig(config.RestConfig)
+			if syncer.journal != nil {
+				restConfig.Wrap(journal.WrapTransport(syncer.journal, p.ID))
+			}
+			if impersonation != nil {
+				restConfig.Wrap(impersonation)
+			}
+			if tenantLabeling != nil {
+				restConfig.Wrap(tenantLabeling)
+			}
+			if superAccounting != nil {
+				restConfig.Wrap(superAccounting)
+			}
+			client, err := clientset.NewForConfig(restConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create super cluster client for plugin %q: %v", p.ID, err)
+			}
+			pluginContext = &plugin.InitContext{}
+			*pluginContext = *initContext
+			pluginContext.Client = client
+		}
+
+		result := p.Init(pluginContext)
 		instance, err := result.Instance()
 		if err != nil {
 			klog.Errorf("failed to load plugin %q", p.ID)
@@ -165,9 +344,42 @@ func New(
 		s, ok := instance.(manager.ResourceSyncer)
 		if ok {
 			multiClusterControllerManager.AddResourceSyncer(s)
+			if len(config.TenantResourceSplit) > 0 {
+				resource := p.ID
+				multiClusterControllerManager.SetClusterFilter(s, func(cluster mc.ClusterInterface) bool {
+					name, namespace, _ := cluster.GetOwnerInfo()
+					return split.SyncsResource(config.TenantResourceSplit, config.SyncSplitTenantsOnly, namespace+"/"+name, resource)
+				})
+			}
+			if bulkResources.Has(p.ID) {
+				multiClusterControllerManager.SetResyncClass(s, resync.Bulk)
+			}
 		} else {
 			klog.Warningf("unrecognized plugin %q", p.ID)
 		}
+
+		if v, ok := instance.(admission.Validator); ok && syncer.admission != nil {
+			klog.Infof("register admission validator of plugin %q", p.ID)
+			syncer.admission.Register(s.GetMCController().GetObjectKind(), v)
+		}
+	}
+
+	// The resource syncers don't take the reloadable settings at construction.
+	multiClusterControllerManager.ReloadConfig(config)
+	if config.ConfigReloadFile != "" {
+		syncer.reloader = reload.NewReloader(config.ConfigReloadFile, config, multiClusterControllerManager.ReloadConfig)
+	}
+
+	if syncer.admission != nil {
+		var caBundle []byte
+		if config.AdmissionWebhookCAFile != "" {
+			var err error
+			caBundle, err = ioutil.ReadFile(config.AdmissionWebhookCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read admission webhook ca file: %v", err)
+			}
+		}
+		listener.AddListener(admission.NewWebhookListener(syncer.admission, config.AdmissionWebhookURL, caBundle))
 	}
 
 	return syncer, nil
@@ -176,10 +388,8 @@ func New(
 func LoadPlugins(config *config.SyncerConfiguration) []*plugin.Registration {
 	allPlugin := plugin.SyncerResourceRegister.List()
 	var enablePlugin []*plugin.Registration
-	extraSets := sets.NewString(config.ExtraSyncingResources...)
-
 	for i, r := range allPlugin {
-		if !r.Disable || extraSets.Has(r.ID) {
+		if r.Enabled(config.SyncingResources, config.ExtraSyncingResources) {
 			enablePlugin = append(enablePlugin, allPlugin[i])
 		}
 	}
@@ -226,30 +436,144 @@ func (s *Syncer) Run(stopChan <-chan struct{}) {
 			klog.Infof("Fail to get ID value from configmap kube-system/%v. Quit!", utilconst.SuperClusterInfoCfgMap)
 			os.Exit(1)
 		}
+		setSuperClusterUnschedulable(cfg)
+		go wait.Until(s.refreshSuperClusterInfo, superClusterInfoRefreshPeriod, stopChan)
 	}
+	s.lead()
+	atomic.StoreInt32(&s.running, 1)
 	go func() {
+		defer close(s.stopped)
 		if err := s.controllerManager.Start(stopChan); err != nil {
 			klog.V(1).Infof("controller manager exit: %v", err)
 		}
 	}()
 	go wait.Until(s.healthPatrol, 1*time.Minute, stopChan)
-	go func() {
-		defer utilruntime.HandleCrash()
-		defer s.queue.ShutDown()
+	if s.reporter != nil {
+		go s.reporter.Run(s.config.UsageReportingInterval.Duration, stopChan)
+	}
+	if s.summary != nil {
+		go s.summary.Run(stopChan)
+	}
+	if s.capabilities != nil {
+		go s.capabilities.Run(s.config.CapabilityProbeInterval.Duration, stopChan)
+	}
+	if s.reloader != nil {
+		go s.reloader.Run(s.config.ConfigReloadInterval.Duration, stopChan)
+	}
+	go s.runOwnershipBackfill(stopChan)
+	s.runClusters(stopChan)
+}
 
-		klog.Infof("starting virtual cluster controller")
-		defer klog.Infof("shutting down virtual cluster controller")
+// WarmUp starts the caches of the tenant clusters without syncing them, it is called by a standby
+// replica before it leads. Once Run is called the warm caches are watched right away instead of
+// being listed again from the tenant apiservers.
+func (s *Syncer) WarmUp(stopChan <-chan struct{}) {
+	klog.Infof("warming up the tenant cluster caches until leading")
+	s.runClusters(stopChan)
+}
 
-		if !cache.WaitForCacheSync(stopChan, s.virtualClusterSynced) {
-			return
-		}
+// runClusters starts the virtual cluster controller adding the tenant clusters, once.
+func (s *Syncer) runClusters(stopChan <-chan struct{}) {
+	s.clustersOnce.Do(func() { go s.runClusterController(stopChan) })
+}
+
+func (s *Syncer) runClusterController(stopChan <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer s.queue.ShutDown()
+
+	klog.Infof("starting virtual cluster controller")
+	defer klog.Infof("shutting down virtual cluster controller")
 
-		klog.V(5).Infof("starting workers")
-		for i := 0; i < s.workers; i++ {
-			go wait.Until(s.run, 1*time.Second, stopChan)
+	if !cache.WaitForCacheSync(stopChan, s.virtualClusterSynced) {
+		return
+	}
+	s.recordInitialClusters()
+
+	klog.V(5).Infof("starting workers")
+	shutdown.RunWorkers("virtual cluster controller", s.queue, s.workers, s.run, 1*time.Second, stopChan)
+}
+
+// lead makes the replica leading, the warm tenant cluster caches are watched from then on.
+func (s *Syncer) lead() {
+	s.leadingOnce.Do(func() { close(s.leading) })
+}
+
+// isLeading returns true once Run is called.
+func (s *Syncer) isLeading() bool {
+	select {
+	case <-s.leading:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordInitialClusters records the running clusters found at startup, the initial sync is done once
+// their caches are synced. It is called before the clusters are added by the workers.
+func (s *Syncer) recordInitialClusters() {
+	vcs, err := s.lister.List(labels.Everything())
+	if err != nil {
+		klog.Warningf("failed to list virtual clusters: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.initialClusters = sets.NewString()
+	for _, vc := range vcs {
+		if vc.Status.Phase != v1alpha1.ClusterRunning || vc.Labels[constants.LabelVCHibernated] == "true" {
+			continue
 		}
-		<-stopChan
-	}()
+		key, err := cache.MetaNamespaceKeyFunc(vc)
+		if err != nil || !split.SyncsCluster(s.config.TenantResourceSplit, s.config.SyncSplitTenantsOnly, key) {
+			continue
+		}
+		s.initialClusters.Insert(key)
+	}
+}
+
+// initialClustersSynced returns true once the caches of the running clusters found at startup are synced.
+func (s *Syncer) initialClustersSynced() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.initialClusters != nil && s.initialClusters.Len() == 0
+}
+
+// refreshSuperClusterInfo reloads the supercluster-info configmap to observe the super cluster being cordoned.
+func (s *Syncer) refreshSuperClusterInfo() {
+	cfg, err := s.superClient.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), utilconst.SuperClusterInfoCfgMap, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("fail to get configmap kube-system/%v from super cluster: %v", utilconst.SuperClusterInfoCfgMap, err)
+		return
+	}
+	setSuperClusterUnschedulable(cfg)
+}
+
+func setSuperClusterUnschedulable(cfg *corev1.ConfigMap) {
+	unschedulable, _ := strconv.ParseBool(cfg.Data[utilconst.SuperClusterUnschedulableKey])
+	if unschedulable != util.IsSuperClusterUnschedulable() {
+		klog.Infof("super cluster %s is marked unschedulable: %v", utilconst.SuperClusterID, unschedulable)
+	}
+	util.SetSuperClusterUnschedulable(unschedulable)
+}
+
+// WaitForShutdown waits up to timeout for the resource syncers to drain their queues once the
+// stop channel passed to Run is closed, then writes a last usage report so that the usage since
+// the previous report is not lost. It returns false if the resource syncers did not drain in time.
+func (s *Syncer) WaitForShutdown(timeout time.Duration) bool {
+	if atomic.LoadInt32(&s.running) == 0 {
+		return true
+	}
+	drained := true
+	select {
+	case <-s.stopped:
+	case <-time.After(timeout):
+		klog.Warningf("timed out waiting for the resource syncers to drain")
+		drained = false
+	}
+	if s.reporter != nil {
+		s.reporter.Report(context.TODO())
+	}
+	return drained
 }
 
 // ListenAndServe initializes a server to respond to HTTP network requests on the syncer.
@@ -257,6 +581,12 @@ func (s *Syncer) ListenAndServe(address, certFile, keyFile string) {
 	metrics.Register()
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
+	if s.admission != nil {
+		mux.Handle(admission.PathPrefix, s.admission)
+	}
+	if s.journal != nil {
+		mux.Handle(journal.PathPrefix, s.journal)
+	}
 	if certFile != "" && keyFile != "" {
 		klog.Fatal(http.ListenAndServeTLS(address, certFile, keyFile, mux))
 	} else {
@@ -306,9 +636,25 @@ func (s *Syncer) syncVirtualCluster(key string) error {
 		return nil
 	}
 
+	if !split.SyncsCluster(s.config.TenantResourceSplit, s.config.SyncSplitTenantsOnly, key) {
+		// the cluster is synced by other syncer instances
+		s.removeCluster(key)
+		return nil
+	}
+
 	switch vc.Status.Phase {
 	case v1alpha1.ClusterRunning:
-		return s.addCluster(key, vc)
+		if vc.Labels[constants.LabelVCHibernated] == "true" {
+			// the informers of a hibernated cluster are started again once it wakes up
+			klog.Infof("Cluster %s is hibernated", key)
+			s.removeCluster(key)
+			return nil
+		}
+		if err := s.addCluster(key, vc); err != nil {
+			s.recordClusterError(vcReference(vc.Namespace, vc.Name, vc.UID), err, "VirtualCluster %s is not synced: %v", key, err)
+			return err
+		}
+		return nil
 	case v1alpha1.ClusterError:
 		s.removeCluster(key)
 		return nil
@@ -324,6 +670,10 @@ func (s *Syncer) removeCluster(key string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.initialClusters != nil {
+		s.initialClusters.Delete(key)
+	}
+
 	vc, exist := s.clusterSet[key]
 	if !exist {
 		// already deleted
@@ -341,6 +691,11 @@ func (s *Syncer) removeCluster(key string) {
 	}
 
 	delete(s.clusterSet, key)
+	s.unreachable.Delete(vc.GetClusterName())
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantAPIAccounting) {
+		s.superLimiters.Forget(vc.GetClusterName())
+		metrics.DeleteTenantAPIMetrics(vc.GetClusterName())
+	}
 }
 
 // addCluster registers and start an informer cache for the given VirtualCluster
@@ -356,15 +711,48 @@ func (s *Syncer) addCluster(key string, vc *v1alpha1.VirtualCluster) error {
 
 	clusterName := conversion.ToClusterKey(vc)
 
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterRequirements) {
+		met, err := s.checkSuperClusterRequirements(vc)
+		if err != nil {
+			return err
+		}
+		if !met {
+			klog.Warningf("skip cluster %s: the super cluster doesn't meet its requirements, retry in %v", key, superClusterRequirementsRetryPeriod)
+			s.queue.AddAfter(key, superClusterRequirementsRetryPeriod)
+			return nil
+		}
+	}
+
 	adminKubeConfigBytes, err := conversion.GetKubeConfigOfVC(s.metaClient.CoreV1(), vc)
 	if err != nil {
 		return err
 	}
-	tenantCluster, err := cluster.NewCluster(clusterName, vc.Namespace, vc.Name, string(vc.UID), &virtualclusterGetter{lister: s.lister}, adminKubeConfigBytes, cluster.Options{})
+	var tenantAccounting transport.WrapperFunc
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantAPIAccounting) {
+		tenantAccounting = accounting.TenantTransport(clusterName)
+	}
+	tenantCluster, err := cluster.NewCluster(clusterName, vc.Namespace, vc.Name, string(vc.UID), &virtualclusterGetter{lister: s.lister}, adminKubeConfigBytes, cluster.Options{
+		AllowedExecCommands: s.config.AllowedExecCredentialCommands,
+		Connections:         s.connections,
+		ReloadKubeConfig: func() ([]byte, error) {
+			return conversion.GetKubeConfigOfVC(s.metaClient.CoreV1(), vc)
+		},
+		WrapTransport: tenantAccounting,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to new tenant cluster %s/%s: %v", vc.Namespace, vc.Name, err)
 	}
 
+	if v, err := mc.CheckServerVersion(tenantCluster); err != nil {
+		if v == nil {
+			return err
+		}
+		// the cluster is not retried until the VirtualCluster is updated, e.g. once its control plane is upgraded.
+		s.recordClusterError(vcReference(vc.Namespace, vc.Name, vc.UID), err, "VirtualCluster %v is not synced: %v", clusterName, err)
+		klog.Errorf("skip cluster %s: %v", key, err)
+		return nil
+	}
+
 	// for each resource type of the newly added VirtualCluster, we add the object to informer cache.
 	for _, clusterChangeListener := range listener.Listeners {
 		clusterChangeListener.AddCluster(tenantCluster)
@@ -380,32 +768,53 @@ func (s *Syncer) addCluster(key string, vc *v1alpha1.VirtualCluster) error {
 }
 
 func (s *Syncer) runCluster(cluster *cluster.Cluster, vc *v1alpha1.VirtualCluster) {
+	key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(vc)
 	go func() {
 		err := cluster.Start()
 		klog.Infof("cluster %s shutdown: %v", cluster.GetClusterName(), err)
 	}()
 
 	if !cluster.WaitForCacheSync() {
-		s.recorder.Eventf(&corev1.ObjectReference{
-			Kind:      "VirtualCluster",
-			Namespace: vc.Namespace,
-			Name:      vc.Name,
-			UID:       vc.UID,
-		}, corev1.EventTypeWarning, "ClusterUnHealth", "VirtualCluster %v unhealth: failed to sync cache", cluster.GetClusterName())
+		s.recordClusterError(vcReference(vc.Namespace, vc.Name, vc.UID), errors.NewTransientInfra("failed to sync cache"),
+			"VirtualCluster %v unhealth: failed to sync cache", cluster.GetClusterName())
 
 		klog.Warningf("failed to sync cache for cluster %s, retry", cluster.GetClusterName())
-		key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(vc)
 		s.removeCluster(key)
 		s.queue.AddAfter(key, 5*time.Second)
 		return
 	}
 	cluster.SetSynced()
 	klog.Infof("cluster %s cache sync done", cluster.GetClusterName())
+	s.watchCluster(key, cluster)
+}
+
+// watchCluster starts watching the synced cluster once the replica leads, unless the cluster
+// was removed or replaced in the meantime.
+func (s *Syncer) watchCluster(key string, cluster mc.ClusterInterface) {
+	if !s.isLeading() {
+		// the listeners enqueue the objects to sync and may write to the tenant cluster, a standby
+		// replica only keeps the cache warm until it leads.
+		<-s.leading
+		s.mu.Lock()
+		current := s.clusterSet[key]
+		s.mu.Unlock()
+		if current != cluster {
+			// the cluster was removed or replaced while waiting
+			return
+		}
+		klog.Infof("cluster %s cache is warm, start watching", cluster.GetClusterName())
+	}
 
 	// start watching cluster resource event after cache sync done.
 	for _, clusterChangeListener := range listener.Listeners {
 		clusterChangeListener.WatchCluster(cluster)
 	}
+
+	s.mu.Lock()
+	if s.initialClusters != nil {
+		s.initialClusters.Delete(key)
+	}
+	s.mu.Unlock()
 }
 
 func (s *Syncer) healthPatrol() {
@@ -445,6 +854,13 @@ func (s *Syncer) checkTenantClusterHealth(cluster mc.ClusterInterface) {
 	}
 
 	_, discoveryErr := cs.Discovery().ServerVersion()
+	s.mu.Lock()
+	if discoveryErr == nil {
+		s.unreachable.Delete(cluster.GetClusterName())
+	} else {
+		s.unreachable.Insert(cluster.GetClusterName())
+	}
+	s.mu.Unlock()
 	if discoveryErr == nil {
 		atomic.AddUint64(&numHealthCluster, 1)
 		return
@@ -452,12 +868,36 @@ func (s *Syncer) checkTenantClusterHealth(cluster mc.ClusterInterface) {
 
 	atomic.AddUint64(&numUnHealthCluster, 1)
 
-	ns, name, uid := cluster.GetOwnerInfo()
+	name, ns, uid := cluster.GetOwnerInfo()
+
+	s.recordClusterError(vcReference(ns, name, types.UID(uid)), discoveryErr,
+		"VirtualCluster %v unhealth: %v", cluster.GetClusterName(), discoveryErr.Error())
+}
+
+// reachable returns whether the tenant apiserver of the cluster passed the last health check.
+func (s *Syncer) reachable(clusterName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.unreachable.Has(clusterName)
+}
 
-	s.recorder.Eventf(&corev1.ObjectReference{
-		Kind:      "VirtualCluster",
-		Namespace: ns,
-		Name:      name,
-		UID:       types.UID(uid),
-	}, corev1.EventTypeWarning, "ClusterUnHealth", "VirtualCluster %v unhealth: %v", cluster.GetClusterName(), discoveryErr.Error())
+// recordClusterError records a warning event of the VirtualCluster ref whose reason is the
+// category of err, see errors.Category.
+func (s *Syncer) recordClusterError(ref *corev1.ObjectReference, err error, messageFmt string, args ...interface{}) {
+	if !s.isLeading() {
+		// the leader records the same errors
+		klog.Warningf(messageFmt, args...)
+		return
+	}
+	s.recorder.Eventf(ref, corev1.EventTypeWarning, string(errors.CategoryOf(err)), messageFmt, args...)
+}
+
+func vcReference(namespace, name string, uid types.UID) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind:       "VirtualCluster",
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Namespace:  namespace,
+		Name:       name,
+		UID:        uid,
+	}
 }
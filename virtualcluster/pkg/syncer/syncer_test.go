@@ -0,0 +1,210 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+type fakeCluster struct {
+	mc.ClusterInterface
+	name string
+}
+
+func (c *fakeCluster) GetClusterName() string {
+	return c.name
+}
+
+type fakeListener struct {
+	watched chan string
+}
+
+func (l *fakeListener) AddCluster(cluster mc.ClusterInterface)    {}
+func (l *fakeListener) RemoveCluster(cluster mc.ClusterInterface) {}
+func (l *fakeListener) WatchCluster(cluster mc.ClusterInterface) {
+	l.watched <- cluster.GetClusterName()
+}
+
+func TestWatchClusterOnStandby(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		replaced bool
+	}{
+		{name: "watched once leading"},
+		{name: "replaced while standby", replaced: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l := &fakeListener{watched: make(chan string, 1)}
+			defer func(listeners []listener.ClusterChangeListener) { listener.Listeners = listeners }(listener.Listeners)
+			listener.Listeners = []listener.ClusterChangeListener{l}
+
+			cluster := &fakeCluster{name: "tenant-1"}
+			s := &Syncer{
+				leading:    make(chan struct{}),
+				clusterSet: map[string]mc.ClusterInterface{"default/vc": cluster},
+			}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				s.watchCluster("default/vc", cluster)
+			}()
+
+			select {
+			case <-l.watched:
+				t.Fatal("expected the standby replica not to watch the cluster")
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			if tc.replaced {
+				s.mu.Lock()
+				s.clusterSet["default/vc"] = &fakeCluster{name: "tenant-1"}
+				s.mu.Unlock()
+			}
+			s.lead()
+			select {
+			case <-done:
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatal("timeout waiting for the promotion")
+			}
+			select {
+			case <-l.watched:
+				if tc.replaced {
+					t.Errorf("expected the replaced cluster not to be watched")
+				}
+			default:
+				if !tc.replaced {
+					t.Errorf("expected the cluster to be watched once leading")
+				}
+			}
+		})
+	}
+}
+
+func TestRecordClusterErrorOnStandby(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	s := &Syncer{leading: make(chan struct{}), recorder: recorder}
+	ref := vcReference("default", "vc", "uid")
+
+	s.recordClusterError(ref, errors.NewTransientInfra("failed to sync cache"), "VirtualCluster %v unhealth", "tenant-1")
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected the standby replica not to record events, got %d", len(recorder.Events))
+	}
+
+	s.lead()
+	s.recordClusterError(ref, errors.NewTransientInfra("failed to sync cache"), "VirtualCluster %v unhealth", "tenant-1")
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected the leading replica to record an event, got %d", len(recorder.Events))
+	}
+}
+
+func TestCheckSuperClusterRequirementsOnStandby(t *testing.T) {
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vc"},
+		Spec: v1alpha1.VirtualClusterSpec{
+			SuperClusterRequirements: &v1alpha1.SuperClusterRequirements{StorageClasses: []string{"fast"}},
+		},
+	}
+	vcClient := vcfake.NewSimpleClientset(vc)
+	s := &Syncer{
+		leading:     make(chan struct{}),
+		vcClient:    vcClient,
+		superClient: fake.NewSimpleClientset(),
+		recorder:    record.NewFakeRecorder(10),
+	}
+
+	notReady := func() *v1alpha1.ClusterCondition {
+		got, err := vcClient.TenancyV1alpha1().VirtualClusters("default").Get("vc", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range got.Status.Conditions {
+			if got.Status.Conditions[i].Type == v1alpha1.SuperClusterNotReadyCondition {
+				return &got.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	if met, err := s.checkSuperClusterRequirements(vc); err != nil || met {
+		t.Fatalf("expected the requirements not to be met, got %v, %v", met, err)
+	}
+	if cond := notReady(); cond != nil {
+		t.Errorf("expected the standby replica not to write the status, got %v", cond)
+	}
+
+	s.lead()
+	if met, err := s.checkSuperClusterRequirements(vc); err != nil || met {
+		t.Fatalf("expected the requirements not to be met, got %v, %v", met, err)
+	}
+	if cond := notReady(); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected the leading replica to set the %s condition, got %v", v1alpha1.SuperClusterNotReadyCondition, cond)
+	}
+}
+
+func TestUpdateVirtualClusterCondition(t *testing.T) {
+	vcClient := vcfake.NewSimpleClientset(&v1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vc"}})
+	s := &Syncer{vcClient: vcClient}
+
+	update := func(status corev1.ConditionStatus, reason string) []v1alpha1.ClusterCondition {
+		vcClient.ClearActions()
+		cond := v1alpha1.ClusterCondition{Type: v1alpha1.PatrolBudgetExceededCondition, Status: status, Reason: reason}
+		if err := s.updateVirtualClusterCondition("default", "vc", cond); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := vcClient.TenancyV1alpha1().VirtualClusters("default").Get("vc", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.Status.Conditions
+	}
+	updated := func() bool {
+		for _, action := range vcClient.Actions() {
+			if action.Matches("update", "virtualclusters") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if conds := update(corev1.ConditionFalse, "WithinBudget"); len(conds) != 0 || updated() {
+		t.Errorf("expected a false condition not to be added, got %v", conds)
+	}
+	conds := update(corev1.ConditionTrue, "RemediationStopped")
+	if len(conds) != 1 || conds[0].Status != corev1.ConditionTrue || !updated() {
+		t.Fatalf("expected the condition to be added, got %v", conds)
+	}
+	if conds := update(corev1.ConditionTrue, "RemediationStopped"); len(conds) != 1 || updated() {
+		t.Errorf("expected an unchanged condition not to be updated, got %v", conds)
+	}
+	conds = update(corev1.ConditionFalse, "WithinBudget")
+	if len(conds) != 1 || conds[0].Status != corev1.ConditionFalse || !updated() {
+		t.Fatalf("expected the condition to be cleared, got %v", conds)
+	}
+}
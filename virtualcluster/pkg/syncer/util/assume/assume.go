@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package assume accounts the objects a syncer created in the super control plane until its
+// informer cache sees them, so that the checks reading the cache do not miss them.
+package assume
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TTL is how long an object is assumed without being seen by the informer cache, the cache is
+// expected to have seen it well before.
+const TTL = time.Minute
+
+// Tenants holds the Tenant of every tenant cluster.
+type Tenants struct {
+	tenants sync.Map
+}
+
+// Get returns the Tenant of the cluster.
+func (t *Tenants) Get(clusterName string) *Tenant {
+	tenant, _ := t.tenants.LoadOrStore(clusterName, &Tenant{})
+	return tenant.(*Tenant)
+}
+
+// Tenant serializes the checks and the writes of a tenant, and accounts the objects written
+// that the informer cache has not seen yet.
+type Tenant struct {
+	sync.Mutex
+	assumed map[types.UID]assumedObject
+}
+
+type assumedObject struct {
+	obj     metav1.Object
+	created time.Time
+}
+
+// Assume accounts obj until the informer cache sees it, the tenant must be locked.
+func (t *Tenant) Assume(obj metav1.Object) {
+	if t.assumed == nil {
+		t.assumed = make(map[types.UID]assumedObject)
+	}
+	t.assumed[obj.GetUID()] = assumedObject{obj: obj, created: time.Now()}
+}
+
+// Assumed returns the assumed objects the informer cache has not seen yet, the tenant must be
+// locked. seen tells whether the cache has seen an object, the ones seen or expired are forgotten.
+func (t *Tenant) Assumed(seen func(obj metav1.Object) bool) []metav1.Object {
+	var objs []metav1.Object
+	for uid, a := range t.assumed {
+		if seen(a.obj) || time.Since(a.created) > TTL {
+			delete(t.assumed, uid)
+			continue
+		}
+		objs = append(objs, a.obj)
+	}
+	return objs
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assume
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestTenantAssumed(t *testing.T) {
+	obj := func(uid string) metav1.Object {
+		return &metav1.ObjectMeta{Name: uid, UID: types.UID(uid)}
+	}
+	cached := map[types.UID]bool{"a": true, "c": true}
+	seen := func(obj metav1.Object) bool {
+		return cached[obj.GetUID()]
+	}
+	names := func(objs []metav1.Object) []string {
+		var names []string
+		for _, o := range objs {
+			names = append(names, o.GetName())
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	var tenants Tenants
+	tenant := tenants.Get("tenant-1")
+	if tenants.Get("tenant-1") != tenant || tenants.Get("tenant-2") == tenant {
+		t.Fatalf("expected one Tenant per cluster")
+	}
+	tenant.Assume(obj("a"))
+	tenant.Assume(obj("b"))
+	tenant.Assume(obj("d"))
+	tenant.assumed["expired"] = assumedObject{obj: obj("expired"), created: time.Now().Add(-2 * TTL)}
+
+	if got := names(tenant.Assumed(seen)); !reflect.DeepEqual(got, []string{"b", "d"}) {
+		t.Errorf("expected the assumed objects b and d, got %v", got)
+	}
+	if _, ok := tenant.assumed["a"]; ok {
+		t.Errorf("expected the assumed object seen by the cache to be forgotten")
+	}
+	if _, ok := tenant.assumed["expired"]; ok {
+		t.Errorf("expected the expired assumed object to be forgotten")
+	}
+	cached["d"] = true
+	if got := names(tenant.Assumed(seen)); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("expected the assumed object b, got %v", got)
+	}
+}
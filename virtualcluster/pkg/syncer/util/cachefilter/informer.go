@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cachefilter reduces the memory of the super cluster informer caches of the syncer, by
+// transforming the cached objects and by selecting the objects that are cached at all.
+//
+// The caches keep the full objects, metadata-only caches are not offered: the syncer compares
+// the specs of the super cluster objects to the tenant objects in both the downward syncers and
+// the patrollers, and reads most objects through typed listers.
+package cachefilter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+type resource struct {
+	object     runtime.Object
+	restClient func(clientset.Interface) rest.Interface
+}
+
+func coreV1(c clientset.Interface) rest.Interface       { return c.CoreV1().RESTClient() }
+func storageV1(c clientset.Interface) rest.Interface    { return c.StorageV1().RESTClient() }
+func schedulingV1(c clientset.Interface) rest.Interface { return c.SchedulingV1().RESTClient() }
+func networkingV1(c clientset.Interface) rest.Interface { return c.NetworkingV1().RESTClient() }
+
+// resources are the super cluster resources whose informer cache can be filtered,
+// keyed by the plural resource name.
+var resources = map[string]resource{
+	"configmaps":             {&corev1.ConfigMap{}, coreV1},
+	"endpoints":              {&corev1.Endpoints{}, coreV1},
+	"events":                 {&corev1.Event{}, coreV1},
+	"namespaces":             {&corev1.Namespace{}, coreV1},
+	"nodes":                  {&corev1.Node{}, coreV1},
+	"persistentvolumeclaims": {&corev1.PersistentVolumeClaim{}, coreV1},
+	"persistentvolumes":      {&corev1.PersistentVolume{}, coreV1},
+	"pods":                   {&corev1.Pod{}, coreV1},
+	"secrets":                {&corev1.Secret{}, coreV1},
+	"serviceaccounts":        {&corev1.ServiceAccount{}, coreV1},
+	"services":               {&corev1.Service{}, coreV1},
+	"storageclasses":         {&storagev1.StorageClass{}, storageV1},
+	"priorityclasses":        {&schedulingv1.PriorityClass{}, schedulingV1},
+	"ingresses":              {&networkingv1.Ingress{}, networkingV1},
+}
+
+// KnownResources returns the resource names that support cache transforms.
+func KnownResources() []string {
+	known := make([]string, 0, len(resources))
+	for name := range resources {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return known
+}
+
+// RegisterInformers registers filtered informers in factory for every resource in
+// transforms or fieldSelectors. It must be called before any informer of those resources
+// is requested from factory, later requests of the same type then share the filtered informer.
+func RegisterInformers(factory informers.SharedInformerFactory, transforms map[string][]string,
+	fieldSelectors map[string]string, maxAnnotationSize int) error {
+	filtered := make(map[string]struct{})
+	for name, names := range transforms {
+		if len(names) > 0 {
+			filtered[name] = struct{}{}
+		}
+	}
+	for name := range fieldSelectors {
+		filtered[name] = struct{}{}
+	}
+
+	for name := range filtered {
+		r, ok := resources[name]
+		if !ok {
+			return fmt.Errorf("cache filtering is not supported for resource %q, supported resources are %v", name, KnownResources())
+		}
+		var fn TransformFunc
+		if names := transforms[name]; len(names) > 0 {
+			var err error
+			if fn, err = NewTransform(names, maxAnnotationSize); err != nil {
+				return err
+			}
+		}
+		selector := fields.Everything()
+		if s, ok := fieldSelectors[name]; ok {
+			var err error
+			if selector, err = fields.ParseSelector(s); err != nil {
+				return fmt.Errorf("invalid cache field selector of resource %q: %v", name, err)
+			}
+		}
+		resourceName, res := name, r
+		factory.InformerFor(res.object, func(client clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+			var lw cache.ListerWatcher = cache.NewListWatchFromClient(res.restClient(client), resourceName, corev1.NamespaceAll, selector)
+			if fn != nil {
+				lw = NewListWatch(lw, fn)
+			}
+			return cache.NewSharedIndexInformer(lw, res.object, resync,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		})
+	}
+	return nil
+}
+
+// ParseFieldSelectors parses a list of resource=selector pairs into a field selector per resource.
+func ParseFieldSelectors(pairs []string) (map[string]string, error) {
+	selectors := make(map[string]string)
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid cache field selector %q, expected <resource>=<selector>", pair)
+		}
+		if _, ok := selectors[kv[0]]; ok {
+			return nil, fmt.Errorf("duplicate cache field selector of resource %q", kv[0])
+		}
+		if _, err := fields.ParseSelector(kv[1]); err != nil {
+			return nil, fmt.Errorf("invalid cache field selector %q: %v", pair, err)
+		}
+		selectors[kv[0]] = kv[1]
+	}
+	return selectors, nil
+}
+
+// ParseTransforms parses a list of resource=transform pairs into transforms per resource.
+func ParseTransforms(pairs []string) (map[string][]string, error) {
+	transforms := make(map[string][]string)
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid cache transform %q, expected <resource>=<transform>", pair)
+		}
+		transforms[kv[0]] = append(transforms[kv[0]], kv[1])
+	}
+	return transforms, nil
+}
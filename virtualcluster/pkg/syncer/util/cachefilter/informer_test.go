@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cachefilter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestParseFieldSelectors(t *testing.T) {
+	got, err := ParseFieldSelectors([]string{"events=involvedObject.kind=Pod", "secrets=type!=helm.sh/release.v1,type!=Opaque"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["events"] != "involvedObject.kind=Pod" || got["secrets"] != "type!=helm.sh/release.v1,type!=Opaque" {
+		t.Errorf("unexpected selectors %v", got)
+	}
+	for _, invalid := range [][]string{
+		{"events"},
+		{"events=involvedObject.kind"},
+		{"events=involvedObject.kind=Pod", "events=reason=Failed"},
+	} {
+		if _, err := ParseFieldSelectors(invalid); err == nil {
+			t.Errorf("expected error for %v", invalid)
+		}
+	}
+}
+
+func TestRegisterInformersFieldSelector(t *testing.T) {
+	selectors := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("watch") != "true" {
+			selectors <- r.URL.Query().Get("fieldSelector")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"EventList","apiVersion":"v1","metadata":{"resourceVersion":"1"},"items":[]}`))
+	}))
+	defer server.Close()
+	client, err := clientset.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	factory := informers.NewSharedInformerFactory(client, 0)
+	if err := RegisterInformers(factory, nil, map[string]string{"events": "involvedObject.kind=Pod"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	informer := factory.Core().V1().Events().Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+
+	select {
+	case got := <-selectors:
+		if got != "involvedObject.kind=Pod" {
+			t.Errorf("expected the events to be listed with the field selector, got %q", got)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timeout waiting for the events list")
+	}
+
+	if err := RegisterInformers(factory, nil, map[string]string{"leases": "metadata.name=a"}, 0); err == nil {
+		t.Errorf("expected error for unsupported resource")
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cachefilter
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// StripManagedFields removes metadata.managedFields from cached objects.
+	// The syncer never reads them and they are usually the largest part of the metadata.
+	StripManagedFields = "strip-managed-fields"
+	// DropLargeAnnotations removes annotations whose value is larger than the configured size.
+	// Only use it for resources the syncer does not write back from the cache, e.g. events,
+	// otherwise the dropped annotations are lost on update.
+	DropLargeAnnotations = "drop-large-annotations"
+)
+
+// DefaultMaxAnnotationSize is the default annotation value size above which
+// DropLargeAnnotations drops an annotation.
+const DefaultMaxAnnotationSize = 4096
+
+// TransformFunc mutates an object in place before it is stored in an informer cache.
+type TransformFunc func(obj metav1.Object)
+
+// KnownTransforms returns the names of all supported transforms.
+func KnownTransforms() []string {
+	known := []string{StripManagedFields, DropLargeAnnotations}
+	sort.Strings(known)
+	return known
+}
+
+// NewTransform chains the named transforms into one TransformFunc.
+func NewTransform(names []string, maxAnnotationSize int) (TransformFunc, error) {
+	var fns []TransformFunc
+	for _, name := range names {
+		switch name {
+		case StripManagedFields:
+			fns = append(fns, stripManagedFields)
+		case DropLargeAnnotations:
+			if maxAnnotationSize <= 0 {
+				maxAnnotationSize = DefaultMaxAnnotationSize
+			}
+			fns = append(fns, dropLargeAnnotations(maxAnnotationSize))
+		default:
+			return nil, fmt.Errorf("unknown cache transform %q, known transforms are %v", name, KnownTransforms())
+		}
+	}
+	return func(obj metav1.Object) {
+		for _, fn := range fns {
+			fn(obj)
+		}
+	}, nil
+}
+
+func stripManagedFields(obj metav1.Object) {
+	obj.SetManagedFields(nil)
+}
+
+func dropLargeAnnotations(maxSize int) TransformFunc {
+	return func(obj metav1.Object) {
+		annotations := obj.GetAnnotations()
+		for k, v := range annotations {
+			if len(v) > maxSize {
+				delete(annotations, k)
+			}
+		}
+	}
+}
+
+// NewListWatch wraps lw so that every listed or watched object is passed
+// through fn before it reaches the informer.
+func NewListWatch(lw cache.ListerWatcher, fn TransformFunc) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			list, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+			err = meta.EachListItem(list, func(item runtime.Object) error {
+				accessor, err := meta.Accessor(item)
+				if err != nil {
+					return err
+				}
+				fn(accessor)
+				return nil
+			})
+			return list, err
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+				if accessor, err := meta.Accessor(in.Object); err == nil {
+					fn(accessor)
+				}
+				return in, true
+			}), nil
+		},
+	}
+}
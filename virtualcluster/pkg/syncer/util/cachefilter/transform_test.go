@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cachefilter
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          "pod",
+			Namespace:     "default",
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+			Annotations: map[string]string{
+				"small": "v",
+				"large": strings.Repeat("x", 10),
+			},
+		},
+	}
+}
+
+func TestNewTransform(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		transforms      []string
+		expectErr       bool
+		expectManaged   bool
+		expectLargeAnno bool
+	}{
+		{
+			name:            "no transforms",
+			expectManaged:   true,
+			expectLargeAnno: true,
+		},
+		{
+			name:            "strip managed fields",
+			transforms:      []string{StripManagedFields},
+			expectLargeAnno: true,
+		},
+		{
+			name:          "drop large annotations",
+			transforms:    []string{DropLargeAnnotations},
+			expectManaged: true,
+		},
+		{
+			name:       "both",
+			transforms: []string{StripManagedFields, DropLargeAnnotations},
+		},
+		{
+			name:       "unknown",
+			transforms: []string{"foo"},
+			expectErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fn, err := NewTransform(tc.transforms, 5)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			pod := newPod()
+			fn(pod)
+			if (len(pod.ManagedFields) != 0) != tc.expectManaged {
+				t.Errorf("expected managed fields %v, got %v", tc.expectManaged, pod.ManagedFields)
+			}
+			if _, ok := pod.Annotations["large"]; ok != tc.expectLargeAnno {
+				t.Errorf("expected large annotation %v, got %v", tc.expectLargeAnno, pod.Annotations)
+			}
+			if _, ok := pod.Annotations["small"]; !ok {
+				t.Errorf("expected small annotation to be kept, got %v", pod.Annotations)
+			}
+		})
+	}
+}
+
+func TestNewListWatch(t *testing.T) {
+	fakeWatch := watch.NewFake()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.PodList{Items: []corev1.Pod{*newPod()}}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return fakeWatch, nil
+		},
+	}
+	fn, err := NewTransform([]string{StripManagedFields}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered := NewListWatch(lw, fn)
+
+	list, err := filtered.List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mf := list.(*corev1.PodList).Items[0].ManagedFields; len(mf) != 0 {
+		t.Errorf("expected listed pod without managed fields, got %v", mf)
+	}
+
+	w, err := filtered.Watch(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	go fakeWatch.Add(newPod())
+	event := <-w.ResultChan()
+	if mf := event.Object.(*corev1.Pod).ManagedFields; len(mf) != 0 {
+		t.Errorf("expected watched pod without managed fields, got %v", mf)
+	}
+}
+
+func TestParseTransforms(t *testing.T) {
+	got, err := ParseTransforms([]string{"pods=strip-managed-fields", "pods=drop-large-annotations", "events=drop-large-annotations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got["pods"]) != 2 || len(got["events"]) != 1 {
+		t.Errorf("unexpected transforms %v", got)
+	}
+	if _, err := ParseTransforms([]string{"pods"}); err == nil {
+		t.Errorf("expected error for pair without transform")
+	}
+}
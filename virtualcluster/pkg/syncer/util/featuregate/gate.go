@@ -88,6 +88,128 @@ const (
 	// add clusterIP of pService to vService's externalIPs.
 	// So that vService can be resolved by using the k8s_external plugin in coredns.
 	VServiceExternalIP = "VServiceExternalIP"
+
+	// TenantPodAdmission is an experimental feature that installs a validating webhook
+	// in each tenant control plane which points to the syncer, so that pods that cannot
+	// be synced are rejected synchronously at creation time. The syncer dry-runs the creation
+	// of the super control plane pod, so its ResourceQuotas and LimitRanges are evaluated.
+	TenantPodAdmission = "TenantPodAdmission"
+
+	// PatrolSemanticHash is an experimental feature that allows the syncer to record
+	// a semantic hash of the tenant object in the super cluster object, so that the
+	// patrollers can skip the full equality check when the hash matches and the super
+	// cluster object is unchanged since it was last found equal.
+	PatrolSemanticHash = "PatrolSemanticHash"
+
+	// VirtualNodeAggregation is an experimental feature that allows the syncer to
+	// present one virtual node per super cluster node pool in tenant clusters,
+	// instead of mirroring every super cluster node. The pool of a node is the value of
+	// the VirtualNodePoolLabel label. It requires a vn-agent that proxies through the
+	// super cluster apiserver, e.g. VNodeProviderService.
+	VirtualNodeAggregation = "VirtualNodeAggregation"
+
+	// TenantNodeLease is an experimental feature that allows the syncer to renew a
+	// Lease per vNode in the tenant kube-node-lease namespace while the super cluster
+	// node is ready, so that vNode status only needs to be written on changes.
+	TenantNodeLease = "TenantNodeLease"
+
+	// NodeMaintenanceEviction is an experimental feature that propagates the cordon state of
+	// super cluster nodes to vNodes as a maintenance taint, and evicts the tenant pods of a
+	// cordoned vNode through the tenant eviction API so that tenant PodDisruptionBudgets are
+	// respected and tenant events explain the disruption.
+	NodeMaintenanceEviction = "NodeMaintenanceEviction"
+
+	// SyncChangeJournal is an experimental feature that records every create, update, patch and
+	// delete the resource syncers send to the super control plane in a ring buffer, served at
+	// /debug/journal by the syncer server and optionally written to the log.
+	SyncChangeJournal = "SyncChangeJournal"
+
+	// TenantImpersonation is an experimental feature that makes the resource syncers write namespaced
+	// objects in the super control plane as a per-tenant service account, bound only to the namespaces
+	// of that tenant, so a compromised tenant path can't touch other tenants' namespaces.
+	TenantImpersonation = "TenantImpersonation"
+
+	// TenantFlowControl is an experimental feature that names the tenant of every request the resource
+	// syncers make into a tenant namespace of the super control plane in its user agent. Combined with
+	// TenantImpersonation and the FlowSchema printed by --print-rbac, API Priority and Fairness queues the
+	// sync traffic of every tenant separately, so one tenant can't starve the super apiserver for others.
+	TenantFlowControl = "TenantFlowControl"
+
+	// NamespaceRecycleBin is an experimental feature that retains the super control plane namespace of a
+	// deleted tenant namespace for the configured retention period instead of deleting it. The pods of the
+	// namespace are deleted, but its configmaps, secrets and persistentvolumeclaims are kept so that the data
+	// can be recovered, and recreating the tenant namespace restores the retained namespace.
+	NamespaceRecycleBin = "NamespaceRecycleBin"
+
+	// NamespaceDeletionBarrier is an experimental feature that tears down the super control plane namespace of
+	// a deleted tenant namespace in order: its pods are deleted first, then its services and claims, and the
+	// namespace is only deleted once they are gone or the teardown timeout expires.
+	NamespaceDeletionBarrier = "NamespaceDeletionBarrier"
+
+	// UsageReporting is an experimental feature that periodically aggregates the resource requests,
+	// limits and usage of the super cluster pods per VirtualCluster and writes them to the configured
+	// reporting sinks, e.g. Prometheus, a CSV file or a webhook.
+	UsageReporting = "UsageReporting"
+
+	// VirtualClusterSummary is an experimental feature that periodically writes the synced object counts,
+	// health, placement and resource usage of every VirtualCluster in the super cluster into the
+	// VirtualClusterSummary object of the VirtualCluster in the meta cluster.
+	VirtualClusterSummary = "VirtualClusterSummary"
+
+	// InPlacePodResize is an experimental feature that resizes the super cluster pods in place through
+	// the resize subresource when the tenant changes the container resources, and back populates the
+	// allocated resources and the resize status to the tenant pods.
+	InPlacePodResize = "InPlacePodResize"
+
+	// TenantPodPolicy is an experimental feature that injects the default tolerations,
+	// node selector, topology spread constraints and runtime class of the TenantPodPolicy
+	// objects into the super cluster pods of the selected VirtualClusters.
+	TenantPodPolicy = "TenantPodPolicy"
+
+	// SuperClusterCapabilities is an experimental feature that periodically probes the API groups,
+	// runtime classes and CSI drivers of the super cluster, and advertises them in the
+	// kube-public/super-cluster-capabilities ConfigMap of every tenant cluster.
+	SuperClusterCapabilities = "SuperClusterCapabilities"
+
+	// ResyncPriority is an experimental feature that holds back the bulk resource syncers, e.g. events
+	// and configmaps, during the initial sync after a syncer restart, until the interactive ones, e.g.
+	// pods and services, converged.
+	ResyncPriority = "ResyncPriority"
+
+	// OwnershipSignature is an experimental feature that signs the ownership annotations of the super
+	// cluster objects with an HMAC key held by the syncer, and verifies the signature before deleting
+	// them, so that forged annotations cannot make the syncer delete objects it does not own.
+	OwnershipSignature = "OwnershipSignature"
+
+	// ControlPlaneSecretChecksum is an experimental feature that records a checksum of the secrets
+	// mounted by the control plane StatefulSets in their pod templates, and updates it when the
+	// secrets change, e.g. on a PKI or encryption config rotation, so that the pods are restarted.
+	ControlPlaneSecretChecksum = "ControlPlaneSecretChecksum"
+
+	// OnDemandConfigSync is an experimental feature that only syncs the tenant configmaps and
+	// secrets referenced by the tenant pods, from their env, volumes, projected volumes and image
+	// pull secrets, and deletes the super control plane copies once no pod references them.
+	OnDemandConfigSync = "OnDemandConfigSync"
+
+	// StatusOnlyUpdateFilter is an experimental feature that skips the downward reconciles of the
+	// tenant objects whose status only changed, e.g. by the upward syncers, and the upward
+	// reconciles of the super objects whose synced fields didn't change.
+	StatusOnlyUpdateFilter = "StatusOnlyUpdateFilter"
+
+	// TenantAPIAccounting is an experimental feature that counts the requests and the bytes of the
+	// syncer clients per tenant, to the tenant apiservers and into the tenant namespaces of the
+	// super cluster, and optionally rate-limits the super cluster requests of every tenant.
+	TenantAPIAccounting = "TenantAPIAccounting"
+
+	// IngressTLSIsolation is an experimental feature that doesn't sync the tenant ingresses whose
+	// TLS certificates aren't secrets of the tenant, e.g. the default or wildcard certificates of
+	// the super cluster ingress controllers.
+	IngressTLSIsolation = "IngressTLSIsolation"
+
+	// SuperClusterRequirements is an experimental feature that checks the super cluster requirements
+	// of the ClusterVersion and the VirtualCluster, e.g. the minimum version, the storage classes and
+	// the node labels, before syncing a virtual cluster, and reports them in a SuperClusterNotReady condition.
+	SuperClusterRequirements = "SuperClusterRequirements"
 )
 
 var defaultFeatures = FeatureList{
@@ -103,6 +225,40 @@ var defaultFeatures = FeatureList{
 	DisableCRDPreserveUnknownFields: {Default: false},
 	RootCACertConfigMapSupport:      {Default: false},
 	VServiceExternalIP:              {Default: false},
+	TenantPodAdmission:              {Default: false},
+	PatrolSemanticHash:              {Default: false},
+	VirtualNodeAggregation:          {Default: false},
+	TenantNodeLease:                 {Default: false},
+	NodeMaintenanceEviction:         {Default: false},
+	SyncChangeJournal:               {Default: false},
+	TenantImpersonation:             {Default: false},
+	TenantFlowControl:               {Default: false},
+	NamespaceRecycleBin:             {Default: false},
+	NamespaceDeletionBarrier:        {Default: false},
+	UsageReporting:                  {Default: false},
+	VirtualClusterSummary:           {Default: false},
+	InPlacePodResize:                {Default: false},
+	TenantPodPolicy:                 {Default: false},
+	SuperClusterCapabilities:        {Default: false},
+	ResyncPriority:                  {Default: false},
+	OwnershipSignature:              {Default: false},
+	ControlPlaneSecretChecksum:      {Default: false},
+	OnDemandConfigSync:              {Default: false},
+	StatusOnlyUpdateFilter:          {Default: false},
+	TenantAPIAccounting:             {Default: false},
+	IngressTLSIsolation:             {Default: false},
+	SuperClusterRequirements:        {Default: false},
+}
+
+// reloadableFeatures are the features that are checked on every sync, so that they can be
+// toggled while the syncer is running without restarting it.
+var reloadableFeatures = map[Feature]struct{}{
+	DisableCRDPreserveUnknownFields: {},
+	PatrolSemanticHash:              {},
+	RootCACertConfigMapSupport:      {},
+	TenantAllowDNSPolicy:            {},
+	TenantAllowResourceNoSync:       {},
+	VServiceExternalIP:              {},
 }
 
 type Feature string
@@ -115,6 +271,22 @@ type FeatureSpec struct {
 // FeatureList represents a list of feature gates
 type FeatureList map[Feature]FeatureSpec
 
+// ReloadableFeatures returns the features that can be toggled without restarting the syncer.
+func ReloadableFeatures() []Feature {
+	features := make([]Feature, 0, len(reloadableFeatures))
+	for k := range reloadableFeatures {
+		features = append(features, k)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i] < features[j] })
+	return features
+}
+
+// Reloadable indicates whether a feature can be toggled without restarting the syncer.
+func Reloadable(key Feature) bool {
+	_, ok := reloadableFeatures[key]
+	return ok
+}
+
 // Supports indicates whether a feature name is supported on the given
 // feature set
 func Supports(featureList FeatureList, featureName string) bool {
@@ -18,15 +18,35 @@ package util
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"k8s.io/apimachinery/pkg/labels"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/handler"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 )
 
+// superClusterUnschedulable is 1 when the super cluster is cordoned with SuperClusterPooling enabled.
+var superClusterUnschedulable int32
+
+// IsSuperClusterUnschedulable returns true if the super cluster is cordoned, in which case
+// the syncer does not create new namespaces in it but keeps syncing the existing ones.
+func IsSuperClusterUnschedulable() bool {
+	return atomic.LoadInt32(&superClusterUnschedulable) == 1
+}
+
+// SetSuperClusterUnschedulable cordons or uncordons the super cluster.
+func SetSuperClusterUnschedulable(unschedulable bool) {
+	var v int32
+	if unschedulable {
+		v = 1
+	}
+	atomic.StoreInt32(&superClusterUnschedulable, v)
+}
+
 func GetVirtualClusterObject(mc mc.MultiClusterInterface, clustername string) (*v1alpha1.VirtualCluster, error) {
 	obj, err := mc.GetClusterObject(clustername)
 	if err != nil {
@@ -50,3 +70,18 @@ func GetSuperClusterListerLabelsSelector() labels.Selector {
 	}
 	return labels.Everything()
 }
+
+// DWSUpdatePredicate returns the filter of the tenant object updates of a downward syncer, which
+// skips the status only updates with StatusOnlyUpdateFilter enabled. The downward syncers don't
+// sync the tenant status, except the changes for which statusSynced returns true, it can be nil.
+func DWSUpdatePredicate(statusSynced handler.UpdatePredicate) handler.UpdatePredicate {
+	if !featuregate.DefaultFeatureGate.Enabled(featuregate.StatusOnlyUpdateFilter) {
+		return nil
+	}
+	return func(oldObj, newObj interface{}) bool {
+		if !handler.StatusOnlyUpdate(oldObj, newObj) {
+			return true
+		}
+		return statusSynced != nil && statusSynced(oldObj, newObj)
+	}
+}
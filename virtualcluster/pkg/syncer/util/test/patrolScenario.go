@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+)
+
+// PatrolScenario is a simulation of one checker round: the objects of the super and tenant clusters
+// the checker finds, and the remediations expected on them. The expected objects are keyed by
+// namespace/name, or by name if they are cluster scoped.
+type PatrolScenario struct {
+	ExistingObjectInSuper    []runtime.Object
+	ExistingObjectInTenant   []runtime.Object
+	ExistingObjectInVCClient []runtime.Object
+	ExpectedDeletedPObject   []string
+	ExpectedDeletedVObject   []string
+	ExpectedCreatedPObject   []string
+	ExpectedCreatedVObject   []string
+	ExpectedUpdatedPObject   []string
+	ExpectedUpdatedVObject   []string
+	ExpectedNoOperation      bool
+	WaitDWS                  bool // Make sure to set this flag if the test involves DWS.
+	WaitUWS                  bool // Make sure to set this flag if the test involves UWS.
+	// ControllerStateModifyFunc updates the resource syncer before the checker runs.
+	ControllerStateModifyFunc func(manager.ResourceSyncer)
+}
+
+// RunPatrolScenarios runs the checker of the resource syncer once per scenario against fake super and
+// tenant clusters, and verifies the objects of resource the checker and the syncers it requeued to
+// created, updated or deleted. The objects of other resources are not verified.
+func RunPatrolScenarios(t *testing.T, newControllerFunc manager.ResourceSyncerNew, testTenant *v1alpha1.VirtualCluster, resource string, scenarios map[string]PatrolScenario) {
+	for k, tc := range scenarios {
+		tc := tc
+		t.Run(k, func(t *testing.T) {
+			tenantActions, superActions, err := RunPatrol(newControllerFunc, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.ExistingObjectInVCClient, tc.WaitDWS, tc.WaitUWS, tc.ControllerStateModifyFunc)
+			if err != nil {
+				t.Fatalf("error running patrol: %v", err)
+			}
+
+			if tc.ExpectedNoOperation {
+				if len(superActions) != 0 {
+					t.Errorf("Expect no operation, got %v in super cluster", superActions)
+				}
+				if len(tenantActions) != 0 {
+					t.Errorf("Expect no operation, got %v in tenant cluster", tenantActions)
+				}
+				return
+			}
+
+			for _, each := range []struct {
+				cluster  string
+				verb     string
+				actions  []core.Action
+				expected []string
+			}{
+				{"super", "delete", superActions, tc.ExpectedDeletedPObject},
+				{"super", "create", superActions, tc.ExpectedCreatedPObject},
+				{"super", "update", superActions, tc.ExpectedUpdatedPObject},
+				{"tenant", "delete", tenantActions, tc.ExpectedDeletedVObject},
+				{"tenant", "create", tenantActions, tc.ExpectedCreatedVObject},
+				{"tenant", "update", tenantActions, tc.ExpectedUpdatedVObject},
+			} {
+				got := actionKeys(each.actions, each.verb, resource)
+				expected := append([]string{}, each.expected...)
+				sort.Strings(expected)
+				if !equalKeys(got, expected) {
+					t.Errorf("Expect to %s %s %v in %s cluster, got %v", each.verb, resource, expected, each.cluster, got)
+				}
+			}
+		})
+	}
+}
+
+// actionKeys returns the sorted keys of the objects of resource the actions of verb are applied to.
+func actionKeys(actions []core.Action, verb, resource string) []string {
+	var keys []string
+	for _, action := range actions {
+		if !action.Matches(verb, resource) || action.GetSubresource() != "" {
+			continue
+		}
+		namespace, name := action.GetNamespace(), ""
+		switch a := action.(type) {
+		case core.DeleteAction:
+			name = a.GetName()
+		case core.CreateAction:
+			if accessor, err := meta.Accessor(a.GetObject()); err == nil {
+				name = accessor.GetName()
+				if accessor.GetNamespace() != "" {
+					namespace = accessor.GetNamespace()
+				}
+			}
+		}
+		if namespace != "" {
+			name = namespace + "/" + name
+		}
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
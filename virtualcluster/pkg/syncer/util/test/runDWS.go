@@ -18,8 +18,10 @@ package util
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
@@ -39,7 +41,15 @@ import (
 
 type fakeReconciler struct {
 	resourceSyncer manager.ResourceSyncer
-	errCh          chan error
+	lock           sync.Mutex
+	reported       map[string]bool
+	// errCh receives the result of the first reconcile of every request.
+	errCh chan reconcileResult
+}
+
+type reconcileResult struct {
+	key string
+	err error
 }
 
 func (r *fakeReconciler) Reconcile(request reconciler.Request) (reconciler.Result, error) {
@@ -50,11 +60,13 @@ func (r *fakeReconciler) Reconcile(request reconciler.Request) (reconciler.Resul
 	} else {
 		res, err = reconciler.Result{}, fmt.Errorf("fake reconciler's controller is not initialized")
 	}
-	select {
-	case <-r.errCh:
-	default:
-		// if channel not closed
-		r.errCh <- err
+	key := request.Namespace + "/" + request.Name
+	r.lock.Lock()
+	first := !r.reported[key]
+	r.reported[key] = true
+	r.lock.Unlock()
+	if first {
+		r.errCh <- reconcileResult{key: key, err: err}
 	}
 
 	// Make sure Reconcile is called once by returning no error.
@@ -75,6 +87,25 @@ func RunDownwardSync(
 	enqueueObject runtime.Object,
 	clientSetMutator FakeClientSetMutator,
 ) (actions []core.Action, reconcileError error, err error) {
+	actions, reconcileErrors, err := RunConcurrentDownwardSync(newControllerFunc, testTenant, existingObjectInSuper, existingObjectInTenant,
+		[]runtime.Object{enqueueObject}, clientSetMutator)
+	if err != nil {
+		return nil, nil, err
+	}
+	return actions, reconcileErrors[0], nil
+}
+
+// RunConcurrentDownwardSync reconciles the enqueued objects concurrently, one worker per object, and
+// returns the errors of their first reconcile in order. The super cluster informers are not updated
+// by the actions, as if they had not seen them yet.
+func RunConcurrentDownwardSync(
+	newControllerFunc manager.ResourceSyncerNew,
+	testTenant *v1alpha1.VirtualCluster,
+	existingObjectInSuper []runtime.Object,
+	existingObjectInTenant []runtime.Object,
+	enqueueObjects []runtime.Object,
+	clientSetMutator FakeClientSetMutator,
+) (actions []core.Action, reconcileErrors []error, err error) {
 	// setup fake tenant cluster
 	tenantClientset := fake.NewSimpleClientset()
 	tenantClientBuilder := fakeClient.NewClientBuilder()
@@ -102,11 +133,9 @@ func RunDownwardSync(
 	}
 
 	// setup fake controller
-	syncErr := make(chan error)
-	defer close(syncErr)
-	fakeRc := &fakeReconciler{errCh: syncErr}
+	fakeRc := &fakeReconciler{reported: make(map[string]bool), errCh: make(chan reconcileResult, len(enqueueObjects))}
 	rsOptions := manager.ResourceSyncerOptions{
-		MCOptions: &mc.Options{Reconciler: fakeRc},
+		MCOptions: &mc.Options{Reconciler: fakeRc, MaxConcurrentReconciles: len(enqueueObjects)},
 		IsFake:    true,
 	}
 
@@ -143,16 +172,30 @@ func RunDownwardSync(
 	}
 
 	// start testing
-	if err := resourceSyncer.GetMCController().RequeueObject(conversion.ToClusterKey(testTenant), enqueueObject); err != nil {
-		return nil, nil, fmt.Errorf("error enqueue object %v: %v", enqueueObject, err)
+	keys := make([]string, 0, len(enqueueObjects))
+	for _, obj := range enqueueObjects {
+		if err := resourceSyncer.GetMCController().RequeueObject(conversion.ToClusterKey(testTenant), obj); err != nil {
+			return nil, nil, fmt.Errorf("error enqueue object %v: %v", obj, err)
+		}
+		o, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, o.GetNamespace()+"/"+o.GetName())
 	}
 
 	// wait to be called
-	select {
-	case reconcileError = <-syncErr:
-	case <-time.After(10 * time.Second):
-		return nil, nil, fmt.Errorf("timeout wating for sync")
+	errs := make(map[string]error, len(keys))
+	for range keys {
+		select {
+		case r := <-fakeRc.errCh:
+			errs[r.key] = r.err
+		case <-time.After(10 * time.Second):
+			return nil, nil, fmt.Errorf("timeout wating for sync")
+		}
 	}
-
-	return superClient.Actions(), reconcileError, nil
+	for _, key := range keys {
+		reconcileErrors = append(reconcileErrors, errs[key])
+	}
+	return superClient.Actions(), reconcileErrors, nil
 }
@@ -73,7 +73,7 @@ func RunPatrol(
 	tenantClientset := fake.NewSimpleClientset()
 	tenantClientBuilder := fakeClient.NewClientBuilder()
 	if existingObjectInTenant != nil {
-		tenantClientset = fake.NewSimpleClientset(existingObjectInTenant...)
+		tenantClientset = fake.NewSimpleClientset(builtinObjects(existingObjectInTenant)...)
 		// For controller runtime client, if the informer cache is empty, the request goes to client obj tracker.
 		// Hence we don't have to populate the infomer cache.
 		tenantClientBuilder = tenantClientBuilder.WithRuntimeObjects(existingObjectInTenant...)
@@ -104,15 +104,14 @@ func RunPatrol(
 	}
 
 	// setup fake controller
-	syncDWS := make(chan error)
-	defer close(syncDWS)
+	syncDWS := make(chan reconcileResult, 1)
 	syncUWS := make(chan error)
 	defer close(syncUWS)
 	syncPatrol := make(chan error)
 	defer close(syncPatrol)
 
 	fakePatrolRc := &fakePatrolReconciler{errCh: syncPatrol}
-	fakeDWRc := &fakeReconciler{errCh: syncDWS}
+	fakeDWRc := &fakeReconciler{reported: make(map[string]bool), errCh: syncDWS}
 	fakeUWRc := &fakeUWReconciler{errCh: syncUWS}
 
 	rsOptions := manager.ResourceSyncerOptions{
@@ -209,3 +208,17 @@ func RunPatrol(
 
 	return tenantActions, superActions, nil
 }
+
+// builtinObjects returns the objects of the kubernetes apis, the objects of the custom resources
+// can only be added to the controller runtime client.
+func builtinObjects(objs []runtime.Object) []runtime.Object {
+	builtin := runtime.NewScheme()
+	_ = fake.AddToScheme(builtin)
+	var ret []runtime.Object
+	for _, each := range objs {
+		if _, _, err := builtin.ObjectKinds(each); err == nil {
+			ret = append(ret, each)
+		}
+	}
+	return ret
+}
@@ -118,6 +118,8 @@ func RunUpwardSync(
 	resourceSyncer.GetListener().AddCluster(tenantCluster)
 	resourceSyncer.GetListener().WatchCluster(tenantCluster)
 	defer resourceSyncer.GetListener().RemoveCluster(tenantCluster)
+	// the discovery requests of the registration are not part of the sync.
+	tenantClientset.ClearActions()
 
 	stopCh := make(chan struct{})
 	defer close(stopCh)
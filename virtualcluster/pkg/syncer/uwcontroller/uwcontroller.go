@@ -22,7 +22,6 @@ import (
 	"time"
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
@@ -32,6 +31,7 @@ import (
 	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -91,13 +91,8 @@ func NewUWController(objectType client.Object, rc reconciler.UWReconciler, opts
 func (c *UpwardController) Start(stop <-chan struct{}) error {
 	klog.Infof("start uw-controller %s", c.name)
 	defer utilruntime.HandleCrash()
-	defer c.Queue.ShutDown()
 
-	for i := 0; i < c.MaxConcurrentReconciles; i++ {
-		go wait.Until(c.worker, c.JitterPeriod, stop)
-	}
-
-	<-stop
+	shutdown.RunWorkers(c.name, c.Queue, c.MaxConcurrentReconciles, c.worker, c.JitterPeriod, stop)
 	klog.Infof("shutting down uw-controller %s", c.name)
 	return nil
 }
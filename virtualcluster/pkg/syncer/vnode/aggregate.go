@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vnode
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+const (
+	// AggregatedNodeNamePrefix is the name prefix of the virtual nodes presenting a super cluster node pool.
+	AggregatedNodeNamePrefix = "vc-pool-"
+	// DefaultNodePool is the pool of the super cluster nodes without the pool label.
+	DefaultNodePool = "default"
+)
+
+// TenantNodeName returns the name of the virtual node presenting the super cluster node in tenant clusters.
+func TenantNodeName(config *config.SyncerConfiguration, node *corev1.Node) string {
+	if !featuregate.DefaultFeatureGate.Enabled(featuregate.VirtualNodeAggregation) {
+		return node.Name
+	}
+	pool := node.Labels[config.VirtualNodePoolLabel]
+	if config.VirtualNodePoolLabel == "" || pool == "" {
+		pool = DefaultNodePool
+	}
+	// label values allow characters that node names don't.
+	return AggregatedNodeNamePrefix + strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(pool))
+}
+
+// IsAggregatedNodeName returns true if the virtual node presents a super cluster node pool.
+func IsAggregatedNodeName(name string) bool {
+	return featuregate.DefaultFeatureGate.Enabled(featuregate.VirtualNodeAggregation) &&
+		strings.HasPrefix(name, AggregatedNodeNamePrefix)
+}
+
+// AggregateNodes returns a synthetic super cluster node with the given name standing for all nodes
+// of a pool. Its capacity is the sum of the pool, the rest is taken from the first ready node so that
+// the pool is ready as long as one of its nodes is.
+func AggregateNodes(name string, nodes []*corev1.Node) *corev1.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	representative := nodes[0]
+	for _, n := range nodes {
+		if IsNodeReady(n) {
+			representative = n
+			break
+		}
+	}
+
+	aggregated := representative.DeepCopy()
+	aggregated.Name = name
+	if aggregated.Labels != nil {
+		aggregated.Labels[corev1.LabelHostname] = name
+	}
+	// The pool is under maintenance only if all its nodes are cordoned.
+	aggregated.Spec.Unschedulable = true
+	for _, n := range nodes {
+		if !n.Spec.Unschedulable {
+			aggregated.Spec.Unschedulable = false
+			break
+		}
+	}
+	aggregated.Status.Capacity = sumResources(nodes, func(n *corev1.Node) corev1.ResourceList { return n.Status.Capacity })
+	aggregated.Status.Allocatable = sumResources(nodes, func(n *corev1.Node) corev1.ResourceList { return n.Status.Allocatable })
+	aggregated.Status.Images = nil
+	aggregated.Status.VolumesInUse = nil
+	aggregated.Status.VolumesAttached = nil
+	return aggregated
+}
+
+// IsNodeReady returns true if the node has a Ready condition with status True.
+func IsNodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func sumResources(nodes []*corev1.Node, get func(*corev1.Node) corev1.ResourceList) corev1.ResourceList {
+	sum := corev1.ResourceList{}
+	for _, n := range nodes {
+		for name, quantity := range get(n) {
+			total := sum[name]
+			total.Add(quantity)
+			sum[name] = total
+		}
+	}
+	return sum
+}
+
+// FilterPoolNodes returns the super cluster nodes presented by the virtual node name.
+func FilterPoolNodes(config *config.SyncerConfiguration, name string, nodes []*corev1.Node) []*corev1.Node {
+	var pool []*corev1.Node
+	for _, n := range nodes {
+		if TenantNodeName(config, n) == name {
+			pool = append(pool, n)
+		}
+	}
+	return pool
+}
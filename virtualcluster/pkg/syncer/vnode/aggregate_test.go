@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vnode
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+func newPoolNode(name, pool string, ready corev1.ConditionStatus, cpu string) *corev1.Node {
+	n := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{corev1.LabelHostname: name},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+			Capacity:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+		},
+	}
+	if pool != "" {
+		n.Labels["pool"] = pool
+	}
+	return n
+}
+
+func TestTenantNodeName(t *testing.T) {
+	defer func() {
+		featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(nil)
+	}()
+	cfg := &config.SyncerConfiguration{VirtualNodePoolLabel: "pool"}
+
+	featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(nil)
+	if got := TenantNodeName(cfg, newPoolNode("n1", "gpu", corev1.ConditionTrue, "1")); got != "n1" {
+		t.Errorf("expected node name without aggregation, got %s", got)
+	}
+
+	featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(map[string]bool{featuregate.VirtualNodeAggregation: true})
+	for _, tc := range []struct {
+		pool     string
+		expected string
+	}{
+		{"gpu", "vc-pool-gpu"},
+		{"GPU_Large", "vc-pool-gpu-large"},
+		{"", "vc-pool-default"},
+	} {
+		if got := TenantNodeName(cfg, newPoolNode("n1", tc.pool, corev1.ConditionTrue, "1")); got != tc.expected {
+			t.Errorf("expected %s for pool %q, got %s", tc.expected, tc.pool, got)
+		}
+	}
+	if !IsAggregatedNodeName("vc-pool-gpu") || IsAggregatedNodeName("n1") {
+		t.Errorf("unexpected aggregated node name check")
+	}
+}
+
+func TestAggregateNodes(t *testing.T) {
+	if AggregateNodes("vc-pool-gpu", nil) != nil {
+		t.Errorf("expected nil for empty pool")
+	}
+
+	n := AggregateNodes("vc-pool-gpu", []*corev1.Node{
+		newPoolNode("n1", "gpu", corev1.ConditionFalse, "2"),
+		newPoolNode("n2", "gpu", corev1.ConditionTrue, "4"),
+	})
+	if n.Name != "vc-pool-gpu" || n.Labels[corev1.LabelHostname] != "vc-pool-gpu" {
+		t.Errorf("expected pool name and hostname, got %s, %v", n.Name, n.Labels)
+	}
+	if !IsNodeReady(n) {
+		t.Errorf("expected pool with a ready node to be ready")
+	}
+	if cpu := n.Status.Capacity[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("6")) != 0 {
+		t.Errorf("expected summed cpu capacity 6, got %s", cpu.String())
+	}
+	if n.Spec.Unschedulable {
+		t.Errorf("expected pool without cordoned nodes to be schedulable")
+	}
+
+	cordoned := newPoolNode("n1", "gpu", corev1.ConditionTrue, "2")
+	cordoned.Spec.Unschedulable = true
+	if n := AggregateNodes("vc-pool-gpu", []*corev1.Node{cordoned, newPoolNode("n2", "gpu", corev1.ConditionTrue, "4")}); n.Spec.Unschedulable {
+		t.Errorf("expected partially cordoned pool to be schedulable")
+	}
+	if n := AggregateNodes("vc-pool-gpu", []*corev1.Node{cordoned}); !n.Spec.Unschedulable {
+		t.Errorf("expected fully cordoned pool to be unschedulable")
+	}
+}
@@ -23,6 +23,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	vnodeprovider "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
 )
 
@@ -156,6 +158,52 @@ func Test_provider_GetNodeTaints(t *testing.T) {
 	}
 }
 
+func Test_provider_GetNodeTaintsMaintenance(t *testing.T) {
+	defer func() {
+		featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(nil)
+	}()
+	now := metav1.Now()
+	maintenance := corev1.Taint{Key: constants.TaintSuperNodeMaintenance, Effect: corev1.TaintEffectNoSchedule, TimeAdded: &now}
+	unschedulable := corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule, TimeAdded: &now}
+	tests := []struct {
+		name          string
+		gateEnabled   bool
+		unschedulable bool
+		want          []corev1.Taint
+	}{
+		{
+			name:          "TestCordonedWithGateDisabled",
+			unschedulable: true,
+			want:          []corev1.Taint{unschedulable},
+		},
+		{
+			name:        "TestNotCordoned",
+			gateEnabled: true,
+			want:        []corev1.Taint{unschedulable},
+		},
+		{
+			name:          "TestCordoned",
+			gateEnabled:   true,
+			unschedulable: true,
+			want:          []corev1.Taint{unschedulable, maintenance},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			featuregate.DefaultFeatureGate, _ = featuregate.NewFeatureGate(map[string]bool{
+				featuregate.NodeMaintenanceEviction: tt.gateEnabled,
+			})
+			node := newNode()
+			node.Spec.Unschedulable = tt.unschedulable
+			p := NewNativeVirtualNodeProvider(8080, map[string]struct{}{}, map[string]struct{}{})
+			got := vnodeprovider.GetNodeTaints(p, node, now)
+			if taintsDiffer(got, tt.want) {
+				t.Errorf("vnodeprovider.GetNodeTaints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // This method is like https://github.com/kubernetes/kubernetes/blob/v1.21.9/pkg/util/taints/taints.go#L324
 // but only returns bool
 func taintsDiffer(t1, t2 []corev1.Taint) bool {
@@ -76,5 +76,12 @@ func GetNodeTaints(p VirtualNodeProvider, node *corev1.Node, now metav1.Time) (t
 	if !updated {
 		taints = append(taints, newTaint)
 	}
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.NodeMaintenanceEviction) && node.Spec.Unschedulable {
+		taints = append(taints, corev1.Taint{
+			Key:       constants.TaintSuperNodeMaintenance,
+			Effect:    corev1.TaintEffectNoSchedule,
+			TimeAdded: &now,
+		})
+	}
 	return taints
 }
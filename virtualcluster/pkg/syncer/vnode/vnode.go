@@ -43,6 +43,9 @@ func GetNodeProvider(config *config.SyncerConfiguration, client clientset.Interf
 		defaultLabelsToSync[labelKey] = struct{}{}
 	}
 	taintsToSync := make(map[string]struct{})
+	for taintKey := range defaultTaintsToSync {
+		taintsToSync[taintKey] = struct{}{}
+	}
 	for _, taintKey := range config.OpaqueTaintKeys {
 		taintsToSync[taintKey] = struct{}{}
 	}
@@ -93,6 +96,16 @@ var defaultLabelsToSync = map[string]struct{}{
 	corev1.LabelOSStable:   {},
 	corev1.LabelArchStable: {},
 	corev1.LabelHostname:   {},
+	// Windows pods select the build of the node, the container image must match the host build
+	corev1.LabelWindowsBuild: {},
+}
+
+// defaultTaintsToSync are the taints keeping workloads off the nodes of another operating
+// system in mixed-OS super clusters, e.g. os=windows:NoSchedule, so that tenant controllers
+// like the DaemonSet controller skip the vNodes of those nodes as well.
+var defaultTaintsToSync = map[string]struct{}{
+	"os":                 {},
+	corev1.LabelOSStable: {},
 }
 
 func nodeConditions() []corev1.NodeCondition {
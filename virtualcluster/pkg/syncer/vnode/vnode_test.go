@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vnode
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+)
+
+func TestNewVirtualNodeWindows(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "win-1",
+			Labels: map[string]string{
+				corev1.LabelOSStable:     "windows",
+				corev1.LabelHostname:     "win-1",
+				corev1.LabelWindowsBuild: "10.0.17763",
+				"pool":                   "windows",
+			},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{Key: "os", Value: "windows", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "dedicated", Value: "team-a", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{OperatingSystem: "windows"},
+		},
+	}
+
+	p := GetNodeProvider(&config.SyncerConfiguration{}, fake.NewSimpleClientset())
+	vnode, err := NewVirtualNode(p, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range []string{corev1.LabelOSStable, corev1.LabelWindowsBuild} {
+		if vnode.Labels[key] != node.Labels[key] {
+			t.Errorf("expected label %s=%s, got %q", key, node.Labels[key], vnode.Labels[key])
+		}
+	}
+	if _, ok := vnode.Labels["pool"]; ok {
+		t.Errorf("expected label pool not to be synced")
+	}
+	var taints []string
+	for _, taint := range vnode.Spec.Taints {
+		taints = append(taints, taint.Key)
+	}
+	if len(taints) != 2 || taints[0] != "os" || taints[1] != corev1.TaintNodeUnschedulable {
+		t.Errorf("expected the os and unschedulable taints, got %v", taints)
+	}
+	if vnode.Status.NodeInfo.OperatingSystem != "windows" {
+		t.Errorf("expected the node info of the node, got %+v", vnode.Status.NodeInfo)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package accounting counts the bytes flowing per tenant through the vn-agent and the syncer
+// clients, and rate-limits the requests of every tenant.
+package accounting
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Limiters holds a token bucket rate limiter per tenant. A nil Limiters doesn't limit anything.
+type Limiters struct {
+	qps   float32
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]flowcontrol.RateLimiter
+}
+
+// NewLimiters returns the limiters allowing qps requests per second with the given burst to every
+// tenant, or nil if qps isn't positive.
+func NewLimiters(qps float32, burst int) *Limiters {
+	if qps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiters{qps: qps, burst: burst, limiters: make(map[string]flowcontrol.RateLimiter)}
+}
+
+func (l *Limiters) get(tenant string) flowcontrol.RateLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.limiters[tenant]
+	if !ok {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(l.qps, l.burst)
+		l.limiters[tenant] = limiter
+	}
+	return limiter
+}
+
+// TryAccept returns true if a request of the tenant is allowed now.
+func (l *Limiters) TryAccept(tenant string) bool {
+	if l == nil {
+		return true
+	}
+	return l.get(tenant).TryAccept()
+}
+
+// Forget drops the limiter of a removed tenant.
+func (l *Limiters) Forget(tenant string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limiter, ok := l.limiters[tenant]; ok {
+		limiter.Stop()
+		delete(l.limiters, tenant)
+	}
+}
+
+// CountReader returns rc calling count with the number of bytes of every read, e.g. to account
+// the body of a watch as it is streamed rather than once it is closed.
+func CountReader(rc io.ReadCloser, count func(n int)) io.ReadCloser {
+	return &countingReadCloser{ReadCloser: rc, count: count}
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	count func(n int)
+}
+
+func (r *countingReadCloser) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	if n > 0 {
+		r.count(n)
+	}
+	return n, err
+}
+
+// CountConn returns conn calling read and written with the number of bytes of every read and
+// write, e.g. to account the hijacked connections of exec, attach and port-forward streams.
+func CountConn(conn net.Conn, read, written func(n int)) net.Conn {
+	return &countingConn{Conn: conn, read: read, written: written}
+}
+
+type countingConn struct {
+	net.Conn
+	read    func(n int)
+	written func(n int)
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.read(n)
+	}
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.written(n)
+	}
+	return n, err
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestLimiters(t *testing.T) {
+	if NewLimiters(0, 10) != nil {
+		t.Errorf("expected no limiters without qps")
+	}
+	var unlimited *Limiters
+	for i := 0; i < 10; i++ {
+		if !unlimited.TryAccept("tenant-1") {
+			t.Fatalf("expected nil limiters to accept everything")
+		}
+	}
+	unlimited.Forget("tenant-1")
+
+	limiters := NewLimiters(0.001, 2)
+	for i := 0; i < 2; i++ {
+		if !limiters.TryAccept("tenant-1") {
+			t.Fatalf("expected the burst to be accepted")
+		}
+	}
+	if limiters.TryAccept("tenant-1") {
+		t.Errorf("expected the requests above the burst to be rejected")
+	}
+	if !limiters.TryAccept("tenant-2") {
+		t.Errorf("expected the tenants to be limited separately")
+	}
+
+	limiters.Forget("tenant-1")
+	if !limiters.TryAccept("tenant-1") {
+		t.Errorf("expected a forgotten tenant to start with a full bucket")
+	}
+}
+
+func TestCountReader(t *testing.T) {
+	var total int
+	r := CountReader(ioutil.NopCloser(strings.NewReader("hello world")), func(n int) { total += n })
+	if _, err := ioutil.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	if total != len("hello world") {
+		t.Errorf("expected %d bytes to be counted, got %d", len("hello world"), total)
+	}
+}
+
+func TestCountConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	var read, written int
+	conn := CountConn(server, func(n int) { read += n }, func(n int) { written += n })
+	go func() {
+		client.Write([]byte("ping"))
+		buf := make([]byte, 8)
+		client.Read(buf)
+	}()
+
+	buf := make([]byte, 8)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("pong!")); err != nil {
+		t.Fatal(err)
+	}
+	if read != n || read != 4 || written != 5 {
+		t.Errorf("expected 4 bytes read and 5 written, got %d and %d", read, written)
+	}
+}
@@ -27,7 +27,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	clientgocache "k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/transport"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -73,6 +73,9 @@ type Cluster struct {
 	// a clientset client for unwatched tenant control plane objects (rw directly to tenant apiserver)
 	client *clientset.Clientset
 
+	// connection is the transport shared by all the clients, it is nil if Options.Connections is not set.
+	connection *Connection
+
 	options Options
 
 	// a flag indicates that the cluster cache has been synced
@@ -89,6 +92,18 @@ type Options struct {
 	// RequestTimeout is the rest client request timeout.
 	// Set this to something reasonable so request to apiserver don't hang forever.
 	RequestTimeout time.Duration
+	// AllowedExecCommands restricts the commands of the exec credential plugins the kubeconfig
+	// can run, any command is allowed if it is empty.
+	AllowedExecCommands []string
+	// ReloadKubeConfig returns the current kubeconfig of the cluster. If set, it is called when the
+	// apiserver rejects the bearer token of the kubeconfig, so that rotated tokens are picked up.
+	ReloadKubeConfig func() ([]byte, error)
+	// Connections creates the transport shared by all the clients of the cluster, whose connections
+	// are closed when the cluster is stopped. Each client has its own transport if it is nil.
+	Connections *ConnectionManager
+	// WrapTransport wraps the transport of all the clients of the cluster, e.g. to account their
+	// requests, it is ignored if nil.
+	WrapTransport transport.WrapperFunc
 }
 
 // CacheOptions is embedded in Options to configure the new Cluster's cache.
@@ -105,10 +120,17 @@ type CacheOptions struct {
 var _ mccontroller.ClusterInterface = &Cluster{}
 
 func NewCluster(key, namespace, name, uid string, getter mccontroller.Getter, configBytes []byte, o Options) (*Cluster, error) {
-	clusterRestConfig, err := clientcmd.RESTConfigFromKubeConfig(configBytes)
+	clusterRestConfig, err := restConfigFromKubeConfig(configBytes, o.AllowedExecCommands)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build rest config: %v", err)
 	}
+	if o.ReloadKubeConfig != nil && clusterRestConfig.BearerToken != "" && clusterRestConfig.ExecProvider == nil {
+		clusterRestConfig.WrapTransport = newReloadableToken(clusterRestConfig.BearerToken, o.ReloadKubeConfig).wrapTransport
+	}
+
+	if o.WrapTransport != nil {
+		clusterRestConfig.Wrap(o.WrapTransport)
+	}
 
 	if o.RequestTimeout == 0 {
 		clusterRestConfig.Timeout = constants.DefaultRequestTimeout
@@ -121,6 +143,15 @@ func NewCluster(key, namespace, name, uid string, getter mccontroller.Getter, co
 		clusterRestConfig.Burst = constants.DefaultSyncerClientBurst
 	}
 
+	var connection *Connection
+	if o.Connections != nil {
+		connection, err = o.Connections.Connect(clusterRestConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect: %v", err)
+		}
+		clusterRestConfig = connection.Config
+	}
+
 	return &Cluster{
 		key:           key,
 		name:          name,
@@ -128,6 +159,7 @@ func NewCluster(key, namespace, name, uid string, getter mccontroller.Getter, co
 		uid:           uid,
 		getter:        getter,
 		RestConfig:    clusterRestConfig,
+		connection:    connection,
 		options:       o,
 		synced:        false,
 		context:       context.Background(),
@@ -323,7 +355,10 @@ func (c *Cluster) SetKey(k string) {
 	c.key = k
 }
 
-// Stop cancel/close the cache to terminate informers.
+// Stop cancel/close the cache to terminate informers, and closes the connections to the apiserver.
 func (c *Cluster) Stop() {
 	c.cancelContext()
+	if c.connection != nil {
+		c.connection.Close()
+	}
 }
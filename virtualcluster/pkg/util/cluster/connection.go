@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	"k8s.io/client-go/util/connrotation"
+)
+
+const (
+	// DefaultDialTimeout is the default timeout of dialing a tenant apiserver.
+	DefaultDialTimeout = 30 * time.Second
+	// DefaultDialKeepAlive is the default keep-alive period of the tenant apiserver connections.
+	DefaultDialKeepAlive = 30 * time.Second
+	// DefaultMaxIdleConnsPerHost is the default number of idle connections kept per tenant apiserver.
+	DefaultMaxIdleConnsPerHost = 25
+	// DefaultIdleConnTimeout is the default time an idle tenant apiserver connection is kept.
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// ConnectionOptions are the transport settings shared by the connections to all the tenant apiservers.
+type ConnectionOptions struct {
+	// DialTimeout is the timeout of dialing a tenant apiserver.
+	DialTimeout time.Duration
+	// DialKeepAlive is the keep-alive period of the tenant apiserver connections.
+	DialKeepAlive time.Duration
+	// MaxIdleConnsPerHost is the number of idle connections kept per tenant apiserver.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is the time an idle tenant apiserver connection is kept before being closed.
+	IdleConnTimeout time.Duration
+}
+
+// ConnectionManager creates the connections to the tenant apiservers with the same transport settings.
+//
+// By default client-go caches a transport per TLS config and never releases it, and it does not cache
+// the transports of configs with a custom dialer or exec credential plugin at all, so every client of a
+// tenant opens its own connections. A Connection instead has a single transport shared by all the
+// clients and informers of a tenant, whose watches are multiplexed on the same HTTP/2 connection, and
+// all its connections are closed when the tenant is stopped.
+type ConnectionManager struct {
+	options ConnectionOptions
+	dialer  *net.Dialer
+}
+
+// NewConnectionManager returns a ConnectionManager, zero options are defaulted.
+func NewConnectionManager(o ConnectionOptions) *ConnectionManager {
+	if o.DialTimeout == 0 {
+		o.DialTimeout = DefaultDialTimeout
+	}
+	if o.DialKeepAlive == 0 {
+		o.DialKeepAlive = DefaultDialKeepAlive
+	}
+	if o.MaxIdleConnsPerHost == 0 {
+		o.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if o.IdleConnTimeout == 0 {
+		o.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	return &ConnectionManager{
+		options: o,
+		dialer: &net.Dialer{
+			Timeout:   o.DialTimeout,
+			KeepAlive: o.DialKeepAlive,
+		},
+	}
+}
+
+// Connection is the transport to a tenant apiserver.
+type Connection struct {
+	// Config is the rest config of the tenant apiserver using the transport of the connection.
+	Config *rest.Config
+
+	dialer    *connrotation.Dialer
+	transport *http.Transport
+}
+
+// Connect creates a connection to the apiserver of the given config.
+func (m *ConnectionManager) Connect(config *rest.Config) (*Connection, error) {
+	dialer := connrotation.NewDialer(m.dialer.DialContext)
+
+	dialConfig := rest.CopyConfig(config)
+	dialConfig.Dial = dialer.DialContext
+	transportConfig, err := dialConfig.TransportConfig()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := transport.TLSConfigFor(transportConfig)
+	if err != nil {
+		return nil, err
+	}
+	proxy := http.ProxyFromEnvironment
+	if transportConfig.Proxy != nil {
+		proxy = transportConfig.Proxy
+	}
+	t := utilnet.SetTransportDefaults(&http.Transport{
+		Proxy:               proxy,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConnsPerHost: m.options.MaxIdleConnsPerHost,
+		IdleConnTimeout:     m.options.IdleConnTimeout,
+		// the exec credential plugin wraps the dialer to close the connections on certificate rotation
+		DialContext:        transportConfig.Dial,
+		DisableCompression: transportConfig.DisableCompression,
+	})
+	rt, err := transport.HTTPWrappersForConfig(transportConfig, t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap the transport: %v", err)
+	}
+
+	// The credentials are handled by the wrapped transport and must not be set twice.
+	shared := rest.CopyConfig(config)
+	shared.Transport = rt
+	shared.TLSClientConfig = rest.TLSClientConfig{}
+	shared.WrapTransport = nil
+	shared.Dial = nil
+	shared.Proxy = nil
+	shared.BearerToken = ""
+	shared.BearerTokenFile = ""
+	shared.Username = ""
+	shared.Password = ""
+	shared.AuthProvider = nil
+	shared.ExecProvider = nil
+	shared.Impersonate = rest.ImpersonationConfig{}
+
+	return &Connection{Config: shared, dialer: dialer, transport: t}, nil
+}
+
+// Close closes all the connections, including the ones of running watches.
+func (c *Connection) Close() {
+	c.transport.CloseIdleConnections()
+	c.dialer.CloseAll()
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+func TestConnection(t *testing.T) {
+	var opened, closed int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"major":"1","minor":"22","gitVersion":"v1.22.4"}`)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			atomic.AddInt32(&opened, 1)
+		case http.StateClosed:
+			atomic.AddInt32(&closed, 1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	config, err := restConfigFromKubeConfig(kubeconfigWithUser(server.URL, "    token: token"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn, err := NewConnectionManager(ConnectionOptions{}).Connect(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// all the clients of the cluster share the connection
+	for i := 0; i < 3; i++ {
+		client, err := kubernetes.NewForConfig(conn.Config)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.Discovery().ServerVersion(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&opened); n != 1 {
+		t.Errorf("expected 1 connection, got %d", n)
+	}
+
+	conn.Close()
+	if err := wait.PollImmediate(100*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return atomic.LoadInt32(&closed) == 1, nil
+	}); err != nil {
+		t.Errorf("expected the connection to be closed")
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+// minTokenReloadInterval limits how often the kubeconfig is reloaded when the apiserver keeps
+// rejecting the bearer token.
+const minTokenReloadInterval = 10 * time.Second
+
+// restConfigFromKubeConfig builds the rest config of a cluster from its kubeconfig. Exec credential
+// plugins are run by client-go, which refreshes the credentials when they expire or are rejected. As
+// the plugin runs in this process, its command must be in allowedExecCommands, if not empty.
+func restConfigFromKubeConfig(configBytes []byte, allowedExecCommands []string) (*rest.Config, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(configBytes)
+	if err != nil {
+		return nil, err
+	}
+	if config.ExecProvider != nil && len(allowedExecCommands) > 0 {
+		allowed := false
+		for _, each := range allowedExecCommands {
+			if each == config.ExecProvider.Command {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("exec credential plugin %q is not allowed", config.ExecProvider.Command)
+		}
+	}
+	return config, nil
+}
+
+// reloadableToken is the bearer token of a cluster, which is reloaded from the kubeconfig of the
+// cluster when the apiserver rejects it. This lets static tokens be rotated in the kubeconfig secret
+// without re-creating the cluster.
+type reloadableToken struct {
+	reload func() ([]byte, error)
+
+	mu         sync.Mutex
+	token      string
+	lastReload time.Time
+}
+
+func newReloadableToken(token string, reload func() ([]byte, error)) *reloadableToken {
+	return &reloadableToken{token: token, reload: reload}
+}
+
+// wrapTransport is used as the WrapTransport of the rest config, all the transports built from the
+// config share the token.
+func (t *reloadableToken) wrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &tokenRefresher{rt: rt, token: t}
+}
+
+func (t *reloadableToken) get() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.token
+}
+
+// refresh returns the bearer token of the reloaded kubeconfig, or the current one if the kubeconfig
+// can't be reloaded. rejected is the token rejected by the apiserver.
+func (t *reloadableToken) refresh(rejected string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != rejected {
+		// already refreshed by a concurrent request
+		return t.token
+	}
+	if time.Since(t.lastReload) < minTokenReloadInterval {
+		return t.token
+	}
+	t.lastReload = time.Now()
+
+	configBytes, err := t.reload()
+	if err != nil {
+		klog.Warningf("failed to reload the kubeconfig: %v", err)
+		return t.token
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(configBytes)
+	if err != nil {
+		klog.Warningf("failed to parse the reloaded kubeconfig: %v", err)
+		return t.token
+	}
+	if config.BearerToken != "" && config.BearerToken != t.token {
+		klog.Infof("bearer token of %s is refreshed", config.Host)
+		t.token = config.BearerToken
+	}
+	return t.token
+}
+
+// tokenRefresher is a round tripper authenticating the requests with the reloadable token. A request
+// rejected with 401 is retried once if the token is refreshed in the meantime.
+type tokenRefresher struct {
+	rt    http.RoundTripper
+	token *reloadableToken
+}
+
+func (t *tokenRefresher) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := t.token.get()
+	resp, err := t.rt.RoundTrip(withBearerToken(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	newToken := t.token.refresh(token)
+	if newToken == token || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+
+	retry := withBearerToken(req, newToken)
+	if req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	resp.Body.Close()
+	return t.rt.RoundTrip(retry)
+}
+
+func withBearerToken(req *http.Request, token string) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+func kubeconfigWithUser(server, user string) []byte {
+	return []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: tenant
+  cluster:
+    server: %s
+    insecure-skip-tls-verify: true
+contexts:
+- name: admin
+  context:
+    cluster: tenant
+    user: admin
+current-context: admin
+users:
+- name: admin
+  user:
+%s
+`, server, user))
+}
+
+func TestRestConfigFromKubeConfig(t *testing.T) {
+	exec := `    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws-iam-authenticator
+      args: ["token", "-i", "tenant"]`
+
+	for _, tc := range []struct {
+		name    string
+		user    string
+		allowed []string
+		valid   bool
+	}{
+		{
+			name:  "static token",
+			user:  "    token: token",
+			valid: true,
+		},
+		{
+			name:  "exec plugin without allow list",
+			user:  exec,
+			valid: true,
+		},
+		{
+			name:    "allowed exec plugin",
+			user:    exec,
+			allowed: []string{"gke-gcloud-auth-plugin", "aws-iam-authenticator"},
+			valid:   true,
+		},
+		{
+			name:    "exec plugin not allowed",
+			user:    exec,
+			allowed: []string{"gke-gcloud-auth-plugin"},
+			valid:   false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := restConfigFromKubeConfig(kubeconfigWithUser("https://tenant:6443", tc.user), tc.allowed)
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestReloadableToken(t *testing.T) {
+	var validToken atomic.Value
+	validToken.Store("old")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer "+validToken.Load().(string) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"major":"1","minor":"22","gitVersion":"v1.22.4"}`)
+	}))
+	defer server.Close()
+
+	var reloads int32
+	reload := func() ([]byte, error) {
+		atomic.AddInt32(&reloads, 1)
+		return kubeconfigWithUser(server.URL, "    token: "+validToken.Load().(string)), nil
+	}
+
+	config, err := restConfigFromKubeConfig(kubeconfigWithUser(server.URL, "    token: old"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config.WrapTransport = newReloadableToken(config.BearerToken, reload).wrapTransport
+	client := kubernetes.NewForConfigOrDie(config)
+
+	if _, err := client.Discovery().ServerVersion(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&reloads); n != 0 {
+		t.Errorf("expected no reload while the token is valid, got %d", n)
+	}
+
+	// the token is rotated in the kubeconfig
+	validToken.Store("new")
+	if _, err := client.CoreV1().RESTClient().Get().AbsPath("/version").DoRaw(context.TODO()); err != nil {
+		t.Fatalf("expected the request to be retried with the new token: %v", err)
+	}
+	if n := atomic.LoadInt32(&reloads); n != 1 {
+		t.Errorf("expected 1 reload, got %d", n)
+	}
+
+	// the kubeconfig is reloaded at most once per minTokenReloadInterval
+	validToken.Store("newer")
+	if _, err := client.Discovery().ServerVersion(); err == nil {
+		t.Errorf("expected the request to fail before the token can be reloaded again")
+	}
+	if n := atomic.LoadInt32(&reloads); n != 1 {
+		t.Errorf("expected 1 reload, got %d", n)
+	}
+}
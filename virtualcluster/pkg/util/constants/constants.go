@@ -28,6 +28,11 @@ import (
 const (
 	SuperClusterInfoCfgMap = "supercluster-info"
 	SuperClusterIDKey      = "id"
+	// SuperClusterUnschedulableKey cordons the super cluster when set to "true" in the supercluster-info
+	// configmap: no new namespace is placed there, but the existing ones are still synced.
+	SuperClusterUnschedulableKey = "unschedulable"
+	// OwnershipBackfillCfgMap records in kube-system that the syncer backfilled the ownership signatures.
+	OwnershipBackfillCfgMap = "vc-syncer-ownership-backfill"
 )
 
 const (
@@ -37,8 +42,27 @@ const (
 	// LabelScheduledPlacements is the scheduled placements the namespace schedules to.
 	LabelScheduledPlacements = "scheduler.virtualcluster.io/placements"
 
+	// LabelLastScheduleTime is the time the placements of the namespace were last updated by the scheduler.
+	LabelLastScheduleTime = "scheduler.virtualcluster.io/lastScheduleTime"
+
+	// NamespaceScheduled is the namespace condition reporting the scheduling outcome of the namespace.
+	NamespaceScheduled corev1.NamespaceConditionType = "Scheduled"
+
 	// LabelNamespaceSlice is the scheduled slice size of the namespace.
 	LabelNamespaceSlice = "scheduler.virtualcluster.io/slice"
+
+	// LabelSchedulingPriority is the scheduling priority of the namespaces of a VirtualCluster, set on
+	// the VirtualCluster. A tenant namespace may lower its own priority with the same annotation.
+	LabelSchedulingPriority = "scheduler.virtualcluster.io/priority"
+
+	// LabelSchedulingZone is the zone of the super clusters, as in the topology.kubernetes.io/zone label of
+	// their nodes, the slices of a tenant namespace are placed in, set on the tenant namespace.
+	LabelSchedulingZone = "scheduler.virtualcluster.io/zone"
+
+	// ReasonPlacementChanged is the reason of the events recorded by the scheduler in the default namespace
+	// of a super cluster when the placements of a tenant namespace in this super cluster change. The events
+	// are annotated with the cluster and the name of the tenant namespace, and with LabelLastScheduleTime.
+	ReasonPlacementChanged = "PlacementChanged"
 )
 
 var DefaultNamespaceSlice = corev1.ResourceList{
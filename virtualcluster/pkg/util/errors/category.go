@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Category tells who has to act on an error. It is used as the reason of the
+// events and conditions recording the error, so automation can retry the
+// transient failures and route the user errors back to the tenant owners.
+type Category string
+
+const (
+	// CategoryUserError is an error in the objects of the user, e.g. an invalid
+	// spec or a missing ClusterVersion, it persists until the user fixes them.
+	CategoryUserError Category = "UserError"
+	// CategoryTransientInfra is a failure of the infrastructure expected to
+	// recover on retry, e.g. a timeout or an unavailable apiserver.
+	CategoryTransientInfra Category = "TransientInfra"
+	// CategoryBug is a state the components are not expected to reach, it has to
+	// be reported to the maintainers.
+	CategoryBug Category = "Bug"
+)
+
+// Retriable returns whether retrying may succeed without any change of the user objects.
+func (c Category) Retriable() bool {
+	return c == CategoryTransientInfra
+}
+
+type categorizedError struct {
+	category Category
+	err      error
+}
+
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+// Cause returns the categorized error, for github.com/pkg/errors.
+func (e *categorizedError) Cause() error {
+	return e.err
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.err
+}
+
+// WithCategory categorizes err, it returns nil if err is nil.
+func WithCategory(err error, category Category) error {
+	if err == nil {
+		return nil
+	}
+	return &categorizedError{category: category, err: err}
+}
+
+// NewUserError returns an error of the user objects.
+func NewUserError(format string, args ...interface{}) error {
+	return WithCategory(fmt.Errorf(format, args...), CategoryUserError)
+}
+
+// NewTransientInfra returns a transient failure of the infrastructure.
+func NewTransientInfra(format string, args ...interface{}) error {
+	return WithCategory(fmt.Errorf(format, args...), CategoryTransientInfra)
+}
+
+// NewBug returns an error that is not expected to happen.
+func NewBug(format string, args ...interface{}) error {
+	return WithCategory(fmt.Errorf(format, args...), CategoryBug)
+}
+
+// CategoryOf returns the category of err. An error not explicitly categorized is
+// categorized by its apiserver status, the invalid and bad requests being user
+// errors. Any other error is transient, so that it is retried.
+func CategoryOf(err error) Category {
+	var categorized *categorizedError
+	if errors.As(err, &categorized) {
+		return categorized.category
+	}
+	if apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) || apierrors.IsRequestEntityTooLargeError(err) {
+		return CategoryUserError
+	}
+	return CategoryTransientInfra
+}
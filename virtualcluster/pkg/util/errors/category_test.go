@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pkgerr "github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestCategoryOf(t *testing.T) {
+	invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "StatefulSet"}, "etcd", field.ErrorList{field.Required(field.NewPath("spec"), "")})
+	for name, tc := range map[string]struct {
+		err      error
+		expected Category
+	}{
+		"user error":              {err: NewUserError("ClusterVersion %s not found", "cv"), expected: CategoryUserError},
+		"wrapped user error":      {err: fmt.Errorf("fail to create: %w", NewUserError("invalid")), expected: CategoryUserError},
+		"pkg wrapped user error":  {err: pkgerr.Wrap(NewUserError("invalid"), "fail to create"), expected: CategoryUserError},
+		"bug":                     {err: NewBug("unknown phase"), expected: CategoryBug},
+		"transient infra":         {err: NewTransientInfra("cache not synced"), expected: CategoryTransientInfra},
+		"invalid object":          {err: invalid, expected: CategoryUserError},
+		"wrapped invalid object":  {err: fmt.Errorf("fail to apply: %w", invalid), expected: CategoryUserError},
+		"bad request":             {err: apierrors.NewBadRequest("bad"), expected: CategoryUserError},
+		"categorized invalid":     {err: WithCategory(invalid, CategoryBug), expected: CategoryBug},
+		"server timeout":          {err: apierrors.NewServerTimeout(schema.GroupResource{Resource: "pods"}, "get", 1), expected: CategoryTransientInfra},
+		"context deadline":        {err: context.DeadlineExceeded, expected: CategoryTransientInfra},
+		"uncategorized error":     {err: fmt.Errorf("connection refused"), expected: CategoryTransientInfra},
+		"uncategorized not found": {err: apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "admin-kubeconfig"), expected: CategoryTransientInfra},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if category := CategoryOf(tc.err); category != tc.expected {
+				t.Errorf("expected category %s, got %s", tc.expected, category)
+			}
+		})
+	}
+
+	if WithCategory(nil, CategoryBug) != nil {
+		t.Errorf("expected nil error not to be categorized")
+	}
+	if err := NewUserError("ClusterVersion %s not found", "cv"); err.Error() != "ClusterVersion cv not found" {
+		t.Errorf("expected the message to be kept, got %q", err.Error())
+	}
+	if !CategoryTransientInfra.Retriable() || CategoryUserError.Retriable() || CategoryBug.Retriable() {
+		t.Errorf("expected only transient infra errors to be retriable")
+	}
+}
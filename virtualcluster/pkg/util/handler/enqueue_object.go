@@ -26,6 +26,8 @@ type EnqueueRequestForObject struct {
 	ClusterName string
 	Queue       Queue
 	AttachUID   bool
+	// UpdatePredicate filters the updates, all of them are enqueued if it is nil.
+	UpdatePredicate UpdatePredicate
 }
 
 func (e *EnqueueRequestForObject) enqueue(obj interface{}) {
@@ -50,6 +52,9 @@ func (e *EnqueueRequestForObject) OnAdd(obj interface{}) {
 }
 
 func (e *EnqueueRequestForObject) OnUpdate(oldObj, newObj interface{}) {
+	if e.UpdatePredicate != nil && !e.UpdatePredicate(oldObj, newObj) {
+		return
+	}
 	e.enqueue(newObj)
 }
 
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// UpdatePredicate returns false for the updates that don't need to be reconciled.
+type UpdatePredicate func(oldObj, newObj interface{}) bool
+
+// StatusOnlyUpdate returns true if the objects only differ in their status and in the metadata
+// maintained by the apiserver, e.g. after a write to the status subresource.
+func StatusOnlyUpdate(oldObj, newObj interface{}) bool {
+	oldContent, ok := withoutStatus(oldObj)
+	if !ok {
+		return false
+	}
+	newContent, ok := withoutStatus(newObj)
+	if !ok {
+		return false
+	}
+	return equality.Semantic.DeepEqual(oldContent, newContent)
+}
+
+// withoutStatus returns the content of the object without its status, resource version and
+// managed fields.
+func withoutStatus(obj interface{}) (map[string]interface{}, bool) {
+	if obj == nil {
+		return nil, false
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, false
+	}
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "resourceVersion")
+		delete(metadata, "managedFields")
+	}
+	return content, true
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStatusOnlyUpdate(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1", Namespace: "ns", ResourceVersion: "1", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "c1", Image: "image"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+
+	status := pod.DeepCopy()
+	status.ResourceVersion = "2"
+	status.Status.Phase = corev1.PodRunning
+	status.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubelet", Operation: metav1.ManagedFieldsOperationUpdate}}
+
+	labels := pod.DeepCopy()
+	labels.ResourceVersion = "2"
+	labels.Labels["app"] = "api"
+
+	spec := status.DeepCopy()
+	spec.ResourceVersion = "3"
+	spec.Spec.Containers[0].Image = "image:v2"
+
+	for _, tc := range []struct {
+		name     string
+		old, new interface{}
+		expected bool
+	}{
+		{name: "status", old: pod, new: status, expected: true},
+		{name: "resync", old: pod, new: pod, expected: true},
+		{name: "labels", old: pod, new: labels, expected: false},
+		{name: "spec and status", old: pod, new: spec, expected: false},
+		{name: "unknown old object", old: nil, new: pod, expected: false},
+		{name: "invalid object", old: invalidAPIObject{A: "a"}, new: invalidAPIObject{A: "a"}, expected: false},
+	} {
+		if got := StatusOnlyUpdate(tc.old, tc.new); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestEnqueueRequestForObjectUpdatePredicate(t *testing.T) {
+	internalQueue := &fifoQueue{}
+	queue := &EnqueueRequestForObject{
+		ClusterName: "test-cluster",
+		Queue:       internalQueue,
+		UpdatePredicate: func(oldObj, newObj interface{}) bool {
+			return !StatusOnlyUpdate(oldObj, newObj)
+		},
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "n1", Namespace: "ns"}}
+	updated := pod.DeepCopy()
+	updated.Status.Phase = corev1.PodRunning
+	queue.OnUpdate(pod, updated)
+	if item, err := internalQueue.Get(); err == nil {
+		t.Errorf("expected the status only update to be dropped, got %v", item)
+	}
+
+	updated.Labels = map[string]string{"app": "web"}
+	queue.OnUpdate(pod, updated)
+	if _, err := internalQueue.Get(); err != nil {
+		t.Errorf("expected the update to be enqueued")
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mccontroller
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/freeze"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+// SyncFrozenFor returns the remaining time the downward sync of the tenant namespace is frozen for,
+// 0 if it is not frozen or the freeze is not honored.
+func (c *MultiClusterController) SyncFrozenFor(clusterName, namespace string) time.Duration {
+	remaining, _, _ := c.syncFrozenFor(clusterName, namespace)
+	return remaining
+}
+
+// syncFrozenFor also returns the tenant namespace and the reason its freeze is not honored.
+func (c *MultiClusterController) syncFrozenFor(clusterName, namespace string) (time.Duration, *corev1.Namespace, error) {
+	if !freeze.Enabled() || namespace == "" {
+		return 0, nil, nil
+	}
+	vNamespace := &corev1.Namespace{}
+	if err := c.Get(clusterName, "", namespace, vNamespace); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.V(4).Infof("failed to get ns %s of cluster %s to check its sync freeze: %v", namespace, clusterName, err)
+		}
+		return 0, nil, nil
+	}
+	remaining, err := freeze.FrozenFor(clusterName, vNamespace, time.Now())
+	return remaining, vNamespace, err
+}
+
+// holdFrozenRequest requeues the request once the sync freeze of its tenant namespace ends, and
+// returns false if the namespace is not frozen. The rejected freezes are only recorded by the
+// namespace controller, so that each rejection is recorded once on the tenant namespace.
+func (c *MultiClusterController) holdFrozenRequest(req reconciler.Request) bool {
+	namespace := req.Namespace
+	if c.objectKind == "Namespace" {
+		namespace = req.Name
+	}
+	remaining, vNamespace, err := c.syncFrozenFor(req.ClusterName, namespace)
+	if err != nil {
+		if c.objectKind == "Namespace" {
+			ref := &corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: vNamespace.Name, UID: vNamespace.UID}
+			if eventErr := c.Eventf(req.ClusterName, ref, corev1.EventTypeWarning, constants.ReasonSyncFreezeRejected, "Sync freeze is not honored: %v", err); eventErr != nil {
+				klog.Errorf("failed to record the rejected sync freeze of %v: %v", req, eventErr)
+			}
+		}
+		return false
+	}
+	if remaining <= 0 {
+		return false
+	}
+	klog.V(4).Infof("%s dws request %v is held back, the sync of namespace %s is frozen for %v", c.name, req, namespace, remaining)
+	c.Queue.AddAfter(req, remaining)
+	return true
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mccontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/freeze"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+// clientCluster is a cluster served by fake clients.
+type clientCluster struct {
+	ClusterInterface
+	clientset clientset.Interface
+	client    client.Client
+}
+
+func (c *clientCluster) GetClientSet() (clientset.Interface, error) {
+	return c.clientset, nil
+}
+
+func (c *clientCluster) GetDelegatingClient() (client.Client, error) {
+	return c.client, nil
+}
+
+func tenantRequest(namespace, name string) reconciler.Request {
+	req := reconciler.Request{ClusterName: "tenant"}
+	req.Namespace, req.Name = namespace, name
+	return req
+}
+
+func TestHoldFrozenRequest(t *testing.T) {
+	defer func(d time.Duration) { freeze.MaxDuration = d }(freeze.MaxDuration)
+
+	frozen := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "frozen", Annotations: map[string]string{
+		constants.LabelSyncFreezeUntil: time.Now().Add(30 * time.Minute).UTC().Format(time.RFC3339)}}}
+	rejected := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "rejected", Annotations: map[string]string{
+		constants.LabelSyncFreezeUntil: time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)}}}
+	active := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "active"}}
+
+	testcases := map[string]struct {
+		object   client.Object
+		req      reconciler.Request
+		disabled bool
+
+		expectedHeld   bool
+		expectedEvents int
+	}{
+		"pod of a frozen namespace": {
+			object:       &corev1.Pod{},
+			req:          tenantRequest("frozen", "web-0"),
+			expectedHeld: true,
+		},
+		"frozen namespace": {
+			object:       &corev1.Namespace{},
+			req:          tenantRequest("", "frozen"),
+			expectedHeld: true,
+		},
+		"pod of an active namespace": {
+			object: &corev1.Pod{},
+			req:    tenantRequest("active", "web-0"),
+		},
+		"pod of a missing namespace": {
+			object: &corev1.Pod{},
+			req:    tenantRequest("missing", "web-0"),
+		},
+		"cluster scoped object": {
+			object: &corev1.PersistentVolume{},
+			req:    tenantRequest("", "frozen"),
+		},
+		"pod of a namespace frozen for too long": {
+			object: &corev1.Pod{},
+			req:    tenantRequest("rejected", "web-0"),
+		},
+		"namespace frozen for too long": {
+			object:         &corev1.Namespace{},
+			req:            tenantRequest("", "rejected"),
+			expectedEvents: 1,
+		},
+		"freezes disabled": {
+			object:   &corev1.Pod{},
+			req:      tenantRequest("frozen", "web-0"),
+			disabled: true,
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			freeze.MaxDuration = time.Hour
+			if tc.disabled {
+				freeze.MaxDuration = 0
+			}
+			c, err := NewMCController(tc.object, &corev1.PodList{}, nopReconciler{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tenantClientset := fake.NewSimpleClientset()
+			c.clusters["tenant"] = &clientCluster{
+				clientset: tenantClientset,
+				client:    fakeClient.NewClientBuilder().WithObjects(frozen.DeepCopy(), rejected.DeepCopy(), active.DeepCopy()).Build(),
+			}
+
+			if held := c.holdFrozenRequest(tc.req); held != tc.expectedHeld {
+				t.Errorf("expected held %v, got %v", tc.expectedHeld, held)
+			}
+			events, err := tenantClientset.CoreV1().Events(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(events.Items) != tc.expectedEvents {
+				t.Errorf("expected %d events, got %d", tc.expectedEvents, len(events.Items))
+			}
+			for _, event := range events.Items {
+				if event.Reason != constants.ReasonSyncFreezeRejected || event.InvolvedObject.Name != tc.req.Name {
+					t.Errorf("unexpected event %+v", event)
+				}
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package mccontroller
 import (
 	"context"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -29,7 +30,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/runtime"
 	clientset "k8s.io/client-go/kubernetes"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -40,7 +41,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metalimit"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/objectlimit"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/scheme"
 	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
@@ -49,6 +52,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/handler"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/record"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/shutdown"
 )
 
 // Cache is the interface used by Controller to start and wait for caches to sync.
@@ -110,6 +114,10 @@ type MultiClusterController struct {
 	// clusters is the internal cluster set this controller watches.
 	clusters map[string]ClusterInterface
 
+	// versionedTypes are the versions of the object type watched in the clusters
+	// not serving objectType.
+	versionedTypes map[string]*VersionedType
+
 	Options
 }
 
@@ -126,6 +134,13 @@ type Options struct {
 	// Queue can be used to override the default queue.
 	Queue workqueue.RateLimitingInterface
 
+	// VersionedTypes are the older versions of the object type, watched in the clusters
+	// not serving the object type.
+	VersionedTypes []VersionedType
+
+	// UpdatePredicate filters the updates of the watched objects, all of them are reconciled if it is nil.
+	UpdatePredicate handler.UpdatePredicate
+
 	// name is used to uniquely identify a Controller in tracing, logging and monitoring.  Name is required.
 	name string
 }
@@ -141,6 +156,8 @@ func NewMCController(objectType client.Object, objectListType client.ObjectList,
 		objectType: objectType,
 		objectKind: kinds[0].Kind,
 		clusters:   make(map[string]ClusterInterface),
+
+		versionedTypes: make(map[string]*VersionedType),
 		Options: Options{
 			name:                    fmt.Sprintf("%s-mccontroller", strings.ToLower(kinds[0].Kind)),
 			JitterPeriod:            1 * time.Second,
@@ -181,8 +198,12 @@ func (c *MultiClusterController) WatchClusterResource(cluster ClusterInterface,
 		return nil
 	}
 
-	h := &handler.EnqueueRequestForObject{ClusterName: cluster.GetClusterName(), Queue: c.Queue, AttachUID: o.AttachUID}
-	return cluster.AddEventHandler(c.objectType, h)
+	objectType := c.objectType
+	if t := c.versionedTypes[cluster.GetClusterName()]; t != nil {
+		objectType = t.ObjectType
+	}
+	h := &handler.EnqueueRequestForObject{ClusterName: cluster.GetClusterName(), Queue: c.Queue, AttachUID: o.AttachUID, UpdatePredicate: c.UpdatePredicate}
+	return cluster.AddEventHandler(objectType, h)
 }
 
 // RegisterClusterResource get the informer *before* trying to wait for the
@@ -193,14 +214,81 @@ func (c *MultiClusterController) RegisterClusterResource(cluster ClusterInterfac
 	if _, exist := c.clusters[cluster.GetClusterName()]; exist {
 		return nil
 	}
-	c.clusters[cluster.GetClusterName()] = cluster
 
 	if c.objectType == nil {
+		c.clusters[cluster.GetClusterName()] = cluster
 		return nil
 	}
 
-	_, err := cluster.GetInformer(c.objectType)
-	return err
+	// a cluster serving no version of the object type is not watched, rather than
+	// failing the cache sync of the cluster.
+	versionedType, err := c.negotiateObjectType(cluster)
+	if err != nil {
+		return err
+	}
+	c.clusters[cluster.GetClusterName()] = cluster
+
+	objectType := c.objectType
+	if versionedType != nil {
+		klog.Infof("cluster %s does not serve the version of %s watched, watch %T instead", cluster.GetClusterName(), c.objectKind, versionedType.ObjectType)
+		c.versionedTypes[cluster.GetClusterName()] = versionedType
+		objectType = versionedType.ObjectType
+	}
+	informer, err := cluster.GetInformer(objectType)
+	if err != nil {
+		return err
+	}
+	if informer != nil {
+		c.instrumentInformer(cluster, informer)
+	}
+	return nil
+}
+
+// instrumentInformer counts the cached objects, the resyncs and the watch restarts of the
+// informer of the cluster. The metrics of a cluster are deleted when it is torn down.
+func (c *MultiClusterController) instrumentInformer(cluster ClusterInterface, informer cache.Informer) {
+	clusterName := cluster.GetClusterName()
+	// the events received after the teardown must not recreate the metrics of the cluster.
+	watched := func() bool {
+		return c.GetCluster(clusterName) == cluster
+	}
+
+	if i, ok := informer.(interface {
+		SetWatchErrorHandler(clientgocache.WatchErrorHandler) error
+	}); ok {
+		// the handler can't be set once the informer is started, e.g. by another controller.
+		if err := i.SetWatchErrorHandler(func(r *clientgocache.Reflector, err error) {
+			if watched() {
+				metrics.WatchRestartCounter.WithLabelValues(c.objectKind, clusterName).Inc()
+			}
+			clientgocache.DefaultWatchErrorHandler(r, err)
+		}); err != nil {
+			klog.V(4).Infof("cannot count the watch restarts of %s in cluster %s: %v", c.objectKind, clusterName, err)
+		}
+	}
+
+	informer.AddEventHandler(clientgocache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if watched() {
+				metrics.CachedObjects.WithLabelValues(c.objectKind, clusterName).Inc()
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, oldErr := meta.Accessor(oldObj)
+			newMeta, newErr := meta.Accessor(newObj)
+			if oldErr != nil || newErr != nil || oldMeta.GetResourceVersion() != newMeta.GetResourceVersion() {
+				return
+			}
+			if watched() {
+				metrics.InformerResyncCounter.WithLabelValues(c.objectKind, clusterName).Inc()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if watched() {
+				metrics.CachedObjects.WithLabelValues(c.objectKind, clusterName).Dec()
+			}
+		},
+	})
 }
 
 // TeardownClusterResource forget the cluster it watches.
@@ -209,20 +297,16 @@ func (c *MultiClusterController) TeardownClusterResource(cluster ClusterInterfac
 	c.Lock()
 	defer c.Unlock()
 	delete(c.clusters, cluster.GetClusterName())
+	delete(c.versionedTypes, cluster.GetClusterName())
+	metrics.DeleteInformerMetrics(c.objectKind, cluster.GetClusterName())
 }
 
 // Start starts the ClustersController's control loops (as many as MaxConcurrentReconciles) in separate channels
-// and blocks until an empty struct is sent to the stop channel.
+// and blocks until an empty struct is sent to the stop channel and the queued requests are drained.
 func (c *MultiClusterController) Start(stop <-chan struct{}) error {
 	klog.Infof("start mc-controller %q", c.name)
 
-	defer c.Queue.ShutDown()
-
-	for i := 0; i < c.MaxConcurrentReconciles; i++ {
-		go wait.Until(c.worker, c.JitterPeriod, stop)
-	}
-
-	<-stop
+	shutdown.RunWorkers(c.name, c.Queue, c.MaxConcurrentReconciles, c.worker, c.JitterPeriod, stop)
 	return nil
 }
 
@@ -246,10 +330,18 @@ func (c *MultiClusterController) Get(clusterName, namespace, name string, obj cl
 	if err != nil {
 		return err
 	}
-	return delegatingClient.Get(context.TODO(), client.ObjectKey{
+	key := client.ObjectKey{
 		Namespace: namespace,
 		Name:      name,
-	}, obj)
+	}
+	if t := c.GetVersionedType(clusterName); t != nil {
+		versioned := t.ObjectType.DeepCopyObject().(client.Object)
+		if err := delegatingClient.Get(context.TODO(), key, versioned); err != nil {
+			return err
+		}
+		return t.Convert(versioned, obj)
+	}
+	return delegatingClient.Get(context.TODO(), key, obj)
 }
 
 // List returns a list of objects with specific cluster.
@@ -264,6 +356,25 @@ func (c *MultiClusterController) List(clusterName string, instanceList client.Ob
 		return err
 	}
 
+	if t := c.GetVersionedType(clusterName); t != nil {
+		versionedList := t.ObjectListType.DeepCopyObject().(client.ObjectList)
+		if err := delegatingClient.List(context.TODO(), versionedList, opts...); err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(versionedList)
+		if err != nil {
+			return err
+		}
+		converted := make([]runtime.Object, 0, len(items))
+		for _, item := range items {
+			obj := c.objectType.DeepCopyObject().(client.Object)
+			if err := t.Convert(item.(client.Object), obj); err != nil {
+				return err
+			}
+			converted = append(converted, obj)
+		}
+		return meta.SetList(instanceList, converted)
+	}
 	return delegatingClient.List(context.TODO(), instanceList, opts...)
 }
 
@@ -324,8 +435,12 @@ func (c *MultiClusterController) GetClusterNames() []string {
 // You want to make that easy.
 // 'message' is intended to be human readable.
 //
-// The resulting event will be created in the same namespace as the reference object.
+// The resulting event will be created in the same namespace as the reference object. The event is
+// also logged, so that the super cluster operators can audit it with the reason code.
 func (c *MultiClusterController) Eventf(clusterName string, ref *corev1.ObjectReference, eventtype string, reason, messageFmt string, args ...interface{}) error {
+	message := fmt.Sprintf(messageFmt, args...)
+	klog.InfoS("tenant object event", "cluster", clusterName, "kind", ref.Kind, "namespace", ref.Namespace,
+		"name", ref.Name, "uid", ref.UID, "type", eventtype, "reason", reason, "message", message)
 	tenantClient, err := c.GetClusterClient(clusterName)
 	if err != nil {
 		return fmt.Errorf("failed to create client from cluster %s config: %v", clusterName, err)
@@ -347,7 +462,7 @@ func (c *MultiClusterController) Eventf(clusterName string, ref *corev1.ObjectRe
 		InvolvedObject:      *ref,
 		Type:                eventtype,
 		Reason:              reason,
-		Message:             fmt.Sprintf(messageFmt, args...),
+		Message:             message,
 		FirstTimestamp:      eventTime,
 		LastTimestamp:       eventTime,
 		ReportingController: "vc-syncer",
@@ -434,6 +549,12 @@ func (c *MultiClusterController) processNextWorkItem() bool {
 		}
 	}
 
+	// the tenant froze the downward sync of the namespace, the request is reconciled once the freeze ends
+	if c.holdFrozenRequest(req) {
+		c.Queue.Forget(obj)
+		return true
+	}
+
 	defer metrics.RecordDWSOperationDuration(c.objectKind, req.ClusterName, time.Now())
 
 	// RunInformersAndControllers the syncHandler, passing it the cluster/namespace/Name
@@ -452,6 +573,31 @@ func (c *MultiClusterController) processNextWorkItem() bool {
 		return true
 	}
 
+	// the metadata of the tenant object exceeds the limits, it is retried once the tenant updates it
+	var violation *metalimit.ViolationError
+	if goerrors.As(err, &violation) {
+		metrics.RecordDWSOperationStatus(c.objectKind, req.ClusterName, utilconstants.StatusCodeBadRequest)
+		klog.Warningf("%s dws request is rejected: %v", c.name, err)
+		if eventErr := c.Eventf(req.ClusterName, &violation.Object, corev1.EventTypeWarning, constants.ReasonMetadataLimitExceeded, "Error syncing: %s", violation.Message); eventErr != nil {
+			klog.Errorf("failed to record the metadata limit violation of %v: %v", req, eventErr)
+		}
+		c.Queue.Forget(obj)
+		return true
+	}
+
+	// the tenant exceeds its object limits, it is retried once other objects may have been deleted
+	var exceeded *objectlimit.ExceededError
+	if goerrors.As(err, &exceeded) {
+		metrics.RecordDWSOperationStatus(c.objectKind, req.ClusterName, utilconstants.StatusCodeBadRequest)
+		klog.Warningf("%s dws request is rejected: %v", c.name, err)
+		if eventErr := c.Eventf(req.ClusterName, &exceeded.Object, corev1.EventTypeWarning, constants.ReasonObjectLimitExceeded, "Error syncing: %s", exceeded.Message); eventErr != nil {
+			klog.Errorf("failed to record the object limit violation of %v: %v", req, eventErr)
+		}
+		c.Queue.Forget(obj)
+		c.Queue.AddAfter(req, objectlimit.RetryPeriod)
+		return true
+	}
+
 	// rejected by apiserver(maybe rejected by webhook or other admission plugins)
 	// we take a negative attitude on this situation and fail fast.
 	if apierr, ok := err.(apierrors.APIStatus); ok {
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mccontroller
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgocache "k8s.io/client-go/tools/cache"
+	fcache "k8s.io/client-go/tools/cache/testing"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+type nopReconciler struct{}
+
+func (nopReconciler) Reconcile(reconciler.Request) (reconciler.Result, error) {
+	return reconciler.Result{}, nil
+}
+
+// informerCluster is a cluster whose informer lists and watches a fake source.
+type informerCluster struct {
+	ClusterInterface
+	name     string
+	informer clientgocache.SharedIndexInformer
+}
+
+func (c *informerCluster) GetClusterName() string {
+	return c.name
+}
+
+func (c *informerCluster) GetInformer(client.Object) (cache.Informer, error) {
+	return c.informer, nil
+}
+
+// failingListWatch fails to list while failed is set.
+type failingListWatch struct {
+	*fcache.FakeControllerSource
+	failed int32
+}
+
+func (lw *failingListWatch) List(options metav1.ListOptions) (runtime.Object, error) {
+	if atomic.LoadInt32(&lw.failed) != 0 {
+		return nil, fmt.Errorf("list failed")
+	}
+	return lw.FakeControllerSource.List(options)
+}
+
+func newPod(name, resourceVersion string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, ResourceVersion: resourceVersion}}
+}
+
+func TestInformerMetrics(t *testing.T) {
+	c, err := NewMCController(&corev1.Pod{}, &corev1.PodList{}, nopReconciler{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the informer can't list until failed is cleared.
+	source := &failingListWatch{FakeControllerSource: fcache.NewFakeControllerSource(), failed: 1}
+	source.Add(newPod("a", "1"))
+	source.Add(newPod("b", "1"))
+	cluster := &informerCluster{
+		name:     "cluster-metrics",
+		informer: clientgocache.NewSharedIndexInformer(source, &corev1.Pod{}, time.Second, clientgocache.Indexers{}),
+	}
+	if err := c.RegisterClusterResource(cluster, WatchOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go cluster.informer.Run(stop)
+
+	cached := metrics.CachedObjects.WithLabelValues("Pod", cluster.name)
+	waitFor := func(cond func() bool) {
+		if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) { return cond(), nil }); err != nil {
+			t.Fatalf("timed out: cached objects %v, resyncs %v, watch restarts %v", testutil.ToFloat64(cached),
+				testutil.ToFloat64(metrics.InformerResyncCounter.WithLabelValues("Pod", cluster.name)),
+				testutil.ToFloat64(metrics.WatchRestartCounter.WithLabelValues("Pod", cluster.name)))
+		}
+	}
+
+	waitFor(func() bool {
+		return testutil.ToFloat64(metrics.WatchRestartCounter.WithLabelValues("Pod", cluster.name)) > 0
+	})
+	atomic.StoreInt32(&source.failed, 0)
+
+	waitFor(func() bool { return testutil.ToFloat64(cached) == 2 })
+	source.Delete(newPod("a", "2"))
+	waitFor(func() bool { return testutil.ToFloat64(cached) == 1 })
+	waitFor(func() bool {
+		return testutil.ToFloat64(metrics.InformerResyncCounter.WithLabelValues("Pod", cluster.name)) > 0
+	})
+
+	c.TeardownClusterResource(cluster)
+	if n := testutil.CollectAndCount(metrics.CachedObjects); n != 0 {
+		t.Errorf("expected the metrics of the removed cluster to be deleted, got %d series", n)
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	"k8s.io/client-go/util/workqueue"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/handler"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
 
@@ -37,6 +38,8 @@ func WithOptions(o *Options) OptConfig {
 		WithWorkQueue(o.Queue)(options)
 		WithJitterPeriod(o.JitterPeriod)(options)
 		WithMaxConcurrentReconciles(o.MaxConcurrentReconciles)(options)
+		WithVersionedTypes(o.VersionedTypes...)(options)
+		WithUpdatePredicate(o.UpdatePredicate)(options)
 	}
 }
 
@@ -84,3 +87,19 @@ func WithMaxConcurrentReconciles(n int) OptConfig {
 		}
 	}
 }
+
+// WithVersionedTypes set the older versions of the object type.
+func WithVersionedTypes(types ...VersionedType) OptConfig {
+	return func(options *Options) {
+		options.VersionedTypes = append(options.VersionedTypes, types...)
+	}
+}
+
+// WithUpdatePredicate set the filter of the updates of the watched objects.
+func WithUpdatePredicate(p handler.UpdatePredicate) OptConfig {
+	return func(options *Options) {
+		if p != nil {
+			options.UpdatePredicate = p
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mccontroller
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/scheme"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+)
+
+// MinimumServerVersion is the oldest Kubernetes version of the tenant control planes the syncer
+// supports, the first version serving the apiextensions.k8s.io/v1 CustomResourceDefinitions.
+var MinimumServerVersion = version.MustParseGeneric("v1.16.0")
+
+// VersionedType is an older version of the object type of a MultiClusterController. It is watched in
+// the clusters not serving the version of the object type, e.g. the networking.k8s.io/v1beta1
+// Ingresses of the control planes older than v1.19.
+type VersionedType struct {
+	// ObjectType is the type of object to watch, e.g. &networkingv1beta1.Ingress{}
+	ObjectType client.Object
+	// ObjectListType is the list type of ObjectType, e.g. &networkingv1beta1.IngressList{}
+	ObjectListType client.ObjectList
+	// Convert converts in, an object of ObjectType or of the object type of the controller, to out,
+	// an object of the other type.
+	Convert func(in, out client.Object) error
+}
+
+// CheckServerVersion returns the Kubernetes version of the cluster, and an error if the syncer
+// doesn't support it.
+func CheckServerVersion(cluster ClusterInterface) (*version.Version, error) {
+	cs, err := cluster.GetClientSet()
+	if err != nil {
+		return nil, err
+	}
+	info, err := cs.Discovery().ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the version of cluster %s: %v", cluster.GetClusterName(), err)
+	}
+	v, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the version %q of cluster %s: %v", info.GitVersion, cluster.GetClusterName(), err)
+	}
+	if v.LessThan(MinimumServerVersion) {
+		return v, errors.NewUserError("cluster %s runs Kubernetes v%s, the oldest version supported is v%s", cluster.GetClusterName(), v, MinimumServerVersion)
+	}
+	return v, nil
+}
+
+// GetVersionedType returns the version of the object type the cluster is watched in, nil if it is
+// the object type of the controller.
+func (c *MultiClusterController) GetVersionedType(clusterName string) *VersionedType {
+	c.Lock()
+	defer c.Unlock()
+	return c.versionedTypes[clusterName]
+}
+
+// negotiateObjectType returns the version of the object type the cluster serves, nil if the cluster
+// serves the object type of the controller.
+func (c *MultiClusterController) negotiateObjectType(cluster ClusterInterface) (*VersionedType, error) {
+	if len(c.VersionedTypes) == 0 {
+		return nil, nil
+	}
+	cs, err := cluster.GetClientSet()
+	if err != nil {
+		return nil, err
+	}
+	served, err := servesObjectType(cs.Discovery(), c.objectType)
+	if err != nil {
+		// fall back to the object type of the controller, its informer reports the error if any.
+		klog.Warningf("failed to discover the versions of %s served by cluster %s: %v", c.objectKind, cluster.GetClusterName(), err)
+		return nil, nil
+	}
+	if served {
+		return nil, nil
+	}
+	for i := range c.VersionedTypes {
+		served, err := servesObjectType(cs.Discovery(), c.VersionedTypes[i].ObjectType)
+		if err != nil {
+			klog.Warningf("failed to discover the versions of %s served by cluster %s: %v", c.objectKind, cluster.GetClusterName(), err)
+			return nil, nil
+		}
+		if served {
+			return &c.VersionedTypes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("cluster %s serves none of the versions of %s supported", cluster.GetClusterName(), c.objectKind)
+}
+
+func servesObjectType(d discovery.DiscoveryInterface, obj client.Object) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme.Scheme)
+	if err != nil {
+		return false, err
+	}
+	resources, err := d.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Kind == gvk.Kind {
+			return true, nil
+		}
+	}
+	return false, nil
+}
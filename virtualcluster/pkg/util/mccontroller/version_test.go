@@ -0,0 +1,259 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mccontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+)
+
+// newDiscoveryServer serves the version of an apiserver and the Ingresses of groupVersions, the
+// other group versions are not found. All the discovery of resources fails if failing.
+func newDiscoveryServer(gitVersion string, failing bool, groupVersions ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL.Path == "/version" {
+			_ = json.NewEncoder(w).Encode(version.Info{GitVersion: gitVersion})
+			return
+		}
+		if failing {
+			http.Error(w, "discovery failed", http.StatusInternalServerError)
+			return
+		}
+		for _, gv := range groupVersions {
+			if req.URL.Path == "/apis/"+gv {
+				_ = json.NewEncoder(w).Encode(metav1.APIResourceList{
+					GroupVersion: gv,
+					APIResources: []metav1.APIResource{{Name: "ingresses", Namespaced: true, Kind: "Ingress"}},
+				})
+				return
+			}
+		}
+		http.NotFound(w, req)
+	}))
+}
+
+// discoveryCluster is a cluster discovered from a server, its objects are read from client.
+type discoveryCluster struct {
+	ClusterInterface
+	name         string
+	clientSet    clientset.Interface
+	client       client.Client
+	informerType client.Object
+}
+
+func newDiscoveryCluster(t *testing.T, server *httptest.Server, objs ...client.Object) *discoveryCluster {
+	cs, err := clientset.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &discoveryCluster{name: "cluster-version", clientSet: cs, client: fake.NewClientBuilder().WithObjects(objs...).Build()}
+}
+
+func (c *discoveryCluster) GetClusterName() string {
+	return c.name
+}
+
+func (c *discoveryCluster) GetClientSet() (clientset.Interface, error) {
+	return c.clientSet, nil
+}
+
+func (c *discoveryCluster) GetDelegatingClient() (client.Client, error) {
+	return c.client, nil
+}
+
+func (c *discoveryCluster) GetInformer(objectType client.Object) (cache.Informer, error) {
+	c.informerType = objectType
+	return nil, nil
+}
+
+func TestCheckServerVersion(t *testing.T) {
+	for _, tc := range []struct {
+		gitVersion  string
+		expectedErr bool
+	}{
+		{gitVersion: "v1.22.3"},
+		{gitVersion: "v1.16.0"},
+		{gitVersion: "v1.21.5-gke.1302"},
+		{gitVersion: "v1.15.12", expectedErr: true},
+		{gitVersion: "unknown", expectedErr: true},
+	} {
+		t.Run(tc.gitVersion, func(t *testing.T) {
+			server := newDiscoveryServer(tc.gitVersion, false)
+			defer server.Close()
+
+			v, err := CheckServerVersion(newDiscoveryCluster(t, server))
+			if !tc.expectedErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if v == nil {
+					t.Errorf("expected the version of the cluster")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected version %s to be refused", tc.gitVersion)
+			}
+			if v != nil && errors.CategoryOf(err) != errors.CategoryUserError {
+				t.Errorf("expected an unsupported version to be a user error, got %v", err)
+			}
+		})
+	}
+}
+
+func convertTestIngress(in, out client.Object) error {
+	switch in := in.(type) {
+	case *networkingv1beta1.Ingress:
+		out.(*networkingv1.Ingress).ObjectMeta = in.ObjectMeta
+		out.(*networkingv1.Ingress).Spec.IngressClassName = in.Spec.IngressClassName
+	case *networkingv1.Ingress:
+		out.(*networkingv1beta1.Ingress).ObjectMeta = in.ObjectMeta
+		out.(*networkingv1beta1.Ingress).Spec.IngressClassName = in.Spec.IngressClassName
+	default:
+		return fmt.Errorf("unexpected type %T", in)
+	}
+	return nil
+}
+
+func TestNegotiateObjectType(t *testing.T) {
+	for _, tc := range []struct {
+		name                 string
+		groupVersions        []string
+		failing              bool
+		expectedInformerType client.Object
+		expectedVersioned    bool
+		expectedErr          bool
+	}{
+		{
+			name:                 "serves the object type",
+			groupVersions:        []string{"networking.k8s.io/v1", "networking.k8s.io/v1beta1"},
+			expectedInformerType: &networkingv1.Ingress{},
+		},
+		{
+			name:                 "serves an older version",
+			groupVersions:        []string{"networking.k8s.io/v1beta1"},
+			expectedInformerType: &networkingv1beta1.Ingress{},
+			expectedVersioned:    true,
+		},
+		{
+			name:        "serves no version",
+			expectedErr: true,
+		},
+		{
+			name:                 "discovery fails",
+			failing:              true,
+			expectedInformerType: &networkingv1.Ingress{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewMCController(&networkingv1.Ingress{}, &networkingv1.IngressList{}, nopReconciler{}, WithVersionedTypes(VersionedType{
+				ObjectType:     &networkingv1beta1.Ingress{},
+				ObjectListType: &networkingv1beta1.IngressList{},
+				Convert:        convertTestIngress,
+			}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			server := newDiscoveryServer("v1.18.20", tc.failing, tc.groupVersions...)
+			defer server.Close()
+			cluster := newDiscoveryCluster(t, server)
+
+			err = c.RegisterClusterResource(cluster, WatchOptions{})
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected the cluster to be refused")
+				}
+				if c.GetCluster(cluster.name) != nil {
+					t.Errorf("expected the refused cluster not to be registered")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reflect.TypeOf(cluster.informerType) != reflect.TypeOf(tc.expectedInformerType) {
+				t.Errorf("expected the cluster to be watched with %T, got %T", tc.expectedInformerType, cluster.informerType)
+			}
+			if versioned := c.GetVersionedType(cluster.name) != nil; versioned != tc.expectedVersioned {
+				t.Errorf("expected versioned type %v, got %v", tc.expectedVersioned, versioned)
+			}
+
+			c.TeardownClusterResource(cluster)
+			if c.GetVersionedType(cluster.name) != nil {
+				t.Errorf("expected the versioned type to be forgotten with the cluster")
+			}
+		})
+	}
+}
+
+func TestVersionedTypeRead(t *testing.T) {
+	c, err := NewMCController(&networkingv1.Ingress{}, &networkingv1.IngressList{}, nopReconciler{}, WithVersionedTypes(VersionedType{
+		ObjectType:     &networkingv1beta1.Ingress{},
+		ObjectListType: &networkingv1beta1.IngressList{},
+		Convert:        convertTestIngress,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server := newDiscoveryServer("v1.18.20", false, "networking.k8s.io/v1beta1")
+	defer server.Close()
+	className := "nginx"
+	cluster := newDiscoveryCluster(t, server,
+		&networkingv1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}, Spec: networkingv1beta1.IngressSpec{IngressClassName: &className}},
+		&networkingv1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"}},
+	)
+	if err := c.RegisterClusterResource(cluster, WatchOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ingress := &networkingv1.Ingress{}
+	if err := c.Get(cluster.name, "default", "web", ingress); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ingress.Name != "web" || ingress.Spec.IngressClassName == nil || *ingress.Spec.IngressClassName != className {
+		t.Errorf("expected the v1beta1 Ingress to be converted, got %+v", ingress)
+	}
+
+	list := &networkingv1.IngressList{}
+	if err := c.List(cluster.name, list, client.InNamespace("default")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, item := range list.Items {
+		names = append(names, item.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"api", "web"}) {
+		t.Errorf("expected the v1beta1 Ingresses to be listed, got %v", names)
+	}
+}
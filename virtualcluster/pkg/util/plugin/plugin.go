@@ -21,6 +21,7 @@ import (
 	"sync"
 
 	pkgerr "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 var (
@@ -50,6 +51,15 @@ func (r *Registration) Init(ic *InitContext) *Plugin {
 	}
 }
 
+// Enabled returns true if the plugin is loaded: when only lists the ids of the plugins to load, it is
+// loaded if it is one of them, otherwise it is loaded unless it is disabled and not listed in extra.
+func (r *Registration) Enabled(only, extra []string) bool {
+	if len(only) > 0 {
+		return sets.NewString(only...).Has(r.ID)
+	}
+	return !r.Disable || sets.NewString(extra...).Has(r.ID)
+}
+
 // Plugin represents an initialized plugin, used with an init context.
 type Plugin struct {
 	Registration *Registration // registration, as initialized
@@ -100,3 +110,11 @@ func (reg *ResourceRegister) List() []*Registration {
 	}
 	return r
 }
+
+// Enabled returns true if the plugin id is registered and loaded, see Registration.Enabled.
+func (reg *ResourceRegister) Enabled(id string, only, extra []string) bool {
+	reg.RLock()
+	defer reg.RUnlock()
+	r, ok := reg.resources[id]
+	return ok && r.Enabled(only, extra)
+}
@@ -84,3 +84,30 @@ func TestResourceRegister_List(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceRegister_Enabled(t *testing.T) {
+	var reg ResourceRegister
+	reg.Register(&Registration{ID: "pod", InitFn: none("pod")})
+	reg.Register(&Registration{ID: "ingress", InitFn: none("ingress"), Disable: true})
+
+	tests := map[string]struct {
+		id    string
+		only  []string
+		extra []string
+		want  bool
+	}{
+		"default":             {id: "pod", want: true},
+		"disabled by default": {id: "ingress", want: false},
+		"extra":               {id: "ingress", extra: []string{"ingress"}, want: true},
+		"only":                {id: "ingress", only: []string{"ingress"}, want: true},
+		"not in only":         {id: "pod", only: []string{"ingress"}, extra: []string{"pod"}, want: false},
+		"unknown":             {id: "service", extra: []string{"service"}, want: false},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if got := reg.Enabled(tt.id, tt.only, tt.extra); got != tt.want {
+				t.Errorf("Enabled(%s) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package profiling provides the pprof and expvar endpoints and the runtime tuning
+// knobs shared by the virtualcluster binaries.
+package profiling
+
+import (
+	"expvar"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+)
+
+// cgroupRoot is where the cgroup filesystem is mounted.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// Options are the profiling and runtime tuning options of a binary.
+type Options struct {
+	// Address the pprof and expvar endpoints listen on, empty disables them.
+	Address string
+	// GCPercent sets the garbage collection target percentage, 0 keeps the default
+	// or the GOGC env and a negative value disables the garbage collector.
+	GCPercent int
+	// CgroupMaxProcs sets GOMAXPROCS to the cgroup CPU quota, unless GOMAXPROCS is set in env.
+	CgroupMaxProcs bool
+}
+
+// NewOptions returns the options with the endpoints listening on address.
+func NewOptions(address string) *Options {
+	return &Options{Address: address, CgroupMaxProcs: true}
+}
+
+// AddFlags adds the flags of o to fs.
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Address, "profiling-address", o.Address, "The address the pprof and expvar endpoints listen on, empty disables them")
+	fs.IntVar(&o.GCPercent, "gc-percent", o.GCPercent, "The garbage collection target percentage, 0 keeps the Go default or GOGC and a negative value disables the garbage collector")
+	fs.BoolVar(&o.CgroupMaxProcs, "cgroup-max-procs", o.CgroupMaxProcs, "If set, GOMAXPROCS is set to the cgroup CPU quota unless the GOMAXPROCS env is set")
+}
+
+// AddGoFlags adds the flags of o to a standard library flag set.
+func (o *Options) AddGoFlags(fs *flag.FlagSet) {
+	pfs := pflag.NewFlagSet("profiling", pflag.ContinueOnError)
+	o.AddFlags(pfs)
+	pfs.VisitAll(func(f *pflag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+}
+
+// Apply applies the runtime tuning options and starts the profiling endpoints.
+func (o *Options) Apply() {
+	if o.CgroupMaxProcs && os.Getenv("GOMAXPROCS") == "" {
+		if procs, ok := cgroupMaxProcs(cgroupRoot); ok && procs < runtime.NumCPU() {
+			klog.Infof("setting GOMAXPROCS to %d from the cgroup cpu quota", procs)
+			runtime.GOMAXPROCS(procs)
+		}
+	}
+	if o.GCPercent != 0 {
+		klog.Infof("setting gc percent to %d", o.GCPercent)
+		debug.SetGCPercent(o.GCPercent)
+	}
+	if o.Address != "" {
+		go func() {
+			klog.Infof("serving pprof and expvar on %s", o.Address)
+			if err := http.ListenAndServe(o.Address, Handler()); err != nil {
+				klog.Errorf("failed to serve profiling endpoints: %v", err)
+			}
+		}()
+	}
+}
+
+// Handler returns the handler of the pprof endpoints under /debug/pprof/ and the
+// expvar endpoint at /debug/vars.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// cgroupMaxProcs returns the number of CPUs allowed by the cgroup CPU quota, rounded up.
+// It supports cgroup v2 cpu.max and cgroup v1 cpu.cfs_quota_us, ok is false if there is no quota.
+func cgroupMaxProcs(root string) (int, bool) {
+	var quota, period float64
+	if data, err := ioutil.ReadFile(filepath.Join(root, "cpu.max")); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0, false
+		}
+		if quota, err = strconv.ParseFloat(fields[0], 64); err != nil {
+			return 0, false
+		}
+		if period, err = strconv.ParseFloat(fields[1], 64); err != nil {
+			return 0, false
+		}
+	} else {
+		var err error
+		if quota, err = readCgroupValue(filepath.Join(root, "cpu", "cpu.cfs_quota_us")); err != nil || quota <= 0 {
+			return 0, false
+		}
+		if period, err = readCgroupValue(filepath.Join(root, "cpu", "cpu.cfs_period_us")); err != nil {
+			return 0, false
+		}
+	}
+	if period <= 0 {
+		return 0, false
+	}
+	procs := int(math.Ceil(quota / period))
+	if procs < 1 {
+		procs = 1
+	}
+	return procs, true
+}
+
+func readCgroupValue(path string) (float64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s: %v", path, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiling
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCgroupMaxProcs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		files    map[string]string
+		expected int
+		ok       bool
+	}{
+		"cgroup v2 quota": {
+			files:    map[string]string{"cpu.max": "250000 100000\n"},
+			expected: 3,
+			ok:       true,
+		},
+		"cgroup v2 no quota": {
+			files: map[string]string{"cpu.max": "max 100000\n"},
+		},
+		"cgroup v1 quota": {
+			files:    map[string]string{"cpu/cpu.cfs_quota_us": "50000\n", "cpu/cpu.cfs_period_us": "100000\n"},
+			expected: 1,
+			ok:       true,
+		},
+		"cgroup v1 no quota": {
+			files: map[string]string{"cpu/cpu.cfs_quota_us": "-1\n", "cpu/cpu.cfs_period_us": "100000\n"},
+		},
+		"no cgroup": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "cgroup")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			for path, content := range tc.files {
+				path = filepath.Join(root, path)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			procs, ok := cgroupMaxProcs(root)
+			if ok != tc.ok || procs != tc.expected {
+				t.Errorf("expected %d, %v, got %d, %v", tc.expected, tc.ok, procs, ok)
+			}
+		})
+	}
+}
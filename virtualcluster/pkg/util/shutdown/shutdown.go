@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package shutdown coordinates the graceful shutdown of the syncer and the scheduler. Once the
+// stop channel is closed the work queues stop accepting new items, the workers drain the queued
+// items within DrainTimeout, and only then the leader lease is released, so that the next leader
+// does not race with half-applied updates of the previous one.
+package shutdown
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// DefaultDrainTimeout is the default time given to the workers to drain their queues.
+const DefaultDrainTimeout = 30 * time.Second
+
+// DrainTimeout bounds the time the workers are given to drain their queues once the process is
+// stopping. It is set once at startup from the configuration.
+var DrainTimeout = DefaultDrainTimeout
+
+// WaitTimeout waits for wg until timeout expires, it returns false if timeout expired first.
+func WaitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// RunWorkers runs workers goroutines calling worker every period until stop is closed. It then
+// shuts down the queue, which drops new items but still hands out the queued ones, and waits for
+// the workers to drain it within DrainTimeout. It returns false if the drain timed out.
+func RunWorkers(name string, queue workqueue.Interface, workers int, worker func(), period time.Duration, stop <-chan struct{}) bool {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(worker, period, stop)
+		}()
+	}
+
+	<-stop
+	queue.ShutDown()
+	if !WaitTimeout(&wg, DrainTimeout) {
+		klog.Warningf("%s: drain timed out after %v with %d items left in queue", name, DrainTimeout, queue.Len())
+		return false
+	}
+	klog.Infof("%s: queue drained", name)
+	return true
+}
+
+// LeaderContext returns the context of the leader election, it is canceled once stopCh is closed
+// and drain returned, so that the leader lease is released after the in-flight work is done when
+// the leader election releases on cancel.
+func LeaderContext(stopCh <-chan struct{}, drain func()) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-stopCh:
+			drain()
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Stopping returns whether stopCh is closed, i.e. the process is shutting down on purpose.
+func Stopping(stopCh <-chan struct{}) bool {
+	select {
+	case <-stopCh:
+		return true
+	default:
+		return false
+	}
+}
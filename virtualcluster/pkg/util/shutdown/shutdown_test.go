@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shutdown
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestRunWorkersDrainsQueue(t *testing.T) {
+	queue := workqueue.New()
+	for i := 0; i < 20; i++ {
+		queue.Add(i)
+	}
+	var processed int32
+	worker := func() {
+		for {
+			item, quit := queue.Get()
+			if quit {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&processed, 1)
+			queue.Done(item)
+		}
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		// stop while the workers are still busy with the queued items.
+		time.Sleep(10 * time.Millisecond)
+		close(stop)
+	}()
+	if !RunWorkers("test", queue, 2, worker, time.Millisecond, stop) {
+		t.Fatalf("expected the queue to be drained")
+	}
+	if n := atomic.LoadInt32(&processed); n != 20 {
+		t.Errorf("expected 20 processed items, got %d", n)
+	}
+	queue.Add(20)
+	if queue.Len() != 0 {
+		t.Errorf("expected the queue to drop new items after shutdown")
+	}
+}
+
+func TestRunWorkersTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { DrainTimeout = timeout }(DrainTimeout)
+	DrainTimeout = 50 * time.Millisecond
+
+	queue := workqueue.New()
+	queue.Add("stuck")
+	block := make(chan struct{})
+	defer close(block)
+	worker := func() {
+		if _, quit := queue.Get(); !quit {
+			<-block
+		}
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(stop)
+	}()
+	if RunWorkers("test", queue, 1, worker, time.Millisecond, stop) {
+		t.Errorf("expected the drain to time out")
+	}
+}
+
+// TestRollingRestart simulates a rolling restart of two replicas: the new replica must take over
+// as soon as the old one drained and released its lease, not after the lease expired, and never
+// while the old one is still draining.
+func TestRollingRestart(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	newElector := func(id string, callbacks leaderelection.LeaderCallbacks) *leaderelection.LeaderElector {
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock: &resourcelock.LeaseLock{
+				LeaseMeta:  metav1.ObjectMeta{Namespace: "vc-manager", Name: "syncer-leaderelection-lock"},
+				Client:     client.CoordinationV1(),
+				LockConfig: resourcelock.ResourceLockConfig{Identity: id},
+			},
+			LeaseDuration:   time.Minute,
+			RenewDeadline:   30 * time.Second,
+			RetryPeriod:     50 * time.Millisecond,
+			ReleaseOnCancel: true,
+			Callbacks:       callbacks,
+		})
+		if err != nil {
+			t.Fatalf("failed to create leader elector: %v", err)
+		}
+		return le
+	}
+
+	var (
+		mu       sync.Mutex
+		events   []string
+		oldLead  = make(chan struct{})
+		newLead  = make(chan struct{})
+		oldStop  = make(chan struct{})
+		oldDone  = make(chan struct{})
+		newStop  = make(chan struct{})
+		recordFn = func(e string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		}
+	)
+
+	oldCtx, oldCancel := LeaderContext(oldStop, func() {
+		time.Sleep(100 * time.Millisecond)
+		recordFn("old drained")
+	})
+	defer oldCancel()
+	go func() {
+		defer close(oldDone)
+		newElector("old", leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) { close(oldLead) },
+			OnStoppedLeading: func() {
+				if !Stopping(oldStop) {
+					t.Errorf("old replica lost its lease before shutdown")
+				}
+			},
+		}).Run(oldCtx)
+	}()
+	<-oldLead
+
+	newCtx, newCancel := LeaderContext(newStop, func() {})
+	defer newCancel()
+	go newElector("new", leaderelection.LeaderCallbacks{
+		OnStartedLeading: func(context.Context) {
+			recordFn("new leading")
+			close(newLead)
+		},
+		OnStoppedLeading: func() {},
+	}).Run(newCtx)
+
+	// the new replica can't take over while the old one holds the lease.
+	select {
+	case <-newLead:
+		t.Fatalf("new replica started leading while the old one holds the lease")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(oldStop)
+	select {
+	case <-newLead:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("new replica did not take over after the old one released its lease")
+	}
+	<-oldDone
+	close(newStop)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0] != "old drained" || events[1] != "new leading" {
+		t.Errorf("expected the old replica to drain before the new one leads, got %v", events)
+	}
+}
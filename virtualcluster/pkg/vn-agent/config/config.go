@@ -18,6 +18,7 @@ package config
 
 import (
 	"crypto/tls"
+	"time"
 )
 
 // TLSOptions holds the TLS options.
@@ -32,4 +33,11 @@ type TLSOptions struct {
 type Config struct {
 	KubeletClientCert *tls.Certificate
 	KubeletServerHost string
+	// StreamIdleTimeout is the maximum time an exec, attach or port-forward stream can be
+	// idle before the connection is closed, 0 means no timeout.
+	StreamIdleTimeout time.Duration
+	// TenantRequestQPS and TenantRequestBurst rate-limit the requests of every tenant, a zero
+	// QPS disables the limit.
+	TenantRequestQPS   float32
+	TenantRequestBurst int
 }
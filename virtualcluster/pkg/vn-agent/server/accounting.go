@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/accounting"
+)
+
+// accountingResponseWriter counts the bytes of a tenant request. The response is counted as it is
+// written, e.g. while following logs, and the connection hijacked by the upgrade aware proxy is
+// counted in both directions for exec, attach and port-forward streams.
+type accountingResponseWriter struct {
+	http.ResponseWriter
+	in  prometheus.Counter
+	out prometheus.Counter
+}
+
+var _ http.Hijacker = &accountingResponseWriter{}
+var _ http.Flusher = &accountingResponseWriter{}
+
+// newAccountingResponseWriter accounts the request and its response to the tenant, the request body
+// is counted as it is read by the proxy.
+func newAccountingResponseWriter(w http.ResponseWriter, req *http.Request, tenantName, action string) *accountingResponseWriter {
+	aw := &accountingResponseWriter{
+		ResponseWriter: w,
+		in:             tenantBytes.WithLabelValues(tenantName, action, directionIn),
+		out:            tenantBytes.WithLabelValues(tenantName, action, directionOut),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = accounting.CountReader(req.Body, aw.countIn)
+	}
+	return aw
+}
+
+func (w *accountingResponseWriter) countIn(n int) {
+	w.in.Add(float64(n))
+}
+
+func (w *accountingResponseWriter) countOut(n int) {
+	w.out.Add(float64(n))
+}
+
+func (w *accountingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	if n > 0 {
+		w.countOut(n)
+	}
+	return n, err
+}
+
+func (w *accountingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *accountingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer %T does not support hijacking", w.ResponseWriter)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return accounting.CountConn(conn, w.countIn, w.countOut), rw, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/cmd/vn-agent/app/options"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/testcerts"
+)
+
+func TestProxyAccountsLogs(t *testing.T) {
+	agent, cleanup := newTestProxyWithConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "log line\n")
+	}), config.Config{}, options.ServerOption{EnableMetrics: true})
+	defer cleanup()
+
+	requests := tenantRequests.WithLabelValues(testcerts.TenantName, "containerLogs")
+	out := tenantBytes.WithLabelValues(testcerts.TenantName, "containerLogs", directionOut)
+	requestsBefore, outBefore := testutil.ToFloat64(requests), testutil.ToFloat64(out)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tenantTLSConfig(t)}}
+	resp, err := client.Get(agent.URL + "/containerLogs/default/foo/bar?follow=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "log line\n" {
+		t.Fatalf("expected the logs to be proxied, got %q: %v", body, err)
+	}
+
+	if got := testutil.ToFloat64(requests) - requestsBefore; got != 1 {
+		t.Errorf("expected 1 request of the tenant, got %v", got)
+	}
+	if got := testutil.ToFloat64(out) - outBefore; got != float64(len(body)) {
+		t.Errorf("expected %d bytes to the tenant, got %v", len(body), got)
+	}
+}
+
+func TestProxyAccountsStreams(t *testing.T) {
+	agent, cleanup := newTestProxyWithConfig(t, upgradeEchoHandler(t, make(chan *http.Request, 1)),
+		config.Config{}, options.ServerOption{EnableMetrics: true})
+	defer cleanup()
+
+	in := tenantBytes.WithLabelValues(testcerts.TenantName, "exec", directionIn)
+	out := tenantBytes.WithLabelValues(testcerts.TenantName, "exec", directionOut)
+	inBefore, outBefore := testutil.ToFloat64(in), testutil.ToFloat64(out)
+
+	tlsConfig := tenantTLSConfig(t)
+	tlsConfig.NextProtos = []string{"http/1.1"}
+	conn, err := tls.Dial("tcp", agent.Listener.Addr().String(), tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	req, err := http.NewRequest(http.MethodPost, agent.URL+"/exec/default/foo/bar?command=sh&stdin=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "SPDY/3.1")
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		b, _ := ioutil.ReadAll(resp.Body)
+		t.Fatalf("expected status %d, got %d %s", http.StatusSwitchingProtocols, resp.StatusCode, b)
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := testutil.ToFloat64(in) - inBefore; got != 4 {
+		t.Errorf("expected the 4 bytes of the stream from the tenant, got %v", got)
+	}
+	// the switching protocols response and the echo
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return testutil.ToFloat64(out)-outBefore > 4, nil
+	}); err != nil {
+		t.Errorf("expected the stream to the tenant to be accounted, got %v bytes", testutil.ToFloat64(out)-outBefore)
+	}
+}
+
+func TestProxyRateLimitsTenants(t *testing.T) {
+	agent, cleanup := newTestProxyWithConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}), config.Config{TenantRequestQPS: 0.001, TenantRequestBurst: 1}, options.ServerOption{})
+	defer cleanup()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tenantTLSConfig(t)}}
+	for i, expected := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		resp, err := client.Get(agent.URL + "/containerLogs/default/foo/bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("expected request %d to get %d, got %d", i, expected, resp.StatusCode)
+		}
+	}
+}
@@ -32,8 +32,16 @@ const (
 	metricNameInFlightRequests           = "in_flight_requests"
 	metricNameTotalRequests              = "total_requests"
 	metricNameRequestLatency             = "request_latencies"
+	metricNameTenantRequests             = "tenant_requests_total"
+	metricNameTenantBytes                = "tenant_bytes_total"
 	errorProxyingRequest                 = "error_proxying_request"
 	errorTranslatingPath                 = "error_translating_path"
+	errorRateLimited                     = "rate_limited"
+
+	// directionIn is the traffic from the tenant to the kubelet or the super apiserver,
+	// directionOut the traffic back to the tenant.
+	directionIn  = "in"
+	directionOut = "out"
 )
 
 var (
@@ -70,6 +78,18 @@ var (
 		},
 		[]string{},
 	)
+
+	tenantRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: resourceVNAgentSubsystem,
+		Name:      metricNameTenantRequests,
+		Help:      "requests received by tenants, including the rate limited ones",
+	}, []string{"tenantName", "action"})
+
+	tenantBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: resourceVNAgentSubsystem,
+		Name:      metricNameTenantBytes,
+		Help:      "bytes proxied by tenants, including the streams of exec, attach and port-forward",
+	}, []string{"tenantName", "action", "direction"})
 )
 
 var registerMetrics sync.Once
@@ -80,7 +100,9 @@ func register() {
 			failureCounter,
 			inFlightRequests,
 			totalRequests,
-			requestLatency)
+			requestLatency,
+			tenantRequests,
+			tenantBytes)
 	})
 }
 
@@ -0,0 +1,245 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/cmd/vn-agent/app/options"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/testcerts"
+)
+
+// newTestProxy starts a vn-agent forwarding to a fake kubelet serving backend, both
+// of them accept HTTP/2.
+func newTestProxy(t *testing.T, backend http.Handler) (*httptest.Server, func()) {
+	return newTestProxyWithConfig(t, backend, config.Config{}, options.ServerOption{})
+}
+
+// newTestProxyWithConfig is newTestProxy with the given vn-agent config and server options,
+// the kubelet settings of cfg are overridden.
+func newTestProxyWithConfig(t *testing.T, backend http.Handler, cfg config.Config, serverOption options.ServerOption) (*httptest.Server, func()) {
+	kubelet := httptest.NewUnstartedServer(backend)
+	kubelet.EnableHTTP2 = true
+	kubelet.StartTLS()
+
+	kubeletClientCert, err := tls.X509KeyPair(testcerts.KubeletClientCert, testcerts.KubeletClientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.KubeletClientCert = &kubeletClientCert
+	cfg.KubeletServerHost = kubelet.URL
+	s, err := NewServer(&cfg, &serverOption)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vnAgentCert, err := tls.X509KeyPair(testcerts.VnAgentCert, testcerts.VnAgentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPool := x509.NewCertPool()
+	certPool.AppendCertsFromPEM(testcerts.CACert)
+	agent := httptest.NewUnstartedServer(s)
+	agent.EnableHTTP2 = true
+	agent.TLS = &tls.Config{
+		ClientCAs:    certPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		Certificates: []tls.Certificate{vnAgentCert},
+	}
+	agent.StartTLS()
+
+	return agent, func() {
+		agent.Close()
+		kubelet.Close()
+	}
+}
+
+func tenantTLSConfig(t *testing.T) *tls.Config {
+	tenantCert, err := tls.X509KeyPair(testcerts.TenantCert, testcerts.TenantKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec
+		Certificates:       []tls.Certificate{tenantCert},
+	}
+}
+
+// upgradeEchoHandler upgrades to the requested protocol, picks the first offered
+// subprotocol and echoes everything it reads.
+func upgradeEchoHandler(t *testing.T, requests chan<- *http.Request) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		upgrade := r.Header.Get("Upgrade")
+		if upgrade == "" {
+			http.Error(w, "upgrade required", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("failed to hijack backend connection: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		protocolHeader := "X-Stream-Protocol-Version"
+		if upgrade == "websocket" {
+			protocolHeader = "Sec-WebSocket-Protocol"
+		}
+		protocol := r.Header.Values(protocolHeader)
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: %s\r\n", upgrade)
+		if len(protocol) > 0 {
+			fmt.Fprintf(rw, "%s: %s\r\n", protocolHeader, protocol[0])
+		}
+		fmt.Fprint(rw, "\r\n")
+		rw.Flush()
+
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(rw, buf); err != nil {
+			return
+		}
+		_, _ = conn.Write(buf)
+	})
+}
+
+func TestProxyUpgradeProtocols(t *testing.T) {
+	for name, tc := range map[string]struct {
+		upgrade        string
+		protocolHeader string
+		protocols      []string
+		enableMetrics  bool
+	}{
+		"spdy": {
+			upgrade:        "SPDY/3.1",
+			protocolHeader: "X-Stream-Protocol-Version",
+			protocols:      []string{"v4.channel.k8s.io", "v3.channel.k8s.io"},
+		},
+		"websocket": {
+			upgrade:        "websocket",
+			protocolHeader: "Sec-WebSocket-Protocol",
+			protocols:      []string{"v5.channel.k8s.io", "v4.channel.k8s.io"},
+		},
+		// the instrumented round tripper must not send the upgrade request itself
+		"spdy with metrics": {
+			upgrade:        "SPDY/3.1",
+			protocolHeader: "X-Stream-Protocol-Version",
+			protocols:      []string{"v4.channel.k8s.io", "v3.channel.k8s.io"},
+			enableMetrics:  true,
+		},
+		"websocket with metrics": {
+			upgrade:        "websocket",
+			protocolHeader: "Sec-WebSocket-Protocol",
+			protocols:      []string{"v5.channel.k8s.io", "v4.channel.k8s.io"},
+			enableMetrics:  true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			requests := make(chan *http.Request, 2)
+			agent, cleanup := newTestProxyWithConfig(t, upgradeEchoHandler(t, requests),
+				config.Config{}, options.ServerOption{EnableMetrics: tc.enableMetrics})
+			defer cleanup()
+
+			tlsConfig := tenantTLSConfig(t)
+			tlsConfig.NextProtos = []string{"http/1.1"}
+			conn, err := tls.Dial("tcp", agent.Listener.Addr().String(), tlsConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			req, err := http.NewRequest(http.MethodPost, agent.URL+"/attach/default/foo/bar?input=1&output=1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", tc.upgrade)
+			for _, p := range tc.protocols {
+				req.Header.Add(tc.protocolHeader, p)
+			}
+			if err := req.Write(conn); err != nil {
+				t.Fatal(err)
+			}
+
+			br := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(br, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				t.Fatalf("expected status %d, got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+			}
+			if got := resp.Header.Get(tc.protocolHeader); got != tc.protocols[0] {
+				t.Errorf("expected negotiated protocol %s, got %s", tc.protocols[0], got)
+			}
+
+			backendReq := <-requests
+			if backendReq.ProtoMajor != 1 {
+				t.Errorf("expected the upgrade to use http/1.1 to the backend, got %s", backendReq.Proto)
+			}
+			if expected := "/attach/" + testcerts.TenantName + "-default/foo/bar"; backendReq.URL.Path != expected {
+				t.Errorf("expected backend path %s, got %s", expected, backendReq.URL.Path)
+			}
+
+			if _, err := conn.Write([]byte("ping")); err != nil {
+				t.Fatal(err)
+			}
+			buf := make([]byte, 4)
+			if _, err := io.ReadFull(br, buf); err != nil || string(buf) != "ping" {
+				t.Errorf("expected the stream to be proxied, got %q: %v", buf, err)
+			}
+			if n := len(requests); n != 0 {
+				t.Errorf("expected a single upgrade request to the backend, got %d more", n)
+			}
+		})
+	}
+}
+
+func TestProxyHTTP2(t *testing.T) {
+	agent, cleanup := newTestProxy(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))
+	defer cleanup()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   tenantTLSConfig(t),
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get(agent.URL + "/containerLogs/default/foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2 from vn-agent, got %s", resp.Proto)
+	}
+	if string(body) != "HTTP/2.0" {
+		t.Errorf("expected HTTP/2 to the backend, got %s", body)
+	}
+}
@@ -160,6 +160,19 @@ func (s *Server) proxy(req *restful.Request, resp *restful.Response) {
 		req.Request.Header.Add("Authorization", "Bearer "+s.restConfig.BearerToken)
 	}
 
+	if s.enableMetrics {
+		tenantRequests.WithLabelValues(tenantName, action).Inc()
+	}
+	if !s.limiters.TryAccept(tenantName) {
+		klog.V(4).Infof("request of tenant %s is rate limited", tenantName)
+		if s.enableMetrics {
+			failureCounter.WithLabelValues(host, action, tenantName, podNamespace, errorRateLimited).Inc()
+		}
+		resp.ResponseWriter.Header().Set("Retry-After", "1")
+		http.Error(resp.ResponseWriter, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+
 	roundTripper := getRoundTripper(s.transport, host, tenantName, action, podNamespace)
 	httpResponder := &responder{
 		action:        action,
@@ -172,13 +185,23 @@ func (s *Server) proxy(req *restful.Request, resp *restful.Response) {
 	if s.enableMetrics {
 		handler = proxy.NewUpgradeAwareHandler(req.Request.URL, roundTripper /*transport*/, false, /*wrapTransport*/
 			httpstream.IsUpgradeRequest(req.Request) /*upgradeRequired*/, httpResponder)
-		handler.UpgradeTransport = proxy.NewUpgradeRequestRoundTripper(s.transport, roundTripper)
+		// the upgrade request is only decorated by the instrumented round tripper, the upgrade
+		// aware proxy dials the stream itself with http/1.1.
+		handler.UpgradeTransport = proxy.NewUpgradeRequestRoundTripper(s.transport,
+			getRoundTripper(proxy.MirrorRequest, host, tenantName, action, podNamespace))
 	} else {
 		handler = proxy.NewUpgradeAwareHandler(req.Request.URL, s.transport /*transport*/, false, /*wrapTransport*/
 			httpstream.IsUpgradeRequest(req.Request) /*upgradeRequired*/, httpResponder)
 	}
 
-	handler.ServeHTTP(resp.ResponseWriter, req.Request)
+	var w http.ResponseWriter = resp.ResponseWriter
+	if s.enableMetrics {
+		w = newAccountingResponseWriter(w, req.Request, tenantName, action)
+	}
+	if s.config.StreamIdleTimeout > 0 && httpstream.IsUpgradeRequest(req.Request) {
+		w = &idleTimeoutResponseWriter{ResponseWriter: w, idleTimeout: s.config.StreamIdleTimeout}
+	}
+	handler.ServeHTTP(w, req.Request)
 }
 
 type responder struct {
@@ -19,8 +19,10 @@ package server
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"net"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/emicklei/go-restful"
 	"github.com/pkg/errors"
@@ -29,6 +31,7 @@ import (
 	certutil "k8s.io/client-go/util/cert"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/cmd/vn-agent/app/options"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/accounting"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/config"
 )
 
@@ -40,6 +43,8 @@ type Server struct {
 	superAPIServerAddress *url.URL
 	restConfig            *rest.Config
 	enableMetrics         bool
+	// limiters rate-limit the requests of every tenant, they are nil without limit.
+	limiters *accounting.Limiters
 }
 
 // ServeHTTP responds to HTTP requests on the vn-agent.
@@ -59,17 +64,16 @@ func NewServer(cfg *config.Config, serverOption *options.ServerOption) (*Server,
 		restfulCont:   restful.NewContainer(),
 		config:        cfg,
 		enableMetrics: serverOption.EnableMetrics,
+		limiters:      accounting.NewLimiters(cfg.TenantRequestQPS, cfg.TenantRequestBurst),
 	}
 
 	server.InstallHandlers()
 
 	if server.config.KubeletClientCert != nil {
-		server.transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, //nolint:gosec
-				Certificates:       []tls.Certificate{*server.config.KubeletClientCert},
-			},
-		}
+		server.transport = newTransport(&tls.Config{
+			InsecureSkipVerify: true, //nolint:gosec
+			Certificates:       []tls.Certificate{*server.config.KubeletClientCert},
+		})
 	} else {
 		var restConfig *rest.Config
 		var caCrtPool *x509.CertPool
@@ -104,13 +108,26 @@ func NewServer(cfg *config.Config, serverOption *options.ServerOption) (*Server,
 		if err != nil {
 			return nil, errors.Wrapf(err, "unable to parse ca file")
 		}
-		server.transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs:    caCrtPool,
-				MinVersion: tls.VersionTLS12,
-			},
-		}
+		server.transport = newTransport(&tls.Config{
+			RootCAs:    caCrtPool,
+			MinVersion: tls.VersionTLS12,
+		})
 	}
 
 	return server, nil
 }
+
+// newTransport returns the transport to the kubelet or the super apiserver. Plain requests,
+// e.g. logs, may use HTTP/2, while the upgrade aware proxy dials exec, attach and port-forward
+// streams with the dialer and always negotiates http/1.1 for them, as both SPDY and WebSocket
+// upgrades require it.
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:   tlsConfig,
+		ForceAttemptHTTP2: true,
+	}
+}
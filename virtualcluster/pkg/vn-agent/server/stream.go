@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// idleTimeoutResponseWriter hands out a connection closed after idleTimeout without any
+// traffic when the upgrade aware proxy hijacks it, so exec, attach and port-forward streams
+// left open by tenants don't pin the vn-agent and the backend forever.
+type idleTimeoutResponseWriter struct {
+	http.ResponseWriter
+	idleTimeout time.Duration
+}
+
+var _ http.Hijacker = &idleTimeoutResponseWriter{}
+
+func (w *idleTimeoutResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer %T does not support hijacking", w.ResponseWriter)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return newIdleTimeoutConn(conn, w.idleTimeout), rw, nil
+}
+
+// idleTimeoutConn extends the deadline of both directions on every read or write, so the
+// connection only times out when neither side sent anything for idleTimeout.
+type idleTimeoutConn struct {
+	net.Conn
+	idleTimeout time.Duration
+}
+
+func newIdleTimeoutConn(conn net.Conn, idleTimeout time.Duration) net.Conn {
+	c := &idleTimeoutConn{Conn: conn, idleTimeout: idleTimeout}
+	c.extendDeadline()
+	return c
+}
+
+func (c *idleTimeoutConn) extendDeadline() {
+	_ = c.Conn.SetDeadline(time.Now().Add(c.idleTimeout))
+}
+
+func (c *idleTimeoutConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.extendDeadline()
+	}
+	return n, err
+}
+
+func (c *idleTimeoutConn) Write(b []byte) (int, error) {
+	c.extendDeadline()
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+)
+
+func TestIdleTimeoutResponseWriter(t *testing.T) {
+	const idleTimeout = 200 * time.Millisecond
+	closed := make(chan time.Time, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := (&idleTimeoutResponseWriter{ResponseWriter: w, idleTimeout: idleTimeout}).Hijack()
+		if err != nil {
+			t.Errorf("failed to hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		// echo until the connection is idle for too long.
+		_, _ = io.Copy(conn, conn)
+		closed <- time.Now()
+	}))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// keep the stream active for longer than the idle timeout.
+	start := time.Now()
+	buf := make([]byte, 4)
+	for i := 0; i < 5; i++ {
+		time.Sleep(idleTimeout / 2)
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			t.Fatalf("failed to write to active stream: %v", err)
+		}
+		if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+			t.Fatalf("expected echo of active stream, got %q: %v", buf, err)
+		}
+	}
+
+	select {
+	case at := <-closed:
+		if at.Sub(start) < 5*idleTimeout/2 {
+			t.Errorf("active stream closed after %v", at.Sub(start))
+		}
+	case <-time.After(10 * idleTimeout):
+		t.Fatalf("idle stream was not closed")
+	}
+}
+
+func TestTranslatePathForSuperStreams(t *testing.T) {
+	for name, tc := range map[string]struct {
+		path       string
+		params     map[string]string
+		rawQuery   string
+		expectPath string
+		expectVals url.Values
+	}{
+		"attach": {
+			path:       "/attach/default/foo/bar",
+			params:     map[string]string{"podNamespace": "default", "podID": "foo", "containerName": "bar"},
+			rawQuery:   "input=1&output=1&tty=0",
+			expectPath: "/api/v1/namespaces/tenant-default/pods/foo/attach",
+			expectVals: url.Values{"stdin": {"true"}, "stdout": {"true"}, "tty": {"false"}, "container": {"bar"}},
+		},
+		"websocket exec": {
+			path:       "/exec/default/foo/bar",
+			params:     map[string]string{"podNamespace": "default", "podID": "foo", "containerName": "bar"},
+			rawQuery:   "command=ls&stdin=true&stdout=1&stderr=false&tty=true",
+			expectPath: "/api/v1/namespaces/tenant-default/pods/foo/exec",
+			expectVals: url.Values{"command": {"ls"}, "stdin": {"true"}, "stdout": {"true"}, "stderr": {"false"}, "tty": {"true"}, "container": {"bar"}},
+		},
+		"websocket port-forward": {
+			path:       "/portForward/default/foo",
+			params:     map[string]string{"podNamespace": "default", "podID": "foo"},
+			rawQuery:   "ports=8080&ports=9090",
+			expectPath: "/api/v1/namespaces/tenant-default/pods/foo/portforward",
+			expectVals: url.Values{"ports": {"8080", "9090"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := restful.NewRequest(httptest.NewRequest(http.MethodPost, tc.path+"?"+tc.rawQuery, nil))
+			for k, v := range tc.params {
+				req.PathParameters()[k] = v
+			}
+			if err := TranslatePathForSuper(req, "tenant"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.Request.URL.Path != tc.expectPath {
+				t.Errorf("expected path %s, got %s", tc.expectPath, req.Request.URL.Path)
+			}
+			if vals := req.Request.URL.Query(); !reflect.DeepEqual(vals, tc.expectVals) {
+				t.Errorf("expected query %v, got %v", tc.expectVals, vals)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/emicklei/go-restful"
@@ -72,11 +73,21 @@ func translateRawQuery(req *restful.Request, containerName string) {
 				query.Add("stderr", "false")
 			}
 		case "tty":
-			if v[0] == "1" {
+			if v[0] == "1" || v[0] == "true" {
 				query.Add("tty", "true")
 			}
-			if v[0] == "0" {
-				query.Add("stdout", "false")
+			if v[0] == "0" || v[0] == "false" {
+				query.Add("tty", "false")
+			}
+		case "stdin", "stdout", "stderr":
+			// websocket clients may already use the apiserver parameters
+			if b, err := strconv.ParseBool(v[0]); err == nil {
+				query.Add(k, strconv.FormatBool(b))
+			}
+		case "ports":
+			// for websocket port forwarding, the spdy one passes the ports in stream headers
+			for _, port := range v {
+				query.Add("ports", port)
 			}
 		case "tailLines", "insecureSkipTLSVerifyBackend", "limitBytes",
 			"follow", "container", "previous", "sinceTime", "timestamps":
@@ -17,16 +17,25 @@ limitations under the License.
 package e2e
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/reporters"
 	"github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework/ginkgowrapper"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework/matrix"
 )
 
 // Similar to SynchronizedBeforeSuite, we want to run some operations only once (such as collecting cluster logs).
@@ -57,7 +66,91 @@ func RunE2ETests(t *testing.T) {
 
 	// Run tests through the Ginkgo runner with output to console + JUnit for Jenkins
 	var r []ginkgo.Reporter
+	if framework.TestContext.ReportDir != "" {
+		r = append(r, reporters.NewJUnitReporter(path.Join(framework.TestContext.ReportDir,
+			fmt.Sprintf("junit_%v%02d.xml", framework.TestContext.ReportPrefix, config.GinkgoConfig.ParallelNode))))
+	}
 	klog.Infof("Starting e2e run %q on Ginkgo node %d", framework.RunID, config.GinkgoConfig.ParallelNode)
 
 	ginkgo.RunSpecsWithDefaultAndCustomReporters(t, "Kubernetes e2e suite", r)
 }
+
+// RunFeatureMatrix runs the suite once per combination of --feature-matrix and prints the
+// results as a matrix. Before each run, the syncer is rolled out with the combination, then
+// the suite is run by a child process of the test binary. The syncer is restored at the end.
+// It returns the exit code of the test binary.
+func RunFeatureMatrix() int {
+	m, err := matrix.Parse(framework.TestContext.FeatureMatrix)
+	if err != nil {
+		klog.Errorf("invalid --feature-matrix: %v", err)
+		return 1
+	}
+	restConfig, err := framework.LoadConfig()
+	if err != nil {
+		klog.Errorf("failed to load the kubeconfig: %v", err)
+		return 1
+	}
+	c, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		klog.Errorf("failed to create the client: %v", err)
+		return 1
+	}
+
+	namespace, name, timeout := framework.TestContext.SyncerNamespace, framework.TestContext.SyncerName, framework.TestContext.SyncerRolloutTimeout
+	command, args, err := matrix.SyncerCommand(c, namespace, name)
+	if err != nil {
+		klog.Errorf("failed to get the syncer %s/%s: %v", namespace, name, err)
+		return 1
+	}
+	defer func() {
+		if err := matrix.RestoreSyncer(c, namespace, name, command, args, timeout); err != nil {
+			klog.Errorf("failed to restore the syncer %s/%s: %v", namespace, name, err)
+		}
+	}()
+
+	results := make([]matrix.Result, 0, len(m.Entries))
+	for _, e := range m.Entries {
+		framework.Logf("Running the suite with %s", m.Name(e))
+		start := time.Now()
+		if err := matrix.ConfigureSyncer(c, namespace, name, e, timeout); err != nil {
+			framework.Logf("Failed to configure the syncer with %s: %v", m.Name(e), err)
+			results = append(results, matrix.Result{Entry: e, Duration: time.Since(start)})
+			continue
+		}
+		suite := exec.Command(os.Args[0], suiteArgs(os.Args[1:], m.ReportPrefix(e))...)
+		suite.Stdout, suite.Stderr = os.Stdout, os.Stderr
+		err := suite.Run()
+		results = append(results, matrix.Result{Entry: e, Passed: err == nil, Duration: time.Since(start)})
+	}
+
+	if err := m.WriteResults(os.Stdout, results); err != nil {
+		klog.Errorf("failed to write the results: %v", err)
+	}
+	for _, r := range results {
+		if !r.Passed {
+			return 1
+		}
+	}
+	return 0
+}
+
+// suiteArgs returns the args of a run of the matrix, without --feature-matrix and with the
+// report prefix of the combination.
+func suiteArgs(args []string, reportPrefix string) []string {
+	var filtered []string
+	for i := 0; i < len(args); i++ {
+		flagName := strings.SplitN(strings.TrimLeft(args[i], "-"), "=", 2)
+		if !strings.HasPrefix(args[i], "-") || (flagName[0] != "feature-matrix" && flagName[0] != "report-prefix") {
+			filtered = append(filtered, args[i])
+			continue
+		}
+		if len(flagName) == 1 {
+			// skip the value of "--flag value".
+			i++
+		}
+	}
+	if framework.TestContext.ReportPrefix != "" {
+		reportPrefix = framework.TestContext.ReportPrefix + "-" + reportPrefix
+	}
+	return append(filtered, "--report-prefix="+reportPrefix)
+}
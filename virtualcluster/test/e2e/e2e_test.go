@@ -38,6 +38,10 @@ func TestMain(m *testing.M) {
 
 	framework.AfterReadingAllFlags(&framework.TestContext)
 
+	if framework.TestContext.FeatureMatrix != "" {
+		os.Exit(RunFeatureMatrix())
+	}
+
 	rand.Seed(time.Now().UnixNano())
 	os.Exit(m.Run())
 }
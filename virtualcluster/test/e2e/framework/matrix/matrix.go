@@ -0,0 +1,337 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package matrix
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// syncerAxisPrefix prefixes the axes turning a resource syncer disabled by default on and off,
+	// e.g. syncer/ingress.
+	syncerAxisPrefix = "syncer/"
+
+	featureGatesFlag          = "--feature-gates"
+	extraSyncingResourcesFlag = "--extra-syncing-resources"
+
+	// poll is how often to poll the syncer rollout.
+	poll = 2 * time.Second
+)
+
+// Matrix is every combination of a set of axes turned on and off.
+type Matrix struct {
+	// Axes are the feature gates, e.g. SuperClusterPooling, and the resource syncers, e.g.
+	// syncer/ingress, of the matrix.
+	Axes    []string
+	Entries []Entry
+}
+
+// Entry is a combination of the axes of a Matrix.
+type Entry struct {
+	// Enabled tells whether each axis is on, in the order of the axes of the matrix.
+	Enabled []bool
+	// FeatureGates are the feature gates of the axes.
+	FeatureGates map[string]bool
+	// ExtraSyncingResources are the resource syncers of the axes turned on.
+	ExtraSyncingResources []string
+	// DisabledSyncingResources are the resource syncers of the axes turned off.
+	DisabledSyncingResources []string
+}
+
+// Result is the outcome of running the suite with an Entry.
+type Result struct {
+	Entry    Entry
+	Passed   bool
+	Duration time.Duration
+}
+
+// Parse expands a comma separated list of axes, e.g. "SuperClusterPooling,syncer/ingress",
+// to the matrix of every combination of the axes.
+func Parse(spec string) (*Matrix, error) {
+	m := &Matrix{}
+	seen := make(map[string]bool)
+	for _, axis := range strings.Split(spec, ",") {
+		axis = strings.TrimSpace(axis)
+		if axis == "" {
+			continue
+		}
+		if axis == syncerAxisPrefix || strings.ContainsAny(axis, "=") {
+			return nil, fmt.Errorf("invalid axis %q, expected a feature gate or %s<resource>", axis, syncerAxisPrefix)
+		}
+		if seen[axis] {
+			return nil, fmt.Errorf("duplicated axis %q", axis)
+		}
+		seen[axis] = true
+		m.Axes = append(m.Axes, axis)
+	}
+	if len(m.Axes) == 0 {
+		return nil, fmt.Errorf("no axis in %q", spec)
+	}
+	if len(m.Axes) > 8 {
+		return nil, fmt.Errorf("%d axes make %d combinations, at most 8 axes are supported", len(m.Axes), 1<<len(m.Axes))
+	}
+
+	for i := 0; i < 1<<len(m.Axes); i++ {
+		e := Entry{FeatureGates: make(map[string]bool)}
+		for j, axis := range m.Axes {
+			enabled := i&(1<<(len(m.Axes)-1-j)) != 0
+			e.Enabled = append(e.Enabled, enabled)
+			switch {
+			case !strings.HasPrefix(axis, syncerAxisPrefix):
+				e.FeatureGates[axis] = enabled
+			case enabled:
+				e.ExtraSyncingResources = append(e.ExtraSyncingResources, strings.TrimPrefix(axis, syncerAxisPrefix))
+			default:
+				e.DisabledSyncingResources = append(e.DisabledSyncingResources, strings.TrimPrefix(axis, syncerAxisPrefix))
+			}
+		}
+		m.Entries = append(m.Entries, e)
+	}
+	return m, nil
+}
+
+// Name returns the name of the entry, e.g. SuperClusterPooling=true,syncer/ingress=false.
+func (m *Matrix) Name(e Entry) string {
+	parts := make([]string, 0, len(m.Axes))
+	for i, axis := range m.Axes {
+		parts = append(parts, axis+"="+strconv.FormatBool(e.Enabled[i]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ReportPrefix returns a prefix for the JUnit reports of the entry, e.g. matrix-10.
+func (m *Matrix) ReportPrefix(e Entry) string {
+	var b strings.Builder
+	b.WriteString("matrix-")
+	for _, enabled := range e.Enabled {
+		if enabled {
+			b.WriteString("1")
+		} else {
+			b.WriteString("0")
+		}
+	}
+	return b.String()
+}
+
+// SyncerCommand returns the command and the args of the syncer container, the one named after
+// the Deployment or the first one.
+func SyncerCommand(c clientset.Interface, namespace, name string) (command, args []string, err error) {
+	d, err := c.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	i, err := syncerContainer(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.Spec.Template.Spec.Containers[i].Command, d.Spec.Template.Spec.Containers[i].Args, nil
+}
+
+// ConfigureSyncer rolls the syncer Deployment out with the feature gates and the resource
+// syncers of the entry, the other feature gates and resource syncers are kept.
+func ConfigureSyncer(c clientset.Interface, namespace, name string, e Entry, timeout time.Duration) error {
+	return updateSyncer(c, namespace, name, timeout, func(ctr *corev1.Container) {
+		gates := parseFeatureGates(flagValue(ctr, featureGatesFlag))
+		for gate, enabled := range e.FeatureGates {
+			gates[gate] = enabled
+		}
+		setFlag(ctr, featureGatesFlag, formatFeatureGates(gates))
+
+		resources := make(map[string]bool)
+		for _, r := range strings.Split(flagValue(ctr, extraSyncingResourcesFlag), ",") {
+			if r != "" {
+				resources[r] = true
+			}
+		}
+		for _, r := range e.ExtraSyncingResources {
+			resources[r] = true
+		}
+		for _, r := range e.DisabledSyncingResources {
+			delete(resources, r)
+		}
+		setFlag(ctr, extraSyncingResourcesFlag, strings.Join(sortedKeys(resources), ","))
+	})
+}
+
+// RestoreSyncer rolls the syncer Deployment out with the command and the args returned by
+// SyncerCommand.
+func RestoreSyncer(c clientset.Interface, namespace, name string, command, args []string, timeout time.Duration) error {
+	return updateSyncer(c, namespace, name, timeout, func(ctr *corev1.Container) {
+		ctr.Command, ctr.Args = command, args
+	})
+}
+
+// updateSyncer updates the syncer container of the Deployment and waits for the rollout to finish.
+func updateSyncer(c clientset.Interface, namespace, name string, timeout time.Duration, update func(*corev1.Container)) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		d, err := c.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		i, err := syncerContainer(d)
+		if err != nil {
+			return err
+		}
+		update(&d.Spec.Template.Spec.Containers[i])
+		_, err = c.AppsV1().Deployments(namespace).Update(context.TODO(), d, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update deployment %s/%s: %v", namespace, name, err)
+	}
+	if err := wait.PollImmediate(poll, timeout, rolledOut(c, namespace, name)); err != nil {
+		return fmt.Errorf("deployment %s/%s is not rolled out: %v", namespace, name, err)
+	}
+	return nil
+}
+
+func syncerContainer(d *appsv1.Deployment) (int, error) {
+	if len(d.Spec.Template.Spec.Containers) == 0 {
+		return 0, fmt.Errorf("deployment %s/%s has no container", d.Namespace, d.Name)
+	}
+	for i := range d.Spec.Template.Spec.Containers {
+		if d.Spec.Template.Spec.Containers[i].Name == d.Name {
+			return i, nil
+		}
+	}
+	return 0, nil
+}
+
+func rolledOut(c clientset.Interface, namespace, name string) wait.ConditionFunc {
+	return func() (bool, error) {
+		d, err := c.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		return d.Status.ObservedGeneration >= d.Generation &&
+			d.Status.UpdatedReplicas == replicas &&
+			d.Status.Replicas == replicas &&
+			d.Status.AvailableReplicas == replicas, nil
+	}
+}
+
+// flagValue returns the value of a --flag=value flag of the container command or args.
+func flagValue(ctr *corev1.Container, flag string) string {
+	for _, arg := range append(append([]string{}, ctr.Command...), ctr.Args...) {
+		if strings.HasPrefix(arg, flag+"=") {
+			return strings.TrimPrefix(arg, flag+"=")
+		}
+	}
+	return ""
+}
+
+// setFlag sets a --flag=value flag in the container command or args, the flag is removed if
+// the value is empty.
+func setFlag(ctr *corev1.Container, flag, value string) {
+	set := false
+	replace := func(args []string) []string {
+		var updated []string
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, flag+"=") {
+				updated = append(updated, arg)
+				continue
+			}
+			if !set && value != "" {
+				updated = append(updated, flag+"="+value)
+			}
+			set = true
+		}
+		return updated
+	}
+	ctr.Command = replace(ctr.Command)
+	ctr.Args = replace(ctr.Args)
+	if !set && value != "" {
+		ctr.Args = append(ctr.Args, flag+"="+value)
+	}
+}
+
+func parseFeatureGates(s string) map[string]bool {
+	gates := make(map[string]bool)
+	for _, gate := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(gate), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if enabled, err := strconv.ParseBool(kv[1]); err == nil {
+			gates[kv[0]] = enabled
+		}
+	}
+	return gates
+}
+
+func formatFeatureGates(gates map[string]bool) string {
+	keys := make([]string, 0, len(gates))
+	for gate := range gates {
+		keys = append(keys, gate)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, gate := range keys {
+		parts = append(parts, gate+"="+strconv.FormatBool(gates[gate]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteResults writes the results as a table with a column per axis.
+func (m *Matrix) WriteResults(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tRESULT\tDURATION\n", strings.Join(m.Axes, "\t"))
+	for _, r := range results {
+		columns := make([]string, 0, len(m.Axes)+2)
+		for _, enabled := range r.Entry.Enabled {
+			if enabled {
+				columns = append(columns, "on")
+			} else {
+				columns = append(columns, "off")
+			}
+		}
+		result := "FAILED"
+		if r.Passed {
+			result = "PASSED"
+		}
+		columns = append(columns, result, r.Duration.Round(time.Second).String())
+		fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	}
+	return tw.Flush()
+}
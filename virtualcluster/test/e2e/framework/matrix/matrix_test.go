@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package matrix
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		spec            string
+		expectedAxes    []string
+		expectedEntries []string
+		expectedErr     string
+	}{
+		{
+			name:            "one feature gate",
+			spec:            "SuperClusterPooling",
+			expectedAxes:    []string{"SuperClusterPooling"},
+			expectedEntries: []string{"SuperClusterPooling=false", "SuperClusterPooling=true"},
+		},
+		{
+			name:         "feature gate and resource syncer",
+			spec:         " SuperClusterPooling, syncer/ingress ,",
+			expectedAxes: []string{"SuperClusterPooling", "syncer/ingress"},
+			expectedEntries: []string{
+				"SuperClusterPooling=false,syncer/ingress=false",
+				"SuperClusterPooling=false,syncer/ingress=true",
+				"SuperClusterPooling=true,syncer/ingress=false",
+				"SuperClusterPooling=true,syncer/ingress=true",
+			},
+		},
+		{
+			name:        "no axis",
+			spec:        " , ",
+			expectedErr: "no axis",
+		},
+		{
+			name:        "gate with a value",
+			spec:        "SuperClusterPooling=true",
+			expectedErr: "invalid axis",
+		},
+		{
+			name:        "syncer without resource",
+			spec:        "syncer/",
+			expectedErr: "invalid axis",
+		},
+		{
+			name:        "duplicated axis",
+			spec:        "syncer/ingress,syncer/ingress",
+			expectedErr: "duplicated axis",
+		},
+		{
+			name:        "too many axes",
+			spec:        "A,B,C,D,E,F,G,H,I",
+			expectedErr: "at most 8 axes",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := Parse(tc.spec)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(m.Axes, tc.expectedAxes) {
+				t.Errorf("expected axes %v, got %v", tc.expectedAxes, m.Axes)
+			}
+			var names []string
+			for _, e := range m.Entries {
+				names = append(names, m.Name(e))
+			}
+			if !reflect.DeepEqual(names, tc.expectedEntries) {
+				t.Errorf("expected entries %v, got %v", tc.expectedEntries, names)
+			}
+		})
+	}
+}
+
+func TestEntry(t *testing.T) {
+	m, err := Parse("SuperClusterPooling,syncer/ingress,syncer/crd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := m.Entries[5]
+	if got := m.ReportPrefix(e); got != "matrix-101" {
+		t.Errorf("expected report prefix matrix-101, got %s", got)
+	}
+	if !reflect.DeepEqual(e.FeatureGates, map[string]bool{"SuperClusterPooling": true}) {
+		t.Errorf("unexpected feature gates %v", e.FeatureGates)
+	}
+	if !reflect.DeepEqual(e.ExtraSyncingResources, []string{"crd"}) || !reflect.DeepEqual(e.DisabledSyncingResources, []string{"ingress"}) {
+		t.Errorf("unexpected resource syncers, extra %v, disabled %v", e.ExtraSyncingResources, e.DisabledSyncingResources)
+	}
+}
+
+func TestSetFlag(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		command         []string
+		args            []string
+		value           string
+		expectedCommand []string
+		expectedArgs    []string
+	}{
+		{
+			name:         "add",
+			args:         []string{"--v=4"},
+			value:        "A=true",
+			expectedArgs: []string{"--v=4", "--feature-gates=A=true"},
+		},
+		{
+			name:            "replace in command",
+			command:         []string{"syncer", "--feature-gates=A=false"},
+			args:            []string{"--v=4"},
+			value:           "A=true",
+			expectedCommand: []string{"syncer", "--feature-gates=A=true"},
+			expectedArgs:    []string{"--v=4"},
+		},
+		{
+			name:         "replace duplicates",
+			args:         []string{"--feature-gates=A=false", "--v=4", "--feature-gates=B=false"},
+			value:        "A=true",
+			expectedArgs: []string{"--feature-gates=A=true", "--v=4"},
+		},
+		{
+			name:         "remove",
+			args:         []string{"--feature-gates=A=false", "--v=4"},
+			expectedArgs: []string{"--v=4"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := &corev1.Container{Command: tc.command, Args: tc.args}
+			setFlag(ctr, featureGatesFlag, tc.value)
+			if !reflect.DeepEqual(ctr.Command, tc.expectedCommand) || !reflect.DeepEqual(ctr.Args, tc.expectedArgs) {
+				t.Errorf("expected command %v and args %v, got %v and %v", tc.expectedCommand, tc.expectedArgs, ctr.Command, ctr.Args)
+			}
+		})
+	}
+}
+
+func TestFeatureGates(t *testing.T) {
+	gates := parseFeatureGates(" B=false, A=true,C,D=maybe")
+	if !reflect.DeepEqual(gates, map[string]bool{"A": true, "B": false}) {
+		t.Errorf("unexpected feature gates %v", gates)
+	}
+	if got := formatFeatureGates(gates); got != "A=true,B=false" {
+		t.Errorf("expected A=true,B=false, got %s", got)
+	}
+}
+
+func TestConfigureSyncer(t *testing.T) {
+	syncer := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vc-manager", Name: "vc-syncer"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "sidecar", Args: []string{"--feature-gates=Other=true"}},
+			{
+				Name:    "vc-syncer",
+				Command: []string{"syncer"},
+				Args:    []string{"--feature-gates=Other=true,SuperClusterPooling=false", "--extra-syncing-resources=ingress,priorityclass"},
+			},
+		}}}},
+		Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	c := fake.NewSimpleClientset(syncer)
+
+	command, args, err := SyncerCommand(c, "vc-manager", "vc-syncer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := Parse("SuperClusterPooling,syncer/ingress,syncer/crd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ConfigureSyncer(c, "vc-manager", "vc-syncer", m.Entries[5], time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, configured, err := SyncerCommand(c, "vc-manager", "vc-syncer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"--feature-gates=Other=true,SuperClusterPooling=true", "--extra-syncing-resources=crd,priorityclass"}
+	if !reflect.DeepEqual(configured, expected) {
+		t.Errorf("expected args %v, got %v", expected, configured)
+	}
+
+	if err := RestoreSyncer(c, "vc-manager", "vc-syncer", command, args, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restoredCommand, restored, err := SyncerCommand(c, "vc-manager", "vc-syncer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(restoredCommand, command) || !reflect.DeepEqual(restored, args) {
+		t.Errorf("expected command %v and args %v to be restored, got %v and %v", command, args, restoredCommand, restored)
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+	m, err := Parse("SuperClusterPooling,syncer/ingress")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var b bytes.Buffer
+	if err := m.WriteResults(&b, []Result{
+		{Entry: m.Entries[0], Passed: true, Duration: 90*time.Second + 300*time.Millisecond},
+		{Entry: m.Entries[2], Duration: time.Minute},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "SuperClusterPooling  syncer/ingress  RESULT  DURATION\n" +
+		"off                  off             PASSED  1m30s\n" +
+		"on                   off             FAILED  1m0s\n"
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
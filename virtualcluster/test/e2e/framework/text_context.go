@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/onsi/ginkgo/config"
 	restclient "k8s.io/client-go/rest"
@@ -47,6 +48,15 @@ type TestContextType struct {
 
 	// If set to true test will dump data about the namespace in which test was running.
 	DumpLogsOnFailure bool
+
+	// FeatureMatrix is a comma separated list of feature gates and resource syncers, e.g.
+	// SuperClusterPooling,syncer/ingress, the suite is run with every combination of.
+	FeatureMatrix string
+	// SyncerNamespace and SyncerName are the syncer Deployment reconfigured by the feature matrix.
+	SyncerNamespace string
+	SyncerName      string
+	// SyncerRolloutTimeout is how long to wait for the syncer to be rolled out.
+	SyncerRolloutTimeout time.Duration
 }
 
 // TestContext should be used by all tests to access common context data.
@@ -82,6 +92,10 @@ func RegisterCommonFlags(flags *flag.FlagSet) {
 func RegisterClusterFlags(flags *flag.FlagSet) {
 	flags.StringVar(&TestContext.KubeConfig, clientcmd.RecommendedConfigPathFlag, os.Getenv(clientcmd.RecommendedConfigPathEnvVar), "Path to kubeconfig containing embedded authinfo.")
 	flags.StringVar(&TestContext.KubeContext, clientcmd.FlagContext, "", "kubeconfig context to use/override. If unset, will use value from 'current-context'")
+	flags.StringVar(&TestContext.FeatureMatrix, "feature-matrix", "", "A comma separated list of syncer feature gates and resource syncers, e.g. SuperClusterPooling,syncer/ingress. If set, the suite is run once per combination of them turned on and off.")
+	flags.StringVar(&TestContext.SyncerNamespace, "syncer-namespace", "vc-manager", "The namespace of the syncer Deployment reconfigured by --feature-matrix.")
+	flags.StringVar(&TestContext.SyncerName, "syncer-name", "vc-syncer", "The name of the syncer Deployment reconfigured by --feature-matrix.")
+	flags.DurationVar(&TestContext.SyncerRolloutTimeout, "syncer-rollout-timeout", 5*time.Minute, "How long to wait for the syncer to be rolled out with a combination of --feature-matrix.")
 }
 
 // HandleFlags sets up all flags and parses the command line.
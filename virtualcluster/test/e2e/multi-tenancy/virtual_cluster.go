@@ -17,15 +17,20 @@ limitations under the License.
 package multitenancy
 
 import (
+	"context"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki/inspect"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework"
 	e2ecv "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework/clusterversion"
+	e2elog "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework/log"
 )
 
 var _ = SIGDescribe("VirtualCluster", func() {
@@ -77,6 +82,15 @@ var _ = SIGDescribe("VirtualCluster", func() {
 			framework.ExpectNoError(err, "failed to create clientset from rest config")
 			// TODO: we need convert tenant apiserver host for nodeport typed tenant.
 
+			By("check if the PKI of the tenant control plane is valid")
+			certs, err := inspect.InspectNamespace(context.TODO(), vcClient.Interface, conversion.ToRootNamespace(vc), time.Now())
+			framework.ExpectNoError(err, "failed to inspect the PKI of vc")
+			for _, c := range certs {
+				if !c.Valid() {
+					e2elog.Failf("certificate %s/%s of vc is invalid: %s", c.Secret, c.Key, c.Error)
+				}
+			}
+
 			By("deleting the virtualcluster " + vc.Name)
 			vcClient.DeleteSync(vc.Name, nil)
 		})
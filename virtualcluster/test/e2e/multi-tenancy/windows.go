@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework"
+	e2ecv "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework/clusterversion"
+	e2elog "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/test/e2e/framework/log"
+)
+
+const (
+	// windowsPauseImage is a multi-arch image with Windows variants for all the supported builds
+	windowsPauseImage = "mcr.microsoft.com/oss/kubernetes/pause:3.6"
+	windowsPodTimeout = 10 * time.Minute
+)
+
+var _ = SIGDescribe("VirtualCluster [Feature:Windows]", func() {
+	f := framework.NewDefaultFramework("virtualcluster-windows")
+	var (
+		ns       string
+		vcClient *framework.VCClient
+		cv       *v1alpha1.ClusterVersion
+	)
+
+	BeforeEach(func() {
+		nodes, err := f.ClientSet.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
+			LabelSelector: labels.Set{corev1.LabelOSStable: "windows"}.String(),
+		})
+		framework.ExpectNoError(err, "failed to list windows nodes")
+		if len(nodes.Items) == 0 {
+			Skip("the super cluster has no windows nodes")
+		}
+
+		vcClient = f.VCClient()
+		ns = f.Namespace.Name
+
+		By("Creating a ClusterVersion " + ns)
+		cv, err = e2ecv.CreateDefaultClusterVersion(f.VCClientSet, ns)
+		framework.ExpectNoError(err, "Error Creating ClusterVersion")
+	})
+
+	AfterEach(func() {
+		if cv == nil {
+			return
+		}
+		By("Deleting ClusterVersion " + ns)
+		framework.ExpectNoError(e2ecv.DeleteCV(f.VCClientSet, cv))
+	})
+
+	framework.VCDescribe("Windows workloads", func() {
+		It("should run tenant pods on windows nodes", func() {
+			name := "windows-" + framework.RandomSuffix()
+			vc := &v1alpha1.VirtualCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: v1alpha1.VirtualClusterSpec{
+					ClusterDomain:      "cluster.local",
+					ClusterVersionName: cv.GetName(),
+					PKIExpireDays:      365,
+				},
+			}
+
+			By("creating the virtualcluster " + vc.Name)
+			vc = vcClient.CreateSync(vc)
+			defer vcClient.DeleteSync(vc.Name, nil)
+
+			kubecfgBytes, err := conversion.GetKubeConfigOfVC(vcClient.Interface.CoreV1(), vc)
+			framework.ExpectNoError(err, "failed to get kubeconfig of vc")
+			clusterRestConfig, err := clientcmd.RESTConfigFromKubeConfig(kubecfgBytes)
+			framework.ExpectNoError(err, "failed to parse kubeconfig")
+			tenantClient, err := clientset.NewForConfig(clusterRestConfig)
+			framework.ExpectNoError(err, "failed to create clientset from rest config")
+
+			By("creating a windows pod in the tenant")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "windows-pause",
+					Namespace: metav1.NamespaceDefault,
+				},
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+					Tolerations: []corev1.Toleration{
+						{Key: "os", Operator: corev1.TolerationOpEqual, Value: "windows", Effect: corev1.TaintEffectNoSchedule},
+					},
+					SecurityContext: &corev1.PodSecurityContext{
+						WindowsOptions: &corev1.WindowsSecurityContextOptions{RunAsUserName: pointer.String("ContainerUser")},
+					},
+					Containers: []corev1.Container{{Name: "pause", Image: windowsPauseImage}},
+				},
+			}
+			// the service account of the namespace may not be created yet
+			err = wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
+				_, err := tenantClient.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+				return err == nil, nil
+			})
+			framework.ExpectNoError(err, "failed to create the windows pod")
+
+			By("waiting for the windows pod to run")
+			err = wait.PollImmediate(5*time.Second, windowsPodTimeout, func() (bool, error) {
+				pod, err = tenantClient.CoreV1().Pods(pod.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+				if err != nil {
+					return false, nil
+				}
+				return pod.Status.Phase == corev1.PodRunning, nil
+			})
+			framework.ExpectNoError(err, "windows pod is not running")
+
+			By("checking the virtual node of the windows node")
+			vNode, err := tenantClient.CoreV1().Nodes().Get(context.TODO(), pod.Spec.NodeName, metav1.GetOptions{})
+			framework.ExpectNoError(err, "failed to get the virtual node")
+			if os := vNode.Labels[corev1.LabelOSStable]; os != "windows" {
+				e2elog.Failf("expected virtual node %s to have os label windows, got %q", vNode.Name, os)
+			}
+			if _, ok := vNode.Labels[corev1.LabelWindowsBuild]; !ok {
+				e2elog.Failf("expected virtual node %s to have the %s label", vNode.Name, corev1.LabelWindowsBuild)
+			}
+		})
+	})
+})